@@ -0,0 +1,210 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+const (
+	// How long an ActionQueue waits before sending the actions radio-browser couldn't be reached for,
+	// doubling after every retry that fails, up to actionMaxRetryDelay.
+	actionRetryDelay    = 15 * time.Second
+	actionMaxRetryDelay = 5 * time.Minute
+	// How many actions an ActionQueue keeps; the oldest are dropped past that.
+	actionQueueSize = 100
+)
+
+// ActionKind tells apart the calls an ActionQueue sends.
+type ActionKind int
+
+const (
+	// ActionClick counts a click on a station.
+	ActionClick ActionKind = iota
+	// ActionVote votes for a station.
+	ActionVote
+)
+
+// ActionResult is how radio-browser answered an action sent by an ActionQueue.
+type ActionResult struct {
+	Kind    ActionKind
+	Station common.Station
+	// Ok and Message are radio-browser's answer.
+	Ok      bool
+	Message string
+	// Queued is set when radio-browser couldn't be reached: the action is sent again by Retry.
+	Queued bool
+	// Err is why the action failed for good (e.g. radio-browser answered with an error).
+	Err error
+}
+
+// ActionQueue sends the clicks and votes that don't matter right away to radio-browser,
+// keeping those it couldn't be reached for (see ErrMirrorUnavailable and ErrRateLimited) to send them
+// again later, all at once when it can be reached again.
+// It is safe for concurrent use.
+type ActionQueue struct {
+	browser RadioBrowserService
+
+	mu        sync.Mutex
+	pending   []ActionResult
+	failures  int
+	nextRetry time.Time
+
+	now func() time.Time
+}
+
+// NewActionQueue returns an ActionQueue sending the actions with browser.
+func NewActionQueue(browser RadioBrowserService) *ActionQueue {
+	return &ActionQueue{
+		browser: browser,
+		now:     time.Now,
+	}
+}
+
+// Send sends the action right away. If radio-browser can't be reached, the action is queued
+// (once per kind and station) and the result is marked Queued.
+// A successful action makes the queued ones due, as radio-browser can be reached again.
+func (q *ActionQueue) Send(kind ActionKind, station common.Station) ActionResult {
+	result := q.send(kind, station)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if result.Queued {
+		q.enqueue(result)
+		q.postpone(result.Err)
+		result.Err = nil
+	} else if result.Err == nil && len(q.pending) > 0 {
+		q.failures = 0
+		q.nextRetry = time.Time{}
+	}
+	return result
+}
+
+// Retry sends the queued actions if they are due, in the order they were queued,
+// and returns the results of those that were sent. If radio-browser still can't be reached,
+// the actions stay queued and are retried later, waiting longer every time.
+func (q *ActionQueue) Retry() []ActionResult {
+	q.mu.Lock()
+	if len(q.pending) == 0 || q.now().Before(q.nextRetry) {
+		q.mu.Unlock()
+		return nil
+	}
+	due := q.pending
+	q.pending = nil
+	q.mu.Unlock()
+
+	var sent []ActionResult
+	for i, action := range due {
+		result := q.send(action.Kind, action.Station)
+		if result.Queued {
+			q.mu.Lock()
+			// Queued while these were sent, so after them
+			queued := q.pending
+			q.pending = nil
+			for _, unsent := range append(due[i:], queued...) {
+				q.enqueue(unsent)
+			}
+			q.failures++
+			q.postpone(result.Err)
+			q.mu.Unlock()
+			return sent
+		}
+		sent = append(sent, result)
+	}
+
+	q.mu.Lock()
+	q.failures = 0
+	q.nextRetry = time.Time{}
+	q.mu.Unlock()
+
+	return sent
+}
+
+// Pending returns how many actions are waiting to be sent again.
+func (q *ActionQueue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// RetryIn returns how long until the queued actions are due (0 if they are).
+func (q *ActionQueue) RetryIn() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if left := q.nextRetry.Sub(q.now()); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// send sends one action, telling whether radio-browser couldn't be reached.
+func (q *ActionQueue) send(kind ActionKind, station common.Station) ActionResult {
+	result := ActionResult{Kind: kind, Station: station}
+	switch kind {
+	case ActionVote:
+		response, err := q.browser.VoteStation(station)
+		result.Ok, result.Message, result.Err = response.Ok, response.Message, err
+	default:
+		response, err := q.browser.ClickStation(station)
+		result.Ok, result.Message, result.Err = response.Ok, response.Message, err
+	}
+	result.Queued = errors.Is(result.Err, ErrMirrorUnavailable) || errors.Is(result.Err, ErrRateLimited)
+	return result
+}
+
+// enqueue queues the action, unless the same kind of action is queued for the station already.
+// Must be called with mu held.
+func (q *ActionQueue) enqueue(action ActionResult) {
+	for _, pending := range q.pending {
+		if pending.Kind == action.Kind && pending.Station.StationUuid == action.Station.StationUuid {
+			return
+		}
+	}
+	action.Queued = true
+	action.Err = nil
+	q.pending = append(q.pending, action)
+	if len(q.pending) > actionQueueSize {
+		q.pending = q.pending[len(q.pending)-actionQueueSize:]
+	}
+}
+
+// postpone sets when the queued actions are due, after the backoff for the failures so far
+// or after as long as radio-browser asked to wait, whichever is longer. Must be called with mu held.
+func (q *ActionQueue) postpone(err error) {
+	delay := actionRetryDelay
+	for i := 0; i < q.failures && delay < actionMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > actionMaxRetryDelay {
+		delay = actionMaxRetryDelay
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+		delay = apiErr.RetryAfter
+	}
+	if next := q.now().Add(delay); next.After(q.nextRetry) {
+		q.nextRetry = next
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyBrowser counts clicks and votes while online, and fails with ErrMirrorUnavailable otherwise.
+func flakyBrowser(online *bool, sent *[]string) *mocks.MockRadioBrowserService {
+	unreachable := &Error{Kind: ErrMirrorUnavailable, Err: errors.New("connection refused")}
+	return &mocks.MockRadioBrowserService{
+		ClickStationFunc: func(station common.Station) (common.ClickStationResponse, error) {
+			if !*online {
+				return common.ClickStationResponse{}, unreachable
+			}
+			*sent = append(*sent, "click "+station.Name)
+			return common.ClickStationResponse{Ok: true}, nil
+		},
+		VoteStationFunc: func(station common.Station) (common.VoteStationResponse, error) {
+			if !*online {
+				return common.VoteStationResponse{}, unreachable
+			}
+			*sent = append(*sent, "vote "+station.Name)
+			return common.VoteStationResponse{Ok: true}, nil
+		},
+	}
+}
+
+func TestActionQueue(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	rock := common.Station{StationUuid: uuid.New(), Name: "Rock FM"}
+
+	newQueue := func(online *bool, sent *[]string) (*ActionQueue, *time.Time) {
+		now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+		queue := NewActionQueue(flakyBrowser(online, sent))
+		queue.now = func() time.Time { return now }
+		return queue, &now
+	}
+
+	t.Run("sends right away when radio-browser can be reached", func(t *testing.T) {
+		online := true
+		var sent []string
+		queue, _ := newQueue(&online, &sent)
+
+		result := queue.Send(ActionVote, jazz)
+
+		assert.True(t, result.Ok)
+		assert.False(t, result.Queued)
+		assert.Equal(t, []string{"vote Jazz FM"}, sent)
+		assert.Zero(t, queue.Pending())
+	})
+
+	t.Run("queues each action once while radio-browser can't be reached, then sends them all in order", func(t *testing.T) {
+		online := false
+		var sent []string
+		queue, now := newQueue(&online, &sent)
+
+		result := queue.Send(ActionClick, jazz)
+		assert.True(t, result.Queued)
+		assert.NoError(t, result.Err)
+		queue.Send(ActionVote, rock)
+		queue.Send(ActionClick, jazz)
+		assert.Equal(t, 2, queue.Pending())
+		assert.Equal(t, actionRetryDelay, queue.RetryIn())
+
+		// Not due yet
+		assert.Nil(t, queue.Retry())
+
+		// Still unreachable: waits twice as long
+		*now = now.Add(actionRetryDelay)
+		assert.Nil(t, queue.Retry())
+		assert.Equal(t, 2, queue.Pending())
+		assert.Equal(t, 2*actionRetryDelay, queue.RetryIn())
+
+		online = true
+		*now = now.Add(2 * actionRetryDelay)
+		results := queue.Retry()
+
+		assert.Len(t, results, 2)
+		assert.Equal(t, []string{"click Jazz FM", "vote Rock FM"}, sent)
+		assert.Equal(t, ActionVote, results[1].Kind)
+		assert.True(t, results[1].Ok)
+		assert.Zero(t, queue.Pending())
+	})
+
+	t.Run("makes the queued actions due once an action goes through", func(t *testing.T) {
+		online := false
+		var sent []string
+		queue, _ := newQueue(&online, &sent)
+
+		queue.Send(ActionVote, rock)
+		online = true
+		queue.Send(ActionClick, jazz)
+
+		assert.Zero(t, queue.RetryIn())
+		assert.Len(t, queue.Retry(), 1)
+		assert.Equal(t, []string{"click Jazz FM", "vote Rock FM"}, sent)
+	})
+
+	t.Run("waits as long as radio-browser asks to", func(t *testing.T) {
+		queue, _ := newQueue(new(bool), new([]string))
+		queue.browser = &mocks.MockRadioBrowserService{
+			ClickStationFunc: func(station common.Station) (common.ClickStationResponse, error) {
+				return common.ClickStationResponse{}, &Error{Kind: ErrRateLimited, StatusCode: 429, RetryAfter: time.Hour}
+			},
+		}
+
+		assert.True(t, queue.Send(ActionClick, jazz).Queued)
+		assert.Equal(t, time.Hour, queue.RetryIn())
+	})
+
+	t.Run("doesn't queue actions radio-browser refused", func(t *testing.T) {
+		queue, _ := newQueue(new(bool), new([]string))
+		queue.browser = &mocks.MockRadioBrowserService{
+			VoteStationFunc: func(station common.Station) (common.VoteStationResponse, error) {
+				return common.VoteStationResponse{}, &Error{Kind: ErrBadResponse, StatusCode: 400}
+			},
+		}
+
+		result := queue.Send(ActionVote, jazz)
+
+		assert.False(t, result.Queued)
+		assert.ErrorIs(t, result.Err, ErrBadResponse)
+		assert.Zero(t, queue.Pending())
+	})
+}
@@ -0,0 +1,390 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package apitest provides a fake radio-browser server, answering from canned fixtures
+// the way the real API does, to exercise a RadioBrowserService end-to-end in tests.
+package apitest
+
+import (
+	"embed"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// Response is a canned response, served instead of what the fixtures would answer.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	// Path is the path of the request, e.g. /json/stations/byname/jazz.
+	Path   string
+	Query  url.Values
+	Header http.Header
+	// Time is when the request was received.
+	Time time.Time
+}
+
+// Server is a fake radio-browser server, listening on a local address.
+// Its stations, tags and checks are loaded from the fixtures, and clicking a station counts it.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	stations []map[string]interface{}
+	tags     []map[string]interface{}
+	checks   []map[string]interface{}
+	queued   []Response
+	requests []Request
+}
+
+// NewServer starts a Server with the fixtures. It must be closed when done.
+func NewServer() *Server {
+	s := &Server{
+		stations: loadFixture("stations.json"),
+		tags:     loadFixture("tags.json"),
+		checks:   loadFixture("checks.json"),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func loadFixture(name string) []map[string]interface{} {
+	data, err := fixtures.ReadFile("fixtures/" + name)
+	if err != nil {
+		panic(err)
+	}
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(data, &objects); err != nil {
+		panic(err)
+	}
+	return objects
+}
+
+// BaseURL returns the base URL of the JSON API, like http://127.0.0.1:1234/json.
+func (s *Server) BaseURL() url.URL {
+	u, err := url.Parse(s.URL + "/json")
+	if err != nil {
+		panic(err)
+	}
+	return *u
+}
+
+// Respond makes the server answer the next requests with the given responses, in order,
+// before going back to the fixtures.
+func (s *Server) Respond(responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queued = append(s.queued, responses...)
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Stations returns the stations of the server, as they are now, in the order of the fixtures.
+func (s *Server) Stations() []common.Station {
+	s.mu.Lock()
+	data, err := json.Marshal(s.stations)
+	s.mu.Unlock()
+	if err != nil {
+		panic(err)
+	}
+	var stations []common.Station
+	if err := json.Unmarshal(data, &stations); err != nil {
+		panic(err)
+	}
+	return stations
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Time:   time.Now(),
+	})
+
+	if len(s.queued) > 0 {
+		response := s.queued[0]
+		s.queued = s.queued[1:]
+		for key, values := range response.Header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(response.StatusCode)
+		_, _ = w.Write([]byte(response.Body))
+		return
+	}
+
+	query := r.URL.Query()
+	// The search term is the rest of the path, even if it has slashes
+	parts := strings.SplitN(strings.Trim(strings.TrimPrefix(r.URL.Path, "/json"), "/"), "/", 3)
+
+	switch {
+	case len(parts) == 1 && parts[0] == "stations":
+		s.writeStations(w, query, s.stations)
+	case len(parts) == 2 && parts[0] == "stations" && parts[1] == "search":
+		s.writeStations(w, query, filter(s.stations, func(station map[string]interface{}) bool {
+			return matches(station, query)
+		}))
+	case len(parts) == 2 && parts[0] == "stations" && parts[1] == "byurl":
+		s.writeStations(w, query, filter(s.stations, func(station map[string]interface{}) bool {
+			return field(station, "url") == query.Get("url")
+		}))
+	case len(parts) == 3 && parts[0] == "stations" && (parts[1] == "topvote" || parts[1] == "topclick"):
+		query.Set("order", map[string]string{"topvote": "votes", "topclick": "clickcount"}[parts[1]])
+		query.Set("reverse", "true")
+		query.Set("limit", parts[2])
+		s.writeStations(w, query, s.stations)
+	case len(parts) == 3 && parts[0] == "stations":
+		match, ok := stationMatchers[parts[1]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		s.writeStations(w, query, filter(s.stations, func(station map[string]interface{}) bool {
+			return match(station, parts[2])
+		}))
+	case len(parts) == 2 && parts[0] == "checks":
+		writeJSON(w, filter(s.checks, func(check map[string]interface{}) bool {
+			return field(check, "stationuuid") == parts[1]
+		}))
+	case len(parts) == 2 && parts[0] == "url":
+		s.click(w, parts[1])
+	case len(parts) == 1 && parts[0] == "countries":
+		writeJSON(w, page(s.countries(query.Get("hidebroken") == "true"), query))
+	case len(parts) <= 2 && parts[0] == "tags":
+		tags := s.tags
+		if len(parts) == 2 {
+			tags = filter(tags, func(tag map[string]interface{}) bool {
+				return containsFold(field(tag, "name"), parts[1])
+			})
+		}
+		writeJSON(w, page(tags, query))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// countries counts the stations of each country, like radio-browser. The lock must be held.
+func (s *Server) countries(hideBroken bool) []map[string]interface{} {
+	var countries []map[string]interface{}
+	byCode := make(map[string]map[string]interface{})
+	for _, station := range s.stations {
+		if hideBroken && field(station, "lastcheckok") != "1" {
+			continue
+		}
+		code := field(station, "countrycode")
+		country, ok := byCode[code]
+		if !ok {
+			country = map[string]interface{}{"name": station["country"], "iso_3166_1": code, "stationcount": float64(0)}
+			byCode[code] = country
+			countries = append(countries, country)
+		}
+		country["stationcount"] = country["stationcount"].(float64) + 1
+	}
+	return countries
+}
+
+// writeStations sends the stations, narrowed down by the listing parameters. The lock must be held.
+func (s *Server) writeStations(w http.ResponseWriter, query url.Values, stations []map[string]interface{}) {
+	if query.Get("hidebroken") == "true" {
+		stations = filter(stations, func(station map[string]interface{}) bool {
+			return field(station, "lastcheckok") == "1"
+		})
+	}
+	writeJSON(w, page(stations, query))
+}
+
+// click counts a click on the station, answering like radio-browser. The lock must be held.
+func (s *Server) click(w http.ResponseWriter, stationUuid string) {
+	for _, station := range s.stations {
+		if field(station, "stationuuid") != stationUuid {
+			continue
+		}
+		clicks, _ := station["clickcount"].(float64)
+		station["clickcount"] = clicks + 1
+		writeJSON(w, map[string]interface{}{
+			"ok":          true,
+			"message":     "retrieved station url",
+			"stationuuid": station["stationuuid"],
+			"name":        station["name"],
+			"url":         station["url_resolved"],
+		})
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"ok":      false,
+		"message": "did not find station with matching uuid",
+	})
+}
+
+// stationMatchers match a station with the search term of the /stations/{query}/{term} endpoints.
+var stationMatchers = map[string]func(station map[string]interface{}, term string) bool{
+	string(common.StationQueryByUuid): func(station map[string]interface{}, term string) bool {
+		return listContains(term, field(station, "stationuuid"), true)
+	},
+	string(common.StationQueryByName):             fieldMatcher("name", false),
+	string(common.StationQueryByNameExact):        fieldMatcher("name", true),
+	string(common.StationQueryByCodec):            fieldMatcher("codec", false),
+	string(common.StationQueryByCodecExact):       fieldMatcher("codec", true),
+	string(common.StationQueryByCountry):          fieldMatcher("country", false),
+	string(common.StationQueryByCountryExact):     fieldMatcher("country", true),
+	string(common.StationQueryByCountryCodeExact): fieldMatcher("countrycode", true),
+	string(common.StationQueryByState):            fieldMatcher("state", false),
+	string(common.StationQueryByStateExact):       fieldMatcher("state", true),
+	string(common.StationQueryByLanguage):         listMatcher("language", false),
+	string(common.StationQueryByLanguageExact):    listMatcher("language", true),
+	string(common.StationQueryByTag):              listMatcher("tags", false),
+	string(common.StationQueryByTagExact):         listMatcher("tags", true),
+}
+
+func fieldMatcher(name string, exact bool) func(map[string]interface{}, string) bool {
+	return func(station map[string]interface{}, term string) bool {
+		if exact {
+			return strings.EqualFold(field(station, name), term)
+		}
+		return containsFold(field(station, name), term)
+	}
+}
+
+// listMatcher matches the items of a comma-separated field, like tags.
+func listMatcher(name string, exact bool) func(map[string]interface{}, string) bool {
+	return func(station map[string]interface{}, term string) bool {
+		return listContains(field(station, name), term, exact)
+	}
+}
+
+// matches reports whether the station matches the parameters of /stations/search.
+func matches(station map[string]interface{}, query url.Values) bool {
+	if name := query.Get("name"); name != "" && !containsFold(field(station, "name"), name) {
+		return false
+	}
+	if code := query.Get("countrycode"); code != "" && !strings.EqualFold(field(station, "countrycode"), code) {
+		return false
+	}
+	if language := query.Get("language"); language != "" && !listContains(field(station, "language"), language, false) {
+		return false
+	}
+	if tag := query.Get("tag"); tag != "" && !listContains(field(station, "tags"), tag, false) {
+		return false
+	}
+	return true
+}
+
+// page sorts the objects and returns the requested page, according to the
+// order, reverse, offset and limit parameters.
+func page(objects []map[string]interface{}, query url.Values) []map[string]interface{} {
+	order := query.Get("order")
+	if order == "" {
+		order = "name"
+	}
+	reverse := query.Get("reverse") == "true"
+	sorted := append([]map[string]interface{}(nil), objects...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if reverse {
+			return less(sorted[j][order], sorted[i][order])
+		}
+		return less(sorted[i][order], sorted[j][order])
+	})
+
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	if offset > len(sorted) {
+		offset = len(sorted)
+	}
+	sorted = sorted[offset:]
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit < len(sorted) {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+func less(a interface{}, b interface{}) bool {
+	if x, ok := a.(float64); ok {
+		y, _ := b.(float64)
+		return x < y
+	}
+	x, _ := a.(string)
+	y, _ := b.(string)
+	return strings.ToLower(x) < strings.ToLower(y)
+}
+
+func filter(objects []map[string]interface{}, keep func(map[string]interface{}) bool) []map[string]interface{} {
+	kept := []map[string]interface{}{}
+	for _, object := range objects {
+		if keep(object) {
+			kept = append(kept, object)
+		}
+	}
+	return kept
+}
+
+// field returns a field of a fixture as a string.
+func field(object map[string]interface{}, name string) string {
+	switch value := object[name].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func listContains(list string, term string, exact bool) bool {
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if (exact && strings.EqualFold(item, term)) || (!exact && containsFold(item, term)) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -20,14 +20,31 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
-	"math/rand"
-	"net"
+	"errors"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/zi0p4tch0/radiogogo/common"
 	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/eventlog"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ClickCooldown is how long radio-browser ignores repeated clicks on the same station from the same client.
+	ClickCooldown = 24 * time.Hour
+	// VoteCooldown is how long radio-browser refuses repeated votes for the same station from the same client.
+	VoteCooldown = 10 * time.Minute
+	// MaxStationResults caps how many stations are decoded out of a single answer, however many are sent.
+	MaxStationResults = 10000
 )
 
 type RadioBrowserService interface {
@@ -48,30 +65,173 @@ type RadioBrowserService interface {
 		limit uint64,
 		hideBroken bool,
 	) ([]common.Station, error)
+	// SearchStations retrieves the radio stations whose name contains the given one, narrowed down by the given filter.
+	// The order, reverse, offset, limit and hideBroken parameters behave like in GetStations.
+	// Returns a slice of Station structs and an error if any occurred.
+	SearchStations(
+		name string,
+		filter common.StationFilter,
+		order string,
+		reverse bool,
+		offset uint64,
+		limit uint64,
+		hideBroken bool,
+	) ([]common.Station, error)
+	// GetStationsByUrl retrieves the radio stations whose stream URL is exactly the given one.
+	// Returns a slice of Station structs and an error if any occurred.
+	GetStationsByUrl(streamUrl string) ([]common.Station, error)
+	// GetStationChecks retrieves the recent availability checks of the given station, as run by the radio-browser servers.
+	// Returns a slice of StationCheck structs and an error if any occurred.
+	GetStationChecks(stationUuid uuid.UUID) ([]common.StationCheck, error)
 	// ClickStation sends a POST request to the RadioBrowser API to increment the click count of a given station.
 	// It takes a Station struct as input and returns a ClickStationResponse struct and an error.
 	ClickStation(station common.Station) (common.ClickStationResponse, error)
+	// VoteStation sends a POST request to the RadioBrowser API to increment the vote count of a given station.
+	// radio-browser only counts one vote per station and client within VoteCooldown.
+	VoteStation(station common.Station) (common.VoteStationResponse, error)
+	// SuggestStationEdit sends a POST request to the RadioBrowser API suggesting new metadata for the given station.
+	// Returns ErrEditsUnsupported if the server doesn't take edit suggestions.
+	SuggestStationEdit(stationUuid uuid.UUID, edit common.StationEdit) (common.StationEditResponse, error)
+	// GetTags retrieves a list of tags from the RadioBrowser API.
+	// If prefix is not empty, only tags starting with it are returned.
+	// The order, reverse, offset, limit and hideBroken parameters behave like in GetStations.
+	// Returns a slice of Tag structs and an error if any occurred.
+	GetTags(
+		prefix string,
+		order string,
+		reverse bool,
+		offset uint64,
+		limit uint64,
+		hideBroken bool,
+	) ([]common.Tag, error)
+	// GetCountries retrieves the countries that have stations, ordered by name.
+	// The hideBroken parameter behaves like in GetStations.
+	// Returns a slice of Country structs and an error if any occurred.
+	GetCountries(hideBroken bool) ([]common.Country, error)
+	// GetLanguages retrieves the languages that have stations, ordered by name.
+	// The hideBroken parameter behaves like in GetStations.
+	// Returns a slice of Language structs and an error if any occurred.
+	GetLanguages(hideBroken bool) ([]common.Language, error)
+	// GetTopStations retrieves the limit stations ranking highest in the given chart,
+	// in the country with the given ISO 3166-1 alpha-2 code, or worldwide if it's empty.
+	// The hideBroken parameter behaves like in GetStations.
+	// Returns a slice of Station structs and an error if any occurred.
+	GetTopStations(chart common.StationChart, countryCode string, limit uint64, hideBroken bool) ([]common.Station, error)
 }
 
 type RadioBrowserImpl struct {
 	// The HTTP client used to make requests to the Radio Browser API.
 	httpClient HTTPClientService
-	// The base URL for the Radio Browser API.)
-	baseUrl url.URL
+	// The Radio Browser API servers, and how they have been answering.
+	mirrors *mirrorPool
+	// Looks the mirrors up again when they go stale (nil when a base URL is configured).
+	resolver *mirrorResolver
+	// Where the odd values tolerated in the stations received are logged (nil doesn't log them),
+	// guarded as the mirrors are looked up again in the background.
+	eventLogMutex sync.Mutex
+	eventLog      *eventlog.Log
+	// Whether searches are sent to two mirrors at once, the first answer winning.
+	raceSearches bool
+	// Keeps the last request and response (nil doesn't keep them).
+	inspector *inspector
+}
+
+// StationStreamer is implemented by a RadioBrowserService that can hand over the stations it finds one at a time
+// as they're decoded, so that long lists needn't be held in memory at once. An error returned by yield stops
+// the request and is returned.
+type StationStreamer interface {
+	StreamStations(
+		stationQuery common.StationQuery,
+		searchTerm string,
+		order string,
+		reverse bool,
+		offset uint64,
+		limit uint64,
+		hideBroken bool,
+		yield func(station common.Station) error,
+	) error
+	StreamSearchStations(
+		name string,
+		filter common.StationFilter,
+		order string,
+		reverse bool,
+		offset uint64,
+		limit uint64,
+		hideBroken bool,
+		yield func(station common.Station) error,
+	) error
+}
+
+// EventLogger is implemented by a RadioBrowserService that can log what it tolerates in radio-browser's answers,
+// such as stations with odd values.
+type EventLogger interface {
+	// SetEventLog logs to log from now on (nil stops logging).
+	SetEventLog(log *eventlog.Log)
+}
+
+// SetEventLog logs the odd values tolerated in the stations received to log.
+func (radioBrowser *RadioBrowserImpl) SetEventLog(log *eventlog.Log) {
+	radioBrowser.eventLogMutex.Lock()
+	defer radioBrowser.eventLogMutex.Unlock()
+	radioBrowser.eventLog = log
+}
+
+// logf writes to the event log, if any.
+func (radioBrowser *RadioBrowserImpl) logf(format string, args ...interface{}) {
+	radioBrowser.eventLogMutex.Lock()
+	log := radioBrowser.eventLog
+	radioBrowser.eventLogMutex.Unlock()
+	if log != nil {
+		_ = log.Printf(format, args...)
+	}
 }
 
 // NewRadioBrowser returns a new instance of RadioBrowserService with the default DNS lookup and HTTP client services.
-func NewRadioBrowser() (RadioBrowserService, error) {
+// Requests go to baseURL, e.g. a self-hosted radio-browser, or to the radio-browser mirrors if it's empty.
+// They are sent no faster than the given limiter allows (nil means no limit).
+func NewRadioBrowser(baseURL string, limiter *RateLimiter) (RadioBrowserService, error) {
+	httpClient := NewRateLimitedHTTPClient(http.DefaultClient, limiter)
+	if baseURL != "" {
+		url, err := ParseBaseURL(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		return NewRadioBrowserWithBaseURL(*url, httpClient), nil
+	}
 	return NewRadioBrowserWithDependencies(
 		&DNSLookupServiceImpl{},
-		http.DefaultClient,
+		httpClient,
 	)
 }
 
+// ParseBaseURL parses the URL of a radio-browser compatible server, e.g. "https://radio.example.com/json".
+// "/json" is appended to a URL without a path, where radio-browser serves its API.
+// It returns ErrInvalidBaseURL unless it's an http or https URL with a host.
+func ParseBaseURL(baseURL string) (*url.URL, error) {
+	url, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || (url.Scheme != "http" && url.Scheme != "https") || url.Host == "" {
+		return nil, ErrInvalidBaseURL
+	}
+	if url.Path == "" {
+		url.Path = "/json"
+	}
+	return url, nil
+}
+
+// NewRadioBrowserWithBaseURL creates a new instance of RadioBrowserService sending every request to baseURL,
+// without looking the radio-browser mirrors up.
+func NewRadioBrowserWithBaseURL(baseURL url.URL, httpClient HTTPClientService) RadioBrowserService {
+	return &RadioBrowserImpl{
+		httpClient: httpClient,
+		mirrors:    newMirrorPool([]url.URL{baseURL}),
+	}
+}
+
 // NewRadioBrowserWithDependencies creates a new instance of RadioBrowserService with the provided dependencies.
 // It takes a DNSLookupService and an HTTPClientService as arguments and returns a pointer to RadioBrowserService and an error.
-// The function performs a DNS lookup for "all.api.radio-browser.info" and selects a random IP address from the returned list.
-// It then constructs a URL with the selected IP address and sets it as the base URL for the browser.
+// The function performs a DNS lookup for "all.api.radio-browser.info" and uses every returned IP address as a mirror.
+// Each mirror is tried once, in random order, then requests go to the one that has been answering fastest and most reliably.
+// The mirrors are looked up again once the DNS answer expires, or when they all fail, without interrupting the requests.
 // Returns an error if the DNS lookup or URL parsing fails.
 func NewRadioBrowserWithDependencies(
 	dnsLookupService DNSLookupService,
@@ -80,26 +240,79 @@ func NewRadioBrowserWithDependencies(
 	browser := &RadioBrowserImpl{
 		httpClient: httpClient,
 	}
-	ips, err := dnsLookupService.LookupIP("all.api.radio-browser.info")
+	baseUrls, ttl, err := lookupMirrors(dnsLookupService)
 	if err != nil {
 		return nil, err
 	}
 
-	randomIp := ips[rand.Intn(len(ips))]
+	browser.mirrors = newMirrorPool(baseUrls)
+	browser.resolver = newMirrorResolver(dnsLookupService, browser.mirrors, ttl)
+	browser.resolver.logf = browser.logf
+	return browser, nil
+}
+
+// mirror returns the base URL of the mirror the next request should be sent to,
+// looking the mirrors up again first if they went stale.
+func (radioBrowser *RadioBrowserImpl) mirror() *url.URL {
+	radioBrowser.resolver.refreshIfStale()
+	return radioBrowser.mirrors.pick()
+}
+
+// MirrorStats returns how each radio-browser mirror has been answering, the preferred one first.
+func (radioBrowser *RadioBrowserImpl) MirrorStats() []MirrorStats {
+	return radioBrowser.mirrors.stats()
+}
+
+func (radioBrowser *RadioBrowserImpl) GetStations(
+	stationQuery common.StationQuery,
+	searchTerm string,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Station, error) {
 
-	if net.ParseIP(randomIp).To4() == nil {
-		randomIp = "[" + randomIp + "]"
+	stations := []common.Station{}
+
+	err := radioBrowser.streamSearch(radioBrowser.stationsURL(stationQuery, searchTerm, order, reverse, offset, limit, hideBroken), func(station common.Station) error {
+		stations = append(stations, station)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	url, err := url.Parse("http://" + randomIp + "/json")
+	return stations, nil
+
+}
+
+func (radioBrowser *RadioBrowserImpl) SearchStations(
+	name string,
+	filter common.StationFilter,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Station, error) {
+
+	stations := []common.Station{}
+
+	err := radioBrowser.streamSearch(radioBrowser.searchURL(name, filter, order, reverse, offset, limit, hideBroken), func(station common.Station) error {
+		stations = append(stations, station)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	browser.baseUrl = *url
-	return browser, nil
+
+	return stations, nil
+
 }
 
-func (radioBrowser *RadioBrowserImpl) GetStations(
+// StreamStations queries stations as GetStations does, handing them to yield one at a time as they're decoded.
+func (radioBrowser *RadioBrowserImpl) StreamStations(
 	stationQuery common.StationQuery,
 	searchTerm string,
 	order string,
@@ -107,11 +320,39 @@ func (radioBrowser *RadioBrowserImpl) GetStations(
 	offset uint64,
 	limit uint64,
 	hideBroken bool,
-) ([]common.Station, error) {
+	yield func(station common.Station) error,
+) error {
+	return radioBrowser.streamSearch(radioBrowser.stationsURL(stationQuery, searchTerm, order, reverse, offset, limit, hideBroken), yield)
+}
+
+// StreamSearchStations searches stations as SearchStations does, handing them to yield one at a time as they're decoded.
+func (radioBrowser *RadioBrowserImpl) StreamSearchStations(
+	name string,
+	filter common.StationFilter,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+	yield func(station common.Station) error,
+) error {
+	return radioBrowser.streamSearch(radioBrowser.searchURL(name, filter, order, reverse, offset, limit, hideBroken), yield)
+}
+
+// stationsURL returns the URL of a query of GetStations.
+func (radioBrowser *RadioBrowserImpl) stationsURL(
+	stationQuery common.StationQuery,
+	searchTerm string,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+) *url.URL {
 
-	url := radioBrowser.baseUrl.JoinPath("/stations")
+	url := radioBrowser.mirror().JoinPath("/stations")
 	if stationQuery != common.StationQueryAll {
-		url = url.JoinPath("/" + string(stationQuery) + "/" + searchTerm)
+		url = joinPathSegment(url.JoinPath("/"+string(stationQuery)), searchTerm)
 	}
 
 	query := url.Query()
@@ -122,68 +363,430 @@ func (radioBrowser *RadioBrowserImpl) GetStations(
 	query.Set("hidebroken", boolToString(hideBroken))
 	url.RawQuery = query.Encode()
 
-	headers := make(map[string]string)
-	headers["User-Agent"] = data.UserAgent
-	headers["Accept"] = "application/json"
+	return url
+
+}
+
+// searchURL returns the URL of a search of SearchStations.
+func (radioBrowser *RadioBrowserImpl) searchURL(
+	name string,
+	filter common.StationFilter,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+) *url.URL {
+
+	url := radioBrowser.mirror().JoinPath("/stations/search")
+
+	query := url.Query()
+	query.Set("name", name)
+	if filter.CountryCode != "" {
+		query.Set("countrycode", filter.CountryCode)
+	}
+	if filter.Language != "" {
+		query.Set("language", filter.Language)
+	}
+	if filter.Country != "" {
+		query.Set("country", filter.Country)
+	}
+	if filter.State != "" {
+		query.Set("state", filter.State)
+	}
+	if filter.Tag != "" {
+		query.Set("tag", filter.Tag)
+	}
+	if filter.Codec != "" {
+		query.Set("codec", filter.Codec)
+	}
+	if filter.BitrateMin > 0 {
+		query.Set("bitrateMin", uint64ToString(filter.BitrateMin))
+	}
+	if filter.BitrateMax > 0 {
+		query.Set("bitrateMax", uint64ToString(filter.BitrateMax))
+	}
+	query.Set("order", order)
+	query.Set("reverse", boolToString(reverse))
+	query.Set("offset", uint64ToString(offset))
+	query.Set("limit", uint64ToString(limit))
+	query.Set("hidebroken", boolToString(hideBroken))
+	url.RawQuery = query.Encode()
+
+	return url
+
+}
+
+func (radioBrowser *RadioBrowserImpl) GetStationsByUrl(streamUrl string) ([]common.Station, error) {
+
+	url := radioBrowser.mirror().JoinPath("/stations/byurl")
+
+	query := url.Query()
+	query.Set("url", streamUrl)
+	url.RawQuery = query.Encode()
 
 	var stations []common.Station
 
-	req, err := http.NewRequest("GET", url.String(), nil)
+	err := radioBrowser.doRequest("GET", url, &stations)
 	if err != nil {
 		return nil, err
 	}
 
-	for key, value := range headers {
-		req.Header.Set(key, value)
+	return stations, nil
+}
+
+func (radioBrowser *RadioBrowserImpl) GetStationChecks(stationUuid uuid.UUID) ([]common.StationCheck, error) {
+
+	url := radioBrowser.mirror().JoinPath("/checks/" + stationUuid.String())
+
+	var checks []common.StationCheck
+
+	err := radioBrowser.doRequest("GET", url, &checks)
+	if err != nil {
+		return nil, err
 	}
 
-	result, err := radioBrowser.httpClient.Do(req)
+	return checks, nil
+}
+
+func (radioBrowser *RadioBrowserImpl) ClickStation(station common.Station) (common.ClickStationResponse, error) {
+
+	url := radioBrowser.mirror().JoinPath("/url/" + station.StationUuid.String())
+
+	var response common.ClickStationResponse
+
+	err := radioBrowser.doRequest("POST", url, &response)
+	if err != nil {
+		return common.ClickStationResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (radioBrowser *RadioBrowserImpl) VoteStation(station common.Station) (common.VoteStationResponse, error) {
+
+	url := radioBrowser.mirror().JoinPath("/vote/" + station.StationUuid.String())
+
+	var response common.VoteStationResponse
+
+	err := radioBrowser.doRequest("POST", url, &response)
+	if err != nil {
+		return common.VoteStationResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (radioBrowser *RadioBrowserImpl) SuggestStationEdit(stationUuid uuid.UUID, edit common.StationEdit) (common.StationEditResponse, error) {
+
+	url := radioBrowser.mirror().JoinPath("/edit/" + stationUuid.String())
+
+	var response common.StationEditResponse
+
+	err := radioBrowser.doFormRequest(url, edit.Values(), &response)
+	var apiErr *Error
+	if errors.As(err, &apiErr) && editsUnsupportedStatus(apiErr.StatusCode) {
+		return common.StationEditResponse{}, ErrEditsUnsupported
+	}
+	if err != nil {
+		return common.StationEditResponse{}, err
+	}
+
+	return response, nil
+}
+
+// editsUnsupportedStatus reports whether a server answering edit suggestions with statusCode doesn't take them
+// (radio-browser servers dropped the edit endpoint).
+func editsUnsupportedStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusGone, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+func (radioBrowser *RadioBrowserImpl) GetTags(
+	prefix string,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Tag, error) {
+
+	url := radioBrowser.mirror().JoinPath("/tags")
+	if prefix != "" {
+		url = joinPathSegment(url, prefix)
+	}
+
+	query := url.Query()
+	query.Set("order", order)
+	query.Set("reverse", boolToString(reverse))
+	query.Set("offset", uint64ToString(offset))
+	query.Set("limit", uint64ToString(limit))
+	query.Set("hidebroken", boolToString(hideBroken))
+	url.RawQuery = query.Encode()
+
+	var tags []common.Tag
+
+	err := radioBrowser.doRequest("GET", url, &tags)
 	if err != nil {
 		return nil, err
 	}
 
-	defer result.Body.Close()
+	return tags, nil
+}
+
+func (radioBrowser *RadioBrowserImpl) GetCountries(hideBroken bool) ([]common.Country, error) {
+
+	url := radioBrowser.mirror().JoinPath("/countries")
+
+	query := url.Query()
+	query.Set("order", "name")
+	query.Set("hidebroken", boolToString(hideBroken))
+	url.RawQuery = query.Encode()
 
-	err = json.NewDecoder(result.Body).Decode(&stations)
+	var countries []common.Country
 
+	err := radioBrowser.doRequest("GET", url, &countries)
+	if err != nil {
+		return nil, err
+	}
+
+	return countries, nil
+}
+
+func (radioBrowser *RadioBrowserImpl) GetLanguages(hideBroken bool) ([]common.Language, error) {
+
+	url := radioBrowser.mirror().JoinPath("/languages")
+
+	query := url.Query()
+	query.Set("order", "name")
+	query.Set("hidebroken", boolToString(hideBroken))
+	url.RawQuery = query.Encode()
+
+	var languages []common.Language
+
+	err := radioBrowser.doRequest("GET", url, &languages)
+	if err != nil {
+		return nil, err
+	}
+
+	return languages, nil
+}
+
+func (radioBrowser *RadioBrowserImpl) GetTopStations(
+	chart common.StationChart,
+	countryCode string,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Station, error) {
+
+	// The chart endpoints can't be narrowed down to a country: searching
+	// the country's stations in the chart's order ranks them the same way.
+	if countryCode != "" {
+		return radioBrowser.SearchStations("", common.StationFilter{CountryCode: countryCode}, chart.Order(), true, 0, limit, hideBroken)
+	}
+
+	url := radioBrowser.mirror().JoinPath("/stations/" + string(chart) + "/" + uint64ToString(limit))
+
+	query := url.Query()
+	query.Set("hidebroken", boolToString(hideBroken))
+	url.RawQuery = query.Encode()
+
+	var stations []common.Station
+
+	err := radioBrowser.doRequest("GET", url, &stations)
 	if err != nil {
 		return nil, err
 	}
 
 	return stations, nil
+}
 
+// doRequest sends a request with the given method to the given URL and decodes the JSON response into v.
+// Failures are reported as *Error.
+func (radioBrowser *RadioBrowserImpl) doRequest(method string, url *url.URL, v interface{}) error {
+	return radioBrowser.doRequestWithBody(method, url, nil, "", v)
 }
 
-func (radioBrowser *RadioBrowserImpl) ClickStation(station common.Station) (common.ClickStationResponse, error) {
+// doFormRequest posts form to the given URL and decodes the JSON response into v, like doRequest.
+func (radioBrowser *RadioBrowserImpl) doFormRequest(url *url.URL, form url.Values, v interface{}) error {
+	return radioBrowser.doRequestWithBody("POST", url, strings.NewReader(form.Encode()), "application/x-www-form-urlencoded", v)
+}
+
+// doRequestWithBody sends a request like doRequest, with the given body of the given content type, if any.
+// Stations are decoded as they're read, see streamRequest.
+func (radioBrowser *RadioBrowserImpl) doRequestWithBody(method string, url *url.URL, requestBody io.Reader, contentType string, v interface{}) error {
+
+	if stations, ok := v.(*[]common.Station); ok {
+		decoded := []common.Station{}
+		err := radioBrowser.streamRequest(method, url, requestBody, contentType, func(station common.Station) error {
+			decoded = append(decoded, station)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		*stations = decoded
+		return nil
+	}
+
+	result, err := radioBrowser.send(context.Background(), method, url, requestBody, contentType)
+	if err != nil {
+		return err
+	}
+	defer result.Body.Close()
+
+	body, err := io.ReadAll(result.Body)
+	if err != nil {
+		radioBrowser.mirrors.record(url.Host, 0, true)
+		return &Error{Kind: ErrMirrorUnavailable, StatusCode: result.StatusCode, Err: err}
+	}
 
-	url := radioBrowser.baseUrl.JoinPath("/url/" + station.StationUuid.String())
+	radioBrowser.mirrors.record(url.Host, time.Since(result.start), false)
+
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		return &Error{Kind: ErrBadResponse, StatusCode: result.StatusCode, Body: bodySnippet(body), Err: err}
+	}
+
+	return nil
+}
+
+// streamRequest sends a request like doRequestWithBody, handing the stations of the answer to yield
+// one at a time as they're decoded, up to MaxStationResults of them. Odd values are logged rather than
+// failing the request. An error returned by yield stops the request and is returned.
+func (radioBrowser *RadioBrowserImpl) streamRequest(
+	method string,
+	url *url.URL,
+	requestBody io.Reader,
+	contentType string,
+	yield func(station common.Station) error,
+) error {
+
+	result, err := radioBrowser.send(context.Background(), method, url, requestBody, contentType)
+	if err != nil {
+		return err
+	}
+	return radioBrowser.streamResponse(url, result, yield)
+}
+
+// streamResponse hands the stations of a response from the given URL to yield, as streamRequest does.
+func (radioBrowser *RadioBrowserImpl) streamResponse(url *url.URL, result response, yield func(station common.Station) error) error {
+
+	defer result.Body.Close()
+
+	// The beginning of the body is kept to tell what was received if it can't be decoded
+	snippet := &snippetWriter{}
+	decoder := common.NewStationDecoder(io.TeeReader(result.Body, snippet))
+	for count := 0; ; count++ {
+		station, warnings, err := decoder.Next()
+		for _, warning := range warnings {
+			radioBrowser.logf("radio-browser: %s", warning)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil && !isDecodingError(err) {
+			radioBrowser.mirrors.record(url.Host, 0, true)
+			return &Error{Kind: ErrMirrorUnavailable, StatusCode: result.StatusCode, Err: err}
+		}
+		if err != nil {
+			radioBrowser.mirrors.record(url.Host, time.Since(result.start), false)
+			return &Error{Kind: ErrBadResponse, StatusCode: result.StatusCode, Body: bodySnippet(snippet.Bytes()), Err: err}
+		}
+		if count == MaxStationResults {
+			radioBrowser.logf("radio-browser: kept the first %d stations of %s", MaxStationResults, url.Path)
+			break
+		}
+		if err := yield(station); err != nil {
+			return err
+		}
+	}
+
+	radioBrowser.mirrors.record(url.Host, time.Since(result.start), false)
+	return nil
+}
+
+// response is a successful response, and when its request was sent.
+type response struct {
+	*http.Response
+	start time.Time
+}
+
+// send sends a request to radio-browser, returning the response if it's successful. Its body must be closed.
+// A request cancelled through ctx isn't held against its mirror.
+func (radioBrowser *RadioBrowserImpl) send(ctx context.Context, method string, url *url.URL, requestBody io.Reader, contentType string) (response, error) {
 
 	headers := make(map[string]string)
 	headers["User-Agent"] = data.UserAgent
 	headers["Accept"] = "application/json"
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
 
-	req, err := http.NewRequest("POST", url.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, method, url.String(), requestBody)
 	if err != nil {
-		return common.ClickStationResponse{}, err
+		return response{}, err
 	}
 
 	for key, value := range headers {
 		req.Header.Set(key, value)
 	}
 
+	inspector := radioBrowser.inspector
+	inspected := inspector.sent(req)
+	start := time.Now()
 	result, err := radioBrowser.httpClient.Do(req)
+	if body := inspector.received(inspected, result, err); body != nil {
+		result.Body = body
+	}
 	if err != nil {
-		return common.ClickStationResponse{}, err
+		if ctx.Err() == nil {
+			radioBrowser.mirrors.record(url.Host, 0, true)
+		}
+		return response{}, &Error{Kind: ErrMirrorUnavailable, Err: err}
 	}
 
-	defer result.Body.Close()
+	if result.StatusCode < 200 || result.StatusCode > 299 {
+		defer result.Body.Close()
+		body, err := io.ReadAll(result.Body)
+		if err != nil {
+			if ctx.Err() == nil {
+				radioBrowser.mirrors.record(url.Host, 0, true)
+			}
+			return response{}, &Error{Kind: ErrMirrorUnavailable, StatusCode: result.StatusCode, Err: err}
+		}
+		responseErr := newResponseError(result, body)
+		radioBrowser.mirrors.record(url.Host, time.Since(start), responseErr.Kind == ErrMirrorUnavailable)
+		return response{}, responseErr
+	}
 
-	var response common.ClickStationResponse
-	err = json.NewDecoder(result.Body).Decode(&response)
+	return response{Response: result, start: start}, nil
+}
 
-	if err != nil {
-		return common.ClickStationResponse{}, err
+// isDecodingError returns true if err tells that a body couldn't be decoded, rather than read.
+func isDecodingError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	return errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, common.ErrNotStationList)
+}
+
+// snippetWriter keeps the first bodySnippetLength bytes written to it.
+type snippetWriter struct {
+	buffer bytes.Buffer
+}
+
+func (w *snippetWriter) Write(p []byte) (int, error) {
+	if room := bodySnippetLength - w.buffer.Len(); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		w.buffer.Write(p[:room])
 	}
+	return len(p), nil
+}
 
-	return response, nil
+func (w *snippetWriter) Bytes() []byte {
+	return w.buffer.Bytes()
 }
@@ -24,10 +24,17 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/zi0p4tch0/radiogogo/common"
 	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/eventlog"
 	"github.com/zi0p4tch0/radiogogo/mocks"
 
 	"github.com/google/uuid"
@@ -51,7 +58,7 @@ func TestBrowserImplEscapesIPV6(t *testing.T) {
 	browser, err := NewRadioBrowserWithDependencies(&mockDNSLookupService, &mockHttpClient)
 	assert.NoError(t, err)
 
-	assert.Equal(t, "http://[2001:db8::1]/json", browser.(*RadioBrowserImpl).baseUrl.String())
+	assert.Equal(t, "http://[2001:db8::1]/json", browser.(*RadioBrowserImpl).mirrors.pick().String())
 
 	assert.NoError(t, err)
 
@@ -103,6 +110,47 @@ func TestBrowserImplNewRadioBrowserWithDependencies(t *testing.T) {
 
 }
 
+func TestBrowserImplNewRadioBrowserWithBaseURL(t *testing.T) {
+
+	t.Run("sends every request to the configured server", func(t *testing.T) {
+
+		baseURL, err := ParseBaseURL("https://radio.example.com/")
+		assert.NoError(t, err)
+
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "https://radio.example.com/json/vote/941ef6f1-0699-4821-95b1-2b678e3ff62e", req.URL.String())
+				return &http.Response{
+					StatusCode: 200,
+					Body:       io.NopCloser(bytes.NewReader([]byte(`{"ok": true}`))),
+				}, nil
+			},
+		}
+
+		radioBrowser := NewRadioBrowserWithBaseURL(*baseURL, &mockHttpClient)
+		_, err = radioBrowser.VoteStation(common.Station{StationUuid: uuid.MustParse("941ef6f1-0699-4821-95b1-2b678e3ff62e")})
+		assert.NoError(t, err)
+
+	})
+
+	t.Run("keeps the path of the configured server", func(t *testing.T) {
+
+		baseURL, err := ParseBaseURL("http://localhost:8080/radio/json")
+		assert.NoError(t, err)
+		assert.Equal(t, "http://localhost:8080/radio/json", baseURL.String())
+
+	})
+
+	t.Run("rejects URLs that aren't http or https", func(t *testing.T) {
+
+		for _, baseURL := range []string{"radio.example.com", "ftp://radio.example.com", "https://", "::"} {
+			_, err := ParseBaseURL(baseURL)
+			assert.ErrorIs(t, err, ErrInvalidBaseURL, baseURL)
+		}
+
+	})
+}
+
 func TestBrowserImplGetStations(t *testing.T) {
 
 	// Note: Search term set to "searchTerm" in all test cases
@@ -223,6 +271,290 @@ func TestBrowserImplGetStations(t *testing.T) {
 		})
 	}
 }
+
+// FuzzBrowserImplGetStations sends search terms to an HTTP server, which must get each one whole,
+// as the last segment of the path.
+func FuzzBrowserImplGetStations(f *testing.F) {
+
+	for _, term := range searchTermSeeds {
+		f.Add(term)
+	}
+
+	received := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	f.Cleanup(server.Close)
+
+	baseURL, err := ParseBaseURL(server.URL)
+	if err != nil {
+		f.Fatal(err)
+	}
+	browser := NewRadioBrowserWithBaseURL(*baseURL, server.Client())
+
+	f.Fuzz(func(t *testing.T, term string) {
+
+		_, err := browser.GetStations(common.StationQueryByName, term, "name", false, 0, 10, true)
+		if !assert.NoError(t, err) {
+			return
+		}
+		req := <-received
+
+		segments := strings.Split(req.URL.EscapedPath(), "/")
+		assert.Equal(t, []string{"", "json", "stations", "byname"}, segments[:len(segments)-1])
+		lastSegment, err := url.PathUnescape(segments[len(segments)-1])
+		assert.NoError(t, err)
+		assert.Equal(t, term, lastSegment)
+		assert.Equal(t, "name", req.URL.Query().Get("order"))
+		assert.Equal(t, "10", req.URL.Query().Get("limit"))
+
+	})
+
+}
+
+func TestBrowserImplGetStationsByUrl(t *testing.T) {
+
+	mockDNSLookupService := mocks.MockDNSLookupService{
+		LookupIPFunc: func(host string) ([]string, error) {
+			return []string{"127.0.0.1"}, nil
+		},
+	}
+
+	mockHttpClient := mocks.MockHttpClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "/json/stations/byurl", req.URL.Path)
+			assert.Equal(t, "GET", req.Method)
+			assert.Equal(t, "http://example.com/stream?format=mp3", req.URL.Query().Get("url"))
+			responseBody := io.NopCloser(bytes.NewReader([]byte(`[{"name":"Example"}]`)))
+			return &http.Response{
+				StatusCode: 200,
+				Body:       responseBody,
+			}, nil
+		},
+	}
+
+	browser, err := NewRadioBrowserWithDependencies(&mockDNSLookupService, &mockHttpClient)
+	assert.NoError(t, err)
+
+	stations, err := browser.GetStationsByUrl("http://example.com/stream?format=mp3")
+
+	assert.NoError(t, err)
+	assert.Len(t, stations, 1)
+	assert.Equal(t, "Example", stations[0].Name)
+
+}
+
+func TestBrowserImplLogsOddStations(t *testing.T) {
+
+	mockHttpClient := mocks.MockHttpClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			responseBody := io.NopCloser(bytes.NewReader([]byte(`[{"name":"Example","bitrate":"128k"},{"name":"Other","votes":"lots"}]`)))
+			return &http.Response{
+				StatusCode: 200,
+				Body:       responseBody,
+			}, nil
+		},
+	}
+	baseURL, err := ParseBaseURL("https://radio.example.com")
+	assert.NoError(t, err)
+	browser := NewRadioBrowserWithBaseURL(*baseURL, &mockHttpClient)
+	logPath := filepath.Join(t.TempDir(), "radiogogo.log")
+	browser.(EventLogger).SetEventLog(eventlog.New(logPath))
+
+	stations, err := browser.GetStationsByUrl("http://example.com/stream")
+
+	assert.NoError(t, err)
+	assert.Len(t, stations, 2)
+	assert.Equal(t, uint64(128), stations[0].Bitrate)
+	log, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(log), `radio-browser: station "Example": coerced odd bitrate "128k"`)
+	assert.Contains(t, string(log), `radio-browser: station "Other": dropped bad votes "lots"`)
+
+}
+
+func TestBrowserImplStreamStations(t *testing.T) {
+
+	newBrowser := func(body string) RadioBrowserService {
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: 200,
+					Body:       io.NopCloser(strings.NewReader(body)),
+				}, nil
+			},
+		}
+		baseURL, err := ParseBaseURL("https://radio.example.com")
+		assert.NoError(t, err)
+		return NewRadioBrowserWithBaseURL(*baseURL, &mockHttpClient)
+	}
+
+	t.Run("hands over stations in order, skipping what isn't one", func(t *testing.T) {
+
+		browser := newBrowser(`[{"name":"First"},42,{"name":"Second"}]`)
+
+		var names []string
+		err := browser.(StationStreamer).StreamStations(common.StationQueryAll, "", "votes", true, 0, 10, true, func(station common.Station) error {
+			names = append(names, station.Name)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"First", "Second"}, names)
+
+	})
+
+	t.Run("stops when told to", func(t *testing.T) {
+
+		browser := newBrowser(`[{"name":"First"},{"name":"Second"}]`)
+		stop := errors.New("stop")
+
+		var names []string
+		err := browser.(StationStreamer).StreamSearchStations("jazz", common.StationFilter{}, "votes", true, 0, 10, true, func(station common.Station) error {
+			names = append(names, station.Name)
+			return stop
+		})
+
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, []string{"First"}, names)
+
+	})
+
+	t.Run("keeps at most MaxStationResults stations", func(t *testing.T) {
+
+		body := "[" + strings.Repeat(`{"name":"Example"},`, MaxStationResults) + `{"name":"Extra"}]`
+		browser := newBrowser(body)
+
+		stations, err := browser.GetStations(common.StationQueryAll, "", "votes", true, 0, MaxStationResults+1, true)
+
+		assert.NoError(t, err)
+		assert.Len(t, stations, MaxStationResults)
+		assert.Equal(t, "Example", stations[len(stations)-1].Name)
+
+	})
+
+	t.Run("reports a truncated answer as a bad response", func(t *testing.T) {
+
+		browser := newBrowser(`[{"name":"First"},{"name":"Sec`)
+
+		var names []string
+		err := browser.(StationStreamer).StreamStations(common.StationQueryAll, "", "votes", true, 0, 10, true, func(station common.Station) error {
+			names = append(names, station.Name)
+			return nil
+		})
+
+		assert.ErrorIs(t, err, ErrBadResponse)
+		var apiErr *Error
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Contains(t, apiErr.Body, `{"name":"First"}`)
+		assert.Equal(t, []string{"First"}, names)
+
+	})
+
+	t.Run("reports an answer that isn't a list as a bad response", func(t *testing.T) {
+
+		browser := newBrowser(`{"error":"nope"}`)
+
+		stations, err := browser.GetStations(common.StationQueryAll, "", "votes", true, 0, 10, true)
+
+		assert.ErrorIs(t, err, ErrBadResponse)
+		assert.Nil(t, stations)
+
+	})
+
+}
+
+func TestBrowserImplSearchStations(t *testing.T) {
+
+	mockDNSLookupService := mocks.MockDNSLookupService{
+		LookupIPFunc: func(host string) ([]string, error) {
+			return []string{"127.0.0.1"}, nil
+		},
+	}
+
+	mockHttpClient := mocks.MockHttpClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "/json/stations/search", req.URL.Path)
+			assert.Equal(t, "GET", req.Method)
+			query := req.URL.Query()
+			assert.Equal(t, "rai radio", query.Get("name"))
+			assert.Equal(t, "IT", query.Get("countrycode"))
+			assert.False(t, query.Has("language"))
+			assert.Equal(t, "news", query.Get("tag"))
+			assert.Equal(t, "129", query.Get("bitrateMin"))
+			assert.False(t, query.Has("bitrateMax"))
+			assert.Equal(t, "votes", query.Get("order"))
+			assert.Equal(t, "true", query.Get("reverse"))
+			assert.Equal(t, "100", query.Get("offset"))
+			assert.Equal(t, "50", query.Get("limit"))
+			assert.Equal(t, "true", query.Get("hidebroken"))
+			responseBody := io.NopCloser(bytes.NewReader([]byte(`[{"name":"Rai Radio 1"}]`)))
+			return &http.Response{
+				StatusCode: 200,
+				Body:       responseBody,
+			}, nil
+		},
+	}
+
+	browser, err := NewRadioBrowserWithDependencies(&mockDNSLookupService, &mockHttpClient)
+	assert.NoError(t, err)
+
+	stations, err := browser.SearchStations("rai radio", common.StationFilter{CountryCode: "IT", Tag: "news", BitrateMin: 129}, "votes", true, 100, 50, true)
+
+	assert.NoError(t, err)
+	assert.Len(t, stations, 1)
+	assert.Equal(t, "Rai Radio 1", stations[0].Name)
+
+}
+
+func TestBrowserImplGetStationChecks(t *testing.T) {
+
+	mockDNSLookupService := mocks.MockDNSLookupService{
+		LookupIPFunc: func(host string) ([]string, error) {
+			return []string{"127.0.0.1"}, nil
+		},
+	}
+
+	mockHttpClient := mocks.MockHttpClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "GET", req.Method)
+			assert.Equal(t, "http://127.0.0.1/json/checks/941ef6f1-0699-4821-95b1-2b678e3ff62e", req.URL.String())
+			responseBody := io.NopCloser(bytes.NewReader([]byte(`[{
+				"checkuuid": "0c6a8b07-2b36-4d0b-8f0e-7a1a4f0f3d11",
+				"stationuuid": "941ef6f1-0699-4821-95b1-2b678e3ff62e",
+				"source": "de1.api.radio-browser.info",
+				"codec": "MP3",
+				"bitrate": 128,
+				"hls": 0,
+				"ok": 0,
+				"timestamp_iso8601": "2023-10-01T12:00:00Z",
+				"ssl_error": 1,
+				"timing_ms": 350
+			}]`)))
+			return &http.Response{
+				StatusCode: 200,
+				Body:       responseBody,
+			}, nil
+		},
+	}
+
+	browser, err := NewRadioBrowserWithDependencies(&mockDNSLookupService, &mockHttpClient)
+	assert.NoError(t, err)
+
+	checks, err := browser.GetStationChecks(uuid.MustParse("941ef6f1-0699-4821-95b1-2b678e3ff62e"))
+
+	assert.NoError(t, err)
+	assert.Len(t, checks, 1)
+	assert.Equal(t, "de1.api.radio-browser.info", checks[0].Source)
+	assert.Equal(t, "MP3", checks[0].Codec)
+	assert.Equal(t, uint64(128), checks[0].Bitrate)
+	assert.False(t, bool(checks[0].Ok))
+	assert.True(t, bool(checks[0].SslError))
+	assert.Equal(t, time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC), checks[0].Timestamp)
+
+}
+
 func TestBrowserImplClickStation(t *testing.T) {
 
 	station := common.Station{
@@ -267,3 +599,187 @@ func TestBrowserImplClickStation(t *testing.T) {
 
 	assert.Equal(t, true, response.Ok)
 }
+
+func TestBrowserImplVoteStation(t *testing.T) {
+
+	station := common.Station{
+		StationUuid: uuid.MustParse("941ef6f1-0699-4821-95b1-2b678e3ff62e"),
+	}
+
+	mockDNSLookupService := mocks.MockDNSLookupService{
+		LookupIPFunc: func(host string) ([]string, error) {
+			return []string{"127.0.0.1"}, nil
+		},
+	}
+
+	mockHttpClient := mocks.MockHttpClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			expectedUrl := "http://127.0.0.1/json/vote/941ef6f1-0699-4821-95b1-2b678e3ff62e"
+			assert.Equal(t, "POST", req.Method)
+			assert.Equal(t, expectedUrl, req.URL.String())
+
+			responseBody := io.NopCloser(bytes.NewReader([]byte(`
+			{
+				"ok": false,
+				"message": "you are voting for the same station too often"
+			}
+			`)))
+			return &http.Response{
+				StatusCode: 200,
+				Body:       responseBody,
+			}, nil
+		},
+	}
+
+	radioBrowser, err := NewRadioBrowserWithDependencies(&mockDNSLookupService, &mockHttpClient)
+	assert.NoError(t, err)
+
+	response, err := radioBrowser.VoteStation(station)
+	assert.NoError(t, err)
+
+	assert.False(t, response.Ok)
+	assert.Equal(t, "you are voting for the same station too often", response.Message)
+}
+
+func TestBrowserImplSuggestStationEdit(t *testing.T) {
+
+	stationUuid := uuid.MustParse("941ef6f1-0699-4821-95b1-2b678e3ff62e")
+	edit := common.StationEdit{Name: "Jazz FM", Url: "https://jazz.fm/live", CountryCode: "gb"}
+
+	mockDNSLookupService := mocks.MockDNSLookupService{
+		LookupIPFunc: func(host string) ([]string, error) {
+			return []string{"127.0.0.1"}, nil
+		},
+	}
+
+	t.Run("posts the edit as a form", func(t *testing.T) {
+
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "POST", req.Method)
+				assert.Equal(t, "http://127.0.0.1/json/edit/941ef6f1-0699-4821-95b1-2b678e3ff62e", req.URL.String())
+				assert.Equal(t, "application/x-www-form-urlencoded", req.Header.Get("Content-Type"))
+				assert.NoError(t, req.ParseForm())
+				assert.Equal(t, "Jazz FM", req.PostForm.Get("name"))
+				assert.Equal(t, "GB", req.PostForm.Get("countrycode"))
+
+				return &http.Response{
+					StatusCode: 200,
+					Body:       io.NopCloser(bytes.NewReader([]byte(`{"ok": true, "message": "edited"}`))),
+				}, nil
+			},
+		}
+
+		radioBrowser, err := NewRadioBrowserWithDependencies(&mockDNSLookupService, &mockHttpClient)
+		assert.NoError(t, err)
+
+		response, err := radioBrowser.SuggestStationEdit(stationUuid, edit)
+		assert.NoError(t, err)
+		assert.True(t, response.Ok)
+	})
+
+	t.Run("tells when the server doesn't take edits", func(t *testing.T) {
+
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: 404,
+					Body:       io.NopCloser(bytes.NewReader(nil)),
+				}, nil
+			},
+		}
+
+		radioBrowser, err := NewRadioBrowserWithDependencies(&mockDNSLookupService, &mockHttpClient)
+		assert.NoError(t, err)
+
+		_, err = radioBrowser.SuggestStationEdit(stationUuid, edit)
+		assert.ErrorIs(t, err, ErrEditsUnsupported)
+	})
+}
+
+func TestBrowserImplGetTags(t *testing.T) {
+
+	testCases := []struct {
+		name             string
+		prefix           string
+		expectedEndpoint string
+	}{
+		{
+			name:             "builds the correct URL without a prefix",
+			prefix:           "",
+			expectedEndpoint: "/json/tags",
+		},
+		{
+			name:             "builds the correct URL with a prefix",
+			prefix:           "jaz",
+			expectedEndpoint: "/json/tags/jaz",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			mockDNSLookupService := mocks.MockDNSLookupService{
+				LookupIPFunc: func(host string) ([]string, error) {
+					return []string{"127.0.0.1"}, nil
+				},
+			}
+
+			mockHttpClient := mocks.MockHttpClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					assert.Equal(t, tc.expectedEndpoint, req.URL.Path)
+					assert.Equal(t, "GET", req.Method)
+					assert.Equal(t, "stationcount", req.URL.Query().Get("order"))
+					assert.Equal(t, "true", req.URL.Query().Get("reverse"))
+					assert.Equal(t, "50", req.URL.Query().Get("limit"))
+					responseBody := io.NopCloser(bytes.NewReader([]byte(`[{"name":"jazz","stationcount":1234}]`)))
+					return &http.Response{
+						StatusCode: 200,
+						Body:       responseBody,
+					}, nil
+				},
+			}
+
+			browser, err := NewRadioBrowserWithDependencies(&mockDNSLookupService, &mockHttpClient)
+			assert.NoError(t, err)
+
+			tags, err := browser.GetTags(tc.prefix, "stationcount", true, 0, 50, true)
+
+			assert.NoError(t, err)
+			assert.Equal(t, []common.Tag{{Name: "jazz", StationCount: 1234}}, tags)
+
+		})
+	}
+}
+
+func TestBrowserImplGetLanguages(t *testing.T) {
+
+	mockDNSLookupService := mocks.MockDNSLookupService{
+		LookupIPFunc: func(host string) ([]string, error) {
+			return []string{"127.0.0.1"}, nil
+		},
+	}
+
+	mockHttpClient := mocks.MockHttpClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "/json/languages", req.URL.Path)
+			assert.Equal(t, "GET", req.Method)
+			assert.Equal(t, "name", req.URL.Query().Get("order"))
+			assert.Equal(t, "true", req.URL.Query().Get("hidebroken"))
+			responseBody := io.NopCloser(bytes.NewReader([]byte(`[{"name":"italian","iso_639":"it","stationcount":1234}]`)))
+			return &http.Response{
+				StatusCode: 200,
+				Body:       responseBody,
+			}, nil
+		},
+	}
+
+	browser, err := NewRadioBrowserWithDependencies(&mockDNSLookupService, &mockHttpClient)
+	assert.NoError(t, err)
+
+	languages, err := browser.GetLanguages(true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Language{{Name: "italian", Code: "it", StationCount: 1234}}, languages)
+
+}
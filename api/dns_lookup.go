@@ -22,6 +22,11 @@ package api
 import (
 	"context"
 	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 // DNSLookupService defines the behavior for looking up IP addresses for a given host.
@@ -62,3 +67,114 @@ func (s *DNSLookupServiceImpl) LookupIP(host string) ([]string, error) {
 	return ipStrings, nil
 
 }
+
+// TTLLookupService is implemented by a DNSLookupService that can tell how long the addresses it found may be cached.
+type TTLLookupService interface {
+	// LookupIPTTL is LookupIP, also returning the shortest TTL of the addresses found, or 0 if it can't be told.
+	LookupIPTTL(host string) ([]string, time.Duration, error)
+}
+
+// LookupIPTTL performs a DNS lookup like LookupIP, reading the TTL of the addresses off the DNS answers
+// with the pure Go resolver. If that fails, e.g. where only the system resolver knows how to reach the DNS
+// servers, the addresses are looked up like LookupIP does, with a TTL of 0. The TTL is 0 as well when the
+// addresses didn't come from a DNS server over UDP, e.g. from the hosts file.
+func (s *DNSLookupServiceImpl) LookupIPTTL(host string) ([]string, time.Duration, error) {
+
+	if net.ParseIP(host) != nil {
+		return []string{host}, 0, nil
+	}
+
+	var recorder ttlRecorder
+	resolver := &net.Resolver{PreferGo: true, Dial: recorder.dial}
+
+	ips, err := resolver.LookupIP(context.Background(), "ip4", host)
+	if err != nil {
+		ipStrings, err := s.LookupIP(host)
+		return ipStrings, 0, err
+	}
+
+	ipStrings := make([]string, len(ips))
+	for i, ip := range ips {
+		ipStrings[i] = ip.String()
+	}
+
+	return ipStrings, recorder.shortest(), nil
+
+}
+
+// ttlRecorder dials the DNS servers for a resolver, keeping the shortest TTL of the addresses they answer with.
+type ttlRecorder struct {
+	mutex sync.Mutex
+	ttl   time.Duration
+}
+
+func (r *ttlRecorder) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	// Answers over TCP are read in pieces: their TTL goes unknown
+	if strings.HasPrefix(network, "tcp") {
+		return conn, nil
+	}
+	return &ttlConn{Conn: conn, recorder: r}, nil
+}
+
+func (r *ttlRecorder) record(ttl time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.ttl == 0 || ttl < r.ttl {
+		r.ttl = ttl
+	}
+}
+
+func (r *ttlRecorder) shortest() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.ttl
+}
+
+// ttlConn is a connection to a DNS server over UDP, each read of which is a whole answer.
+type ttlConn struct {
+	net.Conn
+	recorder *ttlRecorder
+}
+
+func (c *ttlConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if ttl, ok := answerTTL(b[:n]); ok {
+		c.recorder.record(ttl)
+	}
+	return n, err
+}
+
+// answerTTL returns the shortest TTL of the addresses in a DNS answer, or false if it has none.
+func answerTTL(message []byte) (time.Duration, bool) {
+	var parser dnsmessage.Parser
+	if _, err := parser.Start(message); err != nil {
+		return 0, false
+	}
+	if err := parser.SkipAllQuestions(); err != nil {
+		return 0, false
+	}
+	var shortest time.Duration
+	found := false
+	for {
+		header, err := parser.AnswerHeader()
+		if err != nil {
+			break
+		}
+		if header.Type == dnsmessage.TypeA || header.Type == dnsmessage.TypeAAAA {
+			ttl := time.Duration(header.TTL) * time.Second
+			if !found || ttl < shortest {
+				shortest = ttl
+			}
+			found = true
+		}
+		if err := parser.SkipAnswer(); err != nil {
+			break
+		}
+	}
+	return shortest, found
+}
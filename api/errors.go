@@ -0,0 +1,132 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+var (
+	// ErrRateLimited is matched by errors returned when radio-browser asks to slow down (HTTP 429).
+	ErrRateLimited = i18n.Error("api.rateLimited")
+	// ErrMirrorUnavailable is matched by errors returned when the radio-browser server can't be reached
+	// or reports that it is unavailable (HTTP 502, 503 or 504). Another mirror may work.
+	ErrMirrorUnavailable = i18n.Error("api.mirrorUnavailable")
+	// ErrBadResponse is matched by errors returned when radio-browser answers with an unexpected
+	// status code or a body that can't be decoded.
+	ErrBadResponse = i18n.Error("api.badResponse")
+	// ErrEditsUnsupported is returned when the radio-browser server doesn't take edit suggestions.
+	ErrEditsUnsupported = i18n.Error("api.editsUnsupported")
+	// ErrInvalidBaseURL is returned when the configured radio-browser server isn't an http or https URL.
+	ErrInvalidBaseURL = i18n.Error("api.invalidBaseURL")
+)
+
+// How much of a response body is kept in an Error.
+const bodySnippetLength = 200
+
+// Error is the error returned by RadioBrowserService requests.
+// Use errors.Is with ErrRateLimited, ErrMirrorUnavailable or ErrBadResponse to tell them apart,
+// and errors.As to read the details.
+type Error struct {
+	// Kind is one of ErrRateLimited, ErrMirrorUnavailable or ErrBadResponse.
+	Kind error
+	// StatusCode is the HTTP status code of the response, or 0 if there was none.
+	StatusCode int
+	// Body is the beginning of the response body, if any.
+	Body string
+	// RetryAfter is how long radio-browser asked to wait before retrying, or 0 if it didn't say.
+	RetryAfter time.Duration
+	// Err is the underlying error, if any (e.g. a network or decoding error).
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := e.Kind.Error()
+	if e.Kind == ErrRateLimited && e.RetryAfter > 0 {
+		msg = i18n.Tf("api.rateLimited.retryAfter", int(math.Ceil(e.RetryAfter.Seconds())))
+	}
+	if e.StatusCode != 0 && e.Kind != ErrRateLimited {
+		msg += fmt.Sprintf(" (HTTP %d)", e.StatusCode)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	} else if e.Kind == ErrBadResponse && e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// Is makes errors.Is match the Kind of the error.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newResponseError classifies a response with an unsuccessful status code.
+func newResponseError(response *http.Response, body []byte) *Error {
+	err := &Error{
+		Kind:       ErrBadResponse,
+		StatusCode: response.StatusCode,
+		Body:       bodySnippet(body),
+	}
+	switch response.StatusCode {
+	case http.StatusTooManyRequests:
+		err.Kind = ErrRateLimited
+		err.RetryAfter = parseRetryAfter(response.Header.Get("Retry-After"))
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		err.Kind = ErrMirrorUnavailable
+	}
+	return err
+}
+
+// bodySnippet returns the beginning of body as a single line of valid UTF-8.
+func bodySnippet(body []byte) string {
+	if len(body) > bodySnippetLength {
+		body = body[:bodySnippetLength]
+		for len(body) > 0 && !utf8.Valid(body) {
+			body = body[:len(body)-1]
+		}
+	}
+	return strings.Join(strings.Fields(string(body)), " ")
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
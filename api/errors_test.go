@@ -0,0 +1,142 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBrowserRespondingWith(t *testing.T, response *http.Response, err error) RadioBrowserService {
+	mockDNSLookupService := mocks.MockDNSLookupService{
+		LookupIPFunc: func(host string) ([]string, error) {
+			return []string{"127.0.0.1"}, nil
+		},
+	}
+	mockHttpClient := mocks.MockHttpClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return response, err
+		},
+	}
+	browser, newErr := NewRadioBrowserWithDependencies(&mockDNSLookupService, &mockHttpClient)
+	assert.NoError(t, newErr)
+	return browser
+}
+
+func newResponse(statusCode int, body string, header http.Header) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+	}
+}
+
+func TestBrowserImplRateLimited(t *testing.T) {
+
+	browser := newBrowserRespondingWith(t, newResponse(429, "slow down", http.Header{"Retry-After": []string{"30"}}), nil)
+
+	_, err := browser.GetStationChecks(uuid.Nil)
+
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.False(t, errors.Is(err, ErrBadResponse))
+
+	var apiErr *Error
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 429, apiErr.StatusCode)
+	assert.Equal(t, 30*time.Second, apiErr.RetryAfter)
+	assert.Equal(t, "slow down", apiErr.Body)
+	assert.Contains(t, apiErr.Error(), "30 seconds")
+
+}
+
+func TestBrowserImplMirrorUnavailable(t *testing.T) {
+
+	for _, statusCode := range []int{502, 503, 504} {
+		browser := newBrowserRespondingWith(t, newResponse(statusCode, "", nil), nil)
+
+		_, err := browser.GetStationChecks(uuid.Nil)
+
+		assert.ErrorIs(t, err, ErrMirrorUnavailable)
+	}
+
+}
+
+func TestBrowserImplTransportErrorIsMirrorUnavailable(t *testing.T) {
+
+	transportErr := errors.New("connection refused")
+	browser := newBrowserRespondingWith(t, nil, transportErr)
+
+	_, err := browser.GetStationChecks(uuid.Nil)
+
+	assert.ErrorIs(t, err, ErrMirrorUnavailable)
+	assert.ErrorIs(t, err, transportErr)
+
+}
+
+func TestBrowserImplBadResponse(t *testing.T) {
+
+	body := "<html>" + strings.Repeat("x", 500) + "</html>"
+	browser := newBrowserRespondingWith(t, newResponse(500, body, nil), nil)
+
+	_, err := browser.GetStationChecks(uuid.Nil)
+
+	assert.ErrorIs(t, err, ErrBadResponse)
+
+	var apiErr *Error
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 500, apiErr.StatusCode)
+	assert.Len(t, apiErr.Body, bodySnippetLength)
+	assert.Contains(t, apiErr.Error(), "HTTP 500")
+
+}
+
+func TestBrowserImplUndecodableBodyIsBadResponse(t *testing.T) {
+
+	browser := newBrowserRespondingWith(t, newResponse(200, "not json", nil), nil)
+
+	_, err := browser.GetStationChecks(uuid.Nil)
+
+	assert.ErrorIs(t, err, ErrBadResponse)
+
+	var apiErr *Error
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 200, apiErr.StatusCode)
+	assert.Equal(t, "not json", apiErr.Body)
+
+}
+
+func TestParseRetryAfter(t *testing.T) {
+
+	assert.Equal(t, 5*time.Second, parseRetryAfter("5"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-1"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("Mon, 02 Jan 2006 15:04:05 GMT"))
+
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// How much of a response body is kept for inspection.
+const maxInspectedBodyLength = 64 << 10
+
+// Exchange is a request sent to radio-browser and the response it got, as kept for inspection.
+type Exchange struct {
+	Method        string
+	URL           string
+	RequestHeader http.Header
+	RequestBody   string
+	Sent          time.Time
+	// Duration is how long the response took to start arriving.
+	Duration time.Duration
+	// Status and StatusCode are empty if no response was received, as told by Err.
+	Status         string
+	StatusCode     int
+	ResponseHeader http.Header
+	// Body is the beginning of the response body, as much of it as was read so far.
+	Body []byte
+	// Truncated is true if the body was longer than what's kept of it.
+	Truncated bool
+	Err       error
+}
+
+// Inspector is implemented by a RadioBrowserService that can keep its last request and response,
+// for developers and bug reports.
+type Inspector interface {
+	// SetInspecting keeps the last request and response from now on, or stops keeping them.
+	SetInspecting(enabled bool)
+	// LastExchange returns the last request sent and its response, or false if none was kept.
+	LastExchange() (Exchange, bool)
+}
+
+// inspector keeps the last exchange with radio-browser. Its body keeps filling up as the response is read.
+type inspector struct {
+	mu   sync.Mutex
+	last *Exchange
+}
+
+// SetInspecting keeps the last request sent to radio-browser and its response from now on, or stops keeping them.
+func (radioBrowser *RadioBrowserImpl) SetInspecting(enabled bool) {
+	if enabled && radioBrowser.inspector == nil {
+		radioBrowser.inspector = &inspector{}
+	} else if !enabled {
+		radioBrowser.inspector = nil
+	}
+}
+
+// LastExchange returns the last request sent to radio-browser and its response, if inspecting.
+func (radioBrowser *RadioBrowserImpl) LastExchange() (Exchange, bool) {
+	return radioBrowser.inspector.lastExchange()
+}
+
+func (i *inspector) lastExchange() (Exchange, bool) {
+	if i == nil {
+		return Exchange{}, false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.last == nil {
+		return Exchange{}, false
+	}
+	exchange := *i.last
+	exchange.Body = append([]byte(nil), i.last.Body...)
+	return exchange, true
+}
+
+// sent starts keeping the request, whose body is read here and replaced. Returns nil if not inspecting.
+func (i *inspector) sent(req *http.Request) *Exchange {
+	if i == nil {
+		return nil
+	}
+	exchange := &Exchange{
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		RequestHeader: req.Header.Clone(),
+		Sent:          time.Now(),
+	}
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		exchange.RequestBody = string(body)
+	}
+	i.mu.Lock()
+	i.last = exchange
+	i.mu.Unlock()
+	return exchange
+}
+
+// received keeps the response to exchange, or the error it got instead, returning the body to read the response
+// through so that it's kept as it's read.
+func (i *inspector) received(exchange *Exchange, result *http.Response, err error) io.ReadCloser {
+	if exchange == nil {
+		if result == nil {
+			return nil
+		}
+		return result.Body
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	exchange.Duration = time.Since(exchange.Sent)
+	if err != nil {
+		exchange.Err = err
+		return nil
+	}
+	exchange.Status = result.Status
+	exchange.StatusCode = result.StatusCode
+	exchange.ResponseHeader = result.Header.Clone()
+	return &inspectedBody{ReadCloser: result.Body, inspector: i, exchange: exchange}
+}
+
+// inspectedBody keeps the beginning of a response body as it's read.
+type inspectedBody struct {
+	io.ReadCloser
+	inspector *inspector
+	exchange  *Exchange
+}
+
+func (b *inspectedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.inspector.mu.Lock()
+	if room := maxInspectedBodyLength - len(b.exchange.Body); room < n {
+		b.exchange.Body = append(b.exchange.Body, p[:room]...)
+		b.exchange.Truncated = true
+	} else {
+		b.exchange.Body = append(b.exchange.Body, p[:n]...)
+	}
+	b.inspector.mu.Unlock()
+	return n, err
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRadioBrowserImplInspector(t *testing.T) {
+
+	baseURL, _ := url.Parse("http://radio.example.com/json")
+
+	newBrowser := func(respond func(req *http.Request) (*http.Response, error)) *RadioBrowserImpl {
+		browser := NewRadioBrowserWithBaseURL(*baseURL, &mocks.MockHttpClient{DoFunc: respond}).(*RadioBrowserImpl)
+		browser.SetInspecting(true)
+		return browser
+	}
+
+	t.Run("keeps nothing unless inspecting", func(t *testing.T) {
+
+		browser := newBrowser(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`[]`))}, nil
+		})
+		browser.SetInspecting(false)
+
+		_, err := browser.GetStations(common.StationQueryAll, "", "votes", true, 0, 10, false)
+		assert.NoError(t, err)
+
+		_, ok := browser.LastExchange()
+		assert.False(t, ok)
+
+	})
+
+	t.Run("keeps the last request and its response, as read", func(t *testing.T) {
+
+		browser := newBrowser(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				Status:     "200 OK",
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`[{"name":"Jazz FM"}]`)),
+			}, nil
+		})
+
+		_, err := browser.GetStations(common.StationQueryByName, "jazz", "votes", true, 0, 10, false)
+		assert.NoError(t, err)
+
+		exchange, ok := browser.LastExchange()
+		assert.True(t, ok)
+		assert.Equal(t, "GET", exchange.Method)
+		assert.Contains(t, exchange.URL, "http://radio.example.com/json/stations/byname/jazz")
+		assert.Equal(t, "application/json", exchange.RequestHeader.Get("Accept"))
+		assert.Equal(t, "200 OK", exchange.Status)
+		assert.Equal(t, "application/json", exchange.ResponseHeader.Get("Content-Type"))
+		assert.Equal(t, `[{"name":"Jazz FM"}]`, string(exchange.Body))
+		assert.False(t, exchange.Truncated)
+
+	})
+
+	t.Run("truncates long bodies", func(t *testing.T) {
+
+		body := "[" + strings.Repeat(`{"name":"Station"},`, maxInspectedBodyLength/10) + `{"name":"Last"}]`
+		browser := newBrowser(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+		})
+
+		_, err := browser.GetStations(common.StationQueryAll, "", "votes", true, 0, 10, false)
+		assert.NoError(t, err)
+
+		exchange, _ := browser.LastExchange()
+		assert.Equal(t, body[:maxInspectedBodyLength], string(exchange.Body))
+		assert.True(t, exchange.Truncated)
+
+	})
+
+	t.Run("keeps the request body and the error of failed requests", func(t *testing.T) {
+
+		browser := newBrowser(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		})
+
+		_, err := browser.SuggestStationEdit(uuid.New(), common.StationEdit{Name: "Jazz FM"})
+		assert.Error(t, err)
+
+		exchange, ok := browser.LastExchange()
+		assert.True(t, ok)
+		assert.Equal(t, "POST", exchange.Method)
+		assert.Contains(t, exchange.RequestBody, "name=Jazz+FM")
+		assert.EqualError(t, exchange.Err, "connection refused")
+		assert.Equal(t, 0, exchange.StatusCode)
+
+	})
+
+}
@@ -0,0 +1,417 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api/apitest"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/data"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// newIntegrationBrowser returns a RadioBrowserImpl talking to the fake server, through the given rate limiter.
+func newIntegrationBrowser(server *apitest.Server, limiter *RateLimiter) *RadioBrowserImpl {
+	return &RadioBrowserImpl{
+		httpClient: NewRateLimitedHTTPClient(http.DefaultClient, limiter),
+		mirrors:    newMirrorPool([]url.URL{server.BaseURL()}),
+	}
+}
+
+func stationNames(stations []common.Station) []string {
+	names := make([]string, len(stations))
+	for i, station := range stations {
+		names[i] = station.Name
+	}
+	return names
+}
+
+func TestIntegrationGetStations(t *testing.T) {
+
+	server := apitest.NewServer()
+	defer server.Close()
+	browser := newIntegrationBrowser(server, nil)
+
+	t.Run("returns every station", func(t *testing.T) {
+
+		stations, err := browser.GetStations(common.StationQueryAll, "", "name", false, 0, 100, false)
+
+		assert.NoError(t, err)
+		assert.Len(t, stations, len(server.Stations()))
+		assert.Equal(t, "BBC World Service", stations[0].Name)
+		assert.Equal(t, "http://stream.bestfm.sk/128.mp3", stations[1].Url.URL.String())
+
+	})
+
+	t.Run("searches by name, tag and country code", func(t *testing.T) {
+
+		stations, err := browser.GetStations(common.StationQueryByName, "jazz", "votes", true, 0, 100, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Radio Swiss Jazz", "Jazz FM", "Radio Italia Jazz"}, stationNames(stations))
+
+		stations, err = browser.GetStations(common.StationQueryByTagExact, "news", "name", false, 0, 100, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"BBC World Service", "France Info"}, stationNames(stations))
+
+		stations, err = browser.GetStations(common.StationQueryByCountryCodeExact, "gb", "name", false, 0, 100, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"BBC World Service", "Jazz FM"}, stationNames(stations))
+
+	})
+
+	t.Run("finds a station by UUID", func(t *testing.T) {
+
+		stations, err := browser.GetStations(common.StationQueryByUuid, "96202f73-0601-11e8-ae97-52543be04c81", "votes", false, 0, 1, false)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Jazz FM"}, stationNames(stations))
+		assert.Equal(t, uint64(1520), stations[0].Votes)
+
+	})
+
+	t.Run("decodes HLS stations", func(t *testing.T) {
+
+		stations, err := browser.GetStations(common.StationQueryByNameExact, "BBC World Service", "name", false, 0, 1, false)
+
+		assert.NoError(t, err)
+		assert.Len(t, stations, 1)
+		assert.True(t, bool(stations[0].Hls))
+
+	})
+
+	t.Run("hides broken stations", func(t *testing.T) {
+
+		stations, err := browser.GetStations(common.StationQueryByTag, "rock", "name", false, 0, 100, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Dead Air Radio"}, stationNames(stations))
+
+		stations, err = browser.GetStations(common.StationQueryByTag, "rock", "name", false, 0, 100, true)
+		assert.NoError(t, err)
+		assert.Empty(t, stations)
+
+	})
+
+	t.Run("returns an empty list when nothing matches", func(t *testing.T) {
+
+		stations, err := browser.GetStations(common.StationQueryByName, "nothing like this", "name", false, 0, 100, false)
+
+		assert.NoError(t, err)
+		assert.Empty(t, stations)
+
+	})
+
+}
+
+func TestIntegrationPagination(t *testing.T) {
+
+	server := apitest.NewServer()
+	defer server.Close()
+	browser := newIntegrationBrowser(server, nil)
+
+	all, err := browser.GetStations(common.StationQueryAll, "", "clickcount", true, 0, 100, false)
+	assert.NoError(t, err)
+
+	var paged []common.Station
+	for offset := uint64(0); ; offset += 3 {
+		page, err := browser.GetStations(common.StationQueryAll, "", "clickcount", true, offset, 3, false)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(page), 3)
+		paged = append(paged, page...)
+		if len(page) < 3 {
+			break
+		}
+	}
+
+	assert.Equal(t, stationNames(all), stationNames(paged))
+	assert.Equal(t, "BBC World Service", all[0].Name)
+
+	requests := server.Requests()
+	last := requests[len(requests)-1]
+	assert.Equal(t, "/json/stations", last.Path)
+	assert.Equal(t, "6", last.Query.Get("offset"))
+	assert.Equal(t, "3", last.Query.Get("limit"))
+	assert.Equal(t, "true", last.Query.Get("reverse"))
+
+}
+
+func TestIntegrationSearchStations(t *testing.T) {
+
+	server := apitest.NewServer()
+	defer server.Close()
+	browser := newIntegrationBrowser(server, nil)
+
+	stations, err := browser.SearchStations("jazz", common.StationFilter{CountryCode: "CH"}, "name", false, 0, 100, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Radio Swiss Jazz"}, stationNames(stations))
+
+	stations, err = browser.SearchStations("radio", common.StationFilter{Language: "italian"}, "name", false, 0, 100, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Radio Italia Jazz", "Radio Swiss Jazz"}, stationNames(stations))
+
+}
+
+func TestIntegrationGetStationsByUrl(t *testing.T) {
+
+	server := apitest.NewServer()
+	defer server.Close()
+	browser := newIntegrationBrowser(server, nil)
+
+	stations, err := browser.GetStationsByUrl("http://jazzfm.example.com/live.mp3")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Jazz FM"}, stationNames(stations))
+
+	stations, err = browser.GetStationsByUrl("http://jazzfm.example.com/other.mp3")
+	assert.NoError(t, err)
+	assert.Empty(t, stations)
+
+}
+
+func TestIntegrationGetStationChecks(t *testing.T) {
+
+	server := apitest.NewServer()
+	defer server.Close()
+	browser := newIntegrationBrowser(server, nil)
+
+	checks, err := browser.GetStationChecks(uuid.MustParse("96202f73-0601-11e8-ae97-52543be04c81"))
+
+	assert.NoError(t, err)
+	assert.Len(t, checks, 2)
+	assert.True(t, bool(checks[0].Ok))
+	assert.Equal(t, "Icecast 2.4.4", checks[0].ServerSoftware)
+	assert.Equal(t, time.Date(2023, 10, 17, 8, 46, 57, 0, time.UTC), checks[0].Timestamp)
+
+}
+
+func TestIntegrationClickStation(t *testing.T) {
+
+	server := apitest.NewServer()
+	defer server.Close()
+	browser := newIntegrationBrowser(server, nil)
+
+	station := common.Station{StationUuid: uuid.MustParse("96202f73-0601-11e8-ae97-52543be04c81")}
+
+	response, err := browser.ClickStation(station)
+
+	assert.NoError(t, err)
+	assert.True(t, response.Ok)
+	assert.Equal(t, "Jazz FM", response.Name)
+	assert.Equal(t, "http://jazzfm.example.com/live.mp3", response.Url.URL.String())
+	assert.Equal(t, http.MethodPost, server.Requests()[0].Method)
+
+	stations, _ := browser.GetStations(common.StationQueryByUuid, station.StationUuid.String(), "votes", false, 0, 1, false)
+	assert.Equal(t, uint64(311), stations[0].ClickCount)
+
+	response, err = browser.ClickStation(common.Station{StationUuid: uuid.New()})
+	assert.NoError(t, err)
+	assert.False(t, response.Ok)
+
+}
+
+func TestIntegrationGetTags(t *testing.T) {
+
+	server := apitest.NewServer()
+	defer server.Close()
+	browser := newIntegrationBrowser(server, nil)
+
+	tags, err := browser.GetTags("", "stationcount", true, 0, 2, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Tag{{Name: "jazz", StationCount: 3}, {Name: "news", StationCount: 2}}, tags)
+
+	tags, err = browser.GetTags("jazz", "name", false, 0, 100, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Tag{{Name: "jazz", StationCount: 3}, {Name: "smooth jazz", StationCount: 1}}, tags)
+
+}
+
+func TestIntegrationGetCountries(t *testing.T) {
+
+	server := apitest.NewServer()
+	defer server.Close()
+	browser := newIntegrationBrowser(server, nil)
+
+	countries, err := browser.GetCountries(true)
+	assert.NoError(t, err)
+	assert.Len(t, countries, 5)
+	assert.Equal(t, common.Country{Name: "France", Code: "FR", StationCount: 1}, countries[0])
+	assert.Equal(t, common.Country{Name: "The United Kingdom Of Great Britain And Northern Ireland", Code: "GB", StationCount: 2}, countries[4])
+
+}
+
+func TestIntegrationGetTopStations(t *testing.T) {
+
+	server := apitest.NewServer()
+	defer server.Close()
+	browser := newIntegrationBrowser(server, nil)
+
+	t.Run("ranks stations worldwide", func(t *testing.T) {
+
+		stations, err := browser.GetTopStations(common.StationChartTopVote, "", 2, true)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"BBC World Service", "Radio Swiss Jazz"}, stationNames(stations))
+		requests := server.Requests()
+		assert.Equal(t, "/json/stations/topvote/2", requests[len(requests)-1].Path)
+
+	})
+
+	t.Run("ranks the stations of a country", func(t *testing.T) {
+
+		stations, err := browser.GetTopStations(common.StationChartTopClick, "gb", 10, true)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"BBC World Service", "Jazz FM"}, stationNames(stations))
+		requests := server.Requests()
+		request := requests[len(requests)-1]
+		assert.Equal(t, "/json/stations/search", request.Path)
+		assert.Equal(t, "clickcount", request.Query.Get("order"))
+
+	})
+
+}
+
+func TestIntegrationErrors(t *testing.T) {
+
+	server := apitest.NewServer()
+	defer server.Close()
+	browser := newIntegrationBrowser(server, nil)
+
+	t.Run("reports an unavailable mirror", func(t *testing.T) {
+
+		server.Respond(apitest.Response{StatusCode: http.StatusServiceUnavailable, Body: "<html>Service Unavailable</html>"})
+
+		_, err := browser.GetStations(common.StationQueryAll, "", "name", false, 0, 10, false)
+
+		assert.ErrorIs(t, err, ErrMirrorUnavailable)
+		var apiErr *Error
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+
+	})
+
+	t.Run("reports an undecodable response", func(t *testing.T) {
+
+		server.Respond(apitest.Response{StatusCode: http.StatusOK, Body: `{"stations": `})
+
+		_, err := browser.GetTags("", "name", false, 0, 10, false)
+
+		assert.ErrorIs(t, err, ErrBadResponse)
+
+	})
+
+	t.Run("reports an unexpected status code", func(t *testing.T) {
+
+		_, err := browser.GetStations(common.StationQuery("bynothing"), "jazz", "name", false, 0, 10, false)
+
+		assert.ErrorIs(t, err, ErrBadResponse)
+		var apiErr *Error
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+
+	})
+
+	t.Run("reports a mirror that can't be reached", func(t *testing.T) {
+
+		closed := apitest.NewServer()
+		closedBrowser := newIntegrationBrowser(closed, nil)
+		closed.Close()
+
+		_, err := closedBrowser.GetStations(common.StationQueryAll, "", "name", false, 0, 10, false)
+
+		assert.ErrorIs(t, err, ErrMirrorUnavailable)
+
+	})
+
+	t.Run("recovers once the server answers again", func(t *testing.T) {
+
+		server.Respond(apitest.Response{StatusCode: http.StatusBadGateway})
+
+		_, err := browser.GetStationsByUrl("http://jazzfm.example.com/live.mp3")
+		assert.Error(t, err)
+
+		stations, err := browser.GetStationsByUrl("http://jazzfm.example.com/live.mp3")
+		assert.NoError(t, err)
+		assert.Len(t, stations, 1)
+
+	})
+
+}
+
+func TestIntegrationRateLimits(t *testing.T) {
+
+	t.Run("reports when the server asks to slow down", func(t *testing.T) {
+
+		server := apitest.NewServer()
+		defer server.Close()
+		browser := newIntegrationBrowser(server, nil)
+
+		server.Respond(apitest.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{"30"}},
+			Body:       "Too Many Requests",
+		})
+
+		_, err := browser.GetStations(common.StationQueryAll, "", "name", false, 0, 10, false)
+
+		assert.ErrorIs(t, err, ErrRateLimited)
+		var apiErr *Error
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, 30*time.Second, apiErr.RetryAfter)
+
+	})
+
+	t.Run("spaces requests out with a rate limiter", func(t *testing.T) {
+
+		server := apitest.NewServer()
+		defer server.Close()
+		// Bursts of 10 requests, then one every 100ms
+		browser := newIntegrationBrowser(server, NewRateLimiter(10))
+
+		for i := 0; i < 12; i++ {
+			_, err := browser.GetTags("", "name", false, 0, 1, false)
+			assert.NoError(t, err)
+		}
+
+		requests := server.Requests()
+		assert.Len(t, requests, 12)
+		assert.GreaterOrEqual(t, requests[11].Time.Sub(requests[0].Time), 150*time.Millisecond)
+
+	})
+
+}
+
+func TestIntegrationHeaders(t *testing.T) {
+
+	server := apitest.NewServer()
+	defer server.Close()
+	browser := newIntegrationBrowser(server, nil)
+
+	_, err := browser.GetStations(common.StationQueryAll, "", "name", false, 0, 1, false)
+	assert.NoError(t, err)
+
+	request := server.Requests()[0]
+	assert.Equal(t, data.UserAgent, request.Header.Get("User-Agent"))
+	assert.Equal(t, "application/json", request.Header.Get("Accept"))
+
+}
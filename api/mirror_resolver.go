@@ -0,0 +1,151 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// The host whose addresses are the radio-browser mirrors.
+const mirrorsHost = "all.api.radio-browser.info"
+
+const (
+	// How long the mirrors looked up are kept when the DNS answer doesn't tell.
+	defaultMirrorTTL = 10 * time.Minute
+	// The TTLs of the DNS answers are kept within these bounds, so that the mirrors are neither
+	// looked up at every request nor kept for days.
+	minMirrorTTL = time.Minute
+	maxMirrorTTL = time.Hour
+)
+
+// mirrorResolver looks the radio-browser mirrors up again once their addresses expire, as told by the TTL
+// of the DNS answer, so that a long session doesn't keep sending requests to stale addresses.
+// They're looked up sooner when every mirror is failing, though no more than once every minMirrorTTL.
+// Lookups happen in the background, the requests carrying on with the mirrors known meanwhile.
+// It is safe for concurrent use.
+type mirrorResolver struct {
+	lookup DNSLookupService
+	pool   *mirrorPool
+	// logf reports the mirrors changing (nil doesn't).
+	logf func(format string, args ...interface{})
+	now  func() time.Time
+
+	mutex     sync.Mutex
+	expires   time.Time
+	lookedUp  time.Time
+	resolving bool
+}
+
+func newMirrorResolver(lookup DNSLookupService, pool *mirrorPool, ttl time.Duration) *mirrorResolver {
+	r := &mirrorResolver{lookup: lookup, pool: pool, now: time.Now}
+	r.lookedUp = r.now()
+	r.expires = r.lookedUp.Add(boundedMirrorTTL(ttl))
+	return r
+}
+
+// boundedMirrorTTL returns how long mirrors looked up with the given TTL are kept.
+func boundedMirrorTTL(ttl time.Duration) time.Duration {
+	switch {
+	case ttl == 0:
+		return defaultMirrorTTL
+	case ttl < minMirrorTTL:
+		return minMirrorTTL
+	case ttl > maxMirrorTTL:
+		return maxMirrorTTL
+	}
+	return ttl
+}
+
+// lookupMirrors looks the mirrors up, returning their base URLs and how long they may be kept (0 if unknown).
+func lookupMirrors(lookup DNSLookupService) ([]url.URL, time.Duration, error) {
+	var ips []string
+	var ttl time.Duration
+	var err error
+	if ttlLookup, ok := lookup.(TTLLookupService); ok {
+		ips, ttl, err = ttlLookup.LookupIPTTL(mirrorsHost)
+	} else {
+		ips, err = lookup.LookupIP(mirrorsHost)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(ips) == 0 {
+		return nil, 0, &net.DNSError{Err: "no addresses found", Name: mirrorsHost, IsNotFound: true}
+	}
+
+	baseUrls := make([]url.URL, len(ips))
+	for i, ip := range ips {
+		if net.ParseIP(ip).To4() == nil {
+			ip = "[" + ip + "]"
+		}
+		url, err := url.Parse("http://" + ip + "/json")
+		if err != nil {
+			return nil, 0, err
+		}
+		baseUrls[i] = *url
+	}
+	return baseUrls, ttl, nil
+}
+
+// refreshIfStale looks the mirrors up again in the background if they expired, or if they're all failing.
+// A nil resolver does nothing.
+func (r *mirrorResolver) refreshIfStale() {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := r.now()
+	stale := now.After(r.expires) || (now.Sub(r.lookedUp) >= minMirrorTTL && r.pool.failing())
+	if !stale || r.resolving {
+		return
+	}
+	r.resolving = true
+	go r.refresh()
+}
+
+// refresh looks the mirrors up again, keeping the ones known until the next try if that fails.
+func (r *mirrorResolver) refresh() {
+	baseUrls, ttl, err := lookupMirrors(r.lookup)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.resolving = false
+	r.lookedUp = r.now()
+	if err != nil {
+		r.expires = r.lookedUp.Add(minMirrorTTL)
+		r.log("radio-browser mirrors: looking them up again failed, keeping the known ones: %v", err)
+		return
+	}
+	r.expires = r.lookedUp.Add(boundedMirrorTTL(ttl))
+	if added, removed := r.pool.replace(baseUrls); added > 0 || removed > 0 {
+		r.log("radio-browser mirrors: looked up again, %d added and %d removed", added, removed)
+	}
+}
+
+func (r *mirrorResolver) log(format string, args ...interface{}) {
+	if r.logf != nil {
+		r.logf(format, args...)
+	}
+}
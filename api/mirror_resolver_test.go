@@ -0,0 +1,218 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/eventlog"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// newTestMirrorResolver returns a resolver whose lookups answer with the addresses and TTL in answer,
+// with the clock at *now.
+func newTestMirrorResolver(pool *mirrorPool, now *time.Time, answer func() ([]string, time.Duration, error)) *mirrorResolver {
+	resolver := newMirrorResolver(&mocks.MockDNSLookupService{
+		LookupIPTTLFunc: func(host string) ([]string, time.Duration, error) {
+			return answer()
+		},
+	}, pool, 5*time.Minute)
+	resolver.now = func() time.Time { return *now }
+	resolver.lookedUp = *now
+	resolver.expires = now.Add(5 * time.Minute)
+	return resolver
+}
+
+// waitForRefresh waits until the lookup started by refreshIfStale, if any, is over.
+func waitForRefresh(resolver *mirrorResolver) {
+	for {
+		resolver.mutex.Lock()
+		resolving := resolver.resolving
+		resolver.mutex.Unlock()
+		if !resolving {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMirrorResolver(t *testing.T) {
+
+	t.Run("looks the mirrors up again once their TTL expires", func(t *testing.T) {
+
+		now := time.Now()
+		pool := newTestMirrorPool("10.0.0.1")
+		var mutex sync.Mutex
+		lookups := 0
+		resolver := newTestMirrorResolver(pool, &now, func() ([]string, time.Duration, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			lookups++
+			return []string{"10.0.0.2"}, 30 * time.Minute, nil
+		})
+
+		now = now.Add(4 * time.Minute)
+		resolver.refreshIfStale()
+		waitForRefresh(resolver)
+		assert.Equal(t, "10.0.0.1", pool.pick().Host)
+
+		now = now.Add(2 * time.Minute)
+		resolver.refreshIfStale()
+		waitForRefresh(resolver)
+		assert.Equal(t, "10.0.0.2", pool.pick().Host)
+		assert.Equal(t, now.Add(30*time.Minute), resolver.expires)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		assert.Equal(t, 1, lookups)
+
+	})
+
+	t.Run("looks the mirrors up sooner when they all fail", func(t *testing.T) {
+
+		now := time.Now()
+		pool := newTestMirrorPool("10.0.0.1")
+		resolver := newTestMirrorResolver(pool, &now, func() ([]string, time.Duration, error) {
+			return []string{"10.0.0.2"}, 0, nil
+		})
+		pool.record("10.0.0.1", 0, true)
+
+		// Not right after looking them up, though
+		resolver.refreshIfStale()
+		waitForRefresh(resolver)
+		assert.Equal(t, "10.0.0.1", pool.pick().Host)
+
+		now = now.Add(minMirrorTTL)
+		resolver.refreshIfStale()
+		waitForRefresh(resolver)
+		assert.Equal(t, "10.0.0.2", pool.pick().Host)
+		assert.Equal(t, now.Add(defaultMirrorTTL), resolver.expires)
+
+	})
+
+	t.Run("keeps the mirrors known when looking them up fails", func(t *testing.T) {
+
+		now := time.Now()
+		pool := newTestMirrorPool("10.0.0.1")
+		resolver := newTestMirrorResolver(pool, &now, func() ([]string, time.Duration, error) {
+			return nil, 0, errors.New("no network")
+		})
+		var logged []string
+		resolver.logf = func(format string, args ...interface{}) {
+			logged = append(logged, format)
+		}
+
+		now = now.Add(10 * time.Minute)
+		resolver.refresh()
+
+		assert.Equal(t, "10.0.0.1", pool.pick().Host)
+		assert.Equal(t, now.Add(minMirrorTTL), resolver.expires)
+		assert.Len(t, logged, 1)
+
+	})
+
+	t.Run("does nothing when nil", func(t *testing.T) {
+		var resolver *mirrorResolver
+		resolver.refreshIfStale()
+	})
+
+	t.Run("bounds the TTL of the DNS answers", func(t *testing.T) {
+		assert.Equal(t, defaultMirrorTTL, boundedMirrorTTL(0))
+		assert.Equal(t, minMirrorTTL, boundedMirrorTTL(5*time.Second))
+		assert.Equal(t, 20*time.Minute, boundedMirrorTTL(20*time.Minute))
+		assert.Equal(t, maxMirrorTTL, boundedMirrorTTL(48*time.Hour))
+	})
+
+}
+
+func TestMirrorResolverEventLog(t *testing.T) {
+
+	lookups := 0
+	var mutex sync.Mutex
+	browser, err := NewRadioBrowserWithDependencies(&mocks.MockDNSLookupService{
+		LookupIPTTLFunc: func(host string) ([]string, time.Duration, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			lookups++
+			return []string{fmt.Sprintf("10.0.0.%d", lookups)}, 0, nil
+		},
+	}, &mocks.MockHttpClient{})
+	assert.NoError(t, err)
+	radioBrowser := browser.(*RadioBrowserImpl)
+	now := time.Now()
+	radioBrowser.resolver.mutex.Lock()
+	radioBrowser.resolver.now = func() time.Time { return now }
+	radioBrowser.resolver.expires = now.Add(-time.Second)
+	radioBrowser.resolver.mutex.Unlock()
+
+	path := filepath.Join(t.TempDir(), "radiogogo.log")
+	// The event log can be set while the mirrors are looked up again
+	radioBrowser.resolver.refreshIfStale()
+	radioBrowser.SetEventLog(eventlog.New(path))
+	waitForRefresh(radioBrowser.resolver)
+	radioBrowser.resolver.mutex.Lock()
+	radioBrowser.resolver.expires = now.Add(-time.Second)
+	radioBrowser.resolver.mutex.Unlock()
+	radioBrowser.resolver.refreshIfStale()
+	waitForRefresh(radioBrowser.resolver)
+
+	logged, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(logged), "radio-browser mirrors: looked up again, 1 added and 1 removed")
+
+}
+
+func TestAnswerTTL(t *testing.T) {
+
+	answer := func(ttls ...uint32) []byte {
+		builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true})
+		assert.NoError(t, builder.StartQuestions())
+		name := dnsmessage.MustNewName(mirrorsHost + ".")
+		assert.NoError(t, builder.Question(dnsmessage.Question{Name: name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}))
+		assert.NoError(t, builder.StartAnswers())
+		for i, ttl := range ttls {
+			header := dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: ttl}
+			assert.NoError(t, builder.AResource(header, dnsmessage.AResource{A: [4]byte{10, 0, 0, byte(i + 1)}}))
+		}
+		message, err := builder.Finish()
+		assert.NoError(t, err)
+		return message
+	}
+
+	ttl, ok := answerTTL(answer(600, 300))
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Minute, ttl)
+
+	_, ok = answerTTL(answer())
+	assert.False(t, ok)
+
+	_, ok = answerTTL([]byte("garbage"))
+	assert.False(t, ok)
+
+}
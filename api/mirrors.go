@@ -0,0 +1,228 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"math"
+	"math/rand"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// How much the latest request weighs in the moving averages of a mirror's latency and error rate.
+const mirrorSmoothing = 0.3
+
+// How much slower a mirror is considered for each point of error rate, so that a fast but flaky mirror
+// loses to a slower, reliable one.
+const mirrorErrorPenalty = 4
+
+// The error rate from which a mirror is failing, e.g. after failing its last two requests.
+const mirrorFailingRate = 0.5
+
+// MirrorStats describes how a radio-browser mirror has been answering.
+type MirrorStats struct {
+	// Address is the host (and port, if any) of the mirror.
+	Address string
+	// Requests is how many requests were sent to the mirror, and Errors how many of them it failed.
+	Requests int
+	Errors   int
+	// Latency is the exponential moving average of the time the mirror took to answer.
+	// It is 0 until the mirror answers a request.
+	Latency time.Duration
+	// ErrorRate is the exponential moving average of failed requests, from 0 (none) to 1 (all of them).
+	ErrorRate float64
+	// Preferred is true for the mirror the next request is sent to.
+	Preferred bool
+}
+
+// MirrorStatsProvider is implemented by a RadioBrowserService that spreads its requests across mirrors.
+type MirrorStatsProvider interface {
+	// MirrorStats returns the statistics of every known mirror, the preferred one first.
+	MirrorStats() []MirrorStats
+}
+
+// mirror is a radio-browser server and what is known about how it answers.
+type mirror struct {
+	baseUrl url.URL
+	stats   MirrorStats
+}
+
+// score returns how good a mirror is, the lower the better.
+// A mirror that never answered is tried before the others, unless it failed already.
+func (m *mirror) score() float64 {
+	switch {
+	case m.stats.Requests == 0:
+		return 0
+	case m.stats.Latency == 0:
+		return math.Inf(1)
+	}
+	return float64(m.stats.Latency) * (1 + mirrorErrorPenalty*m.stats.ErrorRate)
+}
+
+// mirrorPool picks the mirror each request is sent to: each one is tried once, in random order,
+// then the one with the best moving averages of latency and error rate is preferred.
+// It is safe for concurrent use.
+type mirrorPool struct {
+	mutex   sync.Mutex
+	mirrors []*mirror
+}
+
+func newMirrorPool(baseUrls []url.URL) *mirrorPool {
+	pool := &mirrorPool{}
+	for _, i := range rand.Perm(len(baseUrls)) {
+		pool.mirrors = append(pool.mirrors, &mirror{
+			baseUrl: baseUrls[i],
+			stats:   MirrorStats{Address: baseUrls[i].Host},
+		})
+	}
+	return pool
+}
+
+// replace makes the mirrors those at baseUrls, as looked up again: the ones still there keep their statistics,
+// and the new ones are tried first, in random order. It does nothing without any mirror.
+// It returns how many mirrors were added and removed.
+func (p *mirrorPool) replace(baseUrls []url.URL) (added int, removed int) {
+	if len(baseUrls) == 0 {
+		return 0, 0
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	kept := make(map[string]*mirror)
+	for _, m := range p.mirrors {
+		kept[m.stats.Address] = m
+	}
+	var mirrors []*mirror
+	for _, i := range rand.Perm(len(baseUrls)) {
+		address := baseUrls[i].Host
+		if m, ok := kept[address]; ok {
+			mirrors = append(mirrors, m)
+			delete(kept, address)
+			continue
+		}
+		mirrors = append(mirrors, &mirror{
+			baseUrl: baseUrls[i],
+			stats:   MirrorStats{Address: address},
+		})
+		added++
+	}
+	p.mirrors = mirrors
+	return added, len(kept)
+}
+
+// failing returns true if every mirror failed its last requests, as when the addresses looked up went stale.
+func (p *mirrorPool) failing() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, m := range p.mirrors {
+		if m.stats.Requests == 0 || m.stats.ErrorRate < mirrorFailingRate {
+			return false
+		}
+	}
+	return true
+}
+
+// best returns the preferred mirror. The pool must be locked.
+func (p *mirrorPool) best() *mirror {
+	best := p.mirrors[0]
+	for _, m := range p.mirrors[1:] {
+		if m.score() < best.score() {
+			best = m
+		}
+	}
+	return best
+}
+
+// pick returns the base URL of the mirror the next request should be sent to.
+func (p *mirrorPool) pick() *url.URL {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	baseUrl := p.best().baseUrl
+	return &baseUrl
+}
+
+// runnerUp returns the base URL of the best mirror but the one with the given address,
+// or nil if there's no other mirror.
+func (p *mirrorPool) runnerUp(address string) *url.URL {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	var runnerUp *mirror
+	for _, m := range p.mirrors {
+		if m.stats.Address != address && (runnerUp == nil || m.score() < runnerUp.score()) {
+			runnerUp = m
+		}
+	}
+	if runnerUp == nil {
+		return nil
+	}
+	baseUrl := runnerUp.baseUrl
+	return &baseUrl
+}
+
+// record updates the statistics of the mirror with the given address after a request.
+// latency is how long it took to answer, or 0 if it didn't answer at all.
+func (p *mirrorPool) record(address string, latency time.Duration, failed bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, m := range p.mirrors {
+		if m.stats.Address != address {
+			continue
+		}
+		m.stats.Requests++
+		errorSample := 0.0
+		if failed {
+			m.stats.Errors++
+			errorSample = 1
+		}
+		if m.stats.Requests == 1 {
+			m.stats.ErrorRate = errorSample
+		} else {
+			m.stats.ErrorRate += mirrorSmoothing * (errorSample - m.stats.ErrorRate)
+		}
+		if latency > 0 {
+			if m.stats.Latency == 0 {
+				m.stats.Latency = latency
+			} else {
+				m.stats.Latency += time.Duration(mirrorSmoothing * float64(latency-m.stats.Latency))
+			}
+		}
+		return
+	}
+}
+
+// stats returns the statistics of every mirror, the preferred one first and the others from best to worst.
+func (p *mirrorPool) stats() []MirrorStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	mirrors := append([]*mirror(nil), p.mirrors...)
+	sort.SliceStable(mirrors, func(i, j int) bool {
+		return mirrors[i].score() < mirrors[j].score()
+	})
+	best := p.best()
+	stats := []MirrorStats{best.stats}
+	stats[0].Preferred = true
+	for _, m := range mirrors {
+		if m != best {
+			stats = append(stats, m.stats)
+		}
+	}
+	return stats
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMirrorPool(hosts ...string) *mirrorPool {
+	return newMirrorPool(testMirrorURLs(hosts...))
+}
+
+func testMirrorURLs(hosts ...string) []url.URL {
+	baseUrls := make([]url.URL, len(hosts))
+	for i, host := range hosts {
+		baseUrls[i] = url.URL{Scheme: "http", Host: host, Path: "/json"}
+	}
+	return baseUrls
+}
+
+func TestMirrorPool(t *testing.T) {
+
+	t.Run("tries every mirror once before preferring one", func(t *testing.T) {
+
+		pool := newTestMirrorPool("10.0.0.1", "10.0.0.2", "10.0.0.3")
+
+		tried := map[string]bool{}
+		for i := 0; i < 3; i++ {
+			host := pool.pick().Host
+			tried[host] = true
+			pool.record(host, 100*time.Millisecond, false)
+		}
+
+		assert.Len(t, tried, 3)
+
+	})
+
+	t.Run("prefers the fastest mirror", func(t *testing.T) {
+
+		pool := newTestMirrorPool("10.0.0.1", "10.0.0.2", "10.0.0.3")
+		pool.record("10.0.0.1", 300*time.Millisecond, false)
+		pool.record("10.0.0.2", 50*time.Millisecond, false)
+		pool.record("10.0.0.3", 200*time.Millisecond, false)
+
+		assert.Equal(t, "10.0.0.2", pool.pick().Host)
+
+	})
+
+	t.Run("smooths the latency so that a single slow answer doesn't switch mirrors", func(t *testing.T) {
+
+		pool := newTestMirrorPool("10.0.0.1", "10.0.0.2")
+		for i := 0; i < 5; i++ {
+			pool.record("10.0.0.1", 100*time.Millisecond, false)
+			pool.record("10.0.0.2", 150*time.Millisecond, false)
+		}
+		pool.record("10.0.0.1", 250*time.Millisecond, false)
+
+		assert.Equal(t, "10.0.0.1", pool.pick().Host)
+		assert.Equal(t, 145*time.Millisecond, pool.stats()[0].Latency)
+
+	})
+
+	t.Run("avoids mirrors that fail", func(t *testing.T) {
+
+		pool := newTestMirrorPool("10.0.0.1", "10.0.0.2")
+		pool.record("10.0.0.1", 50*time.Millisecond, false)
+		pool.record("10.0.0.2", 150*time.Millisecond, false)
+		pool.record("10.0.0.1", 0, true)
+		pool.record("10.0.0.1", 0, true)
+
+		assert.Equal(t, "10.0.0.2", pool.pick().Host)
+
+		stats := pool.stats()
+		assert.Equal(t, MirrorStats{Address: "10.0.0.2", Requests: 1, Latency: 150 * time.Millisecond, Preferred: true}, stats[0])
+		assert.Equal(t, 3, stats[1].Requests)
+		assert.Equal(t, 2, stats[1].Errors)
+		assert.InDelta(t, 0.51, stats[1].ErrorRate, 0.001)
+
+	})
+
+	t.Run("never prefers a mirror that failed without answering", func(t *testing.T) {
+
+		pool := newTestMirrorPool("10.0.0.1", "10.0.0.2")
+		pool.record("10.0.0.1", 0, true)
+		pool.record("10.0.0.2", 2*time.Second, true)
+
+		assert.Equal(t, "10.0.0.2", pool.pick().Host)
+
+	})
+
+	t.Run("keeps the statistics of the mirrors still there when replaced, trying the new ones first", func(t *testing.T) {
+
+		pool := newTestMirrorPool("10.0.0.1", "10.0.0.2")
+		pool.record("10.0.0.1", 100*time.Millisecond, false)
+		pool.record("10.0.0.2", 0, true)
+
+		added, removed := pool.replace(testMirrorURLs("10.0.0.1", "10.0.0.3"))
+		assert.Equal(t, 1, added)
+		assert.Equal(t, 1, removed)
+
+		stats := pool.stats()
+		assert.Len(t, stats, 2)
+		assert.Equal(t, "10.0.0.3", stats[0].Address)
+		assert.Equal(t, "10.0.0.1", stats[1].Address)
+		assert.Equal(t, 1, stats[1].Requests)
+
+		added, removed = pool.replace(nil)
+		assert.Zero(t, added)
+		assert.Zero(t, removed)
+		assert.Len(t, pool.stats(), 2)
+
+	})
+
+	t.Run("tells when every mirror is failing", func(t *testing.T) {
+
+		pool := newTestMirrorPool("10.0.0.1", "10.0.0.2")
+		assert.False(t, pool.failing())
+
+		pool.record("10.0.0.1", 0, true)
+		assert.False(t, pool.failing())
+
+		pool.record("10.0.0.2", 0, true)
+		assert.True(t, pool.failing())
+
+		// Until it answers reliably again
+		pool.record("10.0.0.2", 100*time.Millisecond, false)
+		assert.True(t, pool.failing())
+		pool.record("10.0.0.2", 100*time.Millisecond, false)
+		assert.False(t, pool.failing())
+
+	})
+
+}
+
+func TestRadioBrowserImplRecordsMirrorStats(t *testing.T) {
+
+	mockDNSLookupService := mocks.MockDNSLookupService{
+		LookupIPFunc: func(host string) ([]string, error) {
+			return []string{"10.0.0.1", "10.0.0.2"}, nil
+		},
+	}
+
+	mockHttpClient := mocks.MockHttpClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if req.URL.Host == "10.0.0.1" {
+				return nil, errors.New("connection refused")
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader("[]")),
+			}, nil
+		},
+	}
+
+	browser, err := NewRadioBrowserWithDependencies(&mockDNSLookupService, &mockHttpClient)
+	assert.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		_, _ = browser.GetStations(common.StationQueryAll, "", "votes", false, 0, 10, false)
+	}
+
+	stats := browser.(MirrorStatsProvider).MirrorStats()
+	assert.Equal(t, "10.0.0.2", stats[0].Address)
+	assert.True(t, stats[0].Preferred)
+	assert.Equal(t, 0, stats[0].Errors)
+	assert.Equal(t, "10.0.0.1", stats[1].Address)
+	assert.Equal(t, 1, stats[1].Requests)
+	assert.Equal(t, 1, stats[1].Errors)
+
+}
+
+func TestRadioBrowserImplRacesSearches(t *testing.T) {
+
+	mockDNSLookupService := mocks.MockDNSLookupService{
+		LookupIPFunc: func(host string) ([]string, error) {
+			return []string{"10.0.0.1", "10.0.0.2"}, nil
+		},
+	}
+
+	newBrowser := func(t *testing.T, fast func(req *http.Request) (*http.Response, error)) (RadioBrowserService, chan error) {
+		slowCancelled := make(chan error, 1)
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				if req.URL.Host == "10.0.0.1" {
+					return fast(req)
+				}
+				select {
+				case <-req.Context().Done():
+					slowCancelled <- req.Context().Err()
+					return nil, req.Context().Err()
+				case <-time.After(200 * time.Millisecond):
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(`[{"name":"Slow"}]`)),
+					}, nil
+				}
+			},
+		}
+		browser, err := NewRadioBrowserWithDependencies(&mockDNSLookupService, &mockHttpClient)
+		assert.NoError(t, err)
+		browser.(SearchRacer).SetRaceSearches(true)
+		return browser, slowCancelled
+	}
+
+	t.Run("uses the first answer and cancels the other request", func(t *testing.T) {
+
+		browser, slowCancelled := newBrowser(t, func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`[{"name":"Fast"}]`)),
+			}, nil
+		})
+
+		stations, err := browser.SearchStations("jazz", common.StationFilter{}, "votes", true, 0, 10, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Fast", stations[0].Name)
+		select {
+		case err := <-slowCancelled:
+			assert.ErrorIs(t, err, context.Canceled)
+		case <-time.After(time.Second):
+			t.Fatal("the slower request wasn't cancelled")
+		}
+		for _, stats := range browser.(MirrorStatsProvider).MirrorStats() {
+			assert.Zero(t, stats.Errors, stats.Address)
+		}
+
+	})
+
+	t.Run("waits for the other mirror if the first one fails", func(t *testing.T) {
+
+		browser, _ := newBrowser(t, func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		})
+
+		stations, err := browser.GetStations(common.StationQueryAll, "", "votes", true, 0, 10, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Slow", stations[0].Name)
+
+	})
+
+	t.Run("sends searches to a single mirror unless enabled", func(t *testing.T) {
+
+		requests := 0
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				requests++
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("[]")),
+				}, nil
+			},
+		}
+		browser, err := NewRadioBrowserWithDependencies(&mockDNSLookupService, &mockHttpClient)
+		assert.NoError(t, err)
+
+		_, err = browser.GetStations(common.StationQueryAll, "", "votes", true, 0, 10, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, requests)
+
+	})
+
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"context"
+	"io"
+	"net/url"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+// SearchRacer is implemented by a RadioBrowserService that can send searches to two mirrors at once.
+type SearchRacer interface {
+	// SetRaceSearches sends each search to the two preferred mirrors at once if enabled,
+	// using the first successful answer and cancelling the other request.
+	SetRaceSearches(enabled bool)
+}
+
+// SetRaceSearches sends each search to the two preferred mirrors at once if enabled.
+// It doubles the requests searches make, so it's off unless asked for.
+func (radioBrowser *RadioBrowserImpl) SetRaceSearches(enabled bool) {
+	radioBrowser.raceSearches = enabled
+}
+
+// streamSearch sends a search to searchURL like streamRequest. If searches are raced, it's sent
+// to the runner-up mirror as well, and the stations come from whichever answers successfully first.
+func (radioBrowser *RadioBrowserImpl) streamSearch(searchURL *url.URL, yield func(station common.Station) error) error {
+
+	var rival *url.URL
+	if radioBrowser.raceSearches {
+		rival = radioBrowser.mirrors.runnerUp(searchURL.Host)
+	}
+	if rival == nil {
+		return radioBrowser.streamRequest("GET", searchURL, nil, "", yield)
+	}
+
+	rivalURL := *searchURL
+	rivalURL.Scheme = rival.Scheme
+	rivalURL.Host = rival.Host
+
+	winner, result, err := radioBrowser.race([]*url.URL{searchURL, &rivalURL})
+	if err != nil {
+		return err
+	}
+	return radioBrowser.streamResponse(winner, result, yield)
+}
+
+// race sends a GET request to every URL at once, returning the first successful response and where it came from.
+// The other requests are cancelled, and their responses discarded. It fails with the first error
+// if every request does.
+func (radioBrowser *RadioBrowserImpl) race(urls []*url.URL) (*url.URL, response, error) {
+
+	type answer struct {
+		index    int
+		response response
+		err      error
+	}
+
+	answers := make(chan answer, len(urls))
+	cancels := make([]context.CancelFunc, len(urls))
+	for i, u := range urls {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancels[i] = cancel
+		go func(i int, u *url.URL) {
+			result, err := radioBrowser.send(ctx, "GET", u, nil, "")
+			answers <- answer{index: i, response: result, err: err}
+		}(i, u)
+	}
+
+	var firstErr error
+	for pending := len(urls); pending > 0; pending-- {
+		answer := <-answers
+		if answer.err != nil {
+			cancels[answer.index]()
+			if firstErr == nil {
+				firstErr = answer.err
+			}
+			continue
+		}
+		for i, cancel := range cancels {
+			if i != answer.index {
+				cancel()
+			}
+		}
+		// The requests that lost are closed whenever they return
+		go func(pending int) {
+			for ; pending > 0; pending-- {
+				if late := <-answers; late.err == nil {
+					late.response.Body.Close()
+				}
+			}
+		}(pending - 1)
+		answer.response.Body = &cancelOnClose{ReadCloser: answer.response.Body, cancel: cancels[answer.index]}
+		return urls[answer.index], answer.response, nil
+	}
+
+	return nil, response{}, firstErr
+}
+
+// cancelOnClose cancels the context of the request its body was read from once it's closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2)
+	limiter.now = func() time.Time { return now }
+
+	// A burst of up to one second worth of requests goes through immediately
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+
+	// Then requests are spaced out
+	assert.Equal(t, 500*time.Millisecond, limiter.reserve())
+	assert.Equal(t, time.Second, limiter.reserve())
+
+	// Tokens refill over time, up to the burst size
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+	assert.Equal(t, time.Duration(0), limiter.reserve())
+	assert.Equal(t, 500*time.Millisecond, limiter.reserve())
+
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+
+	limiter := NewRateLimiter(0)
+
+	assert.Nil(t, limiter)
+	assert.NoError(t, limiter.Wait(context.Background()))
+	assert.Equal(t, 0, limiter.Queued())
+
+}
+
+func TestRateLimiterWaitHonorsContext(t *testing.T) {
+
+	limiter := NewRateLimiter(0.01)
+	assert.NoError(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- limiter.Wait(ctx)
+	}()
+
+	assert.Eventually(t, func() bool { return limiter.Queued() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, 0, limiter.Queued())
+
+}
+
+func TestRateLimitedHTTPClient(t *testing.T) {
+
+	sent := 0
+	client := NewRateLimitedHTTPClient(&mocks.MockHttpClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			sent++
+			return &http.Response{StatusCode: 200}, nil
+		},
+	}, NewRateLimiter(0.01))
+
+	req, _ := http.NewRequest("GET", "http://127.0.0.1/json/tags", nil)
+	_, err := client.Do(req)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.Do(req.WithContext(ctx))
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, sent)
+
+}
@@ -21,10 +21,13 @@ package api
 
 import (
 	"encoding/json"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/zi0p4tch0/radiogogo/common"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -79,6 +82,99 @@ func TestStationJSON(t *testing.T) {
 
 		assert.NoError(t, err)
 		assert.Len(t, stations, 1)
+		assert.Equal(t, "http://bestfm.sk/", stations[0].Homepage.URL.String())
+
+	})
+
+	t.Run("tolerates odd values", func(t *testing.T) {
+
+		input := `{
+			"stationuuid": "941ef6f1-0699-4821-95b1-2b678e3ff62e",
+			"name": 42,
+			"url": "http://stream.bestfm.sk/128.mp3",
+			"homepage": "http://[broken",
+			"tags": ["pop"],
+			"votes": "57",
+			"bitrate": "128 kbps",
+			"clickcount": -3,
+			"clicktrend": 2.0,
+			"hls": true,
+			"lastcheckok": "1",
+			"lastchangetime_iso8601": "",
+			"lastchecktime_iso8601": "2023-10-17 08:46:57",
+			"clicktimestamp_iso8601": "yesterday",
+			"geo_lat": "48.14",
+			"has_extended_info": 0,
+			"brand_new_field": {"nested": true}
+		}`
+
+		station, warnings, err := common.DecodeStation([]byte(input))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "42", station.Name)
+		assert.Equal(t, "http://stream.bestfm.sk/128.mp3", station.Url.URL.String())
+		assert.Empty(t, station.Homepage.URL.String())
+		assert.Empty(t, station.Tags)
+		assert.Equal(t, uint64(57), station.Votes)
+		assert.Equal(t, uint64(128), station.Bitrate)
+		assert.Equal(t, uint64(0), station.ClickCount)
+		assert.Equal(t, int64(2), station.ClickTrend)
+		assert.True(t, bool(station.Hls))
+		assert.True(t, bool(station.LastCheckOk))
+		assert.True(t, station.LastChangeTime.IsZero())
+		assert.Equal(t, time.Date(2023, 10, 17, 8, 46, 57, 0, time.UTC), station.LastCheckTime)
+		assert.Nil(t, station.ClickTimestamp)
+		assert.Equal(t, 48.14, *station.GeoLat)
+		assert.False(t, *station.HasExtendedInfo)
+
+		fields := map[string]bool{}
+		for _, warning := range warnings {
+			assert.Equal(t, "941ef6f1-0699-4821-95b1-2b678e3ff62e", warning.Station)
+			fields[warning.Field] = warning.Coerced
+		}
+		assert.Equal(t, map[string]bool{
+			"name": true, "votes": true, "bitrate": true, "clicktrend": true, "hls": true, "lastcheckok": true,
+			"lastchecktime_iso8601": true, "geo_lat": true, "has_extended_info": true,
+			"homepage": false, "tags": false, "clickcount": false, "clicktimestamp_iso8601": false,
+		}, fields)
+
+	})
+
+	t.Run("round trips", func(t *testing.T) {
+
+		clicked := time.Date(2023, 10, 17, 11, 34, 28, 0, time.UTC)
+		lat := 48.14
+		station := common.Station{
+			StationUuid:    uuid.MustParse("941ef6f1-0699-4821-95b1-2b678e3ff62e"),
+			Name:           "Best FM",
+			Votes:          57,
+			LastCheckOk:    true,
+			LastChangeTime: time.Date(2022, 11, 1, 8, 40, 32, 0, time.UTC),
+			ClickTimestamp: &clicked,
+			GeoLat:         &lat,
+		}
+		station.Url.URL = url.URL{Scheme: "http", Host: "stream.bestfm.sk", Path: "/128.mp3"}
+
+		data, err := json.Marshal(station)
+		assert.NoError(t, err)
+		var decoded common.Station
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+
+		assert.Equal(t, station, decoded)
+
+	})
+
+	t.Run("skips what isn't a station", func(t *testing.T) {
+
+		stations, warnings, err := common.DecodeStations([]byte(`[{"name": "Best FM"}, 42, {"name": "Jazz FM"}]`))
+
+		assert.NoError(t, err)
+		assert.Len(t, stations, 2)
+		assert.Equal(t, "Jazz FM", stations[1].Name)
+		assert.Equal(t, []common.DecodeWarning{{Station: "#2", Field: "station", Value: "42"}}, warnings)
+
+		_, _, err = common.DecodeStations([]byte(`{"error": "not a list"}`))
+		assert.Error(t, err)
 
 	})
 
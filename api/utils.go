@@ -19,7 +19,11 @@
 
 package api
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
 
 func boolToString(b bool) string {
 	if b {
@@ -31,3 +35,21 @@ func boolToString(b bool) string {
 func uint64ToString(i uint64) string {
 	return fmt.Sprintf("%d", i)
 }
+
+// joinPathSegment returns u with segment appended to its path as a single, escaped segment,
+// so that a search term such as "AC/DC", "#1 hits", "rock?" or ".." can't be split into several segments,
+// be taken as a fragment or a query, or move up the path.
+func joinPathSegment(u *url.URL, segment string) *url.URL {
+	escaped := url.PathEscape(segment)
+	// Dot segments are removed by path cleaning, on either side, unless escaped
+	switch segment {
+	case ".":
+		escaped = "%2E"
+	case "..":
+		escaped = "%2E%2E"
+	}
+	joined := *u
+	joined.Path = strings.TrimSuffix(u.Path, "/") + "/" + segment
+	joined.RawPath = strings.TrimSuffix(u.EscapedPath(), "/") + "/" + escaped
+	return &joined
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// searchTermSeeds are search terms that broke, or could break, the paths built from them.
+var searchTermSeeds = []string{
+	"jazz",
+	"",
+	"AC/DC",
+	"/",
+	"rock & roll",
+	"#1 hits",
+	"rock?",
+	"100%",
+	"%2F",
+	".",
+	"..",
+	"../../tags",
+	"a;b,c",
+	"Radio Führung",
+	"ラジオ",
+	"emoji 📻",
+	"tab\tnewline\n",
+	"\x00",
+	"\xff\xfe",
+}
+
+func TestJoinPathSegment(t *testing.T) {
+
+	base, _ := url.Parse("https://radio.example.com/json/stations/byname")
+
+	testCases := []struct {
+		segment     string
+		escapedPath string
+	}{
+		{segment: "jazz", escapedPath: "/json/stations/byname/jazz"},
+		{segment: "AC/DC", escapedPath: "/json/stations/byname/AC%2FDC"},
+		{segment: "#1 hits", escapedPath: "/json/stations/byname/%231%20hits"},
+		{segment: "rock?", escapedPath: "/json/stations/byname/rock%3F"},
+		{segment: "..", escapedPath: "/json/stations/byname/%2E%2E"},
+		{segment: "Führung", escapedPath: "/json/stations/byname/F%C3%BChrung"},
+		{segment: "", escapedPath: "/json/stations/byname/"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.segment, func(t *testing.T) {
+
+			joined := joinPathSegment(base, tc.segment)
+
+			assert.Equal(t, tc.escapedPath, joined.EscapedPath())
+			assert.Equal(t, "https://radio.example.com"+tc.escapedPath, joined.String())
+			assert.Equal(t, "/json/stations/byname", base.Path, "the base URL is left as it is")
+
+		})
+	}
+
+}
+
+// FuzzJoinPathSegment checks that any segment, appended to any path, is sent as one segment that reads back as it was.
+func FuzzJoinPathSegment(f *testing.F) {
+
+	for _, term := range searchTermSeeds {
+		f.Add("/json/tags", term)
+	}
+	f.Add("/json/", "jazz")
+	f.Add("/a%2Fb", "c")
+
+	f.Fuzz(func(t *testing.T, basePath string, segment string) {
+
+		// Mirrors always have an absolute path
+		if !strings.HasPrefix(basePath, "/") {
+			basePath = "/" + basePath
+		}
+		base := &url.URL{Scheme: "https", Host: "radio.example.com", Path: basePath}
+		joined := joinPathSegment(base, segment)
+
+		parsed, err := url.Parse(joined.String())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Empty(t, parsed.Fragment)
+		assert.Empty(t, parsed.RawQuery)
+
+		escapedPath := parsed.EscapedPath()
+		prefix := strings.TrimSuffix(base.EscapedPath(), "/") + "/"
+		if !assert.True(t, strings.HasPrefix(escapedPath, prefix), "%q doesn't start with %q", escapedPath, prefix) {
+			return
+		}
+		last := strings.TrimPrefix(escapedPath, prefix)
+		assert.NotContains(t, last, "/")
+		unescaped, err := url.PathUnescape(last)
+		assert.NoError(t, err)
+		assert.Equal(t, segment, unescaped)
+
+	})
+
+}
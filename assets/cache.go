@@ -0,0 +1,248 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+// How long downloading an asset may take.
+const fetchTimeout = 10 * time.Second
+
+// Assets larger than this aren't cached.
+const maxAssetSize = 1 << 20
+
+// ErrAssetTooLarge is returned when an asset is larger than maxAssetSize.
+var ErrAssetTooLarge = i18n.Error("assets.tooLarge")
+
+// Cache fetches assets such as station favicons, keeping them to avoid fetching them again.
+type Cache interface {
+	// Get returns the asset at assetUrl, downloading it if it isn't cached.
+	Get(assetUrl url.URL) ([]byte, error)
+}
+
+// DiskCache is a Cache keeping assets in a directory, one file per asset.
+// When the directory grows larger than its limit, the least recently used assets are removed first:
+// the modification time of a file is updated whenever it is read from the cache.
+type DiskCache struct {
+	dir        string
+	maxBytes   int64
+	httpClient api.HTTPClientService
+	// now returns the current time, and is replaced in tests.
+	now func() time.Time
+
+	// Guards the directory, so that eviction doesn't race with writes
+	mu sync.Mutex
+}
+
+// NewDiskCache returns a DiskCache keeping at most maxBytes of assets in dir, downloaded with a default HTTP client.
+func NewDiskCache(dir string, maxBytes int64) *DiskCache {
+	return NewDiskCacheWithDependencies(dir, maxBytes, &http.Client{Timeout: fetchTimeout})
+}
+
+// NewDiskCacheWithDependencies returns a DiskCache keeping at most maxBytes of assets in dir, downloaded with httpClient.
+func NewDiskCacheWithDependencies(dir string, maxBytes int64, httpClient api.HTTPClientService) *DiskCache {
+	return &DiskCache{
+		dir:        dir,
+		maxBytes:   maxBytes,
+		httpClient: httpClient,
+		now:        time.Now,
+	}
+}
+
+// path returns the file an asset is kept in, named after a hash of its URL.
+func (c *DiskCache) path(assetUrl url.URL) string {
+	sum := sha256.Sum256([]byte(assetUrl.String()))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+func (c *DiskCache) Get(assetUrl url.URL) ([]byte, error) {
+
+	path := c.path(assetUrl)
+
+	c.mu.Lock()
+	content, err := os.ReadFile(path)
+	if err == nil {
+		now := c.now()
+		_ = os.Chtimes(path, now, now)
+		c.mu.Unlock()
+		return content, nil
+	}
+	c.mu.Unlock()
+
+	content, err = c.download(assetUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A cache that can't be written to still serves the asset
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return content, nil
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return content, nil
+	}
+	now := c.now()
+	_ = os.Chtimes(path, now, now)
+	_ = c.evict()
+
+	return content, nil
+}
+
+func (c *DiskCache) download(assetUrl url.URL) ([]byte, error) {
+
+	req, err := http.NewRequest("GET", assetUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", data.UserAgent)
+
+	result, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Body.Close()
+
+	if result.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", result.StatusCode)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(result.Body, maxAssetSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxAssetSize {
+		return nil, ErrAssetTooLarge
+	}
+
+	return content, nil
+}
+
+type cachedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (c *DiskCache) files() ([]cachedFile, error) {
+	entries, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var files []cachedFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cachedFile{
+			path:    filepath.Join(c.dir, entry.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	return files, nil
+}
+
+// evict removes the least recently used assets until the cache fits in its limit.
+func (c *DiskCache) evict() error {
+	files, err := c.files()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, file := range files {
+		total += file.size
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, file := range files {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(file.path); err != nil {
+			return err
+		}
+		total -= file.size
+	}
+	return nil
+}
+
+// Usage returns how many assets are cached and how many bytes they take.
+func (c *DiskCache) Usage() (int, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files, err := c.files()
+	if err != nil {
+		return 0, 0, err
+	}
+	var total int64
+	for _, file := range files {
+		total += file.size
+	}
+	return len(files), total, nil
+}
+
+// Clean removes every cached asset, returning how many were removed and how many bytes they took.
+func (c *DiskCache) Clean() (int, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files, err := c.files()
+	if err != nil {
+		return 0, 0, err
+	}
+	removed := 0
+	var total int64
+	for _, file := range files {
+		if err := os.Remove(file.path); err != nil {
+			return removed, total, err
+		}
+		removed++
+		total += file.size
+	}
+	return removed, total, nil
+}
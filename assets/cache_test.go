@@ -0,0 +1,157 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package assets
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskCache(t *testing.T) {
+
+	assetUrl := func(name string) url.URL {
+		u, _ := url.Parse("https://example.com/" + name + ".png")
+		return *u
+	}
+
+	// serve answers with size bytes for every asset, counting the downloads
+	serve := func(size int, downloads *int) *mocks.MockHttpClient {
+		return &mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				*downloads++
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader(bytes.Repeat([]byte{'x'}, size))),
+				}, nil
+			},
+		}
+	}
+
+	t.Run("downloads an asset once", func(t *testing.T) {
+
+		downloads := 0
+		cache := NewDiskCacheWithDependencies(t.TempDir(), 1<<20, serve(10, &downloads))
+
+		first, err := cache.Get(assetUrl("one"))
+		assert.NoError(t, err)
+		second, err := cache.Get(assetUrl("one"))
+		assert.NoError(t, err)
+
+		assert.Len(t, first, 10)
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, downloads)
+
+	})
+
+	t.Run("evicts the least recently used assets", func(t *testing.T) {
+
+		downloads := 0
+		cache := NewDiskCacheWithDependencies(t.TempDir(), 25, serve(10, &downloads))
+		now := time.Now().Add(-time.Hour)
+		cache.now = func() time.Time {
+			now = now.Add(time.Minute)
+			return now
+		}
+
+		_, _ = cache.Get(assetUrl("one"))
+		_, _ = cache.Get(assetUrl("two"))
+		// Reading "one" makes "two" the least recently used
+		_, _ = cache.Get(assetUrl("one"))
+		_, _ = cache.Get(assetUrl("three"))
+
+		count, size, err := cache.Usage()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, int64(20), size)
+		_, err = os.Stat(cache.path(assetUrl("two")))
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(cache.path(assetUrl("one")))
+		assert.NoError(t, err)
+
+	})
+
+	t.Run("refuses assets that are too large", func(t *testing.T) {
+
+		downloads := 0
+		cache := NewDiskCacheWithDependencies(t.TempDir(), 10<<20, serve(maxAssetSize+1, &downloads))
+
+		_, err := cache.Get(assetUrl("huge"))
+
+		assert.ErrorIs(t, err, ErrAssetTooLarge)
+
+	})
+
+	t.Run("fails on an error status", func(t *testing.T) {
+
+		cache := NewDiskCacheWithDependencies(t.TempDir(), 1<<20, &mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}, nil
+			},
+		})
+
+		_, err := cache.Get(assetUrl("missing"))
+
+		assert.Error(t, err)
+
+	})
+
+	t.Run("cleans every asset", func(t *testing.T) {
+
+		downloads := 0
+		cache := NewDiskCacheWithDependencies(t.TempDir(), 1<<20, serve(10, &downloads))
+		_, _ = cache.Get(assetUrl("one"))
+		_, _ = cache.Get(assetUrl("two"))
+
+		count, size, err := cache.Clean()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, int64(20), size)
+
+		count, _, err = cache.Usage()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+	})
+
+	t.Run("has nothing to clean before the first asset", func(t *testing.T) {
+
+		cache := NewDiskCache(t.TempDir()+"/missing", 1<<20)
+
+		count, _, err := cache.Clean()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+	})
+
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/cli"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/favorites"
+	"github.com/zi0p4tch0/radiogogo/opml"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+// How long following a TuneIn link to its stream may take.
+const importTimeout = 10 * time.Second
+
+// newExportCommand returns "radiogogo export", which exports the bookmarks to an OPML file.
+func newExportCommand() *cli.Command {
+	return &cli.Command{
+		Name:          "export",
+		Usage:         "<file>",
+		Short:         "Export bookmarks to an OPML file (\"-\" for stdout)",
+		CompleteFiles: true,
+		Run: func(args []string) error {
+			if len(args) != 1 {
+				return errors.New("exporting bookmarks: usage: radiogogo export <file>")
+			}
+			if err := exportBookmarks(args[0]); err != nil {
+				return fmt.Errorf("exporting bookmarks: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// newImportCommand returns "radiogogo import", which bookmarks the stations of an OPML file,
+// or of the favorites of another player.
+func newImportCommand(loadConfig func() config.Config) *cli.Command {
+
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	formatFlag := flags.String("format", "", "the `format` of the file: opml, m3u, pls, xspf or urls (guessed from its content if not given)")
+
+	return &cli.Command{
+		Name:  "import",
+		Usage: "<file>",
+		Short: "Import bookmarks from an OPML file, the favorites of another player or a list of URLs (\"-\" for stdin)",
+		Long: "Bookmarks the stations of an OPML file (such as TuneIn's favorites), an M3U, PLS or XSPF playlist (such as mpv's and VLC's) " +
+			"or a plain list of stream URLs, looking each of them up on radio-browser by URL, then by name. Entries that can't be found are imported as they are.",
+		Flags:         flags,
+		Interspersed:  true,
+		CompleteFiles: true,
+		Run: func(args []string) error {
+			if len(args) != 1 {
+				return errors.New("importing bookmarks: usage: radiogogo import [--format <format>] <file>")
+			}
+			var format favorites.Format
+			if *formatFlag != "" {
+				var err error
+				if format, err = favorites.ParseFormat(*formatFlag); err != nil {
+					return fmt.Errorf("importing bookmarks: %w", err)
+				}
+			}
+			if err := importBookmarks(loadConfig(), args[0], format); err != nil {
+				return fmt.Errorf("importing bookmarks: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// exportBookmarks writes the bookmarked stations as OPML to the given path ("-" for stdout).
+func exportBookmarks(path string) error {
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	bookmarks := storage.NewBoltBookmarkStore(db).All()
+
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	err = opml.Write(w, "RadioGoGo bookmarks", bookmarks)
+	if err != nil {
+		return err
+	}
+
+	if path != "-" {
+		fmt.Printf("Exported %d bookmarks to %s\n", len(bookmarks), path)
+	}
+
+	return nil
+}
+
+// importBookmarks bookmarks the stations listed in the file at the given path ("-" for stdin),
+// in the given format, or the one guessed from its content if empty.
+func importBookmarks(cfg config.Config, path string, format favorites.Format) error {
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		format = favorites.Detect(content)
+	}
+	entries, err := favorites.Read(bytes.NewReader(content), format)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	bookmarkStore := storage.NewBoltBookmarkStore(db)
+
+	browser, err := api.NewRadioBrowser(cfg.API.BaseURL, api.NewRateLimiter(cfg.API.RequestsPerSecond))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot reach radio-browser, importing entries as they are: %v\n", err)
+		browser = nil
+	}
+
+	imported := favorites.ResolveStations(browser, &http.Client{Timeout: importTimeout}, entries)
+
+	matched := 0
+	for _, entry := range imported {
+		err := bookmarkStore.Add(entry.Station)
+		if err != nil {
+			return err
+		}
+		if entry.Matched {
+			matched++
+		} else {
+			fmt.Printf("Not found on radio-browser, imported as is: %s\n", entry.Station.Name)
+		}
+	}
+
+	fmt.Printf("Imported %d of %d entries (%d found on radio-browser)\n", len(imported), len(entries), matched)
+
+	return nil
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bookmarksync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultBranch is the branch the document is kept on, unless configured otherwise.
+const DefaultBranch = "main"
+
+// Git is a Remote keeping the document in a Git repository, through a working copy of its own.
+// The git command does the work, with the credentials it's configured with.
+type Git struct {
+	url    string
+	branch string
+	dir    string
+}
+
+// NewGit returns a Remote keeping the document on the given branch of the repository at url
+// (DefaultBranch if empty), with its working copy in dir.
+func NewGit(url string, branch string, dir string) *Git {
+	if branch == "" {
+		branch = DefaultBranch
+	}
+	return &Git{url: url, branch: branch, dir: dir}
+}
+
+func (g *Git) Pull() (Document, bool, error) {
+
+	if err := g.prepare(); err != nil {
+		return Document{}, false, err
+	}
+
+	// An empty repository has no branch to fetch yet
+	heads, err := g.git("ls-remote", "--heads", "origin", g.branch)
+	if err != nil {
+		return Document{}, false, err
+	}
+	if strings.TrimSpace(heads) == "" {
+		return Document{}, false, nil
+	}
+
+	if _, err := g.git("fetch", "--quiet", "origin", g.branch); err != nil {
+		return Document{}, false, err
+	}
+	if _, err := g.git("checkout", "--quiet", "--force", "-B", g.branch, "FETCH_HEAD"); err != nil {
+		return Document{}, false, err
+	}
+
+	document, err := ReadDocument(filepath.Join(g.dir, DocumentName))
+	if errors.Is(err, os.ErrNotExist) {
+		return document, false, nil
+	}
+	return document, err == nil, err
+}
+
+// Push commits the document on top of what was pulled, and pushes it.
+// It fails if the branch was pushed to in the meantime: syncing again resolves it.
+func (g *Git) Push(document Document) error {
+
+	if err := g.prepare(); err != nil {
+		return err
+	}
+	if err := WriteDocument(filepath.Join(g.dir, DocumentName), document); err != nil {
+		return err
+	}
+	if _, err := g.git("add", DocumentName); err != nil {
+		return err
+	}
+
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "another machine"
+	}
+	if _, err := g.git("commit", "--quiet", "--allow-empty", "-m", "Sync bookmarks from "+host); err != nil {
+		return err
+	}
+	_, err := g.git("push", "--quiet", "origin", "HEAD:refs/heads/"+g.branch)
+	return err
+}
+
+func (g *Git) String() string {
+	return redact(g.url) + "#" + g.branch
+}
+
+// prepare creates the working copy if needed, pointing it to the repository.
+func (g *Git) prepare() error {
+	if _, err := os.Stat(filepath.Join(g.dir, ".git")); err == nil {
+		_, err := g.git("remote", "set-url", "origin", g.url)
+		return err
+	}
+	if err := os.MkdirAll(g.dir, 0755); err != nil {
+		return err
+	}
+	if _, err := g.git("init", "--quiet"); err != nil {
+		return err
+	}
+	_, err := g.git("remote", "add", "origin", g.url)
+	return err
+}
+
+// git runs git in the working copy, returning what it printed.
+// It never prompts for credentials, which would hang RadioGoGo, and commits as RadioGoGo.
+func (g *Git) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.dir
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_AUTHOR_NAME=RadioGoGo", "GIT_AUTHOR_EMAIL=radiogogo@localhost",
+		"GIT_COMMITTER_NAME=RadioGoGo", "GIT_COMMITTER_EMAIL=radiogogo@localhost",
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
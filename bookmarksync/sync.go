@@ -0,0 +1,207 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package bookmarksync keeps the bookmarks of several machines in sync, through a file in a Git repository
+// or on a WebDAV server. When the bookmarks were changed on both sides since they were last synced,
+// the side changed last wins, and the bookmarks of the other side are backed up.
+package bookmarksync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+// DocumentName is the name of the file the bookmarks are synced through.
+const DocumentName = "radiogogo-bookmarks.json"
+
+// Document is the content of the file the bookmarks are synced through.
+type Document struct {
+	// Updated is when the bookmarks were last changed.
+	Updated time.Time `json:"updated"`
+	// Bookmarks are in the order they were added.
+	Bookmarks []storage.BookmarkEntry `json:"bookmarks"`
+}
+
+// Remote is where the document is shared with the other machines.
+type Remote interface {
+	// Pull returns the shared document, or false if there's none yet.
+	Pull() (Document, bool, error)
+	// Push shares the given document, replacing the one shared.
+	Push(document Document) error
+	// String identifies the remote, so that syncing with another one starts over, without any password.
+	String() string
+}
+
+// Action is what syncing did.
+type Action int
+
+const (
+	// UpToDate means that both sides had the same bookmarks.
+	UpToDate Action = iota
+	// Pushed means that the bookmarks of this machine were shared.
+	Pushed
+	// Pulled means that the bookmarks of this machine were replaced with the shared ones.
+	Pulled
+)
+
+// Result tells what syncing did.
+type Result struct {
+	Action Action
+	// Conflict is true if the bookmarks were changed on both sides since they were last synced.
+	Conflict bool
+	// Backup is the file the replaced bookmarks were saved to, if any.
+	Backup string
+	// Count is how many bookmarks there are now.
+	Count int
+}
+
+// Sync syncs the bookmarks of store with remote, saving the bookmarks that get replaced to backupDir.
+func Sync(store storage.BookmarkSyncStore, remote Remote, backupDir string) (Result, error) {
+
+	local := Document{Updated: store.Changed(), Bookmarks: store.Entries()}
+	localHash := hash(local.Bookmarks)
+
+	state := store.State()
+	if state.Remote != remote.String() {
+		state = storage.BookmarkSyncState{}
+	}
+
+	shared, found, err := remote.Pull()
+	if err != nil {
+		return Result{}, err
+	}
+	sharedHash := hash(shared.Bookmarks)
+
+	var result Result
+	switch {
+	case !found:
+		result, err = push(remote, local, Result{Action: Pushed})
+	case sharedHash == localHash:
+		result = Result{Action: UpToDate, Count: len(local.Bookmarks)}
+	case localHash == state.Hash:
+		result, err = pull(store, shared, local, backupDir, Result{Action: Pulled})
+	case sharedHash == state.Hash:
+		result, err = push(remote, local, Result{Action: Pushed})
+	case len(local.Bookmarks) == 0 && state.Hash == "":
+		// A machine without bookmarks joining the others
+		result, err = pull(store, shared, local, backupDir, Result{Action: Pulled})
+	case local.Updated.After(shared.Updated):
+		result = Result{Action: Pushed, Conflict: true}
+		if result.Backup, err = backup(backupDir, "shared", shared); err == nil {
+			result, err = push(remote, local, result)
+		}
+	default:
+		result, err = pull(store, shared, local, backupDir, Result{Action: Pulled, Conflict: true})
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Both sides have the same bookmarks now
+	err = store.SetState(storage.BookmarkSyncState{Remote: remote.String(), Hash: hash(store.Entries()), Synced: time.Now()})
+	return result, err
+}
+
+// push shares the local document, as changed now if the bookmarks never were.
+func push(remote Remote, local Document, result Result) (Result, error) {
+	if local.Updated.IsZero() {
+		local.Updated = time.Now()
+	}
+	result.Count = len(local.Bookmarks)
+	return result, remote.Push(local)
+}
+
+// pull replaces the local bookmarks with the shared ones, backing them up first.
+func pull(store storage.BookmarkSyncStore, shared Document, local Document, backupDir string, result Result) (Result, error) {
+	var err error
+	if len(local.Bookmarks) > 0 {
+		if result.Backup, err = backup(backupDir, "local", local); err != nil {
+			return result, err
+		}
+	}
+	result.Count = len(shared.Bookmarks)
+	return result, store.Replace(shared.Bookmarks, shared.Updated)
+}
+
+// backup saves the document to a new file of dir, named after the side it comes from, and returns its path.
+func backup(dir string, side string, document Document) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("bookmarks-%s-%s.json", side, time.Now().Format("20060102-150405")))
+	return path, WriteDocument(path, document)
+}
+
+// ReadDocument reads a document, such as a backup, from the file at path.
+func ReadDocument(path string) (Document, error) {
+	var document Document
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return document, err
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return document, fmt.Errorf("%s: %w", path, err)
+	}
+	return document, nil
+}
+
+// WriteDocument writes the document to the file at path.
+func WriteDocument(path string, document Document) error {
+	content, err := encode(document)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// encode returns the document as indented JSON, so that the changes made to it are readable in a diff.
+func encode(document Document) ([]byte, error) {
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+// redact hides the password of rawURL, if any, such as a token expanded from the secrets.
+func redact(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsed.Redacted()
+}
+
+// hash returns a hash of the bookmarks, telling whether they changed regardless of when.
+func hash(bookmarks []storage.BookmarkEntry) string {
+	if len(bookmarks) == 0 {
+		bookmarks = nil
+	}
+	content, _ := json.Marshal(bookmarks)
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
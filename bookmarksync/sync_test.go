@@ -0,0 +1,227 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bookmarksync
+
+import (
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+type fakeStore struct {
+	entries []storage.BookmarkEntry
+	changed time.Time
+	state   storage.BookmarkSyncState
+}
+
+func (s *fakeStore) Entries() []storage.BookmarkEntry { return s.entries }
+func (s *fakeStore) Changed() time.Time               { return s.changed }
+func (s *fakeStore) Replace(entries []storage.BookmarkEntry, changed time.Time) error {
+	s.entries = entries
+	s.changed = changed
+	return nil
+}
+func (s *fakeStore) State() storage.BookmarkSyncState { return s.state }
+func (s *fakeStore) SetState(state storage.BookmarkSyncState) error {
+	s.state = state
+	return nil
+}
+
+type fakeRemote struct {
+	document *Document
+	pushes   int
+}
+
+func (r *fakeRemote) Pull() (Document, bool, error) {
+	if r.document == nil {
+		return Document{}, false, nil
+	}
+	return *r.document, true, nil
+}
+func (r *fakeRemote) Push(document Document) error {
+	r.document = &document
+	r.pushes++
+	return nil
+}
+func (r *fakeRemote) String() string { return "fake" }
+
+func newTestEntry(name string) storage.BookmarkEntry {
+	streamUrl, _ := url.Parse("http://example.com/" + name)
+	return storage.BookmarkEntry{Station: common.Station{
+		StationUuid: uuid.New(),
+		Name:        name,
+		Url:         common.RadioGoGoURL{URL: *streamUrl},
+	}}
+}
+
+var (
+	earlier = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	later   = time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+)
+
+// syncedStores returns a store and a remote that were synced with the given bookmarks.
+func syncedStores(t *testing.T, entries ...storage.BookmarkEntry) (*fakeStore, *fakeRemote) {
+	store := &fakeStore{entries: entries, changed: earlier}
+	remote := &fakeRemote{}
+	_, err := Sync(store, remote, t.TempDir())
+	assert.NoError(t, err)
+	return store, remote
+}
+
+func TestSync(t *testing.T) {
+
+	first := newTestEntry("first")
+	second := newTestEntry("second")
+	third := newTestEntry("third")
+
+	t.Run("shares the bookmarks when there's nothing shared yet", func(t *testing.T) {
+
+		store := &fakeStore{entries: []storage.BookmarkEntry{first}, changed: earlier}
+		remote := &fakeRemote{}
+
+		result, err := Sync(store, remote, t.TempDir())
+
+		assert.NoError(t, err)
+		assert.Equal(t, Result{Action: Pushed, Count: 1}, result)
+		assert.Equal(t, []storage.BookmarkEntry{first}, remote.document.Bookmarks)
+		assert.Equal(t, earlier, remote.document.Updated)
+		assert.Equal(t, "fake", store.state.Remote)
+		assert.NotEmpty(t, store.state.Hash)
+
+	})
+
+	t.Run("does nothing when both sides have the same bookmarks", func(t *testing.T) {
+
+		store, remote := syncedStores(t, first)
+
+		result, err := Sync(store, remote, t.TempDir())
+
+		assert.NoError(t, err)
+		assert.Equal(t, Result{Action: UpToDate, Count: 1}, result)
+		assert.Equal(t, 1, remote.pushes)
+
+	})
+
+	t.Run("shares the bookmarks changed on this machine only", func(t *testing.T) {
+
+		store, remote := syncedStores(t, first)
+		store.entries = []storage.BookmarkEntry{first, second}
+		store.changed = later
+
+		result, err := Sync(store, remote, t.TempDir())
+
+		assert.NoError(t, err)
+		assert.Equal(t, Result{Action: Pushed, Count: 2}, result)
+		assert.Equal(t, []storage.BookmarkEntry{first, second}, remote.document.Bookmarks)
+
+	})
+
+	t.Run("takes the bookmarks changed on another machine only, backing up these", func(t *testing.T) {
+
+		store, remote := syncedStores(t, first)
+		remote.document = &Document{Updated: later, Bookmarks: []storage.BookmarkEntry{second, first}}
+		backups := t.TempDir()
+
+		result, err := Sync(store, remote, backups)
+
+		assert.NoError(t, err)
+		assert.Equal(t, Pulled, result.Action)
+		assert.False(t, result.Conflict)
+		assert.Equal(t, []storage.BookmarkEntry{second, first}, store.entries)
+		assert.Equal(t, later, store.changed)
+		assert.Equal(t, backups, filepath.Dir(result.Backup))
+
+		backup, err := ReadDocument(result.Backup)
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.BookmarkEntry{first}, backup.Bookmarks)
+
+		result, err = Sync(store, remote, backups)
+		assert.NoError(t, err)
+		assert.Equal(t, UpToDate, result.Action)
+
+	})
+
+	t.Run("lets the side changed last win a conflict, backing up the other", func(t *testing.T) {
+
+		store, remote := syncedStores(t, first)
+		store.entries = []storage.BookmarkEntry{first, second}
+		store.changed = later
+		remote.document = &Document{Updated: earlier.Add(time.Hour), Bookmarks: []storage.BookmarkEntry{third}}
+
+		result, err := Sync(store, remote, t.TempDir())
+
+		assert.NoError(t, err)
+		assert.Equal(t, Pushed, result.Action)
+		assert.True(t, result.Conflict)
+		assert.Equal(t, []storage.BookmarkEntry{first, second}, remote.document.Bookmarks)
+		backup, err := ReadDocument(result.Backup)
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.BookmarkEntry{third}, backup.Bookmarks)
+
+		store.entries = []storage.BookmarkEntry{second}
+		store.changed = later
+		remote.document = &Document{Updated: later.Add(time.Hour), Bookmarks: []storage.BookmarkEntry{third}}
+
+		result, err = Sync(store, remote, t.TempDir())
+
+		assert.NoError(t, err)
+		assert.Equal(t, Pulled, result.Action)
+		assert.True(t, result.Conflict)
+		assert.Equal(t, []storage.BookmarkEntry{third}, store.entries)
+		backup, err = ReadDocument(result.Backup)
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.BookmarkEntry{second}, backup.Bookmarks)
+
+	})
+
+	t.Run("takes the shared bookmarks on a machine without any", func(t *testing.T) {
+
+		store := &fakeStore{changed: later}
+		remote := &fakeRemote{document: &Document{Updated: earlier, Bookmarks: []storage.BookmarkEntry{first}}}
+
+		result, err := Sync(store, remote, t.TempDir())
+
+		assert.NoError(t, err)
+		assert.Equal(t, Result{Action: Pulled, Count: 1}, result)
+		assert.Equal(t, []storage.BookmarkEntry{first}, store.entries)
+
+	})
+
+	t.Run("starts over when syncing with another remote", func(t *testing.T) {
+
+		store, _ := syncedStores(t, first)
+		store.state.Remote = "elsewhere"
+		remote := &fakeRemote{document: &Document{Updated: later, Bookmarks: []storage.BookmarkEntry{second}}}
+
+		result, err := Sync(store, remote, t.TempDir())
+
+		assert.NoError(t, err)
+		assert.True(t, result.Conflict)
+		assert.Equal(t, "fake", store.state.Remote)
+
+	})
+
+}
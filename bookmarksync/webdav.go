@@ -0,0 +1,122 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bookmarksync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/data"
+)
+
+// How long a request to the WebDAV server may take.
+const webDAVTimeout = 30 * time.Second
+
+// The largest document read from the WebDAV server.
+const maxDocumentSize = 16 << 20
+
+// WebDAV is a Remote keeping the document on a WebDAV server.
+type WebDAV struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewWebDAV returns a Remote keeping the document at the given URL, or in the directory it points to
+// if it ends with a slash. The username and password are sent with basic auth, if set.
+func NewWebDAV(url string, username string, password string) *WebDAV {
+	return NewWebDAVWithDependencies(url, username, password, &http.Client{Timeout: webDAVTimeout})
+}
+
+// NewWebDAVWithDependencies returns a WebDAV remote sending its requests with the given client.
+func NewWebDAVWithDependencies(url string, username string, password string, client *http.Client) *WebDAV {
+	if strings.HasSuffix(url, "/") {
+		url += DocumentName
+	}
+	return &WebDAV{url: url, username: username, password: password, client: client}
+}
+
+func (w *WebDAV) Pull() (Document, bool, error) {
+
+	var document Document
+
+	resp, err := w.send(http.MethodGet, nil)
+	if err != nil {
+		return document, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return document, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return document, false, fmt.Errorf("GET %s: %s", w.url, resp.Status)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDocumentSize)).Decode(&document); err != nil {
+		return document, false, fmt.Errorf("GET %s: %w", w.url, err)
+	}
+	return document, true, nil
+}
+
+func (w *WebDAV) Push(document Document) error {
+
+	content, err := encode(document)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.send(http.MethodPut, content)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("PUT %s: %s", w.url, resp.Status)
+	}
+	return nil
+}
+
+func (w *WebDAV) String() string {
+	return redact(w.url)
+}
+
+// send sends a request for the document, with the given body if not nil.
+func (w *WebDAV) send(method string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, w.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", data.UserAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	return w.client.Do(req)
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package bookmarksync
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+// newTestWebDAVServer returns a server keeping a single file at /dav/radiogogo-bookmarks.json, behind basic auth.
+func newTestWebDAVServer(t *testing.T) *httptest.Server {
+	var file []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "me" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/dav/"+DocumentName {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			if file == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(file)
+		case http.MethodPut:
+			created := file == nil
+			file, _ = io.ReadAll(r.Body)
+			if created {
+				w.WriteHeader(http.StatusCreated)
+			} else {
+				w.WriteHeader(http.StatusNoContent)
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWebDAV(t *testing.T) {
+
+	t.Run("pushes and pulls the document", func(t *testing.T) {
+
+		server := newTestWebDAVServer(t)
+		remote := NewWebDAVWithDependencies(server.URL+"/dav/", "me", "secret", server.Client())
+
+		_, found, err := remote.Pull()
+		assert.NoError(t, err)
+		assert.False(t, found)
+
+		document := Document{Updated: earlier, Bookmarks: []storage.BookmarkEntry{newTestEntry("first")}}
+		assert.NoError(t, remote.Push(document))
+		assert.NoError(t, remote.Push(document))
+
+		pulled, found, err := remote.Pull()
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, document, pulled)
+		assert.Equal(t, server.URL+"/dav/"+DocumentName, remote.String())
+
+	})
+
+	t.Run("fails when the server refuses the request", func(t *testing.T) {
+
+		server := newTestWebDAVServer(t)
+		remote := NewWebDAVWithDependencies(server.URL+"/dav/", "me", "wrong", server.Client())
+
+		_, _, err := remote.Pull()
+		assert.ErrorContains(t, err, "401")
+		assert.ErrorContains(t, remote.Push(Document{}), "401")
+
+	})
+
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/zi0p4tch0/radiogogo/assets"
+	"github.com/zi0p4tch0/radiogogo/cli"
+	"github.com/zi0p4tch0/radiogogo/config"
+)
+
+// newCacheCommand returns "radiogogo cache", which tells how much space the cached assets take,
+// and "radiogogo cache clean", which removes them all.
+func newCacheCommand(loadConfig func() config.Config) *cli.Command {
+
+	cache := &cli.Command{
+		Name:  "cache",
+		Short: "Tell how much space the cached assets take",
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("maintaining the cache: %w %q: use radiogogo cache [clean]", cli.ErrUnknownCommand, args[0])
+			}
+			cfg := loadConfig()
+			count, size, err := newAssetCache(cfg).Usage()
+			if err != nil {
+				return fmt.Errorf("maintaining the cache: %w", err)
+			}
+			fmt.Printf("%d assets cached in %s (%.1f MB of %d MB)\n", count, config.AssetsDir(), float64(size)/(1<<20), cfg.Assets.CacheMB)
+			return nil
+		},
+	}
+
+	cache.Add(&cli.Command{
+		Name:  "clean",
+		Short: "Remove every cached asset",
+		Run: func(args []string) error {
+			count, size, err := newAssetCache(loadConfig()).Clean()
+			if err != nil {
+				return fmt.Errorf("maintaining the cache: %w", err)
+			}
+			fmt.Printf("Removed %d cached assets (%.1f MB)\n", count, float64(size)/(1<<20))
+			return nil
+		},
+	})
+
+	return cache
+}
+
+// newAssetCache returns the cache of fetched assets, such as station favicons.
+func newAssetCache(cfg config.Config) *assets.DiskCache {
+	return assets.NewDiskCache(config.AssetsDir(), int64(cfg.Assets.CacheMB)<<20)
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package cast sends radio streams to network renderers (DLNA/UPnP and Chromecast devices)
+// instead of playing them locally.
+package cast
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Media describes the stream sent to a device.
+type Media struct {
+	// URL of the stream. Devices can't parse playlists, so it should be a direct stream URL.
+	URL string
+	// Title shown by devices with a display.
+	Title string
+	// MIME type of the stream, e.g. "audio/mpeg".
+	ContentType string
+}
+
+// Device is a renderer on the local network that can play a stream on its own.
+type Device interface {
+	// Name returns the user-friendly name of the device.
+	Name() string
+	// Protocol returns the protocol used to talk to the device, e.g. "DLNA".
+	Protocol() string
+	// Play makes the device play the given media, replacing whatever it was playing.
+	Play(media Media) error
+	// Stop stops the playback on the device.
+	Stop() error
+	// SetVolume sets the volume of the device, from 0 to 100.
+	SetVolume(percent int) error
+}
+
+// ContentTypeForCodec returns the MIME type matching a radio-browser codec name.
+func ContentTypeForCodec(codec string) string {
+	switch strings.ToUpper(codec) {
+	case "AAC", "AAC+":
+		return "audio/aac"
+	case "OGG", "VORBIS":
+		return "audio/ogg"
+	case "OPUS":
+		return "audio/ogg; codecs=opus"
+	case "FLAC":
+		return "audio/flac"
+	default:
+		return "audio/mpeg"
+	}
+}
+
+// Discover looks for DLNA renderers and Chromecast devices on the local network for the given duration.
+// An error is returned only if no discovery method could be run at all.
+func Discover(timeout time.Duration) ([]Device, error) {
+
+	discoverers := []func(time.Duration) ([]Device, error){
+		discoverDLNA,
+		discoverChromecasts,
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var devices []Device
+	var errs []error
+
+	for _, discover := range discoverers {
+		wg.Add(1)
+		go func(discover func(time.Duration) ([]Device, error)) {
+			defer wg.Done()
+			found, err := discover(timeout)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			devices = append(devices, found...)
+		}(discover)
+	}
+
+	wg.Wait()
+
+	if len(errs) == len(discoverers) {
+		return nil, errs[0]
+	}
+
+	sort.SliceStable(devices, func(i, j int) bool {
+		return strings.ToLower(devices[i].Name()) < strings.ToLower(devices[j].Name())
+	})
+
+	return devices, nil
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cast
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const rendererDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <friendlyName>Living Room</friendlyName>
+    <serviceList>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:RenderingControl:1</serviceType>
+        <controlURL>/rendering/control</controlURL>
+      </service>
+    </serviceList>
+    <deviceList>
+      <device>
+        <serviceList>
+          <service>
+            <serviceType>urn:schemas-upnp-org:service:AVTransport:1</serviceType>
+            <controlURL>avtransport/control</controlURL>
+          </service>
+        </serviceList>
+      </device>
+    </deviceList>
+  </device>
+</root>`
+
+type soapCall struct {
+	action string
+	body   string
+}
+
+func newRendererServer(t *testing.T, calls *[]soapCall) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/description.xml":
+			_, _ = io.WriteString(w, rendererDescription)
+		case "/avtransport/control", "/rendering/control":
+			assert.Equal(t, "POST", r.Method)
+			body, _ := io.ReadAll(r.Body)
+			*calls = append(*calls, soapCall{action: r.Header.Get("SOAPAction"), body: string(body)})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestParseSSDPResponse(t *testing.T) {
+
+	t.Run("returns the location of the device description", func(t *testing.T) {
+		response := "HTTP/1.1 200 OK\r\n" +
+			"CACHE-CONTROL: max-age=1800\r\n" +
+			"LOCATION: http://192.168.1.10:49152/description.xml\r\n" +
+			"ST: urn:schemas-upnp-org:device:MediaRenderer:1\r\n\r\n"
+
+		location, ok := parseSSDPResponse([]byte(response))
+
+		assert.True(t, ok)
+		assert.Equal(t, "http://192.168.1.10:49152/description.xml", location)
+	})
+
+	t.Run("rejects responses without a location", func(t *testing.T) {
+		_, ok := parseSSDPResponse([]byte("HTTP/1.1 200 OK\r\n\r\n"))
+		assert.False(t, ok)
+	})
+
+}
+
+func TestDLNADevice(t *testing.T) {
+
+	t.Run("finds the control URLs in nested devices", func(t *testing.T) {
+
+		var calls []soapCall
+		server := newRendererServer(t, &calls)
+		defer server.Close()
+
+		device, err := newDLNADevice(server.Client(), server.URL+"/description.xml")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Living Room", device.Name())
+		assert.Equal(t, server.URL+"/avtransport/control", device.avTransportURL)
+		assert.Equal(t, server.URL+"/rendering/control", device.renderingURL)
+
+	})
+
+	t.Run("sets the transport URI and plays it", func(t *testing.T) {
+
+		var calls []soapCall
+		server := newRendererServer(t, &calls)
+		defer server.Close()
+
+		device, err := newDLNADevice(server.Client(), server.URL+"/description.xml")
+		assert.NoError(t, err)
+
+		err = device.Play(Media{URL: "http://example.com/stream?a=1&b=2", Title: "Jazz & Blues", ContentType: "audio/mpeg"})
+		assert.NoError(t, err)
+
+		assert.Len(t, calls, 2)
+		assert.Equal(t, `"urn:schemas-upnp-org:service:AVTransport:1#SetAVTransportURI"`, calls[0].action)
+		assert.Contains(t, calls[0].body, "<CurrentURI>http://example.com/stream?a=1&amp;b=2</CurrentURI>")
+		assert.Contains(t, calls[0].body, "Jazz &amp;amp; Blues")
+		assert.Equal(t, `"urn:schemas-upnp-org:service:AVTransport:1#Play"`, calls[1].action)
+		assert.True(t, strings.Index(calls[1].body, "<InstanceID>") < strings.Index(calls[1].body, "<Speed>"))
+
+	})
+
+	t.Run("sets the volume through the rendering control service", func(t *testing.T) {
+
+		var calls []soapCall
+		server := newRendererServer(t, &calls)
+		defer server.Close()
+
+		device, err := newDLNADevice(server.Client(), server.URL+"/description.xml")
+		assert.NoError(t, err)
+
+		assert.NoError(t, device.SetVolume(42))
+
+		assert.Len(t, calls, 1)
+		assert.Equal(t, `"urn:schemas-upnp-org:service:RenderingControl:1#SetVolume"`, calls[0].action)
+		assert.Contains(t, calls[0].body, "<DesiredVolume>42</DesiredVolume>")
+
+	})
+
+	t.Run("returns an error if the renderer rejects an action", func(t *testing.T) {
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/description.xml" {
+				_, _ = io.WriteString(w, rendererDescription)
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		device, err := newDLNADevice(server.Client(), server.URL+"/description.xml")
+		assert.NoError(t, err)
+
+		assert.Error(t, device.Stop())
+
+	})
+
+}
+
+func TestCastMessage(t *testing.T) {
+
+	t.Run("round-trips through the wire format", func(t *testing.T) {
+
+		message := castMessage{
+			sourceId:      "sender-0",
+			destinationId: "receiver-0",
+			namespace:     namespaceReceiver,
+			payload:       `{"type":"GET_STATUS","requestId":1}`,
+		}
+
+		var buffer bytes.Buffer
+		assert.NoError(t, writeCastMessage(&buffer, message))
+
+		decoded, err := readCastMessage(&buffer)
+		assert.NoError(t, err)
+		assert.Equal(t, message, decoded)
+
+	})
+
+	t.Run("rejects truncated messages", func(t *testing.T) {
+
+		data := castMessage{payload: "payload"}.marshal()
+
+		_, err := unmarshalCastMessage(data[:len(data)-2])
+		assert.Error(t, err)
+
+	})
+
+}
+
+func TestContentTypeForCodec(t *testing.T) {
+	assert.Equal(t, "audio/mpeg", ContentTypeForCodec("MP3"))
+	assert.Equal(t, "audio/aac", ContentTypeForCodec("aac+"))
+	assert.Equal(t, "audio/ogg", ContentTypeForCodec("OGG"))
+	assert.Equal(t, "audio/mpeg", ContentTypeForCodec(""))
+}
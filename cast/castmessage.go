@@ -0,0 +1,152 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cast
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// The Cast protocol exchanges CastMessage protocol buffers, each prefixed by its length.
+// Only string payloads are used, so the few fields involved are encoded by hand.
+
+// Largest message accepted from a device.
+const maxCastMessageSize = 64 * 1024
+
+// castMessage is a CastMessage with a string payload.
+type castMessage struct {
+	sourceId      string
+	destinationId string
+	namespace     string
+	payload       string
+}
+
+// CastMessage field numbers.
+const (
+	fieldProtocolVersion = 1
+	fieldSourceId        = 2
+	fieldDestinationId   = 3
+	fieldNamespace       = 4
+	fieldPayloadType     = 5
+	fieldPayloadUtf8     = 6
+)
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func (m castMessage) marshal() []byte {
+	var b []byte
+	b = appendVarintField(b, fieldProtocolVersion, 0) // CASTV2_1_0
+	b = appendStringField(b, fieldSourceId, m.sourceId)
+	b = appendStringField(b, fieldDestinationId, m.destinationId)
+	b = appendStringField(b, fieldNamespace, m.namespace)
+	b = appendVarintField(b, fieldPayloadType, 0) // STRING
+	b = appendStringField(b, fieldPayloadUtf8, m.payload)
+	return b
+}
+
+func appendVarintField(b []byte, field int, value uint64) []byte {
+	b = appendUvarint(b, uint64(field<<3|wireVarint))
+	return appendUvarint(b, value)
+}
+
+func appendStringField(b []byte, field int, value string) []byte {
+	b = appendUvarint(b, uint64(field<<3|wireBytes))
+	b = appendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+func appendUvarint(b []byte, value uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], value)
+	return append(b, buf[:n]...)
+}
+
+func unmarshalCastMessage(data []byte) (castMessage, error) {
+
+	var m castMessage
+
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return m, errors.New("invalid cast message")
+		}
+		data = data[n:]
+
+		switch key & 7 {
+		case wireVarint:
+			_, n := binary.Uvarint(data)
+			if n <= 0 {
+				return m, errors.New("invalid cast message")
+			}
+			data = data[n:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return m, errors.New("invalid cast message")
+			}
+			value := string(data[n : n+int(length)])
+			data = data[n+int(length):]
+			switch key >> 3 {
+			case fieldSourceId:
+				m.sourceId = value
+			case fieldDestinationId:
+				m.destinationId = value
+			case fieldNamespace:
+				m.namespace = value
+			case fieldPayloadUtf8:
+				m.payload = value
+			}
+		default:
+			return m, errors.New("unsupported cast message field")
+		}
+	}
+
+	return m, nil
+}
+
+func writeCastMessage(w io.Writer, m castMessage) error {
+	data := m.marshal()
+	frame := make([]byte, 4, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	_, err := w.Write(append(frame, data...))
+	return err
+}
+
+func readCastMessage(r io.Reader) (castMessage, error) {
+	header := make([]byte, 4)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return castMessage{}, err
+	}
+	length := binary.BigEndian.Uint32(header)
+	if length > maxCastMessageSize {
+		return castMessage{}, errors.New("cast message too large")
+	}
+	data := make([]byte, length)
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return castMessage{}, err
+	}
+	return unmarshalCastMessage(data)
+}
@@ -0,0 +1,502 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cast
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	mdnsAddress          = "224.0.0.251:5353"
+	chromecastService    = "_googlecast._tcp.local."
+	defaultMediaReceiver = "CC1AD845"
+
+	namespaceConnection = "urn:x-cast:com.google.cast.tp.connection"
+	namespaceHeartbeat  = "urn:x-cast:com.google.cast.tp.heartbeat"
+	namespaceReceiver   = "urn:x-cast:com.google.cast.receiver"
+	namespaceMedia      = "urn:x-cast:com.google.cast.media"
+
+	senderId   = "sender-0"
+	receiverId = "receiver-0"
+
+	// How long to wait for the device to answer a request.
+	chromecastReplyTimeout = 15 * time.Second
+	// How often to ping the device, which drops silent connections.
+	chromecastHeartbeat = 5 * time.Second
+)
+
+// ChromecastDevice is a Cast device, driven through the default media receiver app.
+type ChromecastDevice struct {
+	name string
+	addr string
+
+	mutex       sync.Mutex
+	writeMutex  sync.Mutex
+	conn        *tls.Conn
+	messages    chan castMessage
+	done        chan struct{}
+	requestId   int
+	sessionId   string
+	transportId string
+}
+
+func NewChromecastDevice(name string, addr string) *ChromecastDevice {
+	return &ChromecastDevice{name: name, addr: addr}
+}
+
+func (d *ChromecastDevice) Name() string {
+	return d.name
+}
+
+func (d *ChromecastDevice) Protocol() string {
+	return "Chromecast"
+}
+
+func (d *ChromecastDevice) Play(media Media) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.conn == nil {
+		err := d.connect()
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.transportId == "" {
+		err := d.launch()
+		if err != nil {
+			d.close()
+			return err
+		}
+	}
+
+	err := d.load(media)
+	if err != nil {
+		d.close()
+	}
+	return err
+}
+
+func (d *ChromecastDevice) Stop() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.conn == nil {
+		return nil
+	}
+
+	var err error
+	if d.sessionId != "" {
+		d.requestId++
+		err = d.send(namespaceReceiver, receiverId, map[string]interface{}{
+			"type":      "STOP",
+			"requestId": d.requestId,
+			"sessionId": d.sessionId,
+		})
+	}
+	d.close()
+	return err
+}
+
+func (d *ChromecastDevice) SetVolume(percent int) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.conn == nil {
+		err := d.connect()
+		if err != nil {
+			return err
+		}
+	}
+
+	d.requestId++
+	return d.send(namespaceReceiver, receiverId, map[string]interface{}{
+		"type":      "SET_VOLUME",
+		"requestId": d.requestId,
+		"volume":    map[string]interface{}{"level": float64(percent) / 100},
+	})
+}
+
+// connect opens the connection to the device and starts answering its heartbeats.
+func (d *ChromecastDevice) connect() error {
+
+	// Cast devices present self-signed certificates.
+	conn, err := tls.DialWithDialer(
+		&net.Dialer{Timeout: 5 * time.Second},
+		"tcp",
+		d.addr,
+		&tls.Config{InsecureSkipVerify: true},
+	)
+	if err != nil {
+		return err
+	}
+
+	d.conn = conn
+	d.messages = make(chan castMessage, 16)
+	d.done = make(chan struct{})
+
+	go d.readLoop(conn, d.messages)
+	go d.heartbeatLoop(d.done)
+
+	err = d.send(namespaceConnection, receiverId, map[string]interface{}{"type": "CONNECT"})
+	if err != nil {
+		d.close()
+		return err
+	}
+
+	return nil
+}
+
+// launch starts the default media receiver and connects to it.
+func (d *ChromecastDevice) launch() error {
+
+	d.requestId++
+	err := d.send(namespaceReceiver, receiverId, map[string]interface{}{
+		"type":      "LAUNCH",
+		"requestId": d.requestId,
+		"appId":     defaultMediaReceiver,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = d.waitFor(namespaceReceiver, func(payload map[string]interface{}) (bool, error) {
+		switch payload["type"] {
+		case "LAUNCH_ERROR":
+			return false, fmt.Errorf("%s: launch failed: %v", d.name, payload["reason"])
+		case "RECEIVER_STATUS":
+			status, _ := payload["status"].(map[string]interface{})
+			applications, _ := status["applications"].([]interface{})
+			for _, application := range applications {
+				app, _ := application.(map[string]interface{})
+				if app["appId"] == defaultMediaReceiver {
+					d.sessionId, _ = app["sessionId"].(string)
+					d.transportId, _ = app["transportId"].(string)
+					return d.transportId != "", nil
+				}
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return d.send(namespaceConnection, d.transportId, map[string]interface{}{"type": "CONNECT"})
+}
+
+// load asks the media receiver to play the given media.
+func (d *ChromecastDevice) load(media Media) error {
+
+	d.requestId++
+	err := d.send(namespaceMedia, d.transportId, map[string]interface{}{
+		"type":      "LOAD",
+		"requestId": d.requestId,
+		"sessionId": d.sessionId,
+		"autoplay":  true,
+		"media": map[string]interface{}{
+			"contentId":   media.URL,
+			"contentType": media.ContentType,
+			"streamType":  "LIVE",
+			"metadata": map[string]interface{}{
+				"metadataType": 0,
+				"title":        media.Title,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return d.waitFor(namespaceMedia, func(payload map[string]interface{}) (bool, error) {
+		switch payload["type"] {
+		case "MEDIA_STATUS":
+			return true, nil
+		case "LOAD_FAILED", "LOAD_CANCELLED", "INVALID_REQUEST":
+			return false, fmt.Errorf("%s: cannot play the stream (%v)", d.name, payload["type"])
+		}
+		return false, nil
+	})
+}
+
+// waitFor reads messages in the given namespace until check reports success or an error.
+func (d *ChromecastDevice) waitFor(namespace string, check func(payload map[string]interface{}) (bool, error)) error {
+	timeout := time.After(chromecastReplyTimeout)
+	for {
+		select {
+		case message, ok := <-d.messages:
+			if !ok {
+				return fmt.Errorf("%s closed the connection", d.name)
+			}
+			if message.namespace != namespace {
+				continue
+			}
+			var payload map[string]interface{}
+			if json.Unmarshal([]byte(message.payload), &payload) != nil {
+				continue
+			}
+			done, err := check(payload)
+			if err != nil || done {
+				return err
+			}
+		case <-timeout:
+			return fmt.Errorf("%s did not answer in time", d.name)
+		}
+	}
+}
+
+func (d *ChromecastDevice) send(namespace string, destinationId string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	d.writeMutex.Lock()
+	defer d.writeMutex.Unlock()
+	if d.conn == nil {
+		return errors.New("not connected")
+	}
+	_ = d.conn.SetWriteDeadline(time.Now().Add(chromecastReplyTimeout))
+	return writeCastMessage(d.conn, castMessage{
+		sourceId:      senderId,
+		destinationId: destinationId,
+		namespace:     namespace,
+		payload:       string(data),
+	})
+}
+
+// readLoop answers pings and forwards every other message, until the connection is closed.
+func (d *ChromecastDevice) readLoop(conn *tls.Conn, messages chan castMessage) {
+	defer close(messages)
+	for {
+		message, err := readCastMessage(conn)
+		if err != nil {
+			return
+		}
+		if message.namespace == namespaceHeartbeat {
+			if strings.Contains(message.payload, `"PING"`) {
+				_ = d.send(namespaceHeartbeat, message.sourceId, map[string]interface{}{"type": "PONG"})
+			}
+			continue
+		}
+		select {
+		case messages <- message:
+		default:
+			// Nobody is waiting for a reply: status updates can be dropped.
+		}
+	}
+}
+
+func (d *ChromecastDevice) heartbeatLoop(done chan struct{}) {
+	ticker := time.NewTicker(chromecastHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = d.send(namespaceHeartbeat, receiverId, map[string]interface{}{"type": "PING"})
+		case <-done:
+			return
+		}
+	}
+}
+
+// close drops the connection; the next request reconnects.
+func (d *ChromecastDevice) close() {
+	d.writeMutex.Lock()
+	if d.conn != nil {
+		d.conn.Close()
+		d.conn = nil
+	}
+	d.writeMutex.Unlock()
+	if d.done != nil {
+		close(d.done)
+		d.done = nil
+	}
+	d.sessionId = ""
+	d.transportId = ""
+}
+
+// Discovery
+
+// discoverChromecasts sends an mDNS query for Cast devices and collects the answers until the timeout.
+func discoverChromecasts(timeout time.Duration) ([]Device, error) {
+
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	destination, err := net.ResolveUDPAddr("udp4", mdnsAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := mdnsQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.WriteTo(query, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+
+	records := newMDNSRecords()
+	buffer := make([]byte, 9000)
+
+	for {
+		n, source, err := conn.ReadFrom(buffer)
+		if err != nil {
+			// The deadline ends the discovery.
+			break
+		}
+		sourceIP := ""
+		if udpAddr, ok := source.(*net.UDPAddr); ok {
+			sourceIP = udpAddr.IP.String()
+		}
+		records.parse(buffer[:n], sourceIP)
+	}
+
+	return records.devices(), nil
+}
+
+func mdnsQuery() ([]byte, error) {
+	name, err := dnsmessage.NewName(chromecastService)
+	if err != nil {
+		return nil, err
+	}
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{})
+	err = builder.StartQuestions()
+	if err != nil {
+		return nil, err
+	}
+	err = builder.Question(dnsmessage.Question{
+		Name: name,
+		Type: dnsmessage.TypePTR,
+		// The top bit asks for a unicast answer, sent to our ephemeral port.
+		Class: dnsmessage.ClassINET | 1<<15,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return builder.Finish()
+}
+
+// mdnsRecords accumulates the records describing Cast devices across mDNS answers.
+type mdnsRecords struct {
+	instances map[string]string // instance name -> IP of the answering host
+	targets   map[string]string // instance name -> SRV target
+	ports     map[string]uint16 // instance name -> SRV port
+	names     map[string]string // instance name -> friendly name
+	ips       map[string]string // host name -> IPv4 address
+}
+
+func newMDNSRecords() *mdnsRecords {
+	return &mdnsRecords{
+		instances: make(map[string]string),
+		targets:   make(map[string]string),
+		ports:     make(map[string]uint16),
+		names:     make(map[string]string),
+		ips:       make(map[string]string),
+	}
+}
+
+func (r *mdnsRecords) parse(packet []byte, sourceIP string) {
+
+	var parser dnsmessage.Parser
+	if _, err := parser.Start(packet); err != nil {
+		return
+	}
+	if err := parser.SkipAllQuestions(); err != nil {
+		return
+	}
+
+	var resources []dnsmessage.Resource
+	for _, section := range []func() ([]dnsmessage.Resource, error){
+		parser.AllAnswers,
+		parser.AllAuthorities,
+		parser.AllAdditionals,
+	} {
+		found, err := section()
+		if err != nil {
+			break
+		}
+		resources = append(resources, found...)
+	}
+
+	for _, resource := range resources {
+		name := resource.Header.Name.String()
+		switch body := resource.Body.(type) {
+		case *dnsmessage.PTRResource:
+			if strings.EqualFold(name, chromecastService) {
+				r.instances[body.PTR.String()] = sourceIP
+			}
+		case *dnsmessage.SRVResource:
+			r.targets[name] = body.Target.String()
+			r.ports[name] = body.Port
+		case *dnsmessage.AResource:
+			r.ips[name] = net.IP(body.A[:]).String()
+		case *dnsmessage.TXTResource:
+			for _, txt := range body.TXT {
+				if strings.HasPrefix(txt, "fn=") {
+					r.names[name] = strings.TrimPrefix(txt, "fn=")
+				}
+			}
+		}
+	}
+}
+
+func (r *mdnsRecords) devices() []Device {
+
+	var devices []Device
+
+	for instance, sourceIP := range r.instances {
+		ip := r.ips[r.targets[instance]]
+		if ip == "" {
+			ip = sourceIP
+		}
+		port := r.ports[instance]
+		if port == 0 {
+			port = 8009
+		}
+		if ip == "" {
+			continue
+		}
+		name := r.names[instance]
+		if name == "" {
+			name = strings.TrimSuffix(instance, "."+chromecastService)
+		}
+		devices = append(devices, NewChromecastDevice(name, net.JoinHostPort(ip, fmt.Sprintf("%d", port))))
+	}
+
+	return devices
+}
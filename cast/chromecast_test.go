@@ -0,0 +1,196 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cast
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// selfSignedCertificate returns a throwaway certificate, like the ones presented by Cast devices.
+func selfSignedCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// fakeChromecast accepts a single connection and answers like the default media receiver would.
+// Every payload received is sent on the returned channel.
+func fakeChromecast(t *testing.T) (string, chan map[string]interface{}) {
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{selfSignedCertificate(t)},
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan map[string]interface{}, 32)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reply := func(namespace string, payload string) {
+			_ = writeCastMessage(conn, castMessage{
+				sourceId:      receiverId,
+				destinationId: senderId,
+				namespace:     namespace,
+				payload:       payload,
+			})
+		}
+
+		for {
+			message, err := readCastMessage(conn)
+			if err != nil {
+				return
+			}
+			var payload map[string]interface{}
+			_ = json.Unmarshal([]byte(message.payload), &payload)
+			received <- payload
+
+			switch payload["type"] {
+			case "LAUNCH":
+				reply(namespaceHeartbeat, `{"type":"PING"}`)
+				reply(namespaceReceiver, `{"type":"RECEIVER_STATUS","status":{"applications":[{"appId":"CC1AD845","sessionId":"session-1","transportId":"transport-1"}]}}`)
+			case "LOAD":
+				reply(namespaceMedia, `{"type":"MEDIA_STATUS","status":[]}`)
+			}
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func nextPayloadOfType(t *testing.T, received chan map[string]interface{}, messageType string) map[string]interface{} {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case payload := <-received:
+			if payload["type"] == messageType {
+				return payload
+			}
+		case <-timeout:
+			t.Fatalf("no %s message received", messageType)
+			return nil
+		}
+	}
+}
+
+func TestChromecastDevice(t *testing.T) {
+
+	t.Run("launches the media receiver and loads the stream", func(t *testing.T) {
+
+		addr, received := fakeChromecast(t)
+		device := NewChromecastDevice("Kitchen", addr)
+
+		err := device.Play(Media{URL: "http://example.com/stream", Title: "Jazz FM", ContentType: "audio/mpeg"})
+		assert.NoError(t, err)
+
+		nextPayloadOfType(t, received, "LAUNCH")
+		nextPayloadOfType(t, received, "PONG")
+		load := nextPayloadOfType(t, received, "LOAD")
+		media := load["media"].(map[string]interface{})
+		assert.Equal(t, "http://example.com/stream", media["contentId"])
+		assert.Equal(t, "LIVE", media["streamType"])
+		assert.Equal(t, "session-1", load["sessionId"])
+
+		assert.NoError(t, device.Stop())
+		stop := nextPayloadOfType(t, received, "STOP")
+		assert.Equal(t, "session-1", stop["sessionId"])
+
+	})
+
+	t.Run("returns an error if the device is unreachable", func(t *testing.T) {
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		addr := listener.Addr().String()
+		listener.Close()
+
+		device := NewChromecastDevice("Kitchen", addr)
+		assert.Error(t, device.Play(Media{URL: "http://example.com/stream"}))
+
+	})
+
+}
+
+func TestMDNSRecords(t *testing.T) {
+
+	instance := "Chromecast-abc123." + chromecastService
+	target := "abc123.local."
+
+	mustName := func(name string) dnsmessage.Name {
+		n, err := dnsmessage.NewName(name)
+		assert.NoError(t, err)
+		return n
+	}
+
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true})
+	assert.NoError(t, builder.StartAnswers())
+	assert.NoError(t, builder.PTRResource(
+		dnsmessage.ResourceHeader{Name: mustName(chromecastService), Class: dnsmessage.ClassINET},
+		dnsmessage.PTRResource{PTR: mustName(instance)},
+	))
+	assert.NoError(t, builder.StartAdditionals())
+	assert.NoError(t, builder.SRVResource(
+		dnsmessage.ResourceHeader{Name: mustName(instance), Class: dnsmessage.ClassINET},
+		dnsmessage.SRVResource{Target: mustName(target), Port: 8009},
+	))
+	assert.NoError(t, builder.TXTResource(
+		dnsmessage.ResourceHeader{Name: mustName(instance), Class: dnsmessage.ClassINET},
+		dnsmessage.TXTResource{TXT: []string{"id=abc123", "fn=Living Room TV"}},
+	))
+	assert.NoError(t, builder.AResource(
+		dnsmessage.ResourceHeader{Name: mustName(target), Class: dnsmessage.ClassINET},
+		dnsmessage.AResource{A: [4]byte{192, 168, 1, 20}},
+	))
+	packet, err := builder.Finish()
+	assert.NoError(t, err)
+
+	records := newMDNSRecords()
+	records.parse(packet, "192.168.1.99")
+
+	devices := records.devices()
+	assert.Len(t, devices, 1)
+	assert.Equal(t, "Living Room TV", devices[0].Name())
+	assert.Equal(t, "192.168.1.20:8009", devices[0].(*ChromecastDevice).addr)
+
+}
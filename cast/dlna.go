@@ -0,0 +1,276 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cast
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ssdpAddress      = "239.255.255.250:1900"
+	mediaRendererURN = "urn:schemas-upnp-org:device:MediaRenderer:1"
+	avTransportURN   = "urn:schemas-upnp-org:service:AVTransport:1"
+	renderingURN     = "urn:schemas-upnp-org:service:RenderingControl:1"
+)
+
+var dlnaHTTPClient = &http.Client{Timeout: 5 * time.Second}
+
+// DLNADevice is a UPnP MediaRenderer controlled through SOAP calls.
+type DLNADevice struct {
+	name           string
+	avTransportURL string
+	renderingURL   string
+	httpClient     *http.Client
+}
+
+func (d *DLNADevice) Name() string {
+	return d.name
+}
+
+func (d *DLNADevice) Protocol() string {
+	return "DLNA"
+}
+
+func (d *DLNADevice) Play(media Media) error {
+	err := d.call(d.avTransportURL, avTransportURN, "SetAVTransportURI", [][2]string{
+		{"InstanceID", "0"},
+		{"CurrentURI", media.URL},
+		{"CurrentURIMetaData", didlMetadata(media)},
+	})
+	if err != nil {
+		return err
+	}
+	return d.call(d.avTransportURL, avTransportURN, "Play", [][2]string{
+		{"InstanceID", "0"},
+		{"Speed", "1"},
+	})
+}
+
+func (d *DLNADevice) Stop() error {
+	return d.call(d.avTransportURL, avTransportURN, "Stop", [][2]string{
+		{"InstanceID", "0"},
+	})
+}
+
+func (d *DLNADevice) SetVolume(percent int) error {
+	if d.renderingURL == "" {
+		return fmt.Errorf("%s does not support volume control", d.name)
+	}
+	return d.call(d.renderingURL, renderingURN, "SetVolume", [][2]string{
+		{"InstanceID", "0"},
+		{"Channel", "Master"},
+		{"DesiredVolume", fmt.Sprintf("%d", percent)},
+	})
+}
+
+// call invokes a UPnP action. Arguments are ordered, as some renderers are picky about it.
+func (d *DLNADevice) call(controlURL string, serviceURN string, action string, args [][2]string) error {
+
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0" encoding="utf-8"?>`)
+	body.WriteString(`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	fmt.Fprintf(&body, `<u:%s xmlns:u="%s">`, action, serviceURN)
+	for _, arg := range args {
+		fmt.Fprintf(&body, "<%s>", arg[0])
+		_ = xml.EscapeText(&body, []byte(arg[1]))
+		fmt.Fprintf(&body, "</%s>", arg[0])
+	}
+	fmt.Fprintf(&body, "</u:%s></s:Body></s:Envelope>", action)
+
+	req, err := http.NewRequest("POST", controlURL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", fmt.Sprintf(`"%s#%s"`, serviceURN, action))
+
+	res, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s failed with status %d", d.name, action, res.StatusCode)
+	}
+
+	return nil
+}
+
+// didlMetadata describes the stream as a broadcast, which some renderers require before playing it.
+func didlMetadata(media Media) string {
+	var b bytes.Buffer
+	b.WriteString(`<DIDL-Lite xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/">`)
+	b.WriteString(`<item id="0" parentID="-1" restricted="1"><dc:title>`)
+	_ = xml.EscapeText(&b, []byte(media.Title))
+	b.WriteString(`</dc:title><upnp:class>object.item.audioItem.audioBroadcast</upnp:class>`)
+	fmt.Fprintf(&b, `<res protocolInfo="http-get:*:%s:*">`, strings.SplitN(media.ContentType, ";", 2)[0])
+	_ = xml.EscapeText(&b, []byte(media.URL))
+	b.WriteString(`</res></item></DIDL-Lite>`)
+	return b.String()
+}
+
+// Discovery
+
+// discoverDLNA sends an SSDP search for media renderers and collects the answers until the timeout.
+func discoverDLNA(timeout time.Duration) ([]Device, error) {
+
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	destination, err := net.ResolveUDPAddr("udp4", ssdpAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	search := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + ssdpAddress + "\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		fmt.Sprintf("MX: %d\r\n", int(timeout.Seconds())) +
+		"ST: " + mediaRendererURN + "\r\n\r\n"
+
+	_, err = conn.WriteTo([]byte(search), destination)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+
+	locations := make(map[string]bool)
+	var devices []Device
+	buffer := make([]byte, 2048)
+
+	for {
+		n, _, err := conn.ReadFrom(buffer)
+		if err != nil {
+			// The deadline ends the discovery.
+			break
+		}
+		location, ok := parseSSDPResponse(buffer[:n])
+		if !ok || locations[location] {
+			continue
+		}
+		locations[location] = true
+		device, err := newDLNADevice(dlnaHTTPClient, location)
+		if err == nil {
+			devices = append(devices, device)
+		}
+	}
+
+	return devices, nil
+}
+
+// parseSSDPResponse returns the location of the device description announced in an SSDP response.
+func parseSSDPResponse(data []byte) (string, bool) {
+	res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), nil)
+	if err != nil {
+		return "", false
+	}
+	res.Body.Close()
+	location := res.Header.Get("Location")
+	return location, location != ""
+}
+
+type deviceDescription struct {
+	URLBase string            `xml:"URLBase"`
+	Device  deviceDescElement `xml:"device"`
+}
+
+type deviceDescElement struct {
+	FriendlyName string `xml:"friendlyName"`
+	Services     []struct {
+		ServiceType string `xml:"serviceType"`
+		ControlURL  string `xml:"controlURL"`
+	} `xml:"serviceList>service"`
+	Devices []deviceDescElement `xml:"deviceList>device"`
+}
+
+// newDLNADevice fetches the device description at location and finds its control URLs.
+func newDLNADevice(httpClient *http.Client, location string) (*DLNADevice, error) {
+
+	res, err := httpClient.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var description deviceDescription
+	err = xml.NewDecoder(res.Body).Decode(&description)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	if description.URLBase != "" {
+		if urlBase, err := url.Parse(description.URLBase); err == nil {
+			base = urlBase
+		}
+	}
+
+	device := &DLNADevice{
+		name:       description.Device.FriendlyName,
+		httpClient: httpClient,
+	}
+
+	var collect func(element deviceDescElement)
+	collect = func(element deviceDescElement) {
+		for _, service := range element.Services {
+			controlURL, err := base.Parse(strings.TrimSpace(service.ControlURL))
+			if err != nil {
+				continue
+			}
+			switch strings.TrimSpace(service.ServiceType) {
+			case avTransportURN:
+				device.avTransportURL = controlURL.String()
+			case renderingURN:
+				device.renderingURL = controlURL.String()
+			}
+		}
+		for _, child := range element.Devices {
+			collect(child)
+		}
+	}
+	collect(description.Device)
+
+	if device.avTransportURL == "" {
+		return nil, fmt.Errorf("%s does not support AVTransport", device.name)
+	}
+	if device.name == "" {
+		device.name = base.Host
+	}
+
+	return device, nil
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cast
+
+import (
+	"net/url"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/playback"
+)
+
+// PlaybackManager plays stations on a network device instead of locally.
+// It implements playback.PlaybackManagerService, playback.VolumeSetter and playback.Ducker.
+type PlaybackManager struct {
+	device  Device
+	playing bool
+	// The volume set, and whether it's turned down and to which percentage of it
+	volume    int
+	ducked    bool
+	duckLevel int
+}
+
+func NewPlaybackManager(device Device) *PlaybackManager {
+	return &PlaybackManager{device: device}
+}
+
+var _ playback.PlaybackManagerService = (*PlaybackManager)(nil)
+var _ playback.VolumeSetter = (*PlaybackManager)(nil)
+var _ playback.Ducker = (*PlaybackManager)(nil)
+
+func (m *PlaybackManager) Name() string {
+	return m.device.Name() + " (" + m.device.Protocol() + ")"
+}
+
+func (m *PlaybackManager) IsAvailable() bool {
+	return true
+}
+
+func (m *PlaybackManager) NotAvailableErrorString() string {
+	return ""
+}
+
+func (m *PlaybackManager) IsPlaying() bool {
+	return m.playing
+}
+
+func (m *PlaybackManager) PlayStation(station common.Station, volume int) error {
+	err := m.device.Play(Media{
+		URL:         streamURL(station),
+		Title:       station.Name,
+		ContentType: ContentTypeForCodec(station.Codec),
+	})
+	if err != nil {
+		return err
+	}
+	m.playing = true
+	m.volume = volume
+	// Not every renderer supports volume control: playing matters more.
+	_ = m.device.SetVolume(m.heardVolume())
+	return nil
+}
+
+func (m *PlaybackManager) StopStation() error {
+	if !m.playing {
+		return nil
+	}
+	err := m.device.Stop()
+	if err != nil {
+		return err
+	}
+	m.playing = false
+	return nil
+}
+
+func (m *PlaybackManager) SetVolume(volume int) error {
+	m.volume = volume
+	return m.device.SetVolume(m.heardVolume())
+}
+
+func (m *PlaybackManager) Duck(level int) error {
+	m.ducked, m.duckLevel = level < 100, level
+	if !m.playing {
+		return nil
+	}
+	return m.device.SetVolume(m.heardVolume())
+}
+
+// heardVolume returns the volume set, lowered if ducking.
+func (m *PlaybackManager) heardVolume() int {
+	if !m.ducked {
+		return m.volume
+	}
+	return playback.DuckedVolume(m.volume, m.duckLevel)
+}
+
+func (m *PlaybackManager) VolumeMin() int {
+	return 0
+}
+
+func (m *PlaybackManager) VolumeDefault() int {
+	return 50
+}
+
+func (m *PlaybackManager) VolumeMax() int {
+	return 100
+}
+
+func (m *PlaybackManager) VolumeIsPercentage() bool {
+	return true
+}
+
+// streamURL prefers the resolved URL, since devices can't parse playlists.
+func streamURL(station common.Station) string {
+	if station.UrlResolved.URL != (url.URL{}) {
+		return station.UrlResolved.URL.String()
+	}
+	return station.Url.URL.String()
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestCommand returns a command tree like RadioGoGo's, recording what ran.
+func newTestCommand(ran *[]string) *Command {
+
+	rootFlags := flag.NewFlagSet("radiogogo", flag.ContinueOnError)
+	profile := rootFlags.String("profile", "", "use the profile with the given `name`")
+
+	searchFlags := flag.NewFlagSet("search", flag.ContinueOnError)
+	asJSON := searchFlags.Bool("json", false, "print the stations as JSON")
+
+	root := &Command{
+		Name:  "radiogogo",
+		Short: "Play radio stations",
+		Flags: rootFlags,
+		Setup: func() error {
+			*ran = append(*ran, "setup "+*profile)
+			return nil
+		},
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return ErrUnknownCommand
+			}
+			*ran = append(*ran, "root")
+			return nil
+		},
+	}
+
+	search := &Command{
+		Name:         "search",
+		Aliases:      []string{"find"},
+		Usage:        "<terms...>",
+		Short:        "Print the stations matching a search",
+		Flags:        searchFlags,
+		Interspersed: true,
+		Run: func(args []string) error {
+			entry := "search"
+			for _, arg := range args {
+				entry += " " + arg
+			}
+			if *asJSON {
+				entry += " as JSON"
+			}
+			*ran = append(*ran, entry)
+			return nil
+		},
+	}
+
+	cache := &Command{Name: "cache", Short: "Maintain the cache"}
+	cache.Add(&Command{
+		Name:  "clean",
+		Short: "Remove every cached asset",
+		Run: func([]string) error {
+			*ran = append(*ran, "clean")
+			return nil
+		},
+	})
+
+	root.Add(search, cache, &Command{Name: "completion", Short: "Print the completion script", Completions: []string{"bash", "zsh", "fish"}, Run: func([]string) error { return nil }})
+
+	return root
+
+}
+
+func TestCommand_Execute(t *testing.T) {
+
+	t.Run("runs the root without a command", func(t *testing.T) {
+
+		var ran []string
+		assert.NoError(t, newTestCommand(&ran).Execute([]string{"--profile", "work"}))
+		assert.Equal(t, []string{"setup work", "root"}, ran)
+
+	})
+
+	t.Run("runs a subcommand after setting up its parent", func(t *testing.T) {
+
+		var ran []string
+		assert.NoError(t, newTestCommand(&ran).Execute([]string{"--profile=work", "search", "jazz", "--json", "fm"}))
+		assert.Equal(t, []string{"setup work", "search jazz fm as JSON"}, ran)
+
+	})
+
+	t.Run("runs a subcommand by alias", func(t *testing.T) {
+
+		var ran []string
+		assert.NoError(t, newTestCommand(&ran).Execute([]string{"find", "jazz"}))
+		assert.Equal(t, []string{"setup ", "search jazz"}, ran)
+
+	})
+
+	t.Run("runs nested subcommands", func(t *testing.T) {
+
+		var ran []string
+		assert.NoError(t, newTestCommand(&ran).Execute([]string{"cache", "clean"}))
+		assert.Equal(t, []string{"setup ", "clean"}, ran)
+
+	})
+
+	t.Run("fails on an unknown subcommand", func(t *testing.T) {
+
+		var ran []string
+		err := newTestCommand(&ran).Execute([]string{"cache", "purge"})
+		assert.ErrorIs(t, err, ErrUnknownCommand)
+		assert.Contains(t, err.Error(), "radiogogo cache --help")
+
+	})
+
+	t.Run("fails on an unknown flag", func(t *testing.T) {
+
+		var ran []string
+		err := newTestCommand(&ran).Execute([]string{"search", "--xml"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "radiogogo search")
+		assert.Empty(t, ran[1:])
+
+	})
+
+}
+
+func TestCommand_PrintHelp(t *testing.T) {
+
+	var ran []string
+	root := newTestCommand(&ran)
+
+	var b bytes.Buffer
+	root.PrintHelp(&b)
+
+	assert.Contains(t, b.String(), "Usage: radiogogo [flags] [<command>]")
+	assert.Contains(t, b.String(), "  search      Print the stations matching a search")
+	assert.Contains(t, b.String(), "  --profile <name>\n      use the profile with the given name")
+	assert.NotContains(t, b.String(), "find")
+
+}
+
+func TestCommand_WriteCompletion(t *testing.T) {
+
+	var ran []string
+	root := newTestCommand(&ran)
+
+	t.Run("bash", func(t *testing.T) {
+
+		var b bytes.Buffer
+		assert.NoError(t, root.WriteCompletion(&b, "bash"))
+		assert.Contains(t, b.String(), "/search|/cache|/cache/clean|/completion) cmdpath=")
+		assert.Contains(t, b.String(), `compgen -W "search cache completion --profile"`)
+		assert.Contains(t, b.String(), `compgen -W "bash zsh fish"`)
+		assert.Contains(t, b.String(), "complete -F _radiogogo radiogogo")
+
+	})
+
+	t.Run("zsh", func(t *testing.T) {
+
+		var b bytes.Buffer
+		assert.NoError(t, root.WriteCompletion(&b, "zsh"))
+		assert.Contains(t, b.String(), "#compdef radiogogo")
+		assert.Contains(t, b.String(), "'search:Print the stations matching a search'")
+		assert.Contains(t, b.String(), "'--json:print the stations as JSON'")
+
+	})
+
+	t.Run("fish", func(t *testing.T) {
+
+		var b bytes.Buffer
+		assert.NoError(t, root.WriteCompletion(&b, "fish"))
+		assert.Contains(t, b.String(), "complete -c radiogogo -n '__fish_use_subcommand' -a search -d 'Print the stations matching a search'")
+		assert.Contains(t, b.String(), "complete -c radiogogo -n '__fish_seen_subcommand_from cache' -a clean")
+		assert.Contains(t, b.String(), "complete -c radiogogo -n '__fish_use_subcommand' -l profile -r")
+
+	})
+
+	t.Run("unknown shell", func(t *testing.T) {
+
+		assert.Error(t, root.WriteCompletion(&bytes.Buffer{}, "tcsh"))
+
+	})
+
+}
+
+func TestCommand_WriteManPage(t *testing.T) {
+
+	var ran []string
+	root := newTestCommand(&ran)
+
+	var b bytes.Buffer
+	assert.NoError(t, root.WriteManPage(&b, "1.2.3"))
+
+	assert.Contains(t, b.String(), ".TH RADIOGOGO 1 \"\" \"radiogogo 1.2.3\" \"User Commands\"\n")
+	assert.Contains(t, b.String(), "radiogogo \\- Play radio stations\n")
+	assert.Contains(t, b.String(), ".B radiogogo search [flags] <terms...>\n")
+	assert.Contains(t, b.String(), ".B \\-\\-profile <name>\nuse the profile with the given name\n")
+	assert.Contains(t, b.String(), ".SS \"radiogogo cache clean\"\nRemove every cached asset\n")
+
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package cli runs the command line from a tree of command definitions,
+// from which it also generates the shell completions and the man page.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrUnknownCommand is returned when the arguments name a command that doesn't exist.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Command is a command of the command line, or the program itself at the root of the tree.
+type Command struct {
+	// Name is how the command is invoked, e.g. "search".
+	Name string
+	// Aliases are other names the command can be invoked with, left out of the help and completions.
+	Aliases []string
+	// Usage describes the arguments following the flags, e.g. "<terms...>".
+	Usage string
+	// Short is a one-line description of the command, and Long an optional longer one.
+	Short string
+	Long  string
+	// Flags are the flags of the command (nil for none). Their values are set before Setup and Run are called.
+	Flags *flag.FlagSet
+	// Interspersed lets flags follow the arguments, as in "radiogogo search jazz --json".
+	Interspersed bool
+	// Completions are the words its arguments are completed with, e.g. the shells of "completion".
+	Completions []string
+	// CompleteFiles completes its arguments with file names.
+	CompleteFiles bool
+	// Setup, if not nil, runs once its flags are parsed, before any of its subcommands.
+	Setup func() error
+	// Run runs the command with the arguments left after the flags.
+	// A command without Run needs a subcommand.
+	Run func(args []string) error
+	// Commands are its subcommands.
+	Commands []*Command
+
+	parent *Command
+}
+
+// Add adds subcommands to the command.
+func (c *Command) Add(commands ...*Command) {
+	for _, command := range commands {
+		command.parent = c
+		c.Commands = append(c.Commands, command)
+	}
+}
+
+// Execute parses the flags of the command in args, then runs the subcommand named by the next argument, if any,
+// or the command itself. Asking for help with -h or --help prints it and returns nil.
+func (c *Command) Execute(args []string) error {
+
+	flags := c.flagSet()
+	flags.SetOutput(io.Discard)
+
+	var positional []string
+	for {
+		err := flags.Parse(args)
+		if errors.Is(err, flag.ErrHelp) {
+			c.PrintHelp(os.Stdout)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.Path(), err)
+		}
+		args = flags.Args()
+		if !c.Interspersed || len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	args = append(positional, args...)
+
+	if c.Setup != nil {
+		if err := c.Setup(); err != nil {
+			return err
+		}
+	}
+
+	if len(args) > 0 {
+		if sub := c.find(args[0]); sub != nil {
+			return sub.Execute(args[1:])
+		}
+	}
+
+	if c.Run == nil {
+		if len(args) > 0 {
+			return fmt.Errorf("%w %q: see %s --help", ErrUnknownCommand, args[0], c.Path())
+		}
+		c.PrintHelp(os.Stdout)
+		return nil
+	}
+
+	return c.Run(args)
+}
+
+// find returns the subcommand with the given name or alias, or nil.
+func (c *Command) find(name string) *Command {
+	for _, command := range c.Commands {
+		if command.Name == name {
+			return command
+		}
+		for _, alias := range command.Aliases {
+			if alias == name {
+				return command
+			}
+		}
+	}
+	return nil
+}
+
+// flagSet returns the flags of the command, which may have none.
+func (c *Command) flagSet() *flag.FlagSet {
+	if c.Flags == nil {
+		c.Flags = flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	}
+	return c.Flags
+}
+
+// Path returns how the command is invoked from the root, e.g. "radiogogo cache clean".
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// Synopsis returns how the command is used, e.g. "radiogogo search [flags] <terms...>".
+func (c *Command) Synopsis() string {
+	synopsis := c.Path()
+	if c.hasFlags() {
+		synopsis += " [flags]"
+	}
+	if len(c.Commands) > 0 && c.Run != nil {
+		synopsis += " [<command>]"
+	} else if len(c.Commands) > 0 {
+		synopsis += " <command>"
+	}
+	if c.Usage != "" {
+		synopsis += " " + c.Usage
+	}
+	return synopsis
+}
+
+// PrintHelp prints how to use the command, its flags and its subcommands.
+func (c *Command) PrintHelp(w io.Writer) {
+
+	fmt.Fprintf(w, "Usage: %s\n", c.Synopsis())
+	if c.Short != "" {
+		fmt.Fprintf(w, "\n%s\n", c.Short)
+	}
+	if c.Long != "" {
+		fmt.Fprintf(w, "\n%s\n", c.Long)
+	}
+
+	if len(c.Commands) > 0 {
+		fmt.Fprintf(w, "\nCommands:\n")
+		width := 0
+		for _, command := range c.Commands {
+			if len(command.Name) > width {
+				width = len(command.Name)
+			}
+		}
+		for _, command := range c.Commands {
+			fmt.Fprintf(w, "  %-*s  %s\n", width, command.Name, command.Short)
+		}
+	}
+
+	if c.hasFlags() {
+		fmt.Fprintf(w, "\nFlags:\n")
+		c.flagSet().VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(w, "  --%s%s\n      %s\n", f.Name, valuePlaceholder(f), flagUsage(f))
+		})
+	}
+}
+
+func (c *Command) hasFlags() bool {
+	count := 0
+	c.flagSet().VisitAll(func(*flag.Flag) { count++ })
+	return count > 0
+}
+
+// visit calls visit for the command and each of its subcommands, depth first.
+func (c *Command) visit(visit func(command *Command)) {
+	visit(c)
+	for _, command := range c.Commands {
+		command.visit(visit)
+	}
+}
+
+// flags returns the flags of the command, in lexical order.
+func (c *Command) flags() []*flag.Flag {
+	var flags []*flag.Flag
+	c.flagSet().VisitAll(func(f *flag.Flag) {
+		flags = append(flags, f)
+	})
+	return flags
+}
+
+// valuePlaceholder returns " <value>" for a flag that takes a value, and nothing for a boolean one.
+func valuePlaceholder(f *flag.Flag) string {
+	if isBoolFlag(f) {
+		return ""
+	}
+	name, _ := flag.UnquoteUsage(f)
+	if name == "" {
+		name = "value"
+	}
+	return " <" + strings.ToLower(name) + ">"
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
+
+// flagUsage returns the usage of a flag without the backquotes around its value name.
+func flagUsage(f *flag.Flag) string {
+	_, usage := flag.UnquoteUsage(f)
+	return usage
+}
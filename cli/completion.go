@@ -0,0 +1,201 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Shells are the shells completions can be generated for.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// WriteCompletion writes the completion script of the command line for the given shell, one of Shells.
+// Subcommands, flags and the words of Completions are completed, and files where CompleteFiles is set.
+func (c *Command) WriteCompletion(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		return c.writeBashCompletion(w)
+	case "zsh":
+		return c.writeZshCompletion(w)
+	case "fish":
+		return c.writeFishCompletion(w)
+	}
+	return fmt.Errorf("no completions for %q: use %s", shell, strings.Join(Shells, ", "))
+}
+
+// The path of a command in the completion scripts, "" for the root, "/cache/clean" for "radiogogo cache clean"
+func completionPath(command *Command) string {
+	if command.parent == nil {
+		return ""
+	}
+	return completionPath(command.parent) + "/" + command.Name
+}
+
+// completionPaths returns the case patterns matching the paths of every subcommand.
+func (c *Command) completionPaths() string {
+	var paths []string
+	c.visit(func(command *Command) {
+		if command.parent != nil {
+			paths = append(paths, completionPath(command))
+		}
+	})
+	return strings.Join(paths, "|")
+}
+
+// words returns what follows the command: its subcommands, the words of Completions and its flags.
+func (c *Command) words() []string {
+	var words []string
+	for _, command := range c.Commands {
+		words = append(words, command.Name)
+	}
+	words = append(words, c.Completions...)
+	for _, f := range c.flags() {
+		words = append(words, "--"+f.Name)
+	}
+	return words
+}
+
+// firstLine returns the first line of a description, for the shells showing one next to each completion.
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return line
+}
+
+func (c *Command) writeBashCompletion(w io.Writer) error {
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion for %[1]s, generated by \"%[1]s completion bash\"\n\n", c.Name)
+	fmt.Fprintf(&b, "_%s() {\n", c.Name)
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    local cmdpath=\"\" word i\n")
+	b.WriteString("    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	b.WriteString("        word=\"${COMP_WORDS[i]}\"\n")
+	b.WriteString("        case \"$cmdpath/$word\" in\n")
+	fmt.Fprintf(&b, "            %s) cmdpath=\"$cmdpath/$word\" ;;\n", c.completionPaths())
+	b.WriteString("        esac\n")
+	b.WriteString("    done\n")
+	b.WriteString("    case \"$cmdpath\" in\n")
+	c.visit(func(command *Command) {
+		files := ""
+		if command.CompleteFiles {
+			files = " -f"
+		}
+		fmt.Fprintf(&b, "        %q)\n", completionPath(command))
+		fmt.Fprintf(&b, "            COMPREPLY=($(compgen -W %q%s -- \"$cur\")) ;;\n", strings.Join(command.words(), " "), files)
+	})
+	b.WriteString("    esac\n")
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "complete -F _%[1]s %[1]s\n", c.Name)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (c *Command) writeZshCompletion(w io.Writer) error {
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n\n", c.Name)
+	fmt.Fprintf(&b, "# zsh completion for %[1]s, generated by \"%[1]s completion zsh\"\n\n", c.Name)
+	fmt.Fprintf(&b, "_%s() {\n", c.Name)
+	b.WriteString("    local cmdpath=\"\" word i\n")
+	b.WriteString("    local -a entries\n")
+	b.WriteString("    for ((i = 2; i < CURRENT; i++)); do\n")
+	b.WriteString("        word=\"${words[i]}\"\n")
+	b.WriteString("        case \"$cmdpath/$word\" in\n")
+	fmt.Fprintf(&b, "            %s) cmdpath=\"$cmdpath/$word\" ;;\n", c.completionPaths())
+	b.WriteString("        esac\n")
+	b.WriteString("    done\n")
+	b.WriteString("    case \"$cmdpath\" in\n")
+	c.visit(func(command *Command) {
+		var entries []string
+		for _, sub := range command.Commands {
+			entries = append(entries, zshEntry(sub.Name, sub.Short))
+		}
+		for _, word := range command.Completions {
+			entries = append(entries, zshEntry(word, ""))
+		}
+		for _, f := range command.flags() {
+			entries = append(entries, zshEntry("--"+f.Name, flagUsage(f)))
+		}
+		fmt.Fprintf(&b, "        %q)\n", completionPath(command))
+		fmt.Fprintf(&b, "            entries=(%s)\n", strings.Join(entries, " "))
+		b.WriteString("            _describe 'command' entries")
+		if command.CompleteFiles {
+			b.WriteString("\n            _files")
+		}
+		b.WriteString(" ;;\n")
+	})
+	b.WriteString("    esac\n")
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "_%s \"$@\"\n", c.Name)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// zshEntry returns a single-quoted "word:description" entry for _describe.
+func zshEntry(word string, description string) string {
+	entry := strings.ReplaceAll(word, ":", "\\:")
+	if description != "" {
+		entry += ":" + firstLine(description)
+	}
+	return "'" + strings.ReplaceAll(entry, "'", "'\\''") + "'"
+}
+
+func (c *Command) writeFishCompletion(w io.Writer) error {
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %[1]s, generated by \"%[1]s completion fish\"\n\n", c.Name)
+	fmt.Fprintf(&b, "complete -c %s -f\n", c.Name)
+	c.visit(func(command *Command) {
+		condition := "__fish_use_subcommand"
+		if command.parent != nil {
+			condition = "__fish_seen_subcommand_from " + command.Name
+		}
+		prefix := fmt.Sprintf("complete -c %s -n %s", c.Name, fishQuote(condition))
+		for _, sub := range command.Commands {
+			fmt.Fprintf(&b, "%s -a %s -d %s\n", prefix, sub.Name, fishQuote(firstLine(sub.Short)))
+		}
+		if len(command.Completions) > 0 {
+			fmt.Fprintf(&b, "%s -a %s\n", prefix, fishQuote(strings.Join(command.Completions, " ")))
+		}
+		if command.CompleteFiles {
+			fmt.Fprintf(&b, "%s -F\n", prefix)
+		}
+		for _, f := range command.flags() {
+			required := ""
+			if !isBoolFlag(f) {
+				required = " -r"
+			}
+			fmt.Fprintf(&b, "%s -l %s%s -d %s\n", prefix, f.Name, required, fishQuote(firstLine(flagUsage(f))))
+		}
+	})
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// fishQuote single-quotes text for fish.
+func fishQuote(text string) string {
+	text = strings.ReplaceAll(text, "\\", "\\\\")
+	return "'" + strings.ReplaceAll(text, "'", "\\'") + "'"
+}
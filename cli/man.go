@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteManPage writes the man page of the command line, in section 1, for the given version.
+// The root command is described first, followed by each of its subcommands and their flags.
+func (c *Command) WriteManPage(w io.Writer, version string) error {
+
+	var b strings.Builder
+	name := strings.ToUpper(c.Name)
+	fmt.Fprintf(&b, ".TH %s 1 \"\" \"%s %s\" \"User Commands\"\n", name, c.Name, version)
+
+	b.WriteString(".SH NAME\n")
+	fmt.Fprintf(&b, "%s \\- %s\n", roffEscape(c.Name), roffEscape(c.Short))
+
+	b.WriteString(".SH SYNOPSIS\n")
+	c.visit(func(command *Command) {
+		if command.Run != nil {
+			fmt.Fprintf(&b, ".B %s\n.br\n", roffEscape(command.Synopsis()))
+		}
+	})
+
+	if c.Long != "" {
+		b.WriteString(".SH DESCRIPTION\n")
+		writeRoffText(&b, c.Long)
+	}
+
+	if c.hasFlags() {
+		b.WriteString(".SH OPTIONS\n")
+		writeRoffFlags(&b, c.flags())
+	}
+
+	if len(c.Commands) > 0 {
+		b.WriteString(".SH COMMANDS\n")
+		for _, command := range c.Commands {
+			command.visit(func(command *Command) {
+				fmt.Fprintf(&b, ".SS \"%s\"\n", roffEscape(command.Synopsis()))
+				writeRoffText(&b, command.Short)
+				if command.Long != "" {
+					b.WriteString(".PP\n")
+					writeRoffText(&b, command.Long)
+				}
+				writeRoffFlags(&b, command.flags())
+			})
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeRoffFlags(b *strings.Builder, flags []*flag.Flag) {
+	for _, f := range flags {
+		fmt.Fprintf(b, ".TP\n.B %s\n", roffEscape("--"+f.Name+valuePlaceholder(f)))
+		writeRoffText(b, flagUsage(f))
+	}
+}
+
+// writeRoffText writes text as paragraphs, separated by blank lines.
+func writeRoffText(b *strings.Builder, text string) {
+	for i, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if i > 0 {
+			b.WriteString(".PP\n")
+		}
+		for _, line := range strings.Split(paragraph, "\n") {
+			b.WriteString(roffEscape(line) + "\n")
+		}
+	}
+}
+
+// roffEscape escapes text for roff, so that it's printed as is.
+func roffEscape(text string) string {
+	text = strings.ReplaceAll(text, "\\", "\\e")
+	text = strings.ReplaceAll(text, "-", "\\-")
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = "\\&" + text
+	}
+	return text
+}
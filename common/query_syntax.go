@@ -0,0 +1,223 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package common
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+// QueryTokenKind tells what a token of a search query is, e.g. to highlight it.
+type QueryTokenKind int
+
+const (
+	// QueryTokenName is a word of the station name.
+	QueryTokenName QueryTokenKind = iota
+	// QueryTokenField is a field and its operator, e.g. "tag:" or "bitrate>".
+	QueryTokenField
+	// QueryTokenValue is the value a field is matched against, e.g. "jazz".
+	QueryTokenValue
+	// QueryTokenInvalid is a token that couldn't be parsed.
+	QueryTokenInvalid
+)
+
+// QueryToken is a token of a search query, spanning the runes from Start to End (excluded).
+type QueryToken struct {
+	Kind  QueryTokenKind
+	Start int
+	End   int
+}
+
+// SearchQuery is a search query parsed by ParseSearchQuery.
+type SearchQuery struct {
+	// Name is what the station name must contain, made of the words that aren't fields.
+	Name string
+	// Filter narrows the search down, as told by the fields.
+	Filter StationFilter
+	// Tokens are the tokens of the query, in order.
+	Tokens []QueryToken
+}
+
+// QuerySyntaxError tells which token of a search query couldn't be parsed, and why.
+type QuerySyntaxError struct {
+	// Token is the text of the token.
+	Token string
+	// Reason is the i18n key of what's wrong with it, formatted with the token.
+	Reason string
+}
+
+func (e *QuerySyntaxError) Error() string {
+	return i18n.Tf(e.Reason, e.Token)
+}
+
+// queryFields maps the fields of the query language to the filters they set.
+var queryFields = map[string]func(filter *StationFilter, value string){
+	"tag": func(filter *StationFilter, value string) { filter.Tag = strings.ToLower(value) },
+	"country": func(filter *StationFilter, value string) {
+		// Two letters are a country code, e.g. "IT", anything longer a country name.
+		if len([]rune(value)) == 2 {
+			filter.CountryCode = strings.ToUpper(value)
+		} else {
+			filter.Country = value
+		}
+	},
+	"language": func(filter *StationFilter, value string) { filter.Language = strings.ToLower(value) },
+	"state":    func(filter *StationFilter, value string) { filter.State = value },
+	"codec":    func(filter *StationFilter, value string) { filter.Codec = strings.ToUpper(value) },
+}
+
+// queryOperators are the operators separating a field from its value, longest first.
+var queryOperators = []string{">=", "<=", ":", ">", "<"}
+
+// ParseSearchQuery parses the query language of the search box, e.g. `smooth tag:jazz country:IT bitrate>128`:
+//   - tag, country, language, state and codec fields narrow the search down, e.g. `tag:"classic rock"`;
+//     two-letter countries are country codes.
+//   - bitrate is compared in kbps with ":", ">", ">=", "<" or "<=".
+//   - every other word is part of the station name.
+//
+// Values with spaces are quoted. The tokens are returned even if the query can't be parsed,
+// along with a *QuerySyntaxError for the first invalid one.
+func ParseSearchQuery(text string) (SearchQuery, error) {
+	var query SearchQuery
+	var name []string
+	var firstErr error
+	runes := []rune(text)
+	for start := 0; start < len(runes); {
+		if unicode.IsSpace(runes[start]) {
+			start++
+			continue
+		}
+		end, word, closed := scanQueryToken(runes, start)
+		tokens, ok, err := parseQueryField(runes[start:end], word, &query.Filter)
+		if err == nil && !closed {
+			err = &QuerySyntaxError{Token: string(runes[start:end]), Reason: "query.syntax.unclosedQuote"}
+		}
+		switch {
+		case err != nil:
+			query.Tokens = append(query.Tokens, QueryToken{Kind: QueryTokenInvalid, Start: start, End: end})
+			if firstErr == nil {
+				firstErr = err
+			}
+		case ok:
+			for _, token := range tokens {
+				token.Start += start
+				token.End += start
+				query.Tokens = append(query.Tokens, token)
+			}
+		default:
+			name = append(name, word)
+			query.Tokens = append(query.Tokens, QueryToken{Kind: QueryTokenName, Start: start, End: end})
+		}
+		start = end
+	}
+	query.Name = strings.Join(name, " ")
+	return query, firstErr
+}
+
+// scanQueryToken returns where the token starting at start ends, and its text without quotes.
+// Spaces within quotes belong to the token. closed is false if a quote is left open.
+func scanQueryToken(runes []rune, start int) (end int, word string, closed bool) {
+	var b strings.Builder
+	quoted := false
+	for end = start; end < len(runes); end++ {
+		r := runes[end]
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			return end, b.String(), true
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return end, b.String(), !quoted
+}
+
+// parseQueryField parses token as a field, setting it on filter. ok is false if token isn't a field,
+// i.e. it doesn't start with letters followed by an operator. word is token without quotes.
+func parseQueryField(token []rune, word string, filter *StationFilter) (tokens []QueryToken, ok bool, err error) {
+	keyEnd := 0
+	for keyEnd < len(token) && unicode.IsLetter(token[keyEnd]) {
+		keyEnd++
+	}
+	if keyEnd == 0 {
+		return nil, false, nil
+	}
+	operator := ""
+	for _, candidate := range queryOperators {
+		if strings.HasPrefix(string(token[keyEnd:]), candidate) {
+			operator = candidate
+			break
+		}
+	}
+	if operator == "" {
+		return nil, false, nil
+	}
+	key := strings.ToLower(string(token[:keyEnd]))
+	valueStart := keyEnd + len(operator)
+	value := strings.TrimSpace(string([]rune(word)[valueStart:]))
+	tokens = []QueryToken{
+		{Kind: QueryTokenField, Start: 0, End: valueStart},
+		{Kind: QueryTokenValue, Start: valueStart, End: len(token)},
+	}
+	if value == "" {
+		return nil, true, &QuerySyntaxError{Token: string(token), Reason: "query.syntax.emptyValue"}
+	}
+	if key == "bitrate" {
+		return tokens, true, parseBitrateField(string(token), operator, value, filter)
+	}
+	set, known := queryFields[key]
+	if !known {
+		return nil, true, &QuerySyntaxError{Token: string(token[:keyEnd]), Reason: "query.syntax.unknownField"}
+	}
+	if operator != ":" {
+		return nil, true, &QuerySyntaxError{Token: string(token), Reason: "query.syntax.notComparable"}
+	}
+	set(filter, value)
+	return tokens, true, nil
+}
+
+// parseBitrateField sets the bitrate bounds of filter told by `bitrate<operator><value>`.
+// Bounds are inclusive, so "bitrate>128" is a minimum of 129 kbps.
+func parseBitrateField(token string, operator string, value string, filter *StationFilter) error {
+	bitrate, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return &QuerySyntaxError{Token: token, Reason: "query.syntax.invalidBitrate"}
+	}
+	switch operator {
+	case ":":
+		filter.BitrateMin, filter.BitrateMax = bitrate, bitrate
+	case ">":
+		filter.BitrateMin = bitrate + 1
+	case ">=":
+		filter.BitrateMin = bitrate
+	case "<":
+		if bitrate == 0 {
+			return &QuerySyntaxError{Token: token, Reason: "query.syntax.invalidBitrate"}
+		}
+		filter.BitrateMax = bitrate - 1
+	case "<=":
+		filter.BitrateMax = bitrate
+	}
+	return nil
+}
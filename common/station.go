@@ -21,9 +21,12 @@ package common
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/zi0p4tch0/radiogogo/i18n"
 )
 
 // BoolFromlInt represents a boolean value that is converted from an integer value (0 or 1).
@@ -44,10 +47,14 @@ type Station struct {
 	// do a resolve on its own (e.g. JavaScript in browser) or you just don't want to invest
 	// the time in decoding playlists yourself.
 	UrlResolved RadioGoGoURL `json:"url_resolved"`
+	// URL to the homepage of the station
+	Homepage RadioGoGoURL `json:"homepage"`
 	// URL to an icon or picture that represents the stream. (PNG, JPG)
 	Favicon RadioGoGoURL `json:"favicon"`
 	// Tags of the stream with more information about it (string, multivalue, split by comma).
 	Tags string `json:"tags"`
+	// Full name of the country
+	Country string `json:"country"`
 	// Official countrycodes as in ISO 3166-1 alpha-2
 	CountryCode string `json:"countrycode"`
 	// Full name of the entity where the station is located inside the country
@@ -93,6 +100,19 @@ type Station struct {
 	// Is true, if the stream owner does provide extended information as HTTP headers
 	// which override the information in the database.
 	HasExtendedInfo *bool `json:"has_extended_info,omitempty"`
+
+	// Headers are sent along with the requests for the stream. They're set locally, right before the station
+	// is played, and never saved with it.
+	Headers StreamHeaders `json:"-"`
+	// Prefs override how the station is played. Like Headers, they're set locally right before it's played.
+	Prefs StationPrefs `json:"-"`
+}
+
+func (bi BoolFromlInt) MarshalJSON() ([]byte, error) {
+	if bi {
+		return []byte("1"), nil
+	}
+	return []byte("0"), nil
 }
 
 func (bi *BoolFromlInt) UnmarshalJSON(data []byte) error {
@@ -106,3 +126,30 @@ func (bi *BoolFromlInt) UnmarshalJSON(data []byte) error {
 	}
 	return nil
 }
+
+// ErrInvalidStreamURL is returned when a stream URL isn't an absolute URL, e.g. "https://example.com/live.mp3".
+var ErrInvalidStreamURL = i18n.Error("station.invalidStreamUrl")
+
+// ParseStreamURL parses the URL of a stream, which must have a scheme and a host.
+func ParseStreamURL(rawUrl string) (url.URL, error) {
+	streamUrl, err := url.Parse(strings.TrimSpace(rawUrl))
+	if err != nil || streamUrl.Scheme == "" || streamUrl.Host == "" {
+		return url.URL{}, ErrInvalidStreamURL
+	}
+	return *streamUrl, nil
+}
+
+// NewStationFromURL returns a station playing streamUrl, which needn't be listed on radio-browser.
+// Its UUID is derived from the URL, so that the same stream is always the same station, e.g. once bookmarked.
+// It's named after the host of the URL if name is empty.
+func NewStationFromURL(streamUrl url.URL, name string) Station {
+	if name == "" {
+		name = streamUrl.Host
+	}
+	return Station{
+		StationUuid: uuid.NewSHA1(uuid.NameSpaceURL, []byte(streamUrl.String())),
+		Name:        name,
+		Url:         RadioGoGoURL{URL: streamUrl},
+		UrlResolved: RadioGoGoURL{URL: streamUrl},
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package common
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StationCheck is the result of one availability check of a station by a radio-browser server.
+type StationCheck struct {
+	// A globally unique identifier for the check
+	CheckUuid uuid.UUID `json:"checkuuid"`
+	// The station that was checked
+	StationUuid uuid.UUID `json:"stationuuid"`
+	// The radio-browser server that ran the check
+	Source string `json:"source"`
+	// The codec detected by the check
+	Codec string `json:"codec"`
+	// The bitrate detected by the check
+	Bitrate uint64 `json:"bitrate"`
+	// Whether the stream uses HLS
+	Hls BoolFromlInt `json:"hls"`
+	// Whether the stream could be played
+	Ok BoolFromlInt `json:"ok"`
+	// When the check ran
+	Timestamp time.Time `json:"timestamp_iso8601"`
+	// The stream URL the check resolved to
+	UrlCache string `json:"urlcache"`
+	// Whether the check ran into an SSL/TLS error
+	SslError BoolFromlInt `json:"ssl_error"`
+	// The server software reported by the stream
+	ServerSoftware string `json:"server_software"`
+	// How long the check took, in milliseconds
+	TimingMs uint64 `json:"timing_ms"`
+}
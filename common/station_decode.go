@@ -0,0 +1,306 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The layouts of the times radio-browser sends, besides RFC 3339.
+var stationTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+// The leading number of a value such as "128 kbps".
+var leadingNumber = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?`)
+
+// ErrNotStationList is matched by errors returned when what's decoded isn't a JSON array of stations.
+var ErrNotStationList = errors.New("not a list of stations")
+
+// DecodeWarning tells that a field of a station had a value that couldn't be decoded as it was:
+// it was coerced, e.g. "128k" to 128, or left empty.
+type DecodeWarning struct {
+	// Station is the UUID of the station, or its name if its UUID couldn't be decoded.
+	Station string
+	Field   string
+	Value   string
+	// Coerced is true if the value was coerced, false if the field was left empty.
+	Coerced bool
+}
+
+func (w DecodeWarning) String() string {
+	if w.Coerced {
+		return fmt.Sprintf("station %s: coerced odd %s %s", w.Station, w.Field, w.Value)
+	}
+	return fmt.Sprintf("station %s: dropped bad %s %s", w.Station, w.Field, w.Value)
+}
+
+// UnmarshalJSON decodes a station leniently: fields it doesn't know are ignored, and odd values are
+// coerced or left empty rather than failing, so that one of them doesn't lose the whole result set.
+func (s *Station) UnmarshalJSON(data []byte) error {
+	station, _, err := DecodeStation(data)
+	if err != nil {
+		return err
+	}
+	*s = station
+	return nil
+}
+
+// DecodeStation decodes a station as Station.UnmarshalJSON does, also returning what it had to coerce or drop.
+// It only fails if data isn't a JSON object.
+func DecodeStation(data []byte) (Station, []DecodeWarning, error) {
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Station{}, nil, err
+	}
+
+	var station Station
+	var dropped, coerced []string
+	value := reflect.ValueOf(&station).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name := strings.Split(value.Type().Field(i).Tag.Get("json"), ",")[0]
+		raw, ok := fields[name]
+		if !ok || name == "" || name == "-" {
+			continue
+		}
+		field := value.Field(i)
+		if json.Unmarshal(raw, field.Addr().Interface()) == nil {
+			continue
+		}
+		field.Set(reflect.Zero(field.Type()))
+		// radio-browser leaves unknown values, e.g. the last change time, empty
+		if bytes.Equal(bytes.TrimSpace(raw), []byte(`""`)) {
+			continue
+		}
+		if coerceStationField(raw, field.Addr().Interface()) {
+			coerced = append(coerced, name)
+			continue
+		}
+		field.Set(reflect.Zero(field.Type()))
+		dropped = append(dropped, name)
+	}
+
+	id := station.StationUuid.String()
+	if station.StationUuid == uuid.Nil {
+		id = strconv.Quote(station.Name)
+	}
+	var warnings []DecodeWarning
+	for _, name := range coerced {
+		warnings = append(warnings, DecodeWarning{Station: id, Field: name, Value: string(fields[name]), Coerced: true})
+	}
+	for _, name := range dropped {
+		warnings = append(warnings, DecodeWarning{Station: id, Field: name, Value: string(fields[name])})
+	}
+	return station, warnings, nil
+
+}
+
+// DecodeStations decodes a JSON array of stations leniently, as DecodeStation does.
+// Elements that aren't stations at all are skipped with a warning. It only fails if data isn't a JSON array.
+func DecodeStations(data []byte) ([]Station, []DecodeWarning, error) {
+	decoder := NewStationDecoder(bytes.NewReader(data))
+	var stations []Station
+	var warnings []DecodeWarning
+	for {
+		station, stationWarnings, err := decoder.Next()
+		warnings = append(warnings, stationWarnings...)
+		if err == io.EOF {
+			if stations == nil && !decoder.null {
+				stations = []Station{}
+			}
+			return stations, warnings, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		stations = append(stations, station)
+	}
+}
+
+// StationDecoder decodes the stations of a JSON array one at a time as they're read, as DecodeStations does,
+// so that a long list needn't be held in memory at once.
+type StationDecoder struct {
+	decoder *json.Decoder
+	started bool
+	done    bool
+	// null is true if the array turned out to be null.
+	null bool
+	// index is the position of the next element in the array, from 1.
+	index int
+}
+
+// NewStationDecoder returns a decoder reading the JSON array of stations in r.
+func NewStationDecoder(r io.Reader) *StationDecoder {
+	return &StationDecoder{decoder: json.NewDecoder(r), index: 1}
+}
+
+// Next returns the next station of the array, and what had to be coerced or dropped to decode it,
+// or io.EOF once there are no more. Elements that aren't stations at all are skipped, warnings
+// being returned for them with the station that follows.
+func (d *StationDecoder) Next() (Station, []DecodeWarning, error) {
+
+	if !d.started {
+		d.started = true
+		token, err := d.decoder.Token()
+		if err != nil {
+			return Station{}, nil, err
+		}
+		if token == nil {
+			d.null, d.done = true, true
+			return Station{}, nil, io.EOF
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return Station{}, nil, fmt.Errorf("%w: found %v", ErrNotStationList, token)
+		}
+	}
+	if d.done {
+		return Station{}, nil, io.EOF
+	}
+
+	var skipped []DecodeWarning
+	for d.decoder.More() {
+		var element json.RawMessage
+		if err := d.decoder.Decode(&element); err != nil {
+			return Station{}, skipped, err
+		}
+		index := d.index
+		d.index++
+		station, warnings, err := DecodeStation(element)
+		if err != nil {
+			skipped = append(skipped, DecodeWarning{Station: fmt.Sprintf("#%d", index), Field: "station", Value: string(element)})
+			continue
+		}
+		return station, append(skipped, warnings...), nil
+	}
+
+	// The closing bracket
+	if _, err := d.decoder.Token(); err != nil {
+		return Station{}, skipped, err
+	}
+	d.done = true
+	return Station{}, skipped, io.EOF
+
+}
+
+// coerceStationField sets field, a pointer to a field of Station, from an odd raw value,
+// such as a number sent as a string. It returns false if raw can't be made sense of.
+func coerceStationField(raw json.RawMessage, field interface{}) bool {
+
+	text := strings.TrimSpace(string(raw))
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = strings.TrimSpace(unquoted)
+	}
+
+	switch field := field.(type) {
+	case *string:
+		// A number or a boolean, not an object or an array
+		if strings.HasPrefix(text, "{") || strings.HasPrefix(text, "[") {
+			return false
+		}
+		*field = text
+		return true
+	case *uint64:
+		number, ok := parseLeadingNumber(text)
+		if !ok || number < 0 {
+			return false
+		}
+		*field = uint64(number)
+		return true
+	case *int64:
+		number, ok := parseLeadingNumber(text)
+		if !ok {
+			return false
+		}
+		*field = int64(number)
+		return true
+	case **float64:
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return false
+		}
+		*field = &number
+		return true
+	case *BoolFromlInt:
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return false
+		}
+		*field = BoolFromlInt(value)
+		return true
+	case **bool:
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return false
+		}
+		*field = &value
+		return true
+	case *time.Time:
+		value, ok := parseStationTime(text)
+		*field = value
+		return ok
+	case **time.Time:
+		value, ok := parseStationTime(text)
+		if !ok {
+			return false
+		}
+		*field = &value
+		return true
+	case *uuid.UUID:
+		value, err := uuid.Parse(text)
+		*field = value
+		return err == nil
+	case *RadioGoGoURL:
+		value, err := url.Parse(text)
+		if err != nil {
+			return false
+		}
+		field.URL = *value
+		return true
+	}
+	return false
+
+}
+
+// parseLeadingNumber parses the number text starts with, e.g. 128 out of "128 kbps".
+func parseLeadingNumber(text string) (float64, bool) {
+	number, err := strconv.ParseFloat(leadingNumber.FindString(text), 64)
+	return number, err == nil
+}
+
+// parseStationTime parses a time in any of the layouts radio-browser sends.
+func parseStationTime(text string) (time.Time, bool) {
+	for _, layout := range stationTimeLayouts {
+		if value, err := time.Parse(layout, text); err == nil {
+			return value, true
+		}
+	}
+	return time.Time{}, false
+}
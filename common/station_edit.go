@@ -0,0 +1,91 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package common
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+// ErrEmptyStationName is returned when an edit suggestion leaves a station without a name.
+var ErrEmptyStationName = i18n.Error("station.emptyName")
+
+// StationEdit is the metadata of a station, as suggested to radio-browser to fix it.
+type StationEdit struct {
+	Name string
+	// The stream URL
+	Url string
+	// URL to an icon or picture that represents the stream
+	Favicon string
+	// Tags, split by comma
+	Tags string
+	// Official countrycode as in ISO 3166-1 alpha-2
+	CountryCode string
+	State       string
+	// Languages, split by comma
+	Language string
+}
+
+// NewStationEdit returns the metadata of the station as listed, to be edited.
+func NewStationEdit(station Station) StationEdit {
+	return StationEdit{
+		Name:        station.Name,
+		Url:         station.Url.URL.String(),
+		Favicon:     station.Favicon.URL.String(),
+		Tags:        station.Tags,
+		CountryCode: station.CountryCode,
+		State:       station.State,
+		Language:    station.Languages,
+	}
+}
+
+// Validate returns an error if the edit can't be suggested: the station must keep a name and a stream URL.
+func (e StationEdit) Validate() error {
+	if strings.TrimSpace(e.Name) == "" {
+		return ErrEmptyStationName
+	}
+	_, err := ParseStreamURL(e.Url)
+	return err
+}
+
+// Values returns the edit as the form values radio-browser takes, trimmed.
+func (e StationEdit) Values() url.Values {
+	return url.Values{
+		"name":        {strings.TrimSpace(e.Name)},
+		"url":         {strings.TrimSpace(e.Url)},
+		"favicon":     {strings.TrimSpace(e.Favicon)},
+		"tags":        {strings.TrimSpace(e.Tags)},
+		"countrycode": {strings.ToUpper(strings.TrimSpace(e.CountryCode))},
+		"state":       {strings.TrimSpace(e.State)},
+		"language":    {strings.TrimSpace(e.Language)},
+	}
+}
+
+// StationEditResponse represents the response returned by the API when an edit of a station is suggested.
+type StationEditResponse struct {
+	// Ok indicates whether the suggestion was taken or not.
+	Ok bool `json:"ok"`
+
+	// Message contains an optional message returned by the server
+	// (e.g. why the suggestion wasn't taken).
+	Message string `json:"message"`
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package common
+
+// StationFilter narrows a name search down, e.g. to the stations of a country and/or language.
+// Empty fields let every station through.
+type StationFilter struct {
+	// CountryCode is an ISO 3166-1 alpha-2 country code, e.g. "IT".
+	CountryCode string
+	// Language is a language as named by radio-browser, e.g. "italian".
+	Language string
+	// Country is part of a country name, e.g. "Italy".
+	Country string
+	// State is part of a state name, e.g. "Lombardy".
+	State string
+	// Tag is part of a tag, e.g. "jazz".
+	Tag string
+	// Codec is a codec, e.g. "MP3".
+	Codec string
+	// BitrateMin and BitrateMax bound the bitrate in kbps (0 doesn't bound it).
+	BitrateMin uint64
+	BitrateMax uint64
+}
+
+// IsEmpty reports whether the filter lets every station through.
+func (f StationFilter) IsEmpty() bool {
+	return f == StationFilter{}
+}
+
+// Merge returns the filter with the non-empty fields of other replacing its own.
+func (f StationFilter) Merge(other StationFilter) StationFilter {
+	for _, field := range []struct{ value, other *string }{
+		{&f.CountryCode, &other.CountryCode},
+		{&f.Language, &other.Language},
+		{&f.Country, &other.Country},
+		{&f.State, &other.State},
+		{&f.Tag, &other.Tag},
+		{&f.Codec, &other.Codec},
+	} {
+		if *field.other != "" {
+			*field.value = *field.other
+		}
+	}
+	if other.BitrateMin != 0 {
+		f.BitrateMin = other.BitrateMin
+	}
+	if other.BitrateMax != 0 {
+		f.BitrateMax = other.BitrateMax
+	}
+	return f
+}
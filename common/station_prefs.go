@@ -0,0 +1,64 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package common
+
+import "github.com/zi0p4tch0/radiogogo/i18n"
+
+// ReconnectPolicy tells how hard a station is reconnected after its stream stalled or went silent.
+type ReconnectPolicy string
+
+const (
+	// ReconnectDefault reconnects the station a few times in a row before giving up.
+	ReconnectDefault ReconnectPolicy = ""
+	// ReconnectNever stops the station straight away.
+	ReconnectNever ReconnectPolicy = "never"
+	// ReconnectPersistent keeps reconnecting the station much longer, for streams known to drop often.
+	ReconnectPersistent ReconnectPolicy = "persistent"
+)
+
+// ErrInvalidReconnectPolicy is returned for a policy other than "default", "never" and "persistent".
+var ErrInvalidReconnectPolicy = i18n.Error("stationPrefs.invalidReconnect")
+
+// ParseReconnectPolicy parses "default", "never" or "persistent".
+func ParseReconnectPolicy(s string) (ReconnectPolicy, error) {
+	switch ReconnectPolicy(s) {
+	case "default":
+		return ReconnectDefault, nil
+	case ReconnectNever, ReconnectPersistent:
+		return ReconnectPolicy(s), nil
+	}
+	return ReconnectDefault, ErrInvalidReconnectPolicy
+}
+
+// StationPrefs are how a station is played, overriding the configuration.
+// Their zero value plays the station as configured.
+type StationPrefs struct {
+	// HLSBitrate is the bitrate, in kbps, of the HLS variant played (0 for the configured one).
+	HLSBitrate int `json:"hlsBitrate,omitempty"`
+	// Reconnect is how hard the station is reconnected once it stalled or went silent.
+	Reconnect ReconnectPolicy `json:"reconnect,omitempty"`
+	// Backend is the playback engine the station is played with, e.g. "mpv" (empty for the configured one).
+	Backend string `json:"backend,omitempty"`
+}
+
+// IsZero returns true if the preferences don't override anything.
+func (p StationPrefs) IsZero() bool {
+	return p == StationPrefs{}
+}
@@ -19,6 +19,8 @@
 
 package common
 
+import "github.com/zi0p4tch0/radiogogo/i18n"
+
 // StationQuery represents the type of query that can be performed on a radio station.
 type StationQuery string
 
@@ -41,133 +43,43 @@ const (
 	StationQueryByTagExact         StationQuery = "bytagexact"         // Returns radio stations by exact tag.
 )
 
-func (m StationQuery) Render() string {
+// translationKey returns the i18n key for the given property of the query.
+func (m StationQuery) translationKey(property string) string {
 	switch m {
-	case StationQueryByUuid:
-		return "By UUID"
-	case StationQueryByName:
-		return "By Name"
-	case StationQueryByNameExact:
-		return "By Exact Name"
-	case StationQueryByCodec:
-		return "By Codec"
-	case StationQueryByCodecExact:
-		return "By Exact Codec"
-	case StationQueryByCountry:
-		return "By Country"
-	case StationQueryByCountryExact:
-		return "By Exact Country"
-	case StationQueryByCountryCodeExact:
-		return "By Exact Country Code"
-	case StationQueryByState:
-		return "By State"
-	case StationQueryByStateExact:
-		return "By Exact State"
-	case StationQueryByLanguage:
-		return "By Language"
-	case StationQueryByLanguageExact:
-		return "By Exact Language"
-	case StationQueryByTag:
-		return "By Tag"
-	case StationQueryByTagExact:
-		return "By Exact Tag"
+	case StationQueryByUuid,
+		StationQueryByName,
+		StationQueryByNameExact,
+		StationQueryByCodec,
+		StationQueryByCodecExact,
+		StationQueryByCountry,
+		StationQueryByCountryExact,
+		StationQueryByCountryCodeExact,
+		StationQueryByState,
+		StationQueryByStateExact,
+		StationQueryByLanguage,
+		StationQueryByLanguageExact,
+		StationQueryByTag,
+		StationQueryByTagExact:
+		return "query." + string(m) + "." + property
 	}
-	return "None"
+	return "query.none." + property
+}
+
+// Render returns the localized name of the query.
+func (m StationQuery) Render() string {
+	return i18n.T(m.translationKey("name"))
+}
+
+// SearchTitle returns the localized title of the search form for the query.
+func (m StationQuery) SearchTitle() string {
+	return i18n.T(m.translationKey("title"))
 }
 
+// ExampleString returns localized usage examples for the query, or an empty string if there are none.
 func (m StationQuery) ExampleString() string {
-	switch m {
-	case StationQueryByName:
-		return `
-Examples:
-- "BBC Radio" matches stations with "BBC Radio" in their name.
-- "Italia" matches stations with "Italia" in their name.
-- "Romance" matches stations with "Romance" in their name.
-`
-	case StationQueryByNameExact:
-		return `
-Examples:
-- "BBC Radio 1" matches stations with "BBC Radio 1" as their name.
-- "Radio Italia" matches stations with "Radio Italia" as their name.
-- "Radio Romance" mtaches stations with "Radio Romance" as their name.
-`
-	case StationQueryByCodec:
-		return `
-Examples:
-- "mp3" matches stations with "mp3" in their codec.
-- "aac" matches stations with "aac" in their codec.
-- "ogg" matches stations with "ogg" in their codec.
-`
-	case StationQueryByCodecExact:
-		return `
-Examples:
-- "mp3" matches stations with "mp3" as their codec.
-- "aac" matches stations with "aac" as their codec.
-- "ogg" matches stations with "ogg" as their codec.
-`
-	case StationQueryByCountry:
-		return `
-Examples:
-- "Italy" matches stations with "Italy" in their country name.
-- "United" matches stations with "United" in their country name.
-- "Republic" matches stations with "Republic" in their country name.
-`
-	case StationQueryByCountryExact:
-		return `
-Examples:
-- "Italy" matches stations with "Italy" as their country.
-- "Spain" matches stations with "Spain" as their country.
-- "Ireland" matches stations with "Ireland" as their country.
-`
-	case StationQueryByCountryCodeExact:
-		return `
-Examples:
-- "IT" matches stations with "IT" as their country code.
-- "US" matches stations with "US" as their country code.
-- "UK" matches stations with "UK" as their country code.
-`
-	case StationQueryByState:
-		return `
-Examples:
-- "Lombardy" matches stations with "Lombardy" in their state.
-- "California" matches stations with "California" in their state.
-- "New York" matches stations with "New York" in their state.
-`
-	case StationQueryByStateExact:
-		return `
-Examples:
-- "Lombardy" matches stations with "Lombardy" as their state.
-- "California" matches stations with "California" as their state.
-- "New York" matches stations with "New York" as their state.
-`
-	case StationQueryByLanguage:
-		return `
-Examples:
-- "Italian" matches stations with "Italian" in their language.
-- "English" matches stations with "English" in their language.
-- "Spanish" matches stations with "Spanish" in their language.
-`
-	case StationQueryByLanguageExact:
-		return `
-Examples:
-- "Italian" matches stations with "Italian" as their language.
-- "English" matches stations with "English" as their language.
-- "Spanish" matches stations with "Spanish" as their language.
-`
-	case StationQueryByTag:
-		return `
-Examples:
-- "rock" matches stations with "rock" in their tags.
-- "jazz" matches stations with "jazz" in their tags.
-- "pop" matches stations with "pop" in their tags.
-`
-	case StationQueryByTagExact:
-		return `
-Examples:
-- "rock" matches stations with "rock" as their tags.
-- "jazz" matches stations with "jazz" as their tags.
-- "pop" matches stations with "pop" as their tags.
-`
+	examples, ok := i18n.Lookup(m.translationKey("examples"))
+	if !ok {
+		return ""
 	}
-	return ""
+	return "\n" + examples
 }
@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package common
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"golang.org/x/net/http/httpguts"
+)
+
+// ErrInvalidStreamHeader is returned when a header can't be sent, e.g. "Referer: https://example.com".
+var ErrInvalidStreamHeader = i18n.Error("station.invalidHeader")
+
+// StreamHeader is an HTTP header sent along with the requests for the stream of a station.
+type StreamHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// String returns the header as it's sent, e.g. "Referer: https://example.com".
+func (h StreamHeader) String() string {
+	return h.Name + ": " + h.Value
+}
+
+// ParseStreamHeader parses a header written as it's sent, e.g. "Referer: https://example.com".
+// The value may be empty.
+func ParseStreamHeader(line string) (StreamHeader, error) {
+	name, value, ok := strings.Cut(line, ":")
+	header := StreamHeader{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)}
+	if !ok || !httpguts.ValidHeaderFieldName(header.Name) || !httpguts.ValidHeaderFieldValue(header.Value) {
+		return StreamHeader{}, ErrInvalidStreamHeader
+	}
+	return header, nil
+}
+
+// StreamHeaders are the headers some streams require, such as a Referer or a browser's User-Agent.
+type StreamHeaders []StreamHeader
+
+// With returns the headers with header set, replacing the one of the same name, if any.
+// A header without a value is removed instead.
+func (h StreamHeaders) With(header StreamHeader) StreamHeaders {
+	var headers StreamHeaders
+	replaced := false
+	for _, existing := range h {
+		if !strings.EqualFold(existing.Name, header.Name) {
+			headers = append(headers, existing)
+			continue
+		}
+		if !replaced && header.Value != "" {
+			headers = append(headers, header)
+		}
+		replaced = true
+	}
+	if !replaced && header.Value != "" {
+		headers = append(headers, header)
+	}
+	return headers
+}
+
+// Apply sets the headers on req, replacing those it already has, such as RadioGoGo's User-Agent.
+func (h StreamHeaders) Apply(req *http.Request) {
+	for _, header := range h {
+		req.Header.Set(header.Name, header.Value)
+	}
+}
+
+// FFmpegArg returns the headers as ffmpeg's and ffplay's -headers option expects them,
+// each one ending with CRLF.
+func (h StreamHeaders) FFmpegArg() string {
+	var arg strings.Builder
+	for _, header := range h {
+		arg.WriteString(header.String() + "\r\n")
+	}
+	return arg.String()
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package common
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StreamInfo describes a stream, as announced by its server.
+// Fields the server does not announce are left empty.
+type StreamInfo struct {
+	// ContentType is the media type of the stream, e.g. "audio/mpeg".
+	ContentType string
+	// Codec is a short name for the format of the stream, e.g. "MP3" or "HLS".
+	Codec string
+	// Bitrate is the announced bitrate, in kbps.
+	Bitrate int
+	// Name is the station name announced by the server.
+	Name string
+	// Latency is how long the server took to send the first byte of the stream.
+	Latency time.Duration
+}
+
+// Summary describes the stream in a few words, e.g. "MP3 · 128 kbps · 180 ms".
+func (i StreamInfo) Summary() string {
+	var parts []string
+	if i.Codec != "" {
+		parts = append(parts, i.Codec)
+	}
+	if i.Bitrate > 0 {
+		parts = append(parts, fmt.Sprintf("%d kbps", i.Bitrate))
+	}
+	if i.Latency > 0 {
+		parts = append(parts, fmt.Sprintf("%d ms", i.Latency.Milliseconds()))
+	}
+	return strings.Join(parts, " · ")
+}
@@ -29,6 +29,10 @@ type RadioGoGoURL struct {
 	URL url.URL
 }
 
+func (m RadioGoGoURL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.URL.String())
+}
+
 func (m *RadioGoGoURL) UnmarshalJSON(data []byte) error {
 
 	// Unquote the JSON string
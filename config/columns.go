@@ -0,0 +1,81 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package config
+
+import (
+	"errors"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StationColumnNames lists the columns the stations table can show.
+var StationColumnNames = []string{"name", "country", "codec", "bitrate", "votes", "tags", "language", "clicks"}
+
+// StationColumn is a column of the stations table.
+type StationColumn struct {
+	// Name is one of StationColumnNames.
+	Name string `yaml:"name"`
+	// Width is the width of the column, in characters. Zero uses the default width.
+	Width int `yaml:"width,omitempty"`
+}
+
+// UnmarshalYAML decodes a column, which can also be given as just its name.
+func (c *StationColumn) UnmarshalYAML(value *yaml.Node) error {
+	var column struct {
+		Name  string `yaml:"name"`
+		Width int    `yaml:"width"`
+	}
+	if value.Kind == yaml.ScalarNode {
+		if err := value.Decode(&column.Name); err != nil {
+			return err
+		}
+	} else if err := value.Decode(&column); err != nil {
+		return err
+	}
+
+	for _, name := range StationColumnNames {
+		if column.Name == name {
+			*c = StationColumn{Name: column.Name, Width: column.Width}
+			return nil
+		}
+	}
+	return errors.New("invalid station column: " + column.Name)
+}
+
+// DefaultStationColumns returns the columns shown when none are configured.
+func DefaultStationColumns() []StationColumn {
+	return []StationColumn{
+		{Name: "name", Width: 30},
+		{Name: "country", Width: 10},
+		{Name: "language", Width: 15},
+		{Name: "codec", Width: 15},
+		{Name: "votes", Width: 10},
+	}
+}
+
+// SaveStationColumns updates the columns in the configuration file at the given path, keeping the other settings.
+func SaveStationColumns(path string, columns []StationColumn) error {
+	cfg := NewDefaultConfig()
+	if err := cfg.Load(path); err != nil {
+		return err
+	}
+	cfg.Stations.Columns = columns
+	return cfg.Save(path)
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package config
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Migration is a file or directory moved from where earlier versions stored it.
+type Migration struct {
+	From string
+	To   string
+}
+
+// MigrateConfig moves the configuration files of every profile from where earlier versions stored them
+// to RootDir, if they aren't there already. Nothing is moved if the directory was set with SetConfigDir.
+func MigrateConfig() ([]Migration, error) {
+	if configDirOverride != "" {
+		return nil, nil
+	}
+	return migrate(legacyMoves(RootDir(), "config.yaml", "labels.json", "bookmarks.json"))
+}
+
+// MigrateData moves the data and cache of every profile from where earlier versions stored them
+// to DataRootDir and CacheDir, if they aren't there already. Nothing is moved if the configuration
+// directory was set with SetConfigDir.
+func MigrateData() ([]Migration, error) {
+	if configDirOverride != "" {
+		return nil, nil
+	}
+	moves := legacyMoves(DataRootDir(), "radiogogo.db", "radiogogo.log", "recordings")
+	moves = append(moves,
+		Migration{From: filepath.Join(legacyRootDir(), "catalog.db"), To: CatalogFile()},
+		Migration{From: filepath.Join(legacyRootDir(), "assets"), To: AssetsDir()},
+	)
+	return migrate(moves)
+}
+
+// legacyMoves returns the moves of the named files of every profile from legacyRootDir to root.
+func legacyMoves(root string, names ...string) []Migration {
+	legacy := legacyRootDir()
+	dirs := map[string]string{legacy: root}
+	entries, _ := os.ReadDir(filepath.Join(legacy, "profiles"))
+	for _, entry := range entries {
+		if entry.IsDir() && profileNamePattern.MatchString(entry.Name()) {
+			dirs[filepath.Join(legacy, "profiles", entry.Name())] = filepath.Join(root, "profiles", entry.Name())
+		}
+	}
+	var moves []Migration
+	for from, to := range dirs {
+		for _, name := range names {
+			moves = append(moves, Migration{From: filepath.Join(from, name), To: filepath.Join(to, name)})
+		}
+	}
+	return moves
+}
+
+// migrate makes the moves whose source exists and whose destination doesn't, returning those made.
+func migrate(moves []Migration) ([]Migration, error) {
+	var made []Migration
+	for _, move := range moves {
+		if filepath.Clean(move.From) == filepath.Clean(move.To) {
+			continue
+		}
+		if _, err := os.Lstat(move.From); err != nil {
+			continue
+		}
+		if _, err := os.Lstat(move.To); !errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err := moveFile(move.From, move.To); err != nil {
+			return made, err
+		}
+		made = append(made, move)
+	}
+	return made, nil
+}
+
+// moveFile moves a file or directory, copying it if it can't be renamed, e.g. to another file system.
+func moveFile(from string, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	if err := copyTree(from, to); err != nil {
+		_ = os.RemoveAll(to)
+		return err
+	}
+	return os.RemoveAll(from)
+}
+
+// copyTree copies the file or directory at from to to, recursively.
+func copyTree(from string, to string) error {
+	return filepath.WalkDir(from, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(from string, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(to, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
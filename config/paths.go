@@ -1,27 +1,250 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
 )
 
-// ConfigDir returns the path to the directory where the application's configuration files are stored.
-// On Windows, the directory is %LOCALAPPDATA%\radiogogo.
-// On other platforms, the directory is ~/.config/radiogogo.
-func ConfigDir() string {
-	var cfgDir string
+// The profile in use, or an empty string for the default one.
+var profile string
+
+// A profile name: letters, digits, dashes and underscores.
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ErrInvalidProfile is matched by errors returned when a profile name can't be used as a directory name.
+var ErrInvalidProfile = i18n.Error("config.invalidProfile")
+
+// DefaultProfile is the name of the profile whose files are stored directly in the root directory.
+const DefaultProfile = "default"
+
+// The directories set by SetConfigDir, SetDataDir and SetCacheDir, empty for the default ones.
+var configDirOverride, dataDirOverride, cacheDirOverride string
+
+// RootDir returns the directory of the configuration files: $XDG_CONFIG_HOME/radiogogo, ~/.config/radiogogo
+// by default, or %LOCALAPPDATA%\radiogogo on Windows. The default profile is stored here,
+// and the other profiles in its "profiles" subdirectory.
+func RootDir() string {
+	if configDirOverride != "" {
+		return configDirOverride
+	}
+	return userDir("XDG_CONFIG_HOME", ".config")
+}
+
+// DataRootDir returns the directory of the data, such as bookmarks, history and recordings:
+// $XDG_DATA_HOME/radiogogo, ~/.local/share/radiogogo by default, or %LOCALAPPDATA%\radiogogo on Windows.
+// Profiles are stored as in RootDir.
+func DataRootDir() string {
+	switch {
+	case dataDirOverride != "":
+		return dataDirOverride
+	case configDirOverride != "":
+		return configDirOverride
+	}
+	return userDir("XDG_DATA_HOME", ".local", "share")
+}
+
+// CacheDir returns the directory of the files that can be fetched again, shared by all profiles:
+// $XDG_CACHE_HOME/radiogogo, ~/.cache/radiogogo by default, or %LOCALAPPDATA%\radiogogo\cache on Windows.
+func CacheDir() string {
+	switch {
+	case cacheDirOverride != "":
+		return cacheDirOverride
+	case configDirOverride != "":
+		return filepath.Join(configDirOverride, "cache")
+	case runtime.GOOS == "windows":
+		return filepath.Join(legacyRootDir(), "cache")
+	}
+	return userDir("XDG_CACHE_HOME", ".cache")
+}
+
+// userDir returns the radiogogo directory in the base directory named by the XDG environment variable,
+// or in the given fallback under the home directory if it isn't set. Windows keeps everything in legacyRootDir.
+func userDir(variable string, fallback ...string) string {
 	if runtime.GOOS == "windows" {
-		localAppData := os.Getenv("LOCALAPPDATA")
-		cfgDir = filepath.Join(localAppData, "radiogogo")
-	} else {
-		home := os.Getenv("HOME")
-		cfgDir = filepath.Join(home, ".config", "radiogogo")
+		return legacyRootDir()
+	}
+	// The specification asks to ignore relative paths
+	if base := os.Getenv(variable); filepath.IsAbs(base) {
+		return filepath.Join(base, "radiogogo")
+	}
+	return filepath.Join(append(append([]string{os.Getenv("HOME")}, fallback...), "radiogogo")...)
+}
+
+// legacyRootDir returns the directory where earlier versions stored every file.
+func legacyRootDir() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), "radiogogo")
+	}
+	return filepath.Join(os.Getenv("HOME"), ".config", "radiogogo")
+}
+
+// SetConfigDir makes dir the directory of the configuration files, as with the --config-dir flag,
+// and of the data and cache unless they're set otherwise. An empty dir switches back to the default.
+func SetConfigDir(dir string) error {
+	dir, err := absDir(dir)
+	configDirOverride = dir
+	return err
+}
+
+// SetDataDir makes dir the directory of the data, as set in the configuration. An empty dir switches back to the default.
+func SetDataDir(dir string) error {
+	dir, err := absDir(dir)
+	dataDirOverride = dir
+	return err
+}
+
+// SetCacheDir makes dir the directory of the cache, as set in the configuration. An empty dir switches back to the default.
+func SetCacheDir(dir string) error {
+	dir, err := absDir(dir)
+	cacheDirOverride = dir
+	return err
+}
+
+// absDir returns dir as an absolute path, expanding a leading "~" to the home directory.
+// An empty dir stays empty.
+func absDir(dir string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, dir[1:])
+	}
+	return filepath.Abs(dir)
+}
+
+// ConfigDir returns the directory of the configuration file of the profile in use.
+func ConfigDir() string {
+	return profileDir(RootDir())
+}
+
+// DataDir returns the directory of the data of the profile in use, holding its database and recordings.
+func DataDir() string {
+	return profileDir(DataRootDir())
+}
+
+// profileDir returns the directory of the profile in use in root.
+func profileDir(root string) string {
+	if profile == "" {
+		return root
+	}
+	return filepath.Join(root, "profiles", profile)
+}
+
+// Profile returns the name of the profile in use.
+func Profile() string {
+	if profile == "" {
+		return DefaultProfile
 	}
-	return cfgDir
+	return profile
+}
+
+// SetProfile switches to the profile with the given name, so that the paths returned by this package
+// point to its directory. An empty name or DefaultProfile switches back to the default profile.
+// The directory is created when the configuration is loaded.
+func SetProfile(name string) error {
+	if name == "" || name == DefaultProfile {
+		profile = ""
+		return nil
+	}
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidProfile, name)
+	}
+	profile = name
+	return nil
+}
+
+// ProfileNames returns the names of the existing profiles, starting with DefaultProfile.
+func ProfileNames() ([]string, error) {
+	names := []string{DefaultProfile}
+	entries, err := os.ReadDir(filepath.Join(RootDir(), "profiles"))
+	if errors.Is(err, os.ErrNotExist) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != DefaultProfile && profileNamePattern.MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
 }
 
 // ConfigFile returns the path to the configuration file.
 func ConfigFile() string {
 	return filepath.Join(ConfigDir(), "config.yaml")
 }
+
+// DatabaseFile returns the path to the database storing bookmarks, labels, history and cached data.
+func DatabaseFile() string {
+	return filepath.Join(DataDir(), "radiogogo.db")
+}
+
+// LogFile returns the path to the log of events, such as stations being reconnected.
+func LogFile() string {
+	return filepath.Join(DataDir(), "radiogogo.log")
+}
+
+// LabelsFile returns the path to the JSON file where earlier versions stored custom station labels.
+func LabelsFile() string {
+	return filepath.Join(ConfigDir(), "labels.json")
+}
+
+// BookmarksFile returns the path to the JSON file where earlier versions stored bookmarked stations.
+func BookmarksFile() string {
+	return filepath.Join(ConfigDir(), "bookmarks.json")
+}
+
+// SocketFile returns the path to the socket used to reach the running instance, in $XDG_RUNTIME_DIR if set.
+// It's shared by all profiles, so that only one of them plays at a time.
+func SocketFile() string {
+	if base := os.Getenv("XDG_RUNTIME_DIR"); configDirOverride == "" && runtime.GOOS != "windows" && filepath.IsAbs(base) {
+		return filepath.Join(base, "radiogogo.sock")
+	}
+	return filepath.Join(RootDir(), "radiogogo.sock")
+}
+
+// CatalogFile returns the path of the offline catalog snapshot written by "radiogogo sync".
+// It's shared by all profiles.
+func CatalogFile() string {
+	return filepath.Join(DataRootDir(), "catalog.db")
+}
+
+// SecretsDir returns the directory the encrypted secrets file is kept in, when there's no OS keychain.
+// It's shared by all profiles.
+func SecretsDir() string {
+	return DataRootDir()
+}
+
+// AssetsDir returns the directory fetched assets, such as station favicons, are cached in.
+// It's shared by all profiles.
+func AssetsDir() string {
+	return filepath.Join(CacheDir(), "assets")
+}
+
+// BookmarkSyncDir returns the directory of the working copy of the Git repository the bookmarks are synced through.
+func BookmarkSyncDir() string {
+	return filepath.Join(DataDir(), "bookmark-sync")
+}
+
+// BookmarkBackupsDir returns the directory the bookmarks replaced when syncing are backed up to.
+func BookmarkBackupsDir() string {
+	return filepath.Join(DataDir(), "bookmark-backups")
+}
+
+// RecordingsDir returns the directory recordings are saved to, unless configured otherwise.
+func RecordingsDir() string {
+	return filepath.Join(DataDir(), "recordings")
+}
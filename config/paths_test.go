@@ -0,0 +1,177 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaths(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("every directory comes from LOCALAPPDATA")
+	}
+
+	t.Run("follows the XDG base directories", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		t.Setenv("XDG_CONFIG_HOME", "")
+		t.Setenv("XDG_DATA_HOME", "")
+		t.Setenv("XDG_CACHE_HOME", "relative/paths/are/ignored")
+		t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+
+		assert.Equal(t, filepath.Join(home, ".config", "radiogogo", "config.yaml"), ConfigFile())
+		assert.Equal(t, filepath.Join(home, ".local", "share", "radiogogo", "radiogogo.db"), DatabaseFile())
+		assert.Equal(t, filepath.Join(home, ".local", "share", "radiogogo", "recordings"), RecordingsDir())
+		assert.Equal(t, filepath.Join(home, ".local", "share", "radiogogo", "catalog.db"), CatalogFile())
+		assert.Equal(t, filepath.Join(home, ".cache", "radiogogo", "assets"), AssetsDir())
+		assert.Equal(t, "/run/user/1000/radiogogo.sock", SocketFile())
+
+		t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+		t.Setenv("XDG_DATA_HOME", "/xdg/data")
+		t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+
+		assert.Equal(t, "/xdg/config/radiogogo/config.yaml", ConfigFile())
+		assert.Equal(t, "/xdg/data/radiogogo/radiogogo.db", DatabaseFile())
+		assert.Equal(t, "/xdg/cache/radiogogo/assets", AssetsDir())
+	})
+
+	t.Run("keeps everything in the configuration directory set", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+		defer SetConfigDir("")
+
+		assert.NoError(t, SetConfigDir("/portable/radiogogo"))
+
+		assert.Equal(t, "/portable/radiogogo/config.yaml", ConfigFile())
+		assert.Equal(t, "/portable/radiogogo/radiogogo.db", DatabaseFile())
+		assert.Equal(t, "/portable/radiogogo/cache/assets", AssetsDir())
+		assert.Equal(t, "/portable/radiogogo/radiogogo.sock", SocketFile())
+	})
+
+	t.Run("overrides the data and cache directories", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		defer SetDataDir("")
+		defer SetCacheDir("")
+		defer SetProfile("")
+
+		assert.NoError(t, SetDataDir("~/Radio"))
+		assert.NoError(t, SetCacheDir("/tmp/radiogogo"))
+		assert.NoError(t, SetProfile("kids"))
+
+		assert.Equal(t, filepath.Join(home, "Radio", "profiles", "kids", "radiogogo.db"), DatabaseFile())
+		assert.Equal(t, filepath.Join(home, "Radio", "catalog.db"), CatalogFile())
+		assert.Equal(t, "/tmp/radiogogo/assets", AssetsDir())
+	})
+
+}
+
+func TestMigrate(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("every directory comes from LOCALAPPDATA")
+	}
+
+	write := func(t *testing.T, path string, content string) {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	t.Run("moves the files of every profile the first time", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg-config"))
+		t.Setenv("XDG_DATA_HOME", "")
+		t.Setenv("XDG_CACHE_HOME", "")
+
+		legacy := filepath.Join(home, ".config", "radiogogo")
+		write(t, filepath.Join(legacy, "config.yaml"), "language: it")
+		write(t, filepath.Join(legacy, "radiogogo.db"), "default")
+		write(t, filepath.Join(legacy, "profiles", "kids", "config.yaml"), "language: fr")
+		write(t, filepath.Join(legacy, "profiles", "kids", "radiogogo.db"), "kids")
+		write(t, filepath.Join(legacy, "profiles", "kids", "recordings", "show.mp3"), "audio")
+		write(t, filepath.Join(legacy, "assets", "favicon"), "png")
+
+		migrated, err := MigrateConfig()
+		assert.NoError(t, err)
+		assert.Len(t, migrated, 2)
+		assert.FileExists(t, filepath.Join(home, "xdg-config", "radiogogo", "profiles", "kids", "config.yaml"))
+
+		migrated, err = MigrateData()
+		assert.NoError(t, err)
+		assert.Len(t, migrated, 4)
+		data := filepath.Join(home, ".local", "share", "radiogogo")
+		content, _ := os.ReadFile(filepath.Join(data, "profiles", "kids", "radiogogo.db"))
+		assert.Equal(t, "kids", string(content))
+		assert.FileExists(t, filepath.Join(data, "radiogogo.db"))
+		assert.FileExists(t, filepath.Join(data, "profiles", "kids", "recordings", "show.mp3"))
+		assert.FileExists(t, filepath.Join(home, ".cache", "radiogogo", "assets", "favicon"))
+		assert.NoFileExists(t, filepath.Join(legacy, "radiogogo.db"))
+
+		migrated, err = MigrateData()
+		assert.NoError(t, err)
+		assert.Empty(t, migrated)
+	})
+
+	t.Run("doesn't replace files already moved", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		t.Setenv("XDG_DATA_HOME", "")
+
+		write(t, filepath.Join(home, ".config", "radiogogo", "radiogogo.db"), "old")
+		write(t, filepath.Join(home, ".local", "share", "radiogogo", "radiogogo.db"), "new")
+
+		migrated, err := MigrateData()
+		assert.NoError(t, err)
+		assert.Empty(t, migrated)
+		content, _ := os.ReadFile(filepath.Join(home, ".local", "share", "radiogogo", "radiogogo.db"))
+		assert.Equal(t, "new", string(content))
+	})
+
+	t.Run("leaves the files alone with a configuration directory set", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		defer SetConfigDir("")
+
+		write(t, filepath.Join(home, ".config", "radiogogo", "radiogogo.db"), "old")
+		assert.NoError(t, SetConfigDir(filepath.Join(home, "portable")))
+
+		migrated, err := MigrateData()
+		assert.NoError(t, err)
+		assert.Empty(t, migrated)
+	})
+
+	t.Run("copies directories it can't rename", func(t *testing.T) {
+		from := filepath.Join(t.TempDir(), "recordings")
+		to := filepath.Join(t.TempDir(), "data", "recordings")
+		write(t, filepath.Join(from, "a", "show.mp3"), "audio")
+
+		assert.NoError(t, copyTree(from, to))
+		content, _ := os.ReadFile(filepath.Join(to, "a", "show.mp3"))
+		assert.Equal(t, "audio", string(content))
+	})
+
+}
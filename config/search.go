@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package config
+
+import (
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+// SearchDefaults narrow plain name searches down to a country and a language.
+type SearchDefaults struct {
+	// DefaultCountryCode is an ISO 3166-1 alpha-2 country code, e.g. "IT" (empty for any country).
+	DefaultCountryCode string `yaml:"defaultCountryCode"`
+	// DefaultLanguage is a language as named by radio-browser, e.g. "italian" (empty for any language).
+	DefaultLanguage string `yaml:"defaultLanguage"`
+}
+
+// localeLanguages maps ISO 639-1 codes to the language names used by radio-browser.
+var localeLanguages = map[string]string{
+	"ar": "arabic",
+	"bg": "bulgarian",
+	"ca": "catalan",
+	"cs": "czech",
+	"da": "danish",
+	"de": "german",
+	"el": "greek",
+	"en": "english",
+	"es": "spanish",
+	"et": "estonian",
+	"fa": "persian",
+	"fi": "finnish",
+	"fr": "french",
+	"he": "hebrew",
+	"hi": "hindi",
+	"hr": "croatian",
+	"hu": "hungarian",
+	"id": "indonesian",
+	"it": "italian",
+	"ja": "japanese",
+	"ko": "korean",
+	"lt": "lithuanian",
+	"lv": "latvian",
+	"nb": "norwegian",
+	"nl": "dutch",
+	"no": "norwegian",
+	"pl": "polish",
+	"pt": "portuguese",
+	"ro": "romanian",
+	"ru": "russian",
+	"sk": "slovak",
+	"sl": "slovenian",
+	"sr": "serbian",
+	"sv": "swedish",
+	"th": "thai",
+	"tr": "turkish",
+	"uk": "ukrainian",
+	"vi": "vietnamese",
+	"zh": "chinese",
+}
+
+// DetectSearchDefaults returns the search defaults matching the system locale
+// (e.g. "it_IT.UTF-8" gives "IT" and "italian"), leaving out what it doesn't specify.
+func DetectSearchDefaults() SearchDefaults {
+	return searchDefaultsForLocale(i18n.SystemLocale())
+}
+
+func searchDefaultsForLocale(locale string) SearchDefaults {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	language, territory := locale, ""
+	if i := strings.IndexAny(locale, "_-"); i >= 0 {
+		language, territory = locale[:i], locale[i+1:]
+	}
+
+	var defaults SearchDefaults
+	if len(territory) == 2 {
+		defaults.DefaultCountryCode = strings.ToUpper(territory)
+	}
+	defaults.DefaultLanguage = localeLanguages[strings.ToLower(language)]
+	return defaults
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package config
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StartupView is what RadioGoGo opens into.
+type StartupView string
+
+const (
+	// StartupSearch opens the search form.
+	StartupSearch StartupView = "search"
+	// StartupBookmarks opens the bookmarks.
+	StartupBookmarks StartupView = "bookmarks"
+	// StartupHistory lists the stations played lately.
+	StartupHistory StartupView = "history"
+	// StartupLast shows the results of the last search again.
+	StartupLast StartupView = "last"
+	// StartupResume plays the last station played again.
+	StartupResume StartupView = "resume"
+)
+
+// StartupViews lists the views RadioGoGo can open into.
+var StartupViews = []StartupView{StartupSearch, StartupBookmarks, StartupHistory, StartupLast, StartupResume}
+
+// ParseStartupView returns the startup view with the given name.
+func ParseStartupView(name string) (StartupView, error) {
+	for _, view := range StartupViews {
+		if StartupView(name) == view {
+			return view, nil
+		}
+	}
+	names := make([]string, len(StartupViews))
+	for i, view := range StartupViews {
+		names[i] = string(view)
+	}
+	return "", errors.New("invalid startup view " + name + " (expected one of " + strings.Join(names, ", ") + ")")
+}
+
+func (v *StartupView) UnmarshalYAML(value *yaml.Node) error {
+	var name string
+	if err := value.Decode(&name); err != nil {
+		return err
+	}
+	// Saving the configuration writes an empty view when none is set
+	if name == "" {
+		*v = ""
+		return nil
+	}
+	view, err := ParseStartupView(name)
+	if err != nil {
+		return err
+	}
+	*v = view
+	return nil
+}
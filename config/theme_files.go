@@ -0,0 +1,212 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrThemeNotFound is matched by the errors of LoadThemeFile when there's no theme file with the given name.
+var ErrThemeNotFound = i18n.Error("config.themeNotFound")
+
+// ThemeBorders are the border names a theme file can use, "none" removing the border.
+var ThemeBorders = []string{"normal", "rounded", "thick", "double", "hidden", "block", "none"}
+
+// ThemeFile is a theme shared as a file in the themes directory: its colors and the styles it overrides.
+//
+//	colors:
+//	    primaryColor: '#ff79c6'
+//	styles:
+//	    tableHeader:
+//	        border: double
+//	        bold: true
+type ThemeFile struct {
+	// Colors replace those of the configuration, the ones left empty being kept.
+	Colors ThemeColors `yaml:"colors"`
+	Styles ThemeStyles `yaml:"styles"`
+}
+
+// ThemeStyles are the styles of the user interface a theme file can override, each property left out
+// being drawn as the colors alone would.
+type ThemeStyles struct {
+	// The blocks of the bottom bar, alternating
+	PrimaryBlock   StyleOverride `yaml:"primaryBlock"`
+	SecondaryBlock StyleOverride `yaml:"secondaryBlock"`
+
+	Text          StyleOverride `yaml:"text"`
+	PrimaryText   StyleOverride `yaml:"primaryText"`
+	SecondaryText StyleOverride `yaml:"secondaryText"`
+	TertiaryText  StyleOverride `yaml:"tertiaryText"`
+	ErrorText     StyleOverride `yaml:"errorText"`
+
+	// The header, the cells and the highlighted row of the station tables
+	TableHeader   StyleOverride `yaml:"tableHeader"`
+	TableCell     StyleOverride `yaml:"tableCell"`
+	TableSelected StyleOverride `yaml:"tableSelected"`
+}
+
+// StyleOverride are the properties of a style a theme file sets. Those left out (nil) are kept.
+type StyleOverride struct {
+	Foreground    *string `yaml:"foreground"`
+	Background    *string `yaml:"background"`
+	Bold          *bool   `yaml:"bold"`
+	Italic        *bool   `yaml:"italic"`
+	Underline     *bool   `yaml:"underline"`
+	Faint         *bool   `yaml:"faint"`
+	Reverse       *bool   `yaml:"reverse"`
+	Strikethrough *bool   `yaml:"strikethrough"`
+	// Padding and Margin take one to four values, in the order of CSS: top, right, bottom, left.
+	Padding []int `yaml:"padding"`
+	Margin  []int `yaml:"margin"`
+	// Border is one of ThemeBorders. It's drawn on every side unless some are picked.
+	Border           *string `yaml:"border"`
+	BorderForeground *string `yaml:"borderForeground"`
+	BorderTop        *bool   `yaml:"borderTop"`
+	BorderRight      *bool   `yaml:"borderRight"`
+	BorderBottom     *bool   `yaml:"borderBottom"`
+	BorderLeft       *bool   `yaml:"borderLeft"`
+}
+
+// validate returns an error naming the property of the style called name that can't be drawn.
+func (o StyleOverride) validate(name string) error {
+	if o.Border != nil && !containsString(ThemeBorders, *o.Border) {
+		return fmt.Errorf("%s: invalid border %q (expected one of %s)", name, *o.Border, strings.Join(ThemeBorders, ", "))
+	}
+	if len(o.Padding) > 4 {
+		return fmt.Errorf("%s: padding takes one to four values", name)
+	}
+	if len(o.Margin) > 4 {
+		return fmt.Errorf("%s: margin takes one to four values", name)
+	}
+	return nil
+}
+
+// Over returns colors with the ones set in t in their place.
+func (t ThemeColors) Over(colors ThemeColors) ThemeColors {
+	for _, color := range []struct {
+		override string
+		base     *string
+	}{
+		{t.TextColor, &colors.TextColor},
+		{t.PrimaryColor, &colors.PrimaryColor},
+		{t.SecondaryColor, &colors.SecondaryColor},
+		{t.TertiaryColor, &colors.TertiaryColor},
+		{t.ErrorColor, &colors.ErrorColor},
+	} {
+		if color.override != "" {
+			*color.base = color.override
+		}
+	}
+	return colors
+}
+
+// ThemesDir returns the directory theme files are loaded from, shared by all profiles.
+func ThemesDir() string {
+	return filepath.Join(RootDir(), "themes")
+}
+
+// LoadThemeFile loads the theme file with the given name, "<name>.yaml" or "<name>.yml" in ThemesDir.
+// Unknown properties are reported, so that typos don't go unnoticed.
+func LoadThemeFile(name string) (ThemeFile, error) {
+	if !profileNamePattern.MatchString(name) {
+		return ThemeFile{}, fmt.Errorf("%w: %s", ErrThemeNotFound, name)
+	}
+	var theme ThemeFile
+	for _, extension := range []string{".yaml", ".yml"} {
+		file, err := os.Open(filepath.Join(ThemesDir(), name+extension))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return ThemeFile{}, err
+		}
+		defer file.Close()
+
+		decoder := yaml.NewDecoder(file)
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&theme); err != nil {
+			return ThemeFile{}, fmt.Errorf("%s: %w", file.Name(), err)
+		}
+		if err := theme.Styles.validate(); err != nil {
+			return ThemeFile{}, fmt.Errorf("%s: %w", file.Name(), err)
+		}
+		return theme, nil
+	}
+	return ThemeFile{}, fmt.Errorf("%w: %s", ErrThemeNotFound, name)
+}
+
+// validate returns an error if any of the styles can't be drawn.
+func (s ThemeStyles) validate() error {
+	for _, style := range []struct {
+		name     string
+		override StyleOverride
+	}{
+		{"primaryBlock", s.PrimaryBlock},
+		{"secondaryBlock", s.SecondaryBlock},
+		{"text", s.Text},
+		{"primaryText", s.PrimaryText},
+		{"secondaryText", s.SecondaryText},
+		{"tertiaryText", s.TertiaryText},
+		{"errorText", s.ErrorText},
+		{"tableHeader", s.TableHeader},
+		{"tableCell", s.TableCell},
+		{"tableSelected", s.TableSelected},
+	} {
+		if err := style.override.validate(style.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ThemeFileNames returns the names of the theme files in ThemesDir, sorted, or none if it can't be read.
+func ThemeFileNames() []string {
+	entries, err := os.ReadDir(ThemesDir())
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		extension := filepath.Ext(entry.Name())
+		name := strings.TrimSuffix(entry.Name(), extension)
+		if !entry.IsDir() && (extension == ".yaml" || extension == ".yml") && profileNamePattern.MatchString(name) && !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package config
+
+import (
+	"errors"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ThemeColors are the colors of the user interface.
+type ThemeColors struct {
+	TextColor      string `yaml:"textColor"`
+	PrimaryColor   string `yaml:"primaryColor"`
+	SecondaryColor string `yaml:"secondaryColor"`
+	TertiaryColor  string `yaml:"tertiaryColor"`
+	ErrorColor     string `yaml:"errorColor"`
+	// ColorBlindMode replaces the colors above with a palette that stays distinguishable
+	// with the given color vision deficiency.
+	ColorBlindMode ColorBlindMode `yaml:"colorBlindMode,omitempty"`
+	// File names a theme file in ThemesDir, whose colors and styles are drawn over the colors above.
+	File string `yaml:"file,omitempty"`
+}
+
+// ColorBlindMode is a color vision deficiency the interface colors are adapted to.
+type ColorBlindMode string
+
+const (
+	ColorBlindOff          ColorBlindMode = ""
+	ColorBlindDeuteranopia ColorBlindMode = "deuteranopia"
+	ColorBlindProtanopia   ColorBlindMode = "protanopia"
+)
+
+func (c *ColorBlindMode) UnmarshalYAML(value *yaml.Node) error {
+	var val string
+	if err := value.Decode(&val); err != nil {
+		return err
+	}
+
+	switch ColorBlindMode(val) {
+	case ColorBlindOff, ColorBlindDeuteranopia, ColorBlindProtanopia:
+		*c = ColorBlindMode(val)
+		return nil
+	default:
+		return errors.New("invalid colorBlindMode value: " + val)
+	}
+}
+
+// colorBlindPalettes are built on the Okabe-Ito palette: blues stand in for the purples,
+// and errors are told apart by their brightness rather than by being red.
+var colorBlindPalettes = map[ColorBlindMode]ThemeColors{
+	ColorBlindDeuteranopia: {
+		TextColor:      "#ffffff",
+		PrimaryColor:   "#0072b2",
+		SecondaryColor: "#56b4e9",
+		TertiaryColor:  "#4e4e4e",
+		ErrorColor:     "#e69f00",
+	},
+	// Reds and oranges look dark to protanopes, so errors are yellow instead
+	ColorBlindProtanopia: {
+		TextColor:      "#ffffff",
+		PrimaryColor:   "#0072b2",
+		SecondaryColor: "#56b4e9",
+		TertiaryColor:  "#4e4e4e",
+		ErrorColor:     "#f0e442",
+	},
+}
+
+// Effective returns the colors to draw the interface with: those of the color-blind mode if one is set,
+// the theme's otherwise.
+func (t ThemeColors) Effective() ThemeColors {
+	palette, ok := colorBlindPalettes[t.ColorBlindMode]
+	if !ok {
+		return t
+	}
+	palette.ColorBlindMode = t.ColorBlindMode
+	return palette
+}
+
+// DefaultTheme is the theme RadioGoGo ships with.
+var DefaultTheme = ThemeColors{
+	TextColor:      "#ffffff",
+	PrimaryColor:   "#5a4f9f",
+	SecondaryColor: "#8b77db",
+	TertiaryColor:  "#4e4e4e",
+	ErrorColor:     "#ff0000",
+}
+
+// ThemePresets are the built-in themes, which can be picked at runtime with ":theme <name>".
+var ThemePresets = map[string]ThemeColors{
+	"default": DefaultTheme,
+	"dracula": {
+		TextColor:      "#f8f8f2",
+		PrimaryColor:   "#6272a4",
+		SecondaryColor: "#bd93f9",
+		TertiaryColor:  "#44475a",
+		ErrorColor:     "#ff5555",
+	},
+	"nord": {
+		TextColor:      "#eceff4",
+		PrimaryColor:   "#5e81ac",
+		SecondaryColor: "#88c0d0",
+		TertiaryColor:  "#4c566a",
+		ErrorColor:     "#bf616a",
+	},
+	"gruvbox": {
+		TextColor:      "#ebdbb2",
+		PrimaryColor:   "#458588",
+		SecondaryColor: "#d79921",
+		TertiaryColor:  "#665c54",
+		ErrorColor:     "#cc241d",
+	},
+	"solarized": {
+		TextColor:      "#fdf6e3",
+		PrimaryColor:   "#268bd2",
+		SecondaryColor: "#2aa198",
+		TertiaryColor:  "#586e75",
+		ErrorColor:     "#dc322f",
+	},
+}
+
+// ThemePresetNames returns the names of the built-in themes, sorted.
+func ThemePresetNames() []string {
+	names := make([]string, 0, len(ThemePresets))
+	for name := range ThemePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SaveThemeColors updates the theme colors in the configuration file at the given path, keeping the other settings
+// and the color-blind mode. The theme file is dropped, since its colors would be drawn over them.
+func SaveThemeColors(path string, colors ThemeColors) error {
+	cfg := NewDefaultConfig()
+	if err := cfg.Load(path); err != nil {
+		return err
+	}
+	colors.ColorBlindMode = cfg.Theme.ColorBlindMode
+	colors.File = ""
+	cfg.Theme = colors
+	return cfg.Save(path)
+}
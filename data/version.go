@@ -19,7 +19,41 @@
 
 package data
 
+import (
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
 const (
-	Version   = "0.3.0"
-	UserAgent = "radiogogo/" + Version
+	Version = "0.3.0"
+	// DefaultUserAgent identifies RadioGoGo to radio-browser, which asks clients for a speaking name and a version.
+	DefaultUserAgent = "radiogogo/" + Version
 )
+
+// ErrInvalidUserAgent is returned when a User-Agent can't be sent in an HTTP header.
+var ErrInvalidUserAgent = i18n.Error("config.invalidUserAgent")
+
+// UserAgent is sent with every HTTP request RadioGoGo makes.
+var UserAgent = DefaultUserAgent
+
+// SetUserAgent makes UserAgent name followed by DefaultUserAgent, e.g. "myfork/1.2 radiogogo/0.3.0",
+// so that radio-browser can still tell which version of RadioGoGo is behind a fork.
+// An empty name restores DefaultUserAgent.
+func SetUserAgent(name string) error {
+	name = strings.TrimSpace(name)
+	for _, r := range name {
+		if r < 0x20 || r > 0x7e {
+			return ErrInvalidUserAgent
+		}
+	}
+	switch {
+	case name == "":
+		UserAgent = DefaultUserAgent
+	case strings.Contains(name, DefaultUserAgent):
+		UserAgent = name
+	default:
+		UserAgent = name + " " + DefaultUserAgent
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetUserAgent(t *testing.T) {
+
+	t.Cleanup(func() {
+		UserAgent = DefaultUserAgent
+	})
+
+	t.Run("appends RadioGoGo's own User-Agent", func(t *testing.T) {
+		assert.NoError(t, SetUserAgent(" myfork/1.2 "))
+		assert.Equal(t, "myfork/1.2 "+DefaultUserAgent, UserAgent)
+	})
+
+	t.Run("does not repeat RadioGoGo's own User-Agent", func(t *testing.T) {
+		assert.NoError(t, SetUserAgent("myfork/1.2 "+DefaultUserAgent))
+		assert.Equal(t, "myfork/1.2 "+DefaultUserAgent, UserAgent)
+	})
+
+	t.Run("restores the default when empty", func(t *testing.T) {
+		assert.NoError(t, SetUserAgent(""))
+		assert.Equal(t, DefaultUserAgent, UserAgent)
+	})
+
+	t.Run("refuses characters that can't be sent in a header", func(t *testing.T) {
+		assert.NoError(t, SetUserAgent("myfork"))
+		assert.ErrorIs(t, SetUserAgent("my\r\nfork"), ErrInvalidUserAgent)
+		assert.ErrorIs(t, SetUserAgent("mönfork"), ErrInvalidUserAgent)
+		assert.Equal(t, "myfork "+DefaultUserAgent, UserAgent)
+	})
+
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+// openDatabase opens the RadioGoGo database, moving in the JSON files written by earlier versions.
+// Only one process can have it open at a time.
+func openDatabase() (*storage.DB, error) {
+	db, err := storage.Open(config.DatabaseFile())
+	if err != nil {
+		return nil, err
+	}
+	err = db.ImportLegacyJSON(config.LabelsFile(), config.BookmarksFile())
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/cli"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/doctor"
+	"github.com/zi0p4tch0/radiogogo/playback"
+)
+
+const (
+	installFFmpeg = "Install FFmpeg with your package manager, see the README's Dependencies"
+	installMPV    = "Install mpv with your package manager, or set playbackEngine to ffplay"
+)
+
+// newDoctorCommand returns "radiogogo doctor", which checks what RadioGoGo depends on.
+func newDoctorCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "doctor",
+		Short: "Check the configuration, the directories, the network and the players",
+		Long:  "Each check that doesn't pass tells what to do about it. Paste the output when reporting an issue.",
+		Run: func(args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("usage: radiogogo doctor")
+			}
+			if failures := doctor.Print(os.Stdout, runDoctor()); failures > 0 {
+				return fmt.Errorf("%d checks failed", failures)
+			}
+			return nil
+		},
+	}
+}
+
+// runDoctor checks the configuration of the profile in use, then the rest as configured.
+func runDoctor() []doctor.Section {
+
+	configResults, cfg := doctor.Config(config.ConfigFile())
+
+	// Where the data and cache go, as loadConfig would set them
+	if err := config.SetDataDir(cfg.Paths.Data); err != nil {
+		configResults = append(configResults, doctor.Result{Check: "paths.data", Status: doctor.Warned, Detail: fmt.Sprintf("%q is ignored: %v", cfg.Paths.Data, err), Advice: "Set it to a directory, or leave it empty"})
+	}
+	if err := config.SetCacheDir(cfg.Paths.Cache); err != nil {
+		configResults = append(configResults, doctor.Result{Check: "paths.cache", Status: doctor.Warned, Detail: fmt.Sprintf("%q is ignored: %v", cfg.Paths.Cache, err), Advice: "Set it to a directory, or leave it empty"})
+	}
+
+	if cfg.Network.Relay {
+		if _, err := playback.RelayTransport(cfg.Network.Proxy, cfg.Network.CAFile); err != nil {
+			configResults = append(configResults, doctor.Result{Check: "network", Status: doctor.Failed, Detail: fmt.Sprintf("stations can't be relayed: %v", err), Advice: "Fix proxy and caFile, or turn relay off"})
+		}
+	}
+
+	recordings := cfg.Recordings.Directory
+	if recordings == "" {
+		recordings = config.RecordingsDir()
+	}
+
+	return []doctor.Section{
+		{Title: "Configuration (profile " + config.Profile() + ")", Results: configResults},
+		{Title: "Directories", Results: []doctor.Result{
+			doctor.Directory("config", config.ConfigDir()),
+			doctor.Directory("data", config.DataDir()),
+			doctor.Directory("cache", config.CacheDir()),
+			doctor.Directory("recordings", recordings),
+		}},
+		{Title: "Network", Results: checkNetwork(cfg)},
+		{Title: "Players", Results: doctor.Programs(exec.LookPath, doctor.Version, doctorPrograms(cfg))},
+	}
+}
+
+// checkNetwork resolves and reaches the radio-browser server in the configuration, or the mirrors.
+func checkNetwork(cfg config.Config) []doctor.Result {
+
+	client := &http.Client{Timeout: doctor.Timeout}
+	lookup := api.NewDNSLookupService().LookupIP
+
+	if cfg.API.BaseURL != "" {
+		if baseURL, err := api.ParseBaseURL(cfg.API.BaseURL); err == nil {
+			host := baseURL.Hostname()
+			if net.ParseIP(host) != nil {
+				return doctor.API(client, []string{baseURL.String()})
+			}
+			dns, addresses := doctor.DNS(lookup, host)
+			if addresses == nil {
+				return []doctor.Result{dns}
+			}
+			return append([]doctor.Result{dns}, doctor.API(client, []string{baseURL.String()})...)
+		}
+	}
+
+	dns, addresses := doctor.DNS(lookup, doctor.MirrorsHost)
+	if addresses == nil {
+		return []doctor.Result{dns}
+	}
+	return append([]doctor.Result{dns}, doctor.API(client, doctor.MirrorURLs(addresses))...)
+}
+
+// doctorPrograms returns the players and encoders RadioGoGo runs, needed or not as configured.
+func doctorPrograms(cfg config.Config) []doctor.Program {
+
+	engine := cfg.PlaybackEngine
+	if engine == "" {
+		engine = playback.FFPlay
+	}
+	networkOutput := cfg.Output.Mode == playback.OutputSnapcast || cfg.Output.Mode == playback.OutputIcecast
+
+	ffmpeg := doctor.Program{Name: "ffmpeg", VersionFlag: "-version", Needed: networkOutput, Purpose: "recordings, casting and the Snapcast and Icecast outputs", Install: installFFmpeg}
+	if networkOutput {
+		ffmpeg.Purpose = "the " + string(cfg.Output.Mode) + " output (output.mode)"
+	}
+
+	if engine == playback.MPV {
+		return []doctor.Program{
+			{Name: "mpv", VersionFlag: "--version", Needed: true, Purpose: "playback (playbackEngine: mpv)", Install: installMPV},
+			ffmpeg,
+		}
+	}
+	return []doctor.Program{
+		{Name: "ffplay", VersionFlag: "-version", Needed: true, Purpose: "playback (playbackEngine: ffplay)", Install: installFFmpeg},
+		ffmpeg,
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/data"
+)
+
+// MirrorsHost is the name radio-browser lists its mirrors under.
+const MirrorsHost = "all.api.radio-browser.info"
+
+// Timeout is how long each network check and each player asked for its version may take.
+const Timeout = 5 * time.Second
+
+// DNS resolves host with lookup, returning the result of the check and the addresses found.
+func DNS(lookup func(host string) ([]string, error), host string) (Result, []string) {
+	addresses, err := lookup(host)
+	if err != nil || len(addresses) == 0 {
+		detail := fmt.Sprintf("%s doesn't resolve", host)
+		if err != nil {
+			detail += ": " + err.Error()
+		}
+		return failed("DNS", detail, "Check your network connection and DNS servers (e.g. with \"nslookup "+host+"\"), or set api.baseURL to a radio-browser server you can reach"), nil
+	}
+	return passed("DNS", fmt.Sprintf("%s resolves to %s", host, strings.Join(addresses, ", "))), addresses
+}
+
+// MirrorURLs returns the base URLs of the radio-browser API on each of addresses, as RadioGoGo reaches them.
+func MirrorURLs(addresses []string) []string {
+	urls := make([]string, len(addresses))
+	for i, address := range addresses {
+		if net.ParseIP(address).To4() == nil {
+			address = "[" + address + "]"
+		}
+		urls[i] = "http://" + address + "/json"
+	}
+	return urls
+}
+
+// stats is the part of radio-browser's /json/stats answer the API check reports.
+type stats struct {
+	SoftwareVersion string `json:"software_version"`
+	Stations        int    `json:"stations"`
+}
+
+// API asks each of the radio-browser servers at baseURLs for its statistics.
+// Servers that can't be reached are failures only if none of them can.
+func API(client *http.Client, baseURLs []string) []Result {
+
+	results := make([]Result, len(baseURLs))
+	reachable := 0
+
+	for i, baseURL := range baseURLs {
+		check := "API " + hostOf(baseURL)
+		started := time.Now()
+		answer, err := getStats(client, baseURL)
+		if err != nil {
+			results[i] = failed(check, err.Error(), "")
+			continue
+		}
+		reachable++
+		detail := fmt.Sprintf("answered in %s", time.Since(started).Round(time.Millisecond))
+		if answer.SoftwareVersion != "" {
+			detail += fmt.Sprintf(" (radio-browser %s, %d stations)", answer.SoftwareVersion, answer.Stations)
+		}
+		results[i] = passed(check, detail)
+	}
+
+	for i := range results {
+		if results[i].Status != Failed {
+			continue
+		}
+		if reachable > 0 {
+			results[i].Status = Warned
+			results[i].Advice = "Other servers answer, so RadioGoGo uses them instead"
+		} else {
+			results[i].Advice = "Check that a firewall or proxy doesn't block HTTP requests, or try again later: radio-browser may be down"
+		}
+	}
+
+	return results
+}
+
+func getStats(client *http.Client, baseURL string) (stats, error) {
+	var answer stats
+
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/stats", nil)
+	if err != nil {
+		return answer, err
+	}
+	request.Header.Set("User-Agent", data.UserAgent)
+
+	response, err := client.Do(request)
+	if err != nil {
+		return answer, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return answer, fmt.Errorf("answered %s", response.Status)
+	}
+	if err := json.NewDecoder(response.Body).Decode(&answer); err != nil {
+		return answer, fmt.Errorf("answered something other than radio-browser statistics: %w", err)
+	}
+	return answer, nil
+}
+
+func hostOf(baseURL string) string {
+	if parsed, err := url.Parse(baseURL); err == nil && parsed.Host != "" {
+		return parsed.Host
+	}
+	return baseURL
+}
+
+// A Program is a program RadioGoGo runs, and what for.
+type Program struct {
+	Name string
+	// VersionFlag makes the program print its version, e.g. "-version".
+	VersionFlag string
+	// Needed is true if RadioGoGo can't work as configured without the program.
+	Needed bool
+	// Purpose tells what the program is used for, e.g. "recordings".
+	Purpose string
+	// Install tells how to get the program.
+	Install string
+}
+
+// Programs looks each of programs up in the PATH with lookPath, and asks it its version with version.
+func Programs(lookPath func(file string) (string, error), version func(path string, flag string) (string, error), programs []Program) []Result {
+
+	results := make([]Result, len(programs))
+
+	for i, program := range programs {
+		path, err := lookPath(program.Name)
+		if err != nil {
+			detail := "not found in the PATH, needed for " + program.Purpose
+			if program.Needed {
+				results[i] = failed(program.Name, detail, program.Install)
+			} else {
+				results[i] = warned(program.Name, detail, program.Install)
+			}
+			continue
+		}
+		found, err := version(path, program.VersionFlag)
+		if err != nil {
+			results[i] = warned(program.Name, fmt.Sprintf("%s doesn't tell its version: %v", path, err), "Reinstall it: it may be broken")
+			continue
+		}
+		results[i] = passed(program.Name, fmt.Sprintf("%s (%s)", found, path))
+	}
+
+	return results
+}
+
+// Version runs the program at path with flag and returns the first line it prints.
+func Version(path string, flag string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, path, flag).Output()
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(line), nil
+}
+
+// Directory checks that path is a directory RadioGoGo can write to, or that it can be created.
+func Directory(check string, path string) Result {
+
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		parent := filepath.Dir(path)
+		for parent != filepath.Dir(parent) {
+			if _, err := os.Stat(parent); err == nil {
+				break
+			}
+			parent = filepath.Dir(parent)
+		}
+		if err := writable(parent); err != nil {
+			return failed(check, fmt.Sprintf("%s doesn't exist, and can't be created: %v", path, err), "Create it yourself, or make "+parent+" writable")
+		}
+		return passed(check, path+" doesn't exist yet, it's created when needed")
+	}
+	if err != nil {
+		return failed(check, err.Error(), "Check the permissions of "+filepath.Dir(path))
+	}
+	if !info.IsDir() {
+		return failed(check, path+" is not a directory", "Move the file away")
+	}
+	if err := writable(path); err != nil {
+		return failed(check, fmt.Sprintf("%s is not writable: %v", path, err), "Make it writable, e.g. with \"chmod u+w "+path+"\"")
+	}
+	return passed(check, path)
+}
+
+// writable tells whether files can be created in the directory at path.
+func writable(path string) error {
+	file, err := os.CreateTemp(path, ".radiogogo-doctor-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/playback"
+)
+
+// Config checks the configuration file at path, and the settings RadioGoGo ignores if they're wrong.
+// It returns the results and the configuration loaded, the default one if it can't be.
+// The User-Agent of the configuration, if valid, is the one the API checks send.
+func Config(path string) ([]Result, config.Config) {
+
+	const check = "config file"
+
+	cfg := config.NewDefaultConfig()
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return []Result{passed(check, path+" doesn't exist yet, the defaults are used")}, cfg
+	}
+	if err := cfg.Load(path); err != nil {
+		return []Result{failed(check, fmt.Sprintf("%s can't be loaded: %v", path, err), "Fix it, or move it away to start over from the defaults")}, config.NewDefaultConfig()
+	}
+
+	var results []Result
+
+	strict := config.NewDefaultConfig()
+	if err := strict.LoadStrict(path); err != nil {
+		results = append(results, warned(check, fmt.Sprintf("%s: %v", path, err), "Correct the setting's name, or remove it: it's ignored"))
+	} else {
+		results = append(results, passed(check, path))
+	}
+
+	if cfg.Language != "" && !isLanguage(cfg.Language) {
+		results = append(results, warned("language", fmt.Sprintf("%q is not translated, English is used", cfg.Language), "Set language to one of "+strings.Join(i18n.Languages(), ", ")+", or leave it empty to follow the system's"))
+	}
+	if cfg.API.BaseURL != "" {
+		if _, err := api.ParseBaseURL(cfg.API.BaseURL); err != nil {
+			results = append(results, warned("api.baseURL", fmt.Sprintf("%q is ignored: %v", cfg.API.BaseURL, err), "Set it to the http(s) URL of a radio-browser server, or leave it empty for the mirrors"))
+		}
+	}
+	if err := data.SetUserAgent(cfg.API.UserAgent); err != nil {
+		results = append(results, warned("api.userAgent", fmt.Sprintf("%q is ignored: %v", cfg.API.UserAgent, err), "Keep to printable ASCII characters"))
+	}
+	if cfg.Playback.CrossfadeSeconds > 0 && cfg.PlaybackEngine == playback.FFPlay {
+		results = append(results, warned("playback.crossfadeSeconds", "ffplay can't fade a station out: stations are switched seamlessly without crossfading", "Set playbackEngine to mpv to crossfade"))
+	}
+	if cfg.Theme.File != "" {
+		if _, err := config.LoadThemeFile(cfg.Theme.File); err != nil {
+			results = append(results, warned("theme file", fmt.Sprintf("%s is ignored: %v", cfg.Theme.File, err), "Fix the theme file, or remove theme.file"))
+		}
+	}
+
+	return results, cfg
+}
+
+// isLanguage tells whether lang, a language code or a locale name such as "de_DE.UTF-8", is translated.
+func isLanguage(lang string) bool {
+	parts := strings.FieldsFunc(lang, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.'
+	})
+	if len(parts) == 0 {
+		return false
+	}
+	code := strings.ToLower(parts[0])
+	for _, language := range i18n.Languages() {
+		if language == code {
+			return true
+		}
+	}
+	return false
+}
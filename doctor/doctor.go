@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package doctor checks what RadioGoGo depends on, from the configuration to the network and the players,
+// telling what's wrong and what to do about it.
+package doctor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Status is the outcome of a check.
+type Status int
+
+const (
+	// Passed means nothing is wrong.
+	Passed Status = iota
+	// Warned means something only some features depend on is wrong, or might be.
+	Warned
+	// Failed means RadioGoGo can't work as configured.
+	Failed
+)
+
+// Symbol returns the mark the status is printed with.
+func (s Status) Symbol() string {
+	switch s {
+	case Warned:
+		return "!"
+	case Failed:
+		return "✗"
+	}
+	return "✓"
+}
+
+// Result is what a check found.
+type Result struct {
+	// Check names what was checked, e.g. "DNS" or "mpv".
+	Check  string
+	Status Status
+	// Detail is what was found.
+	Detail string
+	// Advice tells what to do about it, unless the check passed.
+	Advice string
+}
+
+func passed(check, detail string) Result {
+	return Result{Check: check, Status: Passed, Detail: detail}
+}
+
+func warned(check, detail, advice string) Result {
+	return Result{Check: check, Status: Warned, Detail: detail, Advice: advice}
+}
+
+func failed(check, detail, advice string) Result {
+	return Result{Check: check, Status: Failed, Detail: detail, Advice: advice}
+}
+
+// A Section groups the results of related checks under a title.
+type Section struct {
+	Title   string
+	Results []Result
+}
+
+// Print writes the sections to w, a line per result followed by its advice, if any,
+// and a summary. It returns how many checks failed.
+func Print(w io.Writer, sections []Section) int {
+
+	width := 0
+	for _, section := range sections {
+		for _, result := range section.Results {
+			if len(result.Check) > width {
+				width = len(result.Check)
+			}
+		}
+	}
+
+	var passes, warnings, failures int
+	for i, section := range sections {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, section.Title)
+		for _, result := range section.Results {
+			fmt.Fprintf(w, "  %s %-*s  %s\n", result.Status.Symbol(), width, result.Check, result.Detail)
+			if result.Advice != "" {
+				fmt.Fprintf(w, "    %s  → %s\n", strings.Repeat(" ", width), result.Advice)
+			}
+			switch result.Status {
+			case Passed:
+				passes++
+			case Warned:
+				warnings++
+			case Failed:
+				failures++
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d passed, %d warned, %d failed\n", passes, warnings, failures)
+	return failures
+}
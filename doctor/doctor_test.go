@@ -0,0 +1,234 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package doctor
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrint(t *testing.T) {
+
+	var out bytes.Buffer
+
+	failures := Print(&out, []Section{
+		{Title: "Network", Results: []Result{
+			passed("DNS", "resolves"),
+			failed("API", "unreachable", "Try again"),
+		}},
+		{Title: "Players", Results: []Result{
+			warned("ffmpeg", "missing", "Install it"),
+		}},
+	})
+
+	assert.Equal(t, 1, failures)
+	assert.Equal(t, `Network
+  ✓ DNS     resolves
+  ✗ API     unreachable
+            → Try again
+
+Players
+  ! ffmpeg  missing
+            → Install it
+
+1 passed, 1 warned, 1 failed
+`, out.String())
+}
+
+func TestDNS(t *testing.T) {
+
+	t.Run("passes with the addresses found", func(t *testing.T) {
+
+		result, addresses := DNS(func(host string) ([]string, error) {
+			return []string{"1.2.3.4", "5.6.7.8"}, nil
+		}, MirrorsHost)
+
+		assert.Equal(t, Passed, result.Status)
+		assert.Contains(t, result.Detail, "1.2.3.4, 5.6.7.8")
+		assert.Equal(t, []string{"1.2.3.4", "5.6.7.8"}, addresses)
+
+	})
+
+	t.Run("fails if the host doesn't resolve", func(t *testing.T) {
+
+		result, addresses := DNS(func(host string) ([]string, error) {
+			return nil, errors.New("no such host")
+		}, MirrorsHost)
+
+		assert.Equal(t, Failed, result.Status)
+		assert.Contains(t, result.Detail, "no such host")
+		assert.NotEmpty(t, result.Advice)
+		assert.Nil(t, addresses)
+
+	})
+
+}
+
+func TestMirrorURLs(t *testing.T) {
+	assert.Equal(t, []string{"http://1.2.3.4/json", "http://[2001:db8::1]/json"}, MirrorURLs([]string{"1.2.3.4", "2001:db8::1"}))
+}
+
+func TestAPI(t *testing.T) {
+
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/json/stats", r.URL.Path)
+		assert.NotEmpty(t, r.Header.Get("User-Agent"))
+		w.Write([]byte(`{"software_version": "0.7.31", "stations": 51234}`))
+	}))
+	defer mirror.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	t.Run("passes for the servers that answer, warns for the others", func(t *testing.T) {
+
+		results := API(http.DefaultClient, []string{mirror.URL + "/json", broken.URL + "/json"})
+
+		assert.Equal(t, Passed, results[0].Status)
+		assert.Contains(t, results[0].Detail, "radio-browser 0.7.31, 51234 stations")
+		assert.Equal(t, Warned, results[1].Status)
+		assert.Contains(t, results[1].Detail, "502")
+
+	})
+
+	t.Run("fails if no server answers", func(t *testing.T) {
+
+		results := API(http.DefaultClient, []string{broken.URL + "/json"})
+
+		assert.Equal(t, Failed, results[0].Status)
+		assert.NotEmpty(t, results[0].Advice)
+
+	})
+
+}
+
+func TestPrograms(t *testing.T) {
+
+	lookPath := func(file string) (string, error) {
+		if file == "mpv" {
+			return "/usr/bin/mpv", nil
+		}
+		return "", errors.New("not found")
+	}
+	version := func(path string, flag string) (string, error) {
+		assert.Equal(t, "--version", flag)
+		return "mpv 0.37.0", nil
+	}
+
+	results := Programs(lookPath, version, []Program{
+		{Name: "mpv", VersionFlag: "--version", Needed: true},
+		{Name: "ffplay", Needed: true, Purpose: "playback", Install: "Install FFmpeg"},
+		{Name: "ffmpeg", Purpose: "recordings", Install: "Install FFmpeg"},
+	})
+
+	assert.Equal(t, passed("mpv", "mpv 0.37.0 (/usr/bin/mpv)"), results[0])
+	assert.Equal(t, failed("ffplay", "not found in the PATH, needed for playback", "Install FFmpeg"), results[1])
+	assert.Equal(t, warned("ffmpeg", "not found in the PATH, needed for recordings", "Install FFmpeg"), results[2])
+}
+
+func TestDirectory(t *testing.T) {
+
+	dir := t.TempDir()
+
+	t.Run("passes for a writable directory", func(t *testing.T) {
+		assert.Equal(t, Passed, Directory("data", dir).Status)
+	})
+
+	t.Run("passes for a directory that can be created", func(t *testing.T) {
+		result := Directory("cache", filepath.Join(dir, "cache", "assets"))
+		assert.Equal(t, Passed, result.Status)
+		assert.Contains(t, result.Detail, "doesn't exist yet")
+	})
+
+	t.Run("fails for a file", func(t *testing.T) {
+		file := filepath.Join(dir, "file")
+		assert.NoError(t, os.WriteFile(file, nil, 0644))
+		assert.Equal(t, Failed, Directory("data", file).Status)
+	})
+
+}
+
+func TestConfig(t *testing.T) {
+
+	dir := t.TempDir()
+
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	t.Run("passes without a file", func(t *testing.T) {
+		results, _ := Config(filepath.Join(dir, "missing.yaml"))
+		assert.Len(t, results, 1)
+		assert.Equal(t, Passed, results[0].Status)
+	})
+
+	t.Run("passes for a valid file", func(t *testing.T) {
+		results, cfg := Config(write(t, "language: de\n"))
+		assert.Len(t, results, 1)
+		assert.Equal(t, Passed, results[0].Status)
+		assert.Equal(t, "de", cfg.Language)
+	})
+
+	t.Run("fails for a file that can't be loaded", func(t *testing.T) {
+		results, _ := Config(write(t, "playbackEngine: vlc\n"))
+		assert.Len(t, results, 1)
+		assert.Equal(t, Failed, results[0].Status)
+	})
+
+	t.Run("warns about unknown and ignored settings", func(t *testing.T) {
+		results, _ := Config(write(t, "languge: de\nlanguage: klingon\napi:\n  baseURL: ftp://example.com\n"))
+		assert.Len(t, results, 3)
+		for _, result := range results {
+			assert.Equal(t, Warned, result.Status)
+		}
+		assert.Contains(t, results[0].Detail, "languge")
+		assert.Equal(t, "language", results[1].Check)
+		assert.Equal(t, "api.baseURL", results[2].Check)
+	})
+
+	t.Run("warns that ffplay doesn't crossfade", func(t *testing.T) {
+		results, _ := Config(write(t, "playbackEngine: ffplay\nplayback:\n  crossfadeSeconds: 3\n"))
+		assert.Len(t, results, 2)
+		assert.Equal(t, "playback.crossfadeSeconds", results[1].Check)
+		assert.Equal(t, Warned, results[1].Status)
+
+		results, _ = Config(write(t, "playbackEngine: mpv\nplayback:\n  crossfadeSeconds: 3\n"))
+		assert.Len(t, results, 1)
+	})
+
+}
+
+func TestIsLanguage(t *testing.T) {
+	assert.True(t, isLanguage("it"))
+	assert.True(t, isLanguage("de_DE.UTF-8"))
+	assert.False(t, isLanguage("tlh"))
+	assert.False(t, isLanguage("_"))
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/icy"
+	"github.com/zi0p4tch0/radiogogo/models"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/secrets"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+// printDryRun prints the backend command line that would play the station or stream URL given,
+// and the environment it would inherit, without running it. The station is prepared as it is to be played:
+// its streams are probed to pick the one played, and its credentials are redacted from the command line.
+func printDryRun(out io.Writer, cfg config.Config, arg string) error {
+
+	station, err := dryRunStation(cfg, arg)
+	if err != nil {
+		return err
+	}
+
+	// The headers, preferences, volume trim and URL that played last of the station are left out
+	// while RadioGoGo has the database open
+	stores := models.PlaybackStores{Credentials: secrets.Open(config.SecretsDir())}
+	if db, err := openDatabase(); err == nil {
+		defer db.Close()
+		stores.Variants = storage.NewBoltStreamVariantStore(db)
+		stores.Headers = storage.NewBoltStreamHeaderStore(db)
+		stores.Prefs = storage.NewBoltStationPrefsStore(db)
+		stores.VolumeTrims = storage.NewBoltVolumeTrimStore(db)
+	} else {
+		fmt.Fprintf(out, "# The station's headers and preferences are left out: %v\n", err)
+	}
+
+	fmt.Fprintf(out, "# %s\n", station.Name)
+	player := models.NewPlayer(cfg, models.PlaybackOptions(cfg))
+	invocation, ok, err := models.PreviewPlayback(player, cfg.Filters, icy.NewProber(), stores, station)
+	if err != nil {
+		// The player may still say more about why, given the first stream unprobed
+		fmt.Fprintf(out, "# RadioGoGo wouldn't start the player: %v\n", err)
+		invocation, ok, err = models.PreviewPlayback(player, cfg.Filters, nil, stores, station)
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("the configured player runs no command")
+	}
+	if cfg.Network.Relay || cfg.Bandwidth.Meter || (cfg.Output.Mode == playback.OutputLocal && cfg.Playback.TimeshiftMinutes > 0) {
+		fmt.Fprintln(out, "# RadioGoGo relays the stream as configured, so the player is given a local URL instead of this one")
+	}
+	_, err = fmt.Fprintln(out, invocation)
+	return err
+}
+
+// dryRunStation returns the station with the UUID or radiogogo:// link given, looked up on radio-browser,
+// or a station playing the stream URL given.
+func dryRunStation(cfg config.Config, arg string) (common.Station, error) {
+	stationUuid, err := parseStation(arg)
+	if err != nil {
+		streamUrl, urlErr := common.ParseStreamURL(arg)
+		if urlErr != nil {
+			return common.Station{}, fmt.Errorf("%q is neither a station nor a stream URL: %w", arg, urlErr)
+		}
+		return common.NewStationFromURL(streamUrl, ""), nil
+	}
+	browser, err := newBrowser(cfg)
+	if err != nil {
+		return common.Station{}, err
+	}
+	found, err := browser.GetStations(common.StationQueryByUuid, stationUuid.String(), "", false, 0, 1, false)
+	if err != nil {
+		return common.Station{}, err
+	}
+	if len(found) == 0 {
+		return common.Station{}, fmt.Errorf("no station has the UUID %s", stationUuid)
+	}
+	return found[0], nil
+}
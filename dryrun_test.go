@@ -0,0 +1,164 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/secrets"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintDryRun(t *testing.T) {
+
+	streamUrl := "https://example.com/stream.mp3"
+
+	// Keeps the database away from the user's
+	useTempDirs := func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		t.Setenv("XDG_DATA_HOME", t.TempDir())
+		t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	}
+
+	t.Run("prints the command line playing a stream URL", func(t *testing.T) {
+
+		useTempDirs(t)
+		cfg := config.NewDefaultConfig()
+		cfg.PlaybackEngine = playback.FFPlay
+		var out bytes.Buffer
+
+		err := printDryRun(&out, cfg, streamUrl)
+
+		assert.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		assert.Equal(t, "# example.com", lines[0])
+		assert.Contains(t, lines[len(lines)-1], "ffplay")
+		assert.Contains(t, lines[len(lines)-1], streamUrl)
+		assert.NotContains(t, out.String(), "relays the stream")
+
+	})
+
+	t.Run("passes the station's headers on", func(t *testing.T) {
+
+		useTempDirs(t)
+		parsed, err := common.ParseStreamURL(streamUrl)
+		assert.NoError(t, err)
+		db, err := openDatabase()
+		if !assert.NoError(t, err) {
+			return
+		}
+		station := common.NewStationFromURL(parsed, "")
+		assert.NoError(t, storage.NewBoltStreamHeaderStore(db).SetHeaders(station.StationUuid, common.StreamHeaders{{Name: "Referer", Value: "https://example.com"}}))
+		assert.NoError(t, db.Close())
+		cfg := config.NewDefaultConfig()
+		cfg.PlaybackEngine = playback.FFPlay
+		var out bytes.Buffer
+
+		assert.NoError(t, printDryRun(&out, cfg, streamUrl))
+
+		assert.Contains(t, out.String(), "-headers 'Referer: https://example.com\r\n'")
+
+	})
+
+	// A station whose playlist lists a stream that plays, and one that doesn't
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/listen.pls":
+			w.Header().Set("Content-Type", "audio/x-scpls")
+			fmt.Fprintf(w, "[playlist]\nNumberOfEntries=2\nFile1=http://%s/gone\nFile2=http://%s/live\n", r.Host, r.Host)
+		case "/live":
+			w.Header().Set("Content-Type", "audio/mpeg")
+			w.Write(make([]byte, 4096))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("picks the stream of playlists that plays, as playing does", func(t *testing.T) {
+
+		useTempDirs(t)
+		cfg := config.NewDefaultConfig()
+		cfg.PlaybackEngine = playback.FFPlay
+		var out bytes.Buffer
+
+		assert.NoError(t, printDryRun(&out, cfg, server.URL+"/listen.pls"))
+
+		assert.NotContains(t, out.String(), "wouldn't start the player")
+		assert.Contains(t, out.String(), server.URL+"/live")
+
+	})
+
+	t.Run("applies the station's credentials, redacted", func(t *testing.T) {
+
+		useTempDirs(t)
+		parsed, err := common.ParseStreamURL(server.URL + "/live")
+		assert.NoError(t, err)
+		station := common.NewStationFromURL(parsed, "")
+		credentials := secrets.Open(config.SecretsDir())
+		assert.NoError(t, secrets.SetStationCredentials(credentials, station.StationUuid.String(), secrets.StationCredentials{Token: "abc"}))
+		cfg := config.NewDefaultConfig()
+		cfg.PlaybackEngine = playback.FFPlay
+		var out bytes.Buffer
+
+		assert.NoError(t, printDryRun(&out, cfg, server.URL+"/live"))
+
+		assert.NotContains(t, out.String(), "wouldn't start the player")
+		assert.Contains(t, out.String(), server.URL+"/live?token=xxxxx")
+		assert.NotContains(t, out.String(), "abc")
+
+	})
+
+	t.Run("tells when the stream is relayed", func(t *testing.T) {
+
+		useTempDirs(t)
+		cfg := config.NewDefaultConfig()
+		cfg.Network.Relay = true
+		var out bytes.Buffer
+
+		assert.NoError(t, printDryRun(&out, cfg, streamUrl))
+
+		assert.Contains(t, out.String(), "relays the stream")
+
+	})
+
+	t.Run("fails on neither a station nor a stream URL", func(t *testing.T) {
+
+		useTempDirs(t)
+		var out bytes.Buffer
+
+		err := printDryRun(&out, config.NewDefaultConfig(), "not a station")
+
+		assert.Error(t, err)
+		assert.Empty(t, out.String())
+
+	})
+
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/ducking"
+)
+
+// listenForDucking starts listening for the triggers turning stations down in the configuration, if any.
+// RadioGoGo runs without them, telling why, if they can't be listened for.
+func listenForDucking(cfg config.Config) *ducking.Listener {
+
+	if cfg.Ducking.Pipe == "" && len(cfg.Ducking.DBus) == 0 {
+		return nil
+	}
+
+	listener, err := ducking.Listen(ducking.Triggers{
+		Pipe:         cfg.Ducking.Pipe,
+		DBus:         cfg.Ducking.DBus,
+		DBusContains: cfg.Ducking.DBusContains,
+		Hold:         time.Duration(cfg.Ducking.HoldSeconds) * time.Second,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listening for what turns stations down: %v\n", err)
+		return nil
+	}
+
+	return listener
+
+}
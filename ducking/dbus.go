@@ -0,0 +1,107 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ducking
+
+import (
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// The first line of each message printed by dbus-monitor starts with its type.
+var dbusMessageTypes = []string{"signal ", "method call ", "method return ", "error "}
+
+// dbusMonitor is a running dbus-monitor, printing the messages matching the rules it was given.
+type dbusMonitor struct {
+	io.Reader
+	cmd *exec.Cmd
+}
+
+// startDBusMonitor starts dbus-monitor, printing the session bus messages matching rules.
+func startDBusMonitor(rules []string) (*dbusMonitor, error) {
+	cmd := exec.Command("dbus-monitor", append([]string{"--session"}, rules...)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &dbusMonitor{Reader: stdout, cmd: cmd}, nil
+}
+
+// Close stops dbus-monitor.
+func (m *dbusMonitor) Close() error {
+	_ = m.cmd.Process.Kill()
+	_ = m.cmd.Wait()
+	return nil
+}
+
+// dbusFilter tells which lines printed by dbus-monitor are those of a message turning stations down,
+// once per message.
+type dbusFilter struct {
+	contains []string
+	// Whether the message being printed was matched already, or isn't to be
+	done bool
+}
+
+func newDBusFilter(contains []string) *dbusFilter {
+	lowered := make([]string, len(contains))
+	for i, text := range contains {
+		lowered[i] = strings.ToLower(text)
+	}
+	return &dbusFilter{contains: lowered}
+}
+
+// matches returns true if line makes the message being printed one turning stations down:
+// as soon as it starts without texts, or once one of its arguments contains one of them.
+// The first line, telling the message type and member, is left to the match rules.
+func (f *dbusFilter) matches(line string) bool {
+	if isDBusMessageStart(line) {
+		// dbus-monitor prints its own connection to the bus
+		f.done = strings.Contains(line, "member=NameAcquired") || strings.Contains(line, "member=NameLost")
+		if f.done || len(f.contains) > 0 {
+			return false
+		}
+	} else if f.done || !f.containsAny(strings.ToLower(line)) {
+		return false
+	}
+	f.done = true
+	return true
+}
+
+func (f *dbusFilter) containsAny(line string) bool {
+	for _, text := range f.contains {
+		if strings.Contains(line, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDBusMessageStart returns true if line is the first of a message printed by dbus-monitor.
+func isDBusMessageStart(line string) bool {
+	for _, messageType := range dbusMessageTypes {
+		if strings.HasPrefix(line, messageType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package ducking listens for the external triggers asking RadioGoGo to turn the station being played down
+// for a while, such as an incoming call, and to bring it back: lines written to a named pipe,
+// and messages on the D-Bus session bus.
+package ducking
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnsupported is returned by Listen when a named pipe is asked for on a platform without them.
+var ErrUnsupported = errors.New("named pipes are not supported on this platform")
+
+// ErrNoTriggers is returned by Listen when there's nothing to listen for.
+var ErrNoTriggers = errors.New("no named pipe nor D-Bus match rule to listen for")
+
+// DefaultHold is how long a D-Bus message keeps stations turned down, unless configured otherwise.
+const DefaultHold = time.Minute
+
+// Triggers are what turns stations down.
+type Triggers struct {
+	// Pipe is the path of a named pipe, created if needed, which other programs write "duck" and "restore" lines to.
+	Pipe string
+	// DBus are match rules of the session bus messages turning stations down for Hold, as given to dbus-monitor.
+	DBus []string
+	// DBusContains, if not empty, only lets the messages containing one of these texts, ignoring case, turn stations down.
+	DBusContains []string
+	// Hold is how long a D-Bus message keeps stations turned down (DefaultHold if 0).
+	Hold time.Duration
+}
+
+// The triggers, each of which can want stations turned down
+type source int
+
+const (
+	pipeSource source = iota
+	dbusSource
+)
+
+// Listener tells whether stations should be turned down, each time it changes.
+type Listener struct {
+	events  chan bool
+	closers []io.Closer
+	hold    time.Duration
+
+	// Guards what follows
+	mu sync.Mutex
+	// The triggers wanting stations turned down
+	ducking map[source]bool
+	ducked  bool
+	// Brings stations back once a D-Bus message has been held long enough
+	release *time.Timer
+	closed  bool
+}
+
+// Listen starts listening for the given triggers.
+func Listen(triggers Triggers) (*Listener, error) {
+
+	if triggers.Pipe == "" && len(triggers.DBus) == 0 {
+		return nil, ErrNoTriggers
+	}
+
+	l := newListener(triggers.Hold)
+
+	if triggers.Pipe != "" {
+		pipe, err := openPipe(triggers.Pipe)
+		if err != nil {
+			return nil, err
+		}
+		l.closers = append(l.closers, pipe)
+		go l.readPipe(pipe)
+	}
+
+	if len(triggers.DBus) > 0 {
+		monitor, err := startDBusMonitor(triggers.DBus)
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		l.closers = append(l.closers, monitor)
+		go l.readDBus(monitor, newDBusFilter(triggers.DBusContains))
+	}
+
+	return l, nil
+}
+
+func newListener(hold time.Duration) *Listener {
+	if hold <= 0 {
+		hold = DefaultHold
+	}
+	return &Listener{
+		events:  make(chan bool, 1),
+		hold:    hold,
+		ducking: make(map[source]bool),
+	}
+}
+
+// Events returns the channel on which whether stations should be turned down is delivered, each time it changes.
+// Only the latest is kept until it's received. It is closed when the listener is closed.
+func (l *Listener) Events() <-chan bool {
+	return l.events
+}
+
+// Close stops listening, removing the named pipe if it was created.
+func (l *Listener) Close() error {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.events)
+	}
+	if l.release != nil {
+		l.release.Stop()
+	}
+	l.mu.Unlock()
+
+	var err error
+	for _, closer := range l.closers {
+		if closeErr := closer.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// set records whether source wants stations turned down, delivering the change if that changes the outcome.
+func (l *Listener) set(source source, ducking bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	l.ducking[source] = ducking
+	ducked := false
+	for _, ducking := range l.ducking {
+		ducked = ducked || ducking
+	}
+	if ducked == l.ducked {
+		return
+	}
+	l.ducked = ducked
+	// The previous change no longer matters if it wasn't received
+	select {
+	case <-l.events:
+	default:
+	}
+	l.events <- ducked
+}
+
+// holdDBus turns stations down for a while, or for longer if they already are.
+func (l *Listener) holdDBus() {
+	l.set(dbusSource, true)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.release != nil {
+		l.release.Stop()
+	}
+	l.release = time.AfterFunc(l.hold, func() {
+		l.set(dbusSource, false)
+	})
+}
+
+// readPipe acts on the lines written to the named pipe, until it's closed.
+func (l *Listener) readPipe(pipe io.Reader) {
+	readLines(pipe, func(line string) {
+		if ducking, ok := parseCommand(line); ok {
+			l.set(pipeSource, ducking)
+		}
+	})
+}
+
+// readDBus turns stations down for each message printed by dbus-monitor that passes filter, until it exits.
+func (l *Listener) readDBus(monitor io.Reader, filter *dbusFilter) {
+	readLines(monitor, func(line string) {
+		if filter.matches(line) {
+			l.holdDBus()
+		}
+	})
+}
+
+// readLines calls handle with each line read from r, until it fails or ends.
+func readLines(r io.Reader, handle func(line string)) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		handle(scanner.Text())
+	}
+}
+
+// parseCommand parses a line written to the named pipe: "duck" turns stations down, "restore" brings them back.
+func parseCommand(line string) (ducking bool, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "duck":
+		return true, true
+	case "restore", "unduck":
+		return false, true
+	}
+	return false, false
+}
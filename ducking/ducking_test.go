@@ -0,0 +1,174 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ducking
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// receive returns the next event of l, failing the test if there's none in time.
+func receive(t *testing.T, l *Listener) bool {
+	select {
+	case ducked := <-l.Events():
+		return ducked
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return false
+	}
+}
+
+// assertNoEvent fails the test if l has an event pending.
+func assertNoEvent(t *testing.T, l *Listener) {
+	select {
+	case ducked := <-l.Events():
+		t.Fatalf("unexpected event: %v", ducked)
+	default:
+	}
+}
+
+func TestListen(t *testing.T) {
+
+	t.Run("needs a trigger", func(t *testing.T) {
+		_, err := Listen(Triggers{})
+		assert.ErrorIs(t, err, ErrNoTriggers)
+	})
+
+}
+
+func TestListener(t *testing.T) {
+
+	t.Run("acts on the commands written to the pipe", func(t *testing.T) {
+
+		l := newListener(0)
+
+		l.readPipe(strings.NewReader("duck\nDUCK\nhello\n restore \n"))
+
+		// Only the latest change is kept until received
+		assert.False(t, receive(t, l))
+		assertNoEvent(t, l)
+
+	})
+
+	t.Run("delivers each change", func(t *testing.T) {
+
+		l := newListener(0)
+
+		l.set(pipeSource, true)
+		assert.True(t, receive(t, l))
+		l.set(pipeSource, true)
+		assertNoEvent(t, l)
+		l.set(pipeSource, false)
+		assert.False(t, receive(t, l))
+
+	})
+
+	t.Run("keeps stations down while any trigger wants them down", func(t *testing.T) {
+
+		l := newListener(0)
+
+		l.set(pipeSource, true)
+		assert.True(t, receive(t, l))
+		l.set(dbusSource, true)
+		l.set(pipeSource, false)
+		assertNoEvent(t, l)
+		l.set(dbusSource, false)
+		assert.False(t, receive(t, l))
+
+	})
+
+	t.Run("holds stations down after a D-Bus message", func(t *testing.T) {
+
+		l := newListener(50 * time.Millisecond)
+
+		l.readDBus(strings.NewReader("signal time=1 sender=:1.2 -> destination=(null destination) serial=3 path=/; interface=org.example; member=Ring\n"), newDBusFilter(nil))
+
+		assert.True(t, receive(t, l))
+		assert.False(t, receive(t, l))
+
+	})
+
+	t.Run("stops delivering once closed", func(t *testing.T) {
+
+		l := newListener(0)
+
+		assert.NoError(t, l.Close())
+		l.set(pipeSource, true)
+
+		_, open := <-l.Events()
+		assert.False(t, open)
+
+	})
+
+}
+
+func TestParseCommand(t *testing.T) {
+
+	for line, expected := range map[string]bool{"duck": true, " Duck ": true, "restore": false, "unduck": false} {
+		ducking, ok := parseCommand(line)
+		assert.True(t, ok, line)
+		assert.Equal(t, expected, ducking, line)
+	}
+
+	_, ok := parseCommand("louder")
+	assert.False(t, ok)
+
+}
+
+func TestDBusFilter(t *testing.T) {
+
+	output := []string{
+		"signal time=1.0 sender=org.freedesktop.DBus -> destination=:1.9 serial=2 path=/org/freedesktop/DBus; interface=org.freedesktop.DBus; member=NameAcquired",
+		"   string \":1.9\"",
+		"method call time=2.0 sender=:1.5 -> destination=:1.3 serial=7 path=/org/freedesktop/Notifications; interface=org.freedesktop.Notifications; member=Notify",
+		"   string \"Calls\"",
+		"   string \"Incoming call\"",
+		"   string \"Alice is calling\"",
+		"method call time=3.0 sender=:1.5 -> destination=:1.3 serial=8 path=/org/freedesktop/Notifications; interface=org.freedesktop.Notifications; member=Notify",
+		"   string \"Mail\"",
+		"   string \"New message\"",
+	}
+
+	t.Run("matches each message once without texts", func(t *testing.T) {
+		filter := newDBusFilter(nil)
+		var matched []int
+		for i, line := range output {
+			if filter.matches(line) {
+				matched = append(matched, i)
+			}
+		}
+		assert.Equal(t, []int{2, 6}, matched)
+	})
+
+	t.Run("matches the messages containing one of the texts", func(t *testing.T) {
+		filter := newDBusFilter([]string{"INCOMING", "ringing"})
+		var matched []int
+		for i, line := range output {
+			if filter.matches(line) {
+				matched = append(matched, i)
+			}
+		}
+		assert.Equal(t, []int{4}, matched)
+	})
+
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package ducking
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// pipe is a named pipe read by RadioGoGo, removed when closed if it created it.
+type pipe struct {
+	*os.File
+	path    string
+	created bool
+}
+
+// openPipe opens the named pipe at path, creating it if needed.
+func openPipe(path string) (*pipe, error) {
+	created := false
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if err := syscall.Mkfifo(path, 0600); err != nil {
+			return nil, &fs.PathError{Op: "mkfifo", Path: path, Err: err}
+		}
+		created = true
+	case err != nil:
+		return nil, err
+	case info.Mode()&fs.ModeNamedPipe == 0:
+		return nil, fmt.Errorf("%s exists and isn't a named pipe", path)
+	}
+
+	// Opened for writing too, so that reading doesn't stop whenever a writer is done
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		if created {
+			_ = os.Remove(path)
+		}
+		return nil, err
+	}
+	return &pipe{File: file, path: path, created: created}, nil
+}
+
+func (p *pipe) Close() error {
+	err := p.File.Close()
+	if p.created {
+		_ = os.Remove(p.path)
+	}
+	return err
+}
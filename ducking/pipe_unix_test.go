@@ -0,0 +1,66 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package ducking
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipe(t *testing.T) {
+
+	t.Run("creates the pipe, reads the commands written to it and removes it", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "duck")
+		l, err := Listen(Triggers{Pipe: path})
+		assert.NoError(t, err)
+
+		for _, command := range []string{"duck\n", "restore\n"} {
+			writer, err := os.OpenFile(path, os.O_WRONLY, 0)
+			assert.NoError(t, err)
+			_, err = writer.WriteString(command)
+			assert.NoError(t, err)
+			writer.Close()
+			assert.Equal(t, command == "duck\n", receive(t, l))
+		}
+
+		assert.NoError(t, l.Close())
+		assert.NoFileExists(t, path)
+
+	})
+
+	t.Run("refuses a file that isn't a pipe", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "duck")
+		assert.NoError(t, os.WriteFile(path, nil, 0600))
+
+		_, err := Listen(Triggers{Pipe: path})
+
+		assert.Error(t, err)
+		assert.FileExists(t, path)
+
+	})
+
+}
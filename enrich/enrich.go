@@ -0,0 +1,118 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package enrich looks up details about the tracks announced by streams, such as their album and release year.
+package enrich
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+// ErrNotFound is returned when nothing is known about a track.
+var ErrNotFound = i18n.Error("enrich.notFound")
+
+// Track is a track announced by a stream.
+type Track struct {
+	Artist string
+	Title  string
+}
+
+// ParseTitle splits a stream title of the usual "Artist - Title" form.
+// Returns false if the title isn't of that form, as when stations announce themselves or their shows.
+func ParseTitle(streamTitle string) (Track, bool) {
+	artist, title, found := strings.Cut(streamTitle, " - ")
+	if !found {
+		return Track{}, false
+	}
+	track := Track{Artist: strings.TrimSpace(artist), Title: strings.TrimSpace(title)}
+	if track.Artist == "" || track.Title == "" {
+		return Track{}, false
+	}
+	return track, true
+}
+
+// Details are what's known about a track. Fields are empty (or zero) when unknown.
+type Details struct {
+	Artist string
+	Title  string
+	Album  string
+	Year   int
+}
+
+// Enricher looks up the details of tracks.
+type Enricher interface {
+	// Enrich returns the details of the track, or ErrNotFound.
+	Enrich(track Track) (Details, error)
+}
+
+// cacheEntry is a lookup remembered by Cached.
+type cacheEntry struct {
+	details Details
+	err     error
+}
+
+// Cached is an Enricher remembering the last lookups of another, so that each track is looked up once.
+// Tracks that weren't found are remembered too; other errors aren't, so they're retried.
+type Cached struct {
+	enricher Enricher
+	size     int
+
+	mu      sync.Mutex
+	entries map[Track]cacheEntry
+	// order lists the tracks in entries, oldest first
+	order []Track
+}
+
+// NewCached returns an Enricher remembering the last size lookups of enricher.
+func NewCached(enricher Enricher, size int) *Cached {
+	return &Cached{
+		enricher: enricher,
+		size:     size,
+		entries:  make(map[Track]cacheEntry),
+	}
+}
+
+func (c *Cached) Enrich(track Track) (Details, error) {
+	c.mu.Lock()
+	entry, found := c.entries[track]
+	c.mu.Unlock()
+	if found {
+		return entry.details, entry.err
+	}
+
+	details, err := c.enricher.Enrich(track)
+	if err != nil && err != ErrNotFound {
+		return details, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, found := c.entries[track]; !found {
+		c.order = append(c.order, track)
+	}
+	c.entries[track] = cacheEntry{details: details, err: err}
+	for len(c.order) > c.size {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	return details, err
+}
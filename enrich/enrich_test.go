@@ -0,0 +1,163 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package enrich
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTitle(t *testing.T) {
+
+	testCases := []struct {
+		title    string
+		expected Track
+		ok       bool
+	}{
+		{"Radiohead - Karma Police", Track{Artist: "Radiohead", Title: "Karma Police"}, true},
+		{"  Jay-Z - 99 Problems - Remix ", Track{Artist: "Jay-Z", Title: "99 Problems - Remix"}, true},
+		{"Jazz FM - ", Track{}, false},
+		{"You're listening to Jazz FM", Track{}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			track, ok := ParseTitle(tc.title)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, track)
+		})
+	}
+
+}
+
+type mockEnricher struct {
+	calls int
+	err   error
+}
+
+func (e *mockEnricher) Enrich(track Track) (Details, error) {
+	e.calls++
+	if e.err != nil {
+		return Details{}, e.err
+	}
+	return Details{Title: track.Title}, nil
+}
+
+func TestCached(t *testing.T) {
+
+	karmaPolice := Track{Artist: "Radiohead", Title: "Karma Police"}
+	airbag := Track{Artist: "Radiohead", Title: "Airbag"}
+
+	t.Run("looks each track up once", func(t *testing.T) {
+		enricher := &mockEnricher{}
+		cached := NewCached(enricher, 10)
+
+		for i := 0; i < 3; i++ {
+			details, err := cached.Enrich(karmaPolice)
+			assert.NoError(t, err)
+			assert.Equal(t, "Karma Police", details.Title)
+		}
+		assert.Equal(t, 1, enricher.calls)
+	})
+
+	t.Run("remembers tracks that weren't found, but not failures", func(t *testing.T) {
+		enricher := &mockEnricher{err: ErrNotFound}
+		cached := NewCached(enricher, 10)
+		_, _ = cached.Enrich(karmaPolice)
+		_, err := cached.Enrich(karmaPolice)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Equal(t, 1, enricher.calls)
+
+		enricher = &mockEnricher{err: errors.New("timeout")}
+		cached = NewCached(enricher, 10)
+		_, _ = cached.Enrich(karmaPolice)
+		_, _ = cached.Enrich(karmaPolice)
+		assert.Equal(t, 2, enricher.calls)
+	})
+
+	t.Run("forgets the oldest tracks past its size", func(t *testing.T) {
+		enricher := &mockEnricher{}
+		cached := NewCached(enricher, 1)
+		_, _ = cached.Enrich(karmaPolice)
+		_, _ = cached.Enrich(airbag)
+		_, _ = cached.Enrich(karmaPolice)
+		assert.Equal(t, 3, enricher.calls)
+	})
+
+}
+
+func TestMusicBrainz(t *testing.T) {
+
+	respond := func(status int, body string) *mocks.MockHttpClient {
+		return &mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "musicbrainz.org", req.URL.Host)
+				assert.Equal(t, `recording:"Karma Police" AND artist:"Radiohead"`, req.URL.Query().Get("query"))
+				assert.Equal(t, data.UserAgent, req.Header.Get("User-Agent"))
+				return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}, nil
+			},
+		}
+	}
+
+	karmaPolice := Track{Artist: "Radiohead", Title: "Karma Police"}
+
+	t.Run("reads the album the recording first came out on, and its year", func(t *testing.T) {
+		mb := NewMusicBrainzWithDependencies(respond(http.StatusOK, `{
+			"recordings": [{
+				"title": "Karma Police",
+				"score": 100,
+				"artist-credit": [{"name": "Radiohead", "joinphrase": ""}],
+				"first-release-date": "1997-05-21",
+				"releases": [
+					{"title": "Karma Police", "date": "1997-08-25"},
+					{"title": "OK Computer", "date": "1997-05-21"}
+				]
+			}]
+		}`))
+
+		details, err := mb.Enrich(karmaPolice)
+		assert.NoError(t, err)
+		assert.Equal(t, Details{Artist: "Radiohead", Title: "Karma Police", Album: "OK Computer", Year: 1997}, details)
+	})
+
+	t.Run("doesn't trust loose matches", func(t *testing.T) {
+		mb := NewMusicBrainzWithDependencies(respond(http.StatusOK, `{"recordings": [{"title": "Police", "score": 42}]}`))
+
+		_, err := mb.Enrich(karmaPolice)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("fails on errors from the server", func(t *testing.T) {
+		mb := NewMusicBrainzWithDependencies(respond(http.StatusServiceUnavailable, ``))
+
+		_, err := mb.Enrich(karmaPolice)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrNotFound)
+	})
+
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package enrich
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/data"
+)
+
+const musicBrainzBaseUrl = "https://musicbrainz.org/ws/2/recording"
+
+// MusicBrainz asks clients not to send more than a request per second.
+const musicBrainzRequestsPerSecond = 1
+
+// How long a lookup may take, waiting for its turn included.
+const musicBrainzTimeout = 10 * time.Second
+
+// Recordings matching the track with a lower score (out of 100) are too loose a match.
+const musicBrainzMinScore = 80
+
+// MusicBrainz looks up tracks on MusicBrainz (https://musicbrainz.org).
+type MusicBrainz struct {
+	httpClient api.HTTPClientService
+	baseUrl    string
+}
+
+// NewMusicBrainz returns a MusicBrainz enricher sending no more than a request per second.
+func NewMusicBrainz() *MusicBrainz {
+	return NewMusicBrainzWithDependencies(api.NewRateLimitedHTTPClient(
+		&http.Client{Timeout: musicBrainzTimeout},
+		api.NewRateLimiter(musicBrainzRequestsPerSecond),
+	))
+}
+
+// NewMusicBrainzWithDependencies returns a MusicBrainz enricher using the given HTTP client.
+func NewMusicBrainzWithDependencies(httpClient api.HTTPClientService) *MusicBrainz {
+	return &MusicBrainz{
+		httpClient: httpClient,
+		baseUrl:    musicBrainzBaseUrl,
+	}
+}
+
+type musicBrainzRecordings struct {
+	Recordings []struct {
+		Title        string `json:"title"`
+		Score        int    `json:"score"`
+		ArtistCredit []struct {
+			Name       string `json:"name"`
+			JoinPhrase string `json:"joinphrase"`
+		} `json:"artist-credit"`
+		FirstReleaseDate string `json:"first-release-date"`
+		Releases         []struct {
+			Title string `json:"title"`
+			Date  string `json:"date"`
+		} `json:"releases"`
+	} `json:"recordings"`
+}
+
+func (mb *MusicBrainz) Enrich(track Track) (Details, error) {
+
+	query := url.Values{}
+	query.Set("query", fmt.Sprintf("recording:%s AND artist:%s", luceneQuote(track.Title), luceneQuote(track.Artist)))
+	query.Set("fmt", "json")
+	query.Set("limit", "1")
+
+	req, err := http.NewRequest("GET", mb.baseUrl+"?"+query.Encode(), nil)
+	if err != nil {
+		return Details{}, err
+	}
+	req.Header.Set("User-Agent", data.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	result, err := mb.httpClient.Do(req)
+	if err != nil {
+		return Details{}, err
+	}
+	defer result.Body.Close()
+
+	if result.StatusCode != http.StatusOK {
+		return Details{}, fmt.Errorf("unexpected status code: %d", result.StatusCode)
+	}
+
+	var response musicBrainzRecordings
+	if err := json.NewDecoder(result.Body).Decode(&response); err != nil {
+		return Details{}, err
+	}
+
+	if len(response.Recordings) == 0 || response.Recordings[0].Score < musicBrainzMinScore {
+		return Details{}, ErrNotFound
+	}
+	recording := response.Recordings[0]
+
+	details := Details{Title: recording.Title}
+	for _, credit := range recording.ArtistCredit {
+		details.Artist += credit.Name + credit.JoinPhrase
+	}
+	// The album is the release the recording first came out on, if it's listed
+	for _, release := range recording.Releases {
+		if details.Album == "" {
+			details.Album = release.Title
+		}
+		if release.Date != "" && release.Date == recording.FirstReleaseDate {
+			details.Album = release.Title
+			break
+		}
+	}
+	if len(recording.FirstReleaseDate) >= 4 {
+		details.Year, _ = strconv.Atoi(recording.FirstReleaseDate[:4])
+	}
+
+	return details, nil
+}
+
+// luceneQuote quotes a phrase for the Lucene syntax of MusicBrainz searches.
+func luceneQuote(phrase string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(phrase) + `"`
+}
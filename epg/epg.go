@@ -0,0 +1,324 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package epg
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	"github.com/google/uuid"
+)
+
+// How long fetching a schedule may take.
+const fetchTimeout = 10 * time.Second
+
+// Schedules larger than this are cut off.
+const maxScheduleSize = 2 << 20
+
+var (
+	// ErrUnknownFormat is returned when a schedule is neither RSS nor JSON.
+	ErrUnknownFormat = i18n.Error("epg.unknownFormat")
+	// ErrNoPrograms is returned when a schedule lists no program with a start time.
+	ErrNoPrograms = i18n.Error("epg.noPrograms")
+)
+
+// Format is the format of a schedule.
+type Format string
+
+const (
+	// RSS is an RSS feed with one item per program. Items are timed by the "ev:startdate" and
+	// "ev:enddate" elements of the RSS event module, or else by their publication date.
+	RSS Format = "rss"
+	// JSON is an array of programs, or an object with a "programs" array, where each program has
+	// a "title" (or "name"), a "start" and optionally an "end" (RFC 3339 strings or Unix timestamps).
+	JSON Format = "json"
+)
+
+// Source maps a station to where its schedule is published.
+type Source struct {
+	StationUuid uuid.UUID `yaml:"station"`
+	URL         string    `yaml:"url"`
+	// Format is guessed from the schedule when empty.
+	Format Format `yaml:"format,omitempty"`
+}
+
+// Program is a show on a station's schedule.
+type Program struct {
+	Title       string
+	Description string
+	Start       time.Time
+	// End is zero when the schedule doesn't say: the program then ends when the next one starts.
+	End time.Time
+}
+
+// Guide is a station's schedule, sorted by start time.
+type Guide []Program
+
+// At returns the program on air at t and the one following it, either of which may be nil.
+func (g Guide) At(t time.Time) (now *Program, next *Program) {
+	for i := range g {
+		if g[i].Start.After(t) {
+			return now, &g[i]
+		}
+		end := g[i].End
+		if end.IsZero() && i+1 < len(g) {
+			end = g[i+1].Start
+		}
+		if end.IsZero() || end.After(t) {
+			now = &g[i]
+		} else {
+			now = nil
+		}
+	}
+	return now, nil
+}
+
+// Fetcher downloads station schedules.
+type Fetcher interface {
+	// Fetch downloads and parses the schedule published at source.
+	Fetch(source Source) (Guide, error)
+}
+
+type FetcherImpl struct {
+	httpClient api.HTTPClientService
+}
+
+// NewFetcher returns a new instance of Fetcher with a default HTTP client.
+func NewFetcher() Fetcher {
+	return NewFetcherWithDependencies(&http.Client{Timeout: fetchTimeout})
+}
+
+// NewFetcherWithDependencies returns a new instance of Fetcher using the given HTTP client.
+func NewFetcherWithDependencies(httpClient api.HTTPClientService) Fetcher {
+	return &FetcherImpl{httpClient: httpClient}
+}
+
+func (f *FetcherImpl) Fetch(source Source) (Guide, error) {
+
+	req, err := http.NewRequest("GET", source.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", data.UserAgent)
+
+	result, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Body.Close()
+
+	if result.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", result.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(result.Body, maxScheduleSize))
+	if err != nil {
+		return nil, err
+	}
+
+	format := source.Format
+	if format == "" {
+		format = guessFormat(result.Header.Get("Content-Type"), body)
+	}
+
+	return Parse(body, format)
+}
+
+// guessFormat tells RSS from JSON by the content type of a schedule, or else by its first character.
+func guessFormat(contentType string, body []byte) Format {
+	switch {
+	case strings.Contains(contentType, "json"):
+		return JSON
+	case strings.Contains(contentType, "xml"):
+		return RSS
+	}
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 {
+		switch trimmed[0] {
+		case '[', '{':
+			return JSON
+		case '<':
+			return RSS
+		}
+	}
+	return ""
+}
+
+// Parse reads a schedule in the given format. Programs without a title or a start time are skipped.
+func Parse(body []byte, format Format) (Guide, error) {
+
+	var guide Guide
+	var err error
+
+	switch format {
+	case RSS:
+		guide, err = parseRSS(body)
+	case JSON:
+		guide, err = parseJSON(body)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(guide) == 0 {
+		return nil, ErrNoPrograms
+	}
+
+	sort.SliceStable(guide, func(i, j int) bool {
+		return guide[i].Start.Before(guide[j].Start)
+	})
+
+	return guide, nil
+}
+
+func parseRSS(body []byte) (Guide, error) {
+
+	var feed struct {
+		Items []struct {
+			Title       string `xml:"title"`
+			Description string `xml:"description"`
+			PubDate     string `xml:"pubDate"`
+			StartDate   string `xml:"startdate"`
+			EndDate     string `xml:"enddate"`
+		} `xml:"channel>item"`
+	}
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, err
+	}
+
+	var guide Guide
+	for _, item := range feed.Items {
+		start, ok := parseTime(item.StartDate)
+		if !ok {
+			start, ok = parseTime(item.PubDate)
+		}
+		title := strings.TrimSpace(item.Title)
+		if !ok || title == "" {
+			continue
+		}
+		end, _ := parseTime(item.EndDate)
+		guide = append(guide, Program{
+			Title:       title,
+			Description: strings.TrimSpace(item.Description),
+			Start:       start,
+			End:         end,
+		})
+	}
+
+	return guide, nil
+}
+
+type jsonProgram struct {
+	Title       string          `json:"title"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Start       json.RawMessage `json:"start"`
+	End         json.RawMessage `json:"end"`
+}
+
+func parseJSON(body []byte) (Guide, error) {
+
+	var programs []jsonProgram
+	if err := json.Unmarshal(body, &programs); err != nil {
+		var wrapped struct {
+			Programs []jsonProgram `json:"programs"`
+		}
+		if json.Unmarshal(body, &wrapped) != nil {
+			return nil, err
+		}
+		programs = wrapped.Programs
+	}
+
+	var guide Guide
+	for _, program := range programs {
+		title := strings.TrimSpace(program.Title)
+		if title == "" {
+			title = strings.TrimSpace(program.Name)
+		}
+		start, ok := parseJSONTime(program.Start)
+		if !ok || title == "" {
+			continue
+		}
+		end, _ := parseJSONTime(program.End)
+		guide = append(guide, Program{
+			Title:       title,
+			Description: strings.TrimSpace(program.Description),
+			Start:       start,
+			End:         end,
+		})
+	}
+
+	return guide, nil
+}
+
+// parseJSONTime reads a time given as a string or as a Unix timestamp in seconds.
+func parseJSONTime(raw json.RawMessage) (time.Time, bool) {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return parseTime(s)
+	}
+	var seconds float64
+	if json.Unmarshal(raw, &seconds) == nil && seconds > 0 {
+		return time.Unix(int64(seconds), 0), true
+	}
+	return time.Time{}, false
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// parseTime reads the date formats found in schedules. Times without a zone are local.
+func parseTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(seconds, 0), true
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
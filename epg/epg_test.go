@@ -0,0 +1,189 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package epg
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const rssSchedule = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:ev="http://purl.org/rss/1.0/modules/event/">
+  <channel>
+    <title>Schedule</title>
+    <item>
+      <title>Jazz Hour</title>
+      <ev:startdate>2024-03-10T10:00:00Z</ev:startdate>
+      <ev:enddate>2024-03-10T11:00:00Z</ev:enddate>
+    </item>
+    <item>
+      <title>Morning Show</title>
+      <description>Wake up</description>
+      <pubDate>Sun, 10 Mar 2024 08:00:00 +0000</pubDate>
+    </item>
+    <item>
+      <title>No date</title>
+    </item>
+  </channel>
+</rss>`
+
+func TestParse(t *testing.T) {
+
+	t.Run("reads RSS items timed by the event module or their publication date", func(t *testing.T) {
+		guide, err := Parse([]byte(rssSchedule), RSS)
+		assert.NoError(t, err)
+		assert.Len(t, guide, 2)
+		assert.Equal(t, "Morning Show", guide[0].Title)
+		assert.Equal(t, "Wake up", guide[0].Description)
+		assert.True(t, guide[0].Start.Equal(time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)))
+		assert.True(t, guide[0].End.IsZero())
+		assert.Equal(t, "Jazz Hour", guide[1].Title)
+		assert.True(t, guide[1].End.Equal(time.Date(2024, 3, 10, 11, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("reads a JSON array with string and numeric times", func(t *testing.T) {
+		guide, err := Parse([]byte(`[
+			{"title": "Late Show", "start": 1710100800},
+			{"name": "Drive Time", "start": "2024-03-10T16:00:00Z", "end": "2024-03-10T18:00:00Z"},
+			{"title": "", "start": "2024-03-10T20:00:00Z"}
+		]`), JSON)
+		assert.NoError(t, err)
+		assert.Len(t, guide, 2)
+		assert.Equal(t, "Drive Time", guide[0].Title)
+		assert.Equal(t, "Late Show", guide[1].Title)
+		assert.True(t, guide[1].Start.Equal(time.Unix(1710100800, 0)))
+	})
+
+	t.Run("reads a JSON object with a programs array", func(t *testing.T) {
+		guide, err := Parse([]byte(`{"programs": [{"title": "News", "start": "2024-03-10T12:00:00Z"}]}`), JSON)
+		assert.NoError(t, err)
+		assert.Len(t, guide, 1)
+	})
+
+	t.Run("fails when no program can be timed", func(t *testing.T) {
+		_, err := Parse([]byte(`[{"title": "News"}]`), JSON)
+		assert.ErrorIs(t, err, ErrNoPrograms)
+	})
+
+	t.Run("fails on an unknown format", func(t *testing.T) {
+		_, err := Parse([]byte("News at noon"), "")
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+	})
+
+}
+
+func TestGuideAt(t *testing.T) {
+
+	at := func(hour int) time.Time {
+		return time.Date(2024, 3, 10, hour, 0, 0, 0, time.UTC)
+	}
+	guide := Guide{
+		{Title: "Morning Show", Start: at(8)},
+		{Title: "Jazz Hour", Start: at(10), End: at(11)},
+		{Title: "News", Start: at(12)},
+	}
+
+	t.Run("returns nothing on air before the first program", func(t *testing.T) {
+		now, next := guide.At(at(7))
+		assert.Nil(t, now)
+		assert.Equal(t, "Morning Show", next.Title)
+	})
+
+	t.Run("ends a program without an end when the next one starts", func(t *testing.T) {
+		now, next := guide.At(at(9))
+		assert.Equal(t, "Morning Show", now.Title)
+		assert.Equal(t, "Jazz Hour", next.Title)
+	})
+
+	t.Run("returns nothing on air between programs", func(t *testing.T) {
+		now, next := guide.At(at(11).Add(30 * time.Minute))
+		assert.Nil(t, now)
+		assert.Equal(t, "News", next.Title)
+	})
+
+	t.Run("keeps the last program on air when it has no end", func(t *testing.T) {
+		now, next := guide.At(at(23))
+		assert.Equal(t, "News", now.Title)
+		assert.Nil(t, next)
+	})
+
+}
+
+func TestFetcherImplFetch(t *testing.T) {
+
+	t.Run("guesses the format from the content type", func(t *testing.T) {
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "https://example.com/schedule", req.URL.String())
+				assert.Equal(t, data.UserAgent, req.Header.Get("User-Agent"))
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{"application/rss+xml"}},
+					Body:       io.NopCloser(strings.NewReader(rssSchedule)),
+				}, nil
+			},
+		}
+		fetcher := NewFetcherWithDependencies(&mockHttpClient)
+
+		guide, err := fetcher.Fetch(Source{URL: "https://example.com/schedule"})
+		assert.NoError(t, err)
+		assert.Len(t, guide, 2)
+	})
+
+	t.Run("guesses the format from the body", func(t *testing.T) {
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(` [{"title": "News", "start": "2024-03-10T12:00:00Z"}]`)),
+				}, nil
+			},
+		}
+		fetcher := NewFetcherWithDependencies(&mockHttpClient)
+
+		guide, err := fetcher.Fetch(Source{URL: "https://example.com/schedule"})
+		assert.NoError(t, err)
+		assert.Len(t, guide, 1)
+	})
+
+	t.Run("fails on an error status", func(t *testing.T) {
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       io.NopCloser(strings.NewReader("")),
+				}, nil
+			},
+		}
+		fetcher := NewFetcherWithDependencies(&mockHttpClient)
+
+		_, err := fetcher.Fetch(Source{URL: "https://example.com/schedule"})
+		assert.Error(t, err)
+	})
+
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package eventlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog(t *testing.T) {
+
+	t.Run("appends timestamped events, creating the file", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "logs", "radiogogo.log")
+		log := New(path)
+		log.now = func() time.Time { return time.Date(2023, 10, 1, 12, 30, 0, 0, time.UTC) }
+
+		assert.NoError(t, log.Printf("%s went silent", "Jazz FM"))
+		assert.NoError(t, log.Printf("gave up on %s", "Jazz FM"))
+
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, "2023-10-01T12:30:00Z Jazz FM went silent\n2023-10-01T12:30:00Z gave up on Jazz FM\n", string(data))
+
+	})
+
+	t.Run("discards events when nil", func(t *testing.T) {
+
+		var log *Log
+
+		assert.NoError(t, log.Printf("anything"))
+
+	})
+
+}
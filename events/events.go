@@ -0,0 +1,137 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package events is the bus the events of a listening session are published on, such as a station
+// starting to play, so that the features reacting to them don't have to be wired into the views they happen in.
+package events
+
+import (
+	"sync"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+// Kind is the kind of an Event.
+type Kind string
+
+const (
+	// PlaybackStarted is published when a station starts playing.
+	PlaybackStarted Kind = "playbackStarted"
+	// TrackChanged is published when the station playing announces another track.
+	TrackChanged Kind = "trackChanged"
+	// SearchCompleted is published when the stations found by a search are listed.
+	SearchCompleted Kind = "searchCompleted"
+	// BookmarkAdded is published when a station is bookmarked.
+	BookmarkAdded Kind = "bookmarkAdded"
+	// StreamInterrupted is published when the stream of the station playing drops, is reconnected or fails.
+	StreamInterrupted Kind = "streamInterrupted"
+)
+
+// Event is something that happened during the session.
+type Event struct {
+	Kind Kind
+	// Station is the station played, interrupted or bookmarked.
+	Station common.Station
+	// Resumed tells that the station was reconnected rather than played anew, for PlaybackStarted.
+	Resumed bool
+	// Title is the track announced, for TrackChanged. It is empty when the station stops announcing tracks.
+	Title string
+	// Search is the search made, and Results how many stations it found, for SearchCompleted.
+	Search  storage.Search
+	Results int
+	// Interruption is what interrupted the station, for StreamInterrupted.
+	Interruption storage.Interruption
+}
+
+// Handler reacts to an event. The error it returns is reported, without keeping the other handlers from running.
+type Handler func(Event) error
+
+type subscription struct {
+	id      int
+	kinds   []Kind
+	handler Handler
+}
+
+func (s subscription) wants(kind Kind) bool {
+	if len(s.kinds) == 0 {
+		return true
+	}
+	for _, wanted := range s.kinds {
+		if wanted == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Bus hands the events published to the handlers subscribed to them. It is safe for concurrent use.
+// A nil Bus discards events.
+type Bus struct {
+	mu            sync.Mutex
+	subscriptions []subscription
+	nextID        int
+}
+
+// NewBus returns a bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe has handler called with the events of the given kinds, or with every event if none is given.
+// It returns a function that unsubscribes handler.
+func (b *Bus) Subscribe(handler Handler, kinds ...Kind) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.subscriptions = append(b.subscriptions, subscription{id: id, kinds: kinds, handler: handler})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, subscription := range b.subscriptions {
+			if subscription.id == id {
+				b.subscriptions = append(b.subscriptions[:i:i], b.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish calls the handlers subscribed to the kind of event, in the order they subscribed,
+// and returns the first error they returned.
+func (b *Bus) Publish(event Event) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	subscriptions := append([]subscription(nil), b.subscriptions...)
+	b.mu.Unlock()
+
+	var firstErr error
+	for _, subscription := range subscriptions {
+		if !subscription.wants(event.Kind) {
+			continue
+		}
+		if err := subscription.handler(event); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package events
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus(t *testing.T) {
+
+	t.Run("hands events to the handlers subscribed to their kind, in order", func(t *testing.T) {
+
+		bus := NewBus()
+		var handled []string
+		bus.Subscribe(func(event Event) error {
+			handled = append(handled, "all "+event.Title)
+			return nil
+		})
+		bus.Subscribe(func(event Event) error {
+			handled = append(handled, "tracks "+event.Title)
+			return nil
+		}, TrackChanged)
+		bus.Subscribe(func(event Event) error {
+			handled = append(handled, "bookmarks "+event.Title)
+			return nil
+		}, BookmarkAdded)
+
+		assert.NoError(t, bus.Publish(Event{Kind: TrackChanged, Title: "So What"}))
+		assert.Equal(t, []string{"all So What", "tracks So What"}, handled)
+
+	})
+
+	t.Run("stops handing events once unsubscribed", func(t *testing.T) {
+
+		bus := NewBus()
+		var handled int
+		unsubscribe := bus.Subscribe(func(event Event) error {
+			handled++
+			return nil
+		})
+		bus.Subscribe(func(event Event) error { return nil })
+
+		assert.NoError(t, bus.Publish(Event{Kind: PlaybackStarted}))
+		unsubscribe()
+		unsubscribe()
+		assert.NoError(t, bus.Publish(Event{Kind: PlaybackStarted}))
+		assert.Equal(t, 1, handled)
+
+	})
+
+	t.Run("returns the first error, still calling every handler", func(t *testing.T) {
+
+		bus := NewBus()
+		var handled int
+		for _, err := range []error{errors.New("disk full"), errors.New("offline"), nil} {
+			err := err
+			bus.Subscribe(func(event Event) error {
+				handled++
+				return err
+			})
+		}
+
+		assert.EqualError(t, bus.Publish(Event{Kind: SearchCompleted}), "disk full")
+		assert.Equal(t, 3, handled)
+
+	})
+
+	t.Run("discards events when nil", func(t *testing.T) {
+
+		var bus *Bus
+
+		assert.NoError(t, bus.Publish(Event{Kind: PlaybackStarted}))
+
+	})
+
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package export writes lists of stations, such as search results, as CSV or JSON with the chosen fields,
+// for looking into the radio-browser directory with other tools.
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+// Format is a format stations are exported in.
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{CSV, JSON}
+
+// ParseFormat returns the format of the given name.
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if strings.EqualFold(name, string(format)) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q (csv or json)", name)
+}
+
+// Field is a property of the stations written for each of them, named as the CSV column or JSON key it's written to.
+type Field string
+
+const (
+	FieldName        Field = "name"
+	FieldUuid        Field = "stationuuid"
+	FieldURL         Field = "url"
+	FieldHomepage    Field = "homepage"
+	FieldFavicon     Field = "favicon"
+	FieldCountry     Field = "country"
+	FieldCountryCode Field = "countrycode"
+	FieldState       Field = "state"
+	FieldLanguage    Field = "language"
+	FieldTags        Field = "tags"
+	FieldCodec       Field = "codec"
+	FieldBitrate     Field = "bitrate"
+	FieldHLS         Field = "hls"
+	FieldVotes       Field = "votes"
+	FieldClicks      Field = "clickcount"
+	FieldClickTrend  Field = "clicktrend"
+	FieldLastCheckOk Field = "lastcheckok"
+)
+
+// Fields lists the fields that can be exported, in the order they're written by default.
+var Fields = []Field{
+	FieldName, FieldUuid, FieldURL, FieldHomepage, FieldFavicon, FieldCountry, FieldCountryCode, FieldState,
+	FieldLanguage, FieldTags, FieldCodec, FieldBitrate, FieldHLS, FieldVotes, FieldClicks, FieldClickTrend, FieldLastCheckOk,
+}
+
+// DefaultFields are the fields written when none are chosen.
+var DefaultFields = []Field{
+	FieldName, FieldUuid, FieldURL, FieldHomepage, FieldCountryCode, FieldLanguage, FieldTags,
+	FieldCodec, FieldBitrate, FieldVotes, FieldClicks,
+}
+
+// fieldValues returns the value of each field for a station, as written to JSON.
+var fieldValues = map[Field]func(station common.Station) interface{}{
+	FieldName:        func(s common.Station) interface{} { return s.Name },
+	FieldUuid:        func(s common.Station) interface{} { return s.StationUuid.String() },
+	FieldURL:         func(s common.Station) interface{} { return s.Url.URL.String() },
+	FieldHomepage:    func(s common.Station) interface{} { return s.Homepage.URL.String() },
+	FieldFavicon:     func(s common.Station) interface{} { return s.Favicon.URL.String() },
+	FieldCountry:     func(s common.Station) interface{} { return s.Country },
+	FieldCountryCode: func(s common.Station) interface{} { return s.CountryCode },
+	FieldState:       func(s common.Station) interface{} { return s.State },
+	FieldLanguage:    func(s common.Station) interface{} { return s.Languages },
+	FieldTags:        func(s common.Station) interface{} { return s.Tags },
+	FieldCodec:       func(s common.Station) interface{} { return s.Codec },
+	FieldBitrate:     func(s common.Station) interface{} { return s.Bitrate },
+	FieldHLS:         func(s common.Station) interface{} { return bool(s.Hls) },
+	FieldVotes:       func(s common.Station) interface{} { return s.Votes },
+	FieldClicks:      func(s common.Station) interface{} { return s.ClickCount },
+	FieldClickTrend:  func(s common.Station) interface{} { return s.ClickTrend },
+	FieldLastCheckOk: func(s common.Station) interface{} { return bool(s.LastCheckOk) },
+}
+
+// ParseFields returns the fields named in a comma-separated list, such as "name,url,votes", in that order.
+// "all" stands for every field.
+func ParseFields(list string) ([]Field, error) {
+	if strings.EqualFold(strings.TrimSpace(list), "all") {
+		return Fields, nil
+	}
+	var fields []Field
+	for _, name := range strings.Split(list, ",") {
+		field := Field(strings.ToLower(strings.TrimSpace(name)))
+		if field == "" {
+			continue
+		}
+		if _, ok := fieldValues[field]; !ok {
+			return nil, fmt.Errorf("unknown field %q (%s)", name, fieldNames())
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields given (%s)", fieldNames())
+	}
+	return fields, nil
+}
+
+func fieldNames() string {
+	names := make([]string, len(Fields))
+	for i, field := range Fields {
+		names[i] = string(field)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Write writes the given fields of stations to w in the given format, in the order given.
+func Write(w io.Writer, format Format, fields []Field, stations []common.Station) error {
+	switch format {
+	case JSON:
+		records := make([]record, 0, len(stations))
+		for _, station := range stations {
+			records = append(records, record{fields: fields, station: station})
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case CSV:
+		return writeCSV(w, fields, stations)
+	}
+	return fmt.Errorf("unknown format %q (csv or json)", format)
+}
+
+// writeCSV writes stations as CSV, with a header row naming the fields.
+func writeCSV(w io.Writer, fields []Field, stations []common.Station) error {
+	writer := csv.NewWriter(w)
+	header := make([]string, len(fields))
+	for i, field := range fields {
+		header[i] = string(field)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, station := range stations {
+		row := make([]string, len(fields))
+		for i, field := range fields {
+			row[i] = fmt.Sprint(fieldValues[field](station))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// record is a station written as a JSON object with the given fields, in their order.
+type record struct {
+	fields  []Field
+	station common.Station
+}
+
+func (r record) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteByte('{')
+	for i, field := range r.fields {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		key, err := json.Marshal(string(field))
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(fieldValues[field](r.station))
+		if err != nil {
+			return nil, err
+		}
+		out.Write(key)
+		out.WriteByte(':')
+		out.Write(value)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package export
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+func newTestStations() []common.Station {
+	return []common.Station{
+		{
+			StationUuid: uuid.MustParse("941ef6f1-0699-4821-95b1-2b678e3ff62e"),
+			Name:        "Radio, Test",
+			Url:         common.RadioGoGoURL{URL: url.URL{Scheme: "http", Host: "example.com", Path: "/stream"}},
+			CountryCode: "IT",
+			Bitrate:     128,
+			Votes:       42,
+			LastCheckOk: true,
+		},
+		{
+			StationUuid: uuid.MustParse("2ba4ee1f-5d49-4d3b-8f6b-3b1e7c6c0a11"),
+			Name:        "Jazz FM",
+			CountryCode: "GB",
+		},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+
+	var out bytes.Buffer
+	fields := []Field{FieldName, FieldURL, FieldCountryCode, FieldBitrate, FieldLastCheckOk}
+	assert.NoError(t, Write(&out, CSV, fields, newTestStations()))
+
+	assert.Equal(t, `name,url,countrycode,bitrate,lastcheckok
+"Radio, Test",http://example.com/stream,IT,128,true
+Jazz FM,,GB,0,false
+`, out.String())
+}
+
+func TestWriteJSON(t *testing.T) {
+
+	var out bytes.Buffer
+	fields := []Field{FieldVotes, FieldName, FieldUuid}
+	assert.NoError(t, Write(&out, JSON, fields, newTestStations()[:1]))
+
+	assert.Equal(t, `[
+  {
+    "votes": 42,
+    "name": "Radio, Test",
+    "stationuuid": "941ef6f1-0699-4821-95b1-2b678e3ff62e"
+  }
+]
+`, out.String())
+
+	out.Reset()
+	assert.NoError(t, Write(&out, JSON, fields, nil))
+	assert.Equal(t, "[]\n", out.String())
+}
+
+func TestParseFields(t *testing.T) {
+
+	fields, err := ParseFields("Name, url,,votes")
+	assert.NoError(t, err)
+	assert.Equal(t, []Field{FieldName, FieldURL, FieldVotes}, fields)
+
+	fields, err = ParseFields("all")
+	assert.NoError(t, err)
+	assert.Equal(t, Fields, fields)
+
+	_, err = ParseFields("name,listeners")
+	assert.Error(t, err)
+
+	_, err = ParseFields(",")
+	assert.Error(t, err)
+}
+
+func TestEveryFieldHasAValue(t *testing.T) {
+
+	for _, field := range Fields {
+		assert.Contains(t, fieldValues, field)
+	}
+	assert.Len(t, fieldValues, len(Fields))
+}
+
+func TestParseFormat(t *testing.T) {
+
+	format, err := ParseFormat("JSON")
+	assert.NoError(t, err)
+	assert.Equal(t, JSON, format)
+
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package favorites reads the favorite stations of other players, to bookmark them:
+// OPML files (including TuneIn's), M3U playlists (mpv, VLC and most players), PLS and XSPF playlists (VLC),
+// and plain lists of stream URLs.
+package favorites
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/opml"
+)
+
+// Entry is a station read from the favorites of another player.
+type Entry struct {
+	// Name is the name the player knew the station by, empty if it didn't tell.
+	Name string
+	// URL is the stream URL of the station, or a TuneIn link to it, empty if it didn't tell.
+	URL string
+}
+
+// Format is a format favorites are read from.
+type Format string
+
+const (
+	// OPML is used by TuneIn and most radio directories.
+	OPML Format = "opml"
+	// M3U is the playlist format of mpv, VLC and most players.
+	M3U Format = "m3u"
+	// PLS is the playlist format of Winamp and SHOUTcast, read by VLC.
+	PLS Format = "pls"
+	// XSPF is the playlist format VLC saves to by default.
+	XSPF Format = "xspf"
+	// URLs is a plain list of stream URLs, one per line.
+	URLs Format = "urls"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{OPML, M3U, PLS, XSPF, URLs}
+
+// ParseFormat returns the format of the given name.
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if strings.EqualFold(name, string(format)) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q (opml, m3u, pls, xspf or urls)", name)
+}
+
+// Detect returns the format of the given favorites, telling them apart by their content alone,
+// so that they can be read from stdin too. Anything unrecognized is read as a list of URLs.
+func Detect(data []byte) Format {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\ufeff")))
+	lower := bytes.ToLower(trimmed)
+	switch {
+	case bytes.HasPrefix(lower, []byte("<")) && bytes.Contains(lower, []byte("<opml")):
+		return OPML
+	case bytes.HasPrefix(lower, []byte("<")) && bytes.Contains(lower, []byte("<playlist")):
+		return XSPF
+	case bytes.HasPrefix(lower, []byte("[playlist]")):
+		return PLS
+	case bytes.HasPrefix(lower, []byte("#extm3u")):
+		return M3U
+	}
+	return URLs
+}
+
+// Read decodes favorites in the given format, returning their entries in order.
+func Read(r io.Reader, format Format) ([]Entry, error) {
+	switch format {
+	case OPML:
+		return readOPML(r)
+	case M3U, URLs:
+		// A list of URLs is an M3U playlist without its comments
+		return readM3U(r)
+	case PLS:
+		return readPLS(r)
+	case XSPF:
+		return readXSPF(r)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+func readOPML(r io.Reader) ([]Entry, error) {
+	outlines, err := opml.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(outlines))
+	for _, outline := range outlines {
+		entries = append(entries, Entry{Name: outline.Name(), URL: outline.StreamURL()})
+	}
+	return entries, nil
+}
+
+// readM3U reads an M3U playlist, naming each entry after the #EXTINF line before it, if any.
+func readM3U(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	name := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		switch {
+		case line == "":
+		case strings.HasPrefix(strings.ToUpper(line), "#EXTINF:"):
+			// e.g. #EXTINF:-1 tvg-logo="...",Jazz FM
+			if _, title, ok := strings.Cut(line, ","); ok {
+				name = strings.TrimSpace(title)
+			}
+		case strings.HasPrefix(line, "#"):
+		default:
+			if isStreamURL(line) {
+				entries = append(entries, Entry{Name: name, URL: line})
+			}
+			name = ""
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// readPLS reads a PLS playlist, whose entries are numbered: File1, Title1, File2...
+func readPLS(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	indices := make(map[string]int)
+	entry := func(number string) *Entry {
+		if _, ok := indices[number]; !ok {
+			indices[number] = len(entries)
+			entries = append(entries, Entry{})
+		}
+		return &entries[indices[number]]
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(key, "file"):
+			entry(strings.TrimPrefix(key, "file")).URL = value
+		case strings.HasPrefix(key, "title"):
+			entry(strings.TrimPrefix(key, "title")).Name = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	kept := entries[:0]
+	for _, entry := range entries {
+		if isStreamURL(entry.URL) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, nil
+}
+
+type xspfPlaylist struct {
+	Tracks []struct {
+		Location string `xml:"location"`
+		Title    string `xml:"title"`
+	} `xml:"trackList>track"`
+}
+
+func readXSPF(r io.Reader) ([]Entry, error) {
+	var playlist xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&playlist); err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, track := range playlist.Tracks {
+		location := strings.TrimSpace(track.Location)
+		if isStreamURL(location) {
+			entries = append(entries, Entry{Name: strings.TrimSpace(track.Title), URL: location})
+		}
+	}
+	return entries, nil
+}
+
+// isStreamURL returns true if s is an http or https URL, leaving out local files listed by playlists.
+func isStreamURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
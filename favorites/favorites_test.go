@@ -0,0 +1,132 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package favorites
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TuneIn's favorites, as exported from its presets
+const sampleTuneIn = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="1">
+  <head><title>Favorites</title><status>200</status></head>
+  <body>
+    <outline type="audio" text="Jazz FM (London, UK)" URL="http://opml.radiotime.com/Tune.ashx?id=s12345" guide_id="s12345" item="station"/>
+  </body>
+</opml>`
+
+const sampleM3U = `#EXTM3U
+#EXTINF:-1 tvg-logo="http://example.com/jazz.png",Jazz FM
+http://example.com/jazz
+#EXTVLCOPT:network-caching=1000
+http://example.com/rock
+/home/me/music/song.mp3
+`
+
+const samplePLS = `[playlist]
+NumberOfEntries=2
+File1=http://example.com/jazz
+Title1=Jazz FM
+Length1=-1
+File2=http://example.com/rock
+Version=2
+`
+
+const sampleXSPF = `<?xml version="1.0" encoding="UTF-8"?>
+<playlist xmlns="http://xspf.org/ns/0/" xmlns:vlc="http://www.videolan.org/vlc/playlist/ns/0/" version="1">
+  <title>Playlist</title>
+  <trackList>
+    <track><location>http://example.com/jazz</location><title>Jazz FM</title></track>
+    <track><location>file:///home/me/music/song.mp3</location></track>
+  </trackList>
+</playlist>`
+
+const sampleURLs = `http://example.com/jazz
+
+# rock
+https://example.com/rock
+not a url
+`
+
+func TestDetect(t *testing.T) {
+
+	assert.Equal(t, OPML, Detect([]byte(sampleTuneIn)))
+	assert.Equal(t, M3U, Detect([]byte(sampleM3U)))
+	assert.Equal(t, PLS, Detect([]byte(samplePLS)))
+	assert.Equal(t, XSPF, Detect([]byte(sampleXSPF)))
+	assert.Equal(t, URLs, Detect([]byte(sampleURLs)))
+	assert.Equal(t, M3U, Detect([]byte("\ufeff#EXTM3U\n")))
+
+}
+
+func TestParseFormat(t *testing.T) {
+
+	format, err := ParseFormat("XSPF")
+	assert.NoError(t, err)
+	assert.Equal(t, XSPF, format)
+
+	_, err = ParseFormat("asx")
+	assert.Error(t, err)
+
+}
+
+func TestRead(t *testing.T) {
+
+	t.Run("reads TuneIn favorites", func(t *testing.T) {
+		entries, err := Read(strings.NewReader(sampleTuneIn), OPML)
+		assert.NoError(t, err)
+		assert.Equal(t, []Entry{{Name: "Jazz FM (London, UK)", URL: "http://opml.radiotime.com/Tune.ashx?id=s12345"}}, entries)
+	})
+
+	t.Run("reads M3U playlists, naming entries after their #EXTINF lines", func(t *testing.T) {
+		entries, err := Read(strings.NewReader(sampleM3U), M3U)
+		assert.NoError(t, err)
+		assert.Equal(t, []Entry{{Name: "Jazz FM", URL: "http://example.com/jazz"}, {URL: "http://example.com/rock"}}, entries)
+	})
+
+	t.Run("reads PLS playlists", func(t *testing.T) {
+		entries, err := Read(strings.NewReader(samplePLS), PLS)
+		assert.NoError(t, err)
+		assert.Equal(t, []Entry{{Name: "Jazz FM", URL: "http://example.com/jazz"}, {URL: "http://example.com/rock"}}, entries)
+	})
+
+	t.Run("reads XSPF playlists, leaving out local files", func(t *testing.T) {
+		entries, err := Read(strings.NewReader(sampleXSPF), XSPF)
+		assert.NoError(t, err)
+		assert.Equal(t, []Entry{{Name: "Jazz FM", URL: "http://example.com/jazz"}}, entries)
+	})
+
+	t.Run("reads lists of URLs, skipping comments and anything else", func(t *testing.T) {
+		entries, err := Read(strings.NewReader(sampleURLs), URLs)
+		assert.NoError(t, err)
+		assert.Equal(t, []Entry{{URL: "http://example.com/jazz"}, {URL: "https://example.com/rock"}}, entries)
+	})
+
+	t.Run("returns an error for invalid documents", func(t *testing.T) {
+		_, err := Read(strings.NewReader("<playlist><trackList>"), XSPF)
+		assert.Error(t, err)
+		_, err = Read(strings.NewReader(sampleURLs), Format("asx"))
+		assert.Error(t, err)
+	})
+
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package favorites
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/icy"
+)
+
+// How much of a TuneIn link is read: it only lists a few streams.
+const maxTuneInSize = 64 << 10
+
+// tuneInSuffix is the location or genre TuneIn puts after the names of stations, e.g. "Jazz FM (London, UK)".
+var tuneInSuffix = regexp.MustCompile(`\s*\([^()]*\)$`)
+
+// ImportedStation is a station read from the favorites of another player.
+type ImportedStation struct {
+	Station common.Station
+	// Matched is true if the station was found on radio-browser,
+	// false if it was built from the entry alone.
+	Matched bool
+}
+
+// ResolveStations maps entries to stations, looking each one up on radio-browser
+// by stream URL first and by exact name then, so that imported stations carry the same
+// information as searched ones. TuneIn links are followed to the streams they point to with client,
+// as radio-browser doesn't know them. Entries that can't be found are kept as they are,
+// unless they have no stream URL to play. If browser is nil, no lookup is made, and if client is nil,
+// TuneIn links aren't followed.
+func ResolveStations(browser api.RadioBrowserService, client api.HTTPClientService, entries []Entry) []ImportedStation {
+
+	var imported []ImportedStation
+
+	for _, entry := range entries {
+		if isTuneInLink(entry.URL) {
+			entry = followTuneInLink(client, entry)
+		}
+		if station, ok := lookupStation(browser, entry); ok {
+			imported = append(imported, ImportedStation{Station: station, Matched: true})
+			continue
+		}
+		if station, ok := stationFromEntry(entry); ok {
+			imported = append(imported, ImportedStation{Station: station, Matched: false})
+		}
+	}
+
+	return imported
+}
+
+func lookupStation(browser api.RadioBrowserService, entry Entry) (common.Station, bool) {
+
+	if browser == nil {
+		return common.Station{}, false
+	}
+
+	if entry.URL != "" && !isTuneInLink(entry.URL) {
+		stations, err := browser.GetStationsByUrl(entry.URL)
+		if err == nil && len(stations) > 0 {
+			return stations[0], true
+		}
+	}
+
+	names := []string{entry.Name}
+	if trimmed := tuneInSuffix.ReplaceAllString(entry.Name, ""); trimmed != entry.Name && trimmed != "" {
+		names = append(names, trimmed)
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		stations, err := browser.GetStations(common.StationQueryByNameExact, name, "votes", true, 0, 1, false)
+		if err == nil && len(stations) > 0 {
+			return stations[0], true
+		}
+	}
+
+	return common.Station{}, false
+}
+
+// stationFromEntry builds a station out of an entry.
+// Its UUID is derived from the stream URL, so importing the same file twice doesn't duplicate it.
+func stationFromEntry(entry Entry) (common.Station, bool) {
+
+	if isTuneInLink(entry.URL) {
+		return common.Station{}, false
+	}
+	streamUrl, err := common.ParseStreamURL(entry.URL)
+	if err != nil {
+		return common.Station{}, false
+	}
+	return common.NewStationFromURL(streamUrl, entry.Name), true
+}
+
+// isTuneInLink returns true if rawUrl is a TuneIn link to a station, e.g. http://opml.radiotime.com/Tune.ashx?id=s12345,
+// which lists its streams rather than being one.
+func isTuneInLink(rawUrl string) bool {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return (host == "radiotime.com" || strings.HasSuffix(host, ".radiotime.com")) && strings.EqualFold(u.Path, "/Tune.ashx")
+}
+
+// followTuneInLink returns entry with the first stream its TuneIn link lists in place of the link,
+// or as it is if it can't be followed.
+func followTuneInLink(client api.HTTPClientService, entry Entry) Entry {
+
+	if client == nil {
+		return entry
+	}
+	req, err := http.NewRequest("GET", entry.URL, nil)
+	if err != nil {
+		return entry
+	}
+	req.Header.Set("User-Agent", data.UserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return entry
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return entry
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTuneInSize))
+	if err != nil {
+		return entry
+	}
+
+	streams := icy.ParsePlaylist(string(body), *req.URL)
+	if len(streams) == 0 || isTuneInLink(streams[0].String()) {
+		return entry
+	}
+	entry.URL = streams[0].String()
+	return entry
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package favorites
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveStations(t *testing.T) {
+
+	matched := common.Station{StationUuid: uuid.New(), Name: "Jazz FM (radio-browser)"}
+
+	mockBrowser := mocks.MockRadioBrowserService{
+		GetStationsByUrlFunc: func(streamUrl string) ([]common.Station, error) {
+			if streamUrl == "http://example.com/jazz" {
+				return []common.Station{matched}, nil
+			}
+			return nil, nil
+		},
+		GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+			assert.Equal(t, common.StationQueryByNameExact, stationQuery)
+			if searchTerm == "Smooth" {
+				return []common.Station{{Name: "Smooth"}}, nil
+			}
+			return nil, errors.New("not found")
+		},
+	}
+
+	entries := []Entry{
+		{Name: "Jazz FM", URL: "http://example.com/jazz"},
+		{Name: "Smooth", URL: "http://example.com/smooth"},
+		{Name: "Rock Radio", URL: "http://example.com/rock"},
+		{Name: "No URL"},
+	}
+
+	imported := ResolveStations(&mockBrowser, nil, entries)
+
+	assert.Len(t, imported, 3)
+
+	assert.True(t, imported[0].Matched)
+	assert.Equal(t, matched, imported[0].Station)
+
+	assert.True(t, imported[1].Matched)
+	assert.Equal(t, "Smooth", imported[1].Station.Name)
+
+	assert.False(t, imported[2].Matched)
+	assert.Equal(t, "Rock Radio", imported[2].Station.Name)
+	assert.Equal(t, "http://example.com/rock", imported[2].Station.Url.URL.String())
+	assert.Equal(t, uuid.NewSHA1(uuid.NameSpaceURL, []byte("http://example.com/rock")), imported[2].Station.StationUuid)
+
+}
+
+func TestResolveStationsWithoutBrowser(t *testing.T) {
+
+	imported := ResolveStations(nil, nil, []Entry{{Name: "Rock Radio", URL: "http://example.com/rock"}})
+
+	assert.Len(t, imported, 1)
+	assert.False(t, imported[0].Matched)
+	assert.Equal(t, "Rock Radio", imported[0].Station.Name)
+
+}
+
+func TestResolveStations_TuneIn(t *testing.T) {
+
+	tuneInLink := "http://opml.radiotime.com/Tune.ashx?id=s12345"
+	client := &mocks.MockHttpClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, tuneInLink, req.URL.String())
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader("http://stream.example.com/jazz.mp3\nhttp://stream.example.com/jazz.aac\n")),
+			}, nil
+		},
+	}
+
+	t.Run("looks up the stream TuneIn links to", func(t *testing.T) {
+
+		matched := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+		browser := &mocks.MockRadioBrowserService{
+			GetStationsByUrlFunc: func(streamUrl string) ([]common.Station, error) {
+				assert.Equal(t, "http://stream.example.com/jazz.mp3", streamUrl)
+				return []common.Station{matched}, nil
+			},
+		}
+
+		imported := ResolveStations(browser, client, []Entry{{Name: "Jazz FM (London, UK)", URL: tuneInLink}})
+
+		assert.Equal(t, []ImportedStation{{Station: matched, Matched: true}}, imported)
+
+	})
+
+	t.Run("imports the stream TuneIn links to as it is", func(t *testing.T) {
+
+		imported := ResolveStations(nil, client, []Entry{{Name: "Jazz FM", URL: tuneInLink}})
+
+		assert.Len(t, imported, 1)
+		assert.False(t, imported[0].Matched)
+		assert.Equal(t, "http://stream.example.com/jazz.mp3", imported[0].Station.Url.URL.String())
+
+	})
+
+	t.Run("looks up stations by their name without TuneIn's suffix", func(t *testing.T) {
+
+		var searched []string
+		browser := &mocks.MockRadioBrowserService{
+			GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				searched = append(searched, searchTerm)
+				if searchTerm == "Jazz FM" {
+					return []common.Station{{Name: "Jazz FM"}}, nil
+				}
+				return nil, nil
+			},
+		}
+
+		imported := ResolveStations(browser, nil, []Entry{{Name: "Jazz FM (London, UK)", URL: tuneInLink}})
+
+		assert.Equal(t, []string{"Jazz FM (London, UK)", "Jazz FM"}, searched)
+		assert.Len(t, imported, 1)
+		assert.True(t, imported[0].Matched)
+
+	})
+
+	t.Run("leaves out TuneIn links that can't be followed or found", func(t *testing.T) {
+
+		assert.Empty(t, ResolveStations(nil, nil, []Entry{{Name: "Jazz FM", URL: tuneInLink}}))
+
+	})
+
+}
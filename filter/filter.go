@@ -0,0 +1,113 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package filter implements the allow and deny lists used to keep stations
+// out of search results and to refuse playing them.
+package filter
+
+import (
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+// ErrBlocked is returned when trying to play a station the content filter denies.
+var ErrBlocked = i18n.Error("filter.blocked")
+
+// List matches stations by tag, country code (ISO 3166-1 alpha-2) or station UUID.
+// Matching is case-insensitive.
+type List struct {
+	Tags      []string `yaml:"tags"`
+	Countries []string `yaml:"countries"`
+	Stations  []string `yaml:"stations"`
+}
+
+// IsEmpty reports whether the list matches no station at all.
+func (l List) IsEmpty() bool {
+	return len(l.Tags) == 0 && len(l.Countries) == 0 && len(l.Stations) == 0
+}
+
+func (l List) matchesStation(station common.Station) bool {
+	return containsFold(l.Stations, station.StationUuid.String())
+}
+
+func (l List) matches(station common.Station) bool {
+	if l.matchesStation(station) || containsFold(l.Countries, station.CountryCode) {
+		return true
+	}
+	for _, tag := range strings.Split(station.Tags, ",") {
+		if containsFold(l.Tags, strings.TrimSpace(tag)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentFilter decides which stations can be listed and played.
+// The zero value allows every station.
+type ContentFilter struct {
+	// Allow, when not empty, restricts stations to those it matches.
+	Allow List `yaml:"allow"`
+	// Deny removes the stations it matches.
+	Deny List `yaml:"deny"`
+}
+
+// Allows reports whether station passes the filter.
+// A station explicitly listed by UUID wins over tags and countries:
+// denying it by UUID always blocks it, and allowing it by UUID lets it through
+// even if one of its tags or its country is denied.
+func (f ContentFilter) Allows(station common.Station) bool {
+	if f.Deny.matchesStation(station) {
+		return false
+	}
+	if f.Allow.matchesStation(station) {
+		return true
+	}
+	if f.Deny.matches(station) {
+		return false
+	}
+	return f.Allow.IsEmpty() || f.Allow.matches(station)
+}
+
+// Apply returns the stations the filter allows, in their original order.
+func (f ContentFilter) Apply(stations []common.Station) []common.Station {
+	if f.Allow.IsEmpty() && f.Deny.IsEmpty() {
+		return stations
+	}
+	allowed := make([]common.Station, 0, len(stations))
+	for _, station := range stations {
+		if f.Allows(station) {
+			allowed = append(allowed, station)
+		}
+	}
+	return allowed
+}
+
+func containsFold(values []string, s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, value := range values {
+		if strings.EqualFold(strings.TrimSpace(value), s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package filter
+
+import (
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentFilter_Allows(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz", Tags: "jazz, smooth jazz", CountryCode: "US"}
+	news := common.Station{StationUuid: uuid.New(), Name: "News", Tags: "news,talk", CountryCode: "GB"}
+	talk := common.Station{StationUuid: uuid.New(), Name: "Talk", Tags: "talk", CountryCode: "IT"}
+
+	t.Run("allows everything by default", func(t *testing.T) {
+		var f ContentFilter
+		assert.True(t, f.Allows(jazz))
+		assert.True(t, f.Allows(news))
+	})
+
+	t.Run("denies by tag, country and UUID", func(t *testing.T) {
+		f := ContentFilter{Deny: List{Tags: []string{"NEWS"}}}
+		assert.False(t, f.Allows(news))
+		assert.True(t, f.Allows(jazz))
+
+		f = ContentFilter{Deny: List{Countries: []string{"it"}}}
+		assert.False(t, f.Allows(talk))
+		assert.True(t, f.Allows(news))
+
+		f = ContentFilter{Deny: List{Stations: []string{jazz.StationUuid.String()}}}
+		assert.False(t, f.Allows(jazz))
+		assert.True(t, f.Allows(talk))
+	})
+
+	t.Run("only allows matching stations when the allow list is set", func(t *testing.T) {
+		f := ContentFilter{Allow: List{Tags: []string{"jazz"}, Countries: []string{"GB"}}}
+		assert.True(t, f.Allows(jazz))
+		assert.True(t, f.Allows(news))
+		assert.False(t, f.Allows(talk))
+	})
+
+	t.Run("lets UUIDs win over tags and countries", func(t *testing.T) {
+		f := ContentFilter{
+			Allow: List{Stations: []string{news.StationUuid.String()}},
+			Deny:  List{Tags: []string{"news"}},
+		}
+		assert.True(t, f.Allows(news))
+
+		f = ContentFilter{
+			Allow: List{Tags: []string{"jazz"}},
+			Deny:  List{Stations: []string{jazz.StationUuid.String()}},
+		}
+		assert.False(t, f.Allows(jazz))
+	})
+
+}
+
+func TestContentFilter_Apply(t *testing.T) {
+
+	stations := []common.Station{
+		{StationUuid: uuid.New(), Tags: "rock"},
+		{StationUuid: uuid.New(), Tags: "pop"},
+		{StationUuid: uuid.New(), Tags: "rock,pop"},
+	}
+
+	f := ContentFilter{Deny: List{Tags: []string{"pop"}}}
+
+	assert.Equal(t, stations[:1], f.Apply(stations))
+
+}
@@ -0,0 +1,293 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package hls
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/data"
+)
+
+// Playlists larger than this are cut off.
+const maxPlaylistSize = 1 << 20
+
+var (
+	// ErrNotPlaylist is returned when what was read doesn't start with #EXTM3U.
+	ErrNotPlaylist = errors.New("not an HLS playlist")
+	// ErrNotMasterPlaylist is returned for media playlists, which list segments rather than variant streams.
+	ErrNotMasterPlaylist = errors.New("not an HLS master playlist")
+)
+
+// Codecs of audio-only streams, as found in the CODECS attribute.
+var audioCodecs = []string{"mp4a", "ac-3", "ec-3", "opus", "mp3", "flac", "alac"}
+
+// Variant is a stream listed in an HLS master playlist.
+type Variant struct {
+	// URL of the media playlist of the variant.
+	URL url.URL
+	// Bandwidth is the peak bitrate of the variant, in bits per second.
+	Bandwidth int
+	Codecs    []string
+	// AudioOnly is true when the variant has no video.
+	AudioOnly bool
+	// Audio is the group of alternative audio renditions the variant is played with, if any.
+	Audio string
+}
+
+// Rendition is an alternative audio rendition listed in an HLS master playlist.
+type Rendition struct {
+	// URL of the media playlist of the rendition.
+	URL     url.URL
+	Group   string
+	Name    string
+	Default bool
+}
+
+// MasterPlaylist lists the variant streams of a station and their alternative audio renditions.
+type MasterPlaylist struct {
+	Variants   []Variant
+	Renditions []Rendition
+}
+
+// ParseMasterPlaylist reads a master playlist, resolving the URLs it lists against base.
+// It returns ErrNotMasterPlaylist if the playlist is a media playlist.
+func ParseMasterPlaylist(r io.Reader, base url.URL) (MasterPlaylist, error) {
+
+	var playlist MasterPlaylist
+
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff")) != "#EXTM3U" {
+		if err := scanner.Err(); err != nil {
+			return playlist, err
+		}
+		return playlist, ErrNotPlaylist
+	}
+
+	var pending *Variant
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			attributes := parseAttributes(strings.TrimPrefix(line, "#EXT-X-STREAM-INF:"))
+			bandwidth, _ := strconv.Atoi(attributes["BANDWIDTH"])
+			variant := Variant{
+				Bandwidth: bandwidth,
+				Audio:     attributes["AUDIO"],
+			}
+			if codecs := attributes["CODECS"]; codecs != "" {
+				for _, codec := range strings.Split(codecs, ",") {
+					variant.Codecs = append(variant.Codecs, strings.TrimSpace(codec))
+				}
+			}
+			variant.AudioOnly = attributes["RESOLUTION"] == "" && attributes["VIDEO"] == "" && isAudioOnly(variant.Codecs)
+			pending = &variant
+		case strings.HasPrefix(line, "#EXT-X-MEDIA:"):
+			attributes := parseAttributes(strings.TrimPrefix(line, "#EXT-X-MEDIA:"))
+			if attributes["TYPE"] != "AUDIO" || attributes["URI"] == "" {
+				continue
+			}
+			renditionUrl, err := base.Parse(attributes["URI"])
+			if err != nil {
+				continue
+			}
+			playlist.Renditions = append(playlist.Renditions, Rendition{
+				URL:     *renditionUrl,
+				Group:   attributes["GROUP-ID"],
+				Name:    attributes["NAME"],
+				Default: attributes["DEFAULT"] == "YES",
+			})
+		case strings.HasPrefix(line, "#EXTINF:"):
+			return playlist, ErrNotMasterPlaylist
+		case strings.HasPrefix(line, "#"):
+			continue
+		case pending != nil:
+			variantUrl, err := base.Parse(line)
+			if err == nil {
+				pending.URL = *variantUrl
+				playlist.Variants = append(playlist.Variants, *pending)
+			}
+			pending = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return playlist, err
+	}
+
+	if len(playlist.Variants) == 0 {
+		return playlist, ErrNotMasterPlaylist
+	}
+
+	return playlist, nil
+}
+
+// isAudioOnly returns true if all codecs are audio codecs. Variants that don't list their codecs are assumed to be audio.
+func isAudioOnly(codecs []string) bool {
+	for _, codec := range codecs {
+		audio := false
+		for _, prefix := range audioCodecs {
+			if strings.HasPrefix(strings.ToLower(codec), prefix) {
+				audio = true
+				break
+			}
+		}
+		if !audio {
+			return false
+		}
+	}
+	return true
+}
+
+// parseAttributes reads an attribute list, e.g. BANDWIDTH=128000,CODECS="mp4a.40.2,mp4a.40.5".
+func parseAttributes(list string) map[string]string {
+	attributes := make(map[string]string)
+	for list != "" {
+		name, rest, found := strings.Cut(list, "=")
+		if !found {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, "\"") {
+			end := strings.Index(rest[1:], "\"")
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+			rest = strings.TrimPrefix(rest, ",")
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		attributes[strings.TrimSpace(name)] = value
+		list = rest
+	}
+	return attributes
+}
+
+// Select returns the URL of the media playlist to play: that of the audio-only variant closest to
+// preferredKbps without exceeding it (or the lightest one if all exceed it), or the heaviest one if
+// preferredKbps is 0. Video variants are only picked when there is no audio-only one, and then
+// their alternative audio rendition is played instead, if they have one.
+func (p MasterPlaylist) Select(preferredKbps int) url.URL {
+
+	candidates := make([]Variant, 0, len(p.Variants))
+	for _, variant := range p.Variants {
+		if variant.AudioOnly {
+			candidates = append(candidates, variant)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = p.Variants
+	}
+
+	best := candidates[0]
+	for _, variant := range candidates[1:] {
+		if betterVariant(variant, best, preferredKbps*1000) {
+			best = variant
+		}
+	}
+
+	if rendition, ok := p.rendition(best.Audio); ok && !best.AudioOnly {
+		return rendition.URL
+	}
+	return best.URL
+}
+
+// betterVariant returns true if a is closer than b to the preferred bandwidth (0 meaning the highest).
+func betterVariant(a Variant, b Variant, preferred int) bool {
+	if preferred <= 0 {
+		return a.Bandwidth > b.Bandwidth
+	}
+	aFits, bFits := a.Bandwidth <= preferred, b.Bandwidth <= preferred
+	switch {
+	case aFits && bFits:
+		return a.Bandwidth > b.Bandwidth
+	case aFits != bFits:
+		return aFits
+	default:
+		return a.Bandwidth < b.Bandwidth
+	}
+}
+
+// rendition returns the default rendition of an audio group, or its first one.
+func (p MasterPlaylist) rendition(group string) (Rendition, bool) {
+	var found *Rendition
+	for i, rendition := range p.Renditions {
+		if group == "" || rendition.Group != group {
+			continue
+		}
+		if rendition.Default {
+			return rendition, true
+		}
+		if found == nil {
+			found = &p.Renditions[i]
+		}
+	}
+	if found == nil {
+		return Rendition{}, false
+	}
+	return *found, true
+}
+
+// Resolve fetches the playlist at playlistUrl and returns the media playlist to play, chosen with
+// Select if it's a master playlist, or playlistUrl itself if it's already a media playlist.
+func Resolve(httpClient api.HTTPClientService, playlistUrl url.URL, preferredKbps int) (url.URL, error) {
+
+	req, err := http.NewRequest("GET", playlistUrl.String(), nil)
+	if err != nil {
+		return playlistUrl, err
+	}
+	req.Header.Set("User-Agent", data.UserAgent)
+
+	result, err := httpClient.Do(req)
+	if err != nil {
+		return playlistUrl, err
+	}
+	defer result.Body.Close()
+
+	if result.StatusCode != http.StatusOK {
+		return playlistUrl, fmt.Errorf("unexpected status code: %d", result.StatusCode)
+	}
+
+	// Redirects move the base of relative URLs
+	base := playlistUrl
+	if result.Request != nil && result.Request.URL != nil {
+		base = *result.Request.URL
+	}
+
+	playlist, err := ParseMasterPlaylist(io.LimitReader(result.Body, maxPlaylistSize), base)
+	if errors.Is(err, ErrNotMasterPlaylist) {
+		return playlistUrl, nil
+	}
+	if err != nil {
+		return playlistUrl, err
+	}
+
+	return playlist.Select(preferredKbps), nil
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package hls
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const masterPlaylist = `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",NAME="Stereo",DEFAULT=YES,URI="audio/stereo.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=48000,CODECS="mp4a.40.5"
+low/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=128000,CODECS="mp4a.40.2"
+mid/index.m3u8
+
+#EXT-X-STREAM-INF:BANDWIDTH=320000,CODECS="mp4a.40.2"
+https://cdn.example.com/high/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2000000,RESOLUTION=1280x720,CODECS="avc1.4d401f,mp4a.40.2",AUDIO="aac"
+video/index.m3u8
+`
+
+const mediaPlaylist = `#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXTINF:10.0,
+segment1.aac
+`
+
+func mustParse(t *testing.T, rawUrl string) url.URL {
+	u, err := url.Parse(rawUrl)
+	assert.NoError(t, err)
+	return *u
+}
+
+func TestParseMasterPlaylist(t *testing.T) {
+
+	base := mustParse(t, "https://example.com/live/master.m3u8")
+
+	t.Run("reads variants and audio renditions", func(t *testing.T) {
+		playlist, err := ParseMasterPlaylist(strings.NewReader(masterPlaylist), base)
+		assert.NoError(t, err)
+		assert.Len(t, playlist.Variants, 4)
+		assert.Equal(t, "https://example.com/live/low/index.m3u8", playlist.Variants[0].URL.String())
+		assert.Equal(t, 48000, playlist.Variants[0].Bandwidth)
+		assert.Equal(t, []string{"mp4a.40.5"}, playlist.Variants[0].Codecs)
+		assert.True(t, playlist.Variants[0].AudioOnly)
+		assert.Equal(t, "https://cdn.example.com/high/index.m3u8", playlist.Variants[2].URL.String())
+		assert.False(t, playlist.Variants[3].AudioOnly)
+		assert.Equal(t, []string{"avc1.4d401f", "mp4a.40.2"}, playlist.Variants[3].Codecs)
+		assert.Equal(t, "aac", playlist.Variants[3].Audio)
+		assert.Equal(t, []Rendition{{
+			URL:     mustParse(t, "https://example.com/live/audio/stereo.m3u8"),
+			Group:   "aac",
+			Name:    "Stereo",
+			Default: true,
+		}}, playlist.Renditions)
+	})
+
+	t.Run("tells media playlists apart", func(t *testing.T) {
+		_, err := ParseMasterPlaylist(strings.NewReader(mediaPlaylist), base)
+		assert.ErrorIs(t, err, ErrNotMasterPlaylist)
+	})
+
+	t.Run("refuses what isn't a playlist", func(t *testing.T) {
+		_, err := ParseMasterPlaylist(strings.NewReader("<html></html>"), base)
+		assert.ErrorIs(t, err, ErrNotPlaylist)
+	})
+
+}
+
+func TestMasterPlaylistSelect(t *testing.T) {
+
+	base := mustParse(t, "https://example.com/live/master.m3u8")
+	playlist, err := ParseMasterPlaylist(strings.NewReader(masterPlaylist), base)
+	assert.NoError(t, err)
+
+	t.Run("picks the heaviest audio variant without a preference", func(t *testing.T) {
+		selected := playlist.Select(0)
+		assert.Equal(t, "https://cdn.example.com/high/index.m3u8", selected.String())
+	})
+
+	t.Run("picks the heaviest audio variant within the preference", func(t *testing.T) {
+		selected := playlist.Select(192)
+		assert.Equal(t, "https://example.com/live/mid/index.m3u8", selected.String())
+	})
+
+	t.Run("picks the lightest audio variant when all exceed the preference", func(t *testing.T) {
+		selected := playlist.Select(32)
+		assert.Equal(t, "https://example.com/live/low/index.m3u8", selected.String())
+	})
+
+	t.Run("plays the audio rendition of a video variant", func(t *testing.T) {
+		videoOnly := MasterPlaylist{
+			Variants:   playlist.Variants[3:],
+			Renditions: playlist.Renditions,
+		}
+		selected := videoOnly.Select(0)
+		assert.Equal(t, "https://example.com/live/audio/stereo.m3u8", selected.String())
+	})
+
+}
+
+func TestParseAttributes(t *testing.T) {
+	attributes := parseAttributes(`BANDWIDTH=128000,CODECS="mp4a.40.2,mp4a.40.5",NAME="A, B"`)
+	assert.Equal(t, map[string]string{
+		"BANDWIDTH": "128000",
+		"CODECS":    "mp4a.40.2,mp4a.40.5",
+		"NAME":      "A, B",
+	}, attributes)
+}
+
+func TestResolve(t *testing.T) {
+
+	playlistUrl := mustParse(t, "https://example.com/live/master.m3u8")
+
+	respond := func(body string) *mocks.MockHttpClient {
+		return &mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, data.UserAgent, req.Header.Get("User-Agent"))
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(body)),
+					Request:    req,
+				}, nil
+			},
+		}
+	}
+
+	t.Run("returns the selected variant of a master playlist", func(t *testing.T) {
+		mediaUrl, err := Resolve(respond(masterPlaylist), playlistUrl, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com/live/low/index.m3u8", mediaUrl.String())
+	})
+
+	t.Run("returns a media playlist as it is", func(t *testing.T) {
+		mediaUrl, err := Resolve(respond(mediaPlaylist), playlistUrl, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, playlistUrl, mediaUrl)
+	})
+
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package hls
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/data"
+)
+
+// How far the local clock may be from the server's before the server's is trusted instead.
+// The Date header only has a resolution of one second.
+const clockSkewTolerance = 2 * time.Second
+
+// ErrNoTimestamps is returned for media playlists whose segments aren't timestamped with EXT-X-PROGRAM-DATE-TIME.
+var ErrNoTimestamps = errors.New("the HLS playlist has no timestamps")
+
+// LiveEdge is the newest segment of a live media playlist.
+type LiveEdge struct {
+	// End is when the end of the segment was recorded, by the timestamps of the playlist.
+	End time.Time
+	// TargetDuration is the longest a segment of the playlist lasts.
+	TargetDuration time.Duration
+}
+
+// ParseLiveEdge reads a media playlist and returns its newest segment, or ErrNoTimestamps if it can't tell
+// when it was recorded.
+func ParseLiveEdge(r io.Reader) (LiveEdge, error) {
+
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "#EXTM3U" {
+		return LiveEdge{}, ErrNotPlaylist
+	}
+
+	var edge LiveEdge
+	// When the next segment starts, once a timestamp was found
+	var next time.Time
+	var duration time.Duration
+	found := false
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			seconds, err := strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-TARGETDURATION:"))
+			if err == nil {
+				edge.TargetDuration = time.Duration(seconds) * time.Second
+			}
+		case strings.HasPrefix(line, "#EXT-X-PROGRAM-DATE-TIME:"):
+			if t, err := parseProgramDateTime(strings.TrimPrefix(line, "#EXT-X-PROGRAM-DATE-TIME:")); err == nil {
+				next = t
+			}
+		case strings.HasPrefix(line, "#EXTINF:"):
+			seconds, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			if value, err := strconv.ParseFloat(strings.TrimSpace(seconds), 64); err == nil {
+				duration = time.Duration(value * float64(time.Second))
+			}
+		case strings.HasPrefix(line, "#"):
+		default:
+			// A segment, ending where the next one starts
+			if !next.IsZero() {
+				next = next.Add(duration)
+				edge.End = next
+				found = true
+			}
+			duration = 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return LiveEdge{}, err
+	}
+
+	if !found {
+		return LiveEdge{}, ErrNoTimestamps
+	}
+	return edge, nil
+}
+
+// parseProgramDateTime parses the value of EXT-X-PROGRAM-DATE-TIME, an ISO 8601 date and time,
+// with or without a colon in the time zone offset.
+func parseProgramDateTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Parse("2006-01-02T15:04:05.999999999Z0700", value)
+	}
+	return t, nil
+}
+
+// LiveLatency fetches the media playlist at playlistUrl and returns how long ago the end of its newest segment
+// was recorded, and the target duration of its segments.
+// It's measured against the clock of the server, as told by the Date header, if the local clock is off by more
+// than a couple of seconds.
+func LiveLatency(httpClient api.HTTPClientService, playlistUrl url.URL) (time.Duration, time.Duration, error) {
+
+	req, err := http.NewRequest("GET", playlistUrl.String(), nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Header.Set("User-Agent", data.UserAgent)
+
+	result, err := httpClient.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer result.Body.Close()
+	now := time.Now()
+
+	if result.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("unexpected status code: %d", result.StatusCode)
+	}
+
+	edge, err := ParseLiveEdge(io.LimitReader(result.Body, maxPlaylistSize))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if date, err := http.ParseTime(result.Header.Get("Date")); err == nil {
+		if skew := now.Sub(date); skew > clockSkewTolerance || skew < -clockSkewTolerance {
+			now = date
+		}
+	}
+
+	latency := now.Sub(edge.End)
+	if latency < 0 {
+		latency = 0
+	}
+	return latency, edge.TargetDuration, nil
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package hls
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+)
+
+const timestampedPlaylist = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:6
+#EXT-X-MEDIA-SEQUENCE:1042
+#EXT-X-PROGRAM-DATE-TIME:2023-10-01T12:00:00.000Z
+#EXTINF:6.000,
+segment1042.aac
+#EXTINF:6.000,
+segment1043.aac
+#EXTINF:5.500,
+segment1044.aac
+`
+
+func TestParseLiveEdge(t *testing.T) {
+
+	t.Run("returns when the newest segment ends", func(t *testing.T) {
+		edge, err := ParseLiveEdge(strings.NewReader(timestampedPlaylist))
+		assert.NoError(t, err)
+		assert.Equal(t, LiveEdge{
+			End:            time.Date(2023, 10, 1, 12, 0, 17, 500_000_000, time.UTC),
+			TargetDuration: 6 * time.Second,
+		}, edge)
+	})
+
+	t.Run("follows the latest timestamp, whatever its time zone offset", func(t *testing.T) {
+		edge, err := ParseLiveEdge(strings.NewReader(`#EXTM3U
+#EXT-X-TARGETDURATION:10
+#EXT-X-PROGRAM-DATE-TIME:2023-10-01T13:00:00+0100
+#EXTINF:10,
+a.ts
+#EXT-X-DISCONTINUITY
+#EXT-X-PROGRAM-DATE-TIME:2023-10-01T14:00:00+01:00
+#EXTINF:10,
+b.ts
+`))
+		assert.NoError(t, err)
+		assert.True(t, edge.End.Equal(time.Date(2023, 10, 1, 13, 0, 10, 0, time.UTC)))
+	})
+
+	t.Run("fails without timestamps", func(t *testing.T) {
+		_, err := ParseLiveEdge(strings.NewReader(mediaPlaylist))
+		assert.ErrorIs(t, err, ErrNoTimestamps)
+		_, err = ParseLiveEdge(strings.NewReader("<html>"))
+		assert.ErrorIs(t, err, ErrNotPlaylist)
+	})
+
+}
+
+func TestLiveLatency(t *testing.T) {
+
+	playlistUrl := mustParse(t, "https://example.com/live/index.m3u8")
+
+	t.Run("measures against the server's clock if the local one is off", func(t *testing.T) {
+		client := &mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Date": []string{"Sun, 01 Oct 2023 12:00:25 GMT"}},
+					Body:       io.NopCloser(strings.NewReader(timestampedPlaylist)),
+				}, nil
+			},
+		}
+		latency, targetDuration, err := LiveLatency(client, playlistUrl)
+		assert.NoError(t, err)
+		assert.Equal(t, 7500*time.Millisecond, latency)
+		assert.Equal(t, 6*time.Second, targetDuration)
+	})
+
+	t.Run("measures against the local clock otherwise", func(t *testing.T) {
+		playlist := strings.Replace(timestampedPlaylist, "2023-10-01T12:00:00.000Z", time.Now().Add(-30*time.Second).UTC().Format(time.RFC3339Nano), 1)
+		client := &mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Date": []string{time.Now().UTC().Format(http.TimeFormat)}},
+					Body:       io.NopCloser(strings.NewReader(playlist)),
+				}, nil
+			},
+		}
+		latency, _, err := LiveLatency(client, playlistUrl)
+		assert.NoError(t, err)
+		assert.InDelta(t, float64(12500*time.Millisecond), float64(latency), float64(time.Second))
+	})
+
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/hotkeys"
+)
+
+// listenForHotkeys starts listening for the system-wide hotkeys in the configuration, if enabled.
+// RadioGoGo runs without them, telling why, if they can't be registered.
+func listenForHotkeys(cfg config.Config) *hotkeys.Listener {
+
+	if !cfg.Hotkeys.Enabled {
+		return nil
+	}
+
+	bindings, err := hotkeys.ParseBindings(map[hotkeys.Action]string{
+		hotkeys.ActionPlay: cfg.Hotkeys.Play,
+		hotkeys.ActionStop: cfg.Hotkeys.Stop,
+		hotkeys.ActionNext: cfg.Hotkeys.Next,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring the hotkeys in the config: %v\n", err)
+		return nil
+	}
+
+	listener, err := hotkeys.Listen(bindings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listening for hotkeys: %v\n", err)
+		return nil
+	}
+
+	return listener
+
+}
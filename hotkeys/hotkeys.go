@@ -0,0 +1,207 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package hotkeys registers system-wide hotkeys, so that RadioGoGo can be controlled
+// while its terminal is in the background.
+// They're read from the input devices on Linux and registered with the system on Windows;
+// other platforms aren't supported.
+package hotkeys
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned by Listen on platforms without system-wide hotkeys.
+var ErrUnsupported = errors.New("system-wide hotkeys are not supported on this platform")
+
+// Action is what a hotkey asks RadioGoGo to do.
+type Action string
+
+const (
+	// ActionPlay plays the highlighted station, or stops the one playing.
+	ActionPlay Action = "play"
+	// ActionStop stops playback.
+	ActionStop Action = "stop"
+	// ActionNext plays the station after the highlighted one.
+	ActionNext Action = "next"
+)
+
+// Modifiers are the keys held down along with the key of a combo.
+type Modifiers uint8
+
+const (
+	ModCtrl Modifiers = 1 << iota
+	ModAlt
+	ModShift
+	ModSuper
+)
+
+// Media keys, which can be bound without modifiers.
+const (
+	KeyMediaPlayPause = "media-playpause"
+	KeyMediaStop      = "media-stop"
+	KeyMediaNext      = "media-next"
+	KeyMediaPrevious  = "media-previous"
+)
+
+// DefaultCombos are bound to the actions left unset in the configuration.
+var DefaultCombos = map[Action]string{
+	ActionPlay: KeyMediaPlayPause,
+	ActionStop: KeyMediaStop,
+	ActionNext: KeyMediaNext,
+}
+
+var modifierNames = map[string]Modifiers{
+	"ctrl":    ModCtrl,
+	"control": ModCtrl,
+	"alt":     ModAlt,
+	"option":  ModAlt,
+	"shift":   ModShift,
+	"super":   ModSuper,
+	"win":     ModSuper,
+	"cmd":     ModSuper,
+	"meta":    ModSuper,
+}
+
+// Combo is a key pressed while holding some modifiers, such as ctrl+alt+p.
+type Combo struct {
+	Modifiers Modifiers
+	// Key is a lowercase letter or digit, a function key from "f1" to "f12", or a media key.
+	Key string
+}
+
+// ParseCombo parses a combo such as "ctrl+alt+p" or "media-playpause".
+// Letters, digits and function keys need at least one modifier, so that they can still be typed.
+func ParseCombo(s string) (Combo, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "+")
+	var combo Combo
+	for _, part := range parts[:len(parts)-1] {
+		modifier, ok := modifierNames[strings.TrimSpace(part)]
+		if !ok {
+			return Combo{}, fmt.Errorf("unknown modifier %q in hotkey %q", part, s)
+		}
+		combo.Modifiers |= modifier
+	}
+	combo.Key = strings.TrimSpace(parts[len(parts)-1])
+	if !isKey(combo.Key) {
+		return Combo{}, fmt.Errorf("unknown key %q in hotkey %q", combo.Key, s)
+	}
+	if combo.Modifiers == 0 && !combo.IsMediaKey() {
+		return Combo{}, fmt.Errorf("hotkey %q needs a modifier such as ctrl or alt", s)
+	}
+	return combo, nil
+}
+
+// IsMediaKey returns true if the key of the combo is a media key.
+func (c Combo) IsMediaKey() bool {
+	return strings.HasPrefix(c.Key, "media-")
+}
+
+func (c Combo) String() string {
+	var parts []string
+	for _, modifier := range []struct {
+		name     string
+		modifier Modifiers
+	}{{"ctrl", ModCtrl}, {"alt", ModAlt}, {"shift", ModShift}, {"super", ModSuper}} {
+		if c.Modifiers&modifier.modifier != 0 {
+			parts = append(parts, modifier.name)
+		}
+	}
+	return strings.Join(append(parts, c.Key), "+")
+}
+
+// isKey returns true if key is a letter, a digit, a function key or a media key.
+func isKey(key string) bool {
+	switch key {
+	case KeyMediaPlayPause, KeyMediaStop, KeyMediaNext, KeyMediaPrevious:
+		return true
+	}
+	if len(key) == 1 {
+		return key[0] >= 'a' && key[0] <= 'z' || key[0] >= '0' && key[0] <= '9'
+	}
+	if strings.HasPrefix(key, "f") {
+		n, err := strconv.Atoi(key[1:])
+		return err == nil && n >= 1 && n <= 12
+	}
+	return false
+}
+
+// Bindings map the hotkeys to their actions.
+type Bindings map[Action]Combo
+
+// ParseBindings parses the combos of each action, falling back to DefaultCombos for those left empty.
+func ParseBindings(combos map[Action]string) (Bindings, error) {
+	bindings := make(Bindings)
+	for action, fallback := range DefaultCombos {
+		s := combos[action]
+		if s == "" {
+			s = fallback
+		}
+		combo, err := ParseCombo(s)
+		if err != nil {
+			return nil, err
+		}
+		bindings[action] = combo
+	}
+	return bindings, nil
+}
+
+// match returns the action bound to key pressed while holding modifiers, if any.
+func (b Bindings) match(modifiers Modifiers, key string) (Action, bool) {
+	for action, combo := range b {
+		if combo.Key == key && combo.Modifiers == modifiers {
+			return action, true
+		}
+	}
+	return "", false
+}
+
+// Listener delivers the actions of the hotkeys pressed, wherever the focus is.
+type Listener struct {
+	actions chan Action
+	close   func() error
+}
+
+// Listen starts listening for the hotkeys in bindings.
+// It returns ErrUnsupported on platforms without system-wide hotkeys.
+func Listen(bindings Bindings) (*Listener, error) {
+	return listen(bindings)
+}
+
+// Actions returns the channel on which the actions of the hotkeys pressed are delivered.
+// It is closed when the listener is closed.
+func (l *Listener) Actions() <-chan Action {
+	return l.actions
+}
+
+// Close stops listening for the hotkeys.
+func (l *Listener) Close() error {
+	return l.close()
+}
+
+// send delivers action, dropping it if the previous ones haven't been handled yet.
+func (l *Listener) send(action Action) {
+	select {
+	case l.actions <- action:
+	default:
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build linux
+
+package hotkeys
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// ErrNoDevices is returned by Listen when none of the input devices can be read,
+// usually because the user isn't in the "input" group.
+var ErrNoDevices = errors.New("no input device in /dev/input can be read (is your user in the input group?)")
+
+// inputEvent is the struct input_event of the Linux input subsystem.
+type inputEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+const (
+	evKey = 1
+
+	keyReleased = 0
+	keyPressed  = 1
+)
+
+// modifierCodes are the key codes of the left and right modifier keys.
+var modifierCodes = map[uint16]Modifiers{
+	29:  ModCtrl,
+	97:  ModCtrl,
+	56:  ModAlt,
+	100: ModAlt,
+	42:  ModShift,
+	54:  ModShift,
+	125: ModSuper,
+	126: ModSuper,
+}
+
+// keyCodes are the key codes of the keys that can be bound.
+var keyCodes = func() map[uint16]string {
+	codes := map[uint16]string{
+		87:  "f11",
+		88:  "f12",
+		163: KeyMediaNext,
+		164: KeyMediaPlayPause,
+		165: KeyMediaPrevious,
+		166: KeyMediaStop,
+		200: KeyMediaPlayPause,
+		201: KeyMediaPlayPause,
+	}
+	rows := []struct {
+		first uint16
+		keys  string
+	}{
+		{2, "1234567890"},
+		{16, "qwertyuiop"},
+		{30, "asdfghjkl"},
+		{44, "zxcvbnm"},
+	}
+	for _, row := range rows {
+		for i, key := range row.keys {
+			codes[row.first+uint16(i)] = string(key)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		codes[59+uint16(i)] = "f" + strconv.Itoa(i+1)
+	}
+	return codes
+}()
+
+// keyboard follows the modifiers held down on an input device.
+type keyboard struct {
+	bindings Bindings
+	held     map[uint16]bool
+}
+
+func newKeyboard(bindings Bindings) *keyboard {
+	return &keyboard{bindings: bindings, held: make(map[uint16]bool)}
+}
+
+// event handles an input event, returning the action of the hotkey it completes, if any.
+func (k *keyboard) event(event inputEvent) (Action, bool) {
+	if event.Type != evKey {
+		return "", false
+	}
+	if _, ok := modifierCodes[event.Code]; ok {
+		k.held[event.Code] = event.Value != keyReleased
+		return "", false
+	}
+	key, ok := keyCodes[event.Code]
+	if !ok || event.Value != keyPressed {
+		return "", false
+	}
+	var modifiers Modifiers
+	for code, held := range k.held {
+		if held {
+			modifiers |= modifierCodes[code]
+		}
+	}
+	return k.bindings.match(modifiers, key)
+}
+
+// listen reads the key presses of every input device that can be read.
+func listen(bindings Bindings) (*Listener, error) {
+
+	paths, err := filepath.Glob("/dev/input/event*")
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []*os.File
+	for _, path := range paths {
+		device, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		devices = append(devices, device)
+	}
+	if len(devices) == 0 {
+		return nil, ErrNoDevices
+	}
+
+	listener := &Listener{
+		actions: make(chan Action, 8),
+		close: func() error {
+			for _, device := range devices {
+				device.Close()
+			}
+			return nil
+		},
+	}
+
+	var wg sync.WaitGroup
+	for _, device := range devices {
+		wg.Add(1)
+		go func(device *os.File) {
+			defer wg.Done()
+			listener.read(device, newKeyboard(bindings))
+		}(device)
+	}
+	go func() {
+		wg.Wait()
+		close(listener.actions)
+	}()
+
+	return listener, nil
+}
+
+// read delivers the hotkeys pressed on device until it's closed.
+func (l *Listener) read(device *os.File, keyboard *keyboard) {
+	var events [64]inputEvent
+	buffer := (*[len(events) * int(unsafe.Sizeof(inputEvent{}))]byte)(unsafe.Pointer(&events))[:]
+	for {
+		n, err := device.Read(buffer)
+		if err != nil {
+			return
+		}
+		for _, event := range events[:n/int(unsafe.Sizeof(inputEvent{}))] {
+			if action, ok := keyboard.event(event); ok {
+				l.send(action)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build linux
+
+package hotkeys
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyboard(t *testing.T) {
+
+	const (
+		leftCtrl = 29
+		leftAlt  = 56
+		keyP     = 25
+	)
+
+	key := func(code uint16, value int32) inputEvent {
+		return inputEvent{Type: evKey, Code: code, Value: value}
+	}
+
+	bindings := Bindings{
+		ActionPlay: {Modifiers: ModCtrl | ModAlt, Key: "p"},
+		ActionNext: {Key: KeyMediaNext},
+	}
+
+	t.Run("matches a key pressed while holding the modifiers", func(t *testing.T) {
+
+		keyboard := newKeyboard(bindings)
+
+		_, ok := keyboard.event(key(leftCtrl, keyPressed))
+		assert.False(t, ok)
+		_, ok = keyboard.event(key(leftAlt, keyPressed))
+		assert.False(t, ok)
+
+		action, ok := keyboard.event(key(keyP, keyPressed))
+		assert.True(t, ok)
+		assert.Equal(t, ActionPlay, action)
+
+		// Neither the release nor the autorepeat trigger it again
+		_, ok = keyboard.event(key(keyP, 2))
+		assert.False(t, ok)
+		_, ok = keyboard.event(key(keyP, keyReleased))
+		assert.False(t, ok)
+
+	})
+
+	t.Run("ignores the key once a modifier is released", func(t *testing.T) {
+
+		keyboard := newKeyboard(bindings)
+
+		keyboard.event(key(leftCtrl, keyPressed))
+		keyboard.event(key(leftAlt, keyPressed))
+		keyboard.event(key(leftAlt, keyReleased))
+
+		_, ok := keyboard.event(key(keyP, keyPressed))
+		assert.False(t, ok)
+
+	})
+
+	t.Run("matches media keys", func(t *testing.T) {
+
+		action, ok := newKeyboard(bindings).event(key(163, keyPressed))
+
+		assert.True(t, ok)
+		assert.Equal(t, ActionNext, action)
+
+	})
+
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package hotkeys
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCombo(t *testing.T) {
+
+	t.Run("parses modifiers and keys", func(t *testing.T) {
+
+		combo, err := ParseCombo("Ctrl+Alt+P")
+
+		assert.NoError(t, err)
+		assert.Equal(t, Combo{Modifiers: ModCtrl | ModAlt, Key: "p"}, combo)
+		assert.Equal(t, "ctrl+alt+p", combo.String())
+
+	})
+
+	t.Run("parses function and media keys", func(t *testing.T) {
+
+		combo, err := ParseCombo("super+f12")
+		assert.NoError(t, err)
+		assert.Equal(t, Combo{Modifiers: ModSuper, Key: "f12"}, combo)
+
+		combo, err = ParseCombo("media-playpause")
+		assert.NoError(t, err)
+		assert.Equal(t, Combo{Key: KeyMediaPlayPause}, combo)
+
+	})
+
+	t.Run("rejects unknown keys and modifiers", func(t *testing.T) {
+
+		for _, s := range []string{"ctrl+f13", "hyper+p", "ctrl+", "ctrl+enter"} {
+			_, err := ParseCombo(s)
+			assert.Error(t, err, s)
+		}
+
+	})
+
+	t.Run("rejects keys that would be typed without modifiers", func(t *testing.T) {
+
+		_, err := ParseCombo("p")
+
+		assert.Error(t, err)
+
+	})
+
+}
+
+func TestParseBindings(t *testing.T) {
+
+	bindings, err := ParseBindings(map[Action]string{ActionNext: "ctrl+alt+n"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Bindings{
+		ActionPlay: {Key: KeyMediaPlayPause},
+		ActionStop: {Key: KeyMediaStop},
+		ActionNext: {Modifiers: ModCtrl | ModAlt, Key: "n"},
+	}, bindings)
+
+	action, ok := bindings.match(ModCtrl|ModAlt, "n")
+	assert.True(t, ok)
+	assert.Equal(t, ActionNext, action)
+
+	_, ok = bindings.match(ModCtrl, "n")
+	assert.False(t, ok)
+
+}
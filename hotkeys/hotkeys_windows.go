@@ -0,0 +1,159 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+//go:build windows
+
+package hotkeys
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32                 = windows.NewLazySystemDLL("user32.dll")
+	procRegisterHotKey     = user32.NewProc("RegisterHotKey")
+	procUnregisterHotKey   = user32.NewProc("UnregisterHotKey")
+	procGetMessageW        = user32.NewProc("GetMessageW")
+	procPostThreadMessageW = user32.NewProc("PostThreadMessageW")
+)
+
+const (
+	modAlt      = 0x0001
+	modControl  = 0x0002
+	modShift    = 0x0004
+	modWin      = 0x0008
+	modNoRepeat = 0x4000
+
+	wmQuit   = 0x0012
+	wmHotkey = 0x0312
+)
+
+// message is the MSG struct of the Windows message loop.
+type message struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+	private uint32
+}
+
+// virtualKey returns the virtual-key code of key.
+func virtualKey(key string) uintptr {
+	switch key {
+	case KeyMediaNext:
+		return 0xB0
+	case KeyMediaPrevious:
+		return 0xB1
+	case KeyMediaStop:
+		return 0xB2
+	case KeyMediaPlayPause:
+		return 0xB3
+	}
+	if len(key) == 1 {
+		// Letters and digits are their uppercase ASCII code
+		return uintptr(strings.ToUpper(key)[0])
+	}
+	n, _ := strconv.Atoi(key[1:])
+	return 0x70 + uintptr(n-1)
+}
+
+// windowsModifiers returns the MOD_* flags of modifiers.
+func windowsModifiers(modifiers Modifiers) uintptr {
+	flags := uintptr(modNoRepeat)
+	if modifiers&ModCtrl != 0 {
+		flags |= modControl
+	}
+	if modifiers&ModAlt != 0 {
+		flags |= modAlt
+	}
+	if modifiers&ModShift != 0 {
+		flags |= modShift
+	}
+	if modifiers&ModSuper != 0 {
+		flags |= modWin
+	}
+	return flags
+}
+
+// listen registers the hotkeys with the system, on a thread of their own running the message loop
+// that they're delivered to.
+func listen(bindings Bindings) (*Listener, error) {
+
+	listener := &Listener{actions: make(chan Action, 8)}
+	registered := make(chan error, 1)
+	var threadId uint32
+
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		defer close(listener.actions)
+
+		threadId = windows.GetCurrentThreadId()
+
+		actions := make(map[uintptr]Action)
+		defer func() {
+			for id := range actions {
+				procUnregisterHotKey.Call(0, id)
+			}
+		}()
+		for action, combo := range bindings {
+			id := uintptr(len(actions) + 1)
+			if ok, _, err := procRegisterHotKey.Call(0, id, windowsModifiers(combo.Modifiers), virtualKey(combo.Key)); ok == 0 {
+				registered <- fmt.Errorf("registering hotkey %s: %w", combo, err)
+				return
+			}
+			actions[id] = action
+		}
+		registered <- nil
+
+		var msg message
+		for {
+			ok, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+			if int32(ok) <= 0 {
+				return
+			}
+			if msg.message == wmHotkey {
+				if action, found := actions[msg.wParam]; found {
+					listener.send(action)
+				}
+			}
+		}
+	}()
+
+	if err := <-registered; err != nil {
+		return nil, err
+	}
+
+	listener.close = func() error {
+		if ok, _, err := procPostThreadMessageW.Call(uintptr(threadId), wmQuit, 0, 0); ok == 0 {
+			return err
+		}
+		return nil
+	}
+
+	return listener, nil
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package i18n provides the translations of all user-facing strings.
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultLanguage is the language used when no translation is available.
+const DefaultLanguage = "en"
+
+//go:embed locales/*.yaml
+var localeFiles embed.FS
+
+var (
+	mutex    sync.RWMutex
+	catalogs = loadCatalogs()
+	language = DefaultLanguage
+)
+
+func loadCatalogs() map[string]map[string]string {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	catalogs := make(map[string]map[string]string)
+	for _, entry := range entries {
+		content, err := localeFiles.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		var catalog map[string]string
+		if err := yaml.Unmarshal(content, &catalog); err != nil {
+			panic(fmt.Sprintf("invalid locale file %s: %v", entry.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), ".yaml")] = catalog
+	}
+	return catalogs
+}
+
+// Languages returns the codes of all the available languages, sorted alphabetically.
+func Languages() []string {
+	languages := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// SetLanguage selects the language used by T and Tf.
+// It accepts both plain language codes ("de") and locale names ("de_DE.UTF-8").
+// Unsupported languages fall back to DefaultLanguage.
+func SetLanguage(lang string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	language = normalizeLanguage(lang)
+}
+
+// Language returns the code of the language currently in use.
+func Language() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return language
+}
+
+// DetectLanguage returns the language configured in the environment
+// (LC_ALL, LC_MESSAGES or LANG, in this order), or DefaultLanguage if none is supported.
+func DetectLanguage() string {
+	if locale := SystemLocale(); locale != "" {
+		return normalizeLanguage(locale)
+	}
+	return DefaultLanguage
+}
+
+// SystemLocale returns the locale configured in the environment
+// (LC_ALL, LC_MESSAGES or LANG, in this order), e.g. "it_IT.UTF-8", or an empty string if none is set.
+func SystemLocale() string {
+	for _, variable := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(variable); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func normalizeLanguage(lang string) string {
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// Lookup returns the translation of the given key in the current language,
+// falling back to DefaultLanguage. The boolean is false if the key does not exist.
+func Lookup(key string) (string, bool) {
+	lang := Language()
+	if value, ok := catalogs[lang][key]; ok {
+		return value, true
+	}
+	value, ok := catalogs[DefaultLanguage][key]
+	return value, ok
+}
+
+// T returns the translation of the given key in the current language.
+// If the key does not exist, the key itself is returned.
+func T(key string) string {
+	if value, ok := Lookup(key); ok {
+		return value
+	}
+	return key
+}
+
+// Tf formats the translation of the given key with the given arguments, like fmt.Sprintf.
+func Tf(key string, args ...interface{}) string {
+	return fmt.Sprintf(T(key), args...)
+}
+
+// Error returns an error whose message is the translation of the given key,
+// looked up when the error is displayed rather than when it's created.
+// Errors created with the same key are equal, so they can be used as sentinels.
+func Error(key string) error {
+	return localizedError(key)
+}
+
+type localizedError string
+
+func (e localizedError) Error() string {
+	return T(string(e))
+}
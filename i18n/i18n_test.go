@@ -0,0 +1,126 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package i18n
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogs(t *testing.T) {
+
+	t.Run("ships the expected languages", func(t *testing.T) {
+		assert.Equal(t, []string{"de", "en", "es", "fr", "it"}, Languages())
+	})
+
+	for _, lang := range Languages() {
+		lang := lang
+		t.Run(lang+" translates every English key", func(t *testing.T) {
+			for key := range catalogs[DefaultLanguage] {
+				assert.Contains(t, catalogs[lang], key)
+			}
+		})
+		t.Run(lang+" has no keys missing from English", func(t *testing.T) {
+			for key := range catalogs[lang] {
+				assert.Contains(t, catalogs[DefaultLanguage], key)
+			}
+		})
+	}
+
+}
+
+func TestSetLanguage(t *testing.T) {
+
+	defer SetLanguage(DefaultLanguage)
+
+	t.Run("accepts locale names", func(t *testing.T) {
+		SetLanguage("it_IT.UTF-8")
+		assert.Equal(t, "it", Language())
+	})
+
+	t.Run("falls back to English for unsupported languages", func(t *testing.T) {
+		SetLanguage("tlh")
+		assert.Equal(t, "en", Language())
+	})
+
+	t.Run("falls back to English for C and POSIX locales", func(t *testing.T) {
+		SetLanguage("C.UTF-8")
+		assert.Equal(t, "en", Language())
+	})
+
+}
+
+func TestDetectLanguage(t *testing.T) {
+
+	t.Run("prefers LC_ALL over LANG", func(t *testing.T) {
+		t.Setenv("LC_ALL", "fr_FR.UTF-8")
+		t.Setenv("LANG", "de_DE.UTF-8")
+		assert.Equal(t, "fr", DetectLanguage())
+	})
+
+	t.Run("reads LANG", func(t *testing.T) {
+		t.Setenv("LC_ALL", "")
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", "es_ES.UTF-8")
+		assert.Equal(t, "es", DetectLanguage())
+	})
+
+}
+
+func TestT(t *testing.T) {
+
+	defer SetLanguage(DefaultLanguage)
+
+	t.Run("translates keys in the current language", func(t *testing.T) {
+		SetLanguage("it")
+		assert.Equal(t, "q: esci", T("commands.quit"))
+	})
+
+	t.Run("returns the key itself if it does not exist", func(t *testing.T) {
+		assert.Equal(t, "does.not.exist", T("does.not.exist"))
+	})
+
+	t.Run("formats arguments", func(t *testing.T) {
+		SetLanguage("en")
+		assert.Equal(t, "vol: 80", Tf("commands.volumeLevel", "80"))
+	})
+
+}
+
+func TestError(t *testing.T) {
+
+	defer SetLanguage(DefaultLanguage)
+
+	err := Error("playback.notReady")
+
+	t.Run("is translated when displayed", func(t *testing.T) {
+		SetLanguage("en")
+		assert.Equal(t, "the station did not start playing in time", err.Error())
+		SetLanguage("it")
+		assert.Equal(t, "la stazione non ha iniziato la riproduzione in tempo", err.Error())
+	})
+
+	t.Run("can be used as a sentinel", func(t *testing.T) {
+		assert.True(t, errors.Is(err, Error("playback.notReady")))
+	})
+
+}
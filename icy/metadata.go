@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package icy
+
+import (
+	"io"
+	"strings"
+)
+
+// ParseMetadata parses an ICY metadata block such as
+// "StreamTitle='Artist - Title';StreamUrl=”;" into its key-value pairs.
+// Values may contain quotes and semicolons, so a value only ends at a "';" sequence.
+func ParseMetadata(metadata string) map[string]string {
+
+	fields := make(map[string]string)
+
+	for metadata != "" {
+		eq := strings.Index(metadata, "='")
+		if eq < 0 {
+			break
+		}
+		key := strings.TrimSpace(metadata[:eq])
+		rest := metadata[eq+2:]
+
+		end := strings.Index(rest, "';")
+		if end < 0 {
+			// The last value may lack the trailing semicolon.
+			fields[key] = strings.TrimSuffix(rest, "'")
+			break
+		}
+
+		fields[key] = rest[:end]
+		metadata = rest[end+2:]
+	}
+
+	return fields
+}
+
+// MetadataReader reads the audio of a stream sent with ICY metadata (requested with "Icy-MetaData: 1"),
+// leaving out the metadata blocks interleaved every metaInt bytes, and handing them to a callback.
+type MetadataReader struct {
+	r          io.Reader
+	metaInt    int
+	onMetadata func(metadata string)
+	// audioLeft is how many bytes of audio are left before the next metadata block
+	audioLeft int
+}
+
+// NewMetadataReader returns a MetadataReader reading the stream from r, where metaInt is the
+// "icy-metaint" header of the response. onMetadata is called with every non-empty metadata block.
+func NewMetadataReader(r io.Reader, metaInt int, onMetadata func(metadata string)) *MetadataReader {
+	return &MetadataReader{
+		r:          r,
+		metaInt:    metaInt,
+		onMetadata: onMetadata,
+		audioLeft:  metaInt,
+	}
+}
+
+func (m *MetadataReader) Read(p []byte) (int, error) {
+	if m.audioLeft == 0 {
+		metadata, err := readMetadata(m.r)
+		if err != nil {
+			return 0, err
+		}
+		if metadata != "" {
+			m.onMetadata(metadata)
+		}
+		m.audioLeft = m.metaInt
+	}
+	if len(p) > m.audioLeft {
+		p = p[:m.audioLeft]
+	}
+	n, err := m.r.Read(p)
+	m.audioLeft -= n
+	return n, err
+}
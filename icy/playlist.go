@@ -0,0 +1,152 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package icy
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+// ErrNotPlaylist is returned by Playlist when the URL points to something else than a playlist, such as a stream.
+var ErrNotPlaylist = i18n.Error("probe.notPlaylist")
+
+// How much of a playlist is read: plenty for the few streams they list.
+const maxPlaylistSize = 64 << 10
+
+// playlistTypes are the media types of the playlists stations link to instead of their streams.
+var playlistTypes = map[string]bool{
+	"audio/x-scpls":        true,
+	"audio/scpls":          true,
+	"application/pls+xml":  true,
+	"audio/x-mpegurl":      true,
+	"audio/mpegurl":        true,
+	"video/x-ms-asf":       true,
+	"video/x-ms-asx":       true,
+	"audio/x-ms-wax":       true,
+	"application/xspf+xml": true,
+}
+
+// playlistExtensions are the extensions of playlists served with a generic media type, e.g. text/plain.
+var playlistExtensions = map[string]bool{".pls": true, ".m3u": true, ".asx": true, ".wax": true, ".xspf": true}
+
+var (
+	// Entries of ASX playlists, e.g. <ref href="http://example.com/stream"/>
+	asxRef = regexp.MustCompile(`(?i)<ref\s+href\s*=\s*["']([^"']+)["']`)
+	// Entries of XSPF playlists, e.g. <location>http://example.com/stream</location>
+	xspfLocation = regexp.MustCompile(`(?i)<location>\s*([^<\s]+)\s*</location>`)
+)
+
+// IsPlaylistType returns true if mediaType is the media type of a playlist listing streams, e.g. audio/x-scpls.
+func IsPlaylistType(mediaType string) bool {
+	return playlistTypes[mediaType]
+}
+
+// Playlist fetches the playlist at the given URL and returns the stream URLs it lists, in order.
+// Returns ErrNotPlaylist if the URL points to something else, such as a stream, without downloading it.
+// Errors fetching the playlist are *ProbeError.
+func (p *ProberImpl) Playlist(playlistUrl url.URL) ([]url.URL, error) {
+
+	req, err := http.NewRequest("GET", playlistUrl.String(), nil)
+	if err != nil {
+		return nil, &ProbeError{Kind: ErrStreamUnreachable, Err: err}
+	}
+	req.Header.Set("User-Agent", data.UserAgent)
+	p.headers.Apply(req)
+
+	result, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, &ProbeError{Kind: ErrStreamUnreachable, Err: err}
+	}
+	defer result.Body.Close()
+
+	if result.StatusCode < 200 || result.StatusCode > 299 {
+		return nil, &ProbeError{Kind: ErrStreamRefused, StatusCode: result.StatusCode}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(result.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+	if !IsPlaylistType(mediaType) && !playlistExtensions[strings.ToLower(path.Ext(playlistUrl.Path))] {
+		return nil, ErrNotPlaylist
+	}
+
+	body, err := io.ReadAll(io.LimitReader(result.Body, maxPlaylistSize))
+	if err != nil {
+		return nil, &ProbeError{Kind: ErrStreamUnreachable, Err: err}
+	}
+
+	entries := ParsePlaylist(string(body), playlistUrl)
+	if len(entries) == 0 {
+		return nil, ErrNotPlaylist
+	}
+	return entries, nil
+}
+
+// ParsePlaylist returns the stream URLs listed by a PLS, M3U, ASX or XSPF playlist, in order and without duplicates.
+// Relative URLs are resolved against base. HLS playlists list segments rather than streams, and return nothing.
+func ParsePlaylist(playlist string, base url.URL) []url.URL {
+
+	var refs []string
+	trimmed := strings.TrimSpace(strings.TrimPrefix(playlist, "\ufeff"))
+	switch {
+	case strings.Contains(trimmed, "#EXT-X-"):
+		return nil
+	case strings.HasPrefix(strings.ToLower(trimmed), "[playlist]"):
+		for _, line := range strings.Split(trimmed, "\n") {
+			key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+			if ok && strings.HasPrefix(strings.ToLower(key), "file") {
+				refs = append(refs, value)
+			}
+		}
+	case strings.HasPrefix(trimmed, "<"):
+		for _, pattern := range []*regexp.Regexp{asxRef, xspfLocation} {
+			for _, match := range pattern.FindAllStringSubmatch(trimmed, -1) {
+				refs = append(refs, match[1])
+			}
+		}
+	default:
+		for _, line := range strings.Split(trimmed, "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				refs = append(refs, line)
+			}
+		}
+	}
+
+	var entries []url.URL
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		entry, err := base.Parse(strings.TrimSpace(ref))
+		if err != nil || (entry.Scheme != "http" && entry.Scheme != "https") || seen[entry.String()] {
+			continue
+		}
+		seen[entry.String()] = true
+		entries = append(entries, *entry)
+	}
+	return entries
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package icy
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePlaylist(t *testing.T) {
+
+	base, _ := url.Parse("http://example.com/radio/listen.pls")
+
+	urls := func(entries []url.URL) []string {
+		var strs []string
+		for _, entry := range entries {
+			strs = append(strs, entry.String())
+		}
+		return strs
+	}
+
+	testCases := []struct {
+		name     string
+		playlist string
+		expected []string
+	}{
+		{
+			name:     "PLS",
+			playlist: "[playlist]\r\nNumberOfEntries=2\r\nFile1=http://a.example.com/stream\r\nTitle1=A\r\nFile2=https://b.example.com/stream\r\nVersion=2\r\n",
+			expected: []string{"http://a.example.com/stream", "https://b.example.com/stream"},
+		},
+		{
+			name:     "M3U, with relative URLs and duplicates",
+			playlist: "#EXTM3U\n#EXTINF:-1,A\nhttp://a.example.com/stream\n\nstream.mp3\nhttp://a.example.com/stream\n",
+			expected: []string{"http://a.example.com/stream", "http://example.com/radio/stream.mp3"},
+		},
+		{
+			name:     "ASX",
+			playlist: `<asx version="3.0"><entry><ref href="http://a.example.com/stream"/></entry><entry><REF HREF='mms://b.example.com/stream'/></entry></asx>`,
+			expected: []string{"http://a.example.com/stream"},
+		},
+		{
+			name:     "XSPF",
+			playlist: `<?xml version="1.0"?><playlist><trackList><track><location> http://a.example.com/stream </location></track></trackList></playlist>`,
+			expected: []string{"http://a.example.com/stream"},
+		},
+		{
+			name:     "HLS",
+			playlist: "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=128000\nlow.m3u8\n",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, urls(ParsePlaylist(tc.playlist, *base)))
+		})
+	}
+
+}
+
+func TestProberImplPlaylist(t *testing.T) {
+
+	respond := func(contentType string, body string) *mocks.MockHttpClient {
+		return &mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{contentType}},
+					Body:       io.NopCloser(strings.NewReader(body)),
+				}, nil
+			},
+		}
+	}
+
+	t.Run("lists the streams of playlists, told by their media type or extension", func(t *testing.T) {
+
+		playlistUrl, _ := url.Parse("http://example.com/listen")
+		entries, err := NewProberWithDependencies(respond("audio/x-scpls", "[playlist]\nFile1=http://example.com/stream\n"), 1).Playlist(*playlistUrl)
+		assert.NoError(t, err)
+		assert.Equal(t, "http://example.com/stream", entries[0].String())
+
+		playlistUrl, _ = url.Parse("http://example.com/listen.m3u")
+		entries, err = NewProberWithDependencies(respond("text/plain", "http://example.com/stream\n"), 1).Playlist(*playlistUrl)
+		assert.NoError(t, err)
+		assert.Equal(t, "http://example.com/stream", entries[0].String())
+
+	})
+
+	t.Run("returns ErrNotPlaylist for streams", func(t *testing.T) {
+
+		streamUrl, _ := url.Parse("http://example.com/stream")
+		_, err := NewProberWithDependencies(respond("audio/mpeg", "\xff\xfb\x90"), 1).Playlist(*streamUrl)
+		assert.ErrorIs(t, err, ErrNotPlaylist)
+
+	})
+
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package icy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/data"
+)
+
+// How long a single probe may take, including reading up to the first metadata block.
+const probeTimeout = 10 * time.Second
+
+// How many streams are probed at the same time by default.
+const defaultConcurrency = 4
+
+// ErrNoMetadata is returned when a stream does not send ICY metadata.
+var ErrNoMetadata = errors.New("the stream does not provide metadata")
+
+// ProberService reads what a station is currently playing, without playing it.
+type ProberService interface {
+	// StreamTitle connects to the stream at the given URL, reads its first ICY metadata block
+	// and returns the announced stream title (usually "Artist - Title").
+	// It blocks while the maximum number of concurrent probes is reached.
+	StreamTitle(streamUrl url.URL) (string, error)
+	// Probe connects to the stream at the given URL and returns what its server announces about it.
+	// Errors are *ProbeError and explain why the stream can't be played.
+	Probe(streamUrl url.URL) (common.StreamInfo, error)
+	// Playlist fetches the playlist at the given URL and returns the stream URLs it lists.
+	// Returns ErrNotPlaylist if the URL points to something else, such as a stream.
+	Playlist(playlistUrl url.URL) ([]url.URL, error)
+}
+
+// HeaderProber is implemented by probers that can send additional headers, such as the Referer some streams require.
+type HeaderProber interface {
+	// WithHeaders returns a prober sending headers along with its requests,
+	// sharing the limit of concurrent probes with this one.
+	WithHeaders(headers common.StreamHeaders) ProberService
+}
+
+type ProberImpl struct {
+	// The HTTP client used to connect to the streams.
+	httpClient api.HTTPClientService
+	// A counting semaphore limiting the number of concurrent probes.
+	slots chan struct{}
+	// Sent along with every request, replacing RadioGoGo's own.
+	headers common.StreamHeaders
+}
+
+// NewProber returns a new instance of ProberService with a default HTTP client and concurrency limit.
+func NewProber() ProberService {
+	return NewProberWithDependencies(
+		&http.Client{Timeout: probeTimeout},
+		defaultConcurrency,
+	)
+}
+
+// NewProberWithDependencies returns a new instance of ProberService using the given HTTP client,
+// allowing at most concurrency probes to run at the same time.
+func NewProberWithDependencies(httpClient api.HTTPClientService, concurrency int) ProberService {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &ProberImpl{
+		httpClient: httpClient,
+		slots:      make(chan struct{}, concurrency),
+	}
+}
+
+func (p *ProberImpl) WithHeaders(headers common.StreamHeaders) ProberService {
+	return &ProberImpl{httpClient: p.httpClient, slots: p.slots, headers: headers}
+}
+
+func (p *ProberImpl) StreamTitle(streamUrl url.URL) (string, error) {
+
+	p.slots <- struct{}{}
+	defer func() { <-p.slots }()
+
+	req, err := http.NewRequest("GET", streamUrl.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", data.UserAgent)
+	p.headers.Apply(req)
+	req.Header.Set("Icy-MetaData", "1")
+
+	result, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer result.Body.Close()
+
+	if result.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d", result.StatusCode)
+	}
+
+	metaInt, err := strconv.Atoi(result.Header.Get("icy-metaint"))
+	if err != nil || metaInt <= 0 {
+		return "", ErrNoMetadata
+	}
+
+	metadata, err := ReadMetadataBlock(result.Body, metaInt)
+	if err != nil {
+		return "", err
+	}
+
+	title, ok := ParseMetadata(metadata)["StreamTitle"]
+	if !ok {
+		return "", ErrNoMetadata
+	}
+
+	return title, nil
+}
+
+// ReadMetadataBlock skips metaInt bytes of audio from r and returns the metadata block that follows.
+// The first byte of the block is its length divided by 16; the block itself is padded with zeros.
+func ReadMetadataBlock(r io.Reader, metaInt int) (string, error) {
+
+	_, err := io.CopyN(io.Discard, r, int64(metaInt))
+	if err != nil {
+		return "", err
+	}
+
+	return readMetadata(r)
+}
+
+// readMetadata reads a metadata block, starting from its length byte.
+func readMetadata(r io.Reader) (string, error) {
+
+	length := make([]byte, 1)
+	_, err := io.ReadFull(r, length)
+	if err != nil {
+		return "", err
+	}
+
+	block := make([]byte, int(length[0])*16)
+	_, err = io.ReadFull(r, block)
+	if err != nil {
+		return "", err
+	}
+
+	end := len(block)
+	for end > 0 && block[end-1] == 0 {
+		end--
+	}
+
+	return string(block[:end]), nil
+}
@@ -0,0 +1,356 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package icy
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// icyBody builds a stream body with metaInt bytes of audio followed by the given metadata block.
+func icyBody(metaInt int, metadata string) io.ReadCloser {
+	length := (len(metadata) + 15) / 16
+	block := make([]byte, length*16)
+	copy(block, metadata)
+	body := append(bytes.Repeat([]byte{0xff}, metaInt), byte(length))
+	body = append(body, block...)
+	return io.NopCloser(bytes.NewReader(body))
+}
+
+func TestMetadataReader(t *testing.T) {
+
+	block := func(metadata string) []byte {
+		length := (len(metadata) + 15) / 16
+		b := make([]byte, 1+length*16)
+		b[0] = byte(length)
+		copy(b[1:], metadata)
+		return b
+	}
+
+	var stream []byte
+	stream = append(stream, "aaaa"...)
+	stream = append(stream, block("StreamTitle='Artist - First';")...)
+	stream = append(stream, "bbbb"...)
+	stream = append(stream, block("")...)
+	stream = append(stream, "cccc"...)
+	stream = append(stream, block("StreamTitle='Artist - Second';")...)
+	stream = append(stream, "dd"...)
+
+	var metadata []string
+	reader := NewMetadataReader(bytes.NewReader(stream), 4, func(m string) {
+		metadata = append(metadata, m)
+	})
+
+	audio, err := io.ReadAll(reader)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "aaaabbbbccccdd", string(audio))
+	assert.Equal(t, []string{"StreamTitle='Artist - First';", "StreamTitle='Artist - Second';"}, metadata)
+
+}
+
+func TestParseMetadata(t *testing.T) {
+
+	t.Run("parses all fields", func(t *testing.T) {
+		fields := ParseMetadata("StreamTitle='Artist - Title';StreamUrl='http://example.com';")
+		assert.Equal(t, "Artist - Title", fields["StreamTitle"])
+		assert.Equal(t, "http://example.com", fields["StreamUrl"])
+	})
+
+	t.Run("keeps quotes and semicolons inside values", func(t *testing.T) {
+		fields := ParseMetadata("StreamTitle='Guns N' Roses - Don't Cry; Live';")
+		assert.Equal(t, "Guns N' Roses - Don't Cry; Live", fields["StreamTitle"])
+	})
+
+	t.Run("accepts a missing trailing semicolon", func(t *testing.T) {
+		fields := ParseMetadata("StreamTitle='Artist - Title'")
+		assert.Equal(t, "Artist - Title", fields["StreamTitle"])
+	})
+
+	t.Run("returns no fields for an empty block", func(t *testing.T) {
+		assert.Empty(t, ParseMetadata(""))
+	})
+
+}
+
+func TestProberImplStreamTitle(t *testing.T) {
+
+	streamUrl, _ := url.Parse("http://example.com/stream")
+
+	t.Run("returns the stream title of the first metadata block", func(t *testing.T) {
+
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "1", req.Header.Get("Icy-MetaData"))
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Icy-Metaint": []string{"8000"}},
+					Body:       icyBody(8000, "StreamTitle='Artist - Title';"),
+				}, nil
+			},
+		}
+
+		title, err := NewProberWithDependencies(&mockHttpClient, 1).StreamTitle(*streamUrl)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Artist - Title", title)
+
+	})
+
+	t.Run("returns ErrNoMetadata if the stream does not send metadata", func(t *testing.T) {
+
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{},
+					Body:       io.NopCloser(strings.NewReader("audio")),
+				}, nil
+			},
+		}
+
+		_, err := NewProberWithDependencies(&mockHttpClient, 1).StreamTitle(*streamUrl)
+
+		assert.ErrorIs(t, err, ErrNoMetadata)
+
+	})
+
+	t.Run("returns an error if the stream ends before the metadata block", func(t *testing.T) {
+
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Icy-Metaint": []string{"8000"}},
+					Body:       io.NopCloser(strings.NewReader("short")),
+				}, nil
+			},
+		}
+
+		_, err := NewProberWithDependencies(&mockHttpClient, 1).StreamTitle(*streamUrl)
+
+		assert.Error(t, err)
+
+	})
+
+	t.Run("limits the number of concurrent probes", func(t *testing.T) {
+
+		var mutex sync.Mutex
+		running, maxRunning := 0, 0
+
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				mutex.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mutex.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Icy-Metaint": []string{"16"}},
+					Body:       icyBody(16, "StreamTitle='Title';"),
+				}, nil
+			},
+		}
+
+		prober := NewProberWithDependencies(&mockHttpClient, 2)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = prober.StreamTitle(*streamUrl)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 2, maxRunning)
+
+	})
+
+}
+
+func TestProberImplProbe(t *testing.T) {
+
+	streamUrl, _ := url.Parse("http://example.com/stream")
+
+	t.Run("returns what the server announces about the stream", func(t *testing.T) {
+
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header: http.Header{
+						"Content-Type": []string{"audio/mpeg"},
+						"Icy-Br":       []string{"128,128"},
+						"Icy-Name":     []string{" Radio GoGo "},
+					},
+					Body: io.NopCloser(strings.NewReader("audio")),
+				}, nil
+			},
+		}
+
+		info, err := NewProberWithDependencies(&mockHttpClient, 1).Probe(*streamUrl)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "audio/mpeg", info.ContentType)
+		assert.Equal(t, "MP3", info.Codec)
+		assert.Equal(t, 128, info.Bitrate)
+		assert.Equal(t, "Radio GoGo", info.Name)
+
+	})
+
+	t.Run("reads the bitrate from ice-audio-info", func(t *testing.T) {
+
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header: http.Header{
+						"Content-Type":   []string{"application/ogg"},
+						"Ice-Audio-Info": []string{"channels=2;samplerate=44100;bitrate=96"},
+					},
+					Body: io.NopCloser(strings.NewReader("audio")),
+				}, nil
+			},
+		}
+
+		info, err := NewProberWithDependencies(&mockHttpClient, 1).Probe(*streamUrl)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Ogg", info.Codec)
+		assert.Equal(t, 96, info.Bitrate)
+
+	})
+
+	t.Run("sends the headers it was given, replacing its own", func(t *testing.T) {
+
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "https://example.com/player", req.Header.Get("Referer"))
+				assert.Equal(t, "Mozilla/5.0", req.Header.Get("User-Agent"))
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{"audio/mpeg"}},
+					Body:       io.NopCloser(strings.NewReader("audio")),
+				}, nil
+			},
+		}
+
+		prober := NewProberWithDependencies(&mockHttpClient, 1).(HeaderProber).WithHeaders(common.StreamHeaders{
+			{Name: "Referer", Value: "https://example.com/player"},
+			{Name: "User-Agent", Value: "Mozilla/5.0"},
+		})
+		_, err := prober.Probe(*streamUrl)
+
+		assert.NoError(t, err)
+
+	})
+
+	t.Run("returns ErrStreamUnreachable if the server can't be reached", func(t *testing.T) {
+
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return nil, errors.New("connection refused")
+			},
+		}
+
+		_, err := NewProberWithDependencies(&mockHttpClient, 1).Probe(*streamUrl)
+
+		assert.ErrorIs(t, err, ErrStreamUnreachable)
+		assert.Contains(t, err.Error(), "connection refused")
+
+	})
+
+	t.Run("returns ErrStreamRefused with the status code if the server answers with an error", func(t *testing.T) {
+
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Header:     http.Header{},
+					Body:       io.NopCloser(strings.NewReader("not found")),
+				}, nil
+			},
+		}
+
+		_, err := NewProberWithDependencies(&mockHttpClient, 1).Probe(*streamUrl)
+
+		assert.ErrorIs(t, err, ErrStreamRefused)
+		assert.Contains(t, err.Error(), "HTTP 404")
+
+	})
+
+	t.Run("returns ErrNotAudio if the URL points to a web page", func(t *testing.T) {
+
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+					Body:       io.NopCloser(strings.NewReader("<html></html>")),
+				}, nil
+			},
+		}
+
+		_, err := NewProberWithDependencies(&mockHttpClient, 1).Probe(*streamUrl)
+
+		assert.ErrorIs(t, err, ErrNotAudio)
+		assert.Contains(t, err.Error(), "text/html")
+
+	})
+
+	t.Run("accepts SHOUTcast servers answering with ICY 200 OK", func(t *testing.T) {
+
+		mockHttpClient := mocks.MockHttpClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return nil, errors.New(`net/http: HTTP/1.x transport connection broken: malformed HTTP version "ICY"`)
+			},
+		}
+
+		info, err := NewProberWithDependencies(&mockHttpClient, 1).Probe(*streamUrl)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "ICY", info.Codec)
+
+	})
+
+}
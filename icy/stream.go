@@ -0,0 +1,187 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package icy
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+var (
+	// ErrStreamUnreachable is matched by probe errors returned when the stream server can't be reached.
+	ErrStreamUnreachable = i18n.Error("probe.unreachable")
+	// ErrStreamRefused is matched by probe errors returned when the stream server answers with an error status code.
+	ErrStreamRefused = i18n.Error("probe.refused")
+	// ErrNotAudio is matched by probe errors returned when the stream URL points to something that isn't audio,
+	// such as a web page.
+	ErrNotAudio = i18n.Error("probe.notAudio")
+)
+
+// ProbeError is the error returned by Probe.
+// Use errors.Is with ErrStreamUnreachable, ErrStreamRefused or ErrNotAudio to tell them apart.
+type ProbeError struct {
+	// Kind is one of ErrStreamUnreachable, ErrStreamRefused or ErrNotAudio.
+	Kind error
+	// StatusCode is the HTTP status code of the response, or 0 if there was none.
+	StatusCode int
+	// ContentType is the media type of the response, if any.
+	ContentType string
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (e *ProbeError) Error() string {
+	msg := e.Kind.Error()
+	switch {
+	case e.StatusCode != 0 && e.Kind == ErrStreamRefused:
+		msg += fmt.Sprintf(" (HTTP %d)", e.StatusCode)
+	case e.ContentType != "":
+		msg += " (" + e.ContentType + ")"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Is makes errors.Is match the Kind of the error.
+func (e *ProbeError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+// codecs maps the media types sent by stream servers to a short name for their format.
+var codecs = map[string]string{
+	"audio/mpeg":                    "MP3",
+	"audio/mp3":                     "MP3",
+	"audio/mpeg3":                   "MP3",
+	"audio/aac":                     "AAC",
+	"audio/aacp":                    "AAC+",
+	"audio/x-aac":                   "AAC",
+	"audio/mp4":                     "AAC",
+	"audio/ogg":                     "Ogg",
+	"application/ogg":               "Ogg",
+	"audio/opus":                    "Opus",
+	"audio/flac":                    "FLAC",
+	"audio/x-flac":                  "FLAC",
+	"audio/wav":                     "WAV",
+	"audio/x-wav":                   "WAV",
+	"application/vnd.apple.mpegurl": "HLS",
+	"application/x-mpegurl":         "HLS",
+	"audio/x-mpegurl":               "M3U",
+	"audio/mpegurl":                 "M3U",
+	"audio/x-scpls":                 "PLS",
+}
+
+// Probe connects to the stream at the given URL and reads the headers its server sends back,
+// without downloading more than the first bytes of audio.
+// Unlike StreamTitle, it does not wait for other probes to finish.
+func (p *ProberImpl) Probe(streamUrl url.URL) (common.StreamInfo, error) {
+
+	req, err := http.NewRequest("GET", streamUrl.String(), nil)
+	if err != nil {
+		return common.StreamInfo{}, &ProbeError{Kind: ErrStreamUnreachable, Err: err}
+	}
+	req.Header.Set("User-Agent", data.UserAgent)
+	p.headers.Apply(req)
+
+	start := time.Now()
+	result, err := p.httpClient.Do(req)
+	if err != nil {
+		// SHOUTcast v1 servers answer with "ICY 200 OK", which Go's HTTP client refuses to parse,
+		// but the backends play them just fine.
+		if strings.Contains(err.Error(), `malformed HTTP version "ICY"`) {
+			return common.StreamInfo{Codec: "ICY", Latency: time.Since(start)}, nil
+		}
+		return common.StreamInfo{}, &ProbeError{Kind: ErrStreamUnreachable, Err: err}
+	}
+	defer result.Body.Close()
+
+	if result.StatusCode < 200 || result.StatusCode > 299 {
+		return common.StreamInfo{}, &ProbeError{Kind: ErrStreamRefused, StatusCode: result.StatusCode}
+	}
+
+	contentType := result.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	if mediaType == "text/html" {
+		return common.StreamInfo{}, &ProbeError{Kind: ErrNotAudio, ContentType: mediaType}
+	}
+
+	// Waits for the first byte of audio, so that the latency includes the time the server takes to start streaming
+	_, _ = io.ReadFull(result.Body, make([]byte, 1))
+
+	return common.StreamInfo{
+		ContentType: mediaType,
+		Codec:       codecName(mediaType),
+		Bitrate:     announcedBitrate(result.Header),
+		Name:        strings.TrimSpace(result.Header.Get("icy-name")),
+		Latency:     time.Since(start),
+	}, nil
+}
+
+// codecName returns a short name for the format of a stream with the given media type,
+// or an empty string if it's unknown.
+func codecName(mediaType string) string {
+	if codec, ok := codecs[mediaType]; ok {
+		return codec
+	}
+	if subtype := strings.TrimPrefix(mediaType, "audio/"); subtype != mediaType && subtype != "" {
+		return strings.ToUpper(strings.TrimPrefix(subtype, "x-"))
+	}
+	return ""
+}
+
+// announcedBitrate returns the bitrate in kbps announced by a stream server, or 0 if it doesn't announce one.
+// Servers use "icy-br" (sometimes as "128,128"), "icy-bitrate" or the bitrate field of "ice-audio-info".
+func announcedBitrate(header http.Header) int {
+	for _, name := range []string{"icy-br", "icy-bitrate"} {
+		value := strings.SplitN(header.Get(name), ",", 2)[0]
+		if bitrate, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && bitrate > 0 {
+			return bitrate
+		}
+	}
+	for _, field := range strings.Split(header.Get("ice-audio-info"), ";") {
+		key, value, ok := strings.Cut(field, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || (key != "bitrate" && key != "ice-bitrate") {
+			continue
+		}
+		if bitrate, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && bitrate > 0 {
+			return bitrate
+		}
+	}
+	return 0
+}
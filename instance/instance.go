@@ -0,0 +1,228 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package instance makes sure only one RadioGoGo plays audio at a time.
+// The first instance listens on a unix socket; later launches find it there
+// and forward their command to it instead of starting a second player.
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/nowplaying"
+)
+
+// How long a client waits for the running instance to answer.
+const sendTimeout = 2 * time.Second
+
+// ErrAlreadyRunning is returned by Listen when another instance owns the socket.
+var ErrAlreadyRunning = errors.New("radiogogo is already running")
+
+// Action is what a command asks the running instance to do.
+type Action string
+
+const (
+	// ActionFocus asks the running instance to draw the user's attention.
+	ActionFocus Action = "focus"
+	// ActionPlay asks the running instance to play the station with the given UUID.
+	ActionPlay Action = "play"
+	// ActionPlayURL asks the running instance to play the stream at the given URL, listed on radio-browser or not.
+	ActionPlayURL Action = "playURL"
+	// ActionShow asks the running instance to show the station with the given UUID, without playing it.
+	ActionShow Action = "show"
+	// ActionStatus asks the running instance what it's playing. It's answered by the server itself.
+	ActionStatus Action = "status"
+)
+
+// Command is sent by a new launch to the running instance.
+type Command struct {
+	Action      Action `json:"action"`
+	StationUuid string `json:"stationUuid,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// response is sent back by the running instance once it has accepted a command.
+type response struct {
+	Error string `json:"error,omitempty"`
+	// Track is what's playing, in answer to ActionStatus.
+	Track *nowplaying.Track `json:"track,omitempty"`
+}
+
+// Server receives commands from later launches.
+type Server struct {
+	path     string
+	listener net.Listener
+	commands chan Command
+
+	mu    sync.Mutex
+	track nowplaying.Track
+}
+
+// Listen claims the socket at the given path and starts accepting commands.
+// If another instance is listening on it, ErrAlreadyRunning is returned.
+// A socket left behind by an instance that crashed is removed and claimed.
+func Listen(path string) (*Server, error) {
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		if IsRunning(path) {
+			return nil, ErrAlreadyRunning
+		}
+		// Nobody answers: the socket is stale.
+		if removeErr := os.Remove(path); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+			return nil, err
+		}
+		listener, err = net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	server := &Server{
+		path:     path,
+		listener: listener,
+		commands: make(chan Command, 8),
+	}
+
+	go server.serve()
+
+	return server, nil
+}
+
+// Commands returns the channel on which received commands are delivered.
+// It is closed when the server is closed.
+func (s *Server) Commands() <-chan Command {
+	return s.commands
+}
+
+// Close stops accepting commands and removes the socket.
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+// Write keeps track of what's playing, to answer ActionStatus.
+// It makes the server a nowplaying.Publisher.
+func (s *Server) Write(track nowplaying.Track) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.track = track
+	return nil
+}
+
+func (s *Server) serve() {
+	defer close(s.commands)
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(sendTimeout))
+
+	var command Command
+	err := json.NewDecoder(conn).Decode(&command)
+	if err != nil {
+		_ = json.NewEncoder(conn).Encode(response{Error: err.Error()})
+		return
+	}
+
+	if command.Action == ActionStatus {
+		s.mu.Lock()
+		track := s.track
+		s.mu.Unlock()
+		_ = json.NewEncoder(conn).Encode(response{Track: &track})
+		return
+	}
+
+	select {
+	case s.commands <- command:
+		_ = json.NewEncoder(conn).Encode(response{})
+	default:
+		_ = json.NewEncoder(conn).Encode(response{Error: "too many pending commands"})
+	}
+}
+
+// Send forwards the command to the instance listening on the socket at the given path.
+func Send(path string, command Command) error {
+	_, err := exchange(path, command)
+	return err
+}
+
+// Status asks the instance listening on the socket at the given path what it's playing.
+// The zero Track means that nothing is.
+func Status(path string) (nowplaying.Track, error) {
+	res, err := exchange(path, Command{Action: ActionStatus})
+	if err != nil {
+		return nowplaying.Track{}, err
+	}
+	if res.Track == nil {
+		return nowplaying.Track{}, errors.New("the running instance can't tell what it's playing")
+	}
+	return *res.Track, nil
+}
+
+// exchange sends the command to the instance listening on the socket at the given path,
+// and returns its response.
+func exchange(path string, command Command) (response, error) {
+
+	conn, err := net.DialTimeout("unix", path, sendTimeout)
+	if err != nil {
+		return response{}, err
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(sendTimeout))
+
+	err = json.NewEncoder(conn).Encode(command)
+	if err != nil {
+		return response{}, err
+	}
+
+	var res response
+	err = json.NewDecoder(conn).Decode(&res)
+	if err != nil {
+		return response{}, err
+	}
+	if res.Error != "" {
+		return response{}, errors.New(res.Error)
+	}
+
+	return res, nil
+}
+
+// IsRunning returns true if an instance answers on the socket at the given path.
+func IsRunning(path string) bool {
+	conn, err := net.DialTimeout("unix", path, sendTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
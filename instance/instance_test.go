@@ -0,0 +1,121 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package instance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/nowplaying"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstance(t *testing.T) {
+
+	t.Run("forwards commands to the running instance", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.sock")
+
+		server, err := Listen(path)
+		assert.NoError(t, err)
+		defer server.Close()
+
+		command := Command{Action: ActionPlay, StationUuid: "960e57c5-0601-11e8-ae97-52543be04c81"}
+		assert.NoError(t, Send(path, command))
+
+		assert.Equal(t, command, <-server.Commands())
+
+	})
+
+	t.Run("tells what the running instance is playing", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.sock")
+
+		server, err := Listen(path)
+		assert.NoError(t, err)
+		defer server.Close()
+
+		track, err := Status(path)
+		assert.NoError(t, err)
+		assert.Equal(t, nowplaying.Track{}, track)
+
+		playing := nowplaying.Track{Station: "Jazz FM", StationUuid: "960e57c5-0601-11e8-ae97-52543be04c81", Title: "Miles Davis - So What"}
+		assert.NoError(t, server.Write(playing))
+
+		track, err = Status(path)
+		assert.NoError(t, err)
+		assert.Equal(t, playing, track)
+
+		// Status queries aren't forwarded to the program
+		assert.Empty(t, server.Commands())
+
+	})
+
+	t.Run("returns ErrAlreadyRunning if another instance is listening", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.sock")
+
+		server, err := Listen(path)
+		assert.NoError(t, err)
+		defer server.Close()
+
+		_, err = Listen(path)
+		assert.ErrorIs(t, err, ErrAlreadyRunning)
+
+	})
+
+	t.Run("claims a stale socket", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.sock")
+		assert.NoError(t, os.WriteFile(path, nil, 0600))
+
+		server, err := Listen(path)
+		assert.NoError(t, err)
+		defer server.Close()
+
+	})
+
+	t.Run("returns an error if no instance is running", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.sock")
+
+		assert.Error(t, Send(path, Command{Action: ActionFocus}))
+
+	})
+
+	t.Run("closes the commands channel when the server is closed", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.sock")
+
+		server, err := Listen(path)
+		assert.NoError(t, err)
+		assert.NoError(t, server.Close())
+
+		_, ok := <-server.Commands()
+		assert.False(t, ok)
+
+		_, err = os.Stat(path)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+	})
+
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package instance
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// LinkScheme is the URL scheme of RadioGoGo's deep links.
+const LinkScheme = "radiogogo"
+
+// StationLink returns the deep link to the station with the given UUID, e.g. radiogogo://station/<uuid>.
+func StationLink(stationUuid uuid.UUID) string {
+	return LinkScheme + "://station/" + stationUuid.String()
+}
+
+// IsLink reports whether arg looks like a deep link, rather than e.g. a subcommand.
+func IsLink(arg string) bool {
+	return strings.HasPrefix(strings.ToLower(arg), LinkScheme+":")
+}
+
+// ParseStationLink returns the UUID of the station a deep link points to.
+func ParseStationLink(link string) (uuid.UUID, error) {
+	u, err := url.Parse(link)
+	if err != nil || !strings.EqualFold(u.Scheme, LinkScheme) || !strings.EqualFold(u.Host, "station") {
+		return uuid.Nil, errors.New("invalid station link: " + link)
+	}
+	stationUuid, err := uuid.Parse(strings.Trim(u.Path, "/"))
+	if err != nil {
+		return uuid.Nil, errors.New("invalid station link: " + link)
+	}
+	return stationUuid, nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package instance
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStationLink(t *testing.T) {
+
+	stationUuid := uuid.MustParse("960e57c5-0601-11e8-ae97-52543be04c81")
+
+	t.Run("round-trips a station link", func(t *testing.T) {
+
+		link := StationLink(stationUuid)
+		assert.Equal(t, "radiogogo://station/960e57c5-0601-11e8-ae97-52543be04c81", link)
+		assert.True(t, IsLink(link))
+
+		parsed, err := ParseStationLink(link)
+		assert.NoError(t, err)
+		assert.Equal(t, stationUuid, parsed)
+
+	})
+
+	t.Run("accepts a trailing slash and any case", func(t *testing.T) {
+
+		parsed, err := ParseStationLink("RadioGoGo://Station/960e57c5-0601-11e8-ae97-52543be04c81/")
+		assert.NoError(t, err)
+		assert.Equal(t, stationUuid, parsed)
+
+	})
+
+	t.Run("rejects invalid links", func(t *testing.T) {
+
+		for _, link := range []string{
+			"radiogogo://station/",
+			"radiogogo://station/not-a-uuid",
+			"radiogogo://tag/960e57c5-0601-11e8-ae97-52543be04c81",
+			"https://station/960e57c5-0601-11e8-ae97-52543be04c81",
+			"960e57c5-0601-11e8-ae97-52543be04c81",
+		} {
+			_, err := ParseStationLink(link)
+			assert.Error(t, err, link)
+		}
+		assert.False(t, IsLink("sync"))
+
+	})
+
+}
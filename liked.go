@@ -0,0 +1,106 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/zi0p4tch0/radiogogo/cli"
+	"github.com/zi0p4tch0/radiogogo/liked"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+// newLikedCommand returns "radiogogo liked", which exports the tracks liked while listening.
+func newLikedCommand() *cli.Command {
+
+	flags := flag.NewFlagSet("liked", flag.ContinueOnError)
+	format := flags.String("format", "", "export in the given `format`, csv or json (by default, guessed from the file extension, or csv)")
+
+	return &cli.Command{
+		Name:          "liked",
+		Usage:         "[file]",
+		Short:         "Export the liked tracks as CSV or JSON (to stdout without a file)",
+		Long:          "Tracks are liked with \"L\" while a station plays them, and exported most recent first.",
+		Flags:         flags,
+		CompleteFiles: true,
+		Run: func(args []string) error {
+			if len(args) > 1 {
+				return errors.New("exporting liked tracks: usage: radiogogo liked [--format csv|json] [file]")
+			}
+			path := "-"
+			if len(args) == 1 {
+				path = args[0]
+			}
+			if err := exportLikedTracks(path, *format); err != nil {
+				return fmt.Errorf("exporting liked tracks: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// exportLikedTracks writes the liked tracks to the given path ("-" for stdout) in the format of the given name,
+// or in the format matching the extension of the path if it's empty.
+func exportLikedTracks(path string, formatName string) error {
+
+	format := liked.FormatOf(path)
+	if formatName != "" {
+		var err error
+		if format, err = liked.ParseFormat(formatName); err != nil {
+			return err
+		}
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tracks, err := storage.NewBoltLikedTrackStore(db).All()
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	err = liked.Write(w, format, tracks)
+	if err != nil {
+		return err
+	}
+
+	if path != "-" {
+		fmt.Printf("Exported %d liked tracks to %s\n", len(tracks), path)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package liked exports the tracks liked while listening, as CSV or JSON, so that they can be looked up later.
+package liked
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/enrich"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+// Format is a format liked tracks are exported in.
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{CSV, JSON}
+
+// ParseFormat returns the format of the given name.
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if strings.EqualFold(name, string(format)) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q (csv or json)", name)
+}
+
+// FormatOf returns the format matching the extension of path, CSV if it doesn't match any.
+func FormatOf(path string) Format {
+	if format, err := ParseFormat(strings.TrimPrefix(filepath.Ext(path), ".")); err == nil {
+		return format
+	}
+	return CSV
+}
+
+// Entry is an exported liked track.
+// Artist and Title are split from the stream title when it's of the usual "Artist - Title" form, empty otherwise.
+type Entry struct {
+	LikedAt     time.Time `json:"likedAt"`
+	StreamTitle string    `json:"streamTitle"`
+	Artist      string    `json:"artist,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Station     string    `json:"station"`
+	StationUuid string    `json:"stationuuid"`
+	URL         string    `json:"url"`
+}
+
+// NewEntry returns the entry exported for track.
+func NewEntry(track storage.LikedTrack) Entry {
+	entry := Entry{
+		LikedAt:     track.LikedAt,
+		StreamTitle: track.Title,
+		Station:     track.Station.Name,
+		StationUuid: track.Station.StationUuid.String(),
+		URL:         track.Station.Url.URL.String(),
+	}
+	if parsed, ok := enrich.ParseTitle(track.Title); ok {
+		entry.Artist = parsed.Artist
+		entry.Title = parsed.Title
+	}
+	return entry
+}
+
+// Write writes tracks to w in the given format, in the order given.
+func Write(w io.Writer, format Format, tracks []storage.LikedTrack) error {
+	entries := make([]Entry, 0, len(tracks))
+	for _, track := range tracks {
+		entries = append(entries, NewEntry(track))
+	}
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	case CSV:
+		return writeCSV(w, entries)
+	}
+	return fmt.Errorf("unknown format %q (csv or json)", format)
+}
+
+// writeCSV writes entries as CSV, with a header row.
+func writeCSV(w io.Writer, entries []Entry) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"liked_at", "stream_title", "artist", "title", "station", "stationuuid", "url"})
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err := writer.Write([]string{
+			entry.LikedAt.Format(time.RFC3339),
+			entry.StreamTitle,
+			entry.Artist,
+			entry.Title,
+			entry.Station,
+			entry.StationUuid,
+			entry.URL,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package liked
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+func newTestTracks() []storage.LikedTrack {
+	station := common.Station{
+		StationUuid: uuid.MustParse("941ef6f1-0699-4821-95b1-2b678e3ff62e"),
+		Name:        "Radio, Test",
+		Url:         common.RadioGoGoURL{URL: url.URL{Scheme: "http", Host: "example.com", Path: "/stream"}},
+	}
+	likedAt := time.Date(2023, 10, 1, 12, 30, 0, 0, time.UTC)
+	return []storage.LikedTrack{
+		{Title: "Artist - Song", Station: station, LikedAt: likedAt},
+		{Title: "The Morning Show", Station: station, LikedAt: likedAt.Add(time.Hour)},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+
+	var out bytes.Buffer
+	assert.NoError(t, Write(&out, CSV, newTestTracks()))
+
+	assert.Equal(t, `liked_at,stream_title,artist,title,station,stationuuid,url
+2023-10-01T12:30:00Z,Artist - Song,Artist,Song,"Radio, Test",941ef6f1-0699-4821-95b1-2b678e3ff62e,http://example.com/stream
+2023-10-01T13:30:00Z,The Morning Show,,,"Radio, Test",941ef6f1-0699-4821-95b1-2b678e3ff62e,http://example.com/stream
+`, out.String())
+}
+
+func TestWriteJSON(t *testing.T) {
+
+	var out bytes.Buffer
+	assert.NoError(t, Write(&out, JSON, newTestTracks()[:1]))
+
+	assert.JSONEq(t, `[{
+		"likedAt": "2023-10-01T12:30:00Z",
+		"streamTitle": "Artist - Song",
+		"artist": "Artist",
+		"title": "Song",
+		"station": "Radio, Test",
+		"stationuuid": "941ef6f1-0699-4821-95b1-2b678e3ff62e",
+		"url": "http://example.com/stream"
+	}]`, out.String())
+
+	out.Reset()
+	assert.NoError(t, Write(&out, JSON, nil))
+	assert.Equal(t, "[]\n", out.String())
+}
+
+func TestFormats(t *testing.T) {
+
+	format, err := ParseFormat("JSON")
+	assert.NoError(t, err)
+	assert.Equal(t, JSON, format)
+
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+
+	assert.Equal(t, JSON, FormatOf("liked.json"))
+	assert.Equal(t, CSV, FormatOf("liked.csv"))
+	assert.Equal(t, CSV, FormatOf("liked"))
+}
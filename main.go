@@ -20,18 +20,276 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/cli"
+	"github.com/zi0p4tch0/radiogogo/common"
 	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/instance"
 	"github.com/zi0p4tch0/radiogogo/models"
+	"github.com/zi0p4tch0/radiogogo/nowplaying"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/secrets"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
 )
 
 func main() {
+	if err := newRootCommand().Execute(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// newRootCommand returns the command tree of RadioGoGo. Without a command, it starts the TUI.
+func newRootCommand() *cli.Command {
+
+	flags := flag.NewFlagSet("radiogogo", flag.ContinueOnError)
+	accessible := flags.Bool("accessible", false, "use a screen-reader friendly output mode")
+	lowBandwidth := flags.Bool("low-bandwidth", false, "redraw less and leave out colors and animations, for slow links such as SSH")
+	profile := flags.String("profile", "", "use the profile with the given `name`, with its own configuration, bookmarks and history")
+	view := flags.String("view", "", "open into the given `view`: search, bookmarks, history, last (the last search's results) or resume (play the last station)")
+	configDir := flags.String("config-dir", "", "keep the configuration, data and cache in the given `directory` instead of the XDG ones")
+	// Kept for scripts written before the subcommands: "export", "import", "play" and "show" replace them
+	exportOPML := flags.String("export-opml", "", "export bookmarks to the given OPML `file` (\"-\" for stdout) and exit")
+	importOPML := flags.String("import-opml", "", "import bookmarks from the given OPML `file` (\"-\" for stdin) and exit")
+	play := flags.String("play", "", "play the station with the given `uuid`, in the running instance if there is one")
+	show := flags.String("uuid", "", "show the station with the given `uuid`, in the running instance if there is one")
+
+	load := func() config.Config {
+		cfg := loadConfig(*accessible, *lowBandwidth)
+		if *view != "" {
+			cfg.Startup.View = config.StartupView(*view)
+		}
+		return cfg
+	}
+
+	root := &cli.Command{
+		Name:  "radiogogo",
+		Usage: "[radiogogo://station/<uuid>]",
+		Short: "Search, play and bookmark internet radio stations from the terminal",
+		Long:  "Without a command, RadioGoGo starts its terminal interface, showing the station of a radiogogo:// link if one is given.",
+		Flags: flags,
+		Setup: func() error {
+			if *view != "" {
+				if _, err := config.ParseStartupView(*view); err != nil {
+					return fmt.Errorf("selecting the startup view: %w", err)
+				}
+			}
+			if err := config.SetConfigDir(*configDir); err != nil {
+				return fmt.Errorf("setting the configuration directory: %w", err)
+			}
+			if err := config.SetProfile(*profile); err != nil {
+				return fmt.Errorf("selecting the profile: %w", err)
+			}
+			migrated, err := config.MigrateConfig()
+			reportMigration(migrated, err)
+			return nil
+		},
+		Run: func(args []string) error {
+			switch {
+			case *exportOPML != "":
+				return newExportCommand().Run([]string{*exportOPML})
+			case *importOPML != "":
+				return newImportCommand(load).Run([]string{*importOPML})
+			case *play != "":
+				return launch(load, &instance.Command{Action: instance.ActionPlay, StationUuid: *play})
+			case *show != "":
+				return launch(load, &instance.Command{Action: instance.ActionShow, StationUuid: *show})
+			case len(args) == 0:
+				return launch(load, nil)
+			case len(args) == 1 && instance.IsLink(args[0]):
+				return newShowCommand(load).Run(args)
+			default:
+				return fmt.Errorf("%w %q: see radiogogo --help", cli.ErrUnknownCommand, args[0])
+			}
+		},
+	}
+
+	root.Add(
+		newPlayCommand(load),
+		newShowCommand(load),
+		newSearchCommand(load),
+		newStatusCommand(),
+		newDoctorCommand(),
+		newSyncCommand(load),
+		newCacheCommand(load),
+		newExportCommand(),
+		newImportCommand(load),
+		newLikedCommand(),
+		newSecretCommand(),
+		newCompletionCommand(root),
+		newManCommand(root),
+		newVersionCommand(),
+	)
+
+	return root
+
+}
+
+// newPlayCommand returns "radiogogo play", which plays a station or a stream URL.
+func newPlayCommand(loadConfig func() config.Config) *cli.Command {
+
+	flags := flag.NewFlagSet("play", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "print the player command line, and its environment, instead of playing")
+
+	return &cli.Command{
+		Name:    "play",
+		Aliases: []string{"play-url"},
+		Usage:   "<uuid|link|url>",
+		Short:   "Play a station, given its UUID or radiogogo:// link, or a stream URL",
+		Long:    "If RadioGoGo is already running, the station is played there. With --dry-run, the command line the player would be run with is printed instead, to run it by hand when there's no sound.",
+		Flags:   flags,
+		// As in "radiogogo play <uuid> --dry-run"
+		Interspersed: true,
+		Run: func(args []string) error {
+			if len(args) != 1 {
+				return errors.New("playing: usage: radiogogo play [--dry-run] <uuid|link|url>")
+			}
+			if *dryRun {
+				if err := printDryRun(os.Stdout, loadConfig(), args[0]); err != nil {
+					return fmt.Errorf("playing: %w", err)
+				}
+				return nil
+			}
+			if stationUuid, err := parseStation(args[0]); err == nil {
+				return launch(loadConfig, &instance.Command{Action: instance.ActionPlay, StationUuid: stationUuid.String()})
+			}
+			if _, err := common.ParseStreamURL(args[0]); err != nil {
+				return fmt.Errorf("playing: %q is neither a station nor a stream URL: %w", args[0], err)
+			}
+			return launch(loadConfig, &instance.Command{Action: instance.ActionPlayURL, URL: args[0]})
+		},
+	}
+}
+
+// newShowCommand returns "radiogogo show", which shows a station without playing it.
+func newShowCommand(loadConfig func() config.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "show",
+		Usage: "<uuid|link>",
+		Short: "Show a station, given its UUID or radiogogo:// link",
+		Long:  "If RadioGoGo is already running, the station is shown there.",
+		Run: func(args []string) error {
+			if len(args) != 1 {
+				return errors.New("showing: usage: radiogogo show <uuid|link>")
+			}
+			stationUuid, err := parseStation(args[0])
+			if err != nil {
+				return fmt.Errorf("showing: %w", err)
+			}
+			return launch(loadConfig, &instance.Command{Action: instance.ActionShow, StationUuid: stationUuid.String()})
+		},
+	}
+}
+
+// parseStation parses a station UUID or radiogogo://station/<uuid> link.
+func parseStation(arg string) (uuid.UUID, error) {
+	if instance.IsLink(arg) {
+		return instance.ParseStationLink(arg)
+	}
+	stationUuid, err := uuid.Parse(arg)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid station UUID %q: %w", arg, err)
+	}
+	return stationUuid, nil
+}
 
-	// Create config
+// newCompletionCommand returns "radiogogo completion", which prints the completion script of a shell.
+func newCompletionCommand(root *cli.Command) *cli.Command {
+	return &cli.Command{
+		Name:        "completion",
+		Usage:       "<" + strings.Join(cli.Shells, "|") + ">",
+		Short:       "Print the shell completion script",
+		Long:        "Bash: source <(radiogogo completion bash). Zsh: radiogogo completion zsh > \"${fpath[1]}/_radiogogo\". Fish: radiogogo completion fish > ~/.config/fish/completions/radiogogo.fish.",
+		Completions: cli.Shells,
+		Run: func(args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("usage: radiogogo completion <%s>", strings.Join(cli.Shells, "|"))
+			}
+			return root.WriteCompletion(os.Stdout, args[0])
+		},
+	}
+}
+
+// newManCommand returns "radiogogo man", which prints the man page.
+func newManCommand(root *cli.Command) *cli.Command {
+	return &cli.Command{
+		Name:  "man",
+		Short: "Print the man page, e.g. radiogogo man > radiogogo.1",
+		Run: func(args []string) error {
+			if len(args) != 0 {
+				return errors.New("usage: radiogogo man")
+			}
+			return root.WriteManPage(os.Stdout, data.Version)
+		},
+	}
+}
+
+// launch starts the TUI, playing or showing the station of stationCommand if not nil.
+// If RadioGoGo is already running, the command is forwarded to it instead.
+func launch(loadConfig func() config.Config, stationCommand *instance.Command) error {
+
+	if stationCommand != nil && stationCommand.StationUuid != "" {
+		if _, err := uuid.Parse(stationCommand.StationUuid); err != nil {
+			return fmt.Errorf("invalid station UUID %q: %w", stationCommand.StationUuid, err)
+		}
+	}
+
+	cfg := loadConfig()
+
+	// Make sure only one instance is playing
+
+	server, err := instance.Listen(config.SocketFile())
+
+	if errors.Is(err, instance.ErrAlreadyRunning) {
+		command := instance.Command{Action: instance.ActionFocus}
+		if stationCommand != nil {
+			command = *stationCommand
+		}
+		if err := instance.Send(config.SocketFile(), command); err != nil {
+			return fmt.Errorf("contacting the running instance: %w", err)
+		}
+		fmt.Println("RadioGoGo is already running: the command has been forwarded to it.")
+		return nil
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting single-instance mode: %v\n", err)
+	} else {
+		defer server.Close()
+	}
+
+	// Run, starting over with the profile picked in the profile switcher, if any
+
+	for {
+		nextProfile, err := run(cfg, server, stationCommand)
+		if err != nil {
+			return fmt.Errorf("starting program: %w", err)
+		}
+		if nextProfile == "" {
+			return nil
+		}
+		if err := config.SetProfile(nextProfile); err != nil {
+			return fmt.Errorf("selecting the profile: %w", err)
+		}
+		cfg = loadConfig()
+		stationCommand = nil
+	}
+
+}
+
+// loadConfig loads the configuration of the profile in use, creating it if needed,
+// and selects its language.
+func loadConfig(accessible, lowBandwidth bool) config.Config {
 
 	cfg := config.NewDefaultConfig()
 	err := cfg.LoadOrCreateNew()
@@ -42,20 +300,199 @@ func main() {
 		cfg = config.NewDefaultConfig()
 	}
 
+	if accessible {
+		cfg.Accessible = true
+	}
+	if lowBandwidth {
+		cfg.Render.LowBandwidth = true
+	}
+
+	// Where the data and cache go, moving them there from where earlier versions kept them
+
+	if err := config.SetDataDir(cfg.Paths.Data); err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring the data directory in the config: %v\n", err)
+	}
+	if err := config.SetCacheDir(cfg.Paths.Cache); err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring the cache directory in the config: %v\n", err)
+	}
+	migrated, err := config.MigrateData()
+	reportMigration(migrated, err)
+
+	// Select language
+
+	if cfg.Language != "" {
+		i18n.SetLanguage(cfg.Language)
+	} else {
+		i18n.SetLanguage(i18n.DetectLanguage())
+	}
+
+	if err := data.SetUserAgent(cfg.API.UserAgent); err != nil {
+		fmt.Fprintf(os.Stderr, "Ignoring the User-Agent in the config: %v\n", err)
+	}
+
+	if cfg.API.BaseURL != "" {
+		if _, err := api.ParseBaseURL(cfg.API.BaseURL); err != nil {
+			fmt.Fprintf(os.Stderr, "Ignoring the radio-browser server in the config: %v\n", err)
+			cfg.API.BaseURL = ""
+		}
+	}
+
+	if cfg.Theme.File != "" {
+		if _, err := config.LoadThemeFile(cfg.Theme.File); err != nil {
+			fmt.Fprintf(os.Stderr, "Ignoring the theme file in the config: %v\n", err)
+			cfg.Theme.File = ""
+		}
+	}
+
+	// Fill in the secrets the config refers to
+
+	store := secrets.Open(config.SecretsDir())
+	for _, value := range []*string{&cfg.Output.Snapcast, &cfg.Output.Icecast, &cfg.Sync.Bookmarks.Git, &cfg.Sync.Bookmarks.WebDAV, &cfg.Sync.Bookmarks.Password} {
+		expanded, err := secrets.Expand(store, *value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading a secret from %s: %v\n", store.Description(), err)
+		}
+		*value = expanded
+	}
+
+	return cfg
+
+}
+
+// reportMigration tells which files were moved to their new location, and why the others couldn't be.
+func reportMigration(migrated []config.Migration, err error) {
+	for _, migration := range migrated {
+		fmt.Fprintf(os.Stderr, "Moved %s to %s\n", migration.From, migration.To)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error moving files to their new location: %v\n", err)
+	}
+}
+
+// run opens the database of the profile in use and runs RadioGoGo until it quits.
+// It returns the profile picked in the profile switcher, if any.
+func run(cfg config.Config, server *instance.Server, stationCommand *instance.Command) (string, error) {
+
+	// Open the database
+
+	db, err := openDatabase()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening the database: %v\n", err)
+		os.Exit(1)
+	}
+
+	defer db.Close()
+
+	if cfg.Sync.Bookmarks.Auto {
+		autoSyncBookmarks(cfg, db)
+	}
+
 	// Create model
 
-	model, err := models.NewDefaultModel(cfg)
+	model, err := models.NewDefaultModel(cfg, db)
 
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing model: %v\n", err)
 		os.Exit(1)
 	}
 
-	p := tea.NewProgram(model, tea.WithAltScreen())
+	// The single-instance server tells "radiogogo status" what's playing
+	if server != nil {
+		model.PublishNowPlayingTo(server)
+	}
 
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error starting program: %v\n", err)
-		os.Exit(1)
+	// Signals are handled below
+	options := []tea.ProgramOption{tea.WithAltScreen(), tea.WithoutSignalHandler()}
+	if cfg.Render.LowBandwidth {
+		// Repeated characters and styles are sent once, which matters more than the CPU it takes
+		options = append(options, tea.WithANSICompressor())
+	}
+	p := tea.NewProgram(model, options...)
+
+	// Commands received once the program has quit are left to the next one
+	done := make(chan struct{})
+	defer close(done)
+
+	if server != nil {
+		go func() {
+			for {
+				select {
+				case command := <-server.Commands():
+					p.Send(models.NewRemoteCommandMsg(command))
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	if listener := listenForHotkeys(cfg); listener != nil {
+		defer listener.Close()
+		go func() {
+			for action := range listener.Actions() {
+				p.Send(models.NewHotkeyMsg(action))
+			}
+		}()
+	}
+
+	if listener := listenForDucking(cfg); listener != nil {
+		defer listener.Close()
+		go func() {
+			for ducked := range listener.Events() {
+				p.Send(models.NewDuckMsg(ducked))
+			}
+		}()
+	}
+
+	if stationCommand != nil {
+		go p.Send(models.NewRemoteCommandMsg(*stationCommand))
+	}
+
+	// Don't leave the last station in the terminal title or tmux after quitting
+	if terminal := nowplaying.NewTerminal(os.Stdout, cfg.Terminal.Title, cfg.Terminal.Tmux); terminal != nil {
+		defer terminal.Write(nowplaying.Track{})
+	}
+
+	// SIGINT and SIGTERM quit as "q" does, without asking to confirm, so that the recordings in progress
+	// are finished before RadioGoGo goes. A closed terminal, or another signal, kills RadioGoGo outright
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		terminating := false
+		for {
+			select {
+			case sig := <-signals:
+				if sig == syscall.SIGHUP || terminating {
+					p.Kill()
+					return
+				}
+				terminating = true
+				p.Send(models.NewTerminateMsg())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// Whatever ends the program, including a panic caught by Bubble Tea, no player is left behind
+	finalModel, err := p.Run()
+	playback.KillAll()
+	if errors.Is(err, tea.ErrProgramKilled) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if cfg.Sync.Bookmarks.Auto {
+		autoSyncBookmarks(cfg, db)
+	}
+
+	if finalModel, ok := finalModel.(models.Model); ok {
+		return finalModel.NextProfile(), nil
 	}
+	return "", nil
 
 }
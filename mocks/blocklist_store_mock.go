@@ -0,0 +1,60 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mocks
+
+import (
+	"github.com/google/uuid"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+type MockBlocklistStore struct {
+	GetFunc    func(stationUuid uuid.UUID) (storage.BlockedStation, bool)
+	AllFunc    func() []storage.BlockedStation
+	AddFunc    func(blocked storage.BlockedStation) error
+	RemoveFunc func(stationUuid uuid.UUID) error
+}
+
+func (m *MockBlocklistStore) Get(stationUuid uuid.UUID) (storage.BlockedStation, bool) {
+	if m.GetFunc != nil {
+		return m.GetFunc(stationUuid)
+	}
+	return storage.BlockedStation{}, false
+}
+
+func (m *MockBlocklistStore) All() []storage.BlockedStation {
+	if m.AllFunc != nil {
+		return m.AllFunc()
+	}
+	return nil
+}
+
+func (m *MockBlocklistStore) Add(blocked storage.BlockedStation) error {
+	if m.AddFunc != nil {
+		return m.AddFunc(blocked)
+	}
+	return nil
+}
+
+func (m *MockBlocklistStore) Remove(stationUuid uuid.UUID) error {
+	if m.RemoveFunc != nil {
+		return m.RemoveFunc(stationUuid)
+	}
+	return nil
+}
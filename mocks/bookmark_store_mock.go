@@ -0,0 +1,85 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mocks
+
+import (
+	"github.com/google/uuid"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+type MockBookmarkStore struct {
+	AllFunc          func() []common.Station
+	IsBookmarkedFunc func(stationUuid uuid.UUID) bool
+	AddFunc          func(station common.Station) error
+	RemoveFunc       func(stationUuid uuid.UUID) (storage.RemovedBookmark, error)
+	RestoreFunc      func(bookmark storage.RemovedBookmark) error
+	MetaFunc         func(stationUuid uuid.UUID) storage.BookmarkMeta
+	SetMetaFunc      func(stationUuid uuid.UUID, meta storage.BookmarkMeta) error
+}
+
+func (m *MockBookmarkStore) All() []common.Station {
+	if m.AllFunc != nil {
+		return m.AllFunc()
+	}
+	return nil
+}
+
+func (m *MockBookmarkStore) IsBookmarked(stationUuid uuid.UUID) bool {
+	if m.IsBookmarkedFunc != nil {
+		return m.IsBookmarkedFunc(stationUuid)
+	}
+	return false
+}
+
+func (m *MockBookmarkStore) Add(station common.Station) error {
+	if m.AddFunc != nil {
+		return m.AddFunc(station)
+	}
+	return nil
+}
+
+func (m *MockBookmarkStore) Remove(stationUuid uuid.UUID) (storage.RemovedBookmark, error) {
+	if m.RemoveFunc != nil {
+		return m.RemoveFunc(stationUuid)
+	}
+	return storage.RemovedBookmark{}, nil
+}
+
+func (m *MockBookmarkStore) Restore(bookmark storage.RemovedBookmark) error {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(bookmark)
+	}
+	return nil
+}
+
+func (m *MockBookmarkStore) Meta(stationUuid uuid.UUID) storage.BookmarkMeta {
+	if m.MetaFunc != nil {
+		return m.MetaFunc(stationUuid)
+	}
+	return storage.BookmarkMeta{}
+}
+
+func (m *MockBookmarkStore) SetMeta(stationUuid uuid.UUID, meta storage.BookmarkMeta) error {
+	if m.SetMetaFunc != nil {
+		return m.SetMetaFunc(stationUuid, meta)
+	}
+	return nil
+}
@@ -19,7 +19,10 @@
 
 package mocks
 
-import "github.com/zi0p4tch0/radiogogo/common"
+import (
+	"github.com/google/uuid"
+	"github.com/zi0p4tch0/radiogogo/common"
+)
 
 type MockRadioBrowserService struct {
 	GetStationsFunc func(
@@ -32,7 +35,44 @@ type MockRadioBrowserService struct {
 		hideBroken bool,
 	) ([]common.Station, error)
 
+	SearchStationsFunc func(
+		name string,
+		filter common.StationFilter,
+		order string,
+		reverse bool,
+		offset uint64,
+		limit uint64,
+		hideBroken bool,
+	) ([]common.Station, error)
+
+	GetStationsByUrlFunc func(streamUrl string) ([]common.Station, error)
+
+	GetStationChecksFunc func(stationUuid uuid.UUID) ([]common.StationCheck, error)
+
 	ClickStationFunc func(station common.Station) (common.ClickStationResponse, error)
+
+	VoteStationFunc        func(station common.Station) (common.VoteStationResponse, error)
+	SuggestStationEditFunc func(stationUuid uuid.UUID, edit common.StationEdit) (common.StationEditResponse, error)
+
+	GetTagsFunc func(
+		prefix string,
+		order string,
+		reverse bool,
+		offset uint64,
+		limit uint64,
+		hideBroken bool,
+	) ([]common.Tag, error)
+
+	GetCountriesFunc func(hideBroken bool) ([]common.Country, error)
+
+	GetLanguagesFunc func(hideBroken bool) ([]common.Language, error)
+
+	GetTopStationsFunc func(
+		chart common.StationChart,
+		countryCode string,
+		limit uint64,
+		hideBroken bool,
+	) ([]common.Station, error)
 }
 
 func (m *MockRadioBrowserService) GetStations(
@@ -47,6 +87,62 @@ func (m *MockRadioBrowserService) GetStations(
 	return m.GetStationsFunc(stationQuery, searchTerm, order, reverse, offset, limit, hideBroken)
 }
 
+func (m *MockRadioBrowserService) SearchStations(
+	name string,
+	filter common.StationFilter,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Station, error) {
+	return m.SearchStationsFunc(name, filter, order, reverse, offset, limit, hideBroken)
+}
+
+func (m *MockRadioBrowserService) GetStationsByUrl(streamUrl string) ([]common.Station, error) {
+	return m.GetStationsByUrlFunc(streamUrl)
+}
+
+func (m *MockRadioBrowserService) GetStationChecks(stationUuid uuid.UUID) ([]common.StationCheck, error) {
+	return m.GetStationChecksFunc(stationUuid)
+}
+
 func (m *MockRadioBrowserService) ClickStation(station common.Station) (common.ClickStationResponse, error) {
 	return m.ClickStationFunc(station)
 }
+
+func (m *MockRadioBrowserService) VoteStation(station common.Station) (common.VoteStationResponse, error) {
+	return m.VoteStationFunc(station)
+}
+
+func (m *MockRadioBrowserService) SuggestStationEdit(stationUuid uuid.UUID, edit common.StationEdit) (common.StationEditResponse, error) {
+	return m.SuggestStationEditFunc(stationUuid, edit)
+}
+
+func (m *MockRadioBrowserService) GetTags(
+	prefix string,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Tag, error) {
+	return m.GetTagsFunc(prefix, order, reverse, offset, limit, hideBroken)
+}
+
+func (m *MockRadioBrowserService) GetCountries(hideBroken bool) ([]common.Country, error) {
+	return m.GetCountriesFunc(hideBroken)
+}
+
+func (m *MockRadioBrowserService) GetLanguages(hideBroken bool) ([]common.Language, error) {
+	return m.GetLanguagesFunc(hideBroken)
+}
+
+func (m *MockRadioBrowserService) GetTopStations(
+	chart common.StationChart,
+	countryCode string,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Station, error) {
+	return m.GetTopStationsFunc(chart, countryCode, limit, hideBroken)
+}
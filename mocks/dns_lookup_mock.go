@@ -19,8 +19,11 @@
 
 package mocks
 
+import "time"
+
 type MockDNSLookupService struct {
-	LookupIPFunc func(host string) ([]string, error)
+	LookupIPFunc    func(host string) ([]string, error)
+	LookupIPTTLFunc func(host string) ([]string, time.Duration, error)
 }
 
 func (m *MockDNSLookupService) LookupIP(host string) ([]string, error) {
@@ -29,3 +32,11 @@ func (m *MockDNSLookupService) LookupIP(host string) ([]string, error) {
 	}
 	return []string{}, nil
 }
+
+func (m *MockDNSLookupService) LookupIPTTL(host string) ([]string, time.Duration, error) {
+	if m.LookupIPTTLFunc != nil {
+		return m.LookupIPTTLFunc(host)
+	}
+	ips, err := m.LookupIP(host)
+	return ips, 0, err
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mocks
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+type MockHistoryStore struct {
+	AddFunc             func(station common.Station, playedAt time.Time) error
+	RecentFunc          func(limit int) ([]storage.HistoryEntry, error)
+	AddInterruptionFunc func(stationUuid uuid.UUID, interruption storage.Interruption) error
+	ClearFunc           func() (storage.RemovedHistory, error)
+	ForgetFunc          func(stationUuid uuid.UUID) (storage.RemovedHistory, error)
+	RestoreFunc         func(removed storage.RemovedHistory) error
+}
+
+func (m *MockHistoryStore) Add(station common.Station, playedAt time.Time) error {
+	if m.AddFunc != nil {
+		return m.AddFunc(station, playedAt)
+	}
+	return nil
+}
+
+func (m *MockHistoryStore) Recent(limit int) ([]storage.HistoryEntry, error) {
+	if m.RecentFunc != nil {
+		return m.RecentFunc(limit)
+	}
+	return nil, nil
+}
+
+func (m *MockHistoryStore) AddInterruption(stationUuid uuid.UUID, interruption storage.Interruption) error {
+	if m.AddInterruptionFunc != nil {
+		return m.AddInterruptionFunc(stationUuid, interruption)
+	}
+	return nil
+}
+
+func (m *MockHistoryStore) Clear() (storage.RemovedHistory, error) {
+	if m.ClearFunc != nil {
+		return m.ClearFunc()
+	}
+	return storage.RemovedHistory{}, nil
+}
+
+func (m *MockHistoryStore) Forget(stationUuid uuid.UUID) (storage.RemovedHistory, error) {
+	if m.ForgetFunc != nil {
+		return m.ForgetFunc(stationUuid)
+	}
+	return storage.RemovedHistory{}, nil
+}
+
+func (m *MockHistoryStore) Restore(removed storage.RemovedHistory) error {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(removed)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mocks
+
+import (
+	"net/url"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+type MockProberService struct {
+	StreamTitleFunc func(streamUrl url.URL) (string, error)
+	ProbeFunc       func(streamUrl url.URL) (common.StreamInfo, error)
+	PlaylistFunc    func(playlistUrl url.URL) ([]url.URL, error)
+}
+
+func (m *MockProberService) StreamTitle(streamUrl url.URL) (string, error) {
+	if m.StreamTitleFunc != nil {
+		return m.StreamTitleFunc(streamUrl)
+	}
+	return "", nil
+}
+
+func (m *MockProberService) Probe(streamUrl url.URL) (common.StreamInfo, error) {
+	if m.ProbeFunc != nil {
+		return m.ProbeFunc(streamUrl)
+	}
+	return common.StreamInfo{}, nil
+}
+
+func (m *MockProberService) Playlist(playlistUrl url.URL) ([]url.URL, error) {
+	if m.PlaylistFunc != nil {
+		return m.PlaylistFunc(playlistUrl)
+	}
+	return nil, nil
+}
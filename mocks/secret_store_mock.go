@@ -0,0 +1,60 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package mocks
+
+import (
+	"github.com/zi0p4tch0/radiogogo/secrets"
+)
+
+type MockSecretStore struct {
+	Secrets map[string]string
+	GetErr  error
+}
+
+func (m *MockSecretStore) Get(name string) (string, error) {
+	if m.GetErr != nil {
+		return "", m.GetErr
+	}
+	value, ok := m.Secrets[name]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return value, nil
+}
+
+func (m *MockSecretStore) Set(name string, value string) error {
+	if m.Secrets == nil {
+		m.Secrets = map[string]string{}
+	}
+	m.Secrets[name] = value
+	return nil
+}
+
+func (m *MockSecretStore) Delete(name string) error {
+	if _, ok := m.Secrets[name]; !ok {
+		return secrets.ErrNotFound
+	}
+	delete(m.Secrets, name)
+	return nil
+}
+
+func (m *MockSecretStore) Description() string {
+	return "memory"
+}
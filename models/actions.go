@@ -0,0 +1,111 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// actionQueuedMsg tells that a click or vote will be sent once radio-browser can be reached,
+// with what to tell about it (nothing if empty).
+type actionQueuedMsg struct {
+	text string
+}
+
+// actionRetryTickMsg sends the queued clicks and votes again.
+type actionRetryTickMsg struct{}
+
+// actionsRetriedMsg tells how radio-browser answered the queued clicks and votes sent again.
+type actionsRetriedMsg struct {
+	results []api.ActionResult
+}
+
+// retryActions sends the clicks and votes queued while radio-browser couldn't be reached again
+// until none is left, remembering and telling about those that were counted.
+// Those that failed anyway aren't worth interrupting what's being done: only votes are told about.
+func (m Model) retryActions(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	switch msg := msg.(type) {
+	case actionQueuedMsg:
+		if msg.text != "" {
+			cmds = append(cmds, showToastCmd(msg.text, toastInfo))
+		}
+	case actionRetryTickMsg:
+		// They'd only fail while the network is down
+		if m.network.offline() {
+			return m, actionRetryTickCmd(m.network.interval)
+		}
+		return m, retryActionsCmd(m.actions)
+	case actionsRetriedMsg:
+		m.actionsRetrying = false
+		var voted, failed []string
+		for _, result := range msg.results {
+			if result.Err != nil || !result.Ok {
+				if result.Kind == api.ActionVote {
+					failed = append(failed, stationDisplayName(m.labelStore, result.Station))
+				}
+				continue
+			}
+			if err := recordInteraction(m.interactions, result); err != nil {
+				cmds = append(cmds, showErrorBannerCmd(err))
+			}
+			if result.Kind == api.ActionVote {
+				voted = append(voted, stationDisplayName(m.labelStore, result.Station))
+			}
+		}
+		if len(voted) > 0 {
+			cmds = append(cmds, showToastCmd(i18n.Tf("votes.sent", strings.Join(voted, ", ")), toastSuccess))
+		}
+		if len(failed) > 0 {
+			cmds = append(cmds, showToastCmd(i18n.Tf("votes.notSent", strings.Join(failed, ", ")), toastError))
+		}
+	}
+	if !m.actionsRetrying && m.actions.Pending() > 0 {
+		m.actionsRetrying = true
+		cmds = append(cmds, actionRetryTickCmd(m.actions.RetryIn()))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// Commands
+
+// actionRetryTickCmd sends the queued clicks and votes again after delay.
+func actionRetryTickCmd(delay time.Duration) tea.Cmd {
+	// At least a second apart, so that a queue due right away isn't spun on
+	if delay < time.Second {
+		delay = time.Second
+	}
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		return actionRetryTickMsg{}
+	})
+}
+
+// retryActionsCmd sends the queued clicks and votes that are due.
+func retryActionsCmd(actions *api.ActionQueue) tea.Cmd {
+	return func() tea.Msg {
+		return actionsRetriedMsg{results: actions.Retry()}
+	}
+}
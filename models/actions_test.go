@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModel_RetryActions(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	rock := common.Station{StationUuid: uuid.New(), Name: "Rock FM"}
+
+	newModel := func() Model {
+		model := NewModel(config.Config{}, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{}, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		model.interactions = newInteractionStore()
+		return model
+	}
+
+	t.Run("remembers and tells about the votes counted", func(t *testing.T) {
+		model := newModel()
+
+		_, cmd := model.Update(actionsRetriedMsg{results: []api.ActionResult{
+			{Kind: api.ActionClick, Station: jazz, Ok: true},
+			{Kind: api.ActionVote, Station: rock, Ok: true},
+			{Kind: api.ActionVote, Station: jazz, Ok: false, Message: "too often"},
+		}})
+
+		_, clicked := model.interactions.Last(storage.InteractionClick, jazz.StationUuid)
+		_, voted := model.interactions.Last(storage.InteractionVote, rock.StationUuid)
+		_, refused := model.interactions.Last(storage.InteractionVote, jazz.StationUuid)
+		assert.True(t, clicked)
+		assert.True(t, voted)
+		assert.False(t, refused)
+
+		var toasts []toastMsg
+		for _, msg := range collectMsgs(cmd) {
+			if toast, ok := msg.(toastMsg); ok {
+				toasts = append(toasts, toast)
+			}
+		}
+		assert.Equal(t, []toastMsg{
+			{text: "Votes sent for Rock FM", kind: toastSuccess},
+			{text: "Votes not counted for Jazz FM", kind: toastError},
+		}, toasts)
+	})
+
+	t.Run("retries until nothing is queued", func(t *testing.T) {
+		model := newModel()
+		model.actions = api.NewActionQueue(&mocks.MockRadioBrowserService{
+			ClickStationFunc: func(station common.Station) (common.ClickStationResponse, error) {
+				return common.ClickStationResponse{}, &api.Error{Kind: api.ErrMirrorUnavailable}
+			},
+		})
+		model.actions.Send(api.ActionClick, jazz)
+
+		newModel, cmd := model.Update(actionQueuedMsg{})
+		assert.NotNil(t, cmd)
+		assert.True(t, newModel.(Model).actionsRetrying)
+
+		// Queued again while waiting: no second retry
+		_, cmd = newModel.Update(actionQueuedMsg{})
+		assert.Nil(t, cmd)
+
+		model.actions = api.NewActionQueue(&mocks.MockRadioBrowserService{})
+		newModel, cmd = model.Update(actionsRetriedMsg{})
+		assert.Nil(t, cmd)
+		assert.False(t, newModel.(Model).actionsRetrying)
+	})
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// How long the bottom bar flashes when playback stops on its own.
+const flashDuration = time.Second
+
+// How many times in a row a station is reconnected after the playback watchdog killed its backend,
+// before giving up on it, unless its preferences say otherwise. The count starts over once it has played for watchdogResetAfter.
+const (
+	maxWatchdogReconnects = 3
+	watchdogResetAfter    = 5 * time.Minute
+)
+
+// Messages
+
+// backendExitedMsg is sent when a playback backend process exits on its own.
+type backendExitedMsg struct {
+	exit playback.ProcessExit
+}
+
+// flashEndedMsg ends the flash of the bottom bar, unless another one started since, as told by generation.
+type flashEndedMsg struct {
+	generation int
+}
+
+// reconnectStationMsg plays station again after the playback watchdog killed its backend.
+type reconnectStationMsg struct {
+	station common.Station
+}
+
+// Commands
+
+func reconnectStationCmd(station common.Station) tea.Cmd {
+	return func() tea.Msg {
+		return reconnectStationMsg{station: station}
+	}
+}
+
+// waitForBackendExitCmd waits until a playback backend process exits on its own.
+func waitForBackendExitCmd(exits <-chan playback.ProcessExit) tea.Cmd {
+	return func() tea.Msg {
+		return backendExitedMsg{exit: <-exits}
+	}
+}
+
+// backendExited stops the station whose backend exited, telling why, unless it had already been replaced.
+// The next queued station is played in its place, if any.
+func (m Model) backendExited(exit playback.ProcessExit) (tea.Model, tea.Cmd) {
+	wait := waitForBackendExitCmd(m.backendExits)
+	if !exit.Current() || !m.playbackManager.IsPlaying() {
+		return m, wait
+	}
+	// Reconnecting is pointless while the network is down: the station is once it's back
+	if exit.Reason != nil && !m.network.offline() {
+		if model, cmd, ok := m.watchdogTripped(exit); ok {
+			return model, tea.Batch(wait, cmd)
+		}
+	}
+	cmds := []tea.Cmd{stopStationCmd(m.playbackManager), nonFatalErrorCmd(exit)}
+	if m.state == stationsState && m.queue != nil && m.queue.len() > 0 {
+		cmds = append(cmds, advanceQueueCmd)
+	}
+	// The station is reconnected once the network is back
+	kind := storage.InterruptionFailed
+	if m.network.offline() {
+		kind = storage.InterruptionDropped
+	}
+	m, alert := m.alertPlaybackStopped()
+	return m, tea.Batch(
+		wait,
+		tea.Sequence(cmds...),
+		alert,
+		m.interruptionCmd(m.playingStation, kind, exitReason(exit)),
+	)
+}
+
+// exitReason tells briefly why a backend exited, leaving out what it printed.
+func exitReason(exit playback.ProcessExit) string {
+	if exit.Reason != nil {
+		return exit.Reason.Error()
+	}
+	return i18n.Tf("playback.crashed", exit.Name, exit.Code)
+}
+
+// trackPlayingStation remembers the station being played, for the watchdog to reconnect it.
+func (m Model) trackPlayingStation(msg tea.Msg) Model {
+	switch msg := msg.(type) {
+	case reconnectStationMsg:
+		m.reconnecting = msg.station.StationUuid
+	case playbackStartedMsg:
+		if msg.station.StationUuid != m.playingStation.StationUuid {
+			m.watchdogReconnects = 0
+		}
+		m.resumed = msg.station.StationUuid == m.reconnecting
+		m.reconnecting = uuid.Nil
+		m.playingStation = msg.station
+	case playbackStoppedMsg:
+		m.playingStation = common.Station{}
+	}
+	return m
+}
+
+// watchdogTripped moves on from the station whose backend the playback watchdog killed because it
+// stalled or went silent: the next queued station is played, if any, or else the station is reconnected.
+// Both are logged. It returns false once the station has been reconnected too many times in a row,
+// for it to be stopped like any backend that exits on its own.
+func (m Model) watchdogTripped(exit playback.ProcessExit) (Model, tea.Cmd, bool) {
+	station := m.playingStation
+	name := stationDisplayName(m.labelStore, station)
+	stop := stopStationCmd(m.playbackManager)
+	if m.state == stationsState && m.queue != nil && m.queue.len() > 0 {
+		_ = m.eventLog.Printf("%s (%s): %s, playing the next queued station", name, station.Url.URL.String(), exit.Reason)
+		return m, tea.Batch(
+			tea.Sequence(stop, advanceQueueCmd),
+			showToastCmd(i18n.Tf("watchdog.skipped", name, exit.Reason), toastInfo),
+			m.interruptionCmd(station, storage.InterruptionFailed, exit.Reason.Error()),
+		), true
+	}
+	now := time.Now()
+	if now.Sub(m.watchdogTrippedAt) > watchdogResetAfter {
+		m.watchdogReconnects = 0
+	}
+	m.watchdogTrippedAt = now
+	limit := maxReconnects(station)
+	if m.watchdogReconnects >= limit {
+		_ = m.eventLog.Printf("%s (%s): %s, gave up after %d reconnections", name, station.Url.URL.String(), exit.Reason, m.watchdogReconnects)
+		return m, nil, false
+	}
+	m.watchdogReconnects++
+	_ = m.eventLog.Printf("%s (%s): %s, reconnecting (%d/%d)", name, station.Url.URL.String(), exit.Reason, m.watchdogReconnects, limit)
+	return m, tea.Batch(
+		tea.Sequence(stop, reconnectStationCmd(station)),
+		showToastCmd(i18n.Tf("watchdog.reconnecting", name, exit.Reason, m.watchdogReconnects, limit), toastInfo),
+		m.interruptionCmd(station, storage.InterruptionReconnecting, exit.Reason.Error()),
+	), true
+}
+
+// alertPlaybackStopped draws the user's attention to playback stopping on its own, as configured,
+// so that the silence isn't mistaken for a quiet moment while they're in another view or window.
+func (m Model) alertPlaybackStopped() (Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	if m.alertBell {
+		cmds = append(cmds, ringBellCmd)
+	}
+	if m.alertFlash && !m.theme.Accessible {
+		m.flashing = true
+		m.flashGeneration++
+		generation := m.flashGeneration
+		cmds = append(cmds, tea.Tick(flashDuration, func(t time.Time) tea.Msg {
+			return flashEndedMsg{generation: generation}
+		}))
+	}
+	return m, tea.Batch(cmds...)
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How often the data received is added to the monthly total.
+const bandwidthSaveInterval = time.Minute
+
+// bandwidthUsage follows the data received by the stations, adding it to the monthly total kept in the store.
+// It's shared by the root and stations models, and safe for use by concurrent commands.
+// A nil *bandwidthUsage means the stations aren't metered.
+type bandwidthUsage struct {
+	mu    sync.Mutex
+	meter *playback.Meter
+	store storage.UsageStore
+	// Monthly cap, in bytes (0 for none)
+	monthlyCap uint64
+	// Bytes received that are already in the store
+	saved uint64
+	// Monthly total in the store, as of the last save
+	month uint64
+
+	now func() time.Time
+}
+
+func newBandwidthUsage(meter *playback.Meter, store storage.UsageStore, monthlyCapMB int) *bandwidthUsage {
+	u := &bandwidthUsage{
+		meter: meter,
+		store: store,
+		now:   time.Now,
+	}
+	if monthlyCapMB > 0 {
+		u.monthlyCap = uint64(monthlyCapMB) * 1000 * 1000
+	}
+	u.month = store.Month(u.now())
+	return u
+}
+
+// session returns the bytes received since RadioGoGo started.
+func (u *bandwidthUsage) session() uint64 {
+	return u.meter.Received()
+}
+
+// thisMonth returns the bytes received this month, including what hasn't been saved yet.
+func (u *bandwidthUsage) thisMonth() uint64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.month + u.meter.Received() - u.saved
+}
+
+// overCap returns true if the data received this month went over the monthly cap.
+func (u *bandwidthUsage) overCap() bool {
+	return u.monthlyCap > 0 && u.thisMonth() > u.monthlyCap
+}
+
+// save adds what was received since the last save to the monthly total.
+func (u *bandwidthUsage) save() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	received := u.meter.Received()
+	now := u.now()
+	if err := u.store.Add(now, received-u.saved); err != nil {
+		return err
+	}
+	u.saved = received
+	// Reloaded, so that the total starts over when the month changes
+	u.month = u.store.Month(now)
+	return nil
+}
+
+// text describes the current bitrate and the data used, warning if it's over the monthly cap.
+func (u *bandwidthUsage) text() string {
+	text := i18n.Tf("stations.bandwidth",
+		u.meter.Bitrate()/1000,
+		formatDataSize(u.session()),
+		formatDataSize(u.thisMonth()),
+	)
+	if u.overCap() {
+		text += " " + i18n.Tf("stations.overCap", formatDataSize(u.monthlyCap))
+	}
+	return text
+}
+
+// suffix returns the usage to show after the station being played, or an empty string if not metered.
+func (u *bandwidthUsage) suffix() string {
+	if u == nil {
+		return ""
+	}
+	return " · " + u.text()
+}
+
+// formatDataSize formats a number of bytes in decimal units, e.g. "4.2 MB".
+func formatDataSize(bytes uint64) string {
+	switch {
+	case bytes >= 1000*1000*1000:
+		return fmt.Sprintf("%.1f GB", float64(bytes)/(1000*1000*1000))
+	case bytes >= 1000*1000:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1000*1000))
+	default:
+		return fmt.Sprintf("%d KB", bytes/1000)
+	}
+}
+
+// Messages
+
+type bandwidthTickMsg struct{}
+
+// Commands
+
+// bandwidthTickCmd waits until the data received is due to be saved.
+func bandwidthTickCmd() tea.Cmd {
+	return tea.Tick(bandwidthSaveInterval, func(t time.Time) tea.Msg {
+		return bandwidthTickMsg{}
+	})
+}
+
+// saveBandwidthUsageCmd adds what was received since the last save to the monthly total.
+func saveBandwidthUsageCmd(usage *bandwidthUsage) tea.Cmd {
+	return func() tea.Msg {
+		if err := usage.save(); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return nil
+	}
+}
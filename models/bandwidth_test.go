@@ -0,0 +1,143 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/playback"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBandwidthUsage(t *testing.T) {
+
+	t.Run("adds what hasn't been saved yet to the monthly total", func(t *testing.T) {
+
+		meter := playback.NewMeter()
+		usage := newBandwidthUsage(meter, &mocks.MockUsageStore{
+			MonthFunc: func(t time.Time) uint64 { return 5000 },
+		}, 0)
+
+		_, _ = meter.Write(make([]byte, 1000))
+
+		assert.Equal(t, uint64(1000), usage.session())
+		assert.Equal(t, uint64(6000), usage.thisMonth())
+
+	})
+
+	t.Run("saves only what was received since the last save", func(t *testing.T) {
+
+		var stored uint64
+		meter := playback.NewMeter()
+		usage := newBandwidthUsage(meter, &mocks.MockUsageStore{
+			MonthFunc: func(t time.Time) uint64 { return stored },
+			AddFunc: func(t time.Time, bytes uint64) error {
+				stored += bytes
+				return nil
+			},
+		}, 0)
+
+		_, _ = meter.Write(make([]byte, 1000))
+		assert.NoError(t, usage.save())
+		_, _ = meter.Write(make([]byte, 500))
+		assert.NoError(t, usage.save())
+
+		assert.Equal(t, uint64(1500), stored)
+		assert.Equal(t, uint64(1500), usage.thisMonth())
+		assert.Equal(t, uint64(1500), usage.session())
+
+	})
+
+	t.Run("starts the monthly total over when the month changes", func(t *testing.T) {
+
+		months := map[time.Month]uint64{time.March: 9000}
+		now := time.Date(2024, 3, 31, 23, 59, 0, 0, time.Local)
+		meter := playback.NewMeter()
+		usage := newBandwidthUsage(meter, &mocks.MockUsageStore{
+			MonthFunc: func(t time.Time) uint64 { return months[t.Month()] },
+			AddFunc: func(t time.Time, bytes uint64) error {
+				months[t.Month()] += bytes
+				return nil
+			},
+		}, 0)
+		usage.now = func() time.Time { return now }
+
+		_, _ = meter.Write(make([]byte, 1000))
+		now = now.Add(2 * time.Minute)
+		assert.NoError(t, usage.save())
+
+		assert.Equal(t, uint64(1000), usage.thisMonth())
+		assert.Equal(t, uint64(9000), months[time.March])
+
+	})
+
+	t.Run("keeps the unsaved data when saving fails", func(t *testing.T) {
+
+		meter := playback.NewMeter()
+		usage := newBandwidthUsage(meter, &mocks.MockUsageStore{
+			AddFunc: func(t time.Time, bytes uint64) error { return errors.New("disk full") },
+		}, 0)
+
+		_, _ = meter.Write(make([]byte, 1000))
+
+		msg := saveBandwidthUsageCmd(usage)()
+		assert.Equal(t, nonFatalError{stopPlayback: false, err: errors.New("disk full")}, msg)
+		assert.Equal(t, uint64(1000), usage.thisMonth())
+
+	})
+
+	t.Run("warns when the monthly total goes over the cap", func(t *testing.T) {
+
+		meter := playback.NewMeter()
+		usage := newBandwidthUsage(meter, &mocks.MockUsageStore{
+			MonthFunc: func(t time.Time) uint64 { return 999_000 },
+		}, 1)
+
+		assert.False(t, usage.overCap())
+		assert.NotContains(t, usage.text(), "cap")
+
+		_, _ = meter.Write(make([]byte, 2000))
+
+		assert.True(t, usage.overCap())
+		assert.Contains(t, usage.text(), "2 KB (this month: 1.0 MB)")
+		assert.Contains(t, usage.text(), "over the monthly cap of 1.0 MB")
+
+	})
+
+	t.Run("shows nothing when not metered", func(t *testing.T) {
+
+		var usage *bandwidthUsage
+
+		assert.Equal(t, "", usage.suffix())
+
+	})
+
+}
+
+func TestFormatDataSize(t *testing.T) {
+	assert.Equal(t, "0 KB", formatDataSize(999))
+	assert.Equal(t, "512 KB", formatDataSize(512_000))
+	assert.Equal(t, "4.2 MB", formatDataSize(4_200_000))
+	assert.Equal(t, "1.3 GB", formatDataSize(1_300_000_000))
+}
@@ -0,0 +1,384 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// withoutBlockedStations leaves out the stations hidden for good by the user (none if blocklist is nil).
+func withoutBlockedStations(blocklist storage.BlocklistStore, stations []common.Station) []common.Station {
+	if blocklist == nil {
+		return stations
+	}
+	kept := make([]common.Station, 0, len(stations))
+	for _, station := range stations {
+		if _, blocked := blocklist.Get(station.StationUuid); blocked {
+			continue
+		}
+		kept = append(kept, station)
+	}
+	return kept
+}
+
+// Messages
+
+// blockSubmittedMsg asks to hide a station for good.
+type blockSubmittedMsg struct {
+	blocked storage.BlockedStation
+}
+
+type closeBlockStationMsg struct{}
+
+// stationBlockedMsg tells that a station was hidden for good, to take it out of the results.
+type stationBlockedMsg struct {
+	blocked storage.BlockedStation
+}
+
+// blocklistRequestedMsg asks the root model to open the hidden stations over the current view.
+type blocklistRequestedMsg struct{}
+
+// closeBlocklistMsg closes the hidden stations.
+type closeBlocklistMsg struct{}
+
+type blocklistLoadedMsg struct {
+	blocklist []storage.BlockedStation
+}
+
+// stationReblockedMsg tells that a station shown again was hidden again, by undoing.
+type stationReblockedMsg struct {
+	blocked storage.BlockedStation
+}
+
+// Commands
+
+func showBlocklistCmd() tea.Msg {
+	return blocklistRequestedMsg{}
+}
+
+func blockStationCmd(store storage.BlocklistStore, blocked storage.BlockedStation) tea.Cmd {
+	return func() tea.Msg {
+		if err := store.Add(blocked); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return stationBlockedMsg{blocked: blocked}
+	}
+}
+
+func loadBlocklistCmd(store storage.BlocklistStore) tea.Cmd {
+	return func() tea.Msg {
+		return blocklistLoadedMsg{blocklist: store.All()}
+	}
+}
+
+// unblockStationCmd shows a hidden station again, which can be undone.
+func unblockStationCmd(store storage.BlocklistStore, blocked storage.BlockedStation) tea.Cmd {
+	return func() tea.Msg {
+		if err := store.Remove(blocked.StationUuid); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return undoableMsg{
+			text: i18n.Tf("blocklist.unblocked", displayText(blocked.StationName)),
+			undo: reblockStationCmd(store, blocked),
+		}
+	}
+}
+
+func reblockStationCmd(store storage.BlocklistStore, blocked storage.BlockedStation) tea.Cmd {
+	return func() tea.Msg {
+		if err := store.Add(blocked); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return stationReblockedMsg{blocked: blocked}
+	}
+}
+
+// SetBlocklistStore hides the stations blocked in store from the results, and lets more be hidden (nil hides none).
+func (m *StationsModel) SetBlocklistStore(store storage.BlocklistStore) {
+	m.blocklist = store
+}
+
+// SetBlocklistStore hides the stations blocked in store from the charts (nil hides none).
+func (m *ChartsModel) SetBlocklistStore(store storage.BlocklistStore) {
+	m.blocklist = store
+}
+
+// Block station dialog
+
+// BlockStationModel asks why a station is hidden for good, which is up to the user to say or not.
+type BlockStationModel struct {
+	theme   Theme
+	station common.Station
+	name    string
+	input   textinput.Model
+	now     func() time.Time
+}
+
+// NewBlockStationModel returns a BlockStationModel for station, shown with the given name.
+func NewBlockStationModel(theme Theme, station common.Station, name string) BlockStationModel {
+	input := textinput.New()
+	input.Prompt = i18n.T("blockStation.prompt") + " "
+	input.PromptStyle = theme.SecondaryText
+	input.TextStyle = theme.Text
+	input.Placeholder = i18n.T("blockStation.placeholder")
+	input.CharLimit = 200
+	input.Focus()
+
+	return BlockStationModel{
+		theme:   theme,
+		station: station,
+		name:    name,
+		input:   input,
+		now:     time.Now,
+	}
+}
+
+func (m BlockStationModel) Init() tea.Cmd {
+	return func() tea.Msg {
+		return bottomBarUpdateMsg{
+			commands: []string{i18n.T("commands.cancel"), i18n.T("commands.hideStation")},
+		}
+	}
+}
+
+func (m BlockStationModel) Update(msg tea.Msg) (BlockStationModel, tea.Cmd) {
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			return m, func() tea.Msg {
+				return closeBlockStationMsg{}
+			}
+		case "enter":
+			submitted := blockSubmittedMsg{
+				blocked: storage.BlockedStation{
+					StationUuid: m.station.StationUuid,
+					StationName: m.station.Name,
+					Reason:      strings.TrimSpace(m.input.Value()),
+					BlockedAt:   m.now(),
+				},
+			}
+			return m, func() tea.Msg {
+				return submitted
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m BlockStationModel) View() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.Tf("blockStation.title", m.name)) + "\n\n"
+	v += m.input.View() + "\n\n"
+	v += m.theme.TertiaryText.Render(i18n.T("blockStation.hint")) + "\n"
+
+	return v
+}
+
+// Hidden stations
+
+// BlocklistModel lists the stations hidden for good, most recently hidden first, with why,
+// to show them again. It's opened over the view it was requested from, like the liked tracks.
+type BlocklistModel struct {
+	theme     Theme
+	store     storage.BlocklistStore
+	blocklist []storage.BlockedStation
+	loaded    bool
+	cursor    int
+	// offset is the first station shown
+	offset int
+	width  int
+	height int
+}
+
+func NewBlocklistModel(theme Theme, store storage.BlocklistStore) BlocklistModel {
+	return BlocklistModel{
+		theme: theme,
+		store: store,
+	}
+}
+
+func (m BlocklistModel) Init() tea.Cmd {
+	return loadBlocklistCmd(m.store)
+}
+
+func (m BlocklistModel) Update(msg tea.Msg) (BlocklistModel, tea.Cmd) {
+
+	switch msg := msg.(type) {
+	case blocklistLoadedMsg:
+		m.blocklist = msg.blocklist
+		m.loaded = true
+		m.setCursor(m.cursor)
+		return m, nil
+	case stationReblockedMsg:
+		m.restore(msg.blocked)
+		return m, nil
+	case tea.KeyMsg:
+		return m.updateKeys(msg)
+	}
+
+	return m, nil
+}
+
+func (m BlocklistModel) updateKeys(msg tea.KeyMsg) (BlocklistModel, tea.Cmd) {
+
+	switch msg.String() {
+	case "esc", "q":
+		return m, func() tea.Msg {
+			return closeBlocklistMsg{}
+		}
+	case "u":
+		return m, undoCmd
+	}
+
+	if len(m.blocklist) == 0 {
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		m.setCursor(m.cursor - 1)
+	case "down", "j":
+		m.setCursor(m.cursor + 1)
+	case "pgup":
+		m.setCursor(m.cursor - m.visibleStations())
+	case "pgdown":
+		m.setCursor(m.cursor + m.visibleStations())
+	case "home", "g":
+		m.setCursor(0)
+	case "end", "G":
+		m.setCursor(len(m.blocklist) - 1)
+	case "d", "delete", "enter":
+		blocked := m.blocklist[m.cursor]
+		m.blocklist = append(m.blocklist[:m.cursor:m.cursor], m.blocklist[m.cursor+1:]...)
+		m.setCursor(m.cursor)
+		return m, unblockStationCmd(m.store, blocked)
+	}
+
+	return m, nil
+}
+
+// restore puts a station hidden again back in its place, the stations being sorted by the time they were hidden.
+func (m *BlocklistModel) restore(blocked storage.BlockedStation) {
+	index := 0
+	for index < len(m.blocklist) && m.blocklist[index].BlockedAt.After(blocked.BlockedAt) {
+		index++
+	}
+	m.blocklist = append(m.blocklist[:index], append([]storage.BlockedStation{blocked}, m.blocklist[index:]...)...)
+	m.setCursor(index)
+}
+
+// setCursor moves the cursor to the given station, within bounds, scrolling to keep it visible.
+func (m *BlocklistModel) setCursor(cursor int) {
+	if cursor >= len(m.blocklist) {
+		cursor = len(m.blocklist) - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	m.cursor = cursor
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if visible := m.visibleStations(); m.cursor >= m.offset+visible {
+		m.offset = m.cursor - visible + 1
+	}
+}
+
+// visibleStations returns how many stations fit below the title and above the hint, two lines each.
+func (m BlocklistModel) visibleStations() int {
+	if m.height <= 6 {
+		return 1
+	}
+	return (m.height - 4) / 2
+}
+
+// commands are shown in the bottom bar while the hidden stations are open.
+func (m BlocklistModel) commands() []string {
+	return []string{
+		i18n.T("commands.move"),
+		i18n.T("commands.unhideStation"),
+		i18n.T("commands.undo"),
+		i18n.T("commands.back"),
+	}
+}
+
+func (m BlocklistModel) View() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.Tf("blocklist.title", len(m.blocklist))) + "\n\n"
+
+	if !m.loaded {
+		return v
+	}
+	if len(m.blocklist) == 0 {
+		return v + m.theme.TertiaryText.Render(i18n.T("blocklist.empty")) + "\n"
+	}
+
+	// The accessible view isn't bound by the height of the terminal
+	start, end := 0, len(m.blocklist)
+	if !m.theme.Accessible && m.height > 0 {
+		start = m.offset
+		if end > start+m.visibleStations() {
+			end = start + m.visibleStations()
+		}
+	}
+
+	now := time.Now()
+	for i := start; i < end; i++ {
+		blocked := m.blocklist[i]
+		name := displayText(blocked.StationName)
+		reason := blocked.Reason
+		if reason == "" {
+			reason = i18n.T("blocklist.noReason")
+		}
+		why := "   " + i18n.Tf("blocklist.why", likedAt(blocked.BlockedAt, now), displayText(reason))
+		switch {
+		case m.theme.Accessible && i == m.cursor:
+			v += ">>> " + name + "\n" + why + "\n"
+		case m.theme.Accessible:
+			v += "    " + name + "\n" + why + "\n"
+		case i == m.cursor:
+			v += m.theme.PrimaryBlock.Render(" "+name+" ") + "\n" + m.theme.TertiaryText.Render(why) + "\n"
+		default:
+			v += m.theme.Text.Render(" "+name) + "\n" + m.theme.TertiaryText.Render(why) + "\n"
+		}
+	}
+
+	v += "\n" + m.theme.TertiaryText.Render(i18n.T("blocklist.hint")) + "\n"
+
+	return v
+}
+
+func (m *BlocklistModel) SetWidthAndHeight(width int, height int) {
+	m.width = width
+	m.height = height
+	m.setCursor(m.cursor)
+}
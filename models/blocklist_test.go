@@ -0,0 +1,215 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// blocklistOf returns a blocklist store holding the given stations, most recently blocked first.
+func blocklistOf(blocklist ...storage.BlockedStation) *mocks.MockBlocklistStore {
+	store := &mocks.MockBlocklistStore{}
+	store.GetFunc = func(stationUuid uuid.UUID) (storage.BlockedStation, bool) {
+		for _, blocked := range blocklist {
+			if blocked.StationUuid == stationUuid {
+				return blocked, true
+			}
+		}
+		return storage.BlockedStation{}, false
+	}
+	store.AllFunc = func() []storage.BlockedStation {
+		return append([]storage.BlockedStation(nil), blocklist...)
+	}
+	store.AddFunc = func(blocked storage.BlockedStation) error {
+		blocklist = append([]storage.BlockedStation{blocked}, blocklist...)
+		return nil
+	}
+	store.RemoveFunc = func(stationUuid uuid.UUID) error {
+		for i, blocked := range blocklist {
+			if blocked.StationUuid == stationUuid {
+				blocklist = append(blocklist[:i:i], blocklist[i+1:]...)
+				break
+			}
+		}
+		return nil
+	}
+	return store
+}
+
+func newTestBlocklist() []storage.BlockedStation {
+	blockedAt := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	return []storage.BlockedStation{
+		{StationUuid: uuid.New(), StationName: "Talk Radio 24", Reason: "too many ads", BlockedAt: blockedAt.Add(time.Hour)},
+		{StationUuid: uuid.New(), StationName: "Radio Static", BlockedAt: blockedAt},
+	}
+}
+
+func TestWithoutBlockedStations(t *testing.T) {
+
+	blocklist := newTestBlocklist()
+	blocked := common.Station{StationUuid: blocklist[0].StationUuid, Name: "Talk Radio 24"}
+	other := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+
+	assert.Equal(t, []common.Station{other}, withoutBlockedStations(blocklistOf(blocklist...), []common.Station{blocked, other}))
+	assert.Equal(t, []common.Station{blocked, other}, withoutBlockedStations(nil, []common.Station{blocked, other}))
+
+}
+
+func TestStationsModel_Block(t *testing.T) {
+
+	stations := []common.Station{
+		{StationUuid: uuid.New(), Name: "Jazz FM"},
+		{StationUuid: uuid.New(), Name: "Talk Radio 24"},
+	}
+	newModel := func(store storage.BlocklistStore) StationsModel {
+		model := NewStationsModel(
+			Theme{},
+			&mocks.MockRadioBrowserService{},
+			&mocks.MockPlaybackManagerService{},
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{},
+			&mocks.MockReportStore{},
+			filter.ContentFilter{},
+			stations,
+			config.DefaultStationColumns(),
+			nil,
+			stationPageKey{},
+			false,
+		)
+		model.SetBlocklistStore(store)
+		return model
+	}
+
+	t.Run("hides the station under the cursor for good, with why", func(t *testing.T) {
+
+		store := blocklistOf()
+		model := newModel(store)
+		model.stationsTable.SetCursor(1)
+
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("H")})
+		assert.True(t, updated.(StationsModel).showBlockStation)
+
+		updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("ads")})
+		updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		updated, cmd = updated.Update(cmd())
+		assert.False(t, updated.(StationsModel).showBlockStation)
+
+		var blocked tea.Msg
+		for _, msg := range collectMsgs(cmd) {
+			if msg, ok := msg.(stationBlockedMsg); ok {
+				blocked = msg
+			}
+		}
+		updated, _ = updated.Update(blocked)
+
+		assert.Equal(t, stations[:1], updated.(StationsModel).stations)
+		saved, ok := store.Get(stations[1].StationUuid)
+		assert.True(t, ok)
+		assert.Equal(t, "ads", saved.Reason)
+		assert.Equal(t, "Talk Radio 24", saved.StationName)
+
+	})
+
+	t.Run("cancels hiding the station", func(t *testing.T) {
+
+		model := newModel(blocklistOf())
+
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("H")})
+		updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		updated, _ = updated.Update(cmd())
+
+		assert.False(t, updated.(StationsModel).showBlockStation)
+		assert.Equal(t, stations, updated.(StationsModel).stations)
+
+	})
+
+	t.Run("leaves blocked stations out of the next page", func(t *testing.T) {
+
+		model := newModel(blocklistOf(storage.BlockedStation{StationUuid: stations[1].StationUuid}))
+
+		model.setStations(withoutBlockedStations(model.blocklist, stations))
+
+		assert.Equal(t, stations[:1], model.stations)
+
+	})
+
+}
+
+func TestBlocklistModel(t *testing.T) {
+
+	blocklist := newTestBlocklist()
+	newLoadedModel := func(store storage.BlocklistStore) BlocklistModel {
+		model := NewBlocklistModel(Theme{}, store)
+		model, _ = model.Update(model.Init()())
+		return model
+	}
+
+	t.Run("lists the hidden stations with why", func(t *testing.T) {
+		view := newLoadedModel(blocklistOf(blocklist...)).View()
+		assert.Contains(t, view, "Talk Radio 24")
+		assert.Contains(t, view, "too many ads")
+		assert.Contains(t, view, "Radio Static")
+		assert.Contains(t, view, "no reason given")
+	})
+
+	t.Run("shows the highlighted station again, which can be undone", func(t *testing.T) {
+		store := blocklistOf(blocklist...)
+		model := newLoadedModel(store)
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+		model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+		assert.Equal(t, blocklist[:1], model.blocklist)
+		undoable := cmd().(undoableMsg)
+		assert.Equal(t, blocklist[:1], store.All())
+
+		model, _ = model.Update(undoable.undo())
+		assert.Equal(t, blocklist, model.blocklist)
+		assert.Len(t, store.All(), 2)
+	})
+
+	t.Run("tells how to hide stations when there are none", func(t *testing.T) {
+		assert.Contains(t, newLoadedModel(blocklistOf()).View(), "No hidden stations")
+	})
+
+}
+
+func TestChartsModel_Blocklist(t *testing.T) {
+
+	blocked := common.Station{StationUuid: uuid.New(), Name: "Talk Radio 24"}
+	other := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+
+	model := NewChartsModel(Theme{}, &mocks.MockRadioBrowserService{}, "GB")
+	model.SetBlocklistStore(blocklistOf(storage.BlockedStation{StationUuid: blocked.StationUuid}))
+	updated, _ := model.Update(chartsFetchedMsg{countryCode: "GB", charts: [][]common.Station{{blocked, other}, {other}}})
+
+	assert.Equal(t, [][]common.Station{{other}, {other}}, updated.(ChartsModel).charts)
+
+}
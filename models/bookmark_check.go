@@ -0,0 +1,197 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"sync"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/icy"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// How many bookmarks are checked at the same time.
+const bookmarkCheckWorkers = 8
+
+// bookmarkCheck is what checking a bookmark found.
+type bookmarkCheck struct {
+	// err tells why its stream can't be played, nil if it's alive
+	err error
+	// replacement is the station as radio-browser lists it now, if it has a new stream URL that plays
+	replacement *common.Station
+}
+
+// Messages
+
+// bookmarksCheckedMsg carries what checking every bookmark found.
+type bookmarksCheckedMsg struct {
+	checks map[uuid.UUID]bookmarkCheck
+}
+
+// bookmarksFixedMsg reports the bookmarks updated with their new stream URL.
+type bookmarksFixedMsg struct {
+	stations []common.Station
+}
+
+// Commands
+
+// checkBookmarksCmd probes the stream of every station, a few at a time,
+// and looks the dead ones up on radio-browser for a new stream URL.
+func checkBookmarksCmd(prober icy.ProberService, browser api.RadioBrowserService, stations []common.Station) tea.Cmd {
+	return func() tea.Msg {
+		checks := make(map[uuid.UUID]bookmarkCheck, len(stations))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		jobs := make(chan common.Station)
+		for i := 0; i < bookmarkCheckWorkers && i < len(stations); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for station := range jobs {
+					check := checkBookmark(prober, browser, station)
+					mu.Lock()
+					checks[station.StationUuid] = check
+					mu.Unlock()
+				}
+			}()
+		}
+		for _, station := range stations {
+			jobs <- station
+		}
+		close(jobs)
+		wg.Wait()
+		return bookmarksCheckedMsg{checks: checks}
+	}
+}
+
+// checkBookmark probes the stream of station. If it's dead, the station is looked up on radio-browser by UUID:
+// its new stream URL, if it has one, is offered as a replacement once it's been probed too.
+func checkBookmark(prober icy.ProberService, browser api.RadioBrowserService, station common.Station) bookmarkCheck {
+	_, err := prober.Probe(station.Url.URL)
+	if err == nil {
+		return bookmarkCheck{}
+	}
+	check := bookmarkCheck{err: err}
+	// Stream URLs opened directly aren't on radio-browser
+	if station.StationUuid == common.NewStationFromURL(station.Url.URL, "").StationUuid {
+		return check
+	}
+	stations, lookupErr := browser.GetStations(common.StationQueryByUuid, station.StationUuid.String(), "votes", false, 0, 1, false)
+	if lookupErr != nil || len(stations) == 0 || stations[0].Url.URL.String() == station.Url.URL.String() {
+		return check
+	}
+	if _, err := prober.Probe(stations[0].Url.URL); err != nil {
+		return check
+	}
+	check.replacement = &stations[0]
+	return check
+}
+
+// fixBookmarksCmd bookmarks stations again, replacing the snapshots with their new stream URL.
+func fixBookmarksCmd(bookmarkStore storage.BookmarkStore, stations []common.Station) tea.Cmd {
+	return func() tea.Msg {
+		for _, station := range stations {
+			if err := bookmarkStore.Add(station); err != nil {
+				return nonFatalError{stopPlayback: false, err: err}
+			}
+		}
+		return bookmarksFixedMsg{stations: stations}
+	}
+}
+
+// Model
+
+// checkBookmarks starts checking every bookmark, unless a check is already running.
+func (m BookmarksModel) checkBookmarks() (tea.Model, tea.Cmd) {
+	if m.checking || len(m.bookmarks) == 0 {
+		return m, nil
+	}
+	m.checking = true
+	return m, tea.Batch(
+		showToastCmd(i18n.Tf("bookmarks.checking", len(m.bookmarks)), toastInfo),
+		checkBookmarksCmd(m.prober, m.browser, m.bookmarks),
+	)
+}
+
+// bookmarksChecked marks the dead bookmarks and tells how many have a new stream URL.
+func (m BookmarksModel) bookmarksChecked(msg bookmarksCheckedMsg) (tea.Model, tea.Cmd) {
+	m.checking = false
+	m.checks = make(map[uuid.UUID]bookmarkCheck)
+	dead, fixable := 0, 0
+	for stationUuid, check := range msg.checks {
+		if check.err == nil {
+			continue
+		}
+		m.checks[stationUuid] = check
+		dead++
+		if check.replacement != nil {
+			fixable++
+		}
+	}
+	m.stationsTable.SetRows(m.rows())
+
+	switch {
+	case dead == 0:
+		return m, showToastCmd(i18n.Tf("bookmarks.allAlive", len(msg.checks)), toastSuccess)
+	case fixable > 0:
+		return m, showToastCmd(i18n.Tf("bookmarks.deadFixable", dead, len(msg.checks), fixable), toastInfo)
+	}
+	return m, showToastCmd(i18n.Tf("bookmarks.deadCount", dead, len(msg.checks)), toastInfo)
+}
+
+// fixBookmarks updates the dead bookmarks that have a new stream URL.
+func (m BookmarksModel) fixBookmarks() (tea.Model, tea.Cmd) {
+	var stations []common.Station
+	for _, station := range m.bookmarks {
+		if check, ok := m.checks[station.StationUuid]; ok && check.replacement != nil {
+			stations = append(stations, *check.replacement)
+		}
+	}
+	if len(stations) == 0 {
+		return m, nil
+	}
+	return m, fixBookmarksCmd(m.bookmarkStore, stations)
+}
+
+// bookmarksFixed lists the updated bookmarks as alive, probing what they're playing.
+func (m BookmarksModel) bookmarksFixed(msg bookmarksFixedMsg) (tea.Model, tea.Cmd) {
+	m.bookmarks = m.bookmarkStore.All()
+	cmds := []tea.Cmd{showToastCmd(i18n.Tf("bookmarks.fixed", len(msg.stations)), toastSuccess)}
+	for _, station := range msg.stations {
+		delete(m.checks, station.StationUuid)
+		m.nowPlaying[station.StationUuid] = nowPlaying{state: nowPlayingProbing}
+		cmds = append(cmds, probeStationTitleCmd(m.prober, m.probeRound, station))
+	}
+	m.arrange()
+	return m, tea.Batch(cmds...)
+}
+
+// String tells why a dead bookmark can't be played, and if it can be fixed.
+func (c bookmarkCheck) String() string {
+	if c.replacement != nil {
+		return i18n.T("bookmarks.deadNewUrl")
+	}
+	return i18n.Tf("bookmarks.dead", c.err)
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/icy"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckBookmarksCmd(t *testing.T) {
+
+	streamUrl := func(raw string) common.RadioGoGoURL {
+		u, _ := url.Parse(raw)
+		return common.RadioGoGoURL{URL: *u}
+	}
+
+	alive := common.Station{StationUuid: uuid.New(), Name: "Alive", Url: streamUrl("http://alive.example.com/live")}
+	moved := common.Station{StationUuid: uuid.New(), Name: "Moved", Url: streamUrl("http://old.example.com/live")}
+	gone := common.Station{StationUuid: uuid.New(), Name: "Gone", Url: streamUrl("http://gone.example.com/live")}
+	direct := common.NewStationFromURL(streamUrl("http://direct.example.com/live").URL, "")
+
+	var mu sync.Mutex
+	var lookedUp []string
+	prober := &mocks.MockProberService{
+		ProbeFunc: func(streamUrl url.URL) (common.StreamInfo, error) {
+			if streamUrl.Host == "alive.example.com" || streamUrl.Host == "new.example.com" {
+				return common.StreamInfo{}, nil
+			}
+			return common.StreamInfo{}, &icy.ProbeError{Kind: icy.ErrStreamUnreachable}
+		},
+	}
+	browser := &mocks.MockRadioBrowserService{
+		GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+			mu.Lock()
+			lookedUp = append(lookedUp, searchTerm)
+			mu.Unlock()
+			switch searchTerm {
+			case moved.StationUuid.String():
+				station := moved
+				station.Url = streamUrl("http://new.example.com/live")
+				return []common.Station{station}, nil
+			case gone.StationUuid.String():
+				return []common.Station{gone}, nil
+			}
+			return []common.Station{}, nil
+		},
+	}
+
+	msg := checkBookmarksCmd(prober, browser, []common.Station{alive, moved, gone, direct})().(bookmarksCheckedMsg)
+
+	assert.Len(t, msg.checks, 4)
+	assert.NoError(t, msg.checks[alive.StationUuid].err)
+	assert.ErrorIs(t, msg.checks[moved.StationUuid].err, icy.ErrStreamUnreachable)
+	if assert.NotNil(t, msg.checks[moved.StationUuid].replacement) {
+		assert.Equal(t, "new.example.com", msg.checks[moved.StationUuid].replacement.Url.URL.Host)
+	}
+	assert.Error(t, msg.checks[gone.StationUuid].err)
+	assert.Nil(t, msg.checks[gone.StationUuid].replacement)
+	assert.Error(t, msg.checks[direct.StationUuid].err)
+	assert.ElementsMatch(t, []string{moved.StationUuid.String(), gone.StationUuid.String()}, lookedUp)
+
+}
+
+func TestBookmarksModel_Check(t *testing.T) {
+
+	u, _ := url.Parse("http://old.example.com/live")
+	alive := common.Station{StationUuid: uuid.New(), Name: "Alive"}
+	moved := common.Station{StationUuid: uuid.New(), Name: "Moved", Url: common.RadioGoGoURL{URL: *u}}
+	replacement := moved
+	replacement.Name = "Moved (new)"
+
+	var added []common.Station
+	bookmarks := []common.Station{alive, moved}
+	model := NewBookmarksModel(
+		Theme{},
+		&mocks.MockRadioBrowserService{},
+		&mocks.MockPlaybackManagerService{},
+		&mocks.MockLabelStore{},
+		&mocks.MockBookmarkStore{
+			AllFunc: func() []common.Station {
+				return bookmarks
+			},
+			AddFunc: func(station common.Station) error {
+				added = append(added, station)
+				bookmarks[1] = station
+				return nil
+			},
+		},
+		filter.ContentFilter{},
+		&mocks.MockProberService{},
+	)
+
+	newModel, _ := model.Update(bookmarksCheckedMsg{checks: map[uuid.UUID]bookmarkCheck{
+		alive.StationUuid: {},
+		moved.StationUuid: {err: errors.New("unreachable"), replacement: &replacement},
+	}})
+	model = newModel.(BookmarksModel)
+
+	rows := model.stationsTable.Rows()
+	assert.Equal(t, "Alive", rows[0][0])
+	assert.Equal(t, "✗ Moved", rows[1][0])
+	assert.True(t, strings.HasPrefix(rows[1][1], "Dead"))
+
+	newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("F")})
+	model = newModel.(BookmarksModel)
+	fixed := cmd().(bookmarksFixedMsg)
+	assert.Equal(t, []common.Station{replacement}, added)
+
+	newModel, _ = model.Update(fixed)
+	model = newModel.(BookmarksModel)
+	assert.Empty(t, model.checks)
+	assert.Equal(t, "Moved (new)", model.stationsTable.Rows()[1][0])
+
+}
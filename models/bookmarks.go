@@ -0,0 +1,972 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/icy"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/secrets"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// What a bookmarked station is currently playing, as far as its metadata tells.
+type nowPlayingState int
+
+const (
+	nowPlayingProbing nowPlayingState = iota
+	nowPlayingKnown
+	nowPlayingUnknown
+	nowPlayingUnreachable
+)
+
+type nowPlaying struct {
+	state nowPlayingState
+	title string
+}
+
+func (n nowPlaying) String() string {
+	switch n.state {
+	case nowPlayingProbing:
+		return i18n.T("bookmarks.probing")
+	case nowPlayingKnown:
+		return n.title
+	case nowPlayingUnreachable:
+		return i18n.T("bookmarks.unreachable")
+	default:
+		return i18n.T("bookmarks.noMetadata")
+	}
+}
+
+// Messages
+
+type stationTitleProbedMsg struct {
+	// Results of an older round of probes are discarded.
+	round       int
+	stationUuid uuid.UUID
+	title       string
+	err         error
+}
+
+type bookmarkRemovedMsg struct {
+	stationUuid uuid.UUID
+	removed     storage.RemovedBookmark
+}
+
+type bookmarkMetaChangedMsg struct {
+	stationUuid uuid.UUID
+	meta        storage.BookmarkMeta
+}
+
+// Commands
+
+func probeStationTitleCmd(prober icy.ProberService, round int, station common.Station) tea.Cmd {
+	return func() tea.Msg {
+		title, err := prober.StreamTitle(station.Url.URL)
+		return stationTitleProbedMsg{
+			round:       round,
+			stationUuid: station.StationUuid,
+			title:       title,
+			err:         err,
+		}
+	}
+}
+
+func removeBookmarkCmd(bookmarkStore storage.BookmarkStore, stationUuid uuid.UUID) tea.Cmd {
+	return func() tea.Msg {
+		removed, err := bookmarkStore.Remove(stationUuid)
+		if err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return bookmarkRemovedMsg{stationUuid: stationUuid, removed: removed}
+	}
+}
+
+func setBookmarkMetaCmd(bookmarkStore storage.BookmarkStore, stationUuid uuid.UUID, meta storage.BookmarkMeta) tea.Cmd {
+	return func() tea.Msg {
+		err := bookmarkStore.SetMeta(stationUuid, meta)
+		if err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return bookmarkMetaChangedMsg{stationUuid: stationUuid, meta: meta}
+	}
+}
+
+func updateCommandsForBookmarks(isPlaying bool) tea.Cmd {
+	return func() tea.Msg {
+		commands := []string{
+			i18n.T("commands.quit"),
+			i18n.T("commands.back"),
+			i18n.T("commands.play"),
+			i18n.T("commands.move"),
+			i18n.T("commands.refresh"),
+			i18n.T("commands.removeBookmark"),
+			i18n.T("commands.copy"),
+			i18n.T("commands.commandLine"),
+			i18n.T("commands.help"),
+		}
+		if isPlaying {
+			commands = append(commands, i18n.T("commands.stop"))
+		}
+		return bottomBarUpdateMsg{commands: commands}
+	}
+}
+
+// Model
+
+// BookmarksModel lists the bookmarked stations along with what each one is currently playing.
+// Bookmarks can be filed in folders, which are listed before the bookmarks outside of any,
+// and tagged, to list the bookmarks with a given tag wherever they're filed.
+type BookmarksModel struct {
+	theme Theme
+
+	bookmarks []common.Station
+	meta      map[uuid.UUID]storage.BookmarkMeta
+	// The folder opened, empty for the top level
+	folder string
+	// The tag bookmarks are listed by, empty for none
+	tagFilter string
+	// The folders listed before the stations, at the top level only
+	folders []string
+	// The order the bookmarks are listed in, and how much each station was listened to
+	order     bookmarkOrder
+	playStats map[uuid.UUID]storage.PlayStats
+	// The bookmarks listed, in the folder opened or with the tag filtered by
+	stations   []common.Station
+	nowPlaying map[uuid.UUID]nowPlaying
+	probeRound int
+	// What the last check found about the dead bookmarks, and whether one is running
+	checks                map[uuid.UUID]bookmarkCheck
+	checking              bool
+	stationsTable         table.Model
+	currentStation        common.Station
+	currentStream         common.StreamInfo
+	currentStationSpinner spinner.Model
+	bufferingStation      *common.Station
+	width                 int
+	height                int
+	commandLine           CommandLineModel
+	showCommandLine       bool
+	lastFind              string
+	pendingG              bool
+	// bandwidth is shown next to the station being played, if metered.
+	bandwidth *bandwidthUsage
+	// levels are shown as a VU meter next to the station being played, if measured.
+	levels *playback.Levels
+	// clock tells how long the station being played and all of them have been listened to.
+	clock *listeningClock
+	// delay tells how far behind the broadcast the station being played is heard.
+	delay *streamDelay
+
+	browser         api.RadioBrowserService
+	playbackManager playback.PlaybackManagerService
+	labelStore      storage.LabelStore
+	bookmarkStore   storage.BookmarkStore
+	// Remembers the clicks sent to radio-browser (nil always sends them)
+	interactions storage.InteractionStore
+	// Sends the clicks, again later if radio-browser can't be reached
+	actions *api.ActionQueue
+	// Remembers how much louder or quieter each station plays (nil doesn't trim them)
+	volumeTrims   storage.VolumeTrimStore
+	contentFilter filter.ContentFilter
+	prober        icy.ProberService
+	// Keeps the credentials of private streams (nil plays them as they are)
+	credentials secrets.Store
+	// Remembers which URL of each station played, to try it first (nil always tries the resolved URL first)
+	streamVariants storage.StreamVariantStore
+	// Keeps the headers sent for the streams of bookmarks (nil sends none)
+	streamHeaders storage.StreamHeaderStore
+	// Keeps how each bookmark is played, changed with ":prefs" (nil plays them as configured)
+	stationPrefs    storage.StationPrefsStore
+	copyToClipboard func(text string) error
+	// Opens homepages
+	openURL func(url string) error
+	// Starts the external player, run by the externalPlayer command template
+	startProgram   func(name string, args ...string) error
+	externalPlayer string
+}
+
+func NewBookmarksModel(
+	theme Theme,
+	browser api.RadioBrowserService,
+	playbackManager playback.PlaybackManagerService,
+	labelStore storage.LabelStore,
+	bookmarkStore storage.BookmarkStore,
+	contentFilter filter.ContentFilter,
+	prober icy.ProberService,
+) BookmarksModel {
+
+	t := table.New(
+		table.WithColumns(bookmarksTableColumns(0)),
+		table.WithFocused(true),
+	)
+	t.SetStyles(theme.StationsTableStyle)
+	// "gg" jumps to the first bookmark, see BookmarksModel.Update
+	t.KeyMap.GotoTop.SetKeys("home")
+
+	m := BookmarksModel{
+		theme:           theme,
+		bookmarks:       bookmarkStore.All(),
+		meta:            make(map[uuid.UUID]storage.BookmarkMeta),
+		nowPlaying:      make(map[uuid.UUID]nowPlaying),
+		checks:          make(map[uuid.UUID]bookmarkCheck),
+		stationsTable:   t,
+		browser:         browser,
+		actions:         api.NewActionQueue(browser),
+		playbackManager: playbackManager,
+		labelStore:      labelStore,
+		bookmarkStore:   bookmarkStore,
+		contentFilter:   contentFilter,
+		prober:          prober,
+		copyToClipboard: common.CopyToClipboard,
+		openURL:         common.OpenURL,
+		startProgram:    common.StartDetached,
+	}
+	for _, station := range m.bookmarks {
+		m.meta[station.StationUuid] = bookmarkStore.Meta(station.StationUuid)
+	}
+	m.arrange()
+	m.startProbeRound()
+
+	return m
+}
+
+func bookmarksTableColumns(width int) []table.Column {
+	nowPlayingWidth := width - 78
+	if nowPlayingWidth < 30 {
+		nowPlayingWidth = 30
+	}
+	return []table.Column{
+		{Title: i18n.T("stations.column.name"), Width: 30},
+		{Title: i18n.T("bookmarks.column.nowPlaying"), Width: nowPlayingWidth},
+		{Title: i18n.T("bookmarks.column.tags"), Width: 20},
+		{Title: i18n.T("bookmarks.column.listened"), Width: 18},
+	}
+}
+
+func (m BookmarksModel) rows() []table.Row {
+	columns := bookmarksTableColumns(m.width)
+	rows := make([]table.Row, 0, len(m.folders)+len(m.stations))
+	for _, folder := range m.folders {
+		rows = append(rows, fitRow(table.Row{
+			"▸ " + folder,
+			i18n.Tf("bookmarks.folderCount", m.folderCount(folder)),
+			"",
+			"",
+		}, columns))
+	}
+	for _, station := range m.stations {
+		name := stationDisplayName(m.labelStore, station)
+		status := m.nowPlaying[station.StationUuid].String()
+		if check, ok := m.checks[station.StationUuid]; ok {
+			name = "✗ " + name
+			status = check.String()
+		}
+		rows = append(rows, fitRow(table.Row{
+			name,
+			status,
+			strings.Join(m.meta[station.StationUuid].Tags, ", "),
+			formatPlayStats(m.playStats[station.StationUuid]),
+		}, columns))
+	}
+	return rows
+}
+
+// arrange lists the folders and bookmarks to show, in the folder opened or with the tag filtered by.
+func (m *BookmarksModel) arrange() {
+	m.folders = nil
+	m.stations = nil
+
+	seen := make(map[string]bool)
+	for _, station := range m.bookmarks {
+		meta := m.meta[station.StationUuid]
+		switch {
+		case m.tagFilter != "":
+			if meta.HasTag(m.tagFilter) {
+				m.stations = append(m.stations, station)
+			}
+		case meta.Folder == m.folder:
+			m.stations = append(m.stations, station)
+		case m.folder == "" && !seen[meta.Folder]:
+			seen[meta.Folder] = true
+			m.folders = append(m.folders, meta.Folder)
+		}
+	}
+	sort.Slice(m.folders, func(i, j int) bool {
+		return strings.ToLower(m.folders[i]) < strings.ToLower(m.folders[j])
+	})
+	sortBookmarks(m.stations, m.order, m.labelStore, m.playStats)
+
+	m.stationsTable.SetRows(m.rows())
+	if rows := len(m.folders) + len(m.stations); m.stationsTable.Cursor() >= rows && rows > 0 {
+		m.stationsTable.SetCursor(rows - 1)
+	}
+}
+
+// folderCount returns how many bookmarks are filed in folder.
+func (m BookmarksModel) folderCount(folder string) int {
+	count := 0
+	for _, station := range m.bookmarks {
+		if m.meta[station.StationUuid].Folder == folder {
+			count++
+		}
+	}
+	return count
+}
+
+// selectedFolder returns the folder under the cursor, if the cursor is on a folder.
+func (m BookmarksModel) selectedFolder() (string, bool) {
+	index := m.stationsTable.Cursor()
+	if index < 0 || index >= len(m.folders) {
+		return "", false
+	}
+	return m.folders[index], true
+}
+
+// selectedStation returns the bookmark under the cursor, if the cursor is on a bookmark.
+func (m BookmarksModel) selectedStation() (common.Station, bool) {
+	index := m.stationsTable.Cursor() - len(m.folders)
+	if index < 0 || index >= len(m.stations) {
+		return common.Station{}, false
+	}
+	return m.stations[index], true
+}
+
+// openFolder lists the bookmarks filed in folder, or the top level if empty.
+func (m BookmarksModel) openFolder(folder string) BookmarksModel {
+	previous := m.folder
+	m.folder = folder
+	m.tagFilter = ""
+	m.stationsTable.SetCursor(0)
+	m.arrange()
+	// Back at the top level, the cursor goes back to the folder just left
+	for i, f := range m.folders {
+		if previous != "" && f == previous {
+			m.stationsTable.SetCursor(i)
+		}
+	}
+	return m
+}
+
+// filterByTag lists the bookmarks tagged with tag wherever they're filed, or the folder opened if empty.
+func (m BookmarksModel) filterByTag(tag string) BookmarksModel {
+	m.tagFilter = tag
+	m.stationsTable.SetCursor(0)
+	m.arrange()
+	return m
+}
+
+// startProbeRound discards the titles probed so far and starts a new round of probes,
+// whose results are then fetched by probeCmd.
+func (m *BookmarksModel) startProbeRound() {
+	m.probeRound++
+	for _, station := range m.bookmarks {
+		m.nowPlaying[station.StationUuid] = nowPlaying{state: nowPlayingProbing}
+	}
+	m.stationsTable.SetRows(m.rows())
+}
+
+// probeCmd probes every bookmarked station for the current round.
+// The prober itself limits how many of them run at the same time.
+func (m BookmarksModel) probeCmd() tea.Cmd {
+	cmds := make([]tea.Cmd, len(m.bookmarks))
+	for i, station := range m.bookmarks {
+		cmds[i] = probeStationTitleCmd(m.prober, m.probeRound, station)
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *BookmarksModel) startSpinner() tea.Cmd {
+	m.currentStationSpinner = spinner.New()
+	m.currentStationSpinner.Spinner = spinner.Dot
+	m.currentStationSpinner.Style = m.theme.PrimaryText
+	return m.currentStationSpinner.Tick
+}
+
+// Bubbletea
+
+func (m BookmarksModel) Init() tea.Cmd {
+	return tea.Batch(m.probeCmd(), updateCommandsForBookmarks(false))
+}
+
+func (m BookmarksModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case stationTitleProbedMsg:
+		if msg.round != m.probeRound {
+			return m, nil
+		}
+		switch {
+		case errors.Is(msg.err, icy.ErrNoMetadata):
+			m.nowPlaying[msg.stationUuid] = nowPlaying{state: nowPlayingUnknown}
+		case msg.err != nil:
+			m.nowPlaying[msg.stationUuid] = nowPlaying{state: nowPlayingUnreachable}
+		case msg.title == "":
+			m.nowPlaying[msg.stationUuid] = nowPlaying{state: nowPlayingUnknown}
+		default:
+			m.nowPlaying[msg.stationUuid] = nowPlaying{state: nowPlayingKnown, title: msg.title}
+		}
+		m.stationsTable.SetRows(m.rows())
+		return m, nil
+	case bookmarkRemovedMsg:
+		var undoable tea.Cmd
+		for i, station := range m.bookmarks {
+			if station.StationUuid == msg.stationUuid {
+				m.bookmarks = append(m.bookmarks[:i], m.bookmarks[i+1:]...)
+				undoable = bookmarkRemovedUndoableCmd(m.bookmarkStore, msg.removed, stationDisplayName(m.labelStore, station))
+				break
+			}
+		}
+		delete(m.nowPlaying, msg.stationUuid)
+		delete(m.meta, msg.stationUuid)
+		delete(m.checks, msg.stationUuid)
+		m.arrange()
+		return m, undoable
+	case reconnectStationMsg:
+		if m.bufferingStation != nil {
+			return m, nil
+		}
+		return m.playStation(msg.station)
+	case bookmarkRestoredMsg:
+		stationUuid := msg.station.StationUuid
+		m.bookmarks = m.bookmarkStore.All()
+		m.meta[stationUuid] = m.bookmarkStore.Meta(stationUuid)
+		m.nowPlaying[stationUuid] = nowPlaying{state: nowPlayingProbing}
+		m.arrange()
+		return m, probeStationTitleCmd(m.prober, m.probeRound, msg.station)
+	case bookmarksCheckedMsg:
+		return m.bookmarksChecked(msg)
+	case bookmarksFixedMsg:
+		return m.bookmarksFixed(msg)
+	case bookmarkMetaChangedMsg:
+		m.meta[msg.stationUuid] = msg.meta
+		m.arrange()
+		return m, nil
+	case playbackStartedMsg:
+		m.bufferingStation = nil
+		m.currentStation = msg.station
+		m.currentStream = msg.stream
+		return m, tea.Batch(
+			m.startSpinner(),
+			notifyRadioBrowserCmd(m.actions, m.interactions, m.currentStation),
+			updateCommandsForBookmarks(true),
+		)
+	case externalPlayerStartedMsg:
+		return m, externalPlayerStarted(m.playbackManager.IsPlaying(), stopStationCmd(m.playbackManager), msg)
+	case playbackStoppedMsg:
+		m.currentStation = common.Station{}
+		m.currentStream = common.StreamInfo{}
+		m.currentStationSpinner = spinner.Model{}
+		return m, updateCommandsForBookmarks(false)
+	case nonFatalError:
+		m.bufferingStation = nil
+		return m, nil
+	case closeCommandLineMsg:
+		m.showCommandLine = false
+		return m, updateCommandsForBookmarks(m.playbackManager.IsPlaying())
+	case commandLineSubmittedMsg:
+		m.showCommandLine = false
+		restoreCommands := updateCommandsForBookmarks(m.playbackManager.IsPlaying())
+		if msg.mode == findMode {
+			newModel, cmd := m.find(msg.line)
+			return newModel, tea.Batch(restoreCommands, cmd)
+		}
+		c, err := parseCommand(msg.line)
+		if err != nil {
+			return m, tea.Batch(restoreCommands, nonFatalErrorCmd(err))
+		}
+		newModel, cmd := m.runCommand(c)
+		return newModel, tea.Batch(restoreCommands, cmd)
+	case tea.KeyMsg:
+		if m.showCommandLine {
+			newCommandLine, cmd := m.commandLine.Update(msg)
+			m.commandLine = newCommandLine
+			return m, cmd
+		}
+		pendingG := m.pendingG
+		m.pendingG = false
+		switch msg.String() {
+		case "g":
+			if pendingG {
+				m.stationsTable.GotoTop()
+			} else {
+				m.pendingG = true
+			}
+			return m, nil
+		case ":":
+			return m.openCommandLine(commandMode)
+		case "/":
+			return m.openCommandLine(findMode)
+		case "q":
+			return m, stopAndQuitCmd
+		case "o":
+			return m, openURLCmd
+		case "?", "f1":
+			return m, showHelpCmd
+		case "esc":
+			if m.tagFilter != "" {
+				return m.filterByTag(""), nil
+			}
+			if m.folder != "" {
+				return m.openFolder(""), nil
+			}
+			return m, tea.Sequence(
+				stopStationCmd(m.playbackManager),
+				func() tea.Msg {
+					return switchToSearchModelMsg{}
+				},
+			)
+		case "ctrl+k":
+			return m, stopStationCmd(m.playbackManager)
+		case "r":
+			m.startProbeRound()
+			return m, m.probeCmd()
+		case "s":
+			return m.sortBy(m.order.next())
+		case "c":
+			return m.checkBookmarks()
+		case "F":
+			return m.fixBookmarks()
+		case "L":
+			return m, likeTrackCmd
+		case "d":
+			station, ok := m.selectedStation()
+			if !ok {
+				return m, nil
+			}
+			return m, removeBookmarkCmd(m.bookmarkStore, station.StationUuid)
+		case "y", "Y":
+			station, ok := m.selectedStation()
+			if !ok {
+				return m, nil
+			}
+			return m, copyStationCmd(m.copyToClipboard, station, msg.String() == "Y")
+		case "w":
+			return m.openSelectedHomepage()
+		case "e":
+			return m.playSelectedExternally()
+		case "u":
+			return m, undoCmd
+		case "(":
+			return m.trimVolume(-volumeTrimStep)
+		case ")":
+			return m.trimVolume(volumeTrimStep)
+		case "enter":
+			if folder, ok := m.selectedFolder(); ok {
+				return m.openFolder(folder), nil
+			}
+			return m.playSelectedStation()
+		}
+	}
+
+	if m.playbackManager.IsPlaying() || m.bufferingStation != nil {
+		newSpinner, cmd := m.currentStationSpinner.Update(msg)
+		m.currentStationSpinner = newSpinner
+		cmds = append(cmds, cmd)
+	}
+
+	newStationsTable, cmd := m.stationsTable.Update(msg)
+	m.stationsTable = newStationsTable
+	cmds = append(cmds, cmd)
+
+	return m, tea.Batch(cmds...)
+}
+
+// playSelectedStation starts buffering the bookmark under the cursor.
+func (m BookmarksModel) playSelectedStation() (tea.Model, tea.Cmd) {
+	station, ok := m.selectedStation()
+	if !ok || m.bufferingStation != nil {
+		return m, nil
+	}
+	return m.playStation(station)
+}
+
+// playStation starts buffering station.
+func (m BookmarksModel) playStation(station common.Station) (tea.Model, tea.Cmd) {
+	m.bufferingStation = &station
+	return m, tea.Batch(
+		m.startSpinner(),
+		playStationCmd(m.playbackManager, m.contentFilter, m.prober, m.credentials, m.streamVariants, withStationPrefs(m.stationPrefs, withStreamHeaders(m.streamHeaders, station)), trimmedVolume(m.volumeTrims, m.playbackManager, station, m.playbackManager.VolumeDefault())),
+	)
+}
+
+// changeStationPrefs changes the preferences of the bookmark being played as asked by ":prefs",
+// or of the one under the cursor when none is.
+func (m BookmarksModel) changeStationPrefs(c command) (tea.Model, tea.Cmd) {
+	station := m.currentStation
+	if !m.playbackManager.IsPlaying() {
+		var ok bool
+		if station, ok = m.selectedStation(); !ok {
+			return m, nil
+		}
+	}
+	return m, stationPrefsCmd(m.stationPrefs, m.volumeTrims, station, stationDisplayName(m.labelStore, station), c)
+}
+
+// trimVolume trims the volume of the bookmark being played by delta, or of the one under the cursor
+// when none is.
+func (m BookmarksModel) trimVolume(delta int) (tea.Model, tea.Cmd) {
+	if m.volumeTrims == nil {
+		return m, nil
+	}
+	isPlaying := m.playbackManager.IsPlaying()
+	station := m.currentStation
+	if !isPlaying {
+		var ok bool
+		if station, ok = m.selectedStation(); !ok {
+			return m, nil
+		}
+	}
+	name := stationDisplayName(m.labelStore, station)
+	return m, trimVolumeCmd(m.volumeTrims, m.playbackManager, station, name, m.playbackManager.VolumeDefault(), delta, isPlaying)
+}
+
+// openCommandLine shows the command line in place of the status bar.
+func (m BookmarksModel) openCommandLine(mode commandLineMode) (tea.Model, tea.Cmd) {
+	m.commandLine = NewCommandLineModel(m.theme, mode)
+	m.showCommandLine = true
+	return m, m.commandLine.Init()
+}
+
+// find moves the cursor to the next bookmark matching text, or matching the last text searched if empty.
+func (m BookmarksModel) find(text string) (tea.Model, tea.Cmd) {
+	if text == "" {
+		text = m.lastFind
+	}
+	if text == "" || len(m.stations) == 0 {
+		return m, nil
+	}
+	m.lastFind = text
+	from := m.stationsTable.Cursor() - len(m.folders)
+	if from < 0 {
+		from = len(m.stations) - 1
+	}
+	index, ok := findStation(m.stations, m.labelStore, from, text)
+	if !ok {
+		return m, nonFatalErrorCmd(errors.New(i18n.Tf("find.notFound", text)))
+	}
+	m.stationsTable.SetCursor(len(m.folders) + index)
+	return m, nil
+}
+
+// runCommand runs a command typed in command mode.
+func (m BookmarksModel) runCommand(c command) (tea.Model, tea.Cmd) {
+	switch c.name {
+	case "play":
+		if len(c.args) > 0 {
+			index, err := c.stationNumber(0, len(m.stations))
+			if err != nil {
+				return m, nonFatalErrorCmd(err)
+			}
+			m.stationsTable.SetCursor(len(m.folders) + index)
+		}
+		return m.playSelectedStation()
+	case "stop":
+		return m, stopStationCmd(m.playbackManager)
+	case "remove":
+		station, ok := m.selectedStation()
+		if !ok {
+			return m, nil
+		}
+		return m, removeBookmarkCmd(m.bookmarkStore, station.StationUuid)
+	case "refresh":
+		m.startProbeRound()
+		return m, m.probeCmd()
+	case "check":
+		return m.checkBookmarks()
+	case "fix":
+		return m.fixBookmarks()
+	case "prefs":
+		return m.changeStationPrefs(c)
+	case "copy":
+		station, ok := m.selectedStation()
+		if !ok {
+			return m, nil
+		}
+		return m, copyCommandCmd(m.copyToClipboard, station, c)
+	case "homepage":
+		return m.openSelectedHomepage()
+	case "external":
+		return m.playSelectedExternally()
+	case "like":
+		return m, likeTrackCmd
+	case "liked":
+		return m, showLikedTracksCmd
+	case "hidden":
+		return m, showBlocklistCmd
+	case "folder":
+		station, ok := m.selectedStation()
+		if !ok {
+			return m, nil
+		}
+		meta := m.meta[station.StationUuid]
+		meta.Folder = strings.Join(c.args, " ")
+		return m, setBookmarkMetaCmd(m.bookmarkStore, station.StationUuid, meta)
+	case "tag", "untag":
+		station, ok := m.selectedStation()
+		if !ok {
+			return m, nil
+		}
+		if len(c.args) == 0 {
+			return m, nonFatalErrorCmd(errors.New(i18n.Tf("command.usage", c.name+" <tag>...")))
+		}
+		meta := m.meta[station.StationUuid]
+		if c.name == "tag" {
+			meta.Tags = addTags(meta.Tags, c.args)
+		} else {
+			meta.Tags = removeTags(meta.Tags, c.args)
+		}
+		return m, setBookmarkMetaCmd(m.bookmarkStore, station.StationUuid, meta)
+	case "sort":
+		if len(c.args) == 0 {
+			return m.sortBy(m.order.next())
+		}
+		order, err := parseBookmarkOrder(strings.Join(c.args, " "))
+		if err != nil {
+			return m, nonFatalErrorCmd(err)
+		}
+		return m.sortBy(order)
+	case "tagged":
+		if len(c.args) > 1 {
+			return m, nonFatalErrorCmd(errors.New(i18n.Tf("command.usage", "tagged [tag]")))
+		}
+		return m.filterByTag(strings.Join(c.args, "")), nil
+	case "theme":
+		return m, themeCmd(c)
+	case "search":
+		return m, tea.Sequence(
+			stopStationCmd(m.playbackManager),
+			func() tea.Msg {
+				return switchToSearchModelMsg{}
+			},
+		)
+	case "quit":
+		return m, stopAndQuitCmd
+	}
+	return m, nonFatalErrorCmd(unknownCommandError(c))
+}
+
+// sortBy lists the bookmarks in the given order, keeping the cursor on the bookmark it was on.
+func (m BookmarksModel) sortBy(order bookmarkOrder) (tea.Model, tea.Cmd) {
+	selected, ok := m.selectedStation()
+	m.order = order
+	m.arrange()
+	for i, station := range m.stations {
+		if ok && station.StationUuid == selected.StationUuid {
+			m.stationsTable.SetCursor(len(m.folders) + i)
+		}
+	}
+	return m, func() tea.Msg {
+		return bookmarkOrderChangedMsg{order: order}
+	}
+}
+
+// addTags returns tags followed by those of added it doesn't have yet, ignoring case.
+func addTags(tags []string, added []string) []string {
+	result := append([]string(nil), tags...)
+	for _, tag := range added {
+		if !(storage.BookmarkMeta{Tags: result}).HasTag(tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// removeTags returns tags without those of removed, ignoring case.
+func removeTags(tags []string, removed []string) []string {
+	var result []string
+	for _, tag := range tags {
+		if !(storage.BookmarkMeta{Tags: removed}).HasTag(tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// SetPlayStatsStore shows how much each bookmark was listened to, which they can be sorted by (nil doesn't).
+func (m *BookmarksModel) SetPlayStatsStore(playStats storage.PlayStatsStore) {
+	m.playStats = nil
+	if playStats != nil {
+		m.playStats = playStats.All()
+	}
+	m.arrange()
+}
+
+// SetOrder lists the bookmarks in the given order.
+func (m *BookmarksModel) SetOrder(order bookmarkOrder) {
+	m.order = order
+	m.arrange()
+}
+
+// SetBandwidthUsage shows the bitrate and data used next to the station being played (nil hides them).
+func (m *BookmarksModel) SetBandwidthUsage(usage *bandwidthUsage) {
+	m.bandwidth = usage
+}
+
+// SetLevels shows a VU meter of levels next to the station being played (nil hides it).
+func (m *BookmarksModel) SetLevels(levels *playback.Levels) {
+	m.levels = levels
+}
+
+// SetListeningClock shows how long the station being played and all of them have been listened to (nil hides it).
+func (m *BookmarksModel) SetListeningClock(clock *listeningClock) {
+	m.clock = clock
+}
+
+// SetStreamDelay shows how far behind the broadcast the station being played is heard (nil hides it).
+func (m *BookmarksModel) SetStreamDelay(delay *streamDelay) {
+	m.delay = delay
+}
+
+// SetActionQueue sends the clicks with actions, shared so that those queued while radio-browser
+// can't be reached are all sent again together.
+func (m *BookmarksModel) SetActionQueue(actions *api.ActionQueue) {
+	m.actions = actions
+}
+
+// SetInteractionStore remembers the clicks sent to radio-browser in store,
+// so that replaying a bookmark doesn't count it again during the cooldown (nil always sends them).
+func (m *BookmarksModel) SetInteractionStore(store storage.InteractionStore) {
+	m.interactions = store
+}
+
+// SetTheme changes the theme of the view.
+func (m *BookmarksModel) SetTheme(theme Theme) {
+	m.theme = theme
+	m.stationsTable.SetStyles(theme.StationsTableStyle)
+	m.currentStationSpinner.Style = theme.PrimaryText
+}
+
+func (m BookmarksModel) View() string {
+
+	if len(m.bookmarks) == 0 {
+		if m.theme.Accessible {
+			return "\n" + i18n.T("bookmarks.empty") + "\n"
+		}
+		return "\n" + m.theme.SecondaryText.Bold(true).Render(i18n.T("bookmarks.empty")) + "\n"
+	}
+
+	if m.theme.Accessible {
+		return m.accessibleView()
+	}
+
+	v := m.theme.SecondaryText.Bold(true).Render(m.location()) + "\n" + m.stationsTable.View() + "\n"
+
+	if m.showCommandLine {
+		v += m.commandLine.View()
+	} else if m.bufferingStation != nil {
+		v += fitLine(m.currentStationSpinner.View()+
+			m.theme.SecondaryText.Bold(true).Render(i18n.Tf("stations.buffering", displayText(stationDisplayName(m.labelStore, *m.bufferingStation)))), m.width)
+	} else if m.playbackManager.IsPlaying() {
+		v += fitLine(m.currentStationSpinner.View()+
+			m.theme.SecondaryText.Bold(true).Render(i18n.Tf("stations.listeningTo", displayText(stationDisplayName(m.labelStore, m.currentStation)))+m.clock.suffix()+m.delay.suffix()+streamSuffix(m.currentStream)+m.bandwidth.suffix())+
+			levelMeter(m.theme, m.levels), m.width)
+	} else {
+		v += m.theme.PrimaryText.Bold(true).Render(i18n.T("bookmarks.pick"))
+	}
+
+	return v
+}
+
+// location tells which folder is opened or which tag bookmarks are listed by, and the order they're listed in
+// unless it's the order they were added in. It's empty at the top level, in that order.
+func (m BookmarksModel) location() string {
+	var parts []string
+	switch {
+	case m.tagFilter != "":
+		parts = append(parts, i18n.Tf("bookmarks.tagged", m.tagFilter))
+	case m.folder != "":
+		parts = append(parts, i18n.Tf("bookmarks.folder", m.folder))
+	}
+	if m.order != bookmarkOrderAdded {
+		parts = append(parts, i18n.Tf("bookmarks.sortedBy", m.order))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// accessibleView renders the folders and the bookmarks as plain lines, bookmarks being numbered,
+// followed by the playback state.
+func (m BookmarksModel) accessibleView() string {
+
+	cursor := m.stationsTable.Cursor()
+
+	v := m.location() + "\n"
+	for i, folder := range m.folders {
+		marker := "    "
+		if i == cursor {
+			marker = ">>> "
+		}
+		v += fmt.Sprintf("%s[%s] %s\n", marker, folder, i18n.Tf("bookmarks.folderCount", m.folderCount(folder)))
+	}
+	for i, station := range m.stations {
+		marker := "    "
+		if len(m.folders)+i == cursor {
+			marker = ">>> "
+		}
+		v += fmt.Sprintf(
+			"%s%d. %s | %s",
+			marker,
+			i+1,
+			stationDisplayName(m.labelStore, station),
+			m.nowPlaying[station.StationUuid].String(),
+		)
+		if tags := m.meta[station.StationUuid].Tags; len(tags) > 0 {
+			v += " | " + strings.Join(tags, ", ")
+		}
+		v += "\n"
+	}
+
+	if m.showCommandLine {
+		v += m.commandLine.View()
+	} else if m.bufferingStation != nil {
+		v += i18n.Tf("accessible.buffering", stationDisplayName(m.labelStore, *m.bufferingStation))
+	} else if m.playbackManager.IsPlaying() {
+		v += i18n.Tf("accessible.playing", stationDisplayName(m.labelStore, m.currentStation))
+	} else {
+		v += i18n.T("accessible.stopped")
+	}
+
+	return v
+}
+
+func (m *BookmarksModel) SetWidthAndHeight(width int, height int) {
+	m.width = width
+	m.height = height
+	m.stationsTable.SetColumns(bookmarksTableColumns(width))
+	// The now playing column is as wide as the terminal allows
+	m.stationsTable.SetRows(m.rows())
+	m.stationsTable.SetWidth(width)
+	m.stationsTable.SetHeight(tableHeight(height))
+}
@@ -0,0 +1,404 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/icy"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBookmarksTestModel(stations []common.Station, prober *mocks.MockProberService) BookmarksModel {
+	return NewBookmarksModel(
+		Theme{},
+		&mocks.MockRadioBrowserService{},
+		&mocks.MockPlaybackManagerService{},
+		&mocks.MockLabelStore{},
+		&mocks.MockBookmarkStore{
+			AllFunc: func() []common.Station {
+				return stations
+			},
+		},
+		filter.ContentFilter{},
+		prober,
+	)
+}
+
+func TestBookmarksModel_Init(t *testing.T) {
+
+	t.Run("probes every bookmarked station", func(t *testing.T) {
+
+		streamUrl, _ := url.Parse("http://example.com/stream")
+		stations := []common.Station{
+			{StationUuid: uuid.New(), Name: "One", Url: common.RadioGoGoURL{URL: *streamUrl}},
+			{StationUuid: uuid.New(), Name: "Two", Url: common.RadioGoGoURL{URL: *streamUrl}},
+		}
+
+		prober := mocks.MockProberService{
+			StreamTitleFunc: func(streamUrl url.URL) (string, error) {
+				return "Artist - Title", nil
+			},
+		}
+
+		model := newBookmarksTestModel(stations, &prober)
+
+		var batchMsg tea.BatchMsg = model.Init()().(tea.BatchMsg)
+		var probesMsg tea.BatchMsg = batchMsg[0]().(tea.BatchMsg)
+
+		probed := map[uuid.UUID]string{}
+		for _, cmd := range probesMsg {
+			msg := cmd().(stationTitleProbedMsg)
+			probed[msg.stationUuid] = msg.title
+		}
+
+		assert.Equal(t, map[uuid.UUID]string{
+			stations[0].StationUuid: "Artist - Title",
+			stations[1].StationUuid: "Artist - Title",
+		}, probed)
+
+	})
+
+}
+
+func TestBookmarksModel_Update(t *testing.T) {
+
+	station := common.Station{StationUuid: uuid.New(), Name: "One"}
+
+	t.Run("shows the probed title of a station", func(t *testing.T) {
+
+		model := newBookmarksTestModel([]common.Station{station}, &mocks.MockProberService{})
+
+		newModel, _ := model.Update(stationTitleProbedMsg{
+			round:       model.probeRound,
+			stationUuid: station.StationUuid,
+			title:       "Artist - Title",
+		})
+
+		assert.Equal(t, "Artist - Title", newModel.(BookmarksModel).stationsTable.Rows()[0][1])
+
+	})
+
+	t.Run("distinguishes stations without metadata from unreachable ones", func(t *testing.T) {
+
+		model := newBookmarksTestModel([]common.Station{station}, &mocks.MockProberService{})
+
+		newModel, _ := model.Update(stationTitleProbedMsg{
+			round:       model.probeRound,
+			stationUuid: station.StationUuid,
+			err:         icy.ErrNoMetadata,
+		})
+		assert.Equal(t, "No track information", newModel.(BookmarksModel).stationsTable.Rows()[0][1])
+
+		newModel, _ = model.Update(stationTitleProbedMsg{
+			round:       model.probeRound,
+			stationUuid: station.StationUuid,
+			err:         errors.New("connection refused"),
+		})
+		assert.Equal(t, "Unreachable", newModel.(BookmarksModel).stationsTable.Rows()[0][1])
+
+	})
+
+	t.Run("discards results from an older round of probes", func(t *testing.T) {
+
+		model := newBookmarksTestModel([]common.Station{station}, &mocks.MockProberService{})
+
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+		newModel, _ = newModel.Update(stationTitleProbedMsg{
+			round:       model.probeRound,
+			stationUuid: station.StationUuid,
+			title:       "Stale",
+		})
+
+		assert.Equal(t, "Checking...", newModel.(BookmarksModel).stationsTable.Rows()[0][1])
+
+	})
+
+	t.Run("removes the selected bookmark when 'd' is pressed", func(t *testing.T) {
+
+		var removed uuid.UUID
+
+		model := NewBookmarksModel(
+			Theme{},
+			&mocks.MockRadioBrowserService{},
+			&mocks.MockPlaybackManagerService{},
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{
+				AllFunc: func() []common.Station {
+					return []common.Station{station}
+				},
+				RemoveFunc: func(stationUuid uuid.UUID) (storage.RemovedBookmark, error) {
+					removed = stationUuid
+					return storage.RemovedBookmark{}, nil
+				},
+			},
+			filter.ContentFilter{},
+			&mocks.MockProberService{},
+		)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+		assert.NotNil(t, cmd)
+
+		msg := cmd()
+		assert.Equal(t, bookmarkRemovedMsg{stationUuid: station.StationUuid}, msg)
+		assert.Equal(t, station.StationUuid, removed)
+
+		newModel, cmd := model.Update(msg)
+		assert.Empty(t, newModel.(BookmarksModel).stations)
+		assert.Equal(t, "Removed One from the bookmarks", cmd().(undoableMsg).text)
+
+	})
+
+	t.Run("refuses to play a station denied by the content filter", func(t *testing.T) {
+
+		played := false
+
+		model := NewBookmarksModel(
+			Theme{},
+			&mocks.MockRadioBrowserService{},
+			&mocks.MockPlaybackManagerService{
+				PlayStationFunc: func(station common.Station, volume int) error {
+					played = true
+					return nil
+				},
+			},
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{
+				AllFunc: func() []common.Station {
+					return []common.Station{station}
+				},
+			},
+			filter.ContentFilter{Deny: filter.List{Stations: []string{station.StationUuid.String()}}},
+			&mocks.MockProberService{},
+		)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		var msgs []tea.Msg
+		for _, cmd := range cmd().(tea.BatchMsg) {
+			msgs = append(msgs, cmd())
+		}
+
+		assert.Contains(t, msgs, nonFatalError{stopPlayback: false, err: filter.ErrBlocked})
+		assert.False(t, played)
+
+	})
+
+	t.Run("removes the bookmark with the given number from command mode", func(t *testing.T) {
+
+		other := common.Station{StationUuid: uuid.New(), Name: "Other"}
+		var removed uuid.UUID
+
+		model := NewBookmarksModel(
+			Theme{},
+			&mocks.MockRadioBrowserService{},
+			&mocks.MockPlaybackManagerService{},
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{
+				AllFunc: func() []common.Station {
+					return []common.Station{station, other}
+				},
+				RemoveFunc: func(stationUuid uuid.UUID) (storage.RemovedBookmark, error) {
+					removed = stationUuid
+					return storage.RemovedBookmark{}, nil
+				},
+			},
+			filter.ContentFilter{},
+			&mocks.MockProberService{},
+		)
+
+		newModel, _ := model.Update(commandLineSubmittedMsg{mode: findMode, line: "other"})
+		_, cmd := newModel.Update(commandLineSubmittedMsg{mode: commandMode, line: "remove"})
+
+		assert.Contains(t, collectMsgs(cmd), bookmarkRemovedMsg{stationUuid: other.StationUuid})
+		assert.Equal(t, other.StationUuid, removed)
+
+	})
+
+	t.Run("copies the stream URL or the link of the selected bookmark", func(t *testing.T) {
+
+		streamUrl, _ := url.Parse("http://example.com/stream")
+		bookmarked := common.Station{StationUuid: uuid.MustParse("960e57c5-0601-11e8-ae97-52543be04c81"), Name: "Copied", Url: common.RadioGoGoURL{URL: *streamUrl}}
+		model := newBookmarksTestModel([]common.Station{bookmarked}, &mocks.MockProberService{})
+		var copied []string
+		model.copyToClipboard = func(text string) error {
+			copied = append(copied, text)
+			return nil
+		}
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+		msg := cmd()
+		assert.Equal(t, toastMsg{text: "Stream URL copied to the clipboard", kind: toastSuccess}, msg)
+
+		_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Y")})
+		cmd()
+
+		_, cmd = model.Update(commandLineSubmittedMsg{mode: commandMode, line: "copy link"})
+		collectMsgs(cmd)
+
+		assert.Equal(t, []string{
+			"http://example.com/stream",
+			"radiogogo://station/960e57c5-0601-11e8-ae97-52543be04c81",
+			"radiogogo://station/960e57c5-0601-11e8-ae97-52543be04c81",
+		}, copied)
+
+	})
+
+	t.Run("shows an error if the clipboard can't be used", func(t *testing.T) {
+
+		model := newBookmarksTestModel([]common.Station{station}, &mocks.MockProberService{})
+		model.copyToClipboard = func(text string) error {
+			return errors.New("no clipboard")
+		}
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+
+		assert.Equal(t, nonFatalError{stopPlayback: false, err: errors.New("can't copy to the clipboard: no clipboard")}, cmd())
+
+	})
+
+}
+
+func TestBookmarksModel_Folders(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	news := common.Station{StationUuid: uuid.New(), Name: "News 24"}
+	loose := common.Station{StationUuid: uuid.New(), Name: "Loose"}
+	meta := map[uuid.UUID]storage.BookmarkMeta{
+		jazz.StationUuid: {Folder: "Music", Tags: []string{"Morning"}},
+		news.StationUuid: {Folder: "Talk", Tags: []string{"morning", "news"}},
+	}
+
+	newModel := func(setMeta func(stationUuid uuid.UUID, meta storage.BookmarkMeta) error) BookmarksModel {
+		return NewBookmarksModel(
+			Theme{},
+			&mocks.MockRadioBrowserService{},
+			&mocks.MockPlaybackManagerService{},
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{
+				AllFunc: func() []common.Station {
+					return []common.Station{jazz, news, loose}
+				},
+				MetaFunc: func(stationUuid uuid.UUID) storage.BookmarkMeta {
+					return meta[stationUuid]
+				},
+				SetMetaFunc: setMeta,
+			},
+			filter.ContentFilter{},
+			&mocks.MockProberService{},
+		)
+	}
+
+	names := func(model BookmarksModel) []string {
+		var names []string
+		for _, row := range model.stationsTable.Rows() {
+			names = append(names, row[0])
+		}
+		return names
+	}
+
+	t.Run("lists folders before the bookmarks outside of any", func(t *testing.T) {
+
+		model := newModel(nil)
+
+		assert.Equal(t, []string{"▸ Music", "▸ Talk", "Loose"}, names(model))
+		assert.Equal(t, "1 bookmarks", model.stationsTable.Rows()[0][1])
+
+	})
+
+	t.Run("opens a folder and goes back to the top level", func(t *testing.T) {
+
+		model := newModel(nil)
+		model.stationsTable.SetCursor(1)
+
+		opened, _ := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Equal(t, []string{"News 24"}, names(opened.(BookmarksModel)))
+		assert.Equal(t, "morning, news", opened.(BookmarksModel).stationsTable.Rows()[0][2])
+		assert.Contains(t, opened.(BookmarksModel).View(), "Folder: Talk")
+
+		closed, cmd := opened.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Nil(t, cmd)
+		assert.Equal(t, []string{"▸ Music", "▸ Talk", "Loose"}, names(closed.(BookmarksModel)))
+		assert.Equal(t, 1, closed.(BookmarksModel).stationsTable.Cursor())
+
+	})
+
+	t.Run("lists the bookmarks with a tag wherever they're filed", func(t *testing.T) {
+
+		model := newModel(nil)
+
+		filtered, _ := model.Update(commandLineSubmittedMsg{mode: commandMode, line: "tagged MORNING"})
+		assert.Equal(t, []string{"Jazz FM", "News 24"}, names(filtered.(BookmarksModel)))
+
+		cleared, _ := filtered.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Equal(t, []string{"▸ Music", "▸ Talk", "Loose"}, names(cleared.(BookmarksModel)))
+
+	})
+
+	t.Run("files and tags the selected bookmark", func(t *testing.T) {
+
+		var saved []storage.BookmarkMeta
+		model := newModel(func(stationUuid uuid.UUID, meta storage.BookmarkMeta) error {
+			assert.Equal(t, loose.StationUuid, stationUuid)
+			saved = append(saved, meta)
+			return nil
+		})
+		model.stationsTable.SetCursor(2)
+
+		newModel, cmd := model.Update(commandLineSubmittedMsg{mode: commandMode, line: "tag Chill chill News"})
+		for _, msg := range collectMsgs(cmd) {
+			newModel, _ = newModel.Update(msg)
+		}
+		assert.Equal(t, "Chill, News", newModel.(BookmarksModel).stationsTable.Rows()[2][2])
+
+		newModel, cmd = newModel.Update(commandLineSubmittedMsg{mode: commandMode, line: "folder Late Night"})
+		for _, msg := range collectMsgs(cmd) {
+			newModel, _ = newModel.Update(msg)
+		}
+
+		assert.Equal(t, []storage.BookmarkMeta{
+			{Tags: []string{"Chill", "News"}},
+			{Folder: "Late Night", Tags: []string{"Chill", "News"}},
+		}, saved)
+		assert.Equal(t, []string{"▸ Late Night", "▸ Music", "▸ Talk"}, names(newModel.(BookmarksModel)))
+
+	})
+
+	t.Run("doesn't remove a folder", func(t *testing.T) {
+
+		model := newModel(nil)
+
+		_, cmd := model.Update(commandLineSubmittedMsg{mode: commandMode, line: "remove"})
+		for _, msg := range collectMsgs(cmd) {
+			assert.IsType(t, bottomBarUpdateMsg{}, msg)
+		}
+
+	})
+
+}
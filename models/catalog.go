@@ -0,0 +1,156 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"math/bits"
+	"sync"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// catalogPart is one of the lists the picker views pick from.
+type catalogPart uint
+
+const (
+	catalogCountries catalogPart = iota
+	catalogLanguages
+	catalogTags
+)
+
+// The parts of the catalog, fetched together
+var catalogParts = []catalogPart{catalogCountries, catalogLanguages, catalogTags}
+
+// catalog keeps the countries, languages and most popular tags radio-browser has stations in for the session,
+// so that the picker views only wait for them the first time one of them is opened.
+// It's shared by the views, and safe for use by concurrent commands. A nil *catalog fetches them every time.
+type catalog struct {
+	mu        sync.Mutex
+	countries []common.Country
+	languages []common.Language
+	tags      []common.Tag
+	fetched   catalogProgress
+}
+
+func newCatalog() *catalog {
+	return &catalog{}
+}
+
+// cached returns the part as fetched earlier in the session, if it was.
+func (c *catalog) cached(part catalogPart) (catalogFetchedMsg, bool) {
+	if c == nil {
+		return catalogFetchedMsg{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.fetched.has(part) {
+		return catalogFetchedMsg{}, false
+	}
+	return catalogFetchedMsg{part: part, countries: c.countries, languages: c.languages, tags: c.tags}, true
+}
+
+// store keeps a part fetched for the rest of the session.
+func (c *catalog) store(msg catalogFetchedMsg) {
+	if c == nil || msg.err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch msg.part {
+	case catalogCountries:
+		c.countries = msg.countries
+	case catalogLanguages:
+		c.languages = msg.languages
+	case catalogTags:
+		c.tags = msg.tags
+	}
+	c.fetched = c.fetched.with(msg.part)
+}
+
+// catalogProgress tells which parts of the catalog were received, fetched or not.
+type catalogProgress uint
+
+func (p catalogProgress) with(part catalogPart) catalogProgress {
+	return p | 1<<part
+}
+
+func (p catalogProgress) without(part catalogPart) catalogProgress {
+	return p &^ (1 << part)
+}
+
+func (p catalogProgress) has(part catalogPart) bool {
+	return p&(1<<part) != 0
+}
+
+// complete returns true once every part was received.
+func (p catalogProgress) complete() bool {
+	return bits.OnesCount(uint(p)) == len(catalogParts)
+}
+
+// String tells how many parts of the catalog were received, out of how many.
+func (p catalogProgress) String() string {
+	return i18n.Tf("catalog.loading", bits.OnesCount(uint(p)), len(catalogParts))
+}
+
+// Messages
+
+// catalogFetchedMsg delivers a part of the catalog, or why it couldn't be fetched.
+type catalogFetchedMsg struct {
+	part      catalogPart
+	countries []common.Country
+	languages []common.Language
+	tags      []common.Tag
+	err       error
+}
+
+// Commands
+
+// fetchCatalogCmds fetch the parts of the catalog, all at once when batched, each delivered as soon as it's fetched
+// and those already fetched in the session straight away.
+func fetchCatalogCmds(browser api.RadioBrowserService, c *catalog) []tea.Cmd {
+	cmds := make([]tea.Cmd, len(catalogParts))
+	for i, part := range catalogParts {
+		cmds[i] = fetchCatalogPartCmd(browser, c, part)
+	}
+	return cmds
+}
+
+func fetchCatalogPartCmd(browser api.RadioBrowserService, c *catalog, part catalogPart) tea.Cmd {
+	return func() tea.Msg {
+		if msg, ok := c.cached(part); ok {
+			return msg
+		}
+		msg := catalogFetchedMsg{part: part}
+		switch part {
+		case catalogCountries:
+			msg.countries, msg.err = browser.GetCountries(true)
+		case catalogLanguages:
+			msg.languages, msg.err = browser.GetLanguages(true)
+		case catalogTags:
+			msg.tags, msg.err = browser.GetTags("", "stationcount", true, 0, tagCloudSize, true)
+		}
+		c.store(msg)
+		return msg
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchCatalogCmds(t *testing.T) {
+
+	newBrowser := func(calls *int, countriesErr error) *mocks.MockRadioBrowserService {
+		return &mocks.MockRadioBrowserService{
+			GetCountriesFunc: func(hideBroken bool) ([]common.Country, error) {
+				*calls++
+				return []common.Country{{Name: "Italy", Code: "IT"}}, countriesErr
+			},
+			GetLanguagesFunc: func(hideBroken bool) ([]common.Language, error) {
+				*calls++
+				return []common.Language{{Name: "italian"}}, nil
+			},
+			GetTagsFunc: func(prefix string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Tag, error) {
+				*calls++
+				return []common.Tag{{Name: "jazz"}}, nil
+			},
+		}
+	}
+
+	t.Run("delivers every part and keeps it for the session", func(t *testing.T) {
+
+		calls := 0
+		browser := newBrowser(&calls, nil)
+		c := newCatalog()
+
+		var progress catalogProgress
+		for _, cmd := range fetchCatalogCmds(browser, c) {
+			msg := cmd().(catalogFetchedMsg)
+			assert.NoError(t, msg.err)
+			progress = progress.with(msg.part)
+		}
+		assert.True(t, progress.complete())
+		assert.Equal(t, 3, calls)
+
+		for _, cmd := range fetchCatalogCmds(browser, c) {
+			msg := cmd().(catalogFetchedMsg)
+			if msg.part == catalogLanguages {
+				assert.Equal(t, []common.Language{{Name: "italian"}}, msg.languages)
+			}
+		}
+		assert.Equal(t, 3, calls)
+
+	})
+
+	t.Run("fetches a part that failed again", func(t *testing.T) {
+
+		calls := 0
+		c := newCatalog()
+
+		msg := fetchCatalogPartCmd(newBrowser(&calls, errors.New("boom")), c, catalogCountries)().(catalogFetchedMsg)
+		assert.Error(t, msg.err)
+
+		msg = fetchCatalogPartCmd(newBrowser(&calls, nil), c, catalogCountries)().(catalogFetchedMsg)
+		assert.NoError(t, msg.err)
+		assert.Equal(t, 2, calls)
+
+	})
+
+	t.Run("fetches every time without a catalog", func(t *testing.T) {
+
+		calls := 0
+		browser := newBrowser(&calls, nil)
+
+		fetchCatalogPartCmd(browser, nil, catalogTags)()
+		fetchCatalogPartCmd(browser, nil, catalogTags)()
+		assert.Equal(t, 2, calls)
+
+	})
+
+}
+
+func TestCatalogProgress(t *testing.T) {
+
+	progress := catalogProgress(0).with(catalogCountries).with(catalogTags)
+
+	assert.True(t, progress.has(catalogTags))
+	assert.False(t, progress.has(catalogLanguages))
+	assert.False(t, progress.complete())
+	assert.False(t, progress.without(catalogTags).has(catalogTags))
+	assert.True(t, progress.with(catalogLanguages).complete())
+
+}
@@ -0,0 +1,497 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// How many stations each chart ranks
+	chartSize = 25
+	// Space between the two charts
+	chartsGap = 4
+	// How many countries the country picker lists at once
+	countryPickerSize = 10
+)
+
+// The charts shown side by side, in order
+var charts = []common.StationChart{common.StationChartTopVote, common.StationChartTopClick}
+
+// Messages
+
+type chartsFetchedMsg struct {
+	countryCode string
+	charts      [][]common.Station
+}
+
+type chartsFetchFailedMsg struct {
+	err error
+}
+
+// Model
+
+// ChartsModel shows the most voted and the most clicked stations of a country side by side.
+type ChartsModel struct {
+	theme Theme
+
+	spinnerModel spinner.Model
+	// The country ranked (worldwide if empty), and its name once the countries are known
+	countryCode string
+	countryName string
+	// The stations of each chart, and the selection in each of them
+	charts     [][]common.Station
+	selections []int
+	// The chart the selection moves in
+	column  int
+	loading bool
+	err     string
+	width   int
+	height  int
+
+	// The country picker, listing the countries matching what's typed
+	picking       bool
+	countries     []common.Country
+	countryFilter textinput.Model
+	countryCursor int
+	countriesErr  string
+
+	browser api.RadioBrowserService
+	// The countries are fetched along with the rest of the catalog, kept for the session (fetched every time if nil)
+	catalog  *catalog
+	progress catalogProgress
+	// Stations hidden for good are left out of the charts (none if nil)
+	blocklist storage.BlocklistStore
+}
+
+// NewChartsModel returns the charts of the country with the given ISO 3166-1 alpha-2 code,
+// or the worldwide charts if it's empty.
+func NewChartsModel(theme Theme, browser api.RadioBrowserService, countryCode string) ChartsModel {
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = theme.SecondaryText
+
+	input := textinput.New()
+	input.Prompt = i18n.T("charts.countryPrompt") + " "
+	input.PromptStyle = theme.SecondaryText
+	input.TextStyle = theme.Text
+
+	return ChartsModel{
+		theme:         theme,
+		spinnerModel:  s,
+		countryCode:   strings.ToUpper(countryCode),
+		selections:    make([]int, len(charts)),
+		loading:       true,
+		countryFilter: input,
+		browser:       browser,
+	}
+}
+
+// SetCatalog keeps the countries fetched for the session in c, along with the languages and tags.
+func (m *ChartsModel) SetCatalog(c *catalog) {
+	m.catalog = c
+}
+
+// Commands
+
+func fetchChartsCmd(browser api.RadioBrowserService, countryCode string) tea.Cmd {
+	return func() tea.Msg {
+		fetched := make([][]common.Station, len(charts))
+		for i, chart := range charts {
+			stations, err := browser.GetTopStations(chart, countryCode, chartSize, true)
+			if err != nil {
+				return chartsFetchFailedMsg{err: err}
+			}
+			fetched[i] = stations
+		}
+		return chartsFetchedMsg{countryCode: countryCode, charts: fetched}
+	}
+}
+
+func updateCommandsForCharts() tea.Msg {
+	return bottomBarUpdateMsg{
+		commands: []string{
+			i18n.T("commands.quit"),
+			i18n.T("commands.back"),
+			i18n.T("commands.moveAll"),
+			i18n.T("commands.play"),
+			i18n.T("commands.country"),
+			i18n.T("commands.worldwide"),
+			i18n.T("commands.help"),
+		},
+	}
+}
+
+func updateCommandsForCountryPicker() tea.Msg {
+	return bottomBarUpdateMsg{
+		commands: []string{
+			i18n.T("commands.cancel"),
+			i18n.T("commands.move"),
+			i18n.T("commands.scrollPage"),
+			i18n.T("commands.pickCountry"),
+		},
+	}
+}
+
+// Bubbletea
+
+func (m ChartsModel) Init() tea.Cmd {
+	cmds := []tea.Cmd{m.spinnerModel.Tick, fetchChartsCmd(m.browser, m.countryCode), updateCommandsForCharts}
+	// The countries are picked from, and name the country ranked
+	return tea.Batch(append(cmds, fetchCatalogCmds(m.browser, m.catalog)...)...)
+}
+
+func (m ChartsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	switch msg := msg.(type) {
+	case chartsFetchedMsg:
+		if msg.countryCode != m.countryCode {
+			return m, nil
+		}
+		m.loading = false
+		m.err = ""
+		m.charts = make([][]common.Station, len(msg.charts))
+		for i, chart := range msg.charts {
+			m.charts[i] = withoutBlockedStations(m.blocklist, chart)
+		}
+		m.selections = make([]int, len(charts))
+		return m, nil
+	case chartsFetchFailedMsg:
+		m.loading = false
+		m.err = msg.err.Error()
+		return m, nil
+	case catalogFetchedMsg:
+		m.progress = m.progress.with(msg.part)
+		switch {
+		case msg.part != catalogCountries:
+		case msg.err != nil:
+			m.countriesErr = msg.err.Error()
+		default:
+			m.countries = msg.countries
+			m.countriesErr = ""
+			m.countryName = m.nameOf(m.countryCode)
+		}
+		return m, nil
+	case tea.KeyMsg:
+		if m.picking {
+			return m.updateCountryPicker(msg)
+		}
+		switch msg.String() {
+		case "q":
+			return m, quitCmd
+		case "?", "f1":
+			return m, showHelpCmd
+		case "esc":
+			return m, func() tea.Msg {
+				return switchToSearchModelMsg{}
+			}
+		case "left", "h":
+			if m.column > 0 {
+				m.column--
+			}
+		case "right", "l":
+			if m.column < len(charts)-1 {
+				m.column++
+			}
+		case "tab":
+			m.column = (m.column + 1) % len(charts)
+		case "up", "k":
+			if m.selections[m.column] > 0 {
+				m.selections[m.column]--
+			}
+		case "down", "j":
+			if m.selections[m.column] < len(m.chart())-1 {
+				m.selections[m.column]++
+			}
+		case "c":
+			m.picking = true
+			m.countryFilter.SetValue("")
+			m.countryCursor = 0
+			cmds := []tea.Cmd{m.countryFilter.Focus(), updateCommandsForCountryPicker}
+			if m.countriesErr != "" {
+				m.countriesErr = ""
+				m.progress = m.progress.without(catalogCountries)
+				cmds = append(cmds, fetchCatalogPartCmd(m.browser, m.catalog, catalogCountries))
+			}
+			return m, tea.Batch(cmds...)
+		case "w":
+			return m.showCountry(common.Country{})
+		case "enter":
+			return m, m.playSelectedStation()
+		}
+		return m, nil
+	}
+
+	if m.loading {
+		newSpinnerModel, cmd := m.spinnerModel.Update(msg)
+		m.spinnerModel = newSpinnerModel
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+// updateCountryPicker lets the user pick the country to rank among the ones matching what they type.
+func (m ChartsModel) updateCountryPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.picking = false
+		m.countryFilter.Blur()
+		return m, updateCommandsForCharts
+	case "up":
+		if m.countryCursor > 0 {
+			m.countryCursor--
+		}
+		return m, nil
+	case "down":
+		if m.countryCursor < len(m.matchingCountries())-1 {
+			m.countryCursor++
+		}
+		return m, nil
+	// Home and End move in the list rather than in the filter, which has ctrl+a and ctrl+e
+	case "pgup", "pgdown", "home", "end":
+		m.countryCursor = pagedCursor(msg.String(), m.countryCursor, len(m.matchingCountries()), countryPickerSize)
+		return m, nil
+	case "enter":
+		matching := m.matchingCountries()
+		if len(matching) == 0 {
+			return m, nil
+		}
+		m.picking = false
+		m.countryFilter.Blur()
+		newModel, cmd := m.showCountry(matching[m.countryCursor])
+		return newModel, tea.Batch(cmd, updateCommandsForCharts)
+	}
+	var cmd tea.Cmd
+	m.countryFilter, cmd = m.countryFilter.Update(msg)
+	m.countryCursor = 0
+	return m, cmd
+}
+
+// showCountry fetches the charts of country (worldwide if it has no code).
+func (m ChartsModel) showCountry(country common.Country) (tea.Model, tea.Cmd) {
+	if country.Code == m.countryCode && !m.loading {
+		return m, nil
+	}
+	m.countryCode = country.Code
+	m.countryName = country.Name
+	m.loading = true
+	m.err = ""
+	m.charts = nil
+	return m, tea.Batch(m.spinnerModel.Tick, fetchChartsCmd(m.browser, m.countryCode))
+}
+
+// playSelectedStation lists the chart the selection is in, playing the selected station.
+func (m ChartsModel) playSelectedStation() tea.Cmd {
+	stations := m.chart()
+	if len(stations) == 0 {
+		return nil
+	}
+	selected := stations[m.selections[m.column]]
+	page := stationPageKey{
+		query:     stationQueryChart,
+		queryText: string(charts[m.column]),
+		filter:    common.StationFilter{CountryCode: m.countryCode},
+	}
+	return func() tea.Msg {
+		return switchToStationsModelMsg{stations: stations, autoplay: true, page: page, selected: selected.StationUuid}
+	}
+}
+
+// chart returns the stations of the chart the selection is in.
+func (m ChartsModel) chart() []common.Station {
+	if m.column >= len(m.charts) {
+		return nil
+	}
+	return m.charts[m.column]
+}
+
+// matchingCountries returns the countries whose name contains what's typed in the picker, or whose code is it.
+func (m ChartsModel) matchingCountries() []common.Country {
+	text := strings.TrimSpace(m.countryFilter.Value())
+	var matching []common.Country
+	for _, country := range m.countries {
+		if strings.EqualFold(country.Code, text) || strings.Contains(strings.ToLower(country.Name), strings.ToLower(text)) {
+			matching = append(matching, country)
+		}
+	}
+	return matching
+}
+
+// nameOf returns the name of the country with the given code, or the code if it's unknown.
+func (m ChartsModel) nameOf(countryCode string) string {
+	for _, country := range m.countries {
+		if strings.EqualFold(country.Code, countryCode) {
+			return country.Name
+		}
+	}
+	return countryCode
+}
+
+func (m ChartsModel) View() string {
+
+	title := i18n.T("charts.worldwide")
+	if m.countryCode != "" {
+		name := m.countryName
+		if name == "" {
+			name = m.countryCode
+		}
+		title = i18n.Tf("charts.country", name, m.countryCode)
+	}
+	v := "\n" + m.theme.SecondaryText.Bold(true).Render(title) + "\n\n"
+
+	if m.picking {
+		return v + m.countryPickerView()
+	}
+
+	if m.loading {
+		if m.theme.Accessible {
+			return v + i18n.T("charts.loading")
+		}
+		return v + m.spinnerModel.View() + " " + i18n.T("charts.loading")
+	}
+
+	if m.err != "" {
+		return v + m.theme.RenderError(m.err)
+	}
+
+	width := (m.chartsWidth() - chartsGap*(len(charts)-1)) / len(charts)
+	columns := make([]string, len(charts))
+	for i, chart := range charts {
+		columns[i] = m.chartView(i, chart, width)
+		if i < len(charts)-1 {
+			columns[i] = lipgloss.NewStyle().PaddingRight(chartsGap).Render(columns[i])
+		}
+	}
+
+	return v + lipgloss.JoinHorizontal(lipgloss.Top, columns...)
+}
+
+// chartView renders the stations of the chart in the given column, fitting them in width.
+func (m ChartsModel) chartView(column int, chart common.StationChart, width int) string {
+
+	v := m.theme.PrimaryText.Bold(true).Render(i18n.T("charts."+string(chart))) + "\n"
+
+	var stations []common.Station
+	if column < len(m.charts) {
+		stations = m.charts[column]
+	}
+	if len(stations) == 0 {
+		return v + m.theme.TertiaryText.Render(i18n.T("charts.empty"))
+	}
+
+	// Scroll so that the selection is always visible
+	visible := m.height - 6
+	if visible < 1 {
+		visible = len(stations)
+	}
+	first := 0
+	if m.selections[column] >= visible {
+		first = m.selections[column] - visible + 1
+	}
+
+	lines := make([]string, 0, visible)
+	for i := first; i < len(stations) && i < first+visible; i++ {
+		station := stations[i]
+		count := station.Votes
+		if chart == common.StationChartTopClick {
+			count = station.ClickCount
+		}
+		line := fmt.Sprintf("%2d. %s (%d)", i+1, displayText(strings.TrimSpace(station.Name)), count)
+		selected := column == m.column && i == m.selections[column]
+		switch {
+		case selected && m.theme.Accessible:
+			line = truncateText("> "+line, width)
+		case selected:
+			line = m.theme.PrimaryBlock.Copy().PaddingLeft(0).PaddingRight(0).Render(truncateText(line, width))
+		case m.theme.Accessible:
+			line = truncateText("  "+line, width)
+		default:
+			line = m.theme.Text.Render(truncateText(line, width))
+		}
+		lines = append(lines, line)
+	}
+
+	return v + strings.Join(lines, "\n")
+}
+
+// countryPickerView renders the text field of the country picker and the countries matching it.
+func (m ChartsModel) countryPickerView() string {
+
+	v := m.countryFilter.View() + "\n\n"
+
+	if m.countriesErr != "" {
+		return v + m.theme.RenderError(m.countriesErr)
+	}
+	if m.countries == nil {
+		return v + m.theme.TertiaryText.Render(m.progress.String())
+	}
+
+	matching := m.matchingCountries()
+	if len(matching) == 0 {
+		return v + m.theme.TertiaryText.Render(i18n.T("charts.noCountries"))
+	}
+
+	first := 0
+	if m.countryCursor >= countryPickerSize {
+		first = m.countryCursor - countryPickerSize + 1
+	}
+	for i := first; i < len(matching) && i < first+countryPickerSize; i++ {
+		country := matching[i]
+		line := i18n.Tf("charts.countryEntry", country.Name, country.Code, country.StationCount)
+		switch {
+		case i == m.countryCursor && m.theme.Accessible:
+			v += "> " + line + "\n"
+		case i == m.countryCursor:
+			v += m.theme.PrimaryBlock.Copy().PaddingLeft(0).PaddingRight(0).Render(line) + "\n"
+		case m.theme.Accessible:
+			v += "  " + line + "\n"
+		default:
+			v += m.theme.Text.Render(line) + "\n"
+		}
+	}
+
+	return v
+}
+
+func (m ChartsModel) chartsWidth() int {
+	if m.width <= 0 {
+		return tagCloudDefaultWidth
+	}
+	return m.width
+}
+
+func (m *ChartsModel) SetWidthAndHeight(width int, height int) {
+	m.width = width
+	m.height = height
+	m.countryFilter.Width = width - len(m.countryFilter.Prompt) - 1
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"io"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChartsModel_Init(t *testing.T) {
+
+	t.Run("fetches both charts of the country", func(t *testing.T) {
+
+		var fetched []common.StationChart
+		mockBrowser := mocks.MockRadioBrowserService{
+			GetTopStationsFunc: func(chart common.StationChart, countryCode string, limit uint64, hideBroken bool) ([]common.Station, error) {
+				assert.Equal(t, "IT", countryCode)
+				assert.Equal(t, uint64(chartSize), limit)
+				fetched = append(fetched, chart)
+				return []common.Station{{Name: string(chart)}}, nil
+			},
+			GetCountriesFunc: func(hideBroken bool) ([]common.Country, error) {
+				return []common.Country{{Name: "Italy", Code: "IT"}}, nil
+			},
+			GetLanguagesFunc: func(hideBroken bool) ([]common.Language, error) {
+				return nil, nil
+			},
+			GetTagsFunc: func(prefix string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Tag, error) {
+				return nil, nil
+			},
+		}
+
+		model := NewChartsModel(Theme{}, &mockBrowser, "it")
+
+		var charts, countries bool
+		for _, cmd := range model.Init()().(tea.BatchMsg) {
+			switch msg := cmd().(type) {
+			case chartsFetchedMsg:
+				charts = true
+				assert.Equal(t, [][]common.Station{{{Name: "topvote"}}, {{Name: "topclick"}}}, msg.charts)
+			case catalogFetchedMsg:
+				countries = countries || msg.part == catalogCountries
+			}
+		}
+
+		assert.True(t, charts)
+		assert.True(t, countries)
+		assert.Equal(t, []common.StationChart{common.StationChartTopVote, common.StationChartTopClick}, fetched)
+
+	})
+
+	t.Run("broadcasts chartsFetchFailedMsg on error", func(t *testing.T) {
+
+		mockBrowser := mocks.MockRadioBrowserService{
+			GetTopStationsFunc: func(chart common.StationChart, countryCode string, limit uint64, hideBroken bool) ([]common.Station, error) {
+				return nil, io.EOF
+			},
+			GetCountriesFunc: func(hideBroken bool) ([]common.Country, error) {
+				return nil, io.EOF
+			},
+			GetLanguagesFunc: func(hideBroken bool) ([]common.Language, error) {
+				return nil, io.EOF
+			},
+			GetTagsFunc: func(prefix string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Tag, error) {
+				return nil, io.EOF
+			},
+		}
+
+		model := NewChartsModel(Theme{}, &mockBrowser, "")
+
+		found := false
+		for _, cmd := range model.Init()().(tea.BatchMsg) {
+			if _, ok := cmd().(chartsFetchFailedMsg); ok {
+				found = true
+			}
+		}
+
+		assert.True(t, found)
+
+	})
+
+}
+
+func TestChartsModel_Update(t *testing.T) {
+
+	voted := []common.Station{
+		{StationUuid: uuid.New(), Name: "Radio One", Votes: 900},
+		{StationUuid: uuid.New(), Name: "Radio Two", Votes: 500},
+	}
+	clicked := []common.Station{
+		{StationUuid: uuid.New(), Name: "Radio Three", ClickCount: 2000},
+	}
+
+	newLoadedModel := func() ChartsModel {
+		model := NewChartsModel(Theme{}, &mocks.MockRadioBrowserService{}, "GB")
+		newModel, _ := model.Update(chartsFetchedMsg{countryCode: "GB", charts: [][]common.Station{voted, clicked}})
+		return newModel.(ChartsModel)
+	}
+
+	t.Run("shows both charts side by side", func(t *testing.T) {
+
+		model := newLoadedModel()
+		model.SetWidthAndHeight(80, 20)
+
+		view := model.View()
+
+		assert.Contains(t, view, "Charts: GB (GB)")
+		assert.Contains(t, view, " 1. Radio One (900)")
+		assert.Contains(t, view, " 1. Radio Three (2000)")
+
+	})
+
+	t.Run("ignores the charts of a country no longer shown", func(t *testing.T) {
+
+		model := newLoadedModel()
+
+		newModel, _ := model.Update(chartsFetchedMsg{countryCode: "FR", charts: [][]common.Station{clicked, voted}})
+
+		assert.Equal(t, voted, newModel.(ChartsModel).charts[0])
+
+	})
+
+	t.Run("moves within and across charts", func(t *testing.T) {
+
+		model := newLoadedModel()
+
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyDown})
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+		model = newModel.(ChartsModel)
+		assert.Equal(t, 1, model.selections[0])
+
+		newModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyRight})
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyRight})
+		model = newModel.(ChartsModel)
+		assert.Equal(t, 1, model.column)
+
+		newModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyTab})
+		assert.Equal(t, 0, newModel.(ChartsModel).column)
+
+	})
+
+	t.Run("plays the selected station from its chart", func(t *testing.T) {
+
+		model := newLoadedModel()
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyRight})
+
+		_, cmd := newModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.Equal(t, switchToStationsModelMsg{
+			stations: clicked,
+			autoplay: true,
+			page: stationPageKey{
+				query:     stationQueryChart,
+				queryText: "topclick",
+				filter:    common.StationFilter{CountryCode: "GB"},
+			},
+			selected: clicked[0].StationUuid,
+		}, cmd())
+
+	})
+
+	t.Run("picks a country among the ones matching what's typed", func(t *testing.T) {
+
+		model := newLoadedModel()
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+		newModel, _ = newModel.Update(catalogFetchedMsg{part: catalogCountries, countries: []common.Country{
+			{Name: "France", Code: "FR"},
+			{Name: "Ireland", Code: "IE"},
+			{Name: "Italy", Code: "IT"},
+		}})
+		for _, r := range "it" {
+			newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		model = newModel.(ChartsModel)
+		assert.True(t, model.picking)
+		assert.Equal(t, []common.Country{{Name: "Italy", Code: "IT"}}, model.matchingCountries())
+
+		newModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		model = newModel.(ChartsModel)
+
+		assert.False(t, model.picking)
+		assert.True(t, model.loading)
+		assert.Equal(t, "IT", model.countryCode)
+		assert.Equal(t, "Italy", model.countryName)
+
+	})
+
+	t.Run("switches to the worldwide charts", func(t *testing.T) {
+
+		model := newLoadedModel()
+
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+		model = newModel.(ChartsModel)
+
+		assert.Equal(t, "", model.countryCode)
+		assert.True(t, model.loading)
+		assert.Contains(t, model.View(), "Charts: worldwide")
+
+	})
+
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/instance"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Commands
+
+// copyStationCmd copies the stream URL of station to the clipboard, or its deep link if link is true.
+func copyStationCmd(copyToClipboard func(text string) error, station common.Station, link bool) tea.Cmd {
+	return func() tea.Msg {
+		text, notice := stationStreamURL(station), i18n.T("clipboard.copiedUrl")
+		if link {
+			text, notice = instance.StationLink(station.StationUuid), i18n.T("clipboard.copiedLink")
+		}
+		if err := copyToClipboard(text); err != nil {
+			return nonFatalError{stopPlayback: false, err: errors.New(i18n.Tf("clipboard.failed", err))}
+		}
+		return toastMsg{text: notice, kind: toastSuccess}
+	}
+}
+
+// copyCommandCmd runs a ":copy" command, copying the stream URL of station, or its deep link with ":copy link".
+func copyCommandCmd(copyToClipboard func(text string) error, station common.Station, c command) tea.Cmd {
+	switch {
+	case len(c.args) == 0:
+		return copyStationCmd(copyToClipboard, station, false)
+	case len(c.args) == 1 && strings.ToLower(c.args[0]) == "link":
+		return copyStationCmd(copyToClipboard, station, true)
+	}
+	return nonFatalErrorCmd(errors.New(i18n.Tf("command.usage", "copy [link]")))
+}
+
+// stationStreamURL returns the URL the station is played from.
+func stationStreamURL(station common.Station) string {
+	if station.UrlResolved.URL.Host != "" {
+		return station.UrlResolved.URL.String()
+	}
+	return station.Url.URL.String()
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How much a column grows or shrinks at every key press, and its minimum width.
+const (
+	columnWidthStep = 2
+	columnMinWidth  = 4
+)
+
+// pickerColumn is a column listed in the column picker.
+type pickerColumn struct {
+	column  config.StationColumn
+	visible bool
+}
+
+// Messages
+
+// closeColumnPickerMsg closes the column picker, reporting the chosen columns if they changed.
+type closeColumnPickerMsg struct {
+	columns []config.StationColumn
+	changed bool
+}
+
+// stationColumnsChangedMsg applies and persists the columns chosen in the column picker.
+type stationColumnsChangedMsg struct {
+	columns []config.StationColumn
+}
+
+// Model
+
+// ColumnPickerModel lets the user choose the columns of the stations table, their order and their widths.
+type ColumnPickerModel struct {
+	theme Theme
+
+	initial []config.StationColumn
+	columns []pickerColumn
+	cursor  int
+}
+
+// NewColumnPickerModel lists the given columns first, in order, followed by the hidden ones.
+func NewColumnPickerModel(theme Theme, columns []config.StationColumn) ColumnPickerModel {
+
+	picker := ColumnPickerModel{
+		theme: theme,
+	}
+
+	shown := make(map[string]bool)
+	for _, column := range columns {
+		column.Width = stationColumnWidth(column)
+		picker.initial = append(picker.initial, column)
+		picker.columns = append(picker.columns, pickerColumn{column: column, visible: true})
+		shown[column.Name] = true
+	}
+	for _, name := range config.StationColumnNames {
+		if !shown[name] {
+			column := config.StationColumn{Name: name}
+			column.Width = stationColumnWidth(column)
+			picker.columns = append(picker.columns, pickerColumn{column: column})
+		}
+	}
+
+	return picker
+}
+
+// Commands
+
+func updateCommandsForColumnPicker() tea.Msg {
+	return bottomBarUpdateMsg{
+		commands: []string{
+			i18n.T("commands.back"),
+			i18n.T("commands.move"),
+			i18n.T("commands.columnToggle"),
+			i18n.T("commands.columnOrder"),
+			i18n.T("commands.columnWidth"),
+		},
+	}
+}
+
+// Bubbletea
+
+func (m ColumnPickerModel) Init() tea.Cmd {
+	return updateCommandsForColumnPicker
+}
+
+func (m ColumnPickerModel) Update(msg tea.Msg) (ColumnPickerModel, tea.Cmd) {
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc", "v", "q":
+		columns := m.Columns()
+		changed := !reflect.DeepEqual(columns, m.initial)
+		return m, func() tea.Msg {
+			return closeColumnPickerMsg{columns: columns, changed: changed}
+		}
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.columns)-1 {
+			m.cursor++
+		}
+	case " ", "enter":
+		// At least one column stays visible
+		if !m.columns[m.cursor].visible || len(m.Columns()) > 1 {
+			m.columns[m.cursor].visible = !m.columns[m.cursor].visible
+		}
+	case "shift+up", "K":
+		if m.cursor > 0 {
+			m.columns[m.cursor], m.columns[m.cursor-1] = m.columns[m.cursor-1], m.columns[m.cursor]
+			m.cursor--
+		}
+	case "shift+down", "J":
+		if m.cursor < len(m.columns)-1 {
+			m.columns[m.cursor], m.columns[m.cursor+1] = m.columns[m.cursor+1], m.columns[m.cursor]
+			m.cursor++
+		}
+	case "left", "h":
+		if m.columns[m.cursor].column.Width-columnWidthStep >= columnMinWidth {
+			m.columns[m.cursor].column.Width -= columnWidthStep
+		}
+	case "right", "l":
+		m.columns[m.cursor].column.Width += columnWidthStep
+	}
+
+	return m, nil
+}
+
+// Columns returns the visible columns, in order.
+func (m ColumnPickerModel) Columns() []config.StationColumn {
+	var columns []config.StationColumn
+	for _, column := range m.columns {
+		if column.visible {
+			columns = append(columns, column.column)
+		}
+	}
+	return columns
+}
+
+func (m ColumnPickerModel) View() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.T("columns.title")) + "\n\n"
+
+	for i, column := range m.columns {
+		check := "[ ]"
+		if column.visible {
+			check = "[x]"
+		}
+		title := i18n.T(stationColumnSpecs[column.column.Name].titleKey)
+		item := fmt.Sprintf("%s %-15s %3d", check, title, column.column.Width)
+		switch {
+		case m.theme.Accessible && i == m.cursor:
+			v += ">>> " + item + "\n"
+		case m.theme.Accessible:
+			v += "    " + item + "\n"
+		case i == m.cursor:
+			v += m.theme.PrimaryBlock.Render(item) + "\n"
+		default:
+			v += m.theme.Text.Render(" "+item) + "\n"
+		}
+	}
+
+	return v
+}
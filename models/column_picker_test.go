@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func pressColumnPickerKeys(m ColumnPickerModel, keys ...tea.KeyMsg) ColumnPickerModel {
+	for _, key := range keys {
+		m, _ = m.Update(key)
+	}
+	return m
+}
+
+func TestColumnPickerModel(t *testing.T) {
+
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+	right := tea.KeyMsg{Type: tea.KeyRight}
+	left := tea.KeyMsg{Type: tea.KeyLeft}
+	moveUp := tea.KeyMsg{Type: tea.KeyShiftUp}
+	esc := tea.KeyMsg{Type: tea.KeyEsc}
+
+	columns := []config.StationColumn{{Name: "name", Width: 30}, {Name: "votes"}}
+
+	t.Run("lists the visible columns first, then the hidden ones", func(t *testing.T) {
+
+		model := NewColumnPickerModel(Theme{}, columns)
+
+		assert.Len(t, model.columns, len(config.StationColumnNames))
+		assert.Equal(t, "name", model.columns[0].column.Name)
+		assert.Equal(t, "votes", model.columns[1].column.Name)
+		assert.False(t, model.columns[2].visible)
+		assert.Equal(t, []config.StationColumn{{Name: "name", Width: 30}, {Name: "votes", Width: 10}}, model.Columns())
+
+	})
+
+	t.Run("shows, hides, reorders and resizes columns", func(t *testing.T) {
+
+		model := NewColumnPickerModel(Theme{}, columns)
+
+		// Hide votes, show the first hidden column and move it above name
+		model = pressColumnPickerKeys(model, down, space, down, space, moveUp, moveUp)
+		// Widen it twice and narrow it once
+		model = pressColumnPickerKeys(model, right, right, left)
+
+		hidden := model.columns[0].column.Name
+		assert.Equal(t, []config.StationColumn{
+			{Name: hidden, Width: stationColumnSpecs[hidden].width + columnWidthStep},
+			{Name: "name", Width: 30},
+		}, model.Columns())
+
+	})
+
+	t.Run("keeps at least one column visible", func(t *testing.T) {
+
+		model := NewColumnPickerModel(Theme{}, []config.StationColumn{{Name: "name"}})
+
+		model = pressColumnPickerKeys(model, space)
+
+		assert.Equal(t, []config.StationColumn{{Name: "name", Width: 30}}, model.Columns())
+
+	})
+
+	t.Run("does not narrow columns below the minimum width", func(t *testing.T) {
+
+		model := NewColumnPickerModel(Theme{}, []config.StationColumn{{Name: "name", Width: columnMinWidth + 1}})
+
+		model = pressColumnPickerKeys(model, left)
+
+		assert.Equal(t, columnMinWidth+1, model.Columns()[0].Width)
+
+	})
+
+	t.Run("reports unchanged columns when closed", func(t *testing.T) {
+
+		model := NewColumnPickerModel(Theme{}, columns)
+
+		_, cmd := model.Update(esc)
+
+		assert.Equal(t, closeColumnPickerMsg{columns: model.Columns(), changed: false}, cmd())
+
+	})
+
+	t.Run("reports changed columns when closed", func(t *testing.T) {
+
+		model := NewColumnPickerModel(Theme{}, columns)
+		model = pressColumnPickerKeys(model, right)
+
+		_, cmd := model.Update(esc)
+
+		assert.Equal(t, closeColumnPickerMsg{columns: []config.StationColumn{{Name: "name", Width: 32}, {Name: "votes", Width: 10}}, changed: true}, cmd())
+
+	})
+
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// stationColumnSpec describes a column of the stations table.
+type stationColumnSpec struct {
+	titleKey string
+	width    int
+	// value renders the column for a station. The name column is rendered by newStationsTableRows instead.
+	value func(station common.Station) string
+}
+
+var stationColumnSpecs = map[string]stationColumnSpec{
+	"name": {titleKey: "stations.column.name", width: 30},
+	"country": {titleKey: "stations.column.country", width: 10, value: func(station common.Station) string {
+		return station.CountryCode
+	}},
+	"codec": {titleKey: "stations.column.codecs", width: 15, value: func(station common.Station) string {
+		return station.Codec
+	}},
+	"bitrate": {titleKey: "stations.column.bitrate", width: 10, value: func(station common.Station) string {
+		if station.Bitrate == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%d kbps", station.Bitrate)
+	}},
+	"votes": {titleKey: "stations.column.votes", width: 10, value: func(station common.Station) string {
+		return fmt.Sprintf("%d", station.Votes)
+	}},
+	"tags": {titleKey: "stations.column.tags", width: 25, value: func(station common.Station) string {
+		return strings.ReplaceAll(station.Tags, ",", ", ")
+	}},
+	"language": {titleKey: "stations.column.languages", width: 15, value: func(station common.Station) string {
+		return station.LanguagesCodes
+	}},
+	"clicks": {titleKey: "stations.column.clicks", width: 10, value: func(station common.Station) string {
+		return fmt.Sprintf("%d", station.ClickCount)
+	}},
+}
+
+// stationColumnWidth returns the width of a column, or its default width if none is set.
+func stationColumnWidth(column config.StationColumn) int {
+	if column.Width > 0 {
+		return column.Width
+	}
+	return stationColumnSpecs[column.Name].width
+}
+
+func newStationsTableColumns(columns []config.StationColumn) []table.Column {
+	tableColumns := make([]table.Column, len(columns))
+	for i, column := range columns {
+		tableColumns[i] = table.Column{
+			Title: i18n.T(stationColumnSpecs[column.Name].titleKey),
+			Width: stationColumnWidth(column),
+		}
+	}
+	return tableColumns
+}
+
+// stationColumnHidingOrder is the order the columns of the stations table are hidden in when they don't fit,
+// the least telling first. The name column is never hidden.
+var stationColumnHidingOrder = []string{"tags", "language", "clicks", "codec", "votes", "bitrate", "country"}
+
+const (
+	// The padding of each cell of the stations table, on both sides
+	stationColumnPadding = 2
+	// How wide the name column grows into the room left by the other columns, unless its width is set
+	stationNameMaxWidth = 60
+)
+
+// stationColumnsLayout is how the columns of the stations table fit in its width.
+type stationColumnsLayout struct {
+	// columns are the columns shown, in order, with their widths set
+	columns []config.StationColumn
+	// scrolled is how many columns were scrolled past, and hidden how many more can be scrolled to
+	scrolled int
+	hidden   int
+}
+
+// fitStationColumns fits columns in width, hiding those in stationColumnHidingOrder until the rest fit
+// and giving the room left to the name column. Once scrolled, columns are shown in order from the scroll-th one
+// past the name column, which stays, rather than by importance, so that every column can be scrolled to.
+// A width of zero means the width is unknown and every column is shown.
+func fitStationColumns(columns []config.StationColumn, width int, scroll int) stationColumnsLayout {
+
+	shown := make([]config.StationColumn, len(columns))
+	for i, column := range columns {
+		shown[i] = column
+		shown[i].Width = stationColumnWidth(column)
+	}
+	if width <= 0 {
+		return stationColumnsLayout{columns: shown}
+	}
+
+	layout := stationColumnsLayout{}
+	others := len(shown)
+	if indexOfStationColumn(shown, "name") >= 0 {
+		others--
+	}
+	if scroll >= others {
+		scroll = others - 1
+	}
+	if scroll > 0 {
+		layout.scrolled = scroll
+		var window []config.StationColumn
+		var full bool
+		others = 0
+		for _, column := range shown {
+			if column.Name == "name" {
+				window = append(window, column)
+				continue
+			}
+			others++
+			switch {
+			case others <= scroll:
+			// The column scrolled to is shown even if it doesn't fit, squeezing the name column
+			case others == scroll+1 || (!full && stationColumnsWidth(append(window, column)) <= width):
+				window = append(window, column)
+			default:
+				full = true
+				layout.hidden++
+			}
+		}
+		shown = window
+	} else {
+		for _, name := range stationColumnHidingOrder {
+			if stationColumnsWidth(shown) <= width || len(shown) == 1 {
+				break
+			}
+			for i, column := range shown {
+				if column.Name == name {
+					shown = append(shown[:i:i], shown[i+1:]...)
+					layout.hidden++
+					break
+				}
+			}
+		}
+	}
+
+	room := width - stationColumnsWidth(shown)
+	name := indexOfStationColumn(shown, "name")
+	switch {
+	case name < 0:
+	case room < 0:
+		shown[name].Width += room
+		if shown[name].Width < 1 {
+			shown[name].Width = 1
+		}
+	case columns[indexOfStationColumn(columns, "name")].Width == 0 && shown[name].Width < stationNameMaxWidth:
+		shown[name].Width += room
+		if shown[name].Width > stationNameMaxWidth {
+			shown[name].Width = stationNameMaxWidth
+		}
+	}
+	layout.columns = shown
+	return layout
+
+}
+
+// stationColumnsWidth returns how wide columns are drawn, padding included.
+func stationColumnsWidth(columns []config.StationColumn) int {
+	width := 0
+	for _, column := range columns {
+		width += column.Width + stationColumnPadding
+	}
+	return width
+}
+
+func indexOfStationColumn(columns []config.StationColumn, name string) int {
+	for i, column := range columns {
+		if column.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// tableColumns returns the columns of the stations table, their titles marked where columns are left out
+// so that it can be told that the table can be scrolled.
+func (l stationColumnsLayout) tableColumns() []table.Column {
+	tableColumns := newStationsTableColumns(l.columns)
+	for i, column := range l.columns {
+		if column.Name != "name" && l.scrolled > 0 {
+			tableColumns[i].Title = "‹ " + tableColumns[i].Title
+			break
+		}
+	}
+	if l.hidden > 0 && len(tableColumns) > 0 {
+		tableColumns[len(tableColumns)-1].Title += " ›"
+	}
+	return tableColumns
+}
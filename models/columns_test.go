@@ -0,0 +1,110 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func stationColumnNames(columns []config.StationColumn) []string {
+	var names []string
+	for _, column := range columns {
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+func TestFitStationColumns(t *testing.T) {
+
+	columns := []config.StationColumn{{Name: "name", Width: 30}, {Name: "country"}, {Name: "language"}, {Name: "tags"}}
+
+	t.Run("shows every column while the width is unknown", func(t *testing.T) {
+
+		layout := fitStationColumns(columns, 0, 0)
+
+		assert.Equal(t, []config.StationColumn{{Name: "name", Width: 30}, {Name: "country", Width: 10}, {Name: "language", Width: 15}, {Name: "tags", Width: 25}}, layout.columns)
+		assert.Equal(t, 0, layout.hidden)
+
+	})
+
+	t.Run("hides the tags first, then the languages, as the table narrows", func(t *testing.T) {
+
+		layout := fitStationColumns(columns, 88, 0)
+		assert.Equal(t, []string{"name", "country", "language", "tags"}, stationColumnNames(layout.columns))
+
+		layout = fitStationColumns(columns, 70, 0)
+		assert.Equal(t, []string{"name", "country", "language"}, stationColumnNames(layout.columns))
+		assert.Equal(t, 1, layout.hidden)
+
+		layout = fitStationColumns(columns, 50, 0)
+		assert.Equal(t, []string{"name", "country"}, stationColumnNames(layout.columns))
+		assert.Equal(t, 2, layout.hidden)
+
+	})
+
+	t.Run("squeezes the name column when it doesn't fit on its own", func(t *testing.T) {
+
+		layout := fitStationColumns(columns[:1], 20, 0)
+
+		assert.Equal(t, []config.StationColumn{{Name: "name", Width: 18}}, layout.columns)
+
+	})
+
+	t.Run("gives the room left to the name column unless its width is set", func(t *testing.T) {
+
+		layout := fitStationColumns([]config.StationColumn{{Name: "name"}, {Name: "votes"}}, 60, 0)
+		assert.Equal(t, []config.StationColumn{{Name: "name", Width: 46}, {Name: "votes", Width: 10}}, layout.columns)
+
+		layout = fitStationColumns([]config.StationColumn{{Name: "name"}, {Name: "votes"}}, 200, 0)
+		assert.Equal(t, stationNameMaxWidth, layout.columns[0].Width)
+
+		layout = fitStationColumns(columns, 200, 0)
+		assert.Equal(t, 30, layout.columns[0].Width)
+
+	})
+
+	t.Run("scrolls through the columns in order, keeping the name column", func(t *testing.T) {
+
+		layout := fitStationColumns(columns, 70, 1)
+		assert.Equal(t, []string{"name", "language"}, stationColumnNames(layout.columns))
+		assert.Equal(t, 1, layout.scrolled)
+		assert.Equal(t, 1, layout.hidden)
+
+		layout = fitStationColumns(columns, 70, 5)
+		assert.Equal(t, []string{"name", "tags"}, stationColumnNames(layout.columns))
+		assert.Equal(t, 2, layout.scrolled)
+		assert.Equal(t, 0, layout.hidden)
+
+	})
+
+	t.Run("marks the titles of the columns next to those left out", func(t *testing.T) {
+
+		tableColumns := fitStationColumns(columns, 70, 1).tableColumns()
+
+		assert.Equal(t, "Name", tableColumns[0].Title)
+		assert.Equal(t, "‹ Language(s) ›", tableColumns[1].Title)
+
+	})
+
+}
@@ -0,0 +1,293 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// commandLineMode is what the command line is used for.
+type commandLineMode int
+
+const (
+	// commandMode runs a command, such as ":play 3".
+	commandMode commandLineMode = iota
+	// findMode moves the cursor to the next station matching some text, such as "/jazz".
+	findMode
+	// filterMode shows only the stations matching some text while it's typed, such as "&jazz".
+	filterMode
+)
+
+// command is a line typed in command mode.
+type command struct {
+	name string
+	args []string
+}
+
+// commandAliases maps the short forms of commands to their full names.
+var commandAliases = map[string]string{
+	"q":  "quit",
+	"q!": "quit",
+	"p":  "play",
+	"b":  "bookmark",
+}
+
+// parseCommand splits a line typed in command mode into the command name and its arguments.
+func parseCommand(line string) (command, error) {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), ":"))
+	if len(fields) == 0 {
+		return command{}, errors.New(i18n.T("command.empty"))
+	}
+	name := strings.ToLower(fields[0])
+	if alias, ok := commandAliases[name]; ok {
+		name = alias
+	}
+	return command{name: name, args: fields[1:]}, nil
+}
+
+// stationNumber parses the only argument of a command as the number of a station, starting from 1.
+// Stations are numbered across all pages of results, and offset is the number of the first one minus one.
+func (c command) stationNumber(offset int, count int) (int, error) {
+	if len(c.args) != 1 {
+		return 0, errors.New(i18n.Tf("command.usage", c.name+" <n>"))
+	}
+	number, err := strconv.Atoi(c.args[0])
+	if err != nil || number <= offset || number > offset+count {
+		return 0, errors.New(i18n.Tf("command.noStation", c.args[0]))
+	}
+	return number - offset - 1, nil
+}
+
+// unknownCommandError reports a command the current view doesn't support.
+func unknownCommandError(c command) error {
+	return errors.New(i18n.Tf("command.unknown", c.name))
+}
+
+// themeCmd asks for the built-in theme or the theme file named by the only argument of a ":theme" command,
+// or for the theme editor with ":theme edit".
+func themeCmd(c command) tea.Cmd {
+	if len(c.args) != 1 {
+		return nonFatalErrorCmd(errors.New(i18n.Tf("command.usage", "theme <"+strings.Join(append(themeNames(), "edit"), "|")+">")))
+	}
+	name := c.args[0]
+	if strings.ToLower(name) == "edit" {
+		return showThemeEditorCmd
+	}
+	if _, ok := config.ThemePresets[strings.ToLower(name)]; ok {
+		name = strings.ToLower(name)
+	}
+	return func() tea.Msg {
+		return themeChangedMsg{name: name}
+	}
+}
+
+// themeNames returns the names of the built-in themes followed by those of the theme files.
+func themeNames() []string {
+	return append(config.ThemePresetNames(), config.ThemeFileNames()...)
+}
+
+func nonFatalErrorCmd(err error) tea.Cmd {
+	return func() tea.Msg {
+		return nonFatalError{stopPlayback: false, err: err}
+	}
+}
+
+// findStation returns the index of the first station after from whose name, custom name or tags
+// contain text, wrapping around to the first station.
+func findStation(stations []common.Station, labelStore storage.LabelStore, from int, text string) (int, bool) {
+	text = strings.ToLower(text)
+	for i := 1; i <= len(stations); i++ {
+		index := (from + i) % len(stations)
+		station := stations[index]
+		if strings.Contains(strings.ToLower(stationDisplayName(labelStore, station)), text) ||
+			strings.Contains(strings.ToLower(station.Name), text) ||
+			strings.Contains(strings.ToLower(station.Tags), text) {
+			return index, true
+		}
+	}
+	return 0, false
+}
+
+// filterStations returns the stations matching text, keeping their order.
+// Stations whose name, custom name, tags, country, language or codec contain text come first,
+// followed by those whose name contains the letters of text in the same order (e.g. "rgg" for "Radio GoGo").
+func filterStations(stations []common.Station, labelStore storage.LabelStore, text string) []common.Station {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return stations
+	}
+	var matches, fuzzyMatches []common.Station
+	for _, station := range stations {
+		name := strings.ToLower(stationDisplayName(labelStore, station))
+		fields := []string{name, station.Name, station.Tags, station.Country, station.Languages, station.Codec}
+		matched := false
+		for _, field := range fields {
+			if strings.Contains(strings.ToLower(field), text) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			matches = append(matches, station)
+		} else if fuzzyMatch(name, text) || fuzzyMatch(strings.ToLower(station.Name), text) {
+			fuzzyMatches = append(fuzzyMatches, station)
+		}
+	}
+	return append(matches, fuzzyMatches...)
+}
+
+// fuzzyMatch returns true if s contains the runes of pattern in the same order, not necessarily next to each other.
+func fuzzyMatch(s string, pattern string) bool {
+	runes := []rune(pattern)
+	i := 0
+	for _, r := range s {
+		if i < len(runes) && r == runes[i] {
+			i++
+		}
+	}
+	return i == len(runes)
+}
+
+// Messages
+
+// commandLineSubmittedMsg carries the line typed in the command line when enter is pressed.
+type commandLineSubmittedMsg struct {
+	mode commandLineMode
+	line string
+}
+
+// closeCommandLineMsg closes the command line without running anything.
+type closeCommandLineMsg struct{}
+
+// filterChangedMsg carries the text typed in the command line in filter mode, whenever it changes.
+type filterChangedMsg struct {
+	text string
+}
+
+// themeChangedMsg asks for the built-in theme with the given name.
+type themeChangedMsg struct {
+	name string
+}
+
+// Model
+
+// CommandLineModel is the vim-like line where commands and text to find are typed.
+type CommandLineModel struct {
+	theme      Theme
+	mode       commandLineMode
+	inputModel textinput.Model
+}
+
+func NewCommandLineModel(theme Theme, mode commandLineMode) CommandLineModel {
+	inputModel := textinput.New()
+	inputModel.Prompt = ":"
+	switch mode {
+	case findMode:
+		inputModel.Prompt = "/"
+	case filterMode:
+		inputModel.Prompt = "&"
+	}
+	inputModel.PromptStyle = theme.PrimaryText
+	inputModel.TextStyle = theme.Text
+	inputModel.Focus()
+	return CommandLineModel{
+		theme:      theme,
+		mode:       mode,
+		inputModel: inputModel,
+	}
+}
+
+// Commands
+
+func updateCommandsForCommandLine(mode commandLineMode) tea.Cmd {
+	return func() tea.Msg {
+		switch mode {
+		case findMode:
+			return bottomBarUpdateMsg{
+				commands: []string{i18n.T("commands.find"), i18n.T("commands.cancel")},
+			}
+		case filterMode:
+			return bottomBarUpdateMsg{
+				commands: []string{i18n.T("commands.keepFilter"), i18n.T("commands.clearFilter")},
+			}
+		}
+		return bottomBarUpdateMsg{
+			commands: []string{i18n.T("commands.run"), i18n.T("commands.cancel")},
+		}
+	}
+}
+
+// Bubbletea
+
+func (m CommandLineModel) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, updateCommandsForCommandLine(m.mode))
+}
+
+func (m CommandLineModel) Update(msg tea.Msg) (CommandLineModel, tea.Cmd) {
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			return m, func() tea.Msg {
+				return closeCommandLineMsg{}
+			}
+		case "enter":
+			submitted := commandLineSubmittedMsg{mode: m.mode, line: m.inputModel.Value()}
+			return m, func() tea.Msg {
+				return submitted
+			}
+		case "backspace":
+			// Deleting the prompt closes the command line, as in vim
+			if m.inputModel.Value() == "" {
+				return m, func() tea.Msg {
+					return closeCommandLineMsg{}
+				}
+			}
+		}
+	}
+
+	value := m.inputModel.Value()
+	newInputModel, cmd := m.inputModel.Update(msg)
+	m.inputModel = newInputModel
+	if m.mode == filterMode && m.inputModel.Value() != value {
+		changed := filterChangedMsg{text: m.inputModel.Value()}
+		return m, tea.Batch(cmd, func() tea.Msg {
+			return changed
+		})
+	}
+	return m, cmd
+}
+
+func (m CommandLineModel) View() string {
+	if m.theme.Accessible {
+		return m.inputModel.Prompt + m.inputModel.Value()
+	}
+	return m.inputModel.View()
+}
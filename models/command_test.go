@@ -0,0 +1,484 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func typeKeys(text string) []tea.KeyMsg {
+	var keys []tea.KeyMsg
+	for _, r := range text {
+		keys = append(keys, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return keys
+}
+
+// collectMsgs runs cmd and the commands it batches, returning the messages they produce.
+func collectMsgs(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, cmd := range batch {
+			msgs = append(msgs, collectMsgs(cmd)...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func TestParseCommand(t *testing.T) {
+
+	t.Run("splits the command name from its arguments", func(t *testing.T) {
+
+		c, err := parseCommand(":Play  3 ")
+
+		assert.NoError(t, err)
+		assert.Equal(t, command{name: "play", args: []string{"3"}}, c)
+
+	})
+
+	t.Run("expands aliases", func(t *testing.T) {
+
+		c, err := parseCommand("q")
+
+		assert.NoError(t, err)
+		assert.Equal(t, command{name: "quit", args: []string{}}, c)
+
+	})
+
+	t.Run("refuses an empty line", func(t *testing.T) {
+
+		_, err := parseCommand("  ")
+
+		assert.EqualError(t, err, "no command given")
+
+	})
+
+}
+
+func TestCommand_StationNumber(t *testing.T) {
+
+	c := command{name: "play", args: []string{"102"}}
+
+	index, err := c.stationNumber(100, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, index)
+
+	_, err = c.stationNumber(0, 5)
+	assert.EqualError(t, err, "no station number 102")
+
+	_, err = command{name: "play", args: []string{"one"}}.stationNumber(0, 5)
+	assert.EqualError(t, err, "no station number one")
+
+	_, err = command{name: "play", args: []string{"1", "2"}}.stationNumber(0, 5)
+	assert.EqualError(t, err, "usage: :play <n>")
+
+}
+
+func TestFindStation(t *testing.T) {
+
+	stations := []common.Station{
+		{Name: "Jazz FM", Tags: "jazz"},
+		{Name: "Rock Antenne", Tags: "rock,classic rock"},
+		{Name: "Smooth Jazz", Tags: "jazz,smooth"},
+	}
+
+	t.Run("finds the next match after the cursor, wrapping around", func(t *testing.T) {
+
+		index, ok := findStation(stations, &mocks.MockLabelStore{}, 0, "JAZZ")
+		assert.True(t, ok)
+		assert.Equal(t, 2, index)
+
+		index, ok = findStation(stations, &mocks.MockLabelStore{}, 2, "jazz")
+		assert.True(t, ok)
+		assert.Equal(t, 0, index)
+
+	})
+
+	t.Run("matches tags and custom names", func(t *testing.T) {
+
+		labelStore := &mocks.MockLabelStore{
+			GetFunc: func(stationUuid uuid.UUID) (storage.StationLabel, bool) {
+				return storage.StationLabel{Alias: "Morning show"}, true
+			},
+		}
+
+		index, ok := findStation(stations, labelStore, 0, "classic")
+		assert.True(t, ok)
+		assert.Equal(t, 1, index)
+
+		_, ok = findStation(stations, labelStore, 0, "morning")
+		assert.True(t, ok)
+
+	})
+
+	t.Run("reports no match", func(t *testing.T) {
+
+		_, ok := findStation(stations, &mocks.MockLabelStore{}, 0, "polka")
+		assert.False(t, ok)
+
+	})
+
+}
+
+func TestFilterStations(t *testing.T) {
+
+	stations := []common.Station{
+		{StationUuid: uuid.New(), Name: "Radio GoGo", Tags: "pop"},
+		{StationUuid: uuid.New(), Name: "Jazz FM", Tags: "jazz,smooth"},
+		{StationUuid: uuid.New(), Name: "Rock Antenne", Country: "Germany"},
+		{StationUuid: uuid.New(), Name: "Smooth Jazz", Codec: "AAC"},
+	}
+
+	t.Run("returns all the stations without text", func(t *testing.T) {
+
+		assert.Equal(t, stations, filterStations(stations, &mocks.MockLabelStore{}, " "))
+
+	})
+
+	t.Run("matches names, tags, countries and codecs ignoring case", func(t *testing.T) {
+
+		assert.Equal(t, []common.Station{stations[1], stations[3]}, filterStations(stations, &mocks.MockLabelStore{}, "SMOOTH"))
+		assert.Equal(t, []common.Station{stations[2]}, filterStations(stations, &mocks.MockLabelStore{}, "german"))
+		assert.Equal(t, []common.Station{stations[3]}, filterStations(stations, &mocks.MockLabelStore{}, "aac"))
+
+	})
+
+	t.Run("matches the letters of names in order after substrings", func(t *testing.T) {
+
+		assert.Equal(t, []common.Station{stations[0]}, filterStations(stations, &mocks.MockLabelStore{}, "rgg"))
+		assert.Equal(t, []common.Station{stations[1], stations[3]}, filterStations(stations, &mocks.MockLabelStore{}, "jz"))
+
+	})
+
+}
+
+func TestCommandLineModel(t *testing.T) {
+
+	t.Run("submits the typed line", func(t *testing.T) {
+
+		model := NewCommandLineModel(Theme{}, commandMode)
+		for _, key := range typeKeys("play 2") {
+			model, _ = model.Update(key)
+		}
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.Equal(t, commandLineSubmittedMsg{mode: commandMode, line: "play 2"}, cmd())
+
+	})
+
+	t.Run("closes on esc", func(t *testing.T) {
+
+		model := NewCommandLineModel(Theme{}, findMode)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+		assert.Equal(t, closeCommandLineMsg{}, cmd())
+
+	})
+
+	t.Run("reports the text typed in filter mode as it changes", func(t *testing.T) {
+
+		model := NewCommandLineModel(Theme{}, filterMode)
+		// Keeps the cursor from blinking, which would make collectMsgs wait
+		model.inputModel.Cursor.SetMode(cursor.CursorStatic)
+
+		model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+		assert.Contains(t, collectMsgs(cmd), filterChangedMsg{text: "j"})
+
+		_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyLeft})
+		assert.NotContains(t, collectMsgs(cmd), filterChangedMsg{text: "j"})
+
+	})
+
+	t.Run("closes when the prompt is deleted", func(t *testing.T) {
+
+		model := NewCommandLineModel(Theme{}, findMode)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+
+		assert.Equal(t, closeCommandLineMsg{}, cmd())
+
+	})
+
+}
+
+func TestStationsModel_VimBindings(t *testing.T) {
+
+	stations := []common.Station{
+		{StationUuid: uuid.New(), Name: "Jazz FM"},
+		{StationUuid: uuid.New(), Name: "Rock Antenne"},
+		{StationUuid: uuid.New(), Name: "Smooth Jazz"},
+	}
+
+	newModel := func(playbackManager *mocks.MockPlaybackManagerService) StationsModel {
+		model := NewStationsModel(
+			Theme{},
+			&mocks.MockRadioBrowserService{},
+			playbackManager,
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{},
+			&mocks.MockReportStore{},
+			filter.ContentFilter{},
+			stations,
+			config.DefaultStationColumns(),
+			nil,
+			stationPageKey{},
+			false,
+		)
+		model.SetWidthAndHeight(100, 30)
+		return model
+	}
+
+	update := func(model StationsModel, msg tea.Msg) (StationsModel, tea.Cmd) {
+		newModel, cmd := model.Update(msg)
+		return newModel.(StationsModel), cmd
+	}
+
+	t.Run("reports the cursor position after it moves", func(t *testing.T) {
+
+		model := newModel(&mocks.MockPlaybackManagerService{})
+
+		_, cmd := update(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+
+		assert.Contains(t, collectMsgs(cmd), stationCursorMovedMsg{offset: 1, totalStations: 3})
+
+	})
+
+	t.Run("jumps to the last and first station with G and gg", func(t *testing.T) {
+
+		model := newModel(&mocks.MockPlaybackManagerService{})
+
+		model, _ = update(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")})
+		assert.Equal(t, 2, model.stationsTable.Cursor())
+
+		model, _ = update(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")})
+		assert.Equal(t, 2, model.stationsTable.Cursor())
+
+		model, cmd := update(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")})
+		assert.Equal(t, 0, model.stationsTable.Cursor())
+		assert.Equal(t, stationCursorMovedMsg{offset: 0, totalStations: 3}, cmd())
+
+	})
+
+	t.Run("jumps to the next station starting with the letter typed after '", func(t *testing.T) {
+
+		model := newModel(&mocks.MockPlaybackManagerService{})
+
+		model, _ = update(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("'")})
+		model, cmd := update(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+		assert.Equal(t, 2, model.stationsTable.Cursor())
+		assert.Equal(t, stationCursorMovedMsg{offset: 2, totalStations: 3}, cmd())
+
+		// Without ' first, letters are commands: "j" moves down
+		model, _ = update(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+		model, _ = update(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("'")})
+		model, _ = update(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+		assert.Equal(t, 0, model.stationsTable.Cursor())
+
+	})
+
+	t.Run("plays the station with the given number", func(t *testing.T) {
+
+		var played common.Station
+		model := newModel(&mocks.MockPlaybackManagerService{
+			PlayStationFunc: func(station common.Station, volume int) error {
+				played = station
+				return nil
+			},
+		})
+
+		model, _ = update(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(":")})
+		assert.True(t, model.showCommandLine)
+
+		model, cmd := update(model, commandLineSubmittedMsg{mode: commandMode, line: "play 2"})
+
+		assert.False(t, model.showCommandLine)
+		assert.Equal(t, 1, model.stationsTable.Cursor())
+		assert.Contains(t, collectMsgs(cmd), playbackStartedMsg{station: stations[1]})
+		assert.Equal(t, "Rock Antenne", played.Name)
+
+	})
+
+	t.Run("reports unknown commands", func(t *testing.T) {
+
+		model := newModel(&mocks.MockPlaybackManagerService{})
+
+		_, cmd := update(model, commandLineSubmittedMsg{mode: commandMode, line: "dance"})
+
+		var errs []string
+		for _, msg := range collectMsgs(cmd) {
+			if msg, ok := msg.(nonFatalError); ok {
+				errs = append(errs, msg.err.Error())
+			}
+		}
+		assert.Equal(t, []string{"unknown command: dance"}, errs)
+
+	})
+
+	t.Run("finds stations and repeats the last search", func(t *testing.T) {
+
+		model := newModel(&mocks.MockPlaybackManagerService{})
+
+		model, _ = update(model, commandLineSubmittedMsg{mode: findMode, line: "jazz"})
+		assert.Equal(t, 2, model.stationsTable.Cursor())
+
+		model, _ = update(model, commandLineSubmittedMsg{mode: findMode, line: ""})
+		assert.Equal(t, 0, model.stationsTable.Cursor())
+
+	})
+
+	t.Run("asks for a theme", func(t *testing.T) {
+
+		model := newModel(&mocks.MockPlaybackManagerService{})
+
+		_, cmd := update(model, commandLineSubmittedMsg{mode: commandMode, line: "theme Dracula"})
+
+		assert.Contains(t, collectMsgs(cmd), themeChangedMsg{name: "dracula"})
+
+	})
+
+	t.Run("opens the theme editor", func(t *testing.T) {
+
+		model := newModel(&mocks.MockPlaybackManagerService{})
+
+		_, cmd := update(model, commandLineSubmittedMsg{mode: commandMode, line: "theme edit"})
+
+		assert.Contains(t, collectMsgs(cmd), themeEditorRequestedMsg{})
+
+	})
+
+}
+
+func TestStationsModel_Filter(t *testing.T) {
+
+	stations := []common.Station{
+		{StationUuid: uuid.New(), Name: "Jazz FM"},
+		{StationUuid: uuid.New(), Name: "Rock Antenne"},
+		{StationUuid: uuid.New(), Name: "Smooth Jazz"},
+	}
+
+	newModel := func() StationsModel {
+		model := NewStationsModel(
+			Theme{},
+			&mocks.MockRadioBrowserService{},
+			&mocks.MockPlaybackManagerService{},
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{},
+			&mocks.MockReportStore{},
+			filter.ContentFilter{},
+			stations,
+			config.DefaultStationColumns(),
+			nil,
+			stationPageKey{},
+			false,
+		)
+		model.SetWidthAndHeight(100, 30)
+		return model
+	}
+
+	update := func(model StationsModel, msg tea.Msg) (StationsModel, tea.Cmd) {
+		newModel, cmd := model.Update(msg)
+		return newModel.(StationsModel), cmd
+	}
+
+	t.Run("narrows the stations while typing", func(t *testing.T) {
+
+		model := newModel()
+
+		model, _ = update(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+		assert.True(t, model.showCommandLine)
+
+		for _, text := range []string{"j", "ja", "jaz", "jazz"} {
+			model, _ = update(model, filterChangedMsg{text: text})
+		}
+
+		assert.Equal(t, []common.Station{stations[0], stations[2]}, model.stations)
+		assert.Len(t, model.stationsTable.Rows(), 2)
+
+	})
+
+	t.Run("keeps the highlighted station if it still matches", func(t *testing.T) {
+
+		model := newModel()
+		model.stationsTable.SetCursor(2)
+
+		model, _ = update(model, filterChangedMsg{text: "jazz"})
+
+		assert.Equal(t, stations[2], model.stations[model.stationsTable.Cursor()])
+
+	})
+
+	t.Run("keeps the filter on enter and clears it on esc", func(t *testing.T) {
+
+		model := newModel()
+
+		model, _ = update(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+		model, _ = update(model, commandLineSubmittedMsg{mode: filterMode, line: "rock"})
+		assert.False(t, model.showCommandLine)
+		assert.Equal(t, []common.Station{stations[1]}, model.stations)
+		assert.Contains(t, model.View(), "rock")
+
+		model, _ = update(model, tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Equal(t, stations, model.stations)
+
+	})
+
+	t.Run("clears the filter when the command line is cancelled", func(t *testing.T) {
+
+		model := newModel()
+
+		model, _ = update(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+		model, _ = update(model, filterChangedMsg{text: "rock"})
+		model, _ = update(model, closeCommandLineMsg{})
+
+		assert.Equal(t, stations, model.stations)
+
+	})
+
+	t.Run("keeps the table when nothing matches", func(t *testing.T) {
+
+		model := newModel()
+
+		model, _ = update(model, filterChangedMsg{text: "classical"})
+
+		assert.Empty(t, model.stations)
+		assert.NotContains(t, model.View(), i18n.T("stations.noResults"))
+
+	})
+
+}
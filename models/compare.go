@@ -0,0 +1,183 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/icy"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/secrets"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Messages
+
+// comparisonStartedMsg tells that both compared stations are connected, the first one heard,
+// and what their servers announced.
+type comparisonStartedMsg struct {
+	stations [2]common.Station
+	streams  [2]common.StreamInfo
+}
+
+// Commands
+
+// compareStationsCmd plays the first station, keeping the second one connected, muted, if the playback manager
+// can for both. Otherwise, it plays the first station alone, telling that switching plays the other one like any station.
+func compareStationsCmd(
+	playbackManager playback.PlaybackManagerService,
+	contentFilter filter.ContentFilter,
+	prober icy.ProberService,
+	credentials secrets.Store,
+	variants storage.StreamVariantStore,
+	stations [2]common.Station,
+	volumes [2]int,
+) tea.Cmd {
+	comparer, ok := playbackManager.(playback.Comparer)
+	if !ok || !comparer.CanCompare(stations[0], stations[1]) {
+		return tea.Batch(
+			showToastCmd(i18n.T("compare.reconnects"), toastInfo),
+			playStationCmd(playbackManager, contentFilter, prober, credentials, variants, stations[0], volumes[0]),
+		)
+	}
+	return func() tea.Msg {
+		var authenticated [2]common.Station
+		var streams [2]common.StreamInfo
+		for i := range stations {
+			var err error
+			authenticated[i], streams[i], err = prepareStation(contentFilter, prober, credentials, variants, stations[i])
+			if err != nil {
+				return nonFatalError{stopPlayback: false, err: err}
+			}
+			stations[i].Hls = authenticated[i].Hls
+		}
+		err := comparer.Compare(authenticated[0], volumes[0], authenticated[1], volumes[1])
+		if err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return comparisonStartedMsg{stations: stations, streams: streams}
+	}
+}
+
+// switchComparedCmd hears station, the muted one of the comparison, or plays it if it wasn't kept connected.
+func switchComparedCmd(
+	playbackManager playback.PlaybackManagerService,
+	contentFilter filter.ContentFilter,
+	prober icy.ProberService,
+	credentials secrets.Store,
+	variants storage.StreamVariantStore,
+	station common.Station,
+	volume int,
+	stream common.StreamInfo,
+	connected bool,
+) tea.Cmd {
+	comparer, ok := playbackManager.(playback.Comparer)
+	if !ok || !connected {
+		return playStationCmd(playbackManager, contentFilter, prober, credentials, variants, station, volume)
+	}
+	return func() tea.Msg {
+		err := comparer.SwitchCompared()
+		if err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return playbackStartedMsg{station: station, stream: stream}
+	}
+}
+
+// Model
+
+// comparing returns true if two stations are being compared.
+func (m StationsModel) comparing() bool {
+	return len(m.compared) == 2
+}
+
+// compareSelectedStation marks the highlighted station for comparison, then compares it with the one marked
+// before, e.g. two relays of the same broadcaster. Once comparing, it switches between the two.
+func (m StationsModel) compareSelectedStation() (tea.Model, tea.Cmd) {
+	if m.bufferingStation != nil {
+		return m, nil
+	}
+	if m.comparing() {
+		return m.switchCompared()
+	}
+	if len(m.stations) == 0 {
+		return m, nil
+	}
+	station := m.stations[m.stationsTable.Cursor()]
+	name := stationDisplayName(m.labelStore, station)
+	if len(m.compared) == 1 && m.compared[0].StationUuid == station.StationUuid {
+		m.compared = nil
+		return m, showToastCmd(i18n.Tf("compare.unmarked", name), toastInfo)
+	}
+	if len(m.compared) == 0 {
+		m.compared = []common.Station{station}
+		return m, showToastCmd(i18n.Tf("compare.marked", name), toastInfo)
+	}
+	m.compared = append(m.compared, station)
+	m.comparedHeard = 0
+	m.comparedConnected = false
+	m.comparedStreams = [2]common.StreamInfo{}
+	m.switchingCompared = true
+	stations := [2]common.Station{withStationPrefs(m.stationPrefs, withStreamHeaders(m.streamHeaders, m.compared[0])), withStationPrefs(m.stationPrefs, withStreamHeaders(m.streamHeaders, m.compared[1]))}
+	volumes := [2]int{m.stationVolume(stations[0]), m.stationVolume(stations[1])}
+	return m.bufferStation(stations[0], compareStationsCmd(m.playbackManager, m.contentFilter, m.prober, m.credentials, m.streamVariants, stations, volumes))
+}
+
+// switchCompared hears the other station of the comparison.
+func (m StationsModel) switchCompared() (tea.Model, tea.Cmd) {
+	m.comparedHeard = 1 - m.comparedHeard
+	station := m.compared[m.comparedHeard]
+	stream := m.comparedStreams[m.comparedHeard]
+	m.switchingCompared = true
+	return m.bufferStation(station, switchComparedCmd(m.playbackManager, m.contentFilter, m.prober, m.credentials, m.streamVariants, withStationPrefs(m.stationPrefs, withStreamHeaders(m.streamHeaders, station)), m.stationVolume(station), stream, m.comparedConnected))
+}
+
+// comparisonStarted plays the first station compared, now that both are connected.
+func (m StationsModel) comparisonStarted(msg comparisonStartedMsg) (tea.Model, tea.Cmd) {
+	if !m.comparing() {
+		return m, nil
+	}
+	m.compared = msg.stations[:]
+	m.comparedStreams = msg.streams
+	m.comparedConnected = true
+	return m, func() tea.Msg {
+		return playbackStartedMsg{station: msg.stations[0], stream: msg.streams[0]}
+	}
+}
+
+// stopComparing forgets the stations compared, once another station is played or playback stopped.
+func (m *StationsModel) stopComparing() {
+	if m.comparing() {
+		m.compared = nil
+	}
+}
+
+// comparingText returns the stations compared, the one heard first, or an empty string if none are.
+func (m StationsModel) comparingText() string {
+	if !m.comparing() {
+		return ""
+	}
+	heard := m.compared[m.comparedHeard]
+	other := m.compared[1-m.comparedHeard]
+	return i18n.Tf("compare.comparing", displayText(stationDisplayName(m.labelStore, heard)), displayText(stationDisplayName(m.labelStore, other)))
+}
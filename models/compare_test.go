@@ -0,0 +1,219 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/playback"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// comparingPlaybackManager keeps the muted station of a comparison connected.
+type comparingPlaybackManager struct {
+	mocks.MockPlaybackManagerService
+	compared  []common.Station
+	switches  int
+	switchErr error
+	// Whether the stations compared ask for players that can't keep them both connected
+	cannotCompare bool
+}
+
+func (m *comparingPlaybackManager) Compare(station common.Station, volume int, other common.Station, otherVolume int) error {
+	m.compared = []common.Station{station, other}
+	return nil
+}
+
+func (m *comparingPlaybackManager) CanCompare(station common.Station, other common.Station) bool {
+	return !m.cannotCompare
+}
+
+func (m *comparingPlaybackManager) SwitchCompared() error {
+	m.switches++
+	return m.switchErr
+}
+
+func newCompareStationsModel(playbackManager playback.PlaybackManagerService, stations []common.Station) StationsModel {
+	return NewStationsModel(
+		Theme{},
+		&mocks.MockRadioBrowserService{},
+		playbackManager,
+		&mocks.MockLabelStore{},
+		&mocks.MockBookmarkStore{},
+		&mocks.MockReportStore{},
+		filter.ContentFilter{},
+		stations,
+		config.DefaultStationColumns(),
+		nil,
+		stationPageKey{},
+		false,
+	)
+}
+
+func TestStationsModel_Compare(t *testing.T) {
+
+	relay1 := common.Station{StationUuid: uuid.New(), Name: "Jazz FM (relay 1)"}
+	relay2 := common.Station{StationUuid: uuid.New(), Name: "Jazz FM (relay 2)"}
+	compareKey := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")}
+
+	// markBoth marks the first station, then the second one, returning the command starting the comparison
+	markBoth := func(model StationsModel) (tea.Model, tea.Cmd) {
+		newModel, cmd := model.Update(compareKey)
+		assert.Len(t, newModel.(StationsModel).compared, 1)
+		assert.NotNil(t, cmd)
+		model = newModel.(StationsModel)
+		model.stationsTable.SetCursor(1)
+		return model.Update(compareKey)
+	}
+
+	t.Run("keeps both stations connected, switching between them", func(t *testing.T) {
+
+		playbackManager := &comparingPlaybackManager{MockPlaybackManagerService: mocks.MockPlaybackManagerService{IsPlayingResult: true}}
+		model := newCompareStationsModel(playbackManager, []common.Station{relay1, relay2})
+
+		newModel, cmd := markBoth(model)
+		assert.True(t, newModel.(StationsModel).comparing())
+		msgs := collectMsgs(cmd)
+		assert.Contains(t, msgs, comparisonStartedMsg{stations: [2]common.Station{relay1, relay2}})
+		assert.Equal(t, []common.Station{relay1, relay2}, playbackManager.compared)
+
+		newModel, cmd = newModel.Update(comparisonStartedMsg{stations: [2]common.Station{relay1, relay2}})
+		assert.Equal(t, playbackStartedMsg{station: relay1}, cmd())
+		newModel, _ = newModel.Update(playbackStartedMsg{station: relay1})
+		assert.True(t, newModel.(StationsModel).comparing())
+		assert.Contains(t, newModel.View(), "A/B")
+
+		newModel, cmd = newModel.Update(compareKey)
+		assert.Contains(t, collectMsgs(cmd), playbackStartedMsg{station: relay2})
+		assert.Equal(t, 1, playbackManager.switches)
+		newModel, _ = newModel.Update(playbackStartedMsg{station: relay2})
+		assert.True(t, newModel.(StationsModel).comparing())
+		assert.Equal(t, relay2, newModel.(StationsModel).currentStation)
+
+		// Playing a station otherwise stops comparing
+		newModel, _ = newModel.Update(playbackStartedMsg{station: relay1})
+		assert.False(t, newModel.(StationsModel).comparing())
+
+	})
+
+	t.Run("stops comparing when switching fails", func(t *testing.T) {
+
+		playbackManager := &comparingPlaybackManager{switchErr: playback.ErrComparedStopped}
+		model := newCompareStationsModel(playbackManager, []common.Station{relay1, relay2})
+		newModel, _ := markBoth(model)
+		newModel, _ = newModel.Update(comparisonStartedMsg{stations: [2]common.Station{relay1, relay2}})
+		newModel, _ = newModel.Update(playbackStartedMsg{station: relay1})
+
+		newModel, cmd := newModel.Update(compareKey)
+		assert.Contains(t, collectMsgs(cmd), nonFatalError{stopPlayback: false, err: playback.ErrComparedStopped})
+		newModel, _ = newModel.Update(nonFatalError{stopPlayback: false, err: playback.ErrComparedStopped})
+
+		assert.False(t, newModel.(StationsModel).comparing())
+
+	})
+
+	t.Run("plays the stations in turn when they can't be kept connected", func(t *testing.T) {
+
+		var played []common.Station
+		playbackManager := &mocks.MockPlaybackManagerService{
+			IsPlayingResult: true,
+			PlayStationFunc: func(station common.Station, volume int) error {
+				played = append(played, station)
+				return nil
+			},
+		}
+		model := newCompareStationsModel(playbackManager, []common.Station{relay1, relay2})
+
+		newModel, cmd := markBoth(model)
+		msgs := collectMsgs(cmd)
+		assert.Contains(t, msgs, playbackStartedMsg{station: relay1})
+		assert.Contains(t, msgs, toastMsg{text: "Both stations can't stay connected with ffplay, timeshift or a network output: each switch reconnects", kind: toastInfo})
+		newModel, _ = newModel.Update(playbackStartedMsg{station: relay1})
+
+		newModel, cmd = newModel.Update(compareKey)
+		assert.Contains(t, collectMsgs(cmd), playbackStartedMsg{station: relay2})
+		newModel, _ = newModel.Update(playbackStartedMsg{station: relay2})
+
+		assert.True(t, newModel.(StationsModel).comparing())
+		assert.Equal(t, []common.Station{relay1, relay2}, played)
+
+	})
+
+	t.Run("plays the stations in turn when their preferences ask for players that can't compare", func(t *testing.T) {
+
+		var played []common.Station
+		playbackManager := &comparingPlaybackManager{
+			MockPlaybackManagerService: mocks.MockPlaybackManagerService{
+				IsPlayingResult: true,
+				PlayStationFunc: func(station common.Station, volume int) error {
+					played = append(played, station)
+					return nil
+				},
+			},
+			cannotCompare: true,
+		}
+		model := newCompareStationsModel(playbackManager, []common.Station{relay1, relay2})
+
+		newModel, cmd := markBoth(model)
+		msgs := collectMsgs(cmd)
+		assert.Contains(t, msgs, playbackStartedMsg{station: relay1})
+		assert.Contains(t, msgs, toastMsg{text: "Both stations can't stay connected with ffplay, timeshift or a network output: each switch reconnects", kind: toastInfo})
+		newModel, _ = newModel.Update(playbackStartedMsg{station: relay1})
+
+		newModel, cmd = newModel.Update(compareKey)
+		assert.Contains(t, collectMsgs(cmd), playbackStartedMsg{station: relay2})
+
+		assert.Nil(t, playbackManager.compared)
+		assert.Equal(t, 0, playbackManager.switches)
+		assert.Equal(t, []common.Station{relay1, relay2}, played)
+
+	})
+
+	t.Run("unmarks the station marked twice", func(t *testing.T) {
+
+		model := newCompareStationsModel(&mocks.MockPlaybackManagerService{}, []common.Station{relay1, relay2})
+
+		newModel, _ := model.Update(compareKey)
+		newModel, cmd := newModel.Update(compareKey)
+
+		assert.Empty(t, newModel.(StationsModel).compared)
+		assert.IsType(t, toastMsg{}, cmd())
+
+	})
+
+	t.Run("stops comparing when playback stops", func(t *testing.T) {
+
+		model := newCompareStationsModel(&comparingPlaybackManager{}, []common.Station{relay1, relay2})
+		newModel, _ := markBoth(model)
+
+		newModel, _ = newModel.Update(playbackStoppedMsg{})
+
+		assert.False(t, newModel.(StationsModel).comparing())
+
+	})
+
+}
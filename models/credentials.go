@@ -0,0 +1,63 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/secrets"
+)
+
+// ErrCredentialsUnavailable is matched by errors returned when the credentials of a station can't be read.
+var ErrCredentialsUnavailable = i18n.Error("credentials.unavailable")
+
+// authenticate returns station with the credentials stored for it, if any, in its stream URLs.
+// Only the prober and the playback manager get to see it, so that the credentials stay out of
+// the history, the clipboard and the screen.
+func authenticate(credentials secrets.Store, station common.Station) (common.Station, error) {
+	if credentials == nil {
+		return station, nil
+	}
+	stored, err := secrets.GetStationCredentials(credentials, station.StationUuid.String())
+	if errors.Is(err, secrets.ErrNotFound) {
+		return station, nil
+	}
+	if err != nil {
+		return station, fmt.Errorf("%w: %v", ErrCredentialsUnavailable, err)
+	}
+	station.Url.URL = stored.Apply(station.Url.URL)
+	if station.UrlResolved.URL.Host != "" {
+		station.UrlResolved.URL = stored.Apply(station.UrlResolved.URL)
+	}
+	return station, nil
+}
+
+// SetCredentialStore authenticates the stations credentials were stored for in store (nil never does).
+func (m *StationsModel) SetCredentialStore(store secrets.Store) {
+	m.credentials = store
+}
+
+// SetCredentialStore authenticates the stations credentials were stored for in store (nil never does).
+func (m *BookmarksModel) SetCredentialStore(store secrets.Store) {
+	m.credentials = store
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How often the diagnostics are refreshed while shown.
+const diagnosticsRefreshInterval = time.Second
+
+// Messages
+
+type diagnosticsTickMsg struct{}
+
+// Model
+
+// DiagnosticsModel is a hidden view showing how each radio-browser mirror has been answering,
+// to help tell a slow network from a slow mirror.
+type DiagnosticsModel struct {
+	theme Theme
+
+	// nil when radio-browser is not reached through mirrors (e.g. offline)
+	provider api.MirrorStatsProvider
+	stats    []api.MirrorStats
+	width    int
+	height   int
+}
+
+func NewDiagnosticsModel(theme Theme, provider api.MirrorStatsProvider) DiagnosticsModel {
+	m := DiagnosticsModel{
+		theme:    theme,
+		provider: provider,
+	}
+	m.refresh()
+	return m
+}
+
+func (m *DiagnosticsModel) refresh() {
+	if m.provider != nil {
+		m.stats = m.provider.MirrorStats()
+	}
+}
+
+// Commands
+
+func diagnosticsTickCmd() tea.Cmd {
+	return tea.Tick(diagnosticsRefreshInterval, func(t time.Time) tea.Msg {
+		return diagnosticsTickMsg{}
+	})
+}
+
+func updateCommandsForDiagnostics() tea.Msg {
+	return bottomBarUpdateMsg{
+		commands: []string{
+			i18n.T("commands.quit"),
+			i18n.T("commands.back"),
+		},
+	}
+}
+
+// Bubbletea
+
+func (m DiagnosticsModel) Init() tea.Cmd {
+	return tea.Batch(diagnosticsTickCmd(), updateCommandsForDiagnostics)
+}
+
+func (m DiagnosticsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case diagnosticsTickMsg:
+		m.refresh()
+		return m, diagnosticsTickCmd()
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q":
+			return m, quitCmd
+		case "esc":
+			return m, func() tea.Msg {
+				return switchToSearchModelMsg{}
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m DiagnosticsModel) View() string {
+
+	v := "\n" + m.theme.PrimaryText.Bold(true).Render(i18n.T("diagnostics.mirrors")) + "\n\n"
+
+	if len(m.stats) == 0 {
+		return v + m.theme.SecondaryText.Bold(true).Render(i18n.T("diagnostics.noMirrors")) + "\n"
+	}
+
+	header := fmt.Sprintf("  %-40s %8s %8s %10s %8s",
+		i18n.T("diagnostics.address"),
+		i18n.T("diagnostics.requests"),
+		i18n.T("diagnostics.errors"),
+		i18n.T("diagnostics.latency"),
+		i18n.T("diagnostics.errorRate"),
+	)
+	v += m.theme.SecondaryText.Bold(true).Render(header) + "\n"
+
+	for _, stats := range m.stats {
+		latency := "-"
+		if stats.Latency > 0 {
+			latency = fmt.Sprintf("%d ms", stats.Latency.Milliseconds())
+		}
+		marker := "  "
+		if stats.Preferred {
+			marker = "> "
+		}
+		line := fmt.Sprintf("%s%-40s %8d %8d %10s %7.0f%%",
+			marker,
+			stats.Address,
+			stats.Requests,
+			stats.Errors,
+			latency,
+			stats.ErrorRate*100,
+		)
+		if stats.Preferred {
+			v += m.theme.PrimaryText.Render(line) + "\n"
+		} else {
+			v += m.theme.Text.Render(line) + "\n"
+		}
+	}
+
+	v += "\n" + m.theme.SecondaryText.Render(i18n.T("diagnostics.preferred")) + "\n"
+
+	return v
+}
+
+func (m *DiagnosticsModel) SetWidthAndHeight(width int, height int) {
+	m.width = width
+	m.height = height
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMirrorStatsProvider struct {
+	stats []api.MirrorStats
+}
+
+func (p *fakeMirrorStatsProvider) MirrorStats() []api.MirrorStats {
+	return p.stats
+}
+
+func TestDiagnosticsModel(t *testing.T) {
+
+	t.Run("shows the statistics of each mirror", func(t *testing.T) {
+
+		provider := &fakeMirrorStatsProvider{stats: []api.MirrorStats{
+			{Address: "10.0.0.2", Requests: 12, Latency: 145 * time.Millisecond, Preferred: true},
+			{Address: "10.0.0.1", Requests: 3, Errors: 2, ErrorRate: 0.51},
+		}}
+
+		view := NewDiagnosticsModel(Theme{}, provider).View()
+
+		assert.Regexp(t, `> 10\.0\.0\.2 +12 +0 +145 ms +0%`, view)
+		assert.Regexp(t, `  10\.0\.0\.1 +3 +2 +- +51%`, view)
+
+	})
+
+	t.Run("refreshes the statistics periodically", func(t *testing.T) {
+
+		provider := &fakeMirrorStatsProvider{}
+		model := NewDiagnosticsModel(Theme{}, provider)
+		provider.stats = []api.MirrorStats{{Address: "10.0.0.1", Requests: 1}}
+
+		newModel, cmd := model.Update(diagnosticsTickMsg{})
+
+		assert.Equal(t, provider.stats, newModel.(DiagnosticsModel).stats)
+		assert.NotNil(t, cmd)
+
+	})
+
+	t.Run("explains when no mirror is used", func(t *testing.T) {
+
+		view := NewDiagnosticsModel(Theme{}, nil).View()
+
+		assert.Contains(t, view, "No mirror has been contacted")
+
+	})
+
+	t.Run("goes back to search on esc", func(t *testing.T) {
+
+		_, cmd := NewDiagnosticsModel(Theme{}, nil).Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+		assert.IsType(t, switchToSearchModelMsg{}, cmd())
+
+	})
+
+}
@@ -0,0 +1,347 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// stationQueryDiscover is the query of the stations suggested by discovery, which isn't a radio-browser
+// search either: they're picked at random each time.
+const stationQueryDiscover common.StationQuery = "discover"
+
+const (
+	// How many stations discovery suggests
+	discoverySize = 20
+	// How many of the tags played the most are searched for
+	discoveryTagQueries = 3
+	// How many stations each search brings in
+	discoveryCandidates = 100
+	// How long a suggested station has to be listened to for to count as kept
+	discoveryKeptAfter = 5 * time.Minute
+)
+
+// How much each trait shared with the taste profile counts for in a station's similarity to it.
+const (
+	discoveryTagWeight     = 0.6
+	discoveryCountryWeight = 0.25
+	discoveryCodecWeight   = 0.15
+)
+
+// How far the suggestions kept or skipped move the weight of the stations sharing their traits.
+const (
+	// discoveryLearningRate is how much the weight changes for each suggestion kept (up) or skipped (down)
+	discoveryLearningRate = 0.25
+	discoveryMinBoost     = 0.25
+	discoveryMaxBoost     = 3
+	// discoveryBaseSimilarity keeps the stations sharing nothing with the profile in the draw, if seldom
+	discoveryBaseSimilarity = 0.1
+)
+
+// ErrNoTasteYet is returned when nothing was played yet to discover stations by.
+var ErrNoTasteYet = i18n.Error("discover.noTaste")
+
+// ErrNothingToDiscover is returned when every station found was played already.
+var ErrNothingToDiscover = i18n.Error("discover.none")
+
+// tasteProfile is how often each tag, country and codec was played, going by the history.
+type tasteProfile struct {
+	tags      map[string]int
+	countries map[string]int
+	codecs    map[string]int
+	// plays is how many plays the counts are out of.
+	plays  int
+	played map[uuid.UUID]bool
+}
+
+// tasteProfileOf returns the taste profile of the given history entries, each play counting once.
+func tasteProfileOf(entries []storage.HistoryEntry) tasteProfile {
+	profile := tasteProfile{
+		tags:      map[string]int{},
+		countries: map[string]int{},
+		codecs:    map[string]int{},
+		played:    map[uuid.UUID]bool{},
+	}
+	for _, entry := range entries {
+		profile.plays++
+		profile.played[entry.Station.StationUuid] = true
+		for _, tag := range stationTraits(entry.Station.Tags) {
+			profile.tags[tag]++
+		}
+		if countryCode := strings.ToUpper(entry.Station.CountryCode); countryCode != "" {
+			profile.countries[countryCode]++
+		}
+		if codec := strings.ToUpper(entry.Station.Codec); codec != "" {
+			profile.codecs[codec]++
+		}
+	}
+	return profile
+}
+
+// share returns the share of the plays the given count is of.
+func (p tasteProfile) share(count int) float64 {
+	if p.plays == 0 {
+		return 0
+	}
+	return float64(count) / float64(p.plays)
+}
+
+// similarity returns how close a station is to the profile, from 0 to 1: the share of the plays of its
+// most played tag, of its country and of its codec, weighted.
+func (p tasteProfile) similarity(station common.Station) float64 {
+	tagShare := 0.0
+	for _, tag := range stationTraits(station.Tags) {
+		if share := p.share(p.tags[tag]); share > tagShare {
+			tagShare = share
+		}
+	}
+	return discoveryTagWeight*tagShare +
+		discoveryCountryWeight*p.share(p.countries[strings.ToUpper(station.CountryCode)]) +
+		discoveryCodecWeight*p.share(p.codecs[strings.ToUpper(station.Codec)])
+}
+
+// topTags returns at most limit of the tags played the most, the first in alphabetical order among ties.
+func (p tasteProfile) topTags(limit int) []string {
+	tags := make([]string, 0, len(p.tags))
+	for tag := range p.tags {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if p.tags[tags[i]] != p.tags[tags[j]] {
+			return p.tags[tags[i]] > p.tags[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	if len(tags) > limit {
+		tags = tags[:limit]
+	}
+	return tags
+}
+
+// stationFeatures returns the traits of a station discovery learns from, e.g. "tag:jazz", "country:IT" and "codec:MP3".
+func stationFeatures(station common.Station) []string {
+	var features []string
+	for _, tag := range stationTraits(station.Tags) {
+		features = append(features, "tag:"+tag)
+	}
+	if station.CountryCode != "" {
+		features = append(features, "country:"+strings.ToUpper(station.CountryCode))
+	}
+	if station.Codec != "" {
+		features = append(features, "codec:"+strings.ToUpper(station.Codec))
+	}
+	return features
+}
+
+// discoveryFeedback tells, for each station feature, how many more of the suggestions sharing it were kept than skipped.
+type discoveryFeedback map[string]int
+
+// discoveryFeedbackOf learns from the stations suggested which ones were kept: those listened to for
+// discoveryKeptAfter at least since being suggested. Those played for less were skipped, while those
+// never played tell nothing.
+func discoveryFeedbackOf(discoveries []storage.Discovery, stats map[uuid.UUID]storage.PlayStats) discoveryFeedback {
+	feedback := discoveryFeedback{}
+	for _, discovery := range discoveries {
+		played, ok := stats[discovery.Station.StationUuid]
+		if !ok || played.LastPlayed.Before(discovery.SuggestedAt) {
+			continue
+		}
+		delta := -1
+		if played.Listened >= discoveryKeptAfter {
+			delta = 1
+		}
+		for _, feature := range stationFeatures(discovery.Station) {
+			feedback[feature] += delta
+		}
+	}
+	return feedback
+}
+
+// boost returns how much the suggestions kept and skipped sharing the station's features multiply its weight by.
+func (f discoveryFeedback) boost(station common.Station) float64 {
+	sum := 0
+	for _, feature := range stationFeatures(station) {
+		sum += f[feature]
+	}
+	return math.Max(discoveryMinBoost, math.Min(discoveryMaxBoost, 1+discoveryLearningRate*float64(sum)))
+}
+
+// discoveryWeight returns how likely a station is to be suggested: the closer to the profile,
+// the more voted for and the more like the suggestions kept, the likelier.
+func discoveryWeight(profile tasteProfile, feedback discoveryFeedback, station common.Station) float64 {
+	return (discoveryBaseSimilarity + profile.similarity(station)) * math.Log(2+float64(station.Votes)) * feedback.boost(station)
+}
+
+// sampleDiscoveries draws at most limit of the candidates at random without replacement, each one
+// as likely as its weight, listed in the order drawn. Duplicates are drawn once.
+func sampleDiscoveries(candidates []common.Station, weight func(common.Station) float64, limit int, rnd *rand.Rand) []common.Station {
+	type keyed struct {
+		station common.Station
+		key     float64
+	}
+	// Weighted sampling by Efraimidis and Spirakis: drawing the highest random^(1/weight) keys
+	var drawn []keyed
+	seen := make(map[uuid.UUID]bool)
+	for _, station := range candidates {
+		if seen[station.StationUuid] {
+			continue
+		}
+		seen[station.StationUuid] = true
+		w := weight(station)
+		if w <= 0 {
+			continue
+		}
+		drawn = append(drawn, keyed{station: station, key: math.Pow(rnd.Float64(), 1/w)})
+	}
+	sort.SliceStable(drawn, func(i, j int) bool {
+		return drawn[i].key > drawn[j].key
+	})
+	if len(drawn) > limit {
+		drawn = drawn[:limit]
+	}
+	stations := make([]common.Station, 0, len(drawn))
+	for _, d := range drawn {
+		stations = append(stations, d.station)
+	}
+	return stations
+}
+
+// discoverStations suggests stations never played, searched for by the tags and the country played the most,
+// drawn at random by how close they are to the taste profile, their votes and the suggestions kept before.
+// The stations found are narrowed down by eligible, e.g. to leave out the hidden ones. The suggestions are
+// remembered, so that the next ones learn from whether they were kept.
+func discoverStations(
+	browser api.RadioBrowserService,
+	history storage.HistoryStore,
+	playStats storage.PlayStatsStore,
+	discoveries storage.DiscoveryStore,
+	eligible func([]common.Station) []common.Station,
+	rnd *rand.Rand,
+	now time.Time,
+) ([]common.Station, error) {
+	entries, err := history.Recent(historyEntriesRead)
+	if err != nil {
+		return nil, err
+	}
+	profile := tasteProfileOf(entries)
+	if profile.plays == 0 {
+		return nil, ErrNoTasteYet
+	}
+	stats := playStats.All()
+	for stationUuid := range stats {
+		profile.played[stationUuid] = true
+	}
+
+	var queries []similarQuery
+	for _, tag := range profile.topTags(discoveryTagQueries) {
+		queries = append(queries, similarQuery{query: common.StationQueryByTagExact, term: tag})
+	}
+	if countryCode := mostCounted(profile.countries); countryCode != "" {
+		queries = append(queries, similarQuery{query: common.StationQueryByCountryCodeExact, term: countryCode})
+	}
+	if len(queries) == 0 {
+		return nil, ErrNoTasteYet
+	}
+	var candidates []common.Station
+	var firstErr error
+	failed := 0
+	for _, q := range queries {
+		stations, err := browser.GetStations(q.query, q.term, "votes", true, 0, discoveryCandidates, true)
+		if err != nil {
+			// The other searches may still bring in enough
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+			continue
+		}
+		for _, station := range stations {
+			if !profile.played[station.StationUuid] {
+				candidates = append(candidates, station)
+			}
+		}
+	}
+	if failed == len(queries) {
+		return nil, firstErr
+	}
+	candidates = eligible(candidates)
+	if len(candidates) == 0 {
+		return nil, ErrNothingToDiscover
+	}
+
+	feedback := discoveryFeedback{}
+	if suggested, err := discoveries.All(); err == nil {
+		feedback = discoveryFeedbackOf(suggested, stats)
+	}
+	picked := sampleDiscoveries(candidates, func(station common.Station) float64 {
+		return discoveryWeight(profile, feedback, station)
+	}, discoverySize, rnd)
+	// A suggestion that can't be remembered is only not learnt from
+	_ = discoveries.Add(picked, now)
+	return picked, nil
+}
+
+// Messages
+
+// discoveryRequestedMsg asks the root model to suggest stations by the taste profile.
+type discoveryRequestedMsg struct{}
+
+// Commands
+
+func showDiscoveryCmd() tea.Msg {
+	return discoveryRequestedMsg{}
+}
+
+// showDiscovery stops the station being played and suggests stations by the taste profile.
+func showDiscovery(playbackManager playback.PlaybackManagerService) tea.Cmd {
+	return tea.Sequence(stopStationCmd(playbackManager), showDiscoveryCmd)
+}
+
+// discoverStationsCmd lists the stations suggested by discoverStations, going back to the search form if it fails.
+func (m Model) discoverStationsCmd() tea.Cmd {
+	browser, history, playStats, discoveries := m.browser, m.history, m.playStats, m.discoveries
+	blocklist, reportStore, contentFilter := m.blocklist, m.reportStore, m.contentFilter
+	return func() tea.Msg {
+		if history == nil || playStats == nil || discoveries == nil {
+			return searchFailedMsg{err: ErrNoTasteYet}
+		}
+		now := time.Now()
+		stations, err := discoverStations(browser, history, playStats, discoveries, func(stations []common.Station) []common.Station {
+			return withoutBlockedStations(blocklist, withoutReportedStations(reportStore, contentFilter.Apply(stations)))
+		}, rand.New(rand.NewSource(now.UnixNano())), now)
+		if err != nil {
+			return searchFailedMsg{err: err}
+		}
+		return switchToStationsModelMsg{stations: stations, page: stationPageKey{query: stationQueryDiscover}}
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTasteProfile(t *testing.T) {
+
+	profile := tasteProfileOf([]storage.HistoryEntry{
+		{Station: common.Station{StationUuid: uuid.New(), Tags: "Jazz, smooth jazz", CountryCode: "it", Codec: "mp3"}},
+		{Station: common.Station{StationUuid: uuid.New(), Tags: "jazz,rock", CountryCode: "FR", Codec: "AAC"}},
+		{Station: common.Station{StationUuid: uuid.New(), Tags: "rock", CountryCode: "IT", Codec: "MP3"}},
+		{Station: common.Station{StationUuid: uuid.New(), Tags: "jazz", CountryCode: "IT", Codec: "MP3"}},
+	})
+
+	t.Run("lists the tags played the most", func(t *testing.T) {
+		assert.Equal(t, []string{"jazz", "rock"}, profile.topTags(2))
+		assert.Equal(t, []string{"jazz", "rock", "smooth jazz"}, profile.topTags(5))
+	})
+
+	t.Run("scores stations by the share of plays of their traits", func(t *testing.T) {
+		same := common.Station{Tags: "JAZZ", CountryCode: "it", Codec: "mp3"}
+		assert.InDelta(t, 0.6*0.75+0.25*0.75+0.15*0.75, profile.similarity(same), 1e-9)
+		assert.Equal(t, 0.0, profile.similarity(common.Station{Tags: "metal", CountryCode: "DE", Codec: "OGG"}))
+	})
+
+	t.Run("is empty without a history", func(t *testing.T) {
+		empty := tasteProfileOf(nil)
+		assert.Equal(t, 0, empty.plays)
+		assert.Equal(t, 0.0, empty.similarity(common.Station{Tags: "jazz"}))
+	})
+
+}
+
+func TestDiscoveryFeedbackOf(t *testing.T) {
+
+	suggestedAt := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	kept := common.Station{StationUuid: uuid.New(), Tags: "jazz", CountryCode: "IT"}
+	skipped := common.Station{StationUuid: uuid.New(), Tags: "rock", CountryCode: "IT"}
+	ignored := common.Station{StationUuid: uuid.New(), Tags: "pop"}
+	playedBefore := common.Station{StationUuid: uuid.New(), Tags: "metal"}
+
+	feedback := discoveryFeedbackOf([]storage.Discovery{
+		{Station: kept, SuggestedAt: suggestedAt},
+		{Station: skipped, SuggestedAt: suggestedAt},
+		{Station: ignored, SuggestedAt: suggestedAt},
+		{Station: playedBefore, SuggestedAt: suggestedAt},
+	}, map[uuid.UUID]storage.PlayStats{
+		kept.StationUuid:         {Plays: 1, Listened: time.Hour, LastPlayed: suggestedAt.Add(time.Minute)},
+		skipped.StationUuid:      {Plays: 1, Listened: time.Minute, LastPlayed: suggestedAt.Add(time.Minute)},
+		playedBefore.StationUuid: {Plays: 1, Listened: time.Hour, LastPlayed: suggestedAt.Add(-time.Hour)},
+	})
+
+	assert.Equal(t, discoveryFeedback{"tag:jazz": 1, "tag:rock": -1, "country:IT": 0}, feedback)
+	assert.Equal(t, 1.25, feedback.boost(common.Station{Tags: "jazz"}))
+	assert.Equal(t, 0.75, feedback.boost(common.Station{Tags: "rock"}))
+	assert.Equal(t, 1.0, feedback.boost(common.Station{Tags: "pop"}))
+
+	t.Run("keeps the boost within bounds", func(t *testing.T) {
+		assert.Equal(t, float64(discoveryMaxBoost), discoveryFeedback{"tag:jazz": 100}.boost(common.Station{Tags: "jazz"}))
+		assert.Equal(t, float64(discoveryMinBoost), discoveryFeedback{"tag:rock": -100}.boost(common.Station{Tags: "rock"}))
+	})
+
+}
+
+func TestSampleDiscoveries(t *testing.T) {
+
+	heavy := common.Station{StationUuid: uuid.New(), Name: "Heavy"}
+	light := common.Station{StationUuid: uuid.New(), Name: "Light"}
+	never := common.Station{StationUuid: uuid.New(), Name: "Never"}
+	weight := func(station common.Station) float64 {
+		switch station.StationUuid {
+		case heavy.StationUuid:
+			return 10
+		case light.StationUuid:
+			return 1
+		}
+		return 0
+	}
+
+	t.Run("draws each candidate once and leaves out those weighing nothing", func(t *testing.T) {
+		stations := sampleDiscoveries([]common.Station{heavy, light, heavy, never}, weight, 10, rand.New(rand.NewSource(1)))
+		assert.ElementsMatch(t, []string{"Heavy", "Light"}, stationNames(stations))
+	})
+
+	t.Run("draws at most the limit", func(t *testing.T) {
+		assert.Len(t, sampleDiscoveries([]common.Station{heavy, light}, weight, 1, rand.New(rand.NewSource(1))), 1)
+	})
+
+	t.Run("draws the heavier candidates more often", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(1))
+		heavyFirst := 0
+		for i := 0; i < 200; i++ {
+			if sampleDiscoveries([]common.Station{light, heavy}, weight, 1, rnd)[0].Name == "Heavy" {
+				heavyFirst++
+			}
+		}
+		// 10 times out of 11 on average
+		assert.Greater(t, heavyFirst, 160)
+	})
+
+}
+
+func TestDiscoverStations(t *testing.T) {
+
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	listened := common.Station{StationUuid: uuid.New(), Name: "Listened", Tags: "jazz", CountryCode: "IT"}
+	history := &mocks.MockHistoryStore{
+		RecentFunc: func(limit int) ([]storage.HistoryEntry, error) {
+			return []storage.HistoryEntry{{Station: listened}}, nil
+		},
+	}
+	fresh := common.Station{StationUuid: uuid.New(), Name: "Fresh", Tags: "jazz", CountryCode: "IT"}
+	hidden := common.Station{StationUuid: uuid.New(), Name: "Hidden", Tags: "jazz"}
+	playedLongAgo := common.Station{StationUuid: uuid.New(), Name: "Played long ago", Tags: "jazz"}
+	playStats := &mocks.MockPlayStatsStore{
+		AllFunc: func() map[uuid.UUID]storage.PlayStats {
+			return map[uuid.UUID]storage.PlayStats{playedLongAgo.StationUuid: {Plays: 1}}
+		},
+	}
+	eligible := func(stations []common.Station) []common.Station {
+		var kept []common.Station
+		for _, station := range stations {
+			if station.StationUuid != hidden.StationUuid {
+				kept = append(kept, station)
+			}
+		}
+		return kept
+	}
+
+	t.Run("suggests stations never played by the tags and country played the most, and remembers them", func(t *testing.T) {
+
+		var searched []common.StationQuery
+		browser := &mocks.MockRadioBrowserService{
+			GetStationsFunc: func(query common.StationQuery, term string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				searched = append(searched, query)
+				return []common.Station{listened, fresh, hidden, playedLongAgo}, nil
+			},
+		}
+		var remembered []common.Station
+		discoveries := &mocks.MockDiscoveryStore{
+			AddFunc: func(stations []common.Station, suggestedAt time.Time) error {
+				remembered = stations
+				return nil
+			},
+		}
+
+		stations, err := discoverStations(browser, history, playStats, discoveries, eligible, rand.New(rand.NewSource(1)), now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Fresh"}, stationNames(stations))
+		assert.Equal(t, stations, remembered)
+		assert.Equal(t, []common.StationQuery{common.StationQueryByTagExact, common.StationQueryByCountryCodeExact}, searched)
+
+	})
+
+	t.Run("needs something played to go by", func(t *testing.T) {
+
+		empty := &mocks.MockHistoryStore{}
+
+		_, err := discoverStations(&mocks.MockRadioBrowserService{}, empty, playStats, &mocks.MockDiscoveryStore{}, eligible, rand.New(rand.NewSource(1)), now)
+
+		assert.ErrorIs(t, err, ErrNoTasteYet)
+
+	})
+
+	t.Run("tells when every station found was played", func(t *testing.T) {
+
+		browser := &mocks.MockRadioBrowserService{
+			GetStationsFunc: func(query common.StationQuery, term string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				return []common.Station{listened, playedLongAgo}, nil
+			},
+		}
+
+		_, err := discoverStations(browser, history, playStats, &mocks.MockDiscoveryStore{}, eligible, rand.New(rand.NewSource(1)), now)
+
+		assert.ErrorIs(t, err, ErrNothingToDiscover)
+
+	})
+
+	t.Run("fails when every search fails", func(t *testing.T) {
+
+		browser := &mocks.MockRadioBrowserService{
+			GetStationsFunc: func(query common.StationQuery, term string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				return nil, io.EOF
+			},
+		}
+
+		_, err := discoverStations(browser, history, playStats, &mocks.MockDiscoveryStore{}, eligible, rand.New(rand.NewSource(1)), now)
+
+		assert.ErrorIs(t, err, io.EOF)
+
+	})
+
+}
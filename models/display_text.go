@@ -0,0 +1,157 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/reflow/truncate"
+	"github.com/rivo/uniseg"
+)
+
+// ellipsis ends the text cut short to fit.
+const ellipsis = "…"
+
+// leftToRightMark ends text written right to left, so that what follows it on the line
+// (padding, the next column) isn't drawn as part of it by terminals that reorder such text.
+const leftToRightMark = "\u200e"
+
+// Emoji presentation selector, keycap and the range of regional indicators (pairs of which are flags)
+const (
+	emojiPresentation  = '\ufe0f'
+	keycap             = '\u20e3'
+	regionalIndicatorA = '\U0001f1e6'
+	regionalIndicatorZ = '\U0001f1ff'
+)
+
+// displayText makes text from radio-browser, such as a station name, safe to lay out in the terminal:
+// control characters are dropped (tabs and line breaks become spaces), directional overrides too,
+// and characters drawn as one but measured differently rune by rune and as a whole (emoji sequences, flags...)
+// are simplified so that every width computation agrees with the others. Text written right to left
+// ends with a left-to-right mark.
+func displayText(text string) string {
+	var b strings.Builder
+	rightToLeft := false
+	state := -1
+	for text != "" {
+		var cluster string
+		cluster, text, _, state = uniseg.FirstGraphemeClusterInString(text, state)
+		cluster = displayCluster(cluster)
+		rightToLeft = rightToLeft || isRightToLeft(cluster)
+		b.WriteString(cluster)
+	}
+	if rightToLeft {
+		b.WriteString(leftToRightMark)
+	}
+	return b.String()
+}
+
+// displayCluster returns a character as displayText shows it.
+func displayCluster(cluster string) string {
+	runes := []rune(cluster)
+	first := runes[0]
+	switch {
+	case first == '\t' || first == '\n' || first == '\r' || first == '\v' || first == '\f':
+		return " "
+	case unicode.IsControl(first), isDirectionalFormatting(first):
+		return ""
+	case len(runes) == 1:
+		if first == emojiPresentation {
+			return ""
+		}
+		return cluster
+	}
+
+	// Combining accents and the like are measured alike either way
+	width := 0
+	for _, r := range runes {
+		width += runewidth.RuneWidth(r)
+	}
+	if width == runewidth.StringWidth(cluster) && !strings.ContainsRune(cluster, emojiPresentation) && !strings.ContainsRune(cluster, keycap) {
+		return cluster
+	}
+	if len(runes) == 2 && isRegionalIndicator(runes[0]) && isRegionalIndicator(runes[1]) {
+		// The flag's country code, as wide as the flag
+		return string([]rune{'A' + runes[0] - regionalIndicatorA, 'A' + runes[1] - regionalIndicatorA})
+	}
+	// The emoji the sequence starts with, e.g. without its skin tone
+	return displayCluster(string(first))
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= regionalIndicatorA && r <= regionalIndicatorZ
+}
+
+// isDirectionalFormatting returns true for the embeddings, overrides and isolates that change
+// the direction of the text after them, up to the end of the line.
+func isDirectionalFormatting(r rune) bool {
+	return (r >= '\u202a' && r <= '\u202e') || (r >= '\u2066' && r <= '\u2069')
+}
+
+// isRightToLeft returns true if the character belongs to a script written right to left.
+func isRightToLeft(cluster string) bool {
+	for _, r := range cluster {
+		if unicode.In(r, unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana, unicode.Nko, unicode.Samaritan, unicode.Mandaic, unicode.Adlam) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateText cuts text prepared by displayText to the given width, ending it with an ellipsis if it doesn't fit.
+func truncateText(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if runewidth.StringWidth(text) <= width {
+		return text
+	}
+	rightToLeft := strings.Contains(text, leftToRightMark)
+	text = runewidth.Truncate(text, width, ellipsis)
+	if rightToLeft && !strings.HasSuffix(text, leftToRightMark) {
+		text += leftToRightMark
+	}
+	return text
+}
+
+// fitRow prepares the cells of a table row with displayText, cutting each of them to the width of its column.
+func fitRow(row table.Row, columns []table.Column) table.Row {
+	fitted := make(table.Row, len(row))
+	for i, cell := range row {
+		fitted[i] = displayText(cell)
+		if i < len(columns) {
+			fitted[i] = truncateText(fitted[i], columns[i].Width)
+		}
+	}
+	return fitted
+}
+
+// fitLine cuts a styled line to the given width, ending it with an ellipsis if it doesn't fit.
+// A width of zero or less, e.g. before the size of the terminal is known, leaves it as it is.
+func fitLine(line string, width int) string {
+	if width <= 0 || lipgloss.Width(line) <= width {
+		return line
+	}
+	return truncate.StringWithTail(line, uint(width), ellipsis)
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisplayText(t *testing.T) {
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"latin", "Radio GoGo", "Radio GoGo"},
+		{"accents, composed or not", "Café Café", "Café Café"},
+		{"CJK", "東京ラジオ", "東京ラジオ"},
+		{"emoji", "Lo-fi 🎵 beats", "Lo-fi 🎵 beats"},
+		{"ZWJ sequence", "Family 👨\u200d👩\u200d👧 FM", "Family 👨 FM"},
+		{"skin tone", "👍🏽 Radio", "👍 Radio"},
+		{"emoji presentation", "I ❤\ufe0f Jazz", "I ❤ Jazz"},
+		{"keycap", "1\ufe0f\u20e3 Live", "1 Live"},
+		{"flag", "🇮🇹 Radio Italia", "IT Radio Italia"},
+		{"tabs and line breaks", "Radio\tOne\r\nLive", "Radio One Live"},
+		{"escape sequences", "\x1b[31mRed\x1b[0m", "[31mRed[0m"},
+		{"directional overrides", "\u202eevil\u202c name", "evil name"},
+		{"right to left", "راديو سوا", "راديو سوا\u200e"},
+		{"mixed", "Radio שלום 🇮🇱", "Radio שלום IL\u200e"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text := displayText(test.text)
+			assert.Equal(t, test.want, text)
+			// lipgloss measures rune by rune, go-runewidth character by character
+			assert.Equal(t, runewidth.StringWidth(text), lipgloss.Width(text))
+		})
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{"fits", "Radio", 5, "Radio"},
+		{"latin", "Radio GoGo", 6, "Radio…"},
+		{"wide characters aren't cut in half", "東京ラジオ", 6, "東京…"},
+		{"wide characters leave a gap rather than overflow", "東京ラジオ", 5, "東京…"},
+		{"emoji", "🎵🎵🎵🎵", 5, "🎵🎵…"},
+		{"right to left keeps its mark", displayText("راديو سوا"), 4, "راد…\u200e"},
+		{"no room", "Radio", 0, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text := truncateText(test.text, test.width)
+			assert.Equal(t, test.want, text)
+			assert.LessOrEqual(t, lipgloss.Width(text), test.width)
+		})
+	}
+}
+
+func TestFitLine(t *testing.T) {
+
+	style := lipgloss.NewStyle().Bold(true)
+
+	assert.Equal(t, "short", fitLine("short", 10))
+	assert.Equal(t, "too long", fitLine("too long", 0))
+
+	line := fitLine(style.Render("Listening to: 東京ラジオ · 128 kbps"), 20)
+	assert.LessOrEqual(t, lipgloss.Width(line), 20)
+	assert.True(t, strings.HasSuffix(line, "…"))
+}
+
+func TestFitRow(t *testing.T) {
+
+	columns := []table.Column{{Title: "Name", Width: 6}, {Title: "Tags", Width: 4}}
+
+	row := fitRow(table.Row{"👨\u200d👩\u200d👧 Family", "pop\trock", "extra"}, columns)
+
+	assert.Equal(t, table.Row{"👨 Fa…", "pop…", "extra"}, row)
+}
+
+func TestStationsTableAlignment(t *testing.T) {
+
+	names := []string{
+		"Radio GoGo",
+		"東京ラジオ 東京ラジオ 東京ラジオ 東京ラジオ",
+		"راديو سوا - Radio Sawa",
+		"Family 👨\u200d👩\u200d👧 FM 🇮🇹 I ❤\ufe0f Jazz 👍🏽",
+		"Tab\tand\nnewline",
+		"Ελληνικό Ραδιόφωνο",
+	}
+	stations := make([]common.Station, len(names))
+	for i, name := range names {
+		stations[i] = common.Station{Name: name, Tags: "jazz,東京,موسيقى", CountryCode: "JP"}
+	}
+
+	model := newStationsTableModel(Theme{}, stations, config.DefaultStationColumns(), &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{})
+	model.SetHeight(len(names) + 2)
+
+	lines := strings.Split(model.View(), "\n")[:len(names)+1]
+	for _, line := range lines[1:] {
+		assert.Equal(t, lipgloss.Width(lines[0]), lipgloss.Width(line), line)
+		assert.Equal(t, runewidth.StringWidth(lines[0]), runewidth.StringWidth(line), line)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/playback"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The volume stations are turned down to, in percent of their own, unless configured otherwise
+const defaultDuckLevel = 20
+
+// duckLevel returns the volume stations are turned down to, in percent of their own.
+func duckLevel(cfg config.Config) int {
+	if cfg.Ducking.Level <= 0 || cfg.Ducking.Level > 100 {
+		return defaultDuckLevel
+	}
+	return cfg.Ducking.Level
+}
+
+// Messages
+
+// duckMsg asks to turn the station being played down, or back up, as an external trigger did.
+type duckMsg struct {
+	ducked bool
+}
+
+// NewDuckMsg wraps what an external trigger asked for, so that it can be sent to the running program.
+func NewDuckMsg(ducked bool) tea.Msg {
+	return duckMsg{ducked: ducked}
+}
+
+// Commands
+
+// duckCmd turns the station being played down to level percent of its volume, or back up if ducked is false.
+// Stations played next follow, and only the station being played is toasted about.
+func duckCmd(playbackManager playback.PlaybackManagerService, level int, ducked bool) tea.Cmd {
+	return func() tea.Msg {
+		if !ducked {
+			level = 100
+		}
+		err := playback.Duck(playbackManager, level)
+		if !playbackManager.IsPlaying() {
+			return nil
+		}
+		if err != nil {
+			return nonFatalError{err: err}
+		}
+		if ducked {
+			return toastMsg{text: i18n.T("ducking.down"), kind: toastInfo}
+		}
+		return toastMsg{text: i18n.T("ducking.up"), kind: toastInfo}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/playback"
+)
+
+// fakeDucker is a playback manager recording the levels it's turned down to.
+type fakeDucker struct {
+	*mocks.MockPlaybackManagerService
+	levels []int
+}
+
+func (d *fakeDucker) Duck(level int) error {
+	d.levels = append(d.levels, level)
+	return nil
+}
+
+func TestDuckCmd(t *testing.T) {
+
+	t.Run("turns the station being played down and back up", func(t *testing.T) {
+
+		ducker := &fakeDucker{MockPlaybackManagerService: &mocks.MockPlaybackManagerService{IsPlayingResult: true}}
+
+		assert.Equal(t, toastMsg{text: i18n.T("ducking.down"), kind: toastInfo}, duckCmd(ducker, 30, true)())
+		assert.Equal(t, toastMsg{text: i18n.T("ducking.up"), kind: toastInfo}, duckCmd(ducker, 30, false)())
+		assert.Equal(t, []int{30, 100}, ducker.levels)
+
+	})
+
+	t.Run("turns the stations played next down quietly when nothing is playing", func(t *testing.T) {
+
+		ducker := &fakeDucker{MockPlaybackManagerService: &mocks.MockPlaybackManagerService{}}
+
+		assert.Nil(t, duckCmd(ducker, 30, true)())
+		assert.Equal(t, []int{30}, ducker.levels)
+
+	})
+
+	t.Run("tells when the playback engine can't turn the station down", func(t *testing.T) {
+
+		playbackManager := &mocks.MockPlaybackManagerService{IsPlayingResult: true}
+
+		msg := duckCmd(playbackManager, 30, true)()
+
+		assert.Equal(t, nonFatalError{err: playback.ErrCannotDuck}, msg)
+		assert.Nil(t, duckCmd(&mocks.MockPlaybackManagerService{}, 30, true)())
+
+	})
+
+}
+
+func TestDuckLevel(t *testing.T) {
+
+	var cfg config.Config
+	assert.Equal(t, defaultDuckLevel, duckLevel(cfg))
+
+	cfg.Ducking.Level = 5
+	assert.Equal(t, 5, duckLevel(cfg))
+
+	cfg.Ducking.Level = 150
+	assert.Equal(t, defaultDuckLevel, duckLevel(cfg))
+
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// ErrEditUnchanged is returned when an edit suggestion is submitted without changing anything.
+var ErrEditUnchanged = i18n.Error("editStation.unchanged")
+
+// Messages
+
+// editSubmittedMsg closes the edit form with the edit to suggest for the station.
+type editSubmittedMsg struct {
+	station common.Station
+	name    string
+	edit    common.StationEdit
+}
+
+type closeEditStationMsg struct{}
+
+// stationEditSuggestedMsg tells how radio-browser answered an edit suggestion (err is set if it couldn't).
+type stationEditSuggestedMsg struct {
+	station  common.Station
+	name     string
+	response common.StationEditResponse
+	err      error
+}
+
+// suggestedEdit logs how radio-browser answered an edit suggestion and tells about it,
+// pointing to the station's page on the radio-browser website if the server doesn't take suggestions.
+func (m Model) suggestedEdit(msg stationEditSuggestedMsg) tea.Cmd {
+	switch {
+	case errors.Is(msg.err, api.ErrEditsUnsupported):
+		_ = m.eventLog.Printf("%s (%s): edit suggestion not supported by the server", msg.name, msg.station.StationUuid)
+		return showErrorBannerCmd(errors.New(i18n.Tf("editStation.unsupported", api.StationEditURL(msg.station.StationUuid))))
+	case msg.err != nil:
+		_ = m.eventLog.Printf("%s (%s): edit suggestion failed: %v", msg.name, msg.station.StationUuid, msg.err)
+		return showErrorBannerCmd(msg.err)
+	case !msg.response.Ok:
+		_ = m.eventLog.Printf("%s (%s): edit suggestion refused: %s", msg.name, msg.station.StationUuid, msg.response.Message)
+		return showErrorBannerCmd(errors.New(i18n.Tf("editStation.refused", msg.response.Message)))
+	}
+	_ = m.eventLog.Printf("%s (%s): edit suggested: %s", msg.name, msg.station.StationUuid, msg.response.Message)
+	return showToastCmd(i18n.Tf("editStation.suggested", msg.name), toastSuccess)
+}
+
+// Commands
+
+// suggestEditCmd suggests the edit of the station to radio-browser.
+func suggestEditCmd(browser api.RadioBrowserService, msg editSubmittedMsg) tea.Cmd {
+	return func() tea.Msg {
+		response, err := browser.SuggestStationEdit(msg.station.StationUuid, msg.edit)
+		return stationEditSuggestedMsg{station: msg.station, name: msg.name, response: response, err: err}
+	}
+}
+
+func updateCommandsForEditStation() tea.Msg {
+	return bottomBarUpdateMsg{
+		commands: []string{
+			i18n.T("commands.cancel"),
+			i18n.T("commands.cycleFocus"),
+			i18n.T("commands.suggest"),
+		},
+	}
+}
+
+// Model
+
+// EditStationModel is a form suggesting new metadata for a station, pre-filled with the current one.
+type EditStationModel struct {
+	theme   Theme
+	station common.Station
+	name    string
+	// One input per field of common.StationEdit, in the order they're shown
+	inputs []textinput.Model
+	focus  int
+	err    string
+}
+
+// editStationFields are the i18n keys of the labels of the inputs of the edit form, in order.
+var editStationFields = []string{
+	"editStation.name",
+	"editStation.url",
+	"editStation.favicon",
+	"editStation.tags",
+	"editStation.countryCode",
+	"editStation.state",
+	"editStation.language",
+}
+
+// NewEditStationModel returns an EditStationModel for station, shown with the given name.
+func NewEditStationModel(theme Theme, station common.Station, name string) EditStationModel {
+	current := common.NewStationEdit(station)
+	values := []string{current.Name, current.Url, current.Favicon, current.Tags, current.CountryCode, current.State, current.Language}
+
+	labelWidth := editStationLabelWidth()
+	inputs := make([]textinput.Model, len(editStationFields))
+	for i, field := range editStationFields {
+		input := textinput.New()
+		input.Prompt = runewidth.FillRight(i18n.T(field), labelWidth) + " "
+		input.PromptStyle = theme.SecondaryText
+		input.TextStyle = theme.Text
+		input.Width = 40
+		input.SetValue(values[i])
+		inputs[i] = input
+	}
+	inputs[0].Focus()
+
+	return EditStationModel{
+		theme:   theme,
+		station: station,
+		name:    name,
+		inputs:  inputs,
+	}
+}
+
+// editStationLabelWidth is the width of the longest label of the edit form, so that the inputs line up.
+func editStationLabelWidth() int {
+	width := 0
+	for _, field := range editStationFields {
+		if w := runewidth.StringWidth(i18n.T(field)); w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// edit returns the edit described by the form, or an error explaining what's wrong with it.
+func (m EditStationModel) edit() (common.StationEdit, error) {
+	edit := common.StationEdit{
+		Name:        m.inputs[0].Value(),
+		Url:         m.inputs[1].Value(),
+		Favicon:     m.inputs[2].Value(),
+		Tags:        m.inputs[3].Value(),
+		CountryCode: m.inputs[4].Value(),
+		State:       m.inputs[5].Value(),
+		Language:    m.inputs[6].Value(),
+	}
+	if err := edit.Validate(); err != nil {
+		return common.StationEdit{}, err
+	}
+	if edit.Values().Encode() == common.NewStationEdit(m.station).Values().Encode() {
+		return common.StationEdit{}, ErrEditUnchanged
+	}
+	return edit, nil
+}
+
+// moveFocus focuses the input by ahead of the focused one, wrapping around.
+func (m *EditStationModel) moveFocus(by int) {
+	m.inputs[m.focus].Blur()
+	m.focus = (m.focus + by + len(m.inputs)) % len(m.inputs)
+	m.inputs[m.focus].Focus()
+}
+
+// Bubbletea
+
+func (m EditStationModel) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, updateCommandsForEditStation)
+}
+
+func (m EditStationModel) Update(msg tea.Msg) (EditStationModel, tea.Cmd) {
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			return m, func() tea.Msg {
+				return closeEditStationMsg{}
+			}
+		case "tab", "down":
+			m.moveFocus(1)
+			return m, nil
+		case "shift+tab", "up":
+			m.moveFocus(-1)
+			return m, nil
+		case "enter":
+			edit, err := m.edit()
+			if err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			submitted := editSubmittedMsg{station: m.station, name: m.name, edit: edit}
+			return m, func() tea.Msg {
+				return submitted
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return m, cmd
+}
+
+func (m EditStationModel) View() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.Tf("editStation.title", m.name)) + "\n\n"
+	for _, input := range m.inputs {
+		v += input.View() + "\n"
+	}
+	v += "\n"
+	if m.err != "" {
+		v += m.theme.RenderError(m.err) + "\n"
+	}
+	v += m.theme.TertiaryText.Render(i18n.T("editStation.hint")) + "\n"
+
+	return v
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditStationModel(t *testing.T) {
+
+	station := common.Station{
+		StationUuid: uuid.New(),
+		Name:        "Jaz FM",
+		Url:         common.RadioGoGoURL{URL: url.URL{Scheme: "https", Host: "jazz.fm", Path: "/live"}},
+		CountryCode: "GB",
+		Tags:        "jazz",
+	}
+
+	typeText := func(model EditStationModel, text string) EditStationModel {
+		for _, r := range text {
+			model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		return model
+	}
+
+	t.Run("is pre-filled with the station's metadata", func(t *testing.T) {
+		view := NewEditStationModel(Theme{}, station, "Jaz FM").View()
+		assert.Contains(t, view, "https://jazz.fm/live")
+		assert.Contains(t, view, "GB")
+	})
+
+	t.Run("suggests the edited metadata", func(t *testing.T) {
+		model := NewEditStationModel(Theme{}, station, "Jaz FM")
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyHome})
+		model = typeText(model, "New ")
+		// The languages are last
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+		model = typeText(model, "english")
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		msg := cmd().(editSubmittedMsg)
+		assert.Equal(t, "New Jaz FM", msg.edit.Name)
+		assert.Equal(t, "https://jazz.fm/live", msg.edit.Url)
+		assert.Empty(t, msg.edit.Favicon)
+		assert.Equal(t, "jazz", msg.edit.Tags)
+		assert.Equal(t, "GB", msg.edit.CountryCode)
+		assert.Equal(t, "english", msg.edit.Language)
+	})
+
+	t.Run("explains what's wrong with the edit", func(t *testing.T) {
+		model, cmd := NewEditStationModel(Theme{}, station, "Jaz FM").Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Nil(t, cmd)
+		assert.Equal(t, ErrEditUnchanged.Error(), model.err)
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+		model, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Nil(t, cmd)
+		assert.Equal(t, common.ErrInvalidStreamURL.Error(), model.err)
+	})
+
+	t.Run("is closed with esc", func(t *testing.T) {
+		_, cmd := NewEditStationModel(Theme{}, station, "Jaz FM").Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Equal(t, closeEditStationMsg{}, cmd())
+	})
+}
+
+func TestModel_SuggestedEdit(t *testing.T) {
+
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	model := NewModel(config.Config{}, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{}, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+
+	t.Run("tells the edit was suggested", func(t *testing.T) {
+		msg := model.suggestedEdit(stationEditSuggestedMsg{station: station, name: "Jazz FM", response: common.StationEditResponse{Ok: true}})()
+		assert.Equal(t, toastMsg{text: "Edit of Jazz FM suggested", kind: toastSuccess}, msg)
+	})
+
+	t.Run("points to the website when the server doesn't take suggestions", func(t *testing.T) {
+		msg := model.suggestedEdit(stationEditSuggestedMsg{station: station, name: "Jazz FM", err: api.ErrEditsUnsupported})()
+		assert.Contains(t, msg.(errorBannerMsg).err.Error(), api.StationEditURL(station.StationUuid))
+	})
+
+	t.Run("tells why the suggestion wasn't taken", func(t *testing.T) {
+		msg := model.suggestedEdit(stationEditSuggestedMsg{station: station, name: "Jazz FM", response: common.StationEditResponse{Message: "invalid tags"}})()
+		assert.Contains(t, msg.(errorBannerMsg).err.Error(), "invalid tags")
+
+		msg = model.suggestedEdit(stationEditSuggestedMsg{station: station, name: "Jazz FM", err: errors.New("timeout")})()
+		assert.Contains(t, msg.(errorBannerMsg).err.Error(), "timeout")
+	})
+}
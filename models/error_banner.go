@@ -0,0 +1,102 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Messages
+
+// errorBannerMsg shows err in the banner without the views handling it as a failure,
+// for the views that already did.
+type errorBannerMsg struct {
+	err error
+}
+
+// Commands
+
+func showErrorBannerCmd(err error) tea.Cmd {
+	return func() tea.Msg {
+		return errorBannerMsg{err: err}
+	}
+}
+
+// Model
+
+// ErrorBannerModel shows the latest recoverable error, e.g. a search that timed out, above the bottom bar
+// of the view it happened in, until it's dismissed or another view is opened. A new error replaces the one shown.
+// Errors RadioGoGo can't recover from open the error view instead.
+type ErrorBannerModel struct {
+	theme Theme
+	text  string
+	// The view the error happened in
+	state modelState
+}
+
+func NewErrorBannerModel(theme Theme) ErrorBannerModel {
+	return ErrorBannerModel{theme: theme}
+}
+
+// Shown returns true if the banner shows an error in the given view.
+func (m ErrorBannerModel) Shown(state modelState) bool {
+	return m.text != "" && m.state == state
+}
+
+// Dismiss hides the error shown.
+func (m ErrorBannerModel) Dismiss() ErrorBannerModel {
+	m.text = ""
+	return m
+}
+
+// Height returns the number of lines taken by the banner in the given view (0 when it shows nothing).
+func (m ErrorBannerModel) Height(state modelState) int {
+	if !m.Shown(state) {
+		return 0
+	}
+	return 1
+}
+
+// Bubbletea
+
+// Update shows the errors reported while in the given view.
+func (m ErrorBannerModel) Update(msg tea.Msg, state modelState) ErrorBannerModel {
+	switch msg := msg.(type) {
+	case nonFatalError:
+		m.text = msg.err.Error()
+		m.state = state
+	case errorBannerMsg:
+		m.text = msg.err.Error()
+		m.state = state
+	}
+	return m
+}
+
+func (m ErrorBannerModel) View(state modelState) string {
+	if !m.Shown(state) {
+		return ""
+	}
+	if m.theme.Accessible {
+		return i18n.Tf("accessible.error", m.text) + " (" + i18n.T("errorBanner.dismiss") + ")\n"
+	}
+	return m.theme.RenderError(m.text) + "  " + m.theme.TertiaryText.Render(i18n.T("errorBanner.dismiss")) + "\n"
+}
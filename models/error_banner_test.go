@@ -0,0 +1,104 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorBannerModel(t *testing.T) {
+
+	t.Run("shows the latest error in the view it happened in", func(t *testing.T) {
+
+		model := NewErrorBannerModel(Theme{})
+		assert.Equal(t, 0, model.Height(searchState))
+
+		model = model.Update(nonFatalError{err: errors.New("timeout")}, searchState)
+		model = model.Update(errorBannerMsg{err: errors.New("can't vote")}, searchState)
+		assert.Contains(t, model.View(searchState), errorGlyph+" can't vote")
+		assert.Equal(t, 1, model.Height(searchState))
+
+		assert.Equal(t, "", model.View(stationsState))
+		assert.Equal(t, 0, model.Height(stationsState))
+
+	})
+
+	t.Run("hides the error once dismissed", func(t *testing.T) {
+
+		model := NewErrorBannerModel(Theme{}).Update(nonFatalError{err: errors.New("timeout")}, searchState)
+
+		model = model.Dismiss()
+		assert.False(t, model.Shown(searchState))
+		assert.Equal(t, "", model.View(searchState))
+
+	})
+
+}
+
+func TestModelErrorBanner(t *testing.T) {
+
+	newModel := func(state modelState) Model {
+		model := NewModel(config.Config{}, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{}, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		model.width = 80
+		model.height = 24
+		model.state = state
+		return model
+	}
+
+	t.Run("shows errors above the bottom bar, whatever the view", func(t *testing.T) {
+
+		updated, _ := newModel(bootState).Update(nonFatalError{err: errors.New("can't play")})
+
+		assert.Contains(t, updated.(Model).View(), "can't play")
+		assert.Equal(t, 1, updated.(Model).panesHeight())
+
+	})
+
+	t.Run("dismisses the error on esc, before the view handles it", func(t *testing.T) {
+
+		updated, _ := newModel(searchState).Update(nonFatalError{err: errors.New("timeout")})
+
+		updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Nil(t, cmd)
+		assert.False(t, updated.(Model).errorBannerModel.Shown(searchState))
+		assert.Equal(t, searchState, updated.(Model).state)
+
+	})
+
+	t.Run("goes back to the search form when a search fails", func(t *testing.T) {
+
+		updated, cmd := newModel(loadingState).Update(searchFailedMsg{err: errors.New("timeout")})
+
+		for _, cmd := range sequenceCmds(cmd()) {
+			updated, _ = updated.Update(cmd())
+		}
+		assert.Equal(t, searchState, updated.(Model).state)
+		assert.True(t, updated.(Model).errorBannerModel.Shown(searchState))
+
+	})
+
+}
@@ -23,6 +23,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/playback"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -31,27 +35,72 @@ const (
 	quitTicks = 30
 )
 
+// installCommands tell how packages are usually installed on each operating system (Linux otherwise).
+var installCommands = map[string]string{
+	"darwin":  "brew install %s",
+	"windows": "scoop install %s",
+	"freebsd": "pkg install %s",
+	"netbsd":  "pkg_add %s",
+	"openbsd": "doas pkg_add %s",
+	"linux":   "sudo apt install %s",
+}
+
+// playbackRemedies returns what to do when the playback backend configured can't be used on goos.
+func playbackRemedies(cfg config.Config, goos string) []string {
+	install := installCommands[goos]
+	if install == "" {
+		install = installCommands["linux"]
+	}
+	installRemedies := func(pkg string) []string {
+		return []string{
+			i18n.Tf("error.remedy.install", pkg, fmt.Sprintf(install, pkg)),
+			i18n.T("error.remedy.path"),
+		}
+	}
+
+	switch cfg.Output.Mode {
+	case playback.OutputSnapcast, playback.OutputIcecast:
+		target := cfg.Output.Snapcast
+		if cfg.Output.Mode == playback.OutputIcecast {
+			target = cfg.Output.Icecast
+		}
+		if target == "" {
+			return []string{i18n.Tf("error.remedy.outputTarget", string(cfg.Output.Mode), config.ConfigFile())}
+		}
+		return installRemedies("ffmpeg")
+	}
+
+	if cfg.PlaybackEngine == playback.FFPlay {
+		return append(installRemedies("ffmpeg"), i18n.Tf("error.remedy.engine", string(playback.MPV), config.ConfigFile()))
+	}
+	return append(installRemedies("mpv"), i18n.Tf("error.remedy.engine", string(playback.FFPlay), config.ConfigFile()))
+}
+
 // Messages
 
 type quitTickMsg struct{}
 
 // Model
 
+// ErrorModel tells why RadioGoGo can't go on and what can be done about it, before quitting.
+// Errors it can recover from are shown in the error banner instead.
 type ErrorModel struct {
 	theme Theme
 
-	message string
+	message  string
+	remedies []string
 
 	tickCount int
 	width     int
 	height    int
 }
 
-func NewErrorModel(theme Theme, err string) ErrorModel {
+func NewErrorModel(theme Theme, err string, remedies []string) ErrorModel {
 
 	return ErrorModel{
-		theme:   theme,
-		message: err,
+		theme:    theme,
+		message:  err,
+		remedies: remedies,
 	}
 
 }
@@ -86,9 +135,17 @@ func (m ErrorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m ErrorModel) View() string {
 
-	message := fmt.Sprintf("%s\n\nQuitting in %d seconds (or press \"q\" to exit now)...", m.message, quitTicks-m.tickCount)
+	v := "\n" + m.theme.RenderError(m.message) + "\n\n"
+
+	if len(m.remedies) > 0 {
+		v += m.theme.PrimaryText.Bold(true).Render(i18n.T("error.remedies")) + "\n"
+		for _, remedy := range m.remedies {
+			v += m.theme.Text.Render("  • "+remedy) + "\n"
+		}
+		v += "\n"
+	}
 
-	return "\n" + m.theme.ErrorText.Render(message) + "\n\n"
+	return v + m.theme.TertiaryText.Render(i18n.Tf("error.quitting", quitTicks-m.tickCount)) + "\n\n"
 
 }
 
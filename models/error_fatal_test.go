@@ -22,13 +22,16 @@ package models
 import (
 	"testing"
 
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/playback"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestErrorModel_Init(t *testing.T) {
 
-	model := NewErrorModel(Theme{}, "this is an error")
+	model := NewErrorModel(Theme{}, "this is an error", nil)
 
 	t.Run("broadcasts a quitTickMsg", func(t *testing.T) {
 
@@ -44,7 +47,7 @@ func TestErrorModel_Init(t *testing.T) {
 
 func TestErrorModel_Update(t *testing.T) {
 
-	model := NewErrorModel(Theme{}, "this is an error")
+	model := NewErrorModel(Theme{}, "this is an error", nil)
 
 	t.Run("broadcasts a quitMsg when 'q' is pressed", func(t *testing.T) {
 
@@ -84,3 +87,49 @@ func TestErrorModel_Update(t *testing.T) {
 	})
 
 }
+
+func TestErrorModel_View(t *testing.T) {
+
+	model := NewErrorModel(Theme{}, "this is an error", []string{"Install ffmpeg", "Add it to your PATH"})
+
+	view := model.View()
+	assert.Contains(t, view, "this is an error")
+	assert.Contains(t, view, "  • Install ffmpeg\n")
+	assert.Contains(t, view, "  • Add it to your PATH\n")
+
+}
+
+func TestPlaybackRemedies(t *testing.T) {
+
+	t.Run("tells to install the engine, or use the other one", func(t *testing.T) {
+
+		cfg := config.Config{PlaybackEngine: playback.FFPlay}
+
+		remedies := playbackRemedies(cfg, "darwin")
+		assert.Len(t, remedies, 3)
+		assert.Contains(t, remedies[0], "brew install ffmpeg")
+		assert.Contains(t, remedies[2], "playbackEngine: mpv")
+
+		cfg.PlaybackEngine = playback.MPV
+		remedies = playbackRemedies(cfg, "plan9")
+		assert.Contains(t, remedies[0], "sudo apt install mpv")
+		assert.Contains(t, remedies[2], "playbackEngine: ffplay")
+
+	})
+
+	t.Run("tells to set the address of the network output", func(t *testing.T) {
+
+		cfg := config.Config{}
+		cfg.Output.Mode = playback.OutputSnapcast
+
+		remedies := playbackRemedies(cfg, "linux")
+		assert.Len(t, remedies, 1)
+		assert.Contains(t, remedies[0], "output.snapcast")
+
+		cfg.Output.Snapcast = "/tmp/snapfifo"
+		remedies = playbackRemedies(cfg, "windows")
+		assert.Contains(t, remedies[0], "scoop install ffmpeg")
+
+	})
+
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/eventlog"
+	"github.com/zi0p4tch0/radiogogo/events"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// newSessionBus returns the bus the events of the session are published on, with the stations played
+// added to the history, the searches made remembered, and every event written to the event log.
+// Any of the stores, and the log, can be nil.
+func newSessionBus(history storage.HistoryStore, searches storage.SearchStore, log *eventlog.Log) *events.Bus {
+	bus := events.NewBus()
+	if history != nil {
+		bus.Subscribe(func(event events.Event) error {
+			switch {
+			case event.Kind == events.StreamInterrupted:
+				return history.AddInterruption(event.Station.StationUuid, event.Interruption)
+			case event.Resumed:
+				// A station reconnected carries on its listening session
+				return nil
+			}
+			return history.Add(event.Station, time.Now())
+		}, events.PlaybackStarted, events.StreamInterrupted)
+	}
+	if searches != nil {
+		bus.Subscribe(func(event events.Event) error {
+			return searches.SetLast(event.Search)
+		}, events.SearchCompleted)
+	}
+	if log != nil {
+		bus.Subscribe(func(event events.Event) error {
+			logEvent(log, event)
+			return nil
+		})
+	}
+	return bus
+}
+
+// logEvent writes event to the event log. Failing to is not worth reporting.
+func logEvent(log *eventlog.Log, event events.Event) {
+	switch event.Kind {
+	case events.PlaybackStarted:
+		_ = log.Printf("%s (%s): playing", event.Station.Name, event.Station.StationUuid)
+	case events.TrackChanged:
+		if event.Title != "" {
+			_ = log.Printf("%s (%s): now playing %q", event.Station.Name, event.Station.StationUuid, event.Title)
+		}
+	case events.SearchCompleted:
+		_ = log.Printf("search %q: %d stations found", event.Search.QueryText, event.Results)
+	case events.BookmarkAdded:
+		_ = log.Printf("%s (%s): bookmarked", event.Station.Name, event.Station.StationUuid)
+	case events.StreamInterrupted:
+		_ = log.Printf("%s (%s): stream %s (%s)", event.Station.Name, event.Station.StationUuid, event.Interruption.Kind, event.Interruption.Reason)
+	}
+}
+
+// publishEvents publishes the events msg stands for, along with a change of the track being played
+// from title, the one announced before msg was handled.
+func (m Model) publishEvents(msg tea.Msg, title string) tea.Cmd {
+	var published []events.Event
+	switch msg := msg.(type) {
+	case playbackStartedMsg:
+		published = append(published, events.Event{Kind: events.PlaybackStarted, Station: msg.station, Resumed: m.resumed})
+	case switchToStationsModelMsg:
+		// Listing the history or a stream URL is no search
+		if msg.page.fetchable() {
+			published = append(published, events.Event{
+				Kind: events.SearchCompleted,
+				Search: storage.Search{
+					Query:     msg.page.query,
+					QueryText: msg.page.queryText,
+					Filter:    msg.page.filter,
+				},
+				Results: len(msg.stations),
+			})
+		}
+	case bookmarkToggledMsg:
+		if msg.bookmarked {
+			published = append(published, events.Event{Kind: events.BookmarkAdded, Station: msg.station})
+		}
+	}
+	if m.nowPlayingModel.title != title && m.nowPlayingModel.station != nil {
+		published = append(published, events.Event{Kind: events.TrackChanged, Station: *m.nowPlayingModel.station, Title: m.nowPlayingModel.title})
+	}
+	return publishEventsCmd(m.bus, published)
+}
+
+// interruptionCmd publishes the stream of station being interrupted, as kind and reason tell. It does nothing without a station.
+func (m Model) interruptionCmd(station common.Station, kind storage.InterruptionKind, reason string) tea.Cmd {
+	if station.StationUuid == uuid.Nil {
+		return nil
+	}
+	return publishEventsCmd(m.bus, []events.Event{{
+		Kind:         events.StreamInterrupted,
+		Station:      station,
+		Interruption: storage.Interruption{Kind: kind, At: time.Now(), Reason: reason},
+	}})
+}
+
+// Commands
+
+// publishEventsCmd publishes events on bus, away from the UI as subscribers may write to disk,
+// reporting the first subscriber that failed.
+func publishEventsCmd(bus *events.Bus, published []events.Event) tea.Cmd {
+	if bus == nil || len(published) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		var firstErr error
+		for _, event := range published {
+			if err := bus.Publish(event); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		if firstErr != nil {
+			return nonFatalError{stopPlayback: false, err: firstErr}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/events"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModel_PublishEvents(t *testing.T) {
+
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+
+	t.Run("records the stations played", func(t *testing.T) {
+		var played []common.Station
+		model := newStartupTestModel("", &mocks.MockHistoryStore{
+			AddFunc: func(station common.Station, playedAt time.Time) error {
+				played = append(played, station)
+				return nil
+			},
+		}, nil)
+
+		cmd := model.publishEvents(playbackStartedMsg{station: station}, "")
+		assert.Nil(t, cmd())
+		assert.Equal(t, []common.Station{station}, played)
+	})
+
+	t.Run("remembers searches but not the history or stream URLs", func(t *testing.T) {
+		var saved []storage.Search
+		model := newStartupTestModel("", nil, &mocks.MockSearchStore{
+			SetLastFunc: func(search storage.Search) error {
+				saved = append(saved, search)
+				return nil
+			},
+		})
+
+		page := stationPageKey{query: common.StationQueryByTag, queryText: "jazz"}
+		assert.Nil(t, model.publishEvents(switchToStationsModelMsg{page: page}, "")())
+		assert.Nil(t, model.publishEvents(switchToStationsModelMsg{page: stationPageKey{query: stationQueryHistory}}, ""))
+		assert.Nil(t, model.publishEvents(switchToStationsModelMsg{page: stationPageKey{query: stationQueryURL}}, ""))
+		assert.Equal(t, []storage.Search{{Query: common.StationQueryByTag, QueryText: "jazz"}}, saved)
+	})
+
+	t.Run("carries on the session of a station reconnected, recording what interrupted it", func(t *testing.T) {
+		var played []common.Station
+		var interrupted []storage.Interruption
+		model := newStartupTestModel("", &mocks.MockHistoryStore{
+			AddFunc: func(station common.Station, playedAt time.Time) error {
+				played = append(played, station)
+				return nil
+			},
+			AddInterruptionFunc: func(stationUuid uuid.UUID, interruption storage.Interruption) error {
+				assert.Equal(t, station.StationUuid, stationUuid)
+				interrupted = append(interrupted, interruption)
+				return nil
+			},
+		}, nil)
+
+		model = model.trackPlayingStation(playbackStartedMsg{station: station})
+		assert.Nil(t, model.publishEvents(playbackStartedMsg{station: station}, "")())
+
+		assert.Nil(t, model.interruptionCmd(station, storage.InterruptionReconnecting, "stream stalled")())
+		assert.Nil(t, model.interruptionCmd(common.Station{}, storage.InterruptionFailed, "gone"))
+		model = model.trackPlayingStation(reconnectStationMsg{station: station})
+		model = model.trackPlayingStation(playbackStartedMsg{station: station})
+		assert.Nil(t, model.publishEvents(playbackStartedMsg{station: station}, "")())
+
+		// Played again by hand, it's another session
+		model = model.trackPlayingStation(playbackStartedMsg{station: station})
+		assert.Nil(t, model.publishEvents(playbackStartedMsg{station: station}, "")())
+
+		assert.Equal(t, []common.Station{station, station}, played)
+		if assert.Len(t, interrupted, 1) {
+			assert.Equal(t, storage.InterruptionReconnecting, interrupted[0].Kind)
+			assert.Equal(t, "stream stalled", interrupted[0].Reason)
+		}
+	})
+
+	t.Run("reports failing to remember", func(t *testing.T) {
+		model := newStartupTestModel("", &mocks.MockHistoryStore{
+			AddFunc: func(station common.Station, playedAt time.Time) error { return errors.New("disk full") },
+		}, nil)
+
+		assert.IsType(t, nonFatalError{}, model.publishEvents(playbackStartedMsg{station: station}, "")())
+	})
+
+	t.Run("publishes bookmarks added and tracks changed to subscribers", func(t *testing.T) {
+		model := newStartupTestModel("", nil, nil)
+		var published []events.Event
+		model.bus.Subscribe(func(event events.Event) error {
+			published = append(published, event)
+			return nil
+		}, events.BookmarkAdded, events.TrackChanged)
+
+		assert.Nil(t, model.publishEvents(bookmarkToggledMsg{station: station, bookmarked: false}, ""))
+		assert.Nil(t, model.publishEvents(bookmarkToggledMsg{station: station, bookmarked: true}, "")())
+
+		model.nowPlayingModel.station = &station
+		model.nowPlayingModel.title = "So What"
+		assert.Nil(t, model.publishEvents(nowPlayingProbedMsg{}, "So What"))
+		assert.Nil(t, model.publishEvents(nowPlayingProbedMsg{}, "Blue in Green")())
+
+		assert.Equal(t, []events.Event{
+			{Kind: events.BookmarkAdded, Station: station},
+			{Kind: events.TrackChanged, Station: station, Title: "So What"},
+		}, published)
+	})
+
+}
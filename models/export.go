@@ -0,0 +1,99 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/export"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SetExportDir sets where the results are exported to with ":export".
+func (m *StationsModel) SetExportDir(dir string) {
+	m.exportDir = dir
+}
+
+// exportResults exports every page of the results fetched so far, as asked by ":export [csv|json] [fields]",
+// e.g. ":export json name,url,votes".
+func (m StationsModel) exportResults(c command) (tea.Model, tea.Cmd) {
+	format, fields := export.CSV, export.DefaultFields
+	args := c.args
+	if len(args) > 0 {
+		if parsed, err := export.ParseFormat(args[0]); err == nil {
+			format = parsed
+			args = args[1:]
+		}
+	}
+	switch len(args) {
+	case 0:
+	case 1:
+		parsed, err := export.ParseFields(args[0])
+		if err != nil {
+			return m, nonFatalErrorCmd(errors.New(i18n.Tf("stations.exportFailed", err)))
+		}
+		fields = parsed
+	default:
+		return m, nonFatalErrorCmd(errors.New(i18n.Tf("command.usage", "export [csv|json] [field,field...]")))
+	}
+	return m, exportResultsCmd(m.exportDir, format, fields, m.resultsToExport(), time.Now())
+}
+
+// resultsToExport returns the stations of every page of the results fetched so far,
+// without those left out of the table, or the stations of the table if its pages aren't cached.
+func (m StationsModel) resultsToExport() []common.Station {
+	fetched := m.pages.fetched(m.page)
+	if len(fetched) == 0 {
+		return m.allStations
+	}
+	return withoutBlockedStations(m.blocklist, withoutReportedStations(m.reportStore, m.contentFilter.Apply(fetched)))
+}
+
+// exportResultsCmd writes stations to a file in dir, named after the time and the format.
+func exportResultsCmd(dir string, format export.Format, fields []export.Field, stations []common.Station, now time.Time) tea.Cmd {
+	return func() tea.Msg {
+		path := filepath.Join(dir, "results-"+now.Format("20060102-150405")+"."+string(format))
+		if err := exportResults(path, format, fields, stations); err != nil {
+			return nonFatalError{stopPlayback: false, err: errors.New(i18n.Tf("stations.exportFailed", err))}
+		}
+		return toastMsg{text: i18n.Tf("stations.exported", len(stations), path), kind: toastSuccess}
+	}
+}
+
+func exportResults(path string, format export.Format, fields []export.Field, stations []common.Station) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := export.Write(file, format, fields, stations); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
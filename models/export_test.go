@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStationsModel_ExportResults(t *testing.T) {
+
+	newModel := func(cache *stationPageCache, key stationPageKey, stations []common.Station) StationsModel {
+		model := NewStationsModel(Theme{}, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{}, &mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, filter.ContentFilter{}, stations, nil, cache, key, true)
+		model.SetExportDir(t.TempDir())
+		return model
+	}
+
+	exported := func(t *testing.T, model StationsModel, pattern string) []map[string]interface{} {
+		paths, err := filepath.Glob(filepath.Join(model.exportDir, pattern))
+		assert.NoError(t, err)
+		if !assert.Len(t, paths, 1) {
+			return nil
+		}
+		data, err := os.ReadFile(paths[0])
+		assert.NoError(t, err)
+		var records []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(data, &records))
+		return records
+	}
+
+	t.Run("exports every page fetched so far with the fields asked for", func(t *testing.T) {
+
+		var requests int32
+		browser := newPagingBrowser(&requests)
+		cache := newStationPageCache()
+		first := stationPageKey{query: common.StationQueryByName, queryText: "jazz"}
+		stations, _ := cache.get(browser, first)
+		second := first
+		second.page = 1
+		_, _ = cache.get(browser, second)
+		model := newModel(cache, first, stations)
+
+		_, cmd := model.runCommand(command{name: "export", args: []string{"json", "name,votes"}})
+
+		assert.IsType(t, toastMsg{}, cmd())
+		records := exported(t, model, "results-*.json")
+		assert.Len(t, records, 2*stationPageSize)
+		assert.Equal(t, map[string]interface{}{"name": "jazz", "votes": float64(stationPageSize)}, records[stationPageSize])
+
+	})
+
+	t.Run("exports the stations shown when the pages aren't cached", func(t *testing.T) {
+
+		model := newModel(nil, stationPageKey{}, []common.Station{{Name: "Jazz FM"}, {Name: "Radio GoGo"}})
+
+		_, cmd := model.runCommand(command{name: "export"})
+
+		assert.IsType(t, toastMsg{}, cmd())
+		paths, _ := filepath.Glob(filepath.Join(model.exportDir, "results-*.csv"))
+		assert.Len(t, paths, 1)
+
+	})
+
+	t.Run("reports unknown fields", func(t *testing.T) {
+
+		model := newModel(nil, stationPageKey{}, []common.Station{{Name: "Jazz FM"}})
+
+		_, cmd := model.runCommand(command{name: "export", args: []string{"csv", "name,listeners"}})
+
+		assert.IsType(t, nonFatalError{}, cmd())
+
+	})
+
+}
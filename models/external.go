@@ -0,0 +1,180 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/secrets"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ErrNoHomepage is returned when the station has no homepage to open.
+var ErrNoHomepage = i18n.Error("external.noHomepage")
+
+// ErrUnsafeHomepage is returned when the homepage of the station isn't a web page: radio-browser data is
+// user-submitted, and a file: or custom scheme link would have the desktop run whatever it's bound to.
+var ErrUnsafeHomepage = i18n.Error("external.unsafeHomepage")
+
+// ErrExternalCredentials is returned when a station with stored credentials would be handed to the external
+// player: they'd be on its command line, which other users of the machine can read.
+var ErrExternalCredentials = i18n.Error("external.credentials")
+
+// ErrNoExternalPlayer is returned when the configuration names no external player to hand stations to.
+var ErrNoExternalPlayer = i18n.Error("external.noPlayer")
+
+// externalPlayerArgs returns the command line of template, split on spaces, with {url} replaced by streamURL
+// and {name} by the name of the station. The URL is appended if template doesn't refer to it.
+func externalPlayerArgs(template string, streamURL string, name string) []string {
+	args := strings.Fields(template)
+	hasURL := false
+	for i, arg := range args {
+		hasURL = hasURL || strings.Contains(arg, "{url}")
+		args[i] = strings.NewReplacer("{url}", streamURL, "{name}", name).Replace(arg)
+	}
+	if !hasURL {
+		args = append(args, streamURL)
+	}
+	return args
+}
+
+// Messages
+
+// externalPlayerStartedMsg tells that station was handed to the external player, named player.
+// withoutHeaders is true if the station has custom headers, which the external player doesn't get.
+type externalPlayerStartedMsg struct {
+	name           string
+	player         string
+	withoutHeaders bool
+}
+
+// Commands
+
+// openHomepageCmd opens the homepage of station in the web browser.
+func openHomepageCmd(openURL func(url string) error, station common.Station, name string) tea.Cmd {
+	return func() tea.Msg {
+		if station.Homepage.URL.Host == "" {
+			return nonFatalError{stopPlayback: false, err: ErrNoHomepage}
+		}
+		if scheme := strings.ToLower(station.Homepage.URL.Scheme); scheme != "http" && scheme != "https" {
+			return nonFatalError{stopPlayback: false, err: ErrUnsafeHomepage}
+		}
+		if err := openURL(station.Homepage.URL.String()); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return toastMsg{text: i18n.Tf("external.openingHomepage", name), kind: toastInfo}
+	}
+}
+
+// playExternallyCmd hands the stream URL of station to the external player run by template. Stations with
+// stored credentials are refused, not to put them on the command line, and their custom headers, which
+// a command template can't pass on, are flagged in the toast.
+func playExternallyCmd(
+	startProgram func(name string, args ...string) error,
+	template string,
+	credentials secrets.Store,
+	station common.Station,
+	name string,
+) tea.Cmd {
+	return func() tea.Msg {
+		if strings.TrimSpace(template) == "" {
+			return nonFatalError{stopPlayback: false, err: ErrNoExternalPlayer}
+		}
+		if credentials != nil {
+			_, err := secrets.GetStationCredentials(credentials, station.StationUuid.String())
+			if err == nil {
+				return nonFatalError{stopPlayback: false, err: ErrExternalCredentials}
+			}
+			if !errors.Is(err, secrets.ErrNotFound) {
+				return nonFatalError{stopPlayback: false, err: fmt.Errorf("%w: %v", ErrCredentialsUnavailable, err)}
+			}
+		}
+		args := externalPlayerArgs(template, stationStreamURL(station), name)
+		if err := startProgram(args[0], args[1:]...); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return externalPlayerStartedMsg{name: name, player: args[0], withoutHeaders: len(station.Headers) > 0}
+	}
+}
+
+// externalPlayerStarted stops playing, not to hear the station twice, and tells it was handed over.
+func externalPlayerStarted(isPlaying bool, stopStation tea.Cmd, msg externalPlayerStartedMsg) tea.Cmd {
+	toast := showToastCmd(i18n.Tf("external.started", msg.name, msg.player), toastSuccess)
+	if msg.withoutHeaders {
+		toast = showToastCmd(i18n.Tf("external.startedWithoutHeaders", msg.name, msg.player), toastInfo)
+	}
+	if !isPlaying {
+		return toast
+	}
+	return tea.Batch(stopStation, toast)
+}
+
+// Model
+
+// SetExternalPlayer sets the command stations are handed to with "e", see config.Config.
+func (m *StationsModel) SetExternalPlayer(template string) {
+	m.externalPlayer = template
+}
+
+// SetExternalPlayer sets the command stations are handed to with "e", see config.Config.
+func (m *BookmarksModel) SetExternalPlayer(template string) {
+	m.externalPlayer = template
+}
+
+// openSelectedHomepage opens the homepage of the highlighted station.
+func (m StationsModel) openSelectedHomepage() (tea.Model, tea.Cmd) {
+	if len(m.stations) == 0 {
+		return m, nil
+	}
+	station := m.stations[m.stationsTable.Cursor()]
+	return m, openHomepageCmd(m.openURL, station, stationDisplayName(m.labelStore, station))
+}
+
+// playSelectedExternally hands the highlighted station to the external player.
+func (m StationsModel) playSelectedExternally() (tea.Model, tea.Cmd) {
+	if len(m.stations) == 0 {
+		return m, nil
+	}
+	station := m.stations[m.stationsTable.Cursor()]
+	return m, playExternallyCmd(m.startProgram, m.externalPlayer, m.credentials, withStreamHeaders(m.streamHeaders, station), stationDisplayName(m.labelStore, station))
+}
+
+// openSelectedHomepage opens the homepage of the highlighted bookmark.
+func (m BookmarksModel) openSelectedHomepage() (tea.Model, tea.Cmd) {
+	station, ok := m.selectedStation()
+	if !ok {
+		return m, nil
+	}
+	return m, openHomepageCmd(m.openURL, station, stationDisplayName(m.labelStore, station))
+}
+
+// playSelectedExternally hands the highlighted bookmark to the external player.
+func (m BookmarksModel) playSelectedExternally() (tea.Model, tea.Cmd) {
+	station, ok := m.selectedStation()
+	if !ok {
+		return m, nil
+	}
+	return m, playExternallyCmd(m.startProgram, m.externalPlayer, m.credentials, withStreamHeaders(m.streamHeaders, station), stationDisplayName(m.labelStore, station))
+}
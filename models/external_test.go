@@ -0,0 +1,208 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/secrets"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalPlayerArgs(t *testing.T) {
+
+	assert.Equal(t,
+		[]string{"vlc", "--meta-title=Jazz FM", "https://example.com/live"},
+		externalPlayerArgs("vlc --meta-title={name} {url}", "https://example.com/live", "Jazz FM"),
+	)
+	assert.Equal(t,
+		[]string{"mpv", "--no-video", "https://example.com/live"},
+		externalPlayerArgs("  mpv --no-video ", "https://example.com/live", "Jazz FM"),
+	)
+
+}
+
+func TestStationsModel_External(t *testing.T) {
+
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	station.Url.URL = url.URL{Scheme: "https", Host: "example.com", Path: "/live"}
+	station.Homepage.URL = url.URL{Scheme: "https", Host: "jazz.example.com"}
+
+	newExternalModel := func(playbackManager *mocks.MockPlaybackManagerService, stations ...common.Station) StationsModel {
+		return NewStationsModel(
+			Theme{},
+			&mocks.MockRadioBrowserService{},
+			playbackManager,
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{},
+			&mocks.MockReportStore{},
+			filter.ContentFilter{},
+			stations,
+			config.DefaultStationColumns(),
+			newStationPageCache(),
+			stationPageKey{},
+			false,
+		)
+	}
+
+	t.Run("opens the homepage of the highlighted station", func(t *testing.T) {
+
+		var opened string
+		model := newExternalModel(&mocks.MockPlaybackManagerService{}, station)
+		model.openURL = func(url string) error {
+			opened = url
+			return nil
+		}
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+
+		assert.Equal(t, toastMsg{text: "Opening the homepage of Jazz FM", kind: toastInfo}, cmd())
+		assert.Equal(t, "https://jazz.example.com", opened)
+
+	})
+
+	t.Run("tells when there's no homepage", func(t *testing.T) {
+
+		noHomepage := station
+		noHomepage.Homepage = common.RadioGoGoURL{}
+		model := newExternalModel(&mocks.MockPlaybackManagerService{}, noHomepage)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+
+		assert.Equal(t, nonFatalError{stopPlayback: false, err: ErrNoHomepage}, cmd())
+
+	})
+
+	t.Run("refuses homepages that aren't web pages", func(t *testing.T) {
+
+		for _, homepage := range []url.URL{
+			{Scheme: "file", Host: "localhost", Path: "/etc/passwd"},
+			{Scheme: "smb", Host: "attacker.example.com", Path: "/share"},
+		} {
+			unsafe := station
+			unsafe.Homepage.URL = homepage
+			model := newExternalModel(&mocks.MockPlaybackManagerService{}, unsafe)
+			model.openURL = func(url string) error {
+				t.Errorf("%s opened", url)
+				return nil
+			}
+
+			_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+
+			assert.Equal(t, nonFatalError{stopPlayback: false, err: ErrUnsafeHomepage}, cmd())
+		}
+
+	})
+
+	t.Run("hands the stream to the external player, stopping playback", func(t *testing.T) {
+
+		var started []string
+		stopped := false
+		playbackManager := &mocks.MockPlaybackManagerService{
+			IsPlayingResult: true,
+			StopStationFunc: func() error {
+				stopped = true
+				return nil
+			},
+		}
+		model := newExternalModel(playbackManager, station)
+		model.SetExternalPlayer("vlc {url}")
+		model.startProgram = func(name string, args ...string) error {
+			started = append([]string{name}, args...)
+			return nil
+		}
+
+		newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+		msg := cmd()
+		assert.Equal(t, externalPlayerStartedMsg{name: "Jazz FM", player: "vlc"}, msg)
+		assert.Equal(t, []string{"vlc", "https://example.com/live"}, started)
+
+		_, cmd = newModel.Update(msg)
+		msgs := collectMsgs(cmd)
+		assert.True(t, stopped)
+		assert.Contains(t, msgs, playbackStoppedMsg{})
+		assert.Contains(t, msgs, toastMsg{text: "Jazz FM handed to vlc", kind: toastSuccess})
+
+	})
+
+	t.Run("refuses stations with credentials, not to put them on the command line", func(t *testing.T) {
+
+		credentials := &mocks.MockSecretStore{}
+		assert.NoError(t, secrets.SetStationCredentials(credentials, station.StationUuid.String(), secrets.StationCredentials{Token: "s3cret"}))
+		model := newExternalModel(&mocks.MockPlaybackManagerService{}, station)
+		model.SetExternalPlayer("vlc {url}")
+		model.SetCredentialStore(credentials)
+		model.startProgram = func(name string, args ...string) error {
+			t.Errorf("%s %v started", name, args)
+			return nil
+		}
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+
+		assert.Equal(t, nonFatalError{stopPlayback: false, err: ErrExternalCredentials}, cmd())
+
+	})
+
+	t.Run("tells that custom headers aren't handed over", func(t *testing.T) {
+
+		model := newExternalModel(&mocks.MockPlaybackManagerService{}, station)
+		model.SetExternalPlayer("vlc {url}")
+		model.SetCredentialStore(&mocks.MockSecretStore{})
+		model.SetStreamHeaderStore(&mocks.MockStreamHeaderStore{
+			HeadersFunc: func(stationUuid uuid.UUID) common.StreamHeaders {
+				return common.StreamHeaders{{Name: "Referer", Value: "https://jazz.example.com"}}
+			},
+		})
+		model.startProgram = func(name string, args ...string) error {
+			return nil
+		}
+
+		newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+		msg := cmd()
+		assert.Equal(t, externalPlayerStartedMsg{name: "Jazz FM", player: "vlc", withoutHeaders: true}, msg)
+
+		_, cmd = newModel.Update(msg)
+		assert.Contains(t, collectMsgs(cmd), toastMsg{text: "Jazz FM handed to vlc, without its custom headers", kind: toastInfo})
+
+	})
+
+	t.Run("tells when no external player is set", func(t *testing.T) {
+
+		model := newExternalModel(&mocks.MockPlaybackManagerService{}, station)
+		model.startProgram = func(name string, args ...string) error {
+			return errors.New("not to be started")
+		}
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+
+		assert.Equal(t, nonFatalError{stopPlayback: false, err: ErrNoExternalPlayer}, cmd())
+
+	})
+
+}
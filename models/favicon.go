@@ -0,0 +1,110 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	// Favicons are decoded from any of these formats
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/assets"
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/uuid"
+)
+
+// Size of the favicon thumbnail, in cells. Each cell shows two pixels, one above the other.
+const (
+	faviconColumns = 16
+	faviconRows    = 8
+)
+
+// Messages
+
+type faviconFetchedMsg struct {
+	stationUuid uuid.UUID
+	// nil if the favicon couldn't be fetched or decoded
+	image image.Image
+}
+
+// Commands
+
+// fetchFaviconCmd fetches the favicon of station through the asset cache.
+// Favicons are a nicety: failing to fetch one isn't reported.
+func fetchFaviconCmd(cache assets.Cache, station common.Station) tea.Cmd {
+	return func() tea.Msg {
+		content, err := cache.Get(station.Favicon.URL)
+		if err != nil {
+			return faviconFetchedMsg{stationUuid: station.StationUuid}
+		}
+		img, _, err := image.Decode(bytes.NewReader(content))
+		if err != nil {
+			return faviconFetchedMsg{stationUuid: station.StationUuid}
+		}
+		return faviconFetchedMsg{stationUuid: station.StationUuid, image: img}
+	}
+}
+
+// renderFavicon draws img with half blocks, scaled to faviconColumns by faviconRows cells.
+// Transparent pixels are left to the terminal background.
+func renderFavicon(img image.Image) string {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return ""
+	}
+
+	pixel := func(column int, row int) (lipgloss.Color, bool) {
+		x := bounds.Min.X + column*bounds.Dx()/faviconColumns
+		y := bounds.Min.Y + row*bounds.Dy()/(faviconRows*2)
+		r, g, b, a := img.At(x, y).RGBA()
+		if a < 0x8000 {
+			return "", false
+		}
+		return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)), true
+	}
+
+	var lines []string
+	for row := 0; row < faviconRows; row++ {
+		var line strings.Builder
+		for column := 0; column < faviconColumns; column++ {
+			top, topVisible := pixel(column, row*2)
+			bottom, bottomVisible := pixel(column, row*2+1)
+			switch {
+			case topVisible && bottomVisible:
+				line.WriteString(lipgloss.NewStyle().Foreground(top).Background(bottom).Render("▀"))
+			case topVisible:
+				line.WriteString(lipgloss.NewStyle().Foreground(top).Render("▀"))
+			case bottomVisible:
+				line.WriteString(lipgloss.NewStyle().Foreground(bottom).Render("▄"))
+			default:
+				line.WriteString(" ")
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n")
+}
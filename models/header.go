@@ -21,10 +21,12 @@ package models
 
 import (
 	"fmt"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/i18n"
 	"github.com/zi0p4tch0/radiogogo/playback"
 )
 
@@ -37,6 +39,11 @@ type HeaderModel struct {
 	showOffset    bool
 	stationOffset int
 	totalStations int
+	moreStations  bool
+	// How many scheduled recordings are in progress
+	recordings int
+	// The profile in use, unless it's the default one
+	profile string
 }
 
 func NewHeaderModel(theme Theme, playbackManager playback.PlaybackManagerService) HeaderModel {
@@ -55,6 +62,7 @@ func (m HeaderModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case stationCursorMovedMsg:
 		m.stationOffset = msg.offset
 		m.totalStations = msg.totalStations
+		m.moreStations = msg.moreStations
 	}
 	return m, nil
 }
@@ -63,15 +71,42 @@ func (m HeaderModel) View() string {
 
 	header := m.theme.PrimaryBlock.Render("radiogogo")
 	version := m.theme.SecondaryBlock.Render(fmt.Sprintf("v%s", data.Version))
-	engine := m.theme.PrimaryBlock.Render("Playback engine: " + m.engineName)
+	engine := m.theme.PrimaryBlock.Render(i18n.Tf("header.engine", m.engineName))
+
+	if m.theme.Accessible {
+		parts := []string{"radiogogo v" + data.Version, i18n.Tf("header.engine", m.engineName)}
+		if m.profile != "" {
+			parts = append(parts, i18n.Tf("header.profile", m.profile))
+		}
+		if m.recordings > 0 {
+			parts = append(parts, i18n.Tf("header.recording", m.recordings))
+		}
+		if m.showOffset {
+			parts = append(parts, i18n.Tf("accessible.stationOffset", m.stationOffset+1, m.totalStations))
+		}
+		return strings.Join(parts, " | ") + "\n"
+	}
 
 	leftHeader := header + version + engine
+	if m.profile != "" {
+		leftHeader += m.theme.SecondaryBlock.Render(i18n.Tf("header.profile", m.profile))
+	}
+	if m.recordings > 0 {
+		leftHeader += m.theme.SecondaryBlock.Render(i18n.Tf("header.recording", m.recordings))
+	}
 
 	if m.showOffset {
 
-		rightHeader := m.theme.PrimaryBlock.Render(fmt.Sprintf("%d/%d", m.stationOffset+1, m.totalStations))
+		total := fmt.Sprintf("%d", m.totalStations)
+		if m.moreStations {
+			total += "+"
+		}
+		rightHeader := m.theme.PrimaryBlock.Render(fmt.Sprintf("%d/%s", m.stationOffset+1, total))
 
 		fillerWidth := m.width - lipgloss.Width(leftHeader) - lipgloss.Width(rightHeader)
+		if fillerWidth < 0 {
+			fillerWidth = 0
+		}
 		filler := lipgloss.NewStyle().Width(fillerWidth).Render(" ")
 
 		return leftHeader + filler + rightHeader + "\n"
@@ -0,0 +1,203 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Messages
+
+// helpRequestedMsg asks the root model to show the help overlay of the current view.
+type helpRequestedMsg struct{}
+
+// closeHelpMsg closes the help overlay.
+type closeHelpMsg struct{}
+
+// Commands
+
+func showHelpCmd() tea.Msg {
+	return helpRequestedMsg{}
+}
+
+// Model
+
+// HelpModel lists the key bindings of a view, as registered in keyBindings, scrolling when they don't fit.
+type HelpModel struct {
+	theme    Theme
+	sections []keyBindingSection
+	// offset is the first line shown
+	offset int
+	width  int
+	height int
+}
+
+func NewHelpModel(theme Theme, sections []keyBindingSection) HelpModel {
+	return HelpModel{
+		theme:    theme,
+		sections: sections,
+	}
+}
+
+func (m HelpModel) Update(msg tea.Msg) (HelpModel, tea.Cmd) {
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc", "q", "?", "f1":
+		return m, func() tea.Msg {
+			return closeHelpMsg{}
+		}
+	case "up", "k":
+		m.offset--
+	case "down", "j":
+		m.offset++
+	case "pgup":
+		m.offset -= m.height
+	case "pgdown", " ":
+		m.offset += m.height
+	case "home", "g":
+		m.offset = 0
+	case "end", "G":
+		m.offset = len(m.lines())
+	}
+	m.offset = m.clampedOffset()
+
+	return m, nil
+}
+
+// commands are shown in the bottom bar while the overlay is open.
+func (m HelpModel) commands() []string {
+	return []string{i18n.T("commands.scroll"), i18n.T("commands.back")}
+}
+
+// lines returns every line of the overlay: each section's title, followed by its key bindings
+// with their keys aligned.
+func (m HelpModel) lines() []string {
+	keyWidth := 0
+	for _, section := range m.sections {
+		for _, binding := range section.bindings {
+			for _, help := range splitKeyBinding(i18n.T(binding)) {
+				if width := lipgloss.Width(help.keys); width > keyWidth {
+					keyWidth = width
+				}
+			}
+		}
+	}
+
+	var lines []string
+	for i, section := range m.sections {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, m.theme.SecondaryText.Bold(true).Render(i18n.T(section.title)))
+		for _, binding := range section.bindings {
+			for _, help := range splitKeyBinding(i18n.T(binding)) {
+				padding := strings.Repeat(" ", keyWidth-lipgloss.Width(help.keys))
+				lines = append(lines, "  "+m.theme.PrimaryText.Render(help.keys)+padding+"  "+help.description)
+			}
+		}
+	}
+	return lines
+}
+
+// keyHelp is a line of the help overlay: keys and what they do.
+type keyHelp struct {
+	keys        string
+	description string
+}
+
+// splitKeyBinding splits a "keys: what they do" text, as shown in the bottom bars, in its two halves.
+// Texts describing several keys, such as "[/]: -10s/+10s, }: live", give a keyHelp for each.
+func splitKeyBinding(text string) []keyHelp {
+	var helps []keyHelp
+	for _, part := range strings.Split(text, ", ") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		// The keys end at the first colon, unless they're the colon itself (as in ": command")
+		var help keyHelp
+		if i := strings.Index(part[1:], ":"); i >= 0 {
+			help = keyHelp{keys: strings.TrimSpace(part[:i+1]), description: strings.TrimSpace(part[i+2:])}
+		} else if strings.HasPrefix(part, ":") {
+			help = keyHelp{keys: ":", description: strings.TrimSpace(part[1:])}
+		} else if len(helps) > 0 {
+			// A comma in the description
+			helps[len(helps)-1].description += ", " + part
+			continue
+		} else {
+			help = keyHelp{description: part}
+		}
+		helps = append(helps, help)
+	}
+	return helps
+}
+
+// visibleLines returns how many lines fit below the title.
+func (m HelpModel) visibleLines() int {
+	if m.height <= 2 {
+		return 1
+	}
+	return m.height - 2
+}
+
+// clampedOffset keeps the offset from scrolling past the last line.
+func (m HelpModel) clampedOffset() int {
+	offset := m.offset
+	if last := len(m.lines()) - m.visibleLines(); offset > last {
+		offset = last
+	}
+	if offset < 0 {
+		return 0
+	}
+	return offset
+}
+
+func (m HelpModel) View() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.T("help.title")) + "\n\n"
+
+	lines := m.lines()
+	// The accessible view isn't bound by the height of the terminal
+	if m.theme.Accessible || m.height == 0 {
+		return v + strings.Join(lines, "\n") + "\n"
+	}
+
+	end := m.offset + m.visibleLines()
+	if end > len(lines) {
+		end = len(lines)
+	}
+	return v + strings.Join(lines[m.offset:end], "\n") + "\n"
+}
+
+func (m *HelpModel) SetWidthAndHeight(width int, height int) {
+	m.width = width
+	m.height = height
+	m.offset = m.clampedOffset()
+}
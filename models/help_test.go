@@ -0,0 +1,376 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/uuid"
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyBindings(t *testing.T) {
+
+	t.Run("every key binding is translated and has keys", func(t *testing.T) {
+		for state, sections := range keyBindings {
+			for _, section := range sections {
+				_, ok := i18n.Lookup(section.title)
+				assert.True(t, ok, "view %d: %s", state, section.title)
+				for _, binding := range section.bindings {
+					text, ok := i18n.Lookup(binding)
+					assert.True(t, ok, "view %d: %s", state, binding)
+					for _, help := range splitKeyBinding(text) {
+						assert.NotEmpty(t, help.keys, binding)
+						assert.NotEmpty(t, help.description, binding)
+					}
+				}
+			}
+		}
+	})
+
+	// The station highlighted is only told apart by its colors
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	defer lipgloss.SetColorProfile(profile)
+
+	t.Run("every listed key is handled by its view", func(t *testing.T) {
+		for state, sections := range keyBindings {
+			for _, section := range sections {
+				for _, binding := range section.bindings {
+					presses, ok := keyBindingPresses[binding]
+					if !assert.True(t, ok, "view %d: no keys to press for %s", state, binding) {
+						continue
+					}
+					for _, press := range presses {
+						assert.True(t, handlesKeys(t, state, press), "view %d: %s (%s) is not handled", state, binding, press)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("tells keys that aren't handled apart", func(t *testing.T) {
+		for state := range keyBindings {
+			assert.False(t, handlesKeys(t, state, "ctrl+y"), "view %d", state)
+		}
+	})
+
+}
+
+// keyBindingPresses are the keys pressed to exercise each key binding, space separated when pressed in a row,
+// the last of which must be handled.
+var keyBindingPresses = map[string][]string{
+	"commands.cycleFocus":      {"tab"},
+	"commands.changeFilter":    {"tab tab tab down", "tab tab tab down up"},
+	"commands.search":          {"j enter"},
+	"commands.completeTag":     {tagSearch + " j a down", tagSearch + " j a down up", tagSearch + " j a tab"},
+	"commands.replayRecent":    {"alt+1"},
+	"commands.forgetRecent":    {"alt+x", "alt+x 1", "alt+x alt+x"},
+	"commands.stationOfTheDay": {"alt+0", "alt+b", "alt+d"},
+	"commands.undo":            {"u"},
+	"commands.tags":            {"ctrl+t"},
+	"commands.charts":          {"ctrl+r"},
+	"commands.bookmarks":       {"ctrl+b"},
+	"commands.likedTracks":     {"ctrl+l"},
+	"commands.blocklist":       {"ctrl+x"},
+	"commands.discover":        {"ctrl+n"},
+	"commands.output":          {"ctrl+o"},
+	"commands.profiles":        {"ctrl+p"},
+	"commands.openUrl":         {"o"},
+	"commands.helpAnywhere":    {"f1"},
+	"commands.quit":            {"q"},
+	"commands.move":            {"down", "down up"},
+	"commands.moveAll":         {"down", "down up", "right", "right left"},
+	"commands.jump":            {"G", "G g g"},
+	"commands.scrollPage":      {"pgdown", "pgdown pgup"},
+	"commands.firstLast":       {"end", "end home"},
+	"commands.typeAhead":       {"' r"},
+	"commands.typeAheadTag":    {"t", "' j"},
+	"commands.page":            {"n", "p"},
+	"commands.pageJump":        {"<", ">"},
+	"commands.commandLine":     {":", "/", "f"},
+	"commands.details":         {"i"},
+	"commands.splitPane":       {"tab"},
+	"commands.columns":         {"v"},
+	"commands.scrollColumns":   {"shift+right", "shift+right shift+left"},
+	"commands.refresh":         {"r"},
+	"commands.map":             {"M"},
+	"commands.play":            {"enter"},
+	"commands.stop":            {"ctrl+k"},
+	"commands.pause":           {"x"},
+	"commands.seek":            {"[", "]", "}"},
+	"commands.volume":          {"9", "0"},
+	"commands.volumeTrim":      {"(", ")"},
+	"commands.queue":           {"a", "Q"},
+	"commands.scan":            {"S"},
+	"commands.compare":         {"c"},
+	"commands.record":          {"R"},
+	"commands.likeTrack":       {"L"},
+	"commands.bookmark":        {"b"},
+	"commands.vote":            {"+"},
+	"commands.similar":         {"m"},
+	"commands.copy":            {"y", "Y"},
+	"commands.homepage":        {"w"},
+	"commands.externalPlayer":  {"e"},
+	"commands.flag":            {"!"},
+	"commands.hide":            {"H"},
+	"commands.suggestEdit":     {"E"},
+	"commands.newSearch":       {"s"},
+	"commands.help":            {"?"},
+	"commands.removeBookmark":  {"d"},
+	"commands.checkBookmarks":  {"c"},
+	"commands.fixBookmarks":    {"F"},
+	"commands.sortBookmarks":   {"s"},
+	"commands.back":            {"esc"},
+	"commands.country":         {"c"},
+	"commands.worldwide":       {"w"},
+	"commands.searchTag":       {"enter"},
+}
+
+// tagSearch are the keys choosing a search by tag, back in the name field.
+var tagSearch = "tab tab tab " + strings.Repeat("down ", 11) + "tab"
+
+// timeshiftingPlaybackManager can pause and rewind the station it plays, and change its volume.
+type timeshiftingPlaybackManager struct {
+	mocks.MockPlaybackManagerService
+}
+
+func (m *timeshiftingPlaybackManager) Pause() error                    { return nil }
+func (m *timeshiftingPlaybackManager) Resume() error                   { return nil }
+func (m *timeshiftingPlaybackManager) IsPaused() bool                  { return false }
+func (m *timeshiftingPlaybackManager) Seek(offset time.Duration) error { return nil }
+func (m *timeshiftingPlaybackManager) GoLive() error                   { return nil }
+func (m *timeshiftingPlaybackManager) SetVolume(volume int) error      { return nil }
+func (m *timeshiftingPlaybackManager) Delay() time.Duration            { return 0 }
+
+// keyMsg is the key press named like in the key bindings.
+func keyMsg(name string) tea.KeyMsg {
+	switch {
+	case strings.HasPrefix(name, "alt+"):
+		key := keyMsg(strings.TrimPrefix(name, "alt+"))
+		key.Alt = true
+		return key
+	case strings.HasPrefix(name, "ctrl+"):
+		return tea.KeyMsg{Type: tea.KeyCtrlA + tea.KeyType(name[len("ctrl+")]-'a')}
+	}
+	types := map[string]tea.KeyType{
+		"tab": tea.KeyTab, "enter": tea.KeyEnter, "esc": tea.KeyEsc, "f1": tea.KeyF1,
+		"up": tea.KeyUp, "down": tea.KeyDown, "left": tea.KeyLeft, "right": tea.KeyRight,
+		"shift+left": tea.KeyShiftLeft, "shift+right": tea.KeyShiftRight,
+		"pgup": tea.KeyPgUp, "pgdown": tea.KeyPgDown, "home": tea.KeyHome, "end": tea.KeyEnd,
+	}
+	if keyType, ok := types[name]; ok {
+		return tea.KeyMsg{Type: keyType}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+}
+
+// handlesKeys tells whether the view, showing a page of stations, handles the last of the keys pressed,
+// by returning a command or by changing what it shows.
+func handlesKeys(t *testing.T, state modelState, keys string) bool {
+
+	stations := make([]common.Station, stationPageSize)
+	for i := range stations {
+		name := fmt.Sprintf("radio%d", i)
+		stations[i] = common.Station{
+			StationUuid: uuid.New(),
+			Name:        name,
+			Url:         common.RadioGoGoURL{URL: url.URL{Scheme: "http", Host: name + ".example", Path: "/stream"}},
+			Homepage:    common.RadioGoGoURL{URL: url.URL{Scheme: "https", Host: name + ".example"}},
+			Tags:        "jazz",
+		}
+	}
+	tags := []common.Tag{{Name: "jazz", StationCount: 10}, {Name: "java", StationCount: 20}}
+	for i := 0; i < 100; i++ {
+		tags = append(tags, common.Tag{Name: fmt.Sprintf("tag%d", i), StationCount: uint64(i)})
+	}
+
+	playbackManager := &timeshiftingPlaybackManager{mocks.MockPlaybackManagerService{
+		IsPlayingResult: true,
+		PlayStationFunc: func(station common.Station, volume int) error {
+			return nil
+		},
+		StopStationFunc: func() error {
+			return nil
+		},
+		VolumeMaxResult:     100,
+		VolumeDefaultResult: 50,
+	}}
+	bookmarkStore := &mocks.MockBookmarkStore{
+		AllFunc: func() []common.Station {
+			return stations
+		},
+	}
+	cfg := config.NewDefaultConfig()
+	// More columns than fit, to be scrolled through
+	for _, name := range []string{"name", "country", "language", "codec", "votes", "bitrate", "clicks", "tags"} {
+		cfg.Stations.Columns = append(cfg.Stations.Columns, config.StationColumn{Name: name, Width: 20})
+	}
+	model := NewModel(cfg, &mocks.MockRadioBrowserService{}, playbackManager, &mocks.MockLabelStore{}, bookmarkStore, &mocks.MockReportStore{}, &mocks.MockProberService{})
+	model.history = historyOf(stations...)
+	model.volumeTrims = newVolumeTrimStore()
+	model.blocklist = &mocks.MockBlocklistStore{}
+	// Every frame is drawn
+	model.render = nil
+
+	var updated tea.Model = model
+	updated, _ = updated.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	switch state {
+	case searchState:
+		updated, _ = updated.Update(switchToSearchModelMsg{})
+		updated, _ = updated.Update(recentlyPlayedLoadedMsg{stations: stations[:9]})
+		updated, _ = updated.Update(stationOfTheDayLoadedMsg{suggestion: stationOfTheDay{Day: "2023-10-01", Station: stations[9]}})
+	case stationsState:
+		page := stationPageKey{query: common.StationQueryByTagExact, queryText: "jazz", page: 1}
+		updated, _ = updated.Update(switchToStationsModelMsg{stations: stations, page: page})
+		updated, _ = updated.Update(playbackStartedMsg{station: stations[0]})
+	case bookmarksState:
+		updated, _ = updated.Update(switchToBookmarksModelMsg{})
+		updated, _ = updated.Update(playbackStartedMsg{station: stations[0]})
+		// A dead bookmark moved to a new stream
+		replacement := stations[0]
+		checks := map[uuid.UUID]bookmarkCheck{replacement.StationUuid: {err: errors.New("404 Not Found"), replacement: &replacement}}
+		updated, _ = updated.Update(bookmarksCheckedMsg{checks: checks})
+	case chartsState:
+		model := updated.(Model)
+		model.searchFilter.CountryCode = "GB"
+		updated, _ = model.Update(switchToChartsModelMsg{})
+		updated, _ = updated.Update(chartsFetchedMsg{countryCode: "GB", charts: [][]common.Station{stations, stations}})
+	case tagCloudState:
+		updated, _ = updated.Update(switchToTagCloudModelMsg{})
+		updated, _ = updated.Update(catalogFetchedMsg{part: catalogTags, tags: tags})
+	}
+	if !assert.Equal(t, state, updated.(Model).state) {
+		return false
+	}
+
+	// Styles derived from the theme while rendering share its rules (lipgloss styles are only copied
+	// with Copy), so the first frame can differ from the next ones whatever the key
+	updated.View()
+
+	presses := strings.Fields(keys)
+	for _, key := range presses[:len(presses)-1] {
+		updated, _ = updated.Update(keyMsg(key))
+		// The tags completing what's typed come back right away
+		if len(updated.(Model).searchModel.completion.tags) == 0 {
+			updated, _ = updated.Update(tagsCompletedMsg{prefix: "ja", tags: tags})
+		}
+	}
+	before := updated.View()
+
+	updated, cmd := updated.Update(keyMsg(presses[len(presses)-1]))
+	return cmd != nil || updated.View() != before
+
+}
+
+func TestHelpModel(t *testing.T) {
+
+	t.Run("lists the key bindings of the view, aligned", func(t *testing.T) {
+
+		model := NewHelpModel(Theme{}, keyBindings[tagCloudState])
+
+		view := model.View()
+
+		assert.Contains(t, view, "Browsing")
+		assert.Contains(t, view, "  ←/→/↑/↓    move\n")
+		assert.Contains(t, view, "  PgUp/PgDn  scroll a screen\n")
+		assert.Contains(t, view, "  ?          help\n")
+
+	})
+
+	t.Run("splits the texts describing several keys", func(t *testing.T) {
+
+		assert.Equal(t, []keyHelp{
+			{keys: ":", description: "command"},
+			{keys: "/", description: "find"},
+			{keys: "f", description: "filter"},
+		}, splitKeyBinding(": command, /: find, f: filter"))
+		assert.Equal(t, []keyHelp{{keys: ":", description: "commande"}}, splitKeyBinding(": : commande"))
+		assert.Equal(t, []keyHelp{{keys: "[/]", description: "-10s/+10s"}, {keys: "}", description: "live"}}, splitKeyBinding("[/] : -10s/+10s, }: live"))
+
+	})
+
+	t.Run("scrolls without going past the last line", func(t *testing.T) {
+
+		model := NewHelpModel(Theme{}, keyBindings[stationsState])
+		model.SetWidthAndHeight(80, 7)
+		lines := len(model.lines())
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+		assert.Equal(t, 1, model.offset)
+		assert.NotContains(t, model.View(), "Browsing")
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnd})
+		assert.Equal(t, lines-5, model.offset)
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+		assert.Equal(t, lines-5, model.offset)
+
+		for i := 0; i < lines; i += 5 {
+			model, _ = model.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+		}
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+		assert.Equal(t, 0, model.offset)
+
+	})
+
+	t.Run("closes with esc or ?", func(t *testing.T) {
+
+		model := NewHelpModel(Theme{}, keyBindings[stationsState])
+
+		for _, key := range []tea.KeyMsg{{Type: tea.KeyEsc}, {Type: tea.KeyRunes, Runes: []rune("?")}} {
+			_, cmd := model.Update(key)
+			assert.Equal(t, closeHelpMsg{}, cmd())
+		}
+
+	})
+
+	t.Run("is opened over the current view and takes its keys", func(t *testing.T) {
+
+		model := NewModel(config.Config{}, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{}, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		model.state = tagCloudState
+
+		updated, _ := model.Update(helpRequestedMsg{})
+		model = updated.(Model)
+		assert.True(t, model.helpShown())
+		assert.True(t, strings.Contains(model.View(), "Keys"))
+
+		updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+		model = updated.(Model)
+		assert.Equal(t, closeHelpMsg{}, cmd())
+
+		updated, _ = model.Update(closeHelpMsg{})
+		assert.False(t, updated.(Model).helpShown())
+
+	})
+
+}
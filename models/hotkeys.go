@@ -0,0 +1,110 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"github.com/zi0p4tch0/radiogogo/hotkeys"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Messages
+
+// hotkeyMsg carries the action of a system-wide hotkey.
+type hotkeyMsg struct {
+	action hotkeys.Action
+}
+
+// NewHotkeyMsg wraps the action of a system-wide hotkey, so that it can be sent to the running program.
+func NewHotkeyMsg(action hotkeys.Action) tea.Msg {
+	return hotkeyMsg{action: action}
+}
+
+// Model
+
+// handleHotkey acts on the stations or bookmarks list, whichever is shown.
+// Hotkeys are ignored by the other views, which nothing plays in.
+func (m Model) handleHotkey(action hotkeys.Action) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch m.state {
+	case stationsState:
+		m.stationsModel, cmd = m.stationsModel.hotkey(action)
+	case bookmarksState:
+		m.bookmarksModel, cmd = m.bookmarksModel.hotkey(action)
+	}
+	return m, cmd
+}
+
+// hotkey plays the highlighted station (or stops the one playing), stops playback,
+// or plays the station after the highlighted one. Scanning stops, as it does on any key.
+func (m StationsModel) hotkey(action hotkeys.Action) (StationsModel, tea.Cmd) {
+	m.scanning = false
+	var newModel tea.Model
+	var cmd tea.Cmd
+	switch action {
+	case hotkeys.ActionPlay:
+		if m.playbackManager.IsPlaying() {
+			return m, stopStationCmd(m.playbackManager)
+		}
+		newModel, cmd = m.playSelectedStation()
+	case hotkeys.ActionStop:
+		return m, stopStationCmd(m.playbackManager)
+	case hotkeys.ActionNext:
+		if len(m.stations) == 0 {
+			return m, nil
+		}
+		m.stationsTable.SetCursor((m.stationsTable.Cursor() + 1) % len(m.stations))
+		newModel, cmd = m.playSelectedStation()
+		cmd = tea.Batch(cmd, m.cursorMovedCmd())
+	default:
+		return m, nil
+	}
+	return newModel.(StationsModel), cmd
+}
+
+// hotkey plays the highlighted bookmark (or stops the one playing), stops playback,
+// or plays the bookmark after the highlighted one.
+func (m BookmarksModel) hotkey(action hotkeys.Action) (BookmarksModel, tea.Cmd) {
+	var newModel tea.Model
+	var cmd tea.Cmd
+	switch action {
+	case hotkeys.ActionPlay:
+		if m.playbackManager.IsPlaying() {
+			return m, stopStationCmd(m.playbackManager)
+		}
+		newModel, cmd = m.playSelectedStation()
+	case hotkeys.ActionStop:
+		return m, stopStationCmd(m.playbackManager)
+	case hotkeys.ActionNext:
+		if len(m.stations) == 0 {
+			return m, nil
+		}
+		// Folders come first, and are skipped
+		index := m.stationsTable.Cursor() - len(m.folders)
+		if index < 0 {
+			index = -1
+		}
+		m.stationsTable.SetCursor(len(m.folders) + (index+1)%len(m.stations))
+		newModel, cmd = m.playSelectedStation()
+	default:
+		return m, nil
+	}
+	return newModel.(BookmarksModel), cmd
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/hotkeys"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStationsModel_Hotkey(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	rock := common.Station{StationUuid: uuid.New(), Name: "Rock Antenne"}
+
+	t.Run("plays the highlighted station", func(t *testing.T) {
+
+		playbackManager := mocks.MockPlaybackManagerService{
+			PlayStationFunc: func(station common.Station, volume int) error {
+				return nil
+			},
+		}
+		model := newQueueStationsModel(&playbackManager, []common.Station{jazz, rock}, nil, 0)
+
+		model, cmd := model.hotkey(hotkeys.ActionPlay)
+
+		assert.Equal(t, jazz, *model.bufferingStation)
+		assert.Contains(t, collectMsgs(cmd), playbackStartedMsg{station: jazz})
+
+	})
+
+	t.Run("stops the station playing", func(t *testing.T) {
+
+		stopped := false
+		playbackManager := mocks.MockPlaybackManagerService{
+			IsPlayingResult: true,
+			StopStationFunc: func() error {
+				stopped = true
+				return nil
+			},
+		}
+		model := newQueueStationsModel(&playbackManager, []common.Station{jazz, rock}, nil, 0)
+
+		model, cmd := model.hotkey(hotkeys.ActionPlay)
+
+		assert.Nil(t, model.bufferingStation)
+		assert.Contains(t, collectMsgs(cmd), playbackStoppedMsg{})
+		assert.True(t, stopped)
+
+	})
+
+	t.Run("plays the next station, wrapping around", func(t *testing.T) {
+
+		var played []common.Station
+		playbackManager := mocks.MockPlaybackManagerService{
+			PlayStationFunc: func(station common.Station, volume int) error {
+				played = append(played, station)
+				return nil
+			},
+		}
+		model := newQueueStationsModel(&playbackManager, []common.Station{jazz, rock}, nil, 0)
+
+		model, cmd := model.hotkey(hotkeys.ActionNext)
+		collectMsgs(cmd)
+		assert.Equal(t, 1, model.stationsTable.Cursor())
+
+		model.bufferingStation = nil
+		model, cmd = model.hotkey(hotkeys.ActionNext)
+		collectMsgs(cmd)
+		assert.Equal(t, 0, model.stationsTable.Cursor())
+
+		assert.Equal(t, []common.Station{rock, jazz}, played)
+
+	})
+
+}
+
+func TestBookmarksModel_Hotkey(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	rock := common.Station{StationUuid: uuid.New(), Name: "Rock Antenne"}
+	news := common.Station{StationUuid: uuid.New(), Name: "News 24"}
+
+	t.Run("plays the next bookmark, skipping folders", func(t *testing.T) {
+
+		var played []common.Station
+		model := NewBookmarksModel(
+			Theme{},
+			&mocks.MockRadioBrowserService{},
+			&mocks.MockPlaybackManagerService{
+				PlayStationFunc: func(station common.Station, volume int) error {
+					played = append(played, station)
+					return nil
+				},
+			},
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{
+				AllFunc: func() []common.Station {
+					return []common.Station{news, jazz, rock}
+				},
+				MetaFunc: func(stationUuid uuid.UUID) storage.BookmarkMeta {
+					if stationUuid == news.StationUuid {
+						return storage.BookmarkMeta{Folder: "News"}
+					}
+					return storage.BookmarkMeta{}
+				},
+			},
+			filter.ContentFilter{},
+			&mocks.MockProberService{},
+		)
+
+		// The cursor is on the folder
+		model, cmd := model.hotkey(hotkeys.ActionNext)
+		collectMsgs(cmd)
+		model.bufferingStation = nil
+		model, cmd = model.hotkey(hotkeys.ActionNext)
+		collectMsgs(cmd)
+		model.bufferingStation = nil
+		model, cmd = model.hotkey(hotkeys.ActionNext)
+		collectMsgs(cmd)
+
+		assert.Equal(t, []common.Station{jazz, rock, jazz}, played)
+
+	})
+
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Messages
+
+// closeInspectorMsg closes the API inspector.
+type closeInspectorMsg struct{}
+
+// Commands
+
+// copyExchangeCmd copies the text of the exchange shown by the API inspector to the clipboard, for bug reports.
+func copyExchangeCmd(copyToClipboard func(text string) error, text string) tea.Cmd {
+	return func() tea.Msg {
+		if err := copyToClipboard(text); err != nil {
+			return nonFatalError{stopPlayback: false, err: errors.New(i18n.Tf("clipboard.failed", err))}
+		}
+		return toastMsg{text: i18n.T("inspector.copied"), kind: toastSuccess}
+	}
+}
+
+// Model
+
+// InspectorModel shows the last request sent to radio-browser and its response, as received, scrolling when
+// they don't fit. It's opened with F12 over any view in developer mode, to tell what radio-browser answered
+// when search results look wrong.
+type InspectorModel struct {
+	theme     Theme
+	inspector api.Inspector
+	exchange  api.Exchange
+	// Whether a request was sent yet
+	inspected bool
+	// offset is the first line shown
+	offset          int
+	width           int
+	height          int
+	copyToClipboard func(text string) error
+}
+
+func NewInspectorModel(theme Theme, inspector api.Inspector) InspectorModel {
+	m := InspectorModel{
+		theme:           theme,
+		inspector:       inspector,
+		copyToClipboard: common.CopyToClipboard,
+	}
+	m.refresh()
+	return m
+}
+
+// refresh shows the last exchange, from the top.
+func (m *InspectorModel) refresh() {
+	m.exchange, m.inspected = m.inspector.LastExchange()
+	m.offset = 0
+}
+
+func (m InspectorModel) Update(msg tea.Msg) (InspectorModel, tea.Cmd) {
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc", "q", "f12":
+		return m, func() tea.Msg {
+			return closeInspectorMsg{}
+		}
+	case "r":
+		m.refresh()
+	case "c":
+		if m.inspected {
+			return m, copyExchangeCmd(m.copyToClipboard, strings.Join(m.lines(0), "\n"))
+		}
+	case "up", "k":
+		m.offset--
+	case "down", "j":
+		m.offset++
+	case "pgup":
+		m.offset -= m.visibleLines()
+	case "pgdown", " ":
+		m.offset += m.visibleLines()
+	case "home", "g":
+		m.offset = 0
+	case "end", "G":
+		m.offset = len(m.lines(m.width))
+	}
+	m.offset = m.clampedOffset()
+
+	return m, nil
+}
+
+// commands are shown in the bottom bar while the inspector is open.
+func (m InspectorModel) commands() []string {
+	return []string{i18n.T("commands.scroll"), i18n.T("commands.refresh"), i18n.T("commands.copyExchange"), i18n.T("commands.back")}
+}
+
+// lines returns the exchange as text, the body indented if it's JSON and wrapped at width (0 doesn't wrap it).
+func (m InspectorModel) lines(width int) []string {
+
+	if !m.inspected {
+		return []string{i18n.T("inspector.empty")}
+	}
+	exchange := m.exchange
+
+	lines := []string{
+		exchange.Method + " " + exchange.URL,
+		i18n.Tf("inspector.sent", exchange.Sent.Format("15:04:05"), exchange.Duration.Milliseconds()),
+	}
+	if exchange.Err != nil {
+		return append(lines, i18n.Tf("inspector.error", exchange.Err))
+	}
+	lines = append(lines, i18n.Tf("inspector.status", exchange.Status))
+
+	lines = append(lines, "", i18n.T("inspector.requestHeaders"))
+	lines = append(lines, headerLines(exchange.RequestHeader)...)
+	if exchange.RequestBody != "" {
+		lines = append(lines, "", i18n.T("inspector.requestBody"), "  "+exchange.RequestBody)
+	}
+	lines = append(lines, "", i18n.T("inspector.responseHeaders"))
+	lines = append(lines, headerLines(exchange.ResponseHeader)...)
+
+	if exchange.Truncated {
+		lines = append(lines, "", i18n.Tf("inspector.truncated", len(exchange.Body)>>10))
+	} else {
+		lines = append(lines, "", i18n.T("inspector.responseBody"))
+	}
+	body := exchange.Body
+	var indented bytes.Buffer
+	if json.Indent(&indented, body, "", "  ") == nil {
+		body = indented.Bytes()
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(body), "\n"), "\n") {
+		lines = append(lines, wrapLine(strings.TrimRight(line, "\r"), width)...)
+	}
+	return lines
+}
+
+// headerLines returns the lines of HTTP headers, sorted by name.
+func headerLines(header map[string][]string) []string {
+	var lines []string
+	for name, values := range header {
+		for _, value := range values {
+			lines = append(lines, "  "+name+": "+value)
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// wrapLine cuts line into lines of width characters (0 leaves it whole).
+// Bodies are often a single line of JSON, with no spaces to wrap at.
+func wrapLine(line string, width int) []string {
+	runes := []rune(line)
+	if width <= 0 || len(runes) <= width {
+		return []string{line}
+	}
+	var lines []string
+	for len(runes) > width {
+		lines = append(lines, string(runes[:width]))
+		runes = runes[width:]
+	}
+	return append(lines, string(runes))
+}
+
+// visibleLines returns how many lines fit below the title.
+func (m InspectorModel) visibleLines() int {
+	if m.height <= 2 {
+		return 1
+	}
+	return m.height - 2
+}
+
+// clampedOffset keeps the offset from scrolling past the last line.
+func (m InspectorModel) clampedOffset() int {
+	offset := m.offset
+	if last := len(m.lines(m.width)) - m.visibleLines(); offset > last {
+		offset = last
+	}
+	if offset < 0 {
+		return 0
+	}
+	return offset
+}
+
+func (m InspectorModel) View() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.T("inspector.title")) + "\n\n"
+
+	// The accessible view isn't bound by the height of the terminal
+	if m.theme.Accessible || m.height == 0 {
+		return v + strings.Join(m.lines(0), "\n") + "\n"
+	}
+
+	lines := m.lines(m.width)
+	end := m.offset + m.visibleLines()
+	if end > len(lines) {
+		end = len(lines)
+	}
+	return v + strings.Join(lines[m.offset:end], "\n") + "\n"
+}
+
+func (m *InspectorModel) SetWidthAndHeight(width int, height int) {
+	m.width = width
+	m.height = height
+	m.offset = m.clampedOffset()
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeInspector struct {
+	exchange  api.Exchange
+	inspected bool
+}
+
+func (i *fakeInspector) SetInspecting(enabled bool) {}
+
+func (i *fakeInspector) LastExchange() (api.Exchange, bool) {
+	return i.exchange, i.inspected
+}
+
+func TestInspectorModel(t *testing.T) {
+
+	exchange := api.Exchange{
+		Method:         "GET",
+		URL:            "http://radio.example.com/json/stations/byname/jazz",
+		RequestHeader:  http.Header{"User-Agent": []string{"radiogogo/0.3.0"}, "Accept": []string{"application/json"}},
+		Sent:           time.Date(2026, 10, 17, 12, 30, 0, 0, time.UTC),
+		Duration:       180 * time.Millisecond,
+		Status:         "200 OK",
+		StatusCode:     200,
+		ResponseHeader: http.Header{"Content-Type": []string{"application/json"}},
+		Body:           []byte(`[{"name":"Jazz FM","votes":12}]`),
+	}
+
+	t.Run("shows the request and the response, its JSON body indented", func(t *testing.T) {
+
+		model := NewInspectorModel(Theme{}, &fakeInspector{exchange: exchange, inspected: true})
+
+		assert.Equal(t, []string{
+			"GET http://radio.example.com/json/stations/byname/jazz",
+			"Sent at 12:30:00, answered in 180 ms",
+			"Status: 200 OK",
+			"",
+			"Request headers",
+			"  Accept: application/json",
+			"  User-Agent: radiogogo/0.3.0",
+			"",
+			"Response headers",
+			"  Content-Type: application/json",
+			"",
+			"Response body",
+			"[",
+			"  {",
+			`    "name": "Jazz FM",`,
+			`    "votes": 12`,
+			"  }",
+			"]",
+		}, model.lines(0))
+
+	})
+
+	t.Run("wraps truncated bodies, which can't be indented", func(t *testing.T) {
+
+		truncated := exchange
+		truncated.Body = []byte(`[{"name":"Jazz FM","vo`)
+		truncated.Truncated = true
+		model := NewInspectorModel(Theme{}, &fakeInspector{exchange: truncated, inspected: true})
+
+		lines := model.lines(10)
+		assert.Equal(t, []string{"Response body (first 0 KB)", `[{"name":"`, `Jazz FM","`, "vo"}, lines[len(lines)-4:])
+
+	})
+
+	t.Run("shows the error of failed requests", func(t *testing.T) {
+
+		failed := api.Exchange{Method: "GET", URL: exchange.URL, Err: errors.New("connection refused")}
+		model := NewInspectorModel(Theme{}, &fakeInspector{exchange: failed, inspected: true})
+
+		assert.Contains(t, model.lines(0), "Error: connection refused")
+
+	})
+
+	t.Run("tells when no request was sent yet, and copies nothing", func(t *testing.T) {
+
+		model := NewInspectorModel(Theme{}, &fakeInspector{})
+
+		assert.Contains(t, model.View(), "No request has been sent to radio-browser yet.")
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+		assert.Nil(t, cmd)
+
+	})
+
+	t.Run("copies the exchange as text", func(t *testing.T) {
+
+		var copied string
+		model := NewInspectorModel(Theme{}, &fakeInspector{exchange: exchange, inspected: true})
+		model.copyToClipboard = func(text string) error {
+			copied = text
+			return nil
+		}
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+		assert.IsType(t, toastMsg{}, cmd())
+		assert.Contains(t, copied, "GET http://radio.example.com/json/stations/byname/jazz\n")
+		assert.Contains(t, copied, `"name": "Jazz FM"`)
+
+	})
+
+	t.Run("scrolls within the lines", func(t *testing.T) {
+
+		model := NewInspectorModel(Theme{}, &fakeInspector{exchange: exchange, inspected: true})
+		model.SetWidthAndHeight(80, 7)
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnd})
+		assert.Equal(t, 13, model.offset)
+		assert.Contains(t, model.View(), "]")
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyHome})
+		assert.Equal(t, 0, model.offset)
+
+	})
+
+}
+
+func TestModelInspector(t *testing.T) {
+
+	newModel := func(inspector api.Inspector) Model {
+		model := NewModel(config.Config{}, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{}, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		model.width = 80
+		model.height = 24
+		model.state = bootState
+		model.inspector = inspector
+		return model
+	}
+
+	t.Run("opens over the current view with F12 in developer mode, and closes with esc", func(t *testing.T) {
+
+		updated, _ := newModel(&fakeInspector{}).Update(tea.KeyMsg{Type: tea.KeyF12})
+		assert.True(t, updated.(Model).inspectorShown())
+		assert.Contains(t, updated.(Model).View(), "API inspector")
+
+		updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		updated, _ = updated.Update(cmd())
+		assert.False(t, updated.(Model).inspectorShown())
+		assert.Equal(t, bootState, updated.(Model).state)
+
+	})
+
+	t.Run("isn't available outside developer mode", func(t *testing.T) {
+
+		updated, _ := newModel(nil).Update(tea.KeyMsg{Type: tea.KeyF12})
+		assert.False(t, updated.(Model).inspectorShown())
+
+	})
+
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+// keyBindingSection is a titled group of the key bindings of a view.
+type keyBindingSection struct {
+	// title is the i18n key of the title of the section.
+	title string
+	// bindings are the i18n keys of the "keys: what they do" texts, shared with the bottom bars.
+	bindings []string
+}
+
+// keyBindings is the registry of the key bindings of each view, from which the help overlay is generated.
+// Views without an entry have no help overlay.
+var keyBindings = map[modelState][]keyBindingSection{
+	searchState: {
+		{
+			title:    "help.search",
+			bindings: []string{"commands.cycleFocus", "commands.changeFilter", "commands.search", "commands.completeTag", "commands.replayRecent", "commands.forgetRecent", "commands.stationOfTheDay", "commands.undo"},
+		},
+		{
+			title:    "help.views",
+			bindings: []string{"commands.tags", "commands.charts", "commands.bookmarks", "commands.likedTracks", "commands.blocklist", "commands.discover", "commands.output", "commands.profiles", "commands.openUrl"},
+		},
+		{
+			title:    "help.general",
+			bindings: []string{"commands.helpAnywhere", "commands.quit"},
+		},
+	},
+	stationsState: {
+		{
+			title: "help.browsing",
+			bindings: []string{
+				"commands.move", "commands.jump", "commands.scrollPage", "commands.typeAhead", "commands.page", "commands.pageJump", "commands.commandLine",
+				"commands.details", "commands.splitPane", "commands.columns", "commands.scrollColumns", "commands.refresh", "commands.map",
+			},
+		},
+		{
+			title: "help.playback",
+			bindings: []string{
+				"commands.play", "commands.stop", "commands.pause", "commands.seek", "commands.volume", "commands.volumeTrim",
+				"commands.queue", "commands.scan", "commands.compare", "commands.record", "commands.likeTrack", "commands.openUrl",
+			},
+		},
+		{
+			title: "help.station",
+			bindings: []string{
+				"commands.bookmark", "commands.vote", "commands.similar", "commands.copy", "commands.homepage", "commands.externalPlayer",
+				"commands.flag", "commands.hide", "commands.suggestEdit", "commands.undo",
+			},
+		},
+		{
+			title:    "help.general",
+			bindings: []string{"commands.newSearch", "commands.help", "commands.quit"},
+		},
+	},
+	bookmarksState: {
+		{
+			title:    "help.browsing",
+			bindings: []string{"commands.move", "commands.jump", "commands.commandLine", "commands.refresh"},
+		},
+		{
+			title:    "help.playback",
+			bindings: []string{"commands.play", "commands.stop", "commands.likeTrack", "commands.openUrl"},
+		},
+		{
+			title: "help.station",
+			bindings: []string{
+				"commands.removeBookmark", "commands.undo", "commands.copy", "commands.homepage", "commands.externalPlayer",
+				"commands.checkBookmarks", "commands.fixBookmarks", "commands.sortBookmarks",
+			},
+		},
+		{
+			title:    "help.general",
+			bindings: []string{"commands.back", "commands.help", "commands.quit"},
+		},
+	},
+	chartsState: {
+		{
+			title:    "help.browsing",
+			bindings: []string{"commands.moveAll", "commands.country", "commands.worldwide"},
+		},
+		{
+			title:    "help.playback",
+			bindings: []string{"commands.play"},
+		},
+		{
+			title:    "help.general",
+			bindings: []string{"commands.back", "commands.help", "commands.quit"},
+		},
+	},
+	tagCloudState: {
+		{
+			title:    "help.browsing",
+			bindings: []string{"commands.moveAll", "commands.scrollPage", "commands.firstLast", "commands.typeAheadTag", "commands.searchTag"},
+		},
+		{
+			title:    "help.general",
+			bindings: []string{"commands.back", "commands.help", "commands.quit"},
+		},
+	},
+}
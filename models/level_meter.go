@@ -0,0 +1,73 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"math"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/playback"
+)
+
+// The quietest level the meter shows, in dBFS: anything quieter is an empty bar.
+const levelMeterFloor = -60.0
+
+// How many cells the bar of each channel takes.
+const levelMeterWidth = 8
+
+// Cells filled by eighths, from empty to full.
+var levelMeterBlocks = []rune(" ▏▎▍▌▋▊▉█")
+
+// levelMeter returns the VU meter to show after the station being played, one bar per channel,
+// or an empty string if there's no meter or the backend isn't reporting levels.
+// It moves along with the spinner next to the station, which redraws the view.
+func levelMeter(theme Theme, levels *playback.Levels) string {
+	if levels == nil {
+		return ""
+	}
+	current := levels.Current()
+	if len(current) == 0 {
+		return ""
+	}
+	bars := make([]string, len(current))
+	for i, level := range current {
+		bars[i] = "▕" + levelBar(level) + "▏"
+	}
+	return "  " + theme.PrimaryText.Render(strings.Join(bars, ""))
+}
+
+// levelBar draws a level, in dBFS, as a bar of levelMeterWidth cells.
+func levelBar(level float64) string {
+	fraction := (level - levelMeterFloor) / -levelMeterFloor
+	// A level that isn't a number would make a bar of any length
+	if math.IsInf(level, -1) || math.IsNaN(level) || fraction < 0 {
+		fraction = 0
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	eighths := int(math.Round(fraction * levelMeterWidth * 8))
+	full := eighths / 8
+	bar := strings.Repeat(string(levelMeterBlocks[8]), full)
+	if full < levelMeterWidth {
+		bar += string(levelMeterBlocks[eighths%8]) + strings.Repeat(" ", levelMeterWidth-full-1)
+	}
+	return bar
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"math"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/playback"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelBar(t *testing.T) {
+
+	tests := []struct {
+		level float64
+		bar   string
+	}{
+		{0, "████████"},
+		{3, "████████"},
+		{-30, "████    "},
+		{-33.75, "███▌    "},
+		{-60, "        "},
+		{-90, "        "},
+		{math.Inf(-1), "        "},
+		{math.NaN(), "        "},
+		{-0.5, "███████▉"},
+		{-59.5, "▏       "},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.bar, levelBar(test.level), test.level)
+	}
+}
+
+func TestLevelMeter(t *testing.T) {
+
+	theme := NewTheme(config.NewDefaultConfig())
+
+	t.Run("nothing without levels", func(t *testing.T) {
+		assert.Empty(t, levelMeter(theme, nil))
+	})
+
+	t.Run("nothing until the backend reports levels", func(t *testing.T) {
+		assert.Empty(t, levelMeter(theme, playback.NewLevels()))
+	})
+}
@@ -0,0 +1,353 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/liked"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Messages
+
+// likeTrackRequestedMsg asks the root model to like the track being played.
+type likeTrackRequestedMsg struct{}
+
+// likedTracksRequestedMsg asks the root model to open the liked tracks over the current view.
+type likedTracksRequestedMsg struct{}
+
+// closeLikedTracksMsg closes the liked tracks.
+type closeLikedTracksMsg struct{}
+
+type likedTracksLoadedMsg struct {
+	tracks []storage.LikedTrack
+	err    error
+}
+
+// likedTrackRestoredMsg tells that a removed liked track was put back.
+type likedTrackRestoredMsg struct {
+	track storage.LikedTrack
+}
+
+// likedTrackStationSelectedMsg asks to play the station a liked track was heard on.
+type likedTrackStationSelectedMsg struct {
+	station common.Station
+}
+
+// Commands
+
+func likeTrackCmd() tea.Msg {
+	return likeTrackRequestedMsg{}
+}
+
+func showLikedTracksCmd() tea.Msg {
+	return likedTracksRequestedMsg{}
+}
+
+// saveLikedTrackCmd likes track, unless it's the track liked last, as when the key is pressed twice.
+func saveLikedTrackCmd(store storage.LikedTrackStore, track storage.LikedTrack) tea.Cmd {
+	return func() tea.Msg {
+		tracks, err := store.All()
+		if err == nil && len(tracks) > 0 && tracks[0].Title == track.Title && tracks[0].Station.StationUuid == track.Station.StationUuid {
+			return toastMsg{text: i18n.Tf("likedTracks.alreadyLiked", displayText(track.Title)), kind: toastInfo}
+		}
+		if err := store.Add(track); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return toastMsg{text: i18n.Tf("likedTracks.liked", displayText(track.Title)), kind: toastSuccess}
+	}
+}
+
+func loadLikedTracksCmd(store storage.LikedTrackStore) tea.Cmd {
+	return func() tea.Msg {
+		tracks, err := store.All()
+		return likedTracksLoadedMsg{tracks: tracks, err: err}
+	}
+}
+
+// removeLikedTrackCmd forgets track, which can be undone by liking it again.
+func removeLikedTrackCmd(store storage.LikedTrackStore, track storage.LikedTrack) tea.Cmd {
+	return func() tea.Msg {
+		if err := store.Remove(track.LikedAt); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return undoableMsg{
+			text: i18n.Tf("likedTracks.removed", displayText(track.Title)),
+			undo: restoreLikedTrackCmd(store, track),
+		}
+	}
+}
+
+func restoreLikedTrackCmd(store storage.LikedTrackStore, track storage.LikedTrack) tea.Cmd {
+	return func() tea.Msg {
+		if err := store.Add(track); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return likedTrackRestoredMsg{track: track}
+	}
+}
+
+// exportLikedTracksCmd writes tracks to a file in dir, named after the format.
+func exportLikedTracksCmd(dir string, format liked.Format, tracks []storage.LikedTrack) tea.Cmd {
+	return func() tea.Msg {
+		path := filepath.Join(dir, "liked-tracks."+string(format))
+		if err := exportLikedTracks(path, format, tracks); err != nil {
+			return nonFatalError{stopPlayback: false, err: errors.New(i18n.Tf("likedTracks.exportFailed", err))}
+		}
+		return toastMsg{text: i18n.Tf("likedTracks.exported", len(tracks), path), kind: toastSuccess}
+	}
+}
+
+func exportLikedTracks(path string, format liked.Format, tracks []storage.LikedTrack) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := liked.Write(file, format, tracks); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Model
+
+// LikedTracksModel lists the tracks liked while listening, most recent first, to play the station
+// they were heard on again, copy their titles, remove them or export them.
+// It's opened over the view it was requested from, like the help overlay.
+type LikedTracksModel struct {
+	theme  Theme
+	store  storage.LikedTrackStore
+	tracks []storage.LikedTrack
+	loaded bool
+	cursor int
+	// offset is the first track shown
+	offset int
+	width  int
+	height int
+	// Where the tracks are exported to
+	exportDir       string
+	copyToClipboard func(text string) error
+}
+
+func NewLikedTracksModel(theme Theme, store storage.LikedTrackStore, exportDir string) LikedTracksModel {
+	return LikedTracksModel{
+		theme:           theme,
+		store:           store,
+		exportDir:       exportDir,
+		copyToClipboard: common.CopyToClipboard,
+	}
+}
+
+func (m LikedTracksModel) Init() tea.Cmd {
+	return loadLikedTracksCmd(m.store)
+}
+
+func (m LikedTracksModel) Update(msg tea.Msg) (LikedTracksModel, tea.Cmd) {
+
+	switch msg := msg.(type) {
+	case likedTracksLoadedMsg:
+		if msg.err != nil {
+			return m, nonFatalErrorCmd(msg.err)
+		}
+		m.tracks = msg.tracks
+		m.loaded = true
+		m.setCursor(m.cursor)
+		return m, nil
+	case likedTrackRestoredMsg:
+		m.restore(msg.track)
+		return m, nil
+	case tea.KeyMsg:
+		return m.updateKeys(msg)
+	}
+
+	return m, nil
+}
+
+func (m LikedTracksModel) updateKeys(msg tea.KeyMsg) (LikedTracksModel, tea.Cmd) {
+
+	switch msg.String() {
+	case "esc", "q":
+		return m, func() tea.Msg {
+			return closeLikedTracksMsg{}
+		}
+	case "u":
+		return m, undoCmd
+	}
+
+	if len(m.tracks) == 0 {
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		m.setCursor(m.cursor - 1)
+	case "down", "j":
+		m.setCursor(m.cursor + 1)
+	case "pgup":
+		m.setCursor(m.cursor - m.visibleTracks())
+	case "pgdown":
+		m.setCursor(m.cursor + m.visibleTracks())
+	case "home", "g":
+		m.setCursor(0)
+	case "end", "G":
+		m.setCursor(len(m.tracks) - 1)
+	case "enter":
+		station := m.tracks[m.cursor].Station
+		return m, func() tea.Msg {
+			return likedTrackStationSelectedMsg{station: station}
+		}
+	case "y":
+		return m, copyLikedTrackCmd(m.copyToClipboard, m.tracks[m.cursor].Title)
+	case "d", "delete":
+		track := m.tracks[m.cursor]
+		m.tracks = append(m.tracks[:m.cursor:m.cursor], m.tracks[m.cursor+1:]...)
+		m.setCursor(m.cursor)
+		return m, removeLikedTrackCmd(m.store, track)
+	case "x":
+		return m, exportLikedTracksCmd(m.exportDir, liked.CSV, m.tracks)
+	case "X":
+		return m, exportLikedTracksCmd(m.exportDir, liked.JSON, m.tracks)
+	}
+
+	return m, nil
+}
+
+func copyLikedTrackCmd(copyToClipboard func(text string) error, title string) tea.Cmd {
+	return func() tea.Msg {
+		if err := copyToClipboard(title); err != nil {
+			return nonFatalError{stopPlayback: false, err: errors.New(i18n.Tf("clipboard.failed", err))}
+		}
+		return toastMsg{text: i18n.T("likedTracks.copied"), kind: toastSuccess}
+	}
+}
+
+// restore puts a removed track back in its place, the tracks being sorted by the time they were liked.
+func (m *LikedTracksModel) restore(track storage.LikedTrack) {
+	index := 0
+	for index < len(m.tracks) && m.tracks[index].LikedAt.After(track.LikedAt) {
+		index++
+	}
+	m.tracks = append(m.tracks[:index], append([]storage.LikedTrack{track}, m.tracks[index:]...)...)
+	m.setCursor(index)
+}
+
+// setCursor moves the cursor to the given track, within bounds, scrolling to keep it visible.
+func (m *LikedTracksModel) setCursor(cursor int) {
+	if cursor >= len(m.tracks) {
+		cursor = len(m.tracks) - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	m.cursor = cursor
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if visible := m.visibleTracks(); m.cursor >= m.offset+visible {
+		m.offset = m.cursor - visible + 1
+	}
+}
+
+// visibleTracks returns how many tracks fit below the title, two lines each.
+func (m LikedTracksModel) visibleTracks() int {
+	if m.height <= 4 {
+		return 1
+	}
+	return (m.height - 2) / 2
+}
+
+// commands are shown in the bottom bar while the liked tracks are open.
+func (m LikedTracksModel) commands() []string {
+	return []string{
+		i18n.T("commands.move"),
+		i18n.T("commands.playLikedStation"),
+		i18n.T("commands.copyTitle"),
+		i18n.T("commands.removeLiked"),
+		i18n.T("commands.exportLiked"),
+		i18n.T("commands.back"),
+	}
+}
+
+func (m LikedTracksModel) View() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.Tf("likedTracks.title", len(m.tracks))) + "\n\n"
+
+	if !m.loaded {
+		return v
+	}
+	if len(m.tracks) == 0 {
+		return v + m.theme.TertiaryText.Render(i18n.T("likedTracks.empty")) + "\n"
+	}
+
+	// The accessible view isn't bound by the height of the terminal
+	start, end := 0, len(m.tracks)
+	if !m.theme.Accessible && m.height > 0 {
+		start = m.offset
+		if end > start+m.visibleTracks() {
+			end = start + m.visibleTracks()
+		}
+	}
+
+	now := time.Now()
+	for i := start; i < end; i++ {
+		track := m.tracks[i]
+		title := displayText(track.Title)
+		heard := "   " + i18n.Tf("likedTracks.heardOn", displayText(track.Station.Name), likedAt(track.LikedAt, now))
+		switch {
+		case m.theme.Accessible && i == m.cursor:
+			v += ">>> " + title + "\n" + heard + "\n"
+		case m.theme.Accessible:
+			v += "    " + title + "\n" + heard + "\n"
+		case i == m.cursor:
+			v += m.theme.PrimaryBlock.Render(" "+title+" ") + "\n" + m.theme.TertiaryText.Render(heard) + "\n"
+		default:
+			v += m.theme.Text.Render(" "+title) + "\n" + m.theme.TertiaryText.Render(heard) + "\n"
+		}
+	}
+
+	return v
+}
+
+// likedAt returns when a track was liked, with the date unless it was today.
+func likedAt(t time.Time, now time.Time) string {
+	t = t.Local()
+	if y, m, d := now.Local().Date(); t.Year() == y && t.Month() == m && t.Day() == d {
+		return t.Format("15:04")
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+func (m *LikedTracksModel) SetWidthAndHeight(width int, height int) {
+	m.width = width
+	m.height = height
+	m.setCursor(m.cursor)
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// likedTracksOf returns a store of the given tracks, most recent first, which adds and removes tracks in memory.
+func likedTracksOf(tracks ...storage.LikedTrack) *mocks.MockLikedTrackStore {
+	store := &mocks.MockLikedTrackStore{}
+	store.AllFunc = func() ([]storage.LikedTrack, error) {
+		return append([]storage.LikedTrack(nil), tracks...), nil
+	}
+	store.AddFunc = func(track storage.LikedTrack) error {
+		tracks = append([]storage.LikedTrack{track}, tracks...)
+		return nil
+	}
+	store.RemoveFunc = func(likedAt time.Time) error {
+		for i, track := range tracks {
+			if track.LikedAt.Equal(likedAt) {
+				tracks = append(tracks[:i:i], tracks[i+1:]...)
+				break
+			}
+		}
+		return nil
+	}
+	return store
+}
+
+func newTestLikedTracks() []storage.LikedTrack {
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	likedAt := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	return []storage.LikedTrack{
+		{Title: "Miles Davis - So What", Station: station, LikedAt: likedAt.Add(time.Hour)},
+		{Title: "Bill Evans - Peace Piece", Station: station, LikedAt: likedAt},
+	}
+}
+
+func newLoadedLikedTracksModel(store storage.LikedTrackStore, exportDir string) LikedTracksModel {
+	model := NewLikedTracksModel(Theme{}, store, exportDir)
+	model, _ = model.Update(model.Init()())
+	return model
+}
+
+func TestSaveLikedTrackCmd(t *testing.T) {
+
+	tracks := newTestLikedTracks()
+
+	t.Run("likes the track", func(t *testing.T) {
+		store := likedTracksOf(tracks[1])
+		msg := saveLikedTrackCmd(store, tracks[0])()
+		assert.Equal(t, toastSuccess, msg.(toastMsg).kind)
+		liked, _ := store.All()
+		assert.Equal(t, tracks, liked)
+	})
+
+	t.Run("doesn't like the track liked last twice", func(t *testing.T) {
+		store := likedTracksOf(tracks[0])
+		again := tracks[0]
+		again.LikedAt = again.LikedAt.Add(time.Minute)
+		msg := saveLikedTrackCmd(store, again)()
+		assert.Equal(t, toastInfo, msg.(toastMsg).kind)
+		liked, _ := store.All()
+		assert.Equal(t, tracks[:1], liked)
+	})
+}
+
+func TestLikedTracksModel(t *testing.T) {
+
+	tracks := newTestLikedTracks()
+
+	t.Run("lists the liked tracks", func(t *testing.T) {
+		model := newLoadedLikedTracksModel(likedTracksOf(tracks...), t.TempDir())
+		view := model.View()
+		assert.Contains(t, view, "Miles Davis - So What")
+		assert.Contains(t, view, "Bill Evans - Peace Piece")
+		assert.Contains(t, view, "Jazz FM")
+	})
+
+	t.Run("plays the station of the highlighted track", func(t *testing.T) {
+		model := newLoadedLikedTracksModel(likedTracksOf(tracks...), t.TempDir())
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Equal(t, likedTrackStationSelectedMsg{station: tracks[1].Station}, cmd())
+	})
+
+	t.Run("removes the highlighted track, which can be undone", func(t *testing.T) {
+		store := likedTracksOf(tracks...)
+		model := newLoadedLikedTracksModel(store, t.TempDir())
+
+		model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+		assert.Equal(t, tracks[1:], model.tracks)
+		undoable := cmd().(undoableMsg)
+		liked, _ := store.All()
+		assert.Equal(t, tracks[1:], liked)
+
+		model, _ = model.Update(undoable.undo())
+		assert.Equal(t, tracks, model.tracks)
+		liked, _ = store.All()
+		assert.Len(t, liked, 2)
+	})
+
+	t.Run("exports the tracks", func(t *testing.T) {
+		dir := t.TempDir()
+		model := newLoadedLikedTracksModel(likedTracksOf(tracks...), dir)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+		assert.Equal(t, toastSuccess, cmd().(toastMsg).kind)
+		csv, err := os.ReadFile(filepath.Join(dir, "liked-tracks.csv"))
+		assert.NoError(t, err)
+		assert.Len(t, strings.Split(strings.TrimSpace(string(csv)), "\n"), 3)
+
+		_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("X")})
+		assert.Equal(t, toastSuccess, cmd().(toastMsg).kind)
+		assert.FileExists(t, filepath.Join(dir, "liked-tracks.json"))
+	})
+
+	t.Run("closes", func(t *testing.T) {
+		model := newLoadedLikedTracksModel(likedTracksOf(), t.TempDir())
+		assert.Contains(t, model.View(), "No liked tracks yet")
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Equal(t, closeLikedTracksMsg{}, cmd())
+	})
+}
+
+func TestModel_LikeTrack(t *testing.T) {
+
+	newLikingModel := func(store storage.LikedTrackStore) Model {
+		model := NewModel(config.Config{}, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{}, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		model.likedTracks = store
+		return model
+	}
+
+	t.Run("likes the track being played", func(t *testing.T) {
+		store := likedTracksOf()
+		model := newLikingModel(store)
+		station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+		model.nowPlayingModel.station = &station
+		model.nowPlayingModel.title = "Miles Davis - So What"
+
+		_, cmd := model.update(likeTrackRequestedMsg{})
+		assert.Equal(t, toastSuccess, cmd().(toastMsg).kind)
+		liked, _ := store.All()
+		assert.Len(t, liked, 1)
+		assert.Equal(t, "Miles Davis - So What", liked[0].Title)
+		assert.Equal(t, station, liked[0].Station)
+	})
+
+	t.Run("tells when the track isn't known", func(t *testing.T) {
+		store := likedTracksOf()
+		_, cmd := newLikingModel(store).update(likeTrackRequestedMsg{})
+		assert.Equal(t, toastInfo, cmd().(toastMsg).kind)
+		liked, _ := store.All()
+		assert.Empty(t, liked)
+	})
+}
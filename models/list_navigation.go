@@ -0,0 +1,79 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// typeAheadKey is pressed before a letter to jump to the next entry starting with it,
+// in the lists whose letters are commands.
+const typeAheadKey = "'"
+
+// typeAheadLetter returns the lowercase letter or digit typed with msg, if it's one.
+func typeAheadLetter(msg tea.KeyMsg) (rune, bool) {
+	if msg.Type != tea.KeyRunes || msg.Alt || len(msg.Runes) != 1 {
+		return 0, false
+	}
+	r := msg.Runes[0]
+	return unicode.ToLower(r), unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// typeAheadIndex returns the index of the first of count entries after current whose name, as told by nameOf,
+// starts with letter, wrapping around so that typing it again goes through all of them.
+// Leading punctuation is skipped, so that "'Jazz FM'" starts with "j". It returns false if none does.
+func typeAheadIndex(count int, current int, letter rune, nameOf func(i int) string) (int, bool) {
+	for step := 1; step <= count; step++ {
+		i := (current + step) % count
+		name := strings.ToLower(nameOf(i))
+		start := strings.IndexFunc(name, func(r rune) bool {
+			return unicode.IsLetter(r) || unicode.IsDigit(r)
+		})
+		if start >= 0 && []rune(name[start:])[0] == letter {
+			return i, true
+		}
+	}
+	return current, false
+}
+
+// pagedCursor returns cursor moved through count entries by key: a page of pageSize entries up or down
+// with "pgup" and "pgdown", to the first or the last one with "home" and "end".
+func pagedCursor(key string, cursor int, count int, pageSize int) int {
+	switch key {
+	case "pgup":
+		cursor -= pageSize
+	case "pgdown":
+		cursor += pageSize
+	case "home":
+		cursor = 0
+	case "end":
+		cursor = count - 1
+	}
+	if cursor >= count {
+		cursor = count - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	return cursor
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeAheadIndex(t *testing.T) {
+
+	names := []string{"Jazz FM", "\"Rock\" Antenne", "jazz24", "Smooth Jazz"}
+	nameOf := func(i int) string {
+		return names[i]
+	}
+
+	t.Run("goes through the entries starting with the letter, wrapping around", func(t *testing.T) {
+
+		index, ok := typeAheadIndex(len(names), 0, 'j', nameOf)
+		assert.True(t, ok)
+		assert.Equal(t, 2, index)
+
+		index, ok = typeAheadIndex(len(names), index, 'j', nameOf)
+		assert.True(t, ok)
+		assert.Equal(t, 0, index)
+
+	})
+
+	t.Run("skips leading punctuation", func(t *testing.T) {
+
+		index, ok := typeAheadIndex(len(names), 0, 'r', nameOf)
+		assert.True(t, ok)
+		assert.Equal(t, 1, index)
+
+	})
+
+	t.Run("stays put when no entry starts with the letter", func(t *testing.T) {
+
+		index, ok := typeAheadIndex(len(names), 3, 'x', nameOf)
+		assert.False(t, ok)
+		assert.Equal(t, 3, index)
+
+	})
+
+}
+
+func TestPagedCursor(t *testing.T) {
+
+	assert.Equal(t, 10, pagedCursor("pgdown", 0, 25, 10))
+	assert.Equal(t, 24, pagedCursor("pgdown", 20, 25, 10))
+	assert.Equal(t, 0, pagedCursor("pgup", 5, 25, 10))
+	assert.Equal(t, 24, pagedCursor("end", 5, 25, 10))
+	assert.Equal(t, 0, pagedCursor("home", 5, 25, 10))
+	assert.Equal(t, 0, pagedCursor("end", 0, 0, 10))
+
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/playback"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// How often the listening time shown is updated.
+const listeningTickInterval = time.Second
+
+// listeningClock times how long the current station and all of them have been listened to since RadioGoGo started.
+// Reconnections don't start the station's time over, and neither pauses nor the time spent reconnecting count.
+// It's shared by the root, stations and bookmarks models, and only used by their Update and View.
+// A nil *listeningClock shows nothing.
+type listeningClock struct {
+	// The station timed, and how long it was listened to before the running stretch
+	station        uuid.UUID
+	stationElapsed time.Duration
+	// How much of the station's time is already in its play statistics
+	stationSaved time.Duration
+	// How long all stations were listened to before the running stretch
+	sessionElapsed time.Duration
+	// When the running stretch started (zero when stopped or paused)
+	runningSince time.Time
+	// The next start is the station being reconnected
+	reconnecting bool
+
+	now func() time.Time
+}
+
+func newListeningClock() *listeningClock {
+	return &listeningClock{now: time.Now}
+}
+
+// start times the given station, from zero unless it's being reconnected.
+// It returns true if the station was played anew rather than reconnected.
+func (c *listeningClock) start(station uuid.UUID) bool {
+	c.stop()
+	played := !c.reconnecting || station != c.station
+	if played {
+		c.station = station
+		c.stationElapsed = 0
+		c.stationSaved = 0
+	}
+	c.reconnecting = false
+	c.runningSince = c.now()
+	return played
+}
+
+// stop stops timing, keeping the station's time in case it's reconnected.
+func (c *listeningClock) stop() {
+	if c.runningSince.IsZero() {
+		return
+	}
+	stretch := c.now().Sub(c.runningSince)
+	c.stationElapsed += stretch
+	c.sessionElapsed += stretch
+	c.runningSince = time.Time{}
+}
+
+// reconnect tells that the station timed is about to be played again.
+func (c *listeningClock) reconnect() {
+	c.reconnecting = true
+}
+
+// setPaused stops timing while the station is paused, and starts again once it's resumed.
+func (c *listeningClock) setPaused(paused bool) {
+	switch {
+	case paused:
+		c.stop()
+	case c.runningSince.IsZero() && c.station != uuid.Nil:
+		c.runningSince = c.now()
+	}
+}
+
+// elapsed returns how long the current station and all of them have been listened to.
+func (c *listeningClock) elapsed() (station time.Duration, session time.Duration) {
+	station, session = c.stationElapsed, c.sessionElapsed
+	if !c.runningSince.IsZero() {
+		stretch := c.now().Sub(c.runningSince)
+		station += stretch
+		session += stretch
+	}
+	return station, session
+}
+
+// unsaved returns the station timed and how long it was listened to since the last call,
+// which is then considered saved in its play statistics.
+func (c *listeningClock) unsaved() (uuid.UUID, time.Duration) {
+	station, _ := c.elapsed()
+	unsaved := station - c.stationSaved
+	c.stationSaved = station
+	return c.station, unsaved
+}
+
+// suffix returns the listening times to show after the station being played, or an empty string if not timed.
+func (c *listeningClock) suffix() string {
+	if c == nil {
+		return ""
+	}
+	station, session := c.elapsed()
+	return " · " + i18n.Tf("stations.elapsed", formatElapsed(station), formatElapsed(session))
+}
+
+// formatElapsed formats a duration as minutes and seconds, with hours in front from the first hour on.
+func formatElapsed(d time.Duration) string {
+	seconds := int(d / time.Second)
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// Messages
+
+// listeningTickMsg updates the listening time shown.
+type listeningTickMsg struct{}
+
+// Commands
+
+func listeningTickCmd() tea.Cmd {
+	return tea.Tick(listeningTickInterval, func(t time.Time) tea.Msg {
+		return listeningTickMsg{}
+	})
+}
+
+// updateListeningClock times the stations played, whatever the view, ticking every second while one plays.
+func (m Model) updateListeningClock(msg tea.Msg) (Model, tea.Cmd) {
+	if m.clock == nil {
+		return m, nil
+	}
+	switch msg := msg.(type) {
+	case playbackStartedMsg:
+		// What the previous station was listened to is saved before it's timed anew
+		cmds := []tea.Cmd{m.saveListenedCmd()}
+		if m.clock.start(msg.station.StationUuid) && m.playStats != nil {
+			cmds = append(cmds, recordPlayCmd(m.playStats, msg.station.StationUuid, m.clock.now()))
+		}
+		if !m.clockTicking {
+			m.clockTicking = true
+			cmds = append(cmds, listeningTickCmd())
+		}
+		return m, tea.Batch(cmds...)
+	case playbackStoppedMsg:
+		m.clock.stop()
+		return m, m.saveListenedCmd()
+	case reconnectStationMsg:
+		m.clock.reconnect()
+	case listeningTickMsg:
+		if !m.playbackManager.IsPlaying() {
+			m.clockTicking = false
+			return m, nil
+		}
+		if timeshifter, ok := m.playbackManager.(playback.Timeshifter); ok {
+			m.clock.setPaused(timeshifter.IsPaused())
+		}
+		return m, listeningTickCmd()
+	}
+	return m, nil
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock returns a listening clock whose time is moved on by advance.
+func fakeClock() (*listeningClock, func(time.Duration)) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := newListeningClock()
+	clock.now = func() time.Time { return now }
+	return clock, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestListeningClock(t *testing.T) {
+
+	first, second := uuid.New(), uuid.New()
+
+	t.Run("times the station and the session", func(t *testing.T) {
+		clock, advance := fakeClock()
+		clock.start(first)
+		advance(90 * time.Second)
+
+		station, session := clock.elapsed()
+		assert.Equal(t, 90*time.Second, station)
+		assert.Equal(t, 90*time.Second, session)
+	})
+
+	t.Run("starts the station over when another one is played", func(t *testing.T) {
+		clock, advance := fakeClock()
+		clock.start(first)
+		advance(time.Minute)
+		clock.stop()
+		advance(time.Hour)
+		clock.start(second)
+		advance(10 * time.Second)
+
+		station, session := clock.elapsed()
+		assert.Equal(t, 10*time.Second, station)
+		assert.Equal(t, 70*time.Second, session)
+	})
+
+	t.Run("starts the station over when it's played again", func(t *testing.T) {
+		clock, advance := fakeClock()
+		clock.start(first)
+		advance(time.Minute)
+		clock.stop()
+		clock.start(first)
+
+		station, session := clock.elapsed()
+		assert.Equal(t, time.Duration(0), station)
+		assert.Equal(t, time.Minute, session)
+	})
+
+	t.Run("survives reconnections without counting them", func(t *testing.T) {
+		clock, advance := fakeClock()
+		clock.start(first)
+		advance(time.Minute)
+		clock.stop()
+		clock.reconnect()
+		advance(5 * time.Second)
+		clock.start(first)
+		advance(time.Second)
+
+		station, session := clock.elapsed()
+		assert.Equal(t, 61*time.Second, station)
+		assert.Equal(t, 61*time.Second, session)
+	})
+
+	t.Run("doesn't count pauses", func(t *testing.T) {
+		clock, advance := fakeClock()
+		clock.start(first)
+		advance(time.Minute)
+		clock.setPaused(true)
+		advance(time.Hour)
+		clock.setPaused(false)
+		advance(time.Second)
+
+		station, session := clock.elapsed()
+		assert.Equal(t, 61*time.Second, station)
+		assert.Equal(t, 61*time.Second, session)
+	})
+
+	t.Run("nil shows nothing", func(t *testing.T) {
+		var clock *listeningClock
+		assert.Empty(t, clock.suffix())
+	})
+}
+
+func TestFormatElapsed(t *testing.T) {
+
+	tests := []struct {
+		elapsed time.Duration
+		text    string
+	}{
+		{0, "0:00"},
+		{1500 * time.Millisecond, "0:01"},
+		{65 * time.Second, "1:05"},
+		{59*time.Minute + 59*time.Second, "59:59"},
+		{time.Hour + 2*time.Minute + 3*time.Second, "1:02:03"},
+		{26 * time.Hour, "26:00:00"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.text, formatElapsed(tt.elapsed))
+	}
+}
@@ -22,6 +22,7 @@ package models
 import (
 	"github.com/zi0p4tch0/radiogogo/api"
 	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -33,17 +34,27 @@ type LoadingModel struct {
 	spinnerModel spinner.Model
 	query        common.StationQuery
 	queryText    string
+	filter       common.StationFilter
+	autoplay     bool
 	width        int
 	height       int
 
-	browser api.RadioBrowserService
+	browser     api.RadioBrowserService
+	rateLimiter *api.RateLimiter
+	pages       *stationPageCache
+	// fetch loads the stations in place of a radio-browser search, if set
+	fetch tea.Cmd
 }
 
 func NewLoadingModel(
 	theme Theme,
 	browser api.RadioBrowserService,
+	rateLimiter *api.RateLimiter,
+	pages *stationPageCache,
 	query common.StationQuery,
 	queryText string,
+	filter common.StationFilter,
+	autoplay bool,
 ) LoadingModel {
 
 	s := spinner.New()
@@ -55,13 +66,21 @@ func NewLoadingModel(
 		spinnerModel: s,
 		query:        query,
 		queryText:    queryText,
+		filter:       filter,
+		autoplay:     autoplay,
 		browser:      browser,
+		rateLimiter:  rateLimiter,
+		pages:        pages,
 	}
 
 }
 
 func (m LoadingModel) Init() tea.Cmd {
-	return tea.Batch(m.spinnerModel.Tick, searchStations(m.browser, m.query, m.queryText))
+	fetch := m.fetch
+	if fetch == nil {
+		fetch = searchStations(m.browser, m.pages, m.query, m.queryText, m.filter, m.autoplay)
+	}
+	return tea.Batch(m.spinnerModel.Tick, fetch)
 }
 
 func (m LoadingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -71,21 +90,43 @@ func (m LoadingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m LoadingModel) View() string {
-	return "\n" + m.spinnerModel.View() + " Fetching radio stations..."
+	text := i18n.T("loading.stations")
+	// The view is redrawn on every spinner tick, so this follows the queue as it drains.
+	if queued := m.rateLimiter.Queued(); queued > 0 {
+		text = i18n.Tf("loading.queued", queued)
+	}
+	if m.theme.Accessible {
+		return "\n" + text
+	}
+	return "\n" + m.spinnerModel.View() + " " + text
+}
+
+// Messages
+
+// searchFailedMsg goes back to the search form, telling why the stations couldn't be loaded.
+type searchFailedMsg struct {
+	err error
 }
 
 // Commands
 
-func searchStations(browser api.RadioBrowserService, query common.StationQuery, queryText string) tea.Cmd {
+// searchStations loads the first page of results, through the page cache so that the next ones can be prefetched.
+func searchStations(browser api.RadioBrowserService, pages *stationPageCache, query common.StationQuery, queryText string, filter common.StationFilter, autoplay bool) tea.Cmd {
 	return func() tea.Msg {
-		stations, err := browser.GetStations(query, queryText, "votes", true, 0, 100, true)
+		key := stationPageKey{query: query, queryText: queryText, filter: filter}
+		stations, err := pages.get(browser, key)
 		if err != nil {
-			return switchToErrorModelMsg{err: err.Error()}
+			return searchFailedMsg{err: err}
 		}
-		return switchToStationsModelMsg{stations: stations}
+		return switchToStationsModelMsg{stations: stations, autoplay: autoplay, page: key}
 	}
 }
 
+// SetFetch has the stations loaded by the given command rather than searched for, e.g. for discovery.
+func (m *LoadingModel) SetFetch(fetch tea.Cmd) {
+	m.fetch = fetch
+}
+
 func (m *LoadingModel) SetWidthAndHeight(width int, height int) {
 	m.width = width
 	m.height = height
@@ -37,7 +37,7 @@ func TestLoadingModel_Init(t *testing.T) {
 	t.Run("starts the spinner", func(t *testing.T) {
 
 		mockBrowser := mocks.MockRadioBrowserService{}
-		model := NewLoadingModel(Theme{}, &mockBrowser, common.StationQueryAll, "text")
+		model := NewLoadingModel(Theme{}, &mockBrowser, nil, nil, common.StationQueryAll, "text", common.StationFilter{}, false)
 
 		cmd := model.Init()
 		assert.NotNil(t, cmd)
@@ -68,7 +68,7 @@ func TestLoadingModel_Init(t *testing.T) {
 			},
 		}
 
-		model := NewLoadingModel(Theme{}, &mockBrowser, common.StationQueryAll, "text")
+		model := NewLoadingModel(Theme{}, &mockBrowser, nil, nil, common.StationQueryAll, "text", common.StationFilter{}, false)
 
 		cmd := model.Init()
 		assert.NotNil(t, cmd)
@@ -88,7 +88,7 @@ func TestLoadingModel_Init(t *testing.T) {
 
 	})
 
-	t.Run("searches for stations and broadcasts switchToErrorModelMsg on error", func(t *testing.T) {
+	t.Run("searches for stations and broadcasts searchFailedMsg on error", func(t *testing.T) {
 
 		mockBrowser := mocks.MockRadioBrowserService{
 			GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
@@ -99,7 +99,7 @@ func TestLoadingModel_Init(t *testing.T) {
 			},
 		}
 
-		model := NewLoadingModel(Theme{}, &mockBrowser, common.StationQueryAll, "text")
+		model := NewLoadingModel(Theme{}, &mockBrowser, nil, nil, common.StationQueryAll, "text", common.StationFilter{}, false)
 
 		cmd := model.Init()
 		assert.NotNil(t, cmd)
@@ -109,7 +109,8 @@ func TestLoadingModel_Init(t *testing.T) {
 		found := false
 		for _, msg := range batchMsg {
 			currentMsg := msg()
-			if _, ok := currentMsg.(switchToErrorModelMsg); ok {
+			if failed, ok := currentMsg.(searchFailedMsg); ok {
+				assert.ErrorIs(t, failed.err, io.EOF)
 				found = true
 				break
 			}
@@ -20,13 +20,45 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
 	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/assets"
+	"github.com/zi0p4tch0/radiogogo/cast"
 	"github.com/zi0p4tch0/radiogogo/common"
 	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/enrich"
+	"github.com/zi0p4tch0/radiogogo/epg"
+	"github.com/zi0p4tch0/radiogogo/eventlog"
+	"github.com/zi0p4tch0/radiogogo/events"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/icy"
+	"github.com/zi0p4tch0/radiogogo/instance"
+	"github.com/zi0p4tch0/radiogogo/netwatch"
+	"github.com/zi0p4tch0/radiogogo/nowplaying"
+	"github.com/zi0p4tch0/radiogogo/offline"
 	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/recording"
+	"github.com/zi0p4tch0/radiogogo/secrets"
+	"github.com/zi0p4tch0/radiogogo/storage"
+	"github.com/zi0p4tch0/radiogogo/updates"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/google/uuid"
+)
+
+// Minimum terminal size below which a warning is shown instead of the UI.
+const (
+	minWidth  = 40
+	minHeight = 10
 )
 
 type modelState int
@@ -37,21 +69,50 @@ const (
 	errorState
 	loadingState
 	stationsState
+	tagCloudState
+	chartsState
+	bookmarksState
+	outputState
+	profilesState
+	diagnosticsState
 )
 
 // State switching messages
 
 type switchToErrorModelMsg struct {
 	err string
+	// remedies tell what can be done about it.
+	remedies []string
 }
 type switchToSearchModelMsg struct {
 }
 type switchToLoadingModelMsg struct {
 	query     common.StationQuery
 	queryText string
+	// filter narrows name searches down to a country and/or language.
+	filter common.StationFilter
+	// autoplay plays the first station found.
+	autoplay bool
 }
 type switchToStationsModelMsg struct {
 	stations []common.Station
+	autoplay bool
+	// page is the page of results the stations belong to.
+	page stationPageKey
+	// selected is the UUID of the station the cursor starts on (the first station if not listed).
+	selected uuid.UUID
+}
+type switchToTagCloudModelMsg struct {
+}
+type switchToChartsModelMsg struct {
+}
+type switchToBookmarksModelMsg struct {
+}
+type switchToOutputModelMsg struct {
+}
+type switchToProfilesModelMsg struct {
+}
+type switchToDiagnosticsModelMsg struct {
 }
 
 // UI messages
@@ -60,21 +121,34 @@ type bottomBarUpdateMsg struct {
 	commands []string
 }
 
-// Quit message
+// Remote control messages
 
-type quitMsg struct{}
+// remoteCommandMsg carries a command forwarded by another launch of RadioGoGo.
+type remoteCommandMsg struct {
+	command instance.Command
+}
 
-func quitCmd() tea.Msg {
-	return quitMsg{}
+// NewRemoteCommandMsg wraps a command received from another launch of RadioGoGo,
+// so that it can be sent to the running program.
+func NewRemoteCommandMsg(command instance.Command) tea.Msg {
+	return remoteCommandMsg{command: command}
 }
 
 // Commands
 
-func checkIfPlaybackIsPossibleCmd(playbackManager playback.PlaybackManagerService) tea.Cmd {
+// ringBellCmd rings the terminal bell, which most terminals and multiplexers
+// turn into an urgency hint on the window or tab running RadioGoGo.
+func ringBellCmd() tea.Msg {
+	fmt.Fprint(os.Stdout, "\a")
+	return nil
+}
+
+func checkIfPlaybackIsPossibleCmd(playbackManager playback.PlaybackManagerService, remedies []string) tea.Cmd {
 	return func() tea.Msg {
 		if !playbackManager.IsAvailable() {
 			return switchToErrorModelMsg{
-				err: playbackManager.NotAvailableErrorString(),
+				err:      playbackManager.NotAvailableErrorString(),
+				remedies: remedies,
 			}
 		}
 		return switchToSearchModelMsg{}
@@ -94,7 +168,47 @@ type Model struct {
 	errorModel        ErrorModel
 	loadingModel      LoadingModel
 	stationsModel     StationsModel
+	tagCloudModel     TagCloudModel
+	chartsModel       ChartsModel
+	bookmarksModel    BookmarksModel
+	outputModel       OutputModel
+	profilesModel     ProfilesModel
+	diagnosticsModel  DiagnosticsModel
+	nowPlayingModel   NowPlayingModel
+	programGuideModel ProgramGuideModel
+	trackDetailsModel TrackDetailsModel
+	toastModel        ToastModel
+	errorBannerModel  ErrorBannerModel
+	updateBannerModel UpdateBannerModel
+	statusBarModel    StatusBarModel
 	bottomBarCommands []string
+	// The destructive actions of the session that can be undone with "u", latest last
+	undoStack []undoableMsg
+	// The help overlay lists the key bindings of the view it was opened from, in place of it
+	helpModel HelpModel
+	showHelp  bool
+	helpState modelState
+	// The Open URL dialog asks for a stream to play, in place of the view it was opened from
+	openURLModel OpenURLModel
+	showOpenURL  bool
+	openURLState modelState
+	// The API inspector, available in developer mode (nil otherwise)
+	inspector      api.Inspector
+	inspectorModel InspectorModel
+	showInspector  bool
+	inspectorState modelState
+	// The liked tracks are listed in place of the view they were opened from
+	likedTracksModel LikedTracksModel
+	showLikedTracks  bool
+	likedTracksState modelState
+	// And so are the stations hidden for good
+	blocklistModel BlocklistModel
+	showBlocklist  bool
+	blocklistState modelState
+	// And so is the theme editor
+	themeEditorModel ThemeEditorModel
+	showThemeEditor  bool
+	themeEditorState modelState
 
 	// State
 	state           modelState
@@ -102,60 +216,593 @@ type Model struct {
 	height          int
 	browser         api.RadioBrowserService
 	playbackManager playback.PlaybackManagerService
+	labelStore      storage.LabelStore
+	bookmarkStore   storage.BookmarkStore
+	reportStore     storage.ReportStore
+	contentFilter   filter.ContentFilter
+	prober          icy.ProberService
+	rateLimiter     *api.RateLimiter
+	pages           *stationPageCache
+	catalog         *catalog
+	stationColumns  []config.StationColumn
+	splitPane       bool
+	// Counts the data used by the stations, if metered
+	bandwidth *bandwidthUsage
+	// Loudness of the station being played, if measured for the VU meter
+	levels *playback.Levels
+	// How long the station being played and all of them have been listened to,
+	// and whether it's ticking to update what's shown
+	clock        *listeningClock
+	clockTicking bool
+	// How far behind the broadcast the station being played is heard, as last estimated
+	delay *streamDelay
+	// Holds back redraws on slow terminals, and keeps the rendering of the sections of the view
+	render *renderThrottle
+	// Remembers the clicks and votes sent to radio-browser, so that they honor its cooldowns
+	interactions storage.InteractionStore
+	// Sends the clicks and votes, again later if radio-browser can't be reached,
+	// and whether it's waiting to retry them
+	actions         *api.ActionQueue
+	actionsRetrying bool
+	// Checks the network, to hold off background requests while it's down and reconnect once it's back (nil never does)
+	network *networkWatch
+	// Remembers how much louder or quieter each station plays
+	volumeTrims storage.VolumeTrimStore
+	// Remembers which URL of each station played, to try it first (nil always tries the resolved URL first)
+	streamVariants storage.StreamVariantStore
+	// Keeps the headers some streams require
+	streamHeaders storage.StreamHeaderStore
+	// Keeps how each station is played, overriding the configuration
+	stationPrefs storage.StationPrefsStore
+	// Keeps the stations hidden for good (nil hides none)
+	blocklist storage.BlocklistStore
+	// Keeps the credentials of private streams
+	credentials secrets.Store
+	// The command stations are handed to with "e"
+	externalPlayer string
+	// How each radio-browser mirror has been answering, if reached through mirrors
+	mirrorStats api.MirrorStatsProvider
+	// Fetched assets, such as station favicons, are cached through it (nil when they aren't shown)
+	assets assets.Cache
+	// Pre-populates the search form
+	searchFilter common.StationFilter
+	// Persists the columns chosen in the column picker
+	saveStationColumns func(columns []config.StationColumn) error
+	// Persists the colors edited in the theme editor
+	saveThemeColors func(colors config.ThemeColors) error
+	// Starts and stops the scheduled recordings, checked periodically once there are any
+	recordings          *recordingScheduler
+	recordingsScheduled bool
+	// Persists the recordings scheduled from the stations view
+	saveRecordingSchedule func(entries []recording.Entry) error
+
+	// Playback manager for the local engine, kept while casting to a device
+	localPlaybackManager playback.PlaybackManagerService
+	discoverDevices      func(timeout time.Duration) ([]cast.Device, error)
+
+	// Station requested by another launch before the boot completed, and whether to play it,
+	// or stream URL to play
+	pendingStationUuid string
+	pendingAutoplay    bool
+	pendingURL         string
+	// What can be done when the playback backend can't be used
+	playbackRemedies []string
+	// What to open once the boot has completed, instead of the search form
+	startupView config.StartupView
+	// Whether a station is suggested a day on the search form, and where the suggestion is cached (nil doesn't cache it)
+	suggestStationOfTheDay bool
+	cache                  storage.CacheStore
+	// Remember the stations played and the last search made (nil forgets them)
+	history  storage.HistoryStore
+	searches storage.SearchStore
+	// Where the events of the session are published, for the history, the last search and the event log to react to
+	bus *events.Bus
+	// Counts the plays and listening time of each station (nil doesn't), and the order bookmarks are listed in
+	playStats     storage.PlayStatsStore
+	bookmarkOrder bookmarkOrder
+	// Keeps the tracks liked while listening (nil can't like them)
+	likedTracks storage.LikedTrackStore
+	// Remembers the stations suggested by discovery, to learn which ones were kept (nil can't discover)
+	discoveries storage.DiscoveryStore
+
+	// Playback backend processes that exit on their own, watched when playing locally
+	backendExits <-chan playback.ProcessExit
+	// The station being played, reconnected when the playback watchdog kills its backend,
+	// how many times in a row it has been, and when it last was
+	playingStation     common.Station
+	watchdogReconnects int
+	watchdogTrippedAt  time.Time
+	// The station about to be reconnected, and whether the one last started was, so that it resumes its listening session
+	reconnecting uuid.UUID
+	resumed      bool
+	// Where events such as reconnections are logged (nil discards them)
+	eventLog *eventlog.Log
+	// Asks for a newer release of RadioGoGo on startup (nil never does)
+	updateChecker updates.CheckerService
+	// How playback stopping on its own is brought to the user's attention, besides telling why
+	alertBell  bool
+	alertFlash bool
+	// Whether an external trigger turned stations down, and to which percentage of their volume
+	ducked    bool
+	duckLevel int
+	// The bottom bar is flashing until the flash of the given generation ends
+	flashing        bool
+	flashGeneration int
+
+	// Kept when switching themes at runtime
+	colorBlindMode config.ColorBlindMode
+	// The colors the theme is drawn with, but for the color-blind palette, which the theme editor starts from
+	themeColors config.ThemeColors
+
+	// Stations queued from the results, kept across searches, and how long each one plays
+	queue      *stationQueue
+	queueDwell time.Duration
+	// scanDwell is how long each station plays while scanning the results.
+	scanDwell time.Duration
+	// refreshInterval is how often the results are fetched again in the background (0 never).
+	refreshInterval time.Duration
+	// highlightDuration is how long the stations that changed are highlighted once the results are refreshed (0 never).
+	highlightDuration time.Duration
+
+	// The profile in use, the profiles that can be switched to (nil disables switching),
+	// and the one picked, which RadioGoGo restarts with after quitting
+	profile      string
+	listProfiles func() ([]string, error)
+	nextProfile  string
+
+	// Asks to quit twice in a row while a station is playing or being recorded
+	quitConfirmation quitConfirmation
+}
+
+// PlaybackOptions returns the playback options configured.
+func PlaybackOptions(cfg config.Config) playback.Options {
+	return playback.Options{
+		SeamlessSwitch:  cfg.Playback.SeamlessSwitch,
+		BufferSeconds:   cfg.Playback.BufferSeconds,
+		LowLatency:      cfg.Playback.LowLatency,
+		Crossfade:       time.Duration(cfg.Playback.CrossfadeSeconds * float64(time.Second)),
+		Normalize:       cfg.Playback.Normalize,
+		WatchdogTimeout: time.Duration(cfg.Playback.WatchdogSeconds) * time.Second,
+	}
+}
+
+// NewBackendPlayer returns the playback manager running the backend configured, which plays
+// to the network output if one is, or else with the engine each station's preferences ask for.
+func NewBackendPlayer(cfg config.Config, options playback.Options) playback.PlaybackManagerService {
+	switch cfg.Output.Mode {
+	case playback.OutputSnapcast:
+		return playback.NewSnapcastPlaybackManager(cfg.Output.Snapcast, options)
+	case playback.OutputIcecast:
+		return playback.NewIcecastPlaybackManager(cfg.Output.Icecast, cfg.Output.IcecastBitrate, options)
+	}
+	// Stations may be played with the other engine, as their preferences ask
+	engines := map[playback.PlaybackEngineType]playback.PlaybackManagerService{
+		playback.FFPlay: playback.NewFFPlaybackManager(options),
+		playback.MPV:    playback.NewMPVbackManager(options),
+	}
+	configured := cfg.PlaybackEngine
+	if configured != playback.FFPlay {
+		configured = playback.MPV
+	}
+	return playback.NewEnginePlaybackManager(engines, configured)
+}
+
+// NewPlayer returns the backend player configured, given the variant of HLS stations to play.
+func NewPlayer(cfg config.Config, options playback.Options) playback.PlaybackManagerService {
+	return playback.NewHLSPlaybackManager(NewBackendPlayer(cfg, options), cfg.Playback.HLSBitrate)
 }
 
-func NewDefaultModel(config config.Config) (Model, error) {
+func NewDefaultModel(cfg config.Config, db *storage.DB) (Model, error) {
 
-	browser, err := api.NewRadioBrowser()
+	rateLimiter := api.NewRateLimiter(cfg.API.RequestsPerSecond)
+	browser, err := api.NewRadioBrowser(cfg.API.BaseURL, rateLimiter)
+	mirrorStats, _ := browser.(api.MirrorStatsProvider)
+	eventLog := eventlog.New(config.LogFile())
+	if logger, ok := browser.(api.EventLogger); ok {
+		logger.SetEventLog(eventLog)
+	}
+	if racer, ok := browser.(api.SearchRacer); ok {
+		racer.SetRaceSearches(cfg.API.RaceSearches)
+	}
+	inspector, _ := browser.(api.Inspector)
+	if inspector != nil {
+		inspector.SetInspecting(cfg.Developer.Enabled)
+	}
+	// Search the snapshot taken by "radiogogo sync" whenever radio-browser can't be reached.
+	if snapshot, snapshotErr := offline.LoadBrowser(config.CatalogFile()); snapshotErr == nil {
+		browser = offline.NewFallbackBrowser(browser, snapshot)
+		err = nil
+	}
 	if err != nil {
 		return Model{}, err
 	}
 
-	var playbackManager playback.PlaybackManagerService
-	if config.PlaybackEngine == playback.FFPlay {
-		playbackManager = playback.NewFFPlaybackManager()
-	} else {
-		playbackManager = playback.NewMPVbackManager()
+	labelStore := storage.NewBoltLabelStore(db)
+	bookmarkStore := storage.NewBoltBookmarkStore(db)
+
+	// The level meter redraws several times a second, which slow links can't keep up with
+	var levels *playback.Levels
+	if cfg.Playback.LevelMeter && !cfg.Render.LowBandwidth {
+		levels = playback.NewLevels()
 	}
 
-	return NewModel(config, browser, playbackManager), nil
+	playbackOptions := PlaybackOptions(cfg)
+	playbackOptions.Levels = levels
+	if cfg.Playback.Verbose {
+		playbackOptions.CommandLog = func(invocation playback.Invocation) {
+			_ = eventLog.Printf("running %s", invocation)
+		}
+	}
+
+	playbackManager := NewPlayer(cfg, playbackOptions)
+
+	var meter *playback.Meter
+	if cfg.Bandwidth.Meter {
+		meter = playback.NewMeter()
+	}
+
+	var transport *http.Transport
+	if cfg.Network.Relay {
+		if transport, err = playback.RelayTransport(cfg.Network.Proxy, cfg.Network.CAFile); err != nil {
+			return Model{}, fmt.Errorf("network relay: %w", err)
+		}
+	}
+
+	// The timeshift download is metered and relayed as it is, other stations are relayed to be metered
+	if cfg.Output.Mode == playback.OutputLocal && cfg.Playback.TimeshiftMinutes > 0 {
+		playbackManager = playback.NewTimeshiftPlaybackManager(playbackManager, cfg.Playback.TimeshiftMinutes, transport, meter)
+	} else if meter != nil || transport != nil {
+		playbackManager = playback.NewRelayPlaybackManager(playbackManager, transport, meter)
+	}
+
+	model := NewModel(cfg, browser, playbackManager, labelStore, bookmarkStore, storage.NewBoltReportStore(db), icy.NewProber())
+	model.rateLimiter = rateLimiter
+	model.backendExits = playback.Exits()
+	model.listProfiles = config.ProfileNames
+	model.mirrorStats = mirrorStats
+	if cfg.Developer.Enabled {
+		model.inspector = inspector
+	}
+	model.interactions = storage.NewBoltInteractionStore(db)
+	model.volumeTrims = storage.NewBoltVolumeTrimStore(db)
+	model.streamVariants = storage.NewBoltStreamVariantStore(db)
+	model.streamHeaders = storage.NewBoltStreamHeaderStore(db)
+	model.stationPrefs = storage.NewBoltStationPrefsStore(db)
+	model.blocklist = storage.NewBoltBlocklistStore(db)
+	model.credentials = secrets.Open(config.SecretsDir())
+	model.history = storage.NewBoltHistoryStore(db)
+	model.cache = storage.NewBoltCacheStore(db)
+	model.searches = storage.NewBoltSearchStore(db)
+	model.playStats = storage.NewBoltPlayStatsStore(db)
+	model.likedTracks = storage.NewBoltLikedTrackStore(db)
+	model.discoveries = storage.NewBoltDiscoveryStore(db)
+	// Track titles are probed whatever is published, so that the one playing can be liked
+	model.nowPlayingModel.probeTitles = true
+	model.eventLog = eventLog
+	model.bus = newSessionBus(model.history, model.searches, eventLog)
+	if !cfg.Render.LowBandwidth {
+		model.assets = assets.NewDiskCache(config.AssetsDir(), int64(cfg.Assets.CacheMB)<<20)
+	}
+	model.levels = levels
+	if cfg.Updates.Check {
+		model.updateChecker = updates.NewGitHub()
+	}
+	if meter != nil {
+		model.bandwidth = newBandwidthUsage(meter, storage.NewBoltUsageStore(db), cfg.Bandwidth.MonthlyCapMB)
+	}
+	return model, nil
 
 }
 
 func NewModel(
-	config config.Config,
+	cfg config.Config,
 	browser api.RadioBrowserService,
 	playbackManager playback.PlaybackManagerService,
+	labelStore storage.LabelStore,
+	bookmarkStore storage.BookmarkStore,
+	reportStore storage.ReportStore,
+	prober icy.ProberService,
 ) Model {
 
-	theme := NewTheme(config)
+	theme := NewTheme(cfg)
+	colors, _ := themeColors(cfg)
+
+	stationColumns := cfg.Stations.Columns
+	if len(stationColumns) == 0 {
+		stationColumns = config.DefaultStationColumns()
+	}
+
+	recordingsDir := cfg.Recordings.Directory
+	if recordingsDir == "" {
+		recordingsDir = config.RecordingsDir()
+	}
+	recordings := newRecordingScheduler(cfg.Recordings.Schedule, recordingsDir, cfg.Recordings.Sidecar, browser)
+	recordings.split = cfg.Recordings.Split
+	recordings.minTrackLength = time.Duration(cfg.Recordings.MinTrackSeconds) * time.Second
+	recordings.limits = recording.Limits{
+		MaxSize:      int64(cfg.Recordings.MaxSizeMB) << 20,
+		MaxDuration:  time.Duration(cfg.Recordings.MaxMinutes) * time.Minute,
+		MinFreeSpace: uint64(cfg.Recordings.MinFreeMB) << 20,
+	}
+	recordings.encodings = cfg.Recordings.Encoding
+
+	headerModel := NewHeaderModel(theme, playbackManager)
+	if config.Profile() != config.DefaultProfile {
+		headerModel.profile = config.Profile()
+	}
+
+	var enricher enrich.Enricher
+	if cfg.Enrichment.MusicBrainz {
+		enricher = enrich.NewCached(enrich.NewMusicBrainz(), trackDetailsCacheSize)
+	}
+
+	nowPlayingModel := NewNowPlayingModel(prober, labelStore, nowPlayingPublishers(cfg)...)
+	nowPlayingModel.probeTitles = enricher != nil
 
 	return Model{
-		theme:           theme,
-		headerModel:     NewHeaderModel(theme, playbackManager),
-		state:           bootState,
-		browser:         browser,
-		playbackManager: playbackManager,
+		theme:                  theme,
+		colorBlindMode:         cfg.Theme.ColorBlindMode,
+		themeColors:            colors,
+		headerModel:            headerModel,
+		nowPlayingModel:        nowPlayingModel,
+		programGuideModel:      NewProgramGuideModel(theme, epg.NewFetcher(), cfg.EPG.Sources),
+		trackDetailsModel:      NewTrackDetailsModel(theme, enricher),
+		toastModel:             NewToastModel(theme),
+		errorBannerModel:       NewErrorBannerModel(theme),
+		updateBannerModel:      NewUpdateBannerModel(theme),
+		statusBarModel:         NewStatusBarModel(theme, playbackManager, cfg.StatusBar.Enabled),
+		state:                  bootState,
+		browser:                browser,
+		actions:                api.NewActionQueue(browser),
+		network:                newNetworkWatch(netwatch.NewChecker(), time.Duration(cfg.Network.CheckSeconds)*time.Second),
+		playbackManager:        playbackManager,
+		labelStore:             labelStore,
+		bookmarkStore:          bookmarkStore,
+		reportStore:            reportStore,
+		prober:                 prober,
+		contentFilter:          cfg.Filters,
+		localPlaybackManager:   playbackManager,
+		discoverDevices:        cast.Discover,
+		pages:                  newStationPageCache(),
+		clock:                  newListeningClock(),
+		delay:                  newStreamDelay(),
+		render:                 newRenderThrottle(renderInterval(cfg)),
+		startupView:            cfg.Startup.View,
+		suggestStationOfTheDay: cfg.Startup.StationOfTheDay,
+		quitConfirmation:       quitConfirmation{enabled: cfg.Quit.Confirm},
+		playbackRemedies:       playbackRemedies(cfg, runtime.GOOS),
+		queue:                  newStationQueue(),
+		queueDwell:             time.Duration(cfg.Queue.DwellSeconds) * time.Second,
+		scanDwell:              time.Duration(cfg.Scan.DwellSeconds) * time.Second,
+		refreshInterval:        time.Duration(cfg.Stations.RefreshMinutes) * time.Minute,
+		highlightDuration:      time.Duration(cfg.Stations.HighlightSeconds) * time.Second,
+		externalPlayer:         cfg.Playback.ExternalPlayer,
+		profile:                config.Profile(),
+		stationColumns:         stationColumns,
+		splitPane:              cfg.Stations.SplitPane,
+		alertBell:              cfg.Terminal.Bell,
+		alertFlash:             cfg.Terminal.Flash,
+		duckLevel:              duckLevel(cfg),
+		searchFilter: common.StationFilter{
+			CountryCode: cfg.Search.DefaultCountryCode,
+			Language:    cfg.Search.DefaultLanguage,
+		},
+		saveStationColumns: func(columns []config.StationColumn) error {
+			return config.SaveStationColumns(config.ConfigFile(), columns)
+		},
+		saveThemeColors: func(colors config.ThemeColors) error {
+			return config.SaveThemeColors(config.ConfigFile(), colors)
+		},
+		recordings:          recordings,
+		recordingsScheduled: len(cfg.Recordings.Schedule) > 0,
+		saveRecordingSchedule: func(entries []recording.Entry) error {
+			return config.SaveRecordingSchedule(config.ConfigFile(), entries)
+		},
+	}
+}
+
+// nowPlayingPublishers returns where the station and track being played are published to.
+func nowPlayingPublishers(cfg config.Config) []nowplaying.Publisher {
+	var publishers []nowplaying.Publisher
+	if writer := nowplaying.NewWriter(cfg.NowPlaying.File, cfg.NowPlaying.Format, cfg.NowPlaying.Pipe); writer != nil {
+		publishers = append(publishers, writer)
 	}
+	if terminal := nowplaying.NewTerminal(os.Stdout, cfg.Terminal.Title, cfg.Terminal.Tmux); terminal != nil {
+		publishers = append(publishers, terminal)
+	}
+	return publishers
 }
 
 func (m Model) Init() tea.Cmd {
-	return checkIfPlaybackIsPossibleCmd(m.playbackManager)
+	cmds := []tea.Cmd{checkIfPlaybackIsPossibleCmd(m.playbackManager, m.playbackRemedies)}
+	if statusBarCmd := m.statusBarModel.Init(); statusBarCmd != nil {
+		cmds = append(cmds, statusBarCmd)
+	}
+	if m.network != nil {
+		cmds = append(cmds, checkNetworkCmd(m.network.checker))
+	}
+	if m.bandwidth != nil {
+		cmds = append(cmds, bandwidthTickCmd())
+	}
+	if m.recordingsScheduled {
+		cmds = append(cmds, runRecordingsCmd(m.recordings), recordingTickCmd())
+	}
+	if m.backendExits != nil {
+		cmds = append(cmds, waitForBackendExitCmd(m.backendExits))
+	}
+	if m.updateChecker != nil {
+		cmds = append(cmds, checkForUpdateCmd(m.updateChecker))
+	}
+	if len(cmds) == 1 {
+		return cmds[0]
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
+	// The panes above the bottom bar take height from the current view
+	panesHeight := m.panesHeight()
+
+	// Redraws that the user isn't waiting for are held back on slow terminals
+	renderCmd := m.render.invalidate(msg)
+
+	// The now-playing output follows playback whatever the current view,
+	// and so do the details of the track being played
+	var nowPlayingCmd, trackDetailsCmd tea.Cmd
+	title := m.nowPlayingModel.title
+	m.nowPlayingModel, nowPlayingCmd = m.nowPlayingModel.Update(msg)
+	if m.nowPlayingModel.title != title {
+		m.trackDetailsModel, trackDetailsCmd = m.trackDetailsModel.SetTitle(m.nowPlayingModel.title)
+	} else {
+		m.trackDetailsModel, trackDetailsCmd = m.trackDetailsModel.Update(msg)
+	}
+
+	// So does the program guide
+	var programGuideCmd tea.Cmd
+	m.programGuideModel, programGuideCmd = m.programGuideModel.Update(msg)
+
+	// The watchdog reconnects the station being played, whatever the view
+	m = m.trackPlayingStation(msg)
+
+	// The network is watched whatever the view
+	var networkCmd tea.Cmd
+	m, networkCmd = m.watchNetwork(msg)
+
+	// The status bar shows what's playing whatever the view
+	var statusBarCmd, statusBarPlayingCmd tea.Cmd
+	m.statusBarModel, statusBarCmd = m.statusBarModel.Update(msg)
+	m.statusBarModel, statusBarPlayingCmd = m.statusBarModel.SetPlaying(m.statusBarStation(), displayText(m.nowPlayingModel.title))
+
+	// Listening is timed whatever the view too
+	var clockCmd tea.Cmd
+	m, clockCmd = m.updateListeningClock(msg)
+
+	// And so is the delay of the station being played estimated
+	var delayCmd tea.Cmd
+	m, delayCmd = m.updateStreamDelay(msg)
+
+	// And so are the events of the session published, for the history, the event log and the rest to react to
+	eventsCmd := m.publishEvents(msg, title)
+
+	// Undoable actions are remembered whatever view they happened in, and toasted
+	var undoCmd tea.Cmd
+	m, msg, undoCmd = m.updateUndo(msg)
+
+	// Toasts are shown over any view, and so are errors, in a banner of the view they happened in
+	var toastCmd tea.Cmd
+	m.toastModel, toastCmd = m.toastModel.Update(msg)
+	m.errorBannerModel = m.errorBannerModel.Update(msg, m.state)
+	// So are newer releases, in a banner of their own
+	m.updateBannerModel = m.updateBannerModel.Update(msg)
+	if msg, ok := msg.(updateCheckedMsg); ok && msg.err != nil {
+		_ = m.eventLog.Printf("update check failed: %v", msg.err)
+	}
+
+	var newModel tea.Model
+	var cmd tea.Cmd
+	switch msg.(type) {
+	case nowPlayingTickMsg, nowPlayingProbedMsg, trackEnrichedMsg, programGuideTickMsg, programGuideFetchedMsg,
+		toastMsg, toastExpiredMsg, errorBannerMsg, listeningTickMsg, statusBarTickMsg,
+		networkTickMsg, networkCheckedMsg, updateCheckedMsg, streamDelayTickMsg, streamDelayEstimatedMsg, renderFlushMsg:
+		newModel = m
+	default:
+		newModel, cmd = m.update(msg)
+	}
+
+	if model, ok := newModel.(Model); ok && model.panesHeight() != panesHeight {
+		newModel, _ = model.update(tea.WindowSizeMsg{Width: model.width, Height: model.height})
+	}
+
+	if nowPlayingCmd == nil && trackDetailsCmd == nil && programGuideCmd == nil && toastCmd == nil && undoCmd == nil && clockCmd == nil && eventsCmd == nil &&
+		statusBarCmd == nil && statusBarPlayingCmd == nil && networkCmd == nil && delayCmd == nil && renderCmd == nil {
+		return newModel, cmd
+	}
+	return newModel, tea.Batch(nowPlayingCmd, trackDetailsCmd, programGuideCmd, toastCmd, undoCmd, clockCmd, eventsCmd, statusBarCmd, statusBarPlayingCmd,
+		networkCmd, delayCmd, renderCmd, cmd)
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	// The help overlay takes the keys until it's closed
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.helpShown() {
+		var cmd tea.Cmd
+		m.helpModel, cmd = m.helpModel.Update(keyMsg)
+		return m, cmd
+	}
+
+	// So does the Open URL dialog
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.openURLShown() {
+		var cmd tea.Cmd
+		m.openURLModel, cmd = m.openURLModel.Update(keyMsg)
+		return m, cmd
+	}
+
+	// And so does the API inspector, opened over any view in developer mode
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.inspectorShown() {
+		var cmd tea.Cmd
+		m.inspectorModel, cmd = m.inspectorModel.Update(keyMsg)
+		return m, cmd
+	}
+	// And so do the liked tracks
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.likedTracksShown() {
+		var cmd tea.Cmd
+		m.likedTracksModel, cmd = m.likedTracksModel.Update(keyMsg)
+		return m, cmd
+	}
+	// And the hidden stations
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.blocklistShown() {
+		var cmd tea.Cmd
+		m.blocklistModel, cmd = m.blocklistModel.Update(keyMsg)
+		return m, cmd
+	}
+	// And the theme editor
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.themeEditorShown() {
+		var cmd tea.Cmd
+		m.themeEditorModel, cmd = m.themeEditorModel.Update(keyMsg)
+		return m, cmd
+	}
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "f12" && m.inspector != nil {
+		m.inspectorModel = NewInspectorModel(m.theme, m.inspector)
+		m.inspectorModel.SetWidthAndHeight(m.width, m.childHeight())
+		m.showInspector = true
+		m.inspectorState = m.state
+		return m, nil
+	}
+
+	// The error banner is dismissed before the view handles esc
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "esc" && m.errorBannerModel.Shown(m.state) {
+		m.errorBannerModel = m.errorBannerModel.Dismiss()
+		return m, nil
+	}
+
+	// And so is the update banner, once the error banner is gone
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "esc" && m.updateBannerModel.Shown() {
+		m.updateBannerModel = m.updateBannerModel.Dismiss()
+		return m, nil
+	}
+
 	// Top-level messages
 	switch msg := msg.(type) {
+	case queuedStationRestoredMsg:
+		m.queue.insert(msg.index, msg.station)
+		return m, nil
 	case stationCursorMovedMsg:
 		m.headerModel.totalStations = msg.totalStations
+		m.headerModel.moreStations = msg.moreStations
 		m.headerModel.stationOffset = msg.offset
 		return m, nil
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 		m.headerModel.width = msg.Width
-		childHeight := m.height - 2 // 2 = header height + bottom bar height
+		childHeight := m.childHeight()
+		m.helpModel.SetWidthAndHeight(m.width, childHeight)
+		m.openURLModel.SetWidth(m.width)
+		m.inspectorModel.SetWidthAndHeight(m.width, childHeight)
+		m.likedTracksModel.SetWidthAndHeight(m.width, childHeight)
+		m.blocklistModel.SetWidthAndHeight(m.width, childHeight)
+		m.themeEditorModel.SetWidthAndHeight(m.width, childHeight)
 		switch m.state {
 		case searchState:
 			m.searchModel.SetWidthAndHeight(m.width, childHeight)
@@ -165,44 +812,354 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.stationsModel.SetWidthAndHeight(m.width, childHeight)
 		case errorState:
 			m.errorModel.SetWidthAndHeight(m.width, childHeight)
+		case tagCloudState:
+			m.tagCloudModel.SetWidthAndHeight(m.width, childHeight)
+		case chartsState:
+			m.chartsModel.SetWidthAndHeight(m.width, childHeight)
+		case bookmarksState:
+			m.bookmarksModel.SetWidthAndHeight(m.width, childHeight)
+		case outputState:
+			m.outputModel.SetWidthAndHeight(m.width, childHeight)
+		case profilesState:
+			m.profilesModel.SetWidthAndHeight(m.width, childHeight)
+		case diagnosticsState:
+			m.diagnosticsModel.SetWidthAndHeight(m.width, childHeight)
 		}
 		return m, nil
 	case quitMsg:
-		return m, tea.Quit
+		if confirm := m.confirmQuit(msg); confirm != nil {
+			return m, confirm
+		}
+		var cmds []tea.Cmd
+		if msg.stopPlayback {
+			cmds = append(cmds, stopStationCmd(m.playbackManager))
+		}
+		if m.recordingsScheduled {
+			cmds = append(cmds, stopRecordingsCmd(m.recordings))
+		}
+		if m.bandwidth != nil {
+			cmds = append(cmds, saveBandwidthUsageCmd(m.bandwidth))
+		}
+		if saveListened := m.saveListenedCmd(); saveListened != nil {
+			cmds = append(cmds, saveListened)
+		}
+		if len(cmds) == 0 {
+			return m, tea.Quit
+		}
+		return m, tea.Sequence(append(cmds, tea.Quit)...)
+	case quitConfirmationExpiredMsg:
+		if msg.id == m.quitConfirmation.id {
+			m.quitConfirmation.armed = false
+		}
+		return m, nil
+	case recordingTickMsg:
+		return m, tea.Batch(runRecordingsCmd(m.recordings), recordingTickCmd())
+	case recordingsUpdatedMsg:
+		m.headerModel.recordings = msg.active
+		var cmds []tea.Cmd
+		if msg.stopped != "" {
+			cmds = append(cmds, showToastCmd(msg.stopped, toastInfo))
+		}
+		if msg.err != nil {
+			err := msg.err
+			cmds = append(cmds, func() tea.Msg {
+				return nonFatalError{stopPlayback: false, err: err}
+			})
+		}
+		return m, tea.Batch(cmds...)
+	case recordingScheduledMsg:
+		cmds := []tea.Cmd{scheduleRecordingCmd(m.recordings, m.saveRecordingSchedule, msg.entry)}
+		if !m.recordingsScheduled {
+			m.recordingsScheduled = true
+			cmds = append(cmds, recordingTickCmd())
+		}
+		return m, tea.Batch(cmds...)
+	case bandwidthTickMsg:
+		return m, tea.Batch(saveBandwidthUsageCmd(m.bandwidth), bandwidthTickCmd())
+	case backendExitedMsg:
+		return m.backendExited(msg.exit)
+	case flashEndedMsg:
+		if msg.generation == m.flashGeneration {
+			m.flashing = false
+		}
+		return m, nil
 	case bottomBarUpdateMsg:
 		m.bottomBarCommands = msg.commands
 		return m, nil
+	case helpRequestedMsg:
+		return m.openHelp()
+	case closeHelpMsg:
+		m.showHelp = false
+		return m, nil
+	case openURLRequestedMsg:
+		m.openURLModel = NewOpenURLModel(m.theme)
+		m.openURLModel.SetWidth(m.width)
+		m.showOpenURL = true
+		m.openURLState = m.state
+		return m, nil
+	case closeOpenURLMsg:
+		m.showOpenURL = false
+		return m, nil
+	case closeInspectorMsg:
+		m.showInspector = false
+		return m, nil
+	case likeTrackRequestedMsg:
+		return m, m.likeTrack()
+	case likedTracksRequestedMsg:
+		if m.likedTracks == nil {
+			return m, nil
+		}
+		m.likedTracksModel = NewLikedTracksModel(m.theme, m.likedTracks, config.DataDir())
+		m.likedTracksModel.SetWidthAndHeight(m.width, m.childHeight())
+		m.showLikedTracks = true
+		m.likedTracksState = m.state
+		return m, m.likedTracksModel.Init()
+	case likedTracksLoadedMsg, likedTrackRestoredMsg:
+		var cmd tea.Cmd
+		m.likedTracksModel, cmd = m.likedTracksModel.Update(msg)
+		return m, cmd
+	case closeLikedTracksMsg:
+		m.showLikedTracks = false
+		return m, nil
+	case blocklistRequestedMsg:
+		if m.blocklist == nil {
+			return m, nil
+		}
+		m.blocklistModel = NewBlocklistModel(m.theme, m.blocklist)
+		m.blocklistModel.SetWidthAndHeight(m.width, m.childHeight())
+		m.showBlocklist = true
+		m.blocklistState = m.state
+		return m, m.blocklistModel.Init()
+	case blocklistLoadedMsg, stationReblockedMsg:
+		var cmd tea.Cmd
+		m.blocklistModel, cmd = m.blocklistModel.Update(msg)
+		return m, cmd
+	case closeBlocklistMsg:
+		m.showBlocklist = false
+		return m, nil
+	case themeEditorRequestedMsg:
+		// The accessible mode has no colors to edit
+		if m.theme.Accessible {
+			return m, nil
+		}
+		m.themeEditorModel = NewThemeEditorModel(m.theme, m.themeColors)
+		m.themeEditorModel.SetWidthAndHeight(m.width, m.childHeight())
+		m.showThemeEditor = true
+		m.themeEditorState = m.state
+		return m, m.themeEditorModel.Init()
+	case closeThemeEditorMsg:
+		m.showThemeEditor = false
+		return m, nil
+	case themeColorsEditedMsg:
+		m.showThemeEditor = false
+		return m.applyTheme(msg.colors, config.ThemeStyles{}), saveThemeColorsCmd(m.saveThemeColors, msg.colors)
+	case themeColorsSavedMsg:
+		return m, showToastCmd(i18n.Tf("themeEditor.saved", msg.path), toastSuccess)
+	case likedTrackStationSelectedMsg:
+		m.showLikedTracks = false
+		return m, tea.Sequence(stopStationCmd(m.playbackManager), playURLCmd(msg.station))
+	case stationEditSuggestedMsg:
+		return m, m.suggestedEdit(msg)
+	case actionQueuedMsg, actionRetryTickMsg, actionsRetriedMsg:
+		return m.retryActions(msg)
+	case urlSubmittedMsg:
+		m.showOpenURL = false
+		return m, tea.Sequence(stopStationCmd(m.playbackManager), playURLCmd(msg.station))
+	case recentlyPlayedSelectedMsg:
+		return m, tea.Sequence(stopStationCmd(m.playbackManager), playURLCmd(msg.station))
+	case historyForgetRequestedMsg:
+		return m, tea.Sequence(forgetStationCmd(m.history, msg.station, stationDisplayName(m.labelStore, msg.station)), loadRecentlyPlayedCmd(m.history))
+	case historyClearRequestedMsg:
+		return m, clearHistoryCmd(m.history)
+	case historyRestoredMsg:
+		return m, loadRecentlyPlayedCmd(m.history)
+	case stationOfTheDayBookmarkedMsg:
+		name := stationDisplayName(m.labelStore, msg.station)
+		if m.bookmarkStore.IsBookmarked(msg.station.StationUuid) {
+			return m, showToastCmd(i18n.Tf("search.stationOfTheDayBookmarked", name), toastInfo)
+		}
+		return m, toggleBookmarkCmd(m.bookmarkStore, msg.station, name)
+	case stationOfTheDayDismissedMsg:
+		return m, dismissStationOfTheDayCmd(m.cache, msg.day)
+	case searchFailedMsg:
+		return m, tea.Sequence(func() tea.Msg { return switchToSearchModelMsg{} }, nonFatalErrorCmd(msg.err))
+	case startupViewFailedMsg:
+		return m, tea.Batch(func() tea.Msg { return switchToSearchModelMsg{} }, showToastCmd(msg.err.Error(), toastInfo))
+	case remoteCommandMsg:
+		return m.handleRemoteCommand(msg.command)
+	case hotkeyMsg:
+		return m.handleHotkey(msg.action)
+	case duckMsg:
+		m.ducked = msg.ducked
+		return m, duckCmd(m.playbackManager, m.duckLevel, m.ducked)
+	case outputSelectedMsg:
+		return m.selectOutput(msg.device)
+	case profileSelectedMsg:
+		return m.selectProfile(msg.name)
+	case themeChangedMsg:
+		return m.changeTheme(msg.name)
+	case splitPaneToggledMsg:
+		m.splitPane = msg.enabled
+		return m, nil
+	case bookmarkOrderChangedMsg:
+		m.bookmarkOrder = msg.order
+		return m, nil
+	case stationColumnsChangedMsg:
+		m.stationColumns = msg.columns
+		m.stationsModel.SetColumns(msg.columns)
+		return m, saveStationColumnsCmd(m.saveStationColumns, msg.columns)
 	}
 
 	// State transitions
 
-	childHeight := m.height - 2 // 2 = header height + bottom bar height
+	childHeight := m.childHeight()
 
 	switch msg := msg.(type) {
 	case switchToSearchModelMsg:
+		if m.pendingStationUuid != "" {
+			stationUuid := m.pendingStationUuid
+			m.pendingStationUuid = ""
+			return m, openStationByUuidCmd(stationUuid, m.pendingAutoplay)
+		}
+		if m.pendingURL != "" {
+			rawUrl := m.pendingURL
+			m.pendingURL = ""
+			return m.handleRemoteCommand(instance.Command{Action: instance.ActionPlayURL, URL: rawUrl})
+		}
+		if m.state == bootState {
+			if model, cmd, ok := m.openStartupView(); ok {
+				return model, cmd
+			}
+		}
 		m.headerModel.showOffset = false
-		m.searchModel = NewSearchModel(m.theme)
+		// A new search always fetches fresh results
+		m.pages.invalidate()
+		m.searchModel = NewSearchModel(m.theme, m.searchFilter)
+		m.searchModel.SetBrowser(m.browser)
+		m.searchModel.SetCatalog(m.catalog)
 		m.searchModel.SetWidthAndHeight(m.width, childHeight)
 		m.state = searchState
-		return m, m.searchModel.Init()
+		return m, tea.Batch(m.searchModel.Init(), loadRecentlyPlayedCmd(m.history), m.stationOfTheDayCmd())
 	case switchToLoadingModelMsg:
 		m.headerModel.showOffset = false
-		m.loadingModel = NewLoadingModel(m.theme, m.browser, msg.query, msg.queryText)
+		m.loadingModel = NewLoadingModel(m.theme, m.browser, m.rateLimiter, m.pages, msg.query, msg.queryText, msg.filter, msg.autoplay)
 		m.loadingModel.SetWidthAndHeight(m.width, childHeight)
 		m.state = loadingState
 		return m, m.loadingModel.Init()
+	case discoveryRequestedMsg:
+		m.headerModel.showOffset = false
+		m.loadingModel = NewLoadingModel(m.theme, m.browser, m.rateLimiter, m.pages, stationQueryDiscover, "", common.StationFilter{}, false)
+		m.loadingModel.SetFetch(m.discoverStationsCmd())
+		m.loadingModel.SetWidthAndHeight(m.width, m.childHeight())
+		m.state = loadingState
+		return m, m.loadingModel.Init()
 	case switchToStationsModelMsg:
 		m.headerModel.showOffset = true
-		m.stationsModel = NewStationsModel(m.theme, m.browser, m.playbackManager, msg.stations)
+		stations := withoutBlockedStations(m.blocklist, withoutReportedStations(m.reportStore, m.contentFilter.Apply(msg.stations)))
+		m.stationsModel = NewStationsModel(m.theme, m.browser, m.playbackManager, m.labelStore, m.bookmarkStore, m.reportStore, m.contentFilter, stations, m.stationColumns, m.pages, msg.page, len(msg.stations) == stationPageSize)
+		m.stationsModel.SetSplitPane(m.splitPane)
+		m.stationsModel.SetBandwidthUsage(m.bandwidth)
+		m.stationsModel.SetLevels(m.levels)
+		m.stationsModel.SetListeningClock(m.clock)
+		m.stationsModel.SetStreamDelay(m.delay)
+		m.stationsModel.SetNetworkWatch(m.network)
+		m.stationsModel.SetProber(m.prober)
+		m.stationsModel.SetAssetCache(m.assets)
+		m.stationsModel.SetInteractionStore(m.interactions)
+		m.stationsModel.SetActionQueue(m.actions)
+		m.stationsModel.SetVolumeTrimStore(m.volumeTrims)
+		m.stationsModel.SetCredentialStore(m.credentials)
+		m.stationsModel.SetStreamVariantStore(m.streamVariants)
+		m.stationsModel.SetStreamHeaderStore(m.streamHeaders)
+		m.stationsModel.SetStationPrefsStore(m.stationPrefs)
+		m.stationsModel.SetHistoryStore(m.history)
+		m.stationsModel.SetBlocklistStore(m.blocklist)
+		m.stationsModel.SetExternalPlayer(m.externalPlayer)
+		m.stationsModel.SetExportDir(config.DataDir())
+		m.stationsModel.SetQueue(m.queue, m.queueDwell)
+		m.stationsModel.SetScanDwell(m.scanDwell)
+		m.stationsModel.SetRefreshInterval(m.refreshInterval)
+		m.stationsModel.SetHighlightDuration(m.highlightDuration)
 		m.stationsModel.SetWidthAndHeight(m.width, childHeight)
+		m.stationsModel.SelectStation(msg.selected)
 		m.state = stationsState
+		if msg.autoplay && len(stations) > 0 {
+			return m, tea.Batch(m.stationsModel.Init(), func() tea.Msg {
+				return playSelectedStationMsg{}
+			})
+		}
+		if msg.autoplay && len(msg.stations) > 0 {
+			return m, tea.Batch(m.stationsModel.Init(), func() tea.Msg {
+				return nonFatalError{stopPlayback: false, err: filter.ErrBlocked}
+			})
+		}
 		return m, m.stationsModel.Init()
 	case switchToErrorModelMsg:
 		m.headerModel.showOffset = false
-		m.errorModel = NewErrorModel(m.theme, msg.err)
+		m.errorModel = NewErrorModel(m.theme, msg.err, msg.remedies)
 		m.errorModel.SetWidthAndHeight(m.width, childHeight)
 		m.state = errorState
 		return m, m.errorModel.Init()
+	case switchToTagCloudModelMsg:
+		m.headerModel.showOffset = false
+		m.tagCloudModel = NewTagCloudModel(m.theme, m.browser)
+		m.tagCloudModel.SetCatalog(m.catalog)
+		m.tagCloudModel.SetWidthAndHeight(m.width, childHeight)
+		m.state = tagCloudState
+		return m, m.tagCloudModel.Init()
+	case switchToChartsModelMsg:
+		m.headerModel.showOffset = false
+		m.chartsModel = NewChartsModel(m.theme, m.browser, m.searchFilter.CountryCode)
+		m.chartsModel.SetCatalog(m.catalog)
+		m.chartsModel.SetBlocklistStore(m.blocklist)
+		m.chartsModel.SetWidthAndHeight(m.width, childHeight)
+		m.state = chartsState
+		return m, m.chartsModel.Init()
+	case switchToBookmarksModelMsg:
+		m.headerModel.showOffset = false
+		m.bookmarksModel = NewBookmarksModel(m.theme, m.browser, m.playbackManager, m.labelStore, m.bookmarkStore, m.contentFilter, m.prober)
+		m.bookmarksModel.SetBandwidthUsage(m.bandwidth)
+		m.bookmarksModel.SetLevels(m.levels)
+		m.bookmarksModel.SetListeningClock(m.clock)
+		m.bookmarksModel.SetStreamDelay(m.delay)
+		m.bookmarksModel.SetInteractionStore(m.interactions)
+		m.bookmarksModel.SetActionQueue(m.actions)
+		m.bookmarksModel.SetVolumeTrimStore(m.volumeTrims)
+		m.bookmarksModel.SetCredentialStore(m.credentials)
+		m.bookmarksModel.SetStreamVariantStore(m.streamVariants)
+		m.bookmarksModel.SetStreamHeaderStore(m.streamHeaders)
+		m.bookmarksModel.SetStationPrefsStore(m.stationPrefs)
+		m.bookmarksModel.SetExternalPlayer(m.externalPlayer)
+		m.bookmarksModel.SetPlayStatsStore(m.playStats)
+		m.bookmarksModel.SetOrder(m.bookmarkOrder)
+		m.bookmarksModel.SetWidthAndHeight(m.width, childHeight)
+		m.state = bookmarksState
+		return m, m.bookmarksModel.Init()
+	case switchToOutputModelMsg:
+		m.headerModel.showOffset = false
+		m.outputModel = NewOutputModel(m.theme, m.localPlaybackManager.Name(), m.discoverDevices)
+		m.outputModel.SetWidthAndHeight(m.width, childHeight)
+		m.state = outputState
+		return m, m.outputModel.Init()
+	case switchToProfilesModelMsg:
+		if m.listProfiles == nil {
+			return m, nil
+		}
+		m.headerModel.showOffset = false
+		profiles, err := m.listProfiles()
+		if err != nil {
+			profiles = []string{m.profile}
+		}
+		m.profilesModel = NewProfilesModel(m.theme, profiles, m.profile, err)
+		m.profilesModel.SetWidthAndHeight(m.width, childHeight)
+		m.state = profilesState
+		return m, m.profilesModel.Init()
+	case switchToDiagnosticsModelMsg:
+		m.headerModel.showOffset = false
+		m.diagnosticsModel = NewDiagnosticsModel(m.theme, m.mirrorStats)
+		m.diagnosticsModel.SetWidthAndHeight(m.width, childHeight)
+		m.state = diagnosticsState
+		return m, m.diagnosticsModel.Init()
 	}
 
 	// State handling
@@ -224,22 +1181,201 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		newErrorModel, cmd := m.errorModel.Update(msg)
 		m.errorModel = newErrorModel.(ErrorModel)
 		return m, cmd
+	case tagCloudState:
+		newTagCloudModel, cmd := m.tagCloudModel.Update(msg)
+		m.tagCloudModel = newTagCloudModel.(TagCloudModel)
+		return m, cmd
+	case chartsState:
+		newChartsModel, cmd := m.chartsModel.Update(msg)
+		m.chartsModel = newChartsModel.(ChartsModel)
+		return m, cmd
+	case bookmarksState:
+		newBookmarksModel, cmd := m.bookmarksModel.Update(msg)
+		m.bookmarksModel = newBookmarksModel.(BookmarksModel)
+		return m, cmd
+	case outputState:
+		newOutputModel, cmd := m.outputModel.Update(msg)
+		m.outputModel = newOutputModel.(OutputModel)
+		return m, cmd
+	case profilesState:
+		newProfilesModel, cmd := m.profilesModel.Update(msg)
+		m.profilesModel = newProfilesModel.(ProfilesModel)
+		return m, cmd
+	case diagnosticsState:
+		newDiagnosticsModel, cmd := m.diagnosticsModel.Update(msg)
+		m.diagnosticsModel = newDiagnosticsModel.(DiagnosticsModel)
+		return m, cmd
 	}
 
 	return m, nil
 }
 
+// handleRemoteCommand executes a command forwarded by another launch of RadioGoGo.
+func (m Model) handleRemoteCommand(command instance.Command) (tea.Model, tea.Cmd) {
+	switch command.Action {
+	case instance.ActionPlay, instance.ActionShow:
+		autoplay := command.Action == instance.ActionPlay
+		if m.state == bootState {
+			m.pendingStationUuid = command.StationUuid
+			m.pendingAutoplay = autoplay
+			return m, nil
+		}
+		return m, tea.Sequence(
+			stopStationCmd(m.playbackManager),
+			openStationByUuidCmd(command.StationUuid, autoplay),
+		)
+	case instance.ActionPlayURL:
+		if m.state == bootState {
+			m.pendingURL = command.URL
+			return m, nil
+		}
+		streamUrl, err := common.ParseStreamURL(command.URL)
+		if err != nil {
+			return m, nonFatalErrorCmd(err)
+		}
+		return m, tea.Sequence(
+			stopStationCmd(m.playbackManager),
+			playURLCmd(common.NewStationFromURL(streamUrl, "")),
+		)
+	case instance.ActionFocus:
+		return m, ringBellCmd
+	}
+	return m, nil
+}
+
+// selectOutput stops whatever is playing and routes playback to device,
+// or back to the local engine if device is nil.
+func (m Model) selectOutput(device cast.Device) (tea.Model, tea.Cmd) {
+	stopCmd := stopStationCmd(m.playbackManager)
+	if device == nil {
+		m.playbackManager = m.localPlaybackManager
+	} else {
+		m.playbackManager = cast.NewPlaybackManager(device)
+	}
+	m.headerModel.engineName = m.playbackManager.Name()
+	cmds := []tea.Cmd{stopCmd}
+	if m.ducked {
+		// So that the stations played on the output are turned down too
+		cmds = append(cmds, duckCmd(m.playbackManager, m.duckLevel, true))
+	}
+	cmds = append(cmds, func() tea.Msg {
+		return switchToSearchModelMsg{}
+	})
+	return m, tea.Sequence(cmds...)
+}
+
+// selectProfile quits, so that RadioGoGo restarts with the profile with the given name,
+// or goes back to the search if it's the profile in use.
+func (m Model) selectProfile(name string) (tea.Model, tea.Cmd) {
+	if name == m.profile {
+		return m, func() tea.Msg {
+			return switchToSearchModelMsg{}
+		}
+	}
+	m.nextProfile = name
+	return m, tea.Sequence(stopStationCmd(m.playbackManager), func() tea.Msg {
+		return quitMsg{confirmed: true}
+	})
+}
+
+// PublishNowPlayingTo also publishes the station and track being played to publisher,
+// such as the single-instance server answering "radiogogo status".
+func (m *Model) PublishNowPlayingTo(publisher nowplaying.Publisher) {
+	m.nowPlayingModel.publishers = append(m.nowPlayingModel.publishers, publisher)
+}
+
+// NextProfile returns the profile picked in the profile switcher, which RadioGoGo should restart with
+// after quitting, or an empty string if none was.
+func (m Model) NextProfile() string {
+	return m.nextProfile
+}
+
+// changeTheme switches to the built-in theme or the theme file with the given name, which lasts until RadioGoGo quits.
+// The accessible theme is never replaced.
+func (m Model) changeTheme(name string) (tea.Model, tea.Cmd) {
+	colors, ok := config.ThemePresets[name]
+	var styles config.ThemeStyles
+	if !ok {
+		file, err := config.LoadThemeFile(name)
+		if errors.Is(err, config.ErrThemeNotFound) {
+			return m, nonFatalErrorCmd(errors.New(i18n.Tf("command.unknownTheme", name, strings.Join(themeNames(), ", "))))
+		}
+		if err != nil {
+			return m, nonFatalErrorCmd(err)
+		}
+		colors, styles = file.Colors.Over(config.DefaultTheme), file.Styles
+	}
+	return m.applyTheme(colors, styles), nil
+}
+
+// applyTheme draws the interface with colors and styles, unless it's in accessible mode.
+func (m Model) applyTheme(colors config.ThemeColors, styles config.ThemeStyles) Model {
+	if m.theme.Accessible {
+		return m
+	}
+	m.themeColors = colors
+	// The color-blind palette, if any, stays in place of the theme's colors
+	colors.ColorBlindMode = m.colorBlindMode
+	m.theme = newStyledTheme(colors.Effective(), styles)
+	m.render.restyle()
+	m.headerModel.theme = m.theme
+	m.trackDetailsModel.theme = m.theme
+	m.toastModel.theme = m.theme
+	m.errorBannerModel.theme = m.theme
+	m.updateBannerModel.theme = m.theme
+	m.statusBarModel.theme = m.theme
+	m.stationsModel.SetTheme(m.theme)
+	m.bookmarksModel.SetTheme(m.theme)
+	return m
+}
+
+func saveStationColumnsCmd(save func(columns []config.StationColumn) error, columns []config.StationColumn) tea.Cmd {
+	return func() tea.Msg {
+		err := save(columns)
+		if err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return nil
+	}
+}
+
+// openStationByUuidCmd lists the station with the given UUID, playing it if autoplay is true.
+func openStationByUuidCmd(stationUuid string, autoplay bool) tea.Cmd {
+	return func() tea.Msg {
+		return switchToLoadingModelMsg{
+			query:     common.StationQueryByUuid,
+			queryText: stationUuid,
+			autoplay:  autoplay,
+		}
+	}
+}
+
 func (m Model) View() string {
+	return m.render.view(m.draw)
+}
+
+// draw draws the view, rendering again only the sections that changed since the last time.
+func (m Model) draw() string {
+
+	if m.isTooSmall() {
+		return m.tooSmallView()
+	}
 
 	var view string
 
 	view = m.headerModel.View()
+	if !m.theme.Accessible && m.width > 0 {
+		header := view
+		view = m.render.section("header", renderKey{source: header, width: m.width}, func() string {
+			return lipgloss.NewStyle().MaxWidth(m.width).Render(strings.TrimSuffix(header, "\n")) + "\n"
+		})
+	}
 
 	var currentView string
 
 	switch m.state {
 	case bootState:
-		currentView = "\nInitializing..."
+		currentView = "\n" + i18n.T("app.initializing")
 	case searchState:
 		currentView = m.searchModel.View()
 	case loadingState:
@@ -248,9 +1384,60 @@ func (m Model) View() string {
 		currentView = m.stationsModel.View()
 	case errorState:
 		currentView = m.errorModel.View()
+	case tagCloudState:
+		currentView = m.tagCloudModel.View()
+	case chartsState:
+		currentView = m.chartsModel.View()
+	case bookmarksState:
+		currentView = m.bookmarksModel.View()
+	case outputState:
+		currentView = m.outputModel.View()
+	case profilesState:
+		currentView = m.profilesModel.View()
+	case diagnosticsState:
+		currentView = m.diagnosticsModel.View()
+	}
+
+	bottomBarCommands := m.bottomBarCommands
+	if m.helpShown() {
+		currentView = m.helpModel.View()
+		bottomBarCommands = m.helpModel.commands()
+	} else if m.openURLShown() {
+		currentView = m.openURLModel.View()
+		bottomBarCommands = m.openURLModel.commands()
+	} else if m.inspectorShown() {
+		currentView = m.inspectorModel.View()
+		bottomBarCommands = m.inspectorModel.commands()
+	} else if m.likedTracksShown() {
+		currentView = m.likedTracksModel.View()
+		bottomBarCommands = m.likedTracksModel.commands()
+	} else if m.blocklistShown() {
+		currentView = m.blocklistModel.View()
+		bottomBarCommands = m.blocklistModel.commands()
+	} else if m.themeEditorShown() {
+		currentView = m.themeEditorModel.View()
+		bottomBarCommands = m.themeEditorModel.commands()
+	}
+
+	// Clip the current view so that it never pushes the bottom bar off screen
+
+	if !m.theme.Accessible && m.width > 0 {
+		unclipped := currentView
+		currentView = m.render.section("view", renderKey{source: unclipped, width: m.width, height: m.childHeight()}, func() string {
+			return lipgloss.NewStyle().
+				MaxWidth(m.width).
+				MaxHeight(m.childHeight()).
+				Render(unclipped)
+		})
 	}
 
-	currentViewHeight := lipgloss.Height(currentView)
+	panes := m.errorBannerModel.View(m.state) + m.updateBannerModel.View() + m.trackDetailsModel.View() + m.programGuideModel.View() + m.toastModel.View() +
+		m.statusBarModel.View()
+
+	fillerHeight := m.height - lipgloss.Height(currentView) - m.panesHeight()
+	if fillerHeight < 0 {
+		fillerHeight = 0
+	}
 
 	// Render the current view
 
@@ -259,12 +1446,138 @@ func (m Model) View() string {
 	// Push the bottom bar at the bottom of the terminal
 
 	view += lipgloss.NewStyle().
-		Height(m.height - currentViewHeight).
+		Height(fillerHeight).
 		Render()
 
+	// Render the track details, program guide, toast and status bar panes right above the bottom bar
+
+	if panes != "" {
+		if !m.theme.Accessible && m.width > 0 {
+			unclipped := panes
+			panes = m.render.section("panes", renderKey{source: unclipped, width: m.width}, func() string {
+				return lipgloss.NewStyle().MaxWidth(m.width).Render(strings.TrimSuffix(unclipped, "\n")) + "\n"
+			})
+		}
+		view += panes
+	}
+
 	// Render bottom bar
 
-	view += m.theme.StyleBottomBar(m.bottomBarCommands)
+	if m.theme.Accessible {
+		view += m.theme.StyleBottomBar(bottomBarCommands)
+	} else if m.flashing {
+		view += m.theme.StyleFlashedBottomBar(bottomBarCommands, m.width)
+	} else {
+		// Fitting the commands in the width renders them over and over, so it's only done when they change
+		view += m.render.section("bottomBar", renderKey{source: strings.Join(bottomBarCommands, "\x00"), width: m.width}, func() string {
+			return m.theme.StyleBottomBarWithin(bottomBarCommands, m.width)
+		})
+	}
 
 	return view
 }
+
+// childHeight returns the height left to the current view once the header and the bottom bar are drawn.
+func (m Model) childHeight() int {
+	height := m.height - 2 - m.panesHeight() // 2 = header height + bottom bar height
+	if height < 1 {
+		return 1
+	}
+	return height
+}
+
+// openHelp shows the key bindings of the current view, if it has any.
+func (m Model) openHelp() (tea.Model, tea.Cmd) {
+	sections, ok := keyBindings[m.state]
+	if !ok {
+		return m, nil
+	}
+	m.helpModel = NewHelpModel(m.theme, sections)
+	m.helpModel.SetWidthAndHeight(m.width, m.childHeight())
+	m.showHelp = true
+	m.helpState = m.state
+	return m, nil
+}
+
+// helpShown returns true if the help overlay is open on the current view.
+// It's left behind if the view changes meanwhile, as when playback is controlled remotely.
+func (m Model) helpShown() bool {
+	return m.showHelp && m.helpState == m.state
+}
+
+// openURLShown returns true if the Open URL dialog is open on the current view.
+// Like the help overlay, it's left behind if the view changes meanwhile.
+func (m Model) openURLShown() bool {
+	return m.showOpenURL && m.openURLState == m.state
+}
+
+// inspectorShown returns true if the API inspector is open on the current view.
+// Like the help overlay, it's left behind if the view changes meanwhile.
+func (m Model) inspectorShown() bool {
+	return m.showInspector && m.inspectorState == m.state
+}
+
+// likedTracksShown returns true if the liked tracks are open on the current view.
+// Like the help overlay, they're left behind if the view changes meanwhile.
+func (m Model) likedTracksShown() bool {
+	return m.showLikedTracks && m.likedTracksState == m.state
+}
+
+// blocklistShown returns true if the hidden stations are open on the current view.
+// Like the liked tracks, they're left behind if the view changes meanwhile.
+func (m Model) blocklistShown() bool {
+	return m.showBlocklist && m.blocklistState == m.state
+}
+
+// themeEditorShown returns true if the theme editor is open on the current view.
+// Like the liked tracks, it's left behind if the view changes meanwhile.
+func (m Model) themeEditorShown() bool {
+	return m.showThemeEditor && m.themeEditorState == m.state
+}
+
+// likeTrack likes the track being played, if its title is known.
+func (m Model) likeTrack() tea.Cmd {
+	if m.likedTracks == nil {
+		return nil
+	}
+	station := m.nowPlayingModel.station
+	if station == nil || m.nowPlayingModel.title == "" {
+		return showToastCmd(i18n.T("likedTracks.noTitle"), toastInfo)
+	}
+	return saveLikedTrackCmd(m.likedTracks, storage.LikedTrack{
+		Title:   m.nowPlayingModel.title,
+		Station: *station,
+		LikedAt: time.Now(),
+	})
+}
+
+// panesHeight returns the number of lines taken by the panes drawn above the bottom bar.
+func (m Model) panesHeight() int {
+	return m.errorBannerModel.Height(m.state) + m.updateBannerModel.Height() + m.trackDetailsModel.Height() + m.programGuideModel.Height() + m.toastModel.Height() +
+		m.statusBarModel.Height()
+}
+
+// statusBarStation returns the name of the station being played for the status bar, or an empty string if none is.
+func (m Model) statusBarStation() string {
+	if m.playingStation.StationUuid == uuid.Nil {
+		return ""
+	}
+	return displayText(stationDisplayName(m.labelStore, m.playingStation))
+}
+
+// isTooSmall returns true if the terminal is smaller than the minimum size the layout needs.
+// Accessible mode has no layout to garble, so it is never too small.
+func (m Model) isTooSmall() bool {
+	if m.theme.Accessible || m.width == 0 {
+		return false
+	}
+	return m.width < minWidth || m.height < minHeight
+}
+
+// tooSmallView renders a warning in place of the whole UI until the terminal is resized.
+func (m Model) tooSmallView() string {
+	warning := m.theme.RenderError(i18n.T("app.tooSmall")) + "\n" +
+		m.theme.TertiaryText.Render(i18n.Tf("app.tooSmallSize", m.width, m.height, minWidth, minHeight))
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center,
+		lipgloss.NewStyle().MaxWidth(m.width).Render(warning))
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/netwatch"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// networkWatch checks the network periodically. While it's down, results aren't refreshed and
+// queued clicks and votes aren't sent again, and once it's back, or it changed, the station that was
+// playing is reconnected. It's shared by the root and stations models, and only used by their Update.
+// A nil *networkWatch is always online.
+type networkWatch struct {
+	checker  netwatch.CheckerService
+	interval time.Duration
+
+	// The status of the last check, and whether one was made yet
+	status  netwatch.Status
+	checked bool
+	// The station playing when the network went down, reconnected once it's back
+	interrupted common.Station
+	// The station whose backend last exited on its own, and when, in case the network going down stopped it
+	exited   common.Station
+	exitedAt time.Time
+
+	now func() time.Time
+}
+
+// newNetworkWatch returns a watch checking the network every interval, or nil if interval is 0.
+func newNetworkWatch(checker netwatch.CheckerService, interval time.Duration) *networkWatch {
+	if interval <= 0 {
+		return nil
+	}
+	return &networkWatch{checker: checker, interval: interval, now: time.Now}
+}
+
+// offline returns true if the last check found the network down.
+func (w *networkWatch) offline() bool {
+	return w != nil && w.checked && !w.status.Online
+}
+
+// Messages
+
+// networkTickMsg checks the network.
+type networkTickMsg struct{}
+
+// networkCheckedMsg tells what the check of the network found.
+type networkCheckedMsg struct {
+	status netwatch.Status
+}
+
+// Commands
+
+func networkTickCmd(interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(t time.Time) tea.Msg {
+		return networkTickMsg{}
+	})
+}
+
+func checkNetworkCmd(checker netwatch.CheckerService) tea.Cmd {
+	return func() tea.Msg {
+		return networkCheckedMsg{status: checker.Check()}
+	}
+}
+
+// Model
+
+// watchNetwork follows the network whatever the view, telling when it goes down and comes back
+// in the status bar and the event log.
+func (m Model) watchNetwork(msg tea.Msg) (Model, tea.Cmd) {
+	if m.network == nil {
+		return m, nil
+	}
+	switch msg := msg.(type) {
+	case networkTickMsg:
+		return m, checkNetworkCmd(m.network.checker)
+	case networkCheckedMsg:
+		return m.networkChecked(msg.status)
+	case backendExitedMsg:
+		if msg.exit.Current() && m.playingStation.StationUuid != uuid.Nil {
+			m.network.exited = m.playingStation
+			m.network.exitedAt = m.network.now()
+			if m.network.offline() && m.network.interrupted.StationUuid == uuid.Nil {
+				m.network.interrupted = m.playingStation
+			}
+		}
+	case playbackStartedMsg:
+		// Another station played meanwhile isn't replaced by the one interrupted
+		m.network.interrupted = common.Station{}
+		m.statusBarModel = m.statusBarModel.SetNotice("")
+	case nonFatalError:
+		if !m.network.offline() {
+			m.statusBarModel = m.statusBarModel.SetNotice("")
+		}
+	}
+	return m, nil
+}
+
+// networkChecked acts on the network going down, coming back or changing, and checks it again later.
+func (m Model) networkChecked(status netwatch.Status) (Model, tea.Cmd) {
+	previous, checked := m.network.status, m.network.checked
+	m.network.status = status
+	m.network.checked = true
+	tick := networkTickCmd(m.network.interval)
+
+	switch {
+	case !status.Online && (!checked || previous.Online):
+		m.network.interrupted = m.playingStation
+		// Playback may have stopped as the network went down, before it was checked
+		if m.network.interrupted.StationUuid == uuid.Nil && m.network.now().Sub(m.network.exitedAt) <= 2*m.network.interval {
+			m.network.interrupted = m.network.exited
+		}
+		_ = m.eventLog.Printf("network down")
+		m.statusBarModel = m.statusBarModel.SetNotice(i18n.T("network.offline"))
+		// A station that stopped as the network went down was recorded as dropped already
+		if m.playingStation.StationUuid != uuid.Nil {
+			return m, tea.Batch(tick, m.interruptionCmd(m.playingStation, storage.InterruptionDropped, "network down"))
+		}
+		return m, tick
+	case status.Online && checked && !previous.Online:
+		_ = m.eventLog.Printf("network back up")
+		station := m.network.interrupted
+		m.network.interrupted = common.Station{}
+		return m.reconnectAfterNetwork(station, "network back up", tick)
+	case status.Online && checked && status.Addresses != previous.Addresses:
+		_ = m.eventLog.Printf("network changed (%s)", status.Addresses)
+		return m.reconnectAfterNetwork(m.playingStation, "network changed", tick)
+	}
+	return m, tick
+}
+
+// reconnectAfterNetwork plays station again, if any, as its stream didn't survive the network going down or changing,
+// as reason tells.
+func (m Model) reconnectAfterNetwork(station common.Station, reason string, tick tea.Cmd) (Model, tea.Cmd) {
+	if station.StationUuid == uuid.Nil {
+		m.statusBarModel = m.statusBarModel.SetNotice("")
+		return m, tick
+	}
+	name := stationDisplayName(m.labelStore, station)
+	_ = m.eventLog.Printf("%s (%s): reconnecting after the network came back or changed", name, station.Url.URL.String())
+	m.statusBarModel = m.statusBarModel.SetNotice(i18n.T("network.reconnecting"))
+	return m, tea.Batch(
+		tick,
+		tea.Sequence(stopStationCmd(m.playbackManager), reconnectStationCmd(station)),
+		m.interruptionCmd(station, storage.InterruptionReconnecting, reason),
+	)
+}
+
+// SetNetworkWatch holds off refreshing the results in the background while the network is down (nil never does).
+func (m *StationsModel) SetNetworkWatch(network *networkWatch) {
+	m.network = network
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/netwatch"
+	"github.com/zi0p4tch0/radiogogo/playback"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// reconnectedStation returns the station reconnected by the sequence among msgs, if any.
+func reconnectedStation(msgs []tea.Msg) (common.Station, bool) {
+	for _, msg := range msgs {
+		if reflect.TypeOf(msg).Kind() != reflect.Slice {
+			continue
+		}
+		for _, cmd := range sequenceCmds(msg) {
+			if reconnect, ok := cmd().(reconnectStationMsg); ok {
+				return reconnect.station, true
+			}
+		}
+	}
+	return common.Station{}, false
+}
+
+func TestNetworkWatch(t *testing.T) {
+
+	online := netwatch.Status{Online: true, Addresses: "192.168.1.20"}
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+
+	newModel := func() Model {
+		cfg := config.Config{}
+		cfg.StatusBar.Enabled = true
+		model := NewModel(cfg, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{
+			IsPlayingResult: true,
+			StopStationFunc: func() error { return nil },
+		}, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		model.network = newNetworkWatch(&mocks.MockNetworkChecker{}, time.Millisecond)
+		model, _ = model.networkChecked(online)
+		return model
+	}
+
+	t.Run("is disabled without an interval", func(t *testing.T) {
+
+		var network *networkWatch = newNetworkWatch(&mocks.MockNetworkChecker{}, 0)
+
+		assert.Nil(t, network)
+		assert.False(t, network.offline())
+
+	})
+
+	t.Run("reconnects the station playing once the network is back", func(t *testing.T) {
+
+		model := newModel()
+		model.playingStation = station
+
+		model, cmd := model.networkChecked(netwatch.Status{})
+		assert.True(t, model.network.offline())
+		assert.Equal(t, i18n.T("network.offline"), model.statusBarModel.notice)
+		_, reconnected := reconnectedStation(collectMsgs(cmd))
+		assert.False(t, reconnected)
+
+		model, cmd = model.networkChecked(online)
+		assert.False(t, model.network.offline())
+		assert.Equal(t, i18n.T("network.reconnecting"), model.statusBarModel.notice)
+		reconnectedTo, reconnected := reconnectedStation(collectMsgs(cmd))
+		assert.True(t, reconnected)
+		assert.Equal(t, station.StationUuid, reconnectedTo.StationUuid)
+
+		model, _ = model.watchNetwork(playbackStartedMsg{station: station})
+		assert.Equal(t, "", model.statusBarModel.notice)
+
+	})
+
+	t.Run("reconnects a station stopped right before the network was found down", func(t *testing.T) {
+
+		model := newModel()
+		now := time.Now()
+		model.network.now = func() time.Time { return now }
+		model.playingStation = station
+		model, _ = model.watchNetwork(backendExitedMsg{exit: playback.ProcessExit{Name: "ffplay", Code: 1}})
+		model.playingStation = common.Station{}
+
+		model, _ = model.networkChecked(netwatch.Status{})
+		_, cmd := model.networkChecked(online)
+
+		_, reconnected := reconnectedStation(collectMsgs(cmd))
+		assert.True(t, reconnected)
+
+	})
+
+	t.Run("reconnects the station playing when the network changed", func(t *testing.T) {
+
+		model := newModel()
+		model.playingStation = station
+
+		_, cmd := model.networkChecked(netwatch.Status{Online: true, Addresses: "10.0.0.2"})
+
+		reconnectedTo, reconnected := reconnectedStation(collectMsgs(cmd))
+		assert.True(t, reconnected)
+		assert.Equal(t, station.StationUuid, reconnectedTo.StationUuid)
+
+	})
+
+	t.Run("only checks again when nothing was playing", func(t *testing.T) {
+
+		model := newModel()
+
+		model, cmd := model.networkChecked(netwatch.Status{})
+		assert.Equal(t, []tea.Msg{networkTickMsg{}}, collectMsgs(cmd))
+
+		model, cmd = model.networkChecked(online)
+		assert.Equal(t, []tea.Msg{networkTickMsg{}}, collectMsgs(cmd))
+		assert.Equal(t, "", model.statusBarModel.notice)
+
+	})
+
+	t.Run("holds off sending queued clicks and votes while offline", func(t *testing.T) {
+
+		model := newModel()
+		model, _ = model.networkChecked(netwatch.Status{})
+
+		_, cmd := model.retryActions(actionRetryTickMsg{})
+
+		assert.Equal(t, []tea.Msg{actionRetryTickMsg{}}, collectMsgs(cmd))
+
+	})
+
+}
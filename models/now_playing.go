@@ -0,0 +1,163 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/icy"
+	"github.com/zi0p4tch0/radiogogo/nowplaying"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How often the playing stream is probed for a new track title.
+const nowPlayingInterval = 15 * time.Second
+
+// NowPlayingModel publishes the station and track being played to the now-playing file and pipe,
+// and to the terminal title and tmux.
+// It has no view: the root model feeds it every message.
+type NowPlayingModel struct {
+	publishers []nowplaying.Publisher
+	prober     icy.ProberService
+	labelStore storage.LabelStore
+	// probeTitles keeps probing the track titles without publishers, for the track details pane
+	probeTitles bool
+
+	station *common.Station
+	title   string
+	// Incremented whenever the station changes, so that stale probes are ignored
+	generation int
+}
+
+// NewNowPlayingModel returns a NowPlayingModel publishing to publishers, which does nothing if there are none
+// (unless it's asked to probe the track titles anyway).
+func NewNowPlayingModel(prober icy.ProberService, labelStore storage.LabelStore, publishers ...nowplaying.Publisher) NowPlayingModel {
+	return NowPlayingModel{
+		publishers: publishers,
+		prober:     prober,
+		labelStore: labelStore,
+	}
+}
+
+// Messages
+
+type nowPlayingTickMsg struct {
+	generation int
+}
+
+type nowPlayingProbedMsg struct {
+	generation int
+	title      string
+	err        error
+}
+
+// Commands
+
+func probeNowPlayingCmd(prober icy.ProberService, generation int, station common.Station) tea.Cmd {
+	return func() tea.Msg {
+		title, err := prober.StreamTitle(station.Url.URL)
+		return nowPlayingProbedMsg{generation: generation, title: title, err: err}
+	}
+}
+
+func writeNowPlayingCmd(publishers []nowplaying.Publisher, track nowplaying.Track) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, publisher := range publishers {
+		publisher := publisher
+		cmds = append(cmds, func() tea.Msg {
+			err := publisher.Write(track)
+			if err != nil {
+				return nonFatalError{stopPlayback: false, err: err}
+			}
+			return nil
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// Model
+
+func (m NowPlayingModel) Update(msg tea.Msg) (NowPlayingModel, tea.Cmd) {
+
+	if len(m.publishers) == 0 && !m.probeTitles {
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case playbackStartedMsg:
+		station := msg.station
+		m.station = &station
+		m.title = ""
+		m.generation++
+		return m, tea.Batch(
+			writeNowPlayingCmd(m.publishers, m.track()),
+			probeNowPlayingCmd(m.prober, m.generation, station),
+		)
+	case playbackStoppedMsg:
+		if m.station == nil {
+			return m, nil
+		}
+		m.station = nil
+		m.title = ""
+		m.generation++
+		return m, writeNowPlayingCmd(m.publishers, nowplaying.Track{})
+	case nowPlayingTickMsg:
+		if msg.generation != m.generation || m.station == nil {
+			return m, nil
+		}
+		return m, probeNowPlayingCmd(m.prober, m.generation, *m.station)
+	case nowPlayingProbedMsg:
+		if msg.generation != m.generation || m.station == nil {
+			return m, nil
+		}
+		var cmds []tea.Cmd
+		// Streams without metadata won't start sending it later
+		if !errors.Is(msg.err, icy.ErrNoMetadata) {
+			generation := m.generation
+			cmds = append(cmds, tea.Tick(nowPlayingInterval, func(t time.Time) tea.Msg {
+				return nowPlayingTickMsg{generation: generation}
+			}))
+		}
+		// Keep the last title if the stream couldn't be probed this time
+		if msg.err == nil && msg.title != m.title {
+			m.title = msg.title
+			cmds = append(cmds, writeNowPlayingCmd(m.publishers, m.track()))
+		}
+		return m, tea.Batch(cmds...)
+	}
+
+	return m, nil
+}
+
+// track returns what's playing, as published.
+func (m NowPlayingModel) track() nowplaying.Track {
+	if m.station == nil {
+		return nowplaying.Track{}
+	}
+	return nowplaying.Track{
+		Station:     stationDisplayName(m.labelStore, *m.station),
+		StationUuid: m.station.StationUuid.String(),
+		Title:       m.title,
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/icy"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/nowplaying"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+// runNowPlayingCmd runs cmd and the commands it batches, except ticks, returning the messages they produce.
+func runNowPlayingCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, cmd := range batch {
+			msgs = append(msgs, runNowPlayingCmd(cmd)...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func TestNowPlayingModel(t *testing.T) {
+
+	streamUrl, _ := url.Parse("http://example.com/stream")
+	station := common.Station{Name: "Jazz FM", Url: common.RadioGoGoURL{URL: *streamUrl}}
+
+	t.Run("does nothing without publishers", func(t *testing.T) {
+
+		model := NewNowPlayingModel(&mocks.MockProberService{}, &mocks.MockLabelStore{})
+
+		_, cmd := model.Update(playbackStartedMsg{station: station})
+
+		assert.Nil(t, cmd)
+
+	})
+
+	t.Run("probes the track titles without publishers if asked to", func(t *testing.T) {
+
+		model := NewNowPlayingModel(
+			&mocks.MockProberService{
+				StreamTitleFunc: func(streamUrl url.URL) (string, error) {
+					return "Artist - Title", nil
+				},
+			},
+			&mocks.MockLabelStore{},
+		)
+		model.probeTitles = true
+
+		model, cmd := model.Update(playbackStartedMsg{station: station})
+		msgs := runNowPlayingCmd(cmd)
+		assert.Len(t, msgs, 1)
+
+		model, _ = model.Update(msgs[0])
+		assert.Equal(t, "Artist - Title", model.title)
+
+	})
+
+	t.Run("writes the station, then its track title", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "nowplaying.txt")
+		model := NewNowPlayingModel(
+			&mocks.MockProberService{
+				StreamTitleFunc: func(streamUrl url.URL) (string, error) {
+					return "Artist - Title", nil
+				},
+			},
+			&mocks.MockLabelStore{},
+			nowplaying.NewWriter(path, "", ""),
+		)
+
+		model, cmd := model.Update(playbackStartedMsg{station: station})
+		var probed tea.Msg
+		for _, msg := range runNowPlayingCmd(cmd) {
+			if _, ok := msg.(nowPlayingProbedMsg); ok {
+				probed = msg
+			}
+		}
+		content, _ := os.ReadFile(path)
+		assert.Equal(t, "Jazz FM", string(content))
+
+		model, cmd = model.Update(probed)
+		// The tick is slow: only run the write
+		for _, cmd := range cmd().(tea.BatchMsg)[1:] {
+			runNowPlayingCmd(cmd)
+		}
+		content, _ = os.ReadFile(path)
+		assert.Equal(t, "Jazz FM - Artist - Title", string(content))
+
+		_, cmd = model.Update(playbackStoppedMsg{})
+		runNowPlayingCmd(cmd)
+		content, _ = os.ReadFile(path)
+		assert.Equal(t, "", string(content))
+
+	})
+
+	t.Run("ignores probes of a previous station", func(t *testing.T) {
+
+		model := NewNowPlayingModel(&mocks.MockProberService{}, &mocks.MockLabelStore{}, nowplaying.NewWriter(filepath.Join(t.TempDir(), "nowplaying.txt"), "", ""))
+
+		model, _ = model.Update(playbackStartedMsg{station: station})
+		stale := nowPlayingProbedMsg{generation: model.generation, title: "Old"}
+		model, _ = model.Update(playbackStartedMsg{station: station})
+
+		model, cmd := model.Update(stale)
+
+		assert.Nil(t, cmd)
+		assert.Equal(t, "", model.title)
+
+	})
+
+	t.Run("stops probing streams without metadata", func(t *testing.T) {
+
+		model := NewNowPlayingModel(&mocks.MockProberService{}, &mocks.MockLabelStore{}, nowplaying.NewWriter(filepath.Join(t.TempDir(), "nowplaying.txt"), "", ""))
+
+		model, _ = model.Update(playbackStartedMsg{station: station})
+		_, cmd := model.Update(nowPlayingProbedMsg{generation: model.generation, err: icy.ErrNoMetadata})
+
+		assert.Nil(t, cmd)
+
+	})
+
+	t.Run("publishes to every publisher", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "nowplaying.txt")
+		var title bytes.Buffer
+		model := NewNowPlayingModel(
+			&mocks.MockProberService{},
+			&mocks.MockLabelStore{},
+			nowplaying.NewWriter(path, "", ""),
+			nowplaying.NewTerminal(&title, true, false),
+		)
+
+		_, cmd := model.Update(playbackStartedMsg{station: station})
+		runNowPlayingCmd(cmd)
+
+		content, _ := os.ReadFile(path)
+		assert.Equal(t, "Jazz FM", string(content))
+		assert.Equal(t, "\x1b]2;▶ Jazz FM\x07", title.String())
+
+	})
+
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Messages
+
+// openURLRequestedMsg asks to open the Open URL dialog over the current view.
+type openURLRequestedMsg struct{}
+
+// urlSubmittedMsg closes the Open URL dialog with the station playing the URL entered.
+type urlSubmittedMsg struct {
+	station common.Station
+}
+
+type closeOpenURLMsg struct{}
+
+// Commands
+
+func openURLCmd() tea.Msg {
+	return openURLRequestedMsg{}
+}
+
+// playURLCmd lists station on its own and plays it.
+func playURLCmd(station common.Station) tea.Cmd {
+	return func() tea.Msg {
+		return switchToStationsModelMsg{
+			stations: []common.Station{station},
+			autoplay: true,
+			page:     stationPageKey{query: stationQueryURL, queryText: station.Url.URL.String()},
+		}
+	}
+}
+
+// Model
+
+// OpenURLModel asks for the URL of a stream to play, which needn't be listed on radio-browser.
+type OpenURLModel struct {
+	theme Theme
+	input textinput.Model
+	err   string
+}
+
+func NewOpenURLModel(theme Theme) OpenURLModel {
+	input := textinput.New()
+	input.Prompt = i18n.T("openUrl.prompt") + " "
+	input.PromptStyle = theme.SecondaryText
+	input.TextStyle = theme.Text
+	input.Placeholder = "https://example.com/live.mp3"
+	input.Focus()
+
+	return OpenURLModel{
+		theme: theme,
+		input: input,
+	}
+}
+
+// SetWidth fits the text field to the given width.
+func (m *OpenURLModel) SetWidth(width int) {
+	m.input.Width = width - len(m.input.Prompt) - 1
+}
+
+func (m OpenURLModel) commands() []string {
+	return []string{i18n.T("commands.cancel"), i18n.T("commands.play")}
+}
+
+// Bubbletea
+
+func (m OpenURLModel) Update(msg tea.Msg) (OpenURLModel, tea.Cmd) {
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			return m, func() tea.Msg {
+				return closeOpenURLMsg{}
+			}
+		case "enter":
+			streamUrl, err := common.ParseStreamURL(m.input.Value())
+			if err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			station := common.NewStationFromURL(streamUrl, "")
+			return m, func() tea.Msg {
+				return urlSubmittedMsg{station: station}
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m OpenURLModel) View() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.T("openUrl.title")) + "\n\n"
+	v += m.input.View() + "\n\n"
+	if m.err != "" {
+		v += m.theme.RenderError(m.err) + "\n"
+	}
+	v += m.theme.TertiaryText.Render(i18n.T("openUrl.hint")) + "\n"
+
+	return v
+}
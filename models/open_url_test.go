@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenURLModel(t *testing.T) {
+
+	typeURL := func(model OpenURLModel, url string) OpenURLModel {
+		for _, r := range url {
+			model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		return model
+	}
+
+	t.Run("submits a station playing the URL, named after its host", func(t *testing.T) {
+
+		model := typeURL(NewOpenURLModel(Theme{}), "https://stream.example.com/live.mp3")
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		msg, ok := cmd().(urlSubmittedMsg)
+
+		assert.True(t, ok)
+		assert.Equal(t, "stream.example.com", msg.station.Name)
+		assert.Equal(t, "https://stream.example.com/live.mp3", msg.station.Url.URL.String())
+
+		// The same URL is always the same station, so that its bookmark is found again
+		_, again := typeURL(NewOpenURLModel(Theme{}), "https://stream.example.com/live.mp3").Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.Equal(t, msg.station.StationUuid, again().(urlSubmittedMsg).station.StationUuid)
+
+	})
+
+	t.Run("explains what's wrong with the URL", func(t *testing.T) {
+
+		model := typeURL(NewOpenURLModel(Theme{}), "stream.example.com")
+
+		model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.Nil(t, cmd)
+		assert.NotEmpty(t, model.err)
+
+	})
+
+	t.Run("lists the station on its own, without refreshing it from radio-browser", func(t *testing.T) {
+
+		model := typeURL(NewOpenURLModel(Theme{}), "https://stream.example.com/live.mp3")
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		station := cmd().(urlSubmittedMsg).station
+
+		msg := playURLCmd(station)().(switchToStationsModelMsg)
+
+		assert.True(t, msg.autoplay)
+		assert.Len(t, msg.stations, 1)
+		assert.False(t, msg.page.fetchable())
+
+	})
+
+	t.Run("closes with esc", func(t *testing.T) {
+
+		_, cmd := NewOpenURLModel(Theme{}).Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+		assert.Equal(t, closeOpenURLMsg{}, cmd())
+
+	})
+
+}
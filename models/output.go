@@ -0,0 +1,215 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/cast"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How long to listen for devices announcing themselves on the network.
+const castDiscoveryTimeout = 3 * time.Second
+
+// Messages
+
+type castDevicesDiscoveredMsg struct {
+	devices []cast.Device
+}
+
+type castDiscoveryFailedMsg struct {
+	err error
+}
+
+// outputSelectedMsg switches playback to the given device, or back to local playback if device is nil.
+type outputSelectedMsg struct {
+	device cast.Device
+}
+
+// Model
+
+// OutputModel lets the user pick where stations are played:
+// locally, or on a DLNA/Chromecast device found on the network.
+type OutputModel struct {
+	theme Theme
+
+	spinnerModel spinner.Model
+	localName    string
+	devices      []cast.Device
+	selection    int
+	discovering  bool
+	err          string
+	width        int
+	height       int
+
+	discover func(timeout time.Duration) ([]cast.Device, error)
+}
+
+func NewOutputModel(
+	theme Theme,
+	localName string,
+	discover func(timeout time.Duration) ([]cast.Device, error),
+) OutputModel {
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = theme.SecondaryText
+
+	return OutputModel{
+		theme:        theme,
+		spinnerModel: s,
+		localName:    localName,
+		discovering:  true,
+		discover:     discover,
+	}
+}
+
+// Commands
+
+func discoverCastDevicesCmd(discover func(timeout time.Duration) ([]cast.Device, error)) tea.Cmd {
+	return func() tea.Msg {
+		devices, err := discover(castDiscoveryTimeout)
+		if err != nil {
+			return castDiscoveryFailedMsg{err: err}
+		}
+		return castDevicesDiscoveredMsg{devices: devices}
+	}
+}
+
+func updateCommandsForOutput() tea.Msg {
+	return bottomBarUpdateMsg{
+		commands: []string{
+			i18n.T("commands.quit"),
+			i18n.T("commands.back"),
+			i18n.T("commands.move"),
+			i18n.T("commands.select"),
+			i18n.T("commands.refresh"),
+		},
+	}
+}
+
+// Bubbletea
+
+func (m OutputModel) Init() tea.Cmd {
+	return tea.Batch(m.spinnerModel.Tick, discoverCastDevicesCmd(m.discover), updateCommandsForOutput)
+}
+
+func (m OutputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	switch msg := msg.(type) {
+	case castDevicesDiscoveredMsg:
+		m.discovering = false
+		m.devices = msg.devices
+		m.err = ""
+		if m.selection > len(m.devices) {
+			m.selection = 0
+		}
+		return m, nil
+	case castDiscoveryFailedMsg:
+		m.discovering = false
+		m.err = msg.err.Error()
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q":
+			return m, quitCmd
+		case "esc":
+			return m, func() tea.Msg {
+				return switchToSearchModelMsg{}
+			}
+		case "up", "k":
+			if m.selection > 0 {
+				m.selection--
+			}
+		case "down", "j":
+			if m.selection < len(m.devices) {
+				m.selection++
+			}
+		case "r":
+			if !m.discovering {
+				m.discovering = true
+				return m, tea.Batch(m.spinnerModel.Tick, discoverCastDevicesCmd(m.discover))
+			}
+		case "enter":
+			var device cast.Device
+			if m.selection > 0 {
+				device = m.devices[m.selection-1]
+			}
+			return m, func() tea.Msg {
+				return outputSelectedMsg{device: device}
+			}
+		}
+		return m, nil
+	}
+
+	if m.discovering {
+		newSpinnerModel, cmd := m.spinnerModel.Update(msg)
+		m.spinnerModel = newSpinnerModel
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+func (m OutputModel) View() string {
+
+	items := []string{i18n.Tf("output.local", m.localName)}
+	for _, device := range m.devices {
+		items = append(items, device.Name()+" ("+device.Protocol()+")")
+	}
+
+	v := "\n"
+	for i, item := range items {
+		switch {
+		case m.theme.Accessible && i == m.selection:
+			v += ">>> " + item + "\n"
+		case m.theme.Accessible:
+			v += "    " + item + "\n"
+		case i == m.selection:
+			v += m.theme.PrimaryBlock.Render(item) + "\n"
+		default:
+			v += m.theme.Text.Render(" "+item) + "\n"
+		}
+	}
+
+	v += "\n"
+
+	switch {
+	case m.discovering && m.theme.Accessible:
+		v += i18n.T("output.discovering")
+	case m.discovering:
+		v += m.spinnerModel.View() + " " + i18n.T("output.discovering")
+	case m.err != "":
+		v += m.theme.RenderError(m.err)
+	case len(m.devices) == 0:
+		v += m.theme.SecondaryText.Bold(true).Render(i18n.T("output.none"))
+	}
+
+	return v
+}
+
+func (m *OutputModel) SetWidthAndHeight(width int, height int) {
+	m.width = width
+	m.height = height
+}
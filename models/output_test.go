@@ -0,0 +1,147 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/cast"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCastDevice struct {
+	name string
+}
+
+func (d fakeCastDevice) Name() string                { return d.name }
+func (d fakeCastDevice) Protocol() string            { return "DLNA" }
+func (d fakeCastDevice) Play(media cast.Media) error { return nil }
+func (d fakeCastDevice) Stop() error                 { return nil }
+func (d fakeCastDevice) SetVolume(percent int) error { return nil }
+
+func TestOutputModel_Update(t *testing.T) {
+
+	devices := []cast.Device{fakeCastDevice{name: "Living Room"}, fakeCastDevice{name: "Kitchen"}}
+
+	discover := func(timeout time.Duration) ([]cast.Device, error) {
+		return devices, nil
+	}
+
+	t.Run("discovers devices on init", func(t *testing.T) {
+
+		model := NewOutputModel(Theme{}, "ffplay", discover)
+
+		msg := discoverCastDevicesCmd(model.discover)()
+		newModel, _ := model.Update(msg)
+
+		assert.False(t, newModel.(OutputModel).discovering)
+		assert.Equal(t, devices, newModel.(OutputModel).devices)
+
+	})
+
+	t.Run("shows the discovery error", func(t *testing.T) {
+
+		model := NewOutputModel(Theme{}, "ffplay", func(timeout time.Duration) ([]cast.Device, error) {
+			return nil, errors.New("network is unreachable")
+		})
+
+		msg := discoverCastDevicesCmd(model.discover)()
+		newModel, _ := model.Update(msg)
+
+		assert.False(t, newModel.(OutputModel).discovering)
+		assert.Contains(t, newModel.(OutputModel).View(), "network is unreachable")
+
+	})
+
+	t.Run("selects local playback as the first entry", func(t *testing.T) {
+
+		model := NewOutputModel(Theme{}, "ffplay", discover)
+		newModel, _ := model.Update(castDevicesDiscoveredMsg{devices: devices})
+
+		_, cmd := newModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.Equal(t, outputSelectedMsg{device: nil}, cmd())
+
+	})
+
+	t.Run("selects a discovered device", func(t *testing.T) {
+
+		model := NewOutputModel(Theme{}, "ffplay", discover)
+		newModel, _ := model.Update(castDevicesDiscoveredMsg{devices: devices})
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+
+		_, cmd := newModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.Equal(t, outputSelectedMsg{device: devices[1]}, cmd())
+
+	})
+
+	t.Run("goes back to search on esc", func(t *testing.T) {
+
+		model := NewOutputModel(Theme{}, "ffplay", discover)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+		assert.IsType(t, switchToSearchModelMsg{}, cmd())
+
+	})
+
+}
+
+func TestModel_SelectOutput(t *testing.T) {
+
+	t.Run("switches to a cast device and back to local playback", func(t *testing.T) {
+
+		stopped := 0
+		playbackManager := mocks.MockPlaybackManagerService{
+			NameResult: "ffplay",
+			StopStationFunc: func() error {
+				stopped++
+				return nil
+			},
+		}
+
+		model := NewModel(config.Config{}, &mocks.MockRadioBrowserService{}, &playbackManager, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+
+		newModel, cmd := model.Update(outputSelectedMsg{device: fakeCastDevice{name: "Kitchen"}})
+		cmds := sequenceCmds(cmd())
+		cmds[0]()
+
+		assert.Equal(t, 1, stopped)
+		assert.Equal(t, "Kitchen (DLNA)", newModel.(Model).headerModel.engineName)
+		assert.IsType(t, &cast.PlaybackManager{}, newModel.(Model).playbackManager)
+		assert.IsType(t, switchToSearchModelMsg{}, cmds[1]())
+
+		newModel, _ = newModel.Update(outputSelectedMsg{device: nil})
+
+		assert.Equal(t, "ffplay", newModel.(Model).headerModel.engineName)
+		assert.Equal(t, &playbackManager, newModel.(Model).playbackManager)
+
+	})
+
+}
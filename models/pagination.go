@@ -0,0 +1,279 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pageSeek looks for a page that may be past the end of the results, since radio-browser doesn't tell
+// how many pages there are: pages are fetched, without being shown, until the one asked for is found
+// or, if there aren't that many, the last one.
+type pageSeek struct {
+	// target is the page asked for, or -1 for the last page.
+	target int
+	// filled is the furthest page known to have stations.
+	filled int
+	// empty is the nearest page known to have none, or -1 if none is known yet.
+	empty int
+}
+
+// next returns the page to fetch after page was fetched with count stations or, once done, the page to show.
+func (s *pageSeek) next(page int, count int) (int, bool) {
+	if count == 0 && page > 0 {
+		s.empty = page
+	} else {
+		s.filled = page
+		// The page asked for, or the last one
+		if page == s.target || count < stationPageSize {
+			return page, true
+		}
+	}
+	if s.empty < 0 {
+		// Looking for the last page, without knowing how far it is
+		return s.filled*2 + 1, false
+	}
+	next := (s.filled + s.empty) / 2
+	if next == s.filled {
+		// The page before the first empty one is the last
+		return s.filled, true
+	}
+	return next, false
+}
+
+// How many of the tags matching a search are counted the stations of, the ones with the most stations first.
+// radio-browser returns no tags at all without a limit.
+const countedTags = 1000
+
+// countableQueries are the searches radio-browser can count the stations of, from the station count
+// of each country or tag.
+var countableQueries = map[common.StationQuery]bool{
+	common.StationQueryAll:                true,
+	common.StationQueryByCountry:          true,
+	common.StationQueryByCountryExact:     true,
+	common.StationQueryByCountryCodeExact: true,
+	common.StationQueryByTag:              true,
+	common.StationQueryByTagExact:         true,
+}
+
+// countResults returns how many stations the search of key finds, as radio-browser counts them.
+// Searches by tag or country are counted from the station count of the matching tags or countries:
+// stations with more than one matching tag are counted more than once, so the count is an estimate.
+func countResults(browser api.RadioBrowserService, key stationPageKey) (int, error) {
+
+	term := strings.ToLower(key.queryText)
+
+	switch key.query {
+	case common.StationQueryByTag, common.StationQueryByTagExact:
+		tags, err := browser.GetTags(key.queryText, "stationcount", true, 0, countedTags, true)
+		if err != nil {
+			return 0, err
+		}
+		count := 0
+		for _, tag := range tags {
+			name := strings.ToLower(tag.Name)
+			if name == term || (key.query == common.StationQueryByTag && strings.Contains(name, term)) {
+				count += int(tag.StationCount)
+			}
+		}
+		return count, nil
+	}
+
+	countries, err := browser.GetCountries(true)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, country := range countries {
+		name := strings.ToLower(country.Name)
+		switch {
+		case key.query == common.StationQueryAll,
+			key.query == common.StationQueryByCountryCodeExact && strings.EqualFold(country.Code, term),
+			key.query == common.StationQueryByCountryExact && name == term,
+			key.query == common.StationQueryByCountry && strings.Contains(name, term):
+			count += int(country.StationCount)
+		}
+	}
+	return count, nil
+}
+
+// Messages
+
+// resultsCountedMsg tells how many stations the search of key finds, as radio-browser counts them.
+type resultsCountedMsg struct {
+	key      stationPageKey
+	stations int
+}
+
+// Commands
+
+// countResultsCmd counts the stations of the search of key, if radio-browser can count them.
+// Errors are ignored: the page indicator goes without a total.
+func countResultsCmd(browser api.RadioBrowserService, key stationPageKey) tea.Cmd {
+	if !countableQueries[key.query] || !key.filter.IsEmpty() {
+		return nil
+	}
+	return func() tea.Msg {
+		count, err := countResults(browser, key)
+		if err != nil || count == 0 {
+			return nil
+		}
+		return resultsCountedMsg{key: key, stations: count}
+	}
+}
+
+// StationsModel
+
+// sameSearch reports whether two pages belong to the same search.
+func sameSearch(a, b stationPageKey) bool {
+	a.page, b.page = 0, 0
+	return a == b
+}
+
+// estimatedPages returns how many pages the results likely span, or 0 if that's unknown.
+// The total is exact once the last page has been seen.
+func (m StationsModel) estimatedPages() (pages int, exact bool) {
+	if m.lastPage >= 0 {
+		return m.lastPage + 1, true
+	}
+	if m.resultCount == 0 {
+		return 0, false
+	}
+	pages = (m.resultCount + stationPageSize - 1) / stationPageSize
+	// The count is wrong, as there are more pages
+	if pages <= m.page.page+1 && m.hasNextPage {
+		return 0, false
+	}
+	return pages, false
+}
+
+// pageIndicator describes the page shown and, if known, how many pages and stations there are.
+func (m StationsModel) pageIndicator() string {
+	page := m.page.page + 1
+	pages, exact := m.estimatedPages()
+	switch {
+	case exact:
+		stations := m.lastPage*stationPageSize + m.lastPageSize
+		return i18n.Tf("stations.pageOf", page, strconv.Itoa(pages), strconv.Itoa(stations))
+	case pages > 0:
+		return i18n.Tf("stations.pageOf", page, "~"+strconv.Itoa(pages), "~"+strconv.Itoa(m.resultCount))
+	}
+	return i18n.Tf("stations.pageOfUnknown", page, strconv.Itoa((m.page.page+1)*stationPageSize)+"+")
+}
+
+// goToPage shows the given page of results, looking for it if it may be past the end of them.
+func (m StationsModel) goToPage(page int) (tea.Model, tea.Cmd) {
+	if m.loadingPage || !m.isPaged() {
+		return m, nil
+	}
+	if m.lastPage >= 0 && page > m.lastPage {
+		newModel, cmd := m.goToPage(m.lastPage)
+		return newModel, tea.Batch(cmd, showToastCmd(i18n.Tf("stations.pastLastPage", page+1, m.lastPage+1), toastInfo))
+	}
+	if page == m.page.page {
+		return m, nil
+	}
+	if page > m.page.page+1 || (page == m.page.page+1 && !m.hasNextPage) {
+		m.seek = &pageSeek{target: page, filled: m.page.page, empty: -1}
+	}
+	return m.loadPage(page)
+}
+
+// goToLastPage shows the last page of results, looking for it unless it's been seen already.
+// The station count, if any, tells where to start looking from.
+func (m StationsModel) goToLastPage() (tea.Model, tea.Cmd) {
+	if m.loadingPage || !m.isPaged() {
+		return m, nil
+	}
+	if m.lastPage >= 0 {
+		return m.goToPage(m.lastPage)
+	}
+	m.seek = &pageSeek{target: -1, filled: m.page.page, empty: -1}
+	next := m.page.page + 1
+	if pages, _ := m.estimatedPages(); pages > next {
+		next = pages - 1
+	}
+	return m.loadPage(next)
+}
+
+// pageSought fetches the next page while looking for one, or shows the page found.
+func (m StationsModel) pageSought(msg stationPageLoadedMsg) (tea.Model, tea.Cmd) {
+	page, done := m.seek.next(msg.key.page, len(msg.stations))
+	if !done {
+		return m.loadPage(page)
+	}
+	seek := *m.seek
+	m.seek = nil
+	if seek.empty == page+1 {
+		m.lastPage, m.lastPageSize = page, stationPageSize
+	}
+	var toast tea.Cmd
+	if seek.target >= 0 && page != seek.target {
+		toast = showToastCmd(i18n.Tf("stations.pastLastPage", seek.target+1, page+1), toastInfo)
+	}
+	if page != msg.key.page {
+		// Fetched already, so it comes from the cache
+		newModel, cmd := m.loadPage(page)
+		return newModel, tea.Batch(cmd, toast)
+	}
+	newModel, cmd := m.Update(msg)
+	return newModel, tea.Batch(cmd, toast)
+}
+
+// setPageSize tells whether there's a page after the given one, just fetched with count stations.
+func (m *StationsModel) setPageSize(page int, count int) {
+	m.hasNextPage = count == stationPageSize
+	switch {
+	case !m.hasNextPage:
+		m.lastPage, m.lastPageSize = page, count
+	case page == m.lastPage && m.lastPageSize == stationPageSize:
+		// Found while looking for the last page: the page after this one is empty
+		m.hasNextPage = false
+	case page >= m.lastPage:
+		m.lastPage = -1
+	}
+}
+
+// pageCommand runs ":page", that goes to the page given by number, or the first or last one.
+func (m StationsModel) pageCommand(c command) (tea.Model, tea.Cmd) {
+	usage := errors.New(i18n.Tf("command.usage", "page <n|first|last>"))
+	if len(c.args) != 1 {
+		return m, nonFatalErrorCmd(usage)
+	}
+	switch strings.ToLower(c.args[0]) {
+	case "first":
+		return m.goToPage(0)
+	case "last":
+		return m.goToLastPage()
+	}
+	page, err := strconv.Atoi(c.args[0])
+	if err != nil || page < 1 {
+		return m, nonFatalErrorCmd(usage)
+	}
+	return m.goToPage(page - 1)
+}
@@ -0,0 +1,355 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFiniteBrowser returns a browser with total stations tagged "jazz", counting the pages requested,
+// and tags that count counted stations.
+func newFiniteBrowser(total int, counted uint64, requests *[]int) *mocks.MockRadioBrowserService {
+	return &mocks.MockRadioBrowserService{
+		GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+			*requests = append(*requests, int(offset/limit))
+			stations := []common.Station{}
+			for i := int(offset); i < total && i < int(offset+limit); i++ {
+				stations = append(stations, common.Station{Name: searchTerm, Votes: uint64(i)})
+			}
+			return stations, nil
+		},
+		GetTagsFunc: func(prefix string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Tag, error) {
+			return limitedTags([]common.Tag{{Name: "jazz", StationCount: counted}, {Name: "acid jazz", StationCount: 5}}, offset, limit), nil
+		},
+	}
+}
+
+// limitedTags returns the tags from offset on, at most limit of them, as radio-browser does.
+func limitedTags(tags []common.Tag, offset uint64, limit uint64) []common.Tag {
+	if offset > uint64(len(tags)) {
+		offset = uint64(len(tags))
+	}
+	tags = tags[offset:]
+	if limit < uint64(len(tags)) {
+		tags = tags[:limit]
+	}
+	return tags
+}
+
+// newPagedStationsModel returns the first page of the stations tagged "jazz" from browser.
+func newPagedStationsModel(browser *mocks.MockRadioBrowserService) StationsModel {
+	cache := newStationPageCache()
+	first := stationPageKey{query: common.StationQueryByTagExact, queryText: "jazz"}
+	stations, _ := cache.get(browser, first)
+	return NewStationsModel(
+		Theme{},
+		browser,
+		&mocks.MockPlaybackManagerService{},
+		&mocks.MockLabelStore{},
+		&mocks.MockBookmarkStore{},
+		&mocks.MockReportStore{},
+		filter.ContentFilter{},
+		stations,
+		nil,
+		cache,
+		first,
+		len(stations) == stationPageSize,
+	)
+}
+
+// settlePages runs cmd and the commands it leads to, feeding the pages and counts back into model,
+// and returns the other messages.
+func settlePages(newModel tea.Model, cmd tea.Cmd) (StationsModel, []tea.Msg) {
+	model := newModel.(StationsModel)
+	var others []tea.Msg
+	pending := collectMsgs(cmd)
+	for len(pending) > 0 {
+		msg := pending[0]
+		pending = pending[1:]
+		switch msg.(type) {
+		case stationPageLoadedMsg, resultsCountedMsg:
+			newModel, cmd := model.Update(msg)
+			model = newModel.(StationsModel)
+			pending = append(pending, collectMsgs(cmd)...)
+		case nil:
+		default:
+			others = append(others, msg)
+		}
+	}
+	return model, others
+}
+
+func TestPageSeek(t *testing.T) {
+
+	t.Run("shows the page asked for if it has stations", func(t *testing.T) {
+
+		seek := pageSeek{target: 5, filled: 0, empty: -1}
+
+		page, done := seek.next(5, stationPageSize)
+
+		assert.True(t, done)
+		assert.Equal(t, 5, page)
+
+	})
+
+	t.Run("bisects down to the last page", func(t *testing.T) {
+
+		// Pages 0 to 3 have stations
+		seek := pageSeek{target: 9, filled: 0, empty: -1}
+
+		page, done := seek.next(9, 0)
+		assert.False(t, done)
+		assert.Equal(t, 4, page)
+
+		page, done = seek.next(4, 0)
+		assert.False(t, done)
+		assert.Equal(t, 2, page)
+
+		page, done = seek.next(2, stationPageSize)
+		assert.False(t, done)
+		assert.Equal(t, 3, page)
+
+		page, done = seek.next(3, 42)
+		assert.True(t, done)
+		assert.Equal(t, 3, page)
+
+	})
+
+	t.Run("looks further and further for the last page", func(t *testing.T) {
+
+		seek := pageSeek{target: -1, filled: 0, empty: -1}
+
+		page, done := seek.next(1, stationPageSize)
+		assert.False(t, done)
+		assert.Equal(t, 3, page)
+
+		page, done = seek.next(3, stationPageSize)
+		assert.False(t, done)
+		assert.Equal(t, 7, page)
+
+		page, done = seek.next(7, 0)
+		assert.False(t, done)
+		assert.Equal(t, 5, page)
+
+		page, done = seek.next(5, 0)
+		assert.False(t, done)
+		assert.Equal(t, 4, page)
+
+		// Page 4 is full, but page 5 is empty
+		page, done = seek.next(4, stationPageSize)
+		assert.True(t, done)
+		assert.Equal(t, 4, page)
+
+	})
+
+}
+
+func TestCountResults(t *testing.T) {
+
+	countries := func(hideBroken bool) ([]common.Country, error) {
+		return []common.Country{
+			{Name: "Italy", Code: "IT", StationCount: 300},
+			{Name: "Germany", Code: "DE", StationCount: 500},
+			{Name: "Niger", Code: "NE", StationCount: 20},
+			{Name: "Nigeria", Code: "NG", StationCount: 40},
+		}, nil
+	}
+	browser := &mocks.MockRadioBrowserService{
+		GetCountriesFunc: countries,
+		GetTagsFunc: func(prefix string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Tag, error) {
+			assert.Equal(t, "jazz", prefix)
+			assert.True(t, hideBroken)
+			return limitedTags([]common.Tag{{Name: "Jazz", StationCount: 900}, {Name: "acid jazz", StationCount: 50}}, offset, limit), nil
+		},
+	}
+
+	tests := []struct {
+		query common.StationQuery
+		text  string
+		count int
+	}{
+		{common.StationQueryAll, "", 860},
+		{common.StationQueryByCountryCodeExact, "it", 300},
+		{common.StationQueryByCountryExact, "niger", 20},
+		{common.StationQueryByCountry, "niger", 60},
+		{common.StationQueryByTagExact, "jazz", 900},
+		{common.StationQueryByTag, "jazz", 950},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.query)+" "+tt.text, func(t *testing.T) {
+			count, err := countResults(browser, stationPageKey{query: tt.query, queryText: tt.text})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.count, count)
+		})
+	}
+
+	t.Run("can't count name searches or filtered searches", func(t *testing.T) {
+
+		assert.Nil(t, countResultsCmd(browser, stationPageKey{query: common.StationQueryByName, queryText: "jazz"}))
+		assert.Nil(t, countResultsCmd(browser, stationPageKey{query: common.StationQueryByTag, queryText: "jazz", filter: common.StationFilter{CountryCode: "IT"}}))
+
+	})
+
+	t.Run("ignores errors", func(t *testing.T) {
+
+		failing := &mocks.MockRadioBrowserService{
+			GetCountriesFunc: func(hideBroken bool) ([]common.Country, error) {
+				return nil, errors.New("unreachable")
+			},
+		}
+
+		assert.Nil(t, countResultsCmd(failing, stationPageKey{})())
+
+	})
+
+}
+
+func TestStationsModel_PageJumps(t *testing.T) {
+
+	t.Run("shows the estimated number of pages", func(t *testing.T) {
+
+		var requests []int
+		model := newPagedStationsModel(newFiniteBrowser(1234, 1300, &requests))
+
+		model, _ = settlePages(model, model.Init())
+
+		assert.Equal(t, "Page 1 of ~13 (~1300 stations)", model.pageIndicator())
+		assert.Contains(t, model.View(), "Page 1 of ~13")
+
+	})
+
+	t.Run("goes without a total the search can't be counted", func(t *testing.T) {
+
+		var requests []int
+		model := newPagedStationsModel(newFiniteBrowser(1234, 0, &requests))
+
+		model, _ = settlePages(model, model.Init())
+
+		assert.Equal(t, "Page 1 (100+ stations)", model.pageIndicator())
+
+	})
+
+	t.Run("finds the last page from the estimate", func(t *testing.T) {
+
+		var requests []int
+		model := newPagedStationsModel(newFiniteBrowser(1234, 1300, &requests))
+		model, _ = settlePages(model, model.Init())
+		requests = nil
+
+		model, _ = settlePages(model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(">")}))
+
+		assert.Equal(t, 12, model.page.page)
+		assert.Equal(t, uint64(1200), model.stations[0].Votes)
+		assert.Equal(t, []int{12}, requests)
+		assert.False(t, model.hasNextPage)
+		assert.Equal(t, "Page 13 of 13 (1234 stations)", model.pageIndicator())
+
+		model, _ = settlePages(model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("<")}))
+
+		assert.Equal(t, 0, model.page.page)
+		assert.Equal(t, "Page 1 of 13 (1234 stations)", model.pageIndicator())
+
+	})
+
+	t.Run("finds the last page when the estimate is too high", func(t *testing.T) {
+
+		var requests []int
+		model := newPagedStationsModel(newFiniteBrowser(450, 2000, &requests))
+		model, _ = settlePages(model, model.Init())
+
+		model, _ = settlePages(model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(">")}))
+
+		assert.Equal(t, 4, model.page.page)
+		assert.Len(t, model.stations, 50)
+		assert.False(t, model.loadingPage)
+		assert.Nil(t, model.seek)
+
+	})
+
+	t.Run("finds the last page without an estimate", func(t *testing.T) {
+
+		var requests []int
+		model := newPagedStationsModel(newFiniteBrowser(600, 0, &requests))
+		model, _ = settlePages(model, model.Init())
+
+		model, _ = settlePages(model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(">")}))
+
+		// The last page is full: the one after it is empty
+		assert.Equal(t, 5, model.page.page)
+		assert.False(t, model.hasNextPage)
+		assert.Equal(t, "Page 6 of 6 (600 stations)", model.pageIndicator())
+
+	})
+
+	t.Run("jumps to a page", func(t *testing.T) {
+
+		var requests []int
+		model := newPagedStationsModel(newFiniteBrowser(1234, 0, &requests))
+
+		model, others := settlePages(model.runCommand(command{name: "page", args: []string{"7"}}))
+
+		assert.Equal(t, 6, model.page.page)
+		for _, msg := range others {
+			_, toast := msg.(toastMsg)
+			assert.False(t, toast)
+		}
+
+	})
+
+	t.Run("shows the last page when asked for one past it", func(t *testing.T) {
+
+		var requests []int
+		model := newPagedStationsModel(newFiniteBrowser(350, 0, &requests))
+
+		model, others := settlePages(model.runCommand(command{name: "page", args: []string{"40"}}))
+
+		assert.Equal(t, 3, model.page.page)
+		assert.Contains(t, others, toastMsg{text: "There's no page 40, the last one is 4", kind: toastInfo})
+
+		model, _ = settlePages(model.runCommand(command{name: "page", args: []string{"first"}}))
+		assert.Equal(t, 0, model.page.page)
+
+		model, others = settlePages(model.runCommand(command{name: "page", args: []string{"9"}}))
+		assert.Equal(t, 3, model.page.page)
+		assert.Contains(t, others, toastMsg{text: "There's no page 9, the last one is 4", kind: toastInfo})
+
+	})
+
+	t.Run("rejects pages that aren't numbers", func(t *testing.T) {
+
+		var requests []int
+		model := newPagedStationsModel(newFiniteBrowser(1234, 0, &requests))
+
+		_, cmd := model.runCommand(command{name: "page", args: []string{"zero"}})
+
+		assert.IsType(t, nonFatalError{}, cmd())
+
+	})
+
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// bookmarkOrder is the order bookmarks are listed in, "s" switching to the next one.
+type bookmarkOrder int
+
+const (
+	// bookmarkOrderAdded lists bookmarks in the order they were added.
+	bookmarkOrderAdded bookmarkOrder = iota
+	// bookmarkOrderName lists bookmarks alphabetically, by the name they're shown with.
+	bookmarkOrderName
+	// bookmarkOrderRecent lists the bookmarks played last first.
+	bookmarkOrderRecent
+	// bookmarkOrderListened lists the bookmarks listened to the longest first.
+	bookmarkOrderListened
+)
+
+// bookmarkOrderNames are the names of the orders in the sort command, in the order "s" switches through them.
+var bookmarkOrderNames = []string{"added", "name", "recent", "listened"}
+
+// next returns the order "s" switches to.
+func (o bookmarkOrder) next() bookmarkOrder {
+	return (o + 1) % bookmarkOrder(len(bookmarkOrderNames))
+}
+
+// String describes the order, e.g. "most listened".
+func (o bookmarkOrder) String() string {
+	return i18n.T("bookmarks.order." + bookmarkOrderNames[o])
+}
+
+// parseBookmarkOrder returns the order with the given name in the sort command.
+func parseBookmarkOrder(name string) (bookmarkOrder, error) {
+	for i, n := range bookmarkOrderNames {
+		if strings.EqualFold(name, n) {
+			return bookmarkOrder(i), nil
+		}
+	}
+	return bookmarkOrderAdded, errors.New(i18n.Tf("command.usage", "sort ["+strings.Join(bookmarkOrderNames, "|")+"]"))
+}
+
+// sortBookmarks sorts stations, which are in the order they were added, by order.
+// Stations ranking the same keep the order they were added in.
+func sortBookmarks(stations []common.Station, order bookmarkOrder, labelStore storage.LabelStore, stats map[uuid.UUID]storage.PlayStats) {
+	var less func(a, b common.Station) bool
+	switch order {
+	case bookmarkOrderName:
+		less = func(a, b common.Station) bool {
+			return strings.ToLower(stationDisplayName(labelStore, a)) < strings.ToLower(stationDisplayName(labelStore, b))
+		}
+	case bookmarkOrderRecent:
+		less = func(a, b common.Station) bool {
+			return stats[a.StationUuid].LastPlayed.After(stats[b.StationUuid].LastPlayed)
+		}
+	case bookmarkOrderListened:
+		less = func(a, b common.Station) bool {
+			statsA, statsB := stats[a.StationUuid], stats[b.StationUuid]
+			if statsA.Listened != statsB.Listened {
+				return statsA.Listened > statsB.Listened
+			}
+			return statsA.Plays > statsB.Plays
+		}
+	default:
+		return
+	}
+	sort.SliceStable(stations, func(i, j int) bool {
+		return less(stations[i], stations[j])
+	})
+}
+
+// formatPlayStats describes how much a station was listened to, e.g. "12 plays · 3:04:10",
+// or returns an empty string if it was never played.
+func formatPlayStats(stats storage.PlayStats) string {
+	if stats.Plays == 0 {
+		return ""
+	}
+	return i18n.Tf("bookmarks.playStats", stats.Plays, formatElapsed(stats.Listened))
+}
+
+// saveListenedCmd adds how long the station timed was listened to since the last save to its play statistics,
+// or returns nil if there's nothing to save.
+func (m Model) saveListenedCmd() tea.Cmd {
+	if m.clock == nil || m.playStats == nil {
+		return nil
+	}
+	station, listened := m.clock.unsaved()
+	if station == uuid.Nil || listened <= 0 {
+		return nil
+	}
+	return addListenedCmd(m.playStats, station, listened)
+}
+
+// Messages
+
+// bookmarkOrderChangedMsg reports the order bookmarks are listed in, which the next visit keeps.
+type bookmarkOrderChangedMsg struct {
+	order bookmarkOrder
+}
+
+// Commands
+
+func recordPlayCmd(playStats storage.PlayStatsStore, stationUuid uuid.UUID, playedAt time.Time) tea.Cmd {
+	return func() tea.Msg {
+		if err := playStats.RecordPlay(stationUuid, playedAt); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return nil
+	}
+}
+
+func addListenedCmd(playStats storage.PlayStatsStore, stationUuid uuid.UUID, listened time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		if err := playStats.AddListened(stationUuid, listened); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return nil
+	}
+}
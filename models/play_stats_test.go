@@ -0,0 +1,196 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModel_PlayStats(t *testing.T) {
+
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+
+	newModel := func() (Model, *[]string, func(time.Duration)) {
+		var recorded []string
+		model := NewModel(config.Config{}, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{}, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		clock, advance := fakeClock()
+		model.clock = clock
+		model.playStats = &mocks.MockPlayStatsStore{
+			RecordPlayFunc: func(stationUuid uuid.UUID, playedAt time.Time) error {
+				recorded = append(recorded, "play "+stationUuid.String())
+				return nil
+			},
+			AddListenedFunc: func(stationUuid uuid.UUID, listened time.Duration) error {
+				recorded = append(recorded, "listened "+stationUuid.String()+" "+listened.String())
+				return nil
+			},
+		}
+		return model, &recorded, advance
+	}
+
+	t.Run("counts the play and saves the time listened once stopped", func(t *testing.T) {
+
+		model, recorded, advance := newModel()
+
+		model, cmd := model.updateListeningClock(playbackStartedMsg{station: station})
+		collectMsgs(cmd)
+		advance(3 * time.Minute)
+		_, cmd = model.updateListeningClock(playbackStoppedMsg{})
+		collectMsgs(cmd)
+
+		assert.Equal(t, []string{"play " + station.StationUuid.String(), "listened " + station.StationUuid.String() + " 3m0s"}, *recorded)
+
+	})
+
+	t.Run("doesn't count reconnections as plays", func(t *testing.T) {
+
+		model, recorded, advance := newModel()
+
+		model, cmd := model.updateListeningClock(playbackStartedMsg{station: station})
+		collectMsgs(cmd)
+		advance(time.Minute)
+		model, cmd = model.updateListeningClock(reconnectStationMsg{})
+		collectMsgs(cmd)
+		model, cmd = model.updateListeningClock(playbackStartedMsg{station: station})
+		collectMsgs(cmd)
+		advance(time.Minute)
+		_, cmd = model.updateListeningClock(playbackStoppedMsg{})
+		collectMsgs(cmd)
+
+		assert.Equal(t, []string{
+			"play " + station.StationUuid.String(),
+			"listened " + station.StationUuid.String() + " 1m0s",
+			"listened " + station.StationUuid.String() + " 1m0s",
+		}, *recorded)
+
+	})
+
+}
+
+func TestBookmarksModel_Order(t *testing.T) {
+
+	added := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	zulu := common.Station{StationUuid: uuid.New(), Name: "Zulu Radio"}
+	alpha := common.Station{StationUuid: uuid.New(), Name: "alpha beats"}
+	mike := common.Station{StationUuid: uuid.New(), Name: "Mike FM"}
+	stats := map[uuid.UUID]storage.PlayStats{
+		zulu.StationUuid:  {Plays: 2, Listened: 10 * time.Minute, LastPlayed: added.Add(time.Hour)},
+		alpha.StationUuid: {Plays: 5, Listened: 3 * time.Hour, LastPlayed: added},
+	}
+
+	newModel := func() BookmarksModel {
+		model := NewBookmarksModel(
+			Theme{},
+			&mocks.MockRadioBrowserService{},
+			&mocks.MockPlaybackManagerService{},
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{
+				AllFunc: func() []common.Station {
+					return []common.Station{zulu, alpha, mike}
+				},
+			},
+			filter.ContentFilter{},
+			&mocks.MockProberService{},
+		)
+		model.SetPlayStatsStore(&mocks.MockPlayStatsStore{
+			AllFunc: func() map[uuid.UUID]storage.PlayStats {
+				return stats
+			},
+		})
+		return model
+	}
+
+	names := func(model BookmarksModel) []string {
+		var names []string
+		for _, station := range model.stations {
+			names = append(names, station.Name)
+		}
+		return names
+	}
+
+	t.Run("lists bookmarks in the order they were added, with how much they were listened to", func(t *testing.T) {
+
+		model := newModel()
+
+		assert.Equal(t, []string{"Zulu Radio", "alpha beats", "Mike FM"}, names(model))
+		assert.Equal(t, "5 plays · 3:00:00", model.stationsTable.Rows()[1][3])
+		assert.Empty(t, model.stationsTable.Rows()[2][3])
+		assert.Empty(t, model.location())
+
+	})
+
+	t.Run("switches between the orders with s", func(t *testing.T) {
+
+		model := newModel()
+
+		newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+		model = newModel.(BookmarksModel)
+		assert.Equal(t, []string{"alpha beats", "Mike FM", "Zulu Radio"}, names(model))
+		assert.Equal(t, bookmarkOrderChangedMsg{order: bookmarkOrderName}, cmd())
+
+		newModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+		model = newModel.(BookmarksModel)
+		assert.Equal(t, []string{"Zulu Radio", "alpha beats", "Mike FM"}, names(model))
+
+		newModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+		model = newModel.(BookmarksModel)
+		assert.Equal(t, []string{"alpha beats", "Zulu Radio", "Mike FM"}, names(model))
+		assert.Equal(t, "Sorted by most listened (s: change)", model.location())
+
+		newModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+		assert.Equal(t, bookmarkOrderAdded, newModel.(BookmarksModel).order)
+
+	})
+
+	t.Run("keeps the cursor on the bookmark it was on", func(t *testing.T) {
+
+		model := newModel()
+		model.stationsTable.SetCursor(1)
+
+		newModel, _ := model.runCommand(command{name: "sort", args: []string{"listened"}})
+
+		selected, ok := newModel.(BookmarksModel).selectedStation()
+		assert.True(t, ok)
+		assert.Equal(t, alpha, selected)
+
+	})
+
+	t.Run("rejects unknown orders", func(t *testing.T) {
+
+		model := newModel()
+
+		_, cmd := model.runCommand(command{name: "sort", args: []string{"votes"}})
+
+		assert.IsType(t, nonFatalError{}, cmd())
+
+	})
+
+}
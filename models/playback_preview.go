@@ -0,0 +1,67 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/icy"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/secrets"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/google/uuid"
+)
+
+// PlaybackStores are what stations are played with besides the player, each of which may be nil.
+type PlaybackStores struct {
+	Credentials secrets.Store
+	Variants    storage.StreamVariantStore
+	Headers     storage.StreamHeaderStore
+	Prefs       storage.StationPrefsStore
+	VolumeTrims storage.VolumeTrimStore
+}
+
+// PreviewPlayback returns the command line player would run to play station, its credentials redacted.
+// The station is prepared as when it's played from the list: given its headers, preferences and credentials,
+// set to the first of its URLs prober finds playing and played at the default volume, trimmed.
+// Which URL played isn't remembered. It's false if player runs no command.
+func PreviewPlayback(player playback.PlaybackManagerService, contentFilter filter.ContentFilter, prober icy.ProberService, stores PlaybackStores, station common.Station) (playback.Invocation, bool, error) {
+	var variants storage.StreamVariantStore
+	if stores.Variants != nil {
+		variants = unrememberedVariants{stores.Variants}
+	}
+	volume := trimmedVolume(stores.VolumeTrims, player, station, player.VolumeDefault())
+	prepared, _, err := prepareStation(contentFilter, prober, stores.Credentials, variants, withStationPrefs(stores.Prefs, withStreamHeaders(stores.Headers, station)))
+	if err != nil {
+		return playback.Invocation{}, false, err
+	}
+	invocation, ok := playback.PreviewCommand(player, prepared, volume)
+	return invocation.Redacted(), ok, nil
+}
+
+// unrememberedVariants tries the URL that played a station last first, without remembering the one picked.
+type unrememberedVariants struct {
+	storage.StreamVariantStore
+}
+
+func (unrememberedVariants) SetVariant(stationUuid uuid.UUID, variant storage.StreamVariant) error {
+	return nil
+}
@@ -0,0 +1,262 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stationPageSize is how many stations are fetched per page of results.
+const stationPageSize = 100
+
+// stationPageKey identifies a page of search results.
+type stationPageKey struct {
+	query     common.StationQuery
+	queryText string
+	// filter narrows name searches down, see common.StationFilter
+	filter common.StationFilter
+	page   int
+}
+
+// stationQueryURL is the query of a station opened from its URL, which isn't a radio-browser search,
+// so that its page is never fetched again.
+const stationQueryURL common.StationQuery = "url"
+
+// stationQueryChart is the query of a chart of stations: its queryText is the common.StationChart,
+// and the country code of its filter the country it ranks (any country if empty).
+const stationQueryChart common.StationQuery = "chart"
+
+// stationQuerySimilar is the query of the stations most like another one: its queryText is that station's UUID.
+const stationQuerySimilar common.StationQuery = "similar"
+
+// fetchable returns true if the page comes from radio-browser, and so can be fetched again.
+func (k stationPageKey) fetchable() bool {
+	return k.query != stationQueryURL && k.query != stationQueryHistory && k.query != stationQueryUnstable && k.query != stationQueryDiscover
+}
+
+// stationPageCache keeps the pages of the current search, including the ones fetched
+// ahead of time, so that moving between pages doesn't wait for radio-browser.
+// It only holds one search at a time: asking for a page of another search drops the others.
+// It's shared by the loading and stations models, and safe for use by concurrent commands.
+// A nil *stationPageCache fetches every page.
+type stationPageCache struct {
+	mu sync.Mutex
+	// The search whose pages are cached
+	query     common.StationQuery
+	queryText string
+	filter    common.StationFilter
+	pages     map[int][]common.Station
+	// Pages being fetched, closed when done
+	pending map[int]chan struct{}
+	// Incremented on every invalidation, so that late fetches aren't cached
+	generation int
+}
+
+func newStationPageCache() *stationPageCache {
+	return &stationPageCache{
+		pages:   make(map[int][]common.Station),
+		pending: make(map[int]chan struct{}),
+	}
+}
+
+// invalidate drops every cached page.
+func (c *stationPageCache) invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidateLocked()
+}
+
+func (c *stationPageCache) invalidateLocked() {
+	c.pages = make(map[int][]common.Station)
+	c.pending = make(map[int]chan struct{})
+	c.generation++
+}
+
+// holds reports whether the given page belongs to the search whose pages are cached.
+func (c *stationPageCache) holds(key stationPageKey) bool {
+	return key.query == c.query && key.queryText == c.queryText && key.filter == c.filter
+}
+
+// cached returns the given page if it has already been fetched.
+func (c *stationPageCache) cached(key stationPageKey) ([]common.Station, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.holds(key) {
+		return nil, false
+	}
+	stations, ok := c.pages[key.page]
+	return stations, ok
+}
+
+// fetched returns the stations of every page of the search of key fetched so far, in page order.
+func (c *stationPageCache) fetched(key stationPageKey) []common.Station {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.holds(key) {
+		return nil
+	}
+	pages := make([]int, 0, len(c.pages))
+	for page := range c.pages {
+		pages = append(pages, page)
+	}
+	sort.Ints(pages)
+	var stations []common.Station
+	for _, page := range pages {
+		stations = append(stations, c.pages[page]...)
+	}
+	return stations
+}
+
+// get returns the given page, fetching it unless it's cached.
+// If the page is already being fetched (e.g. prefetched), it waits for that request instead of sending another.
+func (c *stationPageCache) get(browser api.RadioBrowserService, key stationPageKey) ([]common.Station, error) {
+	if c == nil {
+		return fetchStationPage(browser, key)
+	}
+	return c.load(browser, key, false)
+}
+
+// prefetch fetches the given page unless it's cached, as long as it belongs to the current search.
+func (c *stationPageCache) prefetch(browser api.RadioBrowserService, key stationPageKey) error {
+	_, err := c.load(browser, key, true)
+	return err
+}
+
+func (c *stationPageCache) load(browser api.RadioBrowserService, key stationPageKey, currentSearchOnly bool) ([]common.Station, error) {
+	c.mu.Lock()
+	if !c.holds(key) {
+		if currentSearchOnly {
+			c.mu.Unlock()
+			return nil, nil
+		}
+		c.invalidateLocked()
+		c.query = key.query
+		c.queryText = key.queryText
+		c.filter = key.filter
+	}
+	for {
+		if stations, ok := c.pages[key.page]; ok {
+			c.mu.Unlock()
+			return stations, nil
+		}
+		done, ok := c.pending[key.page]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+		<-done
+		c.mu.Lock()
+		if !c.holds(key) {
+			// Another search started in the meantime
+			c.mu.Unlock()
+			if currentSearchOnly {
+				return nil, nil
+			}
+			return fetchStationPage(browser, key)
+		}
+	}
+	done := make(chan struct{})
+	c.pending[key.page] = done
+	generation := c.generation
+	c.mu.Unlock()
+
+	stations, err := fetchStationPage(browser, key)
+
+	c.mu.Lock()
+	if generation == c.generation {
+		delete(c.pending, key.page)
+		if err == nil {
+			c.pages[key.page] = stations
+		}
+	}
+	c.mu.Unlock()
+	close(done)
+
+	return stations, err
+}
+
+func fetchStationPage(browser api.RadioBrowserService, key stationPageKey) ([]common.Station, error) {
+	if key.query == stationQueryChart {
+		// Charts are a single page
+		if key.page > 0 {
+			return []common.Station{}, nil
+		}
+		return browser.GetTopStations(common.StationChart(key.queryText), key.filter.CountryCode, chartSize, true)
+	}
+	if key.query == stationQuerySimilar {
+		// Recommendations are a single page too
+		if key.page > 0 {
+			return []common.Station{}, nil
+		}
+		return fetchSimilarStations(browser, key.queryText)
+	}
+	offset := uint64(key.page * stationPageSize)
+	if key.query == common.StationQueryByName && !key.filter.IsEmpty() {
+		return browser.SearchStations(key.queryText, key.filter, "votes", true, offset, stationPageSize, true)
+	}
+	return browser.GetStations(key.query, key.queryText, "votes", true, offset, stationPageSize, true)
+}
+
+// Messages
+
+type stationPageLoadedMsg struct {
+	key      stationPageKey
+	stations []common.Station
+}
+
+// Commands
+
+// loadStationPageCmd loads the given page, from the cache if possible.
+func loadStationPageCmd(pages *stationPageCache, browser api.RadioBrowserService, key stationPageKey) tea.Cmd {
+	return func() tea.Msg {
+		stations, err := pages.get(browser, key)
+		if err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return stationPageLoadedMsg{key: key, stations: stations}
+	}
+}
+
+// prefetchStationPageCmd fetches the given page in the background, so that it's cached when needed.
+// Errors are ignored: the page is fetched again when the user moves to it.
+func prefetchStationPageCmd(pages *stationPageCache, browser api.RadioBrowserService, key stationPageKey) tea.Cmd {
+	if pages == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		_ = pages.prefetch(browser, key)
+		return nil
+	}
+}
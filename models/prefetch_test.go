@@ -0,0 +1,331 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// newPagingBrowser returns a browser with full pages of stations named after their search and offset,
+// counting the requests it receives.
+func newPagingBrowser(requests *int32) *mocks.MockRadioBrowserService {
+	return &mocks.MockRadioBrowserService{
+		GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+			atomic.AddInt32(requests, 1)
+			stations := make([]common.Station, limit)
+			stations[0].Name = searchTerm
+			stations[0].Votes = offset
+			return stations, nil
+		},
+	}
+}
+
+func TestStationPageCache(t *testing.T) {
+
+	t.Run("fetches each page once", func(t *testing.T) {
+
+		var requests int32
+		browser := newPagingBrowser(&requests)
+		cache := newStationPageCache()
+		key := stationPageKey{query: common.StationQueryByName, queryText: "jazz", page: 1}
+
+		_, ok := cache.cached(key)
+		assert.False(t, ok)
+
+		stations, err := cache.get(browser, key)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(stationPageSize), stations[0].Votes)
+
+		stations, err = cache.get(browser, key)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(stationPageSize), stations[0].Votes)
+		assert.Equal(t, int32(1), requests)
+
+		_, ok = cache.cached(key)
+		assert.True(t, ok)
+
+	})
+
+	t.Run("drops the pages of the previous search when the query changes", func(t *testing.T) {
+
+		var requests int32
+		browser := newPagingBrowser(&requests)
+		cache := newStationPageCache()
+		jazz := stationPageKey{query: common.StationQueryByName, queryText: "jazz"}
+		rock := stationPageKey{query: common.StationQueryByName, queryText: "rock"}
+
+		_, _ = cache.get(browser, jazz)
+		_, _ = cache.get(browser, rock)
+
+		_, ok := cache.cached(jazz)
+		assert.False(t, ok)
+		_, ok = cache.cached(rock)
+		assert.True(t, ok)
+
+	})
+
+	t.Run("keeps the pages of searches with different filters apart", func(t *testing.T) {
+
+		var requests int32
+		browser := newPagingBrowser(&requests)
+		var filters []common.StationFilter
+		browser.SearchStationsFunc = func(name string, filter common.StationFilter, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+			atomic.AddInt32(&requests, 1)
+			filters = append(filters, filter)
+			return []common.Station{{Name: name}}, nil
+		}
+		cache := newStationPageCache()
+		unfiltered := stationPageKey{query: common.StationQueryByName, queryText: "rai"}
+		filtered := stationPageKey{query: common.StationQueryByName, queryText: "rai", filter: common.StationFilter{CountryCode: "IT"}}
+
+		_, _ = cache.get(browser, unfiltered)
+		stations, err := cache.get(browser, filtered)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "rai", stations[0].Name)
+		assert.Equal(t, []common.StationFilter{{CountryCode: "IT"}}, filters)
+		assert.Equal(t, int32(2), requests)
+		_, ok := cache.cached(unfiltered)
+		assert.False(t, ok)
+
+	})
+
+	t.Run("fetches charts as a single page", func(t *testing.T) {
+
+		browser := &mocks.MockRadioBrowserService{
+			GetTopStationsFunc: func(chart common.StationChart, countryCode string, limit uint64, hideBroken bool) ([]common.Station, error) {
+				return []common.Station{{Name: string(chart) + " " + countryCode}}, nil
+			},
+		}
+		cache := newStationPageCache()
+		key := stationPageKey{query: stationQueryChart, queryText: "topvote", filter: common.StationFilter{CountryCode: "IT"}}
+
+		stations, err := cache.get(browser, key)
+		assert.NoError(t, err)
+		assert.Equal(t, []common.Station{{Name: "topvote IT"}}, stations)
+
+		key.page = 1
+		stations, err = cache.get(browser, key)
+		assert.NoError(t, err)
+		assert.Empty(t, stations)
+
+	})
+
+	t.Run("fetches recommendations as a single page", func(t *testing.T) {
+
+		seed := common.Station{StationUuid: uuid.New(), Name: "Seed", Tags: "jazz"}
+		browser := &mocks.MockRadioBrowserService{
+			GetStationsFunc: func(query common.StationQuery, term string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				if query == common.StationQueryByUuid {
+					return []common.Station{seed}, nil
+				}
+				return []common.Station{{StationUuid: uuid.New(), Name: "Jazz", Tags: "jazz"}}, nil
+			},
+		}
+		cache := newStationPageCache()
+		key := stationPageKey{query: stationQuerySimilar, queryText: seed.StationUuid.String()}
+
+		stations, err := cache.get(browser, key)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Jazz"}, stationNames(stations))
+
+		key.page = 1
+		stations, err = cache.get(browser, key)
+		assert.NoError(t, err)
+		assert.Empty(t, stations)
+
+	})
+
+	t.Run("drops every page when invalidated", func(t *testing.T) {
+
+		var requests int32
+		browser := newPagingBrowser(&requests)
+		cache := newStationPageCache()
+		key := stationPageKey{query: common.StationQueryByName, queryText: "jazz"}
+
+		_, _ = cache.get(browser, key)
+		cache.invalidate()
+		_, _ = cache.get(browser, key)
+
+		assert.Equal(t, int32(2), requests)
+
+	})
+
+	t.Run("doesn't prefetch pages of another search", func(t *testing.T) {
+
+		var requests int32
+		browser := newPagingBrowser(&requests)
+		cache := newStationPageCache()
+		jazz := stationPageKey{query: common.StationQueryByName, queryText: "jazz"}
+		rock := stationPageKey{query: common.StationQueryByName, queryText: "rock", page: 1}
+
+		_, _ = cache.get(browser, jazz)
+		assert.NoError(t, cache.prefetch(browser, rock))
+
+		assert.Equal(t, int32(1), requests)
+		_, ok := cache.cached(jazz)
+		assert.True(t, ok)
+
+	})
+
+	t.Run("waits for a page being prefetched instead of fetching it again", func(t *testing.T) {
+
+		var requests int32
+		release := make(chan struct{})
+		browser := &mocks.MockRadioBrowserService{
+			GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				atomic.AddInt32(&requests, 1)
+				<-release
+				return []common.Station{{Name: searchTerm}}, nil
+			},
+		}
+		cache := newStationPageCache()
+		key := stationPageKey{query: common.StationQueryByName, queryText: "jazz"}
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = cache.get(browser, key)
+		}()
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&requests) == 1 }, time.Second, time.Millisecond)
+		go func() {
+			defer wg.Done()
+			stations, err := cache.get(browser, key)
+			assert.NoError(t, err)
+			assert.Equal(t, "jazz", stations[0].Name)
+		}()
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), requests)
+
+	})
+
+	t.Run("doesn't cache failed requests", func(t *testing.T) {
+
+		var requests int32
+		browser := &mocks.MockRadioBrowserService{
+			GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				atomic.AddInt32(&requests, 1)
+				return nil, errors.New("offline")
+			},
+		}
+		cache := newStationPageCache()
+		key := stationPageKey{query: common.StationQueryByName, queryText: "jazz"}
+
+		_, err := cache.get(browser, key)
+		assert.Error(t, err)
+		_, err = cache.get(browser, key)
+		assert.Error(t, err)
+
+		assert.Equal(t, int32(2), requests)
+
+	})
+
+}
+
+func TestStationsModel_Paging(t *testing.T) {
+
+	t.Run("moves to the next page and prefetches the one after", func(t *testing.T) {
+
+		var requests int32
+		browser := newPagingBrowser(&requests)
+		cache := newStationPageCache()
+		first := stationPageKey{query: common.StationQueryByName, queryText: "jazz"}
+		stations, _ := cache.get(browser, first)
+
+		model := NewStationsModel(
+			Theme{},
+			browser,
+			&mocks.MockPlaybackManagerService{},
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{},
+			&mocks.MockReportStore{},
+			filter.ContentFilter{},
+			stations,
+			nil,
+			cache,
+			first,
+			true,
+		)
+
+		// Init prefetches the second page
+		for _, msg := range model.Init()().(tea.BatchMsg) {
+			if msg != nil {
+				msg()
+			}
+		}
+		assert.Equal(t, int32(2), requests)
+
+		newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+		model = newModel.(StationsModel)
+		assert.True(t, model.loadingPage)
+
+		msg := cmd()
+		assert.Equal(t, 1, msg.(stationPageLoadedMsg).key.page)
+		assert.Equal(t, int32(2), requests)
+
+		newModel, _ = model.Update(msg)
+		model = newModel.(StationsModel)
+		assert.False(t, model.loadingPage)
+		assert.Equal(t, 1, model.page.page)
+		assert.Equal(t, uint64(stationPageSize), model.stations[0].Votes)
+
+	})
+
+	t.Run("doesn't move before the first page", func(t *testing.T) {
+
+		model := NewStationsModel(
+			Theme{},
+			&mocks.MockRadioBrowserService{},
+			&mocks.MockPlaybackManagerService{},
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{},
+			&mocks.MockReportStore{},
+			filter.ContentFilter{},
+			[]common.Station{{Name: "Station"}},
+			nil,
+			newStationPageCache(),
+			stationPageKey{},
+			false,
+		)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+		assert.Nil(t, cmd)
+
+		_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+		assert.Nil(t, cmd)
+
+	})
+
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Messages
+
+// profileSelectedMsg switches to the profile with the given name.
+type profileSelectedMsg struct {
+	name string
+}
+
+// Model
+
+// ProfilesModel lets the user switch to another profile, each with its own configuration,
+// bookmarks and history.
+type ProfilesModel struct {
+	theme Theme
+
+	profiles  []string
+	current   string
+	selection int
+	err       string
+	width     int
+	height    int
+}
+
+// NewProfilesModel lists the given profiles, highlighting the current one.
+// err is shown below them if the profiles could not all be listed.
+func NewProfilesModel(theme Theme, profiles []string, current string, err error) ProfilesModel {
+	m := ProfilesModel{
+		theme:    theme,
+		profiles: profiles,
+		current:  current,
+	}
+	for i, profile := range profiles {
+		if profile == current {
+			m.selection = i
+		}
+	}
+	if err != nil {
+		m.err = err.Error()
+	}
+	return m
+}
+
+// Commands
+
+func updateCommandsForProfiles() tea.Msg {
+	return bottomBarUpdateMsg{
+		commands: []string{
+			i18n.T("commands.quit"),
+			i18n.T("commands.back"),
+			i18n.T("commands.move"),
+			i18n.T("commands.select"),
+		},
+	}
+}
+
+// Bubbletea
+
+func (m ProfilesModel) Init() tea.Cmd {
+	return updateCommandsForProfiles
+}
+
+func (m ProfilesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "q":
+			return m, quitCmd
+		case "esc":
+			return m, func() tea.Msg {
+				return switchToSearchModelMsg{}
+			}
+		case "up", "k":
+			if m.selection > 0 {
+				m.selection--
+			}
+		case "down", "j":
+			if m.selection < len(m.profiles)-1 {
+				m.selection++
+			}
+		case "enter":
+			if len(m.profiles) == 0 {
+				return m, nil
+			}
+			name := m.profiles[m.selection]
+			return m, func() tea.Msg {
+				return profileSelectedMsg{name: name}
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m ProfilesModel) View() string {
+
+	v := "\n"
+	for i, profile := range m.profiles {
+		item := profile
+		if profile == m.current {
+			item = i18n.Tf("profiles.current", profile)
+		}
+		switch {
+		case m.theme.Accessible && i == m.selection:
+			v += ">>> " + item + "\n"
+		case m.theme.Accessible:
+			v += "    " + item + "\n"
+		case i == m.selection:
+			v += m.theme.PrimaryBlock.Render(item) + "\n"
+		default:
+			v += m.theme.Text.Render(" "+item) + "\n"
+		}
+	}
+
+	v += "\n"
+
+	if m.err != "" {
+		v += m.theme.RenderError(m.err)
+	} else {
+		v += m.theme.SecondaryText.Bold(true).Render(i18n.T("profiles.hint"))
+	}
+
+	return v
+}
+
+func (m *ProfilesModel) SetWidthAndHeight(width int, height int) {
+	m.width = width
+	m.height = height
+}
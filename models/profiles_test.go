@@ -0,0 +1,130 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfilesModel_Update(t *testing.T) {
+
+	profiles := []string{"default", "home", "work"}
+
+	t.Run("highlights the current profile", func(t *testing.T) {
+
+		model := NewProfilesModel(Theme{}, profiles, "work", nil)
+
+		assert.Equal(t, 2, model.selection)
+		assert.Contains(t, model.View(), "work (current)")
+
+	})
+
+	t.Run("selects another profile", func(t *testing.T) {
+
+		model := NewProfilesModel(Theme{}, profiles, "default", nil)
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyDown})
+
+		_, cmd := newModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.Equal(t, profileSelectedMsg{name: "home"}, cmd())
+
+	})
+
+	t.Run("shows why the profiles could not be listed", func(t *testing.T) {
+
+		model := NewProfilesModel(Theme{}, []string{"default"}, "default", errors.New("permission denied"))
+
+		assert.Contains(t, model.View(), "permission denied")
+
+	})
+
+	t.Run("goes back to search on esc", func(t *testing.T) {
+
+		model := NewProfilesModel(Theme{}, profiles, "default", nil)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+		assert.IsType(t, switchToSearchModelMsg{}, cmd())
+
+	})
+
+}
+
+func TestModel_SelectProfile(t *testing.T) {
+
+	newModel := func(playbackManager *mocks.MockPlaybackManagerService) Model {
+		model := NewModel(config.Config{}, &mocks.MockRadioBrowserService{}, playbackManager, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		model.listProfiles = func() ([]string, error) {
+			return []string{"default", "kids"}, nil
+		}
+		return model
+	}
+
+	t.Run("opens the profile switcher", func(t *testing.T) {
+
+		model := newModel(&mocks.MockPlaybackManagerService{})
+
+		updated, _ := model.Update(switchToProfilesModelMsg{})
+
+		assert.Equal(t, profilesState, updated.(Model).state)
+		assert.Equal(t, []string{"default", "kids"}, updated.(Model).profilesModel.profiles)
+
+	})
+
+	t.Run("stops playback and quits to restart with another profile", func(t *testing.T) {
+
+		stopped := false
+		playbackManager := mocks.MockPlaybackManagerService{
+			StopStationFunc: func() error {
+				stopped = true
+				return nil
+			},
+		}
+		model := newModel(&playbackManager)
+
+		updated, cmd := model.Update(profileSelectedMsg{name: "kids"})
+		cmds := sequenceCmds(cmd())
+		cmds[0]()
+
+		assert.True(t, stopped)
+		assert.Equal(t, "kids", updated.(Model).NextProfile())
+		assert.IsType(t, quitMsg{}, cmds[1]())
+
+	})
+
+	t.Run("goes back to search when the current profile is selected", func(t *testing.T) {
+
+		model := newModel(&mocks.MockPlaybackManagerService{})
+
+		updated, cmd := model.Update(profileSelectedMsg{name: config.DefaultProfile})
+
+		assert.Empty(t, updated.(Model).NextProfile())
+		assert.IsType(t, switchToSearchModelMsg{}, cmd())
+
+	})
+
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/epg"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/uuid"
+)
+
+// How often the program guide pane checks whether the program on air has changed.
+const programGuideTickInterval = time.Minute
+
+// How often the schedule of the playing station is fetched again.
+const programGuideRefreshInterval = 30 * time.Minute
+
+// ProgramGuideModel shows what is on now and next on the playing station,
+// for the stations whose schedule source is set in the configuration.
+// The root model feeds it every message and draws its pane above the bottom bar.
+type ProgramGuideModel struct {
+	theme   Theme
+	fetcher epg.Fetcher
+	sources map[uuid.UUID]epg.Source
+	now     func() time.Time
+
+	// nil unless the playing station has a schedule source
+	station   *common.Station
+	guide     epg.Guide
+	err       error
+	fetchedAt time.Time
+	// Incremented whenever the station changes, so that stale fetches are ignored
+	generation int
+}
+
+// NewProgramGuideModel returns a ProgramGuideModel for the given schedule sources, which does nothing if there are none.
+func NewProgramGuideModel(theme Theme, fetcher epg.Fetcher, sources []epg.Source) ProgramGuideModel {
+	m := ProgramGuideModel{
+		theme:   theme,
+		fetcher: fetcher,
+		sources: make(map[uuid.UUID]epg.Source),
+		now:     time.Now,
+	}
+	for _, source := range sources {
+		m.sources[source.StationUuid] = source
+	}
+	return m
+}
+
+// Messages
+
+type programGuideTickMsg struct {
+	generation int
+}
+
+type programGuideFetchedMsg struct {
+	generation int
+	guide      epg.Guide
+	err        error
+}
+
+// Commands
+
+func fetchProgramGuideCmd(fetcher epg.Fetcher, generation int, source epg.Source) tea.Cmd {
+	return func() tea.Msg {
+		guide, err := fetcher.Fetch(source)
+		return programGuideFetchedMsg{generation: generation, guide: guide, err: err}
+	}
+}
+
+func programGuideTickCmd(generation int) tea.Cmd {
+	return tea.Tick(programGuideTickInterval, func(t time.Time) tea.Msg {
+		return programGuideTickMsg{generation: generation}
+	})
+}
+
+// Model
+
+func (m ProgramGuideModel) Update(msg tea.Msg) (ProgramGuideModel, tea.Cmd) {
+
+	if len(m.sources) == 0 {
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case playbackStartedMsg:
+		m.generation++
+		m.guide = nil
+		m.err = nil
+		source, ok := m.sources[msg.station.StationUuid]
+		if !ok {
+			m.station = nil
+			return m, nil
+		}
+		station := msg.station
+		m.station = &station
+		return m, fetchProgramGuideCmd(m.fetcher, m.generation, source)
+	case playbackStoppedMsg:
+		if m.station == nil {
+			return m, nil
+		}
+		m.station = nil
+		m.guide = nil
+		m.err = nil
+		m.generation++
+		return m, nil
+	case programGuideFetchedMsg:
+		if msg.generation != m.generation || m.station == nil {
+			return m, nil
+		}
+		m.fetchedAt = m.now()
+		m.err = msg.err
+		// Keep the last schedule if it couldn't be fetched this time
+		if msg.err == nil {
+			m.guide = msg.guide
+		}
+		return m, programGuideTickCmd(m.generation)
+	case programGuideTickMsg:
+		if msg.generation != m.generation || m.station == nil {
+			return m, nil
+		}
+		if m.now().Sub(m.fetchedAt) >= programGuideRefreshInterval {
+			return m, fetchProgramGuideCmd(m.fetcher, m.generation, m.sources[m.station.StationUuid])
+		}
+		// Nothing to fetch: the tick only redraws the pane with the program now on air
+		return m, programGuideTickCmd(m.generation)
+	}
+
+	return m, nil
+}
+
+// View returns the program guide pane, or an empty string when there is nothing to show.
+func (m ProgramGuideModel) View() string {
+
+	if m.station == nil || (m.guide == nil && m.err == nil) {
+		return ""
+	}
+
+	if m.guide == nil {
+		return m.theme.SecondaryText.Render(fmt.Sprintf(i18n.T("epg.unavailable"), m.err.Error())) + "\n"
+	}
+
+	now, next := m.guide.At(m.now())
+
+	var v string
+	if now != nil {
+		v += m.theme.PrimaryText.Render(fmt.Sprintf(i18n.T("epg.onAir"), formatProgram(*now, true))) + "\n"
+	} else {
+		v += m.theme.SecondaryText.Render(i18n.T("epg.offAir")) + "\n"
+	}
+	if next != nil {
+		v += m.theme.SecondaryText.Render(fmt.Sprintf(i18n.T("epg.next"), formatProgram(*next, false))) + "\n"
+	}
+
+	return v
+}
+
+// Height returns the number of lines taken by the pane.
+func (m ProgramGuideModel) Height() int {
+	view := m.View()
+	if view == "" {
+		return 0
+	}
+	return lipgloss.Height(view) - 1
+}
+
+// formatProgram returns the title of a program and when it starts, and when it ends if asked and known.
+func formatProgram(program epg.Program, withEnd bool) string {
+	start := program.Start.Local().Format("15:04")
+	if withEnd && !program.End.IsZero() {
+		return fmt.Sprintf("%s (%s–%s)", program.Title, start, program.End.Local().Format("15:04"))
+	}
+	return fmt.Sprintf("%s (%s)", program.Title, start)
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/epg"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fetcherFunc adapts a function to epg.Fetcher.
+type fetcherFunc func(source epg.Source) (epg.Guide, error)
+
+func (f fetcherFunc) Fetch(source epg.Source) (epg.Guide, error) {
+	return f(source)
+}
+
+func TestProgramGuideModel(t *testing.T) {
+
+	stationUuid := uuid.MustParse("941ef6f1-0699-4821-95b1-2b678e3ff62e")
+	station := common.Station{StationUuid: stationUuid, Name: "Jazz FM"}
+	source := epg.Source{StationUuid: stationUuid, URL: "https://example.com/schedule"}
+
+	at := func(hour int) time.Time {
+		return time.Date(2024, 3, 10, hour, 0, 0, 0, time.Local)
+	}
+	guide := epg.Guide{
+		{Title: "Morning Show", Start: at(8), End: at(10)},
+		{Title: "Jazz Hour", Start: at(10)},
+	}
+
+	newModel := func(fetcher epg.Fetcher) ProgramGuideModel {
+		model := NewProgramGuideModel(Theme{}, fetcher, []epg.Source{source})
+		model.now = func() time.Time { return at(9) }
+		return model
+	}
+
+	t.Run("does nothing without sources", func(t *testing.T) {
+
+		model := NewProgramGuideModel(Theme{}, nil, nil)
+
+		model, cmd := model.Update(playbackStartedMsg{station: station})
+
+		assert.Nil(t, cmd)
+		assert.Equal(t, 0, model.Height())
+
+	})
+
+	t.Run("ignores stations without a source", func(t *testing.T) {
+
+		model := newModel(nil)
+
+		model, cmd := model.Update(playbackStartedMsg{station: common.Station{StationUuid: uuid.New()}})
+
+		assert.Nil(t, cmd)
+		assert.Empty(t, model.View())
+
+	})
+
+	t.Run("shows the programs on now and next", func(t *testing.T) {
+
+		model := newModel(fetcherFunc(func(s epg.Source) (epg.Guide, error) {
+			assert.Equal(t, source, s)
+			return guide, nil
+		}))
+
+		model, cmd := model.Update(playbackStartedMsg{station: station})
+		assert.Empty(t, model.View())
+
+		model, cmd = model.Update(cmd())
+		assert.NotNil(t, cmd)
+		assert.Contains(t, model.View(), "On air: Morning Show (08:00–10:00)")
+		assert.Contains(t, model.View(), "Next: Jazz Hour (10:00)")
+		assert.Equal(t, 2, model.Height())
+
+		model, _ = model.Update(playbackStoppedMsg{})
+		assert.Equal(t, 0, model.Height())
+
+	})
+
+	t.Run("keeps the last schedule when it can't be fetched again", func(t *testing.T) {
+
+		model := newModel(nil)
+		model, _ = model.Update(playbackStartedMsg{station: station})
+		model, _ = model.Update(programGuideFetchedMsg{generation: model.generation, guide: guide})
+
+		model, _ = model.Update(programGuideFetchedMsg{generation: model.generation, err: errors.New("timeout")})
+
+		assert.Contains(t, model.View(), "Morning Show")
+
+	})
+
+	t.Run("shows why the schedule couldn't be fetched", func(t *testing.T) {
+
+		model := newModel(nil)
+		model, _ = model.Update(playbackStartedMsg{station: station})
+		model, _ = model.Update(programGuideFetchedMsg{generation: model.generation, err: errors.New("timeout")})
+
+		assert.Contains(t, model.View(), "Program guide unavailable: timeout")
+
+	})
+
+	t.Run("ignores schedules of a previous station", func(t *testing.T) {
+
+		model := newModel(nil)
+		model, _ = model.Update(playbackStartedMsg{station: station})
+		generation := model.generation
+		model, _ = model.Update(playbackStartedMsg{station: station})
+
+		model, _ = model.Update(programGuideFetchedMsg{generation: generation, guide: guide})
+
+		assert.Empty(t, model.View())
+
+	})
+
+	t.Run("fetches the schedule again once it is old", func(t *testing.T) {
+
+		fetched := 0
+		model := newModel(fetcherFunc(func(s epg.Source) (epg.Guide, error) {
+			fetched++
+			return guide, nil
+		}))
+		model, cmd := model.Update(playbackStartedMsg{station: station})
+		model, _ = model.Update(cmd())
+
+		_, cmd = model.Update(programGuideTickMsg{generation: model.generation})
+		assert.Equal(t, 1, fetched)
+		assert.NotNil(t, cmd)
+
+		model.now = func() time.Time { return at(9).Add(programGuideRefreshInterval) }
+		_, cmd = model.Update(programGuideTickMsg{generation: model.generation})
+		cmd()
+		assert.Equal(t, 2, fetched)
+
+	})
+
+}
@@ -0,0 +1,280 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/icy"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/secrets"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stationQueue lists the stations to play next, in order.
+// It outlives the search results it was filled from.
+type stationQueue struct {
+	stations []common.Station
+}
+
+func newStationQueue() *stationQueue {
+	return &stationQueue{}
+}
+
+// add appends station to the queue. It returns false if the station was already queued.
+func (q *stationQueue) add(station common.Station) bool {
+	for _, queued := range q.stations {
+		if queued.StationUuid == station.StationUuid {
+			return false
+		}
+	}
+	q.stations = append(q.stations, station)
+	return true
+}
+
+// insert puts station back at index, or at the end if the queue got shorter.
+// It returns false if the station was queued again in the meantime.
+func (q *stationQueue) insert(index int, station common.Station) bool {
+	for _, queued := range q.stations {
+		if queued.StationUuid == station.StationUuid {
+			return false
+		}
+	}
+	if index > len(q.stations) {
+		index = len(q.stations)
+	}
+	q.stations = append(q.stations[:index], append([]common.Station{station}, q.stations[index:]...)...)
+	return true
+}
+
+// pop removes the first station from the queue and returns it.
+func (q *stationQueue) pop() (common.Station, bool) {
+	if len(q.stations) == 0 {
+		return common.Station{}, false
+	}
+	station := q.stations[0]
+	q.stations = q.stations[1:]
+	return station, true
+}
+
+// remove removes the station at index from the queue and returns it.
+func (q *stationQueue) remove(index int) common.Station {
+	station := q.stations[index]
+	q.stations = append(q.stations[:index:index], q.stations[index+1:]...)
+	return station
+}
+
+// swap exchanges the positions of two stations in the queue.
+func (q *stationQueue) swap(i, j int) {
+	q.stations[i], q.stations[j] = q.stations[j], q.stations[i]
+}
+
+func (q *stationQueue) len() int {
+	return len(q.stations)
+}
+
+// Messages
+
+type closeQueueMsg struct{}
+
+// playQueuedStationMsg plays a station taken out of the queue.
+type playQueuedStationMsg struct {
+	station common.Station
+}
+
+// advanceQueueMsg plays the next station in the queue, if any.
+type advanceQueueMsg struct{}
+
+// queueDwellMsg moves on to the next station in the queue once the current one has played for long enough.
+// It's ignored if another station was played or stopped in the meantime, as told by generation.
+type queueDwellMsg struct {
+	generation int
+}
+
+// queuedStationFailedMsg reports a queued station that couldn't be played, so that the next one is played instead.
+type queuedStationFailedMsg struct {
+	err error
+}
+
+// Commands
+
+func advanceQueueCmd() tea.Msg {
+	return advanceQueueMsg{}
+}
+
+func queueDwellCmd(dwell time.Duration, generation int) tea.Cmd {
+	return tea.Tick(dwell, func(t time.Time) tea.Msg {
+		return queueDwellMsg{generation: generation}
+	})
+}
+
+// playQueuedStationCmd plays a station taken out of the queue, reporting failures as queuedStationFailedMsg.
+func playQueuedStationCmd(
+	playbackManager playback.PlaybackManagerService,
+	contentFilter filter.ContentFilter,
+	prober icy.ProberService,
+	credentials secrets.Store,
+	variants storage.StreamVariantStore,
+	station common.Station,
+	volume int,
+) tea.Cmd {
+	play := playStationCmd(playbackManager, contentFilter, prober, credentials, variants, station, volume)
+	return func() tea.Msg {
+		msg := play()
+		if failed, ok := msg.(nonFatalError); ok {
+			return queuedStationFailedMsg{err: failed.err}
+		}
+		return msg
+	}
+}
+
+func updateCommandsForQueue() tea.Msg {
+	return bottomBarUpdateMsg{
+		commands: []string{
+			i18n.T("commands.back"),
+			i18n.T("commands.move"),
+			i18n.T("commands.playNow"),
+			i18n.T("commands.columnOrder"),
+			i18n.T("commands.dequeue"),
+			i18n.T("commands.undo"),
+		},
+	}
+}
+
+// Model
+
+// QueueModel lists the queued stations, to reorder them, remove them or play one right away.
+type QueueModel struct {
+	theme      Theme
+	queue      *stationQueue
+	labelStore storage.LabelStore
+	cursor     int
+}
+
+func NewQueueModel(theme Theme, queue *stationQueue, labelStore storage.LabelStore) QueueModel {
+	return QueueModel{
+		theme:      theme,
+		queue:      queue,
+		labelStore: labelStore,
+	}
+}
+
+func (m QueueModel) Init() tea.Cmd {
+	return updateCommandsForQueue
+}
+
+func (m QueueModel) Update(msg tea.Msg) (QueueModel, tea.Cmd) {
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc", "Q", "q":
+		return m, func() tea.Msg {
+			return closeQueueMsg{}
+		}
+	case "u":
+		return m, undoCmd
+	}
+
+	if m.queue.len() == 0 {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < m.queue.len()-1 {
+			m.cursor++
+		}
+	case "shift+up", "K":
+		if m.cursor > 0 {
+			m.queue.swap(m.cursor, m.cursor-1)
+			m.cursor--
+		}
+	case "shift+down", "J":
+		if m.cursor < m.queue.len()-1 {
+			m.queue.swap(m.cursor, m.cursor+1)
+			m.cursor++
+		}
+	case "d", "delete":
+		index := m.cursor
+		station := m.queue.remove(index)
+		if m.cursor > 0 && m.cursor >= m.queue.len() {
+			m.cursor--
+		}
+		return m, func() tea.Msg {
+			return undoableMsg{
+				text: i18n.Tf("queue.removed", stationDisplayName(m.labelStore, station)),
+				undo: func() tea.Msg {
+					return queuedStationRestoredMsg{index: index, station: station}
+				},
+			}
+		}
+	case "enter":
+		station := m.queue.remove(m.cursor)
+		return m, tea.Batch(
+			func() tea.Msg {
+				return closeQueueMsg{}
+			},
+			func() tea.Msg {
+				return playQueuedStationMsg{station: station}
+			},
+		)
+	}
+
+	return m, nil
+}
+
+func (m QueueModel) View() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.Tf("queue.title", m.queue.len())) + "\n\n"
+
+	if m.queue.len() == 0 {
+		return v + m.theme.TertiaryText.Render(i18n.T("queue.empty")) + "\n"
+	}
+
+	for i, station := range m.queue.stations {
+		item := fmt.Sprintf("%2d. %s", i+1, displayText(stationDisplayName(m.labelStore, station)))
+		switch {
+		case m.theme.Accessible && i == m.cursor:
+			v += ">>> " + item + "\n"
+		case m.theme.Accessible:
+			v += "    " + item + "\n"
+		case i == m.cursor:
+			v += m.theme.PrimaryBlock.Render(item) + "\n"
+		default:
+			v += m.theme.Text.Render(" "+item) + "\n"
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newQueueStationsModel(playbackManager *mocks.MockPlaybackManagerService, stations []common.Station, queue *stationQueue, dwell time.Duration) StationsModel {
+	model := NewStationsModel(
+		Theme{},
+		&mocks.MockRadioBrowserService{},
+		playbackManager,
+		&mocks.MockLabelStore{},
+		&mocks.MockBookmarkStore{},
+		&mocks.MockReportStore{},
+		filter.ContentFilter{},
+		stations,
+		config.DefaultStationColumns(),
+		nil,
+		stationPageKey{},
+		false,
+	)
+	model.SetQueue(queue, dwell)
+	return model
+}
+
+func TestStationQueue(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	rock := common.Station{StationUuid: uuid.New(), Name: "Rock Antenne"}
+	news := common.Station{StationUuid: uuid.New(), Name: "News 24"}
+
+	t.Run("plays stations in the order they were added, once each", func(t *testing.T) {
+
+		queue := newStationQueue()
+
+		assert.True(t, queue.add(jazz))
+		assert.True(t, queue.add(rock))
+		assert.False(t, queue.add(jazz))
+		assert.Equal(t, 2, queue.len())
+
+		station, ok := queue.pop()
+		assert.True(t, ok)
+		assert.Equal(t, jazz, station)
+
+		station, ok = queue.pop()
+		assert.True(t, ok)
+		assert.Equal(t, rock, station)
+
+		_, ok = queue.pop()
+		assert.False(t, ok)
+
+	})
+
+	t.Run("reorders and removes stations", func(t *testing.T) {
+
+		queue := newStationQueue()
+		queue.add(jazz)
+		queue.add(rock)
+		queue.add(news)
+
+		queue.swap(0, 2)
+		assert.Equal(t, []common.Station{news, rock, jazz}, queue.stations)
+
+		assert.Equal(t, rock, queue.remove(1))
+		assert.Equal(t, []common.Station{news, jazz}, queue.stations)
+
+	})
+
+}
+
+func TestQueueModel(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	rock := common.Station{StationUuid: uuid.New(), Name: "Rock Antenne"}
+
+	newQueue := func() *stationQueue {
+		queue := newStationQueue()
+		queue.add(jazz)
+		queue.add(rock)
+		return queue
+	}
+
+	t.Run("moves the highlighted station down the queue", func(t *testing.T) {
+
+		queue := newQueue()
+		model := NewQueueModel(Theme{}, queue, &mocks.MockLabelStore{})
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("J")})
+
+		assert.Equal(t, []common.Station{rock, jazz}, queue.stations)
+		assert.Equal(t, 1, model.cursor)
+
+	})
+
+	t.Run("removes the highlighted station", func(t *testing.T) {
+
+		queue := newQueue()
+		model := NewQueueModel(Theme{}, queue, &mocks.MockLabelStore{})
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+
+		assert.Equal(t, []common.Station{jazz}, queue.stations)
+		assert.Equal(t, 0, model.cursor)
+
+	})
+
+	t.Run("plays the highlighted station right away", func(t *testing.T) {
+
+		queue := newQueue()
+		model := NewQueueModel(Theme{}, queue, &mocks.MockLabelStore{})
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		msgs := collectMsgs(cmd)
+		assert.Contains(t, msgs, closeQueueMsg{})
+		assert.Contains(t, msgs, playQueuedStationMsg{station: rock})
+		assert.Equal(t, []common.Station{jazz}, queue.stations)
+
+	})
+
+	t.Run("lists the queued stations", func(t *testing.T) {
+
+		model := NewQueueModel(Theme{}, newQueue(), &mocks.MockLabelStore{})
+
+		assert.Contains(t, model.View(), " 1. Jazz FM")
+		assert.Contains(t, model.View(), " 2. Rock Antenne")
+
+	})
+
+}
+
+func TestStationsModel_Queue(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	rock := common.Station{StationUuid: uuid.New(), Name: "Rock Antenne"}
+
+	t.Run("adds the highlighted station to the queue", func(t *testing.T) {
+
+		queue := newStationQueue()
+		model := newQueueStationsModel(&mocks.MockPlaybackManagerService{}, []common.Station{jazz, rock}, queue, 0)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+
+		assert.Equal(t, []common.Station{jazz}, queue.stations)
+		assert.Equal(t, toastSuccess, cmd().(toastMsg).kind)
+
+	})
+
+	t.Run("plays the next queued station", func(t *testing.T) {
+
+		var played []common.Station
+		playbackManager := mocks.MockPlaybackManagerService{
+			PlayStationFunc: func(station common.Station, volume int) error {
+				played = append(played, station)
+				return nil
+			},
+		}
+		queue := newStationQueue()
+		queue.add(rock)
+		model := newQueueStationsModel(&playbackManager, []common.Station{jazz}, queue, 0)
+
+		newModel, cmd := model.Update(advanceQueueMsg{})
+
+		assert.Contains(t, collectMsgs(cmd), playbackStartedMsg{station: rock})
+		assert.Equal(t, []common.Station{rock}, played)
+		assert.Equal(t, rock, *newModel.(StationsModel).bufferingStation)
+		assert.Equal(t, 0, queue.len())
+
+	})
+
+	t.Run("moves on to the next queued station after the dwell time", func(t *testing.T) {
+
+		playbackManager := mocks.MockPlaybackManagerService{IsPlayingResult: true}
+		queue := newStationQueue()
+		queue.add(rock)
+		model := newQueueStationsModel(&playbackManager, []common.Station{jazz}, queue, time.Millisecond)
+
+		newModel, _ := model.Update(playbackStartedMsg{station: jazz})
+		generation := newModel.(StationsModel).playGeneration
+
+		// A tick of a station that was since replaced is ignored
+		stale, _ := newModel.Update(queueDwellMsg{generation: generation - 1})
+		assert.Nil(t, stale.(StationsModel).bufferingStation)
+
+		newModel, _ = newModel.Update(queueDwellMsg{generation: generation})
+		assert.Equal(t, rock, *newModel.(StationsModel).bufferingStation)
+
+	})
+
+	t.Run("skips a queued station that can't be played", func(t *testing.T) {
+
+		playbackManager := mocks.MockPlaybackManagerService{
+			PlayStationFunc: func(station common.Station, volume int) error {
+				return errors.New("no such stream")
+			},
+		}
+		queue := newStationQueue()
+		queue.add(jazz)
+		queue.add(rock)
+		model := newQueueStationsModel(&playbackManager, []common.Station{jazz}, queue, 0)
+
+		newModel, cmd := model.Update(advanceQueueMsg{})
+		failed := collectMsgs(cmd)
+		assert.Contains(t, failed, queuedStationFailedMsg{err: errors.New("no such stream")})
+
+		_, cmd = newModel.Update(queuedStationFailedMsg{err: errors.New("no such stream")})
+		assert.Contains(t, collectMsgs(cmd), errorBannerMsg{err: errors.New("no such stream")})
+		assert.Contains(t, collectMsgs(cmd), advanceQueueMsg{})
+
+	})
+
+}
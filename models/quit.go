@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// How long quitting waits to be asked again, once it asked to be confirmed.
+const quitConfirmationTimeout = toastDuration
+
+// Messages
+
+// quitMsg asks to quit, stopping the station being played first if stopPlayback is set.
+// Unless confirmed is set, it may have to be asked again while a station is playing or being recorded.
+type quitMsg struct {
+	stopPlayback bool
+	confirmed    bool
+}
+
+func quitCmd() tea.Msg {
+	return quitMsg{}
+}
+
+// stopAndQuitCmd quits once the station being played is stopped.
+func stopAndQuitCmd() tea.Msg {
+	return quitMsg{stopPlayback: true}
+}
+
+// NewTerminateMsg asks RadioGoGo to quit right away, as when it's sent SIGTERM,
+// stopping playback and finishing the recordings in progress first.
+func NewTerminateMsg() tea.Msg {
+	return quitMsg{stopPlayback: true, confirmed: true}
+}
+
+// quitConfirmationExpiredMsg stops waiting for quitting to be asked again, unless it was asked since, as told by id.
+type quitConfirmationExpiredMsg struct {
+	id int
+}
+
+// quitConfirmation asks to quit twice in a row while a station is playing or being recorded,
+// so that a stray keypress doesn't end a recording.
+type quitConfirmation struct {
+	enabled bool
+	// Set once quitting was asked for, until it times out
+	armed bool
+	id    int
+}
+
+// confirmQuit returns a command asking to quit again, or nil if msg can go ahead and quit.
+func (m *Model) confirmQuit(msg quitMsg) tea.Cmd {
+	if msg.confirmed || !m.quitConfirmation.enabled || m.quitConfirmation.armed {
+		return nil
+	}
+	var text string
+	switch {
+	case m.headerModel.recordings > 0:
+		text = i18n.Tf("quit.confirmRecording", m.headerModel.recordings)
+	case m.playingStation.StationUuid != uuid.Nil:
+		text = i18n.T("quit.confirmPlaying")
+	default:
+		return nil
+	}
+	m.quitConfirmation.armed = true
+	m.quitConfirmation.id++
+	id := m.quitConfirmation.id
+	return tea.Batch(
+		showToastCmd(text, toastError),
+		tea.Tick(quitConfirmationTimeout, func(time.Time) tea.Msg {
+			return quitConfirmationExpiredMsg{id: id}
+		}),
+	)
+}
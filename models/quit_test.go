@@ -0,0 +1,123 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModel_QuitConfirmation(t *testing.T) {
+
+	newModel := func(confirm bool, playbackManager *mocks.MockPlaybackManagerService) Model {
+		cfg := config.Config{}
+		cfg.Quit.Confirm = confirm
+		model := NewModel(cfg, &mocks.MockRadioBrowserService{}, playbackManager, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		model.playingStation = common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+		return model
+	}
+
+	t.Run("asks to quit again while a station is playing", func(t *testing.T) {
+
+		model := newModel(true, &mocks.MockPlaybackManagerService{})
+
+		updated, cmd := model.Update(quitMsg{})
+
+		assert.True(t, updated.(Model).quitConfirmation.armed)
+		assert.IsType(t, tea.BatchMsg{}, cmd())
+
+		_, cmd = updated.Update(quitMsg{})
+
+		assert.IsType(t, tea.QuitMsg{}, cmd())
+
+	})
+
+	t.Run("asks to quit again while recording, even once playback is stopped", func(t *testing.T) {
+
+		model := newModel(true, &mocks.MockPlaybackManagerService{})
+		model.playingStation = common.Station{}
+		model.headerModel.recordings = 1
+
+		updated, _ := model.Update(quitMsg{})
+
+		assert.True(t, updated.(Model).quitConfirmation.armed)
+
+	})
+
+	t.Run("asks again once the confirmation expired", func(t *testing.T) {
+
+		model := newModel(true, &mocks.MockPlaybackManagerService{})
+
+		updated, _ := model.Update(quitMsg{})
+		updated, _ = updated.Update(quitConfirmationExpiredMsg{id: updated.(Model).quitConfirmation.id})
+
+		assert.False(t, updated.(Model).quitConfirmation.armed)
+
+	})
+
+	t.Run("quits right away when nothing is playing or recording", func(t *testing.T) {
+
+		model := newModel(true, &mocks.MockPlaybackManagerService{})
+		model.playingStation = common.Station{}
+
+		_, cmd := model.Update(quitMsg{})
+
+		assert.IsType(t, tea.QuitMsg{}, cmd())
+
+	})
+
+	t.Run("quits right away unless asked to confirm", func(t *testing.T) {
+
+		model := newModel(false, &mocks.MockPlaybackManagerService{})
+
+		_, cmd := model.Update(quitMsg{})
+
+		assert.IsType(t, tea.QuitMsg{}, cmd())
+
+	})
+
+	t.Run("stops playback and quits when terminated, without asking", func(t *testing.T) {
+
+		stopped := false
+		playbackManager := mocks.MockPlaybackManagerService{
+			StopStationFunc: func() error {
+				stopped = true
+				return nil
+			},
+		}
+		model := newModel(true, &playbackManager)
+
+		_, cmd := model.Update(NewTerminateMsg())
+		cmds := sequenceCmds(cmd())
+		cmds[0]()
+
+		assert.True(t, stopped)
+		assert.IsType(t, tea.QuitMsg{}, cmds[len(cmds)-1]())
+
+	})
+
+}
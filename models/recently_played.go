@@ -0,0 +1,173 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How many of the stations played lately the search form lists, one per digit key
+const recentlyPlayedShown = 9
+
+// recentlyPlayedLoadedMsg lists the stations played lately on the search form.
+type recentlyPlayedLoadedMsg struct {
+	stations []common.Station
+}
+
+// recentlyPlayedSelectedMsg replays a station listed on the search form.
+type recentlyPlayedSelectedMsg struct {
+	station common.Station
+}
+
+// historyForgetRequestedMsg asks to remove a station listed on the search form from the history.
+type historyForgetRequestedMsg struct {
+	station common.Station
+}
+
+// historyClearRequestedMsg asks to clear the history.
+type historyClearRequestedMsg struct{}
+
+// historyRestoredMsg tells that the entries removed from the history were put back.
+type historyRestoredMsg struct{}
+
+// recentlyPlayedIndex returns which of the stations played lately key replays (-1 for none listed under
+// its number) and whether key is a replay shortcut: alt+1 to alt+9 anywhere, 1 to 9 unless a text
+// field is being typed in.
+func (m SearchModel) recentlyPlayedIndex(key string) (int, bool) {
+	digit := strings.TrimPrefix(key, "alt+")
+	if digit == key && m.textFieldFocused() {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digit)
+	if err != nil || len(digit) != 1 || n < 1 {
+		return 0, false
+	}
+	if n > len(m.recentlyPlayed) {
+		return -1, true
+	}
+	return n - 1, true
+}
+
+// forgetRecentlyPlayed removes what key, pressed after alt+x, tells from the history: the station listed
+// under its number, or every entry if it's alt+x again. Any other key does nothing.
+func (m SearchModel) forgetRecentlyPlayed(key string) (SearchModel, tea.Cmd) {
+	if key == "alt+x" {
+		m.recentlyPlayed = nil
+		return m, func() tea.Msg {
+			return historyClearRequestedMsg{}
+		}
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(key, "alt+"))
+	if err != nil || n < 1 || n > len(m.recentlyPlayed) {
+		return m, nil
+	}
+	station := m.recentlyPlayed[n-1]
+	m.recentlyPlayed = append(m.recentlyPlayed[:n-1:n-1], m.recentlyPlayed[n:]...)
+	return m, func() tea.Msg {
+		return historyForgetRequestedMsg{station: station}
+	}
+}
+
+// recentlyPlayedView renders the stations played lately, numbered by the key replaying them.
+func (m SearchModel) recentlyPlayedView() string {
+	if len(m.recentlyPlayed) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\n" + m.theme.SecondaryText.Render(i18n.T("search.recentlyPlayed")))
+	for i, station := range m.recentlyPlayed {
+		// Numbered names stay within the search form
+		name := truncateText(displayText(station.Name), searchFormWidth-2)
+		b.WriteString(fmt.Sprintf("\n%s %s", m.theme.PrimaryText.Render(strconv.Itoa(i+1)), m.theme.Text.Render(name)))
+	}
+	return b.String()
+}
+
+// Commands
+
+// loadRecentlyPlayedCmd reads the stations played lately for the search form.
+// Nothing is listed if the history can't be read.
+func loadRecentlyPlayedCmd(history storage.HistoryStore) tea.Cmd {
+	if history == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		stations, err := recentStations(history)
+		if err != nil || len(stations) == 0 {
+			return nil
+		}
+		if len(stations) > recentlyPlayedShown {
+			stations = stations[:recentlyPlayedShown]
+		}
+		return recentlyPlayedLoadedMsg{stations: stations}
+	}
+}
+
+// forgetStationCmd removes every session of the station with the given name from the history,
+// which can be undone by restoring them.
+func forgetStationCmd(history storage.HistoryStore, station common.Station, name string) tea.Cmd {
+	if history == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		removed, err := history.Forget(station.StationUuid)
+		if err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return undoableMsg{
+			text: i18n.Tf("history.forgotten", name),
+			undo: restoreHistoryCmd(history, removed),
+		}
+	}
+}
+
+// clearHistoryCmd removes every entry from the history, which can be undone by restoring them.
+func clearHistoryCmd(history storage.HistoryStore) tea.Cmd {
+	if history == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		removed, err := history.Clear()
+		if err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return undoableMsg{
+			text: i18n.Tf("history.cleared", removed.Len()),
+			undo: restoreHistoryCmd(history, removed),
+		}
+	}
+}
+
+func restoreHistoryCmd(history storage.HistoryStore, removed storage.RemovedHistory) tea.Cmd {
+	return func() tea.Msg {
+		if err := history.Restore(removed); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return historyRestoredMsg{}
+	}
+}
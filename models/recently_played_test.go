@@ -0,0 +1,223 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRecentlyPlayedCmd(t *testing.T) {
+
+	t.Run("lists up to recentlyPlayedShown stations, latest first", func(t *testing.T) {
+		var played []common.Station
+		for i := 0; i < recentlyPlayedShown+3; i++ {
+			played = append(played, common.Station{StationUuid: uuid.New()})
+		}
+		msg := loadRecentlyPlayedCmd(historyOf(played...))()
+		assert.Equal(t, recentlyPlayedLoadedMsg{stations: played[:recentlyPlayedShown]}, msg)
+	})
+
+	t.Run("lists nothing if nothing was played or the history can't be read", func(t *testing.T) {
+		assert.Nil(t, loadRecentlyPlayedCmd(nil))
+		assert.Nil(t, loadRecentlyPlayedCmd(historyOf())())
+		failing := &mocks.MockHistoryStore{
+			RecentFunc: func(limit int) ([]storage.HistoryEntry, error) {
+				return nil, errors.New("corrupt")
+			},
+		}
+		assert.Nil(t, loadRecentlyPlayedCmd(failing)())
+	})
+}
+
+func TestSearchModel_RecentlyPlayed(t *testing.T) {
+
+	first := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	second := common.Station{StationUuid: uuid.New(), Name: "Radio Paradise"}
+
+	newModel := func() SearchModel {
+		model := NewSearchModel(Theme{}, common.StationFilter{})
+		newModel, _ := model.Update(recentlyPlayedLoadedMsg{stations: []common.Station{first, second}})
+		return newModel.(SearchModel)
+	}
+
+	t.Run("lists the stations numbered", func(t *testing.T) {
+		view := newModel().View()
+		assert.Contains(t, view, "1 Jazz FM")
+		assert.Contains(t, view, "2 Radio Paradise")
+	})
+
+	t.Run("replays a station with alt and its number", func(t *testing.T) {
+		_, cmd := newModel().Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2"), Alt: true})
+		assert.Equal(t, recentlyPlayedSelectedMsg{station: second}, cmd())
+	})
+
+	t.Run("types digits in the name field, replays with them elsewhere", func(t *testing.T) {
+		model := newModel()
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")})
+		assert.Equal(t, "1", newModel.(SearchModel).inputModel.Value())
+
+		model.inputModel.Blur()
+		model.querySelector.Focus()
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")})
+		assert.Equal(t, recentlyPlayedSelectedMsg{station: first}, cmd())
+	})
+
+	t.Run("forgets a station with alt+x and its number", func(t *testing.T) {
+		model, cmd := newModel().Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x"), Alt: true})
+		assert.Equal(t, toastMsg{text: "1-9: forget that station played lately, alt+x: clear the history, any other key: cancel", kind: toastInfo}, cmd())
+
+		model, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")})
+		assert.Equal(t, historyForgetRequestedMsg{station: first}, cmd())
+		assert.Equal(t, []common.Station{second}, model.(SearchModel).recentlyPlayed)
+
+		// The next digit replays again
+		_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1"), Alt: true})
+		assert.Equal(t, recentlyPlayedSelectedMsg{station: second}, cmd())
+	})
+
+	t.Run("clears the history with alt+x twice", func(t *testing.T) {
+		model, _ := newModel().Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x"), Alt: true})
+		model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x"), Alt: true})
+		assert.Equal(t, historyClearRequestedMsg{}, cmd())
+		assert.Empty(t, model.(SearchModel).recentlyPlayed)
+	})
+
+	t.Run("forgets nothing after alt+x and another key", func(t *testing.T) {
+		model, _ := newModel().Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x"), Alt: true})
+		model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("3")})
+		assert.Nil(t, cmd)
+		assert.Equal(t, []common.Station{first, second}, model.(SearchModel).recentlyPlayed)
+		assert.False(t, model.(SearchModel).forgetting)
+	})
+
+	t.Run("ignores numbers without a station", func(t *testing.T) {
+		newModel, cmd := newModel().Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("3"), Alt: true})
+		assert.Nil(t, cmd)
+		assert.Empty(t, newModel.(SearchModel).inputModel.Value())
+	})
+}
+
+func TestModel_RecentlyPlayedSelected(t *testing.T) {
+
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	model := newStartupTestModel("", historyOf(station), &mocks.MockSearchStore{})
+
+	_, cmd := model.Update(recentlyPlayedSelectedMsg{station: station})
+
+	// Whatever plays is stopped first, then the station is listed alone and played
+	cmds := sequenceCmds(cmd())
+	msg := cmds[len(cmds)-1]().(switchToStationsModelMsg)
+	assert.True(t, msg.autoplay)
+	assert.Equal(t, []common.Station{station}, msg.stations)
+}
+
+func TestModel_ForgetHistory(t *testing.T) {
+
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	removed := storage.RemovedHistory{}
+
+	// undo records the undoable msg on model and undoes it, returning what undoing returns
+	undo := func(model Model, msg tea.Msg) tea.Msg {
+		model, toast, _ := model.updateUndo(msg)
+		assert.Equal(t, toastMsg{text: "Removed Jazz FM from the history · Undo (u)", kind: toastSuccess}, toast)
+		_, _, cmd := model.updateUndo(undoRequestedMsg{})
+		return cmd()
+	}
+
+	t.Run("removes a station from the history, which can be undone", func(t *testing.T) {
+
+		var forgotten uuid.UUID
+		restored := false
+		history := historyOf(station)
+		history.ForgetFunc = func(stationUuid uuid.UUID) (storage.RemovedHistory, error) {
+			forgotten = stationUuid
+			return removed, nil
+		}
+		history.RestoreFunc = func(r storage.RemovedHistory) error {
+			restored = true
+			return nil
+		}
+		model := newStartupTestModel("", history, &mocks.MockSearchStore{})
+
+		_, cmd := model.Update(historyForgetRequestedMsg{station: station})
+		cmds := sequenceCmds(cmd())
+		msg := cmds[0]()
+		assert.Equal(t, station.StationUuid, forgotten)
+		assert.IsType(t, undoableMsg{}, msg)
+		assert.Equal(t, recentlyPlayedLoadedMsg{stations: []common.Station{station}}, cmds[1]())
+
+		assert.Equal(t, historyRestoredMsg{}, undo(model, msg))
+		assert.True(t, restored)
+
+		// The stations played lately are listed again
+		_, cmd = model.Update(historyRestoredMsg{})
+		assert.Equal(t, recentlyPlayedLoadedMsg{stations: []common.Station{station}}, cmd())
+
+	})
+
+	t.Run("clears the history, which can be undone", func(t *testing.T) {
+
+		cleared, restored := false, false
+		history := historyOf(station)
+		history.ClearFunc = func() (storage.RemovedHistory, error) {
+			cleared = true
+			return removed, nil
+		}
+		history.RestoreFunc = func(r storage.RemovedHistory) error {
+			restored = true
+			return nil
+		}
+		model := newStartupTestModel("", history, &mocks.MockSearchStore{})
+
+		_, cmd := model.Update(historyClearRequestedMsg{})
+		msg := cmd()
+		assert.True(t, cleared)
+		undoable, ok := msg.(undoableMsg)
+		if assert.True(t, ok) {
+			assert.Equal(t, "Cleared the history (0 entries)", undoable.text)
+			assert.Equal(t, historyRestoredMsg{}, undoable.undo())
+			assert.True(t, restored)
+		}
+
+	})
+
+	t.Run("tells when the history can't be changed", func(t *testing.T) {
+
+		history := historyOf(station)
+		history.ClearFunc = func() (storage.RemovedHistory, error) {
+			return storage.RemovedHistory{}, errors.New("read-only")
+		}
+		model := newStartupTestModel("", history, &mocks.MockSearchStore{})
+
+		_, cmd := model.Update(historyClearRequestedMsg{})
+		assert.Equal(t, nonFatalError{stopPlayback: false, err: errors.New("read-only")}, cmd())
+
+	})
+
+}
@@ -0,0 +1,287 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/recording"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// How often the scheduled recordings are checked.
+const recordingCheckInterval = 15 * time.Second
+
+// recorder is a recording in progress, as started by playback.Record.
+type recorder interface {
+	Stop()
+	Done() <-chan struct{}
+	// Err returns the limit the recording stopped at, if any.
+	Err() error
+}
+
+// recordingKey identifies an occurrence of a scheduled recording.
+type recordingKey struct {
+	stationUuid uuid.UUID
+	start       int64
+}
+
+type activeRecording struct {
+	recorder recorder
+	name     string
+	end      time.Time
+}
+
+// recordingScheduler starts and stops the scheduled recordings, independently of what's being played.
+// It's safe for use by concurrent commands.
+type recordingScheduler struct {
+	mu        sync.Mutex
+	entries   []recording.Entry
+	directory string
+	// sidecar is the format of the files logging the tracks of the recordings
+	sidecar recording.SidecarFormat
+	// split saves each track to its own file, leaving out those shorter than minTrackLength
+	split          bool
+	minTrackLength time.Duration
+	// encodings tell which recordings are re-encoded, and to what
+	encodings recording.Encodings
+	// limits stop the recordings before they fill the disk
+	limits  recording.Limits
+	browser api.RadioBrowserService
+	record  func(station common.Station, path string, options playback.RecordOptions) (recorder, error)
+	active  map[recordingKey]activeRecording
+	// Occurrences that failed to start, reported once and retried silently
+	failed map[recordingKey]bool
+	// Occurrences that reached their maximum size or duration, which aren't restarted
+	finished map[recordingKey]bool
+
+	now func() time.Time
+}
+
+func newRecordingScheduler(entries []recording.Entry, directory string, sidecar recording.SidecarFormat, browser api.RadioBrowserService) *recordingScheduler {
+	return &recordingScheduler{
+		entries:   entries,
+		directory: directory,
+		sidecar:   sidecar,
+		browser:   browser,
+		record: func(station common.Station, path string, options playback.RecordOptions) (recorder, error) {
+			return playback.Record(station, path, options)
+		},
+		active:   make(map[recordingKey]activeRecording),
+		failed:   make(map[recordingKey]bool),
+		finished: make(map[recordingKey]bool),
+		now:      time.Now,
+	}
+}
+
+// add schedules entry, dropping the one-off recordings that are over.
+// It returns the entries to persist.
+func (s *recordingScheduler) add(entry recording.Entry) []recording.Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entries := make([]recording.Entry, 0, len(s.entries)+1)
+	for _, existing := range s.entries {
+		if !existing.Expired(now) {
+			entries = append(entries, existing)
+		}
+	}
+	s.entries = append(entries, entry)
+	return s.entries
+}
+
+// run stops the recordings that are over and starts those that are due,
+// restarting those whose station hung up early.
+// It returns how many recordings are in progress, a warning if one stopped at its limits,
+// and the first error met starting one.
+func (s *recordingScheduler) run() (int, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var stopped string
+	for key, active := range s.active {
+		select {
+		case <-active.recorder.Done():
+			delete(s.active, key)
+			if err := active.recorder.Err(); err != nil {
+				if stopped == "" {
+					stopped = i18n.Tf("recording.stopped", active.name, err)
+				}
+				if errors.Is(err, recording.ErrDiskSpaceLow) {
+					// Retried silently, in case some space is freed
+					s.failed[key] = true
+				} else {
+					s.finished[key] = true
+				}
+			}
+			continue
+		default:
+		}
+		if !now.Before(active.end) {
+			active.recorder.Stop()
+			delete(s.active, key)
+		}
+	}
+
+	var firstErr error
+	due := make(map[recordingKey]bool)
+	for _, entry := range s.entries {
+		start, end, ok := entry.Occurrence(now)
+		if !ok {
+			continue
+		}
+		key := recordingKey{stationUuid: entry.StationUuid, start: start.Unix()}
+		due[key] = true
+		if _, ok := s.active[key]; ok || s.finished[key] {
+			continue
+		}
+		recorder, name, err := s.start(entry, start, end)
+		if err != nil {
+			if !s.failed[key] && firstErr == nil {
+				firstErr = errors.New(i18n.Tf("recording.failed", name, err))
+			}
+			s.failed[key] = true
+			continue
+		}
+		delete(s.failed, key)
+		s.active[key] = activeRecording{recorder: recorder, name: name, end: end}
+	}
+
+	// Forget the occurrences that are over
+	for key := range s.finished {
+		if !due[key] {
+			delete(s.finished, key)
+		}
+	}
+
+	return len(s.active), stopped, firstErr
+}
+
+// start looks entry's station up and starts recording it. The lock must be held.
+// It returns the name of the station, for errors.
+func (s *recordingScheduler) start(entry recording.Entry, start time.Time, end time.Time) (recorder, string, error) {
+	name := entry.StationName
+	if name == "" {
+		name = entry.StationUuid.String()
+	}
+	stations, err := s.browser.GetStations(common.StationQueryByUuid, entry.StationUuid.String(), "votes", false, 0, 1, false)
+	if err != nil {
+		return nil, name, err
+	}
+	if len(stations) == 0 {
+		return nil, name, i18n.Error("recording.stationNotFound")
+	}
+	station := stations[0]
+	if entry.StationName == "" {
+		name = station.Name
+	}
+	// Checked before the sidecar is created, too
+	if err := recording.CheckFreeSpace(s.directory, s.limits); err != nil {
+		return nil, name, err
+	}
+	encoding := s.encodings.For(station.Codec)
+	path := filepath.Join(s.directory, recording.FileName(name, start, end, encoding.Codec(station.Codec)))
+	sidecar, err := recording.OpenSidecar(path, s.sidecar, name, start)
+	if err != nil {
+		return nil, name, err
+	}
+	recorder, err := s.record(station, path, playback.RecordOptions{
+		Sidecar:        sidecar,
+		Split:          s.split,
+		MinTrackLength: s.minTrackLength,
+		Limits:         s.limits,
+		Encoding:       encoding,
+	})
+	return recorder, name, err
+}
+
+// stopAll stops every recording in progress.
+func (s *recordingScheduler) stopAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, active := range s.active {
+		active.recorder.Stop()
+		delete(s.active, key)
+	}
+}
+
+// Messages
+
+type recordingTickMsg struct{}
+
+// recordingsUpdatedMsg reports how many recordings are in progress.
+// stopped warns that a recording stopped at its limits, and err is set if a recording couldn't be started.
+type recordingsUpdatedMsg struct {
+	active  int
+	stopped string
+	err     error
+}
+
+// recordingScheduledMsg asks to schedule a recording, and to persist it.
+type recordingScheduledMsg struct {
+	entry recording.Entry
+}
+
+// Commands
+
+// recordingTickCmd waits until the scheduled recordings are due to be checked.
+func recordingTickCmd() tea.Cmd {
+	return tea.Tick(recordingCheckInterval, func(t time.Time) tea.Msg {
+		return recordingTickMsg{}
+	})
+}
+
+// runRecordingsCmd starts and stops the scheduled recordings as needed.
+func runRecordingsCmd(scheduler *recordingScheduler) tea.Cmd {
+	return func() tea.Msg {
+		active, stopped, err := scheduler.run()
+		return recordingsUpdatedMsg{active: active, stopped: stopped, err: err}
+	}
+}
+
+// scheduleRecordingCmd adds entry to the schedule and persists it, then starts it if it's due.
+func scheduleRecordingCmd(scheduler *recordingScheduler, save func(entries []recording.Entry) error, entry recording.Entry) tea.Cmd {
+	return func() tea.Msg {
+		if err := save(scheduler.add(entry)); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return runRecordingsCmd(scheduler)()
+	}
+}
+
+// stopRecordingsCmd stops every recording in progress.
+func stopRecordingsCmd(scheduler *recordingScheduler) tea.Cmd {
+	return func() tea.Msg {
+		scheduler.stopAll()
+		return nil
+	}
+}
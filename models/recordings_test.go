@@ -0,0 +1,358 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/recording"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRecorder struct {
+	path    string
+	options playback.RecordOptions
+	stopped bool
+	done    chan struct{}
+	err     error
+}
+
+func (r *fakeRecorder) Stop() {
+	r.stopped = true
+}
+
+func (r *fakeRecorder) Done() <-chan struct{} {
+	return r.done
+}
+
+func (r *fakeRecorder) Err() error {
+	return r.err
+}
+
+func TestRecordingScheduler(t *testing.T) {
+
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM", Codec: "MP3"}
+	browser := &mocks.MockRadioBrowserService{
+		GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+			if searchTerm == station.StationUuid.String() {
+				return []common.Station{station}, nil
+			}
+			return []common.Station{}, nil
+		},
+	}
+	start := time.Date(2024, 3, 10, 20, 0, 0, 0, time.Local)
+
+	newScheduler := func(entries ...recording.Entry) (*recordingScheduler, *[]*fakeRecorder, *time.Time) {
+		recorders := []*fakeRecorder{}
+		now := start.Add(-time.Minute)
+		scheduler := newRecordingScheduler(entries, "/recordings", recording.SidecarNone, browser)
+		scheduler.record = func(station common.Station, path string, options playback.RecordOptions) (recorder, error) {
+			r := &fakeRecorder{path: path, options: options, done: make(chan struct{})}
+			recorders = append(recorders, r)
+			return r, nil
+		}
+		scheduler.now = func() time.Time { return now }
+		return scheduler, &recorders, &now
+	}
+
+	t.Run("starts and stops a recording on schedule", func(t *testing.T) {
+
+		entry, _ := recording.NewEntry(station.StationUuid, "", "2024-03-10 20:00", time.Hour)
+		scheduler, recorders, now := newScheduler(entry)
+
+		active, _, err := scheduler.run()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, active)
+
+		*now = start.Add(time.Minute)
+		active, _, err = scheduler.run()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, active)
+		assert.Len(t, *recorders, 1)
+		assert.Equal(t, filepath.Join("/recordings", "Jazz FM 2024-03-10 20.00-21.00.mp3"), (*recorders)[0].path)
+
+		// Already recording
+		active, _, _ = scheduler.run()
+		assert.Equal(t, 1, active)
+		assert.Len(t, *recorders, 1)
+
+		*now = start.Add(time.Hour)
+		active, _, _ = scheduler.run()
+		assert.Equal(t, 0, active)
+		assert.True(t, (*recorders)[0].stopped)
+
+	})
+
+	t.Run("logs the tracks next to the recording", func(t *testing.T) {
+
+		entry, _ := recording.NewEntry(station.StationUuid, "", "2024-03-10 20:00", time.Hour)
+		scheduler, recorders, now := newScheduler(entry)
+		scheduler.directory = t.TempDir()
+		scheduler.sidecar = recording.SidecarCUE
+
+		*now = start.Add(time.Minute)
+		_, _, err := scheduler.run()
+
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(scheduler.directory, "Jazz FM 2024-03-10 20.00-21.00.cue"), (*recorders)[0].options.Sidecar.Path())
+		assert.FileExists(t, (*recorders)[0].options.Sidecar.Path())
+
+	})
+
+	t.Run("splits the recording by track if asked to", func(t *testing.T) {
+
+		entry, _ := recording.NewEntry(station.StationUuid, "", "2024-03-10 20:00", time.Hour)
+		scheduler, recorders, now := newScheduler(entry)
+		scheduler.split = true
+		scheduler.minTrackLength = time.Minute
+
+		*now = start.Add(time.Minute)
+		_, _, err := scheduler.run()
+
+		assert.NoError(t, err)
+		assert.True(t, (*recorders)[0].options.Split)
+		assert.Equal(t, time.Minute, (*recorders)[0].options.MinTrackLength)
+
+	})
+
+	t.Run("re-encodes the recording as configured for the codec", func(t *testing.T) {
+
+		entry, _ := recording.NewEntry(station.StationUuid, "", "2024-03-10 20:00", time.Hour)
+		scheduler, recorders, now := newScheduler(entry)
+		opus := recording.Encoding{Format: recording.FormatOpus, Bitrate: 96}
+		scheduler.encodings = recording.Encodings{Codecs: map[string]recording.Encoding{"mp3": opus}}
+
+		*now = start.Add(time.Minute)
+		_, _, err := scheduler.run()
+
+		assert.NoError(t, err)
+		assert.Equal(t, opus, (*recorders)[0].options.Encoding)
+		assert.Equal(t, filepath.Join("/recordings", "Jazz FM 2024-03-10 20.00-21.00.opus"), (*recorders)[0].path)
+
+	})
+
+	t.Run("restarts a recording whose stream ended early", func(t *testing.T) {
+
+		entry, _ := recording.NewEntry(station.StationUuid, "", "2024-03-10 20:00", time.Hour)
+		scheduler, recorders, now := newScheduler(entry)
+
+		*now = start.Add(time.Minute)
+		scheduler.run()
+		close((*recorders)[0].done)
+
+		active, _, _ := scheduler.run()
+		assert.Equal(t, 1, active)
+		assert.Len(t, *recorders, 2)
+
+	})
+
+	t.Run("warns about a recording stopped at its limits, without restarting it", func(t *testing.T) {
+
+		entry, _ := recording.NewEntry(station.StationUuid, "", "2024-03-10 20:00", time.Hour)
+		scheduler, recorders, now := newScheduler(entry)
+		scheduler.limits = recording.Limits{MaxSize: 1 << 20}
+
+		*now = start.Add(time.Minute)
+		scheduler.run()
+		assert.Equal(t, recording.Limits{MaxSize: 1 << 20}, (*recorders)[0].options.Limits)
+		(*recorders)[0].err = recording.ErrMaxSize
+		close((*recorders)[0].done)
+
+		active, stopped, err := scheduler.run()
+		assert.Equal(t, 0, active)
+		assert.Contains(t, stopped, "Jazz FM")
+		assert.NoError(t, err)
+		assert.Len(t, *recorders, 1)
+
+		active, stopped, _ = scheduler.run()
+		assert.Equal(t, 0, active)
+		assert.Empty(t, stopped)
+
+	})
+
+	t.Run("retries a recording stopped for lack of space silently", func(t *testing.T) {
+
+		entry, _ := recording.NewEntry(station.StationUuid, "", "2024-03-10 20:00", time.Hour)
+		scheduler, recorders, now := newScheduler(entry)
+
+		*now = start.Add(time.Minute)
+		scheduler.run()
+		(*recorders)[0].err = recording.ErrDiskSpaceLow
+		close((*recorders)[0].done)
+
+		active, stopped, err := scheduler.run()
+		assert.Equal(t, 1, active)
+		assert.Contains(t, stopped, "Jazz FM")
+		assert.NoError(t, err)
+		assert.Len(t, *recorders, 2)
+
+	})
+
+	t.Run("reports a station that can't be found once", func(t *testing.T) {
+
+		entry, _ := recording.NewEntry(uuid.New(), "Gone FM", "2024-03-10 20:00", time.Hour)
+		scheduler, recorders, now := newScheduler(entry)
+
+		*now = start.Add(time.Minute)
+		active, _, err := scheduler.run()
+		assert.Equal(t, 0, active)
+		assert.ErrorContains(t, err, "Gone FM")
+		assert.Empty(t, *recorders)
+
+		_, _, err = scheduler.run()
+		assert.NoError(t, err)
+
+	})
+
+	t.Run("drops recordings that are over when adding one", func(t *testing.T) {
+
+		over, _ := recording.NewEntry(station.StationUuid, "", "2024-03-01 20:00", time.Hour)
+		weekly, _ := recording.NewEntry(station.StationUuid, "", "friday 20:00", time.Hour)
+		scheduler, _, _ := newScheduler(over, weekly)
+
+		entry, _ := recording.NewEntry(station.StationUuid, "", "2024-03-10 20:00", time.Hour)
+		entries := scheduler.add(entry)
+
+		assert.Equal(t, []recording.Entry{weekly, entry}, entries)
+
+	})
+
+	t.Run("stops every recording", func(t *testing.T) {
+
+		entry, _ := recording.NewEntry(station.StationUuid, "", "2024-03-10 20:00", time.Hour)
+		scheduler, recorders, now := newScheduler(entry)
+
+		*now = start.Add(time.Minute)
+		scheduler.run()
+		scheduler.stopAll()
+
+		assert.True(t, (*recorders)[0].stopped)
+		active, _, _ := scheduler.run()
+		assert.Equal(t, 1, active)
+
+	})
+
+	t.Run("doesn't run the schedule if it can't be saved", func(t *testing.T) {
+
+		scheduler, recorders, now := newScheduler()
+		*now = start.Add(time.Minute)
+
+		entry, _ := recording.NewEntry(station.StationUuid, "", "2024-03-10 20:00", time.Hour)
+		msg := scheduleRecordingCmd(scheduler, func(entries []recording.Entry) error {
+			return errors.New("read-only")
+		}, entry)()
+
+		assert.IsType(t, nonFatalError{}, msg)
+		assert.Empty(t, *recorders)
+
+	})
+
+	t.Run("saves the schedule and starts a due recording", func(t *testing.T) {
+
+		scheduler, recorders, now := newScheduler()
+		*now = start.Add(time.Minute)
+
+		var saved []recording.Entry
+		entry, _ := recording.NewEntry(station.StationUuid, "", "2024-03-10 20:00", time.Hour)
+		msg := scheduleRecordingCmd(scheduler, func(entries []recording.Entry) error {
+			saved = entries
+			return nil
+		}, entry)()
+
+		assert.Equal(t, recordingsUpdatedMsg{active: 1}, msg)
+		assert.Equal(t, []recording.Entry{entry}, saved)
+		assert.Len(t, *recorders, 1)
+
+	})
+
+}
+
+func TestScheduleRecordingModel(t *testing.T) {
+
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	now := time.Date(2024, 3, 10, 19, 25, 0, 0, time.Local)
+
+	t.Run("pre-fills the next full hour", func(t *testing.T) {
+
+		model := NewScheduleRecordingModel(Theme{}, station, "Jazz FM", now)
+
+		assert.Equal(t, "2024-03-10 20:00", model.startInput.Value())
+		assert.Equal(t, "1h", model.durationInput.Value())
+
+	})
+
+	t.Run("submits a valid recording on enter", func(t *testing.T) {
+
+		model := NewScheduleRecordingModel(Theme{}, station, "Jazz FM", now)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.NotNil(t, cmd)
+		msg, ok := cmd().(recordingSubmittedMsg)
+		assert.True(t, ok)
+		assert.Equal(t, station.StationUuid, msg.entry.StationUuid)
+		assert.Equal(t, "Jazz FM", msg.entry.StationName)
+		assert.Equal(t, time.Hour, msg.entry.Duration)
+
+	})
+
+	t.Run("shows an error for an invalid duration", func(t *testing.T) {
+
+		model := NewScheduleRecordingModel(Theme{}, station, "Jazz FM", now)
+		model.durationInput.SetValue("forever")
+
+		model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.Nil(t, cmd)
+		assert.NotEmpty(t, model.err)
+
+	})
+
+	t.Run("switches field on tab", func(t *testing.T) {
+
+		model := NewScheduleRecordingModel(Theme{}, station, "Jazz FM", now)
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyTab})
+
+		assert.False(t, model.startInput.Focused())
+		assert.True(t, model.durationInput.Focused())
+
+	})
+
+	t.Run("closes on esc", func(t *testing.T) {
+
+		model := NewScheduleRecordingModel(Theme{}, station, "Jazz FM", now)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+		assert.IsType(t, closeScheduleRecordingMsg{}, cmd())
+
+	})
+
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// resultsAgeInterval is how often the age of the results is checked against the refresh interval.
+const resultsAgeInterval = time.Minute
+
+// Messages
+
+// resultsAgeTickMsg refreshes the results once they're old enough, updating their age meanwhile.
+// It's ignored if the results were loaded again since, as told by loadedAt.
+type resultsAgeTickMsg struct {
+	loadedAt time.Time
+}
+
+// resultsRefreshedMsg carries the page of results fetched again.
+type resultsRefreshedMsg struct {
+	key      stationPageKey
+	stations []common.Station
+}
+
+// resultsRefreshFailedMsg reports that the results couldn't be fetched again. They're kept as they are.
+type resultsRefreshFailedMsg struct {
+	err error
+}
+
+// Commands
+
+// resultsAgeTickCmd waits for the next check of the age of the results, if they're refreshed at all.
+func (m StationsModel) resultsAgeTickCmd() tea.Cmd {
+	if m.refreshInterval <= 0 || !m.page.fetchable() {
+		return nil
+	}
+	loadedAt := m.loadedAt
+	return tea.Tick(resultsAgeInterval, func(t time.Time) tea.Msg {
+		return resultsAgeTickMsg{loadedAt: loadedAt}
+	})
+}
+
+// refreshResultsCmd fetches the given page again, dropping the cached pages of the search.
+func refreshResultsCmd(pages *stationPageCache, browser api.RadioBrowserService, key stationPageKey) tea.Cmd {
+	return func() tea.Msg {
+		pages.invalidate()
+		stations, err := pages.get(browser, key)
+		if err != nil {
+			return resultsRefreshFailedMsg{err: err}
+		}
+		return resultsRefreshedMsg{key: key, stations: stations}
+	}
+}
+
+// Model
+
+// refreshResults fetches the page of results being shown again, unless it's already being loaded.
+func (m StationsModel) refreshResults() (tea.Model, tea.Cmd) {
+	if m.loadingPage || m.refreshing || !m.page.fetchable() {
+		return m, nil
+	}
+	m.refreshing = true
+	return m, refreshResultsCmd(m.pages, m.browser, m.page)
+}
+
+// resultsRefreshed shows the results fetched again, keeping the cursor on the highlighted station
+// if it's still there, or where it was otherwise.
+func (m StationsModel) resultsRefreshed(msg resultsRefreshedMsg) (tea.Model, tea.Cmd) {
+	m.refreshing = false
+	// The user moved to another page in the meantime
+	if msg.key != m.page {
+		return m, nil
+	}
+	cursor := m.stationsTable.Cursor()
+	var highlighted common.Station
+	if cursor < len(m.stations) {
+		highlighted = m.stations[cursor]
+	}
+	m.setPageSize(msg.key.page, len(msg.stations))
+	before := m.allStations
+	m.setStations(withoutBlockedStations(m.blocklist, withoutReportedStations(m.reportStore, m.contentFilter.Apply(msg.stations))))
+	highlight := m.highlightChanges(before)
+	for i, station := range m.stations {
+		if station.StationUuid == highlighted.StationUuid {
+			cursor = i
+			break
+		}
+	}
+	if cursor >= len(m.stations) {
+		cursor = len(m.stations) - 1
+	}
+	if cursor >= 0 {
+		m.stationsTable.SetCursor(cursor)
+	}
+	m.loadedAt = time.Now()
+	return m, tea.Batch(
+		m.resultsAgeTickCmd(),
+		m.cursorMovedCmd(),
+		m.prefetchNextPageCmd(),
+		highlight,
+	)
+}
+
+// resultsAge tells how long ago the results were fetched.
+func (m StationsModel) resultsAge() string {
+	age := time.Since(m.loadedAt)
+	switch {
+	case age < time.Minute:
+		return i18n.T("stations.updatedJustNow")
+	case age < time.Hour:
+		return i18n.Tf("stations.updated", fmt.Sprintf("%dm", int(age.Minutes())))
+	}
+	return i18n.Tf("stations.updated", fmt.Sprintf("%dh", int(age.Hours())))
+}
+
+// SetRefreshInterval sets how often the results are fetched again in the background (0 never).
+func (m *StationsModel) SetRefreshInterval(interval time.Duration) {
+	m.refreshInterval = interval
+}
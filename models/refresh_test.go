@@ -0,0 +1,226 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStationsModel_Refresh(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	rock := common.Station{StationUuid: uuid.New(), Name: "Rock Antenne"}
+	news := common.Station{StationUuid: uuid.New(), Name: "News 24"}
+	key := stationPageKey{query: common.StationQueryByTag, queryText: "music"}
+
+	newRefreshModel := func(browser *mocks.MockRadioBrowserService, stations []common.Station) StationsModel {
+		model := NewStationsModel(
+			Theme{},
+			browser,
+			&mocks.MockPlaybackManagerService{},
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{},
+			&mocks.MockReportStore{},
+			filter.ContentFilter{},
+			stations,
+			config.DefaultStationColumns(),
+			newStationPageCache(),
+			key,
+			false,
+		)
+		model.SetRefreshInterval(10 * time.Minute)
+		return model
+	}
+
+	t.Run("keeps the cursor on the highlighted station", func(t *testing.T) {
+
+		browser := &mocks.MockRadioBrowserService{
+			GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				return []common.Station{news, jazz, rock}, nil
+			},
+		}
+		model := newRefreshModel(browser, []common.Station{jazz, rock})
+		model.stationsTable.SetCursor(1)
+		model.loadedAt = time.Now().Add(-time.Hour)
+
+		newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+		assert.True(t, newModel.(StationsModel).refreshing)
+
+		newModel, _ = newModel.Update(cmd())
+		model = newModel.(StationsModel)
+
+		assert.False(t, model.refreshing)
+		assert.Equal(t, []common.Station{news, jazz, rock}, model.stations)
+		assert.Equal(t, 2, model.stationsTable.Cursor())
+		assert.WithinDuration(t, time.Now(), model.loadedAt, time.Minute)
+
+	})
+
+	t.Run("marks the stations that changed until the highlight expires", func(t *testing.T) {
+
+		jazz, rock, news := jazz, rock, news
+		jazz.Votes, rock.Votes, news.ClickCount = 10, 10, 5
+		rock.LastCheckOk = true
+		votedJazz, brokenRock, clickedNews := jazz, rock, news
+		votedJazz.Votes = 12
+		brokenRock.LastCheckOk = false
+		clickedNews.ClickCount = 3
+		browser := &mocks.MockRadioBrowserService{
+			GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				return []common.Station{votedJazz, brokenRock, clickedNews}, nil
+			},
+		}
+		model := newRefreshModel(browser, []common.Station{jazz, rock, news})
+		model.SetHighlightDuration(10 * time.Second)
+
+		newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+		newModel, _ = newModel.Update(cmd())
+		model = newModel.(StationsModel)
+
+		assert.Equal(t, stationChanges{
+			jazz.StationUuid: {votes: 2},
+			rock.StationUuid: {checkChanged: true},
+			news.StationUuid: {clicks: -2},
+		}, model.changes)
+		rows := model.stationsTable.Rows()
+		assert.Equal(t, "▲ Jazz FM", strings.TrimSpace(rows[0][0]))
+		assert.Equal(t, "12 ▲", strings.TrimSpace(rows[0][4]))
+		assert.Equal(t, "✗ Rock Antenne", strings.TrimSpace(rows[1][0]))
+		assert.Equal(t, "10", strings.TrimSpace(rows[1][4]))
+		assert.Equal(t, "▼ News 24", strings.TrimSpace(rows[2][0]))
+
+		// A later refresh expires its own highlight
+		newModel, _ = model.Update(resultsChangesExpiredMsg{generation: model.changesGeneration - 1})
+		assert.NotNil(t, newModel.(StationsModel).changes)
+
+		newModel, _ = model.Update(resultsChangesExpiredMsg{generation: model.changesGeneration})
+		model = newModel.(StationsModel)
+		assert.Nil(t, model.changes)
+		assert.Equal(t, "Jazz FM", strings.TrimSpace(model.stationsTable.Rows()[0][0]))
+
+	})
+
+	t.Run("doesn't highlight changes if disabled", func(t *testing.T) {
+
+		voted := jazz
+		voted.Votes++
+		browser := &mocks.MockRadioBrowserService{
+			GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				return []common.Station{voted}, nil
+			},
+		}
+		model := newRefreshModel(browser, []common.Station{jazz})
+
+		newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+		newModel, _ = newModel.Update(cmd())
+
+		assert.Empty(t, newModel.(StationsModel).changes)
+		assert.Equal(t, "Jazz FM", strings.TrimSpace(newModel.(StationsModel).stationsTable.Rows()[0][0]))
+
+	})
+
+	t.Run("refreshes the results once they're old enough", func(t *testing.T) {
+
+		model := newRefreshModel(&mocks.MockRadioBrowserService{}, []common.Station{jazz})
+
+		// Not yet
+		newModel, cmd := model.Update(resultsAgeTickMsg{loadedAt: model.loadedAt})
+		assert.False(t, newModel.(StationsModel).refreshing)
+		assert.NotNil(t, cmd)
+
+		model.loadedAt = time.Now().Add(-10 * time.Minute)
+
+		// A tick of results loaded before is ignored
+		newModel, cmd = model.Update(resultsAgeTickMsg{loadedAt: model.loadedAt.Add(-time.Minute)})
+		assert.False(t, newModel.(StationsModel).refreshing)
+		assert.Nil(t, cmd)
+
+		newModel, _ = model.Update(resultsAgeTickMsg{loadedAt: model.loadedAt})
+		assert.True(t, newModel.(StationsModel).refreshing)
+
+	})
+
+	t.Run("holds off refreshing while the network is down", func(t *testing.T) {
+
+		model := newRefreshModel(&mocks.MockRadioBrowserService{}, []common.Station{jazz})
+		model.loadedAt = time.Now().Add(-10 * time.Minute)
+		model.SetNetworkWatch(&networkWatch{checked: true})
+
+		newModel, cmd := model.Update(resultsAgeTickMsg{loadedAt: model.loadedAt})
+
+		assert.False(t, newModel.(StationsModel).refreshing)
+		assert.NotNil(t, cmd)
+
+	})
+
+	t.Run("ignores results of a page that's no longer shown", func(t *testing.T) {
+
+		model := newRefreshModel(&mocks.MockRadioBrowserService{}, []common.Station{jazz})
+		model.refreshing = true
+		other := key
+		other.page = 1
+
+		newModel, _ := model.Update(resultsRefreshedMsg{key: other, stations: []common.Station{news}})
+
+		assert.Equal(t, []common.Station{jazz}, newModel.(StationsModel).stations)
+
+	})
+
+	t.Run("keeps the results if they can't be fetched again", func(t *testing.T) {
+
+		model := newRefreshModel(&mocks.MockRadioBrowserService{}, []common.Station{jazz})
+		model.refreshing = true
+		// Not waiting for the next refresh when collecting the messages
+		model.refreshInterval = 0
+
+		newModel, cmd := model.Update(resultsRefreshFailedMsg{err: errors.New("timeout")})
+
+		assert.False(t, newModel.(StationsModel).refreshing)
+		assert.Contains(t, collectMsgs(cmd), errorBannerMsg{err: errors.New("timeout")})
+		assert.Equal(t, []common.Station{jazz}, newModel.(StationsModel).stations)
+
+	})
+
+	t.Run("tells the age of the results", func(t *testing.T) {
+
+		model := newRefreshModel(&mocks.MockRadioBrowserService{}, []common.Station{jazz})
+		assert.Equal(t, "updated just now", model.resultsAge())
+
+		model.loadedAt = time.Now().Add(-5 * time.Minute)
+		assert.Equal(t, "updated 5m ago", model.resultsAge())
+
+		model.loadedAt = time.Now().Add(-3 * time.Hour)
+		assert.Equal(t, "updated 3h ago", model.resultsAge())
+
+	})
+
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The least time between two redraws in low-bandwidth mode, whatever the configured throttle.
+const lowBandwidthRenderInterval = 500 * time.Millisecond
+
+// renderThrottle keeps the root view from being redrawn more often than its interval by anything but
+// the user, so that spinners, meters and tickers don't flood slow terminals, e.g. over SSH.
+// It also keeps the rendering of each section of the view, reusing it while the section doesn't change.
+// It's owned by the root model, and only used by its Update and View. A nil *renderThrottle draws every time.
+type renderThrottle struct {
+	interval time.Duration
+	now      func() time.Time
+	// urgent is true when the next frame must be drawn right away, e.g. to echo a key press
+	urgent bool
+	// pending is true while a renderFlushMsg is on its way, to draw the frames held back
+	pending bool
+	drawn   bool
+	drawnAt time.Time
+	frame   string
+	// sections maps the name of each section to its last rendering
+	sections map[string]renderedSection
+}
+
+// renderedSection is the rendering of a section of the view, along with what it was rendered from.
+type renderedSection struct {
+	key      renderKey
+	rendered string
+}
+
+// renderKey is what a section is rendered from: it's rendered again only when it changes.
+type renderKey struct {
+	source        string
+	width, height int
+}
+
+func newRenderThrottle(interval time.Duration) *renderThrottle {
+	return &renderThrottle{
+		interval: interval,
+		now:      time.Now,
+		sections: make(map[string]renderedSection),
+	}
+}
+
+// renderInterval returns the least time between two background redraws, as configured.
+func renderInterval(cfg config.Config) time.Duration {
+	interval := time.Duration(cfg.Render.ThrottleMs) * time.Millisecond
+	if cfg.Render.LowBandwidth && interval < lowBandwidthRenderInterval {
+		interval = lowBandwidthRenderInterval
+	}
+	return interval
+}
+
+// invalidate tells the throttle that msg is about to change the view. Input from the user is drawn
+// right away; anything else is drawn at most once per interval, returning the command that draws
+// what's been held back once the interval is over.
+func (r *renderThrottle) invalidate(msg tea.Msg) tea.Cmd {
+	if r == nil || r.interval <= 0 {
+		return nil
+	}
+	switch msg.(type) {
+	case renderFlushMsg:
+		r.pending = false
+		r.urgent = true
+		return nil
+	case tea.KeyMsg, tea.MouseMsg, tea.WindowSizeMsg:
+		r.urgent = true
+		return nil
+	}
+	if r.pending {
+		return nil
+	}
+	r.pending = true
+	return tea.Tick(r.interval, func(time.Time) tea.Msg {
+		return renderFlushMsg{}
+	})
+}
+
+// view returns the frame drawn by draw, or the last one if it's been drawn less than an interval ago
+// and nothing urgent happened since.
+func (r *renderThrottle) view(draw func() string) string {
+	if r == nil {
+		return draw()
+	}
+	now := r.now()
+	if r.interval > 0 && r.drawn && !r.urgent && now.Sub(r.drawnAt) < r.interval {
+		return r.frame
+	}
+	r.frame = draw()
+	r.drawn = true
+	r.drawnAt = now
+	r.urgent = false
+	return r.frame
+}
+
+// section returns the named section of the view as rendered by render, reusing its last rendering
+// if it's drawn from the same source at the same size.
+func (r *renderThrottle) section(name string, key renderKey, render func() string) string {
+	if r == nil {
+		return render()
+	}
+	if last, ok := r.sections[name]; ok && last.key == key {
+		return last.rendered
+	}
+	rendered := render()
+	r.sections[name] = renderedSection{key: key, rendered: rendered}
+	return rendered
+}
+
+// restyle forgets the rendering of the sections, as the theme changes.
+func (r *renderThrottle) restyle() {
+	if r == nil {
+		return
+	}
+	r.sections = make(map[string]renderedSection)
+	r.urgent = true
+}
+
+// Messages
+
+// renderFlushMsg draws the frames held back by the render throttle.
+type renderFlushMsg struct{}
@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderThrottle(t *testing.T) {
+
+	// newTestThrottle returns a throttle with a clock that only moves when told to.
+	newTestThrottle := func(interval time.Duration) (*renderThrottle, *time.Time) {
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		throttle := newRenderThrottle(interval)
+		throttle.now = func() time.Time { return now }
+		return throttle, &now
+	}
+
+	t.Run("holds back background redraws until the interval is over", func(t *testing.T) {
+
+		throttle, now := newTestThrottle(100 * time.Millisecond)
+		assert.Equal(t, "frame 1", throttle.view(func() string { return "frame 1" }))
+
+		cmd := throttle.invalidate(statusBarTickMsg{})
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "frame 1", throttle.view(func() string { return "frame 2" }))
+
+		// A single flush is scheduled however many messages come in the meantime
+		assert.Nil(t, throttle.invalidate(statusBarTickMsg{}))
+
+		*now = now.Add(100 * time.Millisecond)
+		assert.Equal(t, "frame 3", throttle.view(func() string { return "frame 3" }))
+
+	})
+
+	t.Run("draws the frames held back once the flush arrives", func(t *testing.T) {
+
+		throttle, _ := newTestThrottle(100 * time.Millisecond)
+		throttle.view(func() string { return "frame 1" })
+
+		cmd := throttle.invalidate(statusBarTickMsg{})
+		assert.Equal(t, "frame 1", throttle.view(func() string { return "frame 2" }))
+
+		assert.Nil(t, throttle.invalidate(cmd()))
+		assert.Equal(t, "frame 2", throttle.view(func() string { return "frame 2" }))
+		assert.NotNil(t, throttle.invalidate(statusBarTickMsg{}))
+
+	})
+
+	t.Run("draws the keys pressed right away", func(t *testing.T) {
+
+		throttle, _ := newTestThrottle(100 * time.Millisecond)
+		throttle.view(func() string { return "frame 1" })
+
+		assert.Nil(t, throttle.invalidate(tea.KeyMsg{Type: tea.KeyDown}))
+		assert.Equal(t, "frame 2", throttle.view(func() string { return "frame 2" }))
+
+	})
+
+	t.Run("draws every frame without an interval", func(t *testing.T) {
+
+		throttle, _ := newTestThrottle(0)
+		throttle.view(func() string { return "frame 1" })
+
+		assert.Nil(t, throttle.invalidate(statusBarTickMsg{}))
+		assert.Equal(t, "frame 2", throttle.view(func() string { return "frame 2" }))
+
+		var none *renderThrottle
+		assert.Nil(t, none.invalidate(statusBarTickMsg{}))
+		assert.Equal(t, "frame 3", none.view(func() string { return "frame 3" }))
+
+	})
+
+	t.Run("renders a section again only when it changes", func(t *testing.T) {
+
+		throttle, _ := newTestThrottle(0)
+		renders := 0
+		render := func() string {
+			renders++
+			return "rendered"
+		}
+
+		throttle.section("view", renderKey{source: "stations", width: 80, height: 20}, render)
+		throttle.section("view", renderKey{source: "stations", width: 80, height: 20}, render)
+		assert.Equal(t, 1, renders)
+
+		throttle.section("view", renderKey{source: "stations", width: 100, height: 20}, render)
+		throttle.section("view", renderKey{source: "bookmarks", width: 100, height: 20}, render)
+		assert.Equal(t, 3, renders)
+
+		throttle.restyle()
+		throttle.section("view", renderKey{source: "bookmarks", width: 100, height: 20}, render)
+		assert.Equal(t, 4, renders)
+
+	})
+
+	t.Run("redraws at most twice a second in low-bandwidth mode", func(t *testing.T) {
+
+		cfg := config.Config{}
+		cfg.Render.ThrottleMs = 50
+		assert.Equal(t, 50*time.Millisecond, renderInterval(cfg))
+
+		cfg.Render.LowBandwidth = true
+		assert.Equal(t, lowBandwidthRenderInterval, renderInterval(cfg))
+
+	})
+
+}
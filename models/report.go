@@ -0,0 +1,179 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// reportReasons are the reasons offered in the report dialog, in order.
+var reportReasons = []storage.ReportReason{storage.ReportBroken, storage.ReportMiscategorized}
+
+// withoutReportedStations leaves out the stations reported by the user that haven't changed upstream since.
+func withoutReportedStations(reportStore storage.ReportStore, stations []common.Station) []common.Station {
+	kept := make([]common.Station, 0, len(stations))
+	for _, station := range stations {
+		if report, ok := reportStore.Get(station.StationUuid); ok && report.Hides(station) {
+			continue
+		}
+		kept = append(kept, station)
+	}
+	return kept
+}
+
+// Messages
+
+// reportSubmittedMsg asks to store a report, and to open the station's edit page on radio-browser if openEditPage is true.
+type reportSubmittedMsg struct {
+	report       storage.Report
+	openEditPage bool
+}
+
+type closeReportMsg struct{}
+
+// stationReportedMsg reports that a station was reported, and hides it.
+// err is set if its edit page couldn't be opened.
+type stationReportedMsg struct {
+	stationUuid uuid.UUID
+	err         error
+}
+
+// Commands
+
+func saveReportCmd(reportStore storage.ReportStore, openURL func(url string) error, msg reportSubmittedMsg) tea.Cmd {
+	return func() tea.Msg {
+		err := reportStore.Add(msg.report)
+		if err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		if msg.openEditPage {
+			err = openURL(api.StationEditURL(msg.report.StationUuid))
+		}
+		return stationReportedMsg{stationUuid: msg.report.StationUuid, err: err}
+	}
+}
+
+func updateCommandsForReport() tea.Msg {
+	return bottomBarUpdateMsg{
+		commands: []string{
+			i18n.T("commands.cancel"),
+			i18n.T("commands.move"),
+			i18n.T("commands.report"),
+			i18n.T("commands.reportAndEdit"),
+		},
+	}
+}
+
+// Model
+
+// ReportModel asks why a station is reported: broken or miscategorized.
+type ReportModel struct {
+	theme   Theme
+	station common.Station
+	name    string
+	cursor  int
+	now     func() time.Time
+}
+
+// NewReportModel returns a ReportModel for station, shown with the given name.
+func NewReportModel(theme Theme, station common.Station, name string) ReportModel {
+	return ReportModel{
+		theme:   theme,
+		station: station,
+		name:    name,
+		now:     time.Now,
+	}
+}
+
+// Bubbletea
+
+func (m ReportModel) Init() tea.Cmd {
+	return updateCommandsForReport
+}
+
+func (m ReportModel) Update(msg tea.Msg) (ReportModel, tea.Cmd) {
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc":
+		return m, func() tea.Msg {
+			return closeReportMsg{}
+		}
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(reportReasons)-1 {
+			m.cursor++
+		}
+	case "enter", "o":
+		submitted := reportSubmittedMsg{
+			report: storage.Report{
+				StationUuid:      m.station.StationUuid,
+				StationName:      m.station.Name,
+				Reason:           reportReasons[m.cursor],
+				ReportedAt:       m.now(),
+				StationChangedAt: m.station.LastChangeTime,
+			},
+			openEditPage: keyMsg.String() == "o",
+		}
+		return m, func() tea.Msg {
+			return submitted
+		}
+	}
+
+	return m, nil
+}
+
+func (m ReportModel) View() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.Tf("report.title", m.name)) + "\n\n"
+
+	for i, reason := range reportReasons {
+		item := i18n.T("report.reason." + string(reason))
+		switch {
+		case m.theme.Accessible && i == m.cursor:
+			v += ">>> " + item + "\n"
+		case m.theme.Accessible:
+			v += "    " + item + "\n"
+		case i == m.cursor:
+			v += m.theme.PrimaryBlock.Render(item) + "\n"
+		default:
+			v += m.theme.Text.Render(" "+item) + "\n"
+		}
+	}
+
+	v += "\n" + m.theme.TertiaryText.Render(i18n.T("report.hint")) + "\n"
+
+	return v
+}
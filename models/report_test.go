@@ -0,0 +1,205 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithoutReportedStations(t *testing.T) {
+
+	changedAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	reported := common.Station{StationUuid: uuid.New(), Name: "Reported", LastChangeTime: changedAt}
+	fixed := common.Station{StationUuid: uuid.New(), Name: "Fixed", LastChangeTime: changedAt.Add(time.Hour)}
+	other := common.Station{StationUuid: uuid.New(), Name: "Other"}
+
+	reportStore := &mocks.MockReportStore{
+		GetFunc: func(stationUuid uuid.UUID) (storage.Report, bool) {
+			if stationUuid == reported.StationUuid || stationUuid == fixed.StationUuid {
+				return storage.Report{StationUuid: stationUuid, StationChangedAt: changedAt}, true
+			}
+			return storage.Report{}, false
+		},
+	}
+
+	stations := withoutReportedStations(reportStore, []common.Station{reported, fixed, other})
+
+	assert.Equal(t, []common.Station{fixed, other}, stations)
+
+}
+
+func TestReportModel(t *testing.T) {
+
+	reportedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM", LastChangeTime: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	newModel := func() ReportModel {
+		model := NewReportModel(Theme{}, station, "Jazz FM")
+		model.now = func() time.Time { return reportedAt }
+		return model
+	}
+
+	t.Run("reports a broken station", func(t *testing.T) {
+
+		_, cmd := newModel().Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.Equal(t, reportSubmittedMsg{
+			report: storage.Report{
+				StationUuid:      station.StationUuid,
+				StationName:      "Jazz FM",
+				Reason:           storage.ReportBroken,
+				ReportedAt:       reportedAt,
+				StationChangedAt: station.LastChangeTime,
+			},
+		}, cmd())
+
+	})
+
+	t.Run("reports a miscategorized station and asks to edit it", func(t *testing.T) {
+
+		model, _ := newModel().Update(tea.KeyMsg{Type: tea.KeyDown})
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+
+		msg := cmd().(reportSubmittedMsg)
+		assert.Equal(t, storage.ReportMiscategorized, msg.report.Reason)
+		assert.True(t, msg.openEditPage)
+
+	})
+
+	t.Run("closes on esc", func(t *testing.T) {
+
+		_, cmd := newModel().Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+		assert.Equal(t, closeReportMsg{}, cmd())
+
+	})
+
+}
+
+func TestSaveReportCmd(t *testing.T) {
+
+	report := storage.Report{StationUuid: uuid.MustParse("960e57c5-0601-11e8-ae97-52543be04c81")}
+
+	t.Run("stores the report and opens the edit page", func(t *testing.T) {
+
+		var added storage.Report
+		var opened string
+		reportStore := &mocks.MockReportStore{
+			AddFunc: func(report storage.Report) error {
+				added = report
+				return nil
+			},
+		}
+		openURL := func(url string) error {
+			opened = url
+			return nil
+		}
+
+		msg := saveReportCmd(reportStore, openURL, reportSubmittedMsg{report: report, openEditPage: true})()
+
+		assert.Equal(t, stationReportedMsg{stationUuid: report.StationUuid}, msg)
+		assert.Equal(t, report, added)
+		assert.Equal(t, "https://www.radio-browser.info/edit/960e57c5-0601-11e8-ae97-52543be04c81", opened)
+
+	})
+
+	t.Run("reports a station whose edit page couldn't be opened", func(t *testing.T) {
+
+		openErr := errors.New("xdg-open not found")
+		openURL := func(url string) error {
+			return openErr
+		}
+
+		msg := saveReportCmd(&mocks.MockReportStore{}, openURL, reportSubmittedMsg{report: report, openEditPage: true})()
+
+		assert.Equal(t, stationReportedMsg{stationUuid: report.StationUuid, err: openErr}, msg)
+
+	})
+
+	t.Run("reports a report that couldn't be stored", func(t *testing.T) {
+
+		addErr := errors.New("disk full")
+		reportStore := &mocks.MockReportStore{
+			AddFunc: func(report storage.Report) error {
+				return addErr
+			},
+		}
+
+		msg := saveReportCmd(reportStore, nil, reportSubmittedMsg{report: report})()
+
+		assert.Equal(t, nonFatalError{stopPlayback: false, err: addErr}, msg)
+
+	})
+
+}
+
+func TestStationsModel_Report(t *testing.T) {
+
+	stations := []common.Station{
+		{StationUuid: uuid.New(), Name: "Jazz FM"},
+		{StationUuid: uuid.New(), Name: "Rock Antenne"},
+	}
+
+	model := NewStationsModel(
+		Theme{},
+		&mocks.MockRadioBrowserService{},
+		&mocks.MockPlaybackManagerService{},
+		&mocks.MockLabelStore{},
+		&mocks.MockBookmarkStore{},
+		&mocks.MockReportStore{},
+		filter.ContentFilter{},
+		stations,
+		config.DefaultStationColumns(),
+		nil,
+		stationPageKey{},
+		false,
+	)
+
+	newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("!")})
+	assert.True(t, newModel.(StationsModel).showReport)
+
+	newModel, cmd := newModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	newModel, cmd = newModel.Update(cmd())
+	assert.False(t, newModel.(StationsModel).showReport)
+
+	var reported tea.Msg
+	for _, msg := range collectMsgs(cmd) {
+		if msg, ok := msg.(stationReportedMsg); ok {
+			reported = msg
+		}
+	}
+	newModel, _ = newModel.Update(reported)
+
+	assert.Equal(t, stations[1:], newModel.(StationsModel).stations)
+	assert.Len(t, newModel.(StationsModel).stationsTable.Rows(), 1)
+
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// stationChange is how a station changed between two fetches of the results.
+type stationChange struct {
+	votes  int64
+	clicks int64
+	// checkChanged is true if the station passed its last check after failing the one before, or the other way round.
+	checkChanged bool
+	checkOk      bool
+}
+
+// trend returns the marker of the change: the outcome of the check if it changed, or which way
+// the votes and clicks went otherwise.
+func (c stationChange) trend() string {
+	switch {
+	case c.checkChanged && c.checkOk:
+		return "✓"
+	case c.checkChanged:
+		return "✗"
+	case c.votes+c.clicks > 0:
+		return "▲"
+	case c.votes+c.clicks < 0:
+		return "▼"
+	}
+	return ""
+}
+
+// stationChanges are the stations that changed since the results were last fetched, by UUID.
+type stationChanges map[uuid.UUID]stationChange
+
+// diffStations returns how the stations in after changed since before.
+// Stations that are new or gone are left out, there being nothing to compare them with.
+func diffStations(before []common.Station, after []common.Station) stationChanges {
+	previous := make(map[uuid.UUID]common.Station, len(before))
+	for _, station := range before {
+		previous[station.StationUuid] = station
+	}
+	changes := stationChanges{}
+	for _, station := range after {
+		old, ok := previous[station.StationUuid]
+		if !ok {
+			continue
+		}
+		change := stationChange{
+			votes:        int64(station.Votes) - int64(old.Votes),
+			clicks:       int64(station.ClickCount) - int64(old.ClickCount),
+			checkChanged: station.LastCheckOk != old.LastCheckOk,
+			checkOk:      bool(station.LastCheckOk),
+		}
+		if change.votes != 0 || change.clicks != 0 || change.checkChanged {
+			changes[station.StationUuid] = change
+		}
+	}
+	return changes
+}
+
+// countTrend returns the arrow telling which way a count went, if it changed.
+func countTrend(delta int64) string {
+	switch {
+	case delta > 0:
+		return " ▲"
+	case delta < 0:
+		return " ▼"
+	}
+	return ""
+}
+
+// Messages
+
+// resultsChangesExpiredMsg stops highlighting the changes found by a refresh of the results.
+// It's ignored if the results were refreshed again since, as told by generation.
+type resultsChangesExpiredMsg struct {
+	generation int
+}
+
+// Commands
+
+func resultsChangesExpiredCmd(duration time.Duration, generation int) tea.Cmd {
+	return tea.Tick(duration, func(t time.Time) tea.Msg {
+		return resultsChangesExpiredMsg{generation: generation}
+	})
+}
+
+// Model
+
+// highlightChanges highlights the stations that changed between before and the results shown,
+// for a while, if changes are highlighted at all.
+func (m *StationsModel) highlightChanges(before []common.Station) tea.Cmd {
+	m.changesGeneration++
+	m.changes = nil
+	if m.highlightDuration > 0 {
+		m.changes = diffStations(before, m.allStations)
+	}
+	m.stationsTable.SetRows(newStationsTableRows(m.stations, m.columnsLayout.columns, m.labelStore, m.bookmarkStore, m.changes))
+	if len(m.changes) == 0 {
+		return nil
+	}
+	return resultsChangesExpiredCmd(m.highlightDuration, m.changesGeneration)
+}
+
+// changesExpired stops highlighting the changes, unless the results were refreshed again since.
+func (m StationsModel) changesExpired(msg resultsChangesExpiredMsg) (tea.Model, tea.Cmd) {
+	if msg.generation != m.changesGeneration || m.changes == nil {
+		return m, nil
+	}
+	m.changes = nil
+	m.stationsTable.SetRows(newStationsTableRows(m.stations, m.columnsLayout.columns, m.labelStore, m.bookmarkStore, m.changes))
+	return m, nil
+}
+
+// SetHighlightDuration sets how long the stations that changed are highlighted once the results are refreshed (0 never).
+func (m *StationsModel) SetHighlightDuration(duration time.Duration) {
+	m.highlightDuration = duration
+}
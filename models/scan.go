@@ -0,0 +1,139 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/icy"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/secrets"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultScanDwell is how long each station plays while scanning, unless configured otherwise.
+const defaultScanDwell = 10 * time.Second
+
+// Messages
+
+// scanTickMsg moves the scan on to the next station once the current one has played for long enough.
+// It's ignored if another station was played or stopped in the meantime, as told by generation.
+type scanTickMsg struct {
+	generation int
+}
+
+// scanStationFailedMsg reports a scanned station that couldn't be played, so that the next one is played instead.
+type scanStationFailedMsg struct {
+	err error
+}
+
+// Commands
+
+func scanTickCmd(dwell time.Duration, generation int) tea.Cmd {
+	return tea.Tick(dwell, func(t time.Time) tea.Msg {
+		return scanTickMsg{generation: generation}
+	})
+}
+
+// playScannedStationCmd plays a station while scanning, reporting failures as scanStationFailedMsg.
+func playScannedStationCmd(
+	playbackManager playback.PlaybackManagerService,
+	contentFilter filter.ContentFilter,
+	prober icy.ProberService,
+	credentials secrets.Store,
+	variants storage.StreamVariantStore,
+	station common.Station,
+	volume int,
+) tea.Cmd {
+	play := playStationCmd(playbackManager, contentFilter, prober, credentials, variants, station, volume)
+	return func() tea.Msg {
+		msg := play()
+		if failed, ok := msg.(nonFatalError); ok {
+			return scanStationFailedMsg{err: failed.err}
+		}
+		return msg
+	}
+}
+
+func updateCommandsForScan() tea.Msg {
+	return bottomBarUpdateMsg{
+		commands: []string{
+			i18n.T("commands.lockOn"),
+		},
+	}
+}
+
+// Model
+
+// startScan plays the highlighted station, then each of the following ones in turn for scanDwell,
+// wrapping around to the first, until a key is pressed.
+func (m StationsModel) startScan(dwell time.Duration) (tea.Model, tea.Cmd) {
+	if len(m.stations) == 0 || m.bufferingStation != nil {
+		return m, nil
+	}
+	if dwell <= 0 {
+		dwell = m.scanDwell
+	}
+	m.scanning = true
+	m.scanPeriod = dwell
+	m.scanFailures = 0
+	newModel, cmd := m.playScannedStation(m.stationsTable.Cursor())
+	return newModel, tea.Batch(cmd, updateCommandsForScan)
+}
+
+// lockOn stops scanning, keeping the station being played.
+func (m StationsModel) lockOn() (tea.Model, tea.Cmd) {
+	m.scanning = false
+	return m, updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift())
+}
+
+// scanNext moves the cursor to the station after the highlighted one, wrapping around, and plays it.
+// Scanning stops once every station failed to play in a row.
+func (m StationsModel) scanNext() (tea.Model, tea.Cmd) {
+	if !m.scanning || m.bufferingStation != nil {
+		return m, nil
+	}
+	if len(m.stations) == 0 || m.scanFailures >= len(m.stations) {
+		return m.lockOn()
+	}
+	index := (m.stationsTable.Cursor() + 1) % len(m.stations)
+	m.stationsTable.SetCursor(index)
+	newModel, cmd := m.playScannedStation(index)
+	return newModel, tea.Batch(cmd, newModel.(StationsModel).cursorMovedCmd())
+}
+
+// playScannedStation plays the station at index while scanning.
+func (m StationsModel) playScannedStation(index int) (tea.Model, tea.Cmd) {
+	station := m.stations[index]
+	return m.bufferStation(station, playScannedStationCmd(m.playbackManager, m.contentFilter, m.prober, m.credentials, m.streamVariants, withStationPrefs(m.stationPrefs, withStreamHeaders(m.streamHeaders, station)), m.stationVolume(station)))
+}
+
+// SetScanDwell sets how long each station plays while scanning with "S" (0 uses the default).
+func (m *StationsModel) SetScanDwell(dwell time.Duration) {
+	if dwell <= 0 {
+		dwell = defaultScanDwell
+	}
+	m.scanDwell = dwell
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStationsModel_Scan(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	rock := common.Station{StationUuid: uuid.New(), Name: "Rock Antenne"}
+
+	t.Run("plays the highlighted station, then the next one after the dwell time", func(t *testing.T) {
+
+		var played []common.Station
+		playbackManager := mocks.MockPlaybackManagerService{
+			IsPlayingResult: true,
+			PlayStationFunc: func(station common.Station, volume int) error {
+				played = append(played, station)
+				return nil
+			},
+		}
+		model := newQueueStationsModel(&playbackManager, []common.Station{jazz, rock}, nil, 0)
+
+		newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")})
+		assert.True(t, newModel.(StationsModel).scanning)
+		assert.Contains(t, collectMsgs(cmd), playbackStartedMsg{station: jazz})
+
+		newModel, _ = newModel.Update(playbackStartedMsg{station: jazz})
+		generation := newModel.(StationsModel).playGeneration
+
+		// A tick of a station that was since replaced is ignored
+		stale, _ := newModel.Update(scanTickMsg{generation: generation - 1})
+		assert.Nil(t, stale.(StationsModel).bufferingStation)
+
+		newModel, cmd = newModel.Update(scanTickMsg{generation: generation})
+		collectMsgs(cmd)
+		assert.Equal(t, rock, *newModel.(StationsModel).bufferingStation)
+		assert.Equal(t, 1, newModel.(StationsModel).stationsTable.Cursor())
+		assert.Equal(t, []common.Station{jazz, rock}, played)
+
+		// Wraps around to the first station
+		newModel, _ = newModel.Update(playbackStartedMsg{station: rock})
+		newModel, _ = newModel.Update(scanTickMsg{generation: newModel.(StationsModel).playGeneration})
+		assert.Equal(t, jazz, *newModel.(StationsModel).bufferingStation)
+		assert.Equal(t, 0, newModel.(StationsModel).stationsTable.Cursor())
+
+	})
+
+	t.Run("locks onto the current station on any key", func(t *testing.T) {
+
+		playbackManager := mocks.MockPlaybackManagerService{IsPlayingResult: true}
+		model := newQueueStationsModel(&playbackManager, []common.Station{jazz, rock}, nil, 0)
+		model.scanning = true
+		model.scanPeriod = time.Second
+
+		newModel, _ := model.Update(playbackStartedMsg{station: jazz})
+		generation := newModel.(StationsModel).playGeneration
+
+		// The key is only used to lock on, so "q" doesn't quit
+		newModel, cmd := newModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+		assert.False(t, newModel.(StationsModel).scanning)
+		assert.NotContains(t, collectMsgs(cmd), tea.QuitMsg{})
+
+		newModel, _ = newModel.Update(scanTickMsg{generation: generation})
+		assert.Nil(t, newModel.(StationsModel).bufferingStation)
+		assert.Equal(t, jazz, newModel.(StationsModel).currentStation)
+
+	})
+
+	t.Run("skips a station that can't be played", func(t *testing.T) {
+
+		playbackManager := mocks.MockPlaybackManagerService{
+			PlayStationFunc: func(station common.Station, volume int) error {
+				return errors.New("no such stream")
+			},
+		}
+		model := newQueueStationsModel(&playbackManager, []common.Station{jazz, rock}, nil, 0)
+
+		newModel, cmd := model.Update(commandLineSubmittedMsg{mode: commandMode, line: "scan 5"})
+		assert.Equal(t, 5*time.Second, newModel.(StationsModel).scanPeriod)
+		assert.Contains(t, collectMsgs(cmd), scanStationFailedMsg{err: errors.New("no such stream")})
+
+		newModel, cmd = newModel.Update(scanStationFailedMsg{err: errors.New("no such stream")})
+		assert.Contains(t, collectMsgs(cmd), errorBannerMsg{err: errors.New("no such stream")})
+		assert.Equal(t, rock, *newModel.(StationsModel).bufferingStation)
+
+		// Stops once every station failed in a row
+		newModel, _ = newModel.Update(scanStationFailedMsg{err: errors.New("no such stream")})
+		assert.False(t, newModel.(StationsModel).scanning)
+
+	})
+
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/recording"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Messages
+
+// recordingSubmittedMsg closes the scheduling dialog with the recording to schedule.
+type recordingSubmittedMsg struct {
+	entry recording.Entry
+}
+
+type closeScheduleRecordingMsg struct{}
+
+// Commands
+
+func updateCommandsForScheduleRecording() tea.Msg {
+	return bottomBarUpdateMsg{
+		commands: []string{
+			i18n.T("commands.cancel"),
+			i18n.T("commands.cycleFocus"),
+			i18n.T("commands.save"),
+		},
+	}
+}
+
+// Model
+
+// ScheduleRecordingModel asks when to record a station, and for how long.
+type ScheduleRecordingModel struct {
+	theme         Theme
+	station       common.Station
+	name          string
+	startInput    textinput.Model
+	durationInput textinput.Model
+	err           string
+}
+
+// NewScheduleRecordingModel returns a ScheduleRecordingModel for station, shown with the given name.
+// The start is pre-filled with the next full hour.
+func NewScheduleRecordingModel(theme Theme, station common.Station, name string, now time.Time) ScheduleRecordingModel {
+	start := newScheduleInput(theme, i18n.T("schedule.start"))
+	start.SetValue(now.Truncate(time.Hour).Add(time.Hour).Format("2006-01-02 15:04"))
+	start.Focus()
+	duration := newScheduleInput(theme, i18n.T("schedule.duration"))
+	duration.SetValue("1h")
+
+	return ScheduleRecordingModel{
+		theme:         theme,
+		station:       station,
+		name:          name,
+		startInput:    start,
+		durationInput: duration,
+	}
+}
+
+func newScheduleInput(theme Theme, prompt string) textinput.Model {
+	i := textinput.New()
+	i.Prompt = prompt + " "
+	i.PromptStyle = theme.SecondaryText
+	i.TextStyle = theme.Text
+	i.Width = 20
+	return i
+}
+
+// entry returns the recording described by the dialog, or an error explaining what's wrong with it.
+func (m ScheduleRecordingModel) entry() (recording.Entry, error) {
+	duration, err := time.ParseDuration(m.durationInput.Value())
+	if err != nil || duration <= 0 {
+		return recording.Entry{}, i18n.Error("schedule.invalidDuration")
+	}
+	entry, err := recording.NewEntry(m.station.StationUuid, m.name, m.startInput.Value(), duration)
+	if err != nil {
+		return recording.Entry{}, i18n.Error("schedule.invalidStart")
+	}
+	return entry, nil
+}
+
+// Bubbletea
+
+func (m ScheduleRecordingModel) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, updateCommandsForScheduleRecording)
+}
+
+func (m ScheduleRecordingModel) Update(msg tea.Msg) (ScheduleRecordingModel, tea.Cmd) {
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			return m, func() tea.Msg {
+				return closeScheduleRecordingMsg{}
+			}
+		case "tab", "shift+tab", "up", "down":
+			if m.startInput.Focused() {
+				m.startInput.Blur()
+				m.durationInput.Focus()
+			} else {
+				m.durationInput.Blur()
+				m.startInput.Focus()
+			}
+			return m, nil
+		case "enter":
+			entry, err := m.entry()
+			if err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			return m, func() tea.Msg {
+				return recordingSubmittedMsg{entry: entry}
+			}
+		}
+	}
+
+	var startCmd, durationCmd tea.Cmd
+	m.startInput, startCmd = m.startInput.Update(msg)
+	m.durationInput, durationCmd = m.durationInput.Update(msg)
+	return m, tea.Batch(startCmd, durationCmd)
+}
+
+func (m ScheduleRecordingModel) View() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.Tf("schedule.title", m.name)) + "\n\n"
+	v += m.startInput.View() + "\n"
+	v += m.durationInput.View() + "\n\n"
+	if m.err != "" {
+		v += m.theme.RenderError(m.err) + "\n"
+	}
+	v += m.theme.TertiaryText.Render(i18n.T("schedule.hint")) + "\n"
+
+	return v
+}
@@ -21,35 +21,67 @@ package models
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/zi0p4tch0/radiogogo/api"
 	"github.com/zi0p4tch0/radiogogo/assets"
 	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+const (
+	// searchInputWidth is the width of the search input when the terminal is wide enough.
+	searchInputWidth = 30
+	// searchInputMinWidth is the width the search input never shrinks below.
+	searchInputMinWidth = 10
+	// searchFormWidth is the room the search form needs on the right of the logo.
+	searchFormWidth = 40
+)
+
 type SearchModel struct {
 	theme         Theme
 	inputModel    textinput.Model
 	querySelector SelectorModel[common.StationQuery]
-	width         int
-	height        int
+	// Narrow name searches down, pre-populated with the configured defaults
+	countryInput  textinput.Model
+	languageInput textinput.Model
+	// Why the name query couldn't be parsed when last submitted, until it's edited
+	syntaxErr error
+	// Suggests tags fetched from browser in tag searches (nil suggests none)
+	browser    api.RadioBrowserService
+	completion tagCompletion
+	// Suggests the countries and languages of the catalog while typing in the filters, fetched when they're first
+	// focused and kept for the session (fetched every time if nil)
+	catalog          *catalog
+	catalogRequested bool
+	countries        []common.Country
+	languages        []common.Language
+	// The stations played lately, replayed with the digit keys
+	recentlyPlayed []common.Station
+	// Whether alt+x was pressed, for the next key to tell what to remove from the history
+	forgetting bool
+	// The station suggested for the day, if any
+	stationOfTheDay *stationOfTheDay
+	width           int
+	height          int
 }
 
-func NewSearchModel(theme Theme) SearchModel {
+func NewSearchModel(theme Theme, filter common.StationFilter) SearchModel {
 	i := textinput.New()
-	i.Placeholder = "Name"
-	i.Width = 30
+	i.Placeholder = i18n.T("search.placeholder")
+	i.Width = searchInputWidth
 	i.TextStyle = theme.Text
 	i.PlaceholderStyle = theme.TertiaryText
 	i.Focus()
 
 	selector := NewSelectorModel[common.StationQuery](
 		theme,
-		"Filter:",
+		i18n.T("search.filter"),
 		[]common.StationQuery{
 			common.StationQueryByName,
 			common.StationQueryByNameExact,
@@ -68,25 +100,134 @@ func NewSearchModel(theme Theme) SearchModel {
 		0,
 	)
 
+	country := newSearchFilterInput(theme, i18n.T("search.country"), filter.CountryCode)
+	country.CharLimit = 2
+	country.Width = 3
+	language := newSearchFilterInput(theme, i18n.T("search.language"), filter.Language)
+	language.Width = 12
+
 	return SearchModel{
 		theme:         theme,
 		inputModel:    i,
 		querySelector: selector,
+		countryInput:  country,
+		languageInput: language,
+		completion:    tagCompletion{selected: -1},
 	}
 
 }
 
+// SetBrowser suggests the tags radio-browser knows while typing a tag search (nil suggests none).
+func (m *SearchModel) SetBrowser(browser api.RadioBrowserService) {
+	m.browser = browser
+}
+
+// SetCatalog suggests the countries and languages kept for the session in c while typing in the filters.
+func (m *SearchModel) SetCatalog(c *catalog) {
+	m.catalog = c
+}
+
+func newSearchFilterInput(theme Theme, prompt string, value string) textinput.Model {
+	i := textinput.New()
+	i.Prompt = prompt + " "
+	i.PromptStyle = theme.SecondaryText
+	i.Placeholder = i18n.T("search.any")
+	i.TextStyle = theme.Text
+	i.PlaceholderStyle = theme.TertiaryText
+	i.SetValue(value)
+	return i
+}
+
+// showsFilter reports whether the country and language filters apply to the selected query.
+func (m SearchModel) showsFilter() bool {
+	return m.querySelector.Selection() == common.StationQueryByName
+}
+
+// filter returns the country and language filters for the selected query.
+func (m SearchModel) filter() common.StationFilter {
+	if !m.showsFilter() {
+		return common.StationFilter{}
+	}
+	return common.StationFilter{
+		CountryCode: strings.ToUpper(strings.TrimSpace(m.countryInput.Value())),
+		Language:    strings.ToLower(strings.TrimSpace(m.languageInput.Value())),
+	}
+}
+
+// textFieldFocused reports whether the name or one of the filters is being typed in.
+func (m SearchModel) textFieldFocused() bool {
+	return m.inputModel.Focused() || m.countryInput.Focused() || m.languageInput.Focused()
+}
+
+// cycleFocus moves the focus to the next field: the name, the filters (if shown) and the query selector.
+func (m *SearchModel) cycleFocus() tea.Cmd {
+	switch {
+	case m.inputModel.Focused():
+		m.inputModel.Blur()
+		if m.showsFilter() {
+			m.countryInput.Focus()
+			if fetch := m.fetchCatalog(); fetch != nil {
+				return tea.Batch(updateCommandsForTextfieldFocus, fetch)
+			}
+			return updateCommandsForTextfieldFocus
+		}
+		m.querySelector.Focus()
+		return updateCommandsForSelectorFocus
+	case m.countryInput.Focused():
+		m.countryInput.Blur()
+		m.languageInput.Focus()
+		return updateCommandsForTextfieldFocus
+	case m.languageInput.Focused():
+		m.languageInput.Blur()
+		m.querySelector.Focus()
+		return updateCommandsForSelectorFocus
+	default:
+		m.inputModel.Focus()
+		m.querySelector.Blur()
+		return updateCommandsForTextfieldFocus
+	}
+}
+
+// fetchCatalog fetches the countries and languages suggested in the filters, the first time they're focused.
+func (m *SearchModel) fetchCatalog() tea.Cmd {
+	if m.browser == nil || m.catalogRequested {
+		return nil
+	}
+	m.catalogRequested = true
+	return tea.Batch(fetchCatalogCmds(m.browser, m.catalog)...)
+}
+
 // Commands
 
 func updateCommandsForTextfieldFocus() tea.Msg {
 	return bottomBarUpdateMsg{
-		commands: []string{"q: quit", "tab: cycle focus", "enter: search"},
+		commands: []string{
+			i18n.T("commands.quit"),
+			i18n.T("commands.cycleFocus"),
+			i18n.T("commands.search"),
+			i18n.T("commands.tags"),
+			i18n.T("commands.charts"),
+			i18n.T("commands.bookmarks"),
+			i18n.T("commands.output"),
+			i18n.T("commands.profiles"),
+			i18n.T("commands.helpAnywhere"),
+		},
 	}
 }
 
 func updateCommandsForSelectorFocus() tea.Msg {
 	return bottomBarUpdateMsg{
-		commands: []string{"q: quit", "tab: cycle focus", "↑/↓: change filter"},
+		commands: []string{
+			i18n.T("commands.quit"),
+			i18n.T("commands.cycleFocus"),
+			i18n.T("commands.changeFilter"),
+			i18n.T("commands.tags"),
+			i18n.T("commands.charts"),
+			i18n.T("commands.bookmarks"),
+			i18n.T("commands.output"),
+			i18n.T("commands.profiles"),
+			i18n.T("commands.helpAnywhere"),
+		},
 	}
 }
 
@@ -99,44 +240,154 @@ func (m SearchModel) Init() tea.Cmd {
 func (m SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
+	case tagCompletionTickMsg:
+		if msg.generation != m.completion.generation || !m.completesTags() {
+			return m, nil
+		}
+		return m, completeTagsCmd(m.browser, m.completionPrefix())
+	case tagsCompletedMsg:
+		if msg.prefix == m.completionPrefix() {
+			m.completion.tags = rankTagCompletions(msg.prefix, msg.tags)
+			m.completion.selected = -1
+		}
+		return m, nil
+	case recentlyPlayedLoadedMsg:
+		m.recentlyPlayed = msg.stations
+		return m, nil
+	case catalogFetchedMsg:
+		switch {
+		case msg.err != nil:
+			// Suggestions are a nicety: the filters can be typed without them
+		case msg.part == catalogCountries:
+			m.countries = msg.countries
+		case msg.part == catalogLanguages:
+			m.languages = msg.languages
+		}
+		return m, nil
+	case stationOfTheDayLoadedMsg:
+		m.stationOfTheDay = &msg.suggestion
+		return m, nil
+	case bookmarkToggledMsg:
+		if msg.bookmarked {
+			return m, showToastCmd(i18n.Tf("bookmarks.added", msg.name), toastSuccess)
+		}
+		return m, nil
 	case tea.KeyMsg:
+		if m.completionShown() {
+			if newModel, cmd, handled := m.updateCompletion(msg); handled {
+				return newModel, cmd
+			}
+		}
+		if m.forgetting {
+			m.forgetting = false
+			return m.forgetRecentlyPlayed(msg.String())
+		}
+		if msg.String() == "alt+x" {
+			m.forgetting = true
+			return m, showToastCmd(i18n.T("history.forgetHint"), toastInfo)
+		}
+		if newModel, cmd, handled := m.updateStationOfTheDay(msg.String()); handled {
+			return newModel, cmd
+		}
+		if i, ok := m.recentlyPlayedIndex(msg.String()); ok {
+			if i < 0 {
+				return m, nil
+			}
+			station := m.recentlyPlayed[i]
+			return m, func() tea.Msg {
+				return recentlyPlayedSelectedMsg{station: station}
+			}
+		}
 		switch msg.String() {
 		case "tab":
-			if m.inputModel.Focused() {
-				m.inputModel.Blur()
-				m.querySelector.Focus()
-				return m, updateCommandsForSelectorFocus
-			} else {
-				m.inputModel.Focus()
-				m.querySelector.Blur()
-				return m, updateCommandsForTextfieldFocus
-			}
+			return m, m.cycleFocus()
 		case "q":
-			if !m.inputModel.Focused() {
+			if !m.textFieldFocused() {
 				return m, quitCmd
 			}
-		case "enter":
-			if !m.inputModel.Focused() {
-				return m, nil
+		case "ctrl+t":
+			return m, func() tea.Msg {
+				return switchToTagCloudModelMsg{}
+			}
+		case "ctrl+r":
+			return m, func() tea.Msg {
+				return switchToChartsModelMsg{}
+			}
+		case "ctrl+b":
+			return m, func() tea.Msg {
+				return switchToBookmarksModelMsg{}
 			}
+		case "ctrl+o":
 			return m, func() tea.Msg {
-				return switchToLoadingModelMsg{
-					query:     m.querySelector.Selection(),
-					queryText: m.inputModel.Value(),
-				}
+				return switchToOutputModelMsg{}
+			}
+		case "ctrl+l":
+			return m, showLikedTracksCmd
+		case "ctrl+x":
+			return m, showBlocklistCmd
+		case "ctrl+n":
+			return m, showDiscoveryCmd
+		case "f1":
+			return m, showHelpCmd
+		case "?":
+			if !m.textFieldFocused() {
+				return m, showHelpCmd
+			}
+		case "o":
+			if !m.textFieldFocused() {
+				return m, openURLCmd
+			}
+		case "u":
+			if !m.textFieldFocused() {
+				return m, undoCmd
+			}
+		case "ctrl+p":
+			return m, func() tea.Msg {
+				return switchToProfilesModelMsg{}
+			}
+		case "ctrl+g":
+			// Hidden: how the radio-browser mirrors have been answering
+			return m, func() tea.Msg {
+				return switchToDiagnosticsModelMsg{}
+			}
+		case "enter":
+			if !m.textFieldFocused() {
+				return m, nil
 			}
+			return m.submit()
 		}
 	}
 
 	var cmds []tea.Cmd
 
 	newInputModel, inputCmd := m.inputModel.Update(msg)
+	edited := newInputModel.Value() != m.inputModel.Value()
 	m.inputModel = newInputModel
+	if edited {
+		m.syntaxErr = nil
+		if completeCmd := m.completeTags(); completeCmd != nil {
+			cmds = append(cmds, completeCmd)
+		}
+	}
 
 	if inputCmd != nil {
 		cmds = append(cmds, inputCmd)
 	}
 
+	newCountryInput, countryCmd := m.countryInput.Update(msg)
+	m.countryInput = newCountryInput
+
+	if countryCmd != nil {
+		cmds = append(cmds, countryCmd)
+	}
+
+	newLanguageInput, languageCmd := m.languageInput.Update(msg)
+	m.languageInput = newLanguageInput
+
+	if languageCmd != nil {
+		cmds = append(cmds, languageCmd)
+	}
+
 	newSelectorModel, selectorCmd := m.querySelector.Update(msg)
 	m.querySelector = newSelectorModel
 
@@ -149,20 +400,36 @@ func (m SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m SearchModel) View() string {
 
-	searchType := m.querySelector.Selection().Render()
-	searchType = strings.ToLower(searchType)
+	if m.theme.Accessible {
+		return fmt.Sprintf("\n%s\n\n%s\n\n%s\n%s%s",
+			m.querySelector.Selection().SearchTitle(),
+			m.inputModel.View()+m.completionView()+m.filterView()+m.syntaxErrorView(),
+			m.querySelector.View(),
+			m.querySelector.Selection().ExampleString(),
+			m.recentlyPlayedView()+m.stationOfTheDayView(),
+		)
+	}
 
 	rightOfLogoStyle := lipgloss.NewStyle().
 		PaddingLeft(2)
 
+	if !m.showsLogo() {
+		rightOfLogoStyle = rightOfLogoStyle.PaddingLeft(1)
+	}
+
 	rightV := rightOfLogoStyle.Render(
-		fmt.Sprintf("\n%s\n\n%s\n\n%s\n%s",
-			m.theme.SecondaryText.Render(fmt.Sprint("Search radio ", searchType)),
-			m.inputModel.View(),
+		fmt.Sprintf("\n%s\n\n%s\n\n%s\n%s%s",
+			m.theme.SecondaryText.Render(m.querySelector.Selection().SearchTitle()),
+			m.inputView()+m.completionView()+m.filterView()+m.syntaxErrorView(),
 			m.querySelector.View(),
 			m.theme.TertiaryText.Render(m.querySelector.Selection().ExampleString()),
+			m.recentlyPlayedView()+m.stationOfTheDayView(),
 		))
 
+	if !m.showsLogo() {
+		return lipgloss.NewStyle().MaxWidth(m.width).Render(rightV)
+	}
+
 	leftV := fmt.Sprintf(
 		"\n%s\n\n",
 		assets.Logo,
@@ -173,7 +440,83 @@ func (m SearchModel) View() string {
 	return v
 }
 
+// filterView renders the country and language filters on their own line, if they apply to the selected query.
+func (m SearchModel) filterView() string {
+	if !m.showsFilter() {
+		return ""
+	}
+	return "\n" + m.countryInput.View() + "  " + m.languageInput.View() + m.filterSuggestionsView()
+}
+
+// How many countries or languages are suggested while typing in the filters
+const filterSuggestionsShown = 3
+
+// filterSuggestionsView renders the countries or languages matching what's typed in the focused filter,
+// the ones with the most stations first.
+func (m SearchModel) filterSuggestionsView() string {
+	var suggestions []string
+	switch {
+	case m.countryInput.Focused():
+		typed := strings.ToLower(strings.TrimSpace(m.countryInput.Value()))
+		if typed == "" {
+			return ""
+		}
+		countries := append([]common.Country(nil), m.countries...)
+		sort.SliceStable(countries, func(i, j int) bool { return countries[i].StationCount > countries[j].StationCount })
+		for _, country := range countries {
+			if strings.HasPrefix(strings.ToLower(country.Code), typed) || strings.Contains(strings.ToLower(country.Name), typed) {
+				suggestions = append(suggestions, strings.ToUpper(country.Code)+" "+country.Name)
+			}
+		}
+	case m.languageInput.Focused():
+		typed := strings.ToLower(strings.TrimSpace(m.languageInput.Value()))
+		if typed == "" {
+			return ""
+		}
+		languages := append([]common.Language(nil), m.languages...)
+		sort.SliceStable(languages, func(i, j int) bool { return languages[i].StationCount > languages[j].StationCount })
+		for _, language := range languages {
+			if strings.HasPrefix(strings.ToLower(language.Name), typed) {
+				suggestions = append(suggestions, language.Name)
+			}
+		}
+	}
+	if len(suggestions) == 0 {
+		return ""
+	}
+	if len(suggestions) > filterSuggestionsShown {
+		suggestions = suggestions[:filterSuggestionsShown]
+	}
+	text := truncateText(displayText(i18n.Tf("search.filterSuggestions", strings.Join(suggestions, ", "))), searchFormWidth)
+	if m.theme.Accessible {
+		return "\n" + text
+	}
+	return "\n" + m.theme.TertiaryText.Render(text)
+}
+
+// showsLogo returns true if the terminal is large enough to fit the logo next to the search form.
+// The logo is shown until the size of the terminal is known.
+func (m SearchModel) showsLogo() bool {
+	if m.width == 0 {
+		return true
+	}
+	return m.width >= lipgloss.Width(string(assets.Logo))+searchFormWidth &&
+		m.height >= lipgloss.Height(string(assets.Logo))+3
+}
+
 func (m *SearchModel) SetWidthAndHeight(width int, height int) {
 	m.width = width
 	m.height = height
+	if width == 0 {
+		return
+	}
+	// Shrink the search input to fit narrow terminals (padding, prompt and cursor excluded)
+	inputWidth := width - 6
+	if inputWidth > searchInputWidth {
+		inputWidth = searchInputWidth
+	}
+	if inputWidth < searchInputMinWidth {
+		inputWidth = searchInputMinWidth
+	}
+	m.inputModel.Width = inputWidth
 }
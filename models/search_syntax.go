@@ -0,0 +1,117 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// submit searches for what was typed. Name searches are parsed with the query language first,
+// e.g. `smooth tag:jazz bitrate>128`, its fields taking precedence over the country and language filters.
+func (m SearchModel) submit() (tea.Model, tea.Cmd) {
+	query := m.querySelector.Selection()
+	queryText := m.inputModel.Value()
+	filter := m.filter()
+	if m.showsFilter() {
+		parsed, err := common.ParseSearchQuery(queryText)
+		if err != nil {
+			m.syntaxErr = err
+			return m, nil
+		}
+		queryText = parsed.Name
+		filter = filter.Merge(parsed.Filter)
+	}
+	return m, func() tea.Msg {
+		return switchToLoadingModelMsg{
+			query:     query,
+			queryText: queryText,
+			filter:    filter,
+		}
+	}
+}
+
+// syntaxErrorView renders why the query couldn't be parsed on its own line, if it couldn't.
+func (m SearchModel) syntaxErrorView() string {
+	if m.syntaxErr == nil {
+		return ""
+	}
+	return "\n" + m.theme.ErrorText.Render(m.syntaxErr.Error())
+}
+
+// queryTokenStyle returns how tokens of the given kind are highlighted.
+func (m SearchModel) queryTokenStyle(kind common.QueryTokenKind) lipgloss.Style {
+	switch kind {
+	case common.QueryTokenField:
+		return m.theme.SecondaryText
+	case common.QueryTokenValue:
+		return m.theme.PrimaryText
+	case common.QueryTokenInvalid:
+		return m.theme.ErrorText
+	}
+	return m.inputModel.TextStyle
+}
+
+// inputView renders the name input like textinput does, with the tokens of the query highlighted.
+// Text that doesn't fit, which textinput scrolls, and other queries are rendered as they are.
+func (m SearchModel) inputView() string {
+	input := m.inputModel
+	value := []rune(input.Value())
+	if len(value) == 0 || !m.showsFilter() || lipgloss.Width(input.Value()) >= input.Width {
+		return input.View()
+	}
+
+	// Spaces between tokens are styled like names.
+	kinds := make([]common.QueryTokenKind, len(value))
+	parsed, _ := common.ParseSearchQuery(input.Value())
+	for _, token := range parsed.Tokens {
+		for i := token.Start; i < token.End; i++ {
+			kinds[i] = token.Kind
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(input.PromptStyle.Render(input.Prompt))
+	pos := input.Position()
+	for start := 0; start < len(value); {
+		if start == pos && input.Focused() {
+			input.Cursor.SetChar(string(value[pos]))
+			b.WriteString(input.Cursor.View())
+			start++
+			continue
+		}
+		// Runs of runes styled alike are rendered at once, stopping at the cursor.
+		end := start + 1
+		for end < len(value) && kinds[end] == kinds[start] && (end != pos || !input.Focused()) {
+			end++
+		}
+		b.WriteString(m.queryTokenStyle(kinds[start]).Inline(true).Render(string(value[start:end])))
+		start = end
+	}
+	if pos >= len(value) && input.Focused() {
+		input.Cursor.SetChar(" ")
+		b.WriteString(input.Cursor.View())
+	}
+	return b.String()
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchModel_QueryLanguage(t *testing.T) {
+
+	t.Run("parses fields into the filter, taking precedence over the filter inputs", func(t *testing.T) {
+
+		model := NewSearchModel(Theme{}, common.StationFilter{CountryCode: "GB", Language: "english"})
+		model.inputModel.SetValue(`smooth tag:"Classic Rock" country:it bitrate>128 jazz`)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.NotNil(t, cmd)
+
+		assert.Equal(t, switchToLoadingModelMsg{
+			query:     common.StationQueryByName,
+			queryText: "smooth jazz",
+			filter: common.StationFilter{
+				CountryCode: "IT",
+				Language:    "english",
+				Tag:         "classic rock",
+				BitrateMin:  129,
+			},
+		}, cmd())
+
+	})
+
+	t.Run("parses country names and bitrate ranges", func(t *testing.T) {
+
+		model := NewSearchModel(Theme{}, common.StationFilter{})
+		model.inputModel.SetValue(`country:"United Kingdom" state:London codec:aac bitrate>=64 bitrate<=128 LANGUAGE:English`)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.NotNil(t, cmd)
+
+		assert.Equal(t, switchToLoadingModelMsg{
+			query: common.StationQueryByName,
+			filter: common.StationFilter{
+				Country:    "United Kingdom",
+				State:      "London",
+				Codec:      "AAC",
+				Language:   "english",
+				BitrateMin: 64,
+				BitrateMax: 128,
+			},
+		}, cmd())
+
+	})
+
+	t.Run("reports parse errors instead of searching", func(t *testing.T) {
+
+		testCases := []struct {
+			query    string
+			expected string
+		}{
+			{"jazz genre:smooth", `Unknown field "genre"`},
+			{"tag:", `"tag:" needs a value`},
+			{"tag>jazz", `Only bitrate can be compared`},
+			{"bitrate>fast", `Invalid bitrate in "bitrate>fast"`},
+			{"bitrate<0", `Invalid bitrate in "bitrate<0"`},
+			{`tag:"classic rock`, `Missing closing quote`},
+		}
+
+		for _, tc := range testCases {
+			model := NewSearchModel(Theme{}, common.StationFilter{})
+			model.inputModel.SetValue(tc.query)
+
+			newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+			model = newModel.(SearchModel)
+
+			assert.Nil(t, cmd, tc.query)
+			assert.Contains(t, model.View(), tc.expected, tc.query)
+		}
+
+	})
+
+	t.Run("clears the parse error once the query is edited", func(t *testing.T) {
+
+		model := NewSearchModel(Theme{}, common.StationFilter{})
+		model.inputModel.SetValue("genre:jazz")
+
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		model = newModel.(SearchModel)
+		assert.Error(t, model.syntaxErr)
+
+		newModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+		model = newModel.(SearchModel)
+		assert.NoError(t, model.syntaxErr)
+
+	})
+
+	t.Run("keeps the query text while highlighting it", func(t *testing.T) {
+
+		model := NewSearchModel(Theme{}, common.StationFilter{})
+		model.inputModel.SetValue("smooth tag:jazz")
+
+		assert.Contains(t, model.View(), "smooth tag:jazz")
+
+	})
+
+	t.Run("doesn't parse other searches", func(t *testing.T) {
+
+		model := NewSearchModel(Theme{}, common.StationFilter{})
+		model.querySelector.Focus()
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyDown})
+		model = newModel.(SearchModel)
+		model.querySelector.Blur()
+		model.inputModel.Focus()
+		model.inputModel.SetValue("genre:jazz")
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.NotNil(t, cmd)
+
+		assert.Equal(t, switchToLoadingModelMsg{
+			query:     common.StationQueryByNameExact,
+			queryText: "genre:jazz",
+		}, cmd())
+
+	})
+
+}
@@ -22,10 +22,12 @@ package models
 import (
 	"testing"
 
+	"github.com/zi0p4tch0/radiogogo/assets"
 	"github.com/zi0p4tch0/radiogogo/common"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,7 +35,7 @@ func TestSearchModel_Init(t *testing.T) {
 
 	t.Run("starts blinking the input field cursor", func(t *testing.T) {
 
-		model := NewSearchModel(Theme{})
+		model := NewSearchModel(Theme{}, common.StationFilter{})
 
 		cmd := model.Init()
 		assert.NotNil(t, cmd)
@@ -55,7 +57,7 @@ func TestSearchModel_Init(t *testing.T) {
 
 	t.Run("broadcasts a bottomBarUpdateMsg", func(t *testing.T) {
 
-		model := NewSearchModel(Theme{})
+		model := NewSearchModel(Theme{}, common.StationFilter{})
 
 		cmd := model.Init()
 		assert.NotNil(t, cmd)
@@ -75,7 +77,7 @@ func TestSearchModel_Init(t *testing.T) {
 
 		assert.True(t, found)
 
-		expectedCommands := []string{"q: quit", "tab: cycle focus", "enter: search"}
+		expectedCommands := []string{"q: quit", "tab: cycle focus", "enter: search", "ctrl+t: tags", "ctrl+r: charts", "ctrl+b: bookmarks", "ctrl+o: output", "ctrl+p: profiles", "f1: help"}
 
 		assert.Equal(t, expectedCommands, commands)
 
@@ -87,7 +89,7 @@ func TestSearchModel_Update(t *testing.T) {
 
 	t.Run("does not broadcast quitMsg when 'q' is pressed and textarea is focused", func(t *testing.T) {
 
-		model := NewSearchModel(Theme{})
+		model := NewSearchModel(Theme{}, common.StationFilter{})
 
 		model.inputModel.Focus()
 		model.querySelector.Blur()
@@ -105,7 +107,7 @@ func TestSearchModel_Update(t *testing.T) {
 
 	t.Run("broadcasts a quitMsg when 'q' is pressed and textarea is not focused", func(t *testing.T) {
 
-		model := NewSearchModel(Theme{})
+		model := NewSearchModel(Theme{}, common.StationFilter{})
 
 		model.inputModel.Blur()
 		model.querySelector.Focus()
@@ -123,7 +125,7 @@ func TestSearchModel_Update(t *testing.T) {
 
 	t.Run("broadcasts a switchToLoadingModelMsg when 'enter' is pressed, propagating text area value", func(t *testing.T) {
 
-		model := NewSearchModel(Theme{})
+		model := NewSearchModel(Theme{}, common.StationFilter{})
 		model.inputModel.SetValue("fancy value")
 
 		input := tea.KeyMsg{Type: tea.KeyEnter}
@@ -142,7 +144,7 @@ func TestSearchModel_Update(t *testing.T) {
 
 	t.Run("ignores 'enter' when is not in focus", func(t *testing.T) {
 
-		model := NewSearchModel(Theme{})
+		model := NewSearchModel(Theme{}, common.StationFilter{})
 		model.inputModel.SetValue("fancy value")
 		model.inputModel.Blur()
 
@@ -155,19 +157,32 @@ func TestSearchModel_Update(t *testing.T) {
 
 	t.Run("cycles focused input when 'tab' is pressed and updates bottom bar", func(t *testing.T) {
 
-		model := NewSearchModel(Theme{})
+		model := NewSearchModel(Theme{}, common.StationFilter{})
 
 		input := tea.KeyMsg{Type: tea.KeyTab}
 
 		newModel, cmd := model.Update(input)
 
 		assert.Equal(t, newModel.(SearchModel).inputModel.Focused(), false)
-		assert.Equal(t, newModel.(SearchModel).querySelector.Focused(), true)
+		assert.Equal(t, newModel.(SearchModel).countryInput.Focused(), true)
 		assert.NotNil(t, cmd)
 
 		msg := cmd()
 		assert.IsType(t, bottomBarUpdateMsg{}, msg)
 
+		newModel, _ = newModel.Update(input)
+
+		assert.Equal(t, newModel.(SearchModel).countryInput.Focused(), false)
+		assert.Equal(t, newModel.(SearchModel).languageInput.Focused(), true)
+
+		newModel, cmd = newModel.Update(input)
+
+		assert.Equal(t, newModel.(SearchModel).languageInput.Focused(), false)
+		assert.Equal(t, newModel.(SearchModel).querySelector.Focused(), true)
+
+		msg = cmd()
+		assert.IsType(t, bottomBarUpdateMsg{}, msg)
+
 		newModel, cmd = newModel.Update(input)
 
 		assert.Equal(t, newModel.(SearchModel).inputModel.Focused(), true)
@@ -178,9 +193,73 @@ func TestSearchModel_Update(t *testing.T) {
 
 	})
 
+	t.Run("skips the country and language filters when the query is not by name", func(t *testing.T) {
+
+		model := NewSearchModel(Theme{}, common.StationFilter{})
+		model.querySelector.Focus()
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyDown})
+		model = newModel.(SearchModel)
+		model.querySelector.Blur()
+		model.inputModel.Focus()
+
+		newModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyTab})
+
+		assert.Equal(t, common.StationQueryByNameExact, newModel.(SearchModel).querySelector.Selection())
+		assert.False(t, newModel.(SearchModel).countryInput.Focused())
+		assert.True(t, newModel.(SearchModel).querySelector.Focused())
+
+	})
+
+	t.Run("pre-populates the country and language filters", func(t *testing.T) {
+
+		model := NewSearchModel(Theme{}, common.StationFilter{CountryCode: "IT", Language: "italian"})
+
+		assert.Equal(t, "IT", model.countryInput.Value())
+		assert.Equal(t, "italian", model.languageInput.Value())
+		assert.Contains(t, model.View(), "italian")
+
+	})
+
+	t.Run("applies the country and language filters to name searches", func(t *testing.T) {
+
+		model := NewSearchModel(Theme{}, common.StationFilter{CountryCode: "it", Language: " Italian "})
+		model.inputModel.SetValue("rai")
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.NotNil(t, cmd)
+
+		assert.Equal(t, switchToLoadingModelMsg{
+			query:     common.StationQueryByName,
+			queryText: "rai",
+			filter:    common.StationFilter{CountryCode: "IT", Language: "italian"},
+		}, cmd())
+
+	})
+
+	t.Run("doesn't apply the country and language filters to other searches", func(t *testing.T) {
+
+		model := NewSearchModel(Theme{}, common.StationFilter{CountryCode: "IT", Language: "italian"})
+		model.querySelector.Focus()
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyDown})
+		model = newModel.(SearchModel)
+		model.querySelector.Blur()
+		model.inputModel.Focus()
+		model.inputModel.SetValue("jazz")
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.NotNil(t, cmd)
+
+		assert.Equal(t, switchToLoadingModelMsg{
+			query:     common.StationQueryByNameExact,
+			queryText: "jazz",
+		}, cmd())
+		assert.NotContains(t, model.View(), "italian")
+
+	})
+
 }
 func TestUpdateCommandsForTextfieldFocus(t *testing.T) {
-	expectedCommands := []string{"q: quit", "tab: cycle focus", "enter: search"}
+	expectedCommands := []string{"q: quit", "tab: cycle focus", "enter: search", "ctrl+t: tags", "ctrl+r: charts", "ctrl+b: bookmarks", "ctrl+o: output", "ctrl+p: profiles", "f1: help"}
 
 	msg := updateCommandsForTextfieldFocus()
 
@@ -191,7 +270,7 @@ func TestUpdateCommandsForTextfieldFocus(t *testing.T) {
 }
 
 func TestUpdateCommandsForSelectorFocus(t *testing.T) {
-	expectedCommands := []string{"q: quit", "tab: cycle focus", "↑/↓: change filter"}
+	expectedCommands := []string{"q: quit", "tab: cycle focus", "↑/↓: change filter", "ctrl+t: tags", "ctrl+r: charts", "ctrl+b: bookmarks", "ctrl+o: output", "ctrl+p: profiles", "f1: help"}
 
 	msg := updateCommandsForSelectorFocus()
 
@@ -200,3 +279,38 @@ func TestUpdateCommandsForSelectorFocus(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, expectedCommands, updateMsg.commands)
 }
+
+func TestSearchModel_View(t *testing.T) {
+
+	t.Run("shows the logo when the terminal is large enough", func(t *testing.T) {
+
+		model := NewSearchModel(Theme{}, common.StationFilter{})
+		model.SetWidthAndHeight(120, 40)
+
+		assert.True(t, model.showsLogo())
+		assert.Greater(t, lipgloss.Width(model.View()), lipgloss.Width(string(assets.Logo)))
+		assert.Equal(t, searchInputWidth, model.inputModel.Width)
+
+	})
+
+	t.Run("hides the logo and shrinks the input in narrow terminals", func(t *testing.T) {
+
+		model := NewSearchModel(Theme{}, common.StationFilter{})
+		model.SetWidthAndHeight(26, 40)
+
+		assert.False(t, model.showsLogo())
+		assert.Less(t, lipgloss.Width(model.View()), lipgloss.Width(string(assets.Logo)))
+		assert.Equal(t, 20, model.inputModel.Width)
+
+	})
+
+	t.Run("hides the logo in short terminals", func(t *testing.T) {
+
+		model := NewSearchModel(Theme{}, common.StationFilter{})
+		model.SetWidthAndHeight(120, 12)
+
+		assert.False(t, model.showsLogo())
+
+	})
+
+}
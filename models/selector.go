@@ -22,6 +22,8 @@ package models
 import (
 	"fmt"
 
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -100,6 +102,10 @@ func (m SelectorModel[T]) View() string {
 
 	v := m.theme.SecondaryText.Bold(true).Render(m.title) + "\n\n"
 
+	if m.theme.Accessible {
+		return v + m.accessibleItemsView()
+	}
+
 	for i, item := range m.items {
 		if i == m.selection {
 			if m.focus {
@@ -127,3 +133,20 @@ func (m SelectorModel[T]) View() string {
 	return v
 
 }
+
+// accessibleItemsView renders the items as plain lines, marking the selected one with words and a large cursor.
+func (m SelectorModel[T]) accessibleItemsView() string {
+	var v string
+	for i, item := range m.items {
+		switch {
+		case i == m.selection && m.focus:
+			v += ">>> " + item.Render() + " (" + i18n.T("accessible.selected") + ")"
+		case i == m.selection:
+			v += "    " + item.Render() + " (" + i18n.T("accessible.selected") + ")"
+		default:
+			v += "    " + item.Render()
+		}
+		v += "\n"
+	}
+	return v
+}
@@ -76,6 +76,13 @@ func TestSelectorModel(t *testing.T) {
 		assert.Equal(t, expected, model.View())
 	})
 
+	t.Run("view returns plain lines with a large cursor in accessible mode", func(t *testing.T) {
+		model := NewSelectorModel(NewAccessibleTheme(), "Title", items, 1)
+		model.Focus()
+		expected := "Title\n\n    Item 1\n>>> Item 2 (selected)\n    Item 3\n"
+		assert.Equal(t, expected, model.View())
+	})
+
 	t.Run("view returns the correct string with a different selection", func(t *testing.T) {
 		model := NewSelectorModel(Theme{}, "Title", items, 0)
 		model.selection = 2
@@ -0,0 +1,216 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/playback"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// How many recommendations are listed
+	similarSize = 25
+	// How many of the station's tags are searched for, in the order it lists them
+	similarTagQueries = 3
+	// How many stations each query brings in
+	similarCandidates = 50
+)
+
+// How much each trait shared with the station counts for when ranking recommendations.
+const (
+	similarTagWeight      = 3
+	similarLanguageWeight = 2
+	similarCountryWeight  = 1
+)
+
+// ErrNothingToGoBy is returned when a station has no tags, language or country to find similar stations by.
+var ErrNothingToGoBy = i18n.Error("similar.nothingToGoBy")
+
+// similarQuery is a search for stations sharing a trait with another one.
+type similarQuery struct {
+	query common.StationQuery
+	term  string
+}
+
+// similarQueries returns the searches bringing in stations sharing the station's main tags, language or country.
+func similarQueries(station common.Station) []similarQuery {
+	var queries []similarQuery
+	tags := stationTraits(station.Tags)
+	if len(tags) > similarTagQueries {
+		tags = tags[:similarTagQueries]
+	}
+	for _, tag := range tags {
+		queries = append(queries, similarQuery{query: common.StationQueryByTagExact, term: tag})
+	}
+	if languages := stationTraits(station.Languages); len(languages) > 0 {
+		queries = append(queries, similarQuery{query: common.StationQueryByLanguageExact, term: languages[0]})
+	}
+	if station.CountryCode != "" {
+		queries = append(queries, similarQuery{query: common.StationQueryByCountryCodeExact, term: station.CountryCode})
+	}
+	return queries
+}
+
+// showSimilarStations stops the station being played and lists the stations most like the given one.
+func showSimilarStations(playbackManager playback.PlaybackManagerService, station common.Station) tea.Cmd {
+	if len(similarQueries(station)) == 0 {
+		return nonFatalErrorCmd(ErrNothingToGoBy)
+	}
+	return tea.Sequence(
+		stopStationCmd(playbackManager),
+		func() tea.Msg {
+			return switchToLoadingModelMsg{query: stationQuerySimilar, queryText: station.StationUuid.String()}
+		},
+	)
+}
+
+// fetchSimilarStations returns the stations most like the one with the given UUID.
+func fetchSimilarStations(browser api.RadioBrowserService, stationUuid string) ([]common.Station, error) {
+	found, err := browser.GetStations(common.StationQueryByUuid, stationUuid, "", false, 0, 1, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return []common.Station{}, nil
+	}
+	seed := found[0]
+
+	queries := similarQueries(seed)
+	if len(queries) == 0 {
+		return nil, ErrNothingToGoBy
+	}
+	var candidates []common.Station
+	var firstErr error
+	failed := 0
+	for _, q := range queries {
+		stations, err := browser.GetStations(q.query, q.term, "votes", true, 0, similarCandidates, true)
+		if err != nil {
+			// The other searches may still bring in enough
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+			continue
+		}
+		candidates = append(candidates, stations...)
+	}
+	if failed == len(queries) {
+		return nil, firstErr
+	}
+	return rankSimilarStations(seed, candidates, similarSize), nil
+}
+
+// rankSimilarStations returns at most limit candidates, without duplicates or the station itself,
+// ranked by how many traits they share with the station, then by votes.
+func rankSimilarStations(station common.Station, candidates []common.Station, limit int) []common.Station {
+	type scored struct {
+		station common.Station
+		score   int
+	}
+	seen := map[string]bool{
+		station.StationUuid.String(): true,
+		streamKey(station):           true,
+	}
+	var ranked []scored
+	for _, candidate := range candidates {
+		// The same station is often listed more than once, under other names or as another entry for the same stream
+		if seen[candidate.StationUuid.String()] || seen[streamKey(candidate)] {
+			continue
+		}
+		seen[candidate.StationUuid.String()] = true
+		seen[streamKey(candidate)] = true
+		ranked = append(ranked, scored{station: candidate, score: similarity(station, candidate)})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].station.Votes > ranked[j].station.Votes
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	stations := make([]common.Station, len(ranked))
+	for i, r := range ranked {
+		stations[i] = r.station
+	}
+	return stations
+}
+
+// similarity scores how many traits two stations share: tags, languages and country.
+func similarity(a common.Station, b common.Station) int {
+	score := similarTagWeight * countShared(stationTraits(a.Tags), stationTraits(b.Tags))
+	if countShared(stationTraits(a.Languages), stationTraits(b.Languages)) > 0 {
+		score += similarLanguageWeight
+	}
+	if a.CountryCode != "" && strings.EqualFold(a.CountryCode, b.CountryCode) {
+		score += similarCountryWeight
+	}
+	return score
+}
+
+// stationTraits splits a comma-separated list of tags or languages, lower-cased and without duplicates.
+func stationTraits(list string) []string {
+	var traits []string
+	seen := make(map[string]bool)
+	for _, trait := range strings.Split(list, ",") {
+		trait = strings.ToLower(strings.TrimSpace(trait))
+		if trait == "" || seen[trait] {
+			continue
+		}
+		seen[trait] = true
+		traits = append(traits, trait)
+	}
+	return traits
+}
+
+// countShared returns how many of a are also in b.
+func countShared(a []string, b []string) int {
+	shared := 0
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				shared++
+				break
+			}
+		}
+	}
+	return shared
+}
+
+// streamKey identifies the stream of a station, whatever entry it's listed under.
+func streamKey(station common.Station) string {
+	streamUrl := station.UrlResolved.URL
+	if streamUrl.Host == "" {
+		streamUrl = station.Url.URL
+	}
+	if streamUrl.Host == "" {
+		// Nothing to tell it apart by but its UUID
+		return station.StationUuid.String()
+	}
+	return "url:" + strings.TrimSuffix(strings.ToLower(streamUrl.Host)+streamUrl.Path, "/")
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func similarTestURL(t *testing.T, raw string) common.RadioGoGoURL {
+	u, err := url.Parse(raw)
+	assert.NoError(t, err)
+	return common.RadioGoGoURL{URL: *u}
+}
+
+func TestSimilarQueries(t *testing.T) {
+
+	station := common.Station{Tags: "Jazz, smooth jazz,jazz,lounge,chill", Languages: "english,french", CountryCode: "US"}
+
+	assert.Equal(t, []similarQuery{
+		{query: common.StationQueryByTagExact, term: "jazz"},
+		{query: common.StationQueryByTagExact, term: "smooth jazz"},
+		{query: common.StationQueryByTagExact, term: "lounge"},
+		{query: common.StationQueryByLanguageExact, term: "english"},
+		{query: common.StationQueryByCountryCodeExact, term: "US"},
+	}, similarQueries(station))
+
+	assert.Empty(t, similarQueries(common.Station{Name: "Nothing known"}))
+}
+
+func TestRankSimilarStations(t *testing.T) {
+
+	seed := common.Station{
+		StationUuid: uuid.New(),
+		Name:        "Jazz FM",
+		Url:         similarTestURL(t, "http://jazz.example/stream"),
+		Tags:        "jazz,lounge",
+		Languages:   "english",
+		CountryCode: "GB",
+	}
+	sameStream := common.Station{StationUuid: uuid.New(), Name: "Jazz FM (mirror)", Url: similarTestURL(t, "http://JAZZ.example/stream/"), Tags: "jazz,lounge"}
+	bothTags := common.Station{StationUuid: uuid.New(), Name: "Lounge Jazz", Url: similarTestURL(t, "http://lounge.example/"), Tags: "lounge,jazz", Votes: 10}
+	oneTagPopular := common.Station{StationUuid: uuid.New(), Name: "Jazz Popular", Url: similarTestURL(t, "http://popular.example/"), Tags: "jazz", Votes: 5000}
+	oneTagLocal := common.Station{StationUuid: uuid.New(), Name: "Jazz London", Url: similarTestURL(t, "http://london.example/"), Tags: "jazz", Languages: "English", CountryCode: "gb", Votes: 1}
+	countryOnly := common.Station{StationUuid: uuid.New(), Name: "BBC", Url: similarTestURL(t, "http://bbc.example/"), CountryCode: "GB", Votes: 9000}
+
+	candidates := []common.Station{seed, countryOnly, oneTagPopular, sameStream, bothTags, oneTagLocal, bothTags, oneTagPopular}
+
+	ranked := rankSimilarStations(seed, candidates, 10)
+
+	assert.Equal(t, []string{"Lounge Jazz", "Jazz London", "Jazz Popular", "BBC"}, stationNames(ranked))
+
+	assert.Len(t, rankSimilarStations(seed, candidates, 2), 2)
+}
+
+func stationNames(stations []common.Station) []string {
+	names := make([]string, len(stations))
+	for i, station := range stations {
+		names[i] = station.Name
+	}
+	return names
+}
+
+func TestFetchSimilarStations(t *testing.T) {
+
+	seed := common.Station{StationUuid: uuid.New(), Name: "Seed", Tags: "jazz", CountryCode: "IT"}
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz", Url: similarTestURL(t, "http://jazz.example/"), Tags: "jazz"}
+	italian := common.Station{StationUuid: uuid.New(), Name: "Italian", Url: similarTestURL(t, "http://italian.example/"), CountryCode: "IT"}
+
+	t.Run("searches by each trait of the station", func(t *testing.T) {
+
+		var searched []common.StationQuery
+		mockBrowser := mocks.MockRadioBrowserService{
+			GetStationsFunc: func(query common.StationQuery, term string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				searched = append(searched, query)
+				switch query {
+				case common.StationQueryByUuid:
+					assert.Equal(t, seed.StationUuid.String(), term)
+					return []common.Station{seed}, nil
+				case common.StationQueryByTagExact:
+					assert.Equal(t, "jazz", term)
+					return []common.Station{seed, jazz}, nil
+				default:
+					assert.Equal(t, "IT", term)
+					return []common.Station{italian, seed}, nil
+				}
+			},
+		}
+
+		stations, err := fetchSimilarStations(&mockBrowser, seed.StationUuid.String())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Jazz", "Italian"}, stationNames(stations))
+		assert.Equal(t, []common.StationQuery{common.StationQueryByUuid, common.StationQueryByTagExact, common.StationQueryByCountryCodeExact}, searched)
+	})
+
+	t.Run("keeps what the other searches found when one fails", func(t *testing.T) {
+
+		mockBrowser := mocks.MockRadioBrowserService{
+			GetStationsFunc: func(query common.StationQuery, term string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				switch query {
+				case common.StationQueryByUuid:
+					return []common.Station{seed}, nil
+				case common.StationQueryByTagExact:
+					return nil, io.EOF
+				default:
+					return []common.Station{italian}, nil
+				}
+			},
+		}
+
+		stations, err := fetchSimilarStations(&mockBrowser, seed.StationUuid.String())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Italian"}, stationNames(stations))
+	})
+
+	t.Run("fails when every search fails", func(t *testing.T) {
+
+		mockBrowser := mocks.MockRadioBrowserService{
+			GetStationsFunc: func(query common.StationQuery, term string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				if query == common.StationQueryByUuid {
+					return []common.Station{seed}, nil
+				}
+				return nil, io.EOF
+			},
+		}
+
+		_, err := fetchSimilarStations(&mockBrowser, seed.StationUuid.String())
+
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("refuses a station with nothing to go by", func(t *testing.T) {
+
+		mockBrowser := mocks.MockRadioBrowserService{
+			GetStationsFunc: func(query common.StationQuery, term string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				return []common.Station{{StationUuid: seed.StationUuid, Name: "Bare"}}, nil
+			},
+		}
+
+		_, err := fetchSimilarStations(&mockBrowser, seed.StationUuid.String())
+
+		assert.ErrorIs(t, err, ErrNothingToGoBy)
+	})
+}
+
+func TestShowSimilarStations(t *testing.T) {
+
+	t.Run("tells when there's nothing to go by, without leaving the list", func(t *testing.T) {
+
+		msg := showSimilarStations(&mocks.MockPlaybackManagerService{}, common.Station{Name: "Bare"})()
+
+		assert.Equal(t, nonFatalError{stopPlayback: false, err: ErrNothingToGoBy}, msg)
+	})
+}
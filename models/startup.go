@@ -0,0 +1,145 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+const (
+	// How many stations the history lists, latest first
+	historySize = 50
+	// How many plays are gone through to find them, as stations are often played more than once
+	historyEntriesRead = 10 * historySize
+)
+
+// stationQueryHistory is the query of the stations played lately, which isn't a radio-browser search either.
+const stationQueryHistory common.StationQuery = "history"
+
+// ErrNothingPlayed is returned when the history is needed but no station has been played yet.
+var ErrNothingPlayed = i18n.Error("startup.nothingPlayed")
+
+// ErrNoLastSearch is returned when the last search is needed but none has been made yet.
+var ErrNoLastSearch = i18n.Error("startup.noLastSearch")
+
+// recentStations returns the stations played lately, latest first, each one once.
+func recentStations(history storage.HistoryStore) ([]common.Station, error) {
+	entries, err := history.Recent(historyEntriesRead)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[uuid.UUID]bool)
+	stations := []common.Station{}
+	for _, entry := range entries {
+		if seen[entry.Station.StationUuid] {
+			continue
+		}
+		seen[entry.Station.StationUuid] = true
+		stations = append(stations, entry.Station)
+		if len(stations) == historySize {
+			break
+		}
+	}
+	return stations, nil
+}
+
+// openStartupView opens the view configured to start in, once the boot has completed.
+// It returns false if RadioGoGo starts in the search form.
+func (m Model) openStartupView() (Model, tea.Cmd, bool) {
+	view := m.startupView
+	m.startupView = ""
+	switch view {
+	case config.StartupBookmarks:
+		return m, func() tea.Msg { return switchToBookmarksModelMsg{} }, true
+	case config.StartupHistory:
+		return m, openHistoryCmd(m.history), true
+	case config.StartupLast:
+		return m, openLastSearchCmd(m.searches), true
+	case config.StartupResume:
+		return m, resumeLastStationCmd(m.history), true
+	}
+	return m, nil, false
+}
+
+// Messages
+
+// startupViewFailedMsg falls back to the search form when the view to start in can't be opened.
+type startupViewFailedMsg struct {
+	err error
+}
+
+// Commands
+
+// openHistoryCmd lists the stations played lately.
+func openHistoryCmd(history storage.HistoryStore) tea.Cmd {
+	return func() tea.Msg {
+		if history == nil {
+			return startupViewFailedMsg{err: ErrNothingPlayed}
+		}
+		stations, err := recentStations(history)
+		if err == nil && len(stations) == 0 {
+			err = ErrNothingPlayed
+		}
+		if err != nil {
+			return startupViewFailedMsg{err: err}
+		}
+		return switchToStationsModelMsg{stations: stations, page: stationPageKey{query: stationQueryHistory}}
+	}
+}
+
+// openLastSearchCmd makes the last search again.
+func openLastSearchCmd(searches storage.SearchStore) tea.Cmd {
+	return func() tea.Msg {
+		if searches == nil {
+			return startupViewFailedMsg{err: ErrNoLastSearch}
+		}
+		search, found, err := searches.Last()
+		if err == nil && !found {
+			err = ErrNoLastSearch
+		}
+		if err != nil {
+			return startupViewFailedMsg{err: err}
+		}
+		return switchToLoadingModelMsg{query: search.Query, queryText: search.QueryText, filter: search.Filter}
+	}
+}
+
+// resumeLastStationCmd plays the last station played again.
+func resumeLastStationCmd(history storage.HistoryStore) tea.Cmd {
+	return func() tea.Msg {
+		if history == nil {
+			return startupViewFailedMsg{err: ErrNothingPlayed}
+		}
+		entries, err := history.Recent(1)
+		if err == nil && len(entries) == 0 {
+			err = ErrNothingPlayed
+		}
+		if err != nil {
+			return startupViewFailedMsg{err: err}
+		}
+		return playURLCmd(entries[0].Station)()
+	}
+}
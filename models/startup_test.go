@@ -0,0 +1,149 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStartupTestModel(view config.StartupView, history storage.HistoryStore, searches storage.SearchStore) Model {
+	cfg := config.Config{}
+	cfg.Startup.View = view
+	model := NewModel(cfg, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{}, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+	model.history = history
+	model.searches = searches
+	model.bus = newSessionBus(history, searches, nil)
+	return model
+}
+
+func historyOf(stations ...common.Station) *mocks.MockHistoryStore {
+	return &mocks.MockHistoryStore{
+		RecentFunc: func(limit int) ([]storage.HistoryEntry, error) {
+			var entries []storage.HistoryEntry
+			for _, station := range stations {
+				if len(entries) == limit {
+					break
+				}
+				entries = append(entries, storage.HistoryEntry{Station: station, PlayedAt: time.Now()})
+			}
+			return entries, nil
+		},
+	}
+}
+
+func TestRecentStations(t *testing.T) {
+
+	first := common.Station{StationUuid: uuid.New(), Name: "First"}
+	second := common.Station{StationUuid: uuid.New(), Name: "Second"}
+
+	t.Run("lists each station once, latest first", func(t *testing.T) {
+		stations, err := recentStations(historyOf(first, second, first, first))
+		assert.NoError(t, err)
+		assert.Equal(t, []common.Station{first, second}, stations)
+	})
+
+	t.Run("lists up to historySize stations", func(t *testing.T) {
+		var played []common.Station
+		for i := 0; i < historySize+10; i++ {
+			played = append(played, common.Station{StationUuid: uuid.New()})
+		}
+		stations, err := recentStations(historyOf(played...))
+		assert.NoError(t, err)
+		assert.Equal(t, played[:historySize], stations)
+	})
+}
+
+func TestModel_StartupView(t *testing.T) {
+
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM", Url: common.RadioGoGoURL{URL: url.URL{Scheme: "http", Host: "jazz.fm"}}}
+
+	t.Run("opens the search form by default", func(t *testing.T) {
+		model := newStartupTestModel("", historyOf(station), &mocks.MockSearchStore{})
+
+		newModel, _ := model.Update(switchToSearchModelMsg{})
+		assert.Equal(t, searchState, newModel.(Model).state)
+	})
+
+	t.Run("opens the bookmarks", func(t *testing.T) {
+		model := newStartupTestModel(config.StartupBookmarks, nil, nil)
+
+		_, cmd := model.Update(switchToSearchModelMsg{})
+		assert.Equal(t, switchToBookmarksModelMsg{}, cmd())
+	})
+
+	t.Run("lists the stations played lately", func(t *testing.T) {
+		model := newStartupTestModel(config.StartupHistory, historyOf(station, station), nil)
+
+		_, cmd := model.Update(switchToSearchModelMsg{})
+		msg := cmd().(switchToStationsModelMsg)
+		assert.Equal(t, []common.Station{station}, msg.stations)
+		assert.False(t, msg.page.fetchable())
+	})
+
+	t.Run("makes the last search again", func(t *testing.T) {
+		search := storage.Search{Query: common.StationQueryByName, QueryText: "jazz", Filter: common.StationFilter{CountryCode: "IT"}}
+		model := newStartupTestModel(config.StartupLast, nil, &mocks.MockSearchStore{
+			LastFunc: func() (storage.Search, bool, error) { return search, true, nil },
+		})
+
+		_, cmd := model.Update(switchToSearchModelMsg{})
+		assert.Equal(t, switchToLoadingModelMsg{query: common.StationQueryByName, queryText: "jazz", filter: search.Filter}, cmd())
+	})
+
+	t.Run("plays the last station played again", func(t *testing.T) {
+		other := common.Station{StationUuid: uuid.New(), Name: "Rock FM"}
+		model := newStartupTestModel(config.StartupResume, historyOf(station, other), nil)
+
+		_, cmd := model.Update(switchToSearchModelMsg{})
+		msg := cmd().(switchToStationsModelMsg)
+		assert.Equal(t, []common.Station{station}, msg.stations)
+		assert.True(t, msg.autoplay)
+	})
+
+	t.Run("falls back to the search form when there's nothing to open", func(t *testing.T) {
+		model := newStartupTestModel(config.StartupResume, historyOf(), nil)
+
+		newModel, cmd := model.Update(switchToSearchModelMsg{})
+		msg := cmd()
+		assert.Equal(t, startupViewFailedMsg{err: ErrNothingPlayed}, msg)
+
+		newModel, _ = newModel.Update(msg)
+		newModel, _ = newModel.Update(switchToSearchModelMsg{})
+		assert.Equal(t, searchState, newModel.(Model).state)
+	})
+
+	t.Run("gives way to a station requested by another launch", func(t *testing.T) {
+		model := newStartupTestModel(config.StartupBookmarks, nil, nil)
+		model.pendingStationUuid = station.StationUuid.String()
+
+		_, cmd := model.Update(switchToSearchModelMsg{})
+		assert.Equal(t, common.StationQueryByUuid, cmd().(switchToLoadingModelMsg).query)
+	})
+}
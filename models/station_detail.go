@@ -0,0 +1,429 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/assets"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/uuid"
+)
+
+type stationDetailField int
+
+const (
+	noField stationDetailField = iota
+	aliasField
+	noteField
+	headerField
+)
+
+// Messages
+
+type stationLabelChangedMsg struct {
+	stationUuid uuid.UUID
+	label       storage.StationLabel
+}
+
+type closeStationDetailMsg struct{}
+
+type stationChecksFetchedMsg struct {
+	stationUuid uuid.UUID
+	checks      []common.StationCheck
+	err         error
+}
+
+// How many of the most recent checks are listed.
+const maxDisplayedChecks = 10
+
+// Model
+
+type StationDetailModel struct {
+	theme Theme
+
+	station    common.Station
+	label      storage.StationLabel
+	editing    stationDetailField
+	inputModel textinput.Model
+	inputErr   string
+	width      int
+
+	// headers are sent along with the requests for the stream of the station
+	headers common.StreamHeaders
+
+	// how often the station was interrupted lately
+	stability stationStability
+
+	showChecks    bool
+	loadingChecks bool
+	checks        []common.StationCheck
+	checksErr     string
+
+	// nil when favicons aren't shown
+	assets  assets.Cache
+	favicon image.Image
+
+	browser api.RadioBrowserService
+}
+
+func NewStationDetailModel(theme Theme, browser api.RadioBrowserService, station common.Station, label storage.StationLabel) StationDetailModel {
+
+	i := textinput.New()
+	i.Width = 40
+	i.TextStyle = theme.Text
+	i.PlaceholderStyle = theme.TertiaryText
+
+	return StationDetailModel{
+		theme:      theme,
+		station:    station,
+		label:      label,
+		inputModel: i,
+		browser:    browser,
+	}
+}
+
+// Commands
+
+func updateCommandsForStationDetail(editing bool) tea.Cmd {
+	return func() tea.Msg {
+		if editing {
+			return bottomBarUpdateMsg{
+				commands: []string{i18n.T("commands.save"), i18n.T("commands.cancel")},
+			}
+		}
+		return bottomBarUpdateMsg{
+			commands: []string{i18n.T("commands.back"), i18n.T("commands.editName"), i18n.T("commands.editNote"), i18n.T("commands.editHeaders"), i18n.T("commands.checks")},
+		}
+	}
+}
+
+func fetchStationChecksCmd(browser api.RadioBrowserService, stationUuid uuid.UUID) tea.Cmd {
+	return func() tea.Msg {
+		checks, err := browser.GetStationChecks(stationUuid)
+		return stationChecksFetchedMsg{stationUuid: stationUuid, checks: checks, err: err}
+	}
+}
+
+// Bubbletea
+
+func (m StationDetailModel) Init() tea.Cmd {
+	if m.assets == nil || m.station.Favicon.URL.Host == "" || m.theme.Accessible {
+		return updateCommandsForStationDetail(false)
+	}
+	return tea.Batch(updateCommandsForStationDetail(false), fetchFaviconCmd(m.assets, m.station))
+}
+
+func (m StationDetailModel) Update(msg tea.Msg) (StationDetailModel, tea.Cmd) {
+
+	if msg, ok := msg.(faviconFetchedMsg); ok {
+		if msg.stationUuid == m.station.StationUuid {
+			m.favicon = msg.image
+		}
+		return m, nil
+	}
+
+	if msg, ok := msg.(stationChecksFetchedMsg); ok {
+		if msg.stationUuid != m.station.StationUuid {
+			return m, nil
+		}
+		m.loadingChecks = false
+		m.checksErr = ""
+		if msg.err != nil {
+			m.checksErr = msg.err.Error()
+		}
+		m.checks = newestChecksFirst(msg.checks)
+		return m, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+
+	if m.editing == noField {
+		if !ok {
+			return m, nil
+		}
+		switch keyMsg.String() {
+		case "esc":
+			return m, func() tea.Msg {
+				return closeStationDetailMsg{}
+			}
+		case "e":
+			return m.startEditing(aliasField, m.label.Alias, m.station.Name)
+		case "n":
+			return m.startEditing(noteField, m.label.Note, i18n.T("detail.note"))
+		case "h":
+			return m.startEditing(headerField, "", i18n.T("detail.headerPlaceholder"))
+		case "c":
+			m.showChecks = !m.showChecks
+			if m.showChecks && !m.loadingChecks {
+				m.loadingChecks = true
+				return m, fetchStationChecksCmd(m.browser, m.station.StationUuid)
+			}
+		}
+		return m, nil
+	}
+
+	if ok {
+		switch keyMsg.String() {
+		case "esc":
+			m.editing = noField
+			m.inputErr = ""
+			m.inputModel.Blur()
+			return m, updateCommandsForStationDetail(false)
+		case "enter":
+			if m.editing == headerField {
+				return m.setHeader()
+			}
+			value := strings.TrimSpace(m.inputModel.Value())
+			if m.editing == aliasField {
+				m.label.Alias = value
+			} else {
+				m.label.Note = value
+			}
+			m.editing = noField
+			m.inputModel.Blur()
+			stationUuid := m.station.StationUuid
+			label := m.label
+			return m, tea.Batch(
+				updateCommandsForStationDetail(false),
+				func() tea.Msg {
+					return stationLabelChangedMsg{stationUuid: stationUuid, label: label}
+				},
+			)
+		}
+	}
+
+	newInputModel, cmd := m.inputModel.Update(msg)
+	m.inputModel = newInputModel
+	return m, cmd
+}
+
+// setHeader sets the header typed in, e.g. "Referer: https://example.com", or removes it when it has no value.
+// A header that can't be sent is reported, and left to be corrected.
+func (m StationDetailModel) setHeader() (StationDetailModel, tea.Cmd) {
+	if value := strings.TrimSpace(m.inputModel.Value()); value != "" {
+		header, err := common.ParseStreamHeader(value)
+		if err != nil {
+			m.inputErr = err.Error()
+			return m, nil
+		}
+		m.headers = m.headers.With(header)
+	}
+	m.editing = noField
+	m.inputErr = ""
+	m.inputModel.Blur()
+	changed := streamHeadersChangedMsg{stationUuid: m.station.StationUuid, name: m.station.Name, headers: m.headers}
+	if m.label.Alias != "" {
+		changed.name = m.label.Alias
+	}
+	return m, tea.Batch(
+		updateCommandsForStationDetail(false),
+		func() tea.Msg {
+			return changed
+		},
+	)
+}
+
+func (m StationDetailModel) startEditing(field stationDetailField, value string, placeholder string) (StationDetailModel, tea.Cmd) {
+	m.editing = field
+	m.inputModel.Placeholder = placeholder
+	m.inputModel.SetValue(value)
+	m.inputModel.CursorEnd()
+	m.inputModel.Focus()
+	return m, tea.Batch(textinput.Blink, updateCommandsForStationDetail(true))
+}
+
+func (m StationDetailModel) View() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.T("detail.title")) + "\n\n"
+
+	alias := m.renderValue(m.label.Alias)
+	if m.editing == aliasField {
+		alias = m.inputModel.View()
+	}
+
+	note := m.renderValue(m.label.Note)
+	if m.editing == noteField {
+		note = m.inputModel.View()
+	}
+
+	headers := m.headersView()
+
+	codec := m.station.Codec
+	if m.station.Bitrate > 0 {
+		codec = i18n.Tf("detail.bitrate", codec, m.station.Bitrate)
+	}
+
+	rows := [][2]string{
+		{i18n.T("detail.name"), m.renderValue(m.station.Name)},
+		{i18n.T("detail.customName"), alias},
+		{i18n.T("detail.note"), note},
+		{i18n.T("detail.country"), m.renderValue(m.station.CountryCode)},
+		{i18n.T("detail.state"), m.renderValue(m.station.State)},
+		{i18n.T("detail.languages"), m.renderValue(m.station.Languages)},
+		{i18n.T("detail.codec"), m.renderValue(codec)},
+		{i18n.T("detail.votes"), m.renderValue(fmt.Sprintf("%d", m.station.Votes))},
+		{i18n.T("detail.tags"), m.renderValue(m.station.Tags)},
+		{i18n.T("detail.stream"), m.renderValue(m.station.Url.URL.String())},
+		{i18n.T("detail.headers"), headers},
+		{i18n.T("detail.stability"), m.renderValue(m.stability.String())},
+		{i18n.T("detail.uuid"), m.renderValue(m.station.StationUuid.String())},
+	}
+
+	keyStyle := m.theme.PrimaryText.Copy().Width(14)
+
+	var lines []string
+	for _, row := range rows {
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top, keyStyle.Render(row[0]), row[1]))
+	}
+	details := strings.Join(lines, "\n")
+
+	if m.favicon != nil {
+		details = lipgloss.JoinHorizontal(lipgloss.Top, details, "   ", renderFavicon(m.favicon))
+	}
+	v += details + "\n"
+
+	if m.showChecks {
+		v += "\n" + m.checksView()
+	}
+
+	return v
+}
+
+// headersView lists the headers sent for the stream, one per line, followed by the one being typed in.
+func (m StationDetailModel) headersView() string {
+	var lines []string
+	for _, header := range m.headers {
+		lines = append(lines, m.theme.Text.Render(header.String()))
+	}
+	if m.editing == headerField {
+		lines = append(lines, m.inputModel.View())
+		if m.inputErr != "" {
+			lines = append(lines, m.theme.RenderError(m.inputErr))
+		} else {
+			lines = append(lines, m.theme.TertiaryText.Render(i18n.T("detail.headerHint")))
+		}
+	}
+	if len(lines) == 0 {
+		return m.renderValue("")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// checksView lists the most recent availability checks, with a summary of how many succeeded.
+func (m StationDetailModel) checksView() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.T("detail.checks.title")) + "\n"
+
+	switch {
+	case m.loadingChecks:
+		return v + m.theme.TertiaryText.Render(i18n.T("detail.checks.loading")) + "\n"
+	case m.checksErr != "":
+		return v + m.theme.RenderError(m.checksErr) + "\n"
+	case len(m.checks) == 0:
+		return v + m.theme.TertiaryText.Render(i18n.T("detail.checks.empty")) + "\n"
+	}
+
+	succeeded := 0
+	for _, check := range m.checks {
+		if check.Ok {
+			succeeded++
+		}
+	}
+	v += m.theme.Text.Render(i18n.Tf("detail.checks.summary", succeeded, len(m.checks))) + "\n"
+
+	for i, check := range m.checks {
+		if i == maxDisplayedChecks {
+			break
+		}
+
+		result := m.theme.RenderOk(i18n.T("detail.checks.ok"))
+		if !check.Ok {
+			result = m.theme.RenderError(i18n.T("detail.checks.failed"))
+		}
+
+		codec := check.Codec
+		if check.Bitrate > 0 {
+			codec = i18n.Tf("detail.bitrate", codec, check.Bitrate)
+		}
+
+		parts := []string{
+			m.theme.Text.Render(check.Timestamp.Local().Format("2006-01-02 15:04")),
+			result,
+			m.renderValue(codec),
+		}
+		if check.SslError {
+			parts = append(parts, m.theme.RenderError(i18n.T("detail.checks.sslError")))
+		}
+		parts = append(parts, m.theme.TertiaryText.Render(check.Source))
+
+		v += strings.Join(parts, "  ") + "\n"
+	}
+
+	return v
+}
+
+func newestChecksFirst(checks []common.StationCheck) []common.StationCheck {
+	sorted := make([]common.StationCheck, len(checks))
+	copy(sorted, checks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.After(sorted[j].Timestamp)
+	})
+	return sorted
+}
+
+func (m StationDetailModel) renderValue(value string) string {
+	if value == "" {
+		return m.theme.TertiaryText.Render("-")
+	}
+	return m.theme.Text.Render(value)
+}
+
+// SetStreamHeaders shows the headers sent for the stream of the station, which "h" changes.
+func (m *StationDetailModel) SetStreamHeaders(headers common.StreamHeaders) {
+	m.headers = headers
+}
+
+// SetStability tells how often the station was interrupted lately.
+func (m *StationDetailModel) SetStability(stability stationStability) {
+	m.stability = stability
+}
+
+// SetAssetCache shows the favicon of the station, fetched through cache (nil hides it).
+func (m *StationDetailModel) SetAssetCache(cache assets.Cache) {
+	m.assets = cache
+}
+
+func (m *StationDetailModel) SetWidth(width int) {
+	m.width = width
+	if width > 30 {
+		m.inputModel.Width = width - 20
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStationDetailModel_Update(t *testing.T) {
+
+	station := common.Station{StationUuid: uuid.New(), Name: "Upstream Name"}
+
+	t.Run("broadcasts closeStationDetailMsg when 'esc' is pressed", func(t *testing.T) {
+
+		model := NewStationDetailModel(Theme{}, &mocks.MockRadioBrowserService{}, station, storage.StationLabel{})
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.NotNil(t, cmd)
+
+		assert.IsType(t, closeStationDetailMsg{}, cmd())
+
+	})
+
+	t.Run("starts editing the custom name when 'e' is pressed", func(t *testing.T) {
+
+		model := NewStationDetailModel(Theme{}, &mocks.MockRadioBrowserService{}, station, storage.StationLabel{Alias: "Alias"})
+
+		newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+		assert.NotNil(t, cmd)
+
+		assert.Equal(t, aliasField, newModel.editing)
+		assert.Equal(t, "Alias", newModel.inputModel.Value())
+		assert.True(t, newModel.inputModel.Focused())
+
+	})
+
+	t.Run("broadcasts stationLabelChangedMsg when an edit is saved", func(t *testing.T) {
+
+		model := NewStationDetailModel(Theme{}, &mocks.MockRadioBrowserService{}, station, storage.StationLabel{Alias: "Alias"})
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+		model.inputModel.SetValue("  A note  ")
+
+		newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.NotNil(t, cmd)
+		assert.Equal(t, noField, newModel.editing)
+
+		var batchMsg tea.BatchMsg = cmd().(tea.BatchMsg)
+
+		var changed *stationLabelChangedMsg
+		for _, msg := range batchMsg {
+			if currentMsg, ok := msg().(stationLabelChangedMsg); ok {
+				changed = &currentMsg
+			}
+		}
+
+		assert.NotNil(t, changed)
+		assert.Equal(t, station.StationUuid, changed.stationUuid)
+		assert.Equal(t, storage.StationLabel{Alias: "Alias", Note: "A note"}, changed.label)
+
+	})
+
+	t.Run("discards an edit when 'esc' is pressed", func(t *testing.T) {
+
+		model := NewStationDetailModel(Theme{}, &mocks.MockRadioBrowserService{}, station, storage.StationLabel{})
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+		model.inputModel.SetValue("Something")
+
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+		assert.Equal(t, noField, newModel.editing)
+		assert.Equal(t, "", newModel.label.Alias)
+
+	})
+
+	t.Run("broadcasts streamHeadersChangedMsg when a header is set", func(t *testing.T) {
+
+		model := NewStationDetailModel(Theme{}, &mocks.MockRadioBrowserService{}, station, storage.StationLabel{Alias: "Alias"})
+		model.SetStreamHeaders(common.StreamHeaders{{Name: "Referer", Value: "https://old.example.com"}})
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+		assert.Equal(t, headerField, model.editing)
+		model.inputModel.SetValue("referer:  https://example.com/player ")
+
+		newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.NotNil(t, cmd)
+		assert.Equal(t, noField, newModel.editing)
+		assert.Contains(t, newModel.View(), "referer: https://example.com/player")
+
+		var changed *streamHeadersChangedMsg
+		for _, msg := range cmd().(tea.BatchMsg) {
+			if currentMsg, ok := msg().(streamHeadersChangedMsg); ok {
+				changed = &currentMsg
+			}
+		}
+
+		assert.NotNil(t, changed)
+		assert.Equal(t, station.StationUuid, changed.stationUuid)
+		assert.Equal(t, "Alias", changed.name)
+		assert.Equal(t, common.StreamHeaders{{Name: "referer", Value: "https://example.com/player"}}, changed.headers)
+
+	})
+
+	t.Run("removes a header typed without a value", func(t *testing.T) {
+
+		model := NewStationDetailModel(Theme{}, &mocks.MockRadioBrowserService{}, station, storage.StationLabel{})
+		model.SetStreamHeaders(common.StreamHeaders{{Name: "Referer", Value: "https://example.com"}, {Name: "X-Token", Value: "secret"}})
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+		model.inputModel.SetValue("Referer:")
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.Equal(t, common.StreamHeaders{{Name: "X-Token", Value: "secret"}}, model.headers)
+
+	})
+
+	t.Run("reports a header that can't be sent and keeps editing it", func(t *testing.T) {
+
+		model := NewStationDetailModel(Theme{}, &mocks.MockRadioBrowserService{}, station, storage.StationLabel{})
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+		model.inputModel.SetValue("Not a header")
+		newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.Nil(t, cmd)
+		assert.Equal(t, headerField, newModel.editing)
+		assert.Contains(t, newModel.View(), "the header must be like Referer: https://example.com")
+		assert.Empty(t, newModel.headers)
+
+	})
+
+	t.Run("fetches and lists the station checks when 'c' is pressed", func(t *testing.T) {
+
+		now := time.Now()
+
+		browser := mocks.MockRadioBrowserService{
+			GetStationChecksFunc: func(stationUuid uuid.UUID) ([]common.StationCheck, error) {
+				assert.Equal(t, station.StationUuid, stationUuid)
+				return []common.StationCheck{
+					{Source: "old.example.com", Ok: true, Codec: "MP3", Timestamp: now.Add(-time.Hour)},
+					{Source: "new.example.com", Ok: false, SslError: true, Timestamp: now},
+				}, nil
+			},
+		}
+
+		model := NewStationDetailModel(Theme{}, &browser, station, storage.StationLabel{})
+
+		model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+		assert.NotNil(t, cmd)
+		assert.True(t, model.showChecks)
+		assert.Contains(t, model.View(), "Fetching checks...")
+
+		model, _ = model.Update(cmd())
+
+		assert.Equal(t, "new.example.com", model.checks[0].Source)
+		view := model.View()
+		assert.Contains(t, view, "1 of 2 checks succeeded")
+		assert.Contains(t, view, "SSL error")
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+		assert.False(t, model.showChecks)
+		assert.NotContains(t, model.View(), "checks succeeded")
+
+	})
+
+	t.Run("shows the error if the checks can't be fetched", func(t *testing.T) {
+
+		browser := mocks.MockRadioBrowserService{
+			GetStationChecksFunc: func(stationUuid uuid.UUID) ([]common.StationCheck, error) {
+				return nil, errors.New("connection refused")
+			},
+		}
+
+		model := NewStationDetailModel(Theme{}, &browser, station, storage.StationLabel{})
+
+		model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+		model, _ = model.Update(cmd())
+
+		assert.Contains(t, model.View(), "connection refused")
+
+	})
+
+}
+
+func TestStationDisplayName(t *testing.T) {
+
+	station := common.Station{StationUuid: uuid.New(), Name: "Upstream Name"}
+
+	t.Run("returns the upstream name if there is no alias", func(t *testing.T) {
+		assert.Equal(t, "Upstream Name", stationDisplayName(&mocks.MockLabelStore{}, station))
+	})
+
+	t.Run("returns the alias if set", func(t *testing.T) {
+		labelStore := mocks.MockLabelStore{
+			GetFunc: func(stationUuid uuid.UUID) (storage.StationLabel, bool) {
+				return storage.StationLabel{Alias: "Alias"}, true
+			},
+		}
+		assert.Equal(t, "Alias", stationDisplayName(&labelStore, station))
+	})
+
+}
+
+// assetCacheFunc adapts a function to assets.Cache.
+type assetCacheFunc func(assetUrl url.URL) ([]byte, error)
+
+func (f assetCacheFunc) Get(assetUrl url.URL) ([]byte, error) {
+	return f(assetUrl)
+}
+
+func TestStationDetailModel_Favicon(t *testing.T) {
+
+	faviconUrl, _ := url.Parse("https://example.com/favicon.png")
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM", Favicon: common.RadioGoGoURL{URL: *faviconUrl}}
+
+	favicon := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			favicon.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	var encoded bytes.Buffer
+	assert.NoError(t, png.Encode(&encoded, favicon))
+
+	t.Run("shows the favicon fetched through the cache", func(t *testing.T) {
+
+		model := NewStationDetailModel(Theme{}, &mocks.MockRadioBrowserService{}, station, storage.StationLabel{})
+		model.SetAssetCache(assetCacheFunc(func(assetUrl url.URL) ([]byte, error) {
+			assert.Equal(t, *faviconUrl, assetUrl)
+			return encoded.Bytes(), nil
+		}))
+
+		var fetched tea.Msg
+		for _, msg := range collectMsgs(model.Init()) {
+			if _, ok := msg.(faviconFetchedMsg); ok {
+				fetched = msg
+			}
+		}
+		model, _ = model.Update(fetched)
+
+		assert.NotNil(t, model.favicon)
+		assert.Contains(t, model.View(), "▀")
+
+	})
+
+	t.Run("shows no favicon when it can't be decoded", func(t *testing.T) {
+
+		model := NewStationDetailModel(Theme{}, &mocks.MockRadioBrowserService{}, station, storage.StationLabel{})
+		model.SetAssetCache(assetCacheFunc(func(assetUrl url.URL) ([]byte, error) {
+			return []byte("<svg/>"), nil
+		}))
+
+		msg := fetchFaviconCmd(model.assets, station)()
+		model, _ = model.Update(msg)
+
+		assert.Nil(t, model.favicon)
+		assert.NotContains(t, model.View(), "▀")
+
+	})
+
+	t.Run("doesn't fetch favicons without a cache", func(t *testing.T) {
+
+		model := NewStationDetailModel(Theme{}, &mocks.MockRadioBrowserService{}, station, storage.StationLabel{})
+
+		for _, msg := range collectMsgs(model.Init()) {
+			assert.IsType(t, bottomBarUpdateMsg{}, msg)
+		}
+
+	})
+
+}
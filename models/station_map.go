@@ -0,0 +1,322 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+	"github.com/zi0p4tch0/radiogogo/worldmap"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// How far shift and the arrows, or H/J/K/L, move the cursor across the map.
+const stationMapLongStep = 8
+
+// The smallest map drawn, however small the terminal.
+const (
+	stationMapMinCols = 20
+	stationMapMinRows = 5
+)
+
+// Glyphs drawn over the land of the map.
+const (
+	stationMapStation  = "•"
+	stationMapSelected = "◉"
+	stationMapCursor   = "+"
+)
+
+// Messages
+
+type closeStationMapMsg struct{}
+
+// pickMappedStationMsg selects a station picked on the map in the stations list, and plays it.
+type pickMappedStationMsg struct {
+	station common.Station
+}
+
+// Commands
+
+func updateCommandsForStationMap() tea.Msg {
+	return bottomBarUpdateMsg{
+		commands: []string{
+			i18n.T("commands.back"),
+			i18n.T("commands.moveAll"),
+			i18n.T("commands.nextMapped"),
+			i18n.T("commands.play"),
+		},
+	}
+}
+
+// Model
+
+// mappedStation is a station that can be plotted, and where.
+type mappedStation struct {
+	station  common.Station
+	lon, lat float64
+}
+
+// StationMapModel plots the stations with a location on a world map,
+// selecting the one nearest to a cursor moved across it.
+type StationMapModel struct {
+	theme      Theme
+	labelStore storage.LabelStore
+	world      worldmap.Map
+	stations   []mappedStation
+	// unmapped counts the stations that have no location.
+	unmapped int
+	// col and row are the cell of the map the cursor is on.
+	col, row int
+	// selected is the index in stations of the selected station.
+	selected int
+}
+
+// NewStationMapModel returns a map of the stations that have a location, drawn to fit width and height,
+// with the cursor on current if it has one, or on the first station that has one otherwise.
+func NewStationMapModel(theme Theme, labelStore storage.LabelStore, stations []common.Station, current common.Station, width, height int) StationMapModel {
+
+	m := StationMapModel{
+		theme:      theme,
+		labelStore: labelStore,
+	}
+
+	for _, station := range stations {
+		lon, lat, ok := stationLocation(station)
+		if !ok {
+			m.unmapped++
+			continue
+		}
+		if station.StationUuid == current.StationUuid {
+			m.selected = len(m.stations)
+		}
+		m.stations = append(m.stations, mappedStation{station: station, lon: lon, lat: lat})
+	}
+
+	m.SetSize(width, height)
+	return m
+}
+
+// stationLocation returns the longitude and latitude of station, if radio-browser knows them.
+func stationLocation(station common.Station) (lon, lat float64, ok bool) {
+	if station.GeoLat == nil || station.GeoLong == nil {
+		return 0, 0, false
+	}
+	lon, lat = *station.GeoLong, *station.GeoLat
+	// Stations without a location are sometimes sent at 0, 0, in the middle of the sea
+	if lon == 0 && lat == 0 {
+		return 0, 0, false
+	}
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+	return lon, lat, true
+}
+
+// hasStationsWithLocation returns true if any of stations can be plotted on the map.
+func hasStationsWithLocation(stations []common.Station) bool {
+	for _, station := range stations {
+		if _, _, ok := stationLocation(station); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSize redraws the map to fit width and height, keeping the cursor on the selected station.
+func (m *StationMapModel) SetSize(width, height int) {
+	cols := width - 2
+	if cols < stationMapMinCols {
+		cols = stationMapMinCols
+	}
+	// Leave room for the title, the selected station and the status bar
+	rows := worldmap.FittingRows(cols)
+	if available := height - 8; rows > available {
+		rows = available
+	}
+	if rows < stationMapMinRows {
+		rows = stationMapMinRows
+	}
+	m.world = worldmap.New(cols, rows)
+	if len(m.stations) > 0 {
+		selected := m.stations[m.selected]
+		m.col, m.row = m.world.Cell(selected.lon, selected.lat)
+	}
+}
+
+func (m StationMapModel) Init() tea.Cmd {
+	return updateCommandsForStationMap
+}
+
+func (m StationMapModel) Update(msg tea.Msg) (StationMapModel, tea.Cmd) {
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc", "q":
+		return m, func() tea.Msg {
+			return closeStationMapMsg{}
+		}
+	case "up", "k":
+		m.moveCursor(0, -1)
+	case "down", "j":
+		m.moveCursor(0, 1)
+	case "left", "h":
+		m.moveCursor(-1, 0)
+	case "right", "l":
+		m.moveCursor(1, 0)
+	case "shift+up", "K":
+		m.moveCursor(0, -stationMapLongStep)
+	case "shift+down", "J":
+		m.moveCursor(0, stationMapLongStep)
+	case "shift+left", "H":
+		m.moveCursor(-stationMapLongStep, 0)
+	case "shift+right", "L":
+		m.moveCursor(stationMapLongStep, 0)
+	case "tab":
+		m.selectStation(m.selected + 1)
+	case "shift+tab":
+		m.selectStation(m.selected - 1)
+	case "enter":
+		if len(m.stations) == 0 {
+			return m, nil
+		}
+		station := m.stations[m.selected].station
+		return m, func() tea.Msg {
+			return pickMappedStationMsg{station: station}
+		}
+	}
+
+	return m, nil
+}
+
+// moveCursor moves the cursor by cols and rows cells, around the world across the antimeridian,
+// and selects the station nearest to it.
+func (m *StationMapModel) moveCursor(cols, rows int) {
+	width, height := m.world.Size()
+	m.col = ((m.col+cols)%width + width) % width
+	m.row += rows
+	if m.row < 0 {
+		m.row = 0
+	}
+	if m.row >= height {
+		m.row = height - 1
+	}
+	m.selected = m.nearestStation()
+}
+
+// selectStation selects the station at index, wrapping around the stations, and moves the cursor onto it.
+func (m *StationMapModel) selectStation(index int) {
+	if len(m.stations) == 0 {
+		return
+	}
+	m.selected = (index%len(m.stations) + len(m.stations)) % len(m.stations)
+	selected := m.stations[m.selected]
+	m.col, m.row = m.world.Cell(selected.lon, selected.lat)
+}
+
+// nearestStation returns the index of the station nearest to the cursor, as the crow flies.
+func (m StationMapModel) nearestStation() int {
+	lon, lat := m.world.Location(m.col, m.row)
+	nearest := 0
+	for i, station := range m.stations {
+		if worldmap.Distance(lon, lat, station.lon, station.lat) < worldmap.Distance(lon, lat, m.stations[nearest].lon, m.stations[nearest].lat) {
+			nearest = i
+		}
+	}
+	return nearest
+}
+
+// Selected returns the selected station, if any station could be plotted.
+func (m StationMapModel) Selected() (common.Station, bool) {
+	if len(m.stations) == 0 {
+		return common.Station{}, false
+	}
+	return m.stations[m.selected].station, true
+}
+
+func (m StationMapModel) View() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.Tf("stationMap.title", len(m.stations), len(m.stations)+m.unmapped)) + "\n\n"
+
+	if len(m.stations) == 0 {
+		return v
+	}
+
+	cols, rows := m.world.Size()
+	plotted := make(map[[2]int]bool, len(m.stations))
+	for _, station := range m.stations {
+		col, row := m.world.Cell(station.lon, station.lat)
+		plotted[[2]int{col, row}] = true
+	}
+	selected := m.stations[m.selected]
+	selectedCol, selectedRow := m.world.Cell(selected.lon, selected.lat)
+
+	for row := 0; row < rows; row++ {
+		var land strings.Builder
+		flush := func() {
+			if land.Len() > 0 {
+				v += m.theme.TertiaryText.Render(land.String())
+				land.Reset()
+			}
+		}
+		for col := 0; col < cols; col++ {
+			var glyph string
+			var style lipgloss.Style
+			switch {
+			case col == selectedCol && row == selectedRow:
+				glyph, style = stationMapSelected, m.theme.PrimaryBlock
+			case col == m.col && row == m.row:
+				glyph, style = stationMapCursor, m.theme.SecondaryText.Bold(true)
+			case plotted[[2]int{col, row}]:
+				glyph, style = stationMapStation, m.theme.PrimaryText
+			default:
+				land.WriteRune(m.world.Land(col, row))
+				continue
+			}
+			flush()
+			v += style.Render(glyph)
+		}
+		flush()
+		v += "\n"
+	}
+
+	name := displayText(stationDisplayName(m.labelStore, selected.station))
+	lon, lat := m.world.Location(m.col, m.row)
+	distance := int(worldmap.Distance(lon, lat, selected.lon, selected.lat))
+	place := selected.station.Country
+	if place == "" {
+		place = selected.station.CountryCode
+	}
+
+	v += "\n" + m.theme.PrimaryText.Bold(true).Render(name)
+	if place != "" {
+		v += m.theme.Text.Render(" · " + place)
+	}
+	v += m.theme.TertiaryText.Render(" · "+i18n.Tf("stationMap.distance", distance)) + "\n"
+
+	return v
+}
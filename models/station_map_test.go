@@ -0,0 +1,191 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func locatedStation(name string, lon, lat float64) common.Station {
+	return common.Station{StationUuid: uuid.New(), Name: name, GeoLong: &lon, GeoLat: &lat}
+}
+
+func TestStationMapModel(t *testing.T) {
+
+	paris := locatedStation("Radio Paris", 2.35, 48.85)
+	rome := locatedStation("Radio Roma", 12.5, 41.9)
+	sydney := locatedStation("Sydney FM", 151.2, -33.9)
+	nowhere := common.Station{StationUuid: uuid.New(), Name: "Nowhere FM"}
+	stations := []common.Station{paris, nowhere, rome, sydney}
+
+	t.Run("plots the stations that have a location only", func(t *testing.T) {
+
+		model := NewStationMapModel(Theme{}, &mocks.MockLabelStore{}, stations, common.Station{}, 100, 40)
+
+		assert.Len(t, model.stations, 3)
+		assert.Equal(t, 1, model.unmapped)
+		selected, ok := model.Selected()
+		assert.True(t, ok)
+		assert.Equal(t, paris, selected)
+
+		view := model.View()
+		assert.Contains(t, view, "3 of 4 stations")
+		assert.Contains(t, view, "Radio Paris")
+		assert.Equal(t, 2, strings.Count(view, stationMapStation))
+		assert.Equal(t, 1, strings.Count(view, stationMapSelected))
+
+	})
+
+	t.Run("starts on the station highlighted in the list", func(t *testing.T) {
+
+		model := NewStationMapModel(Theme{}, &mocks.MockLabelStore{}, stations, rome, 100, 40)
+
+		selected, _ := model.Selected()
+		assert.Equal(t, rome, selected)
+		assert.Equal(t, [2]int{model.col, model.row}, func() [2]int {
+			col, row := model.world.Cell(12.5, 41.9)
+			return [2]int{col, row}
+		}())
+
+	})
+
+	t.Run("selects the station nearest to the cursor", func(t *testing.T) {
+
+		model := NewStationMapModel(Theme{}, &mocks.MockLabelStore{}, stations, paris, 100, 40)
+
+		for i := 0; i < 4; i++ {
+			model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+		}
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+
+		selected, _ := model.Selected()
+		assert.Equal(t, rome, selected)
+		assert.Contains(t, model.View(), stationMapCursor)
+
+	})
+
+	t.Run("wraps the cursor around the world", func(t *testing.T) {
+
+		model := NewStationMapModel(Theme{}, &mocks.MockLabelStore{}, []common.Station{sydney}, sydney, 100, 40)
+		cols, _ := model.world.Size()
+
+		model.col = 0
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyLeft})
+
+		assert.Equal(t, cols-1, model.col)
+
+	})
+
+	t.Run("jumps from a station to the next one", func(t *testing.T) {
+
+		model := NewStationMapModel(Theme{}, &mocks.MockLabelStore{}, stations, rome, 100, 40)
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyTab})
+		selected, _ := model.Selected()
+		assert.Equal(t, sydney, selected)
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyTab})
+		selected, _ = model.Selected()
+		assert.Equal(t, paris, selected)
+
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+		selected, _ = model.Selected()
+		assert.Equal(t, sydney, selected)
+
+	})
+
+	t.Run("picks the selected station", func(t *testing.T) {
+
+		model := NewStationMapModel(Theme{}, &mocks.MockLabelStore{}, stations, rome, 100, 40)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.Equal(t, pickMappedStationMsg{station: rome}, cmd())
+
+	})
+
+	t.Run("closes on esc", func(t *testing.T) {
+
+		model := NewStationMapModel(Theme{}, &mocks.MockLabelStore{}, stations, rome, 100, 40)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+		assert.Equal(t, closeStationMapMsg{}, cmd())
+
+	})
+
+	t.Run("ignores locations sent as 0, 0", func(t *testing.T) {
+
+		assert.False(t, hasStationsWithLocation([]common.Station{locatedStation("Null Island", 0, 0), nowhere}))
+		assert.True(t, hasStationsWithLocation(stations))
+
+	})
+
+}
+
+func TestStationsModelMap(t *testing.T) {
+
+	paris := locatedStation("Radio Paris", 2.35, 48.85)
+	rome := locatedStation("Radio Roma", 12.5, 41.9)
+
+	t.Run("tells when no station can be plotted", func(t *testing.T) {
+
+		model := newQueueStationsModel(&mocks.MockPlaybackManagerService{}, []common.Station{{StationUuid: uuid.New(), Name: "Nowhere FM"}}, nil, 0)
+
+		newModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("M")})
+
+		assert.False(t, newModel.(StationsModel).showStationMap)
+		assert.IsType(t, toastMsg{}, cmd())
+
+	})
+
+	t.Run("plays the station picked on the map", func(t *testing.T) {
+
+		var played []common.Station
+		playbackManager := mocks.MockPlaybackManagerService{
+			PlayStationFunc: func(station common.Station, volume int) error {
+				played = append(played, station)
+				return nil
+			},
+		}
+		model := newQueueStationsModel(&playbackManager, []common.Station{paris, rome}, nil, 0)
+
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("M")})
+		assert.True(t, newModel.(StationsModel).showStationMap)
+
+		newModel, cmd := newModel.Update(pickMappedStationMsg{station: rome})
+		stationsModel := newModel.(StationsModel)
+
+		assert.False(t, stationsModel.showStationMap)
+		assert.Equal(t, 1, stationsModel.stationsTable.Cursor())
+		assert.Contains(t, collectMsgs(cmd), playbackStartedMsg{station: rome})
+		assert.Equal(t, []common.Station{rome}, played)
+
+	})
+
+}
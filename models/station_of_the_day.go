@@ -0,0 +1,257 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+const (
+	// The cache key the station of the day is kept under, along with the day it was picked on
+	stationOfTheDayKey = "stationOfTheDay"
+	// How many trending stations the station of the day is picked from
+	stationOfTheDayCandidates = 50
+	// How many of the most trending stations left the station of the day rotates through from one day to the next
+	stationOfTheDayRotation = 10
+	// The layout of the days the station of the day is picked on
+	stationOfTheDayLayout = "2006-01-02"
+)
+
+// stationOfTheDay is the station suggested on a day, as cached.
+type stationOfTheDay struct {
+	Day     string         `json:"day"`
+	Station common.Station `json:"station"`
+	// Tag is the tag played the most it was picked for, if any
+	Tag       string `json:"tag,omitempty"`
+	Dismissed bool   `json:"dismissed,omitempty"`
+}
+
+// listeningTaste is what's played the most, going by the history.
+type listeningTaste struct {
+	tag         string
+	countryCode string
+	played      map[uuid.UUID]bool
+}
+
+// tasteOf returns the tag and the country played the most in entries, each play counting once.
+// Ties go to the first in alphabetical order, so that the taste doesn't change from one run to the next.
+func tasteOf(entries []storage.HistoryEntry) listeningTaste {
+	profile := tasteProfileOf(entries)
+	return listeningTaste{
+		tag:         mostCounted(profile.tags),
+		countryCode: mostCounted(profile.countries),
+		played:      profile.played,
+	}
+}
+
+// mostCounted returns the key with the highest count, the first in alphabetical order among ties.
+func mostCounted(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	most := ""
+	for _, key := range keys {
+		if most == "" || counts[key] > counts[most] {
+			most = key
+		}
+	}
+	return most
+}
+
+// pickStationOfTheDay picks, out of trending stations, one that was never played, preferring those
+// in the country played the most, rotating through the most trending of them from one day to the next.
+// Returns false if every station was played already.
+func pickStationOfTheDay(trending []common.Station, taste listeningTaste, day time.Time) (common.Station, bool) {
+	var fresh, local []common.Station
+	for _, station := range trending {
+		if taste.played[station.StationUuid] {
+			continue
+		}
+		fresh = append(fresh, station)
+		if taste.countryCode != "" && strings.EqualFold(station.CountryCode, taste.countryCode) {
+			local = append(local, station)
+		}
+	}
+	if len(local) > 0 {
+		fresh = local
+	}
+	if len(fresh) == 0 {
+		return common.Station{}, false
+	}
+	if len(fresh) > stationOfTheDayRotation {
+		fresh = fresh[:stationOfTheDayRotation]
+	}
+	return fresh[day.YearDay()%len(fresh)], true
+}
+
+// stationOfTheDayView renders the station of the day under the search form, with the keys acting on it.
+func (m SearchModel) stationOfTheDayView() string {
+	if m.stationOfTheDay == nil {
+		return ""
+	}
+	reason := i18n.T("search.stationOfTheDayTrending")
+	if m.stationOfTheDay.Tag != "" {
+		reason = i18n.Tf("search.stationOfTheDayTag", m.stationOfTheDay.Tag)
+	}
+	// The name and the keys stay within the search form
+	name := truncateText(displayText(m.stationOfTheDay.Station.Name), searchFormWidth-2)
+	return "\n\n" + m.theme.SecondaryText.Render(i18n.T("search.stationOfTheDay")) +
+		"\n" + m.theme.PrimaryText.Render("0") + " " + m.theme.Text.Render(name) +
+		"\n" + m.theme.TertiaryText.Render(truncateText(reason, searchFormWidth)) +
+		"\n" + m.theme.TertiaryText.Render(truncateText(i18n.T("search.stationOfTheDayKeys"), searchFormWidth))
+}
+
+// updateStationOfTheDay handles the keys acting on the station of the day: alt+0 (or 0 unless a text field is
+// being typed in) plays it, alt+b bookmarks it and alt+d dismisses it for the rest of the day.
+func (m SearchModel) updateStationOfTheDay(key string) (SearchModel, tea.Cmd, bool) {
+	if m.stationOfTheDay == nil {
+		return m, nil, false
+	}
+	station := m.stationOfTheDay.Station
+	switch {
+	case key == "alt+0" || key == "0" && !m.textFieldFocused():
+		return m, func() tea.Msg { return recentlyPlayedSelectedMsg{station: station} }, true
+	case key == "alt+b":
+		return m, func() tea.Msg { return stationOfTheDayBookmarkedMsg{station: station} }, true
+	case key == "alt+d":
+		day := m.stationOfTheDay.Day
+		m.stationOfTheDay = nil
+		return m, func() tea.Msg { return stationOfTheDayDismissedMsg{day: day} }, true
+	}
+	return m, nil, false
+}
+
+// stationOfTheDayCmd suggests the station of the day on the search form, if it's to be.
+func (m Model) stationOfTheDayCmd() tea.Cmd {
+	if !m.suggestStationOfTheDay {
+		return nil
+	}
+	blocklist, reportStore, contentFilter := m.blocklist, m.reportStore, m.contentFilter
+	return loadStationOfTheDayCmd(m.cache, m.history, m.browser, func(stations []common.Station) []common.Station {
+		return withoutBlockedStations(blocklist, withoutReportedStations(reportStore, contentFilter.Apply(stations)))
+	}, time.Now())
+}
+
+// Messages
+
+// stationOfTheDayLoadedMsg suggests the station of the day on the search form.
+type stationOfTheDayLoadedMsg struct {
+	suggestion stationOfTheDay
+}
+
+// stationOfTheDayBookmarkedMsg bookmarks the station of the day.
+type stationOfTheDayBookmarkedMsg struct {
+	station common.Station
+}
+
+// stationOfTheDayDismissedMsg stops suggesting the station of the given day.
+type stationOfTheDayDismissedMsg struct {
+	day string
+}
+
+// Commands
+
+// loadStationOfTheDayCmd suggests the station of the day, picked out of the stations trending in the tag
+// played the most, or out of those trending overall when nothing was played yet or none of them is new.
+// The suggestion is cached for the rest of the day, so that radio-browser is asked once a day at most.
+// Nothing is suggested if it was dismissed, or if the stations can't be fetched: a suggestion isn't worth an error.
+// The stations fetched are narrowed down by eligible, e.g. to leave out the hidden ones. The cache can be nil.
+func loadStationOfTheDayCmd(
+	cache storage.CacheStore,
+	history storage.HistoryStore,
+	browser api.RadioBrowserService,
+	eligible func([]common.Station) []common.Station,
+	now time.Time,
+) tea.Cmd {
+	return func() tea.Msg {
+
+		day := now.Format(stationOfTheDayLayout)
+		var suggestion stationOfTheDay
+		if cache != nil {
+			if found, err := cache.Get(stationOfTheDayKey, &suggestion); err == nil && found && suggestion.Day == day {
+				if suggestion.Dismissed {
+					return nil
+				}
+				return stationOfTheDayLoadedMsg{suggestion: suggestion}
+			}
+		}
+
+		taste := listeningTaste{}
+		if history != nil {
+			if entries, err := history.Recent(historyEntriesRead); err == nil {
+				taste = tasteOf(entries)
+			}
+		}
+
+		suggestion = stationOfTheDay{Day: day, Tag: taste.tag}
+		picked := false
+		if taste.tag != "" {
+			if trending, err := browser.GetStations(common.StationQueryByTagExact, taste.tag, "clicktrend", true, 0, stationOfTheDayCandidates, true); err == nil {
+				suggestion.Station, picked = pickStationOfTheDay(eligible(trending), taste, now)
+			}
+		}
+		if !picked {
+			suggestion.Tag = ""
+			trending, err := browser.GetStations(common.StationQueryAll, "", "clicktrend", true, 0, stationOfTheDayCandidates, true)
+			if err != nil {
+				return nil
+			}
+			if suggestion.Station, picked = pickStationOfTheDay(eligible(trending), taste, now); !picked {
+				return nil
+			}
+		}
+
+		if cache != nil {
+			_ = cache.Put(stationOfTheDayKey, suggestion, 0)
+		}
+		return stationOfTheDayLoadedMsg{suggestion: suggestion}
+
+	}
+}
+
+// dismissStationOfTheDayCmd stops suggesting the station of the given day, until the next one.
+func dismissStationOfTheDayCmd(cache storage.CacheStore, day string) tea.Cmd {
+	if cache == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		var suggestion stationOfTheDay
+		if found, err := cache.Get(stationOfTheDayKey, &suggestion); err != nil || !found || suggestion.Day != day {
+			suggestion = stationOfTheDay{Day: day}
+		}
+		suggestion.Dismissed = true
+		if err := cache.Put(stationOfTheDayKey, suggestion, 0); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return nil
+	}
+}
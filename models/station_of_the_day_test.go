@@ -0,0 +1,231 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryCache returns a cache store keeping values in memory, as JSON like the database does.
+func memoryCache() *mocks.MockCacheStore {
+	values := map[string][]byte{}
+	return &mocks.MockCacheStore{
+		GetFunc: func(key string, v interface{}) (bool, error) {
+			value, ok := values[key]
+			if !ok {
+				return false, nil
+			}
+			return true, json.Unmarshal(value, v)
+		},
+		PutFunc: func(key string, v interface{}, ttl time.Duration) error {
+			value, err := json.Marshal(v)
+			values[key] = value
+			return err
+		},
+	}
+}
+
+func TestTasteOf(t *testing.T) {
+
+	played := uuid.New()
+	taste := tasteOf([]storage.HistoryEntry{
+		{Station: common.Station{StationUuid: played, Tags: "Jazz, smooth jazz", CountryCode: "it"}},
+		{Station: common.Station{StationUuid: uuid.New(), Tags: "jazz,rock", CountryCode: "FR"}},
+		{Station: common.Station{StationUuid: uuid.New(), Tags: "rock", CountryCode: "IT"}},
+	})
+
+	assert.Equal(t, "jazz", taste.tag)
+	assert.Equal(t, "IT", taste.countryCode)
+	assert.True(t, taste.played[played])
+	assert.Equal(t, "", tasteOf(nil).tag)
+
+}
+
+func TestPickStationOfTheDay(t *testing.T) {
+
+	played := common.Station{StationUuid: uuid.New(), CountryCode: "IT"}
+	local := common.Station{StationUuid: uuid.New(), CountryCode: "it"}
+	abroad := common.Station{StationUuid: uuid.New(), CountryCode: "FR"}
+	day := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("prefers stations never played in the country played the most", func(t *testing.T) {
+
+		taste := listeningTaste{countryCode: "IT", played: map[uuid.UUID]bool{played.StationUuid: true}}
+
+		station, ok := pickStationOfTheDay([]common.Station{played, abroad, local}, taste, day)
+
+		assert.True(t, ok)
+		assert.Equal(t, local, station)
+
+	})
+
+	t.Run("rotates through the trending stations from one day to the next", func(t *testing.T) {
+
+		trending := []common.Station{local, abroad}
+
+		first, _ := pickStationOfTheDay(trending, listeningTaste{}, day)
+		second, _ := pickStationOfTheDay(trending, listeningTaste{}, day.AddDate(0, 0, 1))
+
+		assert.NotEqual(t, first, second)
+
+	})
+
+	t.Run("picks nothing when every station was played", func(t *testing.T) {
+
+		_, ok := pickStationOfTheDay([]common.Station{played}, listeningTaste{played: map[uuid.UUID]bool{played.StationUuid: true}}, day)
+
+		assert.False(t, ok)
+
+	})
+
+}
+
+func TestLoadStationOfTheDayCmd(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz FM", Tags: "jazz"}
+	trending := common.Station{StationUuid: uuid.New(), Name: "Trending FM"}
+	day := time.Date(2023, 10, 1, 8, 0, 0, 0, time.UTC)
+	everything := func(stations []common.Station) []common.Station { return stations }
+	history := historyOf(common.Station{StationUuid: uuid.New(), Tags: "jazz"})
+
+	t.Run("picks out of the stations trending in the tag played the most, once a day", func(t *testing.T) {
+
+		var requests []string
+		browser := &mocks.MockRadioBrowserService{
+			GetStationsFunc: func(query common.StationQuery, term string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				requests = append(requests, string(query)+":"+term+":"+order)
+				return []common.Station{jazz}, nil
+			},
+		}
+		cache := memoryCache()
+
+		msg := loadStationOfTheDayCmd(cache, history, browser, everything, day)()
+		assert.Equal(t, stationOfTheDayLoadedMsg{suggestion: stationOfTheDay{Day: "2023-10-01", Station: jazz, Tag: "jazz"}}, msg)
+
+		assert.Equal(t, msg, loadStationOfTheDayCmd(cache, history, browser, everything, day.Add(time.Hour))())
+		assert.Equal(t, []string{"bytagexact:jazz:clicktrend"}, requests)
+
+		loadStationOfTheDayCmd(cache, history, browser, everything, day.AddDate(0, 0, 1))()
+		assert.Len(t, requests, 2)
+
+	})
+
+	t.Run("falls back to the stations trending overall", func(t *testing.T) {
+
+		browser := &mocks.MockRadioBrowserService{
+			GetStationsFunc: func(query common.StationQuery, term string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				if query == common.StationQueryAll {
+					return []common.Station{jazz, trending}, nil
+				}
+				return nil, errors.New("offline")
+			},
+		}
+		withoutJazz := func(stations []common.Station) []common.Station {
+			var kept []common.Station
+			for _, station := range stations {
+				if station.StationUuid != jazz.StationUuid {
+					kept = append(kept, station)
+				}
+			}
+			return kept
+		}
+
+		msg := loadStationOfTheDayCmd(nil, history, browser, withoutJazz, day)()
+
+		assert.Equal(t, stationOfTheDayLoadedMsg{suggestion: stationOfTheDay{Day: "2023-10-01", Station: trending}}, msg)
+
+	})
+
+	t.Run("suggests nothing once dismissed for the day, or when nothing can be fetched", func(t *testing.T) {
+
+		browser := &mocks.MockRadioBrowserService{
+			GetStationsFunc: func(query common.StationQuery, term string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				return nil, errors.New("offline")
+			},
+		}
+		cache := memoryCache()
+
+		assert.Nil(t, loadStationOfTheDayCmd(cache, nil, browser, everything, day)())
+
+		assert.Nil(t, dismissStationOfTheDayCmd(cache, "2023-10-01")())
+		browser.GetStationsFunc = func(query common.StationQuery, term string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+			return []common.Station{trending}, nil
+		}
+		assert.Nil(t, loadStationOfTheDayCmd(cache, nil, browser, everything, day)())
+		assert.NotNil(t, loadStationOfTheDayCmd(cache, nil, browser, everything, day.AddDate(0, 0, 1))())
+
+	})
+
+}
+
+func TestSearchModel_StationOfTheDay(t *testing.T) {
+
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	newModel := func() SearchModel {
+		updated, _ := NewSearchModel(Theme{}, common.StationFilter{}).Update(stationOfTheDayLoadedMsg{
+			suggestion: stationOfTheDay{Day: "2023-10-01", Station: station, Tag: "jazz"},
+		})
+		return updated.(SearchModel)
+	}
+
+	t.Run("shows the station of the day under the search form", func(t *testing.T) {
+
+		view := newModel().View()
+
+		assert.Contains(t, view, "Station of the day")
+		assert.Contains(t, view, "0 Jazz FM")
+		assert.Contains(t, view, "Trending in jazz")
+
+	})
+
+	t.Run("plays, bookmarks and dismisses it with alt keys", func(t *testing.T) {
+
+		_, cmd := newModel().Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("0"), Alt: true})
+		assert.Equal(t, recentlyPlayedSelectedMsg{station: station}, cmd())
+
+		_, cmd = newModel().Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b"), Alt: true})
+		assert.Equal(t, stationOfTheDayBookmarkedMsg{station: station}, cmd())
+
+		updated, cmd := newModel().Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d"), Alt: true})
+		assert.Equal(t, stationOfTheDayDismissedMsg{day: "2023-10-01"}, cmd())
+		assert.NotContains(t, updated.View(), "Jazz FM")
+
+	})
+
+	t.Run("leaves 0 to the search field while it's typed in", func(t *testing.T) {
+
+		updated, _ := newModel().Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("0")})
+
+		assert.Equal(t, "0", updated.(SearchModel).inputModel.Value())
+
+	})
+
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How many times in a row a station is reconnected by the playback watchdog when its preferences
+// ask to persist.
+const persistentWatchdogReconnects = 10
+
+// withStationPrefs returns station with the preferences stored for it, if any, to be played as they ask.
+func withStationPrefs(store storage.StationPrefsStore, station common.Station) common.Station {
+	if store == nil {
+		return station
+	}
+	station.Prefs = store.Prefs(station.StationUuid)
+	return station
+}
+
+// maxReconnects returns how many times in a row the playback watchdog reconnects station.
+func maxReconnects(station common.Station) int {
+	switch station.Prefs.Reconnect {
+	case common.ReconnectNever:
+		return 0
+	case common.ReconnectPersistent:
+		return persistentWatchdogReconnects
+	}
+	return maxWatchdogReconnects
+}
+
+// Commands
+
+// stationPrefsCmd changes a preference of station as asked by ":prefs", e.g. ":prefs bitrate 128",
+// resets them all with ":prefs reset", or toasts them without arguments.
+// The volume is the trim "(" and ")" change. Preferences apply the next time the station plays.
+func stationPrefsCmd(store storage.StationPrefsStore, trims storage.VolumeTrimStore, station common.Station, name string, c command) tea.Cmd {
+	if store == nil || trims == nil {
+		return nil
+	}
+	prefs := store.Prefs(station.StationUuid)
+	trim := trims.Trim(station.StationUuid)
+	switch {
+	case len(c.args) == 0:
+		return showToastCmd(describeStationPrefs(name, prefs, trim), toastInfo)
+	case len(c.args) == 1 && strings.ToLower(c.args[0]) == "reset":
+		prefs, trim = common.StationPrefs{}, 0
+	case len(c.args) == 2:
+		var err error
+		if prefs, trim, err = changedStationPrefs(prefs, trim, strings.ToLower(c.args[0]), strings.ToLower(c.args[1])); err != nil {
+			return nonFatalErrorCmd(err)
+		}
+	default:
+		return nonFatalErrorCmd(errors.New(i18n.Tf("command.usage", "prefs [bitrate|reconnect|backend|volume <value>|reset]")))
+	}
+	return func() tea.Msg {
+		if err := store.SetPrefs(station.StationUuid, prefs); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		if err := trims.SetTrim(station.StationUuid, trim); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return toastMsg{text: i18n.Tf("stationPrefs.saved", name), kind: toastSuccess}
+	}
+}
+
+// changedStationPrefs returns prefs and trim with the preference named key set to value,
+// "default" setting it back to the configuration.
+func changedStationPrefs(prefs common.StationPrefs, trim int, key string, value string) (common.StationPrefs, int, error) {
+	switch key {
+	case "bitrate":
+		if value == "default" {
+			prefs.HLSBitrate = 0
+			return prefs, trim, nil
+		}
+		bitrate, err := strconv.Atoi(strings.TrimSuffix(value, "k"))
+		if err != nil || bitrate <= 0 {
+			return prefs, trim, errors.New(i18n.Tf("command.usage", "prefs bitrate <kbps>|default"))
+		}
+		prefs.HLSBitrate = bitrate
+	case "reconnect":
+		policy, err := common.ParseReconnectPolicy(value)
+		if err != nil {
+			return prefs, trim, err
+		}
+		prefs.Reconnect = policy
+	case "backend":
+		switch playback.PlaybackEngineType(value) {
+		case "default":
+			prefs.Backend = ""
+		case playback.MPV, playback.FFPlay:
+			prefs.Backend = value
+		default:
+			return prefs, trim, errors.New(i18n.Tf("command.usage", "prefs backend mpv|ffplay|default"))
+		}
+	case "volume":
+		if value == "default" {
+			return prefs, 0, nil
+		}
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset > maxVolumeTrim || offset < -maxVolumeTrim {
+			return prefs, trim, errors.New(i18n.Tf("command.usage", fmt.Sprintf("prefs volume <-%d..+%d>|default", maxVolumeTrim, maxVolumeTrim)))
+		}
+		trim = offset
+	default:
+		return prefs, trim, errors.New(i18n.Tf("command.usage", "prefs [bitrate|reconnect|backend|volume <value>|reset]"))
+	}
+	return prefs, trim, nil
+}
+
+// describeStationPrefs tells what the preferences of a station override,
+// e.g. "Jazz FM: bitrate 128 kbps, reconnect persistent, volume +5".
+func describeStationPrefs(name string, prefs common.StationPrefs, trim int) string {
+	var parts []string
+	if prefs.HLSBitrate > 0 {
+		parts = append(parts, i18n.Tf("stationPrefs.bitrate", prefs.HLSBitrate))
+	}
+	if prefs.Reconnect != common.ReconnectDefault {
+		parts = append(parts, i18n.Tf("stationPrefs.reconnect", prefs.Reconnect))
+	}
+	if prefs.Backend != "" {
+		parts = append(parts, i18n.Tf("stationPrefs.backend", prefs.Backend))
+	}
+	if trim != 0 {
+		parts = append(parts, i18n.Tf("stationPrefs.volume", fmt.Sprintf("%+d", trim)))
+	}
+	if len(parts) == 0 {
+		return i18n.Tf("stationPrefs.none", name)
+	}
+	return name + ": " + strings.Join(parts, ", ")
+}
+
+// SetStationPrefsStore plays stations as the preferences stored in store ask,
+// and lets ":prefs" change them (nil plays them as configured).
+func (m *StationsModel) SetStationPrefsStore(store storage.StationPrefsStore) {
+	m.stationPrefs = store
+}
+
+// SetStationPrefsStore plays bookmarks as the preferences stored in store ask,
+// and lets ":prefs" change them (nil plays them as configured).
+func (m *BookmarksModel) SetStationPrefsStore(store storage.StationPrefsStore) {
+	m.stationPrefs = store
+}
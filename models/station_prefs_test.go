@@ -0,0 +1,174 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/playback"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// newStationPrefsStore returns an in-memory station preferences store.
+func newStationPrefsStore() *mocks.MockStationPrefsStore {
+	prefs := map[uuid.UUID]common.StationPrefs{}
+	return &mocks.MockStationPrefsStore{
+		PrefsFunc: func(stationUuid uuid.UUID) common.StationPrefs {
+			return prefs[stationUuid]
+		},
+		SetPrefsFunc: func(stationUuid uuid.UUID, stationPrefs common.StationPrefs) error {
+			prefs[stationUuid] = stationPrefs
+			return nil
+		},
+	}
+}
+
+func TestStationsModel_StationPrefs(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+
+	newModel := func(prefs *mocks.MockStationPrefsStore, trims *mocks.MockVolumeTrimStore) StationsModel {
+		model := NewStationsModel(
+			Theme{},
+			&mocks.MockRadioBrowserService{},
+			&mocks.MockPlaybackManagerService{VolumeMinResult: 0, VolumeDefaultResult: 80, VolumeMaxResult: 100},
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{},
+			&mocks.MockReportStore{},
+			filter.ContentFilter{},
+			[]common.Station{jazz},
+			config.DefaultStationColumns(),
+			nil,
+			stationPageKey{},
+			false,
+		)
+		model.SetStationPrefsStore(prefs)
+		model.SetVolumeTrimStore(trims)
+		return model
+	}
+
+	t.Run("changes the preferences of the station under the cursor", func(t *testing.T) {
+
+		prefs, trims := newStationPrefsStore(), newVolumeTrimStore()
+		model := newModel(prefs, trims)
+
+		for _, args := range [][]string{{"bitrate", "128"}, {"reconnect", "persistent"}, {"backend", "mpv"}, {"volume", "-5"}} {
+			_, cmd := model.runCommand(command{name: "prefs", args: args})
+			assert.Equal(t, toastMsg{text: "Jazz FM preferences saved, they apply the next time it plays", kind: toastSuccess}, cmd())
+		}
+
+		assert.Equal(t, common.StationPrefs{HLSBitrate: 128, Reconnect: common.ReconnectPersistent, Backend: "mpv"}, prefs.Prefs(jazz.StationUuid))
+		assert.Equal(t, -5, trims.Trim(jazz.StationUuid))
+
+		_, cmd := model.runCommand(command{name: "prefs"})
+		assert.Equal(t, toastMsg{text: "Jazz FM: bitrate 128 kbps, reconnect persistent, engine mpv, volume -5", kind: toastInfo}, cmd())
+
+	})
+
+	t.Run("resets the preferences to the configuration", func(t *testing.T) {
+
+		prefs, trims := newStationPrefsStore(), newVolumeTrimStore()
+		model := newModel(prefs, trims)
+		_ = prefs.SetPrefs(jazz.StationUuid, common.StationPrefs{Backend: "ffplay"})
+		_ = trims.SetTrim(jazz.StationUuid, 10)
+
+		_, cmd := model.runCommand(command{name: "prefs", args: []string{"reset"}})
+		cmd()
+
+		assert.True(t, prefs.Prefs(jazz.StationUuid).IsZero())
+		assert.Equal(t, 0, trims.Trim(jazz.StationUuid))
+
+		_, cmd = model.runCommand(command{name: "prefs"})
+		assert.Equal(t, toastMsg{text: "Jazz FM plays as configured", kind: toastInfo}, cmd())
+
+	})
+
+	t.Run("refuses values it doesn't know", func(t *testing.T) {
+
+		prefs := newStationPrefsStore()
+		model := newModel(prefs, newVolumeTrimStore())
+
+		for _, args := range [][]string{{"bitrate", "fast"}, {"reconnect", "always"}, {"backend", "vlc"}, {"volume", "+90"}, {"colour", "red"}} {
+			_, cmd := model.runCommand(command{name: "prefs", args: args})
+			_, isError := cmd().(nonFatalError)
+			assert.True(t, isError, args)
+		}
+		assert.True(t, prefs.Prefs(jazz.StationUuid).IsZero())
+
+	})
+
+	t.Run("plays stations with their preferences", func(t *testing.T) {
+
+		prefs := newStationPrefsStore()
+		_ = prefs.SetPrefs(jazz.StationUuid, common.StationPrefs{HLSBitrate: 64})
+
+		assert.Equal(t, 64, withStationPrefs(prefs, jazz).Prefs.HLSBitrate)
+		assert.Equal(t, jazz, withStationPrefs(nil, jazz))
+
+	})
+
+}
+
+func TestModel_StationPrefsReconnects(t *testing.T) {
+
+	newModel := func(prefs common.StationPrefs) Model {
+		playbackManager := mocks.MockPlaybackManagerService{
+			IsPlayingResult: true,
+			StopStationFunc: func() error {
+				return nil
+			},
+		}
+		model := NewModel(config.Config{}, &mocks.MockRadioBrowserService{}, &playbackManager, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		model.backendExits = make(chan playback.ProcessExit)
+		return model.trackPlayingStation(playbackStartedMsg{station: common.Station{Name: "Jazz FM", Prefs: prefs}})
+	}
+	exit := playback.ProcessExit{Name: "mpv", Code: -1, Reason: playback.ErrStreamStalled}
+
+	t.Run("never reconnects stations that ask not to", func(t *testing.T) {
+
+		model := newModel(common.StationPrefs{Reconnect: common.ReconnectNever})
+
+		_, _, ok := model.watchdogTripped(exit)
+
+		assert.False(t, ok)
+
+	})
+
+	t.Run("keeps reconnecting stations that ask to persist", func(t *testing.T) {
+
+		model := newModel(common.StationPrefs{Reconnect: common.ReconnectPersistent})
+		model.watchdogReconnects = maxWatchdogReconnects
+		model.watchdogTrippedAt = time.Now()
+
+		model, _, ok := model.watchdogTripped(exit)
+
+		assert.True(t, ok)
+		assert.Equal(t, maxWatchdogReconnects+1, model.watchdogReconnects)
+
+	})
+
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The split-pane layout needs a terminal at least splitPaneMinWidth columns wide,
+// otherwise only the stations table is shown.
+const (
+	splitPaneMinWidth = 80
+	splitPaneGap      = 2
+)
+
+// Messages
+
+// splitPaneToggledMsg reports that the split-pane layout was turned on or off.
+type splitPaneToggledMsg struct {
+	enabled bool
+}
+
+// stationPreviewWidth returns the width of the preview pane for a terminal of the given width.
+func stationPreviewWidth(width int) int {
+	previewWidth := width * 2 / 5
+	if previewWidth < 30 {
+		return 30
+	}
+	if previewWidth > 60 {
+		return 60
+	}
+	return previewWidth
+}
+
+// stationPreviewView renders the details of the highlighted station in the right pane of the split-pane layout.
+func stationPreviewView(theme Theme, station common.Station, label storage.StationLabel, width int) string {
+
+	name := station.Name
+	if label.Alias != "" {
+		name = label.Alias
+	}
+
+	v := theme.SecondaryText.Bold(true).Copy().Width(width).Render(displayText(name)) + "\n"
+
+	if station.LastCheckOk {
+		v += theme.RenderOk(i18n.T("preview.online")) + "\n\n"
+	} else {
+		v += theme.RenderError(i18n.T("preview.offline")) + "\n\n"
+	}
+
+	country := station.CountryCode
+	if station.State != "" {
+		country = fmt.Sprintf("%s, %s", station.State, station.CountryCode)
+	}
+
+	codec := station.Codec
+	if station.Bitrate > 0 {
+		codec = i18n.Tf("detail.bitrate", codec, station.Bitrate)
+	}
+
+	rows := [][2]string{
+		{i18n.T("detail.country"), country},
+		{i18n.T("detail.languages"), station.Languages},
+		{i18n.T("detail.codec"), codec},
+		{i18n.T("detail.votes"), fmt.Sprintf("%d", station.Votes)},
+		{i18n.T("preview.clicks"), fmt.Sprintf("%d", station.ClickCount)},
+		{i18n.T("preview.trend"), stationTrend(station.ClickTrend)},
+		{i18n.T("detail.note"), label.Note},
+	}
+
+	keyWidth := 14
+	keyStyle := theme.PrimaryText.Copy().Width(keyWidth)
+	valueWidth := width - keyWidth
+	if valueWidth < 1 {
+		valueWidth = 1
+	}
+
+	for _, row := range rows {
+		value := theme.TertiaryText.Render("-")
+		if row[1] != "" {
+			value = theme.Text.Copy().Width(valueWidth).Render(displayText(row[1]))
+		}
+		v += lipgloss.JoinHorizontal(lipgloss.Top, keyStyle.Render(row[0]), value) + "\n"
+	}
+
+	v += "\n" + theme.PrimaryText.Render(i18n.T("detail.tags")) + "\n"
+	tags := strings.ReplaceAll(station.Tags, ",", ", ")
+	if tags == "" {
+		v += theme.TertiaryText.Render("-")
+	} else {
+		v += theme.Text.Copy().Width(width).Render(displayText(tags))
+	}
+
+	return v
+}
+
+// stationTrend describes how the clicks of a station changed over the last two days.
+func stationTrend(clickTrend int64) string {
+	switch {
+	case clickTrend > 0:
+		return i18n.Tf("preview.trend.up", clickTrend)
+	case clickTrend < 0:
+		return i18n.Tf("preview.trend.down", -clickTrend)
+	default:
+		return i18n.T("preview.trend.steady")
+	}
+}
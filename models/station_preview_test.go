@@ -0,0 +1,135 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSplitPaneStationsModel(width int) StationsModel {
+	model := NewStationsModel(
+		Theme{},
+		&mocks.MockRadioBrowserService{},
+		&mocks.MockPlaybackManagerService{},
+		&mocks.MockLabelStore{},
+		&mocks.MockBookmarkStore{},
+		&mocks.MockReportStore{},
+		filter.ContentFilter{},
+		[]common.Station{{Name: "Jazz FM", Tags: "jazz,smooth", ClickTrend: 12}},
+		config.DefaultStationColumns(),
+		nil,
+		stationPageKey{},
+		false,
+	)
+	model.SetWidthAndHeight(width, 30)
+	return model
+}
+
+func TestStationPreviewWidth(t *testing.T) {
+
+	assert.Equal(t, 30, stationPreviewWidth(60))
+	assert.Equal(t, 40, stationPreviewWidth(100))
+	assert.Equal(t, 60, stationPreviewWidth(200))
+
+}
+
+func TestStationPreviewView(t *testing.T) {
+
+	station := common.Station{Name: "Jazz FM", Tags: "jazz,smooth", ClickTrend: -3, LastCheckOk: true}
+
+	t.Run("shows the details of the station", func(t *testing.T) {
+
+		view := stationPreviewView(Theme{}, station, storage.StationLabel{}, 40)
+
+		assert.Contains(t, view, "Jazz FM")
+		assert.Contains(t, view, "Online")
+		assert.Contains(t, view, "jazz, smooth")
+		assert.Contains(t, view, "▼ -3")
+
+	})
+
+	t.Run("shows the custom name and note of the station", func(t *testing.T) {
+
+		view := stationPreviewView(Theme{}, station, storage.StationLabel{Alias: "Morning jazz", Note: "Great at 7am"}, 40)
+
+		assert.Contains(t, view, "Morning jazz")
+		assert.Contains(t, view, "Great at 7am")
+
+	})
+
+	t.Run("fits the width of the pane", func(t *testing.T) {
+
+		station := station
+		station.Tags = strings.Repeat("ambient,", 20)
+
+		view := stationPreviewView(Theme{}, station, storage.StationLabel{}, 30)
+
+		for _, line := range strings.Split(view, "\n") {
+			assert.LessOrEqual(t, len([]rune(line)), 30)
+		}
+
+	})
+
+}
+
+func TestStationsModel_SplitPane(t *testing.T) {
+
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+
+	t.Run("toggles the preview of the highlighted station", func(t *testing.T) {
+
+		model := newSplitPaneStationsModel(100)
+		assert.NotContains(t, model.View(), "▲ +12")
+
+		newModel, cmd := model.Update(tab)
+
+		assert.Equal(t, splitPaneToggledMsg{enabled: true}, cmd())
+		assert.Contains(t, newModel.View(), "▲ +12")
+
+		_, cmd = newModel.Update(tab)
+
+		assert.Equal(t, splitPaneToggledMsg{enabled: false}, cmd())
+
+	})
+
+	t.Run("shows only the stations table in narrow terminals", func(t *testing.T) {
+
+		model := newSplitPaneStationsModel(splitPaneMinWidth - 1)
+		model.SetSplitPane(true)
+
+		assert.NotContains(t, model.View(), "▲ +12")
+
+		model.SetWidthAndHeight(splitPaneMinWidth, 30)
+
+		assert.Contains(t, model.View(), "▲ +12")
+
+	})
+
+}
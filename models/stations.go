@@ -20,78 +20,277 @@
 package models
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/zi0p4tch0/radiogogo/api"
 	"github.com/zi0p4tch0/radiogogo/assets"
 	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/icy"
 	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/secrets"
+	"github.com/zi0p4tch0/radiogogo/storage"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/google/uuid"
 )
 
+// How far the timeshift keys move the station.
+const timeshiftSeekStep = 10 * time.Second
+
 type StationsModel struct {
 	theme Theme
 
 	stations              []common.Station
+	columns               []config.StationColumn
 	stationsTable         table.Model
 	currentStation        common.Station
+	currentStream         common.StreamInfo
 	currentStationSpinner spinner.Model
+	bufferingStation      *common.Station
 	volume                int
-	err                   string
+	detailModel           StationDetailModel
+	showDetail            bool
+	columnPicker          ColumnPickerModel
+	showColumnPicker      bool
+	// How the columns fit in the width of the table, scrolled past columnScroll columns
+	columnsLayout         stationColumnsLayout
+	columnScroll          int
+	splitPane             bool
+	commandLine           CommandLineModel
+	showCommandLine       bool
+	reportModel           ReportModel
+	showReport            bool
+	blockStation          BlockStationModel
+	showBlockStation      bool
+	scheduleRecording     ScheduleRecordingModel
+	showScheduleRecording bool
+	editStation           EditStationModel
+	showEditStation       bool
+	queueModel            QueueModel
+	showQueue             bool
+	stationMap            StationMapModel
+	showStationMap        bool
+	// queue is played on, a station after the other, when a station stops or has played for queueDwell.
+	queue      *stationQueue
+	queueDwell time.Duration
+	// playGeneration changes whenever a station is played or stopped, so that stale dwell ticks are ignored.
+	playGeneration int
+	// scanning plays each station for scanPeriod before moving on to the next one, until a key is pressed.
+	scanning   bool
+	scanPeriod time.Duration
+	// scanDwell is the scanPeriod used when none is given to ":scan".
+	scanDwell time.Duration
+	// scanFailures counts the stations in a row that couldn't be played while scanning.
+	scanFailures int
+	// compared are the stations compared with "c", the first one alone until a second one is marked,
+	// which of the two is heard, what their servers announced and whether both are kept connected.
+	// Playing any station but by switching between them stops comparing.
+	compared          []common.Station
+	comparedHeard     int
+	comparedStreams   [2]common.StreamInfo
+	comparedConnected bool
+	switchingCompared bool
+	// lastFind is the text last searched with "/", found again by an empty search.
+	lastFind string
+	// allStations are the stations of the current page, of which stations are those matching filterText.
+	allStations []common.Station
+	filterText  string
+	// pendingG is true after "g" is pressed, waiting for a second "g" to jump to the first station.
+	pendingG bool
+	// pendingTypeAhead is true after typeAheadKey is pressed, waiting for the letter to jump to.
+	pendingTypeAhead bool
+	// bandwidth is shown next to the station being played, if metered.
+	bandwidth *bandwidthUsage
+	// levels are shown as a VU meter next to the station being played, if measured.
+	levels *playback.Levels
+	// clock tells how long the station being played and all of them have been listened to.
+	clock *listeningClock
+	// delay tells how far behind the broadcast the station being played is heard.
+	delay *streamDelay
 
 	browser         api.RadioBrowserService
 	playbackManager playback.PlaybackManagerService
-	width           int
-	height          int
+	labelStore      storage.LabelStore
+	bookmarkStore   storage.BookmarkStore
+	reportStore     storage.ReportStore
+	// Stations hidden for good are left out of the results (nil when there's nowhere to keep them)
+	blocklist storage.BlocklistStore
+	// Remembers the clicks and votes sent to radio-browser (nil always sends them)
+	interactions storage.InteractionStore
+	// Sends the clicks and votes, again later if radio-browser can't be reached
+	actions *api.ActionQueue
+	// Remembers how much louder or quieter each station plays (nil doesn't trim them)
+	volumeTrims   storage.VolumeTrimStore
+	contentFilter filter.ContentFilter
+	// Probes streams before they're played (nil plays them straight away)
+	prober icy.ProberService
+	// Keeps the credentials of private streams (nil plays them as they are)
+	credentials secrets.Store
+	// Remembers which URL of each station played, to try it first (nil always tries the resolved URL first)
+	streamVariants storage.StreamVariantStore
+	// Keeps the headers sent for the streams of stations, edited in their details (nil sends none)
+	streamHeaders storage.StreamHeaderStore
+	// Keeps how each station is played, changed with ":prefs" (nil plays them as configured)
+	stationPrefs storage.StationPrefsStore
+	// Tells which stations were interrupted lately (nil doesn't)
+	history storage.HistoryStore
+	// Fetches the favicons shown in the station details (nil hides them)
+	assets assets.Cache
+	width  int
+	height int
+	// Opens the edit page of reported stations, and homepages
+	openURL         func(url string) error
+	copyToClipboard func(text string) error
+	// Starts the external player, run by the externalPlayer command template
+	startProgram   func(name string, args ...string) error
+	externalPlayer string
+	// Where the results are exported to with ":export" (empty for the current directory)
+	exportDir string
+
+	// Paging
+	pages       *stationPageCache
+	page        stationPageKey
+	hasNextPage bool
+	loadingPage bool
+	// lastPage is the last page of results once seen (-1 until then), and lastPageSize how many stations it has.
+	lastPage     int
+	lastPageSize int
+	// resultCount is how many stations radio-browser counts for the search, 0 if it can't count them.
+	resultCount int
+	// seek is the page being looked for, while moving past the next page.
+	seek *pageSeek
+	// loadedAt is when the results shown were loaded. They're fetched again every refreshInterval (0 never).
+	loadedAt        time.Time
+	refreshInterval time.Duration
+	// The stations that changed when the results were last refreshed, highlighted for highlightDuration (0 never)
+	changes           stationChanges
+	changesGeneration int
+	highlightDuration time.Duration
+	refreshing        bool
+	// Holds off refreshing while the network is down
+	network *networkWatch
 }
 
 func NewStationsModel(
 	theme Theme,
 	browser api.RadioBrowserService,
 	playbackManager playback.PlaybackManagerService,
+	labelStore storage.LabelStore,
+	bookmarkStore storage.BookmarkStore,
+	reportStore storage.ReportStore,
+	contentFilter filter.ContentFilter,
 	stations []common.Station,
+	columns []config.StationColumn,
+	pages *stationPageCache,
+	page stationPageKey,
+	hasNextPage bool,
 ) StationsModel {
 
-	return StationsModel{
+	model := StationsModel{
 		theme:           theme,
 		stations:        stations,
-		stationsTable:   newStationsTableModel(theme, stations),
+		allStations:     stations,
+		stationsTable:   newStationsTableModel(theme, stations, columns, labelStore, bookmarkStore),
+		scanDwell:       defaultScanDwell,
+		loadedAt:        time.Now(),
+		columns:         columns,
+		columnsLayout:   fitStationColumns(columns, 0, 0),
 		volume:          playbackManager.VolumeDefault(),
 		browser:         browser,
+		actions:         api.NewActionQueue(browser),
 		playbackManager: playbackManager,
+		labelStore:      labelStore,
+		bookmarkStore:   bookmarkStore,
+		reportStore:     reportStore,
+		contentFilter:   contentFilter,
+		openURL:         common.OpenURL,
+		copyToClipboard: common.CopyToClipboard,
+		startProgram:    common.StartDetached,
+		pages:           pages,
+		page:            page,
+		hasNextPage:     hasNextPage,
+		lastPage:        -1,
 	}
+	if !hasNextPage {
+		model.lastPage, model.lastPageSize = page.page, len(stations)
+	}
+	return model
 }
 
-func newStationsTableModel(theme Theme, stations []common.Station) table.Model {
+// stationDisplayName returns the custom name of the station if the user set one,
+// or the upstream station name otherwise.
+func stationDisplayName(labelStore storage.LabelStore, station common.Station) string {
+	if label, ok := labelStore.Get(station.StationUuid); ok && label.Alias != "" {
+		return label.Alias
+	}
+	return station.Name
+}
 
+func newStationsTableRows(
+	stations []common.Station,
+	columns []config.StationColumn,
+	labelStore storage.LabelStore,
+	bookmarkStore storage.BookmarkStore,
+	changes stationChanges,
+) []table.Row {
 	rows := make([]table.Row, len(stations))
 	for i, station := range stations {
-		rows[i] = table.Row{
-			station.Name,
-			station.CountryCode,
-			station.LanguagesCodes,
-			station.Codec,
-			fmt.Sprintf("%d", station.Votes),
+		change, changed := changes[station.StationUuid]
+		row := make(table.Row, len(columns))
+		for j, column := range columns {
+			if column.Name != "name" {
+				row[j] = stationColumnSpecs[column.Name].value(station)
+				switch column.Name {
+				case "votes":
+					row[j] += countTrend(change.votes)
+				case "clicks":
+					row[j] += countTrend(change.clicks)
+				}
+				continue
+			}
+			name := stationDisplayName(labelStore, station)
+			if bookmarkStore.IsBookmarked(station.StationUuid) {
+				name = "★ " + name
+			}
+			if changed {
+				name = change.trend() + " " + name
+			}
+			row[j] = name
 		}
+		rows[i] = fitRow(row, newStationsTableColumns(columns))
 	}
+	return rows
+}
+
+func newStationsTableModel(
+	theme Theme,
+	stations []common.Station,
+	columns []config.StationColumn,
+	labelStore storage.LabelStore,
+	bookmarkStore storage.BookmarkStore,
+) table.Model {
+
+	rows := newStationsTableRows(stations, columns, labelStore, bookmarkStore, nil)
 
 	t := table.New(
-		table.WithColumns([]table.Column{
-			{Title: "Name", Width: 30},
-			{Title: "Country", Width: 10},
-			{Title: "Language(s)", Width: 15},
-			{Title: "Codec(s)", Width: 15},
-			{Title: "Votes", Width: 10},
-		}),
+		table.WithColumns(newStationsTableColumns(columns)),
 		table.WithRows(rows),
 		table.WithFocused(true),
 	)
 
 	t.SetStyles(theme.StationsTableStyle)
+	// "gg" jumps to the first station, see StationsModel.Update
+	t.KeyMap.GotoTop.SetKeys("home")
 
 	return t
 
@@ -101,6 +300,8 @@ func newStationsTableModel(theme Theme, stations []common.Station) table.Model {
 
 type playbackStartedMsg struct {
 	station common.Station
+	// stream is what the stream server announced when probed, if it was.
+	stream common.StreamInfo
 }
 type playbackStoppedMsg struct{}
 
@@ -108,26 +309,99 @@ type nonFatalError struct {
 	stopPlayback bool
 	err          error
 }
-type clearNonFatalError struct{}
 
+type stationLabelSavedMsg struct{}
+
+// bookmarkToggledMsg tells that the station with the given name was bookmarked, or that its bookmark was removed.
+type bookmarkToggledMsg struct {
+	// removed is the bookmark removed, if it was
+	removed    storage.RemovedBookmark
+	station    common.Station
+	name       string
+	bookmarked bool
+}
+
+// playSelectedStationMsg plays the station under the cursor, as if "enter" was pressed.
+type playSelectedStationMsg struct{}
+
+// stationCursorMovedMsg reports the position of the cursor across all pages of results.
 type stationCursorMovedMsg struct {
 	offset        int
 	totalStations int
+	// moreStations is true if there are more stations on the next pages.
+	moreStations bool
 }
 
 // Commands
 
+// playStationCmd plays a station. If prober is not nil, the stream is probed first,
+// so that a stream that can't be played is reported before the backend is started,
+// and the other URLs of the station are tried in its place.
 func playStationCmd(
 	playbackManager playback.PlaybackManagerService,
+	contentFilter filter.ContentFilter,
+	prober icy.ProberService,
+	credentials secrets.Store,
+	variants storage.StreamVariantStore,
 	station common.Station,
 	volume int,
 ) tea.Cmd {
 	return func() tea.Msg {
-		err := playbackManager.PlayStation(station, volume)
+		authenticated, stream, err := prepareStation(contentFilter, prober, credentials, variants, station)
 		if err != nil {
 			return nonFatalError{stopPlayback: false, err: err}
 		}
-		return playbackStartedMsg{station: station}
+		err = playbackManager.PlayStation(authenticated, volume)
+		if err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		station.Hls = authenticated.Hls
+		return playbackStartedMsg{station: station, stream: stream}
+	}
+}
+
+// prepareStation returns station as the playback manager is given it: with the credentials stored for it,
+// and set to the URL it's played from, as picked by probeStation.
+func prepareStation(contentFilter filter.ContentFilter, prober icy.ProberService, credentials secrets.Store, variants storage.StreamVariantStore, station common.Station) (common.Station, common.StreamInfo, error) {
+	authenticated, err := authenticate(credentials, station)
+	if err != nil {
+		return station, common.StreamInfo{}, err
+	}
+	return probeStation(contentFilter, prober, variants, authenticated)
+}
+
+// probeStation checks that station may be played and picks the URL it's played from, returning station set to it.
+// If prober isn't nil, it also returns what the server announces about the stream, and the URL that played
+// after the one tried first failed is remembered in variants, if not nil, to be tried first the next time.
+func probeStation(contentFilter filter.ContentFilter, prober icy.ProberService, variants storage.StreamVariantStore, station common.Station) (common.Station, common.StreamInfo, error) {
+	if !contentFilter.Allows(station) {
+		return station, common.StreamInfo{}, filter.ErrBlocked
+	}
+	if headerProber, ok := prober.(icy.HeaderProber); ok && len(station.Headers) > 0 {
+		prober = headerProber.WithHeaders(station.Headers)
+	}
+	order := streamVariantOrder(variants, station)
+	station, stream, variant, err := pickStream(prober, station, order)
+	if err != nil {
+		return station, common.StreamInfo{}, err
+	}
+	if prober != nil && variants != nil && variant != order[0] {
+		_ = variants.SetVariant(station.StationUuid, variant)
+	}
+	// radio-browser doesn't flag every HLS station
+	if stream.Codec == "HLS" {
+		station.Hls = true
+	}
+	return station, stream, nil
+}
+
+func setVolumeCmd(volumeSetter playback.VolumeSetter, volume int) tea.Cmd {
+	return func() tea.Msg {
+		err := volumeSetter.SetVolume(volume)
+		if err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return nil
 	}
 }
 
@@ -141,9 +415,10 @@ func stopStationCmd(playbackManager playback.PlaybackManagerService) tea.Cmd {
 	}
 }
 
-func notifyRadioBrowserCmd(browser api.RadioBrowserService, station common.Station) tea.Cmd {
+// timeshiftCmd runs a timeshift action, which restarts the player and may take a while.
+func timeshiftCmd(action func() error) tea.Cmd {
 	return func() tea.Msg {
-		_, err := browser.ClickStation(station)
+		err := action()
 		if err != nil {
 			return nonFatalError{stopPlayback: false, err: err}
 		}
@@ -151,21 +426,79 @@ func notifyRadioBrowserCmd(browser api.RadioBrowserService, station common.Stati
 	}
 }
 
-func updateCommandsCmd(isPlaying bool, volume int, volumeIsPercentage bool) tea.Cmd {
+func saveStationLabelCmd(labelStore storage.LabelStore, msg stationLabelChangedMsg) tea.Cmd {
+	return func() tea.Msg {
+		err := labelStore.Set(msg.stationUuid, msg.label)
+		if err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return stationLabelSavedMsg{}
+	}
+}
+
+func toggleBookmarkCmd(bookmarkStore storage.BookmarkStore, station common.Station, name string) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		var removed storage.RemovedBookmark
+		bookmarked := !bookmarkStore.IsBookmarked(station.StationUuid)
+		if bookmarked {
+			err = bookmarkStore.Add(station)
+		} else {
+			removed, err = bookmarkStore.Remove(station.StationUuid)
+		}
+		if err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return bookmarkToggledMsg{station: station, name: name, bookmarked: bookmarked, removed: removed}
+	}
+}
+
+// updateCommandsCmd lists the available commands. The volume can only be changed
+// while stopped, unless liveVolume is true. Paging commands are listed if paged is true,
+// and timeshift commands while playing if timeshift is true.
+func updateCommandsCmd(isPlaying bool, volume int, volumeIsPercentage bool, liveVolume bool, paged bool, timeshift bool) tea.Cmd {
 	return func() tea.Msg {
 
-		commands := []string{"q: quit", "s: search", "enter: play", "↑/↓: move"}
+		commands := []string{
+			i18n.T("commands.quit"),
+			i18n.T("commands.newSearch"),
+			i18n.T("commands.play"),
+			i18n.T("commands.move"),
+			i18n.T("commands.details"),
+			i18n.T("commands.bookmark"),
+			i18n.T("commands.vote"),
+			i18n.T("commands.columns"),
+			i18n.T("commands.flag"),
+			i18n.T("commands.record"),
+			i18n.T("commands.queue"),
+			i18n.T("commands.scan"),
+			i18n.T("commands.copy"),
+			i18n.T("commands.refresh"),
+			i18n.T("commands.splitPane"),
+			i18n.T("commands.commandLine"),
+			i18n.T("commands.help"),
+		}
+
+		if paged {
+			commands = append(commands, i18n.T("commands.page"), i18n.T("commands.pageJump"))
+		}
 
 		if isPlaying {
-			commands = append(commands, "ctrl+k: stop")
-		} else {
+			commands = append(commands, i18n.T("commands.stop"))
+		}
+
+		if isPlaying && timeshift {
+			commands = append(commands, i18n.T("commands.pause"), i18n.T("commands.seek"))
+		}
+
+		if !isPlaying || liveVolume {
 
 			volume := fmt.Sprintf("%d", volume)
 			if volumeIsPercentage {
 				volume += "%"
 			}
 
-			commands = append(commands, "9/0: vol down/up", "vol: "+volume)
+			commands = append(commands, i18n.T("commands.volume"), i18n.Tf("commands.volumeLevel", volume))
 		}
 
 		return bottomBarUpdateMsg{
@@ -178,58 +511,369 @@ func updateCommandsCmd(isPlaying bool, volume int, volumeIsPercentage bool) tea.
 
 func (m StationsModel) Init() tea.Cmd {
 	return tea.Batch(
-		updateCommandsCmd(false, m.volume, m.playbackManager.VolumeIsPercentage()),
-		func() tea.Msg {
-			return stationCursorMovedMsg{
-				offset:        m.stationsTable.Cursor(),
-				totalStations: len(m.stations),
-			}
-		},
+		updateCommandsCmd(false, m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift()),
+		m.cursorMovedCmd(),
+		m.prefetchNextPageCmd(),
+		m.resultsAgeTickCmd(),
+		m.countResultsCmd(),
 	)
 }
 
 func (m StationsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	var cmds []tea.Cmd
+	cursorMoved := false
 
 	switch msg := msg.(type) {
 	case playbackStartedMsg:
+		if !m.switchingCompared {
+			m.stopComparing()
+		}
+		m.switchingCompared = false
+		m.bufferingStation = nil
 		m.currentStation = msg.station
+		m.currentStream = msg.stream
 		m.currentStationSpinner = spinner.New()
 		m.currentStationSpinner.Spinner = spinner.Dot
 		m.currentStationSpinner.Style = m.theme.PrimaryText
-		return m, tea.Batch(
+		m.playGeneration++
+		cmds := []tea.Cmd{
 			m.currentStationSpinner.Tick,
-			notifyRadioBrowserCmd(m.browser, m.currentStation),
-			updateCommandsCmd(true, m.volume, m.playbackManager.VolumeIsPercentage()),
-		)
+			notifyRadioBrowserCmd(m.actions, m.interactions, m.currentStation),
+		}
+		if m.scanning {
+			m.scanFailures = 0
+			cmds = append(cmds, updateCommandsForScan, scanTickCmd(m.scanPeriod, m.playGeneration))
+			return m, tea.Batch(cmds...)
+		}
+		cmds = append(cmds, updateCommandsCmd(true, m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift()))
+		if m.queueDwell > 0 && m.queue != nil && m.queue.len() > 0 {
+			cmds = append(cmds, queueDwellCmd(m.queueDwell, m.playGeneration))
+		}
+		return m, tea.Batch(cmds...)
 	case playbackStoppedMsg:
+		m.stopComparing()
 		m.currentStation = common.Station{}
+		m.currentStream = common.StreamInfo{}
 		m.currentStationSpinner = spinner.Model{}
-		return m, updateCommandsCmd(false, m.volume, m.playbackManager.VolumeIsPercentage())
+		m.playGeneration++
+		if m.scanning {
+			return m.scanNext()
+		}
+		return m, updateCommandsCmd(false, m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift())
+	case closeQueueMsg:
+		m.showQueue = false
+		return m, updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift())
+	case playQueuedStationMsg:
+		return m.playQueuedStation(msg.station)
+	case resultsCountedMsg:
+		if sameSearch(msg.key, m.page) {
+			m.resultCount = msg.stations
+		}
+		return m, nil
+	case closeStationMapMsg:
+		m.showStationMap = false
+		return m, updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift())
+	case pickMappedStationMsg:
+		return m.pickMappedStation(msg.station)
+	case advanceQueueMsg:
+		return m.advanceQueue()
+	case reconnectStationMsg:
+		// A scan moves on to the next station instead
+		if m.scanning || m.bufferingStation != nil {
+			return m, nil
+		}
+		return m.bufferStation(msg.station, playStationCmd(m.playbackManager, m.contentFilter, m.prober, m.credentials, m.streamVariants, withStationPrefs(m.stationPrefs, withStreamHeaders(m.streamHeaders, msg.station)), m.stationVolume(msg.station)))
+	case queueDwellMsg:
+		if msg.generation != m.playGeneration || !m.playbackManager.IsPlaying() {
+			return m, nil
+		}
+		return m.advanceQueue()
+	case queuedStationFailedMsg:
+		newModel, cmd := m.Update(nonFatalError{stopPlayback: false, err: msg.err})
+		return newModel, tea.Batch(cmd, showErrorBannerCmd(msg.err), advanceQueueCmd)
+	case comparisonStartedMsg:
+		return m.comparisonStarted(msg)
+	case scanTickMsg:
+		if msg.generation != m.playGeneration || !m.playbackManager.IsPlaying() {
+			return m, nil
+		}
+		return m.scanNext()
+	case scanStationFailedMsg:
+		m.scanFailures++
+		newModel, cmd := m.Update(nonFatalError{stopPlayback: false, err: msg.err})
+		newModel, next := newModel.(StationsModel).scanNext()
+		return newModel, tea.Batch(cmd, showErrorBannerCmd(msg.err), next)
 	case nonFatalError:
 		var cmds []tea.Cmd
 		if msg.stopPlayback {
 			cmds = append(cmds, stopStationCmd(m.playbackManager))
 		}
-		m.err = msg.err.Error()
-		cmds = append(cmds, tea.Tick(3*time.Second, func(t time.Time) tea.Msg {
-			return clearNonFatalError{}
-		}))
-
+		// The comparison couldn't start, or switch
+		if m.bufferingStation != nil {
+			m.stopComparing()
+			m.switchingCompared = false
+		}
+		m.bufferingStation = nil
+		m.loadingPage = false
+		m.seek = nil
 		return m, tea.Sequence(cmds...)
-	case clearNonFatalError:
-		m.err = ""
+	case stationLabelChangedMsg:
+		return m, saveStationLabelCmd(m.labelStore, msg)
+	case streamHeadersChangedMsg:
+		return m, saveStreamHeadersCmd(m.streamHeaders, msg)
+	case stationLabelSavedMsg:
+		m.stationsTable.SetRows(newStationsTableRows(m.stations, m.columnsLayout.columns, m.labelStore, m.bookmarkStore, m.changes))
 		return m, nil
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "up", "down", "j", "k":
+	case bookmarkToggledMsg:
+		m.stationsTable.SetRows(newStationsTableRows(m.stations, m.columnsLayout.columns, m.labelStore, m.bookmarkStore, m.changes))
+		if msg.bookmarked {
+			return m, showToastCmd(i18n.Tf("bookmarks.added", msg.name), toastSuccess)
+		}
+		return m, bookmarkRemovedUndoableCmd(m.bookmarkStore, msg.removed, msg.name)
+	case bookmarkRestoredMsg:
+		m.stationsTable.SetRows(newStationsTableRows(m.stations, m.columnsLayout.columns, m.labelStore, m.bookmarkStore, m.changes))
+		return m, nil
+	case playSelectedStationMsg:
+		return m.playSelectedStation()
+	case stationPageLoadedMsg:
+		if m.seek != nil {
+			return m.pageSought(msg)
+		}
+		m.loadingPage = false
+		m.page = msg.key
+		m.changes = nil
+		m.setPageSize(msg.key.page, len(msg.stations))
+		m.setStations(withoutBlockedStations(m.blocklist, withoutReportedStations(m.reportStore, m.contentFilter.Apply(msg.stations))))
+		m.stationsTable.SetCursor(0)
+		m.loadedAt = time.Now()
+		return m, tea.Batch(
+			updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift()),
+			m.cursorMovedCmd(),
+			m.prefetchNextPageCmd(),
+			m.resultsAgeTickCmd(),
+		)
+	case resultsAgeTickMsg:
+		if !msg.loadedAt.Equal(m.loadedAt) {
+			return m, nil
+		}
+		if m.refreshInterval > 0 && time.Since(m.loadedAt) >= m.refreshInterval && !m.network.offline() {
+			return m.refreshResults()
+		}
+		return m, m.resultsAgeTickCmd()
+	case resultsRefreshedMsg:
+		return m.resultsRefreshed(msg)
+	case resultsChangesExpiredMsg:
+		return m.changesExpired(msg)
+	case externalPlayerStartedMsg:
+		return m, externalPlayerStarted(m.playbackManager.IsPlaying(), stopStationCmd(m.playbackManager), msg)
+	case resultsRefreshFailedMsg:
+		m.refreshing = false
+		newModel, cmd := m.Update(nonFatalError{stopPlayback: false, err: msg.err})
+		return newModel, tea.Batch(cmd, showErrorBannerCmd(msg.err), m.resultsAgeTickCmd())
+	case closeColumnPickerMsg:
+		m.showColumnPicker = false
+		cmds := []tea.Cmd{
+			updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift()),
+		}
+		if msg.changed {
+			columns := msg.columns
 			cmds = append(cmds, func() tea.Msg {
-				return stationCursorMovedMsg{
-					offset:        m.stationsTable.Cursor(),
-					totalStations: len(m.stations),
-				}
+				return stationColumnsChangedMsg{columns: columns}
 			})
+		}
+		return m, tea.Batch(cmds...)
+	case closeCommandLineMsg:
+		m.showCommandLine = false
+		restoreCommands := updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift())
+		if m.commandLine.mode == filterMode {
+			// Cancelling the filter shows all the stations again
+			newModel, cmd := m.filter("")
+			return newModel, tea.Batch(restoreCommands, cmd)
+		}
+		return m, restoreCommands
+	case filterChangedMsg:
+		return m.filter(msg.text)
+	case commandLineSubmittedMsg:
+		m.showCommandLine = false
+		restoreCommands := updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift())
+		switch msg.mode {
+		case findMode:
+			newModel, cmd := m.find(msg.line)
+			return newModel, tea.Batch(restoreCommands, cmd)
+		case filterMode:
+			newModel, cmd := m.filter(msg.line)
+			return newModel, tea.Batch(restoreCommands, cmd)
+		}
+		c, err := parseCommand(msg.line)
+		if err != nil {
+			return m, tea.Batch(restoreCommands, nonFatalErrorCmd(err))
+		}
+		newModel, cmd := m.runCommand(c)
+		return newModel, tea.Batch(restoreCommands, cmd)
+	case closeReportMsg:
+		m.showReport = false
+		return m, updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift())
+	case reportSubmittedMsg:
+		m.showReport = false
+		return m, tea.Batch(
+			updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift()),
+			saveReportCmd(m.reportStore, m.openURL, msg),
+		)
+	case stationReportedMsg:
+		return m.hideStation(msg.stationUuid, msg.err)
+	case closeBlockStationMsg:
+		m.showBlockStation = false
+		return m, updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift())
+	case blockSubmittedMsg:
+		m.showBlockStation = false
+		return m, tea.Batch(
+			updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift()),
+			blockStationCmd(m.blocklist, msg.blocked),
+		)
+	case stationBlockedMsg:
+		newModel, cmd := m.hideStation(msg.blocked.StationUuid, nil)
+		return newModel, tea.Batch(cmd, showToastCmd(i18n.Tf("blocklist.blocked", displayText(msg.blocked.StationName)), toastSuccess))
+	case closeScheduleRecordingMsg:
+		m.showScheduleRecording = false
+		return m, updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift())
+	case recordingSubmittedMsg:
+		m.showScheduleRecording = false
+		entry := msg.entry
+		return m, tea.Batch(
+			updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift()),
+			func() tea.Msg {
+				return recordingScheduledMsg{entry: entry}
+			},
+		)
+	case closeEditStationMsg:
+		m.showEditStation = false
+		return m, updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift())
+	case editSubmittedMsg:
+		m.showEditStation = false
+		return m, tea.Batch(
+			updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift()),
+			suggestEditCmd(m.browser, msg),
+		)
+	case closeStationDetailMsg:
+		m.showDetail = false
+		return m, updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift())
+	case tea.KeyMsg:
+		if m.scanning {
+			return m.lockOn()
+		}
+		if m.showDetail {
+			newDetailModel, cmd := m.detailModel.Update(msg)
+			m.detailModel = newDetailModel
+			return m, cmd
+		}
+		if m.showColumnPicker {
+			newColumnPicker, cmd := m.columnPicker.Update(msg)
+			m.columnPicker = newColumnPicker
+			return m, cmd
+		}
+		if m.showCommandLine {
+			newCommandLine, cmd := m.commandLine.Update(msg)
+			m.commandLine = newCommandLine
+			return m, cmd
+		}
+		if m.showReport {
+			newReportModel, cmd := m.reportModel.Update(msg)
+			m.reportModel = newReportModel
+			return m, cmd
+		}
+		if m.showBlockStation {
+			newBlockStation, cmd := m.blockStation.Update(msg)
+			m.blockStation = newBlockStation
+			return m, cmd
+		}
+		if m.showScheduleRecording {
+			newScheduleRecording, cmd := m.scheduleRecording.Update(msg)
+			m.scheduleRecording = newScheduleRecording
+			return m, cmd
+		}
+		if m.showEditStation {
+			newEditStation, cmd := m.editStation.Update(msg)
+			m.editStation = newEditStation
+			return m, cmd
+		}
+		if m.showQueue {
+			newQueueModel, cmd := m.queueModel.Update(msg)
+			m.queueModel = newQueueModel
+			return m, cmd
+		}
+		if m.showStationMap {
+			newStationMap, cmd := m.stationMap.Update(msg)
+			m.stationMap = newStationMap
+			return m, cmd
+		}
+		pendingG := m.pendingG
+		m.pendingG = false
+		pendingTypeAhead := m.pendingTypeAhead
+		m.pendingTypeAhead = false
+		if letter, ok := typeAheadLetter(msg); ok && pendingTypeAhead {
+			return m.jumpToLetter(letter)
+		}
+		switch msg.String() {
+		case "up", "down", "j", "k", "G", "home", "end", "pgup", "pgdown":
+			cursorMoved = true
+		case typeAheadKey:
+			m.pendingTypeAhead = true
+			return m, nil
+		case "g":
+			if !pendingG {
+				m.pendingG = true
+				return m, nil
+			}
+			m.stationsTable.GotoTop()
+			return m, m.cursorMovedCmd()
+		case ":":
+			return m.openCommandLine(commandMode)
+		case "/":
+			return m.openCommandLine(findMode)
+		case "f":
+			return m.openFilter()
+		case "esc":
+			if m.filterText == "" {
+				return m, nil
+			}
+			return m.filter("")
+		case "l":
+			if !m.hasNextPage || m.loadingPage {
+				return m, nil
+			}
+			return m.loadPage(m.page.page + 1)
+		case "h":
+			if m.page.page == 0 || m.loadingPage {
+				return m, nil
+			}
+			return m.loadPage(m.page.page - 1)
+		case "shift+left":
+			if m.columnScroll > 0 {
+				m.columnScroll--
+				m.layOutColumns()
+			}
+			return m, nil
+		case "shift+right":
+			if m.columnsLayout.hidden > 0 {
+				m.columnScroll++
+				m.layOutColumns()
+			}
+			return m, nil
+		case "<":
+			return m.goToPage(0)
+		case ">":
+			return m.goToLastPage()
+		case "n":
+			if !m.hasNextPage || m.loadingPage {
+				return m, nil
+			}
+			return m.loadPage(m.page.page + 1)
+		case "p":
+			if m.page.page == 0 || m.loadingPage {
+				return m, nil
+			}
+			return m.loadPage(m.page.page - 1)
 		case "ctrl+k":
 			return m, func() tea.Msg {
 				err := m.playbackManager.StopStation()
@@ -239,7 +883,7 @@ func (m StationsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return playbackStoppedMsg{}
 			}
 		case "q":
-			return m, tea.Sequence(stopStationCmd(m.playbackManager), quitCmd)
+			return m, stopAndQuitCmd
 		case "s":
 			return m, tea.Sequence(
 				stopStationCmd(m.playbackManager),
@@ -248,27 +892,99 @@ func (m StationsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				},
 			)
 		case "9":
-			if m.volume > m.playbackManager.VolumeMin() && !m.playbackManager.IsPlaying() {
-				m.volume -= 10
-				return m, updateCommandsCmd(false, m.volume, m.playbackManager.VolumeIsPercentage())
+			if m.volume > m.playbackManager.VolumeMin() {
+				return m.changeVolume(-10)
 			}
 			return m, nil
 		case "0":
-			if m.volume < m.playbackManager.VolumeMax() && !m.playbackManager.IsPlaying() {
-				m.volume += 10
-				return m, updateCommandsCmd(false, m.volume, m.playbackManager.VolumeIsPercentage())
+			if m.volume < m.playbackManager.VolumeMax() {
+				return m.changeVolume(10)
 			}
 			return m, nil
+		case "u":
+			return m, undoCmd
+		case "(":
+			return m.trimVolume(-volumeTrimStep)
+		case ")":
+			return m.trimVolume(volumeTrimStep)
 		case "enter":
+			return m.playSelectedStation()
+		case "x", "[", "]", "}":
+			return m.timeshift(msg.String())
+		case "tab":
+			return m.toggleSplitPane()
+		case "v":
+			return m.openColumnPicker()
+		case "!":
+			return m.openReport()
+		case "H":
+			return m.openBlockStation()
+		case "R":
+			return m.openScheduleRecording()
+		case "E":
+			return m.openEditStation()
+		case "a":
+			return m.enqueueSelectedStation()
+		case "S":
+			return m.startScan(0)
+		case "c":
+			return m.compareSelectedStation()
+		case "r":
+			return m.refreshResults()
+		case "+":
+			return m.voteSelectedStation()
+		case "w":
+			return m.openSelectedHomepage()
+		case "e":
+			return m.playSelectedExternally()
+		case "o":
+			return m, openURLCmd
+		case "m":
+			if len(m.stations) == 0 {
+				return m, nil
+			}
+			return m, showSimilarStations(m.playbackManager, m.stations[m.stationsTable.Cursor()])
+		case "?", "f1":
+			return m, showHelpCmd
+		case "Q":
+			return m.openQueue()
+		case "M":
+			return m.openStationMap()
+		case "L":
+			return m, likeTrackCmd
+		case "y", "Y":
+			if len(m.stations) == 0 {
+				return m, nil
+			}
+			return m, copyStationCmd(m.copyToClipboard, m.stations[m.stationsTable.Cursor()], msg.String() == "Y")
+		case "b":
+			if len(m.stations) == 0 {
+				return m, nil
+			}
+			return m, m.toggleSelectedBookmark()
+		case "i":
 			if len(m.stations) == 0 {
 				return m, nil
 			}
 			station := m.stations[m.stationsTable.Cursor()]
-			return m, playStationCmd(m.playbackManager, station, m.volume)
+			label, _ := m.labelStore.Get(station.StationUuid)
+			m.detailModel = NewStationDetailModel(m.theme, m.browser, station, label)
+			m.detailModel.SetStreamHeaders(withStreamHeaders(m.streamHeaders, station).Headers)
+			m.detailModel.SetWidth(m.width)
+			m.detailModel.SetAssetCache(m.assets)
+			m.detailModel.SetStability(stabilityOf(m.history, station.StationUuid))
+			m.showDetail = true
+			return m, m.detailModel.Init()
 		}
 	}
 
-	if m.playbackManager.IsPlaying() {
+	if m.showDetail {
+		newDetailModel, cmd := m.detailModel.Update(msg)
+		m.detailModel = newDetailModel
+		cmds = append(cmds, cmd)
+	}
+
+	if m.playbackManager.IsPlaying() || m.bufferingStation != nil {
 		newSpinner, cmd := m.currentStationSpinner.Update(msg)
 		m.currentStationSpinner = newSpinner
 		cmds = append(cmds, cmd)
@@ -279,30 +995,721 @@ func (m StationsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	cmds = append(cmds, cmd)
 
+	if cursorMoved {
+		cmds = append(cmds, m.cursorMovedCmd())
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// openColumnPicker shows the column picker in place of the stations table.
+func (m StationsModel) openColumnPicker() (tea.Model, tea.Cmd) {
+	m.columnPicker = NewColumnPickerModel(m.theme, m.columns)
+	m.showColumnPicker = true
+	return m, m.columnPicker.Init()
+}
+
+// openReport asks why the station under the cursor is reported.
+func (m StationsModel) openReport() (tea.Model, tea.Cmd) {
+	if len(m.stations) == 0 {
+		return m, nil
+	}
+	station := m.stations[m.stationsTable.Cursor()]
+	m.reportModel = NewReportModel(m.theme, station, stationDisplayName(m.labelStore, station))
+	m.showReport = true
+	return m, m.reportModel.Init()
+}
+
+// openEditStation opens a form suggesting new metadata for the station under the cursor.
+func (m StationsModel) openEditStation() (tea.Model, tea.Cmd) {
+	if len(m.stations) == 0 {
+		return m, nil
+	}
+	station := m.stations[m.stationsTable.Cursor()]
+	m.editStation = NewEditStationModel(m.theme, station, stationDisplayName(m.labelStore, station))
+	m.showEditStation = true
+	return m, m.editStation.Init()
+}
+
+// openScheduleRecording asks when to record the station under the cursor.
+func (m StationsModel) openScheduleRecording() (tea.Model, tea.Cmd) {
+	if len(m.stations) == 0 {
+		return m, nil
+	}
+	station := m.stations[m.stationsTable.Cursor()]
+	m.scheduleRecording = NewScheduleRecordingModel(m.theme, station, stationDisplayName(m.labelStore, station), time.Now())
+	m.showScheduleRecording = true
+	return m, m.scheduleRecording.Init()
+}
+
+// openBlockStation asks why the station under the cursor is hidden for good.
+func (m StationsModel) openBlockStation() (tea.Model, tea.Cmd) {
+	if len(m.stations) == 0 || m.blocklist == nil {
+		return m, nil
+	}
+	station := m.stations[m.stationsTable.Cursor()]
+	m.blockStation = NewBlockStationModel(m.theme, station, stationDisplayName(m.labelStore, station))
+	m.showBlockStation = true
+	return m, m.blockStation.Init()
+}
+
+// hideStation removes a reported or blocked station from the results, reporting err if not nil.
+func (m StationsModel) hideStation(stationUuid uuid.UUID, err error) (tea.Model, tea.Cmd) {
+	for i, station := range m.allStations {
+		if station.StationUuid == stationUuid {
+			m.setStations(append(m.allStations[:i:i], m.allStations[i+1:]...))
+			break
+		}
+	}
+	if m.stationsTable.Cursor() >= len(m.stations) && len(m.stations) > 0 {
+		m.stationsTable.SetCursor(len(m.stations) - 1)
+	}
+	cmds := []tea.Cmd{m.cursorMovedCmd()}
+	if err != nil {
+		cmds = append(cmds, nonFatalErrorCmd(err))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// toggleSplitPane turns the split-pane layout on or off.
+func (m StationsModel) toggleSplitPane() (tea.Model, tea.Cmd) {
+	m.SetSplitPane(!m.splitPane)
+	enabled := m.splitPane
+	return m, func() tea.Msg {
+		return splitPaneToggledMsg{enabled: enabled}
+	}
+}
+
+// openCommandLine shows the command line in place of the status bar.
+func (m StationsModel) openCommandLine(mode commandLineMode) (tea.Model, tea.Cmd) {
+	m.commandLine = NewCommandLineModel(m.theme, mode)
+	m.showCommandLine = true
+	return m, m.commandLine.Init()
+}
+
+// openFilter shows the command line in filter mode, starting from the current filter.
+func (m StationsModel) openFilter() (tea.Model, tea.Cmd) {
+	m.commandLine = NewCommandLineModel(m.theme, filterMode)
+	m.commandLine.inputModel.SetValue(m.filterText)
+	m.commandLine.inputModel.CursorEnd()
+	m.showCommandLine = true
+	return m, m.commandLine.Init()
+}
+
+// filter shows only the stations of the current page matching text, or all of them if empty.
+// The highlighted station stays highlighted if it still matches.
+func (m StationsModel) filter(text string) (tea.Model, tea.Cmd) {
+	var highlighted common.Station
+	if len(m.stations) > 0 {
+		highlighted = m.stations[m.stationsTable.Cursor()]
+	}
+	m.filterText = strings.TrimSpace(text)
+	m.setStations(m.allStations)
+	cursor := 0
+	for i, station := range m.stations {
+		if station.StationUuid == highlighted.StationUuid {
+			cursor = i
+			break
+		}
+	}
+	m.stationsTable.SetCursor(cursor)
+	return m, m.cursorMovedCmd()
+}
+
+// SelectStation moves the cursor to the station with the given UUID, if it's listed.
+func (m *StationsModel) SelectStation(stationUuid uuid.UUID) {
+	for i, station := range m.stations {
+		if station.StationUuid == stationUuid {
+			m.stationsTable.SetCursor(i)
+			return
+		}
+	}
+}
+
+// setStations replaces the stations of the current page, showing only those matching the filter.
+func (m *StationsModel) setStations(stations []common.Station) {
+	m.allStations = stations
+	m.stations = filterStations(stations, m.labelStore, m.filterText)
+	m.stationsTable.SetRows(newStationsTableRows(m.stations, m.columnsLayout.columns, m.labelStore, m.bookmarkStore, m.changes))
+}
+
+// find moves the cursor to the next station matching text, or matching the last text searched if empty.
+func (m StationsModel) find(text string) (tea.Model, tea.Cmd) {
+	if text == "" {
+		text = m.lastFind
+	}
+	if text == "" || len(m.stations) == 0 {
+		return m, nil
+	}
+	m.lastFind = text
+	index, ok := findStation(m.stations, m.labelStore, m.stationsTable.Cursor(), text)
+	if !ok {
+		return m, nonFatalErrorCmd(errors.New(i18n.Tf("find.notFound", text)))
+	}
+	m.stationsTable.SetCursor(index)
+	return m, m.cursorMovedCmd()
+}
+
+// runCommand runs a command typed in command mode.
+func (m StationsModel) runCommand(c command) (tea.Model, tea.Cmd) {
+	switch c.name {
+	case "play":
+		if len(c.args) > 0 {
+			index, err := c.stationNumber(m.page.page*stationPageSize, len(m.stations))
+			if err != nil {
+				return m, nonFatalErrorCmd(err)
+			}
+			m.stationsTable.SetCursor(index)
+			newModel, cmd := m.playSelectedStation()
+			return newModel, tea.Batch(cmd, newModel.(StationsModel).cursorMovedCmd())
+		}
+		return m.playSelectedStation()
+	case "stop":
+		return m, stopStationCmd(m.playbackManager)
+	case "bookmark":
+		if len(m.stations) == 0 {
+			return m, nil
+		}
+		return m, m.toggleSelectedBookmark()
+	case "theme":
+		return m, themeCmd(c)
+	case "search":
+		return m, tea.Sequence(
+			stopStationCmd(m.playbackManager),
+			func() tea.Msg {
+				return switchToSearchModelMsg{}
+			},
+		)
+	case "columns":
+		return m.openColumnPicker()
+	case "report":
+		return m.openReport()
+	case "hide":
+		return m.openBlockStation()
+	case "hidden":
+		return m, showBlocklistCmd
+	case "record":
+		return m.openScheduleRecording()
+	case "edit":
+		return m.openEditStation()
+	case "queue":
+		switch {
+		case len(c.args) == 0:
+			return m.openQueue()
+		case len(c.args) == 1 && strings.ToLower(c.args[0]) == "add":
+			return m.enqueueSelectedStation()
+		}
+		return m, nonFatalErrorCmd(errors.New(i18n.Tf("command.usage", "queue [add]")))
+	case "map":
+		return m.openStationMap()
+	case "like":
+		return m, likeTrackCmd
+	case "liked":
+		return m, showLikedTracksCmd
+	case "page":
+		return m.pageCommand(c)
+	case "scan":
+		if len(c.args) == 0 {
+			return m.startScan(0)
+		}
+		seconds, err := strconv.Atoi(c.args[0])
+		if len(c.args) > 1 || err != nil || seconds <= 0 {
+			return m, nonFatalErrorCmd(errors.New(i18n.Tf("command.usage", "scan [seconds]")))
+		}
+		return m.startScan(time.Duration(seconds) * time.Second)
+	case "export":
+		return m.exportResults(c)
+	case "prefs":
+		return m.changeStationPrefs(c)
+	case "copy":
+		if len(m.stations) == 0 {
+			return m, nil
+		}
+		return m, copyCommandCmd(m.copyToClipboard, m.stations[m.stationsTable.Cursor()], c)
+	case "unstable":
+		return m.showUnstableStations()
+	case "discover":
+		return m, showDiscovery(m.playbackManager)
+	case "similar":
+		if len(m.stations) == 0 {
+			return m, nil
+		}
+		return m, showSimilarStations(m.playbackManager, m.stations[m.stationsTable.Cursor()])
+	case "split":
+		return m.toggleSplitPane()
+	case "refresh":
+		return m.refreshResults()
+	case "vote":
+		return m.voteSelectedStation()
+	case "homepage":
+		return m.openSelectedHomepage()
+	case "external":
+		return m.playSelectedExternally()
+	case "quit":
+		return m, stopAndQuitCmd
+	}
+	return m, nonFatalErrorCmd(unknownCommandError(c))
+}
+
+// SetTheme changes the theme of the view.
+func (m *StationsModel) SetTheme(theme Theme) {
+	m.theme = theme
+	m.stationsTable.SetStyles(theme.StationsTableStyle)
+	m.currentStationSpinner.Style = theme.PrimaryText
+}
+
+// SetColumns changes the columns of the stations table.
+func (m *StationsModel) SetColumns(columns []config.StationColumn) {
+	m.columns = columns
+	m.columnScroll = 0
+	m.layOutColumns()
+}
+
+// layOutColumns fits the columns of the stations table in its width, scrolled past columnScroll columns.
+// Once every column fits, the table is scrolled back.
+func (m *StationsModel) layOutColumns() {
+	if fitStationColumns(m.columns, m.tableWidth(), 0).hidden == 0 {
+		m.columnScroll = 0
+	}
+	m.columnsLayout = fitStationColumns(m.columns, m.tableWidth(), m.columnScroll)
+	m.columnScroll = m.columnsLayout.scrolled
+	m.stationsTable.SetRows(nil)
+	m.stationsTable.SetColumns(m.columnsLayout.tableColumns())
+	m.stationsTable.SetRows(newStationsTableRows(m.stations, m.columnsLayout.columns, m.labelStore, m.bookmarkStore, m.changes))
+}
+
+// SetProber probes streams with the given prober before playing them, showing what was detected
+// next to the station being played (nil plays them straight away).
+func (m *StationsModel) SetProber(prober icy.ProberService) {
+	m.prober = prober
+}
+
+// SetAssetCache shows station favicons in the station details, fetched through cache (nil hides them).
+func (m *StationsModel) SetAssetCache(cache assets.Cache) {
+	m.assets = cache
+}
+
+// SetBandwidthUsage shows the bitrate and data used next to the station being played (nil hides them).
+func (m *StationsModel) SetBandwidthUsage(usage *bandwidthUsage) {
+	m.bandwidth = usage
+}
+
+// SetLevels shows a VU meter of levels next to the station being played (nil hides it).
+func (m *StationsModel) SetLevels(levels *playback.Levels) {
+	m.levels = levels
+}
+
+// SetListeningClock shows how long the station being played and all of them have been listened to (nil hides it).
+func (m *StationsModel) SetListeningClock(clock *listeningClock) {
+	m.clock = clock
+}
+
+// SetStreamDelay shows how far behind the broadcast the station being played is heard (nil hides it).
+func (m *StationsModel) SetStreamDelay(delay *streamDelay) {
+	m.delay = delay
+}
+
+// SetSplitPane turns the split-pane layout on or off.
+func (m *StationsModel) SetSplitPane(enabled bool) {
+	m.splitPane = enabled
+	m.stationsTable.SetWidth(m.tableWidth())
+	m.layOutColumns()
+}
+
+// showsSplitPane returns true if the highlighted station is previewed next to the stations table,
+// which needs a wide enough terminal.
+func (m StationsModel) showsSplitPane() bool {
+	return m.splitPane && !m.theme.Accessible && m.width >= splitPaneMinWidth
+}
+
+// tableWidth returns the width left to the stations table.
+func (m StationsModel) tableWidth() int {
+	if m.showsSplitPane() {
+		return m.width - stationPreviewWidth(m.width) - splitPaneGap
+	}
+	return m.width
+}
+
+// splitPaneView renders the stations table with the highlighted station previewed on its right.
+func (m StationsModel) splitPaneView() string {
+	station := m.stations[m.stationsTable.Cursor()]
+	label, _ := m.labelStore.Get(station.StationUuid)
+	return lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		lipgloss.NewStyle().MaxWidth(m.tableWidth()).Render(m.stationsTable.View()),
+		strings.Repeat(" ", splitPaneGap),
+		lipgloss.NewStyle().MaxHeight(m.height-2).Render(stationPreviewView(m.theme, station, label, stationPreviewWidth(m.width))),
+	)
+}
+
+// isPaged returns true if the results span more than one page.
+func (m StationsModel) isPaged() bool {
+	return m.hasNextPage || m.page.page > 0
+}
+
+// loadPage shows the given page of results, which is usually instant as pages are prefetched.
+func (m StationsModel) loadPage(page int) (tea.Model, tea.Cmd) {
+	key := m.page
+	key.page = page
+	m.loadingPage = true
+	return m, loadStationPageCmd(m.pages, m.browser, key)
+}
+
+// countResultsCmd counts the stations of the search, if there's more than a page of them.
+func (m StationsModel) countResultsCmd() tea.Cmd {
+	if !m.hasNextPage || !m.page.fetchable() {
+		return nil
+	}
+	return countResultsCmd(m.browser, m.page)
+}
+
+// prefetchNextPageCmd fetches the next page of results in the background, if there is one.
+func (m StationsModel) prefetchNextPageCmd() tea.Cmd {
+	if !m.hasNextPage {
+		return nil
+	}
+	key := m.page
+	key.page++
+	return prefetchStationPageCmd(m.pages, m.browser, key)
+}
+
+func (m StationsModel) cursorMovedCmd() tea.Cmd {
+	offset := m.page.page * stationPageSize
+	msg := stationCursorMovedMsg{
+		offset:        offset + m.stationsTable.Cursor(),
+		totalStations: offset + len(m.stations),
+		moreStations:  m.hasNextPage,
+	}
+	return func() tea.Msg {
+		return msg
+	}
+}
+
+// canTimeshift returns true if the station being played can be paused and rewound.
+func (m StationsModel) canTimeshift() bool {
+	_, ok := m.playbackManager.(playback.Timeshifter)
+	return ok
+}
+
+// timeshift pauses, resumes or moves the station being played, depending on the key pressed.
+func (m StationsModel) timeshift(key string) (tea.Model, tea.Cmd) {
+	timeshifter, ok := m.playbackManager.(playback.Timeshifter)
+	if !ok || !m.playbackManager.IsPlaying() {
+		return m, nil
+	}
+	switch key {
+	case "x":
+		if timeshifter.IsPaused() {
+			return m, timeshiftCmd(timeshifter.Resume)
+		}
+		return m, timeshiftCmd(timeshifter.Pause)
+	case "[":
+		return m, timeshiftCmd(func() error { return timeshifter.Seek(-timeshiftSeekStep) })
+	case "]":
+		return m, timeshiftCmd(func() error { return timeshifter.Seek(timeshiftSeekStep) })
+	case "}":
+		return m, timeshiftCmd(timeshifter.GoLive)
+	}
+	return m, nil
+}
+
+// playingText describes the station being played, how far behind live it is if timeshifted
+// and the data it uses if metered.
+func (m StationsModel) playingText(playingKey string, pausedKey string) string {
+	name := displayText(stationDisplayName(m.labelStore, m.currentStation))
+	timeshifter, ok := m.playbackManager.(playback.Timeshifter)
+	if !ok {
+		return i18n.Tf(playingKey, name) + m.clock.suffix() + m.delay.suffix() + streamSuffix(m.currentStream) + m.bandwidth.suffix()
+	}
+	text := i18n.Tf(playingKey, name)
+	if timeshifter.IsPaused() {
+		text = i18n.Tf(pausedKey, name)
+	}
+	if delay := timeshifter.Delay(); delay >= time.Second {
+		text += " (" + i18n.Tf("stations.behindLive", formatDelay(delay)) + ")"
+	}
+	return text + m.clock.suffix() + m.delay.suffix() + streamSuffix(m.currentStream) + m.bandwidth.suffix()
+}
+
+// streamSuffix returns the codec, bitrate and latency of a stream to show after the station being played,
+// or an empty string if nothing is known about it.
+func streamSuffix(stream common.StreamInfo) string {
+	summary := stream.Summary()
+	if summary == "" {
+		return ""
+	}
+	return " · " + summary
+}
+
+// formatDelay formats a delay as minutes and seconds.
+func formatDelay(delay time.Duration) string {
+	seconds := int(delay.Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// canSetVolumeLive returns true if the volume can be changed while playing.
+func (m StationsModel) canSetVolumeLive() bool {
+	_, ok := m.playbackManager.(playback.VolumeSetter)
+	return ok
+}
+
+// changeVolume changes the volume used for the next station, and for the current one
+// if the playback manager supports it.
+func (m StationsModel) changeVolume(delta int) (tea.Model, tea.Cmd) {
+	isPlaying := m.playbackManager.IsPlaying()
+	if isPlaying && !m.canSetVolumeLive() {
+		return m, nil
+	}
+	m.volume += delta
+	cmds := []tea.Cmd{
+		updateCommandsCmd(isPlaying, m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift()),
+	}
+	if isPlaying {
+		cmds = append(cmds, setVolumeCmd(m.playbackManager.(playback.VolumeSetter), m.stationVolume(m.currentStation)))
+	}
 	return m, tea.Batch(cmds...)
 }
 
+// stationVolume returns the volume station plays at, once trimmed.
+func (m StationsModel) stationVolume(station common.Station) int {
+	return trimmedVolume(m.volumeTrims, m.playbackManager, station, m.volume)
+}
+
+// trimVolume trims the volume of the station being played by delta, or of the one under the cursor
+// when none is. The playing station is turned up or down straight away if the playback manager supports it.
+func (m StationsModel) trimVolume(delta int) (tea.Model, tea.Cmd) {
+	if m.volumeTrims == nil {
+		return m, nil
+	}
+	isPlaying := m.playbackManager.IsPlaying()
+	station := m.currentStation
+	if !isPlaying {
+		if len(m.stations) == 0 {
+			return m, nil
+		}
+		station = m.stations[m.stationsTable.Cursor()]
+	}
+	name := stationDisplayName(m.labelStore, station)
+	return m, trimVolumeCmd(m.volumeTrims, m.playbackManager, station, name, m.volume, delta, isPlaying)
+}
+
+// jumpToLetter moves the cursor to the next station whose name starts with letter, if any.
+func (m StationsModel) jumpToLetter(letter rune) (tea.Model, tea.Cmd) {
+	index, ok := typeAheadIndex(len(m.stations), m.stationsTable.Cursor(), letter, func(i int) string {
+		return stationDisplayName(m.labelStore, m.stations[i])
+	})
+	if !ok {
+		return m, nil
+	}
+	m.stationsTable.SetCursor(index)
+	return m, m.cursorMovedCmd()
+}
+
+// changeStationPrefs changes the preferences of the station being played as asked by ":prefs",
+// or of the one under the cursor when none is.
+func (m StationsModel) changeStationPrefs(c command) (tea.Model, tea.Cmd) {
+	station := m.currentStation
+	if !m.playbackManager.IsPlaying() {
+		if len(m.stations) == 0 {
+			return m, nil
+		}
+		station = m.stations[m.stationsTable.Cursor()]
+	}
+	return m, stationPrefsCmd(m.stationPrefs, m.volumeTrims, station, stationDisplayName(m.labelStore, station), c)
+}
+
+// playSelectedStation starts buffering the station under the cursor.
+func (m StationsModel) playSelectedStation() (tea.Model, tea.Cmd) {
+	if len(m.stations) == 0 || m.bufferingStation != nil {
+		return m, nil
+	}
+	station := m.stations[m.stationsTable.Cursor()]
+	return m.bufferStation(station, playStationCmd(m.playbackManager, m.contentFilter, m.prober, m.credentials, m.streamVariants, withStationPrefs(m.stationPrefs, withStreamHeaders(m.streamHeaders, station)), m.stationVolume(station)))
+}
+
+// playQueuedStation plays a station taken out of the queue.
+func (m StationsModel) playQueuedStation(station common.Station) (tea.Model, tea.Cmd) {
+	if m.bufferingStation != nil {
+		return m, nil
+	}
+	return m.bufferStation(station, playQueuedStationCmd(m.playbackManager, m.contentFilter, m.prober, m.credentials, m.streamVariants, withStationPrefs(m.stationPrefs, withStreamHeaders(m.streamHeaders, station)), m.stationVolume(station)))
+}
+
+// bufferStation shows station as buffering while play starts it.
+func (m StationsModel) bufferStation(station common.Station, play tea.Cmd) (tea.Model, tea.Cmd) {
+	m.bufferingStation = &station
+	m.currentStationSpinner = spinner.New()
+	m.currentStationSpinner.Spinner = spinner.Dot
+	m.currentStationSpinner.Style = m.theme.PrimaryText
+	return m, tea.Batch(
+		m.currentStationSpinner.Tick,
+		play,
+	)
+}
+
+// advanceQueue plays the next station in the queue, if any.
+func (m StationsModel) advanceQueue() (tea.Model, tea.Cmd) {
+	if m.queue == nil || m.bufferingStation != nil {
+		return m, nil
+	}
+	station, ok := m.queue.pop()
+	if !ok {
+		return m, nil
+	}
+	return m.playQueuedStation(station)
+}
+
+// toggleSelectedBookmark bookmarks the station under the cursor, or removes its bookmark.
+func (m StationsModel) toggleSelectedBookmark() tea.Cmd {
+	station := m.stations[m.stationsTable.Cursor()]
+	return toggleBookmarkCmd(m.bookmarkStore, station, stationDisplayName(m.labelStore, station))
+}
+
+// enqueueSelectedStation adds the station under the cursor to the queue.
+func (m StationsModel) enqueueSelectedStation() (tea.Model, tea.Cmd) {
+	if len(m.stations) == 0 || m.queue == nil {
+		return m, nil
+	}
+	station := m.stations[m.stationsTable.Cursor()]
+	name := stationDisplayName(m.labelStore, station)
+	if m.queue.add(station) {
+		return m, showToastCmd(i18n.Tf("queue.added", name, m.queue.len()), toastSuccess)
+	}
+	return m, showToastCmd(i18n.Tf("queue.alreadyQueued", name), toastInfo)
+}
+
+// openQueue shows the queue in place of the stations table.
+func (m StationsModel) openQueue() (tea.Model, tea.Cmd) {
+	if m.queue == nil {
+		return m, nil
+	}
+	m.queueModel = NewQueueModel(m.theme, m.queue, m.labelStore)
+	m.showQueue = true
+	return m, m.queueModel.Init()
+}
+
+// openStationMap shows the stations that have a location on a world map in place of the stations table.
+func (m StationsModel) openStationMap() (tea.Model, tea.Cmd) {
+	if !hasStationsWithLocation(m.stations) {
+		return m, showToastCmd(i18n.T("stationMap.noLocations"), toastInfo)
+	}
+	var current common.Station
+	if len(m.stations) > 0 {
+		current = m.stations[m.stationsTable.Cursor()]
+	}
+	m.stationMap = NewStationMapModel(m.theme, m.labelStore, m.stations, current, m.width, m.height)
+	m.showStationMap = true
+	return m, m.stationMap.Init()
+}
+
+// pickMappedStation closes the map, moving the cursor to the station picked on it, and plays it.
+func (m StationsModel) pickMappedStation(station common.Station) (tea.Model, tea.Cmd) {
+	m.showStationMap = false
+	closed := updateCommandsCmd(m.playbackManager.IsPlaying(), m.volume, m.playbackManager.VolumeIsPercentage(), m.canSetVolumeLive(), m.isPaged(), m.canTimeshift())
+	for i, listed := range m.stations {
+		if listed.StationUuid != station.StationUuid {
+			continue
+		}
+		m.stationsTable.SetCursor(i)
+		newModel, cmd := m.playSelectedStation()
+		return newModel, tea.Batch(closed, cmd, newModel.(StationsModel).cursorMovedCmd())
+	}
+	return m, closed
+}
+
+// SetQueue sets the queue that "a" adds stations to, and how long each queued station plays (0 until it stops).
+func (m *StationsModel) SetQueue(queue *stationQueue, dwell time.Duration) {
+	m.queue = queue
+	m.queueDwell = dwell
+}
+
 func (m StationsModel) View() string {
 
 	extraBar := ""
 
-	if m.err != "" {
-		extraBar += m.theme.ErrorText.Render(m.err)
+	if m.theme.Accessible {
+		return m.accessibleView()
+	}
+
+	if m.loadingPage {
+		extraBar += m.theme.SecondaryText.Bold(true).Render(i18n.T("stations.loadingPage"))
+	} else if m.bufferingStation != nil {
+		extraBar +=
+			m.currentStationSpinner.View() +
+				m.theme.SecondaryText.Bold(true).Render(i18n.Tf("stations.buffering", displayText(stationDisplayName(m.labelStore, *m.bufferingStation))))
 	} else if m.playbackManager.IsPlaying() {
 		extraBar +=
 			m.currentStationSpinner.View() +
-				m.theme.SecondaryText.Bold(true).Render("Listening to: "+m.currentStation.Name)
+				m.theme.SecondaryText.Bold(true).Render(m.playingText("stations.listeningTo", "stations.paused")) +
+				levelMeter(m.theme, m.levels)
 	} else {
-		extraBar += m.theme.PrimaryText.Bold(true).Render("It's quiet here, time to play something!")
+		extraBar += m.theme.PrimaryText.Bold(true).Render(i18n.T("stations.quiet"))
+	}
+
+	if m.filterText != "" {
+		extraBar = m.theme.PrimaryText.Bold(true).Render(i18n.Tf("stations.filtered", m.filterText, len(m.stations), len(m.allStations))) + "  " + extraBar
+	}
+
+	if m.scanning {
+		extraBar = m.theme.PrimaryText.Bold(true).Render(i18n.T("stations.scanning")) + "  " + extraBar
+	}
+
+	if comparing := m.comparingText(); comparing != "" {
+		extraBar = m.theme.PrimaryText.Bold(true).Render(comparing) + "  " + extraBar
+	}
+
+	if m.isPaged() {
+		extraBar += "  " + m.theme.SecondaryText.Render(m.pageIndicator())
+	}
+	if m.page.fetchable() {
+		extraBar += "  " + m.theme.TertiaryText.Render(m.resultsAge())
+	}
+	if hint := m.voteHint(); hint != "" {
+		extraBar += "  " + m.theme.TertiaryText.Render(hint)
+	}
+
+	extraBar = fitLine(extraBar, m.width)
+
+	if m.showCommandLine {
+		extraBar = m.commandLine.View()
 	}
 
 	var v string
-	if len(m.stations) == 0 {
-		v = fmt.Sprintf(
-			"\n%s\n\n%s\n",
-			assets.NoStations,
-			m.theme.SecondaryText.Bold(true).Render("No stations found, try another search!"),
-		)
+	if len(m.allStations) == 0 {
+		message := m.theme.SecondaryText.Bold(true).Render(i18n.T("stations.noResults"))
+		if m.height > 0 && m.height < lipgloss.Height(string(assets.NoStations))+3 {
+			// Not enough room for the artwork, keep the message only
+			v = fmt.Sprintf("\n%s\n", message)
+		} else {
+			v = fmt.Sprintf("\n%s\n\n%s\n", assets.NoStations, message)
+		}
+	} else if m.showDetail {
+		v = "\n" + m.detailModel.View() + "\n"
+		v += extraBar
+	} else if m.showColumnPicker {
+		v = "\n" + m.columnPicker.View() + "\n"
+		v += extraBar
+	} else if m.showReport {
+		v = "\n" + m.reportModel.View() + "\n"
+		v += extraBar
+	} else if m.showBlockStation {
+		v = "\n" + m.blockStation.View() + "\n"
+		v += extraBar
+	} else if m.showScheduleRecording {
+		v = "\n" + m.scheduleRecording.View() + "\n"
+		v += extraBar
+	} else if m.showEditStation {
+		v = "\n" + m.editStation.View() + "\n"
+		v += extraBar
+	} else if m.showQueue {
+		v = "\n" + m.queueModel.View() + "\n"
+		v += extraBar
+	} else if m.showStationMap {
+		v = "\n" + m.stationMap.View() + "\n"
+		v += extraBar
+	} else if m.showsSplitPane() && len(m.stations) > 0 {
+		v = "\n" + m.splitPaneView() + "\n"
+		v += extraBar
 	} else {
 		v = "\n" + m.stationsTable.View() + "\n"
 		v += extraBar
@@ -311,9 +1718,108 @@ func (m StationsModel) View() string {
 	return v
 }
 
+// accessibleView renders the stations as plain numbered lines without borders or colors,
+// followed by an explicit announcement of the playback state.
+func (m StationsModel) accessibleView() string {
+
+	if len(m.allStations) == 0 {
+		return "\n" + i18n.T("stations.noResults") + "\n"
+	}
+
+	var v string
+
+	if m.showDetail {
+		v = "\n" + m.detailModel.View() + "\n"
+	} else if m.showColumnPicker {
+		v = "\n" + m.columnPicker.View() + "\n"
+	} else if m.showReport {
+		v = "\n" + m.reportModel.View() + "\n"
+	} else if m.showBlockStation {
+		v = "\n" + m.blockStation.View() + "\n"
+	} else if m.showScheduleRecording {
+		v = "\n" + m.scheduleRecording.View() + "\n"
+	} else if m.showEditStation {
+		v = "\n" + m.editStation.View() + "\n"
+	} else if m.showQueue {
+		v = "\n" + m.queueModel.View() + "\n"
+	} else if m.showStationMap {
+		v = "\n" + m.stationMap.View() + "\n"
+	} else {
+		visibleRows := m.height - 4
+		if visibleRows < 1 {
+			visibleRows = len(m.stations)
+		}
+		cursor := m.stationsTable.Cursor()
+		first := (cursor / visibleRows) * visibleRows
+
+		v = "\n"
+		if m.filterText != "" {
+			v += i18n.Tf("stations.filtered", m.filterText, len(m.stations), len(m.allStations)) + "\n"
+		}
+		if m.isPaged() {
+			v += m.pageIndicator() + "\n"
+		}
+		if m.scanning {
+			v += i18n.T("stations.scanning") + "\n"
+		}
+		if comparing := m.comparingText(); comparing != "" {
+			v += comparing + "\n"
+		}
+		for i := first; i < len(m.stations) && i < first+visibleRows; i++ {
+			station := m.stations[i]
+			marker := "    "
+			if i == cursor {
+				marker = ">>> "
+			}
+			name := stationDisplayName(m.labelStore, station)
+			if m.bookmarkStore.IsBookmarked(station.StationUuid) {
+				name += " (" + i18n.T("accessible.bookmarked") + ")"
+			}
+			v += fmt.Sprintf(
+				"%s%d. %s | %s | %s | %s | %s\n",
+				marker,
+				m.page.page*stationPageSize+i+1,
+				name,
+				station.CountryCode,
+				station.LanguagesCodes,
+				station.Codec,
+				i18n.Tf("accessible.votes", station.Votes),
+			)
+		}
+	}
+
+	if m.showCommandLine {
+		v += m.commandLine.View()
+	} else if m.loadingPage {
+		v += i18n.T("stations.loadingPage")
+	} else if m.bufferingStation != nil {
+		v += i18n.Tf("accessible.buffering", stationDisplayName(m.labelStore, *m.bufferingStation))
+	} else if m.playbackManager.IsPlaying() {
+		v += m.playingText("accessible.playing", "accessible.paused")
+	} else {
+		v += i18n.T("accessible.stopped")
+	}
+
+	return v
+}
+
+// tableHeight returns the height of a stations table drawn in a view of the given height,
+// leaving room for the padding and the status bar below it.
+func tableHeight(height int) int {
+	if height < 5 {
+		return 1
+	}
+	return height - 4
+}
+
 func (m *StationsModel) SetWidthAndHeight(width int, height int) {
 	m.width = width
 	m.height = height
-	m.stationsTable.SetWidth(width)
-	m.stationsTable.SetHeight(height - 4)
+	m.stationsTable.SetWidth(m.tableWidth())
+	m.stationsTable.SetHeight(tableHeight(height))
+	m.layOutColumns()
+	m.detailModel.SetWidth(width)
+	if m.showStationMap {
+		m.stationMap.SetSize(width, height)
+	}
 }
@@ -0,0 +1,249 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/playback"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// How often the title scrolls by one column when it doesn't fit the status bar.
+const statusBarScrollInterval = 300 * time.Millisecond
+
+// What separates the end of a scrolling title from its start.
+const statusBarScrollGap = "   "
+
+// Messages
+
+// statusBarTickMsg updates the time shown, and scrolls the title if it doesn't fit.
+// Ticks of earlier generations are dropped, so that only one keeps ticking.
+type statusBarTickMsg struct {
+	generation int
+}
+
+// Commands
+
+// statusBarTickCmd ticks when the title has to scroll, or else when the minute shown changes.
+func statusBarTickCmd(generation int, scrolling bool, now time.Time) tea.Cmd {
+	delay := statusBarScrollInterval
+	if !scrolling {
+		delay = now.Truncate(time.Minute).Add(time.Minute).Sub(now)
+	}
+	return tea.Tick(delay, func(t time.Time) tea.Msg {
+		return statusBarTickMsg{generation: generation}
+	})
+}
+
+// Model
+
+// StatusBarModel shows the time and what's playing on a line above the bottom bar, whatever the view.
+// The title scrolls when it doesn't fit next to the station.
+type StatusBarModel struct {
+	theme           Theme
+	playbackManager playback.PlaybackManagerService
+	enabled         bool
+	width           int
+
+	// The station being played and its title (empty when stopped)
+	station string
+	title   string
+	// What's going on besides, such as the network being down
+	notice string
+
+	// How many runes the title has scrolled by, whether it's scrolling,
+	// and the generation of the tick being waited for
+	offset     int
+	scrolling  bool
+	generation int
+
+	now func() time.Time
+}
+
+func NewStatusBarModel(theme Theme, playbackManager playback.PlaybackManagerService, enabled bool) StatusBarModel {
+	return StatusBarModel{
+		theme:           theme,
+		playbackManager: playbackManager,
+		enabled:         enabled,
+		now:             time.Now,
+	}
+}
+
+// Height returns the number of lines taken by the status bar (0 when disabled).
+func (m StatusBarModel) Height() int {
+	if !m.enabled {
+		return 0
+	}
+	return 1
+}
+
+// SetPlaying shows the given station and title, scrolling the title from its start if it changed.
+func (m StatusBarModel) SetPlaying(station string, title string) (StatusBarModel, tea.Cmd) {
+	if station == m.station && title == m.title {
+		return m, nil
+	}
+	m.station = station
+	m.title = title
+	m.offset = 0
+	return m.rescheduleIfNeeded()
+}
+
+// SetNotice shows text next to the playback state, e.g. that the network is down (empty for none).
+func (m StatusBarModel) SetNotice(text string) StatusBarModel {
+	m.notice = text
+	return m
+}
+
+// rescheduleIfNeeded starts ticking at the pace the title needs, when it starts or stops scrolling.
+func (m StatusBarModel) rescheduleIfNeeded() (StatusBarModel, tea.Cmd) {
+	if !m.enabled || m.overflows() == m.scrolling {
+		return m, nil
+	}
+	m.scrolling = !m.scrolling
+	m.generation++
+	return m, statusBarTickCmd(m.generation, m.scrolling, m.now())
+}
+
+// overflows returns true if the title doesn't fit next to the station, and so scrolls.
+// It never does in accessible and low-bandwidth mode, where the title is cut instead.
+func (m StatusBarModel) overflows() bool {
+	if m.theme.Accessible || m.theme.LowBandwidth || m.title == "" {
+		return false
+	}
+	return runewidth.StringWidth(m.title) > m.titleWidth()
+}
+
+// titleWidth returns how many columns are left to the title after the time, the playback state and the station.
+func (m StatusBarModel) titleWidth() int {
+	return m.width - runewidth.StringWidth(m.prefix()) - runewidth.StringWidth(m.stationText()) - runewidth.StringWidth(" · ")
+}
+
+// prefix returns the time, the playback state and the notice.
+func (m StatusBarModel) prefix() string {
+	prefix := m.now().Format("15:04") + " " + m.state() + " "
+	if m.notice != "" {
+		prefix += m.notice + " · "
+	}
+	return prefix
+}
+
+// state returns a symbol for whether a station is playing, paused or stopped, or a word in accessible mode.
+func (m StatusBarModel) state() string {
+	key, symbol := "statusBar.stopped", "■"
+	if m.station != "" && m.playbackManager != nil && m.playbackManager.IsPlaying() {
+		key, symbol = "statusBar.playing", "▶"
+		if timeshifter, ok := m.playbackManager.(playback.Timeshifter); ok && timeshifter.IsPaused() {
+			key, symbol = "statusBar.paused", "⏸"
+		}
+	}
+	if m.theme.Accessible {
+		return i18n.T(key)
+	}
+	return symbol
+}
+
+// stationText returns the station, shortened to leave at least half of the line to the title.
+func (m StatusBarModel) stationText() string {
+	if m.station == "" {
+		return i18n.T("statusBar.idle")
+	}
+	if m.theme.Accessible {
+		return m.station
+	}
+	available := m.width - runewidth.StringWidth(m.prefix())
+	if m.title != "" {
+		available /= 2
+	}
+	return truncateText(m.station, available)
+}
+
+// marquee returns the part of the title shown once it has scrolled by offset runes.
+func marquee(title string, width int, offset int) string {
+	if width <= 0 {
+		return ""
+	}
+	if runewidth.StringWidth(title) <= width {
+		return title
+	}
+	loop := []rune(title + statusBarScrollGap)
+	start := offset % len(loop)
+	rotated := string(loop[start:]) + string(loop[:start])
+	return runewidth.Truncate(rotated, width, "")
+}
+
+// Bubbletea
+
+func (m StatusBarModel) Init() tea.Cmd {
+	if !m.enabled {
+		return nil
+	}
+	return statusBarTickCmd(m.generation, m.scrolling, m.now())
+}
+
+func (m StatusBarModel) Update(msg tea.Msg) (StatusBarModel, tea.Cmd) {
+	if !m.enabled {
+		return m, nil
+	}
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		return m.rescheduleIfNeeded()
+	case statusBarTickMsg:
+		if msg.generation != m.generation {
+			return m, nil
+		}
+		if m.scrolling {
+			m.offset++
+		}
+		return m, statusBarTickCmd(m.generation, m.scrolling, m.now())
+	}
+	return m, nil
+}
+
+func (m StatusBarModel) View() string {
+	if !m.enabled {
+		return ""
+	}
+	if m.theme.Accessible {
+		parts := []string{m.now().Format("15:04"), m.state()}
+		if m.notice != "" {
+			parts = append(parts, m.notice)
+		}
+		parts = append(parts, m.stationText())
+		if m.title != "" {
+			parts = append(parts, m.title)
+		}
+		return strings.Join(parts, " | ") + "\n"
+	}
+	view := m.theme.TertiaryText.Render(m.now().Format("15:04") + " " + m.state() + " ")
+	if m.notice != "" {
+		view += m.theme.SecondaryText.Bold(true).Render(m.notice) + m.theme.TertiaryText.Render(" · ")
+	}
+	view += m.theme.PrimaryText.Render(m.stationText())
+	if m.station != "" && m.title != "" {
+		view += m.theme.TertiaryText.Render(" · ") + m.theme.SecondaryText.Render(marquee(m.title, m.titleWidth(), m.offset))
+	}
+	return view + "\n"
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusBarModel(t *testing.T) {
+
+	newModel := func(playing bool, width int) StatusBarModel {
+		model := NewStatusBarModel(Theme{}, &mocks.MockPlaybackManagerService{IsPlayingResult: playing}, true)
+		model.now = func() time.Time { return time.Date(2023, 11, 5, 21, 7, 30, 0, time.UTC) }
+		model, _ = model.Update(tea.WindowSizeMsg{Width: width, Height: 24})
+		return model
+	}
+
+	t.Run("shows the time and that nothing is playing", func(t *testing.T) {
+
+		model := newModel(false, 80)
+
+		assert.Equal(t, 1, model.Height())
+		assert.Equal(t, "21:07 ■ "+i18n.T("statusBar.idle")+"\n", model.View())
+
+	})
+
+	t.Run("shows the station and title being played", func(t *testing.T) {
+
+		model, cmd := newModel(true, 80).SetPlaying("Jazz FM", "Miles Davis - So What")
+
+		assert.Nil(t, cmd)
+		assert.Equal(t, "21:07 ▶ Jazz FM · Miles Davis - So What\n", model.View())
+
+	})
+
+	t.Run("scrolls titles that don't fit", func(t *testing.T) {
+
+		model, cmd := newModel(true, 30).SetPlaying("Jazz FM", "Miles Davis - So What")
+
+		assert.NotNil(t, cmd)
+		assert.True(t, model.scrolling)
+		assert.Equal(t, "21:07 ▶ Jazz FM · Miles Davis \n", model.View())
+
+		model, cmd = model.Update(statusBarTickMsg{generation: model.generation})
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "21:07 ▶ Jazz FM · iles Davis -\n", model.View())
+
+		// Ticks of the minute ticking from before are dropped
+		model, cmd = model.Update(statusBarTickMsg{generation: model.generation - 1})
+		assert.Nil(t, cmd)
+		assert.Equal(t, 1, model.offset)
+
+		// A shorter title stops scrolling, from its start
+		model, cmd = model.SetPlaying("Jazz FM", "So What")
+		assert.NotNil(t, cmd)
+		assert.False(t, model.scrolling)
+		assert.Equal(t, "21:07 ▶ Jazz FM · So What\n", model.View())
+
+	})
+
+	t.Run("wraps scrolling titles around", func(t *testing.T) {
+
+		assert.Equal(t, "bcde", marquee("abcdef", 4, 1))
+		assert.Equal(t, " abc", marquee("abcdef", 4, 8))
+		assert.Equal(t, "abcd", marquee("abcd", 4, 3))
+
+	})
+
+	t.Run("shows words in accessible mode, with the whole title", func(t *testing.T) {
+
+		model := NewStatusBarModel(Theme{Accessible: true}, &mocks.MockPlaybackManagerService{IsPlayingResult: true}, true)
+		model.now = func() time.Time { return time.Date(2023, 11, 5, 9, 5, 0, 0, time.UTC) }
+		model, cmd := model.SetPlaying("Jazz FM", "Miles Davis - So What")
+
+		assert.Nil(t, cmd)
+		assert.Equal(t, "09:05 | "+i18n.T("statusBar.playing")+" | Jazz FM | Miles Davis - So What\n", model.View())
+
+	})
+
+	t.Run("takes no room when disabled", func(t *testing.T) {
+
+		model := NewStatusBarModel(Theme{}, &mocks.MockPlaybackManagerService{}, false)
+
+		assert.Equal(t, 0, model.Height())
+		assert.Equal(t, "", model.View())
+		assert.Nil(t, model.Init())
+
+	})
+
+}
+
+func TestModelStatusBar(t *testing.T) {
+
+	t.Run("shows the station played above the bottom bar, whatever the view", func(t *testing.T) {
+
+		cfg := config.Config{}
+		cfg.StatusBar.Enabled = true
+		model := NewModel(cfg, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{IsPlayingResult: true}, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		assert.Equal(t, 1, model.panesHeight())
+
+		updated, _ := model.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+		station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+		updated, _ = updated.(Model).Update(playbackStartedMsg{station: station})
+
+		assert.Contains(t, updated.(Model).View(), "▶ Jazz FM")
+
+		updated, _ = updated.(Model).Update(playbackStoppedMsg{})
+
+		assert.Contains(t, updated.(Model).View(), i18n.T("statusBar.idle"))
+
+	})
+
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/playback"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How often the delay of the station being played is estimated.
+const streamDelayInterval = 3 * time.Second
+
+// streamDelay is the latest estimate of how far behind the broadcast the station being played is heard,
+// e.g. to tell how much earlier a goal is announced on the radio than seen on TV.
+// It's shared by the root, stations and bookmarks models, and only used by their Update and View.
+// A nil *streamDelay shows nothing.
+type streamDelay struct {
+	estimate playback.StreamDelay
+	known    bool
+	// Incremented whenever the station changes, so that stale estimates are ignored
+	generation int
+}
+
+func newStreamDelay() *streamDelay {
+	return &streamDelay{}
+}
+
+// reset forgets the estimate, as the station changes.
+func (d *streamDelay) reset() {
+	d.estimate = playback.StreamDelay{}
+	d.known = false
+	d.generation++
+}
+
+// suffix returns the delay to show after the station being played, or an empty string if it isn't known.
+func (d *streamDelay) suffix() string {
+	if d == nil || !d.known {
+		return ""
+	}
+	return " · " + i18n.Tf("stations.delay", formatStreamDelay(d.estimate.Delay), i18n.T(delaySourceKey(d.estimate.Source)))
+}
+
+// formatStreamDelay formats a delay in seconds, with a decimal under ten seconds, as minutes and seconds from a minute on.
+func formatStreamDelay(delay time.Duration) string {
+	switch {
+	case delay >= time.Minute:
+		return formatDelay(delay)
+	case delay >= 10*time.Second:
+		return fmt.Sprintf("%d s", int(delay.Seconds()))
+	}
+	return fmt.Sprintf("%.1f s", delay.Seconds())
+}
+
+// delaySourceKey returns the key of the text telling how a delay was estimated.
+func delaySourceKey(source playback.DelaySource) string {
+	switch source {
+	case playback.DelayTimestamped:
+		return "stations.delayTimestamped"
+	case playback.DelayBuffered:
+		return "stations.delayBuffered"
+	}
+	return "stations.delayConfigured"
+}
+
+// Messages
+
+type streamDelayTickMsg struct {
+	generation int
+}
+
+type streamDelayEstimatedMsg struct {
+	generation int
+	estimate   playback.StreamDelay
+	known      bool
+}
+
+// Commands
+
+func streamDelayTickCmd(generation int) tea.Cmd {
+	return tea.Tick(streamDelayInterval, func(t time.Time) tea.Msg {
+		return streamDelayTickMsg{generation: generation}
+	})
+}
+
+func estimateStreamDelayCmd(playbackManager playback.PlaybackManagerService, generation int) tea.Cmd {
+	return func() tea.Msg {
+		estimate, known := playback.EstimatedDelay(playbackManager)
+		return streamDelayEstimatedMsg{generation: generation, estimate: estimate, known: known}
+	}
+}
+
+// updateStreamDelay estimates the delay of the station being played, whatever the view,
+// once it has started and every few seconds after, as the buffers fill and drain.
+func (m Model) updateStreamDelay(msg tea.Msg) (Model, tea.Cmd) {
+	if m.delay == nil {
+		return m, nil
+	}
+	switch msg := msg.(type) {
+	case playbackStartedMsg:
+		m.delay.reset()
+		return m, streamDelayTickCmd(m.delay.generation)
+	case playbackStoppedMsg:
+		m.delay.reset()
+	case streamDelayTickMsg:
+		if msg.generation == m.delay.generation && m.playbackManager.IsPlaying() {
+			return m, estimateStreamDelayCmd(m.playbackManager, msg.generation)
+		}
+	case streamDelayEstimatedMsg:
+		if msg.generation == m.delay.generation {
+			m.delay.estimate, m.delay.known = msg.estimate, msg.known
+			return m, streamDelayTickCmd(msg.generation)
+		}
+	}
+	return m, nil
+}
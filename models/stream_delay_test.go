@@ -0,0 +1,92 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/playback"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamDelay(t *testing.T) {
+
+	t.Run("shows the delay and how it was estimated", func(t *testing.T) {
+
+		delay := newStreamDelay()
+		delay.estimate = playback.StreamDelay{Delay: 4200 * time.Millisecond, Source: playback.DelayBuffered}
+		delay.known = true
+
+		assert.Equal(t, " · delay 4.2 s (player buffer)", delay.suffix())
+
+	})
+
+	t.Run("shows nothing until the delay is known", func(t *testing.T) {
+
+		var none *streamDelay
+
+		assert.Equal(t, "", none.suffix())
+		assert.Equal(t, "", newStreamDelay().suffix())
+
+	})
+
+	t.Run("estimates the delay once playback has started and keeps estimating it", func(t *testing.T) {
+
+		m := Model{delay: newStreamDelay(), playbackManager: &mocks.MockPlaybackManagerService{IsPlayingResult: true}}
+
+		m, cmd := m.updateStreamDelay(playbackStartedMsg{})
+		assert.NotNil(t, cmd)
+
+		m, cmd = m.updateStreamDelay(streamDelayTickMsg{generation: m.delay.generation})
+		assert.Equal(t, streamDelayEstimatedMsg{generation: m.delay.generation}, cmd())
+
+		estimate := playback.StreamDelay{Delay: 25 * time.Second, Source: playback.DelayTimestamped}
+		m, cmd = m.updateStreamDelay(streamDelayEstimatedMsg{generation: m.delay.generation, estimate: estimate, known: true})
+		assert.NotNil(t, cmd)
+		assert.Equal(t, " · delay 25 s (stream clock)", m.delay.suffix())
+
+	})
+
+	t.Run("ignores estimates of the station played before", func(t *testing.T) {
+
+		m := Model{delay: newStreamDelay(), playbackManager: &mocks.MockPlaybackManagerService{IsPlayingResult: true}}
+		m, _ = m.updateStreamDelay(playbackStartedMsg{})
+		stale := m.delay.generation
+		m, _ = m.updateStreamDelay(playbackStoppedMsg{})
+
+		m, cmd := m.updateStreamDelay(streamDelayEstimatedMsg{generation: stale, estimate: playback.StreamDelay{Delay: time.Second}, known: true})
+		assert.Nil(t, cmd)
+		assert.Equal(t, "", m.delay.suffix())
+
+		_, cmd = m.updateStreamDelay(streamDelayTickMsg{generation: stale})
+		assert.Nil(t, cmd)
+
+	})
+
+}
+
+func TestFormatStreamDelay(t *testing.T) {
+	assert.Equal(t, "0.8 s", formatStreamDelay(800*time.Millisecond))
+	assert.Equal(t, "12 s", formatStreamDelay(12500*time.Millisecond))
+	assert.Equal(t, formatDelay(90*time.Second), formatStreamDelay(90*time.Second))
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// withStreamHeaders returns station with the headers stored for it, if any, to be sent along with
+// the requests for its stream.
+func withStreamHeaders(store storage.StreamHeaderStore, station common.Station) common.Station {
+	if store == nil {
+		return station
+	}
+	station.Headers = store.Headers(station.StationUuid)
+	return station
+}
+
+// Messages
+
+type streamHeadersChangedMsg struct {
+	stationUuid uuid.UUID
+	name        string
+	headers     common.StreamHeaders
+}
+
+// Commands
+
+// saveStreamHeadersCmd stores the headers of a station, which are sent the next time it plays.
+func saveStreamHeadersCmd(store storage.StreamHeaderStore, msg streamHeadersChangedMsg) tea.Cmd {
+	return func() tea.Msg {
+		if store == nil {
+			return nil
+		}
+		if err := store.SetHeaders(msg.stationUuid, msg.headers); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return toastMsg{text: i18n.Tf("streamHeaders.saved", msg.name), kind: toastSuccess}
+	}
+}
+
+// SetStreamHeaderStore sends the headers stored in store along with the requests for the streams of stations,
+// and lets them be edited in the station details (nil sends none).
+func (m *StationsModel) SetStreamHeaderStore(store storage.StreamHeaderStore) {
+	m.streamHeaders = store
+}
+
+// SetStreamHeaderStore sends the headers stored in store along with the requests for the streams of bookmarks
+// (nil sends none).
+func (m *BookmarksModel) SetStreamHeaderStore(store storage.StreamHeaderStore) {
+	m.streamHeaders = store
+}
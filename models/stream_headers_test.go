@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamHeaders(t *testing.T) {
+
+	station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+	headers := common.StreamHeaders{{Name: "Referer", Value: "https://example.com"}}
+
+	t.Run("sends the headers stored for the station", func(t *testing.T) {
+
+		store := &mocks.MockStreamHeaderStore{
+			HeadersFunc: func(stationUuid uuid.UUID) common.StreamHeaders {
+				assert.Equal(t, station.StationUuid, stationUuid)
+				return headers
+			},
+		}
+
+		assert.Equal(t, headers, withStreamHeaders(store, station).Headers)
+		assert.Nil(t, withStreamHeaders(nil, station).Headers)
+
+	})
+
+	t.Run("saves the headers and tells when they apply", func(t *testing.T) {
+
+		var saved common.StreamHeaders
+		store := &mocks.MockStreamHeaderStore{
+			SetHeadersFunc: func(stationUuid uuid.UUID, headers common.StreamHeaders) error {
+				saved = headers
+				return nil
+			},
+		}
+
+		msg := saveStreamHeadersCmd(store, streamHeadersChangedMsg{stationUuid: station.StationUuid, name: station.Name, headers: headers})()
+
+		assert.Equal(t, headers, saved)
+		assert.Equal(t, toastMsg{text: "Jazz FM headers saved, sent the next time it plays", kind: toastSuccess}, msg)
+
+	})
+
+	t.Run("reports headers that can't be saved", func(t *testing.T) {
+
+		store := &mocks.MockStreamHeaderStore{
+			SetHeadersFunc: func(stationUuid uuid.UUID, headers common.StreamHeaders) error {
+				return errors.New("disk full")
+			},
+		}
+
+		msg := saveStreamHeadersCmd(store, streamHeadersChangedMsg{stationUuid: station.StationUuid, headers: headers})()
+
+		assert.Equal(t, nonFatalError{stopPlayback: false, err: errors.New("disk full")}, msg)
+
+	})
+
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"sort"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// stationQueryUnstable is the query of the stations whose streams were interrupted lately,
+// which isn't a radio-browser search either.
+const stationQueryUnstable common.StationQuery = "unstable"
+
+// ErrNoUnstableStations is returned when no station played lately was interrupted.
+var ErrNoUnstableStations = i18n.Error("stability.none")
+
+// stationStability tells how many of the listening sessions of a station in the history were interrupted.
+type stationStability struct {
+	sessions    int
+	interrupted int
+	// failed is how many of the interrupted sessions ended with the station stopped for good.
+	failed int
+}
+
+// share returns the share of the sessions that were interrupted.
+func (s stationStability) share() float64 {
+	if s.sessions == 0 {
+		return 0
+	}
+	return float64(s.interrupted) / float64(s.sessions)
+}
+
+// String describes the stability for the station details, e.g. "3 of 8 sessions interrupted, 1 failed".
+func (s stationStability) String() string {
+	switch {
+	case s.sessions == 0:
+		return ""
+	case s.failed > 0:
+		return i18n.Tf("stability.summaryFailed", s.interrupted, s.sessions, s.failed)
+	}
+	return i18n.Tf("stability.summary", s.interrupted, s.sessions)
+}
+
+// addSession counts a listening session from the history.
+func (s stationStability) addSession(entry storage.HistoryEntry) stationStability {
+	s.sessions++
+	if len(entry.Interruptions) == 0 {
+		return s
+	}
+	s.interrupted++
+	if entry.Interruptions[len(entry.Interruptions)-1].Kind == storage.InterruptionFailed {
+		s.failed++
+	}
+	return s
+}
+
+// stabilityOf returns the stability of the station with the given UUID over the sessions played lately.
+func stabilityOf(history storage.HistoryStore, stationUuid uuid.UUID) stationStability {
+	var stability stationStability
+	if history == nil {
+		return stability
+	}
+	entries, err := history.Recent(historyEntriesRead)
+	if err != nil {
+		return stability
+	}
+	for _, entry := range entries {
+		if entry.Station.StationUuid == stationUuid {
+			stability = stability.addSession(entry)
+		}
+	}
+	return stability
+}
+
+// unstableStations returns the stations played lately whose streams were interrupted,
+// the most often interrupted first, each one once.
+func unstableStations(history storage.HistoryStore) ([]common.Station, error) {
+	entries, err := history.Recent(historyEntriesRead)
+	if err != nil {
+		return nil, err
+	}
+	stabilities := make(map[uuid.UUID]stationStability)
+	stations := []common.Station{}
+	for _, entry := range entries {
+		stationUuid := entry.Station.StationUuid
+		if _, seen := stabilities[stationUuid]; !seen {
+			stations = append(stations, entry.Station)
+		}
+		stabilities[stationUuid] = stabilities[stationUuid].addSession(entry)
+	}
+	unstable := stations[:0]
+	for _, station := range stations {
+		if stabilities[station.StationUuid].interrupted > 0 {
+			unstable = append(unstable, station)
+		}
+	}
+	// The latest played come first among the ones as unstable
+	sort.SliceStable(unstable, func(i, j int) bool {
+		a, b := stabilities[unstable[i].StationUuid], stabilities[unstable[j].StationUuid]
+		if a.share() != b.share() {
+			return a.share() > b.share()
+		}
+		return a.interrupted > b.interrupted
+	})
+	if len(unstable) > historySize {
+		unstable = unstable[:historySize]
+	}
+	return unstable, nil
+}
+
+// showUnstableStations lists the stations whose streams were interrupted lately, for ":unstable".
+func (m StationsModel) showUnstableStations() (tea.Model, tea.Cmd) {
+	if m.history == nil {
+		return m, nonFatalErrorCmd(ErrNoUnstableStations)
+	}
+	stations, err := unstableStations(m.history)
+	if err == nil && len(stations) == 0 {
+		err = ErrNoUnstableStations
+	}
+	if err != nil {
+		return m, nonFatalErrorCmd(err)
+	}
+	return m, tea.Sequence(
+		stopStationCmd(m.playbackManager),
+		func() tea.Msg {
+			return switchToStationsModelMsg{stations: stations, page: stationPageKey{query: stationQueryUnstable}}
+		},
+	)
+}
+
+// SetHistoryStore lets ":unstable" list the stations whose streams were interrupted lately, and the details
+// of a station tell how often it was (nil doesn't).
+func (m *StationsModel) SetHistoryStore(history storage.HistoryStore) {
+	m.history = history
+}
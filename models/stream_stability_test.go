@@ -0,0 +1,93 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// historyOfSessions returns a history of the sessions given, latest first.
+func historyOfSessions(entries ...storage.HistoryEntry) *mocks.MockHistoryStore {
+	return &mocks.MockHistoryStore{
+		RecentFunc: func(limit int) ([]storage.HistoryEntry, error) {
+			if len(entries) > limit {
+				return entries[:limit], nil
+			}
+			return entries, nil
+		},
+	}
+}
+
+func interruptedSession(station common.Station, kinds ...storage.InterruptionKind) storage.HistoryEntry {
+	entry := storage.HistoryEntry{Station: station, PlayedAt: time.Now()}
+	for _, kind := range kinds {
+		entry.Interruptions = append(entry.Interruptions, storage.Interruption{Kind: kind, At: time.Now(), Reason: "stream stalled"})
+	}
+	return entry
+}
+
+func TestStreamStability(t *testing.T) {
+
+	steady := common.Station{StationUuid: uuid.New(), Name: "Steady"}
+	flaky := common.Station{StationUuid: uuid.New(), Name: "Flaky"}
+	broken := common.Station{StationUuid: uuid.New(), Name: "Broken"}
+
+	history := historyOfSessions(
+		interruptedSession(flaky, storage.InterruptionReconnecting),
+		interruptedSession(steady),
+		interruptedSession(broken, storage.InterruptionReconnecting, storage.InterruptionFailed),
+		interruptedSession(flaky),
+		interruptedSession(steady),
+	)
+
+	t.Run("lists the stations interrupted, the most often first", func(t *testing.T) {
+		stations, err := unstableStations(history)
+		assert.NoError(t, err)
+		assert.Equal(t, []common.Station{broken, flaky}, stations)
+	})
+
+	t.Run("tells how many sessions of a station were interrupted", func(t *testing.T) {
+		assert.Equal(t, stationStability{sessions: 2, interrupted: 1}, stabilityOf(history, flaky.StationUuid))
+		assert.Equal(t, stationStability{sessions: 1, interrupted: 1, failed: 1}, stabilityOf(history, broken.StationUuid))
+		assert.Equal(t, stationStability{sessions: 2}, stabilityOf(history, steady.StationUuid))
+		assert.Equal(t, stationStability{}, stabilityOf(nil, steady.StationUuid))
+		assert.Equal(t, "", stationStability{}.String())
+	})
+
+	t.Run("reports when no station was interrupted", func(t *testing.T) {
+		model := StationsModel{}
+		model.SetHistoryStore(historyOfSessions(interruptedSession(steady)))
+
+		_, cmd := model.runCommand(command{name: "unstable"})
+
+		msg, ok := cmd().(nonFatalError)
+		assert.True(t, ok)
+		assert.ErrorIs(t, msg.err, ErrNoUnstableStations)
+	})
+
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"net/url"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/icy"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+// streamVariantOrder returns the variants of the URLs of station in the order they're tried:
+// the one that played it last, if remembered, then its resolved URL, its URL and the streams of its playlist.
+// Variants station has no URL for are left out.
+func streamVariantOrder(variants storage.StreamVariantStore, station common.Station) []storage.StreamVariant {
+	var order []storage.StreamVariant
+	if station.UrlResolved.URL.Host != "" && station.UrlResolved.URL != station.Url.URL {
+		order = append(order, storage.StreamResolved)
+	}
+	order = append(order, storage.StreamURL, storage.StreamPlaylist)
+	if variants == nil {
+		return order
+	}
+	last := variants.Variant(station.StationUuid)
+	for i, variant := range order {
+		if variant == last {
+			return append([]storage.StreamVariant{last}, append(order[:i:i], order[i+1:]...)...)
+		}
+	}
+	return order
+}
+
+// variantURL returns the URL of station of the given variant, which mustn't be the playlist one.
+func variantURL(station common.Station, variant storage.StreamVariant) url.URL {
+	if variant == storage.StreamResolved {
+		return station.UrlResolved.URL
+	}
+	return station.Url.URL
+}
+
+// playlistSources returns the URLs of station that may be playlists.
+func playlistSources(station common.Station) []url.URL {
+	sources := []url.URL{station.Url.URL}
+	if station.UrlResolved.URL.Host != "" && station.UrlResolved.URL != station.Url.URL {
+		sources = append(sources, station.UrlResolved.URL)
+	}
+	return sources
+}
+
+// withStream returns station set to be played from streamUrl, whatever URL the backends read.
+func withStream(station common.Station, streamUrl url.URL) common.Station {
+	station.Url = common.RadioGoGoURL{URL: streamUrl}
+	station.UrlResolved = common.RadioGoGoURL{URL: streamUrl}
+	return station
+}
+
+// pickStream probes the URLs of station in the given order, returning station set to be played from the first
+// that works, what its server announced and which variant it is. A URL serving a playlist is played only if none of
+// the streams it lists works, since the backends may still make sense of it. Without a prober, the first URL is
+// picked unprobed. If none works, the error is the one of the first URL tried.
+func pickStream(prober icy.ProberService, station common.Station, order []storage.StreamVariant) (common.Station, common.StreamInfo, storage.StreamVariant, error) {
+
+	if prober == nil {
+		return withStream(station, variantURL(station, order[0])), common.StreamInfo{}, order[0], nil
+	}
+
+	var firstErr error
+	var playlist *common.Station
+	var playlistStream common.StreamInfo
+	var playlistVariant storage.StreamVariant
+
+	for _, variant := range order {
+		if variant == storage.StreamPlaylist {
+			for _, source := range playlistSources(station) {
+				// Fetching isn't worth reporting: the source was probed on its own, or will be
+				entries, err := prober.Playlist(source)
+				if err != nil {
+					continue
+				}
+				for _, entry := range entries {
+					stream, err := prober.Probe(entry)
+					if err == nil && !icy.IsPlaylistType(stream.ContentType) {
+						return withStream(station, entry), stream, storage.StreamPlaylist, nil
+					}
+					if firstErr == nil {
+						firstErr = err
+					}
+				}
+			}
+			continue
+		}
+		streamUrl := variantURL(station, variant)
+		stream, err := prober.Probe(streamUrl)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if !icy.IsPlaylistType(stream.ContentType) {
+			return withStream(station, streamUrl), stream, variant, nil
+		}
+		if playlist == nil {
+			picked := withStream(station, streamUrl)
+			playlist, playlistStream, playlistVariant = &picked, stream, variant
+		}
+	}
+
+	if playlist != nil {
+		return *playlist, playlistStream, playlistVariant, nil
+	}
+	return station, common.StreamInfo{}, "", firstErr
+}
+
+// SetStreamVariantStore remembers in store which URL of each station played, to try it first the next time
+// (nil always tries the resolved URL first).
+func (m *StationsModel) SetStreamVariantStore(store storage.StreamVariantStore) {
+	m.streamVariants = store
+}
+
+// SetStreamVariantStore remembers in store which URL of each bookmark played, to try it first the next time
+// (nil always tries the resolved URL first).
+func (m *BookmarksModel) SetStreamVariantStore(store storage.StreamVariantStore) {
+	m.streamVariants = store
+}
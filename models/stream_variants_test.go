@@ -0,0 +1,184 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/icy"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlayStationCmdStreamFallback(t *testing.T) {
+
+	parse := func(raw string) url.URL {
+		parsed, _ := url.Parse(raw)
+		return *parsed
+	}
+
+	station := common.Station{Name: "Station", StationUuid: uuid.MustParse("9617a958-0601-11e8-ae97-52543be04c81")}
+	station.Url.URL = parse("http://example.com/listen.pls")
+	station.UrlResolved.URL = parse("http://stream.example.com/live")
+
+	// newProber serves the streams in working, the playlist of station.Url, and fails the others
+	newProber := func(working map[string]string, probed *[]string) *mocks.MockProberService {
+		return &mocks.MockProberService{
+			ProbeFunc: func(streamUrl url.URL) (common.StreamInfo, error) {
+				*probed = append(*probed, streamUrl.String())
+				if contentType, ok := working[streamUrl.String()]; ok {
+					return common.StreamInfo{ContentType: contentType}, nil
+				}
+				return common.StreamInfo{}, &icy.ProbeError{Kind: icy.ErrStreamRefused, StatusCode: 404}
+			},
+			PlaylistFunc: func(playlistUrl url.URL) ([]url.URL, error) {
+				if playlistUrl == station.Url.URL {
+					return []url.URL{parse("http://a.example.com/stream"), parse("http://b.example.com/stream")}, nil
+				}
+				return nil, icy.ErrNotPlaylist
+			},
+		}
+	}
+
+	// play plays station, returning the URL the backend was given and the variant remembered, if any
+	play := func(prober *mocks.MockProberService, last storage.StreamVariant) (string, storage.StreamVariant, interface{}) {
+		var played string
+		playbackManager := mocks.MockPlaybackManagerService{
+			PlayStationFunc: func(station common.Station, volume int) error {
+				played = station.Url.URL.String()
+				return nil
+			},
+		}
+		var remembered storage.StreamVariant
+		variants := mocks.MockStreamVariantStore{
+			VariantFunc: func(stationUuid uuid.UUID) storage.StreamVariant {
+				return last
+			},
+			SetVariantFunc: func(stationUuid uuid.UUID, variant storage.StreamVariant) error {
+				assert.Equal(t, station.StationUuid, stationUuid)
+				remembered = variant
+				return nil
+			},
+		}
+		msg := playStationCmd(&playbackManager, filter.ContentFilter{}, prober, nil, &variants, station, 80)()
+		return played, remembered, msg
+	}
+
+	t.Run("plays the resolved URL first, remembering nothing when it works", func(t *testing.T) {
+
+		var probed []string
+		played, remembered, msg := play(newProber(map[string]string{"http://stream.example.com/live": "audio/mpeg"}, &probed), "")
+
+		assert.Equal(t, "http://stream.example.com/live", played)
+		assert.Equal(t, storage.StreamVariant(""), remembered)
+		assert.Equal(t, []string{"http://stream.example.com/live"}, probed)
+		assert.Equal(t, station, msg.(playbackStartedMsg).station)
+
+	})
+
+	t.Run("falls back to the URL, remembering it", func(t *testing.T) {
+
+		var probed []string
+		played, remembered, _ := play(newProber(map[string]string{"http://example.com/listen.pls": "audio/mpeg"}, &probed), "")
+
+		assert.Equal(t, "http://example.com/listen.pls", played)
+		assert.Equal(t, storage.StreamURL, remembered)
+
+	})
+
+	t.Run("falls back to the streams of the playlist when the URL serves one", func(t *testing.T) {
+
+		var probed []string
+		played, remembered, _ := play(newProber(map[string]string{
+			"http://example.com/listen.pls": "audio/x-scpls",
+			"http://b.example.com/stream":   "audio/mpeg",
+		}, &probed), "")
+
+		assert.Equal(t, "http://b.example.com/stream", played)
+		assert.Equal(t, storage.StreamPlaylist, remembered)
+		assert.Equal(t, []string{
+			"http://stream.example.com/live",
+			"http://example.com/listen.pls",
+			"http://a.example.com/stream",
+			"http://b.example.com/stream",
+		}, probed)
+
+	})
+
+	t.Run("plays the playlist itself when none of its streams work", func(t *testing.T) {
+
+		var probed []string
+		played, remembered, _ := play(newProber(map[string]string{"http://example.com/listen.pls": "audio/x-scpls"}, &probed), "")
+
+		assert.Equal(t, "http://example.com/listen.pls", played)
+		assert.Equal(t, storage.StreamURL, remembered)
+
+	})
+
+	t.Run("tries the variant that played last first", func(t *testing.T) {
+
+		var probed []string
+		played, remembered, _ := play(newProber(map[string]string{
+			"http://stream.example.com/live": "audio/mpeg",
+			"http://a.example.com/stream":    "audio/mpeg",
+		}, &probed), storage.StreamPlaylist)
+
+		assert.Equal(t, "http://a.example.com/stream", played)
+		assert.Equal(t, storage.StreamVariant(""), remembered)
+		assert.Equal(t, []string{"http://a.example.com/stream"}, probed)
+
+	})
+
+	t.Run("reports the failure of the first URL when none works", func(t *testing.T) {
+
+		var probed []string
+		played, remembered, msg := play(newProber(nil, &probed), storage.StreamURL)
+
+		assert.Equal(t, "", played)
+		assert.Equal(t, storage.StreamVariant(""), remembered)
+		assert.Equal(t, "http://example.com/listen.pls", probed[0])
+		assert.ErrorIs(t, msg.(nonFatalError).err, icy.ErrStreamRefused)
+
+	})
+
+	t.Run("plays the resolved URL unprobed without a prober", func(t *testing.T) {
+
+		var played string
+		playbackManager := mocks.MockPlaybackManagerService{
+			PlayStationFunc: func(station common.Station, volume int) error {
+				played = station.Url.URL.String()
+				return nil
+			},
+		}
+
+		msg := playStationCmd(&playbackManager, filter.ContentFilter{}, nil, nil, nil, station, 80)()
+
+		assert.Equal(t, "http://stream.example.com/live", played)
+		assert.Equal(t, playbackStartedMsg{station: station}, msg)
+
+	})
+
+}
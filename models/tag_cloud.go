@@ -0,0 +1,422 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// How many of the most popular tags to show in the cloud
+	tagCloudSize = 150
+	// Separator between tags on the same line
+	tagCloudSeparator = "  "
+	// Width used when the terminal width is still unknown
+	tagCloudDefaultWidth = 80
+)
+
+// Model
+
+type TagCloudModel struct {
+	theme Theme
+
+	spinnerModel spinner.Model
+	tags         []common.Tag
+	levels       []int
+	selection    int
+	// pendingTypeAhead is true after typeAheadKey is pressed, waiting for the letter to jump to.
+	pendingTypeAhead bool
+	loading          bool
+	err              string
+	width            int
+	height           int
+
+	browser api.RadioBrowserService
+	// The tags are fetched along with the rest of the catalog, kept for the session (fetched every time if nil)
+	catalog  *catalog
+	progress catalogProgress
+}
+
+func NewTagCloudModel(theme Theme, browser api.RadioBrowserService) TagCloudModel {
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = theme.SecondaryText
+
+	return TagCloudModel{
+		theme:        theme,
+		spinnerModel: s,
+		loading:      true,
+		browser:      browser,
+	}
+}
+
+// SetCatalog keeps the tags fetched for the session in c, along with the countries and languages.
+func (m *TagCloudModel) SetCatalog(c *catalog) {
+	m.catalog = c
+}
+
+// Commands
+
+func updateCommandsForTagCloud() tea.Msg {
+	return bottomBarUpdateMsg{
+		commands: []string{
+			i18n.T("commands.quit"),
+			i18n.T("commands.back"),
+			i18n.T("commands.moveAll"),
+			i18n.T("commands.searchTag"),
+			i18n.T("commands.help"),
+		},
+	}
+}
+
+// Bubbletea
+
+func (m TagCloudModel) Init() tea.Cmd {
+	cmds := []tea.Cmd{m.spinnerModel.Tick, updateCommandsForTagCloud}
+	return tea.Batch(append(cmds, fetchCatalogCmds(m.browser, m.catalog)...)...)
+}
+
+func (m TagCloudModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	switch msg := msg.(type) {
+	case catalogFetchedMsg:
+		m.progress = m.progress.with(msg.part)
+		if msg.part != catalogTags {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.err = msg.err.Error()
+			return m, nil
+		}
+		m.tags = append([]common.Tag(nil), msg.tags...)
+		sort.SliceStable(m.tags, func(i, j int) bool {
+			return strings.ToLower(m.tags[i].Name) < strings.ToLower(m.tags[j].Name)
+		})
+		m.levels = tagPopularityLevels(m.tags)
+		m.selection = 0
+		return m, nil
+	case tea.KeyMsg:
+		pendingTypeAhead := m.pendingTypeAhead
+		m.pendingTypeAhead = false
+		if letter, ok := typeAheadLetter(msg); ok && pendingTypeAhead {
+			return m.jumpToLetter(letter), nil
+		}
+		switch msg.String() {
+		case "q":
+			return m, quitCmd
+		case "?", "f1":
+			return m, showHelpCmd
+		case "esc":
+			return m, func() tea.Msg {
+				return switchToSearchModelMsg{}
+			}
+		case "left", "h":
+			if m.selection > 0 {
+				m.selection--
+			}
+		case "right", "l":
+			if m.selection < len(m.tags)-1 {
+				m.selection++
+			}
+		case "up", "k":
+			m.selection = m.verticalNeighbour(-1)
+		case "down", "j":
+			m.selection = m.verticalNeighbour(1)
+		case "pgup":
+			m.selection = m.verticalNeighbour(-m.pageLines())
+		case "pgdown":
+			m.selection = m.verticalNeighbour(m.pageLines())
+		case "home":
+			m.selection = 0
+		case "end":
+			if len(m.tags) > 0 {
+				m.selection = len(m.tags) - 1
+			}
+		case typeAheadKey:
+			m.pendingTypeAhead = true
+		case "enter":
+			if len(m.tags) == 0 {
+				return m, nil
+			}
+			tag := m.tags[m.selection].Name
+			return m, func() tea.Msg {
+				return switchToLoadingModelMsg{
+					query:     common.StationQueryByTagExact,
+					queryText: tag,
+				}
+			}
+		default:
+			// Letters that aren't commands jump straight to the tags starting with them
+			if letter, ok := typeAheadLetter(msg); ok {
+				return m.jumpToLetter(letter), nil
+			}
+		}
+		return m, nil
+	}
+
+	if m.loading {
+		newSpinnerModel, cmd := m.spinnerModel.Update(msg)
+		m.spinnerModel = newSpinnerModel
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+func (m TagCloudModel) View() string {
+
+	if m.loading {
+		if m.theme.Accessible {
+			return "\n" + m.progress.String()
+		}
+		return "\n" + m.spinnerModel.View() + " " + m.progress.String()
+	}
+
+	if m.err != "" {
+		return "\n" + m.theme.RenderError(m.err)
+	}
+
+	if len(m.tags) == 0 {
+		return "\n" + m.theme.SecondaryText.Bold(true).Render(i18n.T("tags.empty"))
+	}
+
+	lines := layoutTagCloud(m.tags, m.cloudWidth())
+
+	// Scroll so that the line containing the selection is always visible
+	visibleLines := m.visibleLines()
+	if visibleLines < 1 {
+		visibleLines = len(lines)
+	}
+	firstLine := 0
+	selectedLine := tagCloudLineOf(lines, m.selection)
+	if selectedLine >= visibleLines {
+		firstLine = selectedLine - visibleLines + 1
+	}
+
+	v := "\n"
+	for lineIndex := firstLine; lineIndex < len(lines) && lineIndex < firstLine+visibleLines; lineIndex++ {
+		renderedTags := make([]string, len(lines[lineIndex]))
+		for i, tagIndex := range lines[lineIndex] {
+			renderedTags[i] = m.renderTag(tagIndex)
+		}
+		v += strings.Join(renderedTags, tagCloudSeparator) + "\n"
+	}
+
+	selected := m.tags[m.selection]
+	v += "\n" + m.theme.SecondaryText.Bold(true).Render(
+		i18n.Tf("tags.stationCount", selected.Name, selected.StationCount),
+	)
+
+	return v
+}
+
+func (m TagCloudModel) renderTag(index int) string {
+
+	name := m.tags[index].Name
+
+	if m.theme.Accessible {
+		if index == m.selection {
+			return ">>> " + name + " <<<"
+		}
+		return name
+	}
+
+	if index == m.selection {
+		return m.theme.PrimaryBlock.Copy().PaddingLeft(0).PaddingRight(0).Bold(true).Render(name)
+	}
+
+	switch m.levels[index] {
+	case 3:
+		return m.theme.PrimaryText.Copy().Bold(true).Underline(true).Render(name)
+	case 2:
+		return m.theme.SecondaryText.Copy().Bold(true).Render(name)
+	case 1:
+		return m.theme.Text.Render(name)
+	default:
+		return m.theme.TertiaryText.Render(name)
+	}
+}
+
+func (m TagCloudModel) cloudWidth() int {
+	if m.width <= 0 {
+		return tagCloudDefaultWidth
+	}
+	return m.width
+}
+
+// verticalNeighbour returns the index of the tag on the line above (direction -1) or below (direction 1)
+// that is horizontally closest to the current selection.
+func (m TagCloudModel) verticalNeighbour(direction int) int {
+
+	lines := layoutTagCloud(m.tags, m.cloudWidth())
+	currentLine := tagCloudLineOf(lines, m.selection)
+	targetLine := currentLine + direction
+
+	if currentLine < 0 {
+		return m.selection
+	}
+	// Moving past the first or the last line stops there
+	if targetLine < 0 {
+		targetLine = 0
+	}
+	if targetLine >= len(lines) {
+		targetLine = len(lines) - 1
+	}
+
+	center := tagCloudCenterOf(m.tags, lines[currentLine], m.selection)
+
+	best := lines[targetLine][0]
+	bestDistance := -1
+	for _, tagIndex := range lines[targetLine] {
+		distance := tagCloudCenterOf(m.tags, lines[targetLine], tagIndex) - center
+		if distance < 0 {
+			distance = -distance
+		}
+		if bestDistance < 0 || distance < bestDistance {
+			best = tagIndex
+			bestDistance = distance
+		}
+	}
+
+	return best
+}
+
+// visibleLines returns how many lines of tags fit in the view, less than 1 if its height is still unknown.
+func (m TagCloudModel) visibleLines() int {
+	return m.height - 4
+}
+
+// pageLines returns how many lines PgUp and PgDn move the selection by.
+func (m TagCloudModel) pageLines() int {
+	if lines := m.visibleLines(); lines > 1 {
+		return lines
+	}
+	return 1
+}
+
+// jumpToLetter selects the next tag starting with letter, if any.
+func (m TagCloudModel) jumpToLetter(letter rune) TagCloudModel {
+	m.selection, _ = typeAheadIndex(len(m.tags), m.selection, letter, func(i int) string {
+		return m.tags[i].Name
+	})
+	return m
+}
+
+func (m *TagCloudModel) SetWidthAndHeight(width int, height int) {
+	m.width = width
+	m.height = height
+}
+
+// Layout
+
+// layoutTagCloud splits the tags into lines that fit the given width.
+// Each line is a list of indexes into tags.
+func layoutTagCloud(tags []common.Tag, width int) [][]int {
+
+	var lines [][]int
+	var line []int
+	lineWidth := 0
+
+	for i, tag := range tags {
+		tagWidth := lipgloss.Width(tag.Name)
+		if len(line) > 0 && lineWidth+len(tagCloudSeparator)+tagWidth > width {
+			lines = append(lines, line)
+			line = nil
+			lineWidth = 0
+		}
+		if len(line) > 0 {
+			lineWidth += len(tagCloudSeparator)
+		}
+		line = append(line, i)
+		lineWidth += tagWidth
+	}
+
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// tagCloudLineOf returns the line containing the given tag index, or -1 if not found.
+func tagCloudLineOf(lines [][]int, index int) int {
+	for lineIndex, line := range lines {
+		for _, tagIndex := range line {
+			if tagIndex == index {
+				return lineIndex
+			}
+		}
+	}
+	return -1
+}
+
+// tagCloudCenterOf returns the horizontal center of the given tag within its line.
+func tagCloudCenterOf(tags []common.Tag, line []int, index int) int {
+	x := 0
+	for _, tagIndex := range line {
+		tagWidth := lipgloss.Width(tags[tagIndex].Name)
+		if tagIndex == index {
+			return x + tagWidth/2
+		}
+		x += tagWidth + len(tagCloudSeparator)
+	}
+	return x
+}
+
+// tagPopularityLevels ranks tags by station count and assigns each one
+// a level from 0 (least popular) to 3 (most popular).
+func tagPopularityLevels(tags []common.Tag) []int {
+
+	ranking := make([]int, len(tags))
+	for i := range ranking {
+		ranking[i] = i
+	}
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return tags[ranking[i]].StationCount > tags[ranking[j]].StationCount
+	})
+
+	levels := make([]int, len(tags))
+	for rank, tagIndex := range ranking {
+		percentile := float64(rank) / float64(len(tags))
+		switch {
+		case percentile < 0.1:
+			levels[tagIndex] = 3
+		case percentile < 0.3:
+			levels[tagIndex] = 2
+		case percentile < 0.6:
+			levels[tagIndex] = 1
+		default:
+			levels[tagIndex] = 0
+		}
+	}
+
+	return levels
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"io"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagCloudModel_Init(t *testing.T) {
+
+	newBrowser := func(tags func() ([]common.Tag, error)) *mocks.MockRadioBrowserService {
+		return &mocks.MockRadioBrowserService{
+			GetTagsFunc: func(prefix string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Tag, error) {
+				assert.Equal(t, "stationcount", order)
+				assert.True(t, reverse)
+				return tags()
+			},
+			GetCountriesFunc: func(hideBroken bool) ([]common.Country, error) {
+				return []common.Country{{Name: "Italy", Code: "IT"}}, nil
+			},
+			GetLanguagesFunc: func(hideBroken bool) ([]common.Language, error) {
+				return []common.Language{{Name: "italian"}}, nil
+			},
+		}
+	}
+
+	fetchedTags := func(model TagCloudModel) (catalogFetchedMsg, bool) {
+		for _, cmd := range model.Init()().(tea.BatchMsg) {
+			if msg, ok := cmd().(catalogFetchedMsg); ok && msg.part == catalogTags {
+				return msg, true
+			}
+		}
+		return catalogFetchedMsg{}, false
+	}
+
+	t.Run("fetches tags along with the rest of the catalog", func(t *testing.T) {
+
+		model := NewTagCloudModel(Theme{}, newBrowser(func() ([]common.Tag, error) {
+			return []common.Tag{{Name: "jazz", StationCount: 10}}, nil
+		}))
+
+		msg, found := fetchedTags(model)
+
+		assert.True(t, found)
+		assert.Equal(t, []common.Tag{{Name: "jazz", StationCount: 10}}, msg.tags)
+
+	})
+
+	t.Run("tells why the tags couldn't be fetched", func(t *testing.T) {
+
+		model := NewTagCloudModel(Theme{}, newBrowser(func() ([]common.Tag, error) {
+			return nil, io.EOF
+		}))
+
+		msg, found := fetchedTags(model)
+
+		assert.True(t, found)
+		assert.ErrorIs(t, msg.err, io.EOF)
+
+	})
+
+	t.Run("fetches the catalog once for the session", func(t *testing.T) {
+
+		fetched := 0
+		browser := newBrowser(func() ([]common.Tag, error) {
+			fetched++
+			return []common.Tag{{Name: "jazz", StationCount: 10}}, nil
+		})
+		catalog := newCatalog()
+
+		for i := 0; i < 2; i++ {
+			model := NewTagCloudModel(Theme{}, browser)
+			model.SetCatalog(catalog)
+			msg, _ := fetchedTags(model)
+			assert.Equal(t, []common.Tag{{Name: "jazz", StationCount: 10}}, msg.tags)
+		}
+
+		assert.Equal(t, 1, fetched)
+
+	})
+
+}
+
+func TestTagCloudModel_Update(t *testing.T) {
+
+	tags := []common.Tag{
+		{Name: "rock", StationCount: 300},
+		{Name: "jazz", StationCount: 100},
+		{Name: "ambient", StationCount: 5},
+	}
+
+	newLoadedModel := func() TagCloudModel {
+		model := NewTagCloudModel(Theme{}, &mocks.MockRadioBrowserService{})
+		newModel, _ := model.Update(catalogFetchedMsg{part: catalogTags, tags: tags})
+		return newModel.(TagCloudModel)
+	}
+
+	t.Run("sorts tags alphabetically when fetched", func(t *testing.T) {
+
+		model := newLoadedModel()
+
+		assert.False(t, model.loading)
+		assert.Equal(t, "ambient", model.tags[0].Name)
+		assert.Equal(t, "jazz", model.tags[1].Name)
+		assert.Equal(t, "rock", model.tags[2].Name)
+
+	})
+
+	t.Run("moves the selection with the arrow keys", func(t *testing.T) {
+
+		model := newLoadedModel()
+
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyRight})
+		assert.Equal(t, 1, newModel.(TagCloudModel).selection)
+
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyLeft})
+		assert.Equal(t, 0, newModel.(TagCloudModel).selection)
+
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyLeft})
+		assert.Equal(t, 0, newModel.(TagCloudModel).selection)
+
+	})
+
+	t.Run("moves the selection to the closest tag on the next line", func(t *testing.T) {
+
+		model := newLoadedModel()
+		model.width = 10 // "ambient" and "jazz" don't fit on the same line
+
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyDown})
+		assert.Equal(t, 1, newModel.(TagCloudModel).selection)
+
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyUp})
+		assert.Equal(t, 0, newModel.(TagCloudModel).selection)
+
+	})
+
+	t.Run("pages through the tags and jumps to the first and the last", func(t *testing.T) {
+
+		model := newLoadedModel()
+		model.width = 4  // One tag per line
+		model.height = 6 // Two lines shown
+
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+		assert.Equal(t, 2, newModel.(TagCloudModel).selection)
+
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+		assert.Equal(t, 0, newModel.(TagCloudModel).selection)
+
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyEnd})
+		assert.Equal(t, 2, newModel.(TagCloudModel).selection)
+
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyHome})
+		assert.Equal(t, 0, newModel.(TagCloudModel).selection)
+
+	})
+
+	t.Run("jumps to the tags starting with the letter typed", func(t *testing.T) {
+
+		model := newLoadedModel()
+
+		newModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+		assert.Equal(t, 2, newModel.(TagCloudModel).selection)
+
+		// "j" moves down, unless typed after '
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("'")})
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+		assert.Equal(t, 1, newModel.(TagCloudModel).selection)
+
+	})
+
+	t.Run("broadcasts switchToLoadingModelMsg with an exact tag query when 'enter' is pressed", func(t *testing.T) {
+
+		model := newLoadedModel()
+		model.selection = 1
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		assert.NotNil(t, cmd)
+
+		assert.Equal(t, switchToLoadingModelMsg{
+			query:     common.StationQueryByTagExact,
+			queryText: "jazz",
+		}, cmd())
+
+	})
+
+	t.Run("broadcasts switchToSearchModelMsg when 'esc' is pressed", func(t *testing.T) {
+
+		model := newLoadedModel()
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.NotNil(t, cmd)
+
+		assert.IsType(t, switchToSearchModelMsg{}, cmd())
+
+	})
+
+}
+
+func TestTagPopularityLevels(t *testing.T) {
+
+	tags := make([]common.Tag, 10)
+	for i := range tags {
+		tags[i] = common.Tag{Name: "tag", StationCount: uint64(i)}
+	}
+
+	levels := tagPopularityLevels(tags)
+
+	assert.Equal(t, 3, levels[9])
+	assert.Equal(t, 2, levels[8])
+	assert.Equal(t, 1, levels[5])
+	assert.Equal(t, 0, levels[0])
+
+}
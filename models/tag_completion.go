@@ -0,0 +1,208 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// How long typing must pause before tags are fetched, so that every key doesn't send a request
+	tagCompletionDelay = 250 * time.Millisecond
+	// How many characters are typed before tags are suggested
+	tagCompletionMinLength = 2
+	// How many tags are fetched, and how many of them are suggested
+	tagCompletionFetched   = 50
+	tagCompletionSuggested = 8
+)
+
+// tagCompletion suggests tags matching what's typed in a tag search.
+type tagCompletion struct {
+	// The tags suggested, and which one is highlighted (-1 for none)
+	tags     []common.Tag
+	selected int
+	// Tells the pauses in typing apart, so that only the last one fetches tags
+	generation int
+}
+
+// rankTagCompletions returns the tags containing prefix, those starting with it first,
+// then the ones with most stations, each name once.
+func rankTagCompletions(prefix string, tags []common.Tag) []common.Tag {
+	prefix = strings.ToLower(prefix)
+	seen := make(map[string]bool)
+	var ranked []common.Tag
+	for _, tag := range tags {
+		name := strings.ToLower(tag.Name)
+		if seen[name] || !strings.Contains(name, prefix) {
+			continue
+		}
+		seen[name] = true
+		ranked = append(ranked, tag)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		iPrefixed := strings.HasPrefix(strings.ToLower(ranked[i].Name), prefix)
+		jPrefixed := strings.HasPrefix(strings.ToLower(ranked[j].Name), prefix)
+		if iPrefixed != jPrefixed {
+			return iPrefixed
+		}
+		return ranked[i].StationCount > ranked[j].StationCount
+	})
+	if len(ranked) > tagCompletionSuggested {
+		ranked = ranked[:tagCompletionSuggested]
+	}
+	return ranked
+}
+
+// completesTags reports whether tags are suggested for what's typed in the name field.
+func (m SearchModel) completesTags() bool {
+	query := m.querySelector.Selection()
+	return m.browser != nil && m.inputModel.Focused() &&
+		(query == common.StationQueryByTag || query == common.StationQueryByTagExact)
+}
+
+// completionShown reports whether tags are being suggested.
+func (m SearchModel) completionShown() bool {
+	return m.completesTags() && len(m.completion.tags) > 0
+}
+
+// completionPrefix returns what's typed, as tags are matched against.
+func (m SearchModel) completionPrefix() string {
+	return strings.ToLower(strings.TrimSpace(m.inputModel.Value()))
+}
+
+// completeTags updates the tags suggested once the name field has been edited:
+// the ones already fetched are narrowed down at once, and fetched again once typing pauses.
+func (m *SearchModel) completeTags() tea.Cmd {
+	m.completion.generation++
+	m.completion.selected = -1
+	prefix := m.completionPrefix()
+	if !m.completesTags() || len([]rune(prefix)) < tagCompletionMinLength {
+		m.completion.tags = nil
+		return nil
+	}
+	m.completion.tags = rankTagCompletions(prefix, m.completion.tags)
+	generation := m.completion.generation
+	return tea.Tick(tagCompletionDelay, func(t time.Time) tea.Msg {
+		return tagCompletionTickMsg{generation: generation}
+	})
+}
+
+// acceptCompletion puts the highlighted tag, or else the first one, in the name field.
+func (m *SearchModel) acceptCompletion() {
+	selected := m.completion.selected
+	if selected < 0 {
+		selected = 0
+	}
+	name := m.completion.tags[selected].Name
+	m.inputModel.SetValue(name)
+	m.inputModel.CursorEnd()
+	m.completion = tagCompletion{selected: -1, generation: m.completion.generation + 1}
+}
+
+// updateCompletion handles the keys choosing among the tags suggested.
+// It returns false for the keys it leaves to the rest of the form.
+func (m SearchModel) updateCompletion(msg tea.KeyMsg) (SearchModel, tea.Cmd, bool) {
+	switch msg.String() {
+	case "down":
+		if m.completion.selected < len(m.completion.tags)-1 {
+			m.completion.selected++
+		}
+		return m, nil, true
+	case "up":
+		if m.completion.selected >= 0 {
+			m.completion.selected--
+		}
+		return m, nil, true
+	case "tab":
+		m.acceptCompletion()
+		return m, nil, true
+	case "enter":
+		if m.completion.selected < 0 {
+			return m, nil, false
+		}
+		m.acceptCompletion()
+		newModel, cmd := m.submit()
+		return newModel.(SearchModel), cmd, true
+	case "esc":
+		m.completion.tags = nil
+		return m, nil, true
+	}
+	return m, nil, false
+}
+
+// completionView renders the tags suggested below the name field, with their number of stations.
+func (m SearchModel) completionView() string {
+	if !m.completionShown() {
+		return ""
+	}
+	if m.theme.Accessible {
+		names := make([]string, len(m.completion.tags))
+		for i, tag := range m.completion.tags {
+			names[i] = fmt.Sprintf("%s (%d)", tag.Name, tag.StationCount)
+		}
+		return "\n" + i18n.Tf("search.suggestions", strings.Join(names, ", "))
+	}
+	var v string
+	for i, tag := range m.completion.tags {
+		count := m.theme.TertiaryText.Render(fmt.Sprintf("(%d)", tag.StationCount))
+		if i == m.completion.selected {
+			v += "\n" + m.theme.PrimaryText.Bold(true).Render("> "+displayText(tag.Name)) + " " + count
+		} else {
+			v += "\n" + m.theme.Text.Render("  "+displayText(tag.Name)) + " " + count
+		}
+	}
+	return v + "\n" + m.theme.TertiaryText.Render(i18n.T("search.completionHint"))
+}
+
+// Messages
+
+// tagCompletionTickMsg fetches the tags matching what's typed, unless typing went on since, as told by generation.
+type tagCompletionTickMsg struct {
+	generation int
+}
+
+// tagsCompletedMsg carries the tags matching prefix.
+type tagsCompletedMsg struct {
+	prefix string
+	tags   []common.Tag
+}
+
+// Commands
+
+// completeTagsCmd fetches the most used tags containing prefix.
+// Completion is a convenience: when radio-browser can't be reached, no tags are suggested.
+func completeTagsCmd(browser api.RadioBrowserService, prefix string) tea.Cmd {
+	return func() tea.Msg {
+		tags, err := browser.GetTags(prefix, "stationcount", true, 0, tagCompletionFetched, true)
+		if err != nil {
+			return nil
+		}
+		return tagsCompletedMsg{prefix: prefix, tags: tags}
+	}
+}
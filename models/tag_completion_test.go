@@ -0,0 +1,172 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankTagCompletions(t *testing.T) {
+
+	tags := []common.Tag{
+		{Name: "smooth jazz", StationCount: 300},
+		{Name: "jazz", StationCount: 2000},
+		{Name: "Jazz", StationCount: 5},
+		{Name: "jazz fusion", StationCount: 80},
+		{Name: "rock", StationCount: 9000},
+	}
+
+	assert.Equal(t, []common.Tag{
+		{Name: "jazz", StationCount: 2000},
+		{Name: "jazz fusion", StationCount: 80},
+		{Name: "smooth jazz", StationCount: 300},
+	}, rankTagCompletions("Jaz", tags))
+
+}
+
+// newTagSearchModel returns a search form set to search by tag, whose tags are fetched from browser.
+func newTagSearchModel(browser *mocks.MockRadioBrowserService) SearchModel {
+	model := NewSearchModel(Theme{}, common.StationFilter{})
+	model.SetBrowser(browser)
+	for model.querySelector.Selection() != common.StationQueryByTag {
+		model.querySelector.selection++
+	}
+	return model
+}
+
+// typeText types text in the search form, returning the messages of the last key.
+func typeText(model SearchModel, text string) (SearchModel, tea.Cmd) {
+	var cmd tea.Cmd
+	for _, r := range text {
+		var newModel tea.Model
+		newModel, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		model = newModel.(SearchModel)
+	}
+	return model, cmd
+}
+
+func TestSearchModel_TagCompletion(t *testing.T) {
+
+	tags := []common.Tag{{Name: "jazz", StationCount: 2000}, {Name: "jazz fusion", StationCount: 80}}
+	var prefixes []string
+	browser := &mocks.MockRadioBrowserService{
+		GetTagsFunc: func(prefix string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Tag, error) {
+			prefixes = append(prefixes, prefix)
+			return tags, nil
+		},
+	}
+
+	t.Run("fetches the tags once typing pauses", func(t *testing.T) {
+
+		prefixes = nil
+		model, _ := typeText(newTagSearchModel(browser), "j")
+		assert.Equal(t, 1, model.completion.generation)
+
+		model, _ = typeText(model, "az")
+		// The tick of an earlier pause fetches nothing
+		_, cmd := model.Update(tagCompletionTickMsg{generation: 2})
+		assert.Nil(t, cmd)
+
+		_, cmd = model.Update(tagCompletionTickMsg{generation: model.completion.generation})
+		assert.Equal(t, tagsCompletedMsg{prefix: "jaz", tags: tags}, cmd())
+		assert.Equal(t, []string{"jaz"}, prefixes)
+
+	})
+
+	t.Run("suggests nothing in other searches", func(t *testing.T) {
+
+		model := NewSearchModel(Theme{}, common.StationFilter{})
+		model.SetBrowser(browser)
+
+		model, _ = typeText(model, "jaz")
+		assert.False(t, model.completesTags())
+		assert.Empty(t, model.completion.tags)
+
+	})
+
+	t.Run("shows the tags of what's still typed, with their stations", func(t *testing.T) {
+
+		model, _ := typeText(newTagSearchModel(browser), "jaz")
+
+		newModel, _ := model.Update(tagsCompletedMsg{prefix: "ja", tags: tags})
+		assert.False(t, newModel.(SearchModel).completionShown())
+
+		newModel, _ = model.Update(tagsCompletedMsg{prefix: "jaz", tags: tags})
+		assert.True(t, newModel.(SearchModel).completionShown())
+		assert.Contains(t, newModel.View(), "jazz fusion (80)")
+
+	})
+
+	t.Run("completes the highlighted tag with tab", func(t *testing.T) {
+
+		model, _ := typeText(newTagSearchModel(browser), "jaz")
+		newModel, _ := model.Update(tagsCompletedMsg{prefix: "jaz", tags: tags})
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+		newModel, cmd := newModel.Update(tea.KeyMsg{Type: tea.KeyTab})
+
+		assert.Nil(t, cmd)
+		assert.Equal(t, "jazz fusion", newModel.(SearchModel).inputModel.Value())
+		assert.False(t, newModel.(SearchModel).completionShown())
+		assert.True(t, newModel.(SearchModel).inputModel.Focused())
+
+	})
+
+	t.Run("searches for the highlighted tag with enter", func(t *testing.T) {
+
+		model, _ := typeText(newTagSearchModel(browser), "jaz")
+		newModel, _ := model.Update(tagsCompletedMsg{prefix: "jaz", tags: tags})
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+		_, cmd := newModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		assert.Equal(t, switchToLoadingModelMsg{query: common.StationQueryByTag, queryText: "jazz"}, cmd())
+
+	})
+
+	t.Run("hides the tags with esc", func(t *testing.T) {
+
+		model, _ := typeText(newTagSearchModel(browser), "jaz")
+		newModel, _ := model.Update(tagsCompletedMsg{prefix: "jaz", tags: tags})
+		newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+		assert.False(t, newModel.(SearchModel).completionShown())
+
+	})
+
+	t.Run("suggests nothing when the tags can't be fetched", func(t *testing.T) {
+
+		failing := &mocks.MockRadioBrowserService{
+			GetTagsFunc: func(prefix string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Tag, error) {
+				return nil, errors.New("timeout")
+			},
+		}
+
+		assert.Nil(t, completeTagsCmd(failing, "jaz")())
+
+	})
+
+}
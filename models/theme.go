@@ -20,6 +20,8 @@
 package models
 
 import (
+	"strings"
+
 	"github.com/zi0p4tch0/radiogogo/config"
 
 	"github.com/charmbracelet/bubbles/table"
@@ -28,6 +30,13 @@ import (
 
 // Theme represents a style configuration for the application.
 type Theme struct {
+	// Accessible is true when views should render screen-reader friendly output:
+	// plain text without colors, borders or decorations, and explicit state announcements.
+	Accessible bool
+	// LowBandwidth is true when decorations that keep the screen redrawing, such as scrolling titles,
+	// should be left out for slow links.
+	LowBandwidth bool
+
 	PrimaryBlock   lipgloss.Style
 	SecondaryBlock lipgloss.Style
 
@@ -40,68 +49,246 @@ type Theme struct {
 	StationsTableStyle table.Styles
 }
 
-func NewTheme(config config.Config) Theme {
+func NewTheme(cfg config.Config) Theme {
+
+	if cfg.Accessible {
+		return NewAccessibleTheme()
+	}
+	if cfg.Render.LowBandwidth {
+		return NewLowBandwidthTheme()
+	}
+
+	colors, styles := themeColors(cfg)
+	return newStyledTheme(colors.Effective(), styles)
+}
+
+// themeColors returns the colors of the configuration, with those of its theme file drawn over them,
+// and the styles of the theme file.
+func themeColors(cfg config.Config) (config.ThemeColors, config.ThemeStyles) {
+	colors := cfg.Theme
+	var styles config.ThemeStyles
+	// A theme file that can't be loaded is reported along with the rest of the configuration
+	if colors.File != "" {
+		if file, err := config.LoadThemeFile(colors.File); err == nil {
+			colors = file.Colors.Over(colors)
+			styles = file.Styles
+		}
+	}
+	return colors, styles
+}
+
+// newStyledTheme returns the Theme drawn with the given colors, with the styles of a theme file drawn over them.
+func newStyledTheme(colors config.ThemeColors, styles config.ThemeStyles) Theme {
 
 	primaryBlock := lipgloss.NewStyle().
-		Foreground(lipgloss.Color(config.Theme.TextColor)).
-		Background(lipgloss.Color(config.Theme.PrimaryColor)).
+		Foreground(lipgloss.Color(colors.TextColor)).
+		Background(lipgloss.Color(colors.PrimaryColor)).
 		PaddingLeft(2).
 		PaddingRight(2)
 
 	secondaryBlock := lipgloss.NewStyle().
-		Foreground(lipgloss.Color(config.Theme.TextColor)).
-		Background(lipgloss.Color(config.Theme.SecondaryColor)).
+		Foreground(lipgloss.Color(colors.TextColor)).
+		Background(lipgloss.Color(colors.SecondaryColor)).
 		PaddingLeft(2).
 		PaddingRight(2)
 
 	text := lipgloss.NewStyle().
-		Foreground(lipgloss.Color(config.Theme.TextColor))
+		Foreground(lipgloss.Color(colors.TextColor))
 
 	primaryText := lipgloss.NewStyle().
-		Foreground(lipgloss.Color(config.Theme.PrimaryColor))
+		Foreground(lipgloss.Color(colors.PrimaryColor))
 
 	secondaryText := lipgloss.NewStyle().
-		Foreground(lipgloss.Color(config.Theme.SecondaryColor))
+		Foreground(lipgloss.Color(colors.SecondaryColor))
 
 	tertiaryText := lipgloss.NewStyle().
-		Foreground(lipgloss.Color(config.Theme.TertiaryColor))
+		Foreground(lipgloss.Color(colors.TertiaryColor))
 
 	errorText := lipgloss.NewStyle().
-		Foreground(lipgloss.Color(config.Theme.ErrorColor))
+		Foreground(lipgloss.Color(colors.ErrorColor))
 
 	stationsTableStyles := table.DefaultStyles()
 	stationsTableStyles.Header = stationsTableStyles.Header.
 		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color(config.Theme.TextColor)).
+		BorderForeground(lipgloss.Color(colors.TextColor)).
 		BorderBottom(true).
 		Bold(false)
 	stationsTableStyles.Cell = stationsTableStyles.Cell.
-		Foreground(lipgloss.Color(config.Theme.TextColor))
+		Foreground(lipgloss.Color(colors.TextColor))
 	stationsTableStyles.Selected = stationsTableStyles.Selected.
-		Foreground(lipgloss.Color(config.Theme.TextColor)).
-		Background(lipgloss.Color(config.Theme.PrimaryColor)).
+		Foreground(lipgloss.Color(colors.TextColor)).
+		Background(lipgloss.Color(colors.PrimaryColor)).
 		Bold(false)
 
+	stationsTableStyles.Header = overrideStyle(stationsTableStyles.Header, styles.TableHeader)
+	stationsTableStyles.Cell = overrideStyle(stationsTableStyles.Cell, styles.TableCell)
+	stationsTableStyles.Selected = overrideStyle(stationsTableStyles.Selected, styles.TableSelected)
+
 	return Theme{
-		PrimaryBlock:       primaryBlock,
-		SecondaryBlock:     secondaryBlock,
-		Text:               text,
-		PrimaryText:        primaryText,
-		SecondaryText:      secondaryText,
-		TertiaryText:       tertiaryText,
-		ErrorText:          errorText,
+		PrimaryBlock:       overrideStyle(primaryBlock, styles.PrimaryBlock),
+		SecondaryBlock:     overrideStyle(secondaryBlock, styles.SecondaryBlock),
+		Text:               overrideStyle(text, styles.Text),
+		PrimaryText:        overrideStyle(primaryText, styles.PrimaryText),
+		SecondaryText:      overrideStyle(secondaryText, styles.SecondaryText),
+		TertiaryText:       overrideStyle(tertiaryText, styles.TertiaryText),
+		ErrorText:          overrideStyle(errorText, styles.ErrorText),
 		StationsTableStyle: stationsTableStyles,
 	}
 }
 
+// themeBorders are the borders of config.ThemeBorders, but "none".
+var themeBorders = map[string]lipgloss.Border{
+	"normal":  lipgloss.NormalBorder(),
+	"rounded": lipgloss.RoundedBorder(),
+	"thick":   lipgloss.ThickBorder(),
+	"double":  lipgloss.DoubleBorder(),
+	"hidden":  lipgloss.HiddenBorder(),
+	"block":   lipgloss.BlockBorder(),
+}
+
+// overrideStyle returns style with the properties set by a theme file in place of its own.
+func overrideStyle(style lipgloss.Style, override config.StyleOverride) lipgloss.Style {
+	style = style.Copy()
+	if override.Foreground != nil {
+		style = style.Foreground(lipgloss.Color(*override.Foreground))
+	}
+	if override.Background != nil {
+		style = style.Background(lipgloss.Color(*override.Background))
+	}
+	for _, attribute := range []struct {
+		value *bool
+		set   func(lipgloss.Style, bool) lipgloss.Style
+	}{
+		{override.Bold, lipgloss.Style.Bold},
+		{override.Italic, lipgloss.Style.Italic},
+		{override.Underline, lipgloss.Style.Underline},
+		{override.Faint, lipgloss.Style.Faint},
+		{override.Reverse, lipgloss.Style.Reverse},
+		{override.Strikethrough, lipgloss.Style.Strikethrough},
+	} {
+		if attribute.value != nil {
+			style = attribute.set(style, *attribute.value)
+		}
+	}
+	if len(override.Padding) > 0 {
+		style = style.Padding(override.Padding...)
+	}
+	if len(override.Margin) > 0 {
+		style = style.Margin(override.Margin...)
+	}
+	if override.Border != nil {
+		if border, ok := themeBorders[*override.Border]; ok {
+			style = style.BorderStyle(border)
+		} else {
+			style = style.UnsetBorderStyle().BorderTop(false).BorderRight(false).BorderBottom(false).BorderLeft(false)
+		}
+	}
+	if override.BorderForeground != nil {
+		style = style.BorderForeground(lipgloss.Color(*override.BorderForeground))
+	}
+	for _, side := range []struct {
+		value *bool
+		set   func(lipgloss.Style, bool) lipgloss.Style
+	}{
+		{override.BorderTop, lipgloss.Style.BorderTop},
+		{override.BorderRight, lipgloss.Style.BorderRight},
+		{override.BorderBottom, lipgloss.Style.BorderBottom},
+		{override.BorderLeft, lipgloss.Style.BorderLeft},
+	} {
+		if side.value != nil {
+			style = side.set(style, *side.value)
+		}
+	}
+	return style
+}
+
+// NewAccessibleTheme returns a Theme without any colors or decorations,
+// meant to be used with screen readers.
+func NewAccessibleTheme() Theme {
+
+	plain := lipgloss.NewStyle()
+
+	stationsTableStyles := table.Styles{
+		Header:   plain,
+		Cell:     plain,
+		Selected: plain,
+	}
+
+	return Theme{
+		Accessible:         true,
+		PrimaryBlock:       plain,
+		SecondaryBlock:     plain,
+		Text:               plain,
+		PrimaryText:        plain,
+		SecondaryText:      plain,
+		TertiaryText:       plain,
+		ErrorText:          plain,
+		StationsTableStyle: stationsTableStyles,
+	}
+}
+
+// NewLowBandwidthTheme returns a Theme without colors, which take most of what is sent to the terminal,
+// telling the selection and the bottom bar apart with reverse video.
+func NewLowBandwidthTheme() Theme {
+
+	plain := lipgloss.NewStyle()
+	block := lipgloss.NewStyle().Reverse(true).PaddingLeft(1).PaddingRight(1)
+
+	stationsTableStyles := table.DefaultStyles()
+	stationsTableStyles.Header = stationsTableStyles.Header.Bold(false)
+	stationsTableStyles.Cell = stationsTableStyles.Cell.Copy()
+	stationsTableStyles.Selected = plain.Copy().Reverse(true)
+
+	return Theme{
+		LowBandwidth:       true,
+		PrimaryBlock:       block,
+		SecondaryBlock:     plain.Copy().PaddingLeft(1).PaddingRight(1),
+		Text:               plain,
+		PrimaryText:        plain.Copy().Bold(true),
+		SecondaryText:      plain,
+		TertiaryText:       plain,
+		ErrorText:          plain.Copy().Bold(true),
+		StationsTableStyle: stationsTableStyles,
+	}
+}
+
+// Glyphs that go with the colored status cues, so that they don't rely on colors alone.
+const (
+	okGlyph    = "✓"
+	errorGlyph = "✗"
+)
+
+// RenderError renders text as an error, marked with a glyph as well as colored.
+// In accessible mode, the text is left as it is.
+func (t Theme) RenderError(text string) string {
+	if t.Accessible {
+		return text
+	}
+	return t.ErrorText.Render(errorGlyph + " " + text)
+}
+
+// RenderOk renders text as a good status, marked with a glyph as well as colored.
+// In accessible mode, the text is left as it is.
+func (t Theme) RenderOk(text string) string {
+	if t.Accessible {
+		return text
+	}
+	return t.PrimaryText.Render(okGlyph + " " + text)
+}
+
 // StyleBottomBar returns a string representing the styled bottom bar of the given Theme.
 // It takes a slice of strings representing the commands to be displayed in the bottom bar.
 // The function iterates over the commands and applies a different style to each one based on its index.
 // If the index is even, the command is styled with the primary color of the Theme as background.
 // If the index is odd, the command is styled with the secondary color of the Theme as background.
 // The styled commands are concatenated into a single string and returned.
+// In accessible mode, the commands are separated by a vertical bar instead.
 func (t Theme) StyleBottomBar(commands []string) string {
 
+	if t.Accessible {
+		return strings.Join(commands, " | ")
+	}
+
 	var bottomBar string
 	for i, command := range commands {
 		if i%2 == 0 {
@@ -113,3 +300,32 @@ func (t Theme) StyleBottomBar(commands []string) string {
 	return bottomBar
 
 }
+
+// StyleBottomBarWithin styles the bottom bar like StyleBottomBar, leaving out the trailing commands
+// that would not fit in the given width so that the bar never wraps onto a second line.
+// A width of zero means the width is unknown and every command is kept.
+func (t Theme) StyleBottomBarWithin(commands []string, width int) string {
+
+	if width <= 0 {
+		return t.StyleBottomBar(commands)
+	}
+
+	fitting := len(commands)
+	for fitting > 0 && lipgloss.Width(t.StyleBottomBar(commands[:fitting])) > width {
+		fitting--
+	}
+	return t.StyleBottomBar(commands[:fitting])
+
+}
+
+// StyleFlashedBottomBar styles the bottom bar as a whole in the error color, to draw attention to it.
+// Like StyleBottomBarWithin, it never wraps onto a second line.
+func (t Theme) StyleFlashedBottomBar(commands []string, width int) string {
+
+	style := t.ErrorText.Copy().Reverse(true)
+	if width > 0 {
+		style = style.Width(width).MaxWidth(width).MaxHeight(1)
+	}
+	return style.Render(" " + strings.Join(commands, "  "))
+
+}
@@ -0,0 +1,415 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// How much a key turns the hue, in degrees, and changes the saturation and lightness of a color.
+const (
+	themeEditorHueStep        = 10
+	themeEditorSaturationStep = 0.05
+	themeEditorLightnessStep  = 0.05
+)
+
+// themeEditorColor is a color of the theme, as listed in the theme editor.
+type themeEditorColor struct {
+	// label is the i18n key of its name
+	label string
+	value func(colors *config.ThemeColors) *string
+}
+
+var themeEditorColors = []themeEditorColor{
+	{"themeEditor.textColor", func(c *config.ThemeColors) *string { return &c.TextColor }},
+	{"themeEditor.primaryColor", func(c *config.ThemeColors) *string { return &c.PrimaryColor }},
+	{"themeEditor.secondaryColor", func(c *config.ThemeColors) *string { return &c.SecondaryColor }},
+	{"themeEditor.tertiaryColor", func(c *config.ThemeColors) *string { return &c.TertiaryColor }},
+	{"themeEditor.errorColor", func(c *config.ThemeColors) *string { return &c.ErrorColor }},
+}
+
+// Colors
+
+// parseHexColor returns the red, green and blue components, from 0 to 1, of a "#rrggbb" or "#rgb" color.
+func parseHexColor(color string) (float64, float64, float64, bool) {
+	hex := strings.TrimPrefix(strings.TrimSpace(color), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return float64(value>>16&0xff) / 255, float64(value>>8&0xff) / 255, float64(value&0xff) / 255, true
+}
+
+// formatHexColor returns the "#rrggbb" color of the given components, from 0 to 1.
+func formatHexColor(r, g, b float64) string {
+	component := func(value float64) int {
+		return int(math.Round(math.Max(0, math.Min(1, value)) * 255))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", component(r), component(g), component(b))
+}
+
+// rgbToHSL returns the hue, in degrees, the saturation and the lightness of a color.
+func rgbToHSL(r, g, b float64) (float64, float64, float64) {
+	high, low := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	l := (high + low) / 2
+	if high == low {
+		return 0, 0, l
+	}
+	d := high - low
+	s := d / (1 - math.Abs(2*l-1))
+	var h float64
+	switch high {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, s, l
+}
+
+// hslToRGB returns the red, green and blue components of a color given by its hue, saturation and lightness.
+func hslToRGB(h, s, l float64) (float64, float64, float64) {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return r + m, g + m, b + m
+}
+
+// adjustColor returns color with its hue turned by dh degrees, and its saturation and lightness changed by ds and dl.
+// A color that isn't given in hex, such as an ANSI color number, is adjusted from mid gray.
+func adjustColor(color string, dh, ds, dl float64) string {
+	r, g, b, ok := parseHexColor(color)
+	if !ok {
+		r, g, b = 0.5, 0.5, 0.5
+	}
+	h, s, l := rgbToHSL(r, g, b)
+	h = math.Mod(h+dh+360, 360)
+	s = math.Max(0, math.Min(1, s+ds))
+	l = math.Max(0, math.Min(1, l+dl))
+	return formatHexColor(hslToRGB(h, s, l))
+}
+
+// Messages
+
+// themeEditorRequestedMsg asks the root model to open the theme editor over the current view.
+type themeEditorRequestedMsg struct{}
+
+// closeThemeEditorMsg closes the theme editor, leaving the theme as it was.
+type closeThemeEditorMsg struct{}
+
+// themeColorsEditedMsg asks to draw the interface with colors, and to save them to the configuration.
+type themeColorsEditedMsg struct {
+	colors config.ThemeColors
+}
+
+// themeColorsSavedMsg tells that the edited colors were saved to the configuration file at path.
+type themeColorsSavedMsg struct {
+	path string
+}
+
+// Commands
+
+func showThemeEditorCmd() tea.Msg {
+	return themeEditorRequestedMsg{}
+}
+
+func saveThemeColorsCmd(save func(colors config.ThemeColors) error, colors config.ThemeColors) tea.Cmd {
+	return func() tea.Msg {
+		if err := save(colors); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return themeColorsSavedMsg{path: config.ConfigFile()}
+	}
+}
+
+// Model
+
+// ThemeEditorModel tweaks the colors of the theme, previewing every style of the theme drawn with them
+// as they change, and saves them to the configuration. It's opened over the view it was requested from,
+// like the liked tracks.
+type ThemeEditorModel struct {
+	// theme frames the editor, while preview is drawn with the colors being edited
+	theme   Theme
+	preview Theme
+	colors  config.ThemeColors
+	cursor  int
+	// preset is the built-in theme picked last with "p", -1 if none
+	preset int
+	// The color being typed in hex, after "e"
+	typing bool
+	input  textinput.Model
+	err    string
+	width  int
+	height int
+}
+
+// NewThemeEditorModel returns a ThemeEditorModel starting from colors.
+func NewThemeEditorModel(theme Theme, colors config.ThemeColors) ThemeEditorModel {
+	input := textinput.New()
+	input.Prompt = i18n.T("themeEditor.hexPrompt") + " "
+	input.PromptStyle = theme.SecondaryText
+	input.TextStyle = theme.Text
+	input.Placeholder = "#5a4f9f"
+	input.CharLimit = 7
+
+	colors.ColorBlindMode = config.ColorBlindOff
+	colors.File = ""
+	m := ThemeEditorModel{
+		theme:  theme,
+		colors: colors,
+		preset: -1,
+		input:  input,
+	}
+	m.repaint()
+	return m
+}
+
+// repaint draws the preview with the colors being edited.
+func (m *ThemeEditorModel) repaint() {
+	m.preview = newStyledTheme(m.colors, config.ThemeStyles{})
+}
+
+// selected returns the color under the cursor.
+func (m *ThemeEditorModel) selected() *string {
+	return themeEditorColors[m.cursor].value(&m.colors)
+}
+
+func (m ThemeEditorModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m ThemeEditorModel) Update(msg tea.Msg) (ThemeEditorModel, tea.Cmd) {
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.typing {
+		return m.updateTyping(keyMsg)
+	}
+
+	m.err = ""
+	switch keyMsg.String() {
+	case "esc", "q":
+		return m, func() tea.Msg {
+			return closeThemeEditorMsg{}
+		}
+	case "ctrl+s":
+		edited := themeColorsEditedMsg{colors: m.colors}
+		return m, func() tea.Msg {
+			return edited
+		}
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(themeEditorColors)-1 {
+			m.cursor++
+		}
+	case "left", "h":
+		m.adjust(-themeEditorHueStep, 0, 0)
+	case "right", "l":
+		m.adjust(themeEditorHueStep, 0, 0)
+	case "[":
+		m.adjust(0, -themeEditorSaturationStep, 0)
+	case "]":
+		m.adjust(0, themeEditorSaturationStep, 0)
+	case "-":
+		m.adjust(0, 0, -themeEditorLightnessStep)
+	case "+", "=":
+		m.adjust(0, 0, themeEditorLightnessStep)
+	case "p":
+		names := config.ThemePresetNames()
+		m.preset = (m.preset + 1) % len(names)
+		m.colors = config.ThemePresets[names[m.preset]]
+		m.repaint()
+	case "e", "enter":
+		m.typing = true
+		m.input.SetValue(*m.selected())
+		m.input.CursorEnd()
+		return m, m.input.Focus()
+	}
+
+	return m, nil
+}
+
+// adjust changes the color under the cursor, see adjustColor.
+func (m *ThemeEditorModel) adjust(dh, ds, dl float64) {
+	color := m.selected()
+	*color = adjustColor(*color, dh, ds, dl)
+	m.repaint()
+}
+
+func (m ThemeEditorModel) updateTyping(msg tea.KeyMsg) (ThemeEditorModel, tea.Cmd) {
+
+	switch msg.String() {
+	case "esc":
+		m.typing = false
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		value := strings.TrimSpace(m.input.Value())
+		if !strings.HasPrefix(value, "#") {
+			value = "#" + value
+		}
+		r, g, b, ok := parseHexColor(value)
+		if !ok {
+			m.err = i18n.Tf("themeEditor.invalidColor", m.input.Value())
+			return m, nil
+		}
+		*m.selected() = formatHexColor(r, g, b)
+		m.err = ""
+		m.typing = false
+		m.input.Blur()
+		m.repaint()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// commands are shown in the bottom bar while the theme editor is open.
+func (m ThemeEditorModel) commands() []string {
+	if m.typing {
+		return []string{i18n.T("commands.save"), i18n.T("commands.cancel")}
+	}
+	return []string{
+		i18n.T("commands.pickColor"),
+		i18n.T("commands.adjustColor"),
+		i18n.T("commands.typeColor"),
+		i18n.T("commands.themePreset"),
+		i18n.T("commands.saveTheme"),
+		i18n.T("commands.back"),
+	}
+}
+
+func (m ThemeEditorModel) View() string {
+
+	v := m.theme.SecondaryText.Bold(true).Render(i18n.T("themeEditor.title")) + "\n\n"
+
+	for i, color := range themeEditorColors {
+		value := *color.value(&m.colors)
+		label := fmt.Sprintf("%-12s %-8s", i18n.T(color.label), value)
+		swatch := ""
+		if !m.theme.Accessible {
+			swatch = " " + lipgloss.NewStyle().Background(lipgloss.Color(value)).Render("      ")
+		}
+		switch {
+		case m.theme.Accessible && i == m.cursor:
+			v += ">>> " + label + "\n"
+		case m.theme.Accessible:
+			v += "    " + label + "\n"
+		case i == m.cursor:
+			v += m.theme.PrimaryBlock.Render(" "+label+" ") + swatch + "\n"
+		default:
+			v += m.theme.Text.Render(" "+label+" ") + swatch + "\n"
+		}
+	}
+	if m.typing {
+		v += "\n" + m.input.View() + "\n"
+	}
+	if m.err != "" {
+		v += "\n" + m.theme.RenderError(m.err) + "\n"
+	}
+
+	v += "\n" + m.theme.SecondaryText.Bold(true).Render(i18n.T("themeEditor.preview")) + "\n\n"
+	v += m.previewView() + "\n"
+	v += m.theme.TertiaryText.Render(i18n.T("themeEditor.hint")) + "\n"
+
+	return v
+}
+
+// previewView shows every style of the theme, drawn with the colors being edited.
+func (m ThemeEditorModel) previewView() string {
+
+	p := m.preview
+	v := p.PrimaryBlock.Render(i18n.T("themeEditor.primaryBlock")) + " " + p.SecondaryBlock.Render(i18n.T("themeEditor.secondaryBlock")) + "\n"
+	v += p.Text.Render(i18n.T("themeEditor.text")) + "  " +
+		p.PrimaryText.Render(i18n.T("themeEditor.primaryText")) + "  " +
+		p.SecondaryText.Render(i18n.T("themeEditor.secondaryText")) + "  " +
+		p.TertiaryText.Render(i18n.T("themeEditor.tertiaryText")) + "\n"
+	v += p.RenderOk(i18n.T("themeEditor.ok")) + "  " + p.RenderError(i18n.T("themeEditor.error")) + "\n\n"
+
+	stations := table.New(
+		table.WithColumns([]table.Column{
+			{Title: i18n.T("stations.column.name"), Width: 20},
+			{Title: i18n.T("stations.column.country"), Width: 10},
+			{Title: i18n.T("stations.column.codecs"), Width: 10},
+		}),
+		table.WithRows([]table.Row{
+			{"Radio GoGo", "IT", "MP3"},
+			{"Jazz FM", "GB", "AAC"},
+			{"Lo-fi Beats", "FR", "OGG"},
+		}),
+		table.WithHeight(4),
+		table.WithFocused(true),
+	)
+	stations.SetStyles(p.StationsTableStyle)
+	v += stations.View() + "\n\n"
+
+	v += p.StyleBottomBar([]string{i18n.T("commands.quit"), i18n.T("commands.search"), i18n.T("commands.tags"), i18n.T("commands.charts")}) + "\n"
+	return v
+}
+
+func (m *ThemeEditorModel) SetWidthAndHeight(width int, height int) {
+	m.width = width
+	m.height = height
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdjustColor(t *testing.T) {
+
+	t.Run("turns the hue", func(t *testing.T) {
+		assert.Equal(t, "#00ff00", adjustColor("#ff0000", 120, 0, 0))
+		assert.Equal(t, "#ff0000", adjustColor("#0000ff", 120, 0, 0))
+		assert.Equal(t, "#ff00ff", adjustColor("#ff0000", -60, 0, 0))
+	})
+
+	t.Run("changes the lightness and saturation within bounds", func(t *testing.T) {
+		assert.Equal(t, "#ffffff", adjustColor("#ff0000", 0, 0, 1))
+		assert.Equal(t, "#000000", adjustColor("#ff0000", 0, 0, -1))
+		assert.Equal(t, "#808080", adjustColor("#ff0000", 0, -1, 0))
+	})
+
+	t.Run("keeps a color it doesn't change", func(t *testing.T) {
+		assert.Equal(t, "#5a4f9f", adjustColor("#5a4f9f", 0, 0, 0))
+		assert.Equal(t, "#5a4f9f", adjustColor("#5A4F9F", 360, 0, 0))
+	})
+
+	t.Run("adjusts colors that aren't in hex from gray", func(t *testing.T) {
+		assert.Equal(t, "#999999", adjustColor("5", 0, 0, 0.1))
+	})
+
+}
+
+func TestParseHexColor(t *testing.T) {
+
+	r, g, b, ok := parseHexColor("#f80")
+	assert.True(t, ok)
+	assert.Equal(t, "#ff8800", formatHexColor(r, g, b))
+
+	_, _, _, ok = parseHexColor("#12345g")
+	assert.False(t, ok)
+	_, _, _, ok = parseHexColor("red")
+	assert.False(t, ok)
+
+}
+
+func TestThemeEditorModel_Update(t *testing.T) {
+
+	key := func(model ThemeEditorModel, keys ...string) (ThemeEditorModel, tea.Cmd) {
+		var cmd tea.Cmd
+		for _, k := range keys {
+			var msg tea.KeyMsg
+			switch k {
+			case "down":
+				msg = tea.KeyMsg{Type: tea.KeyDown}
+			case "right":
+				msg = tea.KeyMsg{Type: tea.KeyRight}
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			case "ctrl+s":
+				msg = tea.KeyMsg{Type: tea.KeyCtrlS}
+			case "ctrl+u":
+				msg = tea.KeyMsg{Type: tea.KeyCtrlU}
+			default:
+				msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+			}
+			model, cmd = model.Update(msg)
+		}
+		return model, cmd
+	}
+
+	t.Run("adjusts the color under the cursor and previews it", func(t *testing.T) {
+
+		model := NewThemeEditorModel(Theme{}, config.ThemeColors{TextColor: "#ffffff", PrimaryColor: "#ff0000"})
+
+		model, _ = key(model, "down", "right", "right")
+
+		assert.Equal(t, adjustColor(adjustColor("#ff0000", themeEditorHueStep, 0, 0), themeEditorHueStep, 0, 0), model.colors.PrimaryColor)
+		assert.NotEqual(t, "#ff0000", model.colors.PrimaryColor)
+		assert.Equal(t, "#ffffff", model.colors.TextColor)
+		assert.Equal(t, newStyledTheme(model.colors, config.ThemeStyles{}).PrimaryText.Render("x"), model.preview.PrimaryText.Render("x"))
+
+	})
+
+	t.Run("takes a color typed in hex", func(t *testing.T) {
+
+		model := NewThemeEditorModel(Theme{}, config.DefaultTheme)
+
+		model, _ = key(model, "e", "ctrl+u", "1", "2", "3", "enter")
+
+		assert.False(t, model.typing)
+		assert.Equal(t, "#112233", model.colors.TextColor)
+
+	})
+
+	t.Run("keeps typing a color that isn't in hex", func(t *testing.T) {
+
+		model := NewThemeEditorModel(Theme{}, config.DefaultTheme)
+
+		model, _ = key(model, "e", "ctrl+u", "z", "enter")
+
+		assert.True(t, model.typing)
+		assert.NotEmpty(t, model.err)
+		assert.Equal(t, config.DefaultTheme.TextColor, model.colors.TextColor)
+
+	})
+
+	t.Run("starts from a built-in theme", func(t *testing.T) {
+
+		model := NewThemeEditorModel(Theme{}, config.ThemeColors{})
+
+		model, _ = key(model, "p")
+
+		assert.Equal(t, config.ThemePresets[config.ThemePresetNames()[0]], model.colors)
+
+	})
+
+	t.Run("asks to save the colors", func(t *testing.T) {
+
+		model := NewThemeEditorModel(Theme{}, config.DefaultTheme)
+
+		_, cmd := key(model, "ctrl+s")
+
+		assert.Equal(t, themeColorsEditedMsg{colors: config.DefaultTheme}, cmd())
+
+	})
+
+}
+
+func TestModel_ThemeEditor(t *testing.T) {
+
+	t.Run("draws the interface with the edited colors and saves them", func(t *testing.T) {
+
+		model := NewModel(config.Config{}, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{}, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		var saved config.ThemeColors
+		model.saveThemeColors = func(colors config.ThemeColors) error {
+			saved = colors
+			return nil
+		}
+		colors := config.ThemePresets["nord"]
+
+		updated, _ := model.Update(themeEditorRequestedMsg{})
+		assert.True(t, updated.(Model).themeEditorShown())
+
+		updated, cmd := updated.Update(themeColorsEditedMsg{colors: colors})
+		model = updated.(Model)
+		cmd()
+
+		assert.False(t, model.themeEditorShown())
+		assert.Equal(t, colors, model.themeColors)
+		assert.Equal(t, colors, saved)
+		assert.Equal(t, newStyledTheme(colors, config.ThemeStyles{}).PrimaryText.Render("x"), model.theme.PrimaryText.Render("x"))
+
+	})
+
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTheme(t *testing.T) {
+
+	t.Run("returns an accessible theme if accessible mode is enabled", func(t *testing.T) {
+
+		cfg := config.NewDefaultConfig()
+		cfg.Accessible = true
+
+		theme := NewTheme(cfg)
+
+		assert.True(t, theme.Accessible)
+
+	})
+
+	t.Run("returns a regular theme by default", func(t *testing.T) {
+
+		theme := NewTheme(config.NewDefaultConfig())
+
+		assert.False(t, theme.Accessible)
+
+	})
+
+	t.Run("draws the styles of the theme file over the colors", func(t *testing.T) {
+
+		assert.NoError(t, config.SetConfigDir(t.TempDir()))
+		defer config.SetConfigDir("")
+		assert.NoError(t, os.MkdirAll(config.ThemesDir(), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(config.ThemesDir(), "neon.yaml"), []byte(
+			"colors:\n  errorColor: '#ff8800'\nstyles:\n  primaryText:\n    bold: true\n    italic: true\n  tableHeader:\n    border: none\n",
+		), 0644))
+		cfg := config.NewDefaultConfig()
+		cfg.Theme.File = "neon"
+
+		theme := NewTheme(cfg)
+
+		assert.Equal(t, lipgloss.Color("#ff8800"), theme.ErrorText.GetForeground())
+		assert.True(t, theme.PrimaryText.GetBold())
+		assert.True(t, theme.PrimaryText.GetItalic())
+		assert.Equal(t, lipgloss.Color(config.DefaultTheme.PrimaryColor), theme.PrimaryText.GetForeground())
+		assert.False(t, theme.StationsTableStyle.Header.GetBorderBottom())
+		assert.Equal(t, NewTheme(config.NewDefaultConfig()).SecondaryBlock.GetPaddingLeft(), theme.SecondaryBlock.GetPaddingLeft())
+
+	})
+
+	t.Run("ignores a theme file that can't be loaded", func(t *testing.T) {
+
+		assert.NoError(t, config.SetConfigDir(t.TempDir()))
+		defer config.SetConfigDir("")
+		cfg := config.NewDefaultConfig()
+		cfg.Theme.File = "missing"
+
+		theme := NewTheme(cfg)
+
+		assert.Equal(t, lipgloss.Color(config.DefaultTheme.ErrorColor), theme.ErrorText.GetForeground())
+
+	})
+
+}
+
+func TestTheme_StyleBottomBar(t *testing.T) {
+
+	t.Run("separates commands with a vertical bar in accessible mode", func(t *testing.T) {
+
+		theme := NewAccessibleTheme()
+
+		assert.Equal(t, "q: quit | s: search", theme.StyleBottomBar([]string{"q: quit", "s: search"}))
+
+	})
+
+}
+
+func TestTheme_StyleBottomBarWithin(t *testing.T) {
+
+	t.Run("keeps every command that fits", func(t *testing.T) {
+
+		theme := NewTheme(config.NewDefaultConfig())
+
+		commands := []string{"q: quit", "s: search"}
+
+		assert.Equal(t, theme.StyleBottomBar(commands), theme.StyleBottomBarWithin(commands, 80))
+
+	})
+
+	t.Run("leaves out the trailing commands that don't fit", func(t *testing.T) {
+
+		theme := NewTheme(config.NewDefaultConfig())
+
+		commands := []string{"q: quit", "s: search", "b: bookmarks"}
+		width := lipgloss.Width(theme.StyleBottomBar(commands[:2]))
+
+		assert.Equal(t, theme.StyleBottomBar(commands[:2]), theme.StyleBottomBarWithin(commands, width))
+
+	})
+
+}
+
+func TestTheme_StatusCues(t *testing.T) {
+
+	t.Run("marks errors and good statuses with glyphs", func(t *testing.T) {
+
+		theme := NewTheme(config.NewDefaultConfig())
+
+		assert.Equal(t, theme.ErrorText.Render("✗ offline"), theme.RenderError("offline"))
+		assert.Equal(t, theme.PrimaryText.Render("✓ online"), theme.RenderOk("online"))
+
+	})
+
+	t.Run("leaves statuses as they are in accessible mode", func(t *testing.T) {
+
+		theme := NewAccessibleTheme()
+
+		assert.Equal(t, "offline", theme.RenderError("offline"))
+		assert.Equal(t, "online", theme.RenderOk("online"))
+
+	})
+
+	t.Run("uses the color-blind palette", func(t *testing.T) {
+
+		cfg := config.NewDefaultConfig()
+		cfg.Theme.ColorBlindMode = config.ColorBlindDeuteranopia
+
+		theme := NewTheme(cfg)
+
+		assert.Equal(t, lipgloss.Color("#e69f00"), theme.ErrorText.GetForeground())
+		assert.Equal(t, lipgloss.Color("#0072b2"), theme.PrimaryBlock.GetBackground())
+
+	})
+
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How long a toast is shown before it's dismissed.
+const toastDuration = 3 * time.Second
+
+// toastKind tells how a toast is styled.
+type toastKind int
+
+const (
+	toastInfo toastKind = iota
+	toastSuccess
+	toastError
+)
+
+// Messages
+
+// toastMsg asks to show a transient message above the bottom bar, whatever the current view.
+type toastMsg struct {
+	text string
+	kind toastKind
+}
+
+// toastExpiredMsg dismisses the toast, unless another one was shown since, as told by id.
+type toastExpiredMsg struct {
+	id int
+}
+
+// Commands
+
+// showToastCmd shows text in a toast styled after kind, e.g. "Bookmarked Jazz FM" as a success.
+func showToastCmd(text string, kind toastKind) tea.Cmd {
+	return func() tea.Msg {
+		return toastMsg{text: text, kind: kind}
+	}
+}
+
+// Model
+
+// ToastModel shows the latest toast until it expires. A new toast replaces the one being shown.
+type ToastModel struct {
+	theme Theme
+	text  string
+	kind  toastKind
+	id    int
+}
+
+func NewToastModel(theme Theme) ToastModel {
+	return ToastModel{theme: theme}
+}
+
+// Height returns the number of lines taken by the toast (0 when none is shown).
+func (m ToastModel) Height() int {
+	if m.text == "" {
+		return 0
+	}
+	return 1
+}
+
+// Bubbletea
+
+func (m ToastModel) Update(msg tea.Msg) (ToastModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case toastMsg:
+		m.text = msg.text
+		m.kind = msg.kind
+		m.id++
+		id := m.id
+		return m, tea.Tick(toastDuration, func(t time.Time) tea.Msg {
+			return toastExpiredMsg{id: id}
+		})
+	case toastExpiredMsg:
+		if msg.id == m.id {
+			m.text = ""
+		}
+	}
+	return m, nil
+}
+
+func (m ToastModel) View() string {
+	if m.text == "" {
+		return ""
+	}
+	switch m.kind {
+	case toastSuccess:
+		return m.theme.RenderOk(m.text) + "\n"
+	case toastError:
+		return m.theme.RenderError(m.text) + "\n"
+	}
+	if m.theme.Accessible {
+		return m.text + "\n"
+	}
+	return m.theme.SecondaryText.Bold(true).Render(m.text) + "\n"
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToastModel(t *testing.T) {
+
+	t.Run("shows the latest toast until it expires", func(t *testing.T) {
+
+		model := NewToastModel(Theme{})
+		assert.Equal(t, 0, model.Height())
+
+		model, _ = model.Update(toastMsg{text: "Bookmarked Jazz FM", kind: toastSuccess})
+		first := model.id
+		model, _ = model.Update(toastMsg{text: "Voted for Jazz FM", kind: toastSuccess})
+		assert.Contains(t, model.View(), "Voted for Jazz FM")
+		assert.Equal(t, 1, model.Height())
+
+		// The first toast expiring doesn't dismiss the second
+		model, _ = model.Update(toastExpiredMsg{id: first})
+		assert.Contains(t, model.View(), "Voted for Jazz FM")
+
+		model, _ = model.Update(toastExpiredMsg{id: model.id})
+		assert.Equal(t, "", model.View())
+
+	})
+
+	t.Run("marks errors", func(t *testing.T) {
+
+		model, _ := NewToastModel(Theme{}).Update(toastMsg{text: "can't copy", kind: toastError})
+
+		assert.Contains(t, model.View(), errorGlyph+" can't copy")
+
+	})
+
+}
+
+func TestModelToasts(t *testing.T) {
+
+	newModel := func() Model {
+		model := NewModel(config.Config{}, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{}, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		model.width = 80
+		model.height = 24
+		return model
+	}
+
+	t.Run("shows toasts above the bottom bar, whatever the view", func(t *testing.T) {
+
+		model := newModel()
+
+		updated, cmd := model.Update(toastMsg{text: "Copied", kind: toastSuccess})
+
+		assert.NotNil(t, cmd)
+		assert.Contains(t, updated.(Model).View(), "Copied")
+		assert.Equal(t, 1, updated.(Model).panesHeight())
+
+	})
+
+	t.Run("doesn't pass toasts on to the views", func(t *testing.T) {
+
+		model := newModel()
+		model.state = stationsState
+
+		_, cmd := model.Update(toastExpiredMsg{id: 1})
+
+		assert.Nil(t, cmd)
+
+	})
+
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/enrich"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// How many tracks are looked up once only, stations often playing the same tracks over and over.
+const trackDetailsCacheSize = 500
+
+// TrackDetailsModel shows the album and release year of the track being played, looked up by an enricher
+// from the track title announced by the stream.
+// The root model feeds it the titles and draws its pane above the bottom bar.
+type TrackDetailsModel struct {
+	theme    Theme
+	enricher enrich.Enricher
+
+	// nil until the details of the current track are known
+	details *enrich.Details
+	// Incremented whenever the track changes, so that stale lookups are ignored
+	generation int
+}
+
+// NewTrackDetailsModel returns a TrackDetailsModel looking tracks up with enricher, which does nothing if it's nil.
+func NewTrackDetailsModel(theme Theme, enricher enrich.Enricher) TrackDetailsModel {
+	return TrackDetailsModel{
+		theme:    theme,
+		enricher: enricher,
+	}
+}
+
+// Messages
+
+type trackEnrichedMsg struct {
+	generation int
+	details    enrich.Details
+	err        error
+}
+
+// Commands
+
+func enrichTrackCmd(enricher enrich.Enricher, generation int, track enrich.Track) tea.Cmd {
+	return func() tea.Msg {
+		details, err := enricher.Enrich(track)
+		return trackEnrichedMsg{generation: generation, details: details, err: err}
+	}
+}
+
+// Model
+
+// SetTitle looks up the track of the given stream title, clearing the pane meanwhile.
+// An empty title (nothing playing, or no title announced) just clears it.
+func (m TrackDetailsModel) SetTitle(title string) (TrackDetailsModel, tea.Cmd) {
+	if m.enricher == nil {
+		return m, nil
+	}
+	m.generation++
+	m.details = nil
+	track, ok := enrich.ParseTitle(title)
+	if !ok {
+		return m, nil
+	}
+	return m, enrichTrackCmd(m.enricher, m.generation, track)
+}
+
+func (m TrackDetailsModel) Update(msg tea.Msg) (TrackDetailsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case trackEnrichedMsg:
+		if msg.generation != m.generation {
+			return m, nil
+		}
+		// The details are a nicety: tracks that can't be looked up just show none
+		if msg.err == nil {
+			details := msg.details
+			m.details = &details
+		}
+	}
+	return m, nil
+}
+
+// View returns the track details pane, or an empty string when there is nothing to show.
+func (m TrackDetailsModel) View() string {
+	if m.details == nil {
+		return ""
+	}
+	var parts []string
+	if m.details.Album != "" {
+		parts = append(parts, i18n.Tf("track.album", m.details.Album))
+	}
+	if m.details.Year > 0 {
+		parts = append(parts, fmt.Sprintf("%d", m.details.Year))
+	}
+	if m.details.Artist != "" {
+		parts = append(parts, m.details.Artist)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return m.theme.SecondaryText.Render(strings.Join(parts, " · ")) + "\n"
+}
+
+// Height returns the number of lines taken by the pane.
+func (m TrackDetailsModel) Height() int {
+	view := m.View()
+	if view == "" {
+		return 0
+	}
+	return lipgloss.Height(view) - 1
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/enrich"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// enricherFunc adapts a function to enrich.Enricher.
+type enricherFunc func(track enrich.Track) (enrich.Details, error)
+
+func (f enricherFunc) Enrich(track enrich.Track) (enrich.Details, error) {
+	return f(track)
+}
+
+func TestTrackDetailsModel(t *testing.T) {
+
+	okComputer := enricherFunc(func(track enrich.Track) (enrich.Details, error) {
+		return enrich.Details{Artist: track.Artist, Title: track.Title, Album: "OK Computer", Year: 1997}, nil
+	})
+
+	t.Run("does nothing without an enricher", func(t *testing.T) {
+
+		model := NewTrackDetailsModel(Theme{}, nil)
+
+		model, cmd := model.SetTitle("Radiohead - Karma Police")
+
+		assert.Nil(t, cmd)
+		assert.Equal(t, 0, model.Height())
+
+	})
+
+	t.Run("shows the album, year and artist of the track", func(t *testing.T) {
+
+		model := NewTrackDetailsModel(Theme{}, okComputer)
+
+		model, cmd := model.SetTitle("Radiohead - Karma Police")
+		model, _ = model.Update(cmd())
+
+		assert.Equal(t, "Album: OK Computer · 1997 · Radiohead\n", model.View())
+		assert.Equal(t, 1, model.Height())
+
+	})
+
+	t.Run("doesn't look up titles that aren't tracks", func(t *testing.T) {
+
+		model := NewTrackDetailsModel(Theme{}, okComputer)
+
+		_, cmd := model.SetTitle("You're listening to Jazz FM")
+
+		assert.Nil(t, cmd)
+
+	})
+
+	t.Run("ignores the lookups of previous tracks", func(t *testing.T) {
+
+		model := NewTrackDetailsModel(Theme{}, okComputer)
+
+		model, cmd := model.SetTitle("Radiohead - Karma Police")
+		stale := cmd()
+		model, _ = model.SetTitle("")
+		model, _ = model.Update(stale)
+
+		assert.Empty(t, model.View())
+
+	})
+
+	t.Run("shows nothing for tracks that can't be looked up", func(t *testing.T) {
+
+		model := NewTrackDetailsModel(Theme{}, enricherFunc(func(track enrich.Track) (enrich.Details, error) {
+			return enrich.Details{}, errors.New("timeout")
+		}))
+
+		model, cmd := model.SetTitle("Radiohead - Karma Police")
+		model, _ = model.Update(cmd())
+
+		assert.Empty(t, model.View())
+
+	})
+
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxUndoActions is how many actions can be undone in a row; older ones are forgotten.
+const maxUndoActions = 20
+
+// Messages
+
+// undoableMsg tells that a destructive action was carried out, e.g. removing a bookmark.
+// It's toasted along with a hint to press "u", which runs undo.
+type undoableMsg struct {
+	// text describes the action, e.g. "Removed Jazz FM from the bookmarks"
+	text string
+	undo tea.Cmd
+}
+
+// undoRequestedMsg asks to undo the latest action, whatever view it happened in.
+type undoRequestedMsg struct{}
+
+// bookmarkRestoredMsg tells that a removed bookmark was put back.
+type bookmarkRestoredMsg struct {
+	station common.Station
+}
+
+// queuedStationRestoredMsg asks to put a station removed from the queue back at index.
+type queuedStationRestoredMsg struct {
+	index   int
+	station common.Station
+}
+
+// Commands
+
+func undoCmd() tea.Msg {
+	return undoRequestedMsg{}
+}
+
+func restoreBookmarkCmd(bookmarkStore storage.BookmarkStore, bookmark storage.RemovedBookmark) tea.Cmd {
+	return func() tea.Msg {
+		if err := bookmarkStore.Restore(bookmark); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return bookmarkRestoredMsg{station: bookmark.Station}
+	}
+}
+
+// bookmarkRemovedUndoableCmd tells that the bookmark of the station with the given name was removed,
+// which can be undone by restoring it.
+func bookmarkRemovedUndoableCmd(bookmarkStore storage.BookmarkStore, bookmark storage.RemovedBookmark, name string) tea.Cmd {
+	return func() tea.Msg {
+		return undoableMsg{
+			text: i18n.Tf("bookmarks.removed", name),
+			undo: restoreBookmarkCmd(bookmarkStore, bookmark),
+		}
+	}
+}
+
+// Model
+
+// updateUndo remembers the undoable actions of the session and undoes the latest one when asked.
+// Both are toasted, so it returns the toastMsg to handle in place of msg, along with the undo command.
+func (m Model) updateUndo(msg tea.Msg) (Model, tea.Msg, tea.Cmd) {
+	switch msg := msg.(type) {
+	case undoableMsg:
+		m.undoStack = append(m.undoStack, msg)
+		if len(m.undoStack) > maxUndoActions {
+			m.undoStack = m.undoStack[len(m.undoStack)-maxUndoActions:]
+		}
+		return m, toastMsg{text: i18n.Tf("undo.hint", msg.text), kind: toastSuccess}, nil
+	case undoRequestedMsg:
+		if len(m.undoStack) == 0 {
+			return m, toastMsg{text: i18n.T("undo.nothing"), kind: toastInfo}, nil
+		}
+		action := m.undoStack[len(m.undoStack)-1]
+		m.undoStack = m.undoStack[:len(m.undoStack)-1]
+		return m, toastMsg{text: i18n.Tf("undo.done", action.text), kind: toastInfo}, action.undo
+	}
+	return m, msg, nil
+}
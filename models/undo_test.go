@@ -0,0 +1,133 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelUndo(t *testing.T) {
+
+	newModel := func(bookmarkStore storage.BookmarkStore) Model {
+		model := NewModel(config.Config{}, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{}, &mocks.MockLabelStore{}, bookmarkStore, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		model.width = 80
+		model.height = 24
+		return model
+	}
+
+	t.Run("toasts undoable actions with a hint", func(t *testing.T) {
+
+		model := newModel(&mocks.MockBookmarkStore{})
+
+		updated, _ := model.Update(undoableMsg{text: "Removed Jazz FM from the bookmarks"})
+
+		assert.Contains(t, updated.(Model).View(), "Removed Jazz FM from the bookmarks · Undo (u)")
+
+	})
+
+	t.Run("undoes the latest action first", func(t *testing.T) {
+
+		var undone []string
+		undo := func(name string) tea.Cmd {
+			return func() tea.Msg {
+				undone = append(undone, name)
+				return nil
+			}
+		}
+		model := newModel(&mocks.MockBookmarkStore{})
+		model, _, _ = model.updateUndo(undoableMsg{text: "first", undo: undo("first")})
+		model, _, _ = model.updateUndo(undoableMsg{text: "second", undo: undo("second")})
+
+		model, toast, cmd := model.updateUndo(undoRequestedMsg{})
+		cmd()
+		assert.Equal(t, toastMsg{text: "Undone: second", kind: toastInfo}, toast)
+		model, _, cmd = model.updateUndo(undoRequestedMsg{})
+		cmd()
+		_, toast, cmd = model.updateUndo(undoRequestedMsg{})
+
+		assert.Equal(t, []string{"second", "first"}, undone)
+		assert.Equal(t, toastMsg{text: "Nothing to undo", kind: toastInfo}, toast)
+		assert.Nil(t, cmd)
+
+	})
+
+	t.Run("forgets the oldest actions", func(t *testing.T) {
+
+		model := newModel(&mocks.MockBookmarkStore{})
+		for i := 0; i < maxUndoActions+5; i++ {
+			updated, _ := model.Update(undoableMsg{text: "removed"})
+			model = updated.(Model)
+		}
+
+		assert.Len(t, model.undoStack, maxUndoActions)
+
+	})
+
+	t.Run("restores a removed bookmark where it was", func(t *testing.T) {
+
+		station := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+		var restored storage.RemovedBookmark
+		bookmarkStore := &mocks.MockBookmarkStore{
+			RestoreFunc: func(bookmark storage.RemovedBookmark) error {
+				restored = bookmark
+				return nil
+			},
+		}
+		model := newModel(bookmarkStore)
+		removed := storage.RemovedBookmark{Station: station, Meta: storage.BookmarkMeta{Folder: "Jazz"}}
+
+		model, _, _ = model.updateUndo(bookmarkRemovedUndoableCmd(bookmarkStore, removed, "Jazz FM")())
+		_, _, cmd := model.updateUndo(undoRequestedMsg{})
+
+		assert.Equal(t, bookmarkRestoredMsg{station: station}, cmd())
+		assert.Equal(t, removed, restored)
+
+	})
+
+	t.Run("puts a station removed from the queue back in its place", func(t *testing.T) {
+
+		first := common.Station{StationUuid: uuid.New(), Name: "First"}
+		second := common.Station{StationUuid: uuid.New(), Name: "Second"}
+		model := newModel(&mocks.MockBookmarkStore{})
+		model.queue.add(first)
+		model.queue.add(second)
+
+		queueModel := NewQueueModel(Theme{}, model.queue, &mocks.MockLabelStore{})
+		_, cmd := queueModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+		assert.Equal(t, []common.Station{second}, model.queue.stations)
+
+		model, _, _ = model.updateUndo(cmd())
+		model, _, cmd = model.updateUndo(undoRequestedMsg{})
+		model.update(cmd())
+
+		assert.Equal(t, []common.Station{first, second}, model.queue.stations)
+
+	})
+
+}
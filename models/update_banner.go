@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/updates"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Messages
+
+// updateCheckedMsg tells what checking for a newer release of RadioGoGo found.
+type updateCheckedMsg struct {
+	release updates.Release
+	newer   bool
+	err     error
+}
+
+// Commands
+
+func checkForUpdateCmd(checker updates.CheckerService) tea.Cmd {
+	return func() tea.Msg {
+		release, newer, err := updates.Check(checker)
+		return updateCheckedMsg{release: release, newer: newer, err: err}
+	}
+}
+
+// Model
+
+// UpdateBannerModel shows a newer release of RadioGoGo, with the summary of its changelog and where to download it,
+// above the bottom bar of any view until it's dismissed. Nothing is shown if the check failed.
+type UpdateBannerModel struct {
+	theme   Theme
+	release updates.Release
+	shown   bool
+}
+
+func NewUpdateBannerModel(theme Theme) UpdateBannerModel {
+	return UpdateBannerModel{theme: theme}
+}
+
+// Shown returns true if the banner shows a newer release.
+func (m UpdateBannerModel) Shown() bool {
+	return m.shown
+}
+
+// Dismiss hides the banner.
+func (m UpdateBannerModel) Dismiss() UpdateBannerModel {
+	m.shown = false
+	return m
+}
+
+// Height returns the number of lines taken by the banner (0 when it shows nothing).
+func (m UpdateBannerModel) Height() int {
+	if !m.shown {
+		return 0
+	}
+	return 2
+}
+
+// Bubbletea
+
+func (m UpdateBannerModel) Update(msg tea.Msg) UpdateBannerModel {
+	if msg, ok := msg.(updateCheckedMsg); ok && msg.err == nil && msg.newer {
+		m.release = msg.release
+		m.shown = true
+	}
+	return m
+}
+
+func (m UpdateBannerModel) View() string {
+	if !m.shown {
+		return ""
+	}
+	available := i18n.Tf("updateBanner.available", m.release.Version, m.release.Summary)
+	download := i18n.Tf("updateBanner.download", m.release.URL)
+	if m.theme.Accessible {
+		return available + "\n" + download + "\n"
+	}
+	return m.theme.SecondaryText.Bold(true).Render(available) + "\n" + m.theme.TertiaryText.Render(download) + "\n"
+}
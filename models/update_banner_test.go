@@ -0,0 +1,106 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/updates"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelUpdateBanner(t *testing.T) {
+
+	newModel := func(latest updates.Release, err error) Model {
+		model := NewModel(config.Config{}, &mocks.MockRadioBrowserService{}, &mocks.MockPlaybackManagerService{}, &mocks.MockLabelStore{}, &mocks.MockBookmarkStore{}, &mocks.MockReportStore{}, &mocks.MockProberService{})
+		model.width = 80
+		model.height = 24
+		model.state = bootState
+		model.updateChecker = &mocks.MockUpdateChecker{
+			LatestFunc: func() (updates.Release, error) {
+				return latest, err
+			},
+		}
+		return model
+	}
+
+	release := updates.Release{
+		Version: "99.0.0",
+		URL:     "https://github.com/Zi0P4tch0/RadioGoGo/releases/tag/v99.0.0",
+		Summary: "Update checker",
+	}
+
+	t.Run("shows a newer release above the bottom bar, until dismissed", func(t *testing.T) {
+
+		model := newModel(release, nil)
+		updated, _ := model.Update(checkForUpdateCmd(model.updateChecker)())
+
+		view := updated.(Model).View()
+		assert.Contains(t, view, "RadioGoGo 99.0.0 is out: Update checker")
+		assert.Contains(t, view, release.URL)
+		assert.Equal(t, 2, updated.(Model).panesHeight())
+
+		updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		assert.Nil(t, cmd)
+		assert.False(t, updated.(Model).updateBannerModel.Shown())
+		assert.Equal(t, bootState, updated.(Model).state)
+
+	})
+
+	t.Run("shows nothing when running the latest release", func(t *testing.T) {
+
+		model := newModel(updates.Release{Version: data.Version}, nil)
+		updated, _ := model.Update(checkForUpdateCmd(model.updateChecker)())
+
+		assert.False(t, updated.(Model).updateBannerModel.Shown())
+		assert.Equal(t, 0, updated.(Model).panesHeight())
+
+	})
+
+	t.Run("shows nothing when the check fails", func(t *testing.T) {
+
+		model := newModel(release, errors.New("API rate limit exceeded"))
+		updated, _ := model.Update(checkForUpdateCmd(model.updateChecker)())
+
+		assert.False(t, updated.(Model).updateBannerModel.Shown())
+
+	})
+
+	t.Run("checks on startup only when asked to", func(t *testing.T) {
+
+		model := newModel(release, nil)
+		var msgs []tea.Msg
+		for _, cmd := range model.Init()().(tea.BatchMsg) {
+			msgs = append(msgs, cmd())
+		}
+		assert.Contains(t, msgs, updateCheckedMsg{release: release, newer: true})
+
+		model.updateChecker = nil
+		assert.NotContains(t, []tea.Msg{model.Init()()}, updateCheckedMsg{release: release, newer: true})
+
+	})
+
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/playback"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+// volumeTrimStep is how much "(" and ")" trim the volume of a station by.
+const volumeTrimStep = 5
+
+// maxVolumeTrim bounds the trim of a station either way.
+const maxVolumeTrim = 50
+
+// trimmedVolume returns volume offset by the trim of station,
+// kept within the range of the playback manager (volume itself if trims aren't remembered).
+func trimmedVolume(trims storage.VolumeTrimStore, playbackManager playback.PlaybackManagerService, station common.Station, volume int) int {
+	if trims == nil {
+		return volume
+	}
+	return clampVolume(playbackManager, volume+trims.Trim(station.StationUuid))
+}
+
+// clampVolume keeps volume within the range of the playback manager.
+func clampVolume(playbackManager playback.PlaybackManagerService, volume int) int {
+	if volume < playbackManager.VolumeMin() {
+		return playbackManager.VolumeMin()
+	}
+	if volume > playbackManager.VolumeMax() {
+		return playbackManager.VolumeMax()
+	}
+	return volume
+}
+
+// nextVolumeTrim returns the trim of station once changed by delta, within maxVolumeTrim.
+func nextVolumeTrim(trims storage.VolumeTrimStore, station common.Station, delta int) int {
+	trim := trims.Trim(station.StationUuid) + delta
+	if trim > maxVolumeTrim {
+		return maxVolumeTrim
+	}
+	if trim < -maxVolumeTrim {
+		return -maxVolumeTrim
+	}
+	return trim
+}
+
+// trimVolumeCmd trims the volume of station by delta and toasts its new trim, e.g. "Jazz FM volume trim: +10".
+// If playing, station is the one being played at volume: it's turned up or down straight away when
+// the playback manager supports it, otherwise the toast tells the trim applies the next time it plays.
+func trimVolumeCmd(trims storage.VolumeTrimStore, playbackManager playback.PlaybackManagerService, station common.Station, name string, volume int, delta int, playing bool) tea.Cmd {
+	trim := nextVolumeTrim(trims, station, delta)
+	volumeSetter, live := playbackManager.(playback.VolumeSetter)
+	live = live && playing
+	save := func() tea.Msg {
+		if err := trims.SetTrim(station.StationUuid, trim); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		text := i18n.Tf("volumeTrim.set", name, fmt.Sprintf("%+d", trim))
+		if !live {
+			text += " " + i18n.T("volumeTrim.nextTime")
+		}
+		return toastMsg{text: text, kind: toastSuccess}
+	}
+	if !live {
+		return save
+	}
+	return tea.Batch(save, setVolumeCmd(volumeSetter, clampVolume(playbackManager, volume+trim)))
+}
+
+// SetVolumeTrimStore remembers how much louder or quieter each station plays in store,
+// so that "(" and ")" can even out their loudness (nil doesn't trim them).
+func (m *StationsModel) SetVolumeTrimStore(store storage.VolumeTrimStore) {
+	m.volumeTrims = store
+}
+
+// SetVolumeTrimStore plays bookmarks trimmed by the volume offsets remembered in store,
+// and lets "(" and ")" change them (nil doesn't trim them).
+func (m *BookmarksModel) SetVolumeTrimStore(store storage.VolumeTrimStore) {
+	m.volumeTrims = store
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// newVolumeTrimStore returns an in-memory volume trim store.
+func newVolumeTrimStore() *mocks.MockVolumeTrimStore {
+	trims := map[uuid.UUID]int{}
+	return &mocks.MockVolumeTrimStore{
+		TrimFunc: func(stationUuid uuid.UUID) int {
+			return trims[stationUuid]
+		},
+		SetTrimFunc: func(stationUuid uuid.UUID, trim int) error {
+			trims[stationUuid] = trim
+			return nil
+		},
+	}
+}
+
+func TestStationsModel_VolumeTrims(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+
+	newModel := func(trims *mocks.MockVolumeTrimStore) StationsModel {
+		model := NewStationsModel(
+			Theme{},
+			&mocks.MockRadioBrowserService{},
+			&mocks.MockPlaybackManagerService{VolumeMinResult: 0, VolumeDefaultResult: 80, VolumeMaxResult: 100},
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{},
+			&mocks.MockReportStore{},
+			filter.ContentFilter{},
+			[]common.Station{jazz},
+			config.DefaultStationColumns(),
+			nil,
+			stationPageKey{},
+			false,
+		)
+		model.SetVolumeTrimStore(trims)
+		return model
+	}
+
+	t.Run("trims the station under the cursor when nothing plays", func(t *testing.T) {
+
+		trims := newVolumeTrimStore()
+		model := newModel(trims)
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("(")})
+
+		assert.Equal(t, toastMsg{text: "Jazz FM volume trim: -5 (applies the next time it plays)", kind: toastSuccess}, cmd())
+		assert.Equal(t, -5, trims.Trim(jazz.StationUuid))
+
+	})
+
+	t.Run("keeps trims within bounds", func(t *testing.T) {
+
+		trims := newVolumeTrimStore()
+		model := newModel(trims)
+
+		for i := 0; i < maxVolumeTrim/volumeTrimStep+2; i++ {
+			_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(")")})
+			cmd()
+		}
+
+		assert.Equal(t, maxVolumeTrim, trims.Trim(jazz.StationUuid))
+
+	})
+
+	t.Run("plays trimmed stations within the volume range", func(t *testing.T) {
+
+		trims := newVolumeTrimStore()
+		model := newModel(trims)
+
+		assert.Equal(t, 80, model.stationVolume(jazz))
+		assert.NoError(t, trims.SetTrim(jazz.StationUuid, -10))
+		assert.Equal(t, 70, model.stationVolume(jazz))
+		assert.NoError(t, trims.SetTrim(jazz.StationUuid, 30))
+		assert.Equal(t, 100, model.stationVolume(jazz))
+
+	})
+
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Commands
+
+// notifyRadioBrowserCmd counts a click on the station, unless it was already clicked within api.ClickCooldown,
+// so that restarting the same station doesn't inflate its click count.
+// The click is sent again later if radio-browser can't be reached.
+func notifyRadioBrowserCmd(actions *api.ActionQueue, interactions storage.InteractionStore, station common.Station) tea.Cmd {
+	if cooldownLeft(interactions, storage.InteractionClick, station, api.ClickCooldown) > 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		result := actions.Send(api.ActionClick, station)
+		if result.Queued {
+			return actionQueuedMsg{}
+		}
+		if result.Err != nil {
+			return nonFatalError{stopPlayback: false, err: result.Err}
+		}
+		if result.Ok {
+			if err := recordInteraction(interactions, result); err != nil {
+				return nonFatalError{stopPlayback: false, err: err}
+			}
+		}
+		return nil
+	}
+}
+
+// voteStationCmd votes for the station, remembering when so that the vote isn't sent again within api.VoteCooldown.
+// The vote is sent again later if radio-browser can't be reached.
+func voteStationCmd(actions *api.ActionQueue, interactions storage.InteractionStore, station common.Station, name string) tea.Cmd {
+	return func() tea.Msg {
+		result := actions.Send(api.ActionVote, station)
+		if result.Queued {
+			return actionQueuedMsg{text: i18n.Tf("votes.queued", name)}
+		}
+		if result.Err != nil {
+			return nonFatalError{stopPlayback: false, err: result.Err}
+		}
+		if !result.Ok {
+			return nonFatalError{stopPlayback: false, err: errors.New(i18n.Tf("votes.failed", result.Message))}
+		}
+		if err := recordInteraction(interactions, result); err != nil {
+			return nonFatalError{stopPlayback: false, err: err}
+		}
+		return toastMsg{text: i18n.Tf("votes.voted", name), kind: toastSuccess}
+	}
+}
+
+// recordInteraction remembers when radio-browser counted the click or vote (if interactions are remembered).
+func recordInteraction(interactions storage.InteractionStore, result api.ActionResult) error {
+	if interactions == nil {
+		return nil
+	}
+	interaction := storage.InteractionClick
+	if result.Kind == api.ActionVote {
+		interaction = storage.InteractionVote
+	}
+	return interactions.Record(interaction, result.Station.StationUuid, time.Now())
+}
+
+// cooldownLeft returns how long radio-browser would still ignore the interaction with the station
+// (0 if it wouldn't, or if interactions aren't remembered).
+func cooldownLeft(interactions storage.InteractionStore, interaction storage.Interaction, station common.Station, cooldown time.Duration) time.Duration {
+	if interactions == nil {
+		return 0
+	}
+	last, found := interactions.Last(interaction, station.StationUuid)
+	if !found {
+		return 0
+	}
+	if left := cooldown - time.Since(last); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// formatCooldown rounds a cooldown up to the minute, e.g. "7m", or to the hour past one.
+func formatCooldown(d time.Duration) string {
+	if d > time.Hour {
+		return fmt.Sprintf("%dh", int((d+time.Hour-1)/time.Hour))
+	}
+	return fmt.Sprintf("%dm", int((d+time.Minute-1)/time.Minute))
+}
+
+// Model
+
+// voteSelectedStation votes for the highlighted station, unless it was voted for too recently.
+func (m StationsModel) voteSelectedStation() (tea.Model, tea.Cmd) {
+	if len(m.stations) == 0 {
+		return m, nil
+	}
+	station := m.stations[m.stationsTable.Cursor()]
+	name := stationDisplayName(m.labelStore, station)
+	if left := cooldownLeft(m.interactions, storage.InteractionVote, station, api.VoteCooldown); left > 0 {
+		return m, showToastCmd(i18n.Tf("votes.cooldown", name, formatCooldown(left)), toastInfo)
+	}
+	return m, voteStationCmd(m.actions, m.interactions, station, name)
+}
+
+// voteHint tells, greyed out next to the status, that the highlighted station can't be voted for yet.
+func (m StationsModel) voteHint() string {
+	if len(m.stations) == 0 {
+		return ""
+	}
+	left := cooldownLeft(m.interactions, storage.InteractionVote, m.stations[m.stationsTable.Cursor()], api.VoteCooldown)
+	if left <= 0 {
+		return ""
+	}
+	return i18n.Tf("votes.hint", formatCooldown(left))
+}
+
+// SetActionQueue sends the clicks and votes with actions, shared so that those queued while radio-browser
+// can't be reached are all sent again together.
+func (m *StationsModel) SetActionQueue(actions *api.ActionQueue) {
+	m.actions = actions
+}
+
+// SetInteractionStore remembers the clicks and votes sent to radio-browser in store,
+// so that they aren't sent again during its cooldowns (nil always sends them).
+func (m *StationsModel) SetInteractionStore(store storage.InteractionStore) {
+	m.interactions = store
+}
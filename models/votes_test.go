@@ -0,0 +1,152 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/filter"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+	"github.com/zi0p4tch0/radiogogo/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// newInteractionStore returns an in-memory interaction store.
+func newInteractionStore() *mocks.MockInteractionStore {
+	recorded := map[string]time.Time{}
+	return &mocks.MockInteractionStore{
+		LastFunc: func(interaction storage.Interaction, stationUuid uuid.UUID) (time.Time, bool) {
+			t, found := recorded[string(interaction)+stationUuid.String()]
+			return t, found
+		},
+		RecordFunc: func(interaction storage.Interaction, stationUuid uuid.UUID, t time.Time) error {
+			recorded[string(interaction)+stationUuid.String()] = t
+			return nil
+		},
+	}
+}
+
+func TestStationsModel_Votes(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.New(), Name: "Jazz FM"}
+
+	newModel := func(browser *mocks.MockRadioBrowserService, interactions storage.InteractionStore) StationsModel {
+		model := NewStationsModel(
+			Theme{},
+			browser,
+			&mocks.MockPlaybackManagerService{},
+			&mocks.MockLabelStore{},
+			&mocks.MockBookmarkStore{},
+			&mocks.MockReportStore{},
+			filter.ContentFilter{},
+			[]common.Station{jazz},
+			config.DefaultStationColumns(),
+			nil,
+			stationPageKey{},
+			false,
+		)
+		model.SetInteractionStore(interactions)
+		return model
+	}
+
+	t.Run("votes once per cooldown", func(t *testing.T) {
+
+		votes := 0
+		browser := &mocks.MockRadioBrowserService{
+			VoteStationFunc: func(station common.Station) (common.VoteStationResponse, error) {
+				votes++
+				return common.VoteStationResponse{Ok: true}, nil
+			},
+		}
+		model := newModel(browser, newInteractionStore())
+
+		updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")})
+		model = updated.(StationsModel)
+		assert.Equal(t, toastMsg{text: "Voted for Jazz FM", kind: toastSuccess}, cmd())
+		assert.Contains(t, model.voteHint(), "10m")
+
+		_, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")})
+		assert.Equal(t, 1, votes)
+		assert.Contains(t, cmd().(toastMsg).text, "Jazz FM")
+
+	})
+
+	t.Run("doesn't remember votes that weren't counted", func(t *testing.T) {
+
+		browser := &mocks.MockRadioBrowserService{
+			VoteStationFunc: func(station common.Station) (common.VoteStationResponse, error) {
+				return common.VoteStationResponse{Ok: false, Message: "you are voting for the same station too often"}, nil
+			},
+		}
+		model := newModel(browser, newInteractionStore())
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")})
+		assert.IsType(t, nonFatalError{}, cmd())
+		assert.Empty(t, model.voteHint())
+
+	})
+
+	t.Run("queues the vote while radio-browser can't be reached", func(t *testing.T) {
+
+		browser := &mocks.MockRadioBrowserService{
+			VoteStationFunc: func(station common.Station) (common.VoteStationResponse, error) {
+				return common.VoteStationResponse{}, &api.Error{Kind: api.ErrMirrorUnavailable}
+			},
+		}
+		model := newModel(browser, newInteractionStore())
+
+		_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")})
+		msg, ok := cmd().(actionQueuedMsg)
+		assert.True(t, ok)
+		assert.Contains(t, msg.text, "Jazz FM")
+		assert.Equal(t, 1, model.actions.Pending())
+		assert.Empty(t, model.voteHint())
+
+	})
+
+	t.Run("doesn't click the same station twice within the cooldown", func(t *testing.T) {
+
+		clicks := 0
+		browser := &mocks.MockRadioBrowserService{
+			ClickStationFunc: func(station common.Station) (common.ClickStationResponse, error) {
+				clicks++
+				return common.ClickStationResponse{Ok: true}, nil
+			},
+		}
+		interactions := newInteractionStore()
+		actions := api.NewActionQueue(browser)
+
+		assert.Nil(t, notifyRadioBrowserCmd(actions, interactions, jazz)())
+		assert.Nil(t, notifyRadioBrowserCmd(actions, interactions, jazz))
+		assert.Equal(t, 1, clicks)
+
+		assert.NoError(t, interactions.Record(storage.InteractionClick, jazz.StationUuid, time.Now().Add(-25*time.Hour)))
+		assert.NotNil(t, notifyRadioBrowserCmd(actions, interactions, jazz))
+
+	})
+
+}
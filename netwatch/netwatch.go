@@ -0,0 +1,125 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package netwatch tells whether the network can be reached, and when it changed, without sending anything over it.
+package netwatch
+
+import (
+	"net"
+	"sort"
+	"strings"
+)
+
+// Addresses whose route is looked up to tell whether the network can be reached.
+// Dialing UDP only looks the route up: no packet is sent.
+var routeProbes = []string{"1.1.1.1:53", "[2606:4700:4700::1111]:53"}
+
+// Status is what a check found out about the network.
+type Status struct {
+	// Online is true if an interface is up with an address, and there's a route out of it.
+	Online bool
+	// Addresses lists the addresses of the interfaces that are up, telling when the network changed,
+	// e.g. when switching from Wi-Fi to Ethernet.
+	Addresses string
+}
+
+// CheckerService checks the network.
+type CheckerService interface {
+	// Check returns the status of the network. It's cheap enough to be done every few seconds.
+	Check() Status
+}
+
+type CheckerImpl struct {
+	// Lists the addresses of the interfaces that are up.
+	listAddresses func() ([]net.IP, error)
+	// Returns true if there's a route out of the host.
+	hasRoute func() bool
+}
+
+// NewChecker returns a new instance of CheckerService looking at the interfaces and routes of the host.
+func NewChecker() CheckerService {
+	return NewCheckerWithDependencies(upAddresses, hasDefaultRoute)
+}
+
+// NewCheckerWithDependencies returns a new instance of CheckerService using the given functions
+// to list the addresses of the interfaces that are up, and to tell whether there's a route out of the host.
+func NewCheckerWithDependencies(listAddresses func() ([]net.IP, error), hasRoute func() bool) CheckerService {
+	return &CheckerImpl{
+		listAddresses: listAddresses,
+		hasRoute:      hasRoute,
+	}
+}
+
+func (c *CheckerImpl) Check() Status {
+	ips, err := c.listAddresses()
+	if err != nil {
+		return Status{}
+	}
+	var addresses []string
+	for _, ip := range ips {
+		// Loopback and link-local addresses are there without a network
+		if ip.IsGlobalUnicast() {
+			addresses = append(addresses, ip.String())
+		}
+	}
+	if len(addresses) == 0 {
+		return Status{}
+	}
+	sort.Strings(addresses)
+	return Status{
+		Online:    c.hasRoute(),
+		Addresses: strings.Join(addresses, ","),
+	}
+}
+
+// upAddresses lists the addresses of the interfaces that are up, but loopback ones.
+func upAddresses() ([]net.IP, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var ips []net.IP
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				ips = append(ips, ipNet.IP)
+			}
+		}
+	}
+	return ips, nil
+}
+
+// hasDefaultRoute returns true if a route to the Internet is found, over IPv4 or IPv6.
+func hasDefaultRoute() bool {
+	for _, probe := range routeProbes {
+		conn, err := net.Dial("udp", probe)
+		if err == nil {
+			conn.Close()
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package netwatch
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker(t *testing.T) {
+
+	addresses := func(ips ...string) func() ([]net.IP, error) {
+		return func() ([]net.IP, error) {
+			var parsed []net.IP
+			for _, ip := range ips {
+				parsed = append(parsed, net.ParseIP(ip))
+			}
+			return parsed, nil
+		}
+	}
+	routed := func() bool { return true }
+
+	t.Run("is online with an address and a route", func(t *testing.T) {
+
+		status := NewCheckerWithDependencies(addresses("192.168.1.20", "fe80::1", "2001:db8::20"), routed).Check()
+
+		assert.Equal(t, Status{Online: true, Addresses: "192.168.1.20,2001:db8::20"}, status)
+
+	})
+
+	t.Run("is offline without a route", func(t *testing.T) {
+
+		status := NewCheckerWithDependencies(addresses("192.168.1.20"), func() bool { return false }).Check()
+
+		assert.False(t, status.Online)
+		assert.Equal(t, "192.168.1.20", status.Addresses)
+
+	})
+
+	t.Run("is offline with only link-local addresses", func(t *testing.T) {
+
+		status := NewCheckerWithDependencies(addresses("169.254.10.1", "fe80::1"), routed).Check()
+
+		assert.Equal(t, Status{}, status)
+
+	})
+
+	t.Run("is offline when the interfaces can't be listed", func(t *testing.T) {
+
+		failing := func() ([]net.IP, error) { return nil, errors.New("no interfaces") }
+
+		assert.Equal(t, Status{}, NewCheckerWithDependencies(failing, routed).Check())
+
+	})
+
+	t.Run("tells addresses apart whatever their order", func(t *testing.T) {
+
+		first := NewCheckerWithDependencies(addresses("10.0.0.2", "192.168.1.20"), routed).Check()
+		second := NewCheckerWithDependencies(addresses("192.168.1.20", "10.0.0.2"), routed).Check()
+
+		assert.Equal(t, first, second)
+
+	})
+
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package nowplaying publishes the station and track being played, for streaming overlays and scripts.
+package nowplaying
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultFormat is the text written to the now-playing file when no format is configured.
+const DefaultFormat = "{station} - {title}"
+
+// How long to wait for a unix socket listener to accept the connection.
+const dialTimeout = time.Second
+
+// Track is what's playing. The zero Track means that nothing is.
+type Track struct {
+	Station     string `json:"station"`
+	StationUuid string `json:"stationuuid"`
+	// Title is the track title announced by the stream (usually "Artist - Title"), if any.
+	Title string `json:"title"`
+}
+
+// Publisher publishes the current Track somewhere.
+type Publisher interface {
+	Write(track Track) error
+}
+
+// Writer writes the current Track to a text file and sends it to a named pipe or unix socket.
+type Writer struct {
+	file   string
+	format string
+	pipe   string
+}
+
+// NewWriter returns a Writer rewriting file with the given format, where {station} and {title}
+// are replaced with the current station and track title, and sending a JSON line to the named pipe
+// or unix socket at pipe. Either path may be empty.
+// Returns nil if both are empty.
+func NewWriter(file string, format string, pipe string) *Writer {
+	if file == "" && pipe == "" {
+		return nil
+	}
+	if format == "" {
+		format = DefaultFormat
+	}
+	return &Writer{
+		file:   file,
+		format: format,
+		pipe:   pipe,
+	}
+}
+
+// Text returns the text written to the file for the given track.
+// Without a title, it's just the station name; when nothing is playing, it's empty.
+func (w *Writer) Text(track Track) string {
+	if track.Title == "" {
+		return track.Station
+	}
+	return strings.NewReplacer("{station}", track.Station, "{title}", track.Title).Replace(w.format)
+}
+
+// Write publishes the given track. Nobody listening on the pipe is not an error.
+func (w *Writer) Write(track Track) error {
+	if w.file != "" {
+		if err := writeFileAtomically(w.file, w.Text(track)); err != nil {
+			return err
+		}
+	}
+	if w.pipe != "" {
+		line, err := json.Marshal(track)
+		if err != nil {
+			return err
+		}
+		if err := sendLine(w.pipe, append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFileAtomically replaces the file at path, so that readers never see it half-written.
+func writeFileAtomically(path string, text string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(text); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// sendLine writes line to the named pipe or unix socket at path, without blocking if nobody reads it.
+func sendLine(path string, line []byte) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSocket != 0:
+		conn, err := net.DialTimeout("unix", path, dialTimeout)
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_ = conn.SetWriteDeadline(time.Now().Add(dialTimeout))
+		_, err = conn.Write(line)
+		return err
+	case info.Mode()&os.ModeNamedPipe != 0:
+		pipe, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+		if errors.Is(err, syscall.ENXIO) {
+			// No reader
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		defer pipe.Close()
+		_, err = pipe.Write(line)
+		return err
+	default:
+		return &os.PathError{Op: "write", Path: path, Err: errors.New("not a named pipe or unix socket")}
+	}
+}
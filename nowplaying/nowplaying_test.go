@@ -0,0 +1,124 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package nowplaying
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWriter(t *testing.T) {
+
+	t.Run("returns nil when there is nowhere to write", func(t *testing.T) {
+		assert.Nil(t, NewWriter("", "{title}", ""))
+	})
+
+	t.Run("uses the default format", func(t *testing.T) {
+		w := NewWriter("nowplaying.txt", "", "")
+		assert.Equal(t, "Jazz FM - Artist - Title", w.Text(Track{Station: "Jazz FM", Title: "Artist - Title"}))
+	})
+
+}
+
+func TestWriterText(t *testing.T) {
+
+	w := NewWriter("nowplaying.txt", "♪ {title} on {station}", "")
+
+	assert.Equal(t, "♪ Artist - Title on Jazz FM", w.Text(Track{Station: "Jazz FM", Title: "Artist - Title"}))
+	assert.Equal(t, "Jazz FM", w.Text(Track{Station: "Jazz FM"}))
+	assert.Equal(t, "", w.Text(Track{}))
+
+}
+
+func TestWriterWrite(t *testing.T) {
+
+	t.Run("replaces the file", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "nowplaying.txt")
+		w := NewWriter(path, "", "")
+
+		assert.NoError(t, w.Write(Track{Station: "Jazz FM", Title: "Artist - Title"}))
+		content, _ := os.ReadFile(path)
+		assert.Equal(t, "Jazz FM - Artist - Title", string(content))
+
+		assert.NoError(t, w.Write(Track{}))
+		content, _ = os.ReadFile(path)
+		assert.Equal(t, "", string(content))
+
+		entries, _ := os.ReadDir(filepath.Dir(path))
+		assert.Len(t, entries, 1)
+
+	})
+
+	t.Run("sends a JSON line to a unix socket", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "nowplaying.sock")
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			t.Skip("unix sockets are not available:", err)
+		}
+		defer listener.Close()
+
+		received := make(chan Track, 1)
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			var track Track
+			line, _ := bufio.NewReader(conn).ReadBytes('\n')
+			_ = json.Unmarshal(line, &track)
+			received <- track
+		}()
+
+		w := NewWriter("", "", path)
+		track := Track{Station: "Jazz FM", StationUuid: "941ef6f1-0699-4821-95b1-2b678e3ff62e", Title: "Artist - Title"}
+
+		assert.NoError(t, w.Write(track))
+		assert.Equal(t, track, <-received)
+
+	})
+
+	t.Run("ignores a missing pipe", func(t *testing.T) {
+
+		w := NewWriter("", "", filepath.Join(t.TempDir(), "missing"))
+
+		assert.NoError(t, w.Write(Track{Station: "Jazz FM"}))
+
+	})
+
+	t.Run("refuses to write to a regular file as a pipe", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "regular")
+		_ = os.WriteFile(path, nil, 0644)
+		w := NewWriter("", "", path)
+
+		assert.Error(t, w.Write(Track{Station: "Jazz FM"}))
+
+	})
+
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package nowplaying
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// TmuxOption is the tmux user option set to the playback status,
+// shown by adding #{@radiogogo} to status-left or status-right.
+const TmuxOption = "@radiogogo"
+
+// StatusText returns the playback status shown by Terminal: "▶ Station — Title",
+// just "▶ Station" without a title, or nothing when nothing is playing.
+func StatusText(track Track) string {
+	if track.Station == "" {
+		return ""
+	}
+	if track.Title == "" {
+		return "▶ " + track.Station
+	}
+	return "▶ " + track.Station + " — " + track.Title
+}
+
+// Terminal shows the playback status in the terminal window title and in a tmux user option.
+type Terminal struct {
+	out   io.Writer
+	title bool
+	tmux  bool
+	// runTmux runs the tmux command with the given arguments.
+	runTmux func(args ...string) error
+}
+
+// NewTerminal returns a Terminal setting the window title by writing to out if title is true,
+// and setting the TmuxOption if tmux is true and RadioGoGo runs inside tmux.
+// Returns nil if there is nothing to update.
+func NewTerminal(out io.Writer, title bool, tmux bool) *Terminal {
+	tmux = tmux && os.Getenv("TMUX") != ""
+	if !title && !tmux {
+		return nil
+	}
+	return &Terminal{
+		out:   out,
+		title: title,
+		tmux:  tmux,
+		runTmux: func(args ...string) error {
+			return exec.Command("tmux", args...).Run()
+		},
+	}
+}
+
+// Write shows the status of the given track, or clears it if nothing is playing.
+func (t *Terminal) Write(track Track) error {
+	text := withoutControlCharacters(StatusText(track))
+	if t.title {
+		// OSC 2 sets the window title; an empty title restores the terminal's default one
+		if _, err := fmt.Fprintf(t.out, "\x1b]2;%s\x07", text); err != nil {
+			return err
+		}
+	}
+	if t.tmux {
+		args := []string{"set-option", "-gq", TmuxOption, text}
+		if text == "" {
+			args = []string{"set-option", "-gqu", TmuxOption}
+		}
+		if err := t.runTmux(args...); err != nil {
+			return err
+		}
+		return t.runTmux("refresh-client", "-S")
+	}
+	return nil
+}
+
+// withoutControlCharacters drops the characters that could end the escape sequence early
+// or be interpreted by the terminal, which stream titles sometimes contain.
+func withoutControlCharacters(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+			return -1
+		}
+		return r
+	}, text)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package nowplaying
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusText(t *testing.T) {
+
+	assert.Equal(t, "▶ Jazz FM — Artist - Title", StatusText(Track{Station: "Jazz FM", Title: "Artist - Title"}))
+	assert.Equal(t, "▶ Jazz FM", StatusText(Track{Station: "Jazz FM"}))
+	assert.Equal(t, "", StatusText(Track{}))
+
+}
+
+func TestNewTerminal(t *testing.T) {
+
+	t.Run("returns nil when there is nothing to update", func(t *testing.T) {
+		assert.Nil(t, NewTerminal(&bytes.Buffer{}, false, false))
+	})
+
+	t.Run("ignores tmux outside of tmux", func(t *testing.T) {
+		t.Setenv("TMUX", "")
+		assert.Nil(t, NewTerminal(&bytes.Buffer{}, false, true))
+	})
+
+	t.Run("uses tmux inside tmux", func(t *testing.T) {
+		t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+		terminal := NewTerminal(&bytes.Buffer{}, false, true)
+		assert.NotNil(t, terminal)
+		assert.True(t, terminal.tmux)
+	})
+
+}
+
+func TestTerminalWrite(t *testing.T) {
+
+	t.Run("sets and clears the window title", func(t *testing.T) {
+
+		var out bytes.Buffer
+		terminal := NewTerminal(&out, true, false)
+
+		assert.NoError(t, terminal.Write(Track{Station: "Jazz FM", Title: "Artist\x07 - Title"}))
+		assert.Equal(t, "\x1b]2;▶ Jazz FM — Artist - Title\x07", out.String())
+
+		out.Reset()
+		assert.NoError(t, terminal.Write(Track{}))
+		assert.Equal(t, "\x1b]2;\x07", out.String())
+
+	})
+
+	t.Run("sets and unsets the tmux option", func(t *testing.T) {
+
+		var commands [][]string
+		terminal := &Terminal{
+			tmux: true,
+			runTmux: func(args ...string) error {
+				commands = append(commands, args)
+				return nil
+			},
+		}
+
+		assert.NoError(t, terminal.Write(Track{Station: "Jazz FM"}))
+		assert.NoError(t, terminal.Write(Track{}))
+
+		assert.Equal(t, [][]string{
+			{"set-option", "-gq", "@radiogogo", "▶ Jazz FM"},
+			{"refresh-client", "-S"},
+			{"set-option", "-gqu", "@radiogogo"},
+			{"refresh-client", "-S"},
+		}, commands)
+
+	})
+
+}
@@ -0,0 +1,394 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package offline
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	"github.com/google/uuid"
+)
+
+// ErrNoSnapshot is returned when there is no catalog snapshot to browse.
+var ErrNoSnapshot = errors.New("no offline catalog snapshot: run \"radiogogo sync\" first")
+
+// ErrNotInSnapshot is returned for data the snapshot doesn't include.
+var ErrNotInSnapshot = errors.New("not available offline")
+
+// BrowserImpl answers radio-browser queries from a catalog snapshot held in memory.
+type BrowserImpl struct {
+	stations []common.Station
+}
+
+// NewBrowser returns a RadioBrowserService that searches the given stations.
+func NewBrowser(stations []common.Station) api.RadioBrowserService {
+	return &BrowserImpl{stations: stations}
+}
+
+// LoadBrowser reads the catalog at path into memory and returns a RadioBrowserService searching it.
+// The catalog is closed right away, so that "radiogogo sync" can update it while RadioGoGo is running.
+// Returns ErrNoSnapshot if the catalog doesn't exist or is empty.
+func LoadBrowser(path string) (api.RadioBrowserService, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoSnapshot
+	}
+	catalog, err := OpenCatalog(path)
+	if err != nil {
+		return nil, err
+	}
+	defer catalog.Close()
+
+	stations, err := catalog.Stations()
+	if err != nil {
+		return nil, err
+	}
+	if len(stations) == 0 {
+		return nil, ErrNoSnapshot
+	}
+	return NewBrowser(stations), nil
+}
+
+func (b *BrowserImpl) GetStations(
+	stationQuery common.StationQuery,
+	searchTerm string,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Station, error) {
+
+	var stations []common.Station
+	for _, station := range b.stations {
+		if hideBroken && !bool(station.LastCheckOk) {
+			continue
+		}
+		if matchesQuery(station, stationQuery, searchTerm) {
+			stations = append(stations, station)
+		}
+	}
+
+	sortStations(stations, order, reverse)
+
+	return paginate(stations, offset, limit), nil
+}
+
+func (b *BrowserImpl) SearchStations(
+	name string,
+	filter common.StationFilter,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Station, error) {
+
+	var stations []common.Station
+	for _, station := range b.stations {
+		if hideBroken && !bool(station.LastCheckOk) {
+			continue
+		}
+		if matchesQuery(station, common.StationQueryByName, name) && matchesFilter(station, filter) {
+			stations = append(stations, station)
+		}
+	}
+
+	sortStations(stations, order, reverse)
+
+	return paginate(stations, offset, limit), nil
+}
+
+func (b *BrowserImpl) GetStationsByUrl(streamUrl string) ([]common.Station, error) {
+	var stations []common.Station
+	for _, station := range b.stations {
+		if station.Url.URL.String() == streamUrl {
+			stations = append(stations, station)
+		}
+	}
+	return stations, nil
+}
+
+// GetStationChecks fails with ErrNotInSnapshot, as checks are not synced.
+func (b *BrowserImpl) GetStationChecks(stationUuid uuid.UUID) ([]common.StationCheck, error) {
+	return nil, ErrNotInSnapshot
+}
+
+// ClickStation can't reach radio-browser, so the click is not counted.
+func (b *BrowserImpl) ClickStation(station common.Station) (common.ClickStationResponse, error) {
+	return common.ClickStationResponse{
+		Ok:          false,
+		Message:     "offline",
+		StationUuid: station.StationUuid,
+		Name:        station.Name,
+		Url:         station.Url,
+	}, nil
+}
+
+// VoteStation can't reach radio-browser, so the vote is not counted.
+func (b *BrowserImpl) VoteStation(station common.Station) (common.VoteStationResponse, error) {
+	return common.VoteStationResponse{
+		Ok:      false,
+		Message: "offline",
+	}, nil
+}
+
+// SuggestStationEdit can't reach radio-browser, so edits can't be suggested.
+func (b *BrowserImpl) SuggestStationEdit(stationUuid uuid.UUID, edit common.StationEdit) (common.StationEditResponse, error) {
+	return common.StationEditResponse{}, api.ErrEditsUnsupported
+}
+
+func (b *BrowserImpl) GetTags(
+	prefix string,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Tag, error) {
+
+	counts := make(map[string]uint64)
+	for _, station := range b.stations {
+		if hideBroken && !bool(station.LastCheckOk) {
+			continue
+		}
+		for _, tag := range splitTags(station.Tags) {
+			if strings.HasPrefix(tag, strings.ToLower(prefix)) {
+				counts[tag]++
+			}
+		}
+	}
+
+	tags := make([]common.Tag, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, common.Tag{Name: name, StationCount: count})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		if order == "stationcount" && tags[i].StationCount != tags[j].StationCount {
+			return tags[i].StationCount < tags[j].StationCount
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	if reverse {
+		for i, j := 0, len(tags)-1; i < j; i, j = i+1, j-1 {
+			tags[i], tags[j] = tags[j], tags[i]
+		}
+	}
+
+	if offset >= uint64(len(tags)) {
+		return []common.Tag{}, nil
+	}
+	tags = tags[offset:]
+	if limit > 0 && limit < uint64(len(tags)) {
+		tags = tags[:limit]
+	}
+	return tags, nil
+}
+
+// GetCountries counts the stations of each country in the snapshot.
+func (b *BrowserImpl) GetCountries(hideBroken bool) ([]common.Country, error) {
+
+	countries := make(map[string]common.Country)
+	for _, station := range b.stations {
+		if station.CountryCode == "" || (hideBroken && !bool(station.LastCheckOk)) {
+			continue
+		}
+		code := strings.ToUpper(station.CountryCode)
+		country := countries[code]
+		country.Code = code
+		if country.Name == "" {
+			country.Name = station.Country
+		}
+		country.StationCount++
+		countries[code] = country
+	}
+
+	sorted := make([]common.Country, 0, len(countries))
+	for _, country := range countries {
+		sorted = append(sorted, country)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return strings.ToLower(sorted[i].Name) < strings.ToLower(sorted[j].Name)
+	})
+	return sorted, nil
+}
+
+// GetLanguages counts the stations of each language in the snapshot.
+// Languages are matched by name, as radio-browser lists them, with their code when the stations have as many codes.
+func (b *BrowserImpl) GetLanguages(hideBroken bool) ([]common.Language, error) {
+
+	languages := make(map[string]common.Language)
+	for _, station := range b.stations {
+		if hideBroken && !bool(station.LastCheckOk) {
+			continue
+		}
+		names := strings.Split(station.Languages, ",")
+		codes := strings.Split(station.LanguagesCodes, ",")
+		for i, name := range names {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			language := languages[name]
+			language.Name = name
+			if language.Code == "" && len(codes) == len(names) {
+				language.Code = strings.ToLower(strings.TrimSpace(codes[i]))
+			}
+			language.StationCount++
+			languages[name] = language
+		}
+	}
+
+	sorted := make([]common.Language, 0, len(languages))
+	for _, language := range languages {
+		sorted = append(sorted, language)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted, nil
+}
+
+// GetTopStations ranks the stations of the snapshot with the votes and clicks they had when it was synced.
+func (b *BrowserImpl) GetTopStations(
+	chart common.StationChart,
+	countryCode string,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Station, error) {
+	return b.SearchStations("", common.StationFilter{CountryCode: countryCode}, chart.Order(), true, 0, limit, hideBroken)
+}
+
+// matchesFilter mirrors the radio-browser search endpoint: the country code must match exactly,
+// the other fields are substrings, all case-insensitively. The bitrate bounds are inclusive.
+func matchesFilter(station common.Station, filter common.StationFilter) bool {
+	if filter.CountryCode != "" && !strings.EqualFold(station.CountryCode, filter.CountryCode) {
+		return false
+	}
+	if filter.BitrateMin > 0 && station.Bitrate < filter.BitrateMin {
+		return false
+	}
+	if filter.BitrateMax > 0 && station.Bitrate > filter.BitrateMax {
+		return false
+	}
+	return containsFold(station.Languages, filter.Language) &&
+		containsFold(station.Country, filter.Country) &&
+		containsFold(station.State, filter.State) &&
+		containsFold(station.Tags, filter.Tag) &&
+		containsFold(station.Codec, filter.Codec)
+}
+
+// matchesQuery mirrors the radio-browser search endpoints:
+// plain queries match substrings, "exact" ones whole values, both case-insensitively.
+func matchesQuery(station common.Station, query common.StationQuery, term string) bool {
+	switch query {
+	case common.StationQueryAll:
+		return true
+	case common.StationQueryByUuid:
+		return strings.EqualFold(station.StationUuid.String(), term)
+	case common.StationQueryByName:
+		return containsFold(station.Name, term)
+	case common.StationQueryByNameExact:
+		return strings.EqualFold(station.Name, term)
+	case common.StationQueryByCodec:
+		return containsFold(station.Codec, term)
+	case common.StationQueryByCodecExact:
+		return strings.EqualFold(station.Codec, term)
+	case common.StationQueryByCountry:
+		return containsFold(station.Country, term)
+	case common.StationQueryByCountryExact:
+		return strings.EqualFold(station.Country, term)
+	case common.StationQueryByCountryCodeExact:
+		return strings.EqualFold(station.CountryCode, term)
+	case common.StationQueryByState:
+		return containsFold(station.State, term)
+	case common.StationQueryByStateExact:
+		return strings.EqualFold(station.State, term)
+	case common.StationQueryByLanguage:
+		return containsFold(station.Languages, term)
+	case common.StationQueryByLanguageExact:
+		return anyEqualFold(strings.Split(station.Languages, ","), term)
+	case common.StationQueryByTag:
+		return containsFold(station.Tags, term)
+	case common.StationQueryByTagExact:
+		return anyEqualFold(splitTags(station.Tags), term)
+	}
+	return false
+}
+
+func sortStations(stations []common.Station, order string, reverse bool) {
+	less := func(i, j int) bool {
+		switch order {
+		case "name":
+			return strings.ToLower(stations[i].Name) < strings.ToLower(stations[j].Name)
+		case "bitrate":
+			return stations[i].Bitrate < stations[j].Bitrate
+		case "clickcount":
+			return stations[i].ClickCount < stations[j].ClickCount
+		default:
+			return stations[i].Votes < stations[j].Votes
+		}
+	}
+	if reverse {
+		sort.SliceStable(stations, func(i, j int) bool { return less(j, i) })
+	} else {
+		sort.SliceStable(stations, less)
+	}
+}
+
+func paginate(stations []common.Station, offset uint64, limit uint64) []common.Station {
+	if offset >= uint64(len(stations)) {
+		return []common.Station{}
+	}
+	stations = stations[offset:]
+	if limit > 0 && limit < uint64(len(stations)) {
+		stations = stations[:limit]
+	}
+	return stations
+}
+
+func splitTags(tags string) []string {
+	var split []string
+	for _, tag := range strings.Split(tags, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" {
+			split = append(split, tag)
+		}
+	}
+	return split
+}
+
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func anyEqualFold(values []string, s string) bool {
+	for _, value := range values {
+		if strings.EqualFold(strings.TrimSpace(value), s) {
+			return true
+		}
+	}
+	return false
+}
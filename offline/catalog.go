@@ -0,0 +1,130 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package offline keeps a local snapshot of the radio-browser catalog,
+// so that stations can still be searched when the API is unreachable.
+package offline
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	stationsBucket = []byte("stations")
+	metaBucket     = []byte("meta")
+	syncedAtKey    = []byte("syncedAt")
+)
+
+// Catalog is a snapshot of stations stored in a bolt database.
+type Catalog struct {
+	db *bolt.DB
+}
+
+// OpenCatalog opens the catalog at path, creating it if it doesn't exist.
+// Only one process can have the catalog open at a time.
+func OpenCatalog(path string) (*Catalog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(stationsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(metaBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Catalog{db: db}, nil
+}
+
+// Close releases the database.
+func (c *Catalog) Close() error {
+	return c.db.Close()
+}
+
+// Replace swaps the whole snapshot for the given stations in a single transaction,
+// so that an interrupted sync leaves the previous snapshot in place.
+func (c *Catalog) Replace(stations []common.Station, syncedAt time.Time) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(stationsBucket); err != nil {
+			return err
+		}
+		bucket, err := tx.CreateBucket(stationsBucket)
+		if err != nil {
+			return err
+		}
+		for _, station := range stations {
+			value, err := json.Marshal(station)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(station.StationUuid.String()), value); err != nil {
+				return err
+			}
+		}
+		value, err := syncedAt.UTC().MarshalText()
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(metaBucket).Put(syncedAtKey, value)
+	})
+}
+
+// Stations returns every station in the snapshot.
+func (c *Catalog) Stations() ([]common.Station, error) {
+	var stations []common.Station
+	err := c.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(stationsBucket).ForEach(func(key, value []byte) error {
+			var station common.Station
+			if err := json.Unmarshal(value, &station); err != nil {
+				return err
+			}
+			stations = append(stations, station)
+			return nil
+		})
+	})
+	return stations, err
+}
+
+// SyncedAt returns when the snapshot was taken, or the zero time if it never was.
+func (c *Catalog) SyncedAt() (time.Time, error) {
+	var syncedAt time.Time
+	err := c.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(metaBucket).Get(syncedAtKey)
+		if value == nil {
+			return nil
+		}
+		return syncedAt.UnmarshalText(value)
+	})
+	return syncedAt, err
+}
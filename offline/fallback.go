@@ -0,0 +1,253 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package offline
+
+import (
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	"github.com/google/uuid"
+)
+
+// FallbackBrowserImpl queries radio-browser and falls back to the catalog snapshot when it can't be reached.
+type FallbackBrowserImpl struct {
+	online  api.RadioBrowserService
+	offline api.RadioBrowserService
+}
+
+// NewFallbackBrowser returns a RadioBrowserService that tries online first and answers from offline
+// if the request fails. online can be nil when the API couldn't be located at all,
+// in which case every request is answered from the snapshot.
+func NewFallbackBrowser(online api.RadioBrowserService, offline api.RadioBrowserService) api.RadioBrowserService {
+	return &FallbackBrowserImpl{online: online, offline: offline}
+}
+
+func (b *FallbackBrowserImpl) GetStations(
+	stationQuery common.StationQuery,
+	searchTerm string,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Station, error) {
+	if b.online != nil {
+		stations, err := b.online.GetStations(stationQuery, searchTerm, order, reverse, offset, limit, hideBroken)
+		if err == nil {
+			return stations, nil
+		}
+	}
+	return b.offline.GetStations(stationQuery, searchTerm, order, reverse, offset, limit, hideBroken)
+}
+
+func (b *FallbackBrowserImpl) SearchStations(
+	name string,
+	filter common.StationFilter,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Station, error) {
+	if b.online != nil {
+		stations, err := b.online.SearchStations(name, filter, order, reverse, offset, limit, hideBroken)
+		if err == nil {
+			return stations, nil
+		}
+	}
+	return b.offline.SearchStations(name, filter, order, reverse, offset, limit, hideBroken)
+}
+
+// StreamStations streams the stations from radio-browser if it can, falling back as GetStations does
+// if it fails before any station was handed over.
+func (b *FallbackBrowserImpl) StreamStations(
+	stationQuery common.StationQuery,
+	searchTerm string,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+	yield func(station common.Station) error,
+) error {
+	return b.stream(
+		func(streamer api.StationStreamer, yield func(station common.Station) error) error {
+			return streamer.StreamStations(stationQuery, searchTerm, order, reverse, offset, limit, hideBroken, yield)
+		},
+		func(browser api.RadioBrowserService) ([]common.Station, error) {
+			return browser.GetStations(stationQuery, searchTerm, order, reverse, offset, limit, hideBroken)
+		},
+		yield,
+	)
+}
+
+// StreamSearchStations searches radio-browser as StreamStations does.
+func (b *FallbackBrowserImpl) StreamSearchStations(
+	name string,
+	filter common.StationFilter,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+	yield func(station common.Station) error,
+) error {
+	return b.stream(
+		func(streamer api.StationStreamer, yield func(station common.Station) error) error {
+			return streamer.StreamSearchStations(name, filter, order, reverse, offset, limit, hideBroken, yield)
+		},
+		func(browser api.RadioBrowserService) ([]common.Station, error) {
+			return browser.SearchStations(name, filter, order, reverse, offset, limit, hideBroken)
+		},
+		yield,
+	)
+}
+
+// stream streams stations from radio-browser, or hands over those fetched at once if it can't stream.
+// If streaming fails before any station was handed over they're fetched from the snapshot
+// (falling back afterwards would repeat them).
+func (b *FallbackBrowserImpl) stream(
+	stream func(streamer api.StationStreamer, yield func(station common.Station) error) error,
+	fetch func(browser api.RadioBrowserService) ([]common.Station, error),
+	yield func(station common.Station) error,
+) error {
+	var from api.RadioBrowserService = b
+	if streamer, ok := b.online.(api.StationStreamer); ok {
+		streamed := false
+		err := stream(streamer, func(station common.Station) error {
+			streamed = true
+			return yield(station)
+		})
+		if err == nil || streamed {
+			return err
+		}
+		from = b.offline
+	}
+	stations, err := fetch(from)
+	if err != nil {
+		return err
+	}
+	for _, station := range stations {
+		if err := yield(station); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *FallbackBrowserImpl) GetStationsByUrl(streamUrl string) ([]common.Station, error) {
+	if b.online != nil {
+		stations, err := b.online.GetStationsByUrl(streamUrl)
+		if err == nil {
+			return stations, nil
+		}
+	}
+	return b.offline.GetStationsByUrl(streamUrl)
+}
+
+func (b *FallbackBrowserImpl) GetStationChecks(stationUuid uuid.UUID) ([]common.StationCheck, error) {
+	if b.online != nil {
+		checks, err := b.online.GetStationChecks(stationUuid)
+		if err == nil {
+			return checks, nil
+		}
+	}
+	return b.offline.GetStationChecks(stationUuid)
+}
+
+func (b *FallbackBrowserImpl) ClickStation(station common.Station) (common.ClickStationResponse, error) {
+	if b.online != nil {
+		response, err := b.online.ClickStation(station)
+		if err == nil {
+			return response, nil
+		}
+	}
+	return b.offline.ClickStation(station)
+}
+
+func (b *FallbackBrowserImpl) VoteStation(station common.Station) (common.VoteStationResponse, error) {
+	if b.online != nil {
+		response, err := b.online.VoteStation(station)
+		if err == nil {
+			return response, nil
+		}
+	}
+	return b.offline.VoteStation(station)
+}
+
+// SuggestStationEdit needs radio-browser: edit suggestions aren't kept for later.
+func (b *FallbackBrowserImpl) SuggestStationEdit(stationUuid uuid.UUID, edit common.StationEdit) (common.StationEditResponse, error) {
+	if b.online != nil {
+		return b.online.SuggestStationEdit(stationUuid, edit)
+	}
+	return b.offline.SuggestStationEdit(stationUuid, edit)
+}
+
+func (b *FallbackBrowserImpl) GetTags(
+	prefix string,
+	order string,
+	reverse bool,
+	offset uint64,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Tag, error) {
+	if b.online != nil {
+		tags, err := b.online.GetTags(prefix, order, reverse, offset, limit, hideBroken)
+		if err == nil {
+			return tags, nil
+		}
+	}
+	return b.offline.GetTags(prefix, order, reverse, offset, limit, hideBroken)
+}
+
+func (b *FallbackBrowserImpl) GetCountries(hideBroken bool) ([]common.Country, error) {
+	if b.online != nil {
+		countries, err := b.online.GetCountries(hideBroken)
+		if err == nil {
+			return countries, nil
+		}
+	}
+	return b.offline.GetCountries(hideBroken)
+}
+
+func (b *FallbackBrowserImpl) GetLanguages(hideBroken bool) ([]common.Language, error) {
+	if b.online != nil {
+		languages, err := b.online.GetLanguages(hideBroken)
+		if err == nil {
+			return languages, nil
+		}
+	}
+	return b.offline.GetLanguages(hideBroken)
+}
+
+func (b *FallbackBrowserImpl) GetTopStations(
+	chart common.StationChart,
+	countryCode string,
+	limit uint64,
+	hideBroken bool,
+) ([]common.Station, error) {
+	if b.online != nil {
+		stations, err := b.online.GetTopStations(chart, countryCode, limit, hideBroken)
+		if err == nil {
+			return stations, nil
+		}
+	}
+	return b.offline.GetTopStations(chart, countryCode, limit, hideBroken)
+}
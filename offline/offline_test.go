@@ -0,0 +1,356 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package offline
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStation(name string, countryCode string, tags string, votes uint64) common.Station {
+	streamUrl, _ := url.Parse("http://example.com/" + name)
+	return common.Station{
+		StationUuid: uuid.New(),
+		Name:        name,
+		Url:         common.RadioGoGoURL{URL: *streamUrl},
+		CountryCode: countryCode,
+		Tags:        tags,
+		Votes:       votes,
+		LastCheckOk: true,
+	}
+}
+
+func TestCatalog(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "catalog.db")
+
+	catalog, err := OpenCatalog(path)
+	assert.NoError(t, err)
+
+	syncedAt, err := catalog.SyncedAt()
+	assert.NoError(t, err)
+	assert.True(t, syncedAt.IsZero())
+
+	stations := []common.Station{newTestStation("One", "IT", "jazz", 1)}
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, catalog.Replace(stations, now))
+	assert.NoError(t, catalog.Replace(stations, now))
+	assert.NoError(t, catalog.Close())
+
+	catalog, err = OpenCatalog(path)
+	assert.NoError(t, err)
+	defer catalog.Close()
+
+	stored, err := catalog.Stations()
+	assert.NoError(t, err)
+	assert.Equal(t, stations, stored)
+
+	syncedAt, err = catalog.SyncedAt()
+	assert.NoError(t, err)
+	assert.Equal(t, now, syncedAt)
+
+}
+
+func TestLoadBrowser(t *testing.T) {
+
+	t.Run("returns ErrNoSnapshot without a catalog", func(t *testing.T) {
+		_, err := LoadBrowser(filepath.Join(t.TempDir(), "catalog.db"))
+		assert.ErrorIs(t, err, ErrNoSnapshot)
+	})
+
+	t.Run("returns ErrNoSnapshot for an empty catalog", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "catalog.db")
+		catalog, err := OpenCatalog(path)
+		assert.NoError(t, err)
+		assert.NoError(t, catalog.Close())
+
+		_, err = LoadBrowser(path)
+		assert.ErrorIs(t, err, ErrNoSnapshot)
+	})
+
+	t.Run("searches the stored stations", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "catalog.db")
+		catalog, err := OpenCatalog(path)
+		assert.NoError(t, err)
+		stations := []common.Station{newTestStation("One", "IT", "jazz", 1)}
+		assert.NoError(t, catalog.Replace(stations, time.Now()))
+		assert.NoError(t, catalog.Close())
+
+		browser, err := LoadBrowser(path)
+		assert.NoError(t, err)
+		found, err := browser.GetStations(common.StationQueryByName, "one", "votes", true, 0, 10, true)
+		assert.NoError(t, err)
+		assert.Equal(t, stations, found)
+	})
+
+}
+
+func TestBrowserImpl_GetStations(t *testing.T) {
+
+	jazz := newTestStation("Jazz FM", "IT", "jazz,smooth jazz", 10)
+	rock := newTestStation("Rock Radio", "IT", "rock", 30)
+	news := newTestStation("News 24", "GB", "news", 20)
+	broken := newTestStation("Broken Jazz", "IT", "jazz", 50)
+	broken.LastCheckOk = false
+
+	browser := NewBrowser([]common.Station{jazz, rock, news, broken})
+
+	testCases := []struct {
+		name     string
+		query    common.StationQuery
+		term     string
+		expected []common.Station
+	}{
+		{"all by votes", common.StationQueryAll, "", []common.Station{rock, news, jazz}},
+		{"by name", common.StationQueryByName, "radio", []common.Station{rock}},
+		{"by country code", common.StationQueryByCountryCodeExact, "it", []common.Station{rock, jazz}},
+		{"by exact tag", common.StationQueryByTagExact, "jazz", []common.Station{jazz}},
+		{"by tag", common.StationQueryByTag, "jazz", []common.Station{jazz}},
+		{"by uuid", common.StationQueryByUuid, news.StationUuid.String(), []common.Station{news}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stations, err := browser.GetStations(tc.query, tc.term, "votes", true, 0, 100, true)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, stations)
+		})
+	}
+
+	t.Run("paginates", func(t *testing.T) {
+		stations, err := browser.GetStations(common.StationQueryAll, "", "votes", true, 1, 1, true)
+		assert.NoError(t, err)
+		assert.Equal(t, []common.Station{news}, stations)
+
+		stations, err = browser.GetStations(common.StationQueryAll, "", "votes", true, 10, 1, true)
+		assert.NoError(t, err)
+		assert.Empty(t, stations)
+	})
+
+}
+
+func TestBrowserImpl_SearchStations(t *testing.T) {
+
+	rai := newTestStation("Rai Radio 1", "IT", "news", 30)
+	rai.Languages = "italian"
+	raiEnglish := newTestStation("Rai Italia English", "IT", "news", 20)
+	raiEnglish.Languages = "english,italian"
+	bbc := newTestStation("BBC Radio 4", "GB", "news", 10)
+	bbc.Languages = "english"
+	bbc.Bitrate = 320
+
+	browser := NewBrowser([]common.Station{rai, raiEnglish, bbc})
+
+	testCases := []struct {
+		name     string
+		term     string
+		filter   common.StationFilter
+		expected []common.Station
+	}{
+		{"without filter", "radio", common.StationFilter{}, []common.Station{rai, bbc}},
+		{"by country code", "rai", common.StationFilter{CountryCode: "it"}, []common.Station{rai, raiEnglish}},
+		{"by language", "", common.StationFilter{Language: "english"}, []common.Station{raiEnglish, bbc}},
+		{"by country code and language", "", common.StationFilter{CountryCode: "IT", Language: "english"}, []common.Station{raiEnglish}},
+		{"by tag and bitrate", "", common.StationFilter{Tag: "NEWS", BitrateMin: 129}, []common.Station{bbc}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stations, err := browser.SearchStations(tc.term, tc.filter, "votes", true, 0, 100, true)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, stations)
+		})
+	}
+
+}
+
+func TestBrowserImpl_GetTags(t *testing.T) {
+
+	browser := NewBrowser([]common.Station{
+		newTestStation("One", "IT", "jazz,Rock", 0),
+		newTestStation("Two", "IT", "rock", 0),
+	})
+
+	tags, err := browser.GetTags("", "stationcount", true, 0, 10, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Tag{{Name: "rock", StationCount: 2}, {Name: "jazz", StationCount: 1}}, tags)
+
+}
+
+func TestBrowserImpl_GetCountries(t *testing.T) {
+
+	one := newTestStation("One", "IT", "", 0)
+	one.Country = "Italy"
+	two := newTestStation("Two", "it", "", 0)
+	three := newTestStation("Three", "DE", "", 0)
+	three.Country = "Germany"
+
+	countries, err := NewBrowser([]common.Station{one, two, three}).GetCountries(true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Country{{Name: "Germany", Code: "DE", StationCount: 1}, {Name: "Italy", Code: "IT", StationCount: 2}}, countries)
+
+}
+
+func TestBrowserImpl_GetLanguages(t *testing.T) {
+
+	one := newTestStation("One", "IT", "", 0)
+	one.Languages, one.LanguagesCodes = "Italian,english", "it,en"
+	two := newTestStation("Two", "IT", "", 0)
+	two.Languages = "italian"
+
+	languages, err := NewBrowser([]common.Station{one, two}).GetLanguages(true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Language{{Name: "english", Code: "en", StationCount: 1}, {Name: "italian", Code: "it", StationCount: 2}}, languages)
+
+}
+
+func TestBrowserImpl_GetTopStations(t *testing.T) {
+
+	popular := newTestStation("Popular", "IT", "", 10)
+	clicked := newTestStation("Clicked", "IT", "", 1)
+	clicked.ClickCount = 500
+	abroad := newTestStation("Abroad", "DE", "", 99)
+	browser := NewBrowser([]common.Station{popular, clicked, abroad})
+
+	voted, err := browser.GetTopStations(common.StationChartTopVote, "it", 10, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Station{popular, clicked}, voted)
+
+	top, err := browser.GetTopStations(common.StationChartTopClick, "", 1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Station{clicked}, top)
+
+}
+
+func TestSync(t *testing.T) {
+
+	page := make([]common.Station, syncPageSize)
+	for i := range page {
+		page[i] = newTestStation("Italian", "IT", "", 0)
+	}
+	jazz := newTestStation("Jazz", "US", "jazz", 0)
+
+	var requests []string
+
+	browser := mocks.MockRadioBrowserService{
+		GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+			requests = append(requests, string(stationQuery)+"/"+searchTerm)
+			switch {
+			case stationQuery == common.StationQueryByCountryCodeExact && offset == 0:
+				return page, nil
+			case stationQuery == common.StationQueryByTagExact:
+				// The first Italian station is also tagged jazz: it must be stored once.
+				return []common.Station{page[0], jazz}, nil
+			}
+			return []common.Station{}, nil
+		},
+	}
+
+	catalog, err := OpenCatalog(filepath.Join(t.TempDir(), "catalog.db"))
+	assert.NoError(t, err)
+	defer catalog.Close()
+
+	progress := map[string]int{}
+	count, err := Sync(&browser, catalog, []string{"IT"}, []string{"jazz"}, func(term string, count int) {
+		progress[term] = count
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, syncPageSize+1, count)
+	assert.Equal(t, []string{"bycountrycodeexact/IT", "bycountrycodeexact/IT", "bytagexact/jazz"}, requests)
+	assert.Equal(t, map[string]int{"IT": syncPageSize, "jazz": 2}, progress)
+
+	stations, err := catalog.Stations()
+	assert.NoError(t, err)
+	assert.Len(t, stations, syncPageSize+1)
+
+}
+
+func TestFallbackBrowser(t *testing.T) {
+
+	snapshot := []common.Station{newTestStation("Offline", "IT", "", 0)}
+	live := []common.Station{newTestStation("Online", "IT", "", 0)}
+
+	t.Run("prefers the online results", func(t *testing.T) {
+		online := mocks.MockRadioBrowserService{
+			GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				return live, nil
+			},
+		}
+		browser := NewFallbackBrowser(&online, NewBrowser(snapshot))
+
+		stations, err := browser.GetStations(common.StationQueryAll, "", "votes", true, 0, 10, true)
+		assert.NoError(t, err)
+		assert.Equal(t, live, stations)
+	})
+
+	t.Run("answers from the snapshot when the API fails", func(t *testing.T) {
+		online := mocks.MockRadioBrowserService{
+			GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				return nil, errors.New("network is unreachable")
+			},
+		}
+		browser := NewFallbackBrowser(&online, NewBrowser(snapshot))
+
+		stations, err := browser.GetStations(common.StationQueryAll, "", "votes", true, 0, 10, true)
+		assert.NoError(t, err)
+		assert.Equal(t, snapshot, stations)
+	})
+
+	t.Run("answers from the snapshot without an API", func(t *testing.T) {
+		browser := NewFallbackBrowser(nil, NewBrowser(snapshot))
+
+		stations, err := browser.GetStations(common.StationQueryAll, "", "votes", true, 0, 10, true)
+		assert.NoError(t, err)
+		assert.Equal(t, snapshot, stations)
+	})
+
+	t.Run("streams from the snapshot when the API can't stream", func(t *testing.T) {
+		online := mocks.MockRadioBrowserService{
+			GetStationsFunc: func(stationQuery common.StationQuery, searchTerm string, order string, reverse bool, offset uint64, limit uint64, hideBroken bool) ([]common.Station, error) {
+				return nil, errors.New("network is unreachable")
+			},
+		}
+		browser := NewFallbackBrowser(&online, NewBrowser(snapshot))
+
+		var stations []common.Station
+		err := browser.(api.StationStreamer).StreamStations(common.StationQueryAll, "", "votes", true, 0, 10, true, func(station common.Station) error {
+			stations = append(stations, station)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, snapshot, stations)
+	})
+
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package offline
+
+import (
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+// How many stations to request at a time while syncing.
+const syncPageSize = 500
+
+// Sync downloads every working station of the given countries (ISO 3166-1 alpha-2 codes)
+// and tags, and replaces the snapshot in catalog with them.
+// progress, if not nil, is called after each country or tag with the number of stations found for it.
+// Returns the number of distinct stations stored.
+func Sync(
+	browser api.RadioBrowserService,
+	catalog *Catalog,
+	countries []string,
+	tags []string,
+	progress func(term string, count int),
+) (int, error) {
+
+	seen := make(map[string]bool)
+	var stations []common.Station
+
+	fetch := func(query common.StationQuery, term string) error {
+		count := 0
+		for offset := uint64(0); ; offset += syncPageSize {
+			page, err := browser.GetStations(query, term, "votes", true, offset, syncPageSize, true)
+			if err != nil {
+				return err
+			}
+			for _, station := range page {
+				count++
+				if !seen[station.StationUuid.String()] {
+					seen[station.StationUuid.String()] = true
+					stations = append(stations, station)
+				}
+			}
+			if len(page) < syncPageSize {
+				break
+			}
+		}
+		if progress != nil {
+			progress(term, count)
+		}
+		return nil
+	}
+
+	for _, country := range countries {
+		if err := fetch(common.StationQueryByCountryCodeExact, country); err != nil {
+			return 0, err
+		}
+	}
+	for _, tag := range tags {
+		if err := fetch(common.StationQueryByTagExact, tag); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := catalog.Replace(stations, time.Now()); err != nil {
+		return 0, err
+	}
+	return len(stations), nil
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package opml reads and writes station lists in the OPML format
+// used by many radio directories and players.
+package opml
+
+import (
+	"encoding/xml"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+// Document is an OPML 2.0 document.
+type Document struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+// Outline is an entry of an OPML document.
+// Directories disagree on the attribute holding the stream URL, so all the common ones are read.
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	URL      string    `xml:"URL,attr,omitempty"`
+	LowerURL string    `xml:"url,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Name returns the display name of the outline.
+func (o Outline) Name() string {
+	if o.Text != "" {
+		return o.Text
+	}
+	return o.Title
+}
+
+// StreamURL returns the stream URL of the outline, or an empty string if it has none.
+func (o Outline) StreamURL() string {
+	for _, u := range []string{o.URL, o.LowerURL, o.XMLURL} {
+		if u != "" {
+			return u
+		}
+	}
+	return ""
+}
+
+// Read decodes an OPML document and returns its station entries.
+// Nested outlines (folders) are flattened, and folders themselves are skipped.
+func Read(r io.Reader) ([]Outline, error) {
+	var doc Document
+	err := xml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+	return flatten(doc.Body.Outlines), nil
+}
+
+func flatten(outlines []Outline) []Outline {
+	var entries []Outline
+	for _, outline := range outlines {
+		if len(outline.Outlines) > 0 {
+			entries = append(entries, flatten(outline.Outlines)...)
+			continue
+		}
+		if outline.StreamURL() != "" || outline.Name() != "" {
+			entries = append(entries, outline)
+		}
+	}
+	return entries
+}
+
+// Write encodes the given stations as an OPML document with the given title.
+func Write(w io.Writer, title string, stations []common.Station) error {
+
+	doc := Document{
+		Version: "2.0",
+		Head: Head{
+			Title:       title,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	for _, station := range stations {
+		doc.Body.Outlines = append(doc.Body.Outlines, Outline{
+			Text: station.Name,
+			Type: "audio",
+			URL:  stationURL(station),
+		})
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(doc)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// stationURL prefers the URL submitted for the station, which other tools can resolve themselves.
+func stationURL(station common.Station) string {
+	if station.Url.URL != (url.URL{}) {
+		return station.Url.URL.String()
+	}
+	return station.UrlResolved.URL.String()
+}
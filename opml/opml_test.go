@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package opml
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleOPML = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head><title>My radios</title></head>
+  <body>
+    <outline text="Jazz">
+      <outline type="audio" text="Jazz FM" URL="http://example.com/jazz"/>
+      <outline type="link" title="Smooth" url="http://example.com/smooth"/>
+    </outline>
+    <outline type="audio" text="Rock Radio" xmlUrl="http://example.com/rock"/>
+  </body>
+</opml>`
+
+func TestRead(t *testing.T) {
+
+	t.Run("flattens folders and reads every URL attribute", func(t *testing.T) {
+
+		outlines, err := Read(strings.NewReader(sampleOPML))
+		assert.NoError(t, err)
+
+		assert.Len(t, outlines, 3)
+		assert.Equal(t, "Jazz FM", outlines[0].Name())
+		assert.Equal(t, "http://example.com/jazz", outlines[0].StreamURL())
+		assert.Equal(t, "Smooth", outlines[1].Name())
+		assert.Equal(t, "http://example.com/smooth", outlines[1].StreamURL())
+		assert.Equal(t, "Rock Radio", outlines[2].Name())
+		assert.Equal(t, "http://example.com/rock", outlines[2].StreamURL())
+
+	})
+
+	t.Run("returns an error for invalid documents", func(t *testing.T) {
+
+		_, err := Read(strings.NewReader("<opml"))
+		assert.Error(t, err)
+
+	})
+
+}
+
+func TestWrite(t *testing.T) {
+
+	streamUrl, _ := url.Parse("http://example.com/stream")
+	stations := []common.Station{
+		{Name: "Example & Co", Url: common.RadioGoGoURL{URL: *streamUrl}},
+	}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, Write(&buffer, "Bookmarks", stations))
+
+	outlines, err := Read(&buffer)
+	assert.NoError(t, err)
+
+	assert.Len(t, outlines, 1)
+	assert.Equal(t, "Example & Co", outlines[0].Name())
+	assert.Equal(t, "audio", outlines[0].Type)
+	assert.Equal(t, "http://example.com/stream", outlines[0].StreamURL())
+
+}
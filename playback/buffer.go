@@ -0,0 +1,169 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// timeshiftChunk is a piece of stream, as received.
+type timeshiftChunk struct {
+	offset int64
+	at     time.Time
+	data   []byte
+}
+
+// timeshiftBuffer keeps the last stretch of a stream, remembering when each piece of it arrived.
+// Offsets count the bytes received since the stream started, so they stay valid as old data is dropped.
+// It's an io.WriteCloser, to be used as a streamTee sink.
+type timeshiftBuffer struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	window time.Duration
+	chunks []timeshiftChunk
+	// Offset of the first byte kept
+	start int64
+	// Offset of the byte after the last one received
+	end    int64
+	closed bool
+
+	now func() time.Time
+}
+
+func newTimeshiftBuffer(window time.Duration) *timeshiftBuffer {
+	b := &timeshiftBuffer{
+		window: window,
+		now:    time.Now,
+	}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Write appends data to the buffer, dropping what arrived more than the window ago.
+func (b *timeshiftBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	data := make([]byte, len(p))
+	copy(data, p)
+	b.chunks = append(b.chunks, timeshiftChunk{offset: b.end, at: now, data: data})
+	b.end += int64(len(p))
+
+	dropped := 0
+	for dropped < len(b.chunks)-1 && now.Sub(b.chunks[dropped].at) > b.window {
+		b.start += int64(len(b.chunks[dropped].data))
+		// Cleared so that the data can be freed, while the rest of the array is still in use
+		b.chunks[dropped] = timeshiftChunk{}
+		dropped++
+	}
+	// Reslicing rather than copying: once the array is full, append moves the chunks kept to a new one
+	b.chunks = b.chunks[dropped:]
+
+	b.cond.Broadcast()
+	return len(p), nil
+}
+
+// Close marks the end of the stream, waking up the readers.
+func (b *timeshiftBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.cond.Broadcast()
+	return nil
+}
+
+// chunkAt returns the index of the chunk holding offset. The lock must be held.
+func (b *timeshiftBuffer) chunkAt(offset int64) int {
+	return sort.Search(len(b.chunks), func(i int) bool {
+		return b.chunks[i].offset+int64(len(b.chunks[i].data)) > offset
+	})
+}
+
+// read returns the data from offset to the end of its chunk and when it arrived, blocking until
+// there is some. An offset that has already been dropped reads from the oldest data instead.
+// It returns io.EOF once the stream has ended and everything has been read.
+func (b *timeshiftBuffer) read(offset int64) (data []byte, at time.Time, next int64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for offset >= b.end && !b.closed {
+		b.cond.Wait()
+	}
+	if offset >= b.end {
+		return nil, time.Time{}, offset, io.EOF
+	}
+	if offset < b.start {
+		offset = b.start
+	}
+
+	chunk := b.chunks[b.chunkAt(offset)]
+	data = chunk.data[offset-chunk.offset:]
+	return data, chunk.at, offset + int64(len(data)), nil
+}
+
+// arrival returns when the data at offset arrived, or the current time past the live edge.
+func (b *timeshiftBuffer) arrival(offset int64) time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if offset >= b.end || len(b.chunks) == 0 {
+		return b.now()
+	}
+	if offset < b.start {
+		offset = b.start
+	}
+	return b.chunks[b.chunkAt(offset)].at
+}
+
+// offsetAt returns the offset of the first data that arrived at or after t,
+// or the live edge if nothing did.
+func (b *timeshiftBuffer) offsetAt(t time.Time) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	i := sort.Search(len(b.chunks), func(i int) bool {
+		return !b.chunks[i].at.Before(t)
+	})
+	if i == len(b.chunks) {
+		return b.end
+	}
+	return b.chunks[i].offset
+}
+
+// live returns the offset of the live edge.
+func (b *timeshiftBuffer) live() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.end
+}
+
+// oldest returns when the oldest data kept arrived.
+func (b *timeshiftBuffer) oldest() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.chunks) == 0 {
+		return b.now()
+	}
+	return b.chunks[0].at
+}
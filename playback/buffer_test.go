@@ -0,0 +1,192 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clockedBuffer returns a timeshift buffer whose clock is moved by hand, from the time returned.
+func clockedBuffer(window time.Duration) (*timeshiftBuffer, *time.Time) {
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	b := newTimeshiftBuffer(window)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestTimeshiftBuffer(t *testing.T) {
+
+	t.Run("reads the data in the order it was written", func(t *testing.T) {
+
+		b, now := clockedBuffer(time.Minute)
+		start := *now
+		_, _ = b.Write([]byte("abc"))
+		*now = now.Add(time.Second)
+		_, _ = b.Write([]byte("de"))
+
+		data, at, next, err := b.read(0)
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", string(data))
+		assert.True(t, start.Equal(at))
+		assert.Equal(t, int64(3), next)
+
+		data, at, next, err = b.read(next)
+		assert.NoError(t, err)
+		assert.Equal(t, "de", string(data))
+		assert.True(t, start.Add(time.Second).Equal(at))
+		assert.Equal(t, int64(5), next)
+
+		data, _, _, err = b.read(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "bc", string(data))
+		assert.Equal(t, int64(5), b.live())
+
+	})
+
+	t.Run("keeps its own copy of the data written", func(t *testing.T) {
+
+		b, _ := clockedBuffer(time.Minute)
+		p := []byte("abc")
+		_, _ = b.Write(p)
+		p[0] = 'x'
+
+		data, _, _, err := b.read(0)
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", string(data))
+
+	})
+
+	t.Run("tells when the data at an offset arrived, and which offset arrived when", func(t *testing.T) {
+
+		b, now := clockedBuffer(time.Minute)
+		start := *now
+		_, _ = b.Write([]byte("abc"))
+		*now = now.Add(10 * time.Second)
+		_, _ = b.Write([]byte("de"))
+		*now = now.Add(10 * time.Second)
+
+		assert.True(t, start.Equal(b.arrival(2)))
+		assert.True(t, start.Add(10*time.Second).Equal(b.arrival(3)))
+		// Past the live edge, it's now
+		assert.True(t, now.Equal(b.arrival(5)))
+
+		assert.Equal(t, int64(0), b.offsetAt(start.Add(-time.Second)))
+		assert.Equal(t, int64(3), b.offsetAt(start.Add(time.Second)))
+		assert.Equal(t, int64(3), b.offsetAt(start.Add(10*time.Second)))
+		assert.Equal(t, int64(5), b.offsetAt(start.Add(15*time.Second)))
+		assert.True(t, start.Equal(b.oldest()))
+
+	})
+
+	t.Run("drops the oldest data once it's out of the window", func(t *testing.T) {
+
+		b, now := clockedBuffer(10 * time.Second)
+		start := *now
+		_, _ = b.Write([]byte("abc"))
+		*now = now.Add(5 * time.Second)
+		_, _ = b.Write([]byte("de"))
+		*now = now.Add(10 * time.Second)
+		_, _ = b.Write([]byte("f"))
+
+		assert.Len(t, b.chunks, 2)
+		assert.True(t, start.Add(5*time.Second).Equal(b.oldest()))
+		// What was dropped reads from the oldest data kept
+		data, _, next, err := b.read(0)
+		assert.NoError(t, err)
+		assert.Equal(t, "de", string(data))
+		assert.Equal(t, int64(5), next)
+		assert.True(t, start.Add(5*time.Second).Equal(b.arrival(1)))
+		assert.Equal(t, int64(6), b.live())
+
+	})
+
+	t.Run("keeps the latest data even when it's out of the window", func(t *testing.T) {
+
+		b, now := clockedBuffer(time.Second)
+		_, _ = b.Write([]byte("abc"))
+		*now = now.Add(time.Minute)
+		_, _ = b.Write([]byte("de"))
+
+		data, _, _, err := b.read(0)
+		assert.NoError(t, err)
+		assert.Equal(t, "de", string(data))
+
+	})
+
+	t.Run("reads what's left, then io.EOF once closed", func(t *testing.T) {
+
+		b, _ := clockedBuffer(time.Minute)
+		_, _ = b.Write([]byte("abc"))
+		assert.NoError(t, b.Close())
+
+		data, _, next, err := b.read(0)
+		assert.NoError(t, err)
+		assert.Equal(t, "abc", string(data))
+
+		_, _, _, err = b.read(next)
+		assert.Equal(t, io.EOF, err)
+
+	})
+
+	t.Run("wakes up the readers waiting for data when closed", func(t *testing.T) {
+
+		b, _ := clockedBuffer(time.Minute)
+		done := make(chan error)
+		go func() {
+			_, _, _, err := b.read(0)
+			done <- err
+		}()
+
+		assert.NoError(t, b.Close())
+
+		select {
+		case err := <-done:
+			assert.Equal(t, io.EOF, err)
+		case <-time.After(time.Second):
+			t.Fatal("the reader wasn't woken up")
+		}
+
+	})
+
+	t.Run("wakes up the readers waiting for data when written to", func(t *testing.T) {
+
+		b, _ := clockedBuffer(time.Minute)
+		done := make(chan string)
+		go func() {
+			data, _, _, _ := b.read(0)
+			done <- string(data)
+		}()
+
+		_, _ = b.Write([]byte("abc"))
+
+		select {
+		case data := <-done:
+			assert.Equal(t, "abc", data)
+		case <-time.After(time.Second):
+			t.Fatal("the reader wasn't woken up")
+		}
+
+	})
+
+}
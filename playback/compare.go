@@ -0,0 +1,123 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"fmt"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+var (
+	// ErrNotComparing is returned when switching between compared stations while none are.
+	ErrNotComparing = i18n.Error("playback.notComparing")
+	// ErrComparedStopped is returned when switching to a compared station that stopped meanwhile.
+	ErrComparedStopped = i18n.Error("playback.comparedStopped")
+	// ErrCannotCompare is returned when comparing stations that can't both be kept connected, see Comparer.CanCompare.
+	ErrCannotCompare = i18n.Error("playback.cannotCompare")
+)
+
+// Comparer is implemented by playback managers that can keep a second station connected, muted,
+// next to the one being played, so that switching between the two is instant.
+// Playing or stopping a station ends the comparison.
+type Comparer interface {
+	// Compare plays station like PlayStation, keeping other connected at otherVolume, muted.
+	Compare(station common.Station, volume int, other common.Station, otherVolume int) error
+	// SwitchCompared hears the muted station and mutes the one being played, keeping both connected.
+	SwitchCompared() error
+	// CanCompare returns true if station and other can both be kept connected, as when their preferences
+	// don't ask for players that can't.
+	CanCompare(station common.Station, other common.Station) bool
+}
+
+// Compare starts other muted, then station, so that station is the current process of the two.
+// The level meter follows neither while comparing, as it can't tell which one is heard.
+func (d *MPVPlaybackManager) Compare(station common.Station, volume int, other common.Station, otherVolume int) error {
+	if err := d.StopStation(); err != nil {
+		return err
+	}
+	options := d.options
+	options.Levels = nil
+	comparedIPCPath := newIPCPath()
+	compared, err := d.start(options, other, d.heardVolume(otherVolume), comparedIPCPath, false, "--mute=yes")
+	if err != nil {
+		return err
+	}
+	ipcPath := newIPCPath()
+	proc, err := d.start(options, station, d.heardVolume(volume), ipcPath, false)
+	if err != nil {
+		_ = stopProcess(compared)
+		removeIPC(comparedIPCPath)
+		return err
+	}
+	d.nowPlaying, d.volume, d.ipcPath = proc, volume, ipcPath
+	d.compared, d.comparedIPCPath = compared, comparedIPCPath
+	return nil
+}
+
+func (d *MPVPlaybackManager) CanCompare(station common.Station, other common.Station) bool {
+	return true
+}
+
+func (d *MPVPlaybackManager) SwitchCompared() error {
+	if d.compared == nil || d.nowPlaying == nil {
+		return ErrNotComparing
+	}
+	if d.compared.hasExited() {
+		_ = d.stopCompared()
+		return ErrComparedStopped
+	}
+	if err := setMPVProperty(d.comparedIPCPath, "mute", "false"); err != nil {
+		return err
+	}
+	if err := setMPVProperty(d.ipcPath, "mute", "true"); err != nil {
+		return err
+	}
+	supervisor.makeCurrent(d.compared)
+	d.nowPlaying, d.compared = d.compared, d.nowPlaying
+	d.ipcPath, d.comparedIPCPath = d.comparedIPCPath, d.ipcPath
+	return nil
+}
+
+// stopCompared stops the station kept connected while comparing, if any.
+func (d *MPVPlaybackManager) stopCompared() error {
+	if d.compared == nil {
+		return nil
+	}
+	if err := stopProcess(d.compared); err != nil {
+		return err
+	}
+	d.compared = nil
+	removeIPC(d.comparedIPCPath)
+	d.comparedIPCPath = ""
+	return nil
+}
+
+// setMPVProperty sets a property of the mpv instance listening on path to value, a JSON value.
+func setMPVProperty(path string, property string, value string) error {
+	conn, err := dialIPC(path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = fmt.Fprintf(conn, "{\"command\": [\"set_property\", %q, %s]}\n", property, value)
+	return err
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// How many volume changes a fade out is made of.
+const crossfadeSteps = 20
+
+// Numbers the mpv IPC sockets opened by this process.
+var ipcCounter int64
+
+// fadeInFilter returns the audio filter fading a station in over duration.
+func fadeInFilter(duration time.Duration) string {
+	return fmt.Sprintf("afade=t=in:d=%.2f", duration.Seconds())
+}
+
+// newIPCPath returns a unique path for an mpv IPC server:
+// a named pipe on Windows, a Unix socket in the temporary directory elsewhere.
+func newIPCPath() string {
+	name := fmt.Sprintf("radiogogo-mpv-%d-%d", os.Getpid(), atomic.AddInt64(&ipcCounter, 1))
+	if runtime.GOOS == "windows" {
+		return `\\.\pipe\` + name
+	}
+	return filepath.Join(os.TempDir(), name+".sock")
+}
+
+// dialIPC connects to the mpv IPC server at path.
+func dialIPC(path string) (io.ReadWriteCloser, error) {
+	if runtime.GOOS == "windows" {
+		return os.OpenFile(path, os.O_RDWR, 0)
+	}
+	return net.Dial("unix", path)
+}
+
+// fadeOutMPV lowers the volume of the mpv instance listening on path from volume to silence, over duration.
+// If mpv can't be reached, the station just keeps playing for duration.
+func fadeOutMPV(path string, volume int, duration time.Duration) {
+	step := duration / crossfadeSteps
+	conn, err := dialIPC(path)
+	if err != nil {
+		time.Sleep(duration)
+		return
+	}
+	defer conn.Close()
+
+	for i := 1; i <= crossfadeSteps; i++ {
+		time.Sleep(step)
+		level := volume * (crossfadeSteps - i) / crossfadeSteps
+		_, err := fmt.Fprintf(conn, "{\"command\": [\"set_property\", \"volume\", %d]}\n", level)
+		if err != nil {
+			time.Sleep(time.Duration(crossfadeSteps-i) * step)
+			return
+		}
+	}
+}
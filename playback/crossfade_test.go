@@ -0,0 +1,104 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFadeInFilter(t *testing.T) {
+
+	assert.Equal(t, "afade=t=in:d=3.00", fadeInFilter(3*time.Second))
+	assert.Equal(t, "afade=t=in:d=1.50", fadeInFilter(1500*time.Millisecond))
+	assert.Equal(t, "afade=t=in:d=0.25", fadeInFilter(250*time.Millisecond))
+
+}
+
+func TestFadeOutMPV(t *testing.T) {
+
+	if runtime.GOOS == "windows" {
+		t.Skip("mpv listens on a named pipe on Windows")
+	}
+
+	t.Run("lowers the volume step by step down to silence", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "mpv.sock")
+		listener, err := net.Listen("unix", path)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer listener.Close()
+
+		// The fake mpv records the volumes it's set to
+		volumes := make(chan []int)
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				volumes <- nil
+				return
+			}
+			defer conn.Close()
+			var set []int
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				var request struct {
+					Command []interface{} `json:"command"`
+				}
+				if json.Unmarshal(scanner.Bytes(), &request) != nil || len(request.Command) != 3 || request.Command[0] != "set_property" || request.Command[1] != "volume" {
+					continue
+				}
+				set = append(set, int(request.Command[2].(float64)))
+			}
+			volumes <- set
+		}()
+
+		fadeOutMPV(path, 100, 40*time.Millisecond)
+		listener.Close()
+
+		set := <-volumes
+		if assert.Len(t, set, crossfadeSteps) {
+			assert.Equal(t, 95, set[0])
+			assert.Equal(t, 0, set[len(set)-1])
+			for i := 1; i < len(set); i++ {
+				assert.Less(t, set[i], set[i-1])
+			}
+		}
+
+	})
+
+	t.Run("waits out the crossfade when mpv can't be reached", func(t *testing.T) {
+
+		start := time.Now()
+
+		fadeOutMPV(filepath.Join(t.TempDir(), "missing.sock"), 100, 30*time.Millisecond)
+
+		assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	})
+
+}
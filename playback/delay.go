@@ -0,0 +1,196 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How long mpv may take to answer over its IPC server.
+const mpvIPCTimeout = time.Second
+
+// How many target durations from the live edge HLS players start playing, as the specification recommends.
+const hlsHoldBackSegments = 3
+
+// DelaySource tells how a stream delay was estimated, from the roughest to the most accurate.
+type DelaySource int
+
+const (
+	// DelayConfigured is estimated from the buffering options, the player not telling how much it buffers.
+	DelayConfigured DelaySource = iota
+	// DelayBuffered is measured from the audio the player has buffered.
+	DelayBuffered
+	// DelayTimestamped is measured from the timestamps of the stream, as those of HLS segments, against the clock.
+	DelayTimestamped
+)
+
+// StreamDelay is an estimate of how long ago the audio heard was broadcast.
+type StreamDelay struct {
+	Delay  time.Duration
+	Source DelaySource
+}
+
+// DelayEstimator is implemented by playback managers that can tell how far behind the broadcast
+// the station being played is heard.
+type DelayEstimator interface {
+	// EstimatedDelay returns the delay of the station being played, or false if it can't be told.
+	// It may take a moment, e.g. to ask the player.
+	EstimatedDelay() (StreamDelay, bool)
+}
+
+// EstimatedDelay returns the delay of the station played by player, or false if it can't be told,
+// as when player isn't a DelayEstimator.
+func EstimatedDelay(player PlaybackManagerService) (StreamDelay, bool) {
+	estimator, ok := player.(DelayEstimator)
+	if !ok {
+		return StreamDelay{}, false
+	}
+	return estimator.EstimatedDelay()
+}
+
+// configuredDelay returns the delay expected from the buffering options, if set.
+func (o Options) configuredDelay() (StreamDelay, bool) {
+	if o.BufferSeconds <= 0 {
+		return StreamDelay{}, false
+	}
+	return StreamDelay{Delay: time.Duration(o.BufferSeconds) * time.Second, Source: DelayConfigured}, true
+}
+
+// EstimatedDelay measures the audio queued by ffplay, as reported by its status lines, at the bitrate
+// of the station.
+func (d *FFPlayPlaybackManager) EstimatedDelay() (StreamDelay, bool) {
+	if d.nowPlaying == nil {
+		return StreamDelay{}, false
+	}
+	if queued, ok := audioQueueSize(d.nowPlaying.lastStatus()); ok && d.bitrate > 0 {
+		seconds := float64(queued*8) / float64(d.bitrate*1000)
+		return StreamDelay{Delay: time.Duration(seconds * float64(time.Second)), Source: DelayBuffered}, true
+	}
+	return d.options.configuredDelay()
+}
+
+// audioQueueSize reads the size of the audio queue, in bytes, from a status line of ffplay
+// such as "5.31 M-A: -0.000 fd= 0 aq= 17KB vq= 0KB sq= 0B f=0/0".
+func audioQueueSize(line string) (uint64, bool) {
+	_, rest, found := strings.Cut(line, "aq=")
+	if !found {
+		return 0, false
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || !strings.HasSuffix(fields[0], "KB") {
+		return 0, false
+	}
+	kilobytes, err := strconv.ParseUint(strings.TrimSuffix(fields[0], "KB"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return kilobytes * 1024, true
+}
+
+// EstimatedDelay asks mpv how much audio it has cached ahead of what's heard.
+func (d *MPVPlaybackManager) EstimatedDelay() (StreamDelay, bool) {
+	if d.nowPlaying == nil {
+		return StreamDelay{}, false
+	}
+	if d.ipcPath != "" {
+		value, err := getMPVProperty(d.ipcPath, "demuxer-cache-duration")
+		var seconds float64
+		if err == nil && json.Unmarshal(value, &seconds) == nil {
+			return StreamDelay{Delay: time.Duration(seconds * float64(time.Second)), Source: DelayBuffered}, true
+		}
+	}
+	return d.options.configuredDelay()
+}
+
+// getMPVProperty returns the value of a property of the mpv instance listening on path, as JSON.
+func getMPVProperty(path string, property string) (json.RawMessage, error) {
+	conn, err := dialIPC(path)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadliner, ok := conn.(interface{ SetDeadline(t time.Time) error }); ok {
+		_ = deadliner.SetDeadline(time.Now().Add(mpvIPCTimeout))
+	}
+
+	_, err = fmt.Fprintf(conn, "{\"command\": [\"get_property\", %q]}\n", property)
+	if err != nil {
+		return nil, err
+	}
+
+	// Events may come before the reply, which is the line with an error field
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var reply struct {
+			Data  json.RawMessage `json:"data"`
+			Error string          `json:"error"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &reply) != nil || reply.Error == "" {
+			continue
+		}
+		if reply.Error != "success" {
+			return nil, errors.New(reply.Error)
+		}
+		return reply.Data, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("mpv closed the connection without replying")
+}
+
+// EstimatedDelay adds how far behind live the station is played to what the player buffers.
+func (d *TimeshiftPlaybackManager) EstimatedDelay() (StreamDelay, bool) {
+	delay, ok := EstimatedDelay(d.player)
+	if !ok {
+		return StreamDelay{}, false
+	}
+	delay.Delay += d.Delay()
+	return delay, true
+}
+
+func (d *RelayPlaybackManager) EstimatedDelay() (StreamDelay, bool) {
+	return EstimatedDelay(d.player)
+}
+
+// EstimatedDelay adds how late the newest segment of an HLS station was published, by its timestamps,
+// to the audio the player has buffered up to it, or to the usual hold-back of players if it can't tell.
+// Other stations are left to the player.
+func (d *HLSPlaybackManager) EstimatedDelay() (StreamDelay, bool) {
+	delay, ok := EstimatedDelay(d.player)
+
+	d.mu.Lock()
+	latency, holdBack, timestamped := d.latency, d.holdBack, d.timestamped
+	d.mu.Unlock()
+
+	if !timestamped {
+		return delay, ok
+	}
+	if !ok || delay.Source != DelayBuffered {
+		delay.Delay = holdBack
+	}
+	return StreamDelay{Delay: delay.Delay + latency, Source: DelayTimestamped}, true
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// estimatingEngine is a fake engine telling a fixed delay.
+type estimatingEngine struct {
+	*fakeEngine
+	delay StreamDelay
+	known bool
+}
+
+func (e *estimatingEngine) EstimatedDelay() (StreamDelay, bool) {
+	return e.delay, e.known
+}
+
+// fakeMPVProperty listens on a socket like mpv's IPC server, answering every request with reply
+// after an event, and returns its path.
+func fakeMPVProperty(t *testing.T, reply string) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("mpv listens on a named pipe on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "mpv.sock")
+	listener, err := net.Listen("unix", path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			scanner := bufio.NewScanner(conn)
+			if scanner.Scan() {
+				fmt.Fprintln(conn, `{"event":"audio-reconfig"}`)
+				fmt.Fprintln(conn, reply)
+			}
+			conn.Close()
+		}
+	}()
+	return path
+}
+
+func TestAudioQueueSize(t *testing.T) {
+
+	tests := []struct {
+		line  string
+		size  uint64
+		known bool
+	}{
+		{"5.31 M-A: -0.000 fd=   0 aq=   17KB vq=    0KB sq=    0B f=0/0", 17 * 1024, true},
+		{"  12.04 M-A:  0.000 fd=   0 aq=    0KB vq=    0KB sq=    0B f=0/0", 0, true},
+		{"5.31 M-A: -0.000 fd=   0 aq=  512B vq=    0KB sq=    0B f=0/0", 0, false},
+		{"5.31 M-A: -0.000 fd=   0 aq=", 0, false},
+		{"Stream #0:0: Audio: mp3, 44100 Hz, stereo, fltp, 128 kb/s", 0, false},
+	}
+
+	for _, test := range tests {
+		size, known := audioQueueSize(test.line)
+		assert.Equal(t, test.size, size, test.line)
+		assert.Equal(t, test.known, known, test.line)
+	}
+
+}
+
+func TestEstimatedDelay(t *testing.T) {
+
+	t.Run("can't tell for players that don't estimate it", func(t *testing.T) {
+		_, known := EstimatedDelay(&fakeEngine{})
+		assert.False(t, known)
+	})
+
+	t.Run("falls back on the configured buffer", func(t *testing.T) {
+
+		_, known := Options{}.configuredDelay()
+		assert.False(t, known)
+
+		delay, known := Options{BufferSeconds: 4}.configuredDelay()
+		assert.True(t, known)
+		assert.Equal(t, StreamDelay{Delay: 4 * time.Second, Source: DelayConfigured}, delay)
+
+	})
+
+	t.Run("measures the audio queued by ffplay at the bitrate of the station", func(t *testing.T) {
+
+		// 16KB at 128kbps last a second
+		d := &FFPlayPlaybackManager{
+			options:    Options{BufferSeconds: 4},
+			nowPlaying: &process{status: "5.31 M-A: -0.000 fd=   0 aq=   16KB vq=    0KB sq=    0B f=0/0"},
+			bitrate:    128,
+		}
+		delay, known := d.EstimatedDelay()
+		assert.True(t, known)
+		assert.Equal(t, StreamDelay{Delay: 1024 * time.Millisecond, Source: DelayBuffered}, delay)
+
+		// Without a bitrate, the queue can't be timed
+		d.bitrate = 0
+		delay, known = d.EstimatedDelay()
+		assert.True(t, known)
+		assert.Equal(t, StreamDelay{Delay: 4 * time.Second, Source: DelayConfigured}, delay)
+
+		d.nowPlaying = nil
+		_, known = d.EstimatedDelay()
+		assert.False(t, known)
+
+	})
+
+	t.Run("asks mpv how much audio it has cached", func(t *testing.T) {
+
+		d := &MPVPlaybackManager{
+			options:    Options{BufferSeconds: 4},
+			nowPlaying: &process{},
+			ipcPath:    fakeMPVProperty(t, `{"data":2.5,"request_id":0,"error":"success"}`),
+		}
+		delay, known := d.EstimatedDelay()
+		assert.True(t, known)
+		assert.Equal(t, StreamDelay{Delay: 2500 * time.Millisecond, Source: DelayBuffered}, delay)
+
+		// The configured buffer, if mpv can't tell
+		d.ipcPath = fakeMPVProperty(t, `{"request_id":0,"error":"property unavailable"}`)
+		delay, known = d.EstimatedDelay()
+		assert.True(t, known)
+		assert.Equal(t, StreamDelay{Delay: 4 * time.Second, Source: DelayConfigured}, delay)
+
+		d.ipcPath = ""
+		delay, known = d.EstimatedDelay()
+		assert.True(t, known)
+		assert.Equal(t, StreamDelay{Delay: 4 * time.Second, Source: DelayConfigured}, delay)
+
+	})
+
+	t.Run("adds how far behind live the station is timeshifted", func(t *testing.T) {
+
+		d, engine, clock := playingTimeshift(t, 5)
+		d.player = &estimatingEngine{fakeEngine: engine, delay: StreamDelay{Delay: 2 * time.Second, Source: DelayBuffered}, known: true}
+		receive(d, clock, 60)
+		assert.NoError(t, d.Seek(-30*time.Second))
+
+		delay, known := d.EstimatedDelay()
+		assert.True(t, known)
+		assert.Equal(t, StreamDelay{Delay: 32 * time.Second, Source: DelayBuffered}, delay)
+
+		d.player = engine
+		_, known = d.EstimatedDelay()
+		assert.False(t, known)
+
+	})
+
+	t.Run("adds the latency of timestamped HLS stations", func(t *testing.T) {
+
+		tests := []struct {
+			name        string
+			player      StreamDelay
+			known       bool
+			timestamped bool
+			delay       StreamDelay
+			estimated   bool
+		}{
+			{
+				name:        "to the audio buffered",
+				player:      StreamDelay{Delay: 3 * time.Second, Source: DelayBuffered},
+				known:       true,
+				timestamped: true,
+				delay:       StreamDelay{Delay: 8 * time.Second, Source: DelayTimestamped},
+				estimated:   true,
+			},
+			{
+				name:        "to the hold-back, if the player only knows its configuration",
+				player:      StreamDelay{Delay: 3 * time.Second, Source: DelayConfigured},
+				known:       true,
+				timestamped: true,
+				delay:       StreamDelay{Delay: 23 * time.Second, Source: DelayTimestamped},
+				estimated:   true,
+			},
+			{
+				name:        "to the hold-back, if the player can't tell",
+				timestamped: true,
+				delay:       StreamDelay{Delay: 23 * time.Second, Source: DelayTimestamped},
+				estimated:   true,
+			},
+			{
+				name:      "not without timestamps",
+				player:    StreamDelay{Delay: 3 * time.Second, Source: DelayBuffered},
+				known:     true,
+				delay:     StreamDelay{Delay: 3 * time.Second, Source: DelayBuffered},
+				estimated: true,
+			},
+		}
+
+		for _, test := range tests {
+			d := &HLSPlaybackManager{
+				player:      &estimatingEngine{fakeEngine: &fakeEngine{}, delay: test.player, known: test.known},
+				timestamped: test.timestamped,
+				latency:     5 * time.Second,
+				holdBack:    18 * time.Second,
+			}
+			delay, estimated := d.EstimatedDelay()
+			assert.Equal(t, test.delay, delay, test.name)
+			assert.Equal(t, test.estimated, estimated, test.name)
+		}
+
+	})
+
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"strconv"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+// ErrCannotDuck is returned by Duck when the volume of the station being played can't be lowered.
+var ErrCannotDuck = i18n.Error("playback.cannotDuck")
+
+// Ducker is implemented by playback managers that can lower the volume of the station being played
+// for a while, e.g. during a call, and bring it back.
+type Ducker interface {
+	// Duck plays the station being played, and those played next, at level percent of their volume,
+	// until it's called again with 100.
+	Duck(level int) error
+}
+
+// Duck lowers the volume of player to level percent of it (100 brings it back),
+// or returns ErrCannotDuck if player isn't a Ducker.
+func Duck(player PlaybackManagerService, level int) error {
+	ducker, ok := player.(Ducker)
+	if !ok {
+		return ErrCannotDuck
+	}
+	return ducker.Duck(level)
+}
+
+// DuckedVolume returns volume lowered to level percent of it.
+func DuckedVolume(volume int, level int) int {
+	return volume * level / 100
+}
+
+// Duck turns the station being played down through its IPC server.
+func (d *MPVPlaybackManager) Duck(level int) error {
+	d.ducked, d.duckLevel = level < 100, level
+	if d.nowPlaying == nil {
+		return nil
+	}
+	if d.ipcPath == "" {
+		return ErrCannotDuck
+	}
+	return setMPVProperty(d.ipcPath, "volume", strconv.Itoa(d.heardVolume(d.volume)))
+}
+
+// heardVolume returns volume, lowered if ducking.
+func (d *MPVPlaybackManager) heardVolume(volume int) int {
+	if !d.ducked {
+		return volume
+	}
+	return DuckedVolume(volume, d.duckLevel)
+}
+
+func (d *HLSPlaybackManager) Duck(level int) error {
+	return Duck(d.player, level)
+}
+
+func (d *RelayPlaybackManager) Duck(level int) error {
+	return Duck(d.player, level)
+}
+
+func (d *TimeshiftPlaybackManager) Duck(level int) error {
+	return Duck(d.player, level)
+}
@@ -29,3 +29,30 @@ func (p *PlaybackEngineType) UnmarshalYAML(value *yaml.Node) error {
 		return errors.New("invalid playbackEngine value: " + val)
 	}
 }
+
+// OutputMode selects where decoded audio goes.
+type OutputMode string
+
+const (
+	// OutputLocal plays audio on this machine with the configured playback engine.
+	OutputLocal OutputMode = "local"
+	// OutputSnapcast sends raw PCM to a Snapcast server, either through its pipe or a TCP source.
+	OutputSnapcast OutputMode = "snapcast"
+	// OutputIcecast streams the audio to an Icecast mountpoint.
+	OutputIcecast OutputMode = "icecast"
+)
+
+func (o *OutputMode) UnmarshalYAML(value *yaml.Node) error {
+	var val string
+	if err := value.Decode(&val); err != nil {
+		return err
+	}
+
+	switch OutputMode(val) {
+	case OutputLocal, OutputSnapcast, OutputIcecast:
+		*o = OutputMode(val)
+		return nil
+	default:
+		return errors.New("invalid output mode value: " + val)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"sync"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+// EnginePlaybackManager plays each station with the playback engine its preferences ask for,
+// or with the configured one, which also plays the stations whose engine isn't installed.
+// Volumes are in the range of the configured engine, and rescaled for the others.
+type EnginePlaybackManager struct {
+	engines    map[PlaybackEngineType]PlaybackManagerService
+	configured PlaybackManagerService
+
+	// Guards current, which is read while estimating the delay
+	mu sync.Mutex
+	// The engine of the station being played, or of the last one
+	current PlaybackManagerService
+}
+
+// NewEnginePlaybackManager returns a playback manager playing stations with the engine their
+// preferences ask for among engines, or configured.
+// It's a Comparer if any of the engines is, comparing stations with the engine both are played with.
+func NewEnginePlaybackManager(engines map[PlaybackEngineType]PlaybackManagerService, configured PlaybackEngineType) PlaybackManagerService {
+	manager := &EnginePlaybackManager{
+		engines:    engines,
+		configured: engines[configured],
+		current:    engines[configured],
+	}
+	for _, engine := range engines {
+		if _, ok := engine.(Comparer); ok {
+			return &engineComparer{EnginePlaybackManager: manager}
+		}
+	}
+	return manager
+}
+
+// engineComparer is an EnginePlaybackManager with an engine that's a Comparer.
+type engineComparer struct {
+	*EnginePlaybackManager
+}
+
+// comparerFor returns the engine station and other are both played with, if it can compare them.
+func (d *engineComparer) comparerFor(station common.Station, other common.Station) (PlaybackManagerService, Comparer, bool) {
+	engine := d.engineFor(station)
+	if d.engineFor(other) != engine {
+		return nil, nil, false
+	}
+	comparer, ok := engine.(Comparer)
+	if !ok || !comparer.CanCompare(station, other) {
+		return nil, nil, false
+	}
+	return engine, comparer, true
+}
+
+func (d *engineComparer) CanCompare(station common.Station, other common.Station) bool {
+	_, _, ok := d.comparerFor(station, other)
+	return ok
+}
+
+func (d *engineComparer) Compare(station common.Station, volume int, other common.Station, otherVolume int) error {
+	engine, comparer, ok := d.comparerFor(station, other)
+	if !ok {
+		return ErrCannotCompare
+	}
+	if err := d.switchTo(engine); err != nil {
+		return err
+	}
+	return comparer.Compare(station, d.rescaledVolume(engine, volume), other, d.rescaledVolume(engine, otherVolume))
+}
+
+func (d *engineComparer) SwitchCompared() error {
+	comparer, ok := d.engine().(Comparer)
+	if !ok {
+		return ErrNotComparing
+	}
+	return comparer.SwitchCompared()
+}
+
+// engineFor returns the engine station is played with.
+func (d *EnginePlaybackManager) engineFor(station common.Station) PlaybackManagerService {
+	if engine, ok := d.engines[PlaybackEngineType(station.Prefs.Backend)]; ok && engine.IsAvailable() {
+		return engine
+	}
+	return d.configured
+}
+
+// switchTo stops the station being played by another engine than engine, which plays the next one.
+func (d *EnginePlaybackManager) switchTo(engine PlaybackManagerService) error {
+	d.mu.Lock()
+	current := d.current
+	d.current = engine
+	d.mu.Unlock()
+	if current != engine && current.IsPlaying() {
+		return current.StopStation()
+	}
+	return nil
+}
+
+// engine returns the engine of the station being played, or of the last one.
+func (d *EnginePlaybackManager) engine() PlaybackManagerService {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.current
+}
+
+// rescaledVolume returns volume, in the range of the configured engine, in the range of engine.
+func (d *EnginePlaybackManager) rescaledVolume(engine PlaybackManagerService, volume int) int {
+	if engine == d.configured {
+		return volume
+	}
+	from := d.configured.VolumeMax() - d.configured.VolumeMin()
+	if from == 0 {
+		return engine.VolumeDefault()
+	}
+	to := engine.VolumeMax() - engine.VolumeMin()
+	return engine.VolumeMin() + (volume-d.configured.VolumeMin())*to/from
+}
+
+// PreviewCommand tells the command line of the engine station is played with, if it runs one.
+func (d *EnginePlaybackManager) PreviewCommand(station common.Station, volume int) Invocation {
+	engine := d.engineFor(station)
+	invocation, _ := PreviewCommand(engine, station, d.rescaledVolume(engine, volume))
+	return invocation
+}
+
+func (d *EnginePlaybackManager) Name() string {
+	return d.engine().Name()
+}
+
+func (d *EnginePlaybackManager) IsAvailable() bool {
+	return d.configured.IsAvailable()
+}
+
+func (d *EnginePlaybackManager) NotAvailableErrorString() string {
+	return d.configured.NotAvailableErrorString()
+}
+
+func (d *EnginePlaybackManager) IsPlaying() bool {
+	return d.engine().IsPlaying()
+}
+
+func (d *EnginePlaybackManager) PlayStation(station common.Station, volume int) error {
+	engine := d.engineFor(station)
+	if err := d.switchTo(engine); err != nil {
+		return err
+	}
+	return engine.PlayStation(station, d.rescaledVolume(engine, volume))
+}
+
+func (d *EnginePlaybackManager) StopStation() error {
+	return d.engine().StopStation()
+}
+
+func (d *EnginePlaybackManager) VolumeMin() int {
+	return d.configured.VolumeMin()
+}
+
+func (d *EnginePlaybackManager) VolumeDefault() int {
+	return d.configured.VolumeDefault()
+}
+
+func (d *EnginePlaybackManager) VolumeMax() int {
+	return d.configured.VolumeMax()
+}
+
+func (d *EnginePlaybackManager) VolumeIsPercentage() bool {
+	return d.configured.VolumeIsPercentage()
+}
+
+// Duck ducks every engine, so that the stations played next are ducked whichever plays them,
+// and fails if the engine of the station being played can't.
+func (d *EnginePlaybackManager) Duck(level int) error {
+	current := d.engine()
+	for _, engine := range d.engines {
+		if engine != current {
+			_ = Duck(engine, level)
+		}
+	}
+	return Duck(current, level)
+}
+
+func (d *EnginePlaybackManager) EstimatedDelay() (StreamDelay, bool) {
+	return EstimatedDelay(d.engine())
+}
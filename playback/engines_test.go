@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnginePlaybackManagerCompares(t *testing.T) {
+
+	station := func(name string, backend PlaybackEngineType) common.Station {
+		return common.Station{Name: name, Prefs: common.StationPrefs{Backend: string(backend)}}
+	}
+	newManager := func(configured PlaybackEngineType) (Comparer, *comparingEngine, *fakeEngine) {
+		mpv := &comparingEngine{fakeEngine: &fakeEngine{}}
+		ffplay := &fakeEngine{}
+		manager := NewEnginePlaybackManager(map[PlaybackEngineType]PlaybackManagerService{
+			MPV:    mpv,
+			FFPlay: ffplay,
+		}, configured)
+		comparer, ok := manager.(Comparer)
+		assert.True(t, ok)
+		return comparer, mpv, ffplay
+	}
+
+	t.Run("only if an engine can", func(t *testing.T) {
+		_, ok := NewEnginePlaybackManager(map[PlaybackEngineType]PlaybackManagerService{
+			MPV:    &fakeEngine{},
+			FFPlay: &fakeEngine{},
+		}, MPV).(Comparer)
+		assert.False(t, ok)
+	})
+
+	t.Run("with the engine both stations are played with", func(t *testing.T) {
+
+		comparer, mpv, _ := newManager(FFPlay)
+		a, b := station("A", MPV), station("B", MPV)
+
+		assert.True(t, comparer.CanCompare(a, b))
+		assert.NoError(t, comparer.Compare(a, 80, b, 60))
+		assert.Equal(t, [2]common.Station{a, b}, mpv.compared)
+
+		assert.NoError(t, comparer.SwitchCompared())
+		assert.Equal(t, 1, mpv.switches)
+
+	})
+
+	t.Run("not when a station asks for an engine that can't", func(t *testing.T) {
+
+		comparer, mpv, _ := newManager(MPV)
+		a, b := station("A", ""), station("B", FFPlay)
+
+		assert.False(t, comparer.CanCompare(a, b))
+		assert.ErrorIs(t, comparer.Compare(a, 80, b, 60), ErrCannotCompare)
+		assert.Equal(t, [2]common.Station{}, mpv.compared)
+
+	})
+
+	t.Run("not when the configured engine can't and the stations don't ask for another", func(t *testing.T) {
+
+		comparer, _, _ := newManager(FFPlay)
+
+		assert.False(t, comparer.CanCompare(station("A", ""), station("B", "")))
+		assert.ErrorIs(t, comparer.SwitchCompared(), ErrNotComparing)
+
+	})
+
+}
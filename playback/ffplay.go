@@ -3,18 +3,22 @@ package playback
 import (
 	"fmt"
 	"os/exec"
-	"runtime"
+	"strings"
 
 	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
 )
 
 // FFPlayPlaybackManager represents a playback manager for FFPlay.
 type FFPlayPlaybackManager struct {
-	nowPlaying *exec.Cmd
+	options    Options
+	nowPlaying *process
+	// Bitrate of the station being played in kbps (0 if unknown), to tell how long the audio queued lasts
+	bitrate uint64
 }
 
-func NewFFPlaybackManager() PlaybackManagerService {
-	return &FFPlayPlaybackManager{}
+func NewFFPlaybackManager(options Options) PlaybackManagerService {
+	return &FFPlayPlaybackManager{options: options}
 }
 
 func (d FFPlayPlaybackManager) Name() string {
@@ -31,39 +35,78 @@ func (d FFPlayPlaybackManager) IsAvailable() bool {
 }
 
 func (d FFPlayPlaybackManager) NotAvailableErrorString() string {
-	return `RadioGoGo requires "ffplay" (part of "ffmpeg") to be installed and available in your PATH.`
+	return i18n.T("playback.ffplay.notAvailable")
 }
 
 func (d *FFPlayPlaybackManager) PlayStation(station common.Station, volume int) error {
-	err := d.StopStation()
-	if err != nil {
-		return err
+	// ffplay's volume can't be changed while playing, so the current station can't be faded out:
+	// crossfading switches seamlessly instead, without fading.
+	if !d.options.SeamlessSwitch && d.options.Crossfade == 0 {
+		err := d.StopStation()
+		if err != nil {
+			return err
+		}
 	}
-	cmd := exec.Command("ffplay", "-nodisp", "-volume", fmt.Sprintf("%d", volume), station.Url.URL.String())
-	err = cmd.Start()
+	cmd := d.command(station, volume)
+	d.options.logCommand(cmd)
+	proc, err := startProcess(cmd, "aq=", d.options.readyTimeout(), d.options.newWatchdog(ffplayProgress), d.options.Levels)
 	if err != nil {
 		return err
 	}
-	d.nowPlaying = cmd
+	err = d.StopStation()
+	d.nowPlaying = proc
+	d.bitrate = station.Bitrate
+	return err
+}
+
+// command returns the ffplay command playing station at volume.
+func (d FFPlayPlaybackManager) command(station common.Station, volume int) *exec.Cmd {
+	// Status lines report the audio queue size once decoding has started.
+	args := []string{"-nodisp", "-stats", "-volume", fmt.Sprintf("%d", volume)}
+	args = append(args, d.bufferArgs()...)
+	if filters := d.options.audioFilters(); filters != "" {
+		args = append(args, "-af", filters)
+	}
+	if len(station.Headers) > 0 {
+		args = append(args, "-headers", station.Headers.FFmpegArg())
+	}
+	args = append(args, station.Url.URL.String())
+	return exec.Command("ffplay", args...)
+}
+
+func (d FFPlayPlaybackManager) PreviewCommand(station common.Station, volume int) Invocation {
+	return newInvocation(d.command(station, volume))
+}
+
+// ffplayProgress reads the playback clock, the first field of ffplay's status lines.
+func ffplayProgress(line string) (string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || !strings.Contains(line, "aq=") {
+		return "", false
+	}
+	return fields[0], true
+}
+
+// bufferArgs maps the buffering options to ffplay flags.
+// ffplay has no playback cache, so a larger buffer is approximated by probing
+// more of the stream (sized for a 320 kbps station) before starting.
+func (d FFPlayPlaybackManager) bufferArgs() []string {
+	if d.options.BufferSeconds > 0 {
+		return []string{
+			"-infbuf",
+			"-probesize", fmt.Sprintf("%d", d.options.BufferSeconds*40000),
+			"-analyzeduration", fmt.Sprintf("%d", d.options.BufferSeconds*1000000),
+		}
+	}
+	if d.options.LowLatency {
+		return []string{"-fflags", "nobuffer", "-flags", "low_delay", "-probesize", "32"}
+	}
 	return nil
 }
 
 func (d *FFPlayPlaybackManager) StopStation() error {
 	if d.nowPlaying != nil {
-		if runtime.GOOS == "windows" {
-			// On Windows, use taskkill to ensure all child processes are also killed.
-			killCmd := exec.Command("taskkill", "/T", "/F", "/PID", fmt.Sprintf("%d", d.nowPlaying.Process.Pid))
-			if err := killCmd.Run(); err != nil {
-				return err
-			}
-		} else {
-			// On other platforms, just use the normal Kill method.
-			if err := d.nowPlaying.Process.Kill(); err != nil {
-				return err
-			}
-		}
-
-		_, err := d.nowPlaying.Process.Wait()
+		err := stopProcess(d.nowPlaying)
 		if err != nil {
 			return err
 		}
@@ -71,7 +114,6 @@ func (d *FFPlayPlaybackManager) StopStation() error {
 	}
 	return nil
 }
-
 func (d FFPlayPlaybackManager) VolumeMin() int {
 	return 0
 }
@@ -0,0 +1,194 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/hls"
+)
+
+// How long fetching an HLS master playlist may take.
+const hlsPlaylistTimeout = 10 * time.Second
+
+// isHLS returns true if station is an HLS stream, as flagged by radio-browser or told by its URL.
+func isHLS(station common.Station) bool {
+	return bool(station.Hls) || strings.HasSuffix(strings.ToLower(station.Url.URL.Path), ".m3u8")
+}
+
+// HLSPlaybackManager wraps another playback manager, choosing which variant of HLS stations it plays.
+// Master playlists list the same station at several bitrates, sometimes with video: the wrapped player is
+// given the media playlist of the audio variant closest to the preferred bitrate, instead of picking on its own.
+type HLSPlaybackManager struct {
+	player     PlaybackManagerService
+	httpClient *http.Client
+	// In kbps, 0 for the highest
+	preferredBitrate int
+
+	// Guards what follows, which is read while estimating the delay
+	mu sync.Mutex
+	// Whether the HLS station being played has timestamps, how late its newest segment was when it started
+	// and how far from it players usually start
+	timestamped bool
+	latency     time.Duration
+	holdBack    time.Duration
+}
+
+// NewHLSPlaybackManager returns player, playing the variant of HLS stations closest to preferredBitrate kbps
+// (the highest if 0), unless their preferences ask for another bitrate.
+// It's a Comparer if player is.
+func NewHLSPlaybackManager(player PlaybackManagerService, preferredBitrate int) PlaybackManagerService {
+	manager := &HLSPlaybackManager{
+		player:           player,
+		httpClient:       &http.Client{Timeout: hlsPlaylistTimeout},
+		preferredBitrate: preferredBitrate,
+	}
+	if comparer, ok := player.(Comparer); ok {
+		return &hlsComparer{HLSPlaybackManager: manager, comparer: comparer}
+	}
+	return manager
+}
+
+// hlsComparer is an HLSPlaybackManager wrapping a Comparer, choosing the variants of both stations compared.
+type hlsComparer struct {
+	*HLSPlaybackManager
+	comparer Comparer
+}
+
+func (d *hlsComparer) Compare(station common.Station, volume int, other common.Station, otherVolume int) error {
+	return d.comparer.Compare(d.resolve(station), volume, d.resolve(other), otherVolume)
+}
+
+func (d *hlsComparer) SwitchCompared() error {
+	return d.comparer.SwitchCompared()
+}
+
+func (d *hlsComparer) CanCompare(station common.Station, other common.Station) bool {
+	return d.comparer.CanCompare(station, other)
+}
+
+func (d *HLSPlaybackManager) Name() string {
+	return d.player.Name()
+}
+
+func (d *HLSPlaybackManager) IsAvailable() bool {
+	return d.player.IsAvailable()
+}
+
+func (d *HLSPlaybackManager) NotAvailableErrorString() string {
+	return d.player.NotAvailableErrorString()
+}
+
+func (d *HLSPlaybackManager) IsPlaying() bool {
+	return d.player.IsPlaying()
+}
+
+func (d *HLSPlaybackManager) PlayStation(station common.Station, volume int) error {
+	resolved := d.resolve(station)
+	var latency, targetDuration time.Duration
+	timestamped := false
+	if isHLS(station) {
+		var err error
+		latency, targetDuration, err = hls.LiveLatency(d.clientFor(station), resolved.Url.URL)
+		timestamped = err == nil
+	}
+	err := d.player.PlayStation(resolved, volume)
+	d.mu.Lock()
+	d.timestamped = timestamped && err == nil
+	d.latency = latency
+	d.holdBack = hlsHoldBackSegments * targetDuration
+	d.mu.Unlock()
+	return err
+}
+
+// PreviewCommand tells the command line of the wrapped player, given the variant of station it would play.
+func (d *HLSPlaybackManager) PreviewCommand(station common.Station, volume int) Invocation {
+	invocation, _ := PreviewCommand(d.player, d.resolve(station), volume)
+	return invocation
+}
+
+// resolve points HLS stations at the media playlist of the variant to play.
+func (d *HLSPlaybackManager) resolve(station common.Station) common.Station {
+	if !isHLS(station) {
+		return station
+	}
+	playlistUrl := station.UrlResolved.URL
+	if playlistUrl.Host == "" {
+		playlistUrl = station.Url.URL
+	}
+	bitrate := d.preferredBitrate
+	if station.Prefs.HLSBitrate > 0 {
+		bitrate = station.Prefs.HLSBitrate
+	}
+	// The player can still make sense of a playlist that couldn't be read here
+	mediaUrl, err := hls.Resolve(d.clientFor(station), playlistUrl, bitrate)
+	if err == nil {
+		station.Url = common.RadioGoGoURL{URL: mediaUrl}
+		station.UrlResolved = common.RadioGoGoURL{URL: mediaUrl}
+	}
+	return station
+}
+
+// clientFor returns the HTTP client fetching the playlists of station, which sends its headers along.
+func (d *HLSPlaybackManager) clientFor(station common.Station) api.HTTPClientService {
+	if len(station.Headers) == 0 {
+		return d.httpClient
+	}
+	return headerClient{client: d.httpClient, headers: station.Headers}
+}
+
+// headerClient sends headers along with every request, replacing those already set.
+type headerClient struct {
+	client  api.HTTPClientService
+	headers common.StreamHeaders
+}
+
+func (c headerClient) Do(req *http.Request) (*http.Response, error) {
+	c.headers.Apply(req)
+	return c.client.Do(req)
+}
+
+func (d *HLSPlaybackManager) StopStation() error {
+	d.mu.Lock()
+	d.timestamped = false
+	d.mu.Unlock()
+	return d.player.StopStation()
+}
+
+func (d *HLSPlaybackManager) VolumeMin() int {
+	return d.player.VolumeMin()
+}
+
+func (d *HLSPlaybackManager) VolumeDefault() int {
+	return d.player.VolumeDefault()
+}
+
+func (d *HLSPlaybackManager) VolumeMax() int {
+	return d.player.VolumeMax()
+}
+
+func (d *HLSPlaybackManager) VolumeIsPercentage() bool {
+	return d.player.VolumeIsPercentage()
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+// backendEnvironment are the environment variables that change how the backends play or connect,
+// told along with their command lines.
+var backendEnvironment = []string{
+	"SDL_AUDIODRIVER", "AUDIODEV", "PULSE_SERVER", "PULSE_SINK", "PIPEWIRE_REMOTE", "XDG_RUNTIME_DIR",
+	"http_proxy", "https_proxy", "no_proxy", "HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY",
+}
+
+// Invocation is the command line a backend is run with, and the environment variables it inherits
+// that change how it plays, for troubleshooting.
+type Invocation struct {
+	// Path is the program run, as found in the PATH, or its name if it isn't installed.
+	Path string
+	Args []string
+	// Env are the backendEnvironment variables set, as NAME=value.
+	Env []string
+}
+
+// CommandPreviewer is implemented by playback managers that run a backend, to tell the command line
+// they'd run to play a station at volume, without running it.
+type CommandPreviewer interface {
+	PreviewCommand(station common.Station, volume int) Invocation
+}
+
+// PreviewCommand returns the command line player would run to play station at volume,
+// or false if player isn't a CommandPreviewer.
+func PreviewCommand(player PlaybackManagerService, station common.Station, volume int) (Invocation, bool) {
+	previewer, ok := player.(CommandPreviewer)
+	if !ok {
+		return Invocation{}, false
+	}
+	return previewer.PreviewCommand(station, volume), true
+}
+
+func newInvocation(cmd *exec.Cmd) Invocation {
+	var env []string
+	for _, name := range backendEnvironment {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return Invocation{Path: cmd.Path, Args: cmd.Args[1:], Env: env}
+}
+
+// String returns the command line as it can be pasted in a POSIX shell, preceded by its environment.
+func (i Invocation) String() string {
+	var words []string
+	for _, variable := range i.Env {
+		name, value, _ := strings.Cut(variable, "=")
+		words = append(words, name+"="+shellQuote(value))
+	}
+	words = append(words, shellQuote(i.Path))
+	for _, arg := range i.Args {
+		words = append(words, shellQuote(arg))
+	}
+	return strings.Join(words, " ")
+}
+
+// credentialNames are what the names of query parameters and headers carrying credentials contain,
+// e.g. "token", "api_key" or "Authorization".
+var credentialNames = []string{"auth", "token", "key", "pass", "secret", "session", "cookie", "sig"}
+
+// redactedValue replaces the credentials in logs.
+const redactedValue = "xxxxx"
+
+// Redacted returns the invocation with the credentials among its arguments replaced by "xxxxx", for it to be logged:
+// the passwords of the URLs, their query parameters carrying credentials, such as a token, and the values
+// of the headers carrying credentials, such as Authorization.
+func (i Invocation) Redacted() Invocation {
+	args := make([]string, len(i.Args))
+	for n, arg := range i.Args {
+		switch {
+		case n > 0 && i.Args[n-1] == "-headers":
+			// ffplay's and ffmpeg's headers, each one ending with CRLF
+			lines := strings.Split(arg, "\r\n")
+			for l, line := range lines {
+				lines[l] = redactedHeader(line)
+			}
+			args[n] = strings.Join(lines, "\r\n")
+		case strings.HasPrefix(arg, "--http-header-fields"):
+			// mpv's headers, as --http-header-fields-append=Name: value
+			option, header, _ := strings.Cut(arg, "=")
+			args[n] = option + "=" + redactedHeader(header)
+		default:
+			args[n] = redactedURL(arg)
+		}
+	}
+	i.Args = args
+	return i
+}
+
+// isCredentialName returns true if a query parameter or header named name carries credentials.
+func isCredentialName(name string) bool {
+	name = strings.ToLower(name)
+	for _, credential := range credentialNames {
+		if strings.Contains(name, credential) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedHeader returns the header written as it's sent, e.g. "Authorization: Bearer abc", with its value
+// replaced if it carries credentials.
+func redactedHeader(header string) string {
+	name, value, ok := strings.Cut(header, ":")
+	if !ok || strings.TrimSpace(value) == "" || !isCredentialName(name) {
+		return header
+	}
+	return name + ": " + redactedValue
+}
+
+// redactedURL returns arg with its password and the query parameters carrying credentials replaced,
+// if it's a URL.
+func redactedURL(arg string) string {
+	parsed, err := url.Parse(arg)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return arg
+	}
+	redacted := false
+	if parsed.User != nil {
+		if _, hasPassword := parsed.User.Password(); hasPassword {
+			parsed.User = url.UserPassword(parsed.User.Username(), redactedValue)
+			redacted = true
+		}
+	}
+	if parsed.RawQuery != "" {
+		query := parsed.Query()
+		for name, values := range query {
+			if !isCredentialName(name) {
+				continue
+			}
+			for v := range values {
+				values[v] = redactedValue
+			}
+			redacted = true
+		}
+		if redacted {
+			parsed.RawQuery = query.Encode()
+		}
+	}
+	if !redacted {
+		return arg
+	}
+	return parsed.String()
+}
+
+// shellQuote quotes s for a POSIX shell, unless it's made of characters that needn't be.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=,@%+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// logCommand reports the command line of cmd to the CommandLog of the options, if any.
+func (o Options) logCommand(cmd *exec.Cmd) {
+	if o.CommandLog != nil {
+		o.CommandLog(newInvocation(cmd).Redacted())
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long a channel's level is shown after the backend last reported it.
+const levelsTimeout = time.Second
+
+// levelsKey prefixes the levels reported by the analysis filter, followed by the channel number.
+const levelsKey = "lavfi.astats."
+
+// levelsFilter measures the loudness of each channel of every audio frame, and prints it with the backend's log.
+// Only the first two channels are printed, which is all a meter in the terminal shows.
+const levelsFilter = "astats=metadata=1:reset=1," +
+	"ametadata=mode=print:key=" + levelsKey + "1.RMS_level," +
+	"ametadata=mode=print:key=" + levelsKey + "2.RMS_level"
+
+// levelsMarker is in every line printed by the analysis filter.
+const levelsMarker = "ametadata"
+
+// Levels holds the loudness of the station being played, channel by channel, as measured by the backend.
+// It's safe for concurrent use.
+type Levels struct {
+	mu sync.Mutex
+	// RMS level of the first two channels, in dBFS, and when they were last reported
+	levels  [2]float64
+	updated [2]time.Time
+
+	now func() time.Time
+}
+
+// NewLevels returns Levels that haven't been reported yet.
+func NewLevels() *Levels {
+	return &Levels{now: time.Now}
+}
+
+// observe reads a line of output of the backend, returning true if it was printed by the analysis filter.
+// A nil *Levels reads nothing.
+func (l *Levels) observe(line string) bool {
+	if l == nil || !strings.Contains(line, levelsMarker) {
+		return false
+	}
+	i := strings.Index(line, levelsKey)
+	if i < 0 {
+		// The frame number and timestamp the levels are about
+		return true
+	}
+	channel, rest, ok := strings.Cut(line[i+len(levelsKey):], ".RMS_level=")
+	if !ok {
+		return true
+	}
+	index, err := strconv.Atoi(channel)
+	if err != nil || index < 1 || index > len(l.levels) {
+		return true
+	}
+	// Silence is reported as -inf
+	level, err := strconv.ParseFloat(strings.TrimSpace(rest), 64)
+	if err != nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.levels[index-1] = level
+	l.updated[index-1] = l.now()
+	return true
+}
+
+// Current returns the RMS level of each channel of the station being played, in dBFS (-Inf for silence),
+// or nothing if the backend isn't reporting them.
+func (l *Levels) Current() []float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var current []float64
+	for i, level := range l.levels {
+		if l.updated[i].IsZero() || now.Sub(l.updated[i]) > levelsTimeout {
+			break
+		}
+		if math.IsNaN(level) {
+			level = math.Inf(-1)
+		}
+		current = append(current, level)
+	}
+	return current
+}
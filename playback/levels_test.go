@@ -0,0 +1,151 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clockedLevels returns levels whose clock is moved with the returned clock.
+func clockedLevels() (*Levels, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)}
+	l := NewLevels()
+	l.now = clock.Now
+	return l, clock
+}
+
+func TestLevelsObserve(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		lines    []string
+		analysis bool
+		current  []float64
+	}{
+		{
+			name: "ffmpeg",
+			lines: []string{
+				"[Parsed_ametadata_1 @ 0x600001b2c000] frame:41   pts:47104   pts_time:1.06812",
+				"[Parsed_ametadata_1 @ 0x600001b2c000] lavfi.astats.1.RMS_level=-18.204521",
+				"[Parsed_ametadata_2 @ 0x600001b2c0b0] lavfi.astats.2.RMS_level=-19.5",
+			},
+			analysis: true,
+			current:  []float64{-18.204521, -19.5},
+		},
+		{
+			name: "mpv",
+			lines: []string{
+				"[ffmpeg] Parsed_ametadata_1: frame:12 pts:13824 pts_time:0.288",
+				"[ffmpeg] Parsed_ametadata_1: lavfi.astats.1.RMS_level=-23.25",
+				"[ffmpeg] Parsed_ametadata_2: lavfi.astats.2.RMS_level=-24",
+			},
+			analysis: true,
+			current:  []float64{-23.25, -24},
+		},
+		{
+			name:     "silence",
+			lines:    []string{"[Parsed_ametadata_1 @ 0x1] lavfi.astats.1.RMS_level=-inf"},
+			analysis: true,
+			current:  []float64{math.Inf(-1)},
+		},
+		{
+			name:     "not a number, shown as silence",
+			lines:    []string{"[Parsed_ametadata_1 @ 0x1] lavfi.astats.1.RMS_level=nan"},
+			analysis: true,
+			current:  []float64{math.Inf(-1)},
+		},
+		{
+			name:     "mono",
+			lines:    []string{"[Parsed_ametadata_1 @ 0x1] lavfi.astats.1.RMS_level=-12"},
+			analysis: true,
+			current:  []float64{-12},
+		},
+		{
+			name:     "only the second channel, which shows nothing",
+			lines:    []string{"[Parsed_ametadata_2 @ 0x1] lavfi.astats.2.RMS_level=-12"},
+			analysis: true,
+			current:  nil,
+		},
+		{
+			name: "a channel the meter doesn't show, or can't be read",
+			lines: []string{
+				"[Parsed_ametadata_3 @ 0x1] lavfi.astats.3.RMS_level=-12",
+				"[Parsed_ametadata_1 @ 0x1] lavfi.astats.0.RMS_level=-12",
+				"[Parsed_ametadata_1 @ 0x1] lavfi.astats.x.RMS_level=-12",
+				"[Parsed_ametadata_1 @ 0x1] lavfi.astats.1.RMS_level=loud",
+				"[Parsed_ametadata_1 @ 0x1] lavfi.astats.1.Peak_level=-3",
+			},
+			analysis: true,
+			current:  nil,
+		},
+		{
+			name: "not the analysis filter",
+			lines: []string{
+				"size=     128kB time=00:00:08.07 bitrate= 129.9kbits/s speed=1.01x",
+				"lavfi.astats.1.RMS_level=-12",
+			},
+			analysis: false,
+			current:  nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l, _ := clockedLevels()
+			for _, line := range test.lines {
+				assert.Equal(t, test.analysis, l.observe(line), line)
+			}
+			assert.Equal(t, test.current, l.Current())
+		})
+	}
+
+}
+
+func TestLevels(t *testing.T) {
+
+	t.Run("reads nothing when nil", func(t *testing.T) {
+		var l *Levels
+		assert.False(t, l.observe("[Parsed_ametadata_1 @ 0x1] lavfi.astats.1.RMS_level=-12"))
+	})
+
+	t.Run("stops showing levels the backend stopped reporting", func(t *testing.T) {
+
+		l, clock := clockedLevels()
+		l.observe("[Parsed_ametadata_1 @ 0x1] lavfi.astats.1.RMS_level=-12")
+		clock.Advance(levelsTimeout / 2)
+		l.observe("[Parsed_ametadata_2 @ 0x1] lavfi.astats.2.RMS_level=-14")
+
+		clock.Advance(levelsTimeout / 2)
+		assert.Equal(t, []float64{-12, -14}, l.Current())
+
+		// The first channel is stale, which hides the second as well
+		clock.Advance(time.Millisecond)
+		assert.Nil(t, l.Current())
+
+		l.observe("[Parsed_ametadata_1 @ 0x1] lavfi.astats.1.RMS_level=-10")
+		assert.Equal(t, []float64{-10, -14}, l.Current())
+
+	})
+
+}
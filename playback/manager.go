@@ -20,6 +20,8 @@
 package playback
 
 import (
+	"time"
+
 	"github.com/zi0p4tch0/radiogogo/common"
 )
 
@@ -48,3 +50,26 @@ type PlaybackManagerService interface {
 	// VolumeIsPercentage returns true if the volume is represented as a percentage.
 	VolumeIsPercentage() bool
 }
+
+// Timeshifter is implemented by playback managers that keep the last minutes of the station being played,
+// so that it can be paused and rewound.
+type Timeshifter interface {
+	// Pause silences the station, which keeps being buffered.
+	Pause() error
+	// Resume plays the station from where it was paused.
+	Resume() error
+	// IsPaused returns true if the station is paused.
+	IsPaused() bool
+	// Seek moves forward by the given offset (backwards if negative), within the buffer.
+	Seek(offset time.Duration) error
+	// GoLive plays the station from the live edge, even if paused.
+	GoLive() error
+	// Delay returns how far behind live the station is heard.
+	Delay() time.Duration
+}
+
+// VolumeSetter is implemented by playback managers that can change the volume while playing.
+type VolumeSetter interface {
+	// SetVolume changes the volume of the station being played.
+	SetVolume(volume int) error
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"sync"
+	"time"
+)
+
+// How far back the bitrate is averaged over.
+const meterWindow = 5 * time.Second
+
+// meterSample is a piece of stream, as received.
+type meterSample struct {
+	at    time.Time
+	bytes int
+}
+
+// Meter counts the bytes of the streams downloaded by RadioGoGo.
+// It's an io.Writer, to be used as a streamTee sink, and safe for concurrent use.
+type Meter struct {
+	mu       sync.Mutex
+	received uint64
+	// What arrived within the last meterWindow, oldest first
+	samples []meterSample
+
+	now func() time.Time
+}
+
+// NewMeter returns a Meter that hasn't received anything yet.
+func NewMeter() *Meter {
+	return &Meter{now: time.Now}
+}
+
+// Write counts p as received.
+func (m *Meter) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.received += uint64(len(p))
+	m.samples = append(m.samples, meterSample{at: m.now(), bytes: len(p)})
+	m.dropOldSamples()
+	return len(p), nil
+}
+
+// dropOldSamples forgets what arrived more than meterWindow ago. The lock must be held.
+func (m *Meter) dropOldSamples() {
+	now := m.now()
+	dropped := 0
+	for dropped < len(m.samples) && now.Sub(m.samples[dropped].at) > meterWindow {
+		dropped++
+	}
+	if dropped > 0 {
+		m.samples = append(m.samples[:0:0], m.samples[dropped:]...)
+	}
+}
+
+// Received returns how many bytes have been received since the meter was created.
+func (m *Meter) Received() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.received
+}
+
+// Bitrate returns the rate data has been received at over the last few seconds, in bits per second.
+func (m *Meter) Bitrate() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dropOldSamples()
+	if len(m.samples) == 0 {
+		return 0
+	}
+	total := 0
+	for _, sample := range m.samples {
+		total += sample.bytes
+	}
+	// Until the window has filled up, average over the time since the first sample
+	elapsed := m.now().Sub(m.samples[0].at)
+	if elapsed < time.Second {
+		elapsed = time.Second
+	}
+	if elapsed > meterWindow {
+		elapsed = meterWindow
+	}
+	return int(float64(total*8) / elapsed.Seconds())
+}
@@ -2,19 +2,31 @@ package playback
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"runtime"
 
 	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
 )
 
 // MPVPlaybackManager represents a playback manager for MPV.
 type MPVPlaybackManager struct {
-	nowPlaying *exec.Cmd
+	options    Options
+	nowPlaying *process
+	// The volume the current station was started at, and its IPC server when crossfading, comparing or ducking
+	volume  int
+	ipcPath string
+	// Whether stations are turned down, and to which percentage of their volume
+	ducked    bool
+	duckLevel int
+	// The station kept connected, muted, while comparing, and its IPC server
+	compared        *process
+	comparedIPCPath string
 }
 
-func NewMPVbackManager() PlaybackManagerService {
-	return &MPVPlaybackManager{}
+func NewMPVbackManager(options Options) PlaybackManagerService {
+	return &MPVPlaybackManager{options: options}
 }
 
 func (d MPVPlaybackManager) Name() string {
@@ -31,47 +43,114 @@ func (d MPVPlaybackManager) IsAvailable() bool {
 }
 
 func (d MPVPlaybackManager) NotAvailableErrorString() string {
-	return `RadioGoGo requires "mpv" to be installed and available in your PATH.`
+	return i18n.T("playback.mpv.notAvailable")
 }
 
 func (d *MPVPlaybackManager) PlayStation(station common.Station, volume int) error {
-	err := d.StopStation()
-	if err != nil {
+	if err := d.stopCompared(); err != nil {
 		return err
 	}
-	cmd := exec.Command("mpv", "--no-video", fmt.Sprintf("--volume=%d", volume), station.Url.URL.String())
-	err = cmd.Start()
+	crossfade := d.options.Crossfade > 0 && d.nowPlaying != nil
+	if !d.options.SeamlessSwitch && !crossfade {
+		err := d.StopStation()
+		if err != nil {
+			return err
+		}
+	}
+	// Lets the station be faded out when switching to the next one, turned down, and its cache measured
+	ipcPath := newIPCPath()
+	proc, err := d.start(d.options, station, d.heardVolume(volume), ipcPath, crossfade)
 	if err != nil {
 		return err
 	}
-	d.nowPlaying = cmd
+	if crossfade {
+		fadeOutMPV(d.ipcPath, d.heardVolume(d.volume), d.options.Crossfade)
+	}
+	err = d.StopStation()
+	d.nowPlaying = proc
+	d.volume = volume
+	d.ipcPath = ipcPath
+	return err
+}
+
+// start starts mpv playing station, with an IPC server at ipcPath unless it's empty,
+// fading the station in if fadeIn is true.
+func (d *MPVPlaybackManager) start(options Options, station common.Station, volume int, ipcPath string, fadeIn bool, extraArgs ...string) (*process, error) {
+	cmd := d.command(options, station, volume, ipcPath, fadeIn, extraArgs...)
+	options.logCommand(cmd)
+	// mpv logs the audio output configuration once the first buffer is ready.
+	return startProcess(cmd, "AO:", options.readyTimeout(), options.newWatchdog(progressAfter("A:")), options.Levels)
+}
+
+// command returns the mpv command start runs.
+func (d *MPVPlaybackManager) command(options Options, station common.Station, volume int, ipcPath string, fadeIn bool, extraArgs ...string) *exec.Cmd {
+	args := []string{"--no-video", fmt.Sprintf("--volume=%d", volume)}
+	args = append(args, d.bufferArgs()...)
+	if ipcPath != "" {
+		args = append(args, "--input-ipc-server="+ipcPath)
+	}
+	var filters []string
+	if fadeIn {
+		filters = append(filters, fadeInFilter(options.Crossfade))
+	}
+	if filters := options.audioFilters(filters...); filters != "" {
+		args = append(args, "--af=lavfi=["+filters+"]")
+	}
+	if options.logsFilters() {
+		// mpv only shows the silence and level reports of the filters in verbose mode
+		args = append(args, "--msg-level=ffmpeg=v")
+	}
+	for _, header := range station.Headers {
+		// Appended one by one, since a list would be split at the commas of their values
+		args = append(args, "--http-header-fields-append="+header.String())
+	}
+	args = append(args, extraArgs...)
+	args = append(args, station.Url.URL.String())
+	return exec.Command("mpv", args...)
+}
+
+func (d *MPVPlaybackManager) PreviewCommand(station common.Station, volume int) Invocation {
+	return newInvocation(d.command(d.options, station, d.heardVolume(volume), newIPCPath(), false))
+}
+
+// bufferArgs maps the buffering options to mpv cache flags.
+func (d MPVPlaybackManager) bufferArgs() []string {
+	if d.options.BufferSeconds > 0 {
+		return []string{
+			"--cache=yes",
+			fmt.Sprintf("--cache-secs=%d", d.options.BufferSeconds),
+			fmt.Sprintf("--demuxer-readahead-secs=%d", d.options.BufferSeconds),
+			fmt.Sprintf("--cache-pause-wait=%d", d.options.BufferSeconds),
+		}
+	}
+	if d.options.LowLatency {
+		return []string{"--profile=low-latency", "--cache=no"}
+	}
 	return nil
 }
 
 func (d *MPVPlaybackManager) StopStation() error {
+	if err := d.stopCompared(); err != nil {
+		return err
+	}
 	if d.nowPlaying != nil {
-		if runtime.GOOS == "windows" {
-			// On Windows, use taskkill to ensure all child processes are also killed.
-			killCmd := exec.Command("taskkill", "/T", "/F", "/PID", fmt.Sprintf("%d", d.nowPlaying.Process.Pid))
-			if err := killCmd.Run(); err != nil {
-				return err
-			}
-		} else {
-			// On other platforms, just use the normal Kill method.
-			if err := d.nowPlaying.Process.Kill(); err != nil {
-				return err
-			}
-		}
-
-		_, err := d.nowPlaying.Process.Wait()
+		err := stopProcess(d.nowPlaying)
 		if err != nil {
 			return err
 		}
 		d.nowPlaying = nil
+		removeIPC(d.ipcPath)
+		d.ipcPath = ""
 	}
 	return nil
 }
 
+// removeIPC removes the socket of an mpv IPC server, which mpv doesn't get to remove when killed.
+func removeIPC(path string) {
+	if path != "" && runtime.GOOS != "windows" {
+		_ = os.Remove(path)
+	}
+}
 func (d MPVPlaybackManager) VolumeMin() int {
 	return 0
 }
@@ -0,0 +1,86 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"strings"
+	"time"
+)
+
+// Options holds the settings shared by all playback managers.
+type Options struct {
+	// SeamlessSwitch makes the playback manager start the next station
+	// and wait for its first audio buffer before stopping the current one.
+	SeamlessSwitch bool
+	// BufferSeconds is how many seconds of audio the backend should buffer
+	// before and during playback. Zero keeps the backend defaults.
+	BufferSeconds int
+	// LowLatency disables input buffering, trading resilience for a shorter delay.
+	// It is ignored when BufferSeconds is set.
+	LowLatency bool
+	// Crossfade fades the current station out and the next one in over the given duration
+	// when switching stations, which implies SeamlessSwitch. Zero switches abruptly.
+	// ffplay can't fade the current station out: it only switches seamlessly.
+	Crossfade time.Duration
+	// Normalize evens out the loudness of stations (EBU R128) with the backend's audio filters.
+	Normalize bool
+	// WatchdogTimeout is how long a station may stall or stay silent before its backend is killed,
+	// which is then reported as an exit with ErrStreamStalled or ErrStreamSilent. Zero disables it.
+	WatchdogTimeout time.Duration
+	// Levels receives the loudness of each channel of the station being played, measured by the backend's
+	// audio filters. Nil skips the analysis.
+	Levels *Levels
+	// CommandLog receives the command line of each backend started, its passwords redacted. Nil doesn't.
+	CommandLog func(Invocation)
+}
+
+// loudnormFilter normalizes loudness to -16 LUFS, then resamples back down
+// from the 192 kHz loudnorm works at.
+const loudnormFilter = "loudnorm=I=-16:TP=-1.5:LRA=11,aresample=48000"
+
+// audioFilters returns the ffmpeg filter chain for a station: silence detection for the watchdog
+// and loudness normalization when enabled, followed by the given filters and the level analysis.
+// It is empty when there is nothing to apply.
+func (o Options) audioFilters(filters ...string) string {
+	if o.Normalize {
+		filters = append([]string{loudnormFilter}, filters...)
+	}
+	// Before anything changes the volume, so that it's the station's own silence
+	if o.WatchdogTimeout > 0 {
+		filters = append([]string{silenceFilter(o.WatchdogTimeout)}, filters...)
+	}
+	// Last, so that the levels are those of what's heard
+	if o.Levels != nil {
+		filters = append(filters, levelsFilter)
+	}
+	return strings.Join(filters, ",")
+}
+
+// logsFilters returns true if the backend must log at the info level, where the filters report
+// silence and levels.
+func (o Options) logsFilters() bool {
+	return o.WatchdogTimeout > 0 || o.Levels != nil
+}
+
+// readyTimeout returns how long to wait for a backend to start producing audio,
+// which grows with the configured buffer size.
+func (o Options) readyTimeout() time.Duration {
+	return audioreadyTimeout + time.Duration(o.BufferSeconds)*time.Second
+}
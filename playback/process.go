@@ -0,0 +1,167 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+// How long to wait for a backend to start producing audio, on top of any configured buffer.
+const audioreadyTimeout = 15 * time.Second
+
+// ErrAudioNotReady is returned when a backend does not start producing audio in time.
+var ErrAudioNotReady = i18n.Error("playback.notReady")
+
+// startProcess starts the given backend command with its stdout and stderr merged.
+// If readyMarker is not empty, it blocks until a line of output containing the marker
+// is seen, which signals that the backend has filled its first audio buffer.
+// If the backend exits or does not print the marker within timeout, it is killed and an error is returned.
+// Once it is ready, watch (if not nil) reads its output and kills it when the audio stalls or goes silent.
+// levels (if not nil) is updated with the levels reported by its filters.
+func startProcess(cmd *exec.Cmd, readyMarker string, timeout time.Duration, watch *watchdog, levels *Levels) (*process, error) {
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	setProcessAttributes(cmd)
+
+	outputDone := make(chan struct{})
+	proc, err := supervisor.start(cmd, outputDone)
+	writer.Close()
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+
+	ready := make(chan bool, 1)
+
+	go func() {
+		defer close(outputDone)
+		defer reader.Close()
+		isReady := readyMarker == ""
+		if isReady {
+			ready <- true
+		}
+		scanner := bufio.NewScanner(reader)
+		scanner.Split(scanLinesOrCarriageReturns)
+		for scanner.Scan() {
+			line := scanner.Text()
+			// Too many to tell why the process exited
+			if levels.observe(line) {
+				continue
+			}
+			if watch != nil {
+				watch.observe(line)
+			}
+			if readyMarker != "" && strings.Contains(line, readyMarker) {
+				// Status lines repeat the marker: they don't tell why the process exited
+				if !isReady {
+					isReady = true
+					ready <- true
+				}
+				proc.setStatus(line)
+				continue
+			}
+			proc.record(line)
+		}
+		if !isReady {
+			// startProcess reports it: it isn't an exit to tell about
+			proc.markStopped()
+			ready <- false
+		}
+	}()
+
+	select {
+	case ok := <-ready:
+		if ok {
+			if watch != nil {
+				watch.start()
+				go watch.guard(proc)
+			}
+			return proc, nil
+		}
+		<-proc.done
+		if proc.exit.Output != "" {
+			return nil, errors.New(i18n.Tf("playback.exitedWithOutput", proc.name(), proc.exit.Output))
+		}
+		return nil, errors.New(i18n.Tf("playback.exited", proc.name()))
+	case <-time.After(timeout):
+		_ = stopProcess(proc)
+		return nil, ErrAudioNotReady
+	}
+
+}
+
+// stopProcess kills the process, along with its children, and waits for it to be reaped.
+// Stopping a process that already exited does nothing.
+func stopProcess(proc *process) error {
+	if !proc.markStopped() {
+		return nil
+	}
+	return killProcess(proc)
+}
+
+// killProcess kills the process, along with its children, and waits for it to be reaped.
+func killProcess(proc *process) error {
+
+	cmd := proc.cmd
+	if runtime.GOOS == "windows" {
+		// On Windows, use taskkill to ensure all child processes are also killed.
+		killCmd := exec.Command("taskkill", "/T", "/F", "/PID", fmt.Sprintf("%d", cmd.Process.Pid))
+		if err := killCmd.Run(); err != nil {
+			return err
+		}
+	} else {
+		// On other platforms, just use the normal Kill method.
+		if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return err
+		}
+	}
+
+	<-proc.done
+	return nil
+}
+
+func scanLinesOrCarriageReturns(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[0:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+	"github.com/zi0p4tch0/radiogogo/recording"
+)
+
+// ErrRecordingUnavailable is returned when a station can't be recorded, e.g. because it's an HLS stream.
+var ErrRecordingUnavailable = i18n.Error("playback.recording.unavailable")
+
+// Recording is a station being saved to a file, independently of what's being played.
+type Recording struct {
+	tee   *streamTee
+	guard *recording.Guard
+}
+
+// RecordOptions are what a recording does with the tracks announced by the station.
+type RecordOptions struct {
+	// Sidecar logs the tracks, if not nil.
+	Sidecar *recording.Sidecar
+	// Split saves each track to its own file, leaving out those shorter than MinTrackLength.
+	Split          bool
+	MinTrackLength time.Duration
+	// Limits stop the recording before it fills the disk.
+	Limits recording.Limits
+	// Encoding re-encodes the stream with ffmpeg, unless it's saved as broadcast.
+	// Recordings re-encoded to a format that can't be split are saved to a single file.
+	Encoding recording.Encoding
+}
+
+// Record starts saving station to the file at path, appending to it if it exists, so that
+// a recording interrupted by a dropped connection can carry on in the same file.
+// It returns once the stream has answered, or with recording.ErrDiskSpaceLow if there's not enough free space.
+func Record(station common.Station, path string, options RecordOptions) (*Recording, error) {
+	if isHLS(station) {
+		return nil, ErrRecordingUnavailable
+	}
+	if err := recording.CheckFreeSpace(filepath.Dir(path), options.Limits); err != nil {
+		return nil, err
+	}
+
+	streamUrl := station.UrlResolved.URL
+	if streamUrl.Host == "" {
+		streamUrl = station.Url.URL
+	}
+
+	var sink io.WriteCloser
+	var splitter *recording.Splitter
+	if options.Split && options.Encoding.Splittable() {
+		var err error
+		if splitter, err = recording.NewSplitter(path, options.MinTrackLength); err != nil {
+			return nil, err
+		}
+		sink = splitter
+	} else {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		sink = file
+	}
+	guard := recording.NewGuard(sink, filepath.Dir(path), options.Limits)
+	// The limits apply to what's saved, so the guard comes after ffmpeg
+	var out io.WriteCloser = guard
+	if args := options.Encoding.FFmpegArgs(); args != nil {
+		transcoder, err := newTranscoder(args, guard)
+		if err != nil {
+			guard.Close()
+			return nil, err
+		}
+		out = transcoder
+	}
+
+	// No overall timeout, since the stream never ends
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = audioreadyTimeout
+	var onTitle func(title string)
+	if options.Sidecar != nil || splitter != nil {
+		// Tracks are a nicety: one that can't be logged or split doesn't stop the recording,
+		// which carries on in the previous file
+		onTitle = func(title string) {
+			if options.Sidecar != nil {
+				_ = options.Sidecar.Track(title, time.Now())
+			}
+			if splitter != nil {
+				_ = splitter.Track(title)
+			}
+		}
+	}
+	tee, err := startStreamTeeWithTitles(&http.Client{Transport: transport}, streamUrl, station.Headers, onTitle, out)
+	if err != nil {
+		out.Close()
+		return nil, err
+	}
+	return &Recording{tee: tee, guard: guard}, nil
+}
+
+// Stop disconnects from the station and closes the file.
+func (r *Recording) Stop() {
+	r.tee.stop()
+}
+
+// Done is closed when the recording ends, whether it was stopped or the station hung up.
+func (r *Recording) Done() <-chan struct{} {
+	return r.tee.done
+}
+
+// Err returns the limit the recording stopped at, if it reached one of RecordOptions.Limits.
+func (r *Recording) Err() error {
+	return r.guard.Err()
+}
@@ -0,0 +1,341 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+// ErrHLSNotRelayed is returned when playing an HLS station while the stations are relayed for the network,
+// as their segments can't be.
+var ErrHLSNotRelayed = i18n.Error("playback.hlsNotRelayed")
+
+// RelayPlaybackManager wraps another playback manager, fetching the stations it plays itself.
+// The wrapped player is pointed at a local server relaying the stream, which counts it on the way
+// and goes through the proxy and trusts the certificates RadioGoGo is configured with, for players that don't.
+// HLS streams can't be relayed, their segments being fetched by the player: they're played directly
+// and not counted when relaying is only for counting, and refused with ErrHLSNotRelayed otherwise.
+type RelayPlaybackManager struct {
+	player     PlaybackManagerService
+	meter      *Meter
+	httpClient *http.Client
+	// forNetwork is true when the stations are relayed with a transport of their own, for the network to let them through
+	forNetwork bool
+
+	// Guards what follows, which is read by the local server
+	mu       sync.Mutex
+	listener net.Listener
+	// Stream URLs by session: a session is one run of the player
+	sessions map[int]url.URL
+	current  int
+}
+
+// NewRelayPlaybackManager returns player, relaying the stations it plays with transport
+// (the default one if nil, then only to count them) and counting their bytes with meter, unless it's nil.
+// It's a Comparer if player is, relaying both stations compared.
+func NewRelayPlaybackManager(player PlaybackManagerService, transport *http.Transport, meter *Meter) PlaybackManagerService {
+	forNetwork := transport != nil
+	if transport == nil {
+		transport = defaultStreamTransport()
+	}
+	manager := &RelayPlaybackManager{
+		player:     player,
+		meter:      meter,
+		httpClient: &http.Client{Transport: transport},
+		forNetwork: forNetwork,
+		sessions:   make(map[int]url.URL),
+	}
+	if comparer, ok := player.(Comparer); ok {
+		return &relayComparer{RelayPlaybackManager: manager, comparer: comparer}
+	}
+	return manager
+}
+
+// relayComparer is a RelayPlaybackManager wrapping a Comparer, relaying both stations compared,
+// which are counted while they're both connected.
+type relayComparer struct {
+	*RelayPlaybackManager
+	comparer Comparer
+}
+
+func (d *relayComparer) Compare(station common.Station, volume int, other common.Station, otherVolume int) error {
+	relayed, id, err := d.relay(station)
+	if err != nil {
+		return err
+	}
+	relayedOther, otherId, err := d.relay(other)
+	if err != nil {
+		d.forgetSessions(id)
+		return err
+	}
+	err = d.comparer.Compare(relayed, volume, relayedOther, otherVolume)
+	if err != nil {
+		d.forgetSessions(id, otherId)
+	} else {
+		d.keepSessions(id, otherId)
+	}
+	return err
+}
+
+func (d *relayComparer) SwitchCompared() error {
+	return d.comparer.SwitchCompared()
+}
+
+func (d *relayComparer) CanCompare(station common.Station, other common.Station) bool {
+	return d.comparer.CanCompare(station, other)
+}
+
+// defaultStreamTransport returns the transport streams are fetched with, unless configured otherwise.
+func defaultStreamTransport() *http.Transport {
+	// No overall timeout, since the stream never ends
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = audioreadyTimeout
+	return transport
+}
+
+// RelayTransport returns the transport streams are relayed with: through proxy, or the one set
+// by HTTP_PROXY, HTTPS_PROXY and NO_PROXY if empty, trusting the PEM certificates in caFile
+// on top of the system's, as networks intercepting TLS require.
+func RelayTransport(proxy, caFile string) (*http.Transport, error) {
+	transport := defaultStreamTransport()
+	if proxy != "" {
+		proxyUrl, err := url.Parse(proxy)
+		if err != nil || proxyUrl.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %q", proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return transport, nil
+}
+
+func (d *RelayPlaybackManager) Name() string {
+	return d.player.Name()
+}
+
+func (d *RelayPlaybackManager) IsAvailable() bool {
+	return d.player.IsAvailable()
+}
+
+func (d *RelayPlaybackManager) NotAvailableErrorString() string {
+	return d.player.NotAvailableErrorString()
+}
+
+func (d *RelayPlaybackManager) IsPlaying() bool {
+	return d.player.IsPlaying()
+}
+
+func (d *RelayPlaybackManager) PlayStation(station common.Station, volume int) error {
+	relayed, id, err := d.relay(station)
+	if err != nil {
+		return err
+	}
+	err = d.player.PlayStation(relayed, volume)
+	// The previous session keeps being relayed until the player has switched, if switches are seamless
+	if err != nil {
+		d.forgetSessions(id)
+	} else {
+		d.keepSessions(id)
+	}
+	return err
+}
+
+// relay returns station pointed at a new session of the local server, relaying its stream, and the session.
+// HLS stations are returned as they are, with no session, unless they're refused.
+func (d *RelayPlaybackManager) relay(station common.Station) (common.Station, int, error) {
+	if isHLS(station) {
+		// Played directly, the player would go around the proxy and the certificates it can't use
+		if d.forNetwork {
+			return station, 0, ErrHLSNotRelayed
+		}
+		return station, 0, nil
+	}
+
+	if err := d.listen(); err != nil {
+		return station, 0, err
+	}
+
+	streamUrl := station.UrlResolved.URL
+	if streamUrl.Host == "" {
+		streamUrl = station.Url.URL
+	}
+
+	d.mu.Lock()
+	d.current++
+	id := d.current
+	d.sessions[id] = streamUrl
+	local := url.URL{
+		Scheme: "http",
+		Host:   d.listener.Addr().String(),
+		Path:   "/" + strconv.Itoa(id),
+	}
+	d.mu.Unlock()
+
+	station.Url = common.RadioGoGoURL{URL: local}
+	station.UrlResolved = common.RadioGoGoURL{URL: local}
+	return station, id, nil
+}
+
+// keepSessions forgets every session but ids.
+func (d *RelayPlaybackManager) keepSessions(ids ...int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for other := range d.sessions {
+		kept := false
+		for _, id := range ids {
+			kept = kept || other == id
+		}
+		if !kept {
+			delete(d.sessions, other)
+		}
+	}
+}
+
+// forgetSessions forgets the sessions ids, which the player didn't start.
+func (d *RelayPlaybackManager) forgetSessions(ids ...int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, id := range ids {
+		delete(d.sessions, id)
+	}
+}
+
+// listen starts the local server the player connects to, if it isn't running yet.
+func (d *RelayPlaybackManager) listen() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.listener != nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	d.listener = listener
+	go func() {
+		_ = http.Serve(listener, http.HandlerFunc(d.serveSession))
+	}()
+	return nil
+}
+
+// serveSession relays the stream of a session to the player, counting it if metered.
+// The player's headers are passed on, so that it still gets the ICY metadata it asks for.
+func (d *RelayPlaybackManager) serveSession(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+
+	d.mu.Lock()
+	streamUrl, ok := d.sessions[id]
+	d.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), "GET", streamUrl.String(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	for name, values := range r.Header {
+		req.Header[name] = values
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+	flusher, _ := w.(http.Flusher)
+
+	buffer := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(buffer)
+		if n > 0 {
+			if d.meter != nil {
+				_, _ = d.meter.Write(buffer[:n])
+			}
+			if _, err := w.Write(buffer[:n]); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (d *RelayPlaybackManager) StopStation() error {
+	err := d.player.StopStation()
+	d.mu.Lock()
+	d.sessions = make(map[int]url.URL)
+	d.mu.Unlock()
+	return err
+}
+
+func (d *RelayPlaybackManager) VolumeMin() int {
+	return d.player.VolumeMin()
+}
+
+func (d *RelayPlaybackManager) VolumeDefault() int {
+	return d.player.VolumeDefault()
+}
+
+func (d *RelayPlaybackManager) VolumeMax() int {
+	return d.player.VolumeMax()
+}
+
+func (d *RelayPlaybackManager) VolumeIsPercentage() bool {
+	return d.player.VolumeIsPercentage()
+}
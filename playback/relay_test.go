@@ -0,0 +1,188 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelayTransport(t *testing.T) {
+
+	t.Run("goes through the proxy given", func(t *testing.T) {
+		transport, err := RelayTransport("http://proxy.example.com:3128", "")
+		assert.NoError(t, err)
+
+		req, _ := http.NewRequest("GET", "https://example.com/stream", nil)
+		proxy, err := transport.Proxy(req)
+		assert.NoError(t, err)
+		assert.Equal(t, "proxy.example.com:3128", proxy.Host)
+	})
+
+	t.Run("refuses an invalid proxy URL", func(t *testing.T) {
+		for _, proxy := range []string{"proxy.example.com:3128", "http://", "://"} {
+			_, err := RelayTransport(proxy, "")
+			assert.Error(t, err, proxy)
+		}
+	})
+
+	t.Run("trusts the certificates of the CA file", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+		caFile := filepath.Join(t.TempDir(), "ca.pem")
+		certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		assert.NoError(t, os.WriteFile(caFile, certificate, 0600))
+
+		transport, err := RelayTransport("", caFile)
+		assert.NoError(t, err)
+
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+	})
+
+	t.Run("fails on a missing CA file", func(t *testing.T) {
+		_, err := RelayTransport("", filepath.Join(t.TempDir(), "missing.pem"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("fails on a CA file without certificates", func(t *testing.T) {
+		caFile := filepath.Join(t.TempDir(), "ca.pem")
+		assert.NoError(t, os.WriteFile(caFile, []byte("not a certificate\n"), 0600))
+
+		_, err := RelayTransport("", caFile)
+		assert.ErrorContains(t, err, "no certificates found")
+	})
+
+}
+
+func TestRelayRefusesHLSForTheNetwork(t *testing.T) {
+
+	streamUrl, _ := url.Parse("https://example.com/live/master.m3u8")
+	station := common.NewStationFromURL(*streamUrl, "")
+	transport, err := RelayTransport("", "")
+	assert.NoError(t, err)
+
+	// Refused before the player is asked to play it
+	assert.ErrorIs(t, NewRelayPlaybackManager(nil, transport, nil).PlayStation(station, 80), ErrHLSNotRelayed)
+	assert.ErrorIs(t, NewTimeshiftPlaybackManager(nil, 5, transport, nil).PlayStation(station, 80), ErrHLSNotRelayed)
+
+}
+
+// comparingEngine is a fake engine that compares stations, recording them.
+type comparingEngine struct {
+	*fakeEngine
+	compared [2]common.Station
+	switches int
+}
+
+func (e *comparingEngine) Compare(station common.Station, volume int, other common.Station, otherVolume int) error {
+	e.compared = [2]common.Station{station, other}
+	return nil
+}
+
+func (e *comparingEngine) CanCompare(station common.Station, other common.Station) bool {
+	return true
+}
+
+func (e *comparingEngine) SwitchCompared() error {
+	e.switches++
+	return nil
+}
+
+func TestRelayCompares(t *testing.T) {
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.URL.Path)
+	}))
+	defer upstream.Close()
+	stationAt := func(path string) common.Station {
+		streamUrl, _ := url.Parse(upstream.URL + path)
+		return common.NewStationFromURL(*streamUrl, "")
+	}
+
+	t.Run("only if its player does", func(t *testing.T) {
+		_, ok := NewRelayPlaybackManager(&fakeEngine{}, nil, nil).(Comparer)
+		assert.False(t, ok)
+	})
+
+	t.Run("relaying both stations, and counting them", func(t *testing.T) {
+
+		engine := &comparingEngine{fakeEngine: &fakeEngine{}}
+		meter := NewMeter()
+		relay := NewRelayPlaybackManager(engine, nil, meter)
+		comparer, ok := relay.(Comparer)
+		if !assert.True(t, ok) {
+			return
+		}
+
+		assert.NoError(t, comparer.Compare(stationAt("/a.mp3"), 80, stationAt("/b.mp3"), 60))
+		assert.NoError(t, comparer.SwitchCompared())
+		assert.Equal(t, 1, engine.switches)
+
+		for i, path := range []string{"/a.mp3", "/b.mp3"} {
+			local := engine.compared[i].Url.URL
+			assert.Equal(t, "127.0.0.1", local.Hostname())
+			resp, err := http.Get(local.String())
+			if !assert.NoError(t, err) {
+				continue
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, path, string(body))
+		}
+		assert.Equal(t, uint64(len("/a.mp3/b.mp3")), meter.Received())
+
+		// Playing another station ends the comparison
+		assert.NoError(t, relay.PlayStation(stationAt("/c.mp3"), 80))
+		resp, err := http.Get(engine.compared[1].Url.URL.String())
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		}
+
+	})
+
+	t.Run("refusing HLS stations when relaying for the network", func(t *testing.T) {
+
+		hlsUrl, _ := url.Parse("https://example.com/live/master.m3u8")
+		transport, err := RelayTransport("", "")
+		assert.NoError(t, err)
+		engine := &comparingEngine{fakeEngine: &fakeEngine{}}
+		comparer := NewRelayPlaybackManager(engine, transport, nil).(Comparer)
+
+		err = comparer.Compare(stationAt("/a.mp3"), 80, common.NewStationFromURL(*hlsUrl, ""), 60)
+		assert.ErrorIs(t, err, ErrHLSNotRelayed)
+		assert.Equal(t, [2]common.Station{}, engine.compared)
+
+	})
+
+}
@@ -0,0 +1,266 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+// outputTailLines is how many lines of a backend's output are kept to explain why it exited.
+const outputTailLines = 3
+
+// outputGracePeriod is how long the output of a process is read for once it has exited.
+const outputGracePeriod = 200 * time.Millisecond
+
+// ProcessExit describes a backend process that exited on its own, without being stopped,
+// e.g. because it crashed or the station hung up.
+type ProcessExit struct {
+	// Name is the name of the program, e.g. "ffplay".
+	Name string
+	// Code is the exit code of the process, or -1 if it was killed by a signal.
+	Code int
+	// Output is the last lines the process printed before exiting.
+	Output string
+	// Reason is why RadioGoGo killed the process, e.g. ErrStreamStalled, or nil if it exited by itself.
+	Reason error
+
+	generation uint64
+}
+
+func (e ProcessExit) Error() string {
+	if e.Reason != nil {
+		return e.Reason.Error()
+	}
+	if e.Output == "" {
+		return i18n.Tf("playback.crashed", e.Name, e.Code)
+	}
+	return i18n.Tf("playback.crashedWithOutput", e.Name, e.Code, e.Output)
+}
+
+// Current returns true if no other process has been started since the one that exited,
+// i.e. if it was the one being listened to.
+func (e ProcessExit) Current() bool {
+	return supervisor.current(e.generation)
+}
+
+// Exits returns the backend processes that exit on their own.
+// Exits are dropped if they're not received, so nothing piles up when nobody is listening.
+func Exits() <-chan ProcessExit {
+	return supervisor.exits
+}
+
+// KillAll stops every backend process still running, so that none is left playing
+// once RadioGoGo is gone. It's meant to be called on the way out, whatever the reason.
+func KillAll() {
+	supervisor.killAll()
+}
+
+// process is a backend process owned by the supervisor, which reaps it once it exits.
+type process struct {
+	cmd *exec.Cmd
+	// Closed once the process has exited and been reaped
+	done chan struct{}
+
+	mu      sync.Mutex
+	stopped bool
+	output  []string
+	reason  error
+	exit    ProcessExit
+	// The last status line, which the process keeps printing while it plays
+	status string
+	// Changes when the process is made current again, as when switching between compared stations
+	generation uint64
+}
+
+// record keeps a line of output, dropping the oldest ones.
+func (p *process) record(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.output = append(p.output, line)
+	if len(p.output) > outputTailLines {
+		p.output = p.output[len(p.output)-outputTailLines:]
+	}
+}
+
+// setStatus keeps line as the last status line.
+func (p *process) setStatus(line string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status = line
+}
+
+// lastStatus returns the last status line printed, or an empty string if none was.
+func (p *process) lastStatus() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+// kill kills the process, which is then reported as exited for the given reason.
+func (p *process) kill(reason error) {
+	p.mu.Lock()
+	p.reason = reason
+	p.mu.Unlock()
+	_ = killProcess(p)
+}
+
+// hasExited returns true if the process has exited and been reaped.
+func (p *process) hasExited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// markStopped records that the process is being stopped on purpose.
+// It returns false if it had already exited.
+func (p *process) markStopped() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopped = true
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
+
+type processSupervisor struct {
+	mu         sync.Mutex
+	processes  map[*process]struct{}
+	generation uint64
+	exits      chan ProcessExit
+}
+
+var supervisor = &processSupervisor{
+	processes: make(map[*process]struct{}),
+	exits:     make(chan ProcessExit, 8),
+}
+
+// start starts cmd, waiting on it in the background so that it never lingers as a zombie.
+// outputDone must be closed once the output of the process has been read to the end.
+func (s *processSupervisor) start(cmd *exec.Cmd, outputDone <-chan struct{}) (*process, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	s.generation++
+	p := &process{cmd: cmd, generation: s.generation, done: make(chan struct{})}
+	s.processes[p] = struct{}{}
+
+	go func() {
+		err := cmd.Wait()
+		// Let the last lines of output in before telling why the process exited,
+		// unless a child it left behind keeps the output open
+		select {
+		case <-outputDone:
+		case <-time.After(outputGracePeriod):
+		}
+		s.exited(p, err)
+	}()
+
+	return p, nil
+}
+
+// exited forgets p and reports its exit, unless it was stopped on purpose.
+func (s *processSupervisor) exited(p *process, err error) {
+	s.mu.Lock()
+	delete(s.processes, p)
+	s.mu.Unlock()
+
+	code := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.ExitCode()
+	}
+
+	p.mu.Lock()
+	p.exit = ProcessExit{
+		Name:       p.name(),
+		Code:       code,
+		Output:     strings.Join(p.output, " / "),
+		Reason:     p.reason,
+		generation: p.generation,
+	}
+	stopped := p.stopped
+	close(p.done)
+	p.mu.Unlock()
+
+	if stopped {
+		return
+	}
+	select {
+	case s.exits <- p.exit:
+	default:
+	}
+}
+
+// makeCurrent makes p the process listened to, as if it had been started last.
+func (s *processSupervisor) makeCurrent(p *process) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s.generation++
+	p.generation = s.generation
+}
+
+// current returns true if generation is the last process started.
+func (s *processSupervisor) current(generation uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generation == generation
+}
+
+func (s *processSupervisor) killAll() {
+	s.mu.Lock()
+	processes := make([]*process, 0, len(s.processes))
+	for p := range s.processes {
+		processes = append(processes, p)
+	}
+	s.mu.Unlock()
+
+	for _, p := range processes {
+		_ = stopProcess(p)
+	}
+}
+
+// name returns the name of the program run by the process.
+func (p *process) name() string {
+	if len(p.cmd.Args) > 0 {
+		return p.cmd.Args[0]
+	}
+	return p.cmd.Path
+}
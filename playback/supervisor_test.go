@@ -0,0 +1,155 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestSupervisor returns a supervisor of its own, so that the tests don't see the exits of one another.
+func newTestSupervisor() *processSupervisor {
+	return &processSupervisor{
+		processes: make(map[*process]struct{}),
+		exits:     make(chan ProcessExit, 8),
+	}
+}
+
+// startTestProcess starts a shell running script, in place of a backend, with s.
+func startTestProcess(t *testing.T, s *processSupervisor, script string) *process {
+	if runtime.GOOS == "windows" {
+		t.Skip("no sh on Windows")
+	}
+	outputDone := make(chan struct{})
+	close(outputDone)
+	p, err := s.start(exec.Command("sh", "-c", script), outputDone)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { _ = stopProcess(p) })
+	return p
+}
+
+// receiveExit returns the next exit reported by s, failing if none is in time.
+func receiveExit(t *testing.T, s *processSupervisor) ProcessExit {
+	select {
+	case exit := <-s.exits:
+		return exit
+	case <-time.After(5 * time.Second):
+		t.Fatal("no exit reported")
+		return ProcessExit{}
+	}
+}
+
+func TestProcessSupervisor(t *testing.T) {
+
+	t.Run("reports a process exiting on its own, with the last lines it printed", func(t *testing.T) {
+
+		s := newTestSupervisor()
+		p := startTestProcess(t, s, "sleep 0.1; exit 3")
+		for _, line := range []string{"Opening stream", "  ", "Connection reset", "Broken pipe", "Exiting"} {
+			p.record(line)
+		}
+
+		exit := receiveExit(t, s)
+		assert.Equal(t, "sh", exit.Name)
+		assert.Equal(t, 3, exit.Code)
+		assert.Equal(t, "Connection reset / Broken pipe / Exiting", exit.Output)
+		assert.Nil(t, exit.Reason)
+		assert.True(t, p.hasExited())
+
+	})
+
+	t.Run("tells a crash from a kill", func(t *testing.T) {
+
+		s := newTestSupervisor()
+
+		startTestProcess(t, s, "exit 1")
+		crashed := receiveExit(t, s)
+		assert.Equal(t, 1, crashed.Code)
+		assert.Equal(t, "sh stopped unexpectedly (exit code 1)", crashed.Error())
+
+		p := startTestProcess(t, s, "sleep 10")
+		p.kill(ErrStreamSilent)
+		killed := receiveExit(t, s)
+		assert.Equal(t, -1, killed.Code)
+		assert.ErrorIs(t, killed.Reason, ErrStreamSilent)
+
+	})
+
+	t.Run("doesn't report processes stopped on purpose", func(t *testing.T) {
+
+		s := newTestSupervisor()
+		p := startTestProcess(t, s, "sleep 10")
+
+		assert.NoError(t, stopProcess(p))
+		assert.True(t, p.hasExited())
+		// Stopping it again does nothing
+		assert.NoError(t, stopProcess(p))
+
+		select {
+		case exit := <-s.exits:
+			t.Errorf("%v reported", exit)
+		default:
+		}
+
+	})
+
+	t.Run("tells which process is current", func(t *testing.T) {
+
+		s := newTestSupervisor()
+		first := startTestProcess(t, s, "sleep 10")
+		second := startTestProcess(t, s, "sleep 10")
+
+		assert.False(t, s.current(first.generation))
+		assert.True(t, s.current(second.generation))
+
+		s.makeCurrent(first)
+		assert.True(t, s.current(first.generation))
+		assert.False(t, s.current(second.generation))
+
+	})
+
+	t.Run("kills every process still running, without reporting them", func(t *testing.T) {
+
+		s := newTestSupervisor()
+		processes := []*process{
+			startTestProcess(t, s, "sleep 10"),
+			startTestProcess(t, s, "sleep 10"),
+			startTestProcess(t, s, "sleep 10"),
+		}
+
+		s.killAll()
+
+		for _, p := range processes {
+			assert.True(t, p.hasExited())
+		}
+		s.mu.Lock()
+		assert.Empty(t, s.processes)
+		s.mu.Unlock()
+		assert.Empty(t, s.exits)
+
+	})
+
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/icy"
+)
+
+// streamTee downloads a stream once and copies it to every sink as it arrives,
+// so that it can be played, buffered or saved at the same time.
+type streamTee struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startStreamTee connects to the stream at streamUrl and copies it to the sinks in the background.
+// It returns once the stream has answered, or with an error if it can't be played.
+// The headers are sent along with the request for the stream.
+// Sinks implementing io.Closer are closed when the stream ends or the tee is stopped.
+func startStreamTee(client *http.Client, streamUrl url.URL, headers common.StreamHeaders, sinks ...io.Writer) (*streamTee, error) {
+	return startStreamTeeWithTitles(client, streamUrl, headers, nil, sinks...)
+}
+
+// startStreamTeeWithTitles is startStreamTee also asking the stream for its ICY metadata, if onTitle isn't nil.
+// The metadata is left out of what the sinks get, and onTitle is called whenever the stream title changes.
+func startStreamTeeWithTitles(client *http.Client, streamUrl url.URL, headers common.StreamHeaders, onTitle func(title string), sinks ...io.Writer) (*streamTee, error) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", streamUrl.String(), nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	headers.Apply(req)
+	if onTitle != nil {
+		req.Header.Set("Icy-MetaData", "1")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("%s: %s", streamUrl.Redacted(), resp.Status)
+	}
+
+	tee := &streamTee{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	var body io.Reader = resp.Body
+	if metaInt, err := strconv.Atoi(resp.Header.Get("icy-metaint")); onTitle != nil && err == nil && metaInt > 0 {
+		last := ""
+		body = icy.NewMetadataReader(resp.Body, metaInt, func(metadata string) {
+			if title, ok := icy.ParseMetadata(metadata)["StreamTitle"]; ok && title != last {
+				last = title
+				onTitle(title)
+			}
+		})
+	}
+
+	go func() {
+		defer close(tee.done)
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.MultiWriter(sinks...), body)
+		for _, sink := range sinks {
+			if closer, ok := sink.(io.Closer); ok {
+				closer.Close()
+			}
+		}
+	}()
+
+	return tee, nil
+}
+
+// stop disconnects from the stream and waits for the sinks to be closed.
+func (t *streamTee) stop() {
+	t.cancel()
+	<-t.done
+}
@@ -0,0 +1,478 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+// How far ahead of real time buffered audio is sent to the player, so that it can fill its own buffer.
+const timeshiftLead = 2 * time.Second
+
+// ErrTimeshiftUnavailable is returned when the station being played can't be paused or rewound.
+var ErrTimeshiftUnavailable = i18n.Error("playback.timeshift.unavailable")
+
+// TimeshiftPlaybackManager wraps another playback manager, keeping the last minutes of the station
+// being played so that it can be paused and rewound.
+// The stream is downloaded by RadioGoGo itself, and the wrapped player is pointed at a local
+// server replaying it from the chosen position, at the pace it was received.
+type TimeshiftPlaybackManager struct {
+	player     PlaybackManagerService
+	window     time.Duration
+	httpClient *http.Client
+	// forNetwork is true when the stations are fetched with a transport of their own, for the network to let them through
+	forNetwork bool
+	// Counts the stream as it's downloaded, if not nil
+	meter *Meter
+	// The clock of the buffers and of the playhead
+	now func() time.Time
+
+	// Serializes the operations, which restart the player
+	opMu sync.Mutex
+
+	// Guards what follows, which is read by the view and the local server
+	mu       sync.Mutex
+	listener net.Listener
+	// Buffers by session: a session is one run of the player, and ends when it's restarted.
+	sessions map[int]*timeshiftSession
+	current  int
+	buffer   *timeshiftBuffer
+	tee      *streamTee
+	// Whether a station is on, even if paused or not timeshifted
+	playing bool
+	// Whether the station is played directly, e.g. because it's an HLS stream
+	passthrough bool
+	paused      bool
+	// When paused, where to resume from and when the pause started
+	pausedOffset int64
+	pausedAt     time.Time
+	pausedDelay  time.Duration
+
+	station common.Station
+	volume  int
+}
+
+// timeshiftSession is what the player is fed during one of its runs.
+type timeshiftSession struct {
+	buffer *timeshiftBuffer
+	// How far behind live the session plays
+	delay time.Duration
+	// When the session started
+	startedAt time.Time
+}
+
+// NewTimeshiftPlaybackManager returns player, keeping the given minutes of the station being played.
+// The stations are fetched with transport (the default one if nil) and counted with meter, unless it's nil.
+// HLS stations are played directly, unless transport is given: they're refused with ErrHLSNotRelayed then.
+func NewTimeshiftPlaybackManager(player PlaybackManagerService, minutes int, transport *http.Transport, meter *Meter) PlaybackManagerService {
+	forNetwork := transport != nil
+	if transport == nil {
+		transport = defaultStreamTransport()
+	}
+	return &TimeshiftPlaybackManager{
+		player:     player,
+		window:     time.Duration(minutes) * time.Minute,
+		httpClient: &http.Client{Transport: transport},
+		forNetwork: forNetwork,
+		meter:      meter,
+		now:        time.Now,
+		sessions:   make(map[int]*timeshiftSession),
+	}
+}
+
+func (d *TimeshiftPlaybackManager) Name() string {
+	return d.player.Name()
+}
+
+func (d *TimeshiftPlaybackManager) IsAvailable() bool {
+	return d.player.IsAvailable()
+}
+
+func (d *TimeshiftPlaybackManager) NotAvailableErrorString() string {
+	return d.player.NotAvailableErrorString()
+}
+
+// IsPlaying returns true while a station is on, even if it's paused.
+func (d *TimeshiftPlaybackManager) IsPlaying() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.playing
+}
+
+func (d *TimeshiftPlaybackManager) PlayStation(station common.Station, volume int) error {
+	d.opMu.Lock()
+	defer d.opMu.Unlock()
+
+	if isHLS(station) {
+		if d.forNetwork {
+			return ErrHLSNotRelayed
+		}
+		err := d.player.PlayStation(station, volume)
+		if err != nil {
+			return err
+		}
+		d.reset(true)
+		d.mu.Lock()
+		d.station = station
+		d.volume = volume
+		d.mu.Unlock()
+		return nil
+	}
+
+	if err := d.listen(); err != nil {
+		return err
+	}
+
+	streamUrl := station.UrlResolved.URL
+	if streamUrl.Host == "" {
+		streamUrl = station.Url.URL
+	}
+
+	buffer := newTimeshiftBuffer(d.window)
+	buffer.now = d.now
+	sinks := []io.Writer{buffer}
+	if d.meter != nil {
+		sinks = append(sinks, d.meter)
+	}
+	tee, err := startStreamTee(d.httpClient, streamUrl, station.Headers, sinks...)
+	if err != nil {
+		return err
+	}
+
+	// The previous station keeps being fed until the player has switched, if switches are seamless
+	previousTee := d.tee
+	err = d.startSession(station, volume, buffer, 0)
+	if err != nil {
+		tee.stop()
+		return err
+	}
+	if previousTee != nil {
+		previousTee.stop()
+	}
+
+	d.mu.Lock()
+	d.tee = tee
+	d.buffer = buffer
+	d.station = station
+	d.volume = volume
+	d.playing = true
+	d.passthrough = false
+	d.paused = false
+	d.mu.Unlock()
+
+	return nil
+}
+
+// startSession points the player at the given buffer, from offset. The operation lock must be held.
+func (d *TimeshiftPlaybackManager) startSession(station common.Station, volume int, buffer *timeshiftBuffer, offset int64) error {
+	now := d.now()
+	delay := now.Sub(buffer.arrival(offset))
+	if delay < 0 {
+		delay = 0
+	}
+
+	d.mu.Lock()
+	d.current++
+	id := d.current
+	d.sessions[id] = &timeshiftSession{
+		buffer:    buffer,
+		delay:     delay,
+		startedAt: now,
+	}
+	address := d.listener.Addr().String()
+	d.mu.Unlock()
+
+	local := url.URL{
+		Scheme:   "http",
+		Host:     address,
+		Path:     "/" + strconv.Itoa(id),
+		RawQuery: "from=" + strconv.FormatInt(offset, 10),
+	}
+	station.Url = common.RadioGoGoURL{URL: local}
+	station.UrlResolved = common.RadioGoGoURL{URL: local}
+
+	err := d.player.PlayStation(station, volume)
+
+	d.mu.Lock()
+	for other := range d.sessions {
+		if (err == nil && other != id) || (err != nil && other == id) {
+			delete(d.sessions, other)
+		}
+	}
+	d.mu.Unlock()
+
+	return err
+}
+
+// listen starts the local server the player connects to, if it isn't running yet.
+func (d *TimeshiftPlaybackManager) listen() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.listener != nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	d.listener = listener
+	go func() {
+		_ = http.Serve(listener, http.HandlerFunc(d.serveSession))
+	}()
+	return nil
+}
+
+// serveSession sends the buffer of a session to the player, as fast as it was received.
+func (d *TimeshiftPlaybackManager) serveSession(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+
+	d.mu.Lock()
+	session, ok := d.sessions[id]
+	d.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	var first time.Time
+	for {
+		data, at, next, err := session.buffer.read(offset)
+		if err != nil {
+			return
+		}
+		if first.IsZero() {
+			first = at
+		}
+		// Keep the pace of the original stream, or the player would race to the live edge
+		if wait := session.startedAt.Add(at.Sub(first)).Add(-timeshiftLead).Sub(d.now()); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		offset = next
+	}
+}
+
+// playhead returns the offset being heard and how far behind live it is.
+// The lock must be held.
+func (d *TimeshiftPlaybackManager) playhead() (int64, time.Duration) {
+	if d.paused {
+		delay := d.pausedDelay + d.now().Sub(d.pausedAt)
+		// What's older than the buffer is gone: resuming plays the oldest audio left
+		if maxDelay := d.now().Sub(d.buffer.oldest()); delay > maxDelay {
+			delay = maxDelay
+		}
+		return d.pausedOffset, delay
+	}
+	session, ok := d.sessions[d.current]
+	if !ok {
+		return 0, 0
+	}
+	offset := session.buffer.offsetAt(d.now().Add(-session.delay))
+	return offset, session.delay
+}
+
+func (d *TimeshiftPlaybackManager) Pause() error {
+	d.opMu.Lock()
+	defer d.opMu.Unlock()
+
+	d.mu.Lock()
+	if !d.playing || d.passthrough {
+		d.mu.Unlock()
+		return ErrTimeshiftUnavailable
+	}
+	if d.paused {
+		d.mu.Unlock()
+		return nil
+	}
+	d.pausedOffset, d.pausedDelay = d.playhead()
+	d.pausedAt = d.now()
+	d.paused = true
+	d.mu.Unlock()
+
+	return d.player.StopStation()
+}
+
+func (d *TimeshiftPlaybackManager) Resume() error {
+	d.opMu.Lock()
+	defer d.opMu.Unlock()
+
+	d.mu.Lock()
+	if !d.paused {
+		d.mu.Unlock()
+		return nil
+	}
+	offset := d.pausedOffset
+	d.mu.Unlock()
+
+	return d.resumeFrom(offset)
+}
+
+// resumeFrom restarts the player from offset. The operation lock must be held.
+func (d *TimeshiftPlaybackManager) resumeFrom(offset int64) error {
+	d.mu.Lock()
+	station, volume, buffer := d.station, d.volume, d.buffer
+	d.mu.Unlock()
+
+	err := d.startSession(station, volume, buffer, offset)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.paused = false
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *TimeshiftPlaybackManager) IsPaused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.paused
+}
+
+func (d *TimeshiftPlaybackManager) Seek(offset time.Duration) error {
+	d.opMu.Lock()
+	defer d.opMu.Unlock()
+
+	d.mu.Lock()
+	if !d.playing || d.passthrough {
+		d.mu.Unlock()
+		return ErrTimeshiftUnavailable
+	}
+	_, delay := d.playhead()
+	buffer := d.buffer
+	d.mu.Unlock()
+
+	// Seeking moves the delay behind live, within what the buffer holds
+	now := d.now()
+	delay -= offset
+	if maxDelay := now.Sub(buffer.oldest()); delay > maxDelay {
+		delay = maxDelay
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	target := buffer.offsetAt(now.Add(-delay))
+
+	d.mu.Lock()
+	if d.paused {
+		d.pausedOffset = target
+		d.pausedDelay = delay
+		d.pausedAt = now
+		d.mu.Unlock()
+		return nil
+	}
+	d.mu.Unlock()
+
+	return d.resumeFrom(target)
+}
+
+// GoLive plays from the live edge, even if paused.
+func (d *TimeshiftPlaybackManager) GoLive() error {
+	d.opMu.Lock()
+	defer d.opMu.Unlock()
+
+	d.mu.Lock()
+	if !d.playing || d.passthrough {
+		d.mu.Unlock()
+		return ErrTimeshiftUnavailable
+	}
+	buffer := d.buffer
+	d.mu.Unlock()
+
+	return d.resumeFrom(buffer.live())
+}
+
+func (d *TimeshiftPlaybackManager) Delay() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.playing || d.passthrough {
+		return 0
+	}
+	_, delay := d.playhead()
+	return delay
+}
+
+func (d *TimeshiftPlaybackManager) StopStation() error {
+	d.opMu.Lock()
+	defer d.opMu.Unlock()
+
+	err := d.player.StopStation()
+	d.reset(false)
+	return err
+}
+
+// reset forgets the station being timeshifted. The operation lock must be held.
+func (d *TimeshiftPlaybackManager) reset(passthrough bool) {
+	d.mu.Lock()
+	tee := d.tee
+	d.tee = nil
+	d.buffer = nil
+	d.sessions = make(map[int]*timeshiftSession)
+	d.playing = passthrough
+	d.passthrough = passthrough
+	d.paused = false
+	d.mu.Unlock()
+
+	if tee != nil {
+		tee.stop()
+	}
+}
+
+func (d *TimeshiftPlaybackManager) VolumeMin() int {
+	return d.player.VolumeMin()
+}
+
+func (d *TimeshiftPlaybackManager) VolumeDefault() int {
+	return d.player.VolumeDefault()
+}
+
+func (d *TimeshiftPlaybackManager) VolumeMax() int {
+	return d.player.VolumeMax()
+}
+
+func (d *TimeshiftPlaybackManager) VolumeIsPercentage() bool {
+	return d.player.VolumeIsPercentage()
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEngine is a player recording the stations it's asked to play.
+type fakeEngine struct {
+	mu      sync.Mutex
+	played  []common.Station
+	stopped int
+	playing bool
+}
+
+func (e *fakeEngine) Name() string                    { return "fake" }
+func (e *fakeEngine) IsAvailable() bool               { return true }
+func (e *fakeEngine) NotAvailableErrorString() string { return "" }
+func (e *fakeEngine) VolumeMin() int                  { return 0 }
+func (e *fakeEngine) VolumeDefault() int              { return 80 }
+func (e *fakeEngine) VolumeMax() int                  { return 100 }
+func (e *fakeEngine) VolumeIsPercentage() bool        { return true }
+
+func (e *fakeEngine) IsPlaying() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.playing
+}
+
+func (e *fakeEngine) PlayStation(station common.Station, volume int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.played = append(e.played, station)
+	e.playing = true
+	return nil
+}
+
+func (e *fakeEngine) StopStation() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stopped++
+	e.playing = false
+	return nil
+}
+
+// last returns the URL of the last station played.
+func (e *fakeEngine) last() url.URL {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.played[len(e.played)-1].Url.URL
+}
+
+// fakeClock is a clock moved by hand.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// silentStream serves a stream that never sends anything, for the tests to fill the buffer by hand.
+func silentStream(t *testing.T) common.Station {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	streamUrl, _ := url.Parse(server.URL + "/live.mp3")
+	return common.NewStationFromURL(*streamUrl, "")
+}
+
+// playingTimeshift returns a timeshift manager keeping minutes of a station it's playing with a fake engine.
+func playingTimeshift(t *testing.T, minutes int) (*TimeshiftPlaybackManager, *fakeEngine, *fakeClock) {
+	engine := &fakeEngine{}
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)}
+	d := NewTimeshiftPlaybackManager(engine, minutes, nil, nil).(*TimeshiftPlaybackManager)
+	d.now = clock.Now
+	assert.NoError(t, d.PlayStation(silentStream(t), 80))
+	t.Cleanup(func() { _ = d.StopStation() })
+	return d, engine, clock
+}
+
+// receive writes one byte a second to the buffer of d for the given seconds.
+func receive(d *TimeshiftPlaybackManager, clock *fakeClock, seconds int) {
+	for i := 0; i < seconds; i++ {
+		clock.Advance(time.Second)
+		_, _ = d.buffer.Write([]byte{byte(i)})
+	}
+}
+
+// playedFrom returns the offset the engine was last pointed at.
+func playedFrom(engine *fakeEngine) int64 {
+	last := engine.last()
+	offset, _ := strconv.ParseInt(last.Query().Get("from"), 10, 64)
+	return offset
+}
+
+func TestTimeshiftPlaybackManager(t *testing.T) {
+
+	t.Run("points the player at the local server, from the live edge", func(t *testing.T) {
+
+		d, engine, _ := playingTimeshift(t, 5)
+
+		local := engine.last()
+		assert.Equal(t, "127.0.0.1", local.Hostname())
+		assert.Equal(t, int64(0), playedFrom(engine))
+		assert.True(t, d.IsPlaying())
+		assert.False(t, d.IsPaused())
+		assert.Equal(t, time.Duration(0), d.Delay())
+
+	})
+
+	t.Run("serves the buffer to the player", func(t *testing.T) {
+
+		d, engine, clock := playingTimeshift(t, 5)
+		receive(d, clock, 3)
+
+		local := engine.last()
+		resp, err := http.Get(local.String())
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer resp.Body.Close()
+		data := make([]byte, 3)
+		_, err = io.ReadFull(resp.Body, data)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0, 1, 2}, data)
+
+	})
+
+	t.Run("pauses, falling behind live while paused, and resumes from where it paused", func(t *testing.T) {
+
+		d, engine, clock := playingTimeshift(t, 5)
+		receive(d, clock, 10)
+
+		assert.NoError(t, d.Pause())
+		assert.True(t, d.IsPaused())
+		assert.True(t, d.IsPlaying())
+		assert.Equal(t, 1, engine.stopped)
+
+		receive(d, clock, 20)
+		assert.Equal(t, 20*time.Second, d.Delay())
+
+		assert.NoError(t, d.Resume())
+		assert.False(t, d.IsPaused())
+		// The last byte received before the pause arrived at the very moment it paused
+		assert.Equal(t, int64(9), playedFrom(engine))
+		assert.Equal(t, 20*time.Second, d.Delay())
+
+	})
+
+	t.Run("seeks back within the buffer, and not past its oldest data", func(t *testing.T) {
+
+		d, engine, clock := playingTimeshift(t, 5)
+		receive(d, clock, 60)
+
+		assert.NoError(t, d.Seek(-30*time.Second))
+		assert.Equal(t, 30*time.Second, d.Delay())
+		assert.Equal(t, int64(29), playedFrom(engine))
+
+		assert.NoError(t, d.Seek(-time.Hour))
+		assert.Equal(t, 59*time.Second, d.Delay())
+		assert.Equal(t, int64(0), playedFrom(engine))
+
+		assert.NoError(t, d.Seek(time.Hour))
+		assert.Equal(t, time.Duration(0), d.Delay())
+		assert.Equal(t, int64(59), playedFrom(engine))
+
+	})
+
+	t.Run("seeks while paused without restarting the player", func(t *testing.T) {
+
+		d, engine, clock := playingTimeshift(t, 5)
+		receive(d, clock, 60)
+		assert.NoError(t, d.Pause())
+		played := len(engine.played)
+
+		assert.NoError(t, d.Seek(-30*time.Second))
+		assert.Equal(t, played, len(engine.played))
+		assert.Equal(t, 30*time.Second, d.Delay())
+
+		assert.NoError(t, d.Resume())
+		assert.Equal(t, int64(29), playedFrom(engine))
+
+	})
+
+	t.Run("goes live, even if paused", func(t *testing.T) {
+
+		d, engine, clock := playingTimeshift(t, 5)
+		receive(d, clock, 60)
+		assert.NoError(t, d.Seek(-30*time.Second))
+		assert.NoError(t, d.Pause())
+
+		assert.NoError(t, d.GoLive())
+		assert.False(t, d.IsPaused())
+		assert.Equal(t, int64(60), playedFrom(engine))
+		assert.Equal(t, time.Duration(0), d.Delay())
+
+	})
+
+	t.Run("keeps the minutes it's given", func(t *testing.T) {
+
+		d, engine, clock := playingTimeshift(t, 2)
+		assert.Equal(t, 2*time.Minute, d.buffer.window)
+
+		receive(d, clock, 3*60)
+		assert.NoError(t, d.Seek(-time.Hour))
+		assert.Equal(t, 2*time.Minute, d.Delay())
+		assert.Equal(t, int64(59), playedFrom(engine))
+
+	})
+
+	t.Run("is unavailable when nothing is playing", func(t *testing.T) {
+
+		d, engine, _ := playingTimeshift(t, 5)
+		assert.NoError(t, d.StopStation())
+		assert.False(t, d.IsPlaying())
+		assert.False(t, engine.IsPlaying())
+
+		assert.ErrorIs(t, d.Pause(), ErrTimeshiftUnavailable)
+		assert.ErrorIs(t, d.Seek(-time.Second), ErrTimeshiftUnavailable)
+		assert.ErrorIs(t, d.GoLive(), ErrTimeshiftUnavailable)
+		assert.Equal(t, time.Duration(0), d.Delay())
+
+	})
+
+}
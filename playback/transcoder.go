@@ -0,0 +1,106 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"io"
+	"os/exec"
+	"sync"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+// transcoder re-encodes what's written to it with an ffmpeg process, writing the result to a sink.
+// Writing fails once the sink does, with its error (e.g. a recording limit).
+type transcoder struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	sink  io.WriteCloser
+	// copied is closed once the output of ffmpeg has been copied to the sink
+	copied chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// newTranscoder starts ffmpeg with args, which must read from its standard input and write to its standard output.
+func newTranscoder(args []string, sink io.WriteCloser) (*transcoder, error) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return nil, i18n.Error("playback.ffmpeg.notAvailable")
+	}
+	t := &transcoder{
+		cmd:    exec.Command("ffmpeg", args...),
+		sink:   sink,
+		copied: make(chan struct{}),
+	}
+	stdin, err := t.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := t.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.cmd.Start(); err != nil {
+		return nil, err
+	}
+	t.stdin = stdin
+
+	go func() {
+		defer close(t.copied)
+		if _, err := io.Copy(t.sink, stdout); err != nil {
+			t.fail(err)
+			// Let ffmpeg exit rather than block on a full pipe
+			_, _ = io.Copy(io.Discard, stdout)
+		}
+	}()
+
+	return t, nil
+}
+
+// fail remembers the first error writing to the sink.
+func (t *transcoder) fail(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err == nil {
+		t.err = err
+	}
+}
+
+func (t *transcoder) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	err := t.err
+	t.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	return t.stdin.Write(p)
+}
+
+// Close lets ffmpeg encode what it was given, waits for it to exit, then closes the sink.
+func (t *transcoder) Close() error {
+	t.stdin.Close()
+	<-t.copied
+	waitErr := t.cmd.Wait()
+	if err := t.sink.Close(); err != nil {
+		return err
+	}
+	return waitErr
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+var (
+	// ErrStreamStalled is the reason of a ProcessExit whose station stopped sending audio.
+	ErrStreamStalled = i18n.Error("playback.stalled")
+	// ErrStreamSilent is the reason of a ProcessExit whose station has only sent silence for a while.
+	ErrStreamSilent = i18n.Error("playback.silent")
+)
+
+// How often a watchdog checks on its process.
+const watchdogInterval = time.Second
+
+// silenceThreshold is how quiet audio is to count as silence.
+const silenceThreshold = "-60dB"
+
+// silenceMarker is printed by the silencedetect filter once the audio has been silent long enough.
+const silenceMarker = "silence_start"
+
+// silenceFilter returns the audio filter reporting silence lasting at least duration.
+func silenceFilter(duration time.Duration) string {
+	return fmt.Sprintf("silencedetect=noise=%s:d=%.0f", silenceThreshold, duration.Seconds())
+}
+
+// progressAfter returns a function reading the progress of a backend from its status lines,
+// as the token following key, e.g. "time=" for ffmpeg.
+func progressAfter(key string) func(line string) (string, bool) {
+	return func(line string) (string, bool) {
+		i := strings.Index(line, key)
+		if i < 0 {
+			return "", false
+		}
+		fields := strings.Fields(line[i+len(key):])
+		if len(fields) == 0 {
+			return "", false
+		}
+		return fields[0], true
+	}
+}
+
+// watchdog tells when a backend has stopped making progress through the stream,
+// or has reported silence, for longer than its timeout.
+type watchdog struct {
+	timeout time.Duration
+	// Reads the progress of the backend from a line of its output, if it's a status line
+	progress func(line string) (string, bool)
+	now      func() time.Time
+
+	mu           sync.Mutex
+	lastProgress string
+	lastChange   time.Time
+	silent       bool
+}
+
+// newWatchdog returns a watchdog reading progress with progress, or nil if the watchdog is disabled.
+func (o Options) newWatchdog(progress func(line string) (string, bool)) *watchdog {
+	if o.WatchdogTimeout <= 0 {
+		return nil
+	}
+	return &watchdog{timeout: o.WatchdogTimeout, progress: progress, now: time.Now}
+}
+
+// start gives the backend the whole timeout from now on, as it's only just started playing.
+func (w *watchdog) start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastChange = w.now()
+}
+
+// observe reads a line of output of the backend.
+func (w *watchdog) observe(line string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if strings.Contains(line, silenceMarker) {
+		w.silent = true
+		return
+	}
+	if progress, ok := w.progress(line); ok && progress != w.lastProgress {
+		w.lastProgress = progress
+		w.lastChange = w.now()
+	}
+}
+
+// check returns why the backend should be restarted, or nil if it's fine.
+func (w *watchdog) check() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.silent {
+		return ErrStreamSilent
+	}
+	if w.now().Sub(w.lastChange) > w.timeout {
+		return ErrStreamStalled
+	}
+	return nil
+}
+
+// guard kills proc as soon as the watchdog tells, recording why, until proc exits.
+func (w *watchdog) guard(proc *process) {
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-proc.done:
+			return
+		case <-ticker.C:
+			if reason := w.check(); reason != nil {
+				proc.kill(reason)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package playback
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clockedWatchdog returns a started watchdog with the given timeout, whose clock is moved with the returned clock.
+func clockedWatchdog(timeout time.Duration, progress func(line string) (string, bool)) (*watchdog, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)}
+	w := Options{WatchdogTimeout: timeout}.newWatchdog(progress)
+	w.now = clock.Now
+	w.start()
+	return w, clock
+}
+
+func TestProgressAfter(t *testing.T) {
+
+	tests := []struct {
+		key      string
+		line     string
+		progress string
+		ok       bool
+	}{
+		{"time=", "size=     128kB time=00:00:08.07 bitrate= 129.9kbits/s speed=1.01x", "00:00:08.07", true},
+		{"A:", "AV: 00:00:12 / 00:00:00 (0%) A: 00:00:12", "00:00:12", true},
+		{"time=", "size=       0kB time=", "", false},
+		{"time=", "Stream #0:0: Audio: mp3, 44100 Hz, stereo", "", false},
+	}
+	for _, test := range tests {
+		progress, ok := progressAfter(test.key)(test.line)
+		assert.Equal(t, test.progress, progress, test.line)
+		assert.Equal(t, test.ok, ok, test.line)
+	}
+
+}
+
+func TestSilenceFilter(t *testing.T) {
+	assert.Equal(t, "silencedetect=noise=-60dB:d=20", silenceFilter(20*time.Second))
+}
+
+func TestWatchdog(t *testing.T) {
+
+	progress := progressAfter("time=")
+
+	t.Run("is disabled without a timeout", func(t *testing.T) {
+		assert.Nil(t, Options{}.newWatchdog(progress))
+	})
+
+	t.Run("gives a backend the whole timeout once started", func(t *testing.T) {
+
+		w, clock := clockedWatchdog(10*time.Second, progress)
+
+		clock.Advance(10 * time.Second)
+		assert.NoError(t, w.check())
+
+		clock.Advance(time.Millisecond)
+		assert.ErrorIs(t, w.check(), ErrStreamStalled)
+
+	})
+
+	t.Run("is reset by progress, and only by progress", func(t *testing.T) {
+
+		w, clock := clockedWatchdog(10*time.Second, progress)
+
+		clock.Advance(8 * time.Second)
+		w.observe("size=  64kB time=00:00:01.00 bitrate= 128kbits/s")
+		clock.Advance(8 * time.Second)
+		assert.NoError(t, w.check())
+
+		// The same position, printed again, is no progress
+		w.observe("size=  64kB time=00:00:01.00 bitrate= 128kbits/s")
+		w.observe("[http @ 0x1] Will reconnect at 1234 in 0 second(s)")
+		clock.Advance(3 * time.Second)
+		assert.ErrorIs(t, w.check(), ErrStreamStalled)
+
+	})
+
+	t.Run("tells when the backend reports silence, however recent the progress", func(t *testing.T) {
+
+		w, _ := clockedWatchdog(10*time.Second, progress)
+
+		w.observe("[silencedetect @ 0x1] silence_start: 12.5")
+		w.observe("size=  64kB time=00:00:02.00 bitrate= 128kbits/s")
+
+		assert.ErrorIs(t, w.check(), ErrStreamSilent)
+
+	})
+
+	t.Run("kills the process it guards once it trips, telling why", func(t *testing.T) {
+
+		s := newTestSupervisor()
+		p := startTestProcess(t, s, "sleep 10")
+		w, clock := clockedWatchdog(10*time.Second, progress)
+		clock.Advance(time.Minute)
+
+		w.guard(p)
+
+		exit := <-s.exits
+		assert.Equal(t, -1, exit.Code)
+		assert.ErrorIs(t, exit.Reason, ErrStreamStalled)
+		assert.Equal(t, ErrStreamStalled.Error(), exit.Error())
+
+	})
+
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package recording
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the audio format recordings are saved in.
+type Format string
+
+const (
+	// FormatCopy saves the stream as it is broadcast. It's the default.
+	FormatCopy Format = "copy"
+	// FormatMP3, FormatOpus and FormatAAC re-encode the stream with ffmpeg while it's recorded.
+	FormatMP3  Format = "mp3"
+	FormatOpus Format = "opus"
+	FormatAAC  Format = "aac"
+)
+
+// Formats lists the formats recordings can be saved in.
+var Formats = []Format{FormatCopy, FormatMP3, FormatOpus, FormatAAC}
+
+// UnmarshalYAML decodes a format, rejecting unknown ones. An empty format is FormatCopy.
+func (f *Format) UnmarshalYAML(value *yaml.Node) error {
+	var name string
+	if err := value.Decode(&name); err != nil {
+		return err
+	}
+	format := Format(strings.ToLower(strings.TrimSpace(name)))
+	if format == "" {
+		format = FormatCopy
+	}
+	for _, known := range Formats {
+		if format == known {
+			*f = format
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown recording format: %s", name)
+}
+
+// Encoding is how a recording is saved: as broadcast, or re-encoded to another format.
+type Encoding struct {
+	Format Format `yaml:"format"`
+	// Bitrate is the bitrate of the re-encoded audio, in kbit/s (0 for ffmpeg's default).
+	Bitrate int `yaml:"bitrate,omitempty"`
+	// Args are more ffmpeg output arguments, e.g. ["-ac", "1"] to downmix to mono.
+	Args []string `yaml:"args,omitempty"`
+}
+
+// Copies returns true if the recording is saved as broadcast.
+func (e Encoding) Copies() bool {
+	return e.Format == "" || e.Format == FormatCopy
+}
+
+// Codec returns the codec of the recording of a stream broadcast with codec (as named by radio-browser),
+// which names its files.
+func (e Encoding) Codec(codec string) string {
+	if e.Copies() {
+		return codec
+	}
+	return string(e.Format)
+}
+
+// Splittable returns true if the recording can be cut into tracks anywhere:
+// Opus is saved in an Ogg container, whose pages can't be cut apart.
+func (e Encoding) Splittable() bool {
+	return e.Format != FormatOpus
+}
+
+// FFmpegArgs returns the arguments of the ffmpeg process re-encoding the stream it reads on
+// its standard input to its standard output. Returns nil if the recording is saved as broadcast.
+func (e Encoding) FFmpegArgs() []string {
+	var encoder, muxer string
+	switch e.Format {
+	case FormatMP3:
+		encoder, muxer = "libmp3lame", "mp3"
+	case FormatOpus:
+		encoder, muxer = "libopus", "ogg"
+	case FormatAAC:
+		encoder, muxer = "aac", "adts"
+	default:
+		return nil
+	}
+	args := []string{"-hide_banner", "-loglevel", "error", "-i", "pipe:0", "-vn", "-c:a", encoder}
+	if e.Bitrate > 0 {
+		args = append(args, "-b:a", fmt.Sprintf("%dk", e.Bitrate))
+	}
+	args = append(args, e.Args...)
+	return append(args, "-f", muxer, "pipe:1")
+}
+
+// Encodings maps the codecs stations broadcast with to how their recordings are saved.
+type Encodings struct {
+	// Default is how the recordings of codecs not listed in Codecs are saved.
+	Default Encoding `yaml:"default"`
+	// Codecs maps codecs, as named by radio-browser (e.g. "aac+", "flac"), to how their recordings are saved.
+	Codecs map[string]Encoding `yaml:"codecs,omitempty"`
+}
+
+// For returns how the recordings of a stream broadcast with codec are saved.
+func (e Encodings) For(codec string) Encoding {
+	codec = strings.ToLower(strings.TrimSpace(codec))
+	for name, encoding := range e.Codecs {
+		if strings.ToLower(name) == codec {
+			return encoding
+		}
+	}
+	return e.Default
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package recording
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestEncoding(t *testing.T) {
+
+	t.Run("saves the stream as broadcast by default", func(t *testing.T) {
+		var encoding Encoding
+		assert.True(t, encoding.Copies())
+		assert.Equal(t, "AAC+", encoding.Codec("AAC+"))
+		assert.Nil(t, encoding.FFmpegArgs())
+	})
+
+	t.Run("re-encodes the stream with ffmpeg", func(t *testing.T) {
+		encoding := Encoding{Format: FormatMP3, Bitrate: 192, Args: []string{"-ac", "1"}}
+		assert.False(t, encoding.Copies())
+		assert.Equal(t, "mp3", encoding.Codec("FLAC"))
+		assert.Equal(t, []string{
+			"-hide_banner", "-loglevel", "error", "-i", "pipe:0", "-vn",
+			"-c:a", "libmp3lame", "-b:a", "192k", "-ac", "1", "-f", "mp3", "pipe:1",
+		}, encoding.FFmpegArgs())
+	})
+
+	t.Run("saves Opus in an Ogg container, which can't be split", func(t *testing.T) {
+		encoding := Encoding{Format: FormatOpus}
+		assert.Equal(t, []string{"-hide_banner", "-loglevel", "error", "-i", "pipe:0", "-vn", "-c:a", "libopus", "-f", "ogg", "pipe:1"}, encoding.FFmpegArgs())
+		assert.False(t, encoding.Splittable())
+		assert.True(t, Encoding{Format: FormatAAC}.Splittable())
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		var format Format
+		assert.NoError(t, yaml.Unmarshal([]byte(`AAC`), &format))
+		assert.Equal(t, FormatAAC, format)
+		assert.Error(t, yaml.Unmarshal([]byte(`wav`), &format))
+	})
+}
+
+func TestEncodings(t *testing.T) {
+
+	mp3 := Encoding{Format: FormatMP3}
+	encodings := Encodings{
+		Default: Encoding{Format: FormatCopy},
+		Codecs:  map[string]Encoding{"FLAC": mp3},
+	}
+
+	assert.Equal(t, mp3, encodings.For("flac"))
+	assert.True(t, encodings.For("MP3").Copies())
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package recording
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+// How much a recording writes between two checks of the free space, at most.
+const (
+	freeSpaceCheckBytes    = 1 << 20
+	freeSpaceCheckInterval = 10 * time.Second
+)
+
+var (
+	// ErrDiskSpaceLow is returned when the free space left for the recordings is below the minimum.
+	ErrDiskSpaceLow = i18n.Error("recording.diskSpaceLow")
+	// ErrMaxSize is returned when a recording has reached its maximum size.
+	ErrMaxSize = i18n.Error("recording.maxSize")
+	// ErrMaxDuration is returned when a recording has reached its maximum duration.
+	ErrMaxDuration = i18n.Error("recording.maxDuration")
+)
+
+// Limits keep recordings from filling the disk. Zero values mean no limit.
+type Limits struct {
+	// MaxSize is the size a recording stops at, in bytes.
+	MaxSize int64
+	// MaxDuration is how long a recording lasts at most.
+	MaxDuration time.Duration
+	// MinFreeSpace is the free space, in bytes, below which no recording is started and those in progress stop.
+	MinFreeSpace uint64
+}
+
+// CheckFreeSpace returns ErrDiskSpaceLow if the free space in directory, or in its closest existing parent,
+// is below the minimum of limits. If the free space can't be told, it's assumed to be enough.
+func CheckFreeSpace(directory string, limits Limits) error {
+	return checkFreeSpace(FreeSpace, directory, limits.MinFreeSpace)
+}
+
+func checkFreeSpace(freeSpace func(directory string) (uint64, error), directory string, min uint64) error {
+	if min == 0 {
+		return nil
+	}
+	free, err := freeSpace(existingParent(directory))
+	if err != nil {
+		return nil
+	}
+	if free < min {
+		return ErrDiskSpaceLow
+	}
+	return nil
+}
+
+// Guard is a recording's file, stopping it once it reaches its limits.
+// Writing past them fails with the limit reached, which Err returns from then on.
+// It's safe for concurrent use.
+type Guard struct {
+	sink      io.WriteCloser
+	directory string
+	limits    Limits
+	freeSpace func(directory string) (uint64, error)
+	now       func() time.Time
+
+	mu      sync.Mutex
+	started time.Time
+	written int64
+	// Bytes written and time of the last check of the free space
+	checkedAt     int64
+	checkedAtTime time.Time
+	err           error
+}
+
+// NewGuard returns a Guard writing to sink, a file of the recording in directory.
+func NewGuard(sink io.WriteCloser, directory string, limits Limits) *Guard {
+	now := time.Now()
+	return &Guard{
+		sink:          sink,
+		directory:     directory,
+		limits:        limits,
+		freeSpace:     FreeSpace,
+		now:           time.Now,
+		started:       now,
+		checkedAtTime: now,
+	}
+}
+
+func (g *Guard) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.err != nil {
+		return 0, g.err
+	}
+	if err := g.check(int64(len(p))); err != nil {
+		g.err = err
+		return 0, err
+	}
+	n, err := g.sink.Write(p)
+	g.written += int64(n)
+	return n, err
+}
+
+// check returns the limit that writing n more bytes would go past, if any. The lock must be held.
+func (g *Guard) check(n int64) error {
+	now := g.now()
+	if g.limits.MaxDuration > 0 && now.Sub(g.started) >= g.limits.MaxDuration {
+		return ErrMaxDuration
+	}
+	if g.limits.MaxSize > 0 && g.written+n > g.limits.MaxSize {
+		return ErrMaxSize
+	}
+	if g.limits.MinFreeSpace > 0 && (g.written+n-g.checkedAt >= freeSpaceCheckBytes || now.Sub(g.checkedAtTime) >= freeSpaceCheckInterval) {
+		g.checkedAt = g.written + n
+		g.checkedAtTime = now
+		return checkFreeSpace(g.freeSpace, g.directory, g.limits.MinFreeSpace)
+	}
+	return nil
+}
+
+// Err returns the limit the recording stopped at, or nil if it didn't reach any.
+func (g *Guard) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
+
+// Close closes the file.
+func (g *Guard) Close() error {
+	return g.sink.Close()
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package recording
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nopCloser is a buffer that can be closed, counting the bytes written to it.
+type nopCloser struct {
+	written int
+}
+
+func (w *nopCloser) Write(p []byte) (int, error) {
+	w.written += len(p)
+	return len(p), nil
+}
+
+func (w *nopCloser) Close() error {
+	return nil
+}
+
+func TestGuard(t *testing.T) {
+
+	start := time.Date(2024, 3, 10, 20, 0, 0, 0, time.UTC)
+
+	newGuard := func(limits Limits, free uint64) (*Guard, *nopCloser, *time.Time) {
+		sink := &nopCloser{}
+		now := start
+		guard := NewGuard(sink, t.TempDir(), limits)
+		guard.now = func() time.Time { return now }
+		guard.started = start
+		guard.checkedAtTime = start
+		guard.freeSpace = func(string) (uint64, error) { return free, nil }
+		return guard, sink, &now
+	}
+
+	t.Run("stops at the maximum size", func(t *testing.T) {
+
+		guard, sink, _ := newGuard(Limits{MaxSize: 10}, 0)
+
+		_, err := guard.Write(make([]byte, 8))
+		assert.NoError(t, err)
+		_, err = guard.Write(make([]byte, 8))
+		assert.ErrorIs(t, err, ErrMaxSize)
+		assert.ErrorIs(t, guard.Err(), ErrMaxSize)
+		assert.Equal(t, 8, sink.written)
+
+		_, err = guard.Write(make([]byte, 1))
+		assert.ErrorIs(t, err, ErrMaxSize)
+
+	})
+
+	t.Run("stops at the maximum duration", func(t *testing.T) {
+
+		guard, _, now := newGuard(Limits{MaxDuration: time.Hour}, 0)
+
+		_, err := guard.Write(make([]byte, 8))
+		assert.NoError(t, err)
+		*now = start.Add(time.Hour)
+		_, err = guard.Write(make([]byte, 8))
+		assert.ErrorIs(t, err, ErrMaxDuration)
+
+	})
+
+	t.Run("stops when the free space drops below the minimum", func(t *testing.T) {
+
+		guard, sink, now := newGuard(Limits{MinFreeSpace: 100 << 20}, 50<<20)
+
+		// Not checked again until a while or a megabyte later
+		_, err := guard.Write(make([]byte, 8))
+		assert.NoError(t, err)
+		*now = start.Add(freeSpaceCheckInterval)
+		_, err = guard.Write(make([]byte, 8))
+		assert.ErrorIs(t, err, ErrDiskSpaceLow)
+		assert.Equal(t, 8, sink.written)
+
+	})
+
+	t.Run("carries on without limits", func(t *testing.T) {
+
+		guard, _, now := newGuard(Limits{}, 0)
+
+		*now = start.Add(24 * time.Hour)
+		_, err := guard.Write(make([]byte, 2<<20))
+		assert.NoError(t, err)
+		assert.NoError(t, guard.Err())
+
+	})
+
+}
+
+func TestCheckFreeSpace(t *testing.T) {
+
+	free := func(free uint64, err error) func(string) (uint64, error) {
+		return func(string) (uint64, error) { return free, err }
+	}
+
+	assert.NoError(t, checkFreeSpace(free(200, nil), t.TempDir(), 100))
+	assert.ErrorIs(t, checkFreeSpace(free(50, nil), t.TempDir(), 100), ErrDiskSpaceLow)
+	assert.NoError(t, checkFreeSpace(free(0, errors.New("unsupported")), t.TempDir(), 100))
+	assert.NoError(t, checkFreeSpace(free(0, nil), t.TempDir(), 0))
+
+	t.Run("measures the closest existing parent", func(t *testing.T) {
+
+		dir := t.TempDir()
+		var measured string
+		_ = checkFreeSpace(func(directory string) (uint64, error) {
+			measured = directory
+			return 0, nil
+		}, filepath.Join(dir, "recordings", "Jazz FM"), 1)
+		assert.Equal(t, dir, measured)
+
+		_, err := os.Stat(filepath.Join(dir, "recordings"))
+		assert.True(t, os.IsNotExist(err))
+
+	})
+
+	t.Run("tells the free space of this system", func(t *testing.T) {
+
+		free, err := FreeSpace(t.TempDir())
+		assert.NoError(t, err)
+		assert.Greater(t, free, uint64(0))
+
+	})
+
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package recording describes the recordings scheduled by the user, and when they are due.
+package recording
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// oneOffLayout is the layout of the start of one-off recordings.
+const oneOffLayout = "2006-01-02 15:04"
+
+// Entry is a recording of a station made at a set time, once or every week.
+type Entry struct {
+	StationUuid uuid.UUID `yaml:"station"`
+	// StationName names the files, and defaults to the name of the station.
+	StationName string `yaml:"name,omitempty"`
+	// Start is a date and a time, e.g. "2024-03-10 20:00", for a one-off recording,
+	// or a weekday and a time, e.g. "friday 20:00", for a weekly one. Times are local.
+	Start    string        `yaml:"start"`
+	Duration time.Duration `yaml:"duration"`
+
+	start start
+}
+
+// start is when an entry is due.
+type start struct {
+	// at is set for one-off recordings
+	at      time.Time
+	weekly  bool
+	weekday time.Weekday
+	hour    int
+	minute  int
+}
+
+// NewEntry returns an entry recording the given station from start for duration.
+// See Entry.Start for the format of start.
+func NewEntry(stationUuid uuid.UUID, stationName string, start string, duration time.Duration) (Entry, error) {
+	entry := Entry{
+		StationUuid: stationUuid,
+		StationName: stationName,
+		Start:       start,
+		Duration:    duration,
+	}
+	return entry, entry.parse()
+}
+
+// UnmarshalYAML decodes an entry, checking its start and duration.
+func (e *Entry) UnmarshalYAML(value *yaml.Node) error {
+	var entry struct {
+		StationUuid uuid.UUID     `yaml:"station"`
+		StationName string        `yaml:"name"`
+		Start       string        `yaml:"start"`
+		Duration    time.Duration `yaml:"duration"`
+	}
+	if err := value.Decode(&entry); err != nil {
+		return err
+	}
+	parsed := Entry{
+		StationUuid: entry.StationUuid,
+		StationName: entry.StationName,
+		Start:       entry.Start,
+		Duration:    entry.Duration,
+	}
+	if err := parsed.parse(); err != nil {
+		return err
+	}
+	*e = parsed
+	return nil
+}
+
+func (e *Entry) parse() error {
+	if e.Duration <= 0 {
+		return errors.New("invalid recording duration: " + e.Duration.String())
+	}
+	start, err := parseStart(e.Start)
+	if err != nil {
+		return err
+	}
+	e.start = start
+	return nil
+}
+
+func parseStart(value string) (start, error) {
+	value = strings.TrimSpace(value)
+	if at, err := time.ParseInLocation(oneOffLayout, value, time.Local); err == nil {
+		return start{at: at}, nil
+	}
+
+	fields := strings.Fields(value)
+	if len(fields) == 2 {
+		weekday, ok := parseWeekday(fields[0])
+		clock, err := time.Parse("15:04", fields[1])
+		if ok && err == nil {
+			return start{weekly: true, weekday: weekday, hour: clock.Hour(), minute: clock.Minute()}, nil
+		}
+	}
+	return start{}, errors.New("invalid recording start: " + value)
+}
+
+// parseWeekday parses an English weekday, in full or abbreviated to its first three letters.
+func parseWeekday(value string) (time.Weekday, bool) {
+	value = strings.ToLower(value)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if value == name || value == name[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// Weekly returns true if the entry is recorded every week.
+func (e Entry) Weekly() bool {
+	return e.start.weekly
+}
+
+// latest returns the latest time the entry was due at or before now, and false if it never was.
+func (e Entry) latest(now time.Time) (time.Time, bool) {
+	if !e.start.weekly {
+		return e.start.at, !e.start.at.After(now)
+	}
+	daysBack := (int(now.Weekday()) - int(e.start.weekday) + 7) % 7
+	at := time.Date(now.Year(), now.Month(), now.Day()-daysBack, e.start.hour, e.start.minute, 0, 0, now.Location())
+	if at.After(now) {
+		at = time.Date(at.Year(), at.Month(), at.Day()-7, e.start.hour, e.start.minute, 0, 0, now.Location())
+	}
+	return at, true
+}
+
+// Occurrence returns when the recording in progress at now started and ends, and false if none is.
+func (e Entry) Occurrence(now time.Time) (time.Time, time.Time, bool) {
+	start, ok := e.latest(now)
+	if !ok || !now.Before(start.Add(e.Duration)) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, start.Add(e.Duration), true
+}
+
+// Next returns when the entry is next due after now, and false if it never will be.
+func (e Entry) Next(now time.Time) (time.Time, bool) {
+	if !e.start.weekly {
+		return e.start.at, e.start.at.After(now)
+	}
+	at, _ := e.latest(now)
+	return time.Date(at.Year(), at.Month(), at.Day()+7, e.start.hour, e.start.minute, 0, 0, now.Location()), true
+}
+
+// Expired returns true if the entry is a one-off recording that is over at now.
+func (e Entry) Expired(now time.Time) bool {
+	return !e.start.weekly && !now.Before(e.start.at.Add(e.Duration))
+}
+
+// FileName returns the name of the file recording station from start to end, e.g.
+// "Jazz FM 2024-03-10 20.00-21.00.mp3", with the extension matching codec.
+func FileName(station string, start time.Time, end time.Time, codec string) string {
+	name := sanitizeFileName(station, "radiogogo")
+	return fmt.Sprintf("%s %s-%s.%s", name, start.Format("2006-01-02 15.04"), end.Format("15.04"), extension(codec))
+}
+
+// sanitizeFileName replaces the characters that can't be in file names on some systems,
+// returning fallback if nothing is left.
+func sanitizeFileName(name string, fallback string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// extension returns the file extension for the given codec, as named by radio-browser.
+func extension(codec string) string {
+	switch codec := strings.ToLower(codec); {
+	case codec == "mp3":
+		return "mp3"
+	case strings.HasPrefix(codec, "aac"):
+		return "aac"
+	case codec == "ogg" || codec == "vorbis":
+		return "ogg"
+	case codec == "opus":
+		return "opus"
+	case codec == "flac":
+		return "flac"
+	default:
+		return "audio"
+	}
+}
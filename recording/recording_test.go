@@ -0,0 +1,165 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package recording
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func at(value string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestNewEntry(t *testing.T) {
+
+	t.Run("accepts one-off and weekly starts", func(t *testing.T) {
+
+		for _, start := range []string{"2024-03-10 20:00", "friday 20:00", "Fri 08:30", " sunday 0:05 "} {
+			_, err := NewEntry(uuid.New(), "Jazz FM", start, time.Hour)
+			assert.NoError(t, err, start)
+		}
+
+	})
+
+	t.Run("rejects invalid starts and durations", func(t *testing.T) {
+
+		for _, start := range []string{"", "tomorrow", "friday", "frid 20:00", "2024-03-10", "friday 25:00"} {
+			_, err := NewEntry(uuid.New(), "Jazz FM", start, time.Hour)
+			assert.Error(t, err, start)
+		}
+
+		_, err := NewEntry(uuid.New(), "Jazz FM", "friday 20:00", 0)
+		assert.Error(t, err)
+
+	})
+
+}
+
+func TestEntry_Occurrence(t *testing.T) {
+
+	t.Run("finds a one-off recording in progress", func(t *testing.T) {
+
+		entry, _ := NewEntry(uuid.New(), "", "2024-03-10 20:00", time.Hour)
+
+		_, _, ok := entry.Occurrence(at("2024-03-10 19:59"))
+		assert.False(t, ok)
+
+		start, end, ok := entry.Occurrence(at("2024-03-10 20:30"))
+		assert.True(t, ok)
+		assert.Equal(t, at("2024-03-10 20:00"), start)
+		assert.Equal(t, at("2024-03-10 21:00"), end)
+
+		_, _, ok = entry.Occurrence(at("2024-03-10 21:00"))
+		assert.False(t, ok)
+		assert.True(t, entry.Expired(at("2024-03-10 21:00")))
+
+	})
+
+	t.Run("finds a weekly recording in progress", func(t *testing.T) {
+
+		// 2024-03-08 is a Friday
+		entry, _ := NewEntry(uuid.New(), "", "friday 23:30", time.Hour)
+
+		start, end, ok := entry.Occurrence(at("2024-03-15 23:45"))
+		assert.True(t, ok)
+		assert.Equal(t, at("2024-03-15 23:30"), start)
+		assert.Equal(t, at("2024-03-16 00:30"), end)
+
+		// Past midnight, still in Friday's recording
+		start, _, ok = entry.Occurrence(at("2024-03-16 00:15"))
+		assert.True(t, ok)
+		assert.Equal(t, at("2024-03-15 23:30"), start)
+
+		_, _, ok = entry.Occurrence(at("2024-03-15 23:29"))
+		assert.False(t, ok)
+		_, _, ok = entry.Occurrence(at("2024-03-13 23:45"))
+		assert.False(t, ok)
+		assert.False(t, entry.Expired(at("2030-01-01 00:00")))
+
+	})
+
+}
+
+func TestEntry_Next(t *testing.T) {
+
+	oneOff, _ := NewEntry(uuid.New(), "", "2024-03-10 20:00", time.Hour)
+
+	next, ok := oneOff.Next(at("2024-03-01 10:00"))
+	assert.True(t, ok)
+	assert.Equal(t, at("2024-03-10 20:00"), next)
+
+	_, ok = oneOff.Next(at("2024-03-10 20:00"))
+	assert.False(t, ok)
+
+	weekly, _ := NewEntry(uuid.New(), "", "monday 07:00", 30*time.Minute)
+
+	// 2024-03-11 is a Monday
+	next, ok = weekly.Next(at("2024-03-11 07:00"))
+	assert.True(t, ok)
+	assert.Equal(t, at("2024-03-18 07:00"), next)
+
+	next, _ = weekly.Next(at("2024-03-10 12:00"))
+	assert.Equal(t, at("2024-03-11 07:00"), next)
+
+}
+
+func TestEntry_YAML(t *testing.T) {
+
+	t.Run("round-trips through YAML", func(t *testing.T) {
+
+		entry, _ := NewEntry(uuid.MustParse("96202f73-0601-11e8-ae97-52543be04c81"), "Jazz FM", "friday 20:00", 90*time.Minute)
+
+		data, err := yaml.Marshal(entry)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "duration: 1h30m0s")
+
+		var decoded Entry
+		assert.NoError(t, yaml.Unmarshal(data, &decoded))
+		assert.Equal(t, entry, decoded)
+		assert.True(t, decoded.Weekly())
+
+	})
+
+	t.Run("throws an error for an invalid start", func(t *testing.T) {
+
+		var entry Entry
+		err := yaml.Unmarshal([]byte("station: 96202f73-0601-11e8-ae97-52543be04c81\nstart: someday\nduration: 1h\n"), &entry)
+		assert.Error(t, err)
+
+	})
+
+}
+
+func TestFileName(t *testing.T) {
+
+	assert.Equal(t, "Jazz FM 2024-03-10 20.00-21.30.mp3", FileName("Jazz FM", at("2024-03-10 20:00"), at("2024-03-10 21:30"), "MP3"))
+	assert.Equal(t, "AC_DC _ Rock 2024-03-10 20.00-21.00.aac", FileName("AC/DC | Rock", at("2024-03-10 20:00"), at("2024-03-10 21:00"), "AAC+"))
+	assert.Equal(t, "radiogogo 2024-03-10 20.00-21.00.audio", FileName("  ", at("2024-03-10 20:00"), at("2024-03-10 21:00"), "UNKNOWN"))
+
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SidecarFormat is the format of the file logging when the tracks of a recording start, next to it,
+// so that it can be split into songs by other tools.
+type SidecarFormat string
+
+const (
+	// SidecarCUE is a CUE sheet, as read by most splitters and audio editors. It's the default.
+	SidecarCUE SidecarFormat = "cue"
+	// SidecarJSON is a JSON object per track, one per line.
+	SidecarJSON SidecarFormat = "json"
+	// SidecarNone writes no sidecar.
+	SidecarNone SidecarFormat = "none"
+)
+
+// Sidecar logs the tracks announced by a station while it's recorded.
+type Sidecar struct {
+	path   string
+	format SidecarFormat
+	// start is when the recording started, from which the tracks are timed
+	start time.Time
+	// tracks counts the tracks logged, numbering those of CUE sheets
+	tracks int
+}
+
+// sidecarTrack is a line of a JSON sidecar.
+type sidecarTrack struct {
+	// Offset is when the track starts in the recording, in seconds.
+	Offset float64   `json:"offset"`
+	Time   time.Time `json:"time"`
+	Title  string    `json:"title"`
+}
+
+// OpenSidecar returns the sidecar of the recording of station saved at audioPath since start,
+// named after it (e.g. "Jazz FM 2024-03-10 20.00-21.00.cue"). An empty format is a CUE sheet.
+// If the sidecar exists, as when a recording carries on after a dropped connection, tracks are added to it.
+// Returns nil if format is SidecarNone.
+func OpenSidecar(audioPath string, format SidecarFormat, station string, start time.Time) (*Sidecar, error) {
+	if format == "" {
+		format = SidecarCUE
+	}
+	base := strings.TrimSuffix(audioPath, filepath.Ext(audioPath))
+	sidecar := &Sidecar{format: format, start: start}
+	switch format {
+	case SidecarNone:
+		return nil, nil
+	case SidecarCUE:
+		sidecar.path = base + ".cue"
+	case SidecarJSON:
+		sidecar.path = base + ".jsonl"
+	default:
+		return nil, fmt.Errorf("unknown recording sidecar format: %s", format)
+	}
+
+	existing, err := os.ReadFile(sidecar.path)
+	switch {
+	case err == nil:
+		sidecar.tracks = countTracks(string(existing), format)
+		return sidecar, nil
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	if format == SidecarCUE {
+		header := fmt.Sprintf("REM COMMENT \"RadioGoGo\"\nPERFORMER %s\nTITLE %s\nFILE %s %s\n",
+			cueQuote(station),
+			cueQuote(station+" "+start.Format("2006-01-02 15.04")),
+			cueQuote(filepath.Base(audioPath)),
+			cueFileType(audioPath),
+		)
+		if err := os.WriteFile(sidecar.path, []byte(header), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return sidecar, nil
+}
+
+// Path returns where the sidecar is written.
+func (s *Sidecar) Path() string {
+	return s.path
+}
+
+// Track logs that the track with the given stream title (usually "Artist - Title") started at the given time.
+func (s *Sidecar) Track(title string, at time.Time) error {
+	offset := at.Sub(s.start)
+	if offset < 0 {
+		offset = 0
+	}
+
+	var entry string
+	switch s.format {
+	case SidecarCUE:
+		entry = fmt.Sprintf("  TRACK %02d AUDIO\n", s.tracks+1)
+		if artist, song, found := strings.Cut(title, " - "); found {
+			entry += fmt.Sprintf("    TITLE %s\n    PERFORMER %s\n", cueQuote(strings.TrimSpace(song)), cueQuote(strings.TrimSpace(artist)))
+		} else {
+			entry += fmt.Sprintf("    TITLE %s\n", cueQuote(title))
+		}
+		entry += fmt.Sprintf("    INDEX 01 %s\n", cueTime(offset))
+	case SidecarJSON:
+		line, err := json.Marshal(sidecarTrack{Offset: offset.Seconds(), Time: at, Title: title})
+		if err != nil {
+			return err
+		}
+		entry = string(line) + "\n"
+	}
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(entry); err != nil {
+		file.Close()
+		return err
+	}
+	s.tracks++
+	return file.Close()
+}
+
+// countTracks returns how many tracks are logged in the content of a sidecar.
+func countTracks(content string, format SidecarFormat) int {
+	tracks := 0
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if (format == SidecarCUE && strings.HasPrefix(line, "TRACK ")) || (format == SidecarJSON && line != "") {
+			tracks++
+		}
+	}
+	return tracks
+}
+
+// cueQuote quotes a CUE sheet string, which can't contain double quotes.
+func cueQuote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, `'`) + `"`
+}
+
+// cueTime formats an offset as the minutes, seconds and frames (75 a second) of CUE sheets.
+func cueTime(offset time.Duration) string {
+	frames := int64(offset) * 75 / int64(time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", frames/(75*60), frames/75%60, frames%75)
+}
+
+// cueFileType returns the CUE sheet type of the recorded file: MP3 for MP3s, else WAVE,
+// which splitters take for any audio file.
+func cueFileType(audioPath string) string {
+	if strings.EqualFold(filepath.Ext(audioPath), ".mp3") {
+		return "MP3"
+	}
+	return "WAVE"
+}
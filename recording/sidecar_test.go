@@ -0,0 +1,117 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSidecar(t *testing.T) {
+
+	start := time.Date(2024, 3, 10, 20, 0, 0, 0, time.Local)
+
+	t.Run("writes a CUE sheet of the tracks, timed from the start", func(t *testing.T) {
+
+		audioPath := filepath.Join(t.TempDir(), "Jazz FM 2024-03-10 20.00-21.00.mp3")
+		sidecar, err := OpenSidecar(audioPath, "", "Jazz FM", start)
+		assert.NoError(t, err)
+
+		assert.NoError(t, sidecar.Track("Miles Davis - So What", start.Add(-time.Second)))
+		assert.NoError(t, sidecar.Track(`Jazz FM "Late Night"`, start.Add(9*time.Minute+22*time.Second+500*time.Millisecond)))
+
+		assert.Equal(t, strings.TrimSuffix(audioPath, ".mp3")+".cue", sidecar.Path())
+		content, err := os.ReadFile(sidecar.Path())
+		assert.NoError(t, err)
+		assert.Equal(t, `REM COMMENT "RadioGoGo"
+PERFORMER "Jazz FM"
+TITLE "Jazz FM 2024-03-10 20.00"
+FILE "Jazz FM 2024-03-10 20.00-21.00.mp3" MP3
+  TRACK 01 AUDIO
+    TITLE "So What"
+    PERFORMER "Miles Davis"
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "Jazz FM 'Late Night'"
+    INDEX 01 09:22:37
+`, string(content))
+
+	})
+
+	t.Run("carries on numbering the tracks of an existing CUE sheet", func(t *testing.T) {
+
+		audioPath := filepath.Join(t.TempDir(), "Jazz FM.ogg")
+		sidecar, _ := OpenSidecar(audioPath, SidecarCUE, "Jazz FM", start)
+		assert.NoError(t, sidecar.Track("First", start))
+
+		sidecar, err := OpenSidecar(audioPath, SidecarCUE, "Jazz FM", start)
+		assert.NoError(t, err)
+		assert.NoError(t, sidecar.Track("Second", start.Add(time.Minute)))
+
+		content, _ := os.ReadFile(sidecar.Path())
+		assert.Equal(t, 1, strings.Count(string(content), "FILE \"Jazz FM.ogg\" WAVE"))
+		assert.Contains(t, string(content), "TRACK 02 AUDIO\n    TITLE \"Second\"")
+
+	})
+
+	t.Run("writes a JSON object per track", func(t *testing.T) {
+
+		audioPath := filepath.Join(t.TempDir(), "Jazz FM.mp3")
+		sidecar, err := OpenSidecar(audioPath, SidecarJSON, "Jazz FM", start)
+		assert.NoError(t, err)
+		assert.NoError(t, sidecar.Track("Miles Davis - So What", start.Add(90*time.Second)))
+
+		file, err := os.Open(filepath.Join(filepath.Dir(audioPath), "Jazz FM.jsonl"))
+		assert.NoError(t, err)
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		assert.True(t, scanner.Scan())
+		var track sidecarTrack
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &track))
+		assert.Equal(t, 90.0, track.Offset)
+		assert.Equal(t, "Miles Davis - So What", track.Title)
+
+	})
+
+	t.Run("writes none if asked", func(t *testing.T) {
+
+		sidecar, err := OpenSidecar(filepath.Join(t.TempDir(), "Jazz FM.mp3"), SidecarNone, "Jazz FM", start)
+
+		assert.NoError(t, err)
+		assert.Nil(t, sidecar)
+
+	})
+
+	t.Run("refuses unknown formats", func(t *testing.T) {
+
+		_, err := OpenSidecar(filepath.Join(t.TempDir(), "Jazz FM.mp3"), "xml", "Jazz FM", start)
+
+		assert.Error(t, err)
+
+	})
+
+}
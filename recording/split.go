@@ -0,0 +1,119 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package recording
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Splitter saves a recording as a file per track, named after the titles announced by the station
+// (e.g. "Miles Davis - So What.mp3"), in a directory named after the recording.
+// Tracks shorter than a minimum length, such as jingles and station idents, are left out.
+// Until the station announces a title, the recording is saved to its own file, as when it isn't split.
+type Splitter struct {
+	// directory is where the tracks are saved
+	directory string
+	extension string
+	minLength time.Duration
+	now       func() time.Time
+
+	file *os.File
+	// created is true if the current file is a new one, which may be removed if it's too short
+	created bool
+	started time.Time
+}
+
+// NewSplitter returns a Splitter for the recording at path, e.g. "Jazz FM 2024-03-10 20.00-21.00.mp3",
+// saving its tracks to "Jazz FM 2024-03-10 20.00-21.00/". Tracks shorter than minLength are removed.
+func NewSplitter(path string, minLength time.Duration) (*Splitter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	_, err := os.Stat(path)
+	created := os.IsNotExist(err)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	extension := filepath.Ext(path)
+	return &Splitter{
+		directory: strings.TrimSuffix(path, extension),
+		extension: extension,
+		minLength: minLength,
+		now:       time.Now,
+		file:      file,
+		created:   created,
+		started:   time.Now(),
+	}, nil
+}
+
+func (s *Splitter) Write(p []byte) (int, error) {
+	return s.file.Write(p)
+}
+
+// Track saves what follows to a new file named after the stream title of the track that started,
+// ending the file of the previous track. If the new file can't be created, the previous one carries on.
+func (s *Splitter) Track(title string) error {
+	if err := os.MkdirAll(s.directory, 0755); err != nil {
+		return err
+	}
+	file, err := createUnique(filepath.Join(s.directory, sanitizeFileName(title, "radiogogo")), s.extension)
+	if err != nil {
+		return err
+	}
+	closeErr := s.closeFile()
+	s.file = file
+	s.created = true
+	s.started = s.now()
+	return closeErr
+}
+
+// Close ends the file of the last track.
+func (s *Splitter) Close() error {
+	return s.closeFile()
+}
+
+// closeFile closes the current file, removing it if it's a new one shorter than the minimum length.
+func (s *Splitter) closeFile() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	if s.created && s.now().Sub(s.started) < s.minLength {
+		return os.Remove(s.file.Name())
+	}
+	return nil
+}
+
+// createUnique creates the file base+extension, or "base (2)"+extension and so on if it exists,
+// as when a track is played twice.
+func createUnique(base string, extension string) (*os.File, error) {
+	path := base + extension
+	for n := 2; ; n++ {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if !os.IsExist(err) {
+			return file, err
+		}
+		path = base + " (" + strconv.Itoa(n) + ")" + extension
+	}
+}
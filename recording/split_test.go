@@ -0,0 +1,112 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package recording
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitter(t *testing.T) {
+
+	newSplitter := func(t *testing.T, path string) (*Splitter, *time.Time) {
+		splitter, err := NewSplitter(path, time.Minute)
+		assert.NoError(t, err)
+		now := time.Date(2024, 3, 10, 20, 0, 0, 0, time.Local)
+		splitter.now = func() time.Time { return now }
+		splitter.started = now
+		return splitter, &now
+	}
+
+	t.Run("saves each track to a file named after it", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "Jazz FM 2024-03-10 20.00-21.00.mp3")
+		splitter, now := newSplitter(t, path)
+
+		splitter.Write([]byte("intro"))
+		assert.NoError(t, splitter.Track("Miles Davis - So What"))
+		splitter.Write([]byte("so what"))
+		*now = now.Add(9 * time.Minute)
+		assert.NoError(t, splitter.Track("AC/DC - T.N.T."))
+		splitter.Write([]byte("tnt"))
+		*now = now.Add(3 * time.Minute)
+		assert.NoError(t, splitter.Close())
+
+		directory := filepath.Join(filepath.Dir(path), "Jazz FM 2024-03-10 20.00-21.00")
+		content, _ := os.ReadFile(filepath.Join(directory, "Miles Davis - So What.mp3"))
+		assert.Equal(t, "so what", string(content))
+		content, _ = os.ReadFile(filepath.Join(directory, "AC_DC - T.N.T..mp3"))
+		assert.Equal(t, "tnt", string(content))
+
+	})
+
+	t.Run("leaves out the tracks shorter than the minimum length", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "Jazz FM.mp3")
+		splitter, now := newSplitter(t, path)
+
+		assert.NoError(t, splitter.Track("Jazz FM - Ident"))
+		*now = now.Add(10 * time.Second)
+		assert.NoError(t, splitter.Track("Miles Davis - So What"))
+		*now = now.Add(9 * time.Minute)
+		assert.NoError(t, splitter.Close())
+
+		directory := filepath.Join(filepath.Dir(path), "Jazz FM")
+		assert.NoFileExists(t, filepath.Join(directory, "Jazz FM - Ident.mp3"))
+		assert.FileExists(t, filepath.Join(directory, "Miles Davis - So What.mp3"))
+
+	})
+
+	t.Run("numbers the tracks played twice", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "Jazz FM.mp3")
+		splitter, now := newSplitter(t, path)
+
+		assert.NoError(t, splitter.Track("So What"))
+		*now = now.Add(9 * time.Minute)
+		assert.NoError(t, splitter.Track("So What"))
+		*now = now.Add(9 * time.Minute)
+		assert.NoError(t, splitter.Close())
+
+		directory := filepath.Join(filepath.Dir(path), "Jazz FM")
+		assert.FileExists(t, filepath.Join(directory, "So What.mp3"))
+		assert.FileExists(t, filepath.Join(directory, "So What (2).mp3"))
+
+	})
+
+	t.Run("keeps what was recorded before the first title and doesn't remove existing files", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "Jazz FM.mp3")
+		assert.NoError(t, os.WriteFile(path, []byte("before "), 0644))
+		splitter, _ := newSplitter(t, path)
+
+		splitter.Write([]byte("resumed"))
+		assert.NoError(t, splitter.Close())
+
+		content, _ := os.ReadFile(path)
+		assert.Equal(t, "before resumed", string(content))
+
+	})
+
+}
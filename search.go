@@ -0,0 +1,177 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/cli"
+	"github.com/zi0p4tch0/radiogogo/common"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/offline"
+)
+
+// searchQueries are the values of the --by flag of "radiogogo search".
+var searchQueries = map[string]common.StationQuery{
+	"name":        common.StationQueryByName,
+	"tag":         common.StationQueryByTag,
+	"country":     common.StationQueryByCountry,
+	"countrycode": common.StationQueryByCountryCodeExact,
+	"language":    common.StationQueryByLanguage,
+	"codec":       common.StationQueryByCodec,
+	"uuid":        common.StationQueryByUuid,
+}
+
+// newSearchCommand returns "radiogogo search".
+func newSearchCommand(loadConfig func() config.Config) *cli.Command {
+
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	jsonFlag := flags.Bool("json", false, "print the stations as a JSON array")
+	byFlag := flags.String("by", "name", "what to search by: name, tag, country, countrycode, language, codec or uuid")
+	limitFlag := flags.Uint64("limit", 20, "the maximum number of stations to print")
+
+	return &cli.Command{
+		Name:  "search",
+		Usage: "<terms...>",
+		Short: "Print the stations matching a search",
+		Long:  "Prints the stations matching a search, most voted first, one per line or as a JSON array with --json. As in the search form, name searches are narrowed down by the \"search\" section of the configuration.",
+		Flags: flags,
+		// As in "radiogogo search jazz --json"
+		Interspersed: true,
+		Run: func(args []string) error {
+			if err := searchStations(loadConfig(), strings.Join(args, " "), *byFlag, *limitFlag, *jsonFlag); err != nil {
+				return fmt.Errorf("searching: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// searchStations prints the limit stations matching term, searched by the given field, most voted first,
+// as a JSON array if asJSON is set or one per line otherwise.
+func searchStations(cfg config.Config, term string, by string, limit uint64, asJSON bool) error {
+
+	if term == "" {
+		return errors.New("nothing to search: pass what to search for, e.g. radiogogo search jazz")
+	}
+	query, ok := searchQueries[strings.ToLower(by)]
+	if !ok {
+		return fmt.Errorf("can't search by %q: use name, tag, country, countrycode, language, codec or uuid", by)
+	}
+
+	browser, err := newBrowser(cfg)
+	if err != nil {
+		return err
+	}
+
+	filter := common.StationFilter{
+		CountryCode: cfg.Search.DefaultCountryCode,
+		Language:    cfg.Search.DefaultLanguage,
+	}
+	// Stations are printed as they're decoded, rather than once they're all in memory, if the browser can stream them
+	printer := newStationPrinter(os.Stdout, asJSON)
+	byName := query == common.StationQueryByName && !filter.IsEmpty()
+	if streamer, ok := browser.(api.StationStreamer); ok {
+		if byName {
+			err = streamer.StreamSearchStations(term, filter, "votes", true, 0, limit, true, printer.print)
+		} else {
+			err = streamer.StreamStations(query, term, "votes", true, 0, limit, true, printer.print)
+		}
+	} else {
+		var stations []common.Station
+		if byName {
+			stations, err = browser.SearchStations(term, filter, "votes", true, 0, limit, true)
+		} else {
+			stations, err = browser.GetStations(query, term, "votes", true, 0, limit, true)
+		}
+		for _, station := range stations {
+			printer.print(station)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return printer.flush()
+}
+
+// stationPrinter prints stations one at a time, as a JSON array or one per line.
+type stationPrinter struct {
+	out     io.Writer
+	asJSON  bool
+	printed int
+	writer  *tabwriter.Writer
+}
+
+func newStationPrinter(out io.Writer, asJSON bool) *stationPrinter {
+	return &stationPrinter{out: out, asJSON: asJSON, writer: tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)}
+}
+
+// print prints station, after those printed before.
+func (p *stationPrinter) print(station common.Station) error {
+	p.printed++
+	if !p.asJSON {
+		_, err := fmt.Fprintf(p.writer, "%s\t%s\t%s %d kbps\t%s\n", station.Name, station.CountryCode, station.Codec, station.Bitrate, station.StationUuid)
+		return err
+	}
+	encoded, err := json.MarshalIndent(station, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	separator := ",\n  "
+	if p.printed == 1 {
+		separator = "[\n  "
+	}
+	_, err = fmt.Fprintf(p.out, "%s%s", separator, encoded)
+	return err
+}
+
+// flush finishes printing: the stations are aligned in columns, or the JSON array is closed.
+func (p *stationPrinter) flush() error {
+	if !p.asJSON {
+		return p.writer.Flush()
+	}
+	if p.printed == 0 {
+		_, err := fmt.Fprintln(p.out, "[]")
+		return err
+	}
+	_, err := fmt.Fprintln(p.out, "\n]")
+	return err
+}
+
+// newBrowser returns the radio-browser client, which falls back to the snapshot taken
+// by "radiogogo sync" whenever radio-browser can't be reached.
+func newBrowser(cfg config.Config) (api.RadioBrowserService, error) {
+	browser, err := api.NewRadioBrowser(cfg.API.BaseURL, api.NewRateLimiter(cfg.API.RequestsPerSecond))
+	if racer, ok := browser.(api.SearchRacer); ok {
+		racer.SetRaceSearches(cfg.API.RaceSearches)
+	}
+	if snapshot, snapshotErr := offline.LoadBrowser(config.CatalogFile()); snapshotErr == nil {
+		return offline.NewFallbackBrowser(browser, snapshot), nil
+	}
+	return browser, err
+}
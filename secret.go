@@ -0,0 +1,187 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/zi0p4tch0/radiogogo/cli"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/secrets"
+
+	"github.com/google/uuid"
+	"golang.org/x/term"
+)
+
+// newSecretCommand returns "radiogogo secret", which manages the secrets the configuration refers to as ${secret:name}.
+func newSecretCommand() *cli.Command {
+
+	secret := &cli.Command{
+		Name:  "secret",
+		Short: "Tell where the secrets referred to by the config are kept",
+		Long:  "Passwords and tokens are kept in the OS keychain where there is one, or in an encrypted file otherwise, and referred to in the configuration as ${secret:name}. The file is encrypted with a key derived from RADIOGOGO_SECRETS_PASSPHRASE if set, or with a random key kept next to it.",
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("managing secrets: %w %q: use radiogogo secret [set|delete]", cli.ErrUnknownCommand, args[0])
+			}
+			fmt.Printf("Secrets are kept in %s\n", secrets.Open(config.SecretsDir()).Description())
+			return nil
+		},
+	}
+
+	secret.Add(&cli.Command{
+		Name:  "set",
+		Usage: "<name>",
+		Short: "Store a secret, read from the terminal or stdin",
+		Run: func(args []string) error {
+			if len(args) != 1 {
+				return errors.New("storing a secret: usage: radiogogo secret set <name>")
+			}
+			value, err := readSecret(fmt.Sprintf("Value of %s: ", args[0]))
+			if err != nil {
+				return fmt.Errorf("storing a secret: %w", err)
+			}
+			store := secrets.Open(config.SecretsDir())
+			if err := store.Set(args[0], value); err != nil {
+				return fmt.Errorf("storing a secret: %w", err)
+			}
+			fmt.Printf("Stored %s in %s: refer to it as ${secret:%s}\n", args[0], store.Description(), args[0])
+			return nil
+		},
+	})
+
+	secret.Add(&cli.Command{
+		Name:  "delete",
+		Usage: "<name>",
+		Short: "Remove a secret",
+		Run: func(args []string) error {
+			if len(args) != 1 {
+				return errors.New("removing a secret: usage: radiogogo secret delete <name>")
+			}
+			if err := secrets.Open(config.SecretsDir()).Delete(args[0]); err != nil {
+				return fmt.Errorf("removing a secret: %w", err)
+			}
+			return nil
+		},
+	})
+
+	secret.Add(newStationCredentialsCommand())
+
+	return secret
+}
+
+// newStationCredentialsCommand returns "radiogogo secret station", which manages the credentials of private streams.
+func newStationCredentialsCommand() *cli.Command {
+
+	station := &cli.Command{
+		Name:  "station",
+		Short: "Manage the credentials of private streams",
+		Long:  "Private streams may ask for a username and password, a token in their URL, or both. Their credentials are kept with the other secrets, keyed by the station UUID (printed by radiogogo search --json), and only added to the stream URL when the station is played.",
+	}
+
+	flags := flag.NewFlagSet("set", flag.ContinueOnError)
+	usernameFlag := flags.String("username", "", "the username to send with basic auth, whose password is then asked for")
+	tokenFlag := flags.Bool("token", false, "ask for a token to send as a query parameter")
+	tokenParamFlag := flags.String("token-param", secrets.DefaultTokenParam, "the query parameter the token is sent as")
+
+	station.Add(&cli.Command{
+		Name:  "set",
+		Usage: "<station uuid>",
+		Short: "Store the credentials of a station, read from the terminal or stdin",
+		Flags: flags,
+		// As in "radiogogo secret station set <uuid> --username me"
+		Interspersed: true,
+		Run: func(args []string) error {
+			if len(args) != 1 || (*usernameFlag == "" && !*tokenFlag) {
+				return errors.New("storing station credentials: usage: radiogogo secret station set <station uuid> [--username <name>] [--token]")
+			}
+			stationUUID, err := uuid.Parse(args[0])
+			if err != nil {
+				return fmt.Errorf("storing station credentials: %q isn't a station UUID", args[0])
+			}
+			credentials := secrets.StationCredentials{Username: *usernameFlag}
+			if credentials.Username != "" {
+				if credentials.Password, err = readSecret(fmt.Sprintf("Password of %s: ", credentials.Username)); err != nil {
+					return fmt.Errorf("storing station credentials: %w", err)
+				}
+			}
+			if *tokenFlag {
+				if credentials.Token, err = readSecret("Token: "); err != nil {
+					return fmt.Errorf("storing station credentials: %w", err)
+				}
+				if *tokenParamFlag != secrets.DefaultTokenParam {
+					credentials.TokenParam = *tokenParamFlag
+				}
+			}
+			store := secrets.Open(config.SecretsDir())
+			if err := secrets.SetStationCredentials(store, stationUUID.String(), credentials); err != nil {
+				return fmt.Errorf("storing station credentials: %w", err)
+			}
+			fmt.Printf("Stored the credentials of %s in %s\n", stationUUID, store.Description())
+			return nil
+		},
+	})
+
+	station.Add(&cli.Command{
+		Name:  "delete",
+		Usage: "<station uuid>",
+		Short: "Remove the credentials of a station",
+		Run: func(args []string) error {
+			if len(args) != 1 {
+				return errors.New("removing station credentials: usage: radiogogo secret station delete <station uuid>")
+			}
+			stationUUID, err := uuid.Parse(args[0])
+			if err != nil {
+				return fmt.Errorf("removing station credentials: %q isn't a station UUID", args[0])
+			}
+			if err := secrets.DeleteStationCredentials(secrets.Open(config.SecretsDir()), stationUUID.String()); err != nil {
+				return fmt.Errorf("removing station credentials: %w", err)
+			}
+			return nil
+		},
+	})
+
+	return station
+}
+
+// readSecret reads a secret from the terminal without echoing it, or the first line of stdin if it isn't a terminal.
+func readSecret(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		value, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		return string(value), nil
+	}
+	value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && value != "") {
+		return "", err
+	}
+	return strings.TrimRight(value, "\r\n"), nil
+}
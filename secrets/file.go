@@ -0,0 +1,276 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// How many PBKDF2 iterations derive the key from a passphrase.
+const passphraseIterations = 600000
+
+// ErrWrongKey is returned when the secrets file can't be decrypted, e.g. with another passphrase.
+var ErrWrongKey = errors.New("the secrets can't be decrypted with this key or passphrase")
+
+// FileStore keeps the secrets in a JSON file, each encrypted with AES-256-GCM.
+// The key is either derived from a passphrase, or random and kept in a file of its own,
+// readable by the user only (and encrypted with DPAPI on Windows).
+// It's safe for concurrent use.
+type FileStore struct {
+	path       string
+	keyPath    string
+	passphrase string
+
+	mu sync.Mutex
+}
+
+// secretsFile is the content of the secrets file.
+type secretsFile struct {
+	// Salt derives the key from the passphrase, if there's one.
+	Salt []byte `json:"salt,omitempty"`
+	// Secrets are the sealed secrets, by name, nonce first.
+	Secrets map[string][]byte `json:"secrets"`
+}
+
+// NewFileStore returns a FileStore keeping the secrets in dir/secrets.json,
+// encrypted with a key derived from passphrase, or with a random key kept in dir/secrets.key if empty.
+func NewFileStore(dir string, passphrase string) *FileStore {
+	return &FileStore{
+		path:       filepath.Join(dir, "secrets.json"),
+		keyPath:    filepath.Join(dir, "secrets.key"),
+		passphrase: passphrase,
+	}
+}
+
+func (s *FileStore) Get(name string) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	sealed, ok := file.Secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	aead, err := s.cipher(&file, false)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", ErrWrongKey
+	}
+	value, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(name))
+	if err != nil {
+		return "", ErrWrongKey
+	}
+	return string(value), nil
+}
+
+func (s *FileStore) Set(name string, value string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := s.load()
+	if err != nil {
+		return err
+	}
+	aead, err := s.cipher(&file, true)
+	if err != nil {
+		return err
+	}
+	// The key must still open the other secrets, e.g. if the passphrase changed
+	if err := s.checkKey(aead, file); err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	file.Secrets[name] = aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return s.save(file)
+}
+
+func (s *FileStore) Delete(name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Secrets[name]; !ok {
+		return ErrNotFound
+	}
+	delete(file.Secrets, name)
+	return s.save(file)
+}
+
+func (s *FileStore) Description() string {
+	return s.path
+}
+
+// Names returns the names of the secrets in the file, in order.
+func (s *FileStore) Names() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(file.Secrets))
+	for name := range file.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// load reads the secrets file, which is empty if it doesn't exist yet.
+func (s *FileStore) load() (secretsFile, error) {
+	file := secretsFile{Secrets: make(map[string][]byte)}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return file, err
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("reading %s: %w", s.path, err)
+	}
+	if file.Secrets == nil {
+		file.Secrets = make(map[string][]byte)
+	}
+	return file, nil
+}
+
+// save writes the secrets file, readable by the user only.
+func (s *FileStore) save(file secretsFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(s.path, data)
+}
+
+// cipher returns the cipher sealing the secrets, creating the salt or the key file if create is true.
+func (s *FileStore) cipher(file *secretsFile, create bool) (cipher.AEAD, error) {
+	var key []byte
+	if s.passphrase != "" {
+		if file.Salt == nil {
+			if !create {
+				return nil, ErrWrongKey
+			}
+			file.Salt = make([]byte, 16)
+			if _, err := io.ReadFull(rand.Reader, file.Salt); err != nil {
+				return nil, err
+			}
+		}
+		key = pbkdf2.Key([]byte(s.passphrase), file.Salt, passphraseIterations, 32, sha256.New)
+	} else {
+		if file.Salt != nil {
+			return nil, fmt.Errorf("%w: set RADIOGOGO_SECRETS_PASSPHRASE", ErrWrongKey)
+		}
+		var err error
+		if key, err = s.randomKey(create); err != nil {
+			return nil, err
+		}
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// randomKey returns the key kept in the key file, creating it if create is true.
+func (s *FileStore) randomKey(create bool) ([]byte, error) {
+	data, err := os.ReadFile(s.keyPath)
+	if err == nil {
+		key, err := unprotectKey(data)
+		if err != nil || len(key) != 32 {
+			return nil, ErrWrongKey
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || !create {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	protected, err := protectKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return key, writePrivateFile(s.keyPath, protected)
+}
+
+// checkKey returns ErrWrongKey if aead can't open the secrets already in file.
+func (s *FileStore) checkKey(aead cipher.AEAD, file secretsFile) error {
+	for name, sealed := range file.Secrets {
+		if len(sealed) < aead.NonceSize() {
+			return ErrWrongKey
+		}
+		if _, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(name)); err != nil {
+			return ErrWrongKey
+		}
+		return nil
+	}
+	return nil
+}
+
+// writePrivateFile writes data to path, readable and writable by the user only, creating its directory if needed.
+func writePrivateFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
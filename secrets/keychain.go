@@ -0,0 +1,153 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package secrets
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// The service the secrets are filed under in the keychain.
+const keychainService = "radiogogo"
+
+// runner runs a command with the given standard input, returning its standard output.
+type runner func(stdin string, name string, args ...string) (string, error)
+
+func runCommand(stdin string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", errors.New(message)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// systemKeychain returns the OS keychain, or nil if there's none to use:
+// the login keychain on macOS, or the Secret Service (GNOME Keyring, KWallet...) through secret-tool elsewhere.
+func systemKeychain() Store {
+	switch {
+	case runtime.GOOS == "darwin":
+		if _, err := exec.LookPath("security"); err == nil {
+			return &macKeychain{run: runCommand}
+		}
+	case runtime.GOOS != "windows":
+		// The Secret Service needs a session bus, which headless machines usually lack
+		if _, err := exec.LookPath("secret-tool"); err == nil && os.Getenv("DBUS_SESSION_BUS_ADDRESS") != "" {
+			return &secretService{run: runCommand}
+		}
+	}
+	return nil
+}
+
+// macKeychain keeps the secrets in the login keychain with the security command.
+type macKeychain struct {
+	run runner
+}
+
+func (k *macKeychain) Get(name string) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	value, err := k.run("", "security", "find-generic-password", "-s", keychainService, "-a", name, "-w")
+	if err != nil {
+		if strings.Contains(err.Error(), "could not be found") {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(value, "\n"), nil
+}
+
+func (k *macKeychain) Set(name string, value string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	// The secret goes through stdin rather than the command line, where other users could see it,
+	// hex-encoded so that it needs no quoting
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", keychainService, name, hex.EncodeToString([]byte(value)))
+	_, err := k.run(command, "security", "-i")
+	return err
+}
+
+func (k *macKeychain) Delete(name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	_, err := k.run("", "security", "delete-generic-password", "-s", keychainService, "-a", name)
+	if err != nil && strings.Contains(err.Error(), "could not be found") {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (k *macKeychain) Description() string {
+	return "the macOS keychain"
+}
+
+// secretService keeps the secrets in the Secret Service with the secret-tool command,
+// which reads them from its standard input rather than its arguments.
+type secretService struct {
+	run runner
+}
+
+func (s *secretService) Get(name string) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	value, err := s.run("", "secret-tool", "lookup", "service", keychainService, "account", name)
+	// secret-tool fails silently when there's no such secret
+	var exitErr *exec.ExitError
+	if value == "" && (err == nil || errors.As(err, &exitErr)) {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+func (s *secretService) Set(name string, value string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	_, err := s.run(value, "secret-tool", "store", "--label=RadioGoGo: "+name, "service", keychainService, "account", name)
+	return err
+}
+
+func (s *secretService) Delete(name string) error {
+	if _, err := s.Get(name); err != nil {
+		return err
+	}
+	_, err := s.run("", "secret-tool", "clear", "service", keychainService, "account", name)
+	return err
+}
+
+func (s *secretService) Description() string {
+	return "the Secret Service"
+}
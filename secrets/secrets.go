@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package secrets keeps credentials, such as service tokens and passwords, out of the configuration file:
+// in the OS keychain where there is one, or in a file encrypted with AES-GCM otherwise.
+// The configuration refers to them as ${secret:name}.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// ErrNotFound is returned when there's no secret with the name asked for.
+var ErrNotFound = errors.New("secret not found")
+
+// ErrInvalidName is matched by errors returned when a secret name isn't made of letters, digits, dots, dashes and underscores.
+var ErrInvalidName = errors.New("invalid secret name")
+
+// A secret name, e.g. "lastfm.token".
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// A reference to a secret in the configuration, e.g. "${secret:icecast}".
+var referencePattern = regexp.MustCompile(`\$\{secret:([^}]*)\}`)
+
+// Store keeps secrets by name.
+type Store interface {
+	// Get returns the secret with the given name, or ErrNotFound.
+	Get(name string) (string, error)
+	// Set stores the secret with the given name, replacing the previous one.
+	Set(name string, value string) error
+	// Delete removes the secret with the given name, or returns ErrNotFound.
+	Delete(name string) error
+	// Description tells where the secrets are kept, e.g. "the macOS keychain".
+	Description() string
+}
+
+// Open returns the OS keychain if there is one, or the encrypted file store in dir otherwise.
+// With the RADIOGOGO_SECRETS_PASSPHRASE environment variable set, the file is encrypted with a key
+// derived from it, otherwise with a random key kept next to it.
+func Open(dir string) Store {
+	if keychain := systemKeychain(); keychain != nil {
+		return keychain
+	}
+	return NewFileStore(dir, os.Getenv("RADIOGOGO_SECRETS_PASSPHRASE"))
+}
+
+// checkName returns ErrInvalidName if name can't name a secret.
+func checkName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return nil
+}
+
+// Expand replaces the references to secrets in value, such as "${secret:icecast}", with the secrets themselves.
+func Expand(store Store, value string) (string, error) {
+	var firstErr error
+	expanded := referencePattern.ReplaceAllStringFunc(value, func(reference string) string {
+		name := referencePattern.FindStringSubmatch(reference)[1]
+		secret, err := get(store, name)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", reference, err)
+		}
+		return secret
+	})
+	if firstErr != nil {
+		return value, firstErr
+	}
+	return expanded, nil
+}
+
+// get returns the secret with the given name, checking the name first.
+func get(store Store, name string) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	return store.Get(name)
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// The query parameter a token is sent as when no other is named.
+const DefaultTokenParam = "token"
+
+// StationCredentials are what a private stream asks for: a username and password sent with basic auth,
+// a token sent as a query parameter, or both.
+type StationCredentials struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+	// TokenParam is the query parameter the token is sent as, DefaultTokenParam if empty.
+	TokenParam string `json:"tokenParam,omitempty"`
+}
+
+// IsEmpty returns true if there's nothing to authenticate with.
+func (c StationCredentials) IsEmpty() bool {
+	return c.Username == "" && c.Password == "" && c.Token == ""
+}
+
+// Apply returns u with the credentials in it: the username and password as its user info,
+// and the token as a query parameter, replacing any already there.
+func (c StationCredentials) Apply(u url.URL) url.URL {
+	if c.Username != "" || c.Password != "" {
+		u.User = url.UserPassword(c.Username, c.Password)
+	}
+	if c.Token != "" {
+		param := c.TokenParam
+		if param == "" {
+			param = DefaultTokenParam
+		}
+		query := u.Query()
+		query.Set(param, c.Token)
+		u.RawQuery = query.Encode()
+	}
+	return u
+}
+
+// stationSecretName is the name of the secret keeping the credentials of the station with the given UUID.
+func stationSecretName(stationUUID string) string {
+	return "station." + stationUUID
+}
+
+// GetStationCredentials returns the credentials of the station with the given UUID, or ErrNotFound.
+func GetStationCredentials(store Store, stationUUID string) (StationCredentials, error) {
+	value, err := get(store, stationSecretName(stationUUID))
+	if err != nil {
+		return StationCredentials{}, err
+	}
+	var credentials StationCredentials
+	if err := json.Unmarshal([]byte(value), &credentials); err != nil {
+		return StationCredentials{}, fmt.Errorf("credentials of station %s: %w", stationUUID, err)
+	}
+	return credentials, nil
+}
+
+// SetStationCredentials stores the credentials of the station with the given UUID, replacing the previous ones.
+func SetStationCredentials(store Store, stationUUID string, credentials StationCredentials) error {
+	if credentials.IsEmpty() {
+		return errors.New("no credentials to store")
+	}
+	name := stationSecretName(stationUUID)
+	if err := checkName(name); err != nil {
+		return err
+	}
+	value, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+	return store.Set(name, string(value))
+}
+
+// DeleteStationCredentials removes the credentials of the station with the given UUID, or returns ErrNotFound.
+func DeleteStationCredentials(store Store, stationUUID string) error {
+	name := stationSecretName(stationUUID)
+	if err := checkName(name); err != nil {
+		return err
+	}
+	return store.Delete(name)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/zi0p4tch0/radiogogo/cli"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/instance"
+)
+
+// status is what "radiogogo status --json" prints.
+type status struct {
+	// Running is false if no instance of RadioGoGo is running.
+	Running     bool   `json:"running"`
+	Playing     bool   `json:"playing"`
+	Station     string `json:"station,omitempty"`
+	StationUuid string `json:"stationuuid,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+// newStatusCommand returns "radiogogo status".
+func newStatusCommand() *cli.Command {
+
+	flags := flag.NewFlagSet("status", flag.ContinueOnError)
+	jsonFlag := flags.Bool("json", false, "print the status as a JSON object")
+
+	return &cli.Command{
+		Name:  "status",
+		Short: "Print what the running instance is playing",
+		Flags: flags,
+		Run: func(args []string) error {
+			if err := printStatus(*jsonFlag); err != nil {
+				return fmt.Errorf("getting the status: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// printStatus asks the running instance what it's playing,
+// printing it as a JSON object if asJSON is set or as text otherwise.
+func printStatus(asJSON bool) error {
+
+	var current status
+	if instance.IsRunning(config.SocketFile()) {
+		track, err := instance.Status(config.SocketFile())
+		if err != nil {
+			return err
+		}
+		current = status{
+			Running:     true,
+			Playing:     track.Station != "",
+			Station:     track.Station,
+			StationUuid: track.StationUuid,
+			Title:       track.Title,
+		}
+	}
+
+	if asJSON {
+		return json.NewEncoder(os.Stdout).Encode(current)
+	}
+
+	switch {
+	case !current.Running:
+		fmt.Println("RadioGoGo is not running")
+	case !current.Playing:
+		fmt.Println("Not playing")
+	case current.Title != "":
+		fmt.Printf("Playing: %s - %s\n", current.Station, current.Title)
+	default:
+		fmt.Printf("Playing: %s\n", current.Station)
+	}
+	return nil
+}
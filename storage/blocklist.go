@@ -0,0 +1,105 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// BlockedStation records a station the user hid for good, from every search and list of stations.
+type BlockedStation struct {
+	StationUuid uuid.UUID `json:"stationuuid"`
+	StationName string    `json:"stationName"`
+	// Reason is why the station was hidden, in the user's words (it can be empty).
+	Reason    string    `json:"reason"`
+	BlockedAt time.Time `json:"blockedAt"`
+}
+
+// BlocklistStore defines the behavior for storing the stations hidden by the user, keyed by station UUID.
+type BlocklistStore interface {
+	// Get returns the blocked station with the given UUID, and false if it isn't blocked.
+	Get(stationUuid uuid.UUID) (BlockedStation, bool)
+	// All returns every blocked station, most recently blocked first.
+	All() []BlockedStation
+	// Add blocks a station, replacing any previous reason for it.
+	Add(blocked BlockedStation) error
+	// Remove unblocks the station with the given UUID, if it's blocked.
+	Remove(stationUuid uuid.UUID) error
+}
+
+// BoltBlocklistStore is a BlocklistStore persisted in the database.
+type BoltBlocklistStore struct {
+	db *DB
+}
+
+// NewBoltBlocklistStore returns a BlocklistStore backed by the given database.
+func NewBoltBlocklistStore(db *DB) *BoltBlocklistStore {
+	return &BoltBlocklistStore{db: db}
+}
+
+func (s *BoltBlocklistStore) Get(stationUuid uuid.UUID) (BlockedStation, bool) {
+	var blocked BlockedStation
+	found := false
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(blocklistBucket).Get([]byte(stationUuid.String()))
+		found = value != nil && json.Unmarshal(value, &blocked) == nil
+		return nil
+	})
+	return blocked, found
+}
+
+// All returns every blocked station, skipping any record that can't be decoded.
+func (s *BoltBlocklistStore) All() []BlockedStation {
+	var blocklist []BlockedStation
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(blocklistBucket).ForEach(func(key, value []byte) error {
+			var blocked BlockedStation
+			if json.Unmarshal(value, &blocked) == nil {
+				blocklist = append(blocklist, blocked)
+			}
+			return nil
+		})
+	})
+	sort.Slice(blocklist, func(i, j int) bool {
+		return blocklist[i].BlockedAt.After(blocklist[j].BlockedAt)
+	})
+	return blocklist
+}
+
+func (s *BoltBlocklistStore) Add(blocked BlockedStation) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		value, err := json.Marshal(blocked)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(blocklistBucket).Put([]byte(blocked.StationUuid.String()), value)
+	})
+}
+
+func (s *BoltBlocklistStore) Remove(stationUuid uuid.UUID) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(blocklistBucket).Delete([]byte(stationUuid.String()))
+	})
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltBlocklistStore(t *testing.T) {
+
+	t.Run("starts empty", func(t *testing.T) {
+
+		store := NewBoltBlocklistStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		_, ok := store.Get(uuid.New())
+		assert.False(t, ok)
+		assert.Empty(t, store.All())
+
+	})
+
+	t.Run("persists blocked stations across reopenings", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+		blocked := BlockedStation{
+			StationUuid: uuid.New(),
+			StationName: "Talk Radio 24",
+			Reason:      "too many ads",
+			BlockedAt:   time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		}
+
+		db := newTestDB(t, path)
+		assert.NoError(t, NewBoltBlocklistStore(db).Add(blocked))
+		assert.NoError(t, db.Close())
+
+		reloaded, ok := NewBoltBlocklistStore(newTestDB(t, path)).Get(blocked.StationUuid)
+		assert.True(t, ok)
+		assert.Equal(t, blocked, reloaded)
+
+	})
+
+	t.Run("lists the most recently blocked stations first", func(t *testing.T) {
+
+		store := NewBoltBlocklistStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+		older := BlockedStation{StationUuid: uuid.New(), BlockedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+		newer := BlockedStation{StationUuid: uuid.New(), BlockedAt: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)}
+
+		assert.NoError(t, store.Add(older))
+		assert.NoError(t, store.Add(newer))
+
+		assert.Equal(t, []BlockedStation{newer, older}, store.All())
+
+	})
+
+	t.Run("unblocks stations", func(t *testing.T) {
+
+		store := NewBoltBlocklistStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+		blocked := BlockedStation{StationUuid: uuid.New(), Reason: "wrong language"}
+
+		assert.NoError(t, store.Add(blocked))
+		assert.NoError(t, store.Remove(blocked.StationUuid))
+
+		_, ok := store.Get(blocked.StationUuid)
+		assert.False(t, ok)
+
+	})
+
+}
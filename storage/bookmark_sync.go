@@ -0,0 +1,154 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bookmarksChangedKey  = []byte("changed")
+	bookmarkSyncStateKey = []byte("state")
+)
+
+// BookmarkEntry is a bookmarked station along with how it's organized, as exchanged with other machines.
+type BookmarkEntry struct {
+	Station common.Station `json:"station"`
+	BookmarkMeta
+}
+
+// BookmarkSyncState is how the bookmarks were when they were last synced.
+type BookmarkSyncState struct {
+	// Remote identifies where they were synced to: syncing somewhere else starts over.
+	Remote string `json:"remote"`
+	// Hash is the hash of the bookmarks that both sides had then.
+	Hash string `json:"hash"`
+	// Synced is when that happened.
+	Synced time.Time `json:"synced"`
+}
+
+// BookmarkSyncStore defines the behavior needed to sync the bookmarks with other machines.
+type BookmarkSyncStore interface {
+	// Entries returns the bookmarks, in the order they were added.
+	Entries() []BookmarkEntry
+	// Changed returns when the bookmarks were last changed, or the zero time if they never were.
+	Changed() time.Time
+	// Replace replaces every bookmark with the given ones, as they were changed at the given time.
+	Replace(entries []BookmarkEntry, changed time.Time) error
+	// State returns how the bookmarks were when they were last synced, or the zero value if they never were.
+	State() BookmarkSyncState
+	// SetState remembers how the bookmarks were synced.
+	SetState(state BookmarkSyncState) error
+}
+
+// BoltBookmarkSyncStore is a BookmarkSyncStore persisted in the database, next to the bookmarks.
+type BoltBookmarkSyncStore struct {
+	db *DB
+}
+
+// NewBoltBookmarkSyncStore returns a BookmarkSyncStore backed by the given database.
+func NewBoltBookmarkSyncStore(db *DB) *BoltBookmarkSyncStore {
+	return &BoltBookmarkSyncStore{db: db}
+}
+
+func (s *BoltBookmarkSyncStore) Entries() []BookmarkEntry {
+	records := bookmarkRecords(s.db)
+	entries := make([]BookmarkEntry, len(records))
+	for i, record := range records {
+		entries[i] = BookmarkEntry{Station: record.Station, BookmarkMeta: record.BookmarkMeta}
+	}
+	return entries
+}
+
+func (s *BoltBookmarkSyncStore) Changed() time.Time {
+	var changed time.Time
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(bookmarkSyncBucket).Get(bookmarksChangedKey); value != nil {
+			_ = changed.UnmarshalText(value)
+		}
+		return nil
+	})
+	return changed
+}
+
+// Replace removes every bookmark and adds the given ones in order, in a single transaction.
+func (s *BoltBookmarkSyncStore) Replace(entries []BookmarkEntry, changed time.Time) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bookmarksBucket); err != nil {
+			return err
+		}
+		bucket, err := tx.CreateBucket(bookmarksBucket)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			position, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			value, err := json.Marshal(bookmarkRecord{Position: position, Station: entry.Station, BookmarkMeta: entry.BookmarkMeta})
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(entry.Station.StationUuid.String()), value); err != nil {
+				return err
+			}
+		}
+		return setBookmarksChanged(tx, changed)
+	})
+}
+
+func (s *BoltBookmarkSyncStore) State() BookmarkSyncState {
+	var state BookmarkSyncState
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(bookmarkSyncBucket).Get(bookmarkSyncStateKey); value != nil {
+			_ = json.Unmarshal(value, &state)
+		}
+		return nil
+	})
+	return state
+}
+
+func (s *BoltBookmarkSyncStore) SetState(state BookmarkSyncState) error {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bookmarkSyncBucket).Put(bookmarkSyncStateKey, value)
+	})
+}
+
+// touchBookmarks remembers that the bookmarks changed now, for the last change to win when syncing.
+func touchBookmarks(tx *bolt.Tx) error {
+	return setBookmarksChanged(tx, time.Now())
+}
+
+func setBookmarksChanged(tx *bolt.Tx, changed time.Time) error {
+	value, err := changed.UTC().MarshalText()
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bookmarkSyncBucket).Put(bookmarksChangedKey, value)
+}
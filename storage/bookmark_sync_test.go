@@ -0,0 +1,133 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+func TestBoltBookmarkSyncStore(t *testing.T) {
+
+	t.Run("starts empty and never changed", func(t *testing.T) {
+
+		store := NewBoltBookmarkSyncStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.Empty(t, store.Entries())
+		assert.True(t, store.Changed().IsZero())
+		assert.Equal(t, BookmarkSyncState{}, store.State())
+
+	})
+
+	t.Run("lists the bookmarks in order with how they're organized", func(t *testing.T) {
+
+		db := newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db"))
+		bookmarks := NewBoltBookmarkStore(db)
+		first := newTestStation("first")
+		second := newTestStation("second")
+		assert.NoError(t, bookmarks.Add(first))
+		assert.NoError(t, bookmarks.Add(second))
+		assert.NoError(t, bookmarks.SetMeta(second.StationUuid, BookmarkMeta{Folder: "Jazz", Tags: []string{"night"}}))
+
+		entries := NewBoltBookmarkSyncStore(db).Entries()
+
+		assert.Equal(t, []BookmarkEntry{
+			{Station: first},
+			{Station: second, BookmarkMeta: BookmarkMeta{Folder: "Jazz", Tags: []string{"night"}}},
+		}, entries)
+
+	})
+
+	t.Run("remembers when the bookmarks were changed", func(t *testing.T) {
+
+		db := newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db"))
+		bookmarks := NewBoltBookmarkStore(db)
+		store := NewBoltBookmarkSyncStore(db)
+		station := newTestStation("station")
+
+		before := time.Now().Add(-time.Second)
+		assert.NoError(t, bookmarks.Add(station))
+		added := store.Changed()
+		assert.True(t, added.After(before))
+
+		_, err := bookmarks.Remove(newTestStation("unknown").StationUuid)
+		assert.NoError(t, err)
+		assert.Equal(t, added, store.Changed())
+
+		_, err = bookmarks.Remove(station.StationUuid)
+		assert.NoError(t, err)
+		assert.False(t, store.Changed().Before(added))
+
+	})
+
+	t.Run("replaces every bookmark", func(t *testing.T) {
+
+		db := newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db"))
+		bookmarks := NewBoltBookmarkStore(db)
+		store := NewBoltBookmarkSyncStore(db)
+		old := newTestStation("old")
+		first := newTestStation("first")
+		second := newTestStation("second")
+		assert.NoError(t, bookmarks.Add(old))
+		changed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+		err := store.Replace([]BookmarkEntry{
+			{Station: second, BookmarkMeta: BookmarkMeta{Folder: "News"}},
+			{Station: first},
+		}, changed)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"second", "first"}, stationNames(bookmarks.All()))
+		assert.False(t, bookmarks.IsBookmarked(old.StationUuid))
+		assert.Equal(t, "News", bookmarks.Meta(second.StationUuid).Folder)
+		assert.True(t, changed.Equal(store.Changed()))
+
+		third := newTestStation("third")
+		assert.NoError(t, bookmarks.Add(third))
+		assert.Equal(t, []string{"second", "first", "third"}, stationNames(bookmarks.All()))
+
+	})
+
+	t.Run("persists the sync state", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+		state := BookmarkSyncState{Remote: "https://dav.example.com/bookmarks.json", Hash: "abc", Synced: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+
+		db := newTestDB(t, path)
+		assert.NoError(t, NewBoltBookmarkSyncStore(db).SetState(state))
+		db.Close()
+
+		assert.Equal(t, state, NewBoltBookmarkSyncStore(newTestDB(t, path)).State())
+
+	})
+
+}
+
+func stationNames(stations []common.Station) []string {
+	names := make([]string, len(stations))
+	for i, station := range stations {
+		names[i] = station.Name
+	}
+	return names
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/zi0p4tch0/radiogogo/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// BookmarkStore defines the behavior for storing bookmarked stations.
+// A snapshot of each station is kept, so bookmarks can be listed without querying the API.
+type BookmarkStore interface {
+	// All returns the bookmarked stations, in the order they were added.
+	All() []common.Station
+	// IsBookmarked returns true if the given station is bookmarked.
+	IsBookmarked(stationUuid uuid.UUID) bool
+	// Add bookmarks the given station. Adding a station twice updates its snapshot.
+	Add(station common.Station) error
+	// Remove removes the bookmark for the given station, if any, and returns it so that it can be restored.
+	Remove(stationUuid uuid.UUID) (RemovedBookmark, error)
+	// Restore puts back a removed bookmark where it was, along with how it was organized.
+	Restore(bookmark RemovedBookmark) error
+	// Meta returns how the bookmark for the given station is organized.
+	Meta(stationUuid uuid.UUID) BookmarkMeta
+	// SetMeta changes how the bookmark for the given station is organized.
+	// It does nothing if the station isn't bookmarked.
+	SetMeta(stationUuid uuid.UUID, meta BookmarkMeta) error
+}
+
+// BookmarkMeta is how the user organized a bookmark.
+type BookmarkMeta struct {
+	// Folder is the folder the bookmark is filed in, empty for none.
+	Folder string `json:"folder,omitempty"`
+	// Tags are the user's own, unrelated to the tags of the station on radio-browser.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// RemovedBookmark is a bookmark returned by Remove, which Restore puts back.
+type RemovedBookmark struct {
+	Station common.Station
+	Meta    BookmarkMeta
+	// Where the bookmark was in the order they were added (0 for the end)
+	position uint64
+}
+
+// HasTag returns true if the bookmark is tagged with tag, ignoring case.
+func (m BookmarkMeta) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// BoltBookmarkStore is a BookmarkStore persisted in the database.
+// Bookmarks are keyed by station UUID and remember the order they were added in.
+type BoltBookmarkStore struct {
+	db *DB
+}
+
+type bookmarkRecord struct {
+	Position uint64         `json:"position"`
+	Station  common.Station `json:"station"`
+	BookmarkMeta
+}
+
+// NewBoltBookmarkStore returns a BookmarkStore backed by the given database.
+func NewBoltBookmarkStore(db *DB) *BoltBookmarkStore {
+	return &BoltBookmarkStore{db: db}
+}
+
+// All returns the bookmarked stations, skipping any record that can't be decoded.
+func (s *BoltBookmarkStore) All() []common.Station {
+	records := bookmarkRecords(s.db)
+	bookmarks := make([]common.Station, len(records))
+	for i, record := range records {
+		bookmarks[i] = record.Station
+	}
+	return bookmarks
+}
+
+// bookmarkRecords returns the records that can be decoded, in the order the bookmarks were added.
+func bookmarkRecords(db *DB) []bookmarkRecord {
+	var records []bookmarkRecord
+	_ = db.bolt.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bookmarksBucket).ForEach(func(key, value []byte) error {
+			var record bookmarkRecord
+			if json.Unmarshal(value, &record) == nil {
+				records = append(records, record)
+			}
+			return nil
+		})
+	})
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Position < records[j].Position
+	})
+	return records
+}
+
+func (s *BoltBookmarkStore) IsBookmarked(stationUuid uuid.UUID) bool {
+	bookmarked := false
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		bookmarked = tx.Bucket(bookmarksBucket).Get([]byte(stationUuid.String())) != nil
+		return nil
+	})
+	return bookmarked
+}
+
+func (s *BoltBookmarkStore) Add(station common.Station) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		return putBookmark(tx, station)
+	})
+}
+
+// putBookmark stores the station, keeping its position if it is already bookmarked.
+func putBookmark(tx *bolt.Tx, station common.Station) error {
+	bucket := tx.Bucket(bookmarksBucket)
+	key := []byte(station.StationUuid.String())
+
+	var record bookmarkRecord
+	if existing := bucket.Get(key); existing == nil || json.Unmarshal(existing, &record) != nil {
+		position, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		record.Position = position
+	}
+	record.Station = station
+
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := bucket.Put(key, value); err != nil {
+		return err
+	}
+	return touchBookmarks(tx)
+}
+
+func (s *BoltBookmarkStore) Remove(stationUuid uuid.UUID) (RemovedBookmark, error) {
+	var removed RemovedBookmark
+	err := s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bookmarksBucket)
+		key := []byte(stationUuid.String())
+		existing := bucket.Get(key)
+		if existing == nil {
+			return nil
+		}
+		var record bookmarkRecord
+		if json.Unmarshal(existing, &record) == nil {
+			removed = RemovedBookmark{Station: record.Station, Meta: record.BookmarkMeta, position: record.Position}
+		}
+		if err := bucket.Delete(key); err != nil {
+			return err
+		}
+		return touchBookmarks(tx)
+	})
+	return removed, err
+}
+
+// Restore puts the bookmark back at its position, or at the end if it's unknown.
+func (s *BoltBookmarkStore) Restore(bookmark RemovedBookmark) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bookmarksBucket)
+		record := bookmarkRecord{Position: bookmark.position, Station: bookmark.Station, BookmarkMeta: bookmark.Meta}
+		if record.Position == 0 {
+			position, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			record.Position = position
+		}
+		value, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put([]byte(bookmark.Station.StationUuid.String()), value); err != nil {
+			return err
+		}
+		return touchBookmarks(tx)
+	})
+}
+
+func (s *BoltBookmarkStore) Meta(stationUuid uuid.UUID) BookmarkMeta {
+	var record bookmarkRecord
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(bookmarksBucket).Get([]byte(stationUuid.String())); value != nil {
+			_ = json.Unmarshal(value, &record)
+		}
+		return nil
+	})
+	return record.BookmarkMeta
+}
+
+func (s *BoltBookmarkStore) SetMeta(stationUuid uuid.UUID, meta BookmarkMeta) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bookmarksBucket)
+		key := []byte(stationUuid.String())
+
+		existing := bucket.Get(key)
+		if existing == nil {
+			return nil
+		}
+		var record bookmarkRecord
+		if err := json.Unmarshal(existing, &record); err != nil {
+			return err
+		}
+		record.BookmarkMeta = meta
+
+		value, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(key, value); err != nil {
+			return err
+		}
+		return touchBookmarks(tx)
+	})
+}
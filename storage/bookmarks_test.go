@@ -0,0 +1,159 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+func newTestStation(name string) common.Station {
+	streamUrl, _ := url.Parse("http://example.com/" + name)
+	return common.Station{
+		StationUuid: uuid.New(),
+		Name:        name,
+		Url:         common.RadioGoGoURL{URL: *streamUrl},
+		LastCheckOk: true,
+	}
+}
+
+func TestBoltBookmarkStore(t *testing.T) {
+
+	t.Run("starts empty", func(t *testing.T) {
+
+		store := NewBoltBookmarkStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.Empty(t, store.All())
+		assert.False(t, store.IsBookmarked(uuid.New()))
+
+	})
+
+	t.Run("persists bookmarks in insertion order across reopenings", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+		first := newTestStation("first")
+		second := newTestStation("second")
+
+		db := newTestDB(t, path)
+		store := NewBoltBookmarkStore(db)
+		assert.NoError(t, store.Add(first))
+		assert.NoError(t, store.Add(second))
+		assert.NoError(t, store.Add(first))
+		assert.NoError(t, db.Close())
+
+		reloaded := NewBoltBookmarkStore(newTestDB(t, path))
+
+		bookmarks := reloaded.All()
+		assert.Len(t, bookmarks, 2)
+		assert.Equal(t, first.StationUuid, bookmarks[0].StationUuid)
+		assert.Equal(t, "http://example.com/first", bookmarks[0].Url.URL.String())
+		assert.True(t, bool(bookmarks[0].LastCheckOk))
+		assert.Equal(t, second.StationUuid, bookmarks[1].StationUuid)
+		assert.True(t, reloaded.IsBookmarked(second.StationUuid))
+
+	})
+
+	t.Run("updates the snapshot when adding a station twice", func(t *testing.T) {
+
+		store := NewBoltBookmarkStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		station := newTestStation("station")
+		assert.NoError(t, store.Add(station))
+		station.Name = "renamed"
+		assert.NoError(t, store.Add(station))
+
+		bookmarks := store.All()
+		assert.Len(t, bookmarks, 1)
+		assert.Equal(t, "renamed", bookmarks[0].Name)
+
+	})
+
+	t.Run("removes a bookmark", func(t *testing.T) {
+
+		store := NewBoltBookmarkStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		station := newTestStation("station")
+		assert.NoError(t, store.Add(station))
+		removed, err := store.Remove(station.StationUuid)
+		assert.NoError(t, err)
+		assert.Equal(t, station.StationUuid, removed.Station.StationUuid)
+		_, err = store.Remove(station.StationUuid)
+		assert.NoError(t, err)
+
+		assert.False(t, store.IsBookmarked(station.StationUuid))
+		assert.Empty(t, store.All())
+
+	})
+
+	t.Run("restores a removed bookmark where it was, with its folder and tags", func(t *testing.T) {
+
+		store := NewBoltBookmarkStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		first, second, third := newTestStation("first"), newTestStation("second"), newTestStation("third")
+		for _, station := range []common.Station{first, second, third} {
+			assert.NoError(t, store.Add(station))
+		}
+		meta := BookmarkMeta{Folder: "Jazz", Tags: []string{"night"}}
+		assert.NoError(t, store.SetMeta(second.StationUuid, meta))
+
+		removed, err := store.Remove(second.StationUuid)
+		assert.NoError(t, err)
+		assert.NoError(t, store.Restore(removed))
+
+		bookmarks := store.All()
+		assert.Len(t, bookmarks, 3)
+		assert.Equal(t, "second", bookmarks[1].Name)
+		assert.Equal(t, meta, store.Meta(second.StationUuid))
+
+	})
+
+	t.Run("keeps the folder and tags of a bookmark when its snapshot is updated", func(t *testing.T) {
+
+		store := NewBoltBookmarkStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		station := newTestStation("station")
+		assert.NoError(t, store.Add(station))
+		meta := BookmarkMeta{Folder: "Jazz", Tags: []string{"Morning"}}
+		assert.NoError(t, store.SetMeta(station.StationUuid, meta))
+		assert.NoError(t, store.Add(station))
+
+		assert.Equal(t, meta, store.Meta(station.StationUuid))
+		assert.True(t, store.Meta(station.StationUuid).HasTag("morning"))
+
+	})
+
+	t.Run("ignores the folder and tags of a station that isn't bookmarked", func(t *testing.T) {
+
+		store := NewBoltBookmarkStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		station := newTestStation("station")
+		assert.NoError(t, store.SetMeta(station.StationUuid, BookmarkMeta{Folder: "Jazz"}))
+
+		assert.False(t, store.IsBookmarked(station.StationUuid))
+		assert.Equal(t, BookmarkMeta{}, store.Meta(station.StationUuid))
+
+	})
+
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// CacheStore defines the behavior for caching values that can be fetched again, e.g. API responses.
+type CacheStore interface {
+	// Get decodes the value cached under key into v.
+	// Returns false if there is no value or it has expired.
+	Get(key string, v interface{}) (bool, error)
+	// Put caches v under key for ttl. A ttl of zero never expires.
+	Put(key string, v interface{}, ttl time.Duration) error
+	// Delete removes the value cached under key, if any.
+	Delete(key string) error
+}
+
+// BoltCacheStore is a CacheStore persisted in the database.
+type BoltCacheStore struct {
+	db *DB
+	// now returns the current time, and is replaced in tests.
+	now func() time.Time
+}
+
+type cacheRecord struct {
+	ExpiresAt time.Time       `json:"expiresAt"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// NewBoltCacheStore returns a CacheStore backed by the given database.
+func NewBoltCacheStore(db *DB) *BoltCacheStore {
+	return &BoltCacheStore{db: db, now: time.Now}
+}
+
+func (s *BoltCacheStore) Get(key string, v interface{}) (bool, error) {
+	var record cacheRecord
+	found := false
+	err := s.db.bolt.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(cacheBucket).Get([]byte(key))
+		if value == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(value, &record)
+	})
+	if err != nil || !found {
+		return false, err
+	}
+	if !record.ExpiresAt.IsZero() && !s.now().Before(record.ExpiresAt) {
+		return false, nil
+	}
+	return true, json.Unmarshal(record.Value, v)
+}
+
+func (s *BoltCacheStore) Put(key string, v interface{}, ttl time.Duration) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	record := cacheRecord{Value: value}
+	if ttl > 0 {
+		record.ExpiresAt = s.now().Add(ttl)
+	}
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(cacheBucket).Put([]byte(key), encoded)
+	})
+}
+
+func (s *BoltCacheStore) Delete(key string) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(cacheBucket).Delete([]byte(key))
+	})
+}
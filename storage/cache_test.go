@@ -0,0 +1,65 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+func TestBoltCacheStore(t *testing.T) {
+
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	store := NewBoltCacheStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+	store.now = func() time.Time { return now }
+
+	var tags []common.Tag
+
+	found, err := store.Get("tags", &tags)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	assert.NoError(t, store.Put("tags", []common.Tag{{Name: "jazz", StationCount: 3}}, time.Hour))
+
+	found, err = store.Get("tags", &tags)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []common.Tag{{Name: "jazz", StationCount: 3}}, tags)
+
+	now = now.Add(time.Hour)
+	found, err = store.Get("tags", &tags)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	assert.NoError(t, store.Put("forever", 42, 0))
+	assert.NoError(t, store.Delete("tags"))
+
+	var answer int
+	found, err = store.Get("forever", &answer)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 42, answer)
+
+}
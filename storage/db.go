@@ -0,0 +1,242 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrLocked is returned by Open when another process is using the database.
+var ErrLocked = errors.New("the database is in use by another RadioGoGo process")
+
+var (
+	metaBucket      = []byte("meta")
+	bookmarksBucket = []byte("bookmarks")
+	labelsBucket    = []byte("labels")
+	historyBucket   = []byte("history")
+	cacheBucket     = []byte("cache")
+	reportsBucket   = []byte("reports")
+	usageBucket     = []byte("usage")
+	// interactionsBucket remembers the clicks and votes sent to radio-browser.
+	interactionsBucket = []byte("interactions")
+	// volumeTrimsBucket remembers how much each station's volume is trimmed.
+	volumeTrimsBucket = []byte("volumeTrims")
+	// searchesBucket remembers the last search made.
+	searchesBucket = []byte("searches")
+	// playStatsBucket remembers how much each station was listened to.
+	playStatsBucket = []byte("playStats")
+	// streamVariantsBucket remembers which URL of each station plays.
+	streamVariantsBucket = []byte("streamVariants")
+	// bookmarkSyncBucket remembers when the bookmarks changed and when they were last synced.
+	bookmarkSyncBucket = []byte("bookmarkSync")
+	// likedTracksBucket keeps the tracks liked while listening.
+	likedTracksBucket = []byte("likedTracks")
+	// streamHeadersBucket keeps the headers sent along with the requests for each station's stream.
+	streamHeadersBucket = []byte("streamHeaders")
+	// blocklistBucket keeps the stations hidden for good, and why.
+	blocklistBucket = []byte("blocklist")
+	// stationPrefsBucket keeps how each station is played, overriding the configuration.
+	stationPrefsBucket = []byte("stationPrefs")
+	// discoveriesBucket remembers the stations suggested by discovery, to learn which ones were kept.
+	discoveriesBucket = []byte("discoveries")
+
+	versionKey = []byte("version")
+)
+
+// A migration upgrades the database schema by one version.
+type migration func(tx *bolt.Tx) error
+
+// migrations are applied in order: the database version is the number of migrations applied.
+// Never edit or reorder existing migrations, only append new ones.
+var migrations = []migration{
+	// 1: one bucket per repository.
+	func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bookmarksBucket, labelsBucket, historyBucket, cacheBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	// 2: stations reported as broken or miscategorized.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(reportsBucket)
+		return err
+	},
+	// 3: bytes used by the streams, by month.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(usageBucket)
+		return err
+	},
+	// 4: clicks and votes sent to radio-browser, by station.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(interactionsBucket)
+		return err
+	},
+	// 5: volume trims, by station.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(volumeTrimsBucket)
+		return err
+	},
+	// 6: the last search made.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(searchesBucket)
+		return err
+	},
+	// 7: plays and listening time, by station.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(playStatsBucket)
+		return err
+	},
+	// 8: the URL that plays, by station.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(streamVariantsBucket)
+		return err
+	},
+	// 9: when the bookmarks changed and were last synced.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bookmarkSyncBucket)
+		return err
+	},
+	// 10: tracks liked while listening.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(likedTracksBucket)
+		return err
+	},
+	// 11: headers sent for the streams of stations.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(streamHeadersBucket)
+		return err
+	},
+	// 12: stations hidden for good.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(blocklistBucket)
+		return err
+	},
+	// 13: playback preferences of stations.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(stationPrefsBucket)
+		return err
+	},
+	// 14: stations suggested by discovery.
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(discoveriesBucket)
+		return err
+	},
+}
+
+// DB is the embedded database shared by every store.
+type DB struct {
+	bolt *bolt.DB
+}
+
+// Open opens the database at path, creating it if needed, and migrates it to the latest version.
+// Returns ErrLocked if another process has it open.
+func Open(path string) (*DB, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	boltDB, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{bolt: boltDB}
+	err = db.migrate()
+	if err != nil {
+		boltDB.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Close releases the database.
+func (db *DB) Close() error {
+	return db.bolt.Close()
+}
+
+// Version returns the number of migrations applied to the database.
+func (db *DB) Version() (int, error) {
+	var version int
+	err := db.bolt.View(func(tx *bolt.Tx) error {
+		version = readVersion(tx)
+		return nil
+	})
+	return version, err
+}
+
+// migrate applies the pending migrations, each in its own transaction.
+func (db *DB) migrate() error {
+	for {
+		done := false
+		err := db.bolt.Update(func(tx *bolt.Tx) error {
+			version := readVersion(tx)
+			if version >= len(migrations) {
+				done = true
+				return nil
+			}
+			if err := migrations[version](tx); err != nil {
+				return err
+			}
+			return writeVersion(tx, version+1)
+		})
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+func readVersion(tx *bolt.Tx) int {
+	bucket := tx.Bucket(metaBucket)
+	if bucket == nil {
+		return 0
+	}
+	value := bucket.Get(versionKey)
+	if len(value) != 8 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint64(value))
+}
+
+func writeVersion(tx *bolt.Tx, version int) error {
+	bucket, err := tx.CreateBucketIfNotExists(metaBucket)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(versionKey, uint64ToBytes(uint64(version)))
+}
+
+// uint64ToBytes encodes n in big-endian order, so that keys sort numerically.
+func uint64ToBytes(n uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, n)
+	return b
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestDB opens the database at path and closes it when the test ends.
+func newTestDB(t *testing.T, path string) *DB {
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return db
+}
+
+func TestOpen(t *testing.T) {
+
+	t.Run("migrates a new database to the latest version", func(t *testing.T) {
+
+		db := newTestDB(t, filepath.Join(t.TempDir(), "data", "radiogogo.db"))
+
+		version, err := db.Version()
+		assert.NoError(t, err)
+		assert.Equal(t, len(migrations), version)
+
+	})
+
+	t.Run("does not migrate twice", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+		db := newTestDB(t, path)
+		assert.NoError(t, NewBoltLabelStore(db).Set(uuid.New(), StationLabel{Alias: "kept"}))
+		assert.NoError(t, db.Close())
+
+		db = newTestDB(t, path)
+		version, err := db.Version()
+		assert.NoError(t, err)
+		assert.Equal(t, len(migrations), version)
+
+	})
+
+	t.Run("returns ErrLocked if the database is already open", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+		newTestDB(t, path)
+
+		_, err := Open(path)
+		assert.ErrorIs(t, err, ErrLocked)
+
+	})
+
+}
+
+func TestDB_ImportLegacyJSON(t *testing.T) {
+
+	t.Run("imports labels and bookmarks once", func(t *testing.T) {
+
+		dir := t.TempDir()
+		labelsPath := filepath.Join(dir, "labels.json")
+		bookmarksPath := filepath.Join(dir, "bookmarks.json")
+
+		stationUuid := uuid.MustParse("960e57c5-0601-11e8-ae97-52543be04c81")
+		assert.NoError(t, os.WriteFile(labelsPath, []byte(`{"960e57c5-0601-11e8-ae97-52543be04c81": {"alias": "My Radio"}}`), 0644))
+		assert.NoError(t, os.WriteFile(bookmarksPath, []byte(`[
+			{"stationuuid": "960e57c5-0601-11e8-ae97-52543be04c81", "name": "First", "url": "http://example.com/first"},
+			{"stationuuid": "961e57c5-0601-11e8-ae97-52543be04c81", "name": "Second", "url": "http://example.com/second"}
+		]`), 0644))
+
+		db := newTestDB(t, filepath.Join(dir, "radiogogo.db"))
+		assert.NoError(t, db.ImportLegacyJSON(labelsPath, bookmarksPath))
+
+		label, ok := NewBoltLabelStore(db).Get(stationUuid)
+		assert.True(t, ok)
+		assert.Equal(t, "My Radio", label.Alias)
+
+		bookmarks := NewBoltBookmarkStore(db).All()
+		assert.Len(t, bookmarks, 2)
+		assert.Equal(t, "First", bookmarks[0].Name)
+		assert.Equal(t, "http://example.com/second", bookmarks[1].Url.URL.String())
+
+		assert.NoFileExists(t, labelsPath)
+		assert.FileExists(t, labelsPath+".migrated")
+		assert.FileExists(t, bookmarksPath+".migrated")
+
+		// Nothing left to import the second time.
+		_, err := NewBoltBookmarkStore(db).Remove(bookmarks[0].StationUuid)
+		assert.NoError(t, err)
+		assert.NoError(t, db.ImportLegacyJSON(labelsPath, bookmarksPath))
+		assert.Len(t, NewBoltBookmarkStore(db).All(), 1)
+
+	})
+
+	t.Run("returns an error and keeps a corrupted file", func(t *testing.T) {
+
+		dir := t.TempDir()
+		labelsPath := filepath.Join(dir, "labels.json")
+		assert.NoError(t, os.WriteFile(labelsPath, []byte("{"), 0644))
+
+		db := newTestDB(t, filepath.Join(dir, "radiogogo.db"))
+
+		assert.Error(t, db.ImportLegacyJSON(labelsPath, filepath.Join(dir, "bookmarks.json")))
+		assert.FileExists(t, labelsPath)
+
+	})
+
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zi0p4tch0/radiogogo/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Discovery is a station suggested by discovery, which it learns from once it's played or not.
+type Discovery struct {
+	Station     common.Station `json:"station"`
+	SuggestedAt time.Time      `json:"suggestedAt"`
+}
+
+// DiscoveryStore defines the behavior for remembering the stations suggested by discovery.
+type DiscoveryStore interface {
+	// Add remembers the stations as suggested at the given time, replacing any earlier suggestion of them.
+	Add(stations []common.Station, suggestedAt time.Time) error
+	// All returns every station suggested, the latest suggested first.
+	All() ([]Discovery, error)
+}
+
+// BoltDiscoveryStore is a DiscoveryStore persisted in the database.
+type BoltDiscoveryStore struct {
+	db *DB
+}
+
+// NewBoltDiscoveryStore returns a DiscoveryStore backed by the given database.
+func NewBoltDiscoveryStore(db *DB) *BoltDiscoveryStore {
+	return &BoltDiscoveryStore{db: db}
+}
+
+func (s *BoltDiscoveryStore) Add(stations []common.Station, suggestedAt time.Time) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(discoveriesBucket)
+		for _, station := range stations {
+			value, err := json.Marshal(Discovery{Station: station, SuggestedAt: suggestedAt})
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(station.StationUuid.String()), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// All returns every station suggested, skipping any record that can't be decoded.
+func (s *BoltDiscoveryStore) All() ([]Discovery, error) {
+	discoveries := []Discovery{}
+	err := s.db.bolt.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(discoveriesBucket).ForEach(func(key, value []byte) error {
+			if _, err := uuid.ParseBytes(key); err != nil {
+				return nil
+			}
+			var discovery Discovery
+			if json.Unmarshal(value, &discovery) == nil {
+				discoveries = append(discoveries, discovery)
+			}
+			return nil
+		})
+	})
+	sort.SliceStable(discoveries, func(i, j int) bool {
+		return discoveries[i].SuggestedAt.After(discoveries[j].SuggestedAt)
+	})
+	return discoveries, err
+}
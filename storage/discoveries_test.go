@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+func TestBoltDiscoveryStore(t *testing.T) {
+
+	jazz := common.Station{StationUuid: uuid.MustParse("960e57c5-0601-11e8-ae97-52543be04c81"), Name: "Jazz"}
+	rock := common.Station{StationUuid: uuid.MustParse("a1b2c3d4-0601-11e8-ae97-52543be04c81"), Name: "Rock"}
+	suggestedAt := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	t.Run("starts empty", func(t *testing.T) {
+
+		store := NewBoltDiscoveryStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		discoveries, err := store.All()
+
+		assert.NoError(t, err)
+		assert.Empty(t, discoveries)
+
+	})
+
+	t.Run("lists the latest suggested first", func(t *testing.T) {
+
+		store := NewBoltDiscoveryStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.NoError(t, store.Add([]common.Station{jazz}, suggestedAt))
+		assert.NoError(t, store.Add([]common.Station{rock}, suggestedAt.Add(time.Hour)))
+
+		discoveries, err := store.All()
+
+		assert.NoError(t, err)
+		if assert.Len(t, discoveries, 2) {
+			assert.Equal(t, "Rock", discoveries[0].Station.Name)
+			assert.Equal(t, "Jazz", discoveries[1].Station.Name)
+		}
+
+	})
+
+	t.Run("replaces an earlier suggestion of the same station", func(t *testing.T) {
+
+		store := NewBoltDiscoveryStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.NoError(t, store.Add([]common.Station{jazz, rock}, suggestedAt))
+		assert.NoError(t, store.Add([]common.Station{jazz}, suggestedAt.Add(time.Hour)))
+
+		discoveries, err := store.All()
+
+		assert.NoError(t, err)
+		if assert.Len(t, discoveries, 2) {
+			assert.Equal(t, "Jazz", discoveries[0].Station.Name)
+			assert.True(t, suggestedAt.Add(time.Hour).Equal(discoveries[0].SuggestedAt))
+		}
+
+	})
+
+	t.Run("persists suggestions across reopenings", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+
+		db := newTestDB(t, path)
+		assert.NoError(t, NewBoltDiscoveryStore(db).Add([]common.Station{jazz}, suggestedAt))
+		assert.NoError(t, db.Close())
+
+		discoveries, err := NewBoltDiscoveryStore(newTestDB(t, path)).All()
+
+		assert.NoError(t, err)
+		assert.Len(t, discoveries, 1)
+
+	})
+
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zi0p4tch0/radiogogo/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// HistoryEntry records a station being played: a listening session, which lasts until another one is played.
+type HistoryEntry struct {
+	Station  common.Station `json:"station"`
+	PlayedAt time.Time      `json:"playedAt"`
+	// Interruptions are what interrupted the station during the session, in order.
+	Interruptions []Interruption `json:"interruptions,omitempty"`
+}
+
+// InterruptionKind tells what became of a station whose stream was interrupted.
+type InterruptionKind string
+
+const (
+	// InterruptionDropped is the stream lost along with the network, waiting for it to come back.
+	InterruptionDropped InterruptionKind = "dropped"
+	// InterruptionReconnecting is the station being reconnected, after its stream stalled, went silent,
+	// or was lost as the network went down or changed.
+	InterruptionReconnecting InterruptionKind = "reconnecting"
+	// InterruptionFailed is the station stopped for good, as its player exited or it couldn't be reconnected.
+	InterruptionFailed InterruptionKind = "failed"
+)
+
+// Interruption records the stream of a station being interrupted.
+type Interruption struct {
+	Kind InterruptionKind `json:"kind"`
+	At   time.Time        `json:"at"`
+	// Reason tells why, e.g. "stream stalled".
+	Reason string `json:"reason,omitempty"`
+}
+
+// HistoryStore defines the behavior for storing the playback history.
+type HistoryStore interface {
+	// Add records that station started playing at playedAt.
+	Add(station common.Station, playedAt time.Time) error
+	// Recent returns up to limit entries, most recent first.
+	Recent(limit int) ([]HistoryEntry, error)
+	// AddInterruption records interruption in the latest session of the station, if it was ever played.
+	AddInterruption(stationUuid uuid.UUID, interruption Interruption) error
+	// Clear removes every entry, returning them for Restore to put back.
+	Clear() (RemovedHistory, error)
+	// Forget removes every entry of the station, returning them for Restore to put back.
+	Forget(stationUuid uuid.UUID) (RemovedHistory, error)
+	// Restore puts back entries removed by Clear or Forget.
+	Restore(removed RemovedHistory) error
+}
+
+// RemovedHistory is history entries returned by Clear or Forget, which Restore puts back where they were.
+type RemovedHistory struct {
+	// The entries, by their key
+	entries map[string][]byte
+}
+
+// Len returns how many entries were removed.
+func (r RemovedHistory) Len() int {
+	return len(r.entries)
+}
+
+// BoltHistoryStore is a HistoryStore persisted in the database.
+// Entries are keyed by play time, so that they are kept in chronological order.
+type BoltHistoryStore struct {
+	db *DB
+}
+
+// NewBoltHistoryStore returns a HistoryStore backed by the given database.
+func NewBoltHistoryStore(db *DB) *BoltHistoryStore {
+	return &BoltHistoryStore{db: db}
+}
+
+func (s *BoltHistoryStore) Add(station common.Station, playedAt time.Time) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyBucket)
+		// The sequence number keeps entries recorded within the same nanosecond apart.
+		sequence, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := append(uint64ToBytes(uint64(playedAt.UnixNano())), uint64ToBytes(sequence)...)
+		value, err := json.Marshal(HistoryEntry{Station: station, PlayedAt: playedAt})
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, value)
+	})
+}
+
+func (s *BoltHistoryStore) Recent(limit int) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	err := s.db.bolt.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(historyBucket).Cursor()
+		for key, value := cursor.Last(); key != nil && len(entries) < limit; key, value = cursor.Prev() {
+			var entry HistoryEntry
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+func (s *BoltHistoryStore) AddInterruption(stationUuid uuid.UUID, interruption Interruption) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyBucket)
+		cursor := bucket.Cursor()
+		for key, value := cursor.Last(); key != nil; key, value = cursor.Prev() {
+			var entry HistoryEntry
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return err
+			}
+			if entry.Station.StationUuid != stationUuid {
+				continue
+			}
+			entry.Interruptions = append(entry.Interruptions, interruption)
+			updated, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			return bucket.Put(key, updated)
+		}
+		return nil
+	})
+}
+
+func (s *BoltHistoryStore) Clear() (RemovedHistory, error) {
+	return s.remove(nil)
+}
+
+func (s *BoltHistoryStore) Forget(stationUuid uuid.UUID) (RemovedHistory, error) {
+	return s.remove(func(entry HistoryEntry) bool {
+		return entry.Station.StationUuid == stationUuid
+	})
+}
+
+// remove removes the entries matching, or every entry if matches is nil.
+func (s *BoltHistoryStore) remove(matches func(entry HistoryEntry) bool) (RemovedHistory, error) {
+	removed := RemovedHistory{entries: make(map[string][]byte)}
+	err := s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyBucket)
+		var keys [][]byte
+		err := bucket.ForEach(func(key, value []byte) error {
+			if matches != nil {
+				var entry HistoryEntry
+				if json.Unmarshal(value, &entry) != nil || !matches(entry) {
+					return nil
+				}
+			}
+			// Copied, as they're only valid during the transaction
+			removed.entries[string(key)] = append([]byte(nil), value...)
+			keys = append(keys, append([]byte(nil), key...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return RemovedHistory{}, err
+	}
+	return removed, nil
+}
+
+func (s *BoltHistoryStore) Restore(removed RemovedHistory) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyBucket)
+		for key, value := range removed.entries {
+			if err := bucket.Put([]byte(key), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltHistoryStore(t *testing.T) {
+
+	store := NewBoltHistoryStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+	first := newTestStation("first")
+	second := newTestStation("second")
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, store.Add(first, now))
+	assert.NoError(t, store.Add(second, now.Add(time.Minute)))
+	assert.NoError(t, store.Add(first, now.Add(time.Minute)))
+
+	entries, err := store.Recent(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []HistoryEntry{
+		{Station: first, PlayedAt: now.Add(time.Minute)},
+		{Station: second, PlayedAt: now.Add(time.Minute)},
+	}, entries)
+
+	interruption := Interruption{Kind: InterruptionReconnecting, At: now.Add(2 * time.Minute), Reason: "stream stalled"}
+	assert.NoError(t, store.AddInterruption(first.StationUuid, interruption))
+	assert.NoError(t, store.AddInterruption(newTestStation("never played").StationUuid, interruption))
+
+	entries, err = store.Recent(3)
+	assert.NoError(t, err)
+	assert.Equal(t, []Interruption{interruption}, entries[0].Interruptions)
+	assert.Empty(t, entries[1].Interruptions)
+	assert.Empty(t, entries[2].Interruptions)
+
+	forgotten, err := store.Forget(first.StationUuid)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, forgotten.Len())
+	entries, err = store.Recent(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []HistoryEntry{{Station: second, PlayedAt: now.Add(time.Minute)}}, entries)
+
+	assert.NoError(t, store.Restore(forgotten))
+	restored, err := store.Recent(10)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(restored))
+	assert.Equal(t, []Interruption{interruption}, restored[0].Interruptions)
+
+	cleared, err := store.Clear()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, cleared.Len())
+	entries, err = store.Recent(10)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Entries are put back in order, before the ones played since
+	assert.NoError(t, store.Add(second, now.Add(time.Hour)))
+	assert.NoError(t, store.Restore(cleared))
+	entries, err = store.Recent(10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 4)
+	assert.Equal(t, now.Add(time.Hour), entries[0].PlayedAt)
+	assert.Equal(t, restored, entries[1:])
+
+}
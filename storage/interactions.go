@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Interaction is something sent to radio-browser on behalf of a station.
+type Interaction string
+
+const (
+	// InteractionClick is a click, sent when a station starts playing.
+	InteractionClick Interaction = "click"
+	// InteractionVote is a vote for a station.
+	InteractionVote Interaction = "vote"
+)
+
+// interactionRetention is how long interactions are kept: longer than any radio-browser cooldown.
+const interactionRetention = 7 * 24 * time.Hour
+
+// InteractionStore defines the behavior for remembering when stations were last clicked or voted for,
+// so that radio-browser's cooldowns can be honored across restarts.
+type InteractionStore interface {
+	// Last returns when the interaction was last recorded for the station, and whether it ever was.
+	Last(interaction Interaction, stationUuid uuid.UUID) (time.Time, bool)
+	// Record records the interaction for the station at the given time.
+	Record(interaction Interaction, stationUuid uuid.UUID, t time.Time) error
+}
+
+// BoltInteractionStore is an InteractionStore persisted in the database.
+type BoltInteractionStore struct {
+	db *DB
+}
+
+// NewBoltInteractionStore returns an InteractionStore backed by the given database.
+func NewBoltInteractionStore(db *DB) *BoltInteractionStore {
+	return &BoltInteractionStore{db: db}
+}
+
+// interactionKey returns the key of the interaction with the station, e.g. "vote/<uuid>".
+func interactionKey(interaction Interaction, stationUuid uuid.UUID) []byte {
+	return []byte(string(interaction) + "/" + stationUuid.String())
+}
+
+func (s *BoltInteractionStore) Last(interaction Interaction, stationUuid uuid.UUID) (time.Time, bool) {
+	var last time.Time
+	var found bool
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(interactionsBucket).Get(interactionKey(interaction, stationUuid)); len(value) == 8 {
+			last = time.Unix(0, int64(binary.BigEndian.Uint64(value)))
+			found = true
+		}
+		return nil
+	})
+	return last, found
+}
+
+// Record also drops the interactions older than interactionRetention, so the bucket doesn't grow forever.
+func (s *BoltInteractionStore) Record(interaction Interaction, stationUuid uuid.UUID, t time.Time) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(interactionsBucket)
+		cutoff := uint64ToBytes(uint64(t.Add(-interactionRetention).UnixNano()))
+		var expired [][]byte
+		cursor := bucket.Cursor()
+		for key, value := cursor.First(); key != nil; key, value = cursor.Next() {
+			if bytes.Compare(value, cutoff) < 0 {
+				expired = append(expired, key)
+			}
+		}
+		for _, key := range expired {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		return bucket.Put(interactionKey(interaction, stationUuid), uint64ToBytes(uint64(t.UnixNano())))
+	})
+}
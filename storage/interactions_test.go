@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltInteractionStore(t *testing.T) {
+
+	stationUuid := uuid.MustParse("960e57c5-0601-11e8-ae97-52543be04c81")
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("starts empty", func(t *testing.T) {
+
+		store := NewBoltInteractionStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		_, found := store.Last(InteractionVote, stationUuid)
+		assert.False(t, found)
+
+	})
+
+	t.Run("keeps clicks and votes apart", func(t *testing.T) {
+
+		store := NewBoltInteractionStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.NoError(t, store.Record(InteractionVote, stationUuid, now))
+
+		last, found := store.Last(InteractionVote, stationUuid)
+		assert.True(t, found)
+		assert.True(t, now.Equal(last))
+
+		_, found = store.Last(InteractionClick, stationUuid)
+		assert.False(t, found)
+
+	})
+
+	t.Run("drops old interactions when recording", func(t *testing.T) {
+
+		store := NewBoltInteractionStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+		other := uuid.MustParse("961e57c5-0601-11e8-ae97-52543be04c81")
+
+		assert.NoError(t, store.Record(InteractionClick, stationUuid, now))
+		assert.NoError(t, store.Record(InteractionClick, other, now.Add(interactionRetention+time.Hour)))
+
+		_, found := store.Last(InteractionClick, stationUuid)
+		assert.False(t, found)
+		_, found = store.Last(InteractionClick, other)
+		assert.True(t, found)
+
+	})
+
+	t.Run("persists interactions across reopenings", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+
+		db := newTestDB(t, path)
+		assert.NoError(t, NewBoltInteractionStore(db).Record(InteractionClick, stationUuid, now))
+		assert.NoError(t, db.Close())
+
+		_, found := NewBoltInteractionStore(newTestDB(t, path)).Last(InteractionClick, stationUuid)
+		assert.True(t, found)
+
+	})
+
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// StationLabel holds the user's own annotations for a station.
+type StationLabel struct {
+	// Alias is a custom display name used instead of the upstream station name.
+	Alias string `json:"alias,omitempty"`
+	// Note is a free-text note about the station.
+	Note string `json:"note,omitempty"`
+}
+
+// IsEmpty returns true if the label carries no information.
+func (l StationLabel) IsEmpty() bool {
+	return l.Alias == "" && l.Note == ""
+}
+
+// LabelStore defines the behavior for storing custom station labels, keyed by station UUID.
+type LabelStore interface {
+	// Get returns the label for the given station, and false if there is none.
+	Get(stationUuid uuid.UUID) (StationLabel, bool)
+	// Set stores the label for the given station.
+	// Setting an empty label removes it.
+	Set(stationUuid uuid.UUID, label StationLabel) error
+}
+
+// BoltLabelStore is a LabelStore persisted in the database.
+type BoltLabelStore struct {
+	db *DB
+}
+
+// NewBoltLabelStore returns a LabelStore backed by the given database.
+func NewBoltLabelStore(db *DB) *BoltLabelStore {
+	return &BoltLabelStore{db: db}
+}
+
+func (s *BoltLabelStore) Get(stationUuid uuid.UUID) (StationLabel, bool) {
+	var label StationLabel
+	found := false
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(labelsBucket).Get([]byte(stationUuid.String()))
+		found = value != nil && json.Unmarshal(value, &label) == nil
+		return nil
+	})
+	return label, found
+}
+
+func (s *BoltLabelStore) Set(stationUuid uuid.UUID, label StationLabel) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		return putLabel(tx, stationUuid, label)
+	})
+}
+
+func putLabel(tx *bolt.Tx, stationUuid uuid.UUID, label StationLabel) error {
+	bucket := tx.Bucket(labelsBucket)
+	key := []byte(stationUuid.String())
+	if label.IsEmpty() {
+		return bucket.Delete(key)
+	}
+	value, err := json.Marshal(label)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, value)
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltLabelStore(t *testing.T) {
+
+	t.Run("starts empty", func(t *testing.T) {
+
+		store := NewBoltLabelStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		_, ok := store.Get(uuid.New())
+		assert.False(t, ok)
+
+	})
+
+	t.Run("persists labels across reopenings", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+		stationUuid := uuid.New()
+
+		db := newTestDB(t, path)
+		store := NewBoltLabelStore(db)
+
+		err := store.Set(stationUuid, StationLabel{Alias: "My Radio", Note: "Great in the morning"})
+		assert.NoError(t, err)
+		assert.NoError(t, db.Close())
+
+		reloaded := NewBoltLabelStore(newTestDB(t, path))
+
+		label, ok := reloaded.Get(stationUuid)
+		assert.True(t, ok)
+		assert.Equal(t, "My Radio", label.Alias)
+		assert.Equal(t, "Great in the morning", label.Note)
+
+	})
+
+	t.Run("removes a label when set to empty", func(t *testing.T) {
+
+		store := NewBoltLabelStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		stationUuid := uuid.New()
+
+		assert.NoError(t, store.Set(stationUuid, StationLabel{Alias: "My Radio"}))
+		assert.NoError(t, store.Set(stationUuid, StationLabel{}))
+
+		_, ok := store.Get(stationUuid)
+		assert.False(t, ok)
+
+	})
+
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/zi0p4tch0/radiogogo/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// legacySuffix is appended to the JSON files of earlier versions once they have been imported.
+const legacySuffix = ".migrated"
+
+// ImportLegacyJSON moves the labels and bookmarks that earlier versions kept in JSON files into the database.
+// Each existing file is imported in a single transaction and then renamed with a ".migrated" suffix,
+// so it is only imported once. Missing files are skipped.
+func (db *DB) ImportLegacyJSON(labelsPath string, bookmarksPath string) error {
+
+	var labels map[uuid.UUID]StationLabel
+	err := db.importLegacyFile(labelsPath, &labels, func(tx *bolt.Tx) error {
+		for stationUuid, label := range labels {
+			if err := putLabel(tx, stationUuid, label); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var bookmarks []common.Station
+	return db.importLegacyFile(bookmarksPath, &bookmarks, func(tx *bolt.Tx) error {
+		for _, station := range bookmarks {
+			if err := putBookmark(tx, station); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// importLegacyFile decodes the JSON file at path into v, stores it with store and renames the file.
+func (db *DB) importLegacyFile(path string, v interface{}, store func(tx *bolt.Tx) error) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = json.NewDecoder(file).Decode(v)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	err = db.bolt.Update(store)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path, path+legacySuffix)
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// LikedTrack is a track liked while listening, with the station playing it.
+type LikedTrack struct {
+	Title   string         `json:"title"`
+	Station common.Station `json:"station"`
+	LikedAt time.Time      `json:"likedAt"`
+}
+
+// LikedTrackStore defines the behavior for storing the liked tracks.
+type LikedTrackStore interface {
+	// Add records a liked track.
+	Add(track LikedTrack) error
+	// All returns every liked track, most recent first.
+	All() ([]LikedTrack, error)
+	// Remove forgets the track liked at the given time.
+	Remove(likedAt time.Time) error
+}
+
+// BoltLikedTrackStore is a LikedTrackStore persisted in the database.
+// Tracks are keyed by the time they were liked, so that they are kept in chronological order.
+type BoltLikedTrackStore struct {
+	db *DB
+}
+
+// NewBoltLikedTrackStore returns a LikedTrackStore backed by the given database.
+func NewBoltLikedTrackStore(db *DB) *BoltLikedTrackStore {
+	return &BoltLikedTrackStore{db: db}
+}
+
+func (s *BoltLikedTrackStore) Add(track LikedTrack) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(likedTracksBucket)
+		// The sequence number keeps tracks liked within the same nanosecond apart.
+		sequence, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := append(uint64ToBytes(uint64(track.LikedAt.UnixNano())), uint64ToBytes(sequence)...)
+		value, err := json.Marshal(track)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, value)
+	})
+}
+
+func (s *BoltLikedTrackStore) All() ([]LikedTrack, error) {
+	var tracks []LikedTrack
+	err := s.db.bolt.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(likedTracksBucket).Cursor()
+		for key, value := cursor.Last(); key != nil; key, value = cursor.Prev() {
+			var track LikedTrack
+			if err := json.Unmarshal(value, &track); err != nil {
+				return err
+			}
+			tracks = append(tracks, track)
+		}
+		return nil
+	})
+	return tracks, err
+}
+
+func (s *BoltLikedTrackStore) Remove(likedAt time.Time) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		prefix := uint64ToBytes(uint64(likedAt.UnixNano()))
+		cursor := tx.Bucket(likedTracksBucket).Cursor()
+		for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Seek(prefix) {
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltLikedTrackStore(t *testing.T) {
+
+	store := NewBoltLikedTrackStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+	first := LikedTrack{Title: "Artist - Song", Station: newTestStation("first"), LikedAt: time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)}
+	second := LikedTrack{Title: "Other Artist - Other Song", Station: newTestStation("second"), LikedAt: first.LikedAt.Add(time.Minute)}
+
+	tracks, err := store.All()
+	assert.NoError(t, err)
+	assert.Empty(t, tracks)
+
+	assert.NoError(t, store.Add(first))
+	assert.NoError(t, store.Add(second))
+
+	tracks, err = store.All()
+	assert.NoError(t, err)
+	assert.Equal(t, []LikedTrack{second, first}, tracks)
+
+	assert.NoError(t, store.Remove(second.LikedAt))
+
+	tracks, err = store.All()
+	assert.NoError(t, err)
+	assert.Equal(t, []LikedTrack{first}, tracks)
+
+}
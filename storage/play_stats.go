@@ -0,0 +1,111 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// PlayStats is how much a station was listened to.
+type PlayStats struct {
+	// Plays is how many times the station was played, reconnections aside.
+	Plays int `json:"plays"`
+	// Listened is how long it was listened to in total, pauses aside.
+	Listened time.Duration `json:"listened"`
+	// LastPlayed is when it was last played.
+	LastPlayed time.Time `json:"lastPlayed"`
+}
+
+// PlayStatsStore defines the behavior for remembering how much each station was listened to,
+// which the bookmarks can be sorted by.
+type PlayStatsStore interface {
+	// All returns the statistics of every station played, by station UUID.
+	All() map[uuid.UUID]PlayStats
+	// RecordPlay counts a play of the station, started at playedAt.
+	RecordPlay(stationUuid uuid.UUID, playedAt time.Time) error
+	// AddListened adds to how long the station was listened to.
+	AddListened(stationUuid uuid.UUID, listened time.Duration) error
+}
+
+// BoltPlayStatsStore is a PlayStatsStore persisted in the database.
+type BoltPlayStatsStore struct {
+	db *DB
+}
+
+// NewBoltPlayStatsStore returns a PlayStatsStore backed by the given database.
+func NewBoltPlayStatsStore(db *DB) *BoltPlayStatsStore {
+	return &BoltPlayStatsStore{db: db}
+}
+
+// All returns the statistics of every station played, skipping any record that can't be decoded.
+func (s *BoltPlayStatsStore) All() map[uuid.UUID]PlayStats {
+	all := make(map[uuid.UUID]PlayStats)
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(playStatsBucket).ForEach(func(key, value []byte) error {
+			stationUuid, err := uuid.ParseBytes(key)
+			if err != nil {
+				return nil
+			}
+			var stats PlayStats
+			if json.Unmarshal(value, &stats) == nil {
+				all[stationUuid] = stats
+			}
+			return nil
+		})
+	})
+	return all
+}
+
+func (s *BoltPlayStatsStore) RecordPlay(stationUuid uuid.UUID, playedAt time.Time) error {
+	return s.update(stationUuid, func(stats *PlayStats) {
+		stats.Plays++
+		stats.LastPlayed = playedAt
+	})
+}
+
+func (s *BoltPlayStatsStore) AddListened(stationUuid uuid.UUID, listened time.Duration) error {
+	return s.update(stationUuid, func(stats *PlayStats) {
+		stats.Listened += listened
+	})
+}
+
+// update changes the statistics of the station with change, starting over if they can't be decoded.
+func (s *BoltPlayStatsStore) update(stationUuid uuid.UUID, change func(stats *PlayStats)) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(playStatsBucket)
+		key := []byte(stationUuid.String())
+
+		var stats PlayStats
+		if existing := bucket.Get(key); existing != nil && json.Unmarshal(existing, &stats) != nil {
+			stats = PlayStats{}
+		}
+		change(&stats)
+
+		value, err := json.Marshal(stats)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, value)
+	})
+}
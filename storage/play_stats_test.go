@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltPlayStatsStore(t *testing.T) {
+
+	stationUuid := uuid.MustParse("960e57c5-0601-11e8-ae97-52543be04c81")
+	playedAt := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	t.Run("starts empty", func(t *testing.T) {
+
+		store := NewBoltPlayStatsStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.Empty(t, store.All())
+
+	})
+
+	t.Run("counts plays and adds up the time listened", func(t *testing.T) {
+
+		store := NewBoltPlayStatsStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.NoError(t, store.RecordPlay(stationUuid, playedAt))
+		assert.NoError(t, store.AddListened(stationUuid, 10*time.Minute))
+		assert.NoError(t, store.RecordPlay(stationUuid, playedAt.Add(time.Hour)))
+		assert.NoError(t, store.AddListened(stationUuid, 5*time.Minute))
+
+		stats := store.All()[stationUuid]
+		assert.Equal(t, 2, stats.Plays)
+		assert.Equal(t, 15*time.Minute, stats.Listened)
+		assert.True(t, playedAt.Add(time.Hour).Equal(stats.LastPlayed))
+
+	})
+
+	t.Run("persists statistics across reopenings", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+
+		db := newTestDB(t, path)
+		assert.NoError(t, NewBoltPlayStatsStore(db).RecordPlay(stationUuid, playedAt))
+		assert.NoError(t, db.Close())
+
+		assert.Equal(t, 1, NewBoltPlayStatsStore(newTestDB(t, path)).All()[stationUuid].Plays)
+
+	})
+
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zi0p4tch0/radiogogo/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ReportReason is why a station was reported.
+type ReportReason string
+
+const (
+	// ReportBroken is for stations that don't play.
+	ReportBroken ReportReason = "broken"
+	// ReportMiscategorized is for stations with wrong tags, country or language.
+	ReportMiscategorized ReportReason = "miscategorized"
+)
+
+// Report records a station the user flagged as broken or miscategorized.
+type Report struct {
+	StationUuid uuid.UUID    `json:"stationuuid"`
+	StationName string       `json:"stationName"`
+	Reason      ReportReason `json:"reason"`
+	ReportedAt  time.Time    `json:"reportedAt"`
+	// StationChangedAt is when the station last changed upstream at the time it was reported.
+	StationChangedAt time.Time `json:"stationChangedAt"`
+}
+
+// Hides returns true if the report still applies to station, that is the station
+// hasn't changed upstream since it was reported.
+func (r Report) Hides(station common.Station) bool {
+	return r.StationUuid == station.StationUuid && !station.LastChangeTime.After(r.StationChangedAt)
+}
+
+// ReportStore defines the behavior for storing station reports, keyed by station UUID.
+type ReportStore interface {
+	// Get returns the report for the given station, and false if there is none.
+	Get(stationUuid uuid.UUID) (Report, bool)
+	// All returns every report, most recent first.
+	All() []Report
+	// Add stores a report, replacing any previous report for the same station.
+	Add(report Report) error
+	// Remove removes the report for the given station, if any.
+	Remove(stationUuid uuid.UUID) error
+}
+
+// BoltReportStore is a ReportStore persisted in the database.
+type BoltReportStore struct {
+	db *DB
+}
+
+// NewBoltReportStore returns a ReportStore backed by the given database.
+func NewBoltReportStore(db *DB) *BoltReportStore {
+	return &BoltReportStore{db: db}
+}
+
+func (s *BoltReportStore) Get(stationUuid uuid.UUID) (Report, bool) {
+	var report Report
+	found := false
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(reportsBucket).Get([]byte(stationUuid.String()))
+		found = value != nil && json.Unmarshal(value, &report) == nil
+		return nil
+	})
+	return report, found
+}
+
+// All returns every report, skipping any record that can't be decoded.
+func (s *BoltReportStore) All() []Report {
+	var reports []Report
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(reportsBucket).ForEach(func(key, value []byte) error {
+			var report Report
+			if json.Unmarshal(value, &report) == nil {
+				reports = append(reports, report)
+			}
+			return nil
+		})
+	})
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].ReportedAt.After(reports[j].ReportedAt)
+	})
+	return reports
+}
+
+func (s *BoltReportStore) Add(report Report) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		value, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(reportsBucket).Put([]byte(report.StationUuid.String()), value)
+	})
+}
+
+func (s *BoltReportStore) Remove(stationUuid uuid.UUID) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(reportsBucket).Delete([]byte(stationUuid.String()))
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+func TestBoltReportStore(t *testing.T) {
+
+	t.Run("starts empty", func(t *testing.T) {
+
+		store := NewBoltReportStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		_, ok := store.Get(uuid.New())
+		assert.False(t, ok)
+		assert.Empty(t, store.All())
+
+	})
+
+	t.Run("persists reports across reopenings", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+		report := Report{
+			StationUuid:      uuid.New(),
+			StationName:      "Jazz FM",
+			Reason:           ReportBroken,
+			ReportedAt:       time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+			StationChangedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+
+		db := newTestDB(t, path)
+		assert.NoError(t, NewBoltReportStore(db).Add(report))
+		assert.NoError(t, db.Close())
+
+		reloaded, ok := NewBoltReportStore(newTestDB(t, path)).Get(report.StationUuid)
+		assert.True(t, ok)
+		assert.Equal(t, report, reloaded)
+
+	})
+
+	t.Run("lists the most recent reports first", func(t *testing.T) {
+
+		store := NewBoltReportStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+		older := Report{StationUuid: uuid.New(), ReportedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+		newer := Report{StationUuid: uuid.New(), ReportedAt: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)}
+
+		assert.NoError(t, store.Add(older))
+		assert.NoError(t, store.Add(newer))
+
+		assert.Equal(t, []Report{newer, older}, store.All())
+
+	})
+
+	t.Run("removes reports", func(t *testing.T) {
+
+		store := NewBoltReportStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+		report := Report{StationUuid: uuid.New(), Reason: ReportMiscategorized}
+
+		assert.NoError(t, store.Add(report))
+		assert.NoError(t, store.Remove(report.StationUuid))
+
+		_, ok := store.Get(report.StationUuid)
+		assert.False(t, ok)
+
+	})
+
+}
+
+func TestReport_Hides(t *testing.T) {
+
+	changedAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := Report{StationUuid: uuid.New(), StationChangedAt: changedAt}
+
+	assert.True(t, report.Hides(common.Station{StationUuid: report.StationUuid, LastChangeTime: changedAt}))
+	assert.False(t, report.Hides(common.Station{StationUuid: report.StationUuid, LastChangeTime: changedAt.Add(time.Hour)}))
+	assert.False(t, report.Hides(common.Station{StationUuid: uuid.New(), LastChangeTime: changedAt}))
+
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/json"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Search is a search of radio-browser, remembered to be made again.
+type Search struct {
+	Query     common.StationQuery  `json:"query"`
+	QueryText string               `json:"queryText"`
+	Filter    common.StationFilter `json:"filter"`
+}
+
+// SearchStore defines the behavior for remembering the last search made, to show its results again.
+type SearchStore interface {
+	// Last returns the last search made, false if none was.
+	Last() (Search, bool, error)
+	// SetLast remembers search as the last one made.
+	SetLast(search Search) error
+}
+
+// BoltSearchStore is a SearchStore persisted in the database.
+type BoltSearchStore struct {
+	db *DB
+}
+
+var lastSearchKey = []byte("last")
+
+// NewBoltSearchStore returns a SearchStore backed by the given database.
+func NewBoltSearchStore(db *DB) *BoltSearchStore {
+	return &BoltSearchStore{db: db}
+}
+
+func (s *BoltSearchStore) Last() (Search, bool, error) {
+	var search Search
+	found := false
+	err := s.db.bolt.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(searchesBucket).Get(lastSearchKey)
+		if value == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(value, &search)
+	})
+	if err != nil {
+		return Search{}, false, err
+	}
+	return search, found, nil
+}
+
+func (s *BoltSearchStore) SetLast(search Search) error {
+	value, err := json.Marshal(search)
+	if err != nil {
+		return err
+	}
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(searchesBucket).Put(lastSearchKey, value)
+	})
+}
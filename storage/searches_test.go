@@ -0,0 +1,63 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/common"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltSearchStore(t *testing.T) {
+
+	t.Run("starts without a last search", func(t *testing.T) {
+
+		store := NewBoltSearchStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		_, found, err := store.Last()
+		assert.NoError(t, err)
+		assert.False(t, found)
+
+	})
+
+	t.Run("keeps the last search", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+		store := NewBoltSearchStore(newTestDB(t, path))
+
+		assert.NoError(t, store.SetLast(Search{Query: common.StationQueryByTag, QueryText: "rock"}))
+		search := Search{
+			Query:     common.StationQueryByName,
+			QueryText: "jazz",
+			Filter:    common.StationFilter{CountryCode: "IT", Language: "italian"},
+		}
+		assert.NoError(t, store.SetLast(search))
+
+		last, found, err := store.Last()
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, search, last)
+
+	})
+
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/zi0p4tch0/radiogogo/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// StationPrefsStore defines the behavior for remembering how each station is played,
+// such as the bitrate of its HLS variant or the playback engine, overriding the configuration.
+type StationPrefsStore interface {
+	// Prefs returns the preferences of the station, the zero value if it has none.
+	Prefs(stationUuid uuid.UUID) common.StationPrefs
+	// SetPrefs sets the preferences of the station, forgetting them when they don't override anything.
+	SetPrefs(stationUuid uuid.UUID, prefs common.StationPrefs) error
+}
+
+// BoltStationPrefsStore is a StationPrefsStore persisted in the database.
+type BoltStationPrefsStore struct {
+	db *DB
+}
+
+// NewBoltStationPrefsStore returns a StationPrefsStore backed by the given database.
+func NewBoltStationPrefsStore(db *DB) *BoltStationPrefsStore {
+	return &BoltStationPrefsStore{db: db}
+}
+
+func (s *BoltStationPrefsStore) Prefs(stationUuid uuid.UUID) common.StationPrefs {
+	var prefs common.StationPrefs
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(stationPrefsBucket).Get([]byte(stationUuid.String())); value != nil {
+			// Preferences that can't be read are as good as none
+			if err := json.Unmarshal(value, &prefs); err != nil {
+				prefs = common.StationPrefs{}
+			}
+		}
+		return nil
+	})
+	return prefs
+}
+
+func (s *BoltStationPrefsStore) SetPrefs(stationUuid uuid.UUID, prefs common.StationPrefs) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(stationPrefsBucket)
+		key := []byte(stationUuid.String())
+		if prefs.IsZero() {
+			return bucket.Delete(key)
+		}
+		value, err := json.Marshal(prefs)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, value)
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+func TestBoltStationPrefsStore(t *testing.T) {
+
+	stationUuid := uuid.MustParse("960e57c5-0601-11e8-ae97-52543be04c81")
+	prefs := common.StationPrefs{HLSBitrate: 128, Reconnect: common.ReconnectPersistent, Backend: "mpv"}
+
+	t.Run("starts without preferences", func(t *testing.T) {
+
+		store := NewBoltStationPrefsStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.True(t, store.Prefs(stationUuid).IsZero())
+
+	})
+
+	t.Run("keeps the preferences of each station", func(t *testing.T) {
+
+		store := NewBoltStationPrefsStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+		other := uuid.MustParse("961e57c5-0601-11e8-ae97-52543be04c81")
+
+		assert.NoError(t, store.SetPrefs(stationUuid, prefs))
+
+		assert.Equal(t, prefs, store.Prefs(stationUuid))
+		assert.True(t, store.Prefs(other).IsZero())
+
+	})
+
+	t.Run("forgets the preferences once they're back to the configuration", func(t *testing.T) {
+
+		store := NewBoltStationPrefsStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.NoError(t, store.SetPrefs(stationUuid, prefs))
+		assert.NoError(t, store.SetPrefs(stationUuid, common.StationPrefs{}))
+
+		assert.True(t, store.Prefs(stationUuid).IsZero())
+
+	})
+
+	t.Run("persists preferences across reopenings", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+
+		db := newTestDB(t, path)
+		assert.NoError(t, NewBoltStationPrefsStore(db).SetPrefs(stationUuid, prefs))
+		assert.NoError(t, db.Close())
+
+		assert.Equal(t, prefs, NewBoltStationPrefsStore(newTestDB(t, path)).Prefs(stationUuid))
+
+	})
+
+}
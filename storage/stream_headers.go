@@ -0,0 +1,76 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/zi0p4tch0/radiogogo/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// StreamHeaderStore defines the behavior for remembering the headers sent along with the requests
+// for the stream of each station, such as the Referer some streams require.
+type StreamHeaderStore interface {
+	// Headers returns the headers of the station, nil if it has none.
+	Headers(stationUuid uuid.UUID) common.StreamHeaders
+	// SetHeaders sets the headers of the station, forgetting them when there are none.
+	SetHeaders(stationUuid uuid.UUID, headers common.StreamHeaders) error
+}
+
+// BoltStreamHeaderStore is a StreamHeaderStore persisted in the database.
+type BoltStreamHeaderStore struct {
+	db *DB
+}
+
+// NewBoltStreamHeaderStore returns a StreamHeaderStore backed by the given database.
+func NewBoltStreamHeaderStore(db *DB) *BoltStreamHeaderStore {
+	return &BoltStreamHeaderStore{db: db}
+}
+
+func (s *BoltStreamHeaderStore) Headers(stationUuid uuid.UUID) common.StreamHeaders {
+	var headers common.StreamHeaders
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(streamHeadersBucket).Get([]byte(stationUuid.String())); value != nil {
+			// Headers that can't be read are as good as none
+			if err := json.Unmarshal(value, &headers); err != nil {
+				headers = nil
+			}
+		}
+		return nil
+	})
+	return headers
+}
+
+func (s *BoltStreamHeaderStore) SetHeaders(stationUuid uuid.UUID, headers common.StreamHeaders) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(streamHeadersBucket)
+		key := []byte(stationUuid.String())
+		if len(headers) == 0 {
+			return bucket.Delete(key)
+		}
+		value, err := json.Marshal(headers)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, value)
+	})
+}
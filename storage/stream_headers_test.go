@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/zi0p4tch0/radiogogo/common"
+)
+
+func TestBoltStreamHeaderStore(t *testing.T) {
+
+	stationUuid := uuid.MustParse("960e57c5-0601-11e8-ae97-52543be04c81")
+	headers := common.StreamHeaders{
+		{Name: "Referer", Value: "https://example.com/player"},
+		{Name: "X-Token", Value: "a,b;c"},
+	}
+
+	t.Run("starts without headers", func(t *testing.T) {
+
+		store := NewBoltStreamHeaderStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.Nil(t, store.Headers(stationUuid))
+
+	})
+
+	t.Run("keeps the headers of each station in order", func(t *testing.T) {
+
+		store := NewBoltStreamHeaderStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+		other := uuid.MustParse("961e57c5-0601-11e8-ae97-52543be04c81")
+
+		assert.NoError(t, store.SetHeaders(stationUuid, headers))
+
+		assert.Equal(t, headers, store.Headers(stationUuid))
+		assert.Nil(t, store.Headers(other))
+
+	})
+
+	t.Run("forgets the headers when there are none left", func(t *testing.T) {
+
+		store := NewBoltStreamHeaderStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.NoError(t, store.SetHeaders(stationUuid, headers))
+		assert.NoError(t, store.SetHeaders(stationUuid, nil))
+
+		assert.Nil(t, store.Headers(stationUuid))
+
+	})
+
+	t.Run("persists headers across reopenings", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+
+		db := newTestDB(t, path)
+		assert.NoError(t, NewBoltStreamHeaderStore(db).SetHeaders(stationUuid, headers))
+		assert.NoError(t, db.Close())
+
+		assert.Equal(t, headers, NewBoltStreamHeaderStore(newTestDB(t, path)).Headers(stationUuid))
+
+	})
+
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// StreamVariant tells which of the URLs of a station plays.
+type StreamVariant string
+
+const (
+	// StreamResolved is the URL radio-browser resolved the station's URL to ("url_resolved").
+	StreamResolved StreamVariant = "url_resolved"
+	// StreamURL is the URL the station was added with ("url").
+	StreamURL StreamVariant = "url"
+	// StreamPlaylist is one of the streams listed by the station's URL, when it's a playlist.
+	StreamPlaylist StreamVariant = "playlist"
+)
+
+// StreamVariantStore defines the behavior for remembering which URL of each station played last,
+// so that it's tried first the next time.
+type StreamVariantStore interface {
+	// Variant returns the variant that played the station last, or an empty string if none is remembered.
+	Variant(stationUuid uuid.UUID) StreamVariant
+	// SetVariant remembers the variant that played the station.
+	SetVariant(stationUuid uuid.UUID, variant StreamVariant) error
+}
+
+// BoltStreamVariantStore is a StreamVariantStore persisted in the database.
+type BoltStreamVariantStore struct {
+	db *DB
+}
+
+// NewBoltStreamVariantStore returns a StreamVariantStore backed by the given database.
+func NewBoltStreamVariantStore(db *DB) *BoltStreamVariantStore {
+	return &BoltStreamVariantStore{db: db}
+}
+
+func (s *BoltStreamVariantStore) Variant(stationUuid uuid.UUID) StreamVariant {
+	var variant StreamVariant
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		variant = StreamVariant(tx.Bucket(streamVariantsBucket).Get([]byte(stationUuid.String())))
+		return nil
+	})
+	return variant
+}
+
+func (s *BoltStreamVariantStore) SetVariant(stationUuid uuid.UUID, variant StreamVariant) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(streamVariantsBucket).Put([]byte(stationUuid.String()), []byte(variant))
+	})
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltStreamVariantStore(t *testing.T) {
+
+	stationUuid := uuid.MustParse("960e57c5-0601-11e8-ae97-52543be04c81")
+
+	t.Run("remembers nothing at first", func(t *testing.T) {
+
+		store := NewBoltStreamVariantStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.Equal(t, StreamVariant(""), store.Variant(stationUuid))
+
+	})
+
+	t.Run("remembers the last variant that played, across reopenings", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+
+		db := newTestDB(t, path)
+		store := NewBoltStreamVariantStore(db)
+		assert.NoError(t, store.SetVariant(stationUuid, StreamURL))
+		assert.NoError(t, store.SetVariant(stationUuid, StreamPlaylist))
+		assert.NoError(t, db.Close())
+
+		assert.Equal(t, StreamPlaylist, NewBoltStreamVariantStore(newTestDB(t, path)).Variant(stationUuid))
+
+	})
+
+}
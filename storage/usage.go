@@ -0,0 +1,73 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/binary"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// UsageStore defines the behavior for storing how many bytes the streams used, by month.
+type UsageStore interface {
+	// Month returns how many bytes were used in the month of the given time.
+	Month(t time.Time) uint64
+	// Add adds bytes to the month of the given time.
+	Add(t time.Time, bytes uint64) error
+}
+
+// BoltUsageStore is a UsageStore persisted in the database.
+type BoltUsageStore struct {
+	db *DB
+}
+
+// NewBoltUsageStore returns a UsageStore backed by the given database.
+func NewBoltUsageStore(db *DB) *BoltUsageStore {
+	return &BoltUsageStore{db: db}
+}
+
+// usageKey returns the key of the month of t, e.g. "2024-03", in local time.
+func usageKey(t time.Time) []byte {
+	return []byte(t.Local().Format("2006-01"))
+}
+
+func (s *BoltUsageStore) Month(t time.Time) uint64 {
+	var bytes uint64
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(usageBucket).Get(usageKey(t)); len(value) == 8 {
+			bytes = binary.BigEndian.Uint64(value)
+		}
+		return nil
+	})
+	return bytes
+}
+
+func (s *BoltUsageStore) Add(t time.Time, bytes uint64) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(usageBucket)
+		key := usageKey(t)
+		var total uint64
+		if value := bucket.Get(key); len(value) == 8 {
+			total = binary.BigEndian.Uint64(value)
+		}
+		return bucket.Put(key, uint64ToBytes(total+bytes))
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltUsageStore(t *testing.T) {
+
+	march := time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local)
+	april := time.Date(2024, 4, 2, 12, 0, 0, 0, time.Local)
+
+	t.Run("starts empty", func(t *testing.T) {
+
+		store := NewBoltUsageStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.Equal(t, uint64(0), store.Month(march))
+
+	})
+
+	t.Run("adds up the bytes of each month", func(t *testing.T) {
+
+		store := NewBoltUsageStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.NoError(t, store.Add(march, 1000))
+		assert.NoError(t, store.Add(march.Add(24*time.Hour), 500))
+		assert.NoError(t, store.Add(april, 42))
+
+		assert.Equal(t, uint64(1500), store.Month(march))
+		assert.Equal(t, uint64(42), store.Month(april))
+
+	})
+
+	t.Run("persists usage across reopenings", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+
+		db := newTestDB(t, path)
+		assert.NoError(t, NewBoltUsageStore(db).Add(march, 1000))
+		assert.NoError(t, db.Close())
+
+		assert.Equal(t, uint64(1000), NewBoltUsageStore(newTestDB(t, path)).Month(march))
+
+	})
+
+}
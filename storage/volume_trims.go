@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"encoding/binary"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// VolumeTrimStore defines the behavior for remembering how much louder or quieter each station
+// is played than the chosen volume, to even out stations with different loudness.
+type VolumeTrimStore interface {
+	// Trim returns the volume offset of the station, 0 if it has none.
+	Trim(stationUuid uuid.UUID) int
+	// SetTrim sets the volume offset of the station, forgetting it when it's 0.
+	SetTrim(stationUuid uuid.UUID, trim int) error
+}
+
+// BoltVolumeTrimStore is a VolumeTrimStore persisted in the database.
+type BoltVolumeTrimStore struct {
+	db *DB
+}
+
+// NewBoltVolumeTrimStore returns a VolumeTrimStore backed by the given database.
+func NewBoltVolumeTrimStore(db *DB) *BoltVolumeTrimStore {
+	return &BoltVolumeTrimStore{db: db}
+}
+
+func (s *BoltVolumeTrimStore) Trim(stationUuid uuid.UUID) int {
+	var trim int
+	_ = s.db.bolt.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(volumeTrimsBucket).Get([]byte(stationUuid.String())); len(value) == 8 {
+			trim = int(int64(binary.BigEndian.Uint64(value)))
+		}
+		return nil
+	})
+	return trim
+}
+
+func (s *BoltVolumeTrimStore) SetTrim(stationUuid uuid.UUID, trim int) error {
+	return s.db.bolt.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(volumeTrimsBucket)
+		key := []byte(stationUuid.String())
+		if trim == 0 {
+			return bucket.Delete(key)
+		}
+		return bucket.Put(key, uint64ToBytes(uint64(int64(trim))))
+	})
+}
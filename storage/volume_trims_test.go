@@ -0,0 +1,78 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltVolumeTrimStore(t *testing.T) {
+
+	stationUuid := uuid.MustParse("960e57c5-0601-11e8-ae97-52543be04c81")
+
+	t.Run("starts untrimmed", func(t *testing.T) {
+
+		store := NewBoltVolumeTrimStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.Equal(t, 0, store.Trim(stationUuid))
+
+	})
+
+	t.Run("keeps negative and positive trims", func(t *testing.T) {
+
+		store := NewBoltVolumeTrimStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+		other := uuid.MustParse("961e57c5-0601-11e8-ae97-52543be04c81")
+
+		assert.NoError(t, store.SetTrim(stationUuid, -15))
+		assert.NoError(t, store.SetTrim(other, 10))
+
+		assert.Equal(t, -15, store.Trim(stationUuid))
+		assert.Equal(t, 10, store.Trim(other))
+
+	})
+
+	t.Run("forgets trims set back to zero", func(t *testing.T) {
+
+		store := NewBoltVolumeTrimStore(newTestDB(t, filepath.Join(t.TempDir(), "radiogogo.db")))
+
+		assert.NoError(t, store.SetTrim(stationUuid, 5))
+		assert.NoError(t, store.SetTrim(stationUuid, 0))
+
+		assert.Equal(t, 0, store.Trim(stationUuid))
+
+	})
+
+	t.Run("persists trims across reopenings", func(t *testing.T) {
+
+		path := filepath.Join(t.TempDir(), "radiogogo.db")
+
+		db := newTestDB(t, path)
+		assert.NoError(t, NewBoltVolumeTrimStore(db).SetTrim(stationUuid, -5))
+		assert.NoError(t, db.Close())
+
+		assert.Equal(t, -5, NewBoltVolumeTrimStore(newTestDB(t, path)).Trim(stationUuid))
+
+	})
+
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/api"
+	"github.com/zi0p4tch0/radiogogo/bookmarksync"
+	"github.com/zi0p4tch0/radiogogo/cli"
+	"github.com/zi0p4tch0/radiogogo/config"
+	"github.com/zi0p4tch0/radiogogo/offline"
+	"github.com/zi0p4tch0/radiogogo/storage"
+)
+
+// newSyncCommand returns "radiogogo sync".
+func newSyncCommand(loadConfig func() config.Config) *cli.Command {
+
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	countriesFlag := flags.String("countries", "", "comma-separated ISO 3166-1 alpha-2 country codes to download (e.g. IT,FR)")
+	tagsFlag := flags.String("tags", "", "comma-separated tags to download (e.g. jazz,classical)")
+
+	sync := &cli.Command{
+		Name:  "sync",
+		Short: "Download stations into the offline catalog",
+		Long:  "Downloads the stations of the chosen countries and tags into the offline catalog, which answers searches whenever radio-browser can't be reached. Without flags, the lists in the \"sync\" section of the configuration are used.",
+		Flags: flags,
+		Run: func(args []string) error {
+			if err := syncCatalog(loadConfig(), splitList(*countriesFlag), splitList(*tagsFlag)); err != nil {
+				return fmt.Errorf("syncing the offline catalog: %w", err)
+			}
+			return nil
+		},
+	}
+
+	sync.Add(newSyncBookmarksCommand(loadConfig))
+
+	return sync
+}
+
+// newSyncBookmarksCommand returns "radiogogo sync bookmarks", which syncs the bookmarks with other machines.
+func newSyncBookmarksCommand(loadConfig func() config.Config) *cli.Command {
+
+	flags := flag.NewFlagSet("bookmarks", flag.ContinueOnError)
+	restoreFlag := flags.String("restore", "", "replace the bookmarks with those of a backup `file` before syncing")
+
+	return &cli.Command{
+		Name:          "bookmarks",
+		Short:         "Sync the bookmarks through a Git repository or a WebDAV server",
+		Long:          "Syncs the bookmarks, with their folders and tags, through the Git repository or WebDAV server in the \"sync.bookmarks\" section of the configuration. If they were changed on both sides since they were last synced, the side changed last wins, and the bookmarks of the other side are backed up next to the database.",
+		Flags:         flags,
+		CompleteFiles: true,
+		Run: func(args []string) error {
+			if len(args) > 0 {
+				return errors.New("syncing bookmarks: usage: radiogogo sync bookmarks [--restore <file>]")
+			}
+			if err := syncBookmarks(loadConfig(), *restoreFlag); err != nil {
+				return fmt.Errorf("syncing bookmarks: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// syncCatalog downloads the stations of the given countries and tags into the offline catalog,
+// or of the ones in the "sync" section of the configuration if there are none.
+func syncCatalog(cfg config.Config, countries []string, tags []string) error {
+
+	if len(countries) == 0 && len(tags) == 0 {
+		countries = cfg.Sync.Countries
+		tags = cfg.Sync.Tags
+	}
+	if len(countries) == 0 && len(tags) == 0 {
+		return errors.New("nothing to sync: pass --countries and/or --tags, or set them in the \"sync\" section of the configuration")
+	}
+
+	browser, err := api.NewRadioBrowser(cfg.API.BaseURL, api.NewRateLimiter(cfg.API.RequestsPerSecond))
+	if err != nil {
+		return err
+	}
+
+	catalog, err := offline.OpenCatalog(config.CatalogFile())
+	if err != nil {
+		return err
+	}
+	defer catalog.Close()
+
+	count, err := offline.Sync(browser, catalog, countries, tags, func(term string, count int) {
+		fmt.Printf("%s: %d stations\n", term, count)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved %d stations to %s\n", count, config.CatalogFile())
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// syncBookmarks syncs the bookmarks, replacing them with those of the backup at restore first, if set.
+func syncBookmarks(cfg config.Config, restore string) error {
+
+	remote, err := bookmarkRemote(cfg)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	store := storage.NewBoltBookmarkSyncStore(db)
+
+	if restore != "" {
+		backup, err := bookmarksync.ReadDocument(restore)
+		if err != nil {
+			return err
+		}
+		// Restored now, so that they win over the shared bookmarks
+		if err := store.Replace(backup.Bookmarks, time.Now()); err != nil {
+			return err
+		}
+		fmt.Printf("Restored %d bookmarks from %s\n", len(backup.Bookmarks), restore)
+	}
+
+	result, err := bookmarksync.Sync(store, remote, config.BookmarkBackupsDir())
+	if err != nil {
+		return err
+	}
+	fmt.Println(describeBookmarkSync(result, remote))
+	return nil
+}
+
+// autoSyncBookmarks syncs the bookmarks as RadioGoGo starts or quits, only telling when they changed or couldn't be synced.
+func autoSyncBookmarks(cfg config.Config, db *storage.DB) {
+	remote, err := bookmarkRemote(cfg)
+	if err == nil {
+		var result bookmarksync.Result
+		result, err = bookmarksync.Sync(storage.NewBoltBookmarkSyncStore(db), remote, config.BookmarkBackupsDir())
+		if result.Action == bookmarksync.Pulled || result.Conflict {
+			fmt.Println(describeBookmarkSync(result, remote))
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error syncing bookmarks: %v\n", err)
+	}
+}
+
+// bookmarkRemote returns where the configuration syncs the bookmarks through.
+func bookmarkRemote(cfg config.Config) (bookmarksync.Remote, error) {
+	settings := cfg.Sync.Bookmarks
+	switch {
+	case settings.Git != "" && settings.WebDAV != "":
+		return nil, errors.New("both a Git repository and a WebDAV server are set in the \"sync.bookmarks\" section of the configuration: keep one")
+	case settings.Git != "":
+		return bookmarksync.NewGit(settings.Git, settings.Branch, config.BookmarkSyncDir()), nil
+	case settings.WebDAV != "":
+		return bookmarksync.NewWebDAV(settings.WebDAV, settings.Username, settings.Password), nil
+	}
+	return nil, errors.New("nowhere to sync to: set a Git repository or a WebDAV server in the \"sync.bookmarks\" section of the configuration")
+}
+
+// describeBookmarkSync tells what syncing the bookmarks did.
+func describeBookmarkSync(result bookmarksync.Result, remote bookmarksync.Remote) string {
+	var description string
+	switch result.Action {
+	case bookmarksync.UpToDate:
+		description = fmt.Sprintf("%d bookmarks already in sync with %s", result.Count, remote)
+	case bookmarksync.Pushed:
+		description = fmt.Sprintf("Sent %d bookmarks to %s", result.Count, remote)
+	case bookmarksync.Pulled:
+		description = fmt.Sprintf("Received %d bookmarks from %s", result.Count, remote)
+	}
+	if result.Conflict {
+		description += " (they were changed on both sides: the side changed last won)"
+	}
+	if result.Backup != "" {
+		description += fmt.Sprintf("\nThe bookmarks they replaced were backed up to %s: restore them with radiogogo sync bookmarks --restore <file>", result.Backup)
+	}
+	return description
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package updates tells whether a newer release of RadioGoGo was published on GitHub.
+package updates
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/i18n"
+)
+
+const latestReleaseUrl = "https://api.github.com/repos/Zi0P4tch0/RadioGoGo/releases/latest"
+
+// How long asking GitHub for the latest release may take.
+const checkTimeout = 10 * time.Second
+
+// The summary of a changelog keeps its first few lines, up to a banner's worth of characters.
+const (
+	summaryLines     = 3
+	summaryMaxLength = 160
+)
+
+// ErrBadVersion is returned when a version isn't of the "1.2.3" form.
+var ErrBadVersion = i18n.Error("updates.badVersion")
+
+// Release is a release of RadioGoGo.
+type Release struct {
+	// Version is of the "1.2.3" form, without the "v" of the tag.
+	Version string
+	// URL is the page the release can be downloaded from.
+	URL string
+	// Summary is the first lines of the changelog, on a single line.
+	Summary string
+}
+
+// CheckerService finds out the latest release of RadioGoGo.
+type CheckerService interface {
+	// Latest returns the latest release published.
+	Latest() (Release, error)
+}
+
+// GitHub finds out the latest release from the GitHub releases API.
+type GitHub struct {
+	httpClient *http.Client
+	url        string
+}
+
+// NewGitHub returns a checker asking the GitHub releases API.
+func NewGitHub() *GitHub {
+	return NewGitHubWithDependencies(&http.Client{Timeout: checkTimeout})
+}
+
+// NewGitHubWithDependencies returns a checker asking the GitHub releases API with the given HTTP client.
+func NewGitHubWithDependencies(httpClient *http.Client) *GitHub {
+	return &GitHub{
+		httpClient: httpClient,
+		url:        latestReleaseUrl,
+	}
+}
+
+type gitHubRelease struct {
+	TagName string `json:"tag_name"`
+	HtmlUrl string `json:"html_url"`
+	Body    string `json:"body"`
+}
+
+func (g *GitHub) Latest() (Release, error) {
+
+	req, err := http.NewRequest("GET", g.url, nil)
+	if err != nil {
+		return Release{}, err
+	}
+	req.Header.Set("User-Agent", data.UserAgent)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	result, err := g.httpClient.Do(req)
+	if err != nil {
+		return Release{}, err
+	}
+	defer result.Body.Close()
+
+	if result.StatusCode != http.StatusOK {
+		return Release{}, fmt.Errorf("unexpected status code: %d", result.StatusCode)
+	}
+
+	var response gitHubRelease
+	if err := json.NewDecoder(result.Body).Decode(&response); err != nil {
+		return Release{}, err
+	}
+
+	version := strings.TrimPrefix(response.TagName, "v")
+	if _, err := parseVersion(version); err != nil {
+		return Release{}, err
+	}
+
+	return Release{
+		Version: version,
+		URL:     response.HtmlUrl,
+		Summary: summarize(response.Body),
+	}, nil
+}
+
+// Check returns the latest release, and true if it's newer than the version running.
+func Check(checker CheckerService) (Release, bool, error) {
+	release, err := checker.Latest()
+	if err != nil {
+		return Release{}, false, err
+	}
+	newer, err := Newer(release.Version, data.Version)
+	if err != nil {
+		return Release{}, false, err
+	}
+	return release, newer, nil
+}
+
+// Newer returns true if version is newer than current. Both are of the "1.2.3" form, "v" prefix optional;
+// a pre-release such as "1.2.3-rc1" comes before "1.2.3".
+func Newer(version, current string) (bool, error) {
+	v, err := parseVersion(version)
+	if err != nil {
+		return false, err
+	}
+	c, err := parseVersion(current)
+	if err != nil {
+		return false, err
+	}
+	for i := range v.numbers {
+		if v.numbers[i] != c.numbers[i] {
+			return v.numbers[i] > c.numbers[i], nil
+		}
+	}
+	return v.preRelease == "" && c.preRelease != "", nil
+}
+
+// version is a parsed "1.2.3-rc1" version.
+type version struct {
+	numbers    [3]int
+	preRelease string
+}
+
+func parseVersion(s string) (version, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	// Build metadata doesn't tell versions apart
+	s, _, _ = strings.Cut(s, "+")
+	s, preRelease, _ := strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return version{}, fmt.Errorf("%w: %q", ErrBadVersion, s)
+	}
+	parsed := version{preRelease: preRelease}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return version{}, fmt.Errorf("%w: %q", ErrBadVersion, s)
+		}
+		parsed.numbers[i] = n
+	}
+	return parsed, nil
+}
+
+// summarize returns the first lines of a Markdown changelog on a single line, without headings and list markers,
+// e.g. "Theme files; Faster searches" for "## What's new\n- Theme files\n- Faster searches".
+func summarize(changelog string) string {
+	var lines []string
+	for _, line := range strings.Split(changelog, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimLeft(line, "-*+ "))
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+		if len(lines) == summaryLines {
+			break
+		}
+	}
+	summary := []rune(strings.Join(lines, "; "))
+	if len(summary) > summaryMaxLength {
+		return strings.TrimSpace(string(summary[:summaryMaxLength-1])) + "…"
+	}
+	return string(summary)
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package updates
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zi0p4tch0/radiogogo/data"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewer(t *testing.T) {
+
+	testCases := []struct {
+		version string
+		current string
+		newer   bool
+	}{
+		{"0.3.1", "0.3.0", true},
+		{"v0.4.0", "0.3.9", true},
+		{"1.0", "0.9.9", true},
+		{"0.10.0", "0.9.0", true},
+		{"0.3.0", "0.3.0", false},
+		{"0.2.9", "0.3.0", false},
+		{"0.3.0", "0.3.0-rc1", true},
+		{"0.3.0-rc2", "0.3.0", false},
+		{"0.3.0+build5", "0.3.0", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.version+" over "+tc.current, func(t *testing.T) {
+			newer, err := Newer(tc.version, tc.current)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.newer, newer)
+		})
+	}
+
+	t.Run("fails on what isn't a version", func(t *testing.T) {
+		_, err := Newer("nightly", "0.3.0")
+		assert.ErrorIs(t, err, ErrBadVersion)
+		_, err = Newer("1.2.3.4", "0.3.0")
+		assert.ErrorIs(t, err, ErrBadVersion)
+	})
+
+}
+
+func TestSummarize(t *testing.T) {
+
+	assert.Equal(t, "Theme files; Faster searches; Bookmark sync",
+		summarize("## What's new\r\n\r\n- Theme files\r\n* Faster searches\n\n+ Bookmark sync\n- Play stats\n"))
+	assert.Equal(t, "", summarize(""))
+
+	long := summarize("A very long line " + strings.Repeat("la ", 100))
+	assert.Equal(t, summaryMaxLength, len([]rune(long)))
+	assert.Equal(t, "…", string([]rune(long)[summaryMaxLength-1:]))
+
+}
+
+func TestGitHub(t *testing.T) {
+
+	serve := func(status int, body string) *GitHub {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, data.UserAgent, r.Header.Get("User-Agent"))
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		github := NewGitHubWithDependencies(server.Client())
+		github.url = server.URL
+		return github
+	}
+
+	t.Run("reads the version, page and changelog of the latest release", func(t *testing.T) {
+		github := serve(http.StatusOK, `{
+			"tag_name": "v9.1.0",
+			"html_url": "https://github.com/Zi0P4tch0/RadioGoGo/releases/tag/v9.1.0",
+			"body": "## Changes\n- Update checker\n"
+		}`)
+
+		release, err := github.Latest()
+		assert.NoError(t, err)
+		assert.Equal(t, Release{
+			Version: "9.1.0",
+			URL:     "https://github.com/Zi0P4tch0/RadioGoGo/releases/tag/v9.1.0",
+			Summary: "Update checker",
+		}, release)
+
+		release, newer, err := Check(github)
+		assert.NoError(t, err)
+		assert.True(t, newer)
+		assert.Equal(t, "9.1.0", release.Version)
+	})
+
+	t.Run("isn't newer than the version running", func(t *testing.T) {
+		github := serve(http.StatusOK, `{"tag_name": "v`+data.Version+`", "html_url": "https://example.com"}`)
+
+		_, newer, err := Check(github)
+		assert.NoError(t, err)
+		assert.False(t, newer)
+	})
+
+	t.Run("fails on tags that aren't versions", func(t *testing.T) {
+		github := serve(http.StatusOK, `{"tag_name": "nightly"}`)
+
+		_, err := github.Latest()
+		assert.ErrorIs(t, err, ErrBadVersion)
+	})
+
+	t.Run("fails on errors from the server", func(t *testing.T) {
+		github := serve(http.StatusForbidden, `{"message": "API rate limit exceeded"}`)
+
+		_, err := github.Latest()
+		assert.Error(t, err)
+	})
+
+}
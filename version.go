@@ -0,0 +1,71 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/zi0p4tch0/radiogogo/cli"
+	"github.com/zi0p4tch0/radiogogo/data"
+	"github.com/zi0p4tch0/radiogogo/updates"
+)
+
+// newVersionCommand returns "radiogogo version", which prints the version running
+// and, with --check, whether a newer one was released.
+func newVersionCommand() *cli.Command {
+
+	flags := flag.NewFlagSet("version", flag.ContinueOnError)
+	check := flags.Bool("check", false, "ask GitHub whether a newer version was released")
+
+	return &cli.Command{
+		Name:  "version",
+		Short: "Print the version of RadioGoGo, and whether a newer one was released",
+		Flags: flags,
+		Run: func(args []string) error {
+			fmt.Printf("radiogogo %s\n", data.Version)
+			if !*check {
+				return nil
+			}
+			if err := printUpdate(updates.NewGitHub()); err != nil {
+				return fmt.Errorf("checking for updates: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// printUpdate prints the latest release if it's newer than the version running.
+func printUpdate(checker updates.CheckerService) error {
+	release, newer, err := updates.Check(checker)
+	if err != nil {
+		return err
+	}
+	if !newer {
+		fmt.Println("Up to date")
+		return nil
+	}
+	fmt.Printf("RadioGoGo %s is out", release.Version)
+	if release.Summary != "" {
+		fmt.Printf(": %s", release.Summary)
+	}
+	fmt.Printf("\nDownload: %s\n", release.URL)
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package worldmap
+
+// A Canvas is a grid of terminal cells drawn with braille characters,
+// each of which holds 2×4 dots that are set one by one.
+type Canvas struct {
+	cols  int
+	rows  int
+	cells []rune
+}
+
+// brailleBlank is the braille character without dots, to which the dots of a cell are added.
+const brailleBlank = '⠀'
+
+// brailleDots are the bits of the dots of a braille character, by row and column within its cell.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// NewCanvas returns a blank canvas of cols × rows cells, that is 2·cols × 4·rows dots.
+func NewCanvas(cols, rows int) *Canvas {
+	if cols < 0 {
+		cols = 0
+	}
+	if rows < 0 {
+		rows = 0
+	}
+	cells := make([]rune, cols*rows)
+	for i := range cells {
+		cells[i] = brailleBlank
+	}
+	return &Canvas{cols: cols, rows: rows, cells: cells}
+}
+
+// Size returns the number of cells across and down the canvas.
+func (c *Canvas) Size() (cols, rows int) {
+	return c.cols, c.rows
+}
+
+// Set sets the dot at x, y, counted in dots from the top left corner.
+// Dots outside the canvas are ignored.
+func (c *Canvas) Set(x, y int) {
+	if x < 0 || y < 0 || x >= c.cols*2 || y >= c.rows*4 {
+		return
+	}
+	c.cells[(y/4)*c.cols+x/2] |= brailleDots[y%4][x%2]
+}
+
+// Cell returns the braille character of the cell at col, row.
+func (c *Canvas) Cell(col, row int) rune {
+	if col < 0 || row < 0 || col >= c.cols || row >= c.rows {
+		return brailleBlank
+	}
+	return c.cells[row*c.cols+col]
+}
+
+// IsBlank returns true if no dot of the cell at col, row is set.
+func (c *Canvas) IsBlank(col, row int) bool {
+	return c.Cell(col, row) == brailleBlank
+}
+
+// String returns the rows of the canvas, one per line.
+func (c *Canvas) String() string {
+	v := make([]rune, 0, (c.cols+1)*c.rows)
+	for row := 0; row < c.rows; row++ {
+		if row > 0 {
+			v = append(v, '\n')
+		}
+		v = append(v, c.cells[row*c.cols:(row+1)*c.cols]...)
+	}
+	return string(v)
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package worldmap
+
+// point is a place given as longitude, latitude.
+type point [2]float64
+
+// landmasses are rough outlines of the continents and the largest islands,
+// good enough to tell them apart on a map a terminal wide.
+var landmasses = [][]point{
+	// North America
+	{
+		{-168, 66}, {-162, 70}, {-156, 71}, {-140, 70}, {-128, 70}, {-115, 68}, {-95, 72}, {-85, 70},
+		{-80, 63}, {-94, 59}, {-92, 57}, {-82, 55}, {-79, 52}, {-78, 60}, {-72, 61}, {-65, 60},
+		{-61, 56}, {-56, 52}, {-60, 47}, {-64, 45}, {-70, 43}, {-71, 41}, {-74, 40}, {-76, 35},
+		{-81, 31}, {-80, 25}, {-82, 27}, {-84, 30}, {-89, 30}, {-94, 29}, {-97, 26}, {-97, 22},
+		{-95, 19}, {-91, 19}, {-87, 21}, {-88, 16}, {-83, 15}, {-83, 10}, {-78, 8}, {-80, 7},
+		{-85, 10}, {-88, 13}, {-92, 14}, {-96, 16}, {-105, 20}, {-110, 24}, {-114, 31}, {-117, 32},
+		{-120, 34}, {-124, 40}, {-124, 47}, {-128, 51}, {-134, 57}, {-140, 60}, {-150, 61}, {-158, 57},
+		{-165, 55}, {-163, 60}, {-166, 62},
+	},
+	// Arctic Archipelago
+	{{-120, 72}, {-95, 74}, {-80, 73}, {-62, 82}, {-90, 82}, {-120, 77}},
+	// Greenland
+	{{-73, 78}, {-60, 82}, {-30, 83}, {-20, 80}, {-18, 75}, {-22, 70}, {-32, 68}, {-43, 60}, {-50, 64}, {-54, 68}, {-58, 75}},
+	// Iceland
+	{{-24, 65}, {-22, 66.4}, {-14.5, 66.4}, {-13.5, 65}, {-18, 63.4}, {-22, 63.8}},
+	// Cuba
+	{{-85, 21.8}, {-80, 23.2}, {-74, 20}, {-77.7, 19.8}},
+	// Hispaniola
+	{{-74.5, 18.4}, {-68.4, 18.6}, {-70, 19.9}, {-73, 19.9}},
+	// South America
+	{
+		{-80, 9}, {-77, 8}, {-72, 12}, {-62, 11}, {-52, 5}, {-50, 0}, {-44, -2}, {-35, -5},
+		{-35, -9}, {-39, -13}, {-40, -20}, {-48, -26}, {-53, -34}, {-58, -38}, {-62, -39}, {-65, -45},
+		{-68, -50}, {-69, -53}, {-72, -54}, {-75, -50}, {-74, -42}, {-72, -30}, {-71, -20}, {-76, -14},
+		{-81, -6}, {-80, -1}, {-78, 2},
+	},
+	// Eurasia
+	{
+		{-9, 37}, {-9, 43}, {-2, 43.5}, {-4, 48}, {2, 51}, {5, 53}, {8, 54}, {8, 57},
+		{10, 58}, {12, 56}, {11, 54}, {14, 54}, {20, 55}, {21, 57}, {24, 59}, {30, 60},
+		{22, 61}, {21, 64}, {25, 66}, {21, 65}, {17, 62}, {19, 60}, {16, 56}, {13, 56},
+		{11, 59}, {5, 58}, {5, 62}, {13, 67}, {20, 70}, {28, 71}, {41, 67}, {44, 68},
+		{53, 68}, {60, 70}, {69, 73}, {80, 73}, {87, 75}, {100, 78}, {113, 74}, {130, 71},
+		{141, 73}, {160, 70}, {170, 70}, {180, 68}, {180, 65}, {172, 60}, {163, 56}, {157, 51},
+		{156, 57}, {143, 59}, {137, 54}, {141, 48}, {135, 43}, {129, 41}, {129, 35}, {126, 35},
+		{126, 38}, {121, 40}, {122, 37}, {119, 35}, {122, 31}, {120, 26}, {114, 22}, {108, 21},
+		{106, 19}, {109, 12}, {105, 9}, {100, 13}, {100, 6}, {104, 1}, {101, 2}, {98, 8},
+		{98, 16}, {94, 17}, {92, 22}, {87, 21}, {80, 16}, {78, 8}, {73, 18}, {70, 22},
+		{67, 25}, {58, 25}, {56, 27}, {52, 28}, {48, 30}, {50, 26}, {56, 24}, {60, 22},
+		{57, 19}, {52, 16}, {45, 13}, {43, 14}, {39, 21}, {35, 28}, {34, 31}, {36, 36},
+		{28, 37}, {26, 40}, {29, 41}, {41, 41}, {41, 44}, {37, 45}, {30, 46}, {29, 45},
+		{28, 41}, {23, 40}, {24, 38}, {22, 37}, {20, 40}, {19, 42}, {13, 46}, {12, 44},
+		{16, 41}, {18, 40}, {16, 38}, {15, 40}, {11, 42}, {9, 44}, {3, 43}, {0, 39},
+		{-2, 37}, {-5, 36},
+	},
+	// Great Britain
+	{
+		{-5.7, 50}, {1.4, 51.2}, {1.7, 52.7}, {0, 53.5}, {-1.6, 55.6}, {-2, 57.7}, {-3, 58.6}, {-5, 58.6},
+		{-6.2, 56.8}, {-4.9, 55}, {-3, 54}, {-3, 53.4}, {-4.7, 52.8}, {-5.3, 51.7}, {-3, 51.2},
+	},
+	// Ireland
+	{{-6, 52}, {-6, 54.5}, {-7.5, 55.3}, {-10, 54.2}, {-10, 51.6}},
+	// Sicily
+	{{12.4, 38}, {15.6, 38.3}, {15, 36.7}},
+	// Sardinia
+	{{8.2, 41}, {9.8, 41.2}, {9.6, 39}, {8.4, 39}},
+	// Japan
+	{
+		{129.5, 33}, {131, 31}, {135, 33.5}, {139.8, 34.9}, {141, 38}, {141.5, 41.5}, {145.5, 43.3}, {141.8, 45.5},
+		{139.8, 42}, {139.8, 38.5}, {136, 36.5}, {131, 34.5},
+	},
+	// Taiwan
+	{{120.1, 23}, {121.5, 25.3}, {121.9, 24.5}, {120.8, 22}},
+	// Sri Lanka
+	{{80, 6}, {82, 7}, {80, 9.8}},
+	// Philippines
+	{{120, 18.5}, {122, 18.5}, {124, 12.5}, {126, 7}, {125, 6}, {122, 7}, {120, 14}},
+	// Sumatra
+	{{95, 5.5}, {98, 4}, {104, -2}, {106, -6}, {101, -3}},
+	// Java
+	{{105, -6}, {114, -7.5}, {114, -8.5}, {106, -7}},
+	// Borneo
+	{{109, 2}, {117, 7}, {119, 5}, {118, 1}, {116, -4}, {110, -3}},
+	// Sulawesi
+	{{119, -5.5}, {120, 1}, {125, 1.5}, {121, -1}, {123, -5}},
+	// New Guinea
+	{{131, -1}, {141, -2.5}, {150, -10.5}, {143, -9}, {138, -8}},
+	// Australia
+	{
+		{114, -22}, {114, -34}, {118, -35}, {124, -34}, {131, -31}, {138, -35}, {141, -38}, {147, -38},
+		{150, -37}, {153, -30}, {153, -25}, {146, -19}, {142, -11}, {141, -17}, {136, -15}, {136, -12},
+		{130, -11}, {126, -14}, {122, -18},
+	},
+	// Tasmania
+	{{144.6, -40.7}, {148.3, -40.9}, {148, -43.2}, {146, -43.6}},
+	// New Zealand, North Island
+	{{172.6, -34.4}, {178.5, -37.7}, {176.9, -39.5}, {174.8, -41.3}, {174.6, -39}},
+	// New Zealand, South Island
+	{{172.7, -40.5}, {174.3, -41.7}, {171.2, -44.3}, {169, -46.6}, {166.5, -45.9}},
+	// Africa
+	{
+		{-17, 21}, {-16, 28}, {-10, 30}, {-6, 36}, {10, 37}, {11, 33}, {20, 31}, {30, 31},
+		{32, 30}, {35, 24}, {38, 18}, {43, 12}, {51, 12}, {45, 2}, {40, -3}, {39, -10},
+		{40, -16}, {35, -24}, {32, -29}, {27, -34}, {20, -35}, {18, -32}, {15, -27}, {12, -17},
+		{13, -10}, {9, -1}, {9, 4}, {5, 6}, {-4, 5}, {-8, 4}, {-13, 8}, {-17, 14},
+	},
+	// Madagascar
+	{{44, -25}, {47, -25}, {50, -15}, {49, -12}, {44, -17}},
+}
+
+// IsLand returns true if lon, lat lies within the rough outlines of the land.
+func IsLand(lon, lat float64) bool {
+	for _, outline := range landmasses {
+		if contains(outline, lon, lat) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains tells whether lon, lat lies within outline, by casting a ray eastwards and counting the edges it crosses.
+func contains(outline []point, lon, lat float64) bool {
+	inside := false
+	for i, j := 0, len(outline)-1; i < len(outline); j, i = i, i+1 {
+		a, b := outline[i], outline[j]
+		if (a[1] > lat) == (b[1] > lat) {
+			continue
+		}
+		if lon < a[0]+(lat-a[1])/(b[1]-a[1])*(b[0]-a[0]) {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package worldmap
+
+import "math"
+
+// The parallels the map is cropped to: hardly any station lies beyond them.
+const (
+	North = 84.0
+	South = -58.0
+)
+
+// earthRadius is the mean radius of the earth, in kilometers.
+const earthRadius = 6371.0
+
+// A Map is the world in equirectangular projection, its land drawn in braille dots onto a grid of terminal cells.
+type Map struct {
+	land *Canvas
+}
+
+// New returns the map of the world drawn across cols × rows cells.
+func New(cols, rows int) Map {
+	m := Map{land: NewCanvas(cols, rows)}
+	width, height := cols*2, rows*4
+	for y := 0; y < height; y++ {
+		lat := North - (float64(y)+0.5)/float64(height)*(North-South)
+		for x := 0; x < width; x++ {
+			lon := -180 + (float64(x)+0.5)/float64(width)*360
+			if IsLand(lon, lat) {
+				m.land.Set(x, y)
+			}
+		}
+	}
+	return m
+}
+
+// FittingRows returns how many rows a map cols cells wide takes not to be stretched,
+// for terminal cells twice as tall as they're wide.
+func FittingRows(cols int) int {
+	rows := int(math.Round(float64(cols*2) * (North - South) / 360 / 4))
+	if rows < 1 {
+		return 1
+	}
+	return rows
+}
+
+// Size returns the number of cells across and down the map.
+func (m Map) Size() (cols, rows int) {
+	return m.land.Size()
+}
+
+// Land returns the braille character drawing the land in the cell at col, row, blank over the sea.
+func (m Map) Land(col, row int) rune {
+	return m.land.Cell(col, row)
+}
+
+// String returns the land drawn on the map, a row per line.
+func (m Map) String() string {
+	return m.land.String()
+}
+
+// IsSea returns true if there's no land in the cell at col, row.
+func (m Map) IsSea(col, row int) bool {
+	return m.land.IsBlank(col, row)
+}
+
+// Cell returns the cell in which lon, lat lies. Places beyond the parallels the map is cropped to
+// are put on its top or bottom row.
+func (m Map) Cell(lon, lat float64) (col, row int) {
+	cols, rows := m.Size()
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	col = int(lon / 360 * float64(cols))
+	row = int((North - lat) / (North - South) * float64(rows))
+	return clamp(col, cols-1), clamp(row, rows-1)
+}
+
+// Location returns the longitude and latitude of the center of the cell at col, row.
+func (m Map) Location(col, row int) (lon, lat float64) {
+	cols, rows := m.Size()
+	lon = -180 + (float64(col)+0.5)/float64(cols)*360
+	lat = North - (float64(row)+0.5)/float64(rows)*(North-South)
+	return lon, lat
+}
+
+// Distance returns the great-circle distance between two places, in kilometers.
+func Distance(lon1, lat1, lon2, lat2 float64) float64 {
+	phi1, phi2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dPhi := phi2 - phi1
+	dLambda := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func clamp(value, highest int) int {
+	if value > highest {
+		value = highest
+	}
+	if value < 0 {
+		value = 0
+	}
+	return value
+}
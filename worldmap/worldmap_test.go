@@ -0,0 +1,123 @@
+// Copyright (c) 2023 Matteo Pacini
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package worldmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanvasSetsBrailleDots(t *testing.T) {
+	canvas := NewCanvas(2, 1)
+
+	canvas.Set(0, 0)
+	canvas.Set(1, 3)
+	canvas.Set(3, 1)
+	canvas.Set(4, 0)
+	canvas.Set(-1, 0)
+
+	assert.Equal(t, '⢁', canvas.Cell(0, 0))
+	assert.Equal(t, '⠐', canvas.Cell(1, 0))
+	assert.False(t, canvas.IsBlank(0, 0))
+	assert.True(t, canvas.IsBlank(5, 5))
+	assert.Equal(t, "⢁⠐", canvas.String())
+}
+
+func TestCanvasStringHasARowPerLine(t *testing.T) {
+	canvas := NewCanvas(2, 2)
+
+	canvas.Set(0, 4)
+
+	assert.Equal(t, "⠀⠀\n⠁⠀", canvas.String())
+}
+
+func TestIsLand(t *testing.T) {
+	tests := []struct {
+		name     string
+		lon, lat float64
+		land     bool
+	}{
+		{"Paris", 2.35, 48.85, true},
+		{"Rome", 12.5, 41.9, true},
+		{"London", -0.1, 51.5, true},
+		{"New York", -74.5, 41, true},
+		{"São Paulo", -46.6, -23.5, true},
+		{"Nairobi", 36.8, -1.3, true},
+		{"Tokyo", 139.7, 35.7, true},
+		{"Sydney", 150.5, -33.9, true},
+		{"Atlantic Ocean", -40, 30, false},
+		{"Pacific Ocean", -150, 0, false},
+		{"Mediterranean Sea", 18, 35, false},
+		{"Black Sea", 34, 43, false},
+		{"Indian Ocean", 75, -20, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.land, IsLand(tt.lon, tt.lat))
+		})
+	}
+}
+
+func TestMapDrawsLandOnly(t *testing.T) {
+	m := New(80, FittingRows(80))
+
+	assert.False(t, m.IsSea(m.Cell(2.35, 48.85)))
+	assert.True(t, m.IsSea(m.Cell(-150, 0)))
+}
+
+func TestMapCellAndLocation(t *testing.T) {
+	m := New(36, 10)
+
+	col, row := m.Cell(-180, North)
+	assert.Equal(t, 0, col)
+	assert.Equal(t, 0, row)
+
+	col, row = m.Cell(179.9, South)
+	assert.Equal(t, 35, col)
+	assert.Equal(t, 9, row)
+
+	// Beyond the map's parallels and the antimeridian
+	col, row = m.Cell(185, -80)
+	assert.Equal(t, 0, col)
+	assert.Equal(t, 9, row)
+
+	lon, lat := m.Location(18, 5)
+	assert.InDelta(t, 5, lon, 0.001)
+	assert.InDelta(t, North-5.5*(North-South)/10, lat, 0.001)
+
+	col, row = m.Cell(lon, lat)
+	assert.Equal(t, 18, col)
+	assert.Equal(t, 5, row)
+}
+
+func TestFittingRows(t *testing.T) {
+	assert.Equal(t, 20, FittingRows(100))
+	assert.Equal(t, 1, FittingRows(1))
+}
+
+func TestDistance(t *testing.T) {
+	// Paris to Rome
+	assert.InDelta(t, 1106, Distance(2.35, 48.85, 12.5, 41.9), 5)
+	// Across the antimeridian
+	assert.InDelta(t, 222, Distance(179, 0, -179, 0), 1)
+	assert.Zero(t, Distance(10, 10, 10, 10))
+}